@@ -0,0 +1,532 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strategyexecutor/src/connectors"
+	"strconv"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Exit codes of the single-command mode.
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	exitUnknown = 3
+	exitAborted = 4
+)
+
+var (
+	// errShutdown is returned by exec for the shutdown command.
+	errShutdown = errors.New("shutdown")
+	// errAborted is returned when a destructive command is not confirmed.
+	errAborted = errors.New("aborted: confirmation did not match")
+)
+
+// commandNames lists the commands for tab completion.
+var commandNames = []string{
+	"help", "shutdown", "positions",
+	"long", "short", "close-long", "close-short", "reverse",
+	"limit", "stop", "tp", "cancel", "cancel-all", "cancel-all-positions",
+	"ticker", "orderbook", "orders", "ordershistory", "fills", "klines", "disp", "avl",
+}
+
+// usageError is a command invoked with missing or malformed arguments.
+type usageError struct{ usage string }
+
+func (e *usageError) Error() string { return "Usage: " + e.usage }
+
+// unknownCommandError is a command the CLI does not know.
+type unknownCommandError struct{ cmd string }
+
+func (e *unknownCommandError) Error() string { return "Unknown command: " + e.cmd }
+
+// exitCode maps the error of exec onto the process exit code.
+func exitCode(err error) int {
+	var usage *usageError
+	var unknown *unknownCommandError
+	switch {
+	case err == nil || errors.Is(err, errShutdown):
+		return exitOK
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.As(err, &unknown):
+		return exitUnknown
+	case errors.Is(err, errAborted):
+		return exitAborted
+	default:
+		return exitFailed
+	}
+}
+
+// session runs CLI commands against one client. With jsonOut set every
+// result is written to out as a single JSON document and the progress lines
+// are left out, so the output can be piped into jq.
+type session struct {
+	client  *connectors.Client
+	out     io.Writer
+	jsonOut bool
+	// yes skips the confirmation of destructive commands.
+	yes bool
+	// ask prompts the operator and returns the answer; nil when there is
+	// nobody to ask.
+	ask func(prompt string) (string, error)
+	// symbols seen this session, for tab completion.
+	symbols map[string]bool
+}
+
+// confirm asks the operator to type symbol before a destructive command
+// (reverse, cancel-all, cancel-all-positions).
+func (s *session) confirm(cmd, symbol string) error {
+	if s.yes {
+		return nil
+	}
+	if s.ask == nil {
+		return &usageError{cmd + " is destructive; pass --yes to run it without a prompt"}
+	}
+	answer, err := s.ask(fmt.Sprintf("%s is destructive. Type %s to confirm: ", cmd, symbol))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(answer) != symbol {
+		logger.WithFields(logger.Fields{"cmd": cmd, "symbol": symbol}).Warn("Destructive command not confirmed")
+		return errAborted
+	}
+	return nil
+}
+
+func (s *session) noteSymbol(symbol string) {
+	if s.symbols == nil {
+		s.symbols = make(map[string]bool)
+	}
+	s.symbols[strings.ToUpper(symbol)] = true
+}
+
+// complete returns the words completing the last word of line: a command
+// name first, then a symbol.
+func (s *session) complete(line string) []string {
+	fields := strings.Fields(line)
+	if strings.HasSuffix(line, " ") || len(fields) == 0 {
+		fields = append(fields, "")
+	}
+	word := fields[len(fields)-1]
+
+	var pool []string
+	switch len(fields) {
+	case 1:
+		pool = commandNames
+	case 2:
+		if !takesSymbol(fields[0]) {
+			return nil
+		}
+		for sym := range s.symbols {
+			pool = append(pool, sym)
+		}
+		word = strings.ToUpper(word)
+	default:
+		return nil
+	}
+
+	var out []string
+	for _, c := range pool {
+		if strings.HasPrefix(c, word) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func takesSymbol(cmd string) bool {
+	switch cmd {
+	case "help", "shutdown", "positions":
+		return false
+	}
+	for _, c := range commandNames {
+		if c == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *session) infof(format string, args ...any) {
+	if !s.jsonOut {
+		fmt.Fprintf(s.out, format, args...)
+	}
+}
+
+// emit writes v as JSON, or calls human in text mode.
+func (s *session) emit(v any, human func()) {
+	if s.jsonOut || human == nil {
+		printJSONTo(s.out, v)
+		return
+	}
+	human()
+}
+
+// respond checks the exchange code of resp before emitting its data.
+func (s *session) respond(resp *connectors.APIResponse, human func(json.RawMessage)) error {
+	if err := resp.Err(); err != nil {
+		return err
+	}
+	if human == nil {
+		s.emit(resp.Data, nil)
+		return nil
+	}
+	s.emit(resp.Data, func() { human(resp.Data) })
+	return nil
+}
+
+// exec runs one command line split into parts.
+func (s *session) exec(parts []string) error {
+	cmd := parts[0]
+	c := s.client
+
+	switch cmd {
+
+	case "shutdown":
+		logger.Info("Shutdown command received, exiting CLI")
+		s.infof("Exiting CLI...\n")
+		return errShutdown
+
+	case "help":
+		printUsage()
+
+	case "positions":
+		logger.Info("Listing USDT-M positions")
+		pos, err := c.GetPositionsUSDT()
+		if err != nil {
+			logger.WithError(err).Error("failed to get positions")
+			return err
+		}
+		s.emit(pos, func() { printPositions(pos) })
+
+	case "long", "short", "close-long", "close-short":
+		if len(parts) < 3 {
+			return &usageError{cmd + " SYMBOL QTY"}
+		}
+		symbol, qty := parts[1], parts[2]
+
+		side, posSide, reduce := marketSides(cmd)
+
+		logger.WithFields(logger.Fields{
+			"cmd":    cmd,
+			"symbol": symbol,
+			"qty":    qty,
+		}).Info("Executing market order")
+
+		s.infof("Executing %s %s qty=%s\n", strings.ToUpper(cmd), symbol, qty)
+
+		resp, err := c.PlaceOrder(symbol, side, posSide, qty, "Market", reduce)
+		if err != nil {
+			logger.WithError(err).Errorf("failed to execute %s order", cmd)
+			return err
+		}
+		return s.respond(resp, nil)
+
+	case "reverse":
+		if len(parts) < 3 {
+			return &usageError{"reverse SYMBOL QTY"}
+		}
+		symbol, qty := parts[1], parts[2]
+
+		logger.WithFields(logger.Fields{
+			"cmd":    "reverse",
+			"symbol": symbol,
+			"qty":    qty,
+		}).Info("Reversing position")
+
+		if err := s.confirm(cmd, symbol); err != nil {
+			return err
+		}
+
+		s.infof("Reversing %s qty=%s\n", symbol, qty)
+
+		// Close LONG side
+		closeResp, err := c.PlaceOrder(symbol, "Sell", "Long", qty, "Market", true)
+		if err == nil {
+			err = closeResp.Err()
+		}
+		if err != nil {
+			logger.WithError(err).Error("failed to close LONG part of reverse")
+			return fmt.Errorf("closing LONG: %w", err)
+		}
+
+		// Open SHORT side
+		resp, err := c.PlaceOrder(symbol, "Sell", "Short", qty, "Market", false)
+		if err != nil {
+			logger.WithError(err).Error("failed to open SHORT part of reverse")
+			return fmt.Errorf("opening SHORT: %w", err)
+		}
+		return s.respond(resp, nil)
+
+	case "limit":
+		if len(parts) < 4 {
+			return &usageError{"limit SYMBOL QTY PRICE"}
+		}
+		symbol, price := parts[1], parts[3]
+
+		side, posSide, qty, err := limitSides(parts[2])
+		if err != nil {
+			return &usageError{"limit SYMBOL QTY PRICE (" + err.Error() + ")"}
+		}
+
+		logger.WithFields(logger.Fields{
+			"cmd":     "limit",
+			"symbol":  symbol,
+			"side":    side,
+			"posSide": posSide,
+			"qty":     qty,
+			"price":   price,
+		}).Info("Placing limit order")
+
+		s.infof("Placing LIMIT %s %s qty=%s price=%s\n", side, symbol, qty, price)
+
+		resp, err := c.PlaceLimitOrder(symbol, side, posSide, qty, price, false)
+		if err != nil {
+			logger.WithError(err).Error("failed to place limit order")
+			return err
+		}
+		return s.respond(resp, nil)
+
+	case "stop", "tp":
+		if len(parts) < 4 {
+			return &usageError{cmd + " SYMBOL QTY TRIGGER"}
+		}
+		symbol, qty, trigger := parts[1], parts[2], parts[3]
+
+		posSide, closeSide, err := openPosition(c, symbol)
+		if err != nil {
+			logger.WithError(err).Error("failed to resolve position to protect")
+			return err
+		}
+
+		logger.WithFields(logger.Fields{
+			"cmd":     cmd,
+			"symbol":  symbol,
+			"posSide": posSide,
+			"side":    closeSide,
+			"qty":     qty,
+			"trigger": trigger,
+		}).Info("Placing protective order")
+
+		s.infof("Placing %s on %s %s qty=%s trigger=%s\n", strings.ToUpper(cmd), posSide, symbol, qty, trigger)
+
+		var resp *connectors.APIResponse
+		if cmd == "stop" {
+			resp, err = c.PlaceStopLossOrder(symbol, posSide, closeSide, qty, trigger, connectors.TriggerByMarkPrice, false)
+		} else {
+			resp, err = c.PlaceTakeProfitOrder(symbol, posSide, closeSide, qty, trigger, connectors.TriggerByMarkPrice)
+		}
+		if err != nil {
+			logger.WithError(err).Error("failed to place protective order")
+			return err
+		}
+		return s.respond(resp, nil)
+
+	case "cancel":
+		if len(parts) < 3 {
+			return &usageError{"cancel SYMBOL ORDERID"}
+		}
+		symbol, orderID := parts[1], parts[2]
+
+		logger.WithFields(logger.Fields{
+			"cmd":     "cancel",
+			"symbol":  symbol,
+			"orderID": orderID,
+		}).Info("Canceling order")
+
+		resp, err := c.CancelOrder(symbol, orderID)
+		if err != nil {
+			logger.WithError(err).Error("failed to cancel order")
+			return err
+		}
+		return s.respond(resp, nil)
+
+	case "cancel-all":
+		if len(parts) < 2 {
+			return &usageError{"cancel-all SYMBOL"}
+		}
+		symbol := parts[1]
+
+		if err := s.confirm(cmd, symbol); err != nil {
+			return err
+		}
+
+		logger.WithFields(logger.Fields{
+			"cmd":    "cancel-all",
+			"symbol": symbol,
+		}).Info("Canceling all orders for symbol")
+
+		resp, err := c.CancelAll(symbol)
+		if err != nil {
+			logger.WithError(err).Error("failed to cancel all orders")
+			return err
+		}
+		return s.respond(resp, nil)
+
+	case "cancel-all-positions":
+		if len(parts) < 2 {
+			return &usageError{"cancel-all-positions SYMBOL"}
+		}
+		symbol := parts[1]
+
+		if err := s.confirm(cmd, symbol); err != nil {
+			return err
+		}
+
+		logger.WithFields(logger.Fields{
+			"cmd":    "cancel-all-positions",
+			"symbol": symbol,
+		}).Info("Closing all positions for symbol")
+
+		if err := c.CloseAllPositions(symbol); err != nil {
+			logger.WithError(err).Error("failed to close all positions")
+			return err
+		}
+
+		pos, err := c.GetPositionsUSDT()
+		if err != nil {
+			logger.WithError(err).Error("failed to fetch positions after closing")
+			return err
+		}
+		s.emit(pos, func() { printPositions(pos) })
+
+	case "ticker", "orderbook", "orders", "ordershistory", "fills":
+		if len(parts) < 2 {
+			return &usageError{cmd + " SYMBOL"}
+		}
+		symbol := parts[1]
+
+		logger.WithFields(logger.Fields{
+			"cmd":    cmd,
+			"symbol": symbol,
+		}).Info("Fetching market data")
+
+		var (
+			resp  *connectors.APIResponse
+			err   error
+			human func(json.RawMessage)
+		)
+		switch cmd {
+		case "ticker":
+			resp, err = c.GetTicker(symbol)
+		case "orderbook":
+			resp, err = c.GetOrderbook(symbol)
+			human = printOrderbook
+		case "orders":
+			resp, err = c.GetActiveOrders(symbol)
+			human = printOrders
+		case "ordershistory":
+			resp, err = c.GetOrderHistory(symbol)
+			human = printOrders
+		case "fills":
+			resp, err = c.GetFills(symbol)
+		}
+		if err != nil {
+			logger.WithError(err).Errorf("failed to fetch %s", cmd)
+			return err
+		}
+		return s.respond(resp, human)
+
+	case "klines":
+		if len(parts) < 3 {
+			return &usageError{"klines SYMBOL RESOLUTION"}
+		}
+		symbol := parts[1]
+		res, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return &usageError{"klines SYMBOL RESOLUTION (RESOLUTION must be a number of seconds)"}
+		}
+
+		logger.WithFields(logger.Fields{
+			"cmd":        "klines",
+			"symbol":     symbol,
+			"resolution": res,
+		}).Info("Fetching klines")
+
+		resp, err := c.GetKlines(symbol, res)
+		if err != nil {
+			logger.WithError(err).Error("failed to fetch klines")
+			return err
+		}
+		return s.respond(resp, nil)
+
+	case "disp":
+		if len(parts) < 2 {
+			return &usageError{"disp SYMBOL"}
+		}
+		symbol := parts[1]
+
+		logger.WithFields(logger.Fields{
+			"cmd":    "disp",
+			"symbol": symbol,
+		}).Info("Fetching available USDT margin from risk-unit")
+
+		qtd, err := c.GetFuturesAvailableFromRiskUnit(symbol)
+		if err != nil {
+			logger.WithError(err).Error("failed to fetch available USDT margin")
+			return err
+		}
+
+		s.emit(map[string]any{"symbol": symbol, "usdt_available": qtd}, func() {
+			fmt.Fprintf(s.out, "USDT available %.12f\n", qtd)
+		})
+
+	case "avl":
+		if len(parts) < 2 {
+			return &usageError{"avl SYMBOL"}
+		}
+		symbol := parts[1]
+
+		logger.WithFields(logger.Fields{
+			"cmd":    "avl",
+			"symbol": symbol,
+		}).Info("Fetching base availability from USDT margin")
+
+		baseSymbol, baseAvail, usdtAvail, price, err := c.GetAvailableBaseFromUSDT(symbol)
+		if err != nil {
+			logger.WithError(err).Error("failed to compute base availability from USDT margin")
+			return err
+		}
+
+		out := map[string]any{
+			"base":           baseSymbol,
+			"base_available": baseAvail,
+			"usdt_available": usdtAvail,
+			"price":          price,
+		}
+		s.emit(out, func() {
+			fmt.Fprintf(s.out, "Available %s\n", baseSymbol)
+			fmt.Fprintf(s.out, "USDT -> base coin %.12f\n", baseAvail)
+			fmt.Fprintf(s.out, "USDT available %.12f\n", usdtAvail)
+			fmt.Fprintf(s.out, "USDT price %.12f\n", price)
+		})
+
+	default:
+		logger.WithField("cmd", cmd).Warn("Unknown command received")
+		return &unknownCommandError{cmd}
+	}
+
+	return nil
+}
+
+// marketSides returns the order side, posSide and reduceOnly of the market
+// order commands.
+func marketSides(cmd string) (side, posSide string, reduce bool) {
+	switch cmd {
+	case "long":
+		return "Buy", "Long", false
+	case "short":
+		return "Sell", "Short", false
+	case "close-long":
+		return "Sell", "Long", true
+	default: // close-short
+		return "Buy", "Short", true
+	}
+}
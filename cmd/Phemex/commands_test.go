@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/testsupport"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSession(t *testing.T, m *testsupport.MockExchange, jsonOut bool) (*session, *bytes.Buffer) {
+	t.Helper()
+	var out bytes.Buffer
+	return &session{
+		client:  connectors.NewClient("key", "secret", m.URL),
+		out:     &out,
+		jsonOut: jsonOut,
+	}, &out
+}
+
+func TestParseArgs(t *testing.T) {
+	parts, jsonOut, yes := parseArgs([]string{"positions", "--json"})
+	require.Equal(t, []string{"positions"}, parts)
+	require.True(t, jsonOut)
+	require.False(t, yes)
+
+	parts, jsonOut, yes = parseArgs([]string{"--yes", "limit", "BTCUSDT", "-0.01", "70000"})
+	require.Equal(t, []string{"limit", "BTCUSDT", "-0.01", "70000"}, parts)
+	require.False(t, jsonOut)
+	require.True(t, yes)
+}
+
+func TestExecJSONOutput(t *testing.T) {
+	m := testsupport.NewMockExchange(t).
+		WithPositions(testsupport.Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0.5"})
+	s, out := newTestSession(t, m, true)
+
+	require.NoError(t, s.exec([]string{"positions"}))
+
+	var pos connectors.GAccountPositions
+	require.NoError(t, json.Unmarshal(out.Bytes(), &pos), out.String())
+	require.Len(t, pos.Positions, 1)
+	require.Equal(t, "0.5", pos.Positions[0].SizeRq)
+
+	out.Reset()
+	require.NoError(t, s.exec([]string{"stop", "BTCUSDT", "0.5", "60000"}))
+	require.True(t, json.Valid(out.Bytes()), "progress lines must not leak into JSON: %s", out.String())
+	orders := m.Orders()
+	require.Len(t, orders, 1)
+	require.Equal(t, "Sell", orders[0].Side)
+}
+
+func TestExecExitCodes(t *testing.T) {
+	m := testsupport.NewMockExchange(t).
+		Fail(http.MethodDelete, "/g-orders/all", testsupport.Failure{Code: 10500, Msg: "TE_SYSTEM_BUSY"})
+	s, _ := newTestSession(t, m, false)
+
+	require.Equal(t, exitOK, exitCode(s.exec([]string{"help"})))
+	require.Equal(t, exitOK, exitCode(s.exec([]string{"shutdown"})))
+	require.Equal(t, exitUsage, exitCode(s.exec([]string{"limit", "BTCUSDT"})))
+	require.Equal(t, exitUsage, exitCode(s.exec([]string{"limit", "BTCUSDT", "zero", "1"})))
+	require.Equal(t, exitUnknown, exitCode(s.exec([]string{"moon"})))
+	require.Equal(t, exitFailed, exitCode(s.exec([]string{"stop", "BTCUSDT", "1", "60000"})), "no open position")
+	require.Equal(t, exitUsage, exitCode(s.exec([]string{"cancel-all", "BTCUSDT"})), "destructive without --yes")
+	s.yes = true
+	require.Equal(t, exitFailed, exitCode(s.exec([]string{"cancel-all", "BTCUSDT"})), "business error code")
+}
+
+func TestReportErrorJSON(t *testing.T) {
+	m := testsupport.NewMockExchange(t)
+	s, out := newTestSession(t, m, true)
+
+	reportError(s, nil, s.exec([]string{"moon"}))
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	require.Equal(t, "Unknown command: moon", got["error"])
+	require.EqualValues(t, exitUnknown, got["exit_code"])
+}
+
+func TestConfirmDestructive(t *testing.T) {
+	m := testsupport.NewMockExchange(t)
+	s, _ := newTestSession(t, m, false)
+
+	var asked []string
+	answer := "ETHUSDT"
+	s.ask = func(prompt string) (string, error) {
+		asked = append(asked, prompt)
+		return answer, nil
+	}
+
+	err := s.exec([]string{"cancel-all", "BTCUSDT"})
+	require.ErrorIs(t, err, errAborted)
+	require.Equal(t, exitAborted, exitCode(err))
+	require.Zero(t, m.Count(http.MethodDelete, "/g-orders/all"))
+	require.Equal(t, []string{"cancel-all is destructive. Type BTCUSDT to confirm: "}, asked)
+
+	answer = " BTCUSDT "
+	require.NoError(t, s.exec([]string{"cancel-all", "BTCUSDT"}))
+	require.Equal(t, 1, m.Count(http.MethodDelete, "/g-orders/all"))
+
+	answer = "no"
+	require.ErrorIs(t, s.exec([]string{"reverse", "BTCUSDT", "1"}), errAborted)
+	require.ErrorIs(t, s.exec([]string{"cancel-all-positions", "BTCUSDT"}), errAborted)
+	require.Zero(t, m.Count(http.MethodPost, "/g-orders"))
+}
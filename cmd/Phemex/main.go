@@ -1,11 +1,13 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/logging"
 	"strconv"
 	"strings"
 
@@ -13,23 +15,21 @@ import (
 )
 
 func SetupLogger() {
-	levelStr := strings.ToLower(os.Getenv("LOG_LEVEL"))
+	logging.Setup()
 
-	level, err := logger.ParseLevel(levelStr)
-	if err != nil {
-		level = logger.DebugLevel // fallback seguro
-	}
-
-	logger.SetLevel(level)
-	logger.SetFormatter(&logger.TextFormatter{
-		FullTimestamp: true,
-	})
-
-	logger.WithField("level", level.String()).
+	logger.WithField("level", logger.GetLevel().String()).
 		Info("Logger initialized for Phemex CLI")
 }
 
 func printUsage() {
+	fmt.Println("Usage: phemex [--json] [COMMAND ARGS...]")
+	fmt.Println("  Without a command an interactive prompt is started. With one, it runs")
+	fmt.Println("  once and exits 0 on success, 1 on failure, 2 on bad arguments and 3 on")
+	fmt.Println("  an unknown command. --json prints machine-readable output.")
+	fmt.Println("  reverse, cancel-all and cancel-all-positions ask to type the symbol to")
+	fmt.Println("  confirm; in single-command mode they need --yes instead (exit 4 when a")
+	fmt.Println("  confirmation does not match).")
+	fmt.Println()
 	fmt.Println("Available commands:")
 	fmt.Println("  help                             Show this help message")
 	fmt.Println("  shutdown                         Exit the application")
@@ -39,6 +39,10 @@ func printUsage() {
 	fmt.Println("  close-long SYMBOL QTY            Close LONG")
 	fmt.Println("  close-short SYMBOL QTY           Close SHORT")
 	fmt.Println("  reverse SYMBOL QTY               Reverse position")
+	fmt.Println("  limit SYMBOL QTY PRICE           Place limit order (QTY>0 buys Long, QTY<0 sells Short)")
+	fmt.Println("  stop SYMBOL QTY TRIGGER          Place reduce-only stop loss on the open position")
+	fmt.Println("  tp SYMBOL QTY TRIGGER            Place reduce-only take profit on the open position")
+	fmt.Println("  cancel SYMBOL ORDERID            Cancel one active order")
 	fmt.Println("  cancel-all SYMBOL                Cancel all orders")
 	fmt.Println("  cancel-all-positions SYMBOL      Cancel all positions for a symbol (including open orders)")
 	fmt.Println("  ticker SYMBOL                    Show ticker info")
@@ -53,13 +57,17 @@ func printUsage() {
 }
 
 func printJSON(data any) {
+	printJSONTo(os.Stdout, data)
+}
+
+func printJSONTo(w io.Writer, data any) {
 	b, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		logger.WithError(err).Error("failed to marshal JSON for printing")
-		fmt.Println("JSON error:", err)
+		fmt.Fprintln(w, "JSON error:", err)
 		return
 	}
-	fmt.Println(string(b))
+	fmt.Fprintln(w, string(b))
 }
 
 func printPositions(pos *connectors.GAccountPositions) {
@@ -194,12 +202,92 @@ func printLevels(label string, levels [][]json.RawMessage) {
 	}
 }
 
+// limitSides maps a signed QTY onto the order side: positive buys Long,
+// negative sells Short.
+func limitSides(qty string) (side, posSide, size string, err error) {
+	v, err := strconv.ParseFloat(qty, 64)
+	if err != nil || v == 0 {
+		return "", "", "", fmt.Errorf("invalid QTY %q", qty)
+	}
+	if v > 0 {
+		return "Buy", "Long", qty, nil
+	}
+	return "Sell", "Short", strings.TrimPrefix(qty, "-"), nil
+}
+
+// openPosition returns the posSide of the single open position of symbol and
+// the order side that reduces it. Stops and take profits need one position to
+// protect, so hedged positions on both sides are refused.
+func openPosition(client *connectors.Client, symbol string) (posSide, closeSide string, err error) {
+	pos, err := client.GetPositionsUSDT()
+	if err != nil {
+		return "", "", err
+	}
+
+	found := 0
+	for _, p := range pos.Positions {
+		if p.Symbol != symbol || p.SizeRq == "" || p.SizeRq == "0" {
+			continue
+		}
+		found++
+		posSide = p.PosSide
+		switch p.Side {
+		case "Buy":
+			closeSide = "Sell"
+		case "Sell":
+			closeSide = "Buy"
+		default:
+			return "", "", fmt.Errorf("unknown position side %q", p.Side)
+		}
+	}
+
+	switch found {
+	case 0:
+		return "", "", fmt.Errorf("no open position for %s", symbol)
+	case 1:
+		return posSide, closeSide, nil
+	default:
+		return "", "", fmt.Errorf("both sides of %s are open; use the connector directly", symbol)
+	}
+}
+
 func printMapField(m map[string]interface{}, key, label string) {
 	if v, ok := m[key]; ok {
 		fmt.Printf("%-11s: %v\n", label, v)
 	}
 }
 
+// parseArgs splits the command line into the command to run and the
+// output flags. Flags may appear anywhere, e.g. `phemex positions --json`.
+func parseArgs(args []string) (parts []string, jsonOut, yes bool) {
+	for _, a := range args {
+		switch a {
+		case "--json", "-json":
+			jsonOut = true
+		case "--yes", "-yes", "-y":
+			yes = true
+		default:
+			parts = append(parts, a)
+		}
+	}
+	return parts, jsonOut, yes
+}
+
+// reportError prints the error of a command: as {"error": ...} on stdout in
+// JSON mode so scripts always get a document, on stderr otherwise.
+func reportError(s *session, w io.Writer, err error) {
+	if s.jsonOut {
+		printJSONTo(s.out, map[string]any{"error": err.Error(), "exit_code": exitCode(err)})
+		return
+	}
+	fmt.Fprintln(w, "Error:", err)
+	var usage *usageError
+	var unknown *unknownCommandError
+	if errors.As(err, &usage) || errors.As(err, &unknown) {
+		printUsage()
+	}
+}
+
 func main() {
 	SetupLogger()
 
@@ -216,408 +304,76 @@ func main() {
 		logger.Fatal("Missing API keys (PHEMEX_API_KEY / PHEMEX_API_SECRET)")
 	}
 
-	client := connectors.NewClient(apiKey, apiSecret, baseURL)
+	parts, jsonOut, yes := parseArgs(os.Args[1:])
+	s := &session{
+		client:  connectors.NewClient(apiKey, apiSecret, baseURL),
+		out:     os.Stdout,
+		jsonOut: jsonOut,
+		yes:     yes,
+	}
+
+	// Single-command mode: run the arguments once and exit with its code.
+	if len(parts) > 0 {
+		err := s.exec(parts)
+		if err != nil && !errors.Is(err, errShutdown) {
+			reportError(s, os.Stderr, err)
+		}
+		os.Exit(exitCode(err))
+	}
+
+	hist := loadHistory(historyPath())
+	for _, line := range hist.lines {
+		if f := strings.Fields(line); len(f) > 1 && takesSymbol(f[0]) {
+			s.noteSymbol(f[1])
+		}
+	}
+	if pos, err := s.client.GetPositionsUSDT(); err == nil {
+		for _, p := range pos.Positions {
+			s.noteSymbol(p.Symbol)
+		}
+	}
+
+	reader := newLineReader(hist, s.complete)
+	s.ask = reader.readLine
 
-	reader := bufio.NewScanner(os.Stdin)
-	fmt.Println("Phemex CLI Ready. Type 'help' for a list of commands. Type 'shutdown' to exit.")
+	prompt := "phemex> "
+	if jsonOut {
+		prompt = ""
+	}
+	s.infof("Phemex CLI Ready. Type 'help' for a list of commands. Type 'shutdown' to exit.\n")
 	logger.Info("Phemex CLI started")
 
 	for {
-		fmt.Print("phemex> ")
-
-		if !reader.Scan() {
-			if err := reader.Err(); err != nil {
-				logger.WithError(err).Error("stdin scanner error")
-			}
+		line, err := reader.readLine(prompt)
+		if errors.Is(err, errInterrupted) {
 			continue
 		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.WithError(err).Error("stdin read error")
+			}
+			return
+		}
 
-		line := strings.TrimSpace(reader.Text())
+		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
-
-		parts := strings.Fields(line)
-		cmd := parts[0]
+		hist.add(line)
 
 		logger.WithField("command_line", line).Debug("Received CLI command")
 
-		switch cmd {
-
-		case "shutdown":
-			logger.Info("Shutdown command received, exiting CLI")
-			fmt.Println("Exiting CLI...")
+		parts := strings.Fields(line)
+		err = s.exec(parts)
+		if errors.Is(err, errShutdown) {
 			return
-
-		case "help":
-			printUsage()
-
-		case "positions":
-			logger.Info("Listing USDT-M positions")
-			pos, err := client.GetPositionsUSDT()
-			if err != nil {
-				logger.WithError(err).Error("failed to get positions")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printPositions(pos)
-
-		case "long":
-			if len(parts) < 3 {
-				fmt.Println("Usage: long SYMBOL QTY")
-				printUsage()
-				continue
-			}
-			symbol, qty := parts[1], parts[2]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "long",
-				"symbol": symbol,
-				"qty":    qty,
-			}).Info("Executing LONG order")
-
-			fmt.Printf("Executing LONG %s qty=%s\n", symbol, qty)
-
-			resp, err := client.PlaceOrder(symbol, "Buy", "Long", qty, "Market", false)
-			if err != nil {
-				logger.WithError(err).Error("failed to place LONG order")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printJSON(resp.Data)
-
-		case "short":
-			if len(parts) < 3 {
-				fmt.Println("Usage: short SYMBOL QTY")
-				printUsage()
-				continue
-			}
-			symbol, qty := parts[1], parts[2]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "short",
-				"symbol": symbol,
-				"qty":    qty,
-			}).Info("Executing SHORT order")
-
-			fmt.Printf("Executing SHORT %s qty=%s\n", symbol, qty)
-
-			resp, err := client.PlaceOrder(symbol, "Sell", "Short", qty, "Market", false)
-			if err != nil {
-				logger.WithError(err).Error("failed to place SHORT order")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printJSON(resp.Data)
-
-		case "close-long":
-			if len(parts) < 3 {
-				fmt.Println("Usage: close-long SYMBOL QTY")
-				printUsage()
-				continue
-			}
-			symbol, qty := parts[1], parts[2]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "close-long",
-				"symbol": symbol,
-				"qty":    qty,
-			}).Info("Closing LONG position")
-
-			fmt.Printf("Closing LONG %s qty=%s\n", symbol, qty)
-
-			resp, err := client.PlaceOrder(symbol, "Sell", "Long", qty, "Market", true)
-			if err != nil {
-				logger.WithError(err).Error("failed to close LONG position")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printJSON(resp.Data)
-
-		case "close-short":
-			if len(parts) < 3 {
-				fmt.Println("Usage: close-short SYMBOL QTY")
-				printUsage()
-				continue
-			}
-			symbol, qty := parts[1], parts[2]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "close-short",
-				"symbol": symbol,
-				"qty":    qty,
-			}).Info("Closing SHORT position")
-
-			fmt.Printf("Closing SHORT %s qty=%s\n", symbol, qty)
-
-			resp, err := client.PlaceOrder(symbol, "Buy", "Short", qty, "Market", true)
-			if err != nil {
-				logger.WithError(err).Error("failed to close SHORT position")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printJSON(resp.Data)
-
-		case "reverse":
-			if len(parts) < 3 {
-				fmt.Println("Usage: reverse SYMBOL QTY")
-				printUsage()
-				continue
-			}
-			symbol, qty := parts[1], parts[2]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "reverse",
-				"symbol": symbol,
-				"qty":    qty,
-			}).Info("Reversing position")
-
-			fmt.Printf("Reversing %s qty=%s\n", symbol, qty)
-
-			// Close LONG side
-			if _, err := client.PlaceOrder(symbol, "Sell", "Long", qty, "Market", true); err != nil {
-				logger.WithError(err).Error("failed to close LONG part of reverse")
-				fmt.Println("Error closing LONG:", err)
-				continue
-			}
-
-			// Open SHORT side
-			resp, err := client.PlaceOrder(symbol, "Sell", "Short", qty, "Market", false)
-			if err != nil {
-				logger.WithError(err).Error("failed to open SHORT part of reverse")
-				fmt.Println("Error opening SHORT:", err)
-				continue
-			}
-			printJSON(resp.Data)
-
-		case "cancel-all":
-			if len(parts) < 2 {
-				fmt.Println("Usage: cancel-all SYMBOL")
-				printUsage()
-				continue
-			}
-			symbol := parts[1]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "cancel-all",
-				"symbol": symbol,
-			}).Info("Canceling all orders for symbol")
-
-			resp, err := client.CancelAll(symbol)
-			if err != nil {
-				logger.WithError(err).Error("failed to cancel all orders")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printJSON(resp.Data)
-
-		case "cancel-all-positions":
-			if len(parts) < 2 {
-				fmt.Println("Usage: cancel-all-positions SYMBOL")
-				printUsage()
-				continue
-			}
-			symbol := parts[1]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "cancel-all-positions",
-				"symbol": symbol,
-			}).Info("Closing all positions for symbol")
-
-			err := client.CloseAllPositions(symbol)
-			if err != nil {
-				logger.WithError(err).Error("failed to close all positions")
-				fmt.Println("Error:", err)
-				continue
-			}
-
-			pos, err := client.GetPositionsUSDT()
-			if err != nil {
-				logger.WithError(err).Error("failed to fetch positions after closing")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printPositions(pos)
-
-		case "ticker":
-			if len(parts) < 2 {
-				fmt.Println("Usage: ticker SYMBOL")
-				printUsage()
-				continue
-			}
-			symbol := parts[1]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "ticker",
-				"symbol": symbol,
-			}).Info("Fetching ticker")
-
-			resp, err := client.GetTicker(symbol)
-			if err != nil {
-				logger.WithError(err).Error("failed to fetch ticker")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printJSON(resp.Data)
-
-		case "orderbook":
-			if len(parts) < 2 {
-				fmt.Println("Usage: orderbook SYMBOL")
-				printUsage()
-				continue
-			}
-			symbol := parts[1]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "orderbook",
-				"symbol": symbol,
-			}).Info("Fetching orderbook")
-
-			resp, err := client.GetOrderbook(symbol)
-			if err != nil {
-				logger.WithError(err).Error("failed to fetch orderbook")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printOrderbook(resp.Data)
-
-		case "orders":
-			if len(parts) < 2 {
-				fmt.Println("Usage: orders SYMBOL")
-				printUsage()
-				continue
-			}
-			symbol := parts[1]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "orders",
-				"symbol": symbol,
-			}).Info("Fetching active orders")
-
-			resp, err := client.GetActiveOrders(symbol)
-			if err != nil {
-				logger.WithError(err).Error("failed to fetch active orders")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printOrders(resp.Data)
-
-		case "ordershistory":
-			if len(parts) < 2 {
-				fmt.Println("Usage: ordershistory SYMBOL")
-				printUsage()
-				continue
-			}
-			symbol := parts[1]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "ordershistory",
-				"symbol": symbol,
-			}).Info("Fetching order history")
-
-			resp, err := client.GetOrderHistory(symbol)
-			if err != nil {
-				logger.WithError(err).Error("failed to fetch order history")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printOrders(resp.Data)
-
-		case "fills":
-			if len(parts) < 2 {
-				fmt.Println("Usage: fills SYMBOL")
-				printUsage()
-				continue
-			}
-			symbol := parts[1]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "fills",
-				"symbol": symbol,
-			}).Info("Fetching fills")
-
-			resp, err := client.GetFills(symbol)
-			if err != nil {
-				logger.WithError(err).Error("failed to fetch fills")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printJSON(resp.Data)
-
-		case "klines":
-			if len(parts) < 3 {
-				fmt.Println("Usage: klines SYMBOL RESOLUTION")
-				printUsage()
-				continue
-			}
-			symbol := parts[1]
-			res, _ := strconv.Atoi(parts[2])
-
-			logger.WithFields(logger.Fields{
-				"cmd":        "klines",
-				"symbol":     symbol,
-				"resolution": res,
-			}).Info("Fetching klines")
-
-			resp, err := client.GetKlines(symbol, res)
-			if err != nil {
-				logger.WithError(err).Error("failed to fetch klines")
-				fmt.Println("Error:", err)
-				continue
-			}
-			printJSON(resp.Data)
-
-		case "disp":
-			if len(parts) < 2 {
-				fmt.Println("Usage: disp SYMBOL")
-				printUsage()
-				continue
-			}
-			symbol := parts[1]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "disp",
-				"symbol": symbol,
-			}).Info("Fetching available USDT margin from risk-unit")
-
-			qtd, err := client.GetFuturesAvailableFromRiskUnit(symbol)
-			if err != nil {
-				logger.WithError(err).Error("failed to fetch available USDT margin")
-				fmt.Println("Error:", err)
-				continue
-			}
-
-			fmt.Printf("USDT available %.12f\n", qtd)
-
-		case "avl":
-			if len(parts) < 2 {
-				fmt.Println("Usage: avl SYMBOL")
-				printUsage()
-				continue
-			}
-			symbol := parts[1]
-
-			logger.WithFields(logger.Fields{
-				"cmd":    "avl",
-				"symbol": symbol,
-			}).Info("Fetching base availability from USDT margin")
-
-			baseSymbol, baseAvail, usdtAvail, price, err := client.GetAvailableBaseFromUSDT(symbol)
-			if err != nil {
-				logger.WithError(err).Error("failed to compute base availability from USDT margin")
-				fmt.Println("Error:", err)
-				continue
-			}
-
-			fmt.Printf("Available %s\n", baseSymbol)
-			fmt.Printf("USDT -> base coin %.12f\n", baseAvail)
-			fmt.Printf("USDT available %.12f\n", usdtAvail)
-			fmt.Printf("USDT price %.12f\n", price)
-
-		default:
-			logger.WithField("cmd", cmd).Warn("Unknown command received")
-			fmt.Println("Unknown command:", cmd)
-			printUsage()
+		}
+		if err != nil {
+			reportError(s, os.Stdout, err)
+			continue
+		}
+		if len(parts) > 1 && takesSymbol(parts[0]) {
+			s.noteSymbol(parts[1])
 		}
 	}
 }
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// maxHistory bounds the history kept in memory and on disk.
+const maxHistory = 500
+
+// errInterrupted is returned by readLine when the line is dropped with Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+// history is the list of entered lines, oldest first, optionally persisted
+// to a file so it survives restarts.
+type history struct {
+	path  string
+	lines []string
+}
+
+// historyPath is PHEMEX_HISTORY_FILE, or ~/.phemex_history. An empty
+// result disables persistence.
+func historyPath() string {
+	if p := os.Getenv("PHEMEX_HISTORY_FILE"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".phemex_history")
+}
+
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.WithError(err).Warn("failed to read CLI history")
+		}
+		return h
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			h.lines = append(h.lines, line)
+		}
+	}
+	h.trim()
+	return h
+}
+
+// add records line, skipping repeats of the previous one, and appends it to
+// the history file.
+func (h *history) add(line string) {
+	if line == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == line) {
+		return
+	}
+	h.lines = append(h.lines, line)
+	h.trim()
+	if h.path == "" {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		logger.WithError(err).Warn("failed to write CLI history")
+		return
+	}
+	defer f.Close()
+	_, _ = fmt.Fprintln(f, line)
+}
+
+func (h *history) trim() {
+	if n := len(h.lines); n > maxHistory {
+		h.lines = h.lines[n-maxHistory:]
+	}
+}
+
+// editor is a minimal line editor over a terminal in raw mode: cursor
+// movement, history with up/down, Ctrl-A/E/U and tab completion.
+type editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	history  *history
+	complete func(line string) []string
+}
+
+func (e *editor) readLine(prompt string) (string, error) {
+	var (
+		buf   []rune
+		pos   int
+		hist  = len(e.history.lines) // index into history; len means the new line
+		draft []rune
+	)
+
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(buf))
+		if back := len(buf) - pos; back > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", back)
+		}
+	}
+	recall := func(i int) {
+		if hist == len(e.history.lines) {
+			draft = append([]rune(nil), buf...)
+		}
+		hist = i
+		if hist == len(e.history.lines) {
+			buf = append([]rune(nil), draft...)
+		} else {
+			buf = []rune(e.history.lines[hist])
+		}
+		pos = len(buf)
+		redraw()
+	}
+
+	fmt.Fprint(e.out, prompt)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(buf), nil
+
+		case 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+
+		case 4: // Ctrl-D
+			if len(buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+
+		case 1: // Ctrl-A
+			pos = 0
+			redraw()
+
+		case 5: // Ctrl-E
+			pos = len(buf)
+			redraw()
+
+		case 21: // Ctrl-U
+			buf, pos = buf[pos:], 0
+			redraw()
+
+		case 127, 8: // Backspace
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+				redraw()
+			}
+
+		case '\t':
+			if e.complete == nil || pos != len(buf) {
+				continue
+			}
+			line, options := completeLine(string(buf), e.complete(string(buf)))
+			if len(options) > 1 && line == string(buf) {
+				fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(options, "  "))
+			}
+			buf = []rune(line)
+			pos = len(buf)
+			redraw()
+
+		case 27: // Escape sequence
+			seq, err := e.readEscape()
+			if err != nil {
+				return "", err
+			}
+			switch seq {
+			case "[A": // Up
+				if hist > 0 {
+					recall(hist - 1)
+				}
+			case "[B": // Down
+				if hist < len(e.history.lines) {
+					recall(hist + 1)
+				}
+			case "[C": // Right
+				if pos < len(buf) {
+					pos++
+					redraw()
+				}
+			case "[D": // Left
+				if pos > 0 {
+					pos--
+					redraw()
+				}
+			case "[H", "[1~":
+				pos = 0
+				redraw()
+			case "[F", "[4~":
+				pos = len(buf)
+				redraw()
+			case "[3~": // Delete
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+					redraw()
+				}
+			}
+
+		default:
+			if r < 32 {
+				continue
+			}
+			buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+			pos++
+			redraw()
+		}
+	}
+}
+
+// readEscape reads the rest of an ANSI escape sequence after ESC, e.g. "[A"
+// for the up arrow or "[3~" for delete.
+func (e *editor) readEscape() (string, error) {
+	first, err := e.in.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	seq := []byte{first}
+	if first != '[' && first != 'O' {
+		return string(seq), nil
+	}
+	for {
+		b, err := e.in.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		seq = append(seq, b)
+		// Parameters are digits and ';', the final byte ends the sequence.
+		if b >= 0x40 && b <= 0x7e {
+			return string(seq), nil
+		}
+	}
+}
+
+// completeLine completes the last word of line from candidates, which are
+// the full words matching it. A single match is completed with a trailing
+// space; several are completed up to their common prefix.
+func completeLine(line string, candidates []string) (string, []string) {
+	if len(candidates) == 0 {
+		return line, nil
+	}
+	sort.Strings(candidates)
+
+	start := strings.LastIndex(line, " ") + 1
+	if len(candidates) == 1 {
+		return line[:start] + candidates[0] + " ", candidates
+	}
+
+	prefix := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(line)-start {
+		return line[:start] + prefix, candidates
+	}
+	return line, candidates
+}
+
+// lineReader reads the commands of the interactive prompt.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+// newLineReader returns the line editor when stdin is a terminal and a plain
+// scanner otherwise, e.g. when commands are piped in.
+func newLineReader(h *history, complete func(string) []string) lineReader {
+	fd := int(os.Stdin.Fd())
+	if !isTerminal(fd) {
+		return &scanReader{scanner: bufio.NewScanner(os.Stdin)}
+	}
+	return &termReader{fd: fd, editor: editor{
+		in:       bufio.NewReader(os.Stdin),
+		out:      os.Stdout,
+		history:  h,
+		complete: complete,
+	}}
+}
+
+// termReader puts the terminal in raw mode only while a line is edited, so
+// command output and Ctrl-C during a request behave as usual.
+type termReader struct {
+	fd int
+	editor
+}
+
+func (t *termReader) readLine(prompt string) (string, error) {
+	restore, err := makeRaw(t.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	return t.editor.readLine(prompt)
+}
+
+type scanReader struct {
+	scanner *bufio.Scanner
+}
+
+func (s *scanReader) readLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return s.scanner.Text(), nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEditor(input string, h *history, complete func(string) []string) (*editor, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &editor{
+		in:       bufio.NewReader(strings.NewReader(input)),
+		out:      &out,
+		history:  h,
+		complete: complete,
+	}, &out
+}
+
+func TestEditorEditing(t *testing.T) {
+	// "posx", backspace, "itionz", left, delete, Ctrl-E, "s", Enter.
+	e, _ := newTestEditor("posx\x7fitionz\x1b[D\x1b[3~s\r", &history{}, nil)
+	line, err := e.readLine("> ")
+	require.NoError(t, err)
+	require.Equal(t, "positions", line)
+}
+
+func TestEditorHistory(t *testing.T) {
+	h := &history{lines: []string{"ticker BTCUSDT", "orders ETHUSDT"}}
+
+	e, _ := newTestEditor("\x1b[A\x1b[A\r", h, nil)
+	line, err := e.readLine("> ")
+	require.NoError(t, err)
+	require.Equal(t, "ticker BTCUSDT", line)
+
+	// Down past the newest entry restores the draft.
+	e, _ = newTestEditor("fil\x1b[A\x1b[B\r", h, nil)
+	line, err = e.readLine("> ")
+	require.NoError(t, err)
+	require.Equal(t, "fil", line)
+}
+
+func TestEditorControlKeys(t *testing.T) {
+	e, out := newTestEditor("cancel-all BTC\x03", &history{}, nil)
+	_, err := e.readLine("> ")
+	require.ErrorIs(t, err, errInterrupted)
+	require.Contains(t, out.String(), "^C")
+
+	e, _ = newTestEditor("\x04", &history{}, nil)
+	_, err = e.readLine("> ")
+	require.ErrorIs(t, err, io.EOF)
+
+	e, _ = newTestEditor("garbage\x15ticker\r", &history{}, nil)
+	line, err := e.readLine("> ")
+	require.NoError(t, err)
+	require.Equal(t, "ticker", line)
+}
+
+func TestEditorTabCompletion(t *testing.T) {
+	s := &session{}
+	s.noteSymbol("BTCUSDT")
+	s.noteSymbol("btcusdt")
+	s.noteSymbol("ETHUSDT")
+
+	e, _ := newTestEditor("tic\tb\t\r", &history{}, s.complete)
+	line, err := e.readLine("> ")
+	require.NoError(t, err)
+	require.Equal(t, "ticker BTCUSDT ", line)
+
+	// Ambiguous: extend to the common prefix, then list the options.
+	e, out := newTestEditor("cancel\t\t\r", &history{}, s.complete)
+	line, err = e.readLine("> ")
+	require.NoError(t, err)
+	require.Equal(t, "cancel", line)
+	require.Contains(t, out.String(), "cancel  cancel-all  cancel-all-positions")
+
+	require.Nil(t, s.complete("positions "), "positions takes no symbol")
+	require.Nil(t, s.complete("limit BTCUSDT 1"))
+}
+
+func TestCompleteLine(t *testing.T) {
+	line, _ := completeLine("close", []string{"close-short", "close-long"})
+	require.Equal(t, "close-", line)
+
+	line, options := completeLine("ticker ET", []string{"ETHUSDT"})
+	require.Equal(t, "ticker ETHUSDT ", line)
+	require.Equal(t, []string{"ETHUSDT"}, options)
+
+	line, options = completeLine("zz", nil)
+	require.Equal(t, "zz", line)
+	require.Empty(t, options)
+}
+
+func TestHistoryPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h := loadHistory(path)
+	h.add("positions")
+	h.add("positions")
+	h.add("ticker BTCUSDT")
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "positions\nticker BTCUSDT\n", string(raw))
+	require.Equal(t, []string{"positions", "ticker BTCUSDT"}, loadHistory(path).lines)
+
+	big := &history{}
+	for i := 0; i < maxHistory+10; i++ {
+		big.lines = append(big.lines, "x")
+	}
+	big.trim()
+	require.Len(t, big.lines, maxHistory)
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package main
+
+import "errors"
+
+// Other platforms get the plain prompt without line editing.
+func isTerminal(int) bool { return false }
+
+func makeRaw(int) (func(), error) { return nil, errors.New("raw terminal mode not supported") }
@@ -0,0 +1,92 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/backtest"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+type Backtest struct {
+	Log    *logger.Entry
+	Config *Config
+}
+
+// Start replays the stored signals of the configured window through the
+// Phemex controller on a simulated exchange and logs the summary. Candles are
+// read from the main database, signals from the read-only one and the
+// resulting orders are written into the sandbox schema.
+func (b *Backtest) Start() error {
+	b.Config = GetConfig()
+	ctx := context.Background()
+
+	if !b.Config.To.After(b.Config.From) {
+		return fmt.Errorf("BACKTEST_TO must be after BACKTEST_FROM")
+	}
+
+	candles, err := repository.NewOHLCVRepositoryRepository().
+		FetchOHLCV1mRange(ctx, b.Config.Symbol, b.Config.From.UTC(), b.Config.To.UTC())
+	if err != nil {
+		return fmt.Errorf("load candles: %w", err)
+	}
+	if len(candles) == 0 {
+		return fmt.Errorf("no %s candles between %s and %s", b.Config.Symbol, b.Config.From, b.Config.To)
+	}
+
+	signals, err := repository.NewTradingSignalRepository().
+		FindInTimeRange(ctx, b.Config.SignalSymbol, b.Config.Exchange, b.Config.From.UTC(), b.Config.To.UTC())
+	if err != nil {
+		return fmt.Errorf("load signals: %w", err)
+	}
+
+	sandbox, err := database.OpenSandboxDB(b.Config.SandboxSchema)
+	if err != nil {
+		return err
+	}
+
+	engine := &backtest.Engine{
+		Log:            b.Log,
+		Sandbox:        sandbox,
+		Symbol:         b.Config.SignalSymbol,
+		ExchangeName:   b.Config.Exchange,
+		TickEvery:      b.Config.TickEvery,
+		UserExchange:   model.UserExchange{OrderSizePercent: b.Config.OrderSizePercent},
+		StrategyName:   b.Config.Strategy,
+		StrategyParams: b.Config.StrategyParams,
+		Exchange: backtest.NewSimExchange(backtest.SimConfig{
+			InitialBalance: decimal.NewFromFloat(b.Config.InitialBalance),
+			FeeRate:        decimal.NewFromFloat(b.Config.FeeRate),
+			Leverage:       decimal.NewFromFloat(b.Config.Leverage),
+		}),
+	}
+
+	res, err := engine.Run(ctx, candles, signals)
+	if err != nil {
+		return err
+	}
+
+	b.Log.WithFields(map[string]interface{}{
+		"candles":           res.Candles,
+		"signals":           res.Signals,
+		"controller_runs":   res.ControllerRuns,
+		"controller_errors": res.ControllerErrors,
+		"orders":            res.Orders,
+		"fills":             res.Stats.Fills,
+		"wins":              res.Stats.Wins,
+		"losses":            res.Stats.Losses,
+		"win_rate":          res.Stats.WinRate,
+		"realized_pnl":      res.Stats.RealizedPnL.StringFixed(2),
+		"fees":              res.Stats.Fees.StringFixed(2),
+		"net_pnl":           res.Stats.NetPnL.StringFixed(2),
+		"max_drawdown":      res.Stats.MaxDrawdown.StringFixed(2),
+		"final_balance":     res.FinalBalance.StringFixed(2),
+		"sandbox_schema":    b.Config.SandboxSchema,
+	}).Info("backtest finished")
+
+	return nil
+}
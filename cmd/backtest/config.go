@@ -0,0 +1,33 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// Symbol is the candle / order symbol, SignalSymbol the symbol signals are stored with.
+	Symbol           string        `envconfig:"BACKTEST_SYMBOL" default:"BTCUSDT"`
+	SignalSymbol     string        `envconfig:"BACKTEST_SIGNAL_SYMBOL" default:"BTCUSDT"`
+	Exchange         string        `envconfig:"BACKTEST_EXCHANGE" default:"phemex"`
+	From             time.Time     `envconfig:"BACKTEST_FROM" required:"true"`
+	To               time.Time     `envconfig:"BACKTEST_TO" required:"true"`
+	SandboxSchema    string        `envconfig:"BACKTEST_SANDBOX_SCHEMA" default:"backtest"`
+	InitialBalance   float64       `envconfig:"BACKTEST_INITIAL_BALANCE" default:"10000"`
+	FeeRate          float64       `envconfig:"BACKTEST_FEE_RATE" default:"0.0006"`
+	Leverage         float64       `envconfig:"BACKTEST_LEVERAGE" default:"10"`
+	OrderSizePercent int           `envconfig:"BACKTEST_ORDER_SIZE_PERCENT" default:"10"`
+	TickEvery        time.Duration `envconfig:"BACKTEST_TICK_EVERY" default:"15m"`
+	Strategy         string        `envconfig:"BACKTEST_STRATEGY" default:""`
+	StrategyParams   string        `envconfig:"BACKTEST_STRATEGY_PARAMS" default:""`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
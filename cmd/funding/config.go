@@ -0,0 +1,23 @@
+package funding
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	Exchange        string        `envconfig:"FUNDING_EXCHANGE" default:"phemex"`
+	Symbols         []string      `envconfig:"FUNDING_SYMBOLS" default:"BTCUSDT,ETHUSDT"`
+	BaseURL         string        `envconfig:"FUNDING_BASE_URL" default:"https://api.phemex.com"`
+	FundingInterval time.Duration `envconfig:"FUNDING_INTERVAL" default:"8h"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
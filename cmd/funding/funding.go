@@ -0,0 +1,113 @@
+package funding
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/cron"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func (f *FundingCollector) Start() error {
+	return f.Run(context.Background())
+}
+
+// Run collects one snapshot per configured symbol.
+func (f *FundingCollector) Run(ctx context.Context) error {
+	f.Config = GetConfig()
+
+	if !strings.EqualFold(f.Config.Exchange, "phemex") {
+		return fmt.Errorf("funding collector: unsupported exchange %q", f.Config.Exchange)
+	}
+
+	// Funding and open interest are public market data, no credentials needed.
+	f.client = connectors.NewClient("", "", f.Config.BaseURL)
+	f.repo = repository.NewFundingRepository()
+
+	return f.collect(ctx, time.Now().UTC())
+}
+
+// collect fetches one snapshot per configured symbol and stores it. A failure
+// on one symbol is logged and does not prevent the remaining symbols from
+// being collected; the first error is returned at the end.
+func (f *FundingCollector) collect(ctx context.Context, now time.Time) error {
+	var firstErr error
+
+	for _, symbol := range f.Config.Symbols {
+		symbol = strings.TrimSpace(symbol)
+		if symbol == "" {
+			continue
+		}
+
+		if err := f.collectSymbol(ctx, symbol, now); err != nil {
+			f.Log.WithError(err).WithField("symbol", symbol).Error("funding collect failed")
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		cron.AddItems(ctx, 1)
+	}
+
+	return firstErr
+}
+
+func (f *FundingCollector) collectSymbol(ctx context.Context, symbol string, now time.Time) error {
+	snap, err := f.client.GetFundingSnapshot(symbol)
+	if err != nil {
+		return fmt.Errorf("GetFundingSnapshot %s: %w", symbol, err)
+	}
+
+	// Snapshots are bucketed per minute so reruns within the same minute
+	// upsert instead of piling up rows.
+	ts := snap.Timestamp.UTC().Truncate(time.Minute)
+	exchange := strings.ToLower(f.Config.Exchange)
+	markPrice := decimal.NewFromFloat(snap.MarkPrice)
+
+	fr := &model.FundingRate{
+		Exchange:             exchange,
+		Symbol:               symbol,
+		Datetime:             ts,
+		FundingRate:          decimal.NewFromFloat(snap.FundingRate),
+		PredictedFundingRate: decimal.NewFromFloat(snap.PredictedFundingRate),
+		NextFundingTime:      NextFundingTime(now, f.Config.FundingInterval),
+		MarkPrice:            markPrice,
+	}
+	if err := f.repo.SaveFundingRate(ctx, fr); err != nil {
+		return fmt.Errorf("SaveFundingRate %s: %w", symbol, err)
+	}
+
+	oi := &model.OpenInterest{
+		Exchange:     exchange,
+		Symbol:       symbol,
+		Datetime:     ts,
+		OpenInterest: decimal.NewFromFloat(snap.OpenInterest),
+		MarkPrice:    markPrice,
+	}
+	if err := f.repo.SaveOpenInterest(ctx, oi); err != nil {
+		return fmt.Errorf("SaveOpenInterest %s: %w", symbol, err)
+	}
+
+	f.Log.WithFields(map[string]interface{}{
+		"symbol":          symbol,
+		"fundingRate":     fr.FundingRate.String(),
+		"nextFundingTime": fr.NextFundingTime,
+		"openInterest":    oi.OpenInterest.String(),
+	}).Info("funding snapshot stored")
+
+	return nil
+}
+
+// NextFundingTime returns the next funding settlement after now, assuming
+// settlements happen every interval starting at 00:00 UTC (8h on Phemex).
+func NextFundingTime(now time.Time, interval time.Duration) time.Time {
+	if interval <= 0 {
+		interval = 8 * time.Hour
+	}
+	return now.UTC().Truncate(interval).Add(interval)
+}
@@ -0,0 +1,77 @@
+package funding
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFundingClient struct {
+	snaps map[string]*connectors.FundingSnapshot
+}
+
+func (c *fakeFundingClient) GetFundingSnapshot(symbol string) (*connectors.FundingSnapshot, error) {
+	if s, ok := c.snaps[symbol]; ok {
+		return s, nil
+	}
+	return nil, errors.New("unknown symbol")
+}
+
+type fakeFundingRepo struct {
+	rates []*model.FundingRate
+	ois   []*model.OpenInterest
+}
+
+func (r *fakeFundingRepo) SaveFundingRate(_ context.Context, fr *model.FundingRate) error {
+	r.rates = append(r.rates, fr)
+	return nil
+}
+
+func (r *fakeFundingRepo) SaveOpenInterest(_ context.Context, oi *model.OpenInterest) error {
+	r.ois = append(r.ois, oi)
+	return nil
+}
+
+func TestNextFundingTime(t *testing.T) {
+	cases := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 1, 1, 7, 59, 0, 0, time.UTC), time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 1, 1, 23, 30, 0, 0, time.UTC), time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.want, NextFundingTime(tc.now, 8*time.Hour))
+	}
+}
+
+func TestCollectStoresSnapshotsAndContinuesOnError(t *testing.T) {
+	ts := time.Date(2025, 1, 1, 3, 15, 42, 0, time.UTC)
+	repo := &fakeFundingRepo{}
+	f := &FundingCollector{
+		Log:    logrus.WithField("cmd", "funding"),
+		Config: &Config{Exchange: "Phemex", Symbols: []string{"MISSING", "BTCUSDT"}, FundingInterval: 8 * time.Hour},
+		client: &fakeFundingClient{snaps: map[string]*connectors.FundingSnapshot{
+			"BTCUSDT": {Symbol: "BTCUSDT", FundingRate: 0.0001, OpenInterest: 1500, MarkPrice: 60000, Timestamp: ts},
+		}},
+		repo: repo,
+	}
+
+	err := f.collect(context.Background(), ts)
+	require.Error(t, err)
+
+	require.Len(t, repo.rates, 1)
+	require.Len(t, repo.ois, 1)
+	require.Equal(t, "phemex", repo.rates[0].Exchange)
+	require.Equal(t, time.Date(2025, 1, 1, 3, 15, 0, 0, time.UTC), repo.rates[0].Datetime)
+	require.Equal(t, time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC), repo.rates[0].NextFundingTime)
+	require.Equal(t, "0.0001", repo.rates[0].FundingRate.String())
+	require.Equal(t, "1500", repo.ois[0].OpenInterest.String())
+}
@@ -0,0 +1,27 @@
+package funding
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// fundingClient is the subset of the exchange connector used by the collector.
+type fundingClient interface {
+	GetFundingSnapshot(symbol string) (*connectors.FundingSnapshot, error)
+}
+
+// fundingRepository is the subset of the repository used by the collector.
+type fundingRepository interface {
+	SaveFundingRate(ctx context.Context, fr *model.FundingRate) error
+	SaveOpenInterest(ctx context.Context, oi *model.OpenInterest) error
+}
+
+type FundingCollector struct {
+	Log    *logger.Entry
+	Config *Config
+	client fundingClient
+	repo   fundingRepository
+}
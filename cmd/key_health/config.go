@@ -0,0 +1,25 @@
+package key_health
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// MaxFailures is the number of consecutive authentication failures after
+	// which run_on_server is switched off.
+	MaxFailures   int    `envconfig:"KEY_HEALTH_MAX_FAILURES" default:"3"`
+	PhemexBaseURL string `envconfig:"KEY_HEALTH_PHEMEX_BASE_URL" default:"https://api.phemex.com"`
+	KrakenBaseURL string `envconfig:"KEY_HEALTH_KRAKEN_BASE_URL" default:""`
+	// KucoinKeyVersion is sent as KC-API-KEY-VERSION.
+	KucoinKeyVersion string `envconfig:"KEY_HEALTH_KUCOIN_KEY_VERSION" default:"2"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
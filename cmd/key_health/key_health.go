@@ -0,0 +1,194 @@
+package key_health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/cron"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedExchange is returned by ValidateCredentials for exchanges
+// without a known authenticated endpoint. Those accounts are skipped.
+var ErrUnsupportedExchange = errors.New("exchange not supported by key health check")
+
+func (k *KeyHealth) Start() error {
+	return k.Run(context.Background())
+}
+
+// Run checks the credentials of every server-run account.
+func (k *KeyHealth) Run(ctx context.Context) error {
+	k.Config = GetConfig()
+
+	k.userExchanges = repository.NewUserExchangeRepository()
+	k.users = repository.NewUserRepository()
+	k.notifier = notify.NewNotifier()
+	k.validate = func(ctx context.Context, exchange string, creds *security.Credentials) error {
+		return ValidateCredentials(ctx, k.Config, exchange, creds)
+	}
+	k.now = time.Now
+
+	return k.run(ctx)
+}
+
+// ValidateCredentials performs the cheapest authenticated call each
+// connector offers. A nil error means the exchange accepted the credentials.
+// The KuCoin key version the exchange accepted is set on creds.
+func ValidateCredentials(ctx context.Context, cfg *Config, exchange string, creds *security.Credentials) error {
+	switch strings.ToLower(exchange) {
+	case "phemex":
+		c, err := connectors.NewClientFor(creds.APIKey, creds.APISecret, creds.Environment, cfg.PhemexBaseURL)
+		if err != nil {
+			return err
+		}
+		_, err = c.GetPositionsUSDT()
+		return err
+	case "kraken":
+		c, err := connectors.NewKrakenFuturesClientFor(creds.APIKey, creds.APISecret, creds.Environment, cfg.KrakenBaseURL)
+		if err != nil {
+			return err
+		}
+		_, err = c.GetOpenPositions()
+		return err
+	case "kucoin":
+		c, err := connectors.NewKucoinConnectorFor(creds.APIKey, creds.APISecret, creds.APIPassphrase, creds.KucoinKeyVersion(cfg.KucoinKeyVersion), creds.Environment)
+		if err != nil {
+			return err
+		}
+		if err := c.TestConnection(); err != nil {
+			return err
+		}
+		if version, detected := c.KeyVersion(); detected {
+			creds.KeyVersion = version
+		}
+		return nil
+	case "hydra":
+		if creds.Environment == connectors.EnvironmentTestnet {
+			return fmt.Errorf("hydra: %w", connectors.ErrNoTestnet)
+		}
+		c, err := connectors.NewGooeyClient(creds.APIKey, creds.APISecret)
+		if err != nil {
+			return err
+		}
+		return c.Login(ctx)
+	default:
+		return ErrUnsupportedExchange
+	}
+}
+
+// run validates the credentials of every server-run user exchange. Checks
+// are independent: a failing account never stops the others. Only store
+// errors are returned.
+func (k *KeyHealth) run(ctx context.Context) error {
+	userExchanges, err := k.userExchanges.ListRunOnServer(ctx)
+	if err != nil {
+		return fmt.Errorf("ListRunOnServer: %w", err)
+	}
+
+	var firstErr error
+	for i := range userExchanges {
+		err := k.check(ctx, &userExchanges[i])
+		if err == nil {
+			cron.AddItems(ctx, 1)
+		} else if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (k *KeyHealth) check(ctx context.Context, ue *model.UserExchange) error {
+	exchange := ""
+	if ue.Exchange != nil {
+		exchange = ue.Exchange.Name
+	}
+	log := k.Log.WithFields(map[string]interface{}{
+		"user_id":  ue.UserID,
+		"exchange": exchange,
+	})
+
+	// Failing to load the credentials (secrets backend down, bad master
+	// key) is an operator problem, not a rejected key, so it never counts
+	// toward the failure streak.
+	authFailure := false
+	creds, err := security.ResolveCredentials(ctx, ue)
+	if err == nil {
+		err = k.validate(ctx, exchange, &creds)
+		if errors.Is(err, ErrUnsupportedExchange) {
+			log.Debug("exchange not supported by key health check, skipping")
+			return nil
+		}
+		authFailure = isAuthError(err)
+	}
+
+	now := k.now().UTC()
+	ue.LastValidatedAt = &now
+	disabled := false
+	switch {
+	case err == nil:
+		ue.LastValidationError = ""
+		ue.ValidationFailures = 0
+		if creds.KeyVersion != ue.KeyVersion {
+			log.WithField("key_version", creds.KeyVersion).Info("storing the detected key version")
+			ue.KeyVersion = creds.KeyVersion
+		}
+	case authFailure:
+		ue.LastValidationError = err.Error()
+		ue.ValidationFailures++
+		if ue.ValidationFailures >= k.Config.MaxFailures {
+			ue.RunOnServer = false
+			disabled = true
+		}
+	default:
+		// Network errors and outages say nothing about the key itself: keep
+		// the failure streak as it is.
+		ue.LastValidationError = err.Error()
+	}
+
+	if recErr := k.userExchanges.RecordValidation(ctx, ue); recErr != nil {
+		log.WithError(recErr).Error("failed to record key validation")
+		return fmt.Errorf("RecordValidation: %w", recErr)
+	}
+
+	if err != nil {
+		log.WithError(err).WithField("failures", ue.ValidationFailures).Warn("exchange credentials check failed")
+	} else {
+		log.Info("exchange credentials valid")
+	}
+
+	if disabled {
+		log.Warn("server execution disabled after repeated authentication failures")
+		k.notifyDisabled(ctx, ue, exchange, err)
+	}
+	return nil
+}
+
+func (k *KeyHealth) notifyDisabled(ctx context.Context, ue *model.UserExchange, exchange string, cause error) {
+	username := ""
+	if u, err := k.users.GetUserByID(ctx, ue.UserID); err == nil && u != nil {
+		username = u.Username
+	}
+
+	k.notifier.Notify(ctx, notify.Event{
+		Type:     notify.EventKeyInvalid,
+		UserID:   ue.UserID,
+		Username: username,
+		Exchange: exchange,
+		Message: fmt.Sprintf("server execution disabled after %d consecutive authentication failures (%v)",
+			ue.ValidationFailures, cause),
+		Err:        cause,
+		OccurredAt: *ue.LastValidatedAt,
+	})
+}
+
+// isAuthError reports whether err means the exchange rejected the credentials,
+// as opposed to a transient transport or server failure.
+func isAuthError(err error) bool {
+	return errors.Is(err, connectors.ErrAuth)
+}
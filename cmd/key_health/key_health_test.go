@@ -0,0 +1,162 @@
+package key_health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	rows     []model.UserExchange
+	recorded []model.UserExchange
+}
+
+func (f *fakeStore) ListRunOnServer(context.Context) ([]model.UserExchange, error) {
+	return f.rows, nil
+}
+
+func (f *fakeStore) RecordValidation(_ context.Context, ue *model.UserExchange) error {
+	f.recorded = append(f.recorded, *ue)
+	return nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) GetUserByID(_ context.Context, id uint) (*model.User, error) {
+	return &model.User{ID: id, Username: "alice"}, nil
+}
+
+type fakeNotifier struct{ events []notify.Event }
+
+func (n *fakeNotifier) Notify(_ context.Context, ev notify.Event) { n.events = append(n.events, ev) }
+
+func TestIsAuthError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&connectors.ExchangeError{Exchange: "phemex", Status: 401, Kind: connectors.ErrAuth}, true},
+		{fmt.Errorf("futures ping failed: %w", &connectors.ExchangeError{Exchange: "kucoin", Code: "400003", Kind: connectors.ErrAuth}), true},
+		{&connectors.ExchangeError{Exchange: "phemex", Status: 502, Kind: connectors.ErrUnavailable}, false},
+		{&connectors.ExchangeError{Exchange: "phemex", Code: "11052", Kind: connectors.ErrInsufficientMargin}, false},
+		{errors.New("http do: dial tcp: i/o timeout"), false},
+		{errors.New("HTTP 401: message without a typed error"), false},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.want, isAuthError(tc.err), "%v", tc.err)
+	}
+}
+
+func TestRunDisablesAfterRepeatedAuthFailures(t *testing.T) {
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	account := func(id uint, exchange string, failures int) model.UserExchange {
+		return model.UserExchange{
+			ID:                 id,
+			UserID:             id,
+			APIKeyHash:         key,
+			APISecretHash:      secret,
+			RunOnServer:        true,
+			ValidationFailures: failures,
+			Exchange:           &model.Exchange{Name: exchange},
+		}
+	}
+
+	store := &fakeStore{rows: []model.UserExchange{
+		account(1, "phemex", 2), // third auth failure: disabled
+		account(2, "kraken", 1), // valid key: streak reset
+		account(3, "kucoin", 2), // outage: streak unchanged
+		account(4, "binance", 0),
+	}}
+	n := &fakeNotifier{}
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	k := &KeyHealth{
+		Log:           logrus.NewEntry(logrus.New()),
+		Config:        &Config{MaxFailures: 3},
+		userExchanges: store,
+		users:         fakeUsers{},
+		notifier:      n,
+		now:           func() time.Time { return now },
+		validate: func(_ context.Context, exchange string, creds *security.Credentials) error {
+			require.Equal(t, "key", creds.APIKey)
+			switch exchange {
+			case "phemex":
+				return &connectors.ExchangeError{Exchange: "phemex", Status: 401, Msg: "invalid api key", Kind: connectors.ErrAuth}
+			case "kraken":
+				return nil
+			case "kucoin":
+				return errors.New("http do: connection refused")
+			default:
+				return ErrUnsupportedExchange
+			}
+		},
+	}
+
+	require.NoError(t, k.run(context.Background()))
+	require.Len(t, store.recorded, 3)
+
+	phemex := store.recorded[0]
+	require.False(t, phemex.RunOnServer)
+	require.Equal(t, 3, phemex.ValidationFailures)
+	require.Equal(t, now, *phemex.LastValidatedAt)
+	require.Contains(t, phemex.LastValidationError, "401")
+
+	kraken := store.recorded[1]
+	require.True(t, kraken.RunOnServer)
+	require.Zero(t, kraken.ValidationFailures)
+	require.Empty(t, kraken.LastValidationError)
+
+	kucoin := store.recorded[2]
+	require.True(t, kucoin.RunOnServer)
+	require.Equal(t, 2, kucoin.ValidationFailures)
+	require.Contains(t, kucoin.LastValidationError, "connection refused")
+
+	require.Len(t, n.events, 1)
+	require.Equal(t, notify.EventKeyInvalid, n.events[0].Type)
+	require.Equal(t, uint(1), n.events[0].UserID)
+	require.Equal(t, "alice", n.events[0].Username)
+	require.Equal(t, "phemex", n.events[0].Exchange)
+}
+
+func TestCheckStoresDetectedKeyVersion(t *testing.T) {
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	store := &fakeStore{rows: []model.UserExchange{{
+		ID: 1, UserID: 1, APIKeyHash: key, APISecretHash: secret, RunOnServer: true, KeyVersion: "2",
+		Exchange: &model.Exchange{Name: "kucoin"},
+	}}}
+	k := &KeyHealth{
+		Log:           logrus.NewEntry(logrus.New()),
+		Config:        &Config{MaxFailures: 3},
+		userExchanges: store,
+		users:         fakeUsers{},
+		notifier:      &fakeNotifier{},
+		now:           time.Now,
+		validate: func(_ context.Context, _ string, creds *security.Credentials) error {
+			require.Equal(t, "2", creds.KeyVersion, "the stored key version is used")
+			creds.KeyVersion = "3"
+			return nil
+		},
+	}
+
+	require.NoError(t, k.run(context.Background()))
+	require.Len(t, store.recorded, 1)
+	require.Equal(t, "3", store.recorded[0].KeyVersion)
+}
@@ -0,0 +1,39 @@
+package key_health
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type userExchangeStore interface {
+	ListRunOnServer(ctx context.Context) ([]model.UserExchange, error)
+	RecordValidation(ctx context.Context, ue *model.UserExchange) error
+}
+
+type userLookup interface {
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+type notifier interface {
+	Notify(ctx context.Context, ev notify.Event)
+}
+
+// validateFunc calls a cheap authenticated endpoint of exchange with creds.
+// It may set the key version the exchange accepted on creds.
+type validateFunc func(ctx context.Context, exchange string, creds *security.Credentials) error
+
+type KeyHealth struct {
+	Log    *logger.Entry
+	Config *Config
+
+	userExchanges userExchangeStore
+	users         userLookup
+	notifier      notifier
+	validate      validateFunc
+	now           func() time.Time
+}
@@ -7,7 +7,8 @@ import (
 )
 
 type Config struct {
-	ExchangeID  uint `envconfig:"EXCHANGE_ID" default:"1"`
+	// RunOnServer is the run_on_server value of keys stored for the first
+	// time; existing rows keep theirs.
 	RunOnServer bool `envconfig:"RUN_ON_SERVER" default:"true"`
 }
 
@@ -0,0 +1,241 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strategyexecutor/cmd/key_health"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when the user has no keys on the exchange.
+var ErrNotFound = errors.New("no keys stored for this user and exchange")
+
+// New wires Keys to the main database and validates keys with the same
+// calls as the key_health job.
+func New() *Keys {
+	validation := key_health.GetConfig()
+	return &Keys{
+		Log:           logger.WithField("cmd", "keys"),
+		Config:        GetConfig(),
+		userExchanges: repository.NewUserExchangeRepository(),
+		users:         repository.NewUserRepository(),
+		exchanges:     repository.NewExchangeRepository(),
+		validate: func(ctx context.Context, exchange string, creds *security.Credentials) error {
+			return key_health.ValidateCredentials(ctx, validation, exchange, creds)
+		},
+		now: time.Now,
+	}
+}
+
+// resolveExchange finds an exchange by numeric id or by name.
+func (k *Keys) resolveExchange(ctx context.Context, ref string) (*model.Exchange, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, errors.New("exchange is required")
+	}
+
+	var (
+		ex  *model.Exchange
+		err error
+	)
+	if id, convErr := strconv.ParseUint(ref, 10, 64); convErr == nil {
+		ex, err = k.exchanges.FindByID(ctx, uint(id))
+	} else {
+		ex, err = k.exchanges.FindByName(ctx, strings.ToLower(ref))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ex == nil {
+		return nil, fmt.Errorf("unknown exchange %q", ref)
+	}
+	return ex, nil
+}
+
+func (k *Keys) find(ctx context.Context, userID, exchangeID uint) (*model.UserExchange, error) {
+	ue, err := k.userExchanges.GetByUserAndExchange(ctx, userID, exchangeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return ue, err
+}
+
+// SetKey validates the credentials against the exchange, encrypts them and
+// stores them. Keys the exchange rejects are never saved.
+func (k *Keys) SetKey(ctx context.Context, req SetKeyRequest) (*model.UserExchange, error) {
+	if req.UserID == 0 {
+		return nil, errors.New("user id is required")
+	}
+	if req.APIKey == "" || req.APISecret == "" {
+		return nil, errors.New("api key and secret are required")
+	}
+	if req.OrderSizePercent < 0 || req.OrderSizePercent > 100 {
+		return nil, fmt.Errorf("order size percent must be between 1 and 100, got %d", req.OrderSizePercent)
+	}
+
+	ex, err := k.resolveExchange(ctx, req.Exchange)
+	if err != nil {
+		return nil, err
+	}
+	log := k.Log.WithFields(logger.Fields{"user_id": req.UserID, "exchange": ex.Name})
+
+	ue, err := k.find(ctx, req.UserID, ex.ID)
+	if err != nil {
+		return nil, fmt.Errorf("GetByUserAndExchange: %w", err)
+	}
+	if ue == nil && req.OrderSizePercent == 0 {
+		return nil, errors.New("order size percent is required for new keys")
+	}
+
+	environment, err := k.environment(ctx, req, ex.ID, ue)
+	if err != nil {
+		return nil, err
+	}
+	req.Credentials.Environment = environment
+	log = log.WithField("environment", environment)
+
+	var validatedAt *time.Time
+	if req.SkipValidation {
+		log.Warn("storing keys without validating them against the exchange")
+	} else {
+		err := k.validate(ctx, ex.Name, &req.Credentials)
+		if errors.Is(err, key_health.ErrUnsupportedExchange) {
+			return nil, fmt.Errorf("keys for %s cannot be validated; store them with skip validation", ex.Name)
+		}
+		if err != nil {
+			log.WithError(err).Warn("exchange rejected the keys")
+			return nil, fmt.Errorf("validate keys against %s: %w", ex.Name, err)
+		}
+		now := k.now().UTC()
+		validatedAt = &now
+	}
+
+	created := ue == nil
+	if created {
+		ue = &model.UserExchange{
+			UserID:      req.UserID,
+			ExchangeID:  ex.ID,
+			RunOnServer: k.Config.RunOnServer,
+		}
+	}
+	if ue.APIKeyHash, err = security.EncryptString(req.APIKey); err != nil {
+		return nil, fmt.Errorf("encrypt api key: %w", err)
+	}
+	if ue.APISecretHash, err = security.EncryptString(req.APISecret); err != nil {
+		return nil, fmt.Errorf("encrypt api secret: %w", err)
+	}
+	ue.APIPassphraseHash = ""
+	if req.APIPassphrase != "" {
+		if ue.APIPassphraseHash, err = security.EncryptString(req.APIPassphrase); err != nil {
+			return nil, fmt.Errorf("encrypt api passphrase: %w", err)
+		}
+	}
+	// The ciphertext columns are only read when no external secret is set.
+	ue.SecretBackend, ue.SecretPath = "", ""
+	if req.OrderSizePercent != 0 {
+		ue.OrderSizePercent = req.OrderSizePercent
+	}
+	if req.RunOnServer != nil {
+		ue.RunOnServer = *req.RunOnServer
+	}
+	ue.Environment = environment
+	ue.KeyVersion = req.KeyVersion
+	ue.LastValidatedAt = validatedAt
+	ue.LastValidationError = ""
+	ue.ValidationFailures = 0
+
+	if created {
+		err = k.userExchanges.Create(ctx, ue)
+	} else {
+		err = k.userExchanges.Update(ctx, ue)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("save keys: %w", err)
+	}
+
+	log.WithField("created", created).Info("exchange keys stored")
+	ue.Exchange = ex
+	return ue, nil
+}
+
+// environment resolves the environment of the keys in req, refusing live
+// keys for a testnet-only user.
+func (k *Keys) environment(ctx context.Context, req SetKeyRequest, exchangeID uint, ue *model.UserExchange) (string, error) {
+	user, err := k.users.GetUserByID(ctx, req.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("find user %d: %w", req.UserID, err)
+	}
+
+	environment := req.Environment
+	switch {
+	case environment != "":
+	case ue != nil:
+		environment = ue.Environment
+	case user != nil && user.TestnetOnly:
+		environment = connectors.EnvironmentTestnet
+	}
+	return security.AccountEnvironment(user, &model.UserExchange{UserID: req.UserID, ExchangeID: exchangeID, Environment: environment})
+}
+
+// SetRunOnServer switches server-side execution of a user exchange on or
+// off. Turning it on requires stored keys.
+func (k *Keys) SetRunOnServer(ctx context.Context, userID uint, exchange string, on bool) error {
+	ex, err := k.resolveExchange(ctx, exchange)
+	if err != nil {
+		return err
+	}
+	ue, err := k.find(ctx, userID, ex.ID)
+	if err != nil {
+		return fmt.Errorf("GetByUserAndExchange: %w", err)
+	}
+	if ue == nil {
+		return ErrNotFound
+	}
+	if on && ue.SecretPath == "" && (ue.APIKeyHash == "" || ue.APISecretHash == "") {
+		return fmt.Errorf("no key set for user %d on %s", userID, ex.Name)
+	}
+
+	ue.RunOnServer = on
+	if err := k.userExchanges.Update(ctx, ue); err != nil {
+		return fmt.Errorf("update user exchange: %w", err)
+	}
+	k.Log.WithFields(logger.Fields{"user_id": userID, "exchange": ex.Name, "run_on_server": on}).Info("run on server updated")
+	return nil
+}
+
+// List returns the stored keys of userID, or of every user when 0.
+// Credentials are never decrypted.
+func (k *Keys) List(ctx context.Context, userID uint) ([]model.UserExchange, error) {
+	if userID != 0 {
+		ctx = auth.WithUserID(ctx, userID)
+	}
+	return k.userExchanges.List(ctx)
+}
+
+// Delete removes the keys of a user on an exchange.
+func (k *Keys) Delete(ctx context.Context, userID uint, exchange string) error {
+	ex, err := k.resolveExchange(ctx, exchange)
+	if err != nil {
+		return err
+	}
+	removed, err := k.userExchanges.Delete(ctx, userID, ex.ID)
+	if err != nil {
+		return fmt.Errorf("delete user exchange: %w", err)
+	}
+	if !removed {
+		return ErrNotFound
+	}
+	k.Log.WithFields(logger.Fields{"user_id": userID, "exchange": ex.Name}).Warn("exchange keys deleted")
+	return nil
+}
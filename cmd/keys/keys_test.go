@@ -0,0 +1,325 @@
+package keys
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strategyexecutor/cmd/key_health"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type fakeUserExchanges struct {
+	rows    map[[2]uint]*model.UserExchange
+	listCtx context.Context
+	nextID  uint
+}
+
+func newFakeUserExchanges() *fakeUserExchanges {
+	return &fakeUserExchanges{rows: map[[2]uint]*model.UserExchange{}}
+}
+
+func (f *fakeUserExchanges) GetByUserAndExchange(_ context.Context, userID, exchangeID uint) (*model.UserExchange, error) {
+	ue, ok := f.rows[[2]uint{userID, exchangeID}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *ue
+	return &cp, nil
+}
+
+func (f *fakeUserExchanges) Create(_ context.Context, ue *model.UserExchange) error {
+	f.nextID++
+	ue.ID = f.nextID
+	cp := *ue
+	f.rows[[2]uint{ue.UserID, ue.ExchangeID}] = &cp
+	return nil
+}
+
+func (f *fakeUserExchanges) Update(_ context.Context, ue *model.UserExchange) error {
+	cp := *ue
+	f.rows[[2]uint{ue.UserID, ue.ExchangeID}] = &cp
+	return nil
+}
+
+func (f *fakeUserExchanges) List(ctx context.Context) ([]model.UserExchange, error) {
+	f.listCtx = ctx
+	var out []model.UserExchange
+	for _, ue := range f.rows {
+		out = append(out, *ue)
+	}
+	return out, nil
+}
+
+func (f *fakeUserExchanges) Delete(_ context.Context, userID, exchangeID uint) (bool, error) {
+	key := [2]uint{userID, exchangeID}
+	if _, ok := f.rows[key]; !ok {
+		return false, nil
+	}
+	delete(f.rows, key)
+	return true, nil
+}
+
+type fakeUsers map[uint]*model.User
+
+func (f fakeUsers) GetUserByID(_ context.Context, id uint) (*model.User, error) {
+	u, ok := f[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return u, nil
+}
+
+type fakeExchanges map[string]*model.Exchange
+
+func (f fakeExchanges) FindByID(_ context.Context, id uint) (*model.Exchange, error) {
+	for _, ex := range f {
+		if ex.ID == id {
+			return ex, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f fakeExchanges) FindByName(_ context.Context, name string) (*model.Exchange, error) {
+	return f[name], nil
+}
+
+func newTestKeys(validate validateFunc) (*Keys, *fakeUserExchanges) {
+	store := newFakeUserExchanges()
+	return &Keys{
+		Log:           logrus.NewEntry(logrus.New()),
+		Config:        Config{RunOnServer: true},
+		userExchanges: store,
+		users:         fakeUsers{8: {ID: 8, TestnetOnly: true}},
+		exchanges: fakeExchanges{
+			"phemex": {ID: 1, Name: "phemex"},
+			"kucoin": {ID: 2, Name: "kucoin"},
+		},
+		validate: validate,
+		now:      func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) },
+	}, store
+}
+
+func acceptAll(context.Context, string, *security.Credentials) error { return nil }
+
+func TestSetKeyEncryptsAndStores(t *testing.T) {
+	var validated security.Credentials
+	k, store := newTestKeys(func(_ context.Context, exchange string, creds *security.Credentials) error {
+		require.Equal(t, "kucoin", exchange)
+		validated = *creds
+		creds.KeyVersion = "3"
+		return nil
+	})
+
+	ue, err := k.SetKey(context.Background(), SetKeyRequest{
+		UserID:           7,
+		Exchange:         "KuCoin",
+		Credentials:      security.Credentials{APIKey: "key", APISecret: "secret", APIPassphrase: "pass"},
+		OrderSizePercent: 10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "key", validated.APIKey)
+
+	stored := store.rows[[2]uint{7, 2}]
+	require.NotNil(t, stored)
+	require.True(t, stored.RunOnServer)
+	require.Equal(t, 10, stored.OrderSizePercent)
+	require.Equal(t, "3", stored.KeyVersion, "the detected key version is stored")
+	require.NotNil(t, stored.LastValidatedAt)
+	require.NotEqual(t, "secret", stored.APISecretHash)
+
+	for hash, want := range map[string]string{stored.APIKeyHash: "key", stored.APISecretHash: "secret", stored.APIPassphraseHash: "pass"} {
+		plain, err := security.DecryptString(hash)
+		require.NoError(t, err)
+		require.Equal(t, want, plain)
+	}
+	require.Equal(t, "kucoin", ue.Exchange.Name)
+}
+
+func TestSetKeyEnvironment(t *testing.T) {
+	var validated security.Credentials
+	k, store := newTestKeys(func(_ context.Context, _ string, creds *security.Credentials) error {
+		validated = *creds
+		return nil
+	})
+	ctx := context.Background()
+	req := SetKeyRequest{
+		UserID:           7,
+		Exchange:         "phemex",
+		Credentials:      security.Credentials{APIKey: "key", APISecret: "secret"},
+		OrderSizePercent: 10,
+	}
+
+	_, err := k.SetKey(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "live", store.rows[[2]uint{7, 1}].Environment)
+
+	req.Environment = "TESTNET"
+	_, err = k.SetKey(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "testnet", store.rows[[2]uint{7, 1}].Environment)
+	require.Equal(t, "testnet", validated.Environment, "keys are validated against the testnet")
+
+	req.Environment = ""
+	_, err = k.SetKey(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "testnet", store.rows[[2]uint{7, 1}].Environment, "empty keeps the environment")
+
+	req.Environment = "staging"
+	_, err = k.SetKey(ctx, req)
+	require.ErrorContains(t, err, "unknown environment")
+}
+
+func TestSetKeyTestnetOnlyUser(t *testing.T) {
+	k, store := newTestKeys(acceptAll)
+	ctx := context.Background()
+	req := SetKeyRequest{
+		UserID:           8,
+		Exchange:         "phemex",
+		Credentials:      security.Credentials{APIKey: "key", APISecret: "secret"},
+		OrderSizePercent: 10,
+	}
+
+	_, err := k.SetKey(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "testnet", store.rows[[2]uint{8, 1}].Environment, "new keys of a testnet-only user default to testnet")
+
+	req.Environment = "live"
+	_, err = k.SetKey(ctx, req)
+	require.ErrorIs(t, err, security.ErrTestnetOnly)
+	require.Equal(t, "testnet", store.rows[[2]uint{8, 1}].Environment)
+}
+
+func TestSetKeyRejectedByExchangeIsNotSaved(t *testing.T) {
+	k, store := newTestKeys(func(context.Context, string, *security.Credentials) error {
+		return errors.New("HTTP 401: invalid api key")
+	})
+
+	_, err := k.SetKey(context.Background(), SetKeyRequest{
+		UserID:           7,
+		Exchange:         "phemex",
+		Credentials:      security.Credentials{APIKey: "key", APISecret: "bad"},
+		OrderSizePercent: 10,
+	})
+	require.ErrorContains(t, err, "invalid api key")
+	require.Empty(t, store.rows)
+}
+
+func TestSetKeyUnsupportedExchangeNeedsSkipValidation(t *testing.T) {
+	k, store := newTestKeys(func(context.Context, string, *security.Credentials) error {
+		return key_health.ErrUnsupportedExchange
+	})
+	req := SetKeyRequest{
+		UserID:           7,
+		Exchange:         "1",
+		Credentials:      security.Credentials{APIKey: "key", APISecret: "secret"},
+		OrderSizePercent: 5,
+	}
+
+	_, err := k.SetKey(context.Background(), req)
+	require.ErrorContains(t, err, "skip validation")
+	require.Empty(t, store.rows)
+
+	req.SkipValidation = true
+	ue, err := k.SetKey(context.Background(), req)
+	require.NoError(t, err)
+	require.Nil(t, ue.LastValidatedAt)
+}
+
+func TestSetKeyUpdateKeepsPercentAndClearsExternalSecret(t *testing.T) {
+	k, store := newTestKeys(acceptAll)
+	ctx := context.Background()
+	creds := security.Credentials{APIKey: "key", APISecret: "secret"}
+
+	_, err := k.SetKey(ctx, SetKeyRequest{UserID: 7, Exchange: "phemex", Credentials: creds})
+	require.ErrorContains(t, err, "order size percent is required")
+
+	store.rows[[2]uint{7, 1}] = &model.UserExchange{
+		UserID: 7, ExchangeID: 1, OrderSizePercent: 25,
+		SecretBackend: "vault", SecretPath: "secret/data/u7", ValidationFailures: 2,
+	}
+	_, err = k.SetKey(ctx, SetKeyRequest{UserID: 7, Exchange: "phemex", Credentials: creds})
+	require.NoError(t, err)
+
+	stored := store.rows[[2]uint{7, 1}]
+	require.Equal(t, 25, stored.OrderSizePercent)
+	require.Empty(t, stored.SecretPath)
+	require.Zero(t, stored.ValidationFailures)
+	require.False(t, stored.RunOnServer)
+
+	on := true
+	_, err = k.SetKey(ctx, SetKeyRequest{UserID: 7, Exchange: "phemex", Credentials: creds, RunOnServer: &on})
+	require.NoError(t, err)
+	require.True(t, store.rows[[2]uint{7, 1}].RunOnServer)
+}
+
+func TestSetRunOnServer(t *testing.T) {
+	k, store := newTestKeys(acceptAll)
+	ctx := context.Background()
+
+	require.ErrorIs(t, k.SetRunOnServer(ctx, 7, "phemex", true), ErrNotFound)
+
+	store.rows[[2]uint{7, 1}] = &model.UserExchange{UserID: 7, ExchangeID: 1}
+	require.ErrorContains(t, k.SetRunOnServer(ctx, 7, "phemex", true), "no key set")
+
+	require.NoError(t, k.SetRunOnServer(ctx, 7, "phemex", false))
+	require.False(t, store.rows[[2]uint{7, 1}].RunOnServer)
+
+	_, err := k.SetKey(ctx, SetKeyRequest{UserID: 7, Exchange: "phemex", Credentials: security.Credentials{APIKey: "k", APISecret: "s"}})
+	require.NoError(t, err)
+	require.NoError(t, k.SetRunOnServer(ctx, 7, "phemex", true))
+	require.True(t, store.rows[[2]uint{7, 1}].RunOnServer)
+}
+
+func TestListAndDelete(t *testing.T) {
+	k, store := newTestKeys(acceptAll)
+	ctx := context.Background()
+
+	_, err := k.List(ctx, 7)
+	require.NoError(t, err)
+	userID, ok := auth.UserIDFromContext(store.listCtx)
+	require.True(t, ok)
+	require.Equal(t, uint(7), userID)
+
+	require.ErrorIs(t, k.Delete(ctx, 7, "phemex"), ErrNotFound)
+	require.ErrorContains(t, k.Delete(ctx, 7, "binance"), "unknown exchange")
+
+	store.rows[[2]uint{7, 1}] = &model.UserExchange{UserID: 7, ExchangeID: 1}
+	require.NoError(t, k.Delete(ctx, 7, "phemex"))
+	require.Empty(t, store.rows)
+}
+
+func TestShell(t *testing.T) {
+	k, store := newTestKeys(acceptAll)
+	in := strings.NewReader(strings.Join([]string{
+		"set_key 7 phemex key secret 10",
+		"run_off 7 phemex",
+		"list 7",
+		"set_key x phemex key secret 10",
+		"bogus",
+		"shutdown",
+		"list",
+	}, "\n"))
+	var out bytes.Buffer
+
+	require.NoError(t, k.Shell(context.Background(), in, &out))
+
+	text := out.String()
+	require.Contains(t, text, "user 7 phemex: run_on_server=on order_size=10%")
+	require.Contains(t, text, "user 7 phemex: run_off")
+	require.Contains(t, text, "user 7 1: run_on_server=off")
+	require.Contains(t, text, `Error: invalid user id "x"`)
+	require.Contains(t, text, "Error: unknown command: bogus")
+	require.Contains(t, text, "Exiting CLI...")
+	require.NotContains(t, text, "secret")
+	require.False(t, store.rows[[2]uint{7, 1}].RunOnServer)
+}
@@ -1,160 +1,179 @@
 package keys
 
-//
-//func printUsage() {
-//	fmt.Println("Available commands:")
-//	fmt.Println("  help                             Show this help message")
-//	fmt.Println("  shutdown                         Exit the application")
-//	fmt.Println("  set_key                          Set exchange Keys for the application")
-//	fmt.Println("  run_on                           Turn on the application for the exchange (requires set_key)")
-//	fmt.Println("  run_off                          Turn off the application for the exchange (requires set_key)")
-//	fmt.Println()
-//}
-//
-//func main() {
-//	config := GetConfig()
-//
-//	ctx, stop := signal.NotifyContext(
-//		context.Background(),
-//		os.Interrupt,
-//		syscall.SIGTERM,
-//	)
-//
-//	if err := database.InitMainDB(); err != nil {
-//		logger.WithError(err).Fatal("Failed to connect to database")
-//	}
-//
-//	defer stop()
-//	userExchangeRep := repository.NewUserExchangeRepository()
-//
-//	reader := bufio.NewScanner(os.Stdin)
-//
-//	reader.Buffer(make([]byte, 0, 1024), 1024*1024)
-//
-//	for {
-//		fmt.Print("cmd> ")
-//
-//		if !reader.Scan() {
-//			continue
-//		}
-//
-//		line := strings.TrimSpace(reader.Text())
-//		if line == "" {
-//			continue
-//		}
-//
-//		parts := strings.Split(line, " ")
-//		cmd := parts[0]
-//
-//		switch cmd {
-//
-//		case "shutdown":
-//			fmt.Println("Exiting CLI...")
-//			return
-//
-//		case "help":
-//			printUsage()
-//
-//		case "set_key":
-//			if len(parts) < 3 {
-//				printUsage()
-//				continue
-//			}
-//			userID, key, secret, orderSizePercent := parts[1], parts[2], parts[3], parts[4]
-//
-//			encryptKey, err := security.EncryptString(key)
-//			if err != nil {
-//				logger.Error(err, "Failed to encrypt key")
-//				continue
-//			}
-//
-//			encryptSecret, err := security.EncryptString(secret)
-//			if err != nil {
-//				logger.Error(err, "Failed to encrypt secret")
-//				continue
-//			}
-//
-//			intOrderSizePercent, err := strconv.Atoi(orderSizePercent)
-//			if err != nil {
-//				logger.Error(err, "Failed to convert orderSizePercent to integer")
-//				continue
-//			}
-//
-//			f := &model.UserExchange{
-//				ExchangeID:       config.ExchangeID,
-//				//UserID:           userID,
-//				APIKeyHash:       encryptKey,
-//				APISecretHash:    encryptSecret,
-//				OrderSizePercent: intOrderSizePercent,
-//				RunOnServer:      config.RunOnServer,
-//			}
-//
-//			if err := userExchangeRep.Upsert(ctx, f); err != nil {
-//				logger.Error(err, "Failed to upsert user exchange")
-//			}
-//			//printUsage()
-//
-//		case "run_on":
-//			if len(parts) < 1 {
-//				printUsage()
-//				continue
-//
-//			}
-//			userID := parts[1]
-//
-//			userExchange, err := userExchangeRep.GetByUserAndExchange(ctx, userID, 1)
-//			if err != nil {
-//				logger.Error(err, "Failed to get user exchange")
-//				continue
-//			}
-//
-//			if userExchange == nil {
-//				fmt.Println("No key set for exchange 1")
-//				continue
-//			}
-//			if userExchange.APIKeyHash == "" || userExchange.APISecretHash == "" {
-//				fmt.Println("No key set for exchange 1")
-//				continue
-//			}
-//
-//			userExchange.RunOnServer = true
-//
-//			if err := userExchangeRep.Update(ctx, userExchange); err != nil {
-//				logger.Error(err, "Failed to upsert user exchange")
-//			}
-//			//printUsage()
-//
-//		case "run_off":
-//			if len(parts) < 1 {
-//				printUsage()
-//				continue
-//			}
-//			userID := parts[1]
-//
-//			userExchange, err := userExchangeRep.GetByUserAndExchange(ctx, userID, 1)
-//			if err != nil {
-//				logger.Error(err, "Failed to get user exchange")
-//				continue
-//			}
-//			if userExchange == nil {
-//				fmt.Println("No key set for exchange 1")
-//				continue
-//			}
-//			if userExchange.APIKeyHash == "" || userExchange.APISecretHash == "" {
-//				fmt.Println("No key set for exchange 1")
-//				continue
-//			}
-//
-//			userExchange.RunOnServer = false
-//
-//			if err := userExchangeRep.Update(ctx, userExchange); err != nil {
-//				logger.Error(err, "Failed to upsert user exchange")
-//			}
-//
-//		default:
-//			fmt.Println("Unknown command:", cmd)
-//			printUsage()
-//		}
-//	}
-//
-//}
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func printUsage(out io.Writer) {
+	fmt.Fprintln(out, "Available commands:")
+	fmt.Fprintln(out, "  help                                                  Show this help message")
+	fmt.Fprintln(out, "  shutdown                                              Exit the application")
+	fmt.Fprintln(out, "  set_key USER EXCHANGE KEY SECRET PERCENT [PASSPHRASE] Validate and store exchange keys")
+	fmt.Fprintln(out, "  run_on USER EXCHANGE                                  Turn on the application for the exchange (requires set_key)")
+	fmt.Fprintln(out, "  run_off USER EXCHANGE                                 Turn off the application for the exchange")
+	fmt.Fprintln(out, "  list [USER]                                           List stored keys, never their values")
+	fmt.Fprintln(out, "  delete USER EXCHANGE                                  Delete the keys of a user on an exchange")
+	fmt.Fprintln(out)
+}
+
+// PrintKeys writes one line per user exchange.
+func PrintKeys(out io.Writer, rows []model.UserExchange) {
+	if len(rows) == 0 {
+		fmt.Fprintln(out, "No keys stored.")
+		return
+	}
+	for _, ue := range rows {
+		exchange := strconv.FormatUint(uint64(ue.ExchangeID), 10)
+		if ue.Exchange != nil {
+			exchange = ue.Exchange.Name
+		}
+		source := "db"
+		if ue.SecretPath != "" {
+			source = ue.SecretBackend + ":" + ue.SecretPath
+		}
+		validated := "never"
+		if ue.LastValidatedAt != nil {
+			validated = ue.LastValidatedAt.Format(time.RFC3339)
+		}
+		runOn := "off"
+		if ue.RunOnServer {
+			runOn = "on"
+		}
+		fmt.Fprintf(out, "user %d %s: run_on_server=%s order_size=%d%% env=%s source=%s validated=%s failures=%d %s\n",
+			ue.UserID, exchange, runOn, ue.OrderSizePercent, ue.Environment, source, validated, ue.ValidationFailures, ue.LastValidationError)
+	}
+}
+
+func parseUser(s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid user id %q", s)
+	}
+	return uint(id), nil
+}
+
+// Shell runs the interactive keys prompt until shutdown or EOF.
+func (k *Keys) Shell(ctx context.Context, in io.Reader, out io.Writer) error {
+	reader := bufio.NewScanner(in)
+	reader.Buffer(make([]byte, 0, 1024), 1024*1024)
+
+	for {
+		fmt.Fprint(out, "keys> ")
+
+		if !reader.Scan() {
+			fmt.Fprintln(out)
+			return reader.Err()
+		}
+
+		parts := strings.Fields(reader.Text())
+		if len(parts) == 0 {
+			continue
+		}
+
+		if err := k.exec(ctx, out, parts); err != nil {
+			if errors.Is(err, errShutdown) {
+				fmt.Fprintln(out, "Exiting CLI...")
+				return nil
+			}
+			fmt.Fprintln(out, "Error:", err)
+		}
+	}
+}
+
+var errShutdown = errors.New("shutdown")
+
+func (k *Keys) exec(ctx context.Context, out io.Writer, parts []string) error {
+	switch cmd := parts[0]; cmd {
+
+	case "shutdown":
+		return errShutdown
+
+	case "help":
+		printUsage(out)
+
+	case "set_key":
+		if len(parts) < 6 {
+			printUsage(out)
+			return fmt.Errorf("usage: set_key USER EXCHANGE KEY SECRET PERCENT [PASSPHRASE]")
+		}
+		userID, err := parseUser(parts[1])
+		if err != nil {
+			return err
+		}
+		percent, err := strconv.Atoi(parts[5])
+		if err != nil {
+			return fmt.Errorf("invalid order size percent %q", parts[5])
+		}
+		req := SetKeyRequest{
+			UserID:           userID,
+			Exchange:         parts[2],
+			Credentials:      security.Credentials{APIKey: parts[3], APISecret: parts[4]},
+			OrderSizePercent: percent,
+		}
+		if len(parts) > 6 {
+			req.APIPassphrase = parts[6]
+		}
+		ue, err := k.SetKey(ctx, req)
+		if err != nil {
+			return err
+		}
+		PrintKeys(out, []model.UserExchange{*ue})
+
+	case "run_on", "run_off":
+		if len(parts) < 3 {
+			printUsage(out)
+			return fmt.Errorf("usage: %s USER EXCHANGE", cmd)
+		}
+		userID, err := parseUser(parts[1])
+		if err != nil {
+			return err
+		}
+		if err := k.SetRunOnServer(ctx, userID, parts[2], cmd == "run_on"); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "user %d %s: %s\n", userID, parts[2], cmd)
+
+	case "list":
+		var userID uint
+		if len(parts) > 1 {
+			var err error
+			if userID, err = parseUser(parts[1]); err != nil {
+				return err
+			}
+		}
+		rows, err := k.List(ctx, userID)
+		if err != nil {
+			return err
+		}
+		PrintKeys(out, rows)
+
+	case "delete":
+		if len(parts) < 3 {
+			printUsage(out)
+			return fmt.Errorf("usage: delete USER EXCHANGE")
+		}
+		userID, err := parseUser(parts[1])
+		if err != nil {
+			return err
+		}
+		if err := k.Delete(ctx, userID, parts[2]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "user %d %s: keys deleted\n", userID, parts[2])
+
+	default:
+		printUsage(out)
+		return fmt.Errorf("unknown command: %s", cmd)
+	}
+
+	return nil
+}
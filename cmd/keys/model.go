@@ -0,0 +1,59 @@
+package keys
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type userExchangeStore interface {
+	GetByUserAndExchange(ctx context.Context, userID uint, exchangeID uint) (*model.UserExchange, error)
+	Create(ctx context.Context, ue *model.UserExchange) error
+	Update(ctx context.Context, ue *model.UserExchange) error
+	List(ctx context.Context) ([]model.UserExchange, error)
+	Delete(ctx context.Context, userID uint, exchangeID uint) (bool, error)
+}
+
+type userLookup interface {
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+type exchangeLookup interface {
+	FindByID(ctx context.Context, id uint) (*model.Exchange, error)
+	FindByName(ctx context.Context, name string) (*model.Exchange, error)
+}
+
+// validateFunc calls a cheap authenticated endpoint of exchange with creds.
+// It may set the key version the exchange accepted on creds.
+type validateFunc func(ctx context.Context, exchange string, creds *security.Credentials) error
+
+// SetKeyRequest stores the credentials of a user on an exchange.
+type SetKeyRequest struct {
+	UserID   uint
+	Exchange string // name or numeric id
+	security.Credentials
+	// OrderSizePercent is required for new keys; 0 keeps the current value.
+	OrderSizePercent int
+	// SkipValidation stores the keys without calling the exchange.
+	SkipValidation bool
+	// Environment is "live" or "testnet"; empty keeps the current value,
+	// live for new keys unless the user is testnet-only.
+	Environment string
+	// RunOnServer sets run_on_server of the keys when not nil; new keys
+	// default to Config.RunOnServer, existing ones keep theirs.
+	RunOnServer *bool
+}
+
+type Keys struct {
+	Log    *logger.Entry
+	Config Config
+
+	userExchanges userExchangeStore
+	users         userLookup
+	exchanges     exchangeLookup
+	validate      validateFunc
+	now           func() time.Time
+}
@@ -0,0 +1,32 @@
+package liquidation_monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	BaseURL string `envconfig:"LIQUIDATION_MONITOR_BASE_URL" default:"https://api.phemex.com"`
+	// BufferPct is the distance to liquidation, in percent of the mark
+	// price, under which a position is acted on.
+	BufferPct float64 `envconfig:"LIQUIDATION_MONITOR_BUFFER_PCT" default:"5"`
+	// ReducePct is the share of the position, in percent, closed with a
+	// reduce-only market order when it is within the buffer. 0 only alerts.
+	ReducePct float64 `envconfig:"LIQUIDATION_MONITOR_REDUCE_PCT" default:"0"`
+	// MaintenanceMarginRate is used to compute the liquidation price of
+	// isolated positions the exchange reports none for.
+	MaintenanceMarginRate float64 `envconfig:"LIQUIDATION_MONITOR_MAINTENANCE_MARGIN_RATE" default:"0.005"`
+	// Lookback is how far back filled orders are read to find the entries
+	// a liquidation or auto-deleveraging may have closed.
+	Lookback time.Duration `envconfig:"LIQUIDATION_MONITOR_LOOKBACK" default:"720h"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,284 @@
+package liquidation_monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/report"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type accountKey struct {
+	userID     uint
+	exchangeID uint
+}
+
+type positionKey struct {
+	symbol  string
+	posSide string // Long or Short
+}
+
+// forcedClose is what the liquidation or auto-deleveraging fills of one
+// position add up to.
+type forcedClose struct {
+	Symbol  string
+	PosSide string
+	// RawPosSide is the posSide of the fills, Merged in one-way mode.
+	RawPosSide string
+	Kind       string // liquidation or auto-deleveraging
+	Qty        decimal.Decimal
+	// Price is the average fill price, weighted by quantity.
+	Price       decimal.Decimal
+	Fee         decimal.Decimal
+	RealizedPnl decimal.Decimal
+	At          time.Time
+}
+
+// openEntriesByAccount returns the open entries of orders keyed by account.
+func openEntriesByAccount(orders []model.Order) map[accountKey][]model.Order {
+	out := map[accountKey][]model.Order{}
+	for _, entry := range report.OpenEntries(orders) {
+		k := accountKey{entry.UserID, entry.ExchangeID}
+		out[k] = append(out[k], entry)
+	}
+	return out
+}
+
+// reconcileLiquidations looks for liquidation and auto-deleveraging fills
+// closing the open entries of the account. The entries of such a position
+// get a liquidated exit at the fill price, are themselves marked
+// liquidated, the protective orders left resting for the position are
+// cancelled and the user is notified. Without it the entries stay open
+// forever and keep being trailed and compared against the exchange.
+func (l *LiquidationMonitor) reconcileLiquidations(ctx context.Context, ue *model.UserExchange, client liquidationClient, open []model.Order, now time.Time) error {
+	var (
+		keys       []positionKey
+		byPosition = map[positionKey][]model.Order{}
+		fills      = map[string][]connectors.PhemexFill{}
+		firstErr   error
+	)
+	for _, entry := range open {
+		k := positionKey{entry.Symbol, positionSide(entry.PosSide, entry.Side)}
+		if _, ok := byPosition[k]; !ok {
+			keys = append(keys, k)
+		}
+		byPosition[k] = append(byPosition[k], entry)
+	}
+
+	for _, k := range keys {
+		symbolFills, ok := fills[k.symbol]
+		if !ok {
+			var err error
+			symbolFills, err = client.ListFills(k.symbol)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("ListFills %s: %w", k.symbol, err)
+				}
+				continue
+			}
+			fills[k.symbol] = symbolFills
+		}
+
+		entries := byPosition[k]
+		fc, ok := forcedCloseOf(symbolFills, k, entries[0].CreatedAt)
+		if !ok {
+			continue
+		}
+		if err := l.recordForcedClose(ctx, ue, entries, fc); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		cancelled, err := cancelProtective(client, fc)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		l.alertForcedClose(ctx, ue, fc, len(entries), cancelled, err, now)
+	}
+	return firstErr
+}
+
+// forcedCloseOf sums the liquidation and auto-deleveraging fills of the
+// position k made at or after since. ok is false when there is none.
+func forcedCloseOf(fills []connectors.PhemexFill, k positionKey, since time.Time) (fc forcedClose, ok bool) {
+	fc = forcedClose{Symbol: k.symbol, PosSide: k.posSide, Kind: "liquidation"}
+	notional := decimal.Zero
+	for _, f := range fills {
+		at := time.Unix(0, f.TransactTimeNs).UTC()
+		if !f.Forced() || f.Symbol != k.symbol || closedPositionSide(f) != k.posSide || at.Before(since) {
+			continue
+		}
+		qty := decimalOrZero(f.ExecQtyRq).Abs()
+		fc.Qty = fc.Qty.Add(qty)
+		notional = notional.Add(qty.Mul(decimalOrZero(f.ExecPriceRp)))
+		fc.Fee = fc.Fee.Add(decimalOrZero(f.ExecFeeRv))
+		fc.RealizedPnl = fc.RealizedPnl.Add(decimalOrZero(f.ClosedPnlRv))
+		if f.ExecType == "AdlTrade" {
+			fc.Kind = "auto-deleveraging"
+		}
+		fc.RawPosSide = f.PosSide
+		if at.After(fc.At) {
+			fc.At = at
+		}
+		ok = true
+	}
+	if fc.Qty.IsPositive() {
+		fc.Price = notional.Div(fc.Qty)
+	}
+	return fc, ok
+}
+
+// closedPositionSide is the side of the position a closing fill reduced;
+// one-way (Merged) fills selling close a Long.
+func closedPositionSide(f connectors.PhemexFill) string {
+	opened := "Sell"
+	if f.Side == "Sell" {
+		opened = "Buy"
+	}
+	return positionSide(f.PosSide, opened)
+}
+
+// recordForcedClose records a liquidated exit for each entry, at the average
+// fill price and with its share of the fees, then marks the entry
+// liquidated so it is no longer taken for an open position.
+func (l *LiquidationMonitor) recordForcedClose(ctx context.Context, ue *model.UserExchange, entries []model.Order, fc forcedClose) error {
+	total := decimal.Zero
+	for _, entry := range entries {
+		total = total.Add(entry.Quantity)
+	}
+
+	for _, entry := range entries {
+		fee := fc.Fee
+		if total.IsPositive() {
+			fee = fc.Fee.Mul(entry.Quantity).Div(total)
+		}
+		price := fc.Price
+		executedAt := fc.At
+		exit := &model.Order{
+			UserID:     ue.UserID,
+			ExchangeID: ue.ExchangeID,
+			ExternalID: entry.ExternalID,
+			Symbol:     entry.Symbol,
+			Side:       fc.PosSide,
+			PosSide:    closeSide(fc.PosSide),
+			OrderType:  "market",
+			Quantity:   entry.Quantity,
+			Price:      &price,
+			Fee:        fee.InexactFloat64(),
+			Status:     model.OrderExecutionStatusLiquidated,
+			OrderDir:   model.OrderDirectionExit,
+			ExecutedAt: &executedAt,
+		}
+		reason := fmt.Sprintf("position closed by %s at %s (realized pnl %s), recorded by the liquidation monitor for entry %d",
+			fc.Kind, fc.Price.StringFixed(4), fc.RealizedPnl.String(), entry.ID)
+		if err := l.orders.CreateWithAutoLogReason(ctx, exit, reason); err != nil {
+			return fmt.Errorf("record liquidated exit of order %d: %w", entry.ID, err)
+		}
+		if err := l.orders.UpdateStatusWithAutoLog(ctx, entry.ID, model.OrderExecutionStatusLiquidated, reason); err != nil {
+			return fmt.Errorf("mark order %d liquidated: %w", entry.ID, err)
+		}
+	}
+	return nil
+}
+
+// cancelProtective cancels the stop and take profit orders still resting
+// for the closed position and returns how many were cancelled. Orders gone
+// in the meantime are not an error.
+func cancelProtective(client liquidationClient, fc forcedClose) (int, error) {
+	active, err := client.ListActiveOrders(fc.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("ListActiveOrders %s: %w", fc.Symbol, err)
+	}
+
+	cancelled := 0
+	var firstErr error
+	for _, o := range active {
+		if !(o.IsStop() || o.IsTakeProfit()) || !o.Closes(fc.RawPosSide, fc.PosSide) {
+			continue
+		}
+		if _, err := client.CancelOrder(fc.Symbol, o.OrderID); err != nil {
+			if !errors.Is(err, connectors.ErrOrderNotFound) && firstErr == nil {
+				firstErr = fmt.Errorf("cancel %s order %s: %w", fc.Symbol, o.OrderID, err)
+			}
+			continue
+		}
+		cancelled++
+	}
+	return cancelled, firstErr
+}
+
+// alertForcedClose stores the forced close as a critical exception and
+// notifies the user.
+func (l *LiquidationMonitor) alertForcedClose(ctx context.Context, ue *model.UserExchange, fc forcedClose, entries, cancelled int, err error, now time.Time) {
+	message := fmt.Sprintf("%s %s position closed by %s at %s (realized pnl %s), %d protective order(s) cancelled",
+		fc.Symbol, fc.PosSide, fc.Kind, fc.Price.StringFixed(4), fc.RealizedPnl.String(), cancelled)
+	if err != nil {
+		message += ", " + err.Error()
+	}
+
+	log := l.Log.WithFields(map[string]interface{}{
+		"user_id":  ue.UserID,
+		"symbol":   fc.Symbol,
+		"pos_side": fc.PosSide,
+		"kind":     fc.Kind,
+	})
+	if err != nil {
+		log.WithError(err).Error("protective orders of a liquidated position left resting")
+	} else {
+		log.Warn(message)
+	}
+
+	exchange := ue.Exchange.Name
+	contextData, _ := json.Marshal(map[string]interface{}{
+		"user_id":      ue.UserID,
+		"symbol":       fc.Symbol,
+		"pos_side":     fc.PosSide,
+		"kind":         fc.Kind,
+		"quantity":     fc.Qty.String(),
+		"price":        fc.Price.String(),
+		"fee":          fc.Fee.String(),
+		"realized_pnl": fc.RealizedPnl.String(),
+		"entries":      entries,
+		"cancelled":    cancelled,
+		"executed_at":  fc.At,
+	})
+	exc := &model.Exception{
+		Service:   "liquidation_monitor",
+		Module:    "liquidation_monitor",
+		Method:    "reconcileLiquidations",
+		Message:   message,
+		Exchange:  exchange,
+		Level:     model.ExceptionSeverityCritical,
+		Severity:  model.ExceptionSeverityCritical,
+		Category:  model.ExceptionCategoryPosition,
+		Context:   string(contextData),
+		CreatedAt: now,
+	}
+	if createErr := l.exceptions.Create(ctx, exc); createErr != nil {
+		log.WithError(createErr).Error("failed to store liquidation exception")
+	}
+
+	username := ""
+	if u, lookupErr := l.users.GetUserByID(ctx, ue.UserID); lookupErr == nil && u != nil {
+		username = u.Username
+	}
+	l.notifier.Notify(ctx, notify.Event{
+		Type:       notify.EventCriticalError,
+		UserID:     ue.UserID,
+		Username:   username,
+		Exchange:   exchange,
+		Symbol:     fc.Symbol,
+		PosSide:    fc.PosSide,
+		Price:      fc.Price.InexactFloat64(),
+		Message:    "Position liquidated",
+		Err:        fmt.Errorf("%s", message),
+		OccurredAt: now,
+	})
+}
@@ -0,0 +1,302 @@
+package liquidation_monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func (l *LiquidationMonitor) Start() error {
+	l.Config = GetConfig()
+
+	l.userExchanges = repository.NewUserExchangeRepository()
+	l.orders = repository.NewOrderRepository()
+	l.exceptions = repository.NewExceptionRepository()
+	l.users = repository.NewUserRepository()
+	l.notifier = notify.NewNotifier()
+	l.newClient = func(creds security.Credentials) (liquidationClient, error) {
+		return connectors.NewClientFor(creds.APIKey, creds.APISecret, creds.Environment, l.Config.BaseURL)
+	}
+
+	return l.run(context.Background(), time.Now().UTC())
+}
+
+var hundred = decimal.NewFromInt(100)
+
+// exposure is an open position with its distance to liquidation.
+type exposure struct {
+	Symbol  string
+	PosSide string // Long or Short
+	// RawPosSide is the posSide of the exchange position, Merged in one-way
+	// mode, which reduce orders must carry.
+	RawPosSide string
+	Size       decimal.Decimal
+	Mark       decimal.Decimal
+	Liq        decimal.Decimal
+	// Computed tells the liquidation price was derived from the leverage
+	// because the exchange reported none.
+	Computed bool
+	// DistancePct is how far the mark price is from Liq, in percent of the
+	// mark price; negative once the mark price went past it.
+	DistancePct decimal.Decimal
+}
+
+// run first records the open entries of the server-run Phemex accounts
+// that the exchange closed by a liquidation or an auto-deleveraging (see
+// reconcileLiquidations), then checks the distance to liquidation of every
+// open position. Positions within Config.BufferPct are reduced by
+// Config.ReducePct when set, stored as a critical exception and notified to
+// the user. A failure on one account is logged and the remaining accounts
+// are still checked; the first error is returned at the end.
+func (l *LiquidationMonitor) run(ctx context.Context, now time.Time) error {
+	userExchanges, err := l.userExchanges.ListRunOnServer(ctx)
+	if err != nil {
+		return fmt.Errorf("ListRunOnServer: %w", err)
+	}
+	orders, err := l.orders.FindCreatedSince(ctx, now.Add(-l.Config.Lookback))
+	if err != nil {
+		return fmt.Errorf("FindCreatedSince: %w", err)
+	}
+	open := openEntriesByAccount(orders)
+
+	var firstErr error
+	for i := range userExchanges {
+		ue := &userExchanges[i]
+		log := l.Log.WithFields(map[string]interface{}{
+			"user_id":     ue.UserID,
+			"exchange_id": ue.ExchangeID,
+		})
+
+		// Only the Phemex connector reports mark and liquidation prices;
+		// shadow accounts have no exchange position at risk.
+		if ue.Exchange == nil || !strings.EqualFold(ue.Exchange.Name, "phemex") {
+			log.Debug("exchange not supported by liquidation monitor, skipping")
+			continue
+		}
+		if ue.Shadow {
+			log.Debug("shadow account, skipping liquidation monitor")
+			continue
+		}
+
+		if err := l.check(ctx, ue, open[accountKey{ue.UserID, ue.ExchangeID}], now); err != nil {
+			log.WithError(err).Error("liquidation monitor failed")
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (l *LiquidationMonitor) check(ctx context.Context, ue *model.UserExchange, open []model.Order, now time.Time) error {
+	creds, err := security.ResolveCredentials(ctx, ue)
+	if err != nil {
+		return err
+	}
+	client, err := l.newClient(creds)
+	if err != nil {
+		return err
+	}
+
+	firstErr := l.reconcileLiquidations(ctx, ue, client, open, now)
+
+	positions, err := client.GetPositionsUSDT()
+	if err != nil {
+		return fmt.Errorf("GetPositionsUSDT: %w", err)
+	}
+	if positions == nil {
+		return firstErr
+	}
+
+	buffer := decimal.NewFromFloat(l.Config.BufferPct)
+	mmr := decimal.NewFromFloat(l.Config.MaintenanceMarginRate)
+	for _, pos := range positions.Positions {
+		exp, ok := exposureOf(pos, mmr)
+		if !ok || exp.DistancePct.GreaterThanOrEqual(buffer) {
+			continue
+		}
+
+		reduced, err := l.reduce(client, exp)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		l.alert(ctx, ue, exp, reduced, err, now)
+	}
+	return firstErr
+}
+
+// reduce closes Config.ReducePct of the position with a reduce-only market
+// order and returns the quantity sent, zero when nothing was sent.
+func (l *LiquidationMonitor) reduce(client liquidationClient, exp exposure) (decimal.Decimal, error) {
+	if l.Config.ReducePct <= 0 {
+		return decimal.Zero, nil
+	}
+	// Same precision as the order controller, rounded down so the order
+	// never exceeds the share asked for.
+	qty := exp.Size.Mul(decimal.NewFromFloat(l.Config.ReducePct)).Div(hundred).Truncate(4)
+	if qty.IsZero() {
+		return decimal.Zero, fmt.Errorf("position of %s too small to reduce by %v%%", exp.Size, l.Config.ReducePct)
+	}
+	if _, err := client.PlaceOrder(exp.Symbol, closeSide(exp.PosSide), exp.RawPosSide, qty.String(), "Market", true); err != nil {
+		return decimal.Zero, fmt.Errorf("reduce %s %s by %s: %w", exp.Symbol, exp.PosSide, qty, err)
+	}
+	return qty, nil
+}
+
+// alert stores the position close to liquidation as a critical exception
+// and notifies the user.
+func (l *LiquidationMonitor) alert(ctx context.Context, ue *model.UserExchange, exp exposure, reduced decimal.Decimal, err error, now time.Time) {
+	message := fmt.Sprintf("%s %s position %s%% from liquidation (mark %s, liquidation %s)",
+		exp.Symbol, exp.PosSide, exp.DistancePct.StringFixed(2), exp.Mark, exp.Liq)
+	if !reduced.IsZero() {
+		message += fmt.Sprintf(", reduced by %s", reduced)
+	}
+	if err != nil {
+		message += ", " + err.Error()
+	}
+
+	log := l.Log.WithFields(map[string]interface{}{
+		"user_id":  ue.UserID,
+		"symbol":   exp.Symbol,
+		"pos_side": exp.PosSide,
+	})
+	if err != nil {
+		log.WithError(err).Error("position close to liquidation left as is")
+	} else {
+		log.Warn(message)
+	}
+
+	exchange := ue.Exchange.Name
+	contextData, _ := json.Marshal(map[string]interface{}{
+		"user_id":      ue.UserID,
+		"symbol":       exp.Symbol,
+		"pos_side":     exp.PosSide,
+		"size":         exp.Size.String(),
+		"mark_price":   exp.Mark.String(),
+		"liq_price":    exp.Liq.String(),
+		"liq_computed": exp.Computed,
+		"distance_pct": exp.DistancePct.StringFixed(4),
+		"reduced":      reduced.String(),
+	})
+	exc := &model.Exception{
+		Service:   "liquidation_monitor",
+		Module:    "liquidation_monitor",
+		Method:    "check",
+		Message:   message,
+		Exchange:  exchange,
+		Level:     model.ExceptionSeverityCritical,
+		Severity:  model.ExceptionSeverityCritical,
+		Category:  model.ExceptionCategoryPosition,
+		Context:   string(contextData),
+		CreatedAt: now,
+	}
+	if createErr := l.exceptions.Create(ctx, exc); createErr != nil {
+		log.WithError(createErr).Error("failed to store liquidation monitor exception")
+	}
+
+	username := ""
+	if u, lookupErr := l.users.GetUserByID(ctx, ue.UserID); lookupErr == nil && u != nil {
+		username = u.Username
+	}
+	l.notifier.Notify(ctx, notify.Event{
+		Type:       notify.EventCriticalError,
+		UserID:     ue.UserID,
+		Username:   username,
+		Exchange:   exchange,
+		Symbol:     exp.Symbol,
+		PosSide:    exp.PosSide,
+		Price:      exp.Mark.InexactFloat64(),
+		Message:    "Liquidation monitor",
+		Err:        fmt.Errorf("%s", message),
+		OccurredAt: now,
+	})
+}
+
+// exposureOf returns the distance to liquidation of pos. The liquidation
+// price reported by the exchange is used; isolated positions without one
+// get it computed from their leverage and mmr. Flat positions, and cross
+// margin ones without a reported price, are skipped.
+func exposureOf(pos connectors.GPosition, mmr decimal.Decimal) (exposure, bool) {
+	size := decimalOrZero(pos.SizeRq).Abs()
+	mark := decimalOrZero(pos.MarkPriceRp)
+	if size.IsZero() || !mark.IsPositive() {
+		return exposure{}, false
+	}
+
+	exp := exposure{
+		Symbol:     pos.Symbol,
+		PosSide:    positionSide(pos.PosSide, pos.Side),
+		RawPosSide: pos.PosSide,
+		Size:       size,
+		Mark:       mark,
+		Liq:        decimalOrZero(pos.LiquidationPriceRp),
+	}
+	if !exp.Liq.IsPositive() {
+		liq, ok := isolatedLiquidationPrice(exp.PosSide, decimalOrZero(pos.AvgEntryPriceRp), decimalOrZero(pos.LeverageRr), mmr)
+		if !ok {
+			return exposure{}, false
+		}
+		exp.Liq, exp.Computed = liq, true
+	}
+
+	distance := mark.Sub(exp.Liq)
+	if exp.PosSide == "Short" {
+		distance = exp.Liq.Sub(mark)
+	}
+	exp.DistancePct = distance.Div(mark).Mul(hundred)
+	return exp, true
+}
+
+// isolatedLiquidationPrice is the price at which the margin of an isolated
+// position of leverage is down to the maintenance margin mmr. A negative
+// leverage is cross margin, whose liquidation price depends on the whole
+// account.
+func isolatedLiquidationPrice(posSide string, entry, leverage, mmr decimal.Decimal) (decimal.Decimal, bool) {
+	if !entry.IsPositive() || !leverage.IsPositive() {
+		return decimal.Zero, false
+	}
+	move := decimal.NewFromInt(1).Div(leverage).Sub(mmr)
+	if posSide == "Short" {
+		return entry.Mul(decimal.NewFromInt(1).Add(move)), true
+	}
+	return entry.Mul(decimal.NewFromInt(1).Sub(move)), true
+}
+
+func decimalOrZero(s string) decimal.Decimal {
+	d, err := decimal.NewFromString(strings.TrimSpace(s))
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
+
+// positionSide is Long or Short; one-way (Merged) positions take it from
+// the side they were opened with.
+func positionSide(posSide, side string) string {
+	switch {
+	case strings.EqualFold(posSide, "Long"):
+		return "Long"
+	case strings.EqualFold(posSide, "Short"):
+		return "Short"
+	case strings.EqualFold(side, "Sell"):
+		return "Short"
+	default:
+		return "Long"
+	}
+}
+
+func closeSide(posSide string) string {
+	if posSide == "Short" {
+		return "Buy"
+	}
+	return "Sell"
+}
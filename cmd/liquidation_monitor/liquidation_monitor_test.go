@@ -0,0 +1,228 @@
+package liquidation_monitor
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type placedOrder struct {
+	symbol, side, posSide, qty string
+	reduce                     bool
+}
+
+type fakeClient struct {
+	positions *connectors.GAccountPositions
+	placed    []placedOrder
+	fills     []connectors.PhemexFill
+	active    []connectors.ActiveOrder
+	cancelled []string
+}
+
+func (c *fakeClient) GetPositionsUSDT() (*connectors.GAccountPositions, error) {
+	return c.positions, nil
+}
+
+func (c *fakeClient) PlaceOrder(symbol, side, posSide, qty, _ string, reduce bool) (*connectors.APIResponse, error) {
+	c.placed = append(c.placed, placedOrder{symbol, side, posSide, qty, reduce})
+	return &connectors.APIResponse{Code: 0}, nil
+}
+
+func (c *fakeClient) ListFills(string) ([]connectors.PhemexFill, error) {
+	return c.fills, nil
+}
+
+func (c *fakeClient) ListActiveOrders(string) ([]connectors.ActiveOrder, error) {
+	return c.active, nil
+}
+
+func (c *fakeClient) CancelOrder(_, orderID string) (*connectors.APIResponse, error) {
+	c.cancelled = append(c.cancelled, orderID)
+	return &connectors.APIResponse{Code: 0}, nil
+}
+
+type fakeOrders struct {
+	rows     []model.Order
+	created  []model.Order
+	statuses map[uint]string
+}
+
+func (f *fakeOrders) FindCreatedSince(context.Context, time.Time) ([]model.Order, error) {
+	return f.rows, nil
+}
+
+func (f *fakeOrders) CreateWithAutoLogReason(_ context.Context, order *model.Order, _ string) error {
+	f.created = append(f.created, *order)
+	return nil
+}
+
+func (f *fakeOrders) UpdateStatusWithAutoLog(_ context.Context, orderID uint, newStatus string, _ string) error {
+	if f.statuses == nil {
+		f.statuses = map[uint]string{}
+	}
+	f.statuses[orderID] = newStatus
+	return nil
+}
+
+type fakeUserExchanges struct{ rows []model.UserExchange }
+
+func (f *fakeUserExchanges) ListRunOnServer(context.Context) ([]model.UserExchange, error) {
+	return f.rows, nil
+}
+
+type fakeExceptions struct{ rows []model.Exception }
+
+func (f *fakeExceptions) Create(_ context.Context, exc *model.Exception) error {
+	f.rows = append(f.rows, *exc)
+	return nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) GetUserByID(_ context.Context, id uint) (*model.User, error) {
+	return &model.User{ID: id, Username: "alice"}, nil
+}
+
+type fakeNotifier struct{ events []notify.Event }
+
+func (f *fakeNotifier) Notify(_ context.Context, ev notify.Event) {
+	f.events = append(f.events, ev)
+}
+
+func newTestMonitor(t *testing.T, client *fakeClient, reducePct float64) (*LiquidationMonitor, *fakeExceptions, *fakeNotifier) {
+	t.Helper()
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	exceptions := &fakeExceptions{}
+	notifier := &fakeNotifier{}
+	l := &LiquidationMonitor{
+		Log:    logrus.WithField("cmd", "liquidation_monitor"),
+		Config: &Config{BufferPct: 5, ReducePct: reducePct, MaintenanceMarginRate: 0.005},
+		userExchanges: &fakeUserExchanges{rows: []model.UserExchange{
+			{UserID: 1, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 1, Name: "phemex"}},
+			{UserID: 2, ExchangeID: 2, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 2, Name: "kraken"}},
+			{UserID: 3, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Shadow: true, Exchange: &model.Exchange{ID: 1, Name: "phemex"}},
+		}},
+		orders:     &fakeOrders{},
+		exceptions: exceptions,
+		users:      fakeUsers{},
+		notifier:   notifier,
+		newClient:  func(security.Credentials) (liquidationClient, error) { return client, nil },
+	}
+	return l, exceptions, notifier
+}
+
+func testPositions() *connectors.GAccountPositions {
+	return &connectors.GAccountPositions{Positions: []connectors.GPosition{
+		// 3% above the reported liquidation price
+		{Symbol: "BTCUSDT", PosSide: "Long", SizeRq: "0.01", MarkPriceRp: "60000", LiquidationPriceRp: "58200"},
+		// far from it
+		{Symbol: "ETHUSDT", PosSide: "Short", SizeRq: "1", MarkPriceRp: "2000", LiquidationPriceRp: "2400"},
+		// 20x isolated short without a reported price: liquidation at 2000 * (1 + 0.05 - 0.005) = 2090, 2.9% away
+		{Symbol: "SOLUSDT", PosSide: "Short", SizeRq: "3", MarkPriceRp: "2031", AvgEntryPriceRp: "2000", LeverageRr: "20"},
+		// cross margin without a reported price is not computed
+		{Symbol: "XRPUSDT", PosSide: "Long", SizeRq: "100", MarkPriceRp: "0.5", AvgEntryPriceRp: "0.6", LeverageRr: "-10"},
+		{Symbol: "DOGEUSDT", PosSide: "Long", SizeRq: "0", MarkPriceRp: "0.1", LiquidationPriceRp: "0.099"},
+	}}
+}
+
+func TestRunAlertsWithinBuffer(t *testing.T) {
+	client := &fakeClient{positions: testPositions()}
+	l, exceptions, notifier := newTestMonitor(t, client, 0)
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, l.run(context.Background(), now))
+
+	require.Empty(t, client.placed, "no reduction configured")
+	require.Len(t, exceptions.rows, 2)
+	require.Equal(t, "BTCUSDT Long position 3.00% from liquidation (mark 60000, liquidation 58200)", exceptions.rows[0].Message)
+	require.Equal(t, model.ExceptionSeverityCritical, exceptions.rows[0].Severity)
+	require.Contains(t, exceptions.rows[1].Message, "SOLUSDT Short position 2.90% from liquidation (mark 2031, liquidation 2090)")
+	require.Contains(t, exceptions.rows[1].Context, `"liq_computed":true`)
+
+	require.Len(t, notifier.events, 2)
+	require.Equal(t, notify.EventCriticalError, notifier.events[0].Type)
+	require.Equal(t, "alice", notifier.events[0].Username)
+}
+
+func TestRunReducesWithinBuffer(t *testing.T) {
+	client := &fakeClient{positions: testPositions()}
+	l, exceptions, _ := newTestMonitor(t, client, 50)
+
+	require.NoError(t, l.run(context.Background(), time.Now()))
+
+	require.Equal(t, []placedOrder{
+		{"BTCUSDT", "Sell", "Long", "0.005", true},
+		{"SOLUSDT", "Buy", "Short", "1.5", true},
+	}, client.placed)
+	require.Len(t, exceptions.rows, 2)
+	require.Contains(t, exceptions.rows[0].Message, ", reduced by 0.005")
+}
+
+func TestRunRecordsLiquidatedEntries(t *testing.T) {
+	opened := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	liquidatedAt := opened.Add(time.Hour)
+	client := &fakeClient{
+		positions: &connectors.GAccountPositions{},
+		fills: []connectors.PhemexFill{
+			{Symbol: "BTCUSDT", Side: "Sell", PosSide: "Long", ExecType: "LiqTrade", ExecQtyRq: "0.02", ExecPriceRp: "58000", ExecFeeRv: "0.6", ClosedPnlRv: "-40", TransactTimeNs: liquidatedAt.UnixNano()},
+			{Symbol: "BTCUSDT", Side: "Sell", PosSide: "Long", ExecType: "LiqTrade", ExecQtyRq: "0.01", ExecPriceRp: "57700", ExecFeeRv: "0.3", ClosedPnlRv: "-23", TransactTimeNs: liquidatedAt.UnixNano()},
+			// a regular fill and a forced close of the other side are ignored
+			{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", ExecType: "Trade", ExecQtyRq: "0.03", ExecPriceRp: "60000", TransactTimeNs: opened.UnixNano()},
+			{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Short", ExecType: "AdlTrade", ExecQtyRq: "0.01", ExecPriceRp: "58000", TransactTimeNs: liquidatedAt.UnixNano()},
+		},
+		active: []connectors.ActiveOrder{
+			{OrderID: "sl", Symbol: "BTCUSDT", Side: "Sell", PosSide: "Long", OrdType: "Stop", CloseOnTrigger: true},
+			{OrderID: "tp", Symbol: "BTCUSDT", Side: "Sell", PosSide: "Long", OrdType: "LimitIfTouched", ReduceOnly: true},
+			{OrderID: "short-sl", Symbol: "BTCUSDT", Side: "Buy", PosSide: "Short", OrdType: "Stop", CloseOnTrigger: true},
+		},
+	}
+	l, exceptions, notifier := newTestMonitor(t, client, 0)
+	orders := &fakeOrders{rows: []model.Order{
+		{ID: 1, UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", Quantity: decimal.RequireFromString("0.02"), Status: model.OrderExecutionStatusFilled, OrderDir: model.OrderDirectionEntry, CreatedAt: opened},
+		{ID: 2, UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", Quantity: decimal.RequireFromString("0.01"), Status: model.OrderExecutionStatusFilled, OrderDir: model.OrderDirectionEntry, CreatedAt: opened.Add(time.Minute)},
+		// another account's entry is left alone
+		{ID: 3, UserID: 9, ExchangeID: 1, Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", Quantity: decimal.RequireFromString("1"), Status: model.OrderExecutionStatusFilled, OrderDir: model.OrderDirectionEntry, CreatedAt: opened},
+	}}
+	l.orders = orders
+	l.Config.Lookback = 24 * time.Hour
+
+	require.NoError(t, l.run(context.Background(), liquidatedAt.Add(time.Minute)))
+
+	require.Equal(t, map[uint]string{1: model.OrderExecutionStatusLiquidated, 2: model.OrderExecutionStatusLiquidated}, orders.statuses)
+	require.Len(t, orders.created, 2)
+	exit := orders.created[0]
+	require.Equal(t, model.OrderDirectionExit, exit.OrderDir)
+	require.Equal(t, model.OrderExecutionStatusLiquidated, exit.Status)
+	require.Equal(t, "0.02", exit.Quantity.String())
+	require.Equal(t, "57900", exit.Price.String(), "quantity weighted average of the liquidation fills")
+	require.InDelta(t, 0.6, exit.Fee, 1e-9, "fees split by entry quantity")
+	require.True(t, exit.ExecutedAt.Equal(liquidatedAt))
+
+	require.Equal(t, []string{"sl", "tp"}, client.cancelled)
+	require.Len(t, exceptions.rows, 1)
+	require.Equal(t, "BTCUSDT Long position closed by liquidation at 57900.0000 (realized pnl -63), 2 protective order(s) cancelled", exceptions.rows[0].Message)
+	require.Len(t, notifier.events, 1)
+	require.Equal(t, "Position liquidated", notifier.events[0].Message)
+}
+
+func TestIsolatedLiquidationPrice(t *testing.T) {
+	mmr := decimal.RequireFromString("0.005")
+	liq, ok := isolatedLiquidationPrice("Long", decimal.NewFromInt(60000), decimal.NewFromInt(10), mmr)
+	require.True(t, ok)
+	require.Equal(t, "54300", liq.String())
+
+	_, ok = isolatedLiquidationPrice("Long", decimal.NewFromInt(60000), decimal.NewFromInt(-10), mmr)
+	require.False(t, ok, "cross margin")
+}
@@ -0,0 +1,55 @@
+package liquidation_monitor
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// liquidationClient is the subset of the exchange connector used by the job.
+type liquidationClient interface {
+	GetPositionsUSDT() (*connectors.GAccountPositions, error)
+	PlaceOrder(symbol, side, posSide, qty, ordType string, reduce bool) (*connectors.APIResponse, error)
+	ListFills(symbol string) ([]connectors.PhemexFill, error)
+	ListActiveOrders(symbol string) ([]connectors.ActiveOrder, error)
+	CancelOrder(symbol, orderID string) (*connectors.APIResponse, error)
+}
+
+type userExchangeLister interface {
+	ListRunOnServer(ctx context.Context) ([]model.UserExchange, error)
+}
+
+type orderStore interface {
+	FindCreatedSince(ctx context.Context, since time.Time) ([]model.Order, error)
+	CreateWithAutoLogReason(ctx context.Context, order *model.Order, reason string) error
+	UpdateStatusWithAutoLog(ctx context.Context, orderID uint, newStatus string, reason string) error
+}
+
+type exceptionRepository interface {
+	Create(ctx context.Context, exc *model.Exception) error
+}
+
+type userLookup interface {
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+type notifier interface {
+	Notify(ctx context.Context, ev notify.Event)
+}
+
+type LiquidationMonitor struct {
+	Log    *logger.Entry
+	Config *Config
+
+	userExchanges userExchangeLister
+	orders        orderStore
+	exceptions    exceptionRepository
+	users         userLookup
+	notifier      notifier
+	newClient     func(creds security.Credentials) (liquidationClient, error)
+}
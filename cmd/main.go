@@ -1,37 +1,124 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strategyexecutor/cmd/backtest"
 	"strategyexecutor/cmd/executor"
+	"strategyexecutor/cmd/funding"
+	"strategyexecutor/cmd/key_health"
+	"strategyexecutor/cmd/keys"
+	"strategyexecutor/cmd/liquidation_monitor"
+	"strategyexecutor/cmd/maintenance"
 	"strategyexecutor/cmd/ohlcvcrypto"
+	"strategyexecutor/cmd/order_archive"
+	"strategyexecutor/cmd/orders"
+	"strategyexecutor/cmd/pnl_report"
+	"strategyexecutor/cmd/position_drift"
+	"strategyexecutor/cmd/position_snapshot"
+	"strategyexecutor/cmd/rebalance"
+	"strategyexecutor/cmd/scheduler"
+	"strategyexecutor/cmd/stop_watchdog"
+	"strategyexecutor/cmd/trade_journal"
+	"strategyexecutor/cmd/trailing_stops"
 	"strategyexecutor/cmd/tv_news"
+	"strategyexecutor/src/apiclient"
+	"strategyexecutor/src/auth"
 	"strategyexecutor/src/database"
+	"strategyexecutor/src/database/schema"
+	"strategyexecutor/src/export"
+	"strategyexecutor/src/featureflag"
+	"strategyexecutor/src/killswitch"
+	"strategyexecutor/src/logging"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/onboarding"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strategyexecutor/src/server"
+	"strategyexecutor/src/signalfilter"
+	"strategyexecutor/src/tracing"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	"golang.org/x/term"
 )
 
 var Version string
 
 func main() {
+	logging.Setup()
+
 	app := cli.NewApp()
-	app.Name = "Biidin CMD"
+	app.Name = "biidin"
 	app.Usage = "The Biidin command line interface"
 
 	app.Commands = []cli.Command{
+		serveCMD,
 		tvNewsCMD,
 		executorCMD,
 		ohlcvCryptoCMD,
+		fundingCMD,
+		pnlReportCMD,
+		positionSnapshotCMD,
+		positionDriftCMD,
+		stopWatchdogCMD,
+		trailingStopsCMD,
+		liquidationMonitorCMD,
+		tradeJournalCMD,
+		backtestCMD,
+		keyHealthCMD,
+		orderArchiveCMD,
+		schedulerCMD,
+		migrateCMD,
+		featureFlagsCMD,
+		emergencyStopCMD,
+		keysCMD,
+		ordersCMD,
+		rebalanceCMD,
+		scheduleCMD,
+		maintenanceCMD,
+		exportCMD,
+		usersCMD,
+		loginCMD,
+		logoutCMD,
+	}
+
+	shutdownTracing := tracing.Setup()
+	err := app.Run(os.Args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if flushErr := shutdownTracing(ctx); flushErr != nil {
+		logrus.WithError(flushErr).Warn("Failed to flush traces")
 	}
+	cancel()
 
-	if err := app.Run(os.Args); err != nil {
+	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
 var (
+	serveCMD = cli.Command{
+		Name:   "serve",
+		Usage:  "run the HTTP API server",
+		Action: serveAction,
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "port", EnvVar: "PORT", Usage: "port the API listens on (default 9898)"},
+			cli.StringFlag{Name: "db-main", EnvVar: "DATABASE_URL_MAIN", Usage: "main (read/write) database URL"},
+			cli.StringFlag{Name: "db-readonly", EnvVar: "DATABASE_URL_READONLY", Usage: "read-only database URL"},
+			cli.StringFlag{Name: "migration-mode", EnvVar: "DATABASE_MIGRATION_MODE", Usage: "auto or versioned"},
+		},
+		Description: `Run the HTTP API server CMD`,
+	}
 	tvNewsCMD = cli.Command{
 		Name:        "tvnews",
 		Usage:       "run TV NEWS",
@@ -56,6 +143,477 @@ var (
 		Flags:       []cli.Flag{},
 		Description: `Run OHLCV crypto CMD`,
 	}
+	fundingCMD = cli.Command{
+		Name:        "funding",
+		Usage:       "run funding rate / open interest collector",
+		Action:      fundingAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Run funding rate and open interest collector CMD`,
+	}
+
+	pnlReportCMD = cli.Command{
+		Name:        "pnl_report",
+		Usage:       "run daily PnL report",
+		Action:      pnlReportAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Compute and store the daily PnL report of every server-run account CMD`,
+	}
+
+	positionSnapshotCMD = cli.Command{
+		Name:        "position_snapshot",
+		Usage:       "run open position snapshot",
+		Action:      positionSnapshotAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Record the open positions, mark prices and margin of every server-run account and the equity of each user CMD`,
+	}
+
+	positionDriftCMD = cli.Command{
+		Name:        "position_drift",
+		Usage:       "run position drift check",
+		Action:      positionDriftAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Compare the positions expected from filled orders with the exchange positions of every server-run account, report and optionally correct drifts CMD`,
+	}
+
+	stopWatchdogCMD = cli.Command{
+		Name:        "stop_watchdog",
+		Usage:       "run stop-loss presence check",
+		Action:      stopWatchdogAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Check every open position of a managed symbol has an active stop, re-place missing stops from the stored level and alert CMD`,
+	}
+
+	liquidationMonitorCMD = cli.Command{
+		Name:        "liquidation_monitor",
+		Usage:       "run liquidation distance check",
+		Action:      liquidationMonitorAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Check the distance to liquidation of every open position, reduce and alert on the positions within the buffer CMD`,
+	}
+
+	tradeJournalCMD = cli.Command{
+		Name:        "trade_journal",
+		Usage:       "run trade journal builder",
+		Action:      tradeJournalAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Pair entry and exit orders into trades for performance review CMD`,
+	}
+
+	backtestCMD = cli.Command{
+		Name:        "backtest",
+		Usage:       "replay historical signals on a simulated exchange",
+		Action:      backtestAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Run the order controller against stored OHLCV and signals, writing orders into a sandbox schema CMD`,
+	}
+
+	keyHealthCMD = cli.Command{
+		Name:        "key_health",
+		Usage:       "run exchange credential health check",
+		Action:      keyHealthAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Validate the API keys of every server-run account and disable accounts whose keys keep failing CMD`,
+	}
+
+	orderArchiveCMD = cli.Command{
+		Name:        "order_archive",
+		Usage:       "archive old orders and their logs",
+		Action:      orderArchiveAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Move old orders and their logs into the order archive CMD`,
+	}
+
+	trailingStopsCMD = cli.Command{
+		Name:        "trailing_stops",
+		Usage:       "trail the stops of every open position on each closed candle",
+		Action:      trailingStopsAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Trail the stops of the open positions of every server-run account once per closed candle, reading the candles of each symbol once; set TRAILING_STOP_SERVICE on the executors so their controllers leave the stops alone CMD`,
+	}
+
+	schedulerCMD = cli.Command{
+		Name:        "scheduler",
+		Usage:       "run the periodic jobs on their schedules",
+		Action:      schedulerAction,
+		ArgsUsage:   "",
+		Flags:       []cli.Flag{},
+		Description: `Run the OHLCV collector, funding collector, position drift reconciler, PnL report, position snapshot, key health check and maintenance sync in one process, each enabled and scheduled by SCHEDULE_* and never overlapping itself CMD`,
+	}
+
+	migrateCMD = cli.Command{
+		Name:  "migrate",
+		Usage: "Manage the versioned database schema",
+		Subcommands: []cli.Command{
+			{
+				Name:        "up",
+				Usage:       "Apply pending schema and data migrations",
+				Action:      migrateUpAction,
+				Description: `Apply pending versioned SQL migrations, then data migrations CMD`,
+			},
+			{
+				Name:        "status",
+				Usage:       "Show the schema version and pending migrations",
+				Action:      migrateStatusAction,
+				Description: `Print the applied schema version and list pending SQL migrations CMD`,
+			},
+		},
+		Description: `Run versioned database migrations CMD`,
+	}
+
+	featureFlagUserFlag = cli.UintFlag{
+		Name:  "user",
+		Usage: "user id of the override, 0 for the global one",
+	}
+
+	featureFlagsCMD = cli.Command{
+		Name:  "flags",
+		Usage: "Manage feature flags gating risky behaviors",
+		Subcommands: []cli.Command{
+			{
+				Name:        "list",
+				Usage:       "List the known flags and their overrides",
+				Action:      featureFlagsListAction,
+				Description: `Print the default of every known flag and the stored overrides CMD`,
+			},
+			{
+				Name:        "set",
+				Usage:       "Turn a flag on or off",
+				ArgsUsage:   "FLAG on|off",
+				Action:      featureFlagsSetAction,
+				Flags:       []cli.Flag{featureFlagUserFlag, cli.StringFlag{Name: "note", Usage: "why the flag was flipped"}},
+				Description: `Store a global or per user override of a feature flag CMD`,
+			},
+			{
+				Name:        "unset",
+				Usage:       "Remove an override",
+				ArgsUsage:   "FLAG",
+				Action:      featureFlagsUnsetAction,
+				Flags:       []cli.Flag{featureFlagUserFlag},
+				Description: `Remove a global or per user override of a feature flag CMD`,
+			},
+		},
+		Description: `Flip feature flags without a deploy; running processes pick changes up within FEATURE_FLAG_CACHE_TTL CMD`,
+	}
+
+	emergencyStopTargetFlags = []cli.Flag{
+		cli.UintFlag{Name: "user", Usage: "user id to act on"},
+		cli.BoolFlag{Name: "all", Usage: "act on every user"},
+	}
+
+	emergencyStopCMD = cli.Command{
+		Name:  "emergency-stop",
+		Usage: "Halt trading and cancel working orders for incident response",
+		Subcommands: []cli.Command{
+			{
+				Name:   "stop",
+				Usage:  "Halt trading, cancel working orders and optionally flatten positions",
+				Action: emergencyStopAction,
+				Flags: append([]cli.Flag{
+					cli.BoolFlag{Name: "flatten", Usage: "also close every open position"},
+					cli.StringFlag{Name: "reason", Usage: "why trading is stopped"},
+				}, emergencyStopTargetFlags...),
+				Description: `Halt trading for a user (--user) or everyone (--all) until released, then cancel orders on every exchange account CMD`,
+			},
+			{
+				Name:        "release",
+				Usage:       "Re-enable trading after an emergency stop",
+				Action:      emergencyReleaseAction,
+				Flags:       emergencyStopTargetFlags,
+				Description: `Lift the halt stored for a user (--user) or the global one (--all) CMD`,
+			},
+		},
+		Description: `Emergency stop ("panic button") CMD`,
+	}
+
+	keysTargetFlags = []cli.Flag{
+		cli.UintFlag{Name: "user", Usage: "user id owning the keys"},
+		cli.StringFlag{Name: "exchange", Usage: "exchange name or id"},
+	}
+
+	keysCMD = cli.Command{
+		Name:  "keys",
+		Usage: "Manage the exchange API keys of users",
+		Subcommands: []cli.Command{
+			{
+				Name:   "set_key",
+				Usage:  "Validate and store exchange API keys",
+				Action: keysSetAction,
+				Flags: append([]cli.Flag{
+					cli.StringFlag{Name: "key", EnvVar: "KEYS_API_KEY", Usage: "API key"},
+					cli.StringFlag{Name: "secret", EnvVar: "KEYS_API_SECRET", Usage: "API secret"},
+					cli.StringFlag{Name: "passphrase", EnvVar: "KEYS_API_PASSPHRASE", Usage: "API passphrase (KuCoin)"},
+					cli.IntFlag{Name: "percent", Usage: "order size percent, required for new keys"},
+					cli.BoolFlag{Name: "skip-validation", Usage: "store the keys without calling the exchange"},
+					cli.StringFlag{Name: "environment", Usage: "live or testnet; empty keeps the current environment"},
+				}, keysTargetFlags...),
+				Description: `Check the keys against the exchange, encrypt and store them. Pass secrets through KEYS_API_* to keep them out of the shell history CMD`,
+			},
+			{
+				Name:        "run_on",
+				Usage:       "Turn on server-side execution for the exchange",
+				Action:      keysRunOnAction,
+				Flags:       keysTargetFlags,
+				Description: `Enable run_on_server for a user exchange with stored keys CMD`,
+			},
+			{
+				Name:        "run_off",
+				Usage:       "Turn off server-side execution for the exchange",
+				Action:      keysRunOffAction,
+				Flags:       keysTargetFlags,
+				Description: `Disable run_on_server for a user exchange CMD`,
+			},
+			{
+				Name:        "list",
+				Usage:       "List stored keys without their values",
+				Action:      keysListAction,
+				Flags:       []cli.Flag{cli.UintFlag{Name: "user", Usage: "only this user"}},
+				Description: `Print the user exchanges, their run_on_server flag and last validation CMD`,
+			},
+			{
+				Name:        "delete",
+				Usage:       "Delete the keys of a user on an exchange",
+				Action:      keysDeleteAction,
+				Flags:       keysTargetFlags,
+				Description: `Remove a user exchange and its encrypted keys CMD`,
+			},
+			{
+				Name:        "shell",
+				Usage:       "Start the interactive keys prompt",
+				Action:      keysShellAction,
+				Description: `Interactive set_key / run_on / run_off / list / delete CMD`,
+			},
+		},
+		Description: `Manage encrypted exchange API keys per user and exchange CMD`,
+	}
+
+	ordersCMD = cli.Command{
+		Name:  "orders",
+		Usage: "Inspect, retry and annotate orders",
+		Subcommands: []cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List orders by status",
+				Action: ordersListAction,
+				Flags: []cli.Flag{
+					cli.StringFlag{Name: "status", Value: model.OrderExecutionStatusError, Usage: "order status to list"},
+					cli.UintFlag{Name: "user", Usage: "only this user"},
+					cli.IntFlag{Name: "limit", Value: repository.DefaultPageLimit, Usage: "max orders to print"},
+				},
+				Description: `List orders with a status, newest first CMD`,
+			},
+			{
+				Name:        "show",
+				Usage:       "Show an order and its status log",
+				ArgsUsage:   "ID",
+				Action:      ordersShowAction,
+				Description: `Print an order with its status log CMD`,
+			},
+			{
+				Name:      "retry",
+				Usage:     "Re-dispatch the signal of a failed order",
+				ArgsUsage: "ID",
+				Action:    ordersRetryAction,
+				Flags: []cli.Flag{
+					cli.BoolFlag{Name: "execute", Usage: "send the signal to the exchange; without it the retry is a dry run"},
+				},
+				Description: `Re-run the exchange controller on the signal of an order in error status. Dry run unless --execute CMD`,
+			},
+			{
+				Name:      "note",
+				Usage:     "Attach a note to an order",
+				ArgsUsage: "ID TEXT",
+				Action:    ordersNoteAction,
+				Flags: []cli.Flag{
+					cli.UintFlag{Name: "author", Usage: "user id writing the note; the session user when logged in"},
+				},
+				Description: `Document a manual intervention next to the order it touched, shown in the trade journal CMD`,
+			},
+			{
+				Name:        "notes",
+				Usage:       "List the notes of an order",
+				ArgsUsage:   "ID",
+				Action:      ordersNotesAction,
+				Description: `Print the notes of an order, oldest first CMD`,
+			},
+		},
+		Description: `Manual recovery of failed orders CMD`,
+	}
+
+	rebalanceCMD = cli.Command{
+		Name:  "rebalance",
+		Usage: "Rebalance user assets across exchanges",
+		Subcommands: []cli.Command{
+			{
+				Name:   "plan",
+				Usage:  "Compare balances with the allocation targets",
+				Action: rebalancePlanAction,
+				Flags: []cli.Flag{
+					cli.UintFlag{Name: "user", Usage: "user id to rebalance"},
+					cli.BoolFlag{Name: "execute", Usage: "make the transfers; without it the rebalance is a dry run"},
+				},
+				Description: `Read the balances of every exchange with a target and propose the transfers that restore the targets. Dry run unless --execute CMD`,
+			},
+			{
+				Name:   "set_target",
+				Usage:  "Set the percent of an asset to hold on an exchange",
+				Action: rebalanceSetTargetAction,
+				Flags: []cli.Flag{
+					cli.UintFlag{Name: "user", Usage: "user id owning the target"},
+					cli.StringFlag{Name: "exchange", Usage: "exchange name or id"},
+					cli.StringFlag{Name: "asset", Value: "USDT", Usage: "asset, e.g. USDT"},
+					cli.Float64Flag{Name: "percent", Usage: "target percent of the asset; 0 removes the target"},
+				},
+				Description: `Store an allocation target. The targets of an asset must add up to 100 CMD`,
+			},
+			{
+				Name:        "targets",
+				Usage:       "List the allocation targets of a user",
+				Action:      rebalanceTargetsAction,
+				Flags:       []cli.Flag{cli.UintFlag{Name: "user", Usage: "user id"}},
+				Description: `Print the allocation targets of a user CMD`,
+			},
+		},
+		Description: `Per-user target allocation of assets across exchanges CMD`,
+	}
+
+	scheduleCMD = cli.Command{
+		Name:  "schedule",
+		Usage: "Manage the weekly trading-hours rules of users",
+		Subcommands: []cli.Command{
+			{
+				Name:   "add",
+				Usage:  "Block or reduce new entries during a weekly window",
+				Action: scheduleAddAction,
+				Flags: []cli.Flag{
+					cli.UintFlag{Name: "user", Usage: "user id owning the rule"},
+					cli.StringFlag{Name: "symbol", Usage: "symbol the rule applies to, all symbols when empty"},
+					cli.StringFlag{Name: "from", Usage: "start of the window, e.g. \"Fri 20:00\""},
+					cli.StringFlag{Name: "to", Usage: "end of the window, e.g. \"Sun 22:00\""},
+					cli.StringFlag{Name: "timezone", Value: "UTC", Usage: "IANA timezone of the window"},
+					cli.Float64Flag{Name: "size", Usage: "size multiplier inside the window, 0 blocks new entries"},
+					cli.StringFlag{Name: "note", Usage: "why the rule exists"},
+				},
+				Description: `Store a schedule rule enforced by the signal filter chain, e.g. schedule add --user 3 --from "Fri 20:00" --to "Sun 22:00" CMD`,
+			},
+			{
+				Name:        "list",
+				Usage:       "List the schedule rules of a user",
+				Action:      scheduleListAction,
+				Flags:       []cli.Flag{cli.UintFlag{Name: "user", Usage: "user id"}},
+				Description: `Print the schedule rules of a user CMD`,
+			},
+			{
+				Name:        "remove",
+				Usage:       "Remove a schedule rule",
+				ArgsUsage:   "RULE_ID",
+				Action:      scheduleRemoveAction,
+				Flags:       []cli.Flag{cli.UintFlag{Name: "user", Usage: "user id owning the rule"}},
+				Description: `Remove a schedule rule of a user CMD`,
+			},
+		},
+		Description: `Weekly windows, e.g. weekends, during which new entries are blocked or reduced CMD`,
+	}
+
+	maintenanceCMD = cli.Command{
+		Name:  "maintenance",
+		Usage: "Manage the maintenance windows of the exchanges",
+		Subcommands: []cli.Command{
+			{
+				Name:   "add",
+				Usage:  "Pause the executors of an exchange during a window",
+				Action: maintenanceAddAction,
+				Flags: []cli.Flag{
+					cli.StringFlag{Name: "exchange", Usage: "exchange name, e.g. phemex"},
+					cli.StringFlag{Name: "from", Usage: "start of the window, RFC 3339, e.g. 2025-03-07T06:00:00Z"},
+					cli.StringFlag{Name: "to", Usage: "end of the window, RFC 3339"},
+					cli.StringFlag{Name: "title", Usage: "what the maintenance is about"},
+				},
+				Description: `Store a maintenance window during which the executors of the exchange skip their ticks, e.g. maintenance add --exchange phemex --from 2025-03-07T06:00:00Z --to 2025-03-07T08:00:00Z CMD`,
+			},
+			{
+				Name:        "list",
+				Usage:       "List the current and upcoming maintenance windows",
+				Action:      maintenanceListAction,
+				Flags:       []cli.Flag{cli.StringFlag{Name: "exchange", Usage: "exchange name, every exchange when empty"}},
+				Description: `Print the maintenance windows not over yet CMD`,
+			},
+			{
+				Name:        "remove",
+				Usage:       "Remove a maintenance window",
+				ArgsUsage:   "WINDOW_ID",
+				Action:      maintenanceRemoveAction,
+				Description: `Remove a maintenance window, e.g. one cancelled by the exchange CMD`,
+			},
+			{
+				Name:        "sync",
+				Usage:       "Read the maintenances announced on the exchange status pages",
+				Action:      maintenanceSyncAction,
+				Description: `Store the maintenances of the status pages set in MAINTENANCE_STATUS_PAGES as maintenance windows CMD`,
+			},
+		},
+		Description: `Windows during which an exchange takes no orders and its executors pause CMD`,
+	}
+
+	loginCMD = cli.Command{
+		Name:   "login",
+		Usage:  "Open a session on the API server for the admin commands",
+		Action: loginAction,
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "server", EnvVar: "ADMIN_API_URL", Usage: "base URL of the API server, e.g. https://api.example.com"},
+			cli.StringFlag{Name: "user", Usage: "user name"},
+		},
+		Description: `Trade a password (ADMIN_PASSWORD or prompted) for a session token kept in ADMIN_SESSION_FILE. While logged in, keys set_key/run_on/run_off/delete, emergency-stop and orders retry go through the server instead of the database CMD`,
+	}
+	logoutCMD = cli.Command{
+		Name:        "logout",
+		Usage:       "Revoke and forget the session of login",
+		Action:      logoutAction,
+		Description: `Revoke the session token on the server and remove the session file CMD`,
+	}
+
+	usersCMD = cli.Command{
+		Name:  "users",
+		Usage: "Manage users",
+		Subcommands: []cli.Command{
+			{
+				Name:        "set_role",
+				Usage:       "Change the API role of a user",
+				ArgsUsage:   "admin|operator|viewer",
+				Action:      usersSetRoleAction,
+				Flags:       []cli.Flag{cli.UintFlag{Name: "user", Usage: "user id"}},
+				Description: `Admins toggle the kill switch and edit the accounts of other users, operators manage their own accounts, viewers only read them; e.g. users set_role --user 1 admin CMD`,
+			},
+		},
+		Description: `Manage users CMD`,
+	}
+
+	exportCMD = cli.Command{
+		Name:      "export",
+		Usage:     "Export the orders, closed trades or realized gains of a user as CSV or XLSX",
+		ArgsUsage: "orders|trades|gains",
+		Action:    exportAction,
+		Flags: []cli.Flag{
+			cli.UintFlag{Name: "user", Usage: "user to export"},
+			cli.StringFlag{Name: "from", Usage: "start of the range, RFC 3339, e.g. 2025-01-01T00:00:00Z; open when empty"},
+			cli.StringFlag{Name: "to", Usage: "end of the range, RFC 3339; open when empty"},
+			cli.StringFlag{Name: "format", Value: export.FormatCSV, Usage: "csv or xlsx"},
+			cli.StringFlag{Name: "out", Usage: "file to write, standard output when empty"},
+			cli.IntFlag{Name: "year", Usage: "tax year of the realized gains"},
+			cli.StringFlag{Name: "method", Value: report.LotsFIFO, Usage: "cost basis of the realized gains, fifo or average"},
+		},
+		Description: `Write the orders created or the trades closed in a date range with their fees, funding and PnL, e.g. export trades --user 3 --from 2025-01-01T00:00:00Z --to 2026-01-01T00:00:00Z --format xlsx --out trades-2025.xlsx, or the gains realized in a tax year matched into lots, e.g. export gains --user 3 --year 2025 --method average CMD`,
+	}
 )
 
 func tvNewsAction(_ *cli.Context) error {
@@ -73,6 +631,114 @@ func tvNewsAction(_ *cli.Context) error {
 	return nil
 }
 
+// serveFlagEnv maps the serve flags to the env config they override, so the
+// database and server packages keep reading a single source of settings.
+var serveFlagEnv = map[string]string{
+	"port":           "PORT",
+	"db-main":        "DATABASE_URL_MAIN",
+	"db-readonly":    "DATABASE_URL_READONLY",
+	"migration-mode": "DATABASE_MIGRATION_MODE",
+}
+
+// serveAction starts the HTTP API, e.g. serve --port 3010
+// onboardingKeys stores the keys of the onboarding flow with k, validated
+// against the exchange and off the server until the paper trade passed.
+func onboardingKeys(k *keys.Keys) onboarding.SetKeysFunc {
+	return func(ctx context.Context, userID uint, exchange string, creds security.Credentials, orderSizePercent int) (*model.UserExchange, error) {
+		off := false
+		return k.SetKey(ctx, keys.SetKeyRequest{
+			UserID:           userID,
+			Exchange:         exchange,
+			Credentials:      creds,
+			OrderSizePercent: orderSizePercent,
+			Environment:      creds.Environment,
+			RunOnServer:      &off,
+		})
+	}
+}
+
+// serverKeys runs the keys CLI for the admin routes of the server.
+type serverKeys struct{ k *keys.Keys }
+
+func (s serverKeys) SetKey(ctx context.Context, req server.KeyRequest) (*model.UserExchange, error) {
+	return s.k.SetKey(ctx, keys.SetKeyRequest{
+		UserID:           req.UserID,
+		Exchange:         req.Exchange,
+		Credentials:      req.Credentials,
+		OrderSizePercent: req.OrderSizePercent,
+		SkipValidation:   req.SkipValidation,
+		Environment:      req.Environment,
+	})
+}
+
+func (s serverKeys) SetRunOnServer(ctx context.Context, userID uint, exchange string, on bool) error {
+	return serverNotFound(s.k.SetRunOnServer(ctx, userID, exchange, on), keys.ErrNotFound)
+}
+
+func (s serverKeys) Delete(ctx context.Context, userID uint, exchange string) error {
+	return serverNotFound(s.k.Delete(ctx, userID, exchange), keys.ErrNotFound)
+}
+
+// serverOrders runs the orders retry CLI for the admin routes of the server.
+type serverOrders struct{ o *orders.Orders }
+
+func (s serverOrders) Retry(ctx context.Context, id uint, dryRun bool) (interface{}, error) {
+	plan, err := s.o.Retry(ctx, id, dryRun)
+	if err != nil {
+		return nil, serverNotFound(err, orders.ErrNotFound)
+	}
+	return plan, nil
+}
+
+// serverNotFound wraps err in server.ErrNotFound when it is notFound.
+func serverNotFound(err, notFound error) error {
+	if errors.Is(err, notFound) {
+		return fmt.Errorf("%w: %v", server.ErrNotFound, err)
+	}
+	return err
+}
+
+func serveAction(c *cli.Context) error {
+
+	logrus.Info("Starting API server CMD")
+	defer handlePanic()
+
+	for flag, env := range serveFlagEnv {
+		if c.IsSet(flag) {
+			if err := os.Setenv(env, c.String(flag)); err != nil {
+				return err
+			}
+		}
+	}
+	config := server.GetConfig()
+
+	// Initialize main (read/write) database
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	// Initialize read-only database
+	if err := database.InitReadOnlyDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	k := keys.New()
+	server.StartServer(config.Port, server.Options{
+		Onboarding: onboarding.New(onboardingKeys(k)),
+		Keys:       serverKeys{k},
+		Orders:     serverOrders{orders.New()},
+	})
+	return nil
+}
+
+func handlePanic() {
+	if r := recover(); r != nil {
+		logrus.WithError(fmt.Errorf("%+v", r)).Error("Application panic")
+	}
+	//nolint
+	time.Sleep(time.Second * 5)
+}
+
 func executorAction(_ *cli.Context) error {
 
 	logrus.Info("Starting executor CMD")
@@ -108,3 +774,1313 @@ func ohlcvCryptoAction(_ *cli.Context) error {
 
 	return nil
 }
+
+// fundingAction stores funding rate and open interest snapshots per symbol
+func fundingAction(_ *cli.Context) error {
+
+	logrus.Info("Starting funding collector CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	collector := &funding.FundingCollector{
+		Log: logrus.WithField("cmd", "funding"),
+	}
+
+	err := collector.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting funding cmd")
+		return err
+	}
+
+	return nil
+}
+
+// pnlReportAction stores the daily PnL summary per user exchange and notifies the user
+func pnlReportAction(_ *cli.Context) error {
+
+	logrus.Info("Starting pnl report CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	report := &pnl_report.PnLReport{
+		Log: logrus.WithField("cmd", "pnl_report"),
+	}
+
+	err := report.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting pnl_report cmd")
+		return err
+	}
+
+	return nil
+}
+
+// positionSnapshotAction records the open positions of every user exchange
+func positionSnapshotAction(_ *cli.Context) error {
+
+	logrus.Info("Starting position snapshot CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	snapshot := &position_snapshot.PositionSnapshot{
+		Log: logrus.WithField("cmd", "position_snapshot"),
+	}
+
+	err := snapshot.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting position_snapshot cmd")
+		return err
+	}
+
+	return nil
+}
+
+// positionDriftAction compares expected and exchange positions of every user exchange
+func positionDriftAction(_ *cli.Context) error {
+
+	logrus.Info("Starting position drift CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	drift := &position_drift.PositionDrift{
+		Log: logrus.WithField("cmd", "position_drift"),
+	}
+
+	err := drift.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting position_drift cmd")
+		return err
+	}
+
+	return nil
+}
+
+// stopWatchdogAction re-places the missing stops of every user exchange
+func stopWatchdogAction(_ *cli.Context) error {
+
+	logrus.Info("Starting stop watchdog CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	watchdog := &stop_watchdog.StopWatchdog{
+		Log: logrus.WithField("cmd", "stop_watchdog"),
+	}
+
+	err := watchdog.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting stop_watchdog cmd")
+		return err
+	}
+
+	return nil
+}
+
+// liquidationMonitorAction reduces and alerts on the positions close to
+// liquidation of every user exchange
+func liquidationMonitorAction(_ *cli.Context) error {
+
+	logrus.Info("Starting liquidation monitor CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	monitor := &liquidation_monitor.LiquidationMonitor{
+		Log: logrus.WithField("cmd", "liquidation_monitor"),
+	}
+
+	err := monitor.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting liquidation_monitor cmd")
+		return err
+	}
+
+	return nil
+}
+
+// tradeJournalAction rebuilds the trades table from recent orders
+func tradeJournalAction(_ *cli.Context) error {
+
+	logrus.Info("Starting trade journal CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	journal := &trade_journal.TradeJournal{
+		Log: logrus.WithField("cmd", "trade_journal"),
+	}
+
+	err := journal.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting trade_journal cmd")
+		return err
+	}
+
+	return nil
+}
+
+// backtestAction replays stored signals against OHLCV data and logs the summary
+func backtestAction(_ *cli.Context) error {
+
+	logrus.Info("Starting backtest CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	if err := database.InitReadOnlyDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	bt := &backtest.Backtest{
+		Log: logrus.WithField("cmd", "backtest"),
+	}
+
+	err := bt.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting backtest cmd")
+		return err
+	}
+
+	return nil
+}
+
+// keyHealthAction validates exchange credentials and disables dead accounts
+func keyHealthAction(_ *cli.Context) error {
+
+	logrus.Info("Starting key health CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	kh := &key_health.KeyHealth{
+		Log: logrus.WithField("cmd", "key_health"),
+	}
+
+	err := kh.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting key_health cmd")
+		return err
+	}
+
+	return nil
+}
+
+// schedulerAction runs the periodic jobs until stopped
+func schedulerAction(_ *cli.Context) error {
+
+	logrus.Info("Starting scheduler CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	s := &scheduler.Scheduler{
+		Log: logrus.WithField("cmd", "scheduler"),
+	}
+
+	err := s.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting scheduler cmd")
+		return err
+	}
+
+	return nil
+}
+
+// trailingStopsAction trails the stops of the open positions until stopped
+func trailingStopsAction(_ *cli.Context) error {
+
+	logrus.Info("Starting trailing stops CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	t := &trailing_stops.TrailingStops{
+		Log: logrus.WithField("cmd", "trailing_stops"),
+	}
+
+	err := t.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting trailing_stops cmd")
+		return err
+	}
+
+	return nil
+}
+
+// orderArchiveAction moves orders older than the retention window into the archive
+func orderArchiveAction(_ *cli.Context) error {
+
+	logrus.Info("Starting order archive CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	oa := &order_archive.OrderArchive{
+		Log: logrus.WithField("cmd", "order_archive"),
+	}
+
+	err := oa.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting order_archive cmd")
+		return err
+	}
+
+	return nil
+}
+
+// migrateUpAction applies pending migrations; run it before deploying a release
+func migrateUpAction(_ *cli.Context) error {
+
+	logrus.Info("Starting migrate up CMD")
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	applied, err := database.MigrateMainDB()
+	for _, m := range applied {
+		logrus.WithFields(logrus.Fields{"version": m.Version, "name": m.Name}).Info("Applied migration")
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running migrate up cmd")
+		return err
+	}
+
+	logrus.WithField("applied", len(applied)).Info("Schema is up to date")
+	return nil
+}
+
+// migrateStatusAction reports the schema version without changing anything
+func migrateStatusAction(_ *cli.Context) error {
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	runner, err := schema.NewRunner(database.MainDB)
+	if err != nil {
+		return err
+	}
+	version, err := runner.Version()
+	if err != nil {
+		return err
+	}
+	pending, err := runner.Pending()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("schema version: %d (latest %d)\n", version, runner.Latest())
+	for _, m := range pending {
+		fmt.Printf("pending: %04d_%s\n", m.Version, m.Name)
+	}
+	return nil
+}
+
+// featureFlagsListAction prints every known flag with its default and overrides
+func featureFlagsListAction(_ *cli.Context) error {
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	overrides, err := repository.NewFeatureFlagRepository().List(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range featureflag.Flags() {
+		fmt.Printf("%s: default %s\n", f, onOff(featureflag.Default(f)))
+	}
+	for _, o := range overrides {
+		scope := "global"
+		if o.UserID != 0 {
+			scope = fmt.Sprintf("user %d", o.UserID)
+		}
+		fmt.Printf("%s: %s for %s (updated %s) %s\n", o.Key, onOff(o.Enabled), scope, o.UpdatedAt.Format(time.RFC3339), o.Note)
+	}
+	return nil
+}
+
+// featureFlagsSetAction stores an override, e.g. flags set live_trading off --user 3
+func featureFlagsSetAction(c *cli.Context) error {
+
+	if c.NArg() != 2 {
+		return fmt.Errorf("usage: flags set FLAG on|off")
+	}
+	flag := featureflag.Flag(c.Args().Get(0))
+	if !featureflag.Known(flag) {
+		return fmt.Errorf("unknown feature flag %q", flag)
+	}
+	var enabled bool
+	switch c.Args().Get(1) {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		return fmt.Errorf("flag value must be on or off, got %q", c.Args().Get(1))
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	err := repository.NewFeatureFlagRepository().Set(context.Background(), &model.FeatureFlag{
+		Key:     string(flag),
+		UserID:  c.Uint("user"),
+		Enabled: enabled,
+		Note:    c.String("note"),
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Running flags set cmd")
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{"flag": flag, "user_id": c.Uint("user"), "enabled": enabled}).Info("Feature flag set")
+	return nil
+}
+
+// featureFlagsUnsetAction removes an override, restoring the global value or the default
+func featureFlagsUnsetAction(c *cli.Context) error {
+
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: flags unset FLAG")
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	removed, err := repository.NewFeatureFlagRepository().Unset(context.Background(), c.Args().Get(0), c.Uint("user"))
+	if err != nil {
+		logrus.WithError(err).Error("Running flags unset cmd")
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{"flag": c.Args().Get(0), "user_id": c.Uint("user"), "removed": removed}).Info("Feature flag unset")
+	return nil
+}
+
+// emergencyStopUser reads --user / --all; one of them is required so that a
+// bare command never stops everyone by accident.
+func emergencyStopUser(c *cli.Context) (uint, error) {
+	userID, all := c.Uint("user"), c.Bool("all")
+	if (userID == 0) == !all {
+		return 0, fmt.Errorf("exactly one of --user or --all is required")
+	}
+	return userID, nil
+}
+
+// emergencyStopAction halts trading and cancels orders; it exits non zero
+// when an account could not be stopped so scripts notice
+func emergencyStopAction(c *cli.Context) error {
+
+	userID, err := emergencyStopUser(c)
+	if err != nil {
+		return err
+	}
+
+	logrus.Warn("Starting emergency stop CMD")
+	client, err := adminClient()
+	if err != nil {
+		return err
+	}
+
+	var res *killswitch.Result
+	if client != nil {
+		res, err = client.EmergencyStop(context.Background(), userID, c.Bool("flatten"), c.String("reason"))
+	} else {
+		if err := database.OpenMainDB(); err != nil {
+			logrus.WithError(err).Fatal("Failed to connect to database")
+		}
+		res, err = killswitch.New().Stop(context.Background(), killswitch.Request{
+			UserID:  userID,
+			Flatten: c.Bool("flatten"),
+			Reason:  c.String("reason"),
+		})
+	}
+	if res != nil {
+		for _, a := range res.Accounts {
+			fmt.Printf("user %d %s: orders cancelled=%t positions closed=%t %s\n", a.UserID, a.Exchange, a.OrdersCancelled, a.PositionsClosed, a.Error)
+		}
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running emergency stop cmd")
+		return err
+	}
+	if res.Failed() {
+		return fmt.Errorf("emergency stop incomplete, trading is halted but some accounts need manual action")
+	}
+
+	logrus.WithField("accounts", len(res.Accounts)).Warn("Emergency stop done, trading halted until released")
+	return nil
+}
+
+// emergencyReleaseAction lifts a halt stored by emergency-stop stop
+func emergencyReleaseAction(c *cli.Context) error {
+
+	userID, err := emergencyStopUser(c)
+	if err != nil {
+		return err
+	}
+
+	client, err := adminClient()
+	if err != nil {
+		return err
+	}
+
+	var released bool
+	if client != nil {
+		released, err = client.EmergencyRelease(context.Background(), userID)
+	} else {
+		if err := database.OpenMainDB(); err != nil {
+			logrus.WithError(err).Fatal("Failed to connect to database")
+		}
+		released, err = killswitch.New().Release(context.Background(), userID)
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running emergency stop release cmd")
+		return err
+	}
+	if !released {
+		logrus.WithField("user_id", userID).Warn("No emergency stop stored for this target")
+	}
+	return nil
+}
+
+// loginAction opens a session, e.g. login --server https://api.example.com --user alice
+func loginAction(c *cli.Context) error {
+
+	if c.String("server") == "" || c.String("user") == "" {
+		return fmt.Errorf("--server and --user are required")
+	}
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		_, _ = fmt.Fprint(os.Stderr, "Password: ")
+		line, err := term.ReadPassword(int(os.Stdin.Fd()))
+		_, _ = fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return err
+		}
+		password = string(line)
+	}
+
+	config := apiclient.GetConfig()
+	path, err := apiclient.SessionPath(config.SessionFile)
+	if err != nil {
+		return err
+	}
+	session, err := apiclient.New(c.String("server"), "", config.Timeout).Login(context.Background(), c.String("user"), password)
+	if err != nil {
+		return err
+	}
+	if err := apiclient.SaveSession(path, session); err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{"user_id": session.UserID, "role": session.Role, "expires_at": session.ExpiresAt}).Info("Logged in")
+	return nil
+}
+
+func logoutAction(_ *cli.Context) error {
+
+	config := apiclient.GetConfig()
+	path, err := apiclient.SessionPath(config.SessionFile)
+	if err != nil {
+		return err
+	}
+	session, err := apiclient.LoadSession(path)
+	if err != nil || session == nil {
+		return err
+	}
+	if !session.Expired(time.Now()) {
+		if err := apiclient.New(session.Server, session.Token, config.Timeout).Logout(context.Background()); err != nil {
+			logrus.WithError(err).Warn("Failed to revoke the session on the server")
+		}
+	}
+	return apiclient.RemoveSession(path)
+}
+
+// adminClient returns a client of the server the admin commands go through
+// while logged in, nil without a session.
+func adminClient() (*apiclient.Client, error) {
+	config := apiclient.GetConfig()
+	path, err := apiclient.SessionPath(config.SessionFile)
+	if err != nil {
+		return nil, err
+	}
+	session, err := apiclient.LoadSession(path)
+	if err != nil || session == nil {
+		return nil, err
+	}
+	if session.Expired(time.Now()) {
+		return nil, fmt.Errorf("session on %s expired, run login again or logout to use the database", session.Server)
+	}
+	return apiclient.New(session.Server, session.Token, config.Timeout), nil
+}
+
+// usersSetRoleAction changes the role of a user, e.g. to name the first admin.
+func usersSetRoleAction(c *cli.Context) error {
+
+	role := c.Args().First()
+	switch role {
+	case model.RoleAdmin, model.RoleOperator, model.RoleViewer:
+	default:
+		return fmt.Errorf("usage: users set_role --user ID admin|operator|viewer")
+	}
+	if c.Uint("user") == 0 {
+		return fmt.Errorf("--user is required")
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	found, err := repository.NewUserRepository().SetRole(context.Background(), c.Uint("user"), role)
+	if err != nil {
+		logrus.WithError(err).Error("Running users set_role cmd")
+		return err
+	}
+	if !found {
+		return fmt.Errorf("user %d not found", c.Uint("user"))
+	}
+	logrus.WithFields(logrus.Fields{"user_id": c.Uint("user"), "role": role}).Info("User role changed")
+	return nil
+}
+
+// keysTarget reads the required --user and --exchange flags.
+func keysTarget(c *cli.Context) (uint, string, error) {
+	if c.Uint("user") == 0 || c.String("exchange") == "" {
+		return 0, "", fmt.Errorf("--user and --exchange are required")
+	}
+	return c.Uint("user"), c.String("exchange"), nil
+}
+
+// keysSetAction validates and stores keys, e.g. keys set_key --user 3 --exchange phemex --percent 10
+func keysSetAction(c *cli.Context) error {
+
+	userID, exchange, err := keysTarget(c)
+	if err != nil {
+		return err
+	}
+
+	creds := security.Credentials{
+		APIKey:        c.String("key"),
+		APISecret:     c.String("secret"),
+		APIPassphrase: c.String("passphrase"),
+	}
+	client, err := adminClient()
+	if err != nil {
+		return err
+	}
+
+	var ue *model.UserExchange
+	if client != nil {
+		ue, err = client.SetKey(context.Background(), userID, exchange, apiclient.KeyRequest{
+			Credentials:      creds,
+			OrderSizePercent: c.Int("percent"),
+			SkipValidation:   c.Bool("skip-validation"),
+			Environment:      c.String("environment"),
+		})
+	} else {
+		if err := database.OpenMainDB(); err != nil {
+			logrus.WithError(err).Fatal("Failed to connect to database")
+		}
+		ue, err = keys.New().SetKey(context.Background(), keys.SetKeyRequest{
+			UserID:           userID,
+			Exchange:         exchange,
+			Credentials:      creds,
+			OrderSizePercent: c.Int("percent"),
+			SkipValidation:   c.Bool("skip-validation"),
+			Environment:      c.String("environment"),
+		})
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running keys set_key cmd")
+		return err
+	}
+
+	keys.PrintKeys(os.Stdout, []model.UserExchange{*ue})
+	return nil
+}
+
+func keysRunOnAction(c *cli.Context) error {
+	return keysRunOnServer(c, true)
+}
+
+func keysRunOffAction(c *cli.Context) error {
+	return keysRunOnServer(c, false)
+}
+
+func keysRunOnServer(c *cli.Context, on bool) error {
+
+	userID, exchange, err := keysTarget(c)
+	if err != nil {
+		return err
+	}
+
+	client, err := adminClient()
+	if err != nil {
+		return err
+	}
+
+	if client != nil {
+		err = client.SetRunOnServer(context.Background(), userID, exchange, on)
+	} else {
+		if err := database.OpenMainDB(); err != nil {
+			logrus.WithError(err).Fatal("Failed to connect to database")
+		}
+		err = keys.New().SetRunOnServer(context.Background(), userID, exchange, on)
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running keys run_on/run_off cmd")
+		return err
+	}
+	return nil
+}
+
+// keysListAction prints the stored user exchanges, never the key values
+func keysListAction(c *cli.Context) error {
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	rows, err := keys.New().List(context.Background(), c.Uint("user"))
+	if err != nil {
+		logrus.WithError(err).Error("Running keys list cmd")
+		return err
+	}
+
+	keys.PrintKeys(os.Stdout, rows)
+	return nil
+}
+
+func keysDeleteAction(c *cli.Context) error {
+
+	userID, exchange, err := keysTarget(c)
+	if err != nil {
+		return err
+	}
+
+	client, err := adminClient()
+	if err != nil {
+		return err
+	}
+
+	if client != nil {
+		err = client.DeleteKey(context.Background(), userID, exchange)
+	} else {
+		if err := database.OpenMainDB(); err != nil {
+			logrus.WithError(err).Fatal("Failed to connect to database")
+		}
+		err = keys.New().Delete(context.Background(), userID, exchange)
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running keys delete cmd")
+		return err
+	}
+	return nil
+}
+
+func keysShellAction(_ *cli.Context) error {
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	return keys.New().Shell(context.Background(), os.Stdin, os.Stdout)
+}
+
+// orderIDArg reads the order id given as the first argument.
+func orderIDArg(c *cli.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Args().First(), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("order ID is required, e.g. orders %s 42", c.Command.Name)
+	}
+	return uint(id), nil
+}
+
+// ordersListAction prints orders by status, e.g. orders list --status=error
+func ordersListAction(c *cli.Context) error {
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	ctx := context.Background()
+	if userID := c.Uint("user"); userID != 0 {
+		ctx = auth.WithUserID(ctx, userID)
+	}
+
+	rows, err := orders.New().List(ctx, c.String("status"), repository.Pagination{Limit: c.Int("limit")})
+	if err != nil {
+		logrus.WithError(err).Error("Running orders list cmd")
+		return err
+	}
+
+	orders.PrintOrders(os.Stdout, rows)
+	return nil
+}
+
+func ordersShowAction(c *cli.Context) error {
+
+	id, err := orderIDArg(c)
+	if err != nil {
+		return err
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	order, err := orders.New().Show(context.Background(), id)
+	if err != nil {
+		logrus.WithError(err).Error("Running orders show cmd")
+		return err
+	}
+
+	orders.PrintOrder(os.Stdout, order)
+
+	notes, err := orders.New().Notes(context.Background(), id)
+	if err != nil {
+		logrus.WithError(err).Error("Running orders show cmd")
+		return err
+	}
+	if len(notes) > 0 {
+		orders.PrintNotes(os.Stdout, notes)
+	}
+	return nil
+}
+
+// ordersNoteAction attaches a note to an order, e.g. orders note 42 "closed by hand" --author 1
+func ordersNoteAction(c *cli.Context) error {
+
+	id, err := orderIDArg(c)
+	if err != nil {
+		return err
+	}
+	body := strings.Join(c.Args().Tail(), " ")
+
+	client, err := adminClient()
+	if err != nil {
+		return err
+	}
+
+	var note *model.OrderNote
+	if client != nil {
+		note, err = client.AddOrderNote(context.Background(), id, body)
+	} else {
+		if err := database.OpenMainDB(); err != nil {
+			logrus.WithError(err).Fatal("Failed to connect to database")
+		}
+		note, err = orders.New().AddNote(context.Background(), id, c.Uint("author"), body)
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running orders note cmd")
+		return err
+	}
+
+	orders.PrintNotes(os.Stdout, []model.OrderNote{*note})
+	return nil
+}
+
+// ordersNotesAction prints the notes of an order, e.g. orders notes 42
+func ordersNotesAction(c *cli.Context) error {
+
+	id, err := orderIDArg(c)
+	if err != nil {
+		return err
+	}
+
+	client, err := adminClient()
+	if err != nil {
+		return err
+	}
+
+	var notes []model.OrderNote
+	if client != nil {
+		notes, err = client.OrderNotes(context.Background(), id)
+	} else {
+		if err := database.OpenMainDB(); err != nil {
+			logrus.WithError(err).Fatal("Failed to connect to database")
+		}
+		notes, err = orders.New().Notes(context.Background(), id)
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running orders notes cmd")
+		return err
+	}
+
+	orders.PrintNotes(os.Stdout, notes)
+	return nil
+}
+
+// ordersRetryAction re-dispatches the signal of a failed order, e.g. orders retry 42 --execute
+func ordersRetryAction(c *cli.Context) error {
+
+	id, err := orderIDArg(c)
+	if err != nil {
+		return err
+	}
+
+	client, err := adminClient()
+	if err != nil {
+		return err
+	}
+
+	var plan *orders.RetryPlan
+	if client != nil {
+		plan = &orders.RetryPlan{}
+		err = client.RetryOrder(context.Background(), id, c.Bool("execute"), plan)
+	} else {
+		if err := database.OpenMainDB(); err != nil {
+			logrus.WithError(err).Fatal("Failed to connect to database")
+		}
+
+		// Trading signals are read from the read-only database.
+		if err := database.InitReadOnlyDB(); err != nil {
+			logrus.WithError(err).Fatal("Failed to connect to database")
+		}
+
+		plan, err = orders.New().Retry(context.Background(), id, !c.Bool("execute"))
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running orders retry cmd")
+		return err
+	}
+
+	orders.PrintPlan(os.Stdout, plan)
+	return nil
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// rebalanceUser reads the required --user flag.
+func rebalanceUser(c *cli.Context) (uint, error) {
+	if c.Uint("user") == 0 {
+		return 0, fmt.Errorf("--user is required")
+	}
+	return c.Uint("user"), nil
+}
+
+// rebalancePlanAction reports the rebalance of a user, e.g. rebalance plan --user 3
+func rebalancePlanAction(c *cli.Context) error {
+
+	userID, err := rebalanceUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	report, err := rebalance.New().Run(context.Background(), userID, !c.Bool("execute"))
+	if report != nil {
+		rebalance.PrintReport(os.Stdout, report)
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running rebalance plan cmd")
+		return err
+	}
+	return nil
+}
+
+// rebalanceSetTargetAction stores a target, e.g. rebalance set_target --user 3 --exchange phemex --asset USDT --percent 60
+func rebalanceSetTargetAction(c *cli.Context) error {
+
+	userID, err := rebalanceUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	r := rebalance.New()
+	ctx := context.Background()
+	if err := r.SetTarget(ctx, userID, c.String("exchange"), c.String("asset"), c.Float64("percent")); err != nil {
+		logrus.WithError(err).Error("Running rebalance set_target cmd")
+		return err
+	}
+
+	rows, err := r.Targets(ctx, userID)
+	if err != nil {
+		return err
+	}
+	rebalance.PrintTargets(os.Stdout, rows)
+	return nil
+}
+
+func rebalanceTargetsAction(c *cli.Context) error {
+
+	userID, err := rebalanceUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	rows, err := rebalance.New().Targets(context.Background(), userID)
+	if err != nil {
+		logrus.WithError(err).Error("Running rebalance targets cmd")
+		return err
+	}
+	rebalance.PrintTargets(os.Stdout, rows)
+	return nil
+}
+
+// scheduleAddAction stores a rule, e.g. schedule add --user 3 --from "Sun 22:00" --to "Mon 02:00" --size 0.5
+func scheduleAddAction(c *cli.Context) error {
+
+	userID, err := rebalanceUser(c)
+	if err != nil {
+		return err
+	}
+	startDay, startMinute, err := signalfilter.ParseWeekTime(c.String("from"))
+	if err != nil {
+		return fmt.Errorf("--from: %w", err)
+	}
+	endDay, endMinute, err := signalfilter.ParseWeekTime(c.String("to"))
+	if err != nil {
+		return fmt.Errorf("--to: %w", err)
+	}
+	if startDay == endDay && startMinute == endMinute {
+		return fmt.Errorf("--from and --to must differ")
+	}
+	if _, err := time.LoadLocation(c.String("timezone")); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", c.String("timezone"), err)
+	}
+	size := c.Float64("size")
+	if size < 0 || size >= 1 {
+		return fmt.Errorf("--size must be in [0, 1), got %v", size)
+	}
+	action := model.ScheduleActionBlock
+	if size > 0 {
+		action = model.ScheduleActionReduce
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	rule := &model.ScheduleRule{
+		UserID:         userID,
+		Symbol:         c.String("symbol"),
+		StartDay:       int(startDay),
+		StartMinute:    startMinute,
+		EndDay:         int(endDay),
+		EndMinute:      endMinute,
+		Timezone:       c.String("timezone"),
+		Action:         action,
+		SizeMultiplier: decimal.NewFromFloat(size),
+		Note:           c.String("note"),
+	}
+	if err := repository.NewScheduleRuleRepository().Create(context.Background(), rule); err != nil {
+		logrus.WithError(err).Error("Running schedule add cmd")
+		return err
+	}
+	printScheduleRule(*rule)
+	return nil
+}
+
+func scheduleListAction(c *cli.Context) error {
+
+	userID, err := rebalanceUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	rules, err := repository.NewScheduleRuleRepository().ListByUser(context.Background(), userID)
+	if err != nil {
+		logrus.WithError(err).Error("Running schedule list cmd")
+		return err
+	}
+	for _, rule := range rules {
+		printScheduleRule(rule)
+	}
+	return nil
+}
+
+// scheduleRemoveAction removes a rule, e.g. schedule remove 7 --user 3
+func scheduleRemoveAction(c *cli.Context) error {
+
+	userID, err := rebalanceUser(c)
+	if err != nil {
+		return err
+	}
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: schedule remove RULE_ID --user USER_ID")
+	}
+	id, err := strconv.ParseUint(c.Args().Get(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid rule id %q", c.Args().Get(0))
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	removed, err := repository.NewScheduleRuleRepository().Delete(context.Background(), userID, uint(id))
+	if err != nil {
+		logrus.WithError(err).Error("Running schedule remove cmd")
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("schedule rule %d of user %d not found", id, userID)
+	}
+	logrus.WithFields(logrus.Fields{"rule_id": id, "user_id": userID}).Info("Schedule rule removed")
+	return nil
+}
+
+func printScheduleRule(rule model.ScheduleRule) {
+	symbol := rule.Symbol
+	if symbol == "" {
+		symbol = "all symbols"
+	}
+	action := "block"
+	if rule.Action == model.ScheduleActionReduce {
+		action = "size x" + rule.SizeMultiplier.String()
+	}
+	fmt.Printf("%d: %s %s on %s %s\n", rule.ID, action, signalfilter.FormatScheduleRule(rule), symbol, rule.Note)
+}
+
+// maintenanceExchange returns the exchange named by --exchange.
+func maintenanceExchange(c *cli.Context) (*model.Exchange, error) {
+	name := strings.ToLower(strings.TrimSpace(c.String("exchange")))
+	exchange, err := repository.NewExchangeRepository().FindByName(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+	if exchange == nil {
+		return nil, fmt.Errorf("exchange %q not found", name)
+	}
+	return exchange, nil
+}
+
+// maintenanceAddAction stores a window, e.g. maintenance add --exchange phemex --from 2025-03-07T06:00:00Z --to 2025-03-07T08:00:00Z
+// exportAction writes an export of one user, e.g. export orders --user 3 --out orders.csv
+func exportAction(c *cli.Context) error {
+
+	kind := c.Args().First()
+	if kind != "orders" && kind != "trades" && kind != "gains" {
+		return fmt.Errorf("usage: export orders|trades|gains --user ID")
+	}
+	userID := c.Uint("user")
+	if userID == 0 {
+		return fmt.Errorf("--user is required")
+	}
+	var from, to time.Time
+	var err error
+	if v := c.String("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+	}
+	if v := c.String("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return fmt.Errorf("--to: %w", err)
+		}
+	}
+
+	format := c.String("format")
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		return fmt.Errorf("--format must be csv or xlsx")
+	}
+
+	out := io.Writer(os.Stdout)
+	if path := c.String("out"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	table, err := export.NewTable(format, out, kind)
+	if err != nil {
+		return err
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	exporter := export.New()
+	if kind == "gains" {
+		return exportGains(c, exporter, table, userID)
+	}
+	write := exporter.Orders
+	if kind == "trades" {
+		write = exporter.Trades
+	}
+	n, err := write(context.Background(), table, userID, from, to)
+	if err == nil {
+		err = table.Close()
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running export cmd")
+		return err
+	}
+	logrus.WithFields(logrus.Fields{"export": kind, "rows": n}).Info("export written")
+	return nil
+}
+
+// exportGains writes the gains realized in --year and logs their sum per
+// currency.
+func exportGains(c *cli.Context, exporter *export.Exporter, table export.Table, userID uint) error {
+	year := c.Int("year")
+	if year == 0 {
+		return fmt.Errorf("--year is required to export gains")
+	}
+	gains, err := exporter.RealizedGains(context.Background(), userID, year, c.String("method"))
+	if err == nil {
+		err = export.WriteGains(table, gains)
+	}
+	if err == nil {
+		err = table.Close()
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Running export gains cmd")
+		return err
+	}
+	for _, s := range report.SummarizeGains(gains) {
+		logrus.WithFields(logrus.Fields{
+			"currency":   s.Currency,
+			"disposals":  s.Disposals,
+			"cost_basis": s.CostBasis.String(),
+			"proceeds":   s.Proceeds.String(),
+			"fees":       s.Fees.String(),
+			"gain":       s.Gain.String(),
+		}).Info("realized gains")
+	}
+	return nil
+}
+
+func maintenanceAddAction(c *cli.Context) error {
+
+	if c.String("exchange") == "" {
+		return fmt.Errorf("--exchange is required")
+	}
+	from, err := time.Parse(time.RFC3339, c.String("from"))
+	if err != nil {
+		return fmt.Errorf("--from: %w", err)
+	}
+	to, err := time.Parse(time.RFC3339, c.String("to"))
+	if err != nil {
+		return fmt.Errorf("--to: %w", err)
+	}
+	if !to.After(from) {
+		return fmt.Errorf("--to must come after --from")
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	exchange, err := maintenanceExchange(c)
+	if err != nil {
+		return err
+	}
+	window := &model.MaintenanceWindow{
+		ExchangeID: exchange.ID,
+		StartsAt:   from.UTC(),
+		EndsAt:     to.UTC(),
+		Source:     model.MaintenanceSourceManual,
+		Title:      c.String("title"),
+	}
+	if err := repository.NewMaintenanceWindowRepository().Create(context.Background(), window); err != nil {
+		logrus.WithError(err).Error("Running maintenance add cmd")
+		return err
+	}
+	printMaintenanceWindow(*window, exchange.Name)
+	return nil
+}
+
+func maintenanceListAction(c *cli.Context) error {
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	var exchangeID uint
+	if c.String("exchange") != "" {
+		exchange, err := maintenanceExchange(c)
+		if err != nil {
+			return err
+		}
+		exchangeID = exchange.ID
+	}
+	exchanges, err := repository.NewExchangeRepository().List(context.Background())
+	if err != nil {
+		logrus.WithError(err).Error("Running maintenance list cmd")
+		return err
+	}
+	names := map[uint]string{}
+	for _, e := range exchanges {
+		names[e.ID] = e.Name
+	}
+	windows, err := repository.NewMaintenanceWindowRepository().ListEndingAfter(context.Background(), exchangeID, time.Now().UTC())
+	if err != nil {
+		logrus.WithError(err).Error("Running maintenance list cmd")
+		return err
+	}
+	for _, w := range windows {
+		printMaintenanceWindow(w, names[w.ExchangeID])
+	}
+	return nil
+}
+
+// maintenanceRemoveAction removes a window, e.g. maintenance remove 7
+func maintenanceRemoveAction(c *cli.Context) error {
+
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: maintenance remove WINDOW_ID")
+	}
+	id, err := strconv.ParseUint(c.Args().Get(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid window id %q", c.Args().Get(0))
+	}
+
+	if err := database.OpenMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	removed, err := repository.NewMaintenanceWindowRepository().Delete(context.Background(), uint(id))
+	if err != nil {
+		logrus.WithError(err).Error("Running maintenance remove cmd")
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("maintenance window %d not found", id)
+	}
+	logrus.WithField("window_id", id).Info("Maintenance window removed")
+	return nil
+}
+
+// maintenanceSyncAction reads the exchange status pages once
+func maintenanceSyncAction(_ *cli.Context) error {
+
+	logrus.Info("Starting maintenance sync CMD")
+	if err := database.InitMainDB(); err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+	m := &maintenance.MaintenanceSync{
+		Log: logrus.WithField("cmd", "maintenance_sync"),
+	}
+
+	err := m.Start()
+	if err != nil {
+		logrus.WithError(err).Error("Starting maintenance sync cmd")
+		return err
+	}
+
+	return nil
+}
+
+func printMaintenanceWindow(w model.MaintenanceWindow, exchange string) {
+	fmt.Printf("%d: %s %s to %s (%s) %s\n", w.ID, exchange, w.StartsAt.Format(time.RFC3339), w.EndsAt.Format(time.RFC3339), w.Source, w.Title)
+}
@@ -0,0 +1,23 @@
+package maintenance
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// StatusPages are exchange=url pairs pointing at the scheduled
+	// maintenances of the Statuspage of the exchange, e.g.
+	// kraken=https://status.kraken.com/api/v2/scheduled-maintenances.json.
+	// Exchanges without one only get the windows entered by hand.
+	StatusPages []string `envconfig:"MAINTENANCE_STATUS_PAGES" default:""`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
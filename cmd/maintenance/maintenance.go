@@ -0,0 +1,106 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/cron"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strings"
+)
+
+func (m *MaintenanceSync) Start() error {
+	return m.Run(context.Background())
+}
+
+// Run reads the status page of every configured exchange once.
+func (m *MaintenanceSync) Run(ctx context.Context) error {
+	m.Config = GetConfig()
+
+	m.exchanges = repository.NewExchangeRepository()
+	m.windows = repository.NewMaintenanceWindowRepository()
+	m.fetch = func(ctx context.Context, url string) ([]model.MaintenanceWindow, error) {
+		return connectors.NewStatusPage(nil, url).FetchMaintenances(ctx)
+	}
+
+	return m.run(ctx)
+}
+
+// run syncs the exchanges by name. A failing status page never stops the
+// others; the first error is returned at the end.
+func (m *MaintenanceSync) run(ctx context.Context) error {
+	pages, err := statusPages(m.Config.StatusPages)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(pages))
+	for name := range pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var firstErr error
+	for _, name := range names {
+		stored, err := m.sync(ctx, name, pages[name])
+		log := m.Log.WithFields(map[string]interface{}{
+			"exchange": name,
+			"stored":   stored,
+		})
+		if err != nil {
+			log.WithError(err).Error("failed to sync maintenance windows")
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		log.Info("maintenance windows synced")
+	}
+	return firstErr
+}
+
+// statusPages parses the exchange=url pairs of MAINTENANCE_STATUS_PAGES by
+// lower-cased exchange name.
+func statusPages(pairs []string) (map[string]string, error) {
+	pages := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, url, ok := strings.Cut(pair, "=")
+		name, url = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(url)
+		if !ok || name == "" || url == "" {
+			return nil, fmt.Errorf("invalid status page %q, expected exchange=url", pair)
+		}
+		pages[name] = url
+	}
+	return pages, nil
+}
+
+// sync stores the maintenances of the status page at url as windows of
+// exchange name and returns how many were stored.
+func (m *MaintenanceSync) sync(ctx context.Context, name, url string) (int, error) {
+	exchange, err := m.exchanges.FindByName(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("FindByName %s: %w", name, err)
+	}
+	if exchange == nil {
+		return 0, fmt.Errorf("exchange %q not found", name)
+	}
+	windows, err := m.fetch(ctx, url)
+	if err != nil {
+		return 0, fmt.Errorf("status page of %s: %w", name, err)
+	}
+	stored := 0
+	for i := range windows {
+		w := windows[i]
+		w.ExchangeID = exchange.ID
+		if err := m.windows.Upsert(ctx, &w); err != nil {
+			return stored, fmt.Errorf("Upsert %s: %w", w.ExternalID, err)
+		}
+		stored++
+		cron.AddItems(ctx, 1)
+	}
+	return stored, nil
+}
@@ -0,0 +1,73 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeExchanges map[string]uint
+
+func (f fakeExchanges) FindByName(_ context.Context, name string) (*model.Exchange, error) {
+	id, ok := f[name]
+	if !ok {
+		return nil, nil
+	}
+	return &model.Exchange{ID: id, Name: name}, nil
+}
+
+type fakeWindows struct{ stored []model.MaintenanceWindow }
+
+func (f *fakeWindows) Upsert(_ context.Context, w *model.MaintenanceWindow) error {
+	f.stored = append(f.stored, *w)
+	return nil
+}
+
+func TestRunStoresStatusPageMaintenances(t *testing.T) {
+	starts := time.Date(2025, 3, 7, 6, 0, 0, 0, time.UTC)
+	windows := &fakeWindows{}
+	m := &MaintenanceSync{
+		Log: logrus.NewEntry(logrus.New()),
+		Config: &Config{StatusPages: []string{
+			"kraken=https://status.kraken.example/maintenances.json",
+			"kucoin=https://status.kucoin.example/maintenances.json",
+			" Phemex = https://status.phemex.example/maintenances.json",
+		}},
+		exchanges: fakeExchanges{"kraken": 2, "phemex": 1},
+		windows:   windows,
+		fetch: func(_ context.Context, url string) ([]model.MaintenanceWindow, error) {
+			if url == "https://status.kraken.example/maintenances.json" {
+				return nil, errors.New("status page down")
+			}
+			return []model.MaintenanceWindow{
+				{StartsAt: starts, EndsAt: starts.Add(time.Hour), Source: model.MaintenanceSourceStatusPage, ExternalID: "m1"},
+			}, nil
+		},
+	}
+
+	err := m.run(context.Background())
+	require.ErrorContains(t, err, "status page down", "the first error once every exchange was tried")
+	require.Len(t, windows.stored, 1, "kucoin is not a known exchange")
+	require.Equal(t, uint(1), windows.stored[0].ExchangeID)
+	require.Equal(t, "m1", windows.stored[0].ExternalID)
+}
+
+func TestStatusPages(t *testing.T) {
+	t.Setenv("MAINTENANCE_STATUS_PAGES", "kraken=https://status.kraken.com/api/v2/scheduled-maintenances.json")
+	pages, err := statusPages(GetConfig().StatusPages)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"kraken": "https://status.kraken.com/api/v2/scheduled-maintenances.json"}, pages)
+
+	t.Setenv("MAINTENANCE_STATUS_PAGES", "")
+	pages, err = statusPages(GetConfig().StatusPages)
+	require.NoError(t, err)
+	require.Empty(t, pages)
+
+	_, err = statusPages([]string{"https://status.kraken.com"})
+	require.Error(t, err)
+}
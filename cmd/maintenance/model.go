@@ -0,0 +1,30 @@
+package maintenance
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type exchangeLookup interface {
+	FindByName(ctx context.Context, name string) (*model.Exchange, error)
+}
+
+type windowStore interface {
+	Upsert(ctx context.Context, w *model.MaintenanceWindow) error
+}
+
+// fetchFunc reads the maintenances announced on the status page at url.
+type fetchFunc func(ctx context.Context, url string) ([]model.MaintenanceWindow, error)
+
+// MaintenanceSync stores the maintenances announced on the status pages of
+// the exchanges as maintenance windows.
+type MaintenanceSync struct {
+	Log    *logger.Entry
+	Config *Config
+
+	exchanges exchangeLookup
+	windows   windowStore
+	fetch     fetchFunc
+}
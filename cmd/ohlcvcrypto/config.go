@@ -16,6 +16,9 @@ type Config struct {
 	Symbol               string    `envconfig:"SYMBOL" default:"BTC"`
 	Quote                string    `envconfig:"QUOTE" default:"USDT"`
 	Limit                int       `envconfig:"LIMIT" default:"1000"`
+	// Backfill pages through the whole START_DATE..END_DATE range, LIMIT
+	// candles per request, instead of stopping after the first page.
+	Backfill bool `envconfig:"BACKFILL" default:"false"`
 }
 
 func GetConfig() *Config {
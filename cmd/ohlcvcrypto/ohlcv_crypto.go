@@ -1,16 +1,18 @@
 package ohlcvcrypto
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"net/http"
+	"strategyexecutor/src/cron"
 	common "strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
 	"time"
 
 	"github.com/shopspring/decimal"
 	logger "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 
 	"github.com/nntaoli-project/goex"
 	"github.com/nntaoli-project/goex/binance"
@@ -29,6 +31,11 @@ type OHLCVCrypto struct {
 }
 
 func (o *OHLCVCrypto) Start() error {
+	return o.Run(context.Background())
+}
+
+// Run fetches the candles of the configured period and upserts them.
+func (o *OHLCVCrypto) Run(ctx context.Context) error {
 	o.Config = GetConfig()
 
 	o.exchange = o.newBinanceInstance()
@@ -39,7 +46,7 @@ func (o *OHLCVCrypto) Start() error {
 		}
 	}
 
-	err := o.aggregateAndSave()
+	err := o.aggregateAndSave(ctx)
 
 	return err
 }
@@ -52,17 +59,49 @@ func (*OHLCVCrypto) newBinanceInstance() *binance.Binance {
 	return binance.NewWithConfig(apiConfig)
 }
 
-func (o *OHLCVCrypto) aggregateAndSave() error {
-	series, err := o.fetchOHLCVSeries()
-	if err != nil {
-		return err
+func (o *OHLCVCrypto) aggregateAndSave(ctx context.Context) error {
+	repo := repository.NewOHLCVRepositoryRepositoryWithDB(o.DB)
+	for {
+		series, err := o.fetchOHLCVSeries()
+		if err != nil {
+			return err
+		}
+		if err := o.save(ctx, repo, series); err != nil {
+			return err
+		}
+		cron.AddItems(ctx, len(series))
+
+		o.Log.WithFields(logger.Fields{
+			"Symbol":  o.Config.Symbol,
+			"Candles": len(series),
+			"StartDt": o.Config.StartDt,
+		}).Info("OHLCV data inserted or updated in database")
+
+		// A backfill pages through the whole range, a regular run stops
+		// after one page.
+		if !o.Config.Backfill || len(series) < o.Config.Limit {
+			return nil
+		}
+		next := time.Unix(series[len(series)-1].Timestamp, 0).UTC().Add(o.parseDuration())
+		if !next.After(o.Config.StartDt) || next.After(o.Config.EndDt) {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		o.Config.StartDt = next
 	}
+}
 
+// save upserts series in bulk into the table of the configured duration.
+func (o *OHLCVCrypto) save(ctx context.Context, repo *repository.OHLCVRepository, series []goex.Kline) error {
+	var (
+		rows1m []common.OHLCVCrypto1m
+		rows1h []common.OHLCVCrypto1h
+	)
 	for i := range series {
 		result := series[i]
-
-		var target interface{}
-		target = &common.OHLCVBase{
+		base := &common.OHLCVBase{
 			Datetime: time.Unix(result.Timestamp, 0).UTC(),
 			Open:     decimal.NewFromFloat(result.Open),
 			High:     decimal.NewFromFloat(result.High),
@@ -71,30 +110,27 @@ func (o *OHLCVCrypto) aggregateAndSave() error {
 			Volume:   decimal.NewFromFloat(result.Vol),
 			Symbol:   result.Pair.String(),
 		}
-
-		if o.Config.DurationStr == Duration1m {
-			target = target.(*common.OHLCVBase).ConvertToOHLCVCrypto1m()
-		} else if o.Config.DurationStr == Duration1h {
-			target = target.(*common.OHLCVBase).ConvertToOHLCVCrypto1h()
-		}
-
-		// Upsert: on conflict on (datetime, symbol) do update
-		if err := o.DB.Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "datetime"}, {Name: "symbol"}}, // Composite unique index columns
-			DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "volume"}),
-		}).Create(target).Error; err != nil {
-			o.Log.WithError(err).Error("aggregateAndSave, Create, ")
-			return err
+		switch o.Config.DurationStr {
+		case Duration1m:
+			rows1m = append(rows1m, *base.ConvertToOHLCVCrypto1m())
+		case Duration1h:
+			rows1h = append(rows1h, *base.ConvertToOHLCVCrypto1h())
 		}
-
-		o.Log.WithFields(logger.Fields{
-			"Symbol":    o.Config.Symbol,
-			"Price":     target,
-			"Timestamp": time.Now().UTC(),
-		}).Info("OHLCV data inserted or updated in database")
 	}
 
-	return nil
+	var err error
+	switch o.Config.DurationStr {
+	case Duration1m:
+		err = repo.UpsertOHLCV1m(ctx, rows1m)
+	case Duration1h:
+		err = repo.UpsertOHLCV1h(ctx, rows1h)
+	default:
+		panic("save, invalid DURATION")
+	}
+	if err != nil {
+		o.Log.WithError(err).Error("aggregateAndSave, Upsert, ")
+	}
+	return err
 }
 
 func (o *OHLCVCrypto) determineStartPoint() error {
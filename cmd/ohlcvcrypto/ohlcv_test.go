@@ -1,11 +1,15 @@
 package ohlcvcrypto
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strategyexecutor/src/model"
 	"strategyexecutor/src/utils"
+	"strconv"
+	"strings"
 
 	"testing"
 	"time"
@@ -17,6 +21,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -182,3 +187,49 @@ func TestOHLCVCrypto_getModel(t *testing.T) {
 		})
 	}
 }
+
+// Test aggregateAndSave pages through the range when backfilling.
+func TestOHLCVCrypto_aggregateAndSaveBackfill(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	requests := 0
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/v3/klines", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		from, _ := strconv.ParseInt(r.URL.Query().Get("startTime"), 10, 64)
+		var rows []string
+		// 5 hourly candles are available, 2 per page
+		for ts := from; ts < start.Add(5*time.Hour).UnixMilli() && len(rows) < 2; ts += time.Hour.Milliseconds() {
+			rows = append(rows, fmt.Sprintf(`[%d, "1", "2", "0.5", "1.5", "10", %d, "0", 1, "0", "0", "0"]`, ts, ts+time.Hour.Milliseconds()-1))
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(rows, ",") + "]"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.OHLCVCrypto1h{}))
+
+	ohlcv := OHLCVCrypto{
+		Log: logrus.NewEntry(logrus.New()),
+		DB:  db,
+		Config: &Config{
+			Symbol:      "BTC",
+			Quote:       "USDT",
+			StartDt:     start,
+			EndDt:       start.Add(24 * time.Hour),
+			DurationStr: Duration1h,
+			Limit:       2,
+			Backfill:    true,
+		},
+		exchange: binance.NewWithConfig(&goex.APIConfig{HttpClient: http.DefaultClient, Endpoint: server.URL}),
+	}
+	require.NoError(t, ohlcv.aggregateAndSave(context.Background()))
+	require.Equal(t, 3, requests)
+
+	var n int64
+	require.NoError(t, db.Model(&model.OHLCVCrypto1h{}).Count(&n).Error)
+	require.Equal(t, int64(5), n)
+}
@@ -0,0 +1,23 @@
+package order_archive
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// AfterMonths is the age, by creation time, after which orders are moved
+	// into the archive.
+	AfterMonths int `envconfig:"ORDER_ARCHIVE_AFTER_MONTHS" default:"6"`
+	// BatchSize is the number of orders moved per transaction.
+	BatchSize int `envconfig:"ORDER_ARCHIVE_BATCH_SIZE" default:"500"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
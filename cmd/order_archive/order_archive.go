@@ -0,0 +1,49 @@
+package order_archive
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/repository"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type archiver interface {
+	ArchiveBefore(ctx context.Context, cutoff time.Time, limit int, now time.Time) (int, error)
+}
+
+type OrderArchive struct {
+	Log    *logger.Entry
+	Config *Config
+}
+
+func (a *OrderArchive) Start() error {
+	a.Config = GetConfig()
+	if a.Config.AfterMonths <= 0 {
+		return fmt.Errorf("ORDER_ARCHIVE_AFTER_MONTHS must be positive, got %d", a.Config.AfterMonths)
+	}
+
+	total, err := a.run(context.Background(), repository.NewOrderArchiveRepository(), time.Now().UTC())
+	a.Log.WithField("archived", total).Info("order archive finished")
+	return err
+}
+
+// run archives in batches until a batch comes back short, so a failure only
+// rolls back the batch in progress.
+func (a *OrderArchive) run(ctx context.Context, store archiver, now time.Time) (int, error) {
+	cutoff := now.AddDate(0, -a.Config.AfterMonths, 0)
+	a.Log.WithField("cutoff", cutoff).Info("archiving orders")
+
+	total := 0
+	for {
+		n, err := store.ArchiveBefore(ctx, cutoff, a.Config.BatchSize, now)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < a.Config.BatchSize || n == 0 {
+			return total, nil
+		}
+	}
+}
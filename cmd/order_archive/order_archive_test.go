@@ -0,0 +1,48 @@
+package order_archive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeArchiver struct {
+	batches []int
+	err     error
+	cutoffs []time.Time
+}
+
+func (f *fakeArchiver) ArchiveBefore(_ context.Context, cutoff time.Time, _ int, _ time.Time) (int, error) {
+	f.cutoffs = append(f.cutoffs, cutoff)
+	if len(f.batches) == 0 {
+		return 0, f.err
+	}
+	n := f.batches[0]
+	f.batches = f.batches[1:]
+	return n, nil
+}
+
+func TestRunDrainsBatches(t *testing.T) {
+	a := &OrderArchive{Log: logger.NewEntry(logger.New()), Config: &Config{AfterMonths: 6, BatchSize: 2}}
+	store := &fakeArchiver{batches: []int{2, 2, 1}}
+	now := time.Date(2025, 9, 15, 12, 0, 0, 0, time.UTC)
+
+	total, err := a.run(context.Background(), store, now)
+	require.NoError(t, err)
+	require.Equal(t, 5, total)
+	require.Len(t, store.cutoffs, 3)
+	require.Equal(t, time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC), store.cutoffs[0])
+}
+
+func TestRunStopsOnError(t *testing.T) {
+	a := &OrderArchive{Log: logger.NewEntry(logger.New()), Config: &Config{AfterMonths: 6, BatchSize: 2}}
+	store := &fakeArchiver{batches: []int{2}, err: errors.New("boom")}
+
+	total, err := a.run(context.Background(), store, time.Now())
+	require.Error(t, err)
+	require.Equal(t, 2, total)
+}
@@ -0,0 +1,64 @@
+package orders
+
+import (
+	"context"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type orderStore interface {
+	FindByID(ctx context.Context, id uint) (*model.Order, error)
+	FindByStatus(ctx context.Context, status string, page repository.Pagination) ([]model.Order, error)
+}
+
+type noteStore interface {
+	Create(ctx context.Context, note *model.OrderNote) error
+	FindByOrderIDs(ctx context.Context, orderIDs []uint) ([]model.OrderNote, error)
+}
+
+type signalLookup interface {
+	FindByID(ctx context.Context, id uint) (*externalmodel.TradingSignal, error)
+}
+
+type userLookup interface {
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+type exchangeLookup interface {
+	FindByID(ctx context.Context, id uint) (*model.Exchange, error)
+}
+
+type userExchangeLookup interface {
+	GetByUserAndExchange(ctx context.Context, userID uint, exchangeID uint) (*model.UserExchange, error)
+}
+
+// dispatchFunc runs the exchange controller once on signal.
+type dispatchFunc func(ctx context.Context, user *model.User, userExchange *model.UserExchange, exchange *model.Exchange, signal externalmodel.TradingSignal) error
+
+// RetryPlan is what a retry re-dispatches: the failed order and the signal
+// it was placed for.
+type RetryPlan struct {
+	Order    *model.Order
+	Signal   *externalmodel.TradingSignal
+	User     *model.User
+	Exchange *model.Exchange
+	// Dispatched is false on a dry run.
+	Dispatched bool
+}
+
+type Orders struct {
+	Log *logger.Entry
+
+	orders        orderStore
+	notes         noteStore
+	signals       signalLookup
+	users         userLookup
+	exchanges     exchangeLookup
+	userExchanges userExchangeLookup
+	// allowed reports why trading is currently blocked for a user, if it is.
+	allowed  func(ctx context.Context, userID uint) error
+	dispatch dispatchFunc
+}
@@ -0,0 +1,179 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/executors"
+	"strategyexecutor/src/featureflag"
+	"strategyexecutor/src/killswitch"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strings"
+	"unicode/utf8"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// ErrNotFound is returned when the order does not exist.
+var ErrNotFound = errors.New("order not found")
+
+// retryable are the statuses an order can be retried from.
+var retryable = map[string]bool{
+	model.OrderExecutionStatusError:         true,
+	model.OrderExecutionStatusCanceledError: true,
+}
+
+// New wires Orders to the main and read-only databases and dispatches
+// retries through the executor controllers.
+func New() *Orders {
+	return &Orders{
+		Log:           logger.WithField("cmd", "orders"),
+		orders:        repository.NewOrderRepository(),
+		notes:         repository.NewOrderNoteRepository(),
+		signals:       repository.NewTradingSignalRepository(),
+		users:         repository.NewUserRepository(),
+		exchanges:     repository.NewExchangeRepository(),
+		userExchanges: repository.NewUserExchangeRepository(),
+		allowed:       tradingAllowed,
+		dispatch:      executors.Redispatch,
+	}
+}
+
+// tradingAllowed applies the same gates as the executor loop.
+func tradingAllowed(ctx context.Context, userID uint) error {
+	if killswitch.Halted(ctx, userID) {
+		return fmt.Errorf("emergency stop active for user %d", userID)
+	}
+	if !featureflag.Enabled(ctx, featureflag.LiveTrading, userID) {
+		return fmt.Errorf("live trading disabled for user %d", userID)
+	}
+	return nil
+}
+
+// List returns one page of orders with status.
+func (o *Orders) List(ctx context.Context, status string, page repository.Pagination) ([]model.Order, error) {
+	if status == "" {
+		return nil, errors.New("status is required")
+	}
+	return o.orders.FindByStatus(ctx, status, page)
+}
+
+// Show returns an order with its status log.
+func (o *Orders) Show(ctx context.Context, id uint) (*model.Order, error) {
+	order, err := o.orders.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("FindByID: %w", err)
+	}
+	if order == nil {
+		return nil, ErrNotFound
+	}
+	return order, nil
+}
+
+// Notes returns the notes of order id, oldest first.
+func (o *Orders) Notes(ctx context.Context, id uint) ([]model.OrderNote, error) {
+	order, err := o.Show(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return o.notes.FindByOrderIDs(ctx, []uint{order.ID})
+}
+
+// AddNote attaches body to order id with authorID as author, e.g. to
+// document a manual intervention during an incident.
+func (o *Orders) AddNote(ctx context.Context, id, authorID uint, body string) (*model.OrderNote, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > model.MaxOrderNoteLength {
+		return nil, fmt.Errorf("note must be 1 to %d characters", model.MaxOrderNoteLength)
+	}
+	if authorID == 0 {
+		return nil, errors.New("author is required")
+	}
+	order, err := o.Show(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	author, err := o.users.GetUserByID(ctx, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("author %d: %w", authorID, err)
+	}
+
+	note := &model.OrderNote{
+		OrderID:  order.ID,
+		UserID:   order.UserID,
+		AuthorID: author.ID,
+		Author:   author.Username,
+		Body:     body,
+	}
+	if err := o.notes.Create(ctx, note); err != nil {
+		return nil, fmt.Errorf("create note: %w", err)
+	}
+	o.Log.WithFields(logger.Fields{"order_id": order.ID, "author_id": author.ID}).Info("order note added")
+	return note, nil
+}
+
+// Retry re-dispatches the signal of a failed order through the controller of
+// its exchange. With dryRun it only resolves and returns what would be sent.
+func (o *Orders) Retry(ctx context.Context, id uint, dryRun bool) (*RetryPlan, error) {
+	order, err := o.Show(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !retryable[order.Status] {
+		return nil, fmt.Errorf("order %d has status %s; only failed orders can be retried", order.ID, order.Status)
+	}
+
+	signal, err := o.signals.FindByID(ctx, order.ExternalID)
+	if err != nil {
+		return nil, fmt.Errorf("find signal %d: %w", order.ExternalID, err)
+	}
+	if signal == nil {
+		return nil, fmt.Errorf("signal %d of order %d not found", order.ExternalID, order.ID)
+	}
+
+	user, err := o.users.GetUserByID(ctx, order.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("find user %d: %w", order.UserID, err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %d not found", order.UserID)
+	}
+
+	exchange, err := o.exchanges.FindByID(ctx, order.ExchangeID)
+	if err != nil {
+		return nil, fmt.Errorf("find exchange %d: %w", order.ExchangeID, err)
+	}
+	if exchange == nil {
+		return nil, fmt.Errorf("exchange %d not found", order.ExchangeID)
+	}
+
+	plan := &RetryPlan{Order: order, Signal: signal, User: user, Exchange: exchange}
+	log := o.Log.WithFields(logger.Fields{
+		"order_id":  order.ID,
+		"signal_id": signal.ID,
+		"user_id":   user.ID,
+		"exchange":  exchange.Name,
+		"dry_run":   dryRun,
+	})
+	if dryRun {
+		log.Info("order retry planned")
+		return plan, nil
+	}
+
+	if err := o.allowed(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	userExchange, err := o.userExchanges.GetByUserAndExchange(ctx, user.ID, exchange.ID)
+	if err != nil {
+		return nil, fmt.Errorf("GetByUserAndExchange: %w", err)
+	}
+
+	if err := o.dispatch(ctx, user, userExchange, exchange, *signal); err != nil {
+		log.WithError(err).Error("order retry failed")
+		return nil, fmt.Errorf("dispatch signal %d: %w", signal.ID, err)
+	}
+	plan.Dispatched = true
+	log.Warn("order retried")
+	return plan, nil
+}
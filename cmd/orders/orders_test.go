@@ -0,0 +1,200 @@
+package orders
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOrders struct {
+	rows   map[uint]*model.Order
+	status string
+}
+
+func (f *fakeOrders) FindByID(_ context.Context, id uint) (*model.Order, error) {
+	return f.rows[id], nil
+}
+
+func (f *fakeOrders) FindByStatus(_ context.Context, status string, _ repository.Pagination) ([]model.Order, error) {
+	f.status = status
+	var out []model.Order
+	for _, o := range f.rows {
+		if o.Status == status {
+			out = append(out, *o)
+		}
+	}
+	return out, nil
+}
+
+type fakeNotes struct{ rows []model.OrderNote }
+
+func (f *fakeNotes) Create(_ context.Context, note *model.OrderNote) error {
+	f.rows = append(f.rows, *note)
+	return nil
+}
+
+func (f *fakeNotes) FindByOrderIDs(_ context.Context, ids []uint) ([]model.OrderNote, error) {
+	var out []model.OrderNote
+	for _, n := range f.rows {
+		if n.OrderID == ids[0] {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+type fakeSignals map[uint]*externalmodel.TradingSignal
+
+func (f fakeSignals) FindByID(_ context.Context, id uint) (*externalmodel.TradingSignal, error) {
+	return f[id], nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) GetUserByID(_ context.Context, id uint) (*model.User, error) {
+	return &model.User{ID: id, Username: "alice"}, nil
+}
+
+type fakeExchanges struct{}
+
+func (fakeExchanges) FindByID(_ context.Context, id uint) (*model.Exchange, error) {
+	return &model.Exchange{ID: id, Name: "phemex"}, nil
+}
+
+type fakeUserExchanges struct{}
+
+func (fakeUserExchanges) GetByUserAndExchange(_ context.Context, userID, exchangeID uint) (*model.UserExchange, error) {
+	return &model.UserExchange{UserID: userID, ExchangeID: exchangeID, OrderSizePercent: 10}, nil
+}
+
+type dispatchCall struct {
+	user   uint
+	signal uint
+}
+
+func newTestOrders() (*Orders, *[]dispatchCall) {
+	var calls []dispatchCall
+	return &Orders{
+		Log: logrus.NewEntry(logrus.New()),
+		orders: &fakeOrders{rows: map[uint]*model.Order{
+			1: {ID: 1, UserID: 7, ExchangeID: 1, ExternalID: 100, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusError},
+			2: {ID: 2, UserID: 7, ExchangeID: 1, ExternalID: 101, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusFilled},
+			3: {ID: 3, UserID: 7, ExchangeID: 1, ExternalID: 999, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusError},
+		}},
+		notes: &fakeNotes{},
+		signals: fakeSignals{
+			100: {ID: 100, Symbol: "BTCUSDT", Action: "buy", OrderType: "market", Qty: 1},
+			101: {ID: 101, Symbol: "BTCUSDT", Action: "sell"},
+		},
+		users:         fakeUsers{},
+		exchanges:     fakeExchanges{},
+		userExchanges: fakeUserExchanges{},
+		allowed:       func(context.Context, uint) error { return nil },
+		dispatch: func(_ context.Context, user *model.User, _ *model.UserExchange, _ *model.Exchange, signal externalmodel.TradingSignal) error {
+			calls = append(calls, dispatchCall{user: user.ID, signal: signal.ID})
+			return nil
+		},
+	}, &calls
+}
+
+func TestRetryIsDryRunByDefault(t *testing.T) {
+	o, calls := newTestOrders()
+
+	plan, err := o.Retry(context.Background(), 1, true)
+	require.NoError(t, err)
+	require.False(t, plan.Dispatched)
+	require.Equal(t, uint(100), plan.Signal.ID)
+	require.Empty(t, *calls)
+
+	var out bytes.Buffer
+	PrintPlan(&out, plan)
+	require.Contains(t, out.String(), "order #1 (error) -> signal #100 BTCUSDT buy")
+	require.Contains(t, out.String(), "dry run")
+}
+
+func TestRetryDispatchesSignal(t *testing.T) {
+	o, calls := newTestOrders()
+
+	plan, err := o.Retry(context.Background(), 1, false)
+	require.NoError(t, err)
+	require.True(t, plan.Dispatched)
+	require.Equal(t, []dispatchCall{{user: 7, signal: 100}}, *calls)
+}
+
+func TestRetryRefusals(t *testing.T) {
+	o, calls := newTestOrders()
+	ctx := context.Background()
+
+	_, err := o.Retry(ctx, 42, false)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = o.Retry(ctx, 2, false)
+	require.ErrorContains(t, err, "only failed orders can be retried")
+
+	_, err = o.Retry(ctx, 3, false)
+	require.ErrorContains(t, err, "signal 999 of order 3 not found")
+
+	o.allowed = func(context.Context, uint) error { return errors.New("emergency stop active for user 7") }
+	_, err = o.Retry(ctx, 1, false)
+	require.ErrorContains(t, err, "emergency stop")
+	require.Empty(t, *calls)
+
+	o.allowed = func(context.Context, uint) error { return nil }
+	o.dispatch = func(context.Context, *model.User, *model.UserExchange, *model.Exchange, externalmodel.TradingSignal) error {
+		return errors.New("HTTP 502")
+	}
+	_, err = o.Retry(ctx, 1, false)
+	require.ErrorContains(t, err, "dispatch signal 100: HTTP 502")
+}
+
+func TestListAndShow(t *testing.T) {
+	o, _ := newTestOrders()
+	ctx := context.Background()
+
+	_, err := o.List(ctx, "", repository.Pagination{})
+	require.Error(t, err)
+
+	rows, err := o.List(ctx, model.OrderExecutionStatusError, repository.Pagination{})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	order, err := o.Show(ctx, 2)
+	require.NoError(t, err)
+	order.Logs = []model.OrderLog{{Status: model.OrderExecutionStatusFilled, Reason: "exchange fill"}}
+
+	var out bytes.Buffer
+	PrintOrder(&out, order)
+	require.Contains(t, out.String(), "#2 user=7 exchange=1 signal=101")
+	require.Contains(t, out.String(), "filled exchange fill")
+}
+
+func TestNotes(t *testing.T) {
+	o, _ := newTestOrders()
+	ctx := context.Background()
+
+	_, err := o.AddNote(ctx, 2, 1, "   ")
+	require.Error(t, err)
+	_, err = o.AddNote(ctx, 2, 0, "closed by hand")
+	require.Error(t, err)
+	_, err = o.AddNote(ctx, 42, 1, "closed by hand")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	note, err := o.AddNote(ctx, 2, 1, " closed by hand during the outage ")
+	require.NoError(t, err)
+	require.Equal(t, model.OrderNote{OrderID: 2, UserID: 7, AuthorID: 1, Author: "alice", Body: "closed by hand during the outage"}, *note)
+
+	notes, err := o.Notes(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+
+	var out bytes.Buffer
+	PrintNotes(&out, notes)
+	require.Contains(t, out.String(), "alice: closed by hand during the outage")
+}
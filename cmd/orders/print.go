@@ -0,0 +1,63 @@
+package orders
+
+import (
+	"fmt"
+	"io"
+	"strategyexecutor/src/model"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func price(p *decimal.Decimal) string {
+	if p == nil {
+		return "market"
+	}
+	return p.String()
+}
+
+// PrintOrders writes one line per order.
+func PrintOrders(out io.Writer, rows []model.Order) {
+	if len(rows) == 0 {
+		fmt.Fprintln(out, "No orders found.")
+		return
+	}
+	for _, o := range rows {
+		fmt.Fprintf(out, "#%d user=%d exchange=%d signal=%d %s %s %s %s@%s status=%s created=%s\n",
+			o.ID, o.UserID, o.ExchangeID, o.ExternalID, o.OrderDir, o.Symbol, o.Side, o.Quantity, price(o.Price),
+			o.Status, o.CreatedAt.Format(time.RFC3339))
+	}
+}
+
+// PrintOrder writes an order and its status log.
+func PrintOrder(out io.Writer, o *model.Order) {
+	PrintOrders(out, []model.Order{*o})
+	fmt.Fprintf(out, "  pos_side=%s type=%s sl=%s tp=%s updated=%s\n",
+		o.PosSide, o.OrderType, o.StopLossPct, o.TakeProfitPct, o.UpdatedAt.Format(time.RFC3339))
+	for _, l := range o.Logs {
+		fmt.Fprintf(out, "  %s %s %s\n", l.CreatedAt.Format(time.RFC3339), l.Status, l.Reason)
+	}
+}
+
+// PrintPlan writes the outcome of a retry.
+func PrintPlan(out io.Writer, p *RetryPlan) {
+	s := p.Signal
+	fmt.Fprintf(out, "order #%d (%s) -> signal #%d %s %s %s qty=%g for user %s on %s\n",
+		p.Order.ID, p.Order.Status, s.ID, s.Symbol, s.Action, s.OrderType, s.Qty, p.User.Username, p.Exchange.Name)
+	if p.Dispatched {
+		fmt.Fprintln(out, "signal re-dispatched; check the new order with `orders list`")
+		return
+	}
+	fmt.Fprintln(out, "dry run: nothing sent, pass --execute to re-dispatch")
+}
+
+// PrintNotes writes the notes of an order.
+func PrintNotes(out io.Writer, notes []model.OrderNote) {
+	if len(notes) == 0 {
+		fmt.Fprintln(out, "No notes.")
+		return
+	}
+	for _, n := range notes {
+		fmt.Fprintf(out, "  %s %s: %s\n", n.CreatedAt.Format(time.RFC3339), n.Author, n.Body)
+	}
+}
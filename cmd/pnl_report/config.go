@@ -0,0 +1,22 @@
+package pnl_report
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	Symbols []string `envconfig:"PNL_SYMBOLS" default:"BTCUSDT"`
+	BaseURL string   `envconfig:"PNL_BASE_URL" default:"https://api.phemex.com"`
+	// ReportDate is the UTC day to report (YYYY-MM-DD). Empty means yesterday.
+	ReportDate string `envconfig:"PNL_REPORT_DATE" default:""`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
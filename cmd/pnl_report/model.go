@@ -0,0 +1,44 @@
+package pnl_report
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// pnlClient is the subset of the exchange connector used by the report.
+type pnlClient interface {
+	ListFills(symbol string) ([]connectors.PhemexFill, error)
+	GetPositionsUSDT() (*connectors.GAccountPositions, error)
+}
+
+type pnlRepository interface {
+	Upsert(ctx context.Context, row *model.DailyPnL) error
+}
+
+type userExchangeLister interface {
+	ListRunOnServer(ctx context.Context) ([]model.UserExchange, error)
+}
+
+type userLookup interface {
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+type notifier interface {
+	Notify(ctx context.Context, ev notify.Event)
+}
+
+type PnLReport struct {
+	Log    *logger.Entry
+	Config *Config
+
+	userExchanges userExchangeLister
+	users         userLookup
+	repo          pnlRepository
+	notifier      notifier
+	newClient     func(creds security.Credentials) (pnlClient, error)
+}
@@ -0,0 +1,236 @@
+package pnl_report
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/cron"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func (p *PnLReport) Start() error {
+	return p.Run(context.Background())
+}
+
+// Run reports the configured day of every server-run account.
+func (p *PnLReport) Run(ctx context.Context) error {
+	p.Config = GetConfig()
+
+	day, err := reportDay(p.Config.ReportDate, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	p.userExchanges = repository.NewUserExchangeRepository()
+	p.users = repository.NewUserRepository()
+	p.repo = repository.NewDailyPnLRepository()
+	p.notifier = notify.NewNotifier()
+	p.newClient = func(creds security.Credentials) (pnlClient, error) {
+		return connectors.NewClientFor(creds.APIKey, creds.APISecret, creds.Environment, p.Config.BaseURL)
+	}
+
+	return p.run(ctx, day)
+}
+
+// reportDay parses value (YYYY-MM-DD) or, when empty, returns yesterday
+// relative to now. The result is midnight UTC.
+func reportDay(value string, now time.Time) (time.Time, error) {
+	if strings.TrimSpace(value) == "" {
+		start, _ := report.DayBounds(now.Add(-24 * time.Hour))
+		return start, nil
+	}
+	day, err := time.Parse("2006-01-02", strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid PNL_REPORT_DATE %q: %w", value, err)
+	}
+	return day, nil
+}
+
+// run builds the report of every server-run user exchange. A failure on one
+// account is logged and the remaining accounts are still processed; the
+// first error is returned at the end.
+func (p *PnLReport) run(ctx context.Context, day time.Time) error {
+	userExchanges, err := p.userExchanges.ListRunOnServer(ctx)
+	if err != nil {
+		return fmt.Errorf("ListRunOnServer: %w", err)
+	}
+
+	var firstErr error
+	for i := range userExchanges {
+		ue := &userExchanges[i]
+		log := p.Log.WithFields(map[string]interface{}{
+			"user_id":     ue.UserID,
+			"exchange_id": ue.ExchangeID,
+		})
+
+		// Only Phemex exposes fills with closed PnL for now.
+		if ue.Exchange == nil || !strings.EqualFold(ue.Exchange.Name, "phemex") {
+			log.Debug("exchange not supported by pnl report, skipping")
+			continue
+		}
+
+		if err := p.reportUserExchange(ctx, ue, day); err != nil {
+			log.WithError(err).Error("daily pnl report failed")
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		cron.AddItems(ctx, 1)
+	}
+
+	return firstErr
+}
+
+func (p *PnLReport) reportUserExchange(ctx context.Context, ue *model.UserExchange, day time.Time) error {
+	creds, err := security.ResolveCredentials(ctx, ue)
+	if err != nil {
+		return err
+	}
+
+	client, err := p.newClient(creds)
+	if err != nil {
+		return err
+	}
+	from, to := report.DayBounds(day)
+
+	var fills []report.Fill
+	for _, symbol := range p.Config.Symbols {
+		symbol = strings.TrimSpace(symbol)
+		if symbol == "" {
+			continue
+		}
+		raw, err := client.ListFills(symbol)
+		if err != nil {
+			return fmt.Errorf("ListFills %s: %w", symbol, err)
+		}
+		fills = append(fills, fillsBetween(raw, from, to)...)
+	}
+
+	positions, err := client.GetPositionsUSDT()
+	if err != nil {
+		return fmt.Errorf("GetPositionsUSDT: %w", err)
+	}
+
+	stats := report.ComputeDailyStats(fills, unrealizedPnL(positions, p.Config.Symbols))
+
+	row := &model.DailyPnL{
+		UserID:        ue.UserID,
+		ExchangeID:    ue.ExchangeID,
+		Day:           from,
+		RealizedPnL:   stats.RealizedPnL,
+		UnrealizedPnL: stats.UnrealizedPnL,
+		Fees:          stats.Fees,
+		NetPnL:        stats.NetPnL,
+		MaxDrawdown:   stats.MaxDrawdown,
+		Fills:         stats.Fills,
+		Wins:          stats.Wins,
+		Losses:        stats.Losses,
+		WinRate:       stats.WinRate,
+	}
+	if err := p.repo.Upsert(ctx, row); err != nil {
+		return fmt.Errorf("save daily pnl: %w", err)
+	}
+
+	p.Log.WithFields(map[string]interface{}{
+		"user_id": ue.UserID,
+		"day":     from.Format("2006-01-02"),
+		"net_pnl": stats.NetPnL.String(),
+		"fills":   stats.Fills,
+	}).Info("daily pnl stored")
+
+	username := ""
+	if u, err := p.users.GetUserByID(ctx, ue.UserID); err == nil && u != nil {
+		username = u.Username
+	}
+
+	p.notifier.Notify(ctx, notify.Event{
+		Type:       notify.EventDailyPnL,
+		UserID:     ue.UserID,
+		Username:   username,
+		Exchange:   ue.Exchange.Name,
+		Message:    summary(stats),
+		OccurredAt: from,
+	})
+
+	return nil
+}
+
+// fillsBetween converts the Phemex fills executed in [from, to).
+func fillsBetween(raw []connectors.PhemexFill, from, to time.Time) []report.Fill {
+	var out []report.Fill
+	for _, f := range raw {
+		ts := time.Unix(0, f.TransactTimeNs).UTC()
+		if ts.Before(from) || !ts.Before(to) {
+			continue
+		}
+		out = append(out, report.Fill{
+			Time:      ts,
+			Symbol:    f.Symbol,
+			ClosedPnL: decimalOrZero(f.ClosedPnlRv),
+			Fee:       decimalOrZero(f.ExecFeeRv),
+		})
+	}
+	return out
+}
+
+// unrealizedPnL sums (mark - entry) * size over the open positions of symbols,
+// negated for shorts. It reflects the moment the job runs, not end of day.
+func unrealizedPnL(positions *connectors.GAccountPositions, symbols []string) decimal.Decimal {
+	total := decimal.Zero
+	if positions == nil {
+		return total
+	}
+
+	wanted := make(map[string]struct{}, len(symbols))
+	for _, s := range symbols {
+		wanted[strings.TrimSpace(s)] = struct{}{}
+	}
+
+	for _, pos := range positions.Positions {
+		if _, ok := wanted[pos.Symbol]; !ok {
+			continue
+		}
+		size := decimalOrZero(pos.SizeRq)
+		if size.IsZero() {
+			continue
+		}
+		diff := decimalOrZero(pos.MarkPriceRp).Sub(decimalOrZero(pos.AvgEntryPriceRp))
+		if strings.EqualFold(pos.Side, "Sell") {
+			diff = diff.Neg()
+		}
+		total = total.Add(diff.Mul(size.Abs()))
+	}
+	return total
+}
+
+func summary(s report.DailyStats) string {
+	return fmt.Sprintf(
+		"Net %s (realized %s, unrealized %s, fees %s). Fills %d, wins %d, losses %d, win rate %.0f%%. Max drawdown %s.",
+		s.NetPnL.StringFixed(2),
+		s.RealizedPnL.StringFixed(2),
+		s.UnrealizedPnL.StringFixed(2),
+		s.Fees.StringFixed(2),
+		s.Fills,
+		s.Wins,
+		s.Losses,
+		s.WinRate*100,
+		s.MaxDrawdown.StringFixed(2),
+	)
+}
+
+func decimalOrZero(value string) decimal.Decimal {
+	d, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
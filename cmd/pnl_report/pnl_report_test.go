@@ -0,0 +1,120 @@
+package pnl_report
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePnLClient struct {
+	fills     map[string][]connectors.PhemexFill
+	positions *connectors.GAccountPositions
+}
+
+func (c *fakePnLClient) ListFills(symbol string) ([]connectors.PhemexFill, error) {
+	return c.fills[symbol], nil
+}
+
+func (c *fakePnLClient) GetPositionsUSDT() (*connectors.GAccountPositions, error) {
+	return c.positions, nil
+}
+
+type fakePnLRepo struct{ rows []*model.DailyPnL }
+
+func (r *fakePnLRepo) Upsert(_ context.Context, row *model.DailyPnL) error {
+	r.rows = append(r.rows, row)
+	return nil
+}
+
+type fakeUserExchanges struct{ rows []model.UserExchange }
+
+func (f *fakeUserExchanges) ListRunOnServer(context.Context) ([]model.UserExchange, error) {
+	return f.rows, nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) GetUserByID(_ context.Context, id uint) (*model.User, error) {
+	return &model.User{ID: id, Username: "alice"}, nil
+}
+
+type fakeNotifier struct{ events []notify.Event }
+
+func (n *fakeNotifier) Notify(_ context.Context, ev notify.Event) { n.events = append(n.events, ev) }
+
+func TestReportDay(t *testing.T) {
+	now := time.Date(2025, 3, 5, 1, 2, 3, 0, time.UTC)
+
+	day, err := reportDay("", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC), day)
+
+	day, err = reportDay("2025-01-31", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), day)
+
+	_, err = reportDay("31/01/2025", now)
+	require.Error(t, err)
+}
+
+func TestRunStoresAndNotifiesPhemexAccounts(t *testing.T) {
+	day := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	client := &fakePnLClient{
+		fills: map[string][]connectors.PhemexFill{
+			"BTCUSDT": {
+				{Symbol: "BTCUSDT", ClosedPnlRv: "0", ExecFeeRv: "0.5", TransactTimeNs: day.Add(time.Hour).UnixNano()},
+				{Symbol: "BTCUSDT", ClosedPnlRv: "20", ExecFeeRv: "0.5", TransactTimeNs: day.Add(2 * time.Hour).UnixNano()},
+				// outside the reported day
+				{Symbol: "BTCUSDT", ClosedPnlRv: "99", ExecFeeRv: "1", TransactTimeNs: day.Add(25 * time.Hour).UnixNano()},
+			},
+		},
+		positions: &connectors.GAccountPositions{},
+	}
+	client.positions.Positions = append(client.positions.Positions, connectors.GPosition{Symbol: "BTCUSDT", Side: "Sell", SizeRq: "0.1", AvgEntryPriceRp: "100000", MarkPriceRp: "99000"})
+
+	repo := &fakePnLRepo{}
+	notifier := &fakeNotifier{}
+	p := &PnLReport{
+		Log:    logrus.WithField("cmd", "pnl_report"),
+		Config: &Config{Symbols: []string{"BTCUSDT"}},
+		userExchanges: &fakeUserExchanges{rows: []model.UserExchange{
+			{UserID: 1, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 1, Name: "phemex"}},
+			{UserID: 2, ExchangeID: 2, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 2, Name: "kraken"}},
+		}},
+		users:     fakeUsers{},
+		repo:      repo,
+		notifier:  notifier,
+		newClient: func(security.Credentials) (pnlClient, error) { return client, nil },
+	}
+
+	require.NoError(t, p.run(context.Background(), day))
+
+	require.Len(t, repo.rows, 1)
+	row := repo.rows[0]
+	require.Equal(t, uint(1), row.UserID)
+	require.Equal(t, day, row.Day)
+	require.Equal(t, "20", row.RealizedPnL.String())
+	require.Equal(t, "1", row.Fees.String())
+	require.Equal(t, "100", row.UnrealizedPnL.String())
+	require.Equal(t, "119", row.NetPnL.String())
+	require.Equal(t, 2, row.Fills)
+	require.Equal(t, 1, row.Wins)
+	require.Equal(t, 1.0, row.WinRate)
+
+	require.Len(t, notifier.events, 1)
+	require.Equal(t, notify.EventDailyPnL, notifier.events[0].Type)
+	require.Equal(t, "alice", notifier.events[0].Username)
+	require.Contains(t, notifier.events[0].Message, "Net 119.00")
+}
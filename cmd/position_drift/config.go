@@ -0,0 +1,33 @@
+package position_drift
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	BaseURL string `envconfig:"POSITION_DRIFT_BASE_URL" default:"https://api.phemex.com"`
+	// Lookback is how far back filled orders are read to rebuild the
+	// expected positions; entries older than that are not tracked.
+	Lookback time.Duration `envconfig:"POSITION_DRIFT_LOOKBACK" default:"720h"`
+	// Settle skips the symbols with an order younger than that, the
+	// executor may still be placing it.
+	Settle time.Duration `envconfig:"POSITION_DRIFT_SETTLE" default:"2m"`
+	// QtyTolerance is the relative size difference tolerated between the
+	// expected and the exchange position.
+	QtyTolerance float64 `envconfig:"POSITION_DRIFT_QTY_TOLERANCE" default:"0.01"`
+	// AutoCorrect records an exit for positions closed on the exchange and
+	// closes the exchange positions no order opened. Size mismatches are
+	// only reported.
+	AutoCorrect bool `envconfig:"POSITION_DRIFT_AUTO_CORRECT" default:"false"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,51 @@
+package position_drift
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// driftClient is the subset of the exchange connector used by the job.
+type driftClient interface {
+	GetPositionsUSDT() (*connectors.GAccountPositions, error)
+	PlaceOrder(symbol, side, posSide, qty, ordType string, reduce bool) (*connectors.APIResponse, error)
+}
+
+type userExchangeLister interface {
+	ListRunOnServer(ctx context.Context) ([]model.UserExchange, error)
+}
+
+type orderStore interface {
+	FindCreatedSince(ctx context.Context, since time.Time) ([]model.Order, error)
+	CreateWithAutoLogReason(ctx context.Context, order *model.Order, reason string) error
+}
+
+type exceptionRepository interface {
+	Create(ctx context.Context, exc *model.Exception) error
+}
+
+type userLookup interface {
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+type notifier interface {
+	Notify(ctx context.Context, ev notify.Event)
+}
+
+type PositionDrift struct {
+	Log    *logger.Entry
+	Config *Config
+
+	userExchanges userExchangeLister
+	orders        orderStore
+	exceptions    exceptionRepository
+	users         userLookup
+	notifier      notifier
+	newClient     func(creds security.Credentials) (driftClient, error)
+}
@@ -0,0 +1,389 @@
+package position_drift
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/cron"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func (p *PositionDrift) Start() error {
+	return p.Run(context.Background())
+}
+
+// Run compares the expected and exchange positions of every server-run
+// account.
+func (p *PositionDrift) Run(ctx context.Context) error {
+	p.Config = GetConfig()
+
+	p.userExchanges = repository.NewUserExchangeRepository()
+	p.orders = repository.NewOrderRepository()
+	p.exceptions = repository.NewExceptionRepository()
+	p.users = repository.NewUserRepository()
+	p.notifier = notify.NewNotifier()
+	p.newClient = func(creds security.Credentials) (driftClient, error) {
+		return connectors.NewClientFor(creds.APIKey, creds.APISecret, creds.Environment, p.Config.BaseURL)
+	}
+
+	return p.run(ctx, time.Now().UTC())
+}
+
+type accountKey struct {
+	userID     uint
+	exchangeID uint
+}
+
+type positionKey struct {
+	symbol  string
+	posSide string // Long or Short
+}
+
+// drift is a position whose exchange size differs from the size the filled
+// orders expect. A zero Actual is a position closed behind the bot's back
+// (e.g. a stop triggered on the exchange), a zero Expected one no order
+// opened.
+type drift struct {
+	Symbol   string
+	PosSide  string
+	Expected decimal.Decimal
+	Actual   decimal.Decimal
+}
+
+func (d drift) kind() string {
+	switch {
+	case d.Actual.IsZero():
+		return "missing"
+	case d.Expected.IsZero():
+		return "unexpected"
+	default:
+		return "size"
+	}
+}
+
+func (d drift) Error() string {
+	return fmt.Sprintf("%s %s position drift: orders expect %s, exchange has %s",
+		d.Symbol, d.PosSide, d.Expected.String(), d.Actual.String())
+}
+
+// exchangePosition is an open position as the exchange reports it.
+type exchangePosition struct {
+	size decimal.Decimal
+	// posSide is the posSide the exchange reported, Merged on one-way
+	// accounts; orders closing the position must send it back.
+	posSide string
+}
+
+// expectation is what the filled orders of one account say should be open.
+type expectation struct {
+	open map[positionKey][]model.Order
+	// settling are the symbols with an order younger than Config.Settle.
+	settling map[string]bool
+}
+
+// run compares the expected and the exchange positions of every server-run
+// Phemex account. Each drift is stored as a critical exception and notified
+// to the user, and corrected when Config.AutoCorrect is set. A failure on
+// one account is logged and the remaining accounts are still checked; the
+// first error is returned at the end.
+func (p *PositionDrift) run(ctx context.Context, now time.Time) error {
+	userExchanges, err := p.userExchanges.ListRunOnServer(ctx)
+	if err != nil {
+		return fmt.Errorf("ListRunOnServer: %w", err)
+	}
+	orders, err := p.orders.FindCreatedSince(ctx, now.Add(-p.Config.Lookback))
+	if err != nil {
+		return fmt.Errorf("FindCreatedSince: %w", err)
+	}
+	expected := expectations(orders, now.Add(-p.Config.Settle))
+
+	var firstErr error
+	for i := range userExchanges {
+		ue := &userExchanges[i]
+		log := p.Log.WithFields(map[string]interface{}{
+			"user_id":     ue.UserID,
+			"exchange_id": ue.ExchangeID,
+		})
+
+		// Only the Phemex connector reports positions in a shape we can
+		// compare; shadow accounts have no exchange position to compare.
+		if ue.Exchange == nil || !strings.EqualFold(ue.Exchange.Name, "phemex") {
+			log.Debug("exchange not supported by position drift check, skipping")
+			continue
+		}
+		if ue.Shadow {
+			log.Debug("shadow account, skipping position drift check")
+			continue
+		}
+
+		exp := expected[accountKey{ue.UserID, ue.ExchangeID}]
+		if err := p.check(ctx, ue, exp, now); err != nil {
+			log.WithError(err).Error("position drift check failed")
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		cron.AddItems(ctx, 1)
+	}
+	return firstErr
+}
+
+func (p *PositionDrift) check(ctx context.Context, ue *model.UserExchange, exp expectation, now time.Time) error {
+	creds, err := security.ResolveCredentials(ctx, ue)
+	if err != nil {
+		return err
+	}
+	client, err := p.newClient(creds)
+	if err != nil {
+		return err
+	}
+	positions, err := client.GetPositionsUSDT()
+	if err != nil {
+		return fmt.Errorf("GetPositionsUSDT: %w", err)
+	}
+
+	actual := exchangePositions(positions)
+	drifts := compare(exp, actual, p.Config.QtyTolerance)
+	if len(drifts) == 0 {
+		p.Log.WithField("user_id", ue.UserID).Info("no position drift")
+		return nil
+	}
+
+	var firstErr error
+	for _, d := range drifts {
+		correction := ""
+		if p.Config.AutoCorrect {
+			var err error
+			correction, err = p.correct(ctx, ue, client, d, exp.open[positionKey{d.Symbol, d.PosSide}], actual[positionKey{d.Symbol, d.PosSide}])
+			if err != nil {
+				correction = "failed: " + err.Error()
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		p.raise(ctx, ue, d, correction, now)
+	}
+	return firstErr
+}
+
+// correct aligns one side with the other: a position closed on the exchange
+// gets an exit order per open entry, a position no order opened is closed
+// with a reduce-only market order. Size mismatches are left to the user.
+func (p *PositionDrift) correct(ctx context.Context, ue *model.UserExchange, client driftClient, d drift, open []model.Order, pos exchangePosition) (string, error) {
+	switch d.kind() {
+	case "missing":
+		for _, entry := range open {
+			exit := &model.Order{
+				UserID:     ue.UserID,
+				ExchangeID: ue.ExchangeID,
+				ExternalID: entry.ExternalID,
+				Symbol:     entry.Symbol,
+				Side:       d.PosSide,
+				PosSide:    closeSide(d.PosSide),
+				OrderType:  "market",
+				Quantity:   entry.Quantity,
+				Status:     model.OrderExecutionStatusFilled,
+				OrderDir:   model.OrderDirectionExit,
+			}
+			reason := fmt.Sprintf("position closed on the exchange, recorded by the position drift check for entry %d", entry.ID)
+			if err := p.orders.CreateWithAutoLogReason(ctx, exit, reason); err != nil {
+				return "", fmt.Errorf("record exit of order %d: %w", entry.ID, err)
+			}
+		}
+		return fmt.Sprintf("recorded %d exit order(s)", len(open)), nil
+	case "unexpected":
+		resp, err := client.PlaceOrder(d.Symbol, closeSide(d.PosSide), pos.posSide, d.Actual.String(), "Market", true)
+		if err != nil {
+			return "", fmt.Errorf("close %s %s: %w", d.Symbol, d.PosSide, err)
+		}
+		if resp != nil && resp.Code != 0 {
+			return "", fmt.Errorf("close %s %s: code %d: %s", d.Symbol, d.PosSide, resp.Code, resp.Msg)
+		}
+		return "closed on the exchange", nil
+	default:
+		return "", nil
+	}
+}
+
+// raise stores d as a critical exception and notifies the user.
+func (p *PositionDrift) raise(ctx context.Context, ue *model.UserExchange, d drift, correction string, now time.Time) {
+	log := p.Log.WithFields(map[string]interface{}{
+		"user_id":    ue.UserID,
+		"symbol":     d.Symbol,
+		"pos_side":   d.PosSide,
+		"expected":   d.Expected.String(),
+		"actual":     d.Actual.String(),
+		"kind":       d.kind(),
+		"correction": correction,
+	})
+	log.Warn("position drift detected")
+
+	exchange := ue.Exchange.Name
+	contextData, _ := json.Marshal(map[string]interface{}{
+		"user_id":    ue.UserID,
+		"symbol":     d.Symbol,
+		"pos_side":   d.PosSide,
+		"expected":   d.Expected.String(),
+		"actual":     d.Actual.String(),
+		"kind":       d.kind(),
+		"correction": correction,
+	})
+	exc := &model.Exception{
+		Service:   "position_drift",
+		Module:    "position_drift",
+		Method:    "check",
+		Message:   d.Error(),
+		Exchange:  exchange,
+		Level:     model.ExceptionSeverityCritical,
+		Severity:  model.ExceptionSeverityCritical,
+		Category:  model.ExceptionCategoryDrift,
+		Context:   string(contextData),
+		CreatedAt: now,
+	}
+	if err := p.exceptions.Create(ctx, exc); err != nil {
+		log.WithError(err).Error("failed to store position drift exception")
+	}
+
+	username := ""
+	if u, err := p.users.GetUserByID(ctx, ue.UserID); err == nil && u != nil {
+		username = u.Username
+	}
+	message := "Position drift check"
+	if correction != "" {
+		message += " (" + correction + ")"
+	}
+	p.notifier.Notify(ctx, notify.Event{
+		Type:       notify.EventCriticalError,
+		UserID:     ue.UserID,
+		Username:   username,
+		Exchange:   exchange,
+		Symbol:     d.Symbol,
+		PosSide:    d.PosSide,
+		Message:    message,
+		Err:        d,
+		OccurredAt: now,
+	})
+}
+
+// expectations returns the open entries of each account, see
+// report.OpenEntries.
+func expectations(orders []model.Order, settledBefore time.Time) map[accountKey]expectation {
+	out := map[accountKey]expectation{}
+	get := func(k accountKey) expectation {
+		exp, ok := out[k]
+		if !ok {
+			exp = expectation{open: map[positionKey][]model.Order{}, settling: map[string]bool{}}
+			out[k] = exp
+		}
+		return exp
+	}
+
+	for _, o := range orders {
+		if !o.CreatedAt.Before(settledBefore) {
+			get(accountKey{o.UserID, o.ExchangeID}).settling[o.Symbol] = true
+		}
+	}
+	for _, entry := range report.OpenEntries(orders) {
+		side := positionSide(entry.PosSide, entry.Side)
+		exp := get(accountKey{entry.UserID, entry.ExchangeID})
+		k := positionKey{entry.Symbol, side}
+		exp.open[k] = append(exp.open[k], entry)
+	}
+	return out
+}
+
+// exchangePositions returns the open positions keyed by symbol and side.
+func exchangePositions(positions *connectors.GAccountPositions) map[positionKey]exchangePosition {
+	out := map[positionKey]exchangePosition{}
+	if positions == nil {
+		return out
+	}
+	for _, pos := range positions.Positions {
+		size, err := decimal.NewFromString(strings.TrimSpace(pos.SizeRq))
+		if err != nil || size.IsZero() {
+			continue
+		}
+		k := positionKey{pos.Symbol, positionSide(pos.PosSide, pos.Side)}
+		cur := out[k]
+		cur.size = cur.size.Add(size.Abs())
+		cur.posSide = pos.PosSide
+		out[k] = cur
+	}
+	return out
+}
+
+// compare lists the drifts between exp and actual, sorted by symbol and
+// side. Symbols still settling are left out.
+func compare(exp expectation, actual map[positionKey]exchangePosition, tolerance float64) []drift {
+	keys := map[positionKey]bool{}
+	for k := range exp.open {
+		keys[k] = true
+	}
+	for k := range actual {
+		keys[k] = true
+	}
+
+	var drifts []drift
+	for k := range keys {
+		if exp.settling[k.symbol] {
+			continue
+		}
+		want := decimal.Zero
+		for _, entry := range exp.open[k] {
+			want = want.Add(entry.Quantity.Abs())
+		}
+		have := actual[k].size
+		if want.IsZero() && have.IsZero() {
+			continue
+		}
+		if !want.IsZero() && !have.IsZero() {
+			diff := want.Sub(have).Abs().InexactFloat64()
+			if diff <= tolerance*want.InexactFloat64() {
+				continue
+			}
+		}
+		drifts = append(drifts, drift{Symbol: k.symbol, PosSide: k.posSide, Expected: want, Actual: have})
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Symbol != drifts[j].Symbol {
+			return drifts[i].Symbol < drifts[j].Symbol
+		}
+		return drifts[i].PosSide < drifts[j].PosSide
+	})
+	return drifts
+}
+
+// positionSide is Long or Short; one-way (Merged) positions take it from
+// the side they were opened with.
+func positionSide(posSide, side string) string {
+	switch {
+	case strings.EqualFold(posSide, "Long"):
+		return "Long"
+	case strings.EqualFold(posSide, "Short"):
+		return "Short"
+	case strings.EqualFold(side, "Sell"):
+		return "Short"
+	default:
+		return "Long"
+	}
+}
+
+func closeSide(posSide string) string {
+	if posSide == "Short" {
+		return "Buy"
+	}
+	return "Sell"
+}
@@ -0,0 +1,180 @@
+package position_drift
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type placedOrder struct {
+	symbol, side, posSide, qty string
+	reduce                     bool
+}
+
+type fakeClient struct {
+	positions *connectors.GAccountPositions
+	placed    []placedOrder
+}
+
+func (c *fakeClient) GetPositionsUSDT() (*connectors.GAccountPositions, error) {
+	return c.positions, nil
+}
+
+func (c *fakeClient) PlaceOrder(symbol, side, posSide, qty, _ string, reduce bool) (*connectors.APIResponse, error) {
+	c.placed = append(c.placed, placedOrder{symbol, side, posSide, qty, reduce})
+	return &connectors.APIResponse{Code: 0}, nil
+}
+
+type fakeUserExchanges struct{ rows []model.UserExchange }
+
+func (f *fakeUserExchanges) ListRunOnServer(context.Context) ([]model.UserExchange, error) {
+	return f.rows, nil
+}
+
+type fakeOrders struct {
+	rows    []model.Order
+	created []model.Order
+}
+
+func (f *fakeOrders) FindCreatedSince(context.Context, time.Time) ([]model.Order, error) {
+	return f.rows, nil
+}
+
+func (f *fakeOrders) CreateWithAutoLogReason(_ context.Context, order *model.Order, _ string) error {
+	f.created = append(f.created, *order)
+	return nil
+}
+
+type fakeExceptions struct{ rows []model.Exception }
+
+func (f *fakeExceptions) Create(_ context.Context, exc *model.Exception) error {
+	f.rows = append(f.rows, *exc)
+	return nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) GetUserByID(_ context.Context, id uint) (*model.User, error) {
+	return &model.User{ID: id, Username: "alice"}, nil
+}
+
+type fakeNotifier struct{ events []notify.Event }
+
+func (f *fakeNotifier) Notify(_ context.Context, ev notify.Event) {
+	f.events = append(f.events, ev)
+}
+
+func newTestDrift(t *testing.T, orders []model.Order, client *fakeClient, autoCorrect bool) (*PositionDrift, *fakeOrders, *fakeExceptions, *fakeNotifier) {
+	t.Helper()
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	store := &fakeOrders{rows: orders}
+	exceptions := &fakeExceptions{}
+	notifier := &fakeNotifier{}
+	p := &PositionDrift{
+		Log:    logrus.WithField("cmd", "position_drift"),
+		Config: &Config{Lookback: 30 * 24 * time.Hour, Settle: 2 * time.Minute, QtyTolerance: 0.01, AutoCorrect: autoCorrect},
+		userExchanges: &fakeUserExchanges{rows: []model.UserExchange{
+			{UserID: 1, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 1, Name: "phemex"}},
+			{UserID: 2, ExchangeID: 2, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 2, Name: "kraken"}},
+		}},
+		orders:     store,
+		exceptions: exceptions,
+		users:      fakeUsers{},
+		notifier:   notifier,
+		newClient:  func(security.Credentials) (driftClient, error) { return client, nil },
+	}
+	return p, store, exceptions, notifier
+}
+
+func entry(id uint, symbol, posSide, qty string, at time.Time) model.Order {
+	return model.Order{ID: id, UserID: 1, ExchangeID: 1, ExternalID: 100 + id, Symbol: symbol, PosSide: posSide,
+		Quantity: decimal.RequireFromString(qty), OrderDir: model.OrderDirectionEntry, Status: model.OrderExecutionStatusFilled, CreatedAt: at}
+}
+
+func TestRunReportsDrift(t *testing.T) {
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	orders := []model.Order{
+		// BTC long stopped out on the exchange
+		entry(1, "BTCUSDT", "Long", "0.1", now.Add(-time.Hour)),
+		// ETH long still open
+		entry(2, "ETHUSDT", "Long", "2", now.Add(-time.Hour)),
+		// SOL closed by the bot
+		entry(3, "SOLUSDT", "Short", "10", now.Add(-3*time.Hour)),
+		{ID: 4, UserID: 1, ExchangeID: 1, Symbol: "SOLUSDT", OrderDir: model.OrderDirectionExit, Status: model.OrderExecutionStatusFilled, CreatedAt: now.Add(-2 * time.Hour)},
+		// XRP just placed, the exchange may not show it yet
+		entry(5, "XRPUSDT", "Long", "100", now.Add(-time.Minute)),
+	}
+	positions := &connectors.GAccountPositions{}
+	positions.Positions = []connectors.GPosition{
+		{Symbol: "ETHUSDT", Side: "Buy", PosSide: "Long", SizeRq: "2.005"},
+		// one-way position nothing opened
+		{Symbol: "DOGEUSDT", Side: "Sell", PosSide: "Merged", SizeRq: "500"},
+		{Symbol: "ADAUSDT", Side: "None", PosSide: "Long", SizeRq: "0"},
+	}
+	client := &fakeClient{positions: positions}
+	p, store, exceptions, notifier := newTestDrift(t, orders, client, false)
+
+	require.NoError(t, p.run(context.Background(), now))
+
+	require.Len(t, exceptions.rows, 2)
+	require.Equal(t, "BTCUSDT Long position drift: orders expect 0.1, exchange has 0", exceptions.rows[0].Message)
+	require.Equal(t, model.ExceptionSeverityCritical, exceptions.rows[0].Severity)
+	require.Equal(t, model.ExceptionCategoryDrift, exceptions.rows[0].Category)
+	require.Equal(t, "DOGEUSDT Short position drift: orders expect 0, exchange has 500", exceptions.rows[1].Message)
+
+	require.Len(t, notifier.events, 2)
+	require.Equal(t, notify.EventCriticalError, notifier.events[0].Type)
+	require.Equal(t, "alice", notifier.events[0].Username)
+	require.Equal(t, "BTCUSDT", notifier.events[0].Symbol)
+
+	// nothing is corrected by default
+	require.Empty(t, store.created)
+	require.Empty(t, client.placed)
+}
+
+func TestRunAutoCorrects(t *testing.T) {
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	orders := []model.Order{
+		entry(1, "BTCUSDT", "Long", "0.1", now.Add(-2*time.Hour)),
+		entry(2, "BTCUSDT", "Long", "0.05", now.Add(-time.Hour)),
+		entry(3, "ETHUSDT", "Short", "2", now.Add(-time.Hour)),
+	}
+	positions := &connectors.GAccountPositions{}
+	positions.Positions = []connectors.GPosition{
+		// size mismatch: reported, not corrected
+		{Symbol: "ETHUSDT", Side: "Sell", PosSide: "Short", SizeRq: "1"},
+		{Symbol: "DOGEUSDT", Side: "Sell", PosSide: "Merged", SizeRq: "500"},
+	}
+	client := &fakeClient{positions: positions}
+	p, store, exceptions, notifier := newTestDrift(t, orders, client, true)
+
+	require.NoError(t, p.run(context.Background(), now))
+	require.Len(t, exceptions.rows, 3)
+	require.Len(t, notifier.events, 3)
+
+	// one exit per open entry of the missing position
+	require.Len(t, store.created, 2)
+	for _, exit := range store.created {
+		require.Equal(t, model.OrderDirectionExit, exit.OrderDir)
+		require.Equal(t, "BTCUSDT", exit.Symbol)
+		require.Equal(t, "Sell", exit.PosSide)
+	}
+	require.Equal(t, "0.1", store.created[0].Quantity.String())
+	require.Equal(t, "0.05", store.created[1].Quantity.String())
+
+	// the unexpected position is closed with a reduce-only order
+	require.Equal(t, []placedOrder{{"DOGEUSDT", "Buy", "Merged", "500", true}}, client.placed)
+	require.Contains(t, notifier.events[1].Message, "closed on the exchange")
+}
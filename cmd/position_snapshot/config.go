@@ -0,0 +1,23 @@
+package position_snapshot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	BaseURL string `envconfig:"POSITION_SNAPSHOT_BASE_URL" default:"https://api.phemex.com"`
+	// Bucket is the resolution snapshots are stored at; reruns within the
+	// same bucket replace the rows instead of adding new ones.
+	Bucket time.Duration `envconfig:"POSITION_SNAPSHOT_BUCKET" default:"1m"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,37 @@
+package position_snapshot
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// positionsClient is the subset of the exchange connector used by the job.
+type positionsClient interface {
+	GetPositionsUSDT() (*connectors.GAccountPositions, error)
+}
+
+type snapshotRepository interface {
+	SaveAll(ctx context.Context, rows []model.PositionSnapshot) error
+}
+
+type equityRepository interface {
+	Upsert(ctx context.Context, row *model.EquitySnapshot) error
+}
+
+type userExchangeLister interface {
+	ListRunOnServer(ctx context.Context) ([]model.UserExchange, error)
+}
+
+type PositionSnapshot struct {
+	Log    *logger.Entry
+	Config *Config
+
+	userExchanges userExchangeLister
+	repo          snapshotRepository
+	equity        equityRepository
+	newClient     func(creds security.Credentials) (positionsClient, error)
+}
@@ -0,0 +1,206 @@
+package position_snapshot
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/cron"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func (p *PositionSnapshot) Start() error {
+	return p.Run(context.Background())
+}
+
+// Run snapshots every server-run account.
+func (p *PositionSnapshot) Run(ctx context.Context) error {
+	p.Config = GetConfig()
+
+	p.userExchanges = repository.NewUserExchangeRepository()
+	p.repo = repository.NewPositionSnapshotRepository()
+	p.equity = repository.NewEquitySnapshotRepository()
+	p.newClient = func(creds security.Credentials) (positionsClient, error) {
+		return connectors.NewClientFor(creds.APIKey, creds.APISecret, creds.Environment, p.Config.BaseURL)
+	}
+
+	return p.run(ctx, time.Now().UTC())
+}
+
+// run snapshots every server-run user exchange, then stores the equity of
+// each user summed over their accounts. A failure on one account is logged
+// and the remaining accounts are still processed; the first error is
+// returned at the end.
+func (p *PositionSnapshot) run(ctx context.Context, now time.Time) error {
+	userExchanges, err := p.userExchanges.ListRunOnServer(ctx)
+	if err != nil {
+		return fmt.Errorf("ListRunOnServer: %w", err)
+	}
+
+	takenAt := now.UTC()
+	if p.Config.Bucket > 0 {
+		takenAt = takenAt.Truncate(p.Config.Bucket)
+	}
+
+	var (
+		firstErr error
+		users    []uint
+		equity   = map[uint]*model.EquitySnapshot{}
+		failed   = map[uint]bool{}
+	)
+	for i := range userExchanges {
+		ue := &userExchanges[i]
+		log := p.Log.WithFields(map[string]interface{}{
+			"user_id":     ue.UserID,
+			"exchange_id": ue.ExchangeID,
+		})
+
+		// Only the Phemex connector reports margin and mark prices for now.
+		if ue.Exchange == nil || !strings.EqualFold(ue.Exchange.Name, "phemex") {
+			log.Debug("exchange not supported by position snapshot, skipping")
+			continue
+		}
+
+		balance, upnl, err := p.snapshotUserExchange(ctx, ue, takenAt)
+		if err != nil {
+			log.WithError(err).Error("position snapshot failed")
+			failed[ue.UserID] = true
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		cron.AddItems(ctx, 1)
+
+		row, ok := equity[ue.UserID]
+		if !ok {
+			row = &model.EquitySnapshot{UserID: ue.UserID, TakenAt: takenAt, Balance: decimal.Zero, UnrealizedPnL: decimal.Zero}
+			equity[ue.UserID] = row
+			users = append(users, ue.UserID)
+		}
+		row.Balance = row.Balance.Add(balance)
+		row.UnrealizedPnL = row.UnrealizedPnL.Add(upnl)
+		row.Exchanges++
+	}
+
+	for _, userID := range users {
+		// A partial sum would show up as a drop in the equity curve.
+		if failed[userID] {
+			p.Log.WithField("user_id", userID).Warn("equity snapshot skipped, an account could not be read")
+			continue
+		}
+		row := equity[userID]
+		row.Equity = row.Balance.Add(row.UnrealizedPnL)
+		if err := p.equity.Upsert(ctx, row); err != nil {
+			p.Log.WithError(err).WithField("user_id", userID).Error("equity snapshot failed")
+			if firstErr == nil {
+				firstErr = fmt.Errorf("save equity snapshot: %w", err)
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// snapshotUserExchange stores the open positions of ue and returns the
+// account balance and the unrealized PnL of those positions.
+func (p *PositionSnapshot) snapshotUserExchange(ctx context.Context, ue *model.UserExchange, takenAt time.Time) (decimal.Decimal, decimal.Decimal, error) {
+	creds, err := security.ResolveCredentials(ctx, ue)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	client, err := p.newClient(creds)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	positions, err := client.GetPositionsUSDT()
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("GetPositionsUSDT: %w", err)
+	}
+
+	rows := snapshots(ue, positions, takenAt)
+	if err := p.repo.SaveAll(ctx, rows); err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("save position snapshots: %w", err)
+	}
+
+	p.Log.WithFields(map[string]interface{}{
+		"user_id":   ue.UserID,
+		"taken_at":  takenAt,
+		"positions": len(rows),
+	}).Info("position snapshot stored")
+
+	upnl := decimal.Zero
+	for _, row := range rows {
+		upnl = upnl.Add(row.UnrealizedPnL)
+	}
+	balance := decimal.Zero
+	if positions != nil {
+		balance = decimalOrZero(positions.Account.AccountBalanceRv)
+	}
+	return balance, upnl, nil
+}
+
+// snapshots converts the open positions; flat positions are left out.
+func snapshots(ue *model.UserExchange, positions *connectors.GAccountPositions, takenAt time.Time) []model.PositionSnapshot {
+	if positions == nil {
+		return nil
+	}
+
+	balance := decimalOrZero(positions.Account.AccountBalanceRv)
+	var rows []model.PositionSnapshot
+	for _, pos := range positions.Positions {
+		size := decimalOrZero(pos.SizeRq)
+		if size.IsZero() {
+			continue
+		}
+
+		entry := decimalOrZero(pos.AvgEntryPriceRp)
+		mark := decimalOrZero(pos.MarkPriceRp)
+		posSide := pos.PosSide
+		if posSide == "" {
+			posSide = "Merged"
+		}
+
+		upnl := decimalOrZero(pos.UnRealisedPnlRv)
+		if strings.TrimSpace(pos.UnRealisedPnlRv) == "" {
+			upnl = mark.Sub(entry).Mul(size.Abs())
+			if strings.EqualFold(pos.PosSide, "Short") || strings.EqualFold(pos.Side, "Sell") {
+				upnl = upnl.Neg()
+			}
+		}
+
+		rows = append(rows, model.PositionSnapshot{
+			UserID:           ue.UserID,
+			ExchangeID:       ue.ExchangeID,
+			Symbol:           pos.Symbol,
+			PosSide:          posSide,
+			TakenAt:          takenAt,
+			Side:             pos.Side,
+			Size:             size.Abs(),
+			EntryPrice:       entry,
+			MarkPrice:        mark,
+			Notional:         size.Abs().Mul(mark),
+			PositionMargin:   decimalOrZero(pos.PositionMarginRv),
+			UnrealizedPnL:    upnl,
+			Leverage:         decimalOrZero(pos.LeverageRr).Abs(),
+			LiquidationPrice: decimalOrZero(pos.LiquidationPriceRp),
+			AccountBalance:   balance,
+		})
+	}
+	return rows
+}
+
+func decimalOrZero(value string) decimal.Decimal {
+	d, err := decimal.NewFromString(strings.TrimSpace(value))
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
@@ -0,0 +1,137 @@
+package position_snapshot
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	positions *connectors.GAccountPositions
+	err       error
+}
+
+func (c *fakeClient) GetPositionsUSDT() (*connectors.GAccountPositions, error) {
+	return c.positions, c.err
+}
+
+type fakeRepo struct{ rows []model.PositionSnapshot }
+
+func (r *fakeRepo) SaveAll(_ context.Context, rows []model.PositionSnapshot) error {
+	r.rows = append(r.rows, rows...)
+	return nil
+}
+
+type fakeEquityRepo struct{ rows []model.EquitySnapshot }
+
+func (r *fakeEquityRepo) Upsert(_ context.Context, row *model.EquitySnapshot) error {
+	r.rows = append(r.rows, *row)
+	return nil
+}
+
+type fakeUserExchanges struct{ rows []model.UserExchange }
+
+func (f *fakeUserExchanges) ListRunOnServer(context.Context) ([]model.UserExchange, error) {
+	return f.rows, nil
+}
+
+func TestRunStoresOpenPhemexPositions(t *testing.T) {
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	positions := &connectors.GAccountPositions{}
+	positions.Account.AccountBalanceRv = "5000"
+	positions.Positions = []connectors.GPosition{
+		{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0.1", AvgEntryPriceRp: "100000", MarkPriceRp: "101000",
+			PositionMarginRv: "1000", LeverageRr: "-10", LiquidationPriceRp: "91000", UnRealisedPnlRv: "99.5"},
+		{Symbol: "BTCUSDT", Side: "Sell", PosSide: "Short", SizeRq: "0.2", AvgEntryPriceRp: "102000", MarkPriceRp: "101000"},
+		{Symbol: "ETHUSDT", Side: "None", PosSide: "Long", SizeRq: "0"},
+	}
+
+	repo := &fakeRepo{}
+	equity := &fakeEquityRepo{}
+	p := &PositionSnapshot{
+		Log:    logrus.WithField("cmd", "position_snapshot"),
+		Config: &Config{Bucket: time.Minute},
+		userExchanges: &fakeUserExchanges{rows: []model.UserExchange{
+			{UserID: 1, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 1, Name: "phemex"}},
+			{UserID: 2, ExchangeID: 2, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 2, Name: "kraken"}},
+		}},
+		repo:      repo,
+		equity:    equity,
+		newClient: func(security.Credentials) (positionsClient, error) { return &fakeClient{positions: positions}, nil },
+	}
+
+	now := time.Date(2025, 3, 4, 10, 15, 42, 0, time.UTC)
+	require.NoError(t, p.run(context.Background(), now))
+
+	require.Len(t, repo.rows, 2)
+	long, short := repo.rows[0], repo.rows[1]
+	require.Equal(t, time.Date(2025, 3, 4, 10, 15, 0, 0, time.UTC), long.TakenAt)
+	require.Equal(t, uint(1), long.UserID)
+	require.Equal(t, "Long", long.PosSide)
+	require.Equal(t, "10100", long.Notional.String())
+	require.Equal(t, "99.5", long.UnrealizedPnL.String())
+	require.Equal(t, "10", long.Leverage.String())
+	require.Equal(t, "91000", long.LiquidationPrice.String())
+	require.Equal(t, "5000", long.AccountBalance.String())
+
+	// Without unRealisedPnlRv the PnL is derived from the mark price.
+	require.Equal(t, "Short", short.PosSide)
+	require.Equal(t, "200", short.UnrealizedPnL.String())
+
+	require.Len(t, equity.rows, 1)
+	require.Equal(t, uint(1), equity.rows[0].UserID)
+	require.Equal(t, long.TakenAt, equity.rows[0].TakenAt)
+	require.Equal(t, "5299.5", equity.rows[0].Equity.String())
+	require.Equal(t, 1, equity.rows[0].Exchanges)
+}
+
+func TestRunContinuesAfterAccountFailure(t *testing.T) {
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	calls := 0
+	repo := &fakeRepo{}
+	equity := &fakeEquityRepo{}
+	p := &PositionSnapshot{
+		Log:    logrus.WithField("cmd", "position_snapshot"),
+		Config: &Config{Bucket: time.Minute},
+		userExchanges: &fakeUserExchanges{rows: []model.UserExchange{
+			{UserID: 1, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 1, Name: "phemex"}},
+			{UserID: 2, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 1, Name: "phemex"}},
+		}},
+		repo:   repo,
+		equity: equity,
+		newClient: func(security.Credentials) (positionsClient, error) {
+			calls++
+			if calls == 1 {
+				return &fakeClient{err: errors.New("HTTP 502")}, nil
+			}
+			positions := &connectors.GAccountPositions{}
+			positions.Positions = []connectors.GPosition{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1", MarkPriceRp: "100"}}
+			return &fakeClient{positions: positions}, nil
+		},
+	}
+
+	err = p.run(context.Background(), time.Now())
+	require.ErrorContains(t, err, "HTTP 502")
+	require.Len(t, repo.rows, 1)
+	require.Equal(t, uint(2), repo.rows[0].UserID)
+
+	// The failed user gets no partial equity point.
+	require.Len(t, equity.rows, 1)
+	require.Equal(t, uint(2), equity.rows[0].UserID)
+	require.Equal(t, "100", equity.rows[0].Equity.String())
+}
@@ -0,0 +1,24 @@
+package rebalance
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// MinTransfer is the smallest amount worth moving; smaller drifts from
+	// the targets are left alone.
+	MinTransfer   float64 `envconfig:"REBALANCE_MIN_TRANSFER" default:"10"`
+	PhemexBaseURL string  `envconfig:"REBALANCE_PHEMEX_BASE_URL" default:"https://api.phemex.com"`
+	// KucoinKeyVersion is sent as KC-API-KEY-VERSION.
+	KucoinKeyVersion string `envconfig:"REBALANCE_KUCOIN_KEY_VERSION" default:"2"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,82 @@
+package rebalance
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type targetStore interface {
+	ListByUser(ctx context.Context, userID uint) ([]model.AllocationTarget, error)
+	Upsert(ctx context.Context, t *model.AllocationTarget) error
+	Delete(ctx context.Context, userID, exchangeID uint, asset string) (bool, error)
+}
+
+type userExchangeLister interface {
+	List(ctx context.Context) ([]model.UserExchange, error)
+}
+
+type exchangeLookup interface {
+	FindByID(ctx context.Context, id uint) (*model.Exchange, error)
+	FindByName(ctx context.Context, name string) (*model.Exchange, error)
+}
+
+// balancesFunc returns the balances of an account keyed "<wallet>_<asset>",
+// as connectors.BalanceLister does.
+type balancesFunc func(ctx context.Context, exchange string, creds security.Credentials) (map[string]float64, error)
+
+// transferFunc moves t.Amount of t.Asset from t.From to t.To for userID.
+type transferFunc func(ctx context.Context, userID uint, t Transfer) error
+
+// Holding is the balance of an asset on an exchange, summed over its
+// wallets, next to the balance its target asks for.
+type Holding struct {
+	ExchangeID uint
+	Exchange   string
+	Asset      string
+	Balance    float64
+	TargetPct  float64
+	Target     float64
+}
+
+// Drift is how much the balance is above (positive) or below its target.
+func (h Holding) Drift() float64 {
+	return h.Balance - h.Target
+}
+
+// Transfer moves Amount of Asset from an exchange above its target to one
+// below it.
+type Transfer struct {
+	Asset          string
+	FromExchangeID uint
+	From           string
+	ToExchangeID   uint
+	To             string
+	Amount         float64
+	// Executed is set once the transfer went through; Error holds why it
+	// did not.
+	Executed bool
+	Error    string
+}
+
+// Report is the outcome of a rebalance of one user.
+type Report struct {
+	UserID    uint
+	Holdings  []Holding
+	Transfers []Transfer
+	// DryRun is true when the transfers were only proposed.
+	DryRun bool
+}
+
+type Rebalance struct {
+	Log    *logger.Entry
+	Config *Config
+
+	targets       targetStore
+	userExchanges userExchangeLister
+	exchanges     exchangeLookup
+	balances      balancesFunc
+	transfer      transferFunc
+}
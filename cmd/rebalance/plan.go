@@ -0,0 +1,142 @@
+package rebalance
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strategyexecutor/src/model"
+	"strings"
+)
+
+// pctTolerance absorbs rounding in targets entered as e.g. 33.33 / 33.33 / 33.34.
+const pctTolerance = 0.01
+
+// assetBalances sums "<wallet>_<asset>" balances per asset.
+func assetBalances(balances map[string]float64) map[string]float64 {
+	out := make(map[string]float64, len(balances))
+	for key, amount := range balances {
+		asset := key
+		if _, after, ok := strings.Cut(key, "_"); ok {
+			asset = after
+		}
+		out[strings.ToUpper(asset)] += amount
+	}
+	return out
+}
+
+// validateTargets checks that the targets of every asset add up to 100%.
+func validateTargets(targets []model.AllocationTarget) error {
+	sums := map[string]float64{}
+	var assets []string
+	for _, t := range targets {
+		asset := strings.ToUpper(t.Asset)
+		if _, ok := sums[asset]; !ok {
+			assets = append(assets, asset)
+		}
+		pct, _ := t.TargetPct.Float64()
+		if pct < 0 {
+			return fmt.Errorf("target of %s on exchange %d is negative", asset, t.ExchangeID)
+		}
+		sums[asset] += pct
+	}
+	for _, asset := range assets {
+		if math.Abs(sums[asset]-100) > pctTolerance {
+			return fmt.Errorf("targets for %s add up to %g%%, want 100%%", asset, sums[asset])
+		}
+	}
+	return nil
+}
+
+// plan compares the targets with the balances per exchange (asset ->
+// amount) and proposes the transfers that bring every exchange back to its
+// target. Surpluses are matched largest first against deficits so a user
+// gets as few transfers as possible; transfers below minTransfer are dropped.
+func plan(targets []model.AllocationTarget, balances map[uint]map[string]float64, minTransfer float64) ([]Holding, []Transfer) {
+	totals := map[string]float64{}
+	for _, t := range targets {
+		asset := strings.ToUpper(t.Asset)
+		totals[asset] += balances[t.ExchangeID][asset]
+	}
+
+	holdings := make([]Holding, 0, len(targets))
+	byAsset := map[string][]int{}
+	var assets []string
+	for _, t := range targets {
+		asset := strings.ToUpper(t.Asset)
+		pct, _ := t.TargetPct.Float64()
+		h := Holding{
+			ExchangeID: t.ExchangeID,
+			Exchange:   exchangeName(t),
+			Asset:      asset,
+			Balance:    balances[t.ExchangeID][asset],
+			TargetPct:  pct,
+			Target:     totals[asset] * pct / 100,
+		}
+		if _, ok := byAsset[asset]; !ok {
+			assets = append(assets, asset)
+		}
+		byAsset[asset] = append(byAsset[asset], len(holdings))
+		holdings = append(holdings, h)
+	}
+
+	var transfers []Transfer
+	for _, asset := range assets {
+		transfers = append(transfers, matchDrifts(holdings, byAsset[asset], minTransfer)...)
+	}
+	return holdings, transfers
+}
+
+type drift struct {
+	holding *Holding
+	amount  float64
+}
+
+func matchDrifts(holdings []Holding, idx []int, minTransfer float64) []Transfer {
+	var surplus, deficit []drift
+	for _, i := range idx {
+		h := &holdings[i]
+		switch d := h.Drift(); {
+		case d > 0:
+			surplus = append(surplus, drift{h, d})
+		case d < 0:
+			deficit = append(deficit, drift{h, -d})
+		}
+	}
+	largestFirst := func(ds []drift) {
+		sort.SliceStable(ds, func(i, j int) bool { return ds[i].amount > ds[j].amount })
+	}
+	largestFirst(surplus)
+	largestFirst(deficit)
+
+	var transfers []Transfer
+	for s, d := 0, 0; s < len(surplus) && d < len(deficit); {
+		amount := math.Min(surplus[s].amount, deficit[d].amount)
+		if amount >= minTransfer && amount > 0 {
+			from, to := surplus[s].holding, deficit[d].holding
+			transfers = append(transfers, Transfer{
+				Asset:          from.Asset,
+				FromExchangeID: from.ExchangeID,
+				From:           from.Exchange,
+				ToExchangeID:   to.ExchangeID,
+				To:             to.Exchange,
+				Amount:         amount,
+			})
+		}
+		surplus[s].amount -= amount
+		deficit[d].amount -= amount
+		if surplus[s].amount <= 0 {
+			s++
+		}
+		if deficit[d].amount <= 0 {
+			d++
+		}
+	}
+	return transfers
+}
+
+func exchangeName(t model.AllocationTarget) string {
+	if t.Exchange != nil {
+		return t.Exchange.Name
+	}
+	return fmt.Sprintf("exchange %d", t.ExchangeID)
+}
@@ -0,0 +1,44 @@
+package rebalance
+
+import (
+	"fmt"
+	"io"
+	"strategyexecutor/src/model"
+)
+
+// PrintTargets writes one line per allocation target.
+func PrintTargets(out io.Writer, rows []model.AllocationTarget) {
+	if len(rows) == 0 {
+		fmt.Fprintln(out, "No allocation targets.")
+		return
+	}
+	for _, t := range rows {
+		fmt.Fprintf(out, "user %d %s %s: %s%%\n", t.UserID, exchangeName(t), t.Asset, t.TargetPct.String())
+	}
+}
+
+// PrintReport writes the holdings against their targets, then the transfers.
+func PrintReport(out io.Writer, r *Report) {
+	fmt.Fprintf(out, "user %d allocation:\n", r.UserID)
+	for _, h := range r.Holdings {
+		fmt.Fprintf(out, "  %s %s balance=%.4f target=%.4f (%g%%) drift=%+.4f\n",
+			h.Exchange, h.Asset, h.Balance, h.Target, h.TargetPct, h.Drift())
+	}
+	if len(r.Transfers) == 0 {
+		fmt.Fprintln(out, "balanced: no transfer needed")
+		return
+	}
+	for _, t := range r.Transfers {
+		status := "proposed"
+		switch {
+		case t.Executed:
+			status = "done"
+		case t.Error != "":
+			status = "failed: " + t.Error
+		}
+		fmt.Fprintf(out, "  transfer %.4f %s %s -> %s %s\n", t.Amount, t.Asset, t.From, t.To, status)
+	}
+	if r.DryRun {
+		fmt.Fprintln(out, "dry run: nothing moved, pass --execute to transfer")
+	}
+}
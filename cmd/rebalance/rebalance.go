@@ -0,0 +1,210 @@
+package rebalance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrUnsupportedExchange is returned by FetchBalances for exchanges
+	// whose connector does not report balances.
+	ErrUnsupportedExchange = errors.New("exchange does not report balances")
+	// ErrTransferUnsupported is returned when executing a transfer no
+	// connector can carry out; the transfer has to be made by hand.
+	ErrTransferUnsupported = errors.New("transfers between exchanges are not supported by the connectors")
+)
+
+// New wires Rebalance to the main database and reads balances with the
+// exchange connectors.
+func New() *Rebalance {
+	config := GetConfig()
+	return &Rebalance{
+		Log:           logger.WithField("cmd", "rebalance"),
+		Config:        config,
+		targets:       repository.NewAllocationTargetRepository(),
+		userExchanges: repository.NewUserExchangeRepository(),
+		exchanges:     repository.NewExchangeRepository(),
+		balances: func(ctx context.Context, exchange string, creds security.Credentials) (map[string]float64, error) {
+			return FetchBalances(config, exchange, creds)
+		},
+		transfer: func(context.Context, uint, Transfer) error {
+			return ErrTransferUnsupported
+		},
+	}
+}
+
+// FetchBalances reads the balances of an account through its connector.
+func FetchBalances(cfg *Config, exchange string, creds security.Credentials) (map[string]float64, error) {
+	var (
+		lister connectors.BalanceLister
+		err    error
+	)
+	switch strings.ToLower(exchange) {
+	case "phemex":
+		lister, err = connectors.NewClientFor(creds.APIKey, creds.APISecret, creds.Environment, cfg.PhemexBaseURL)
+	case "kucoin":
+		lister, err = connectors.NewKucoinConnectorFor(creds.APIKey, creds.APISecret, creds.APIPassphrase, creds.KucoinKeyVersion(cfg.KucoinKeyVersion), creds.Environment)
+	default:
+		return nil, ErrUnsupportedExchange
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lister.GetAccountBalances()
+}
+
+// resolveExchange finds an exchange by numeric id or by name.
+func (r *Rebalance) resolveExchange(ctx context.Context, ref string) (*model.Exchange, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, errors.New("exchange is required")
+	}
+
+	var (
+		ex  *model.Exchange
+		err error
+	)
+	if id, convErr := strconv.ParseUint(ref, 10, 64); convErr == nil {
+		ex, err = r.exchanges.FindByID(ctx, uint(id))
+	} else {
+		ex, err = r.exchanges.FindByName(ctx, strings.ToLower(ref))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ex == nil {
+		return nil, fmt.Errorf("unknown exchange %q", ref)
+	}
+	return ex, nil
+}
+
+// SetTarget stores the percent of asset userID wants on exchange. A zero
+// percent removes the target.
+func (r *Rebalance) SetTarget(ctx context.Context, userID uint, exchange, asset string, pct float64) error {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	if userID == 0 || asset == "" {
+		return errors.New("user and asset are required")
+	}
+	if pct < 0 || pct > 100 {
+		return fmt.Errorf("target percent %g must be between 0 and 100", pct)
+	}
+	ex, err := r.resolveExchange(ctx, exchange)
+	if err != nil {
+		return err
+	}
+
+	log := r.Log.WithFields(logger.Fields{"user_id": userID, "exchange": ex.Name, "asset": asset, "target_pct": pct})
+	if pct == 0 {
+		if _, err := r.targets.Delete(ctx, userID, ex.ID, asset); err != nil {
+			return fmt.Errorf("delete allocation target: %w", err)
+		}
+		log.Info("allocation target removed")
+		return nil
+	}
+
+	target := &model.AllocationTarget{UserID: userID, ExchangeID: ex.ID, Asset: asset, TargetPct: decimal.NewFromFloat(pct)}
+	if err := r.targets.Upsert(ctx, target); err != nil {
+		return fmt.Errorf("save allocation target: %w", err)
+	}
+	log.Info("allocation target stored")
+	return nil
+}
+
+// Targets returns the allocation targets of userID.
+func (r *Rebalance) Targets(ctx context.Context, userID uint) ([]model.AllocationTarget, error) {
+	return r.targets.ListByUser(ctx, userID)
+}
+
+// Run compares the allocation targets of userID with the balances of their
+// exchanges and proposes the transfers that restore the targets. Unless
+// dryRun, the transfers are then executed one by one; a failed transfer is
+// recorded on the report and does not stop the others.
+func (r *Rebalance) Run(ctx context.Context, userID uint, dryRun bool) (*Report, error) {
+	targets, err := r.targets.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ListByUser: %w", err)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no allocation targets for user %d", userID)
+	}
+	if err := validateTargets(targets); err != nil {
+		return nil, err
+	}
+
+	balances, err := r.accountBalances(ctx, userID, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	holdings, transfers := plan(targets, balances, r.Config.MinTransfer)
+	report := &Report{UserID: userID, Holdings: holdings, Transfers: transfers, DryRun: dryRun}
+	log := r.Log.WithFields(logger.Fields{"user_id": userID, "transfers": len(transfers), "dry_run": dryRun})
+	if dryRun {
+		log.Info("rebalance planned")
+		return report, nil
+	}
+
+	var firstErr error
+	for i := range report.Transfers {
+		t := &report.Transfers[i]
+		if err := r.transfer(ctx, userID, *t); err != nil {
+			t.Error = err.Error()
+			log.WithError(err).WithFields(logger.Fields{"asset": t.Asset, "from": t.From, "to": t.To, "amount": t.Amount}).
+				Error("rebalance transfer failed")
+			if firstErr == nil {
+				firstErr = fmt.Errorf("transfer %g %s from %s to %s: %w", t.Amount, t.Asset, t.From, t.To, err)
+			}
+			continue
+		}
+		t.Executed = true
+	}
+	log.Warn("rebalance executed")
+	return report, firstErr
+}
+
+// accountBalances reads, once per exchange with a target, the balances of
+// userID summed per asset.
+func (r *Rebalance) accountBalances(ctx context.Context, userID uint, targets []model.AllocationTarget) (map[uint]map[string]float64, error) {
+	userExchanges, err := r.userExchanges.List(auth.WithUserID(ctx, userID))
+	if err != nil {
+		return nil, fmt.Errorf("list user exchanges: %w", err)
+	}
+	accounts := map[uint]*model.UserExchange{}
+	for i := range userExchanges {
+		if userExchanges[i].UserID == userID {
+			accounts[userExchanges[i].ExchangeID] = &userExchanges[i]
+		}
+	}
+
+	balances := map[uint]map[string]float64{}
+	for _, t := range targets {
+		if _, done := balances[t.ExchangeID]; done {
+			continue
+		}
+		ue, ok := accounts[t.ExchangeID]
+		if !ok || ue.Exchange == nil {
+			return nil, fmt.Errorf("user %d has no keys on %s", userID, exchangeName(t))
+		}
+		creds, err := security.ResolveCredentials(ctx, ue)
+		if err != nil {
+			return nil, fmt.Errorf("credentials for %s: %w", ue.Exchange.Name, err)
+		}
+		raw, err := r.balances(ctx, ue.Exchange.Name, creds)
+		if err != nil {
+			return nil, fmt.Errorf("balances on %s: %w", ue.Exchange.Name, err)
+		}
+		balances[t.ExchangeID] = assetBalances(raw)
+	}
+	return balances, nil
+}
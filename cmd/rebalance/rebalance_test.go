@@ -0,0 +1,234 @@
+package rebalance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTargets struct{ rows []model.AllocationTarget }
+
+func (f *fakeTargets) ListByUser(_ context.Context, userID uint) ([]model.AllocationTarget, error) {
+	var out []model.AllocationTarget
+	for _, t := range f.rows {
+		if t.UserID == userID {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeTargets) Upsert(_ context.Context, t *model.AllocationTarget) error {
+	for i := range f.rows {
+		if f.rows[i].UserID == t.UserID && f.rows[i].ExchangeID == t.ExchangeID && f.rows[i].Asset == t.Asset {
+			f.rows[i].TargetPct = t.TargetPct
+			return nil
+		}
+	}
+	f.rows = append(f.rows, *t)
+	return nil
+}
+
+func (f *fakeTargets) Delete(_ context.Context, userID, exchangeID uint, asset string) (bool, error) {
+	for i, t := range f.rows {
+		if t.UserID == userID && t.ExchangeID == exchangeID && t.Asset == asset {
+			f.rows = append(f.rows[:i], f.rows[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeUserExchanges struct{ rows []model.UserExchange }
+
+func (f *fakeUserExchanges) List(context.Context) ([]model.UserExchange, error) {
+	return f.rows, nil
+}
+
+type fakeExchanges map[uint]*model.Exchange
+
+func (f fakeExchanges) FindByID(_ context.Context, id uint) (*model.Exchange, error) {
+	return f[id], nil
+}
+
+func (f fakeExchanges) FindByName(_ context.Context, name string) (*model.Exchange, error) {
+	for _, ex := range f {
+		if ex.Name == name {
+			return ex, nil
+		}
+	}
+	return nil, nil
+}
+
+var (
+	phemex = &model.Exchange{ID: 1, Name: "phemex"}
+	kucoin = &model.Exchange{ID: 2, Name: "kucoin"}
+)
+
+func target(exchange *model.Exchange, asset string, pct float64) model.AllocationTarget {
+	return model.AllocationTarget{UserID: 1, ExchangeID: exchange.ID, Asset: asset, TargetPct: decimal.NewFromFloat(pct), Exchange: exchange}
+}
+
+func newTestRebalance(t *testing.T, targets []model.AllocationTarget, balances map[string]map[string]float64) *Rebalance {
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	return &Rebalance{
+		Log:     logrus.WithField("cmd", "rebalance"),
+		Config:  &Config{MinTransfer: 10},
+		targets: &fakeTargets{rows: targets},
+		userExchanges: &fakeUserExchanges{rows: []model.UserExchange{
+			{UserID: 1, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Exchange: phemex},
+			{UserID: 1, ExchangeID: 2, APIKeyHash: key, APISecretHash: secret, Exchange: kucoin},
+		}},
+		exchanges: fakeExchanges{1: phemex, 2: kucoin},
+		balances: func(_ context.Context, exchange string, _ security.Credentials) (map[string]float64, error) {
+			return balances[exchange], nil
+		},
+		transfer: func(context.Context, uint, Transfer) error { return ErrTransferUnsupported },
+	}
+}
+
+func TestRunProposesTransfersOnDryRun(t *testing.T) {
+	r := newTestRebalance(t,
+		[]model.AllocationTarget{target(phemex, "USDT", 60), target(kucoin, "USDT", 40)},
+		map[string]map[string]float64{
+			"phemex": {"futures_USDT": 200},
+			"kucoin": {"spot_USDT": 500, "futures_USDT": 300, "spot_BTC": 1},
+		},
+	)
+	r.transfer = func(context.Context, uint, Transfer) error {
+		t.Fatal("dry run must not transfer")
+		return nil
+	}
+
+	report, err := r.Run(context.Background(), 1, true)
+	require.NoError(t, err)
+	require.True(t, report.DryRun)
+
+	require.Len(t, report.Holdings, 2)
+	require.Equal(t, 200.0, report.Holdings[0].Balance)
+	require.InDelta(t, 600, report.Holdings[0].Target, 1e-9)
+	require.Equal(t, 800.0, report.Holdings[1].Balance, "KuCoin wallets are summed per asset")
+	require.InDelta(t, 400, report.Holdings[1].Drift(), 1e-9)
+
+	require.Len(t, report.Transfers, 1)
+	tr := report.Transfers[0]
+	require.Equal(t, "USDT", tr.Asset)
+	require.Equal(t, "kucoin", tr.From)
+	require.Equal(t, "phemex", tr.To)
+	require.InDelta(t, 400, tr.Amount, 1e-9)
+	require.False(t, tr.Executed)
+
+	var out bytes.Buffer
+	PrintReport(&out, report)
+	require.Contains(t, out.String(), "transfer 400.0000 USDT kucoin -> phemex proposed")
+	require.Contains(t, out.String(), "dry run")
+}
+
+func TestRunExecuteRecordsFailedTransfers(t *testing.T) {
+	r := newTestRebalance(t,
+		[]model.AllocationTarget{target(phemex, "USDT", 50), target(kucoin, "USDT", 50)},
+		map[string]map[string]float64{
+			"phemex": {"futures_USDT": 1000},
+			"kucoin": {"futures_USDT": 0},
+		},
+	)
+
+	report, err := r.Run(context.Background(), 1, false)
+	require.ErrorIs(t, err, ErrTransferUnsupported)
+	require.Len(t, report.Transfers, 1)
+	require.False(t, report.Transfers[0].Executed)
+	require.Contains(t, report.Transfers[0].Error, "not supported")
+
+	var sent []Transfer
+	r.transfer = func(_ context.Context, _ uint, tr Transfer) error {
+		sent = append(sent, tr)
+		return nil
+	}
+	report, err = r.Run(context.Background(), 1, false)
+	require.NoError(t, err)
+	require.True(t, report.Transfers[0].Executed)
+	require.Len(t, sent, 1)
+	require.InDelta(t, 500, sent[0].Amount, 1e-9)
+}
+
+func TestRunRejectsIncompleteTargets(t *testing.T) {
+	r := newTestRebalance(t, []model.AllocationTarget{target(phemex, "USDT", 60), target(kucoin, "USDT", 30)}, nil)
+	_, err := r.Run(context.Background(), 1, true)
+	require.ErrorContains(t, err, "add up to 90%")
+
+	_, err = r.Run(context.Background(), 2, true)
+	require.ErrorContains(t, err, "no allocation targets")
+}
+
+func TestRunFailsWithoutBalances(t *testing.T) {
+	r := newTestRebalance(t, []model.AllocationTarget{target(phemex, "USDT", 100)}, nil)
+	r.balances = func(context.Context, string, security.Credentials) (map[string]float64, error) {
+		return nil, errors.New("boom")
+	}
+	_, err := r.Run(context.Background(), 1, true)
+	require.ErrorContains(t, err, "balances on phemex: boom")
+}
+
+func TestPlanMatchesLargestDriftsAndSkipsDust(t *testing.T) {
+	okx := &model.Exchange{ID: 3, Name: "okx"}
+	targets := []model.AllocationTarget{
+		target(phemex, "USDT", 33.33), target(kucoin, "USDT", 33.33), target(okx, "USDT", 33.34),
+	}
+	require.NoError(t, validateTargets(targets))
+
+	_, transfers := plan(targets, map[uint]map[string]float64{
+		1: {"USDT": 900},
+		2: {"USDT": 55},
+		3: {"USDT": 45},
+	}, 10)
+	require.Len(t, transfers, 2)
+	require.Equal(t, "phemex", transfers[0].From)
+	require.Equal(t, "okx", transfers[0].To)
+	require.Equal(t, "kucoin", transfers[1].To)
+	total := transfers[0].Amount + transfers[1].Amount
+	require.InDelta(t, 900-333.3, total, 1e-6)
+
+	_, transfers = plan(targets, map[uint]map[string]float64{
+		1: {"USDT": 340}, 2: {"USDT": 330}, 3: {"USDT": 330},
+	}, 10)
+	require.Empty(t, transfers, "drifts under the minimum transfer are left alone")
+}
+
+func TestSetTarget(t *testing.T) {
+	r := newTestRebalance(t, nil, nil)
+	ctx := context.Background()
+
+	require.NoError(t, r.SetTarget(ctx, 1, "phemex", "usdt", 70))
+	require.NoError(t, r.SetTarget(ctx, 1, "2", "USDT", 30))
+	require.NoError(t, r.SetTarget(ctx, 1, "phemex", "USDT", 60))
+	rows, err := r.Targets(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	pct, _ := rows[0].TargetPct.Float64()
+	require.True(t, math.Abs(pct-60) < 1e-9)
+	require.Equal(t, "USDT", rows[0].Asset)
+
+	require.NoError(t, r.SetTarget(ctx, 1, "kucoin", "USDT", 0))
+	rows, _ = r.Targets(ctx, 1)
+	require.Len(t, rows, 1)
+
+	require.Error(t, r.SetTarget(ctx, 1, "phemex", "USDT", 120))
+	require.ErrorContains(t, r.SetTarget(ctx, 1, "kraken", "USDT", 10), "unknown exchange")
+
+	var out bytes.Buffer
+	PrintTargets(&out, []model.AllocationTarget{target(phemex, "USDT", 60)})
+	require.True(t, strings.HasPrefix(out.String(), "user 1 phemex USDT: 60%"))
+}
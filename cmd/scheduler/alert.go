@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// overdueAlerter raises a critical exception for a job that stopped
+// succeeding, e.g. a collector failing every run, and notifies the operator
+// account when one is configured.
+type overdueAlerter struct {
+	log        *logger.Entry
+	exceptions exceptionStore
+	notifier   notifier
+	userID     uint
+}
+
+func (a *overdueAlerter) Overdue(ctx context.Context, name string, lastSuccess time.Time, interval time.Duration) {
+	since := "since the scheduler started"
+	if !lastSuccess.IsZero() {
+		since = "since " + lastSuccess.UTC().Format(time.RFC3339)
+	}
+	message := fmt.Sprintf("job %s has not succeeded %s, it runs every %s", name, since, interval)
+	a.log.WithField("job", name).Error(message)
+
+	contextData, _ := json.Marshal(map[string]interface{}{
+		"job":          name,
+		"last_success": lastSuccess,
+		"interval":     interval.String(),
+	})
+	exc := &model.Exception{
+		Service:   "scheduler",
+		Module:    name,
+		Method:    "run",
+		Message:   message,
+		Level:     model.ExceptionSeverityCritical,
+		Severity:  model.ExceptionSeverityCritical,
+		Category:  model.ExceptionCategoryInternal,
+		Context:   string(contextData),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := a.exceptions.Create(ctx, exc); err != nil {
+		a.log.WithError(err).Error("failed to store overdue job exception")
+	}
+
+	if a.userID != 0 {
+		a.notifier.Notify(ctx, notify.Event{
+			Type:    notify.EventCriticalError,
+			UserID:  a.userID,
+			Message: "Scheduled job overdue",
+			Err:     errors.New(message),
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Config enables each periodic job and sets its cron schedule, see
+// cron.Parse. The defaults match the former CronJobs of the helm chart.
+type Config struct {
+	// LockTTL bounds how long a run that died keeps its job locked.
+	LockTTL time.Duration `envconfig:"SCHEDULER_LOCK_TTL" default:"1h"`
+	// A job without a successful run for StaleFactor times its interval
+	// raises a critical exception, notified to AlertUserID when set.
+	StaleFactor float64 `envconfig:"SCHEDULER_STALE_FACTOR" default:"2"`
+	AlertUserID uint    `envconfig:"SCHEDULER_ALERT_USER_ID" default:"0"`
+
+	OHLCVEnabled            bool   `envconfig:"SCHEDULE_OHLCV_ENABLED" default:"true"`
+	OHLCV                   string `envconfig:"SCHEDULE_OHLCV" default:"*/5 * * * *"`
+	FundingEnabled          bool   `envconfig:"SCHEDULE_FUNDING_ENABLED" default:"true"`
+	Funding                 string `envconfig:"SCHEDULE_FUNDING" default:"*/15 * * * *"`
+	ReconcilerEnabled       bool   `envconfig:"SCHEDULE_RECONCILER_ENABLED" default:"true"`
+	Reconciler              string `envconfig:"SCHEDULE_RECONCILER" default:"*/10 * * * *"`
+	PnLReportEnabled        bool   `envconfig:"SCHEDULE_PNL_REPORT_ENABLED" default:"true"`
+	PnLReport               string `envconfig:"SCHEDULE_PNL_REPORT" default:"15 0 * * *"`
+	PositionSnapshotEnabled bool   `envconfig:"SCHEDULE_POSITION_SNAPSHOT_ENABLED" default:"true"`
+	PositionSnapshot        string `envconfig:"SCHEDULE_POSITION_SNAPSHOT" default:"*/5 * * * *"`
+	KeyHealthEnabled        bool   `envconfig:"SCHEDULE_KEY_HEALTH_ENABLED" default:"true"`
+	KeyHealth               string `envconfig:"SCHEDULE_KEY_HEALTH" default:"0 */6 * * *"`
+	MaintenanceEnabled      bool   `envconfig:"SCHEDULE_MAINTENANCE_ENABLED" default:"true"`
+	Maintenance             string `envconfig:"SCHEDULE_MAINTENANCE" default:"*/15 * * * *"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type exceptionStore interface {
+	Create(ctx context.Context, exc *model.Exception) error
+}
+
+type notifier interface {
+	Notify(ctx context.Context, ev notify.Event)
+}
+
+// definition is a periodic job and its settings.
+type definition struct {
+	name    string
+	enabled bool
+	spec    string
+	run     func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	Log    *logger.Entry
+	Config *Config
+}
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strategyexecutor/cmd/funding"
+	"strategyexecutor/cmd/key_health"
+	"strategyexecutor/cmd/maintenance"
+	"strategyexecutor/cmd/ohlcvcrypto"
+	"strategyexecutor/cmd/pnl_report"
+	"strategyexecutor/cmd/position_drift"
+	"strategyexecutor/cmd/position_snapshot"
+	"strategyexecutor/src/cron"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/repository"
+	"syscall"
+)
+
+// Start runs the enabled jobs on their schedules until SIGINT or SIGTERM.
+func (s *Scheduler) Start() error {
+	s.Config = GetConfig()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c := cron.New(repository.NewScheduledJobRepository(), s.Config.LockTTL)
+	c.WatchOverdue(&overdueAlerter{
+		log:        s.Log,
+		exceptions: repository.NewExceptionRepository(),
+		notifier:   notify.NewNotifier(),
+		userID:     s.Config.AlertUserID,
+	}, s.Config.StaleFactor)
+	names, err := register(c, s.definitions())
+	if err != nil {
+		return err
+	}
+	s.Log.WithField("jobs", names).Info("scheduler started")
+	return c.Run(ctx)
+}
+
+// definitions lists the periodic jobs, each run as its former command
+// with the context of the scheduler run, which counts the items it handled.
+func (s *Scheduler) definitions() []definition {
+	cfg := s.Config
+	return []definition{
+		{"ohlcv_crypto", cfg.OHLCVEnabled, cfg.OHLCV, func(ctx context.Context) error {
+			return (&ohlcvcrypto.OHLCVCrypto{Log: s.Log.WithField("job", "ohlcv_crypto"), DB: database.MainDB}).Run(ctx)
+		}},
+		{"funding", cfg.FundingEnabled, cfg.Funding, func(ctx context.Context) error {
+			return (&funding.FundingCollector{Log: s.Log.WithField("job", "funding")}).Run(ctx)
+		}},
+		{"position_drift", cfg.ReconcilerEnabled, cfg.Reconciler, func(ctx context.Context) error {
+			return (&position_drift.PositionDrift{Log: s.Log.WithField("job", "position_drift")}).Run(ctx)
+		}},
+		{"pnl_report", cfg.PnLReportEnabled, cfg.PnLReport, func(ctx context.Context) error {
+			return (&pnl_report.PnLReport{Log: s.Log.WithField("job", "pnl_report")}).Run(ctx)
+		}},
+		{"position_snapshot", cfg.PositionSnapshotEnabled, cfg.PositionSnapshot, func(ctx context.Context) error {
+			return (&position_snapshot.PositionSnapshot{Log: s.Log.WithField("job", "position_snapshot")}).Run(ctx)
+		}},
+		{"key_health", cfg.KeyHealthEnabled, cfg.KeyHealth, func(ctx context.Context) error {
+			return (&key_health.KeyHealth{Log: s.Log.WithField("job", "key_health")}).Run(ctx)
+		}},
+		{"maintenance_sync", cfg.MaintenanceEnabled, cfg.Maintenance, func(ctx context.Context) error {
+			return (&maintenance.MaintenanceSync{Log: s.Log.WithField("job", "maintenance_sync")}).Run(ctx)
+		}},
+	}
+}
+
+// register adds the enabled definitions to c and returns their names. An
+// invalid schedule fails the start rather than silently never running.
+func register(c *cron.Scheduler, defs []definition) ([]string, error) {
+	var names []string
+	for _, d := range defs {
+		if !d.enabled {
+			continue
+		}
+		schedule, err := cron.Parse(d.spec)
+		if err != nil {
+			return nil, fmt.Errorf("schedule of %s: %w", d.name, err)
+		}
+		c.Add(cron.Job{Name: d.name, Schedule: schedule, Run: d.run})
+		names = append(names, d.name)
+	}
+	return names, nil
+}
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"strategyexecutor/src/cron"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRegister(t *testing.T) {
+	run := func(context.Context) error { return nil }
+	c := cron.New(nil, time.Hour)
+
+	names, err := register(c, []definition{
+		{"funding", true, "*/15 * * * *", run},
+		{"key_health", false, "not a schedule", run},
+		{"pnl_report", true, "@daily", run},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "funding" || names[1] != "pnl_report" {
+		t.Fatalf("expected the enabled jobs registered, got %v", names)
+	}
+
+	if _, err := register(c, []definition{{"funding", true, "*/15 * *", run}}); err == nil {
+		t.Fatal("expected an invalid schedule rejected")
+	}
+}
+
+func TestDefaultSchedulesParse(t *testing.T) {
+	s := &Scheduler{Config: GetConfig()}
+	names, err := register(cron.New(nil, time.Hour), s.definitions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 7 {
+		t.Fatalf("expected every job enabled by default, got %v", names)
+	}
+}
+
+type fakeExceptions []*model.Exception
+
+func (f *fakeExceptions) Create(_ context.Context, exc *model.Exception) error {
+	*f = append(*f, exc)
+	return nil
+}
+
+type fakeNotifier []notify.Event
+
+func (f *fakeNotifier) Notify(_ context.Context, ev notify.Event) {
+	*f = append(*f, ev)
+}
+
+func TestOverdueAlerter(t *testing.T) {
+	exceptions, notified := &fakeExceptions{}, &fakeNotifier{}
+	a := &overdueAlerter{log: logrus.WithField("cmd", "scheduler"), exceptions: exceptions, notifier: notified}
+	last := time.Date(2025, 3, 7, 10, 0, 0, 0, time.UTC)
+
+	a.Overdue(context.Background(), "ohlcv_crypto", last, 5*time.Minute)
+	if len(*exceptions) != 1 || (*exceptions)[0].Severity != model.ExceptionSeverityCritical || (*exceptions)[0].Module != "ohlcv_crypto" {
+		t.Fatalf("expected a critical exception, got %+v", *exceptions)
+	}
+	if want := "job ohlcv_crypto has not succeeded since 2025-03-07T10:00:00Z, it runs every 5m0s"; (*exceptions)[0].Message != want {
+		t.Fatalf("message = %q", (*exceptions)[0].Message)
+	}
+	if len(*notified) != 0 {
+		t.Fatal("expected no notification without an alert user")
+	}
+
+	a.userID = 1
+	a.Overdue(context.Background(), "funding", time.Time{}, 15*time.Minute)
+	if len(*notified) != 1 || (*notified)[0].UserID != 1 || (*notified)[0].Type != notify.EventCriticalError {
+		t.Fatalf("expected the alert user notified, got %+v", *notified)
+	}
+}
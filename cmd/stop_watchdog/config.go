@@ -0,0 +1,26 @@
+package stop_watchdog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	BaseURL string `envconfig:"STOP_WATCHDOG_BASE_URL" default:"https://api.phemex.com"`
+	// Lookback is how far back filled entries are read to find the stored
+	// stop level of the open positions.
+	Lookback time.Duration `envconfig:"STOP_WATCHDOG_LOOKBACK" default:"720h"`
+	// Settle skips the symbols with an order younger than that, the
+	// executor may still be placing its stop.
+	Settle time.Duration `envconfig:"STOP_WATCHDOG_SETTLE" default:"2m"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,59 @@
+package stop_watchdog
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// stopClient is the subset of the exchange connector used by the job.
+type stopClient interface {
+	GetPositionsUSDT() (*connectors.GAccountPositions, error)
+	ListActiveOrders(symbol string) ([]connectors.ActiveOrder, error)
+	GetOrder(symbol, orderID string) (*connectors.ClientOrder, error)
+	SetStopLossForOpenPosition(symbol, posSide, stopPxRp, triggerType string, closeOnTrigger bool) (*connectors.APIResponse, error)
+}
+
+type userExchangeLister interface {
+	ListRunOnServer(ctx context.Context) ([]model.UserExchange, error)
+}
+
+type orderStore interface {
+	FindCreatedSince(ctx context.Context, since time.Time) ([]model.Order, error)
+	UpdateBracket(ctx context.Context, orderID uint, stopOrderID string, takeProfitOrderID string) error
+}
+
+type stopOutStore interface {
+	Get(ctx context.Context, userID, exchangeID uint, symbol, posSide string) (*model.StopOut, error)
+	Upsert(ctx context.Context, s *model.StopOut) error
+}
+
+type exceptionRepository interface {
+	Create(ctx context.Context, exc *model.Exception) error
+}
+
+type userLookup interface {
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+type notifier interface {
+	Notify(ctx context.Context, ev notify.Event)
+}
+
+type StopWatchdog struct {
+	Log    *logger.Entry
+	Config *Config
+
+	userExchanges userExchangeLister
+	orders        orderStore
+	stopOuts      stopOutStore
+	exceptions    exceptionRepository
+	users         userLookup
+	notifier      notifier
+	newClient     func(creds security.Credentials) (stopClient, error)
+}
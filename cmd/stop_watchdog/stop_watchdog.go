@@ -0,0 +1,430 @@
+package stop_watchdog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func (s *StopWatchdog) Start() error {
+	s.Config = GetConfig()
+
+	s.userExchanges = repository.NewUserExchangeRepository()
+	s.orders = repository.NewOrderRepository()
+	s.stopOuts = repository.NewStopOutRepository()
+	s.exceptions = repository.NewExceptionRepository()
+	s.users = repository.NewUserRepository()
+	s.notifier = notify.NewNotifier()
+	s.newClient = func(creds security.Credentials) (stopClient, error) {
+		return connectors.NewClientFor(creds.APIKey, creds.APISecret, creds.Environment, s.Config.BaseURL)
+	}
+
+	return s.run(context.Background(), time.Now().UTC())
+}
+
+type accountKey struct {
+	userID     uint
+	exchangeID uint
+}
+
+type positionKey struct {
+	symbol  string
+	posSide string // Long or Short
+}
+
+// managed is what the orders of one account say about its open positions.
+type managed struct {
+	// entries is the latest open entry of each position; its StopLossPct is
+	// the stored stop level.
+	entries map[positionKey]model.Order
+	// settling are the symbols with an order younger than Config.Settle.
+	settling map[string]bool
+}
+
+// run checks that every open position of a managed symbol on the server-run
+// Phemex accounts has an active reduce-only stop. A missing stop is placed
+// again at the stored stop level, stored as a critical exception and
+// notified to the user; an active stop unknown to the entry is stored on it.
+// Managed positions found closed by their stored stop are recorded as
+// stop-outs for the signal filters. A failure on one account is logged and
+// the remaining accounts are still checked; the first error is returned at
+// the end.
+func (s *StopWatchdog) run(ctx context.Context, now time.Time) error {
+	userExchanges, err := s.userExchanges.ListRunOnServer(ctx)
+	if err != nil {
+		return fmt.Errorf("ListRunOnServer: %w", err)
+	}
+	orders, err := s.orders.FindCreatedSince(ctx, now.Add(-s.Config.Lookback))
+	if err != nil {
+		return fmt.Errorf("FindCreatedSince: %w", err)
+	}
+	accounts := managedPositions(orders, now.Add(-s.Config.Settle))
+
+	var firstErr error
+	for i := range userExchanges {
+		ue := &userExchanges[i]
+		log := s.Log.WithFields(map[string]interface{}{
+			"user_id":     ue.UserID,
+			"exchange_id": ue.ExchangeID,
+		})
+
+		// Only the Phemex connector lists resting stops for now; shadow
+		// accounts have no exchange position to protect.
+		if ue.Exchange == nil || !strings.EqualFold(ue.Exchange.Name, "phemex") {
+			log.Debug("exchange not supported by stop watchdog, skipping")
+			continue
+		}
+		if ue.Shadow {
+			log.Debug("shadow account, skipping stop watchdog")
+			continue
+		}
+
+		m, ok := accounts[accountKey{ue.UserID, ue.ExchangeID}]
+		if !ok {
+			continue
+		}
+		if err := s.check(ctx, ue, m, now); err != nil {
+			log.WithError(err).Error("stop watchdog failed")
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (s *StopWatchdog) check(ctx context.Context, ue *model.UserExchange, m managed, now time.Time) error {
+	creds, err := security.ResolveCredentials(ctx, ue)
+	if err != nil {
+		return err
+	}
+	client, err := s.newClient(creds)
+	if err != nil {
+		return err
+	}
+	positions, err := client.GetPositionsUSDT()
+	if err != nil {
+		return fmt.Errorf("GetPositionsUSDT: %w", err)
+	}
+	if positions == nil {
+		return nil
+	}
+
+	active := map[string][]connectors.ActiveOrder{}
+	open := map[positionKey]bool{}
+	var firstErr error
+	for _, pos := range positions.Positions {
+		size, err := decimal.NewFromString(strings.TrimSpace(pos.SizeRq))
+		if err != nil || size.IsZero() {
+			continue
+		}
+		side := positionSide(pos.PosSide, pos.Side)
+		open[positionKey{pos.Symbol, side}] = true
+		entry, ok := m.entries[positionKey{pos.Symbol, side}]
+		if !ok || m.settling[pos.Symbol] {
+			continue
+		}
+
+		orders, listed := active[pos.Symbol]
+		if !listed {
+			orders, err = client.ListActiveOrders(pos.Symbol)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("ListActiveOrders %s: %w", pos.Symbol, err)
+				}
+				continue
+			}
+			active[pos.Symbol] = orders
+		}
+		if stop, ok := activeStop(orders, pos.PosSide, side); ok {
+			if entry.StopOrderID == "" {
+				s.adoptStop(ctx, entry, stop.OrderID)
+			}
+			continue
+		}
+
+		status := s.storedStopStatus(client, entry)
+		outcome, err := s.replace(ctx, client, pos, entry)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		s.alert(ctx, ue, pos.Symbol, side, entry, status, outcome, err, now)
+	}
+	if err := s.recordStopOuts(ctx, ue, client, m, open, now); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// recordStopOuts records the managed positions no longer open whose stored
+// stop filled: their stop loss closed them, and the signal filters block
+// re-entries on that side for a while (see signalfilter.StopOutCooldown).
+// A stop-out already recorded for the same stop is not looked up again.
+func (s *StopWatchdog) recordStopOuts(ctx context.Context, ue *model.UserExchange, client stopClient, m managed, open map[positionKey]bool, now time.Time) error {
+	var firstErr error
+	for k, entry := range m.entries {
+		if open[k] || m.settling[k.symbol] || entry.StopOrderID == "" {
+			continue
+		}
+		previous, err := s.stopOuts.Get(ctx, ue.UserID, ue.ExchangeID, k.symbol, k.posSide)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("load stop-out of %s %s: %w", k.symbol, k.posSide, err)
+			}
+			continue
+		}
+		if previous != nil && previous.StopOrderID == entry.StopOrderID {
+			continue
+		}
+
+		order, err := client.GetOrder(k.symbol, entry.StopOrderID)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("GetOrder %s: %w", entry.StopOrderID, err)
+			}
+			continue
+		}
+		if order == nil || !strings.EqualFold(order.OrdStatus, "Filled") {
+			continue
+		}
+
+		stop := &model.StopOut{
+			UserID:       ue.UserID,
+			ExchangeID:   ue.ExchangeID,
+			Symbol:       k.symbol,
+			PosSide:      k.posSide,
+			EntryOrderID: entry.ID,
+			StopOrderID:  entry.StopOrderID,
+			StopPrice:    order.StopPxRp,
+			StoppedAt:    now,
+		}
+		if stop.StopPrice == "" {
+			stop.StopPrice = entry.StopLossPct.String()
+		}
+		if order.TransactTimeNs > 0 {
+			stop.StoppedAt = time.Unix(0, order.TransactTimeNs).UTC()
+		}
+		if err := s.stopOuts.Upsert(ctx, stop); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("store stop-out of %s %s: %w", k.symbol, k.posSide, err)
+			}
+			continue
+		}
+		s.Log.WithFields(map[string]interface{}{
+			"user_id":    ue.UserID,
+			"symbol":     k.symbol,
+			"pos_side":   k.posSide,
+			"order_id":   entry.ID,
+			"stopped_at": stop.StoppedAt,
+		}).Info("position stopped out")
+	}
+	return firstErr
+}
+
+// replace places the stop of pos again at the stop level stored on entry
+// and stores the ID of the new stop on it.
+func (s *StopWatchdog) replace(ctx context.Context, client stopClient, pos connectors.GPosition, entry model.Order) (string, error) {
+	if entry.StopLossPct.IsZero() {
+		return "", fmt.Errorf("no stored stop level for order %d", entry.ID)
+	}
+	stop := entry.StopLossPct.String()
+	resp, err := client.SetStopLossForOpenPosition(pos.Symbol, pos.PosSide, stop, connectors.TriggerByMarkPrice, true)
+	if err == nil && resp != nil {
+		err = resp.Err()
+	}
+	if err != nil {
+		return "", fmt.Errorf("re-place stop at %s: %w", stop, err)
+	}
+
+	if stopID := responseOrderID(resp); stopID != "" {
+		if err := s.orders.UpdateBracket(ctx, entry.ID, stopID, entry.TakeProfitOrderID); err != nil {
+			s.Log.WithError(err).WithField("order_id", entry.ID).Error("failed to store the re-placed stop ID")
+		}
+	}
+	return "stop re-placed at " + stop, nil
+}
+
+// adoptStop stores stopID as the stop of entry. Entries whose exits were
+// attached natively get their stop from the exchange once filled; when it
+// was not listed yet at the fill, its ID is only known from here, and the
+// stop-out of the position can only be told with it.
+func (s *StopWatchdog) adoptStop(ctx context.Context, entry model.Order, stopID string) {
+	if stopID == "" {
+		return
+	}
+	log := s.Log.WithFields(map[string]interface{}{
+		"order_id": entry.ID,
+		"stop_id":  stopID,
+	})
+	if err := s.orders.UpdateBracket(ctx, entry.ID, stopID, entry.TakeProfitOrderID); err != nil {
+		log.WithError(err).Error("failed to store the adopted stop ID")
+		return
+	}
+	log.Info("active stop adopted for the entry")
+}
+
+// storedStopStatus tells what became of the stop stored on entry, e.g.
+// Canceled or Rejected, empty when unknown.
+func (s *StopWatchdog) storedStopStatus(client stopClient, entry model.Order) string {
+	if entry.StopOrderID == "" {
+		return ""
+	}
+	order, err := client.GetOrder(entry.Symbol, entry.StopOrderID)
+	if err != nil {
+		s.Log.WithError(err).WithField("order_id", entry.StopOrderID).Warn("failed to look the stored stop up")
+		return ""
+	}
+	if order == nil {
+		return "not found"
+	}
+	return order.OrdStatus
+}
+
+// alert stores the unprotected position as a critical exception and
+// notifies the user.
+func (s *StopWatchdog) alert(ctx context.Context, ue *model.UserExchange, symbol, posSide string, entry model.Order, storedStatus, outcome string, err error, now time.Time) {
+	message := fmt.Sprintf("%s %s position had no active stop", symbol, posSide)
+	if storedStatus != "" {
+		message += fmt.Sprintf(" (stop %s %s)", entry.StopOrderID, storedStatus)
+	}
+	if outcome != "" {
+		message += ", " + outcome
+	}
+	if err != nil {
+		message += ", " + err.Error()
+	}
+
+	log := s.Log.WithFields(map[string]interface{}{
+		"user_id":  ue.UserID,
+		"symbol":   symbol,
+		"pos_side": posSide,
+		"order_id": entry.ID,
+	})
+	if err != nil {
+		log.WithError(err).Error("position left without stop")
+	} else {
+		log.Warn(message)
+	}
+
+	exchange := ue.Exchange.Name
+	contextData, _ := json.Marshal(map[string]interface{}{
+		"user_id":   ue.UserID,
+		"symbol":    symbol,
+		"pos_side":  posSide,
+		"order_id":  entry.ID,
+		"stop_loss": entry.StopLossPct.String(),
+		"stop_id":   entry.StopOrderID,
+		"status":    storedStatus,
+		"replaced":  err == nil,
+	})
+	exc := &model.Exception{
+		Service:   "stop_watchdog",
+		Module:    "stop_watchdog",
+		Method:    "check",
+		Message:   message,
+		Exchange:  exchange,
+		Level:     model.ExceptionSeverityCritical,
+		Severity:  model.ExceptionSeverityCritical,
+		Category:  model.ExceptionCategoryPosition,
+		Context:   string(contextData),
+		CreatedAt: now,
+	}
+	if createErr := s.exceptions.Create(ctx, exc); createErr != nil {
+		log.WithError(createErr).Error("failed to store stop watchdog exception")
+	}
+
+	username := ""
+	if u, lookupErr := s.users.GetUserByID(ctx, ue.UserID); lookupErr == nil && u != nil {
+		username = u.Username
+	}
+	s.notifier.Notify(ctx, notify.Event{
+		Type:       notify.EventCriticalError,
+		UserID:     ue.UserID,
+		Username:   username,
+		Exchange:   exchange,
+		Symbol:     symbol,
+		PosSide:    posSide,
+		OrderID:    entry.ID,
+		StopLoss:   entry.StopLossPct.InexactFloat64(),
+		Message:    "Stop-loss watchdog",
+		Err:        fmt.Errorf("%s", message),
+		OccurredAt: now,
+	})
+}
+
+// responseOrderID returns the exchange order ID of an order placement
+// response, empty when the response carries none.
+func responseOrderID(resp *connectors.APIResponse) string {
+	if resp == nil || len(resp.Data) == 0 {
+		return ""
+	}
+	var payload struct {
+		OrderID string `json:"orderID"`
+	}
+	if err := json.Unmarshal(resp.Data, &payload); err != nil {
+		return ""
+	}
+	return payload.OrderID
+}
+
+// managedPositions returns, per account, the latest open entry of each
+// position, see report.OpenEntries.
+func managedPositions(orders []model.Order, settledBefore time.Time) map[accountKey]managed {
+	out := map[accountKey]managed{}
+	get := func(k accountKey) managed {
+		m, ok := out[k]
+		if !ok {
+			m = managed{entries: map[positionKey]model.Order{}, settling: map[string]bool{}}
+			out[k] = m
+		}
+		return m
+	}
+
+	for _, o := range orders {
+		if !o.CreatedAt.Before(settledBefore) {
+			get(accountKey{o.UserID, o.ExchangeID}).settling[o.Symbol] = true
+		}
+	}
+	// OpenEntries keeps the creation order, later entries win.
+	for _, entry := range report.OpenEntries(orders) {
+		m := get(accountKey{entry.UserID, entry.ExchangeID})
+		m.entries[positionKey{entry.Symbol, positionSide(entry.PosSide, entry.Side)}] = entry
+	}
+	return out
+}
+
+// activeStop returns the reduce-only stop of orders closing the position,
+// false when there is none.
+func activeStop(orders []connectors.ActiveOrder, posSide, side string) (connectors.ActiveOrder, bool) {
+	for _, o := range orders {
+		if o.IsStop() && o.Closes(posSide, side) {
+			return o, true
+		}
+	}
+	return connectors.ActiveOrder{}, false
+}
+
+// positionSide is Long or Short; one-way (Merged) positions take it from
+// the side they were opened with.
+func positionSide(posSide, side string) string {
+	switch {
+	case strings.EqualFold(posSide, "Long"):
+		return "Long"
+	case strings.EqualFold(posSide, "Short"):
+		return "Short"
+	case strings.EqualFold(side, "Sell"):
+		return "Short"
+	default:
+		return "Long"
+	}
+}
@@ -0,0 +1,302 @@
+package stop_watchdog
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type placedStop struct{ symbol, posSide, stopPx string }
+
+type fakeClient struct {
+	positions *connectors.GAccountPositions
+	active    map[string][]connectors.ActiveOrder
+	listed    []string
+	placed    []placedStop
+	orders    map[string]connectors.ClientOrder
+}
+
+func (c *fakeClient) GetPositionsUSDT() (*connectors.GAccountPositions, error) {
+	return c.positions, nil
+}
+
+func (c *fakeClient) ListActiveOrders(symbol string) ([]connectors.ActiveOrder, error) {
+	c.listed = append(c.listed, symbol)
+	return c.active[symbol], nil
+}
+
+func (c *fakeClient) GetOrder(_, orderID string) (*connectors.ClientOrder, error) {
+	order, ok := c.orders[orderID]
+	if !ok {
+		return nil, nil
+	}
+	return &order, nil
+}
+
+func (c *fakeClient) SetStopLossForOpenPosition(symbol, posSide, stopPxRp, _ string, _ bool) (*connectors.APIResponse, error) {
+	c.placed = append(c.placed, placedStop{symbol, posSide, stopPxRp})
+	return &connectors.APIResponse{Code: 0, Data: []byte(`{"orderID":"sl-new"}`)}, nil
+}
+
+type fakeUserExchanges struct{ rows []model.UserExchange }
+
+func (f *fakeUserExchanges) ListRunOnServer(context.Context) ([]model.UserExchange, error) {
+	return f.rows, nil
+}
+
+type fakeOrders struct {
+	rows     []model.Order
+	brackets map[uint][2]string
+}
+
+func (f *fakeOrders) FindCreatedSince(context.Context, time.Time) ([]model.Order, error) {
+	return f.rows, nil
+}
+
+func (f *fakeOrders) UpdateBracket(_ context.Context, orderID uint, stopOrderID string, takeProfitOrderID string) error {
+	f.brackets[orderID] = [2]string{stopOrderID, takeProfitOrderID}
+	for i := range f.rows {
+		if f.rows[i].ID == orderID {
+			f.rows[i].StopOrderID, f.rows[i].TakeProfitOrderID = stopOrderID, takeProfitOrderID
+		}
+	}
+	return nil
+}
+
+type fakeStopOuts struct{ rows map[string]model.StopOut }
+
+func (f *fakeStopOuts) Get(_ context.Context, _, _ uint, symbol, posSide string) (*model.StopOut, error) {
+	row, ok := f.rows[symbol+" "+posSide]
+	if !ok {
+		return nil, nil
+	}
+	return &row, nil
+}
+
+func (f *fakeStopOuts) Upsert(_ context.Context, s *model.StopOut) error {
+	f.rows[s.Symbol+" "+s.PosSide] = *s
+	return nil
+}
+
+type fakeExceptions struct{ rows []model.Exception }
+
+func (f *fakeExceptions) Create(_ context.Context, exc *model.Exception) error {
+	f.rows = append(f.rows, *exc)
+	return nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) GetUserByID(_ context.Context, id uint) (*model.User, error) {
+	return &model.User{ID: id, Username: "alice"}, nil
+}
+
+type fakeNotifier struct{ events []notify.Event }
+
+func (f *fakeNotifier) Notify(_ context.Context, ev notify.Event) {
+	f.events = append(f.events, ev)
+}
+
+func entry(id uint, symbol, posSide, stop string, at time.Time) model.Order {
+	return model.Order{ID: id, UserID: 1, ExchangeID: 1, Symbol: symbol, PosSide: posSide, Quantity: decimal.NewFromInt(1),
+		StopLossPct: decimal.RequireFromString(stop), OrderDir: model.OrderDirectionEntry, Status: model.OrderExecutionStatusFilled, CreatedAt: at}
+}
+
+func withStopID(o model.Order, id string) model.Order {
+	o.StopOrderID = id
+	return o
+}
+
+func TestRunReplacesMissingStops(t *testing.T) {
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	orders := []model.Order{
+		// the stop moved by the trailing SL is the one stored on the entry
+		withStopID(entry(1, "BTCUSDT", "Long", "61000", now.Add(-time.Hour)), "sl-1"),
+		entry(2, "ETHUSDT", "Short", "2100", now.Add(-time.Hour)),
+		entry(3, "SOLUSDT", "Long", "0", now.Add(-time.Hour)),
+		// just opened, its stop may not be placed yet
+		entry(4, "XRPUSDT", "Long", "0.5", now.Add(-30*time.Second)),
+	}
+	positions := &connectors.GAccountPositions{}
+	positions.Positions = []connectors.GPosition{
+		{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"},
+		{Symbol: "ETHUSDT", Side: "Sell", PosSide: "Short", SizeRq: "2"},
+		{Symbol: "SOLUSDT", Side: "Buy", PosSide: "Long", SizeRq: "10"},
+		{Symbol: "XRPUSDT", Side: "Buy", PosSide: "Long", SizeRq: "100"},
+		// no order opened it: left to the position drift check
+		{Symbol: "DOGEUSDT", Side: "Buy", PosSide: "Long", SizeRq: "500"},
+	}
+	client := &fakeClient{positions: positions, active: map[string][]connectors.ActiveOrder{
+		// a take profit does not protect the position
+		"BTCUSDT": {{Symbol: "BTCUSDT", Side: "Sell", PosSide: "Long", OrdType: "MarketIfTouched", ReduceOnly: true}},
+		"ETHUSDT": {{Symbol: "ETHUSDT", Side: "Buy", PosSide: "Short", OrdType: "Stop", ReduceOnly: true, StopPxRp: "2100"}},
+	}, orders: map[string]connectors.ClientOrder{
+		"sl-1": {OrderID: "sl-1", OrdStatus: "Canceled"},
+	}}
+
+	store := &fakeOrders{rows: orders, brackets: map[uint][2]string{}}
+	exceptions := &fakeExceptions{}
+	notifier := &fakeNotifier{}
+	s := &StopWatchdog{
+		Log:    logrus.WithField("cmd", "stop_watchdog"),
+		Config: &Config{Lookback: 30 * 24 * time.Hour, Settle: 2 * time.Minute},
+		userExchanges: &fakeUserExchanges{rows: []model.UserExchange{
+			{UserID: 1, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 1, Name: "phemex"}},
+		}},
+		orders:     store,
+		exceptions: exceptions,
+		users:      fakeUsers{},
+		notifier:   notifier,
+		newClient:  func(security.Credentials) (stopClient, error) { return client, nil },
+	}
+
+	err = s.run(context.Background(), now)
+	require.ErrorContains(t, err, "no stored stop level for order 3")
+
+	require.Equal(t, []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}, client.listed)
+	require.Equal(t, []placedStop{{"BTCUSDT", "Long", "61000"}}, client.placed)
+	require.Equal(t, map[uint][2]string{1: {"sl-new", ""}}, store.brackets)
+
+	require.Len(t, exceptions.rows, 2)
+	require.Equal(t, "BTCUSDT Long position had no active stop (stop sl-1 Canceled), stop re-placed at 61000", exceptions.rows[0].Message)
+	require.Equal(t, model.ExceptionSeverityCritical, exceptions.rows[0].Severity)
+	require.Contains(t, exceptions.rows[1].Message, "SOLUSDT Long position had no active stop, no stored stop level")
+
+	require.Len(t, notifier.events, 2)
+	require.Equal(t, notify.EventCriticalError, notifier.events[0].Type)
+	require.Equal(t, uint(1), notifier.events[0].OrderID)
+	require.Equal(t, 61000.0, notifier.events[0].StopLoss)
+}
+
+func TestRunRecordsStopOuts(t *testing.T) {
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	triggered := time.Date(2025, 3, 4, 11, 42, 0, 0, time.UTC)
+	orders := []model.Order{
+		withStopID(entry(1, "BTCUSDT", "Long", "61000", now.Add(-time.Hour)), "sl-1"),
+		// closed by a take profit, its stop was cancelled
+		withStopID(entry(2, "ETHUSDT", "Short", "2100", now.Add(-time.Hour)), "sl-2"),
+		// already recorded
+		withStopID(entry(3, "SOLUSDT", "Long", "140", now.Add(-time.Hour)), "sl-3"),
+		// still open
+		withStopID(entry(4, "XRPUSDT", "Long", "0.5", now.Add(-time.Hour)), "sl-4"),
+	}
+	positions := &connectors.GAccountPositions{}
+	positions.Positions = []connectors.GPosition{
+		{Symbol: "XRPUSDT", Side: "Buy", PosSide: "Long", SizeRq: "100"},
+	}
+	client := &fakeClient{positions: positions, active: map[string][]connectors.ActiveOrder{
+		"XRPUSDT": {{Symbol: "XRPUSDT", Side: "Sell", PosSide: "Long", OrdType: "Stop", ReduceOnly: true, StopPxRp: "0.5"}},
+	}, orders: map[string]connectors.ClientOrder{
+		"sl-1": {OrderID: "sl-1", OrdStatus: "Filled", StopPxRp: "61000", TransactTimeNs: triggered.UnixNano()},
+		"sl-2": {OrderID: "sl-2", OrdStatus: "Canceled"},
+		"sl-3": {OrderID: "sl-3", OrdStatus: "Filled"},
+		"sl-4": {OrderID: "sl-4", OrdStatus: "Untriggered"},
+	}}
+	recorded := model.StopOut{UserID: 1, ExchangeID: 1, Symbol: "SOLUSDT", PosSide: "Long", StopOrderID: "sl-3", StoppedAt: now.Add(-2 * time.Hour)}
+	stopOuts := &fakeStopOuts{rows: map[string]model.StopOut{"SOLUSDT Long": recorded}}
+
+	s := &StopWatchdog{
+		Log:    logrus.WithField("cmd", "stop_watchdog"),
+		Config: &Config{Lookback: 30 * 24 * time.Hour, Settle: 2 * time.Minute},
+		userExchanges: &fakeUserExchanges{rows: []model.UserExchange{
+			{UserID: 1, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 1, Name: "phemex"}},
+		}},
+		orders:     &fakeOrders{rows: orders, brackets: map[uint][2]string{}},
+		stopOuts:   stopOuts,
+		exceptions: &fakeExceptions{},
+		users:      fakeUsers{},
+		notifier:   &fakeNotifier{},
+		newClient:  func(security.Credentials) (stopClient, error) { return client, nil },
+	}
+
+	require.NoError(t, s.run(context.Background(), now))
+	require.Empty(t, client.placed)
+	require.Len(t, stopOuts.rows, 2)
+	require.Equal(t, recorded, stopOuts.rows["SOLUSDT Long"])
+	require.Equal(t, model.StopOut{
+		UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Long",
+		EntryOrderID: 1, StopOrderID: "sl-1", StopPrice: "61000", StoppedAt: triggered,
+	}, stopOuts.rows["BTCUSDT Long"])
+}
+
+func TestRunRecordsNativeBracketStopOuts(t *testing.T) {
+	key, err := security.EncryptString("key")
+	require.NoError(t, err)
+	secret, err := security.EncryptString("secret")
+	require.NoError(t, err)
+
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	// the stop attached to the entry was not listed yet when it filled
+	orders := &fakeOrders{rows: []model.Order{
+		entry(1, "BTCUSDT", "Long", "61000", now.Add(-time.Hour)),
+	}, brackets: map[uint][2]string{}}
+	positions := &connectors.GAccountPositions{}
+	positions.Positions = []connectors.GPosition{
+		{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"},
+	}
+	client := &fakeClient{positions: positions, active: map[string][]connectors.ActiveOrder{
+		"BTCUSDT": {{OrderID: "sl-native", Symbol: "BTCUSDT", Side: "Sell", PosSide: "Long", OrdType: "Stop", CloseOnTrigger: true, StopPxRp: "61000"}},
+	}, orders: map[string]connectors.ClientOrder{}}
+	stopOuts := &fakeStopOuts{rows: map[string]model.StopOut{}}
+
+	s := &StopWatchdog{
+		Log:    logrus.WithField("cmd", "stop_watchdog"),
+		Config: &Config{Lookback: 30 * 24 * time.Hour, Settle: 2 * time.Minute},
+		userExchanges: &fakeUserExchanges{rows: []model.UserExchange{
+			{UserID: 1, ExchangeID: 1, APIKeyHash: key, APISecretHash: secret, Exchange: &model.Exchange{ID: 1, Name: "phemex"}},
+		}},
+		orders:     orders,
+		stopOuts:   stopOuts,
+		exceptions: &fakeExceptions{},
+		users:      fakeUsers{},
+		notifier:   &fakeNotifier{},
+		newClient:  func(security.Credentials) (stopClient, error) { return client, nil },
+	}
+
+	// the position is protected: its stop is adopted, nothing is re-placed
+	require.NoError(t, s.run(context.Background(), now))
+	require.Empty(t, client.placed)
+	require.Equal(t, [2]string{"sl-native", ""}, orders.brackets[1])
+	require.Empty(t, stopOuts.rows)
+
+	// the stop then fills and closes the position
+	positions.Positions = nil
+	client.active = nil
+	triggered := now.Add(20 * time.Minute)
+	client.orders["sl-native"] = connectors.ClientOrder{OrderID: "sl-native", OrdStatus: "Filled", StopPxRp: "61000", TransactTimeNs: triggered.UnixNano()}
+
+	require.NoError(t, s.run(context.Background(), now.Add(30*time.Minute)))
+	require.Equal(t, model.StopOut{
+		UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Long",
+		EntryOrderID: 1, StopOrderID: "sl-native", StopPrice: "61000", StoppedAt: triggered,
+	}, stopOuts.rows["BTCUSDT Long"])
+}
+
+func TestActiveStopOneWay(t *testing.T) {
+	orders := []connectors.ActiveOrder{{OrderID: "sl-1", Side: "Buy", PosSide: "Merged", OrdType: "Stop", CloseOnTrigger: true}}
+	stop, ok := activeStop(orders, "Merged", "Short")
+	require.True(t, ok)
+	require.Equal(t, "sl-1", stop.OrderID)
+	_, ok = activeStop(orders, "Merged", "Long")
+	require.False(t, ok)
+	_, ok = activeStop(orders, "Short", "Short")
+	require.False(t, ok)
+}
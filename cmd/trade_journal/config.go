@@ -0,0 +1,25 @@
+package trade_journal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// Lookback is how far back orders are re-paired on each run, it must cover
+	// the longest expected holding time so open trades get closed.
+	Lookback time.Duration `envconfig:"TRADE_JOURNAL_LOOKBACK" default:"336h"`
+	// ExcursionBackfill caps the closed trades without MAE/MFE measured per
+	// run, whatever their age.
+	ExcursionBackfill int `envconfig:"TRADE_JOURNAL_EXCURSION_BACKFILL" default:"500"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,90 @@
+package trade_journal
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/repository"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type TradeJournal struct {
+	Log    *logger.Entry
+	Config *Config
+}
+
+func (t *TradeJournal) Start() error {
+	t.Config = GetConfig()
+
+	trades := repository.NewTradeRepository()
+	candles := repository.NewOHLCVRepositoryRepository()
+	builder := &report.JournalBuilder{
+		Orders:     repository.NewOrderRepository(),
+		Executions: repository.NewPhemexOrderRepository(),
+		Candles:    candles,
+		Trades:     trades,
+	}
+	backfill := &report.ExcursionBackfill{
+		Trades:  trades,
+		Candles: candles,
+	}
+	tracker := &report.LossStreakTracker{
+		Trades:   trades,
+		Streaks:  repository.NewLossStreakRepository(),
+		Settings: repository.NewSignalFilterSettingsRepository(),
+		Notifier: notify.NewNotifier(),
+	}
+
+	now := time.Now().UTC()
+	written, err := builder.Build(context.Background(), now.Add(-t.Config.Lookback), now)
+	if err != nil {
+		return err
+	}
+
+	t.Log.WithField("trades", written).Info("trade journal updated")
+
+	measured, err := backfill.Run(context.Background(), t.Config.ExcursionBackfill)
+	if err != nil {
+		return err
+	}
+	if measured > 0 {
+		t.Log.WithField("trades", measured).Info("trade excursions backfilled")
+	}
+
+	closed, err := trades.List(context.Background(), repository.TradeFilter{
+		Status: model.TradeStatusClosed,
+		From:   now.Add(-t.Config.Lookback),
+	})
+	if err != nil {
+		return err
+	}
+	r := report.SummarizeR(closed)
+	t.Log.WithFields(map[string]interface{}{
+		"trades":     r.Trades,
+		"unmeasured": r.Unmeasured,
+		"total_r":    r.TotalR,
+		"expectancy": r.Expectancy,
+		"avg_win_r":  r.AvgWinR,
+		"avg_loss_r": r.AvgLossR,
+	}).Info("R multiples over the lookback")
+
+	e := report.SummarizeExcursions(closed)
+	t.Log.WithFields(map[string]interface{}{
+		"trades":           e.Trades,
+		"avg_mae_r":        e.AvgMAER,
+		"avg_mfe_r":        e.AvgMFER,
+		"winner_mae_r_p90": e.WinnerMAERP90,
+		"mfe_r_p50":        e.MFERP50,
+	}).Info("excursions over the lookback")
+
+	streaks, err := tracker.Refresh(context.Background(), now.Add(-t.Config.Lookback), now)
+	if err != nil {
+		return err
+	}
+
+	t.Log.WithField("streaks", streaks).Info("loss streaks refreshed")
+	return nil
+}
@@ -0,0 +1,27 @@
+package trailing_stops
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// Every is the candle interval the stops are trailed on, once per
+	// closed candle; Delay after the close leaves the collector time to
+	// store it.
+	Every time.Duration `envconfig:"TRAILING_STOP_EVERY" default:"1m"`
+	Delay time.Duration `envconfig:"TRAILING_STOP_DELAY" default:"10s"`
+	// Lookback is how far back filled entries are read; older positions
+	// are not trailed.
+	Lookback time.Duration `envconfig:"TRAILING_STOP_LOOKBACK" default:"720h"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
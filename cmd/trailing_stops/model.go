@@ -0,0 +1,10 @@
+package trailing_stops
+
+import (
+	logger "github.com/sirupsen/logrus"
+)
+
+type TrailingStops struct {
+	Log    *logger.Entry
+	Config *Config
+}
@@ -0,0 +1,35 @@
+package trailing_stops
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strategyexecutor/src/controller"
+	"strategyexecutor/src/executors"
+	"strategyexecutor/src/repository"
+	"syscall"
+)
+
+// Start trails the stops of every open position on each closed candle until
+// SIGINT or SIGTERM.
+func (t *TrailingStops) Start() error {
+	t.Config = GetConfig()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	service := &controller.TrailingStops{
+		Accounts:  repository.NewUserExchangeRepository(),
+		Users:     repository.NewUserRepository(),
+		Orders:    repository.NewOrderRepository(),
+		Candles:   repository.NewOHLCVRepositoryRepository(),
+		NewClient: executors.PhemexConnector,
+		Lookback:  t.Config.Lookback,
+
+		Maintenance: repository.NewMaintenanceWindowRepository(),
+	}
+	t.Log.WithFields(map[string]interface{}{
+		"every": t.Config.Every,
+		"delay": t.Config.Delay,
+	}).Info("trailing stops started")
+	return service.Run(ctx, t.Config.Every, t.Config.Delay)
+}
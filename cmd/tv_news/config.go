@@ -7,6 +7,10 @@ import (
 )
 
 type Config struct {
+	RSSFeeds              []string `envconfig:"NEWS_RSS_FEEDS"`
+	RSSCurrency           string   `envconfig:"NEWS_RSS_CURRENCY" default:"BTC"`
+	CryptoPanicToken      string   `envconfig:"CRYPTOPANIC_TOKEN"`
+	CryptoPanicCurrencies []string `envconfig:"CRYPTOPANIC_CURRENCIES" default:"BTC,ETH"`
 }
 
 func GetConfig() *Config {
@@ -4,7 +4,10 @@ import (
 	"context"
 	"strategyexecutor/src/connectors"
 	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
 	"strategyexecutor/src/repository"
+	"strategyexecutor/src/sentiment"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -15,27 +18,156 @@ func (t *TVNews) Start() error {
 		logrus.WithError(err).Fatal("Failed to connect to database")
 	}
 
-	client := connectors.NewClientTV(nil)
+	t.Config = GetConfig()
 
 	ctx := context.Background()
 
+	repo := repository.NewTradingViewRepository()
+
+	watchlist, err := repo.ListWatchlist(ctx, true)
+	if err != nil {
+		return err
+	}
+
 	// A reasonable window: yesterday → tomorrow
 	from := time.Now().Add(-24 * time.Hour).UTC()
 	to := time.Now().Add(7 * 24 * time.Hour).UTC()
 
-	evs, err := client.FetchImportantEvents(ctx, from, to, []string{"US"})
+	evs, err := fetchAll(ctx, t.newsSources(watchlist), from, to)
 	if err != nil {
 		return err
 	}
 
-	logrus.Infof("Fetched %d events", len(evs))
+	evs = filterByWatchlist(evs, watchlist)
 
-	repo := repository.NewTradingViewRepository()
+	logrus.WithField("watchlist", len(watchlist)).Infof("Keeping %d events after watchlist filter", len(evs))
 
 	// save into DB
 	if err := repo.SaveTradingViewNewsEvents(ctx, evs); err != nil {
 		return err
 	}
 
+	// score each event and persist the score next to it
+	scorer := sentiment.NewRuleScorer(sentiment.DefaultRules())
+	if err := scoreEvents(ctx, scorer, repo, evs); err != nil {
+		return err
+	}
+
 	return nil
 }
+
+// newsSources returns TradingView plus every optional source enabled in the config.
+func (t *TVNews) newsSources(watchlist []model.TVNewsWatchlist) []connectors.NewsSource {
+	sources := []connectors.NewsSource{
+		connectors.NewTradingViewSource(nil, watchlistCountries(watchlist)),
+	}
+
+	for _, feed := range t.Config.RSSFeeds {
+		feed = strings.TrimSpace(feed)
+		if feed == "" {
+			continue
+		}
+		sources = append(sources, connectors.NewRSSSource(nil, feed, t.Config.RSSCurrency, 0))
+	}
+
+	if t.Config.CryptoPanicToken != "" {
+		sources = append(sources, connectors.NewCryptoPanicSource(nil, "", t.Config.CryptoPanicToken, t.Config.CryptoPanicCurrencies))
+	}
+
+	return sources
+}
+
+// fetchAll collects events from every source. TradingView is the primary
+// source and its failure aborts the run; failures of the additional sources
+// are logged and skipped.
+func fetchAll(ctx context.Context, sources []connectors.NewsSource, from, to time.Time) ([]model.Event, error) {
+	var out []model.Event
+
+	for _, src := range sources {
+		evs, err := src.FetchEvents(ctx, from, to)
+		if err != nil {
+			if src.Name() == model.NewsSourceTradingView {
+				return nil, err
+			}
+			logrus.WithError(err).WithField("source", src.Name()).Error("failed to fetch news source, skipping")
+			continue
+		}
+
+		logrus.WithField("source", src.Name()).Infof("Fetched %d events", len(evs))
+		out = append(out, evs...)
+	}
+
+	return out, nil
+}
+
+type sentimentScoreStore interface {
+	UpdateSentimentScore(ctx context.Context, tvEventID string, score float64, scorer string) error
+}
+
+// scoreEvents runs scorer over every fetched event and stores the result.
+// A scorer failure on one event is logged and skipped so one bad event does
+// not leave the rest unscored.
+func scoreEvents(ctx context.Context, scorer sentiment.Scorer, store sentimentScoreStore, evs []model.Event) error {
+	for _, ev := range evs {
+		m := model.NewTradingViewNewsEventFromEvent(ev)
+
+		score, err := scorer.Score(ctx, m)
+		if err != nil {
+			logrus.WithError(err).WithField("tv_event_id", m.TVEventID).Warn("failed to score event")
+			continue
+		}
+
+		if err := store.UpdateSentimentScore(ctx, m.TVEventID, score, scorer.Name()); err != nil {
+			return err
+		}
+	}
+
+	logrus.WithField("scorer", scorer.Name()).Infof("Scored %d events", len(evs))
+
+	return nil
+}
+
+// watchlistCountries returns the distinct countries of the watchlist, or US
+// when the watchlist is empty.
+func watchlistCountries(watchlist []model.TVNewsWatchlist) []string {
+	seen := map[string]bool{}
+	var countries []string
+	for _, w := range watchlist {
+		c := strings.ToUpper(strings.TrimSpace(w.Country))
+		if c == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+		countries = append(countries, c)
+	}
+	if len(countries) == 0 {
+		return []string{"US"}
+	}
+	return countries
+}
+
+// filterByWatchlist keeps only TradingView events whose ticker is on the
+// watchlist. An empty watchlist keeps every event, and events from other
+// sources are always kept.
+func filterByWatchlist(evs []model.Event, watchlist []model.TVNewsWatchlist) []model.Event {
+	if len(watchlist) == 0 {
+		return evs
+	}
+
+	tickers := make(map[string]bool, len(watchlist))
+	for _, w := range watchlist {
+		tickers[strings.ToUpper(strings.TrimSpace(w.Ticker))] = true
+	}
+
+	out := make([]model.Event, 0, len(evs))
+	for _, ev := range evs {
+		if ev.NewsSource != "" && ev.NewsSource != model.NewsSourceTradingView {
+			out = append(out, ev)
+			continue
+		}
+		if tickers[strings.ToUpper(strings.TrimSpace(ev.Ticker))] {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package tv_news
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterByWatchlist(t *testing.T) {
+	evs := []model.Event{
+		{ID: "1", Ticker: "ECONOMICS:USCPI"},
+		{ID: "2", Ticker: "ECONOMICS:USNFP"},
+		{ID: "3", Ticker: ""},
+	}
+
+	require.Len(t, filterByWatchlist(evs, nil), 3)
+
+	out := filterByWatchlist(evs, []model.TVNewsWatchlist{{Ticker: "economics:uscpi", Country: "US"}})
+	require.Len(t, out, 1)
+	require.Equal(t, "1", out[0].ID)
+}
+
+func TestWatchlistCountries(t *testing.T) {
+	require.Equal(t, []string{"US"}, watchlistCountries(nil))
+	require.Equal(t, []string{"US", "EU"}, watchlistCountries([]model.TVNewsWatchlist{
+		{Ticker: "A", Country: "us"},
+		{Ticker: "B", Country: "EU"},
+		{Ticker: "C", Country: "US"},
+	}))
+}
+
+type fakeNewsSource struct {
+	name string
+	evs  []model.Event
+	err  error
+}
+
+func (f *fakeNewsSource) Name() string { return f.name }
+
+func (f *fakeNewsSource) FetchEvents(_ context.Context, _ time.Time, _ time.Time) ([]model.Event, error) {
+	return f.evs, f.err
+}
+
+func TestFetchAll(t *testing.T) {
+	now := time.Now()
+	tv := &fakeNewsSource{name: model.NewsSourceTradingView, evs: []model.Event{{ID: "1"}}}
+	rss := &fakeNewsSource{name: model.NewsSourceRSS, err: errors.New("feed down")}
+	cp := &fakeNewsSource{name: model.NewsSourceCryptoPanic, evs: []model.Event{{ID: "cryptopanic:2", NewsSource: model.NewsSourceCryptoPanic}}}
+
+	evs, err := fetchAll(context.Background(), []connectors.NewsSource{tv, rss, cp}, now, now)
+	require.NoError(t, err)
+	require.Len(t, evs, 2)
+
+	// non TradingView events bypass the ticker watchlist
+	out := filterByWatchlist(evs, []model.TVNewsWatchlist{{Ticker: "ECONOMICS:USCPI"}})
+	require.Len(t, out, 1)
+	require.Equal(t, "cryptopanic:2", out[0].ID)
+
+	tv.err = errors.New("tv down")
+	_, err = fetchAll(context.Background(), []connectors.NewsSource{tv, cp}, now, now)
+	require.Error(t, err)
+}
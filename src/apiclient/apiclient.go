@@ -0,0 +1,209 @@
+// Package apiclient calls the admin routes of the API server with a session
+// token, so that the admin CLIs mutate keys and orders through the audited
+// server path instead of with database credentials.
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strategyexecutor/src/killswitch"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error is an error answer of the server.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("server answered %d: %s", e.Status, e.Message)
+}
+
+// Client calls the API server at BaseURL as the user of Token.
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+func New(baseURL, token string, timeout time.Duration) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: timeout},
+	}
+}
+
+// KeyRequest are the exchange keys of a user.
+type KeyRequest struct {
+	security.Credentials
+	OrderSizePercent int    `json:"order_size_percent"`
+	SkipValidation   bool   `json:"skip_validation"`
+	Environment      string `json:"environment"`
+}
+
+// do sends body as JSON and decodes the answer into out unless nil. Answers
+// with a status in accept are decoded, others are returned as an *Error.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}, accept ...int) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if len(accept) == 0 {
+		accept = []int{http.StatusOK, http.StatusNoContent}
+	}
+	for _, status := range accept {
+		if resp.StatusCode != status {
+			continue
+		}
+		if out == nil || resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+
+	var answer struct {
+		Error string `json:"error"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(raw, &answer) != nil || answer.Error == "" {
+		answer.Error = strings.TrimSpace(string(raw))
+	}
+	return &Error{Status: resp.StatusCode, Message: answer.Error}
+}
+
+// Login opens a session as userName.
+func (c *Client) Login(ctx context.Context, userName, password string) (*Session, error) {
+	var s Session
+	body := map[string]string{"user_name": userName, "password": password}
+	if err := c.do(ctx, http.MethodPost, "/auth/login", body, &s); err != nil {
+		return nil, err
+	}
+	s.Server = c.BaseURL
+	return &s, nil
+}
+
+// Logout revokes the token of c.
+func (c *Client) Logout(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/auth/logout", nil, nil)
+}
+
+func userPath(userID uint, rest ...string) string {
+	parts := []string{"/api/users", strconv.FormatUint(uint64(userID), 10)}
+	for _, p := range rest {
+		parts = append(parts, url.PathEscape(p))
+	}
+	return strings.Join(parts, "/")
+}
+
+// SetKey validates and stores the keys of userID on exchange.
+func (c *Client) SetKey(ctx context.Context, userID uint, exchange string, req KeyRequest) (*model.UserExchange, error) {
+	var ue model.UserExchange
+	if err := c.do(ctx, http.MethodPut, userPath(userID, "keys", exchange), req, &ue); err != nil {
+		return nil, err
+	}
+	return &ue, nil
+}
+
+// SetRunOnServer switches server-side execution of userID on exchange.
+func (c *Client) SetRunOnServer(ctx context.Context, userID uint, exchange string, on bool) error {
+	body := map[string]bool{"run_on_server": on}
+	return c.do(ctx, http.MethodPut, userPath(userID, "keys", exchange, "run-on-server"), body, nil)
+}
+
+// DeleteKey removes the keys of userID on exchange.
+func (c *Client) DeleteKey(ctx context.Context, userID uint, exchange string) error {
+	return c.do(ctx, http.MethodDelete, userPath(userID, "keys", exchange), nil, nil)
+}
+
+func stopPath(path string, userID uint) string {
+	if userID == 0 {
+		return path
+	}
+	return path + "?user_id=" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// EmergencyStop halts trading of userID, of every user when 0. The result
+// is returned with the error of a stop that failed on some account.
+func (c *Client) EmergencyStop(ctx context.Context, userID uint, flatten bool, reason string) (*killswitch.Result, error) {
+	var res killswitch.Result
+	body := map[string]interface{}{"flatten": flatten, "reason": reason}
+	err := c.do(ctx, http.MethodPost, stopPath("/api/emergency-stop", userID), body, &res, http.StatusOK, http.StatusMultiStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// EmergencyRelease lifts the halt of userID, the global one when 0.
+func (c *Client) EmergencyRelease(ctx context.Context, userID uint) (bool, error) {
+	var res struct {
+		Released bool `json:"released"`
+	}
+	if err := c.do(ctx, http.MethodPost, stopPath("/api/emergency-stop/release", userID), nil, &res); err != nil {
+		return false, err
+	}
+	return res.Released, nil
+}
+
+// RetryOrder re-dispatches the signal of failed order id, a dry run unless
+// execute, and decodes the retry plan into plan.
+func (c *Client) RetryOrder(ctx context.Context, id uint, execute bool, plan interface{}) error {
+	path := "/api/orders/" + strconv.FormatUint(uint64(id), 10) + "/retry"
+	if execute {
+		path += "?execute=true"
+	}
+	return c.do(ctx, http.MethodPost, path, nil, plan)
+}
+
+func notesPath(orderID uint) string {
+	return "/api/orders/" + strconv.FormatUint(uint64(orderID), 10) + "/notes"
+}
+
+// AddOrderNote attaches body to order id, written by the user of c.
+func (c *Client) AddOrderNote(ctx context.Context, id uint, body string) (*model.OrderNote, error) {
+	var note model.OrderNote
+	req := map[string]string{"body": body}
+	if err := c.do(ctx, http.MethodPost, notesPath(id), req, &note, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// OrderNotes returns the notes of order id, oldest first.
+func (c *Client) OrderNotes(ctx context.Context, id uint) ([]model.OrderNote, error) {
+	var notes []model.OrderNote
+	if err := c.do(ctx, http.MethodGet, notesPath(id), nil, &notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
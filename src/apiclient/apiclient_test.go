@@ -0,0 +1,91 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	var gotAuth, gotPath string
+	var gotBody map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth, gotPath = r.Header.Get("Authorization"), r.URL.RequestURI()
+		gotBody = nil
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		switch r.URL.Path {
+		case "/auth/login":
+			_, _ = w.Write([]byte(`{"token":"tok","user_id":3,"role":"admin","expires_at":"2026-03-01T10:00:00Z"}`))
+		case "/api/users/4/keys/phemex":
+			_, _ = w.Write([]byte(`{"user_id":4,"order_size_percent":5}`))
+		case "/api/emergency-stop":
+			w.WriteHeader(http.StatusMultiStatus)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"error":"failed to retry order: not failed"}`))
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	s, err := New(srv.URL+"/", "", time.Second).Login(ctx, "alice", "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Token != "tok" || s.UserID != 3 || s.Server != srv.URL || gotAuth != "" || gotBody["user_name"] != "alice" {
+		t.Fatalf("session = %+v, auth = %q, body = %v", s, gotAuth, gotBody)
+	}
+
+	c := New(s.Server, s.Token, time.Second)
+	ue, err := c.SetKey(ctx, 4, "phemex", KeyRequest{OrderSizePercent: 5})
+	if err != nil || ue.UserID != 4 || gotAuth != "Bearer tok" || gotBody["order_size_percent"] != float64(5) {
+		t.Fatalf("set key = %+v, %v, auth = %q, body = %v", ue, err, gotAuth, gotBody)
+	}
+
+	if _, err := c.EmergencyStop(ctx, 4, true, "drill"); err != nil || gotPath != "/api/emergency-stop?user_id=4" {
+		t.Fatalf("emergency stop: %v, path = %s", err, gotPath)
+	}
+
+	err = c.RetryOrder(ctx, 7, true, &struct{}{})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnprocessableEntity || apiErr.Message != "failed to retry order: not failed" {
+		t.Fatalf("retry error = %v", err)
+	}
+	if gotPath != "/api/orders/7/retry?execute=true" {
+		t.Fatalf("retry path = %s", gotPath)
+	}
+}
+
+func TestSessionFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "session.json")
+
+	s, err := LoadSession(path)
+	if err != nil || s != nil {
+		t.Fatalf("missing session = %+v, %v", s, err)
+	}
+
+	expires := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	if err := SaveSession(path, &Session{Server: "http://api", Token: "tok", ExpiresAt: expires}); err != nil {
+		t.Fatal(err)
+	}
+	s, err = LoadSession(path)
+	if err != nil || s.Token != "tok" || !s.ExpiresAt.Equal(expires) {
+		t.Fatalf("loaded session = %+v, %v", s, err)
+	}
+	if s.Expired(expires.Add(-time.Minute)) || !s.Expired(expires) {
+		t.Fatal("session must expire at ExpiresAt")
+	}
+
+	if err := RemoveSession(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveSession(path); err != nil {
+		t.Fatalf("removing a missing session: %v", err)
+	}
+}
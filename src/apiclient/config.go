@@ -0,0 +1,23 @@
+package apiclient
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// SessionFile keeps the session of login between commands,
+	// ~/.strategyexecutor/session.json when empty.
+	SessionFile string        `envconfig:"ADMIN_SESSION_FILE" default:""`
+	Timeout     time.Duration `envconfig:"ADMIN_API_TIMEOUT" default:"60s"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,75 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Session is a login to the API server, kept on disk between commands.
+type Session struct {
+	Server    string    `json:"server"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserID    uint      `json:"user_id"`
+	Role      string    `json:"role"`
+}
+
+// Expired reports whether the token of s stopped authenticating at now.
+func (s *Session) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// SessionPath is where the session is kept: path when set, else
+// ~/.strategyexecutor/session.json.
+func SessionPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home directory: %w", err)
+	}
+	return filepath.Join(home, ".strategyexecutor", "session.json"), nil
+}
+
+// LoadSession reads the session at path, (nil, nil) when there is none.
+func LoadSession(path string) (*Session, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Session
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("read session %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// SaveSession writes s at path, readable by its owner only since the token
+// acts as the user.
+func SaveSession(path string, s *Session) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o600)
+}
+
+// RemoveSession deletes the session at path, if any.
+func RemoveSession(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Decisions an approval link carries.
+const (
+	ApprovalApprove = "approve"
+	ApprovalReject  = "reject"
+)
+
+// ApprovalSignature signs the link deciding orderID with secret. Links are
+// sent in notifications and need no API token.
+func ApprovalSignature(secret string, orderID uint, decision string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d:%s", orderID, decision)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyApproval reports whether sig is the signature of decision on
+// orderID. An empty secret verifies nothing.
+func VerifyApproval(secret string, orderID uint, decision, sig string) bool {
+	if secret == "" {
+		return false
+	}
+	return hmac.Equal([]byte(ApprovalSignature(secret, orderID, decision)), []byte(sig))
+}
@@ -0,0 +1,55 @@
+// Package auth carries the authenticated user through request contexts and
+// handles API token hashing.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+type ctxKey struct{}
+
+// WithUserID returns a copy of ctx authenticated as userID. Repositories
+// scope every query on user-owned tables to that user.
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, ctxKey{}, userID)
+}
+
+// UserIDFromContext returns the authenticated user of ctx. ok is false for
+// background work (jobs, executors) which is not bound to a single user.
+func UserIDFromContext(ctx context.Context) (userID uint, ok bool) {
+	userID, ok = ctx.Value(ctxKey{}).(uint)
+	return userID, ok && userID != 0
+}
+
+// HashToken returns the hex SHA-256 of an API token. Only hashes are stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateToken returns a new random API token and its hash.
+func GenerateToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+type roleKey struct{}
+
+// WithRole returns a copy of ctx carrying the role of its authenticated
+// user, see model.User.Role.
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// RoleFromContext returns the role set by WithRole, empty when none was.
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey{}).(string)
+	return role
+}
@@ -0,0 +1,153 @@
+package backtest
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func candleAt(ts time.Time, open, high, low, closePrice string) model.OHLCVCrypto1m {
+	return model.OHLCVCrypto1m{Symbol: "BTCUSDT", Datetime: ts, Open: d(open), High: d(high), Low: d(low), Close: d(closePrice), Volume: d("1")}
+}
+
+func TestSimExchangeOrdersAndStops(t *testing.T) {
+	ts := time.Date(2025, 3, 4, 14, 0, 0, 0, time.UTC)
+	sim := NewSimExchange(SimConfig{InitialBalance: d("1000"), FeeRate: d("0.001"), Leverage: d("10")})
+	sim.OnCandle(candleAt(ts, "100", "100", "100", "100"))
+
+	_, baseAvail, usdtAvail, price, err := sim.GetAvailableBaseFromUSDT("BTCUSDT")
+	require.NoError(t, err)
+	require.Equal(t, 10.0, baseAvail)
+	require.Equal(t, 1000.0, usdtAvail)
+	require.Equal(t, 100.0, price)
+
+	resp, err := sim.PlaceOrder("BTCUSDT", "Buy", "Long", "2", "Market", false)
+	require.NoError(t, err)
+	require.Equal(t, 0, resp.Code)
+
+	pos, err := sim.GetPositionsUSDT()
+	require.NoError(t, err)
+	require.Len(t, pos.Positions, 1)
+	require.Equal(t, "Buy", pos.Positions[0].Side)
+	require.Equal(t, "2", pos.Positions[0].SizeRq)
+
+	_, err = sim.SetStopLossForOpenPosition("BTCUSDT", "Long", "95", "ByMarkPrice", true)
+	require.NoError(t, err)
+
+	// low touches the stop: closed at 95
+	sim.OnCandle(candleAt(ts.Add(time.Minute), "99", "99", "94", "96"))
+	pos, err = sim.GetPositionsUSDT()
+	require.NoError(t, err)
+	require.Empty(t, pos.Positions)
+
+	fills := sim.Fills()
+	require.Len(t, fills, 2)
+	require.True(t, fills[1].ClosedPnL.Equal(d("-10")))
+	// fees: 200*0.001 + 190*0.001
+	require.True(t, sim.Balance().Equal(d("989.61")), sim.Balance().String())
+
+	resp, err = sim.PlaceOrder("BTCUSDT", "Sell", "Long", "1", "Market", true)
+	require.NoError(t, err)
+	require.Equal(t, simCodeInvalidOrder, resp.Code)
+
+	_, err = sim.SetStopLossForOpenPosition("BTCUSDT", "Long", "95", "ByMarkPrice", true)
+	require.Error(t, err)
+}
+
+func TestGenerateSignals(t *testing.T) {
+	ts := time.Date(2025, 3, 4, 14, 0, 0, 0, time.UTC)
+	candles := []model.OHLCVCrypto1m{
+		candleAt(ts, "100", "101", "99", "100"),
+		candleAt(ts.Add(time.Minute), "100", "103", "99", "102"),
+		candleAt(ts.Add(2*time.Minute), "102", "104", "101", "103"),
+		candleAt(ts.Add(3*time.Minute), "103", "103", "97", "98"),
+	}
+	momentum := func(h []model.OHLCVCrypto1m) string {
+		last := h[len(h)-1]
+		switch {
+		case last.Close.GreaterThan(last.Open):
+			return "long"
+		case last.Close.LessThan(last.Open):
+			return "short"
+		}
+		return ""
+	}
+
+	signals := GenerateSignals(candles, "BTCUSDT", "phemex", momentum)
+	require.Len(t, signals, 2)
+	require.Equal(t, "buy", signals[0].Action)
+	require.Equal(t, "long", signals[0].OrderID)
+	require.Equal(t, ts.Add(2*time.Minute), *signals[0].TimestampDT)
+	require.Equal(t, "sell", signals[1].Action)
+	require.Equal(t, "short", signals[1].OrderID)
+	require.Equal(t, uint(2), signals[1].ID)
+}
+
+func TestEngineRunReplaysThroughController(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:backtest_engine?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	// Tuesday 10:00 New York, US session
+	ts := time.Date(2025, 3, 4, 14, 0, 0, 0, time.UTC)
+	var candles []model.OHLCVCrypto1m
+	prices := []string{"100", "101", "102", "103", "104", "103", "102", "101", "100", "99"}
+	for i, p := range prices {
+		candles = append(candles, candleAt(ts.Add(time.Duration(i)*time.Minute), p, p, p, p))
+	}
+	// long after the 2nd candle, short after the 6th
+	signals := GenerateSignals(candles, "BTCUSDT", "phemex", func(h []model.OHLCVCrypto1m) string {
+		switch len(h) {
+		case 2:
+			return "long"
+		case 6:
+			return "short"
+		}
+		return ""
+	})
+	require.Len(t, signals, 2)
+
+	sim := NewSimExchange(SimConfig{InitialBalance: d("10000"), FeeRate: decimal.Zero, Leverage: d("10")})
+	engine := &Engine{
+		Sandbox:      db,
+		Exchange:     sim,
+		Symbol:       "BTCUSDT",
+		ExchangeName: "phemex",
+		TickEvery:    time.Hour,
+		UserExchange: model.UserExchange{OrderSizePercent: 10},
+	}
+
+	res, err := engine.Run(context.Background(), candles, signals)
+	require.NoError(t, err)
+
+	require.Equal(t, 10, res.Candles)
+	require.Equal(t, 2, res.Signals)
+	require.Equal(t, 2, res.ControllerRuns)
+	// long entry, short entry, exit of the long
+	require.Equal(t, int64(3), res.Orders)
+
+	var entries []model.Order
+	require.NoError(t, db.Where("order_dir = ?", model.OrderDirectionEntry).Order("id").Find(&entries).Error)
+	require.Len(t, entries, 2)
+	require.Equal(t, "Long", entries[0].PosSide)
+	require.Equal(t, "Short", entries[1].PosSide)
+
+	// long opened at 101 and closed at 103 by the short signal
+	require.Equal(t, 1, res.Stats.Wins)
+	require.True(t, res.Stats.RealizedPnL.IsPositive())
+	require.True(t, res.FinalBalance.GreaterThan(d("10000")))
+
+	var phemexOrders int64
+	require.NoError(t, db.Model(&model.PhemexOrder{}).Count(&phemexOrders).Error)
+	require.Equal(t, int64(2), phemexOrders)
+}
@@ -0,0 +1,248 @@
+// Package backtest replays historical trading signals against stored OHLCV
+// candles, running the live Phemex order controller on a simulated exchange
+// and a sandbox database.
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/controller"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/featureflag"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/repository"
+	"time"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const sandboxUserName = "backtest"
+
+// sandboxModels are the tables the controller touches during a run.
+var sandboxModels = []interface{}{
+	&model.User{},
+	&model.Exchange{},
+	&model.UserExchange{},
+	&model.Order{},
+	&model.OrderLog{},
+	&model.OrderExecutionLog{},
+	&model.OrderIntent{},
+	&model.ArchivedOrder{},
+	&model.PhemexOrder{},
+	&model.Exception{},
+	&model.UserNotificationSetting{},
+	&model.Strategy{},
+	&model.StrategyAction{},
+	&model.SignalFilterSetting{},
+	&model.FeatureFlag{},
+	&model.Trade{},
+	&model.LossStreak{},
+	&model.ScheduleRule{},
+	&model.SignalClaim{},
+	&model.TradingViewNewsEvent{},
+	&model.OHLCVCrypto1m{},
+	&externalmodel.TradingSignal{},
+}
+
+// runScopedModels are emptied before every run, children first.
+var runScopedModels = []interface{}{
+	&model.StrategyAction{},
+	&model.Strategy{},
+	&model.PhemexOrder{},
+	&model.OrderIntent{},
+	&model.OrderLog{},
+	&model.OrderExecutionLog{},
+	&model.Order{},
+	&model.Exception{},
+	&model.SignalClaim{},
+	&externalmodel.TradingSignal{},
+	&model.OHLCVCrypto1m{},
+}
+
+// Engine drives a backtest run.
+type Engine struct {
+	Log *logger.Entry
+	// Sandbox must not be the production database: the run wipes the orders,
+	// signals and candles it contains.
+	Sandbox  *gorm.DB
+	Exchange *SimExchange
+	// Symbol and ExchangeName are passed to the controller as target symbol
+	// and exchange, signals must use the same values.
+	Symbol       string
+	ExchangeName string
+	// TickEvery mirrors the executor loop period: besides on every new signal
+	// the controller runs once per TickEvery of simulated time (stop trailing).
+	TickEvery time.Duration
+	// UserExchange carries the sizing and session settings of the simulated account.
+	UserExchange model.UserExchange
+	// StrategyName optionally assigns a registered strategy (with StrategyParams
+	// as JSON) to the simulated account; empty runs the default one.
+	StrategyName   string
+	StrategyParams string
+}
+
+// Result summarises a run.
+type Result struct {
+	Candles          int
+	Signals          int
+	ControllerRuns   int
+	ControllerErrors int
+	Orders           int64
+	FinalBalance     decimal.Decimal
+	Stats            report.DailyStats
+}
+
+// Run replays candles in order. Signals become visible to the controller once
+// their timestamp is at or before the close of the current candle. The
+// controller, its repositories and clock are pointed at the sandbox and the
+// simulated time for the duration of the run, so Run must not be called
+// concurrently with live controllers in the same process.
+func (e *Engine) Run(ctx context.Context, candles []model.OHLCVCrypto1m, signals []externalmodel.TradingSignal) (*Result, error) {
+	if e.Sandbox == nil || e.Exchange == nil {
+		return nil, errors.New("backtest: sandbox and exchange are required")
+	}
+	if e.Log == nil {
+		e.Log = logger.WithField("component", "backtest")
+	}
+
+	user, exchange, userExchange, err := e.prepareSandbox(ctx, candles)
+	if err != nil {
+		return nil, err
+	}
+
+	prevMain, prevReadOnly := database.MainDB, database.ReadOnlyDB
+	database.MainDB, database.ReadOnlyDB = e.Sandbox, e.Sandbox
+	defer func() { database.MainDB, database.ReadOnlyDB = prevMain, prevReadOnly }()
+	restoreFlags := featureflag.SetStore(repository.NewFeatureFlagRepositoryWithDB(e.Sandbox))
+	defer restoreFlags()
+
+	var now time.Time
+	restoreClock := controller.SetClock(func() time.Time { return now })
+	defer restoreClock()
+
+	res := &Result{Candles: len(candles)}
+	next := 0
+	var lastRun time.Time
+
+	for _, c := range candles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		e.Exchange.OnCandle(c)
+		now = c.Datetime.Add(time.Minute)
+
+		newSignal := false
+		for next < len(signals) && !signalTime(signals[next]).After(now) {
+			sig := signals[next]
+			if err := e.Sandbox.WithContext(ctx).Create(&sig).Error; err != nil {
+				return nil, fmt.Errorf("backtest: insert signal %d: %w", sig.ID, err)
+			}
+			next++
+			res.Signals++
+			newSignal = true
+		}
+
+		if res.Signals == 0 {
+			continue
+		}
+		if !newSignal && !lastRun.IsZero() && now.Sub(lastRun) < e.TickEvery {
+			continue
+		}
+
+		lastRun = now
+		res.ControllerRuns++
+		if err := controller.OrderController(ctx, e.Exchange, user, exchange.ID, e.Symbol, e.ExchangeName, userExchange); err != nil {
+			res.ControllerErrors++
+			e.Log.WithError(err).WithField("at", now).Debug("controller returned error")
+		}
+	}
+
+	if err := e.Sandbox.WithContext(ctx).Model(&model.Order{}).Count(&res.Orders).Error; err != nil {
+		return nil, fmt.Errorf("backtest: count orders: %w", err)
+	}
+	res.FinalBalance = e.Exchange.Balance()
+	res.Stats = report.ComputeDailyStats(e.Exchange.Fills(), e.Exchange.UnrealizedPnL())
+
+	return res, nil
+}
+
+func (e *Engine) prepareSandbox(ctx context.Context, candles []model.OHLCVCrypto1m) (*model.User, *model.Exchange, *model.UserExchange, error) {
+	db := e.Sandbox.WithContext(ctx)
+
+	if err := db.AutoMigrate(sandboxModels...); err != nil {
+		return nil, nil, nil, fmt.Errorf("backtest: migrate sandbox: %w", err)
+	}
+	for _, m := range runScopedModels {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(m).Error; err != nil {
+			return nil, nil, nil, fmt.Errorf("backtest: reset sandbox: %w", err)
+		}
+	}
+
+	rows := make([]model.OHLCVCrypto1m, len(candles))
+	for i, c := range candles {
+		c.ID = 0
+		rows[i] = c
+	}
+	if len(rows) > 0 {
+		if err := db.CreateInBatches(rows, 500).Error; err != nil {
+			return nil, nil, nil, fmt.Errorf("backtest: load candles: %w", err)
+		}
+	}
+
+	user := &model.User{Username: sandboxUserName}
+	if err := db.Where("user_name = ?", sandboxUserName).FirstOrCreate(user).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("backtest: sandbox user: %w", err)
+	}
+	exchange := &model.Exchange{Name: e.ExchangeName}
+	if err := db.Where("name = ?", e.ExchangeName).FirstOrCreate(exchange).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("backtest: sandbox exchange: %w", err)
+	}
+
+	userExchange := e.UserExchange
+	userExchange.ID = 0
+	userExchange.UserID = user.ID
+	userExchange.ExchangeID = exchange.ID
+	userExchange.RunOnServer = true
+	userExchange.NoTradeWindowOrdersClosed = false
+	userExchange.Exchange = nil
+	if err := db.Where("user_id = ? AND exchange_id = ?", user.ID, exchange.ID).Delete(&model.UserExchange{}).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("backtest: reset sandbox account: %w", err)
+	}
+	if err := db.Create(&userExchange).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("backtest: sandbox account: %w", err)
+	}
+
+	if e.StrategyName != "" {
+		assignment := &model.Strategy{
+			UserID:     user.ID,
+			ExchangeID: exchange.ID,
+			Symbol:     e.Symbol,
+			Name:       e.StrategyName,
+			Params:     e.StrategyParams,
+			Enabled:    true,
+		}
+		if err := db.Create(assignment).Error; err != nil {
+			return nil, nil, nil, fmt.Errorf("backtest: sandbox strategy: %w", err)
+		}
+	}
+
+	return user, exchange, &userExchange, nil
+}
+
+// signalTime is when the signal was emitted; signals without a timestamp
+// fall back to their receive time.
+func signalTime(s externalmodel.TradingSignal) time.Time {
+	if s.TimestampDT != nil {
+		return *s.TimestampDT
+	}
+	if s.ReceivedAt != nil {
+		return *s.ReceivedAt
+	}
+	return time.Time{}
+}
@@ -0,0 +1,275 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/report"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Phemex-like error code returned for rejected simulated orders.
+const simCodeInvalidOrder = 11001
+
+// SimConfig configures the simulated exchange.
+type SimConfig struct {
+	InitialBalance decimal.Decimal // USDT wallet balance
+	FeeRate        decimal.Decimal // taker fee applied on notional, e.g. 0.0006
+	Leverage       decimal.Decimal // used to compute the margin locked by open positions
+}
+
+type simPosition struct {
+	symbol  string
+	posSide string
+	size    decimal.Decimal
+	entry   decimal.Decimal
+}
+
+// SimExchange is an in-memory, single-symbol USDT-M futures exchange
+// implementing connectors.Connector. Market orders fill at the close of the
+// current candle; stops are triggered on candle highs/lows by OnCandle.
+type SimExchange struct {
+	config    SimConfig
+	balance   decimal.Decimal
+	price     decimal.Decimal
+	now       time.Time
+	positions map[string]*simPosition // keyed by posSide
+	stops     map[string]decimal.Decimal
+	fills     []report.Fill
+	orders    map[string]connectors.ClientOrder // keyed by clOrdID
+	seq       int
+}
+
+var (
+	_ connectors.Connector         = (*SimExchange)(nil)
+	_ connectors.ClientOrderPlacer = (*SimExchange)(nil)
+)
+
+func NewSimExchange(config SimConfig) *SimExchange {
+	if config.Leverage.LessThanOrEqual(decimal.Zero) {
+		config.Leverage = decimal.NewFromInt(1)
+	}
+	return &SimExchange{
+		config:    config,
+		balance:   config.InitialBalance,
+		positions: map[string]*simPosition{},
+		stops:     map[string]decimal.Decimal{},
+		orders:    map[string]connectors.ClientOrder{},
+	}
+}
+
+// OnCandle advances the simulation to c: stops touched by the candle range are
+// filled at their stop price, then the market price becomes the candle close.
+func (s *SimExchange) OnCandle(c model.OHLCVCrypto1m) {
+	s.now = c.Datetime.Add(time.Minute)
+
+	for posSide, stop := range s.stops {
+		pos, ok := s.positions[posSide]
+		if !ok {
+			delete(s.stops, posSide)
+			continue
+		}
+		long := strings.EqualFold(posSide, "Long")
+		if (long && c.Low.LessThanOrEqual(stop)) || (!long && c.High.GreaterThanOrEqual(stop)) {
+			s.close(pos, pos.size, stop)
+		}
+	}
+
+	s.price = c.Close
+}
+
+// Balance returns the wallet balance, realized PnL and fees included.
+func (s *SimExchange) Balance() decimal.Decimal { return s.balance }
+
+// Fills returns every execution made so far.
+func (s *SimExchange) Fills() []report.Fill { return s.fills }
+
+// UnrealizedPnL marks the open positions to the current price.
+func (s *SimExchange) UnrealizedPnL() decimal.Decimal {
+	total := decimal.Zero
+	for _, pos := range s.positions {
+		total = total.Add(direction(pos.posSide).Mul(s.price.Sub(pos.entry)).Mul(pos.size))
+	}
+	return total
+}
+
+func (s *SimExchange) GetAvailableBaseFromUSDT(symbol string) (string, float64, float64, float64, error) {
+	if !strings.HasSuffix(symbol, "USDT") {
+		return "", 0, 0, 0, fmt.Errorf("symbol must end in USDT: %s", symbol)
+	}
+	if s.price.LessThanOrEqual(decimal.Zero) {
+		return "", 0, 0, 0, fmt.Errorf("invalid price for %s", symbol)
+	}
+
+	margin := decimal.Zero
+	for _, pos := range s.positions {
+		margin = margin.Add(pos.size.Mul(pos.entry).Div(s.config.Leverage))
+	}
+	usdtAvail := decimal.Max(s.balance.Sub(margin), decimal.Zero)
+
+	return strings.TrimSuffix(symbol, "USDT"),
+		usdtAvail.Div(s.price).InexactFloat64(),
+		usdtAvail.InexactFloat64(),
+		s.price.InexactFloat64(),
+		nil
+}
+
+func (s *SimExchange) GetPositionsUSDT() (*connectors.GAccountPositions, error) {
+	var out connectors.GAccountPositions
+	out.Account.Currency = "USDT"
+	out.Account.AccountBalanceRv = s.balance.String()
+
+	for _, posSide := range []string{"Long", "Short"} {
+		pos, ok := s.positions[posSide]
+		if !ok {
+			continue
+		}
+		out.Positions = appendZero(out.Positions)
+		p := &out.Positions[len(out.Positions)-1]
+		p.Symbol = pos.symbol
+		p.Currency = "USDT"
+		p.Side = "Buy"
+		if posSide == "Short" {
+			p.Side = "Sell"
+		}
+		p.PosSide = posSide
+		p.SizeRq = pos.size.String()
+		p.AvgEntryPriceRp = pos.entry.String()
+		p.MarkPriceRp = s.price.String()
+	}
+	return &out, nil
+}
+
+func (s *SimExchange) PlaceOrder(symbol, side, posSide, qty, ordType string, reduce bool) (*connectors.APIResponse, error) {
+	return s.PlaceOrderWithClientID(fmt.Sprintf("sim-cl-%d", s.seq+1), symbol, side, posSide, qty, ordType, reduce)
+}
+
+// PlaceOrderWithClientID fills the order immediately and remembers it under
+// clOrdID. Rejected orders are not recorded.
+func (s *SimExchange) PlaceOrderWithClientID(clOrdID, symbol, side, posSide, qty, ordType string, reduce bool) (*connectors.APIResponse, error) {
+	size, err := decimal.NewFromString(qty)
+	if err != nil || size.LessThanOrEqual(decimal.Zero) {
+		return &connectors.APIResponse{Code: simCodeInvalidOrder, Msg: "invalid order qty " + qty}, nil
+	}
+	if s.price.LessThanOrEqual(decimal.Zero) {
+		return &connectors.APIResponse{Code: simCodeInvalidOrder, Msg: "no market price"}, nil
+	}
+	posSide = normalizePosSide(posSide)
+
+	closedPnl := decimal.Zero
+	pos, open := s.positions[posSide]
+	if reduce {
+		if !open {
+			return &connectors.APIResponse{Code: simCodeInvalidOrder, Msg: "reduce-only order without position"}, nil
+		}
+		size = decimal.Min(size, pos.size)
+		closedPnl = s.close(pos, size, s.price)
+	} else {
+		if !open {
+			pos = &simPosition{symbol: symbol, posSide: posSide, size: decimal.Zero, entry: decimal.Zero}
+			s.positions[posSide] = pos
+		}
+		notional := pos.size.Mul(pos.entry).Add(size.Mul(s.price))
+		pos.size = pos.size.Add(size)
+		pos.entry = notional.Div(pos.size)
+		s.fill(symbol, size, s.price, decimal.Zero)
+	}
+
+	s.seq++
+	ts := s.now.UnixNano()
+	payload := model.PhemexOrderResponse{
+		OrderID:        fmt.Sprintf("sim-%d", s.seq),
+		ClOrdID:        clOrdID,
+		Symbol:         symbol,
+		Side:           side,
+		ActionTimeNs:   ts,
+		TransactTimeNs: ts,
+		OrderType:      ordType,
+		PriceRp:        s.price.String(),
+		OrderQtyRq:     size.String(),
+		TimeInForce:    "ImmediateOrCancel",
+		ClosedPnlRv:    closedPnl.String(),
+		CumQtyRq:       size.String(),
+		CumValueRv:     size.Mul(s.price).String(),
+		LeavesQtyRq:    "0",
+		LeavesValueRv:  "0",
+		ExecStatus:     "TakerFill",
+		OrdStatus:      "Filled",
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	s.orders[clOrdID] = connectors.ClientOrder{OrderID: payload.OrderID, ClOrdID: clOrdID, OrdStatus: payload.OrdStatus}
+	return &connectors.APIResponse{Code: 0, Msg: "OK", Data: data}, nil
+}
+
+func (s *SimExchange) FindOrderByClientID(_, clOrdID string) (*connectors.ClientOrder, error) {
+	order, ok := s.orders[clOrdID]
+	if !ok {
+		return nil, nil
+	}
+	return &order, nil
+}
+
+func (s *SimExchange) SetStopLossForOpenPosition(symbol, posSide, stopPxRp, triggerType string, closeOnTrigger bool) (*connectors.APIResponse, error) {
+	posSide = normalizePosSide(posSide)
+	if _, ok := s.positions[posSide]; !ok {
+		return nil, fmt.Errorf("no open position for %s %s", symbol, posSide)
+	}
+	stop, err := decimal.NewFromString(stopPxRp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stopPxRp %q: %w", stopPxRp, err)
+	}
+	s.stops[posSide] = stop
+	return &connectors.APIResponse{Code: 0, Msg: "OK", Data: json.RawMessage(`{}`)}, nil
+}
+
+// close reduces pos by size at price and returns the realized PnL.
+func (s *SimExchange) close(pos *simPosition, size decimal.Decimal, price decimal.Decimal) decimal.Decimal {
+	pnl := direction(pos.posSide).Mul(price.Sub(pos.entry)).Mul(size)
+	s.fill(pos.symbol, size, price, pnl)
+
+	pos.size = pos.size.Sub(size)
+	if pos.size.LessThanOrEqual(decimal.Zero) {
+		delete(s.positions, pos.posSide)
+		delete(s.stops, pos.posSide)
+	}
+	return pnl
+}
+
+func (s *SimExchange) fill(symbol string, size, price, closedPnl decimal.Decimal) {
+	fee := size.Mul(price).Mul(s.config.FeeRate)
+	s.balance = s.balance.Add(closedPnl).Sub(fee)
+	s.fills = append(s.fills, report.Fill{
+		Time:      s.now,
+		Symbol:    symbol,
+		ClosedPnL: closedPnl,
+		Fee:       fee,
+	})
+}
+
+func normalizePosSide(posSide string) string {
+	if strings.EqualFold(posSide, "Short") {
+		return "Short"
+	}
+	return "Long"
+}
+
+func direction(posSide string) decimal.Decimal {
+	if posSide == "Short" {
+		return decimal.NewFromInt(-1)
+	}
+	return decimal.NewFromInt(1)
+}
+
+// appendZero appends a zero element; GAccountPositions uses an anonymous
+// element type that can't be spelled out here.
+func appendZero[S ~[]E, E any](s S) S {
+	var zero E
+	return append(s, zero)
+}
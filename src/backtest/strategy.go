@@ -0,0 +1,71 @@
+package backtest
+
+import (
+	"context"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/strategy"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// StrategyFunc inspects the candles seen so far, the last one being the
+// candle that just closed, and returns the position to hold: "long",
+// "short", or "" to keep the current one.
+type StrategyFunc func(history []model.OHLCVCrypto1m) string
+
+// GenerateSignals runs fn over candles and emits a TradingSignal, shaped like
+// the TradingView webhook rows, every time the desired position changes.
+func GenerateSignals(candles []model.OHLCVCrypto1m, symbol, exchangeName string, fn StrategyFunc) []externalmodel.TradingSignal {
+	var signals []externalmodel.TradingSignal
+	current := ""
+
+	for i := range candles {
+		want := strings.ToLower(strings.TrimSpace(fn(candles[:i+1])))
+		if want == "" || want == current {
+			continue
+		}
+
+		action := "buy"
+		if want == "short" {
+			action = "sell"
+		}
+		ts := candles[i].Datetime.Add(time.Minute)
+
+		signals = append(signals, externalmodel.TradingSignal{
+			ID:                 uint(len(signals) + 1),
+			ExchangeName:       exchangeName,
+			Symbol:             symbol,
+			Action:             action,
+			OrderID:            want,
+			OrderType:          "market",
+			MarketPosition:     want,
+			PrevMarketPosition: current,
+			TimestampDT:        &ts,
+			ReceivedAt:         &ts,
+			Comment:            "backtest",
+		})
+		current = want
+	}
+
+	return signals
+}
+
+// FromStrategy adapts the OnCandle hook of a registered strategy to a
+// StrategyFunc, so candle driven strategies can generate backtest signals.
+// Only ActionOpen decisions change the position.
+func FromStrategy(s strategy.Strategy, sc strategy.Context) StrategyFunc {
+	return func(history []model.OHLCVCrypto1m) string {
+		d, err := s.OnCandle(context.Background(), sc, history[len(history)-1])
+		if err != nil {
+			logger.WithError(err).WithField("strategy", s.Name()).Warn("strategy OnCandle failed")
+			return ""
+		}
+		if d.Action != strategy.ActionOpen {
+			return ""
+		}
+		return strings.ToLower(d.PosSide)
+	}
+}
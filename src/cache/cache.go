@@ -0,0 +1,132 @@
+// Package cache is the optional shared cache of hot reads: ticker prices,
+// instrument metadata and latest signal lookups, which many executors poll
+// for the same symbol. It is backed by Redis when REDIS_URL is set and does
+// nothing otherwise. The cache is best effort: a failing Redis is logged and
+// the read falls through to its source.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Cache stores values under keys for a TTL.
+type Cache interface {
+	// Get returns the value of key, ok false when there is none.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Nop caches nothing.
+type Nop struct{}
+
+func (Nop) Get(context.Context, string) ([]byte, bool, error)        { return nil, false, nil }
+func (Nop) Set(context.Context, string, []byte, time.Duration) error { return nil }
+func (Nop) Delete(context.Context, ...string) error                  { return nil }
+
+var (
+	defaultMu     sync.Mutex
+	defaultCache  Cache
+	defaultConfig Config
+)
+
+// Default returns the cache of the process, built from GetConfig on first
+// use.
+func Default() Cache {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	loadDefaultLocked()
+	return defaultCache
+}
+
+// TTLs returns the configured TTLs of the cached reads.
+func TTLs() Config {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	loadDefaultLocked()
+	return defaultConfig
+}
+
+func loadDefaultLocked() {
+	if defaultCache != nil {
+		return
+	}
+	defaultConfig = GetConfig()
+	defaultCache = Nop{}
+	if defaultConfig.RedisURL == "" {
+		return
+	}
+	r, err := NewRedis(defaultConfig.RedisURL, defaultConfig.RedisTimeout)
+	if err != nil {
+		logger.WithError(err).Error("invalid REDIS_URL, cache disabled")
+		return
+	}
+	defaultCache = r
+}
+
+// SetDefault replaces the cache of the process and returns a function
+// restoring the previous one.
+func SetDefault(c Cache) (restore func()) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	loadDefaultLocked()
+	previous := defaultCache
+	defaultCache = c
+
+	return func() {
+		defaultMu.Lock()
+		defer defaultMu.Unlock()
+		defaultCache = previous
+	}
+}
+
+// Key joins parts into a key under the configured prefix.
+func Key(parts ...string) string {
+	return TTLs().Prefix + strings.Join(parts, ":")
+}
+
+// GetOrLoad returns the JSON value cached under key, or the one load
+// returns, cached for ttl. Errors of load are returned and not cached.
+func GetOrLoad[T any](ctx context.Context, c Cache, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"component": "cache",
+		"key":       key,
+	})
+
+	if raw, ok, err := c.Get(ctx, key); err != nil {
+		log.WithError(err).Warn("cache read failed")
+	} else if ok {
+		var cached T
+		if err := json.Unmarshal(raw, &cached); err == nil {
+			return cached, nil
+		}
+		log.Warn("undecodable cached value, reloading")
+	}
+
+	value, err := load()
+	if err != nil || ttl <= 0 {
+		return value, err
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		log.WithError(err).Warn("cache encode failed")
+		return value, nil
+	}
+	if err := c.Set(ctx, key, raw, ttl); err != nil {
+		log.WithError(err).Warn("cache write failed")
+	}
+	return value, nil
+}
+
+// Invalidate deletes keys from c, logging a failure.
+func Invalidate(ctx context.Context, c Cache, keys ...string) {
+	if err := c.Delete(ctx, keys...); err != nil {
+		logger.WithContext(ctx).WithError(err).WithField("keys", keys).Warn("cache invalidation failed")
+	}
+}
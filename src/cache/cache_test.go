@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type memoryCache struct {
+	values map[string][]byte
+	err    error
+}
+
+func (m *memoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	v, ok := m.values[key]
+	return v, ok, m.err
+}
+
+func (m *memoryCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	if m.err == nil {
+		m.values[key] = value
+	}
+	return m.err
+}
+
+func (m *memoryCache) Delete(_ context.Context, keys ...string) error {
+	for _, k := range keys {
+		delete(m.values, k)
+	}
+	return m.err
+}
+
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	c := &memoryCache{values: map[string][]byte{}}
+	loads := 0
+	load := func() ([]string, error) {
+		loads++
+		return []string{"BTCUSDT"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := GetOrLoad(ctx, c, "k", time.Second, load)
+		if err != nil || len(got) != 1 || got[0] != "BTCUSDT" {
+			t.Fatalf("unexpected %v %v", got, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("expected one load, got %d", loads)
+	}
+
+	Invalidate(ctx, c, "k")
+	if _, err := GetOrLoad(ctx, c, "k", time.Second, load); err != nil || loads != 2 {
+		t.Fatalf("expected a reload after invalidation, got %d loads err=%v", loads, err)
+	}
+
+	if _, err := GetOrLoad(ctx, c, "failing", time.Second, func() (int, error) { return 0, errors.New("boom") }); err == nil {
+		t.Fatal("expected the load error")
+	}
+	if _, ok := c.values["failing"]; ok {
+		t.Fatal("expected errors not cached")
+	}
+
+	// a failing cache falls through to the source
+	c.err = errors.New("redis down")
+	if got, err := GetOrLoad(ctx, c, "k", time.Second, load); err != nil || len(got) != 1 || loads != 3 {
+		t.Fatalf("expected a load, got %v %v after %d loads", got, err, loads)
+	}
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"sync"
+	"time"
+)
+
+// LoadCandles returns the latest limit 1m candles of symbol with a datetime
+// in [from, to], ascending. A zero from means no lower bound.
+type LoadCandles func(ctx context.Context, symbol string, from, to time.Time, limit int) ([]model.OHLCVCrypto1m, error)
+
+// Candles keeps the latest 1m candles of each symbol in memory, so the stop
+// loss of every controller run does not read its whole lookback window
+// again. A symbol is loaded from its source once, then only the candles from
+// the latest one held on are read and appended. The latest held candle is
+// read again as the collector rewrites the still open candle.
+//
+// Candles assumes history is only appended: a candle stored behind the
+// latest held one, e.g. by a backfill of a gap, is not seen until restart.
+type Candles struct {
+	size int
+
+	mu    sync.Mutex
+	rings map[string]*candleRing
+}
+
+// NewCandles returns a cache keeping size candles per symbol.
+func NewCandles(size int) *Candles {
+	return &Candles{size: size, rings: map[string]*candleRing{}}
+}
+
+var (
+	defaultCandlesOnce sync.Once
+	defaultCandles     *Candles
+)
+
+// DefaultCandles returns the candle cache of the process, nil when
+// CANDLE_CACHE_SIZE disables it.
+func DefaultCandles() *Candles {
+	defaultCandlesOnce.Do(func() {
+		if size := TTLs().CandleCacheSize; size > 0 {
+			defaultCandles = NewCandles(size)
+		}
+	})
+	return defaultCandles
+}
+
+// Recent returns the latest limit 1m candles of symbol up to to, ascending,
+// like load with no lower bound. Reads the cache cannot answer, going back
+// in time or beyond its size, go to load.
+func (c *Candles) Recent(ctx context.Context, symbol string, to time.Time, limit int, load LoadCandles) ([]model.OHLCVCrypto1m, error) {
+	if limit > c.size {
+		return load(ctx, symbol, time.Time{}, to, limit)
+	}
+
+	c.mu.Lock()
+	r, ok := c.rings[symbol]
+	if !ok {
+		r = &candleRing{buf: make([]model.OHLCVCrypto1m, c.size)}
+		c.rings[symbol] = r
+	}
+	c.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.warm && to.Before(r.through) {
+		return load(ctx, symbol, time.Time{}, to, limit)
+	}
+	if err := r.sync(ctx, symbol, to, load); err != nil {
+		return nil, err
+	}
+	return r.latest(limit), nil
+}
+
+// candleRing is a ring buffer of the latest candles of a symbol, read from
+// the source up to through.
+type candleRing struct {
+	mu      sync.Mutex
+	buf     []model.OHLCVCrypto1m
+	head    int // index of the oldest candle
+	n       int
+	warm    bool
+	through time.Time
+}
+
+// sync reads the candles up to to newer than the latest held one.
+func (r *candleRing) sync(ctx context.Context, symbol string, to time.Time, load LoadCandles) error {
+	var from time.Time
+	if r.warm && r.n > 0 {
+		from = r.last().Datetime
+	}
+	rows, err := load(ctx, symbol, from, to, len(r.buf))
+	if err != nil {
+		return err
+	}
+	// Rows without the latest held candle do not join the held ones: more
+	// candles arrived than the ring holds, keep only the rows.
+	if from.IsZero() || (len(rows) > 0 && !rows[0].Datetime.Equal(from)) {
+		r.head, r.n = 0, 0
+	}
+	for _, row := range rows {
+		if r.n > 0 && !row.Datetime.After(r.last().Datetime) {
+			if row.Datetime.Equal(r.last().Datetime) {
+				r.buf[r.index(r.n-1)] = row
+			}
+			continue
+		}
+		r.push(row)
+	}
+	r.warm, r.through = true, to
+	return nil
+}
+
+func (r *candleRing) index(i int) int { return (r.head + i) % len(r.buf) }
+
+func (r *candleRing) last() model.OHLCVCrypto1m { return r.buf[r.index(r.n-1)] }
+
+func (r *candleRing) push(row model.OHLCVCrypto1m) {
+	if r.n < len(r.buf) {
+		r.buf[r.index(r.n)] = row
+		r.n++
+		return
+	}
+	r.buf[r.head] = row
+	r.head = (r.head + 1) % len(r.buf)
+}
+
+// latest copies the latest limit candles, ascending.
+func (r *candleRing) latest(limit int) []model.OHLCVCrypto1m {
+	if limit > r.n {
+		limit = r.n
+	}
+	out := make([]model.OHLCVCrypto1m, limit)
+	for i := range out {
+		out[i] = r.buf[r.index(r.n-limit+i)]
+	}
+	return out
+}
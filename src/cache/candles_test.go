@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// candleSource is a LoadCandles over stored, counting the rows read.
+type candleSource struct {
+	stored []model.OHLCVCrypto1m
+	loads  int
+	read   int
+}
+
+func (s *candleSource) load(_ context.Context, _ string, from, to time.Time, limit int) ([]model.OHLCVCrypto1m, error) {
+	s.loads++
+	var rows []model.OHLCVCrypto1m
+	for _, c := range s.stored {
+		if (from.IsZero() || !c.Datetime.Before(from)) && !c.Datetime.After(to) {
+			rows = append(rows, c)
+		}
+	}
+	if len(rows) > limit {
+		rows = rows[len(rows)-limit:]
+	}
+	s.read += len(rows)
+	return rows, nil
+}
+
+func (s *candleSource) put(at time.Time, close int64) {
+	c := model.OHLCVCrypto1m{Symbol: "BTC_USDT", Datetime: at, Close: decimal.NewFromInt(close)}
+	for i := range s.stored {
+		if s.stored[i].Datetime.Equal(at) {
+			s.stored[i] = c
+			return
+		}
+	}
+	s.stored = append(s.stored, c)
+}
+
+func TestCandlesRecent(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	src := &candleSource{}
+	for i := 0; i < 10; i++ {
+		src.put(start.Add(time.Duration(i)*time.Minute), int64(i))
+	}
+	c := NewCandles(5)
+	now := start.Add(9 * time.Minute)
+
+	got, err := c.Recent(ctx, "BTC_USDT", now, 3, src.load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].Close.IntPart() != 7 || got[2].Close.IntPart() != 9 {
+		t.Fatalf("unexpected candles %v", got)
+	}
+	if src.read != 5 {
+		t.Fatalf("expected the cold start to fill the ring, read %d", src.read)
+	}
+
+	// the open candle is rewritten and a new one arrives: only those are read
+	src.put(start.Add(9*time.Minute), 90)
+	src.put(start.Add(10*time.Minute), 10)
+	src.read = 0
+	got, err = c.Recent(ctx, "BTC_USDT", now.Add(time.Minute), 5, src.load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.read != 2 {
+		t.Fatalf("expected an incremental read, read %d", src.read)
+	}
+	if len(got) != 5 || got[0].Close.IntPart() != 6 || got[3].Close.IntPart() != 90 || got[4].Close.IntPart() != 10 {
+		t.Fatalf("unexpected candles %v", got)
+	}
+
+	// more candles than the ring holds arrived while idle
+	for i := 11; i < 20; i++ {
+		src.put(start.Add(time.Duration(i)*time.Minute), int64(i))
+	}
+	got, err = c.Recent(ctx, "BTC_USDT", start.Add(19*time.Minute), 5, src.load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Close.IntPart() != 15 || got[4].Close.IntPart() != 19 {
+		t.Fatalf("unexpected candles after a gap %v", got)
+	}
+
+	// going back in time or beyond the ring size reads the source
+	loads := src.loads
+	if got, _ = c.Recent(ctx, "BTC_USDT", start.Add(5*time.Minute), 2, src.load); got[1].Close.IntPart() != 5 {
+		t.Fatalf("unexpected past candles %v", got)
+	}
+	if got, _ = c.Recent(ctx, "BTC_USDT", start.Add(19*time.Minute), 8, src.load); len(got) != 8 {
+		t.Fatalf("unexpected candles beyond the ring %v", got)
+	}
+	if src.loads != loads+2 {
+		t.Fatalf("expected both reads from the source")
+	}
+}
@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// RedisURL, e.g. redis://:password@redis:6379/0 or rediss:// for TLS,
+	// enables the cache. Without it every read goes to its source.
+	RedisURL     string        `envconfig:"REDIS_URL" default:""`
+	RedisTimeout time.Duration `envconfig:"REDIS_TIMEOUT" default:"200ms"`
+	// Prefix is prepended to every key, to share a Redis between
+	// deployments.
+	Prefix string `envconfig:"CACHE_PREFIX" default:"strategyexecutor:"`
+
+	TickerTTL     time.Duration `envconfig:"CACHE_TICKER_TTL" default:"2s"`
+	InstrumentTTL time.Duration `envconfig:"CACHE_INSTRUMENT_TTL" default:"10m"`
+	SignalTTL     time.Duration `envconfig:"CACHE_SIGNAL_TTL" default:"3s"`
+
+	// CandleCacheSize is the 1m candles of each symbol kept in memory for
+	// the stop loss lookbacks, enough for 60 swing candles of 45m. Zero
+	// reads every lookback from the database.
+	CandleCacheSize int `envconfig:"CANDLE_CACHE_SIZE" default:"3000"`
+}
+
+func GetConfig() Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return config
+}
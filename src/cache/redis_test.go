@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves the commands of the cache from a map.
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	ttls     map[string]string
+	commands []string
+	password string
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	f := &fakeRedis{values: map[string]string{}, ttls: map[string]string{}, password: password}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+		var reply string
+		switch {
+		case args[0] == "AUTH":
+			authed = args[len(args)-1] == f.password
+			reply = "+OK\r\n"
+			if !authed {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "SELECT":
+			reply = "+OK\r\n"
+		case args[0] == "GET":
+			if v, ok := f.values[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case args[0] == "SET":
+			f.values[args[1]] = args[2]
+			f.ttls[args[1]] = strings.Join(args[3:], " ")
+			reply = "+OK\r\n"
+		case args[0] == "DEL":
+			n := 0
+			for _, k := range args[1:] {
+				if _, ok := f.values[k]; ok {
+					delete(f.values, k)
+					n++
+				}
+			}
+			reply = fmt.Sprintf(":%d\r\n", n)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimRight(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedisGetSetDelete(t *testing.T) {
+	f, addr := startFakeRedis(t, "s3cret")
+	r, err := NewRedis("redis://:s3cret@"+addr+"/2", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, ok, err := r.Get(ctx, "k"); err != nil || ok {
+		t.Fatalf("expected a miss, got ok=%v err=%v", ok, err)
+	}
+	if err := r.Set(ctx, "k", []byte(`{"price":"1.5"}`), 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	v, ok, err := r.Get(ctx, "k")
+	if err != nil || !ok || string(v) != `{"price":"1.5"}` {
+		t.Fatalf("unexpected value %q ok=%v err=%v", v, ok, err)
+	}
+	if err := r.Delete(ctx, "k", "other"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := r.Get(ctx, "k"); ok {
+		t.Fatal("expected the key deleted")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ttls["k"] != "PX 2000" {
+		t.Fatalf("unexpected ttl %q", f.ttls["k"])
+	}
+	if f.commands[0] != "AUTH s3cret" || f.commands[1] != "SELECT 2" {
+		t.Fatalf("expected the connection set up once, got %v", f.commands)
+	}
+	for _, c := range f.commands[2:] {
+		if strings.HasPrefix(c, "AUTH") {
+			t.Fatalf("expected the connection reused, got %v", f.commands)
+		}
+	}
+}
+
+func TestRedisErrors(t *testing.T) {
+	_, addr := startFakeRedis(t, "s3cret")
+	r, err := NewRedis("redis://:wrong@"+addr, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replyErr redisError
+	if _, _, err := r.Get(context.Background(), "k"); !errors.As(err, &replyErr) {
+		t.Fatalf("expected the auth error, got %v", err)
+	}
+
+	for _, raw := range []string{"http://localhost", "redis://", "redis://localhost/x"} {
+		if _, err := NewRedis(raw, time.Second); err == nil {
+			t.Fatalf("expected %q rejected", raw)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package connectors
+
+import (
+	"net/http"
+	"strategyexecutor/src/logging"
+	"time"
+)
+
+// APICall is an order-mutating HTTP call a connector made, with its secrets
+// redacted, so a failed order submission can be reconstructed afterwards.
+type APICall struct {
+	Exchange     string
+	Method       string
+	Path         string
+	Query        string
+	RequestBody  string
+	Status       int // 0 when no response was received
+	ResponseBody string
+	Latency      time.Duration
+	Err          error
+	At           time.Time
+}
+
+// CaptureFunc receives the calls of a client built WithCapture.
+type CaptureFunc func(call APICall)
+
+// isMutatingMethod reports whether calls of method can change orders or
+// positions; reads are never captured.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// newAPICall builds the captured call of a request sent at start. Query
+// and bodies go through logging.Redact; headers, which carry the
+// signatures, are never kept.
+func newAPICall(exchange, method, path, query, requestBody string, status int, responseBody []byte, start time.Time, err error) APICall {
+	return APICall{
+		Exchange:     exchange,
+		Method:       method,
+		Path:         path,
+		Query:        logging.Redact(query),
+		RequestBody:  logging.Redact(requestBody),
+		Status:       status,
+		ResponseBody: logging.Redact(string(responseBody)),
+		Latency:      time.Since(start),
+		Err:          err,
+		At:           start,
+	}
+}
@@ -0,0 +1,53 @@
+package connectors
+
+import (
+	"net/http"
+	"strategyexecutor/src/logging"
+	"strategyexecutor/src/testsupport"
+	"strings"
+	"testing"
+)
+
+// TestCaptureOrderMutatingCalls checks only POST, PUT and DELETE calls are
+// captured, with their outcome and with secrets redacted.
+func TestCaptureOrderMutatingCalls(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t).
+		Fail(http.MethodPost, "/g-orders", testsupport.Failure{
+			After:  1,
+			Status: http.StatusBadRequest,
+			Body:   `{"code":10500,"msg":"rejected","data":{"apiKey":"leaked-key"}}`,
+		})
+	var calls []APICall
+	client := newTestClient(exchange.URL, exchange.Server.Client()).WithCapture(func(call APICall) {
+		calls = append(calls, call)
+	})
+
+	if _, err := client.PlaceOrder("BTCUSDT", "Buy", "Long", "1", "Market", false); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if _, err := client.GetPositionsUSDT(); err != nil {
+		t.Fatalf("positions: %v", err)
+	}
+	if _, err := client.PlaceOrder("BTCUSDT", "Buy", "Long", "1", "Market", false); err == nil {
+		t.Fatalf("expected the second order to fail")
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 captured calls, got %+v", calls)
+	}
+	ok := calls[0]
+	if ok.Exchange != "phemex" || ok.Method != http.MethodPost || ok.Path != "/g-orders" || ok.Status != http.StatusOK || ok.Err != nil {
+		t.Fatalf("unexpected captured call: %+v", ok)
+	}
+	if !strings.Contains(ok.RequestBody, `"symbol":"BTCUSDT"`) || ok.ResponseBody == "" || ok.At.IsZero() {
+		t.Fatalf("expected request and response bodies, got %+v", ok)
+	}
+
+	failed := calls[1]
+	if failed.Status != http.StatusBadRequest || failed.Err == nil {
+		t.Fatalf("expected the failure to be captured, got %+v", failed)
+	}
+	if strings.Contains(failed.ResponseBody, "leaked-key") || !strings.Contains(failed.ResponseBody, logging.Redacted) {
+		t.Fatalf("expected the response body to be redacted, got %s", failed.ResponseBody)
+	}
+}
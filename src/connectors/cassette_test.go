@@ -0,0 +1,90 @@
+package connectors_test
+
+import (
+	"errors"
+	"os"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/testsupport"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// useCassette routes the clients of exchange built during the test through
+// the named cassette. Record one with CASSETTE_RECORD=1 and live keys.
+func useCassette(t *testing.T, exchange, name string) *testsupport.Cassette {
+	t.Helper()
+	c := testsupport.NewCassette(t, name, nil)
+	t.Cleanup(connectors.SetExchangeTransport(exchange, c))
+	t.Cleanup(func() {
+		if !c.Record {
+			require.Empty(t, c.Unused(), "recorded interactions not replayed")
+		}
+	})
+	return c
+}
+
+// cassetteEnv returns the live value of key while recording and fallback on
+// replay, where requests are matched without their signatures.
+func cassetteEnv(c *testsupport.Cassette, key, fallback string) string {
+	if v := os.Getenv(key); c.Record && v != "" {
+		return v
+	}
+	return fallback
+}
+
+func TestCassettePhemexCloseAllPositions(t *testing.T) {
+	cas := useCassette(t, "PHEMEX", "phemex_close_all_positions")
+	c := connectors.NewClient(
+		cassetteEnv(cas, "PHEMEX_API_KEY", "key"),
+		cassetteEnv(cas, "PHEMEX_API_SECRET", "secret"),
+		"https://testnet-api.phemex.com",
+	)
+
+	require.NoError(t, c.CloseAllPositions("BTCUSDT"))
+
+	resp, err := c.CancelAll("BTCUSDT")
+	require.NoError(t, err)
+	require.NoError(t, resp.Err())
+}
+
+func TestCassetteKrakenFlatten(t *testing.T) {
+	cas := useCassette(t, "KRAKEN", "kraken_flatten")
+	c := connectors.NewKrakenFuturesClient(
+		cassetteEnv(cas, "KRAKEN_API_KEY", "key"),
+		cassetteEnv(cas, "KRAKEN_API_SECRET", "c2VjcmV0"),
+		"",
+	)
+
+	cancelled, err := c.CancelAllOrders("PF_XBTUSD")
+	require.NoError(t, err)
+	require.Equal(t, "cancelled", cancelled.CancelStatus.Status)
+
+	require.NoError(t, c.CloseAllPositions("PF_XBTUSD"))
+
+	pos, err := c.GetOpenPositions()
+	require.NoError(t, err)
+	require.Len(t, pos.OpenPositions, 1)
+	require.Equal(t, "PF_ETHUSD", pos.OpenPositions[0].Symbol)
+}
+
+func TestCassetteKucoinBalancesAndCancel(t *testing.T) {
+	cas := useCassette(t, "KUCOIN", "kucoin_balances_cancel")
+	k := connectors.NewKucoinConnector(
+		cassetteEnv(cas, "KUCOIN_API_KEY", "key"),
+		cassetteEnv(cas, "KUCOIN_API_SECRET", "secret"),
+		cassetteEnv(cas, "KUCOIN_API_PASSPHRASE", "pass"),
+		"2",
+	)
+
+	balances, err := k.GetAccountBalances()
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"spot_USDT": 150.25, "futures_USDT": 480.1}, balances)
+
+	require.NoError(t, k.CancelAllFuturesOrders("XBTUSDTM"))
+
+	err = k.CancelAllFuturesOrders("XBTUSDTM")
+	var exErr *connectors.ExchangeError
+	require.True(t, errors.As(err, &exErr))
+	require.Equal(t, "400100", exErr.Code)
+}
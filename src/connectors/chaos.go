@@ -0,0 +1,232 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// ErrChaosProduction is returned when fault injection is asked for a client
+// pointing at a production host.
+var ErrChaosProduction = errors.New("chaos injection refused on a production endpoint")
+
+// ChaosClient wraps a Phemex client and injects latency, timeouts, 429s and
+// partial fills into the calls the order controller makes, so the retry,
+// outbox reconciliation and crash paths of the executor can be exercised in
+// staging. Calls it does not override go straight to the embedded client,
+// which keeps the optional connector interfaces of *Client available.
+type ChaosClient struct {
+	*Client
+	cfg ChaosConfig
+
+	mu  *sync.Mutex
+	rnd *rand.Rand
+}
+
+var (
+	_ Connector              = (*ChaosClient)(nil)
+	_ ClientOrderPlacer      = (*ChaosClient)(nil)
+	_ ClientLimitOrderPlacer = (*ChaosClient)(nil)
+	_ FillLister             = (*ChaosClient)(nil)
+	_ OrderBookSource        = (*ChaosClient)(nil)
+)
+
+// NewChaosClient wraps client with the faults of cfg. Clients on production
+// hosts are refused whatever the config says.
+func NewChaosClient(client *Client, cfg ChaosConfig) (*ChaosClient, error) {
+	if IsProductionURL(client.baseURL) {
+		return nil, fmt.Errorf("%s: %w", client.baseURL, ErrChaosProduction)
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &ChaosClient{
+		Client: client,
+		cfg:    cfg,
+		mu:     &sync.Mutex{},
+		rnd:    rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// WithContext returns a copy of c whose requests run under ctx. The copy
+// shares the random source of c.
+func (c *ChaosClient) WithContext(ctx context.Context) *ChaosClient {
+	cp := *c
+	cp.Client = c.Client.WithContext(ctx)
+	return &cp
+}
+
+func (c *ChaosClient) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rnd.Float64() < rate
+}
+
+func (c *ChaosClient) jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(c.rnd.Int63n(int64(max) + 1))
+}
+
+// wait sleeps d, returning early with the context error when the client
+// context ends first.
+func (c *ChaosClient) wait(d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	ctx := c.Client.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *ChaosClient) timeoutError(op string) error {
+	if err := c.wait(c.cfg.Timeout); err != nil {
+		return err
+	}
+	return fmt.Errorf("chaos: %s: %w", op, context.DeadlineExceeded)
+}
+
+// before runs ahead of every wrapped call: it adds latency and may fail the
+// call before it reaches the exchange.
+func (c *ChaosClient) before(op string) error {
+	if err := c.wait(c.jitter(c.cfg.Latency)); err != nil {
+		return err
+	}
+	if c.roll(c.cfg.RateLimitRate) {
+		logger.WithField("op", op).Warn("chaos: injecting rate limit")
+		return httpError("phemex", http.StatusTooManyRequests, "chaos: injected rate limit")
+	}
+	if c.roll(c.cfg.TimeoutRate) {
+		logger.WithField("op", op).Warn("chaos: injecting timeout")
+		return c.timeoutError(op)
+	}
+	return nil
+}
+
+// place wraps an order placement. On top of before it may shrink qty to
+// simulate a partial fill, and may lose the answer of an order the exchange
+// did receive.
+func (c *ChaosClient) place(op, qty string, send func(qty string) (*APIResponse, error)) (*APIResponse, error) {
+	if err := c.before(op); err != nil {
+		return nil, err
+	}
+	if c.roll(c.cfg.PartialFillRate) {
+		partial := partialQty(qty, c.cfg.PartialFillRatio)
+		logger.WithFields(map[string]interface{}{
+			"op":      op,
+			"qty":     qty,
+			"partial": partial,
+		}).Warn("chaos: injecting partial fill")
+		qty = partial
+	}
+	resp, err := send(qty)
+	if err == nil && c.roll(c.cfg.TimeoutRate/2) {
+		logger.WithField("op", op).Warn("chaos: dropping order answer")
+		return nil, c.timeoutError(op)
+	}
+	return resp, err
+}
+
+// partialQty is ratio of qty, keeping the decimals qty was formatted with.
+// qty is returned unchanged when it does not parse.
+func partialQty(qty string, ratio float64) string {
+	v, err := strconv.ParseFloat(qty, 64)
+	if err != nil {
+		return qty
+	}
+	decimals := 0
+	if i := strings.IndexByte(qty, '.'); i >= 0 {
+		decimals = len(qty) - i - 1
+	}
+	return strconv.FormatFloat(v*ratio, 'f', decimals, 64)
+}
+
+func (c *ChaosClient) GetAvailableBaseFromUSDT(symbol string) (string, float64, float64, float64, error) {
+	if err := c.before("GetAvailableBaseFromUSDT"); err != nil {
+		return "", 0, 0, 0, err
+	}
+	return c.Client.GetAvailableBaseFromUSDT(symbol)
+}
+
+func (c *ChaosClient) GetPositionsUSDT() (*GAccountPositions, error) {
+	if err := c.before("GetPositionsUSDT"); err != nil {
+		return nil, err
+	}
+	return c.Client.GetPositionsUSDT()
+}
+
+func (c *ChaosClient) PlaceOrder(symbol, side, posSide, qty, ordType string, reduce bool) (*APIResponse, error) {
+	return c.place("PlaceOrder", qty, func(qty string) (*APIResponse, error) {
+		return c.Client.PlaceOrder(symbol, side, posSide, qty, ordType, reduce)
+	})
+}
+
+func (c *ChaosClient) PlaceOrderWithClientID(clOrdID, symbol, side, posSide, qty, ordType string, reduce bool) (*APIResponse, error) {
+	return c.place("PlaceOrderWithClientID", qty, func(qty string) (*APIResponse, error) {
+		return c.Client.PlaceOrderWithClientID(clOrdID, symbol, side, posSide, qty, ordType, reduce)
+	})
+}
+
+func (c *ChaosClient) PlaceLimitIOCWithClientID(clOrdID, symbol, side, posSide, qty, priceRp string, reduce bool) (*APIResponse, error) {
+	return c.place("PlaceLimitIOCWithClientID", qty, func(qty string) (*APIResponse, error) {
+		return c.Client.PlaceLimitIOCWithClientID(clOrdID, symbol, side, posSide, qty, priceRp, reduce)
+	})
+}
+
+func (c *ChaosClient) PlaceBracketOrderWithClientID(clOrdID, symbol, side, posSide, qty, stopLossRp, takeProfitRp string) (*APIResponse, error) {
+	return c.place("PlaceBracketOrderWithClientID", qty, func(qty string) (*APIResponse, error) {
+		return c.Client.PlaceBracketOrderWithClientID(clOrdID, symbol, side, posSide, qty, stopLossRp, takeProfitRp)
+	})
+}
+
+func (c *ChaosClient) SetStopLossForOpenPosition(symbol, posSide, stopPxRp, triggerType string, closeOnTrigger bool) (*APIResponse, error) {
+	if err := c.before("SetStopLossForOpenPosition"); err != nil {
+		return nil, err
+	}
+	return c.Client.SetStopLossForOpenPosition(symbol, posSide, stopPxRp, triggerType, closeOnTrigger)
+}
+
+func (c *ChaosClient) FindOrderByClientID(symbol, clOrdID string) (*ClientOrder, error) {
+	if err := c.before("FindOrderByClientID"); err != nil {
+		return nil, err
+	}
+	return c.Client.FindOrderByClientID(symbol, clOrdID)
+}
+
+func (c *ChaosClient) ListFills(symbol string) ([]PhemexFill, error) {
+	if err := c.before("ListFills"); err != nil {
+		return nil, err
+	}
+	return c.Client.ListFills(symbol)
+}
+
+func (c *ChaosClient) OrderBook(symbol string) (*OrderBook, error) {
+	if err := c.before("OrderBook"); err != nil {
+		return nil, err
+	}
+	return c.Client.OrderBook(symbol)
+}
@@ -0,0 +1,68 @@
+package connectors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"strategyexecutor/src/testsupport"
+)
+
+func TestNewChaosClientRefusesProduction(t *testing.T) {
+	client := newTestClient("https://api.phemex.com", http.DefaultClient)
+	if _, err := NewChaosClient(client, ChaosConfig{Enabled: true}); !errors.Is(err, ErrChaosProduction) {
+		t.Fatalf("expected a production refusal, got %v", err)
+	}
+}
+
+func TestChaosClientInjectsFaults(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t)
+	client := newTestClient(exchange.URL, exchange.Server.Client())
+
+	limited, err := NewChaosClient(client, ChaosConfig{RateLimitRate: 1, Seed: 1})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	_, err = limited.PlaceOrder("BTCUSDT", "Buy", "Long", "0.0020", "Market", false)
+	if !errors.Is(err, ErrRateLimited) || !IsRetryable(err) {
+		t.Fatalf("expected a retryable rate limit, got %v", err)
+	}
+	if n := exchange.Count("POST", "/g-orders"); n != 0 {
+		t.Fatalf("rate limited order must not reach the exchange, got %d calls", n)
+	}
+
+	timedOut, err := NewChaosClient(client, ChaosConfig{TimeoutRate: 1, Timeout: time.Millisecond, Seed: 1})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := timedOut.GetPositionsUSDT(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+
+	partial, err := NewChaosClient(client, ChaosConfig{PartialFillRate: 1, PartialFillRatio: 0.5, Seed: 1})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := partial.PlaceOrderWithClientID("cl-1", "BTCUSDT", "Buy", "Long", "0.0020", "Market", false); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	orders := exchange.Orders()
+	if len(orders) != 1 || orders[0].OrderQtyRq != "0.0010" {
+		t.Fatalf("expected one order of 0.0010, got %+v", orders)
+	}
+}
+
+func TestChaosClientCancelledWait(t *testing.T) {
+	client := newTestClient("http://example", http.DefaultClient)
+	chaos, err := NewChaosClient(client, ChaosConfig{TimeoutRate: 1, Timeout: time.Hour, Seed: 1})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := chaos.WithContext(ctx).GetPositionsUSDT(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the wait to stop with the context, got %v", err)
+	}
+}
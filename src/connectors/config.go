@@ -2,6 +2,7 @@ package connectors
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 )
@@ -24,3 +25,69 @@ func GetConfig() Config {
 	}
 	return config
 }
+
+// TransportConfig is the network setup of one exchange's HTTP clients. Each
+// field is read with the exchange name as prefix, e.g. PHEMEX_PROXY_URL.
+type TransportConfig struct {
+	// ProxyURL is an http, https or socks5 proxy. When empty the standard
+	// HTTPS_PROXY/NO_PROXY environment applies.
+	ProxyURL      string `envconfig:"PROXY_URL" default:""`
+	TLSMinVersion string `envconfig:"TLS_MIN_VERSION" default:"1.2"`
+	// TLSPins are base64 SHA-256 hashes of server public keys (SPKI); a
+	// connection is accepted when any certificate of the chain matches.
+	// Empty disables pinning.
+	TLSPins []string `envconfig:"TLS_PINS" default:""`
+
+	// The connection pool is shared by every client of the exchange, so
+	// the idle connections kept per host bound how many concurrent users
+	// reuse a TLS connection instead of handshaking again; net/http keeps
+	// only 2. Zero keeps the net/http default of a setting.
+	MaxIdleConns        int           `envconfig:"MAX_IDLE_CONNS" default:"100"`
+	MaxIdleConnsPerHost int           `envconfig:"MAX_IDLE_CONNS_PER_HOST" default:"64"`
+	MaxConnsPerHost     int           `envconfig:"MAX_CONNS_PER_HOST" default:"0"`
+	IdleConnTimeout     time.Duration `envconfig:"IDLE_CONN_TIMEOUT" default:"90s"`
+	// KeepAlive is the TCP keep-alive period of the connections.
+	KeepAlive time.Duration `envconfig:"KEEP_ALIVE" default:"30s"`
+	// HTTP2 multiplexes the requests over one connection per host when the
+	// exchange supports it. Disabling it forces HTTP/1.1.
+	HTTP2 bool `envconfig:"HTTP2" default:"true"`
+}
+
+func GetTransportConfig(exchange string) TransportConfig {
+	var config TransportConfig
+	if err := envconfig.Process(exchange, &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return config
+}
+
+// ChaosConfig drives the fault injection of ChaosClient, used in staging to
+// exercise the executor's retry and reconciliation paths. Rates are
+// probabilities from 0 to 1, drawn independently per call.
+type ChaosConfig struct {
+	Enabled bool `envconfig:"CHAOS_ENABLED" default:"false"`
+	// Latency is the upper bound of the random delay added to every call.
+	Latency time.Duration `envconfig:"CHAOS_LATENCY" default:"0s"`
+	// TimeoutRate calls fail with a timeout after waiting Timeout. Half of
+	// the timed out order placements still reach the exchange, like a
+	// response lost on the way back.
+	TimeoutRate float64       `envconfig:"CHAOS_TIMEOUT_RATE" default:"0"`
+	Timeout     time.Duration `envconfig:"CHAOS_TIMEOUT" default:"10s"`
+	// RateLimitRate calls are answered with HTTP 429 without reaching the
+	// exchange.
+	RateLimitRate float64 `envconfig:"CHAOS_RATE_LIMIT_RATE" default:"0"`
+	// PartialFillRate orders only get PartialFillRatio of their quantity
+	// sent, leaving the position smaller than requested.
+	PartialFillRate  float64 `envconfig:"CHAOS_PARTIAL_FILL_RATE" default:"0"`
+	PartialFillRatio float64 `envconfig:"CHAOS_PARTIAL_FILL_RATIO" default:"0.5"`
+	// Seed makes the injected faults reproducible; 0 seeds from the clock.
+	Seed int64 `envconfig:"CHAOS_SEED" default:"0"`
+}
+
+func GetChaosConfig() ChaosConfig {
+	var config ChaosConfig
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return config
+}
@@ -0,0 +1,230 @@
+package connectors
+
+import "strings"
+
+// Connector is the exchange surface the Phemex order controller depends on.
+// *Client implements it against the live API; the backtest package provides
+// a simulated implementation.
+type Connector interface {
+	GetAvailableBaseFromUSDT(symbol string) (baseSymbol string, baseAvail float64, usdtAvail float64, price float64, err error)
+	GetPositionsUSDT() (*GAccountPositions, error)
+	PlaceOrder(symbol, side, posSide, qty, ordType string, reduce bool) (*APIResponse, error)
+	SetStopLossForOpenPosition(symbol, posSide, stopPxRp, triggerType string, closeOnTrigger bool) (*APIResponse, error)
+}
+
+var _ Connector = (*Client)(nil)
+
+// ClientOrder is an order looked up by the client order id it was placed with.
+type ClientOrder struct {
+	OrderID   string `json:"orderID"`
+	ClOrdID   string `json:"clOrdID"`
+	OrdStatus string `json:"ordStatus"`
+
+	// Set by GetOrder and FindOrderByClientID of *Client; other connectors
+	// may only fill the fields above.
+	Symbol     string `json:"symbol,omitempty"`
+	Side       string `json:"side,omitempty"`
+	PosSide    string `json:"posSide,omitempty"`
+	OrdType    string `json:"ordType,omitempty"`
+	StopPxRp   string `json:"stopPxRp,omitempty"`
+	OrderQtyRq string `json:"orderQtyRq,omitempty"`
+	CumQtyRq   string `json:"cumQtyRq,omitempty"`
+	ReduceOnly bool   `json:"reduceOnly,omitempty"`
+
+	// TransactTimeNs is when the order last changed, e.g. when a stop
+	// triggered; set by *Client only.
+	TransactTimeNs int64 `json:"transactTimeNs,omitempty"`
+}
+
+// ActiveOrder is an open or untriggered order as listed by the exchange.
+type ActiveOrder struct {
+	OrderID        string `json:"orderID"`
+	ClOrdID        string `json:"clOrdID"`
+	Symbol         string `json:"symbol"`
+	Side           string `json:"side"`
+	PosSide        string `json:"posSide"`
+	OrdType        string `json:"ordType"`
+	OrdStatus      string `json:"ordStatus"`
+	StopPxRp       string `json:"stopPxRp"`
+	OrderQtyRq     string `json:"orderQtyRq"`
+	ReduceOnly     bool   `json:"reduceOnly"`
+	CloseOnTrigger bool   `json:"closeOnTrigger"`
+}
+
+// IsStop reports whether o is a reduce-only stop.
+func (o ActiveOrder) IsStop() bool {
+	return (o.OrdType == "Stop" || o.OrdType == "StopLimit") && (o.ReduceOnly || o.CloseOnTrigger)
+}
+
+// IsTakeProfit reports whether o is a reduce-only take profit.
+func (o ActiveOrder) IsTakeProfit() bool {
+	return (o.OrdType == "MarketIfTouched" || o.OrdType == "LimitIfTouched") && (o.ReduceOnly || o.CloseOnTrigger)
+}
+
+// Closes reports whether o trades against the position of posSide held
+// long or short (side). One-way (Merged) positions accept an order of any
+// posSide.
+func (o ActiveOrder) Closes(posSide, side string) bool {
+	closeSide := "Sell"
+	if strings.EqualFold(side, "Short") {
+		closeSide = "Buy"
+	}
+	if !strings.EqualFold(o.Side, closeSide) {
+		return false
+	}
+	if strings.EqualFold(posSide, "Long") || strings.EqualFold(posSide, "Short") {
+		return strings.EqualFold(o.PosSide, posSide)
+	}
+	return true
+}
+
+// ActiveOrderLister is implemented by connectors listing the open orders of
+// a symbol.
+type ActiveOrderLister interface {
+	ListActiveOrders(symbol string) ([]ActiveOrder, error)
+}
+
+var _ ActiveOrderLister = (*Client)(nil)
+
+// OrderCanceler is implemented by connectors that can cancel one open order,
+// given its exchange order ID or its client order ID.
+type OrderCanceler interface {
+	CancelOrder(symbol, orderID string) (*APIResponse, error)
+}
+
+var _ OrderCanceler = (*Client)(nil)
+
+// OrderGetter is implemented by connectors looking one order up by its
+// exchange order ID, whatever its status.
+type OrderGetter interface {
+	// GetOrder returns (nil, nil) when the exchange has no such order.
+	GetOrder(symbol, orderID string) (*ClientOrder, error)
+}
+
+var _ OrderGetter = (*Client)(nil)
+
+// ClientOrderPlacer is implemented by connectors that accept a caller chosen
+// client order id and can find the order again by it, which lets the outbox
+// tell whether an interrupted call reached the exchange.
+type ClientOrderPlacer interface {
+	PlaceOrderWithClientID(clOrdID, symbol, side, posSide, qty, ordType string, reduce bool) (*APIResponse, error)
+	// FindOrderByClientID returns (nil, nil) when the exchange has no order
+	// with clOrdID.
+	FindOrderByClientID(symbol, clOrdID string) (*ClientOrder, error)
+}
+
+var _ ClientOrderPlacer = (*Client)(nil)
+
+// FillLister is implemented by connectors exposing the account executions,
+// which carry the fee actually charged per fill.
+type FillLister interface {
+	ListFills(symbol string) ([]PhemexFill, error)
+}
+
+var _ FillLister = (*Client)(nil)
+
+// LeverageSetter is implemented by connectors that can change the leverage
+// of a symbol before an entry.
+type LeverageSetter interface {
+	SetLeverage(symbol string, leverage float64) (*APIResponse, error)
+}
+
+var _ LeverageSetter = (*Client)(nil)
+
+// TakeProfitPlacer is implemented by connectors that can rest a reduce-only
+// take profit order for an open position.
+type TakeProfitPlacer interface {
+	PlaceTakeProfitOrder(symbol, posSide, side, qty, stopPxRp, triggerType string) (*APIResponse, error)
+}
+
+var _ TakeProfitPlacer = (*Client)(nil)
+
+// ClientLimitOrderPlacer is implemented by connectors that can send a client
+// order id tagged limit order which never rests on the book.
+type ClientLimitOrderPlacer interface {
+	PlaceLimitIOCWithClientID(clOrdID, symbol, side, posSide, qty, priceRp string, reduce bool) (*APIResponse, error)
+}
+
+var _ ClientLimitOrderPlacer = (*Client)(nil)
+
+// ClientBracketOrderPlacer is implemented by connectors that can send a
+// client order id tagged market entry with its stop loss and take profit
+// attached, held by the exchange.
+type ClientBracketOrderPlacer interface {
+	PlaceBracketOrderWithClientID(clOrdID, symbol, side, posSide, qty, stopLossRp, takeProfitRp string) (*APIResponse, error)
+}
+
+var _ ClientBracketOrderPlacer = (*Client)(nil)
+
+// PostOnlyPlacer is implemented by connectors that can rest a client order
+// id tagged limit order which never takes liquidity, for passive entries.
+type PostOnlyPlacer interface {
+	PlacePostOnlyWithClientID(clOrdID, symbol, side, posSide, qty, priceRp string) (*APIResponse, error)
+}
+
+var _ PostOnlyPlacer = (*Client)(nil)
+
+// QuoteSource is implemented by connectors quoting the best bid and ask of
+// a symbol.
+type QuoteSource interface {
+	BestBidAsk(symbol string) (bid float64, ask float64, err error)
+}
+
+var _ QuoteSource = (*Client)(nil)
+
+// BookLevel is a price level of an order book.
+type BookLevel struct {
+	Price float64
+	Qty   float64
+}
+
+// OrderBook is a snapshot of an order book, best levels first.
+type OrderBook struct {
+	Symbol string
+	Bids   []BookLevel
+	Asks   []BookLevel
+}
+
+// OrderBookSource is implemented by connectors exposing order book depth.
+type OrderBookSource interface {
+	OrderBook(symbol string) (*OrderBook, error)
+}
+
+var _ OrderBookSource = (*Client)(nil)
+
+// IndexPriceSource is implemented by connectors publishing the index price
+// of a symbol, the reference of the entry price check.
+type IndexPriceSource interface {
+	IndexPrice(symbol string) (float64, error)
+}
+
+var _ IndexPriceSource = (*Client)(nil)
+
+// BalanceLister is implemented by connectors reporting account balances.
+// Keys are "<wallet>_<currency>", e.g. "spot_USDT" or "futures_USDT".
+type BalanceLister interface {
+	GetAccountBalances() (map[string]float64, error)
+}
+
+var (
+	_ BalanceLister = (*Client)(nil)
+	_ BalanceLister = (*KucoinConnector)(nil)
+)
+
+// Wallets of an account, as used in BalanceLister keys and by
+// WalletTransferer.
+const (
+	WalletSpot    = "spot"
+	WalletFutures = "futures"
+)
+
+// WalletTransferer is implemented by connectors moving funds between the
+// spot and futures wallets of the same account.
+type WalletTransferer interface {
+	TransferBetweenWallets(currency string, amount float64, from, to string) error
+}
+
+var (
+	_ WalletTransferer = (*Client)(nil)
+	_ WalletTransferer = (*KucoinConnector)(nil)
+)
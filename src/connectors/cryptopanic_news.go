@@ -0,0 +1,138 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strategyexecutor/src/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cryptoPanicBaseURL = "https://cryptopanic.com/api/v1/posts/"
+
+// CryptoPanicSource reads public posts from the CryptoPanic API. Posts
+// flagged "important" by CryptoPanic are stored with importance 1.
+type CryptoPanicSource struct {
+	httpClient *http.Client
+	baseURL    string
+	authToken  string
+	currencies []string
+}
+
+func NewCryptoPanicSource(httpClient *http.Client, baseURL string, authToken string, currencies []string) *CryptoPanicSource {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	if baseURL == "" {
+		baseURL = cryptoPanicBaseURL
+	}
+	return &CryptoPanicSource{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		authToken:  authToken,
+		currencies: currencies,
+	}
+}
+
+type cryptoPanicResponse struct {
+	Results []struct {
+		ID          int64     `json:"id"`
+		Kind        string    `json:"kind"`
+		Title       string    `json:"title"`
+		URL         string    `json:"url"`
+		PublishedAt time.Time `json:"published_at"`
+		Source      struct {
+			Title  string `json:"title"`
+			Domain string `json:"domain"`
+		} `json:"source"`
+		Currencies []struct {
+			Code string `json:"code"`
+		} `json:"currencies"`
+		Votes struct {
+			Important int `json:"important"`
+		} `json:"votes"`
+	} `json:"results"`
+}
+
+func (s *CryptoPanicSource) Name() string {
+	return model.NewsSourceCryptoPanic
+}
+
+func (s *CryptoPanicSource) FetchEvents(ctx context.Context, fromUTC time.Time, toUTC time.Time) ([]model.Event, error) {
+	if s.authToken == "" {
+		return nil, fmt.Errorf("cryptopanic: auth token not set")
+	}
+
+	q := url.Values{}
+	q.Set("auth_token", s.authToken)
+	q.Set("public", "true")
+	if len(s.currencies) > 0 {
+		q.Set("currencies", strings.Join(s.currencies, ","))
+	}
+
+	u, err := url.Parse(s.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return nil, fmt.Errorf("unexpected status %d. body: %s", resp.StatusCode, string(b))
+	}
+
+	var decoded cryptoPanicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+
+	out := make([]model.Event, 0, len(decoded.Results))
+	for _, p := range decoded.Results {
+		published := p.PublishedAt.UTC()
+		if published.Before(fromUTC) || published.After(toUTC) {
+			continue
+		}
+
+		var currency string
+		if len(p.Currencies) > 0 {
+			currency = strings.ToUpper(p.Currencies[0].Code)
+		}
+
+		importance := 0
+		if p.Votes.Important > 0 {
+			importance = 1
+		}
+
+		out = append(out, model.Event{
+			ID:         newsEventID(model.NewsSourceCryptoPanic, strconv.FormatInt(p.ID, 10)),
+			Title:      strings.TrimSpace(p.Title),
+			Ticker:     currency,
+			Category:   p.Kind,
+			Source:     p.Source.Title,
+			SourceURL:  p.URL,
+			Currency:   currency,
+			Importance: importance,
+			Date:       model.TVTime{Time: published},
+			NewsSource: model.NewsSourceCryptoPanic,
+		})
+	}
+
+	return out, nil
+}
@@ -0,0 +1,126 @@
+package connectors
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Environments an exchange account trades in.
+const (
+	EnvironmentLive    = "live"
+	EnvironmentTestnet = "testnet"
+)
+
+var (
+	// ErrProductionEndpoint is returned when a testnet account would be sent
+	// to a production endpoint.
+	ErrProductionEndpoint = errors.New("testnet account refused a production endpoint")
+	// ErrNoTestnet is returned for exchanges without a known testnet.
+	ErrNoTestnet = errors.New("exchange has no testnet endpoint")
+)
+
+// endpoints are the default REST base URLs per exchange and environment.
+var endpoints = map[string]map[string]string{
+	"phemex": {
+		EnvironmentLive:    "https://api.phemex.com",
+		EnvironmentTestnet: "https://testnet-api.phemex.com",
+	},
+	"kraken": {
+		EnvironmentLive:    defaultKrakenDerivativesBaseURL,
+		EnvironmentTestnet: "https://demo-futures.kraken.com/derivatives",
+	},
+}
+
+// productionHosts are never reached by a testnet account, whatever the
+// configured base URL says.
+var productionHosts = map[string]bool{
+	"api.phemex.com":         true,
+	"vapi.phemex.com":        true,
+	"futures.kraken.com":     true,
+	"api.kucoin.com":         true,
+	"api-futures.kucoin.com": true,
+}
+
+// NormalizeEnvironment returns environment in canonical form; empty means
+// live.
+func NormalizeEnvironment(environment string) (string, error) {
+	switch env := strings.ToLower(strings.TrimSpace(environment)); env {
+	case "", EnvironmentLive:
+		return EnvironmentLive, nil
+	case EnvironmentTestnet:
+		return EnvironmentTestnet, nil
+	default:
+		return "", fmt.Errorf("unknown environment %q, want %s or %s", environment, EnvironmentLive, EnvironmentTestnet)
+	}
+}
+
+// BaseURL returns the REST base URL of exchange for an account in
+// environment. Live accounts use liveURL when set, e.g. a configured proxy,
+// otherwise the exchange default. Testnet accounts always use the testnet
+// of the exchange and are checked against production hosts.
+func BaseURL(exchange, environment, liveURL string) (string, error) {
+	env, err := NormalizeEnvironment(environment)
+	if err != nil {
+		return "", err
+	}
+	exchange = strings.ToLower(exchange)
+
+	baseURL := endpoints[exchange][env]
+	if env == EnvironmentLive && strings.TrimSpace(liveURL) != "" {
+		baseURL = liveURL
+	}
+	if baseURL == "" {
+		if env == EnvironmentTestnet {
+			return "", fmt.Errorf("%s: %w", exchange, ErrNoTestnet)
+		}
+		return "", fmt.Errorf("no %s endpoint for %s", env, exchange)
+	}
+	if err := GuardEnvironment(env, baseURL); err != nil {
+		return "", err
+	}
+	return baseURL, nil
+}
+
+// GuardEnvironment refuses a production baseURL for a testnet account.
+func GuardEnvironment(environment, baseURL string) error {
+	env, err := NormalizeEnvironment(environment)
+	if err != nil {
+		return err
+	}
+	if env == EnvironmentTestnet && IsProductionURL(baseURL) {
+		return fmt.Errorf("%s: %w", baseURL, ErrProductionEndpoint)
+	}
+	return nil
+}
+
+// IsProductionURL reports whether baseURL points at a known production host.
+// Unparsable URLs count as production.
+func IsProductionURL(baseURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || u.Hostname() == "" {
+		return true
+	}
+	return productionHosts[strings.ToLower(u.Hostname())]
+}
+
+// NewClientFor creates a Phemex client for an account in environment, see
+// BaseURL.
+func NewClientFor(apiKey, apiSecret, environment, liveURL string) (*Client, error) {
+	baseURL, err := BaseURL("phemex", environment, liveURL)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(apiKey, apiSecret, baseURL), nil
+}
+
+// NewKrakenFuturesClientFor creates a Kraken Futures client for an account in
+// environment, see BaseURL.
+func NewKrakenFuturesClientFor(apiKey, apiSecret, environment, liveURL string) (*KrakenFuturesClient, error) {
+	baseURL, err := BaseURL("kraken", environment, liveURL)
+	if err != nil {
+		return nil, err
+	}
+	return NewKrakenFuturesClient(apiKey, apiSecret, baseURL), nil
+}
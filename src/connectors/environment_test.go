@@ -0,0 +1,55 @@
+package connectors
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBaseURL(t *testing.T) {
+	cases := []struct {
+		exchange, environment, liveURL string
+		want                           string
+		err                            error
+	}{
+		{exchange: "phemex", want: "https://api.phemex.com"},
+		{exchange: "Phemex", environment: "live", liveURL: "https://proxy.internal", want: "https://proxy.internal"},
+		{exchange: "phemex", environment: "testnet", liveURL: "https://api.phemex.com", want: "https://testnet-api.phemex.com"},
+		{exchange: "kraken", environment: "testnet", want: "https://demo-futures.kraken.com/derivatives"},
+		{exchange: "hydra", environment: "testnet", err: ErrNoTestnet},
+	}
+	for _, c := range cases {
+		got, err := BaseURL(c.exchange, c.environment, c.liveURL)
+		if c.err != nil {
+			if !errors.Is(err, c.err) {
+				t.Fatalf("BaseURL(%s, %s): expected %v, got %q, %v", c.exchange, c.environment, c.err, got, err)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Fatalf("BaseURL(%s, %s, %s) = %q, %v; want %q", c.exchange, c.environment, c.liveURL, got, err, c.want)
+		}
+	}
+
+	if _, err := BaseURL("phemex", "staging", ""); err == nil {
+		t.Fatalf("expected an error for an unknown environment")
+	}
+}
+
+func TestGuardEnvironment(t *testing.T) {
+	if err := GuardEnvironment("testnet", "https://API.phemex.com/"); !errors.Is(err, ErrProductionEndpoint) {
+		t.Fatalf("expected a production endpoint error, got %v", err)
+	}
+	if err := GuardEnvironment("testnet", "::bad"); !errors.Is(err, ErrProductionEndpoint) {
+		t.Fatalf("unparsable URLs must be refused, got %v", err)
+	}
+	if err := GuardEnvironment("testnet", "https://testnet-api.phemex.com"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := GuardEnvironment("live", "https://api.phemex.com"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, err := NewKucoinConnectorFor("k", "s", "p", "2", "testnet"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
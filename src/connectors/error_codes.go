@@ -1,9 +1,13 @@
 package connectors
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
 // PhemexErrorCodes maps Phemex bizError codes to human-readable messages.
 var PhemexErrorCodes = map[int]string{
+	10002: "OM_ORDER_NOT_FOUND",                // Order not found
 	11001: "TE_SUCCESS",                        // No error, success
 	11002: "TE_UNKNOWN_ERROR",                  // Unknown error
 	11003: "TE_INVALID_ARGUMENT",               // Invalid argument (e.g. missing or wrong param)
@@ -55,3 +59,28 @@ func GetErrorMsg(code int) string {
 	}
 	return fmt.Sprintf("UNKNOWN_PHEMEX_ERROR_%d", code)
 }
+
+// phemexErrorKinds maps bizError codes onto the shared connector error kinds.
+var phemexErrorKinds = map[int]error{
+	10002: ErrOrderNotFound,
+	11005: ErrUnavailable,
+	11040: ErrInsufficientMargin,
+	11051: ErrInsufficientMargin,
+	11052: ErrInsufficientMargin,
+	11120: ErrInvalidSymbol,
+	11121: ErrInvalidSymbol,
+}
+
+// Err returns nil for a successful response and the decoded *ExchangeError
+// otherwise.
+func (r *APIResponse) Err() error {
+	if r.Code == 0 {
+		return nil
+	}
+	return &ExchangeError{
+		Exchange: "phemex",
+		Code:     strconv.Itoa(r.Code),
+		Msg:      r.Msg,
+		Kind:     phemexErrorKinds[r.Code],
+	}
+}
@@ -0,0 +1,152 @@
+package connectors
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrorHint explains an exchange error to operators: what the code means and
+// what to do about it.
+type ErrorHint struct {
+	Exchange string `json:"exchange"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message"`
+	Action   string `json:"action"`
+}
+
+type hint struct {
+	message string
+	action  string
+}
+
+// errorHints is keyed by exchange then by the code carried in
+// ExchangeError.Code.
+var errorHints = map[string]map[string]hint{
+	"phemex": {
+		"10002": {"Order not found", "The order was already filled or canceled, refresh the open orders before acting on it."},
+		"11003": {"Invalid argument", "Check the symbol, side, posSide and quantity format of the request."},
+		"11005": {"Phemex is in maintenance mode", "Wait for the maintenance to end, the order can be retried afterwards."},
+		"11011": {"Reduce-only order aborted", "There is no position to reduce on that side, check the open position and that the account is in hedged mode."},
+		"11012": {"Invalid order quantity", "Round the quantity to the contract lot size."},
+		"11013": {"Invalid order price", "Round the price to the contract tick size."},
+		"11014": {"Invalid leverage", "Set a leverage allowed by the symbol risk limit."},
+		"11015": {"Price below the minimum", "Round the price to the contract tick size."},
+		"11016": {"Price above the maximum", "Check the price is close to the mark price."},
+		"11017": {"Quantity below the contract minimum", "Raise the order size percent or the account balance."},
+		"11018": {"Quantity above the contract maximum", "Lower the order size percent."},
+		"11019": {"Order value below the minimum", "Raise the order size percent or the account balance."},
+		"11020": {"Order value above the maximum", "Lower the order size percent or raise the risk limit on Phemex."},
+		"11021": {"Total open order value above the limit", "Cancel stale open orders or lower the order size."},
+		"11022": {"Invalid stop price", "Long stops must be below and short stops above the mark price."},
+		"11037": {"Account does not exist or is disabled", "Check the Phemex account behind the API key."},
+		"11040": {"Futures account does not exist", "Activate the USDT perpetual account on Phemex."},
+		"11041": {"Futures account frozen", "Contact Phemex support to unfreeze the account."},
+		"11050": {"Risk limit exceeded", "Raise the symbol risk limit on Phemex or lower the order size."},
+		"11051": {"Insufficient balance", "Deposit USDT into the futures account or lower the order size percent."},
+		"11052": {"Insufficient margin", "Deposit USDT into the futures account or lower the order size percent."},
+		"11060": {"Position side mismatch", "Switch the account to hedged mode, the executor sends posSide Long/Short."},
+		"11061": {"Invalid position margin", "Check the position margin and leverage on Phemex."},
+		"11062": {"Position does not exist", "The position is already closed, nothing to do."},
+		"11063": {"Take profit / stop loss too close", "Move the stop loss or take profit further from the mark price."},
+		"11064": {"Take profit / stop loss too far", "Move the stop loss or take profit closer to the mark price."},
+		"11065": {"Invalid take profit / stop loss type", "Check the trigger type of the stop order."},
+		"11066": {"Order type not supported", "Use a market or limit order for this symbol."},
+		"11067": {"Orders disabled for the symbol", "Check the contract status on Phemex."},
+		"11070": {"Market closed", "Wait for the market to reopen."},
+		"11071": {"Region restricted", "The account region cannot trade this product, contact Phemex support."},
+		"11081": {"Duplicate client order id", "The order already reached Phemex, look it up by clOrdID instead of placing it again."},
+		"11082": {"Invalid client order id", "Check the clOrdID generated for the order intent."},
+		"11100": {"Too many open orders", "Cancel stale open orders on the symbol."},
+		"11101": {"Too many open orders on one side", "Cancel stale open orders on the symbol."},
+		"11102": {"Too many open orders at the same price", "Cancel stale open orders on the symbol."},
+		"11103": {"Invalid futures margin account", "Activate the USDT perpetual account on Phemex."},
+		"11104": {"Invalid futures position", "Switch the account to hedged mode and check the open position."},
+		"11120": {"Contract not found", "Check the symbol name, e.g. BTCUSDT."},
+		"11121": {"Contract not allowed", "The contract is not tradable for this account, pick another symbol."},
+	},
+	"kucoin": {
+		"200004": {"Insufficient balance", "Transfer funds to the trading account or lower the order size percent."},
+		"300003": {"Insufficient futures balance", "Transfer USDT to the futures account or lower the order size percent."},
+		"400001": {"Missing authentication headers", "The request was not signed, check the connector configuration."},
+		"400002": {"Invalid request timestamp", "Sync the server clock, KuCoin rejects timestamps more than 5 seconds off."},
+		"400003": {"API key does not exist", "Create a new API key on KuCoin and store it with `keys set_key`."},
+		"400004": {"Invalid API passphrase", "Store the passphrase the key was created with and check the key version."},
+		"400005": {"Invalid signature", "The stored API secret does not match the key, store it again."},
+		"400006": {"IP not in the API key whitelist", "Add the server egress IP to the API key whitelist."},
+		"400007": {"API key lacks permission", "Enable futures trading on the API key."},
+		"400100": {"Invalid parameter", "Check the symbol, side, size and leverage of the request."},
+		"411100": {"Account frozen", "Contact KuCoin support to unfreeze the account."},
+		"429000": {"Too many requests", "KuCoin throttled the account, the request can be retried later."},
+		"900001": {"Symbol does not exist", "Check the KuCoin contract symbol, e.g. XBTUSDTM."},
+	},
+	"kraken": {
+		"accountInactive":            {"Account inactive", "Activate the Kraken Futures account."},
+		"apiLimitExceeded":           {"API rate limit exceeded", "Kraken throttled the key, the request can be retried later."},
+		"authenticationError":        {"Authentication failed", "Check the stored API key and secret and that the key has trading permission."},
+		"insufficientAvailableFunds": {"Insufficient available funds", "Add collateral to the Kraken Futures account or lower the order size percent."},
+		"invalidArgument":            {"Invalid argument", "Check the symbol, side and size of the request."},
+		"invalidSymbol":              {"Invalid symbol", "Check the Kraken Futures symbol, e.g. PF_XBTUSD."},
+		"marketSuspended":            {"Market suspended", "Wait for Kraken to resume the market."},
+		"nonceBelowThreshold":        {"Nonce too low", "Only one process may use an API key, stop the other one or use a dedicated key."},
+		"nonceDuplicate":             {"Duplicate nonce", "Only one process may use an API key, stop the other one or use a dedicated key."},
+		"orderNotFound":              {"Order not found", "The order was already filled or canceled."},
+		"requiredArgumentMissing":    {"Required argument missing", "The request is incomplete, check the connector."},
+		"wouldCauseLiquidation":      {"Order would cause liquidation", "Lower the order size or add collateral."},
+	},
+}
+
+// kindHints covers codes missing from errorHints and plain HTTP errors.
+var kindHints = []struct {
+	kind error
+	hint hint
+}{
+	{ErrAuth, hint{"Credentials rejected", "Check the stored API key, secret and permissions, then run `keys set_key` again."}},
+	{ErrRateLimited, hint{"Rate limited", "The exchange throttled the account, the request can be retried later."}},
+	{ErrUnavailable, hint{"Exchange unavailable", "The exchange is down or in maintenance, retry later."}},
+	{ErrInsufficientMargin, hint{"Insufficient margin", "Add funds to the account or lower the order size percent."}},
+	{ErrInvalidSymbol, hint{"Invalid symbol", "Check the symbol configured for the exchange."}},
+	{ErrOrderNotFound, hint{"Order not found", "The order was already filled or canceled."}},
+}
+
+// LookupErrorHint returns the hint of an exchange error code.
+func LookupErrorHint(exchange, code string) (ErrorHint, bool) {
+	exchange = strings.ToLower(strings.TrimSpace(exchange))
+	code = strings.TrimSpace(code)
+	h, ok := errorHints[exchange][code]
+	if !ok {
+		return ErrorHint{}, false
+	}
+	return ErrorHint{Exchange: exchange, Code: code, Message: h.message, Action: h.action}, true
+}
+
+// HintFor explains err when it wraps an *ExchangeError, by its code first and
+// by its kind otherwise. Unknown Phemex codes still get their name from
+// PhemexErrorCodes.
+func HintFor(err error) (ErrorHint, bool) {
+	var exErr *ExchangeError
+	if !errors.As(err, &exErr) {
+		return ErrorHint{}, false
+	}
+	if h, ok := LookupErrorHint(exErr.Exchange, exErr.Code); ok {
+		return h, true
+	}
+
+	out := ErrorHint{Exchange: exErr.Exchange, Code: exErr.Code}
+	for _, k := range kindHints {
+		if errors.Is(exErr, k.kind) {
+			out.Message, out.Action = k.hint.message, k.hint.action
+			return out, true
+		}
+	}
+	if exErr.Exchange == "phemex" {
+		if code, err := strconv.Atoi(exErr.Code); err == nil {
+			if name, ok := PhemexErrorCodes[code]; ok {
+				out.Message = name
+				out.Action = "See the Phemex API error code reference."
+				return out, true
+			}
+		}
+	}
+	return ErrorHint{}, false
+}
@@ -0,0 +1,80 @@
+package connectors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error kinds shared by all connectors. Exchange specific codes are decoded
+// into an *ExchangeError wrapping one of these, so callers can branch with
+// errors.Is instead of matching messages.
+var (
+	ErrRateLimited        = errors.New("rate limited")
+	ErrInsufficientMargin = errors.New("insufficient margin")
+	ErrInvalidSymbol      = errors.New("invalid symbol")
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrAuth               = errors.New("credentials rejected")
+	// ErrUnavailable covers maintenance and 5xx answers.
+	ErrUnavailable = errors.New("exchange unavailable")
+)
+
+// ExchangeError is an error answer from an exchange. Kind is one of the Err*
+// values above, or nil when the code has no shared meaning.
+type ExchangeError struct {
+	Exchange string
+	// Status is the HTTP status, 0 when the error came in a 200 body.
+	Status int
+	// Code is the exchange error code, empty for plain HTTP errors.
+	Code string
+	Msg  string
+	Kind error
+}
+
+func (e *ExchangeError) Error() string {
+	switch {
+	case e.Code == "":
+		return fmt.Sprintf("%s HTTP %d: %s", e.Exchange, e.Status, e.Msg)
+	case e.Msg == "":
+		return fmt.Sprintf("%s error %s", e.Exchange, e.Code)
+	default:
+		return fmt.Sprintf("%s error %s: %s", e.Exchange, e.Code, e.Msg)
+	}
+}
+
+func (e *ExchangeError) Unwrap() error { return e.Kind }
+
+// IsRetryable reports whether the same request may succeed later without any
+// change: the exchange throttled it or was unavailable.
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable)
+}
+
+// IsRejected reports whether the exchange answered and refused the request,
+// so it did not act on it and retrying unchanged will fail again.
+func IsRejected(err error) bool {
+	var exErr *ExchangeError
+	return errors.As(err, &exErr) && !IsRetryable(err)
+}
+
+// httpError builds the error of a non-2xx answer, classified by status.
+func httpError(exchange string, status int, body string) *ExchangeError {
+	return &ExchangeError{
+		Exchange: exchange,
+		Status:   status,
+		Msg:      body,
+		Kind:     kindForStatus(status),
+	}
+}
+
+func kindForStatus(status int) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuth
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status == http.StatusRequestTimeout || status >= 500:
+		return ErrUnavailable
+	}
+	return nil
+}
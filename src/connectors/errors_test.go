@@ -0,0 +1,104 @@
+package connectors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKindForStatus(t *testing.T) {
+	cases := map[int]error{
+		401: ErrAuth,
+		403: ErrAuth,
+		429: ErrRateLimited,
+		408: ErrUnavailable,
+		502: ErrUnavailable,
+		400: nil,
+		404: nil,
+	}
+	for status, want := range cases {
+		require.Equal(t, want, kindForStatus(status), "status %d", status)
+	}
+}
+
+func TestPhemexResponseErr(t *testing.T) {
+	require.NoError(t, (&APIResponse{Code: 0}).Err())
+
+	err := (&APIResponse{Code: 11052, Msg: "TE_INSUFFICIENT_AVAILABLE_BALANCE"}).Err()
+	require.ErrorIs(t, err, ErrInsufficientMargin)
+	require.EqualError(t, err, "phemex error 11052: TE_INSUFFICIENT_AVAILABLE_BALANCE")
+	require.True(t, IsRejected(err))
+	require.False(t, IsRetryable(err))
+
+	wrapped := fmt.Errorf("place order: %w", (&APIResponse{Code: 11005}).Err())
+	require.ErrorIs(t, wrapped, ErrUnavailable)
+	require.True(t, IsRetryable(wrapped))
+	require.False(t, IsRejected(wrapped))
+
+	// unknown codes are still rejections, just without a shared kind
+	err = (&APIResponse{Code: 19999}).Err()
+	require.True(t, IsRejected(err))
+	require.False(t, errors.Is(err, ErrAuth))
+}
+
+func TestKucoinError(t *testing.T) {
+	err := kucoinError(401, []byte(`{"code":"400003","msg":"KC-API-KEY not exists"}`))
+	require.ErrorIs(t, err, ErrAuth)
+	require.Equal(t, "400003", err.Code)
+
+	err = kucoinError(429, []byte(`{"code":"999999","msg":"slow down"}`))
+	require.ErrorIs(t, err, ErrRateLimited)
+
+	err = kucoinError(503, []byte(`<html>maintenance</html>`))
+	require.ErrorIs(t, err, ErrUnavailable)
+	require.Empty(t, err.Code)
+}
+
+func TestIsRejectedPlainError(t *testing.T) {
+	require.False(t, IsRejected(errors.New("i/o timeout")))
+	require.False(t, IsRetryable(errors.New("i/o timeout")))
+}
+
+func TestHintFor(t *testing.T) {
+	h, ok := HintFor(fmt.Errorf("place order: %w", (&APIResponse{Code: 11082}).Err()))
+	require.True(t, ok)
+	require.Equal(t, "phemex", h.Exchange)
+	require.Equal(t, "11082", h.Code)
+	require.NotEmpty(t, h.Message)
+	require.NotEmpty(t, h.Action)
+
+	h, ok = HintFor(&ExchangeError{Exchange: "kucoin", Code: "400006"})
+	require.True(t, ok)
+	require.Contains(t, h.Action, "whitelist")
+
+	// unknown codes and plain HTTP errors fall back to the error kind
+	h, ok = HintFor(httpError("kraken", 429, "slow down"))
+	require.True(t, ok)
+	require.Equal(t, "Rate limited", h.Message)
+
+	h, ok = HintFor((&APIResponse{Code: 11002}).Err())
+	require.True(t, ok)
+	require.Equal(t, "TE_UNKNOWN_ERROR", h.Message)
+
+	_, ok = HintFor(&ExchangeError{Exchange: "kraken", Code: "somethingNew"})
+	require.False(t, ok)
+	_, ok = HintFor(errors.New("boom"))
+	require.False(t, ok)
+}
+
+func TestLookupErrorHintCoversKnownKinds(t *testing.T) {
+	for code := range phemexErrorKinds {
+		_, ok := LookupErrorHint("Phemex", fmt.Sprint(code))
+		require.True(t, ok, "code %d", code)
+	}
+	for code := range kucoinErrorKinds {
+		_, ok := LookupErrorHint("kucoin", code)
+		require.True(t, ok, "code %s", code)
+	}
+	for code := range krakenErrorKinds {
+		_, ok := LookupErrorHint("kraken", code)
+		require.True(t, ok, "code %s", code)
+	}
+}
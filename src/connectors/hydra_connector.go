@@ -51,8 +51,9 @@ func NewGooeyClient(apiKey, apiSecret string) (*GooeyClient, error) {
 	return &GooeyClient{
 		BaseURL: u,
 		HTTP: &http.Client{
-			Jar:     jar,
-			Timeout: 30 * time.Second,
+			Jar:       jar,
+			Timeout:   30 * time.Second,
+			Transport: DefaultRetryPolicy.Transport(exchangeTransport("HYDRA")),
 		},
 		UserAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
 		APIKey:    apiKey,
@@ -71,7 +72,8 @@ func (c *GooeyClient) Login(ctx context.Context) error {
 	}
 	body, _ := json.Marshal(payload)
 
-	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, loginURL, bytes.NewReader(body))
+	// Logging in twice is harmless, so let the transport retry it.
+	req, _ := http.NewRequestWithContext(WithIdempotent(ctx, true), http.MethodPost, loginURL, bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Origin", c.BaseURL.String())
 	req.Header.Set("Referer", c.BaseURL.String()+"/")
@@ -86,7 +88,7 @@ func (c *GooeyClient) Login(ctx context.Context) error {
 	io.Copy(io.Discard, resp.Body)
 
 	if resp.StatusCode/100 != 2 {
-		return fmt.Errorf("login non-2xx status: %d", resp.StatusCode)
+		return httpError("hydra", resp.StatusCode, "login failed")
 	}
 
 	// Pull cookies out of the jar and store explicit references
@@ -419,7 +421,8 @@ func (c *GooeyClient) HistoryTrades(ctx context.Context, fromMs, toMs int64) ([]
 		RawQuery: fmt.Sprintf("from=%d&to=%d", fromMs, toMs),
 	}).String()
 
-	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, u, http.NoBody)
+	// A read despite the POST: safe to retry.
+	req, _ := http.NewRequestWithContext(WithIdempotent(ctx, true), http.MethodPost, u, http.NoBody)
 	req.Header.Set("User-Agent", userAgentDefault)
 	req.Header.Set("Accept", "*/*")
 	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
@@ -4,6 +4,7 @@ package connectors
 // RESTY ONLY + INTERNAL RETRY
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -13,6 +14,7 @@ import (
 	"fmt"
 	"net/url"
 	"sort"
+	"strategyexecutor/src/cache"
 	"strconv"
 	"strings"
 	"time"
@@ -77,24 +79,35 @@ type KrakenFuturesClient struct {
 	apiSecret string // base64-encoded secret from Kraken
 	baseURL   string
 	http      *resty.Client
+	// ctx, when set, is attached to every request. See WithContext.
+	ctx context.Context
+	// capture, when set, receives the order-mutating calls. See WithCapture.
+	capture CaptureFunc
 }
 
-func NewKrakenFuturesClient(apiKey, apiSecret, baseURL string) *KrakenFuturesClient {
-	retryCount := defaultRetryAttempts - 1
+// WithContext returns a copy of c whose requests run under ctx.
+func (c *KrakenFuturesClient) WithContext(ctx context.Context) *KrakenFuturesClient {
+	cp := *c
+	cp.ctx = ctx
+	return &cp
+}
 
+// WithCapture returns a copy of c handing every POST, PUT and DELETE call,
+// redacted, to capture once it completed.
+func (c *KrakenFuturesClient) WithCapture(capture CaptureFunc) *KrakenFuturesClient {
+	cp := *c
+	cp.capture = capture
+	return &cp
+}
+
+func NewKrakenFuturesClient(apiKey, apiSecret, baseURL string) *KrakenFuturesClient {
 	if strings.TrimSpace(baseURL) == "" {
 		baseURL = defaultKrakenDerivativesBaseURL
 		logger.Warnf("No base URL provided, using default: %s", baseURL)
 	}
 	baseURL = strings.TrimRight(baseURL, "/")
 
-	httpClient := resty.New().
-		SetBaseURL(baseURL).
-		SetTimeout(15 * time.Second).
-		SetRetryCount(retryCount).
-		SetRetryWaitTime(defaultRetryBaseDelay).
-		SetRetryMaxWaitTime(defaultRetryMaxBackoff).
-		AddRetryCondition(isRetryableResp)
+	httpClient := DefaultRetryPolicy.newRestyClient(baseURL, 15*time.Second, exchangeTransport("KRAKEN"))
 
 	return &KrakenFuturesClient{
 		apiKey:    apiKey,
@@ -185,7 +198,7 @@ func (c *KrakenFuturesClient) doPrivateRequest(method, endpoint string, params u
 	return c.doRequest(method, endpoint, params, true, out)
 }
 
-func (c *KrakenFuturesClient) doRequest(method, endpoint string, params url.Values, auth bool, out any) error {
+func (c *KrakenFuturesClient) doRequest(method, endpoint string, params url.Values, auth bool, out any) (err error) {
 	if !strings.HasPrefix(endpoint, "/") {
 		endpoint = "/" + endpoint
 	}
@@ -200,6 +213,9 @@ func (c *KrakenFuturesClient) doRequest(method, endpoint string, params url.Valu
 
 	req := c.http.R().
 		SetHeader("Accept", "application/json")
+	if c.ctx != nil {
+		req.SetContext(c.ctx)
+	}
 
 	if auth {
 		nonce := nonceMillis()
@@ -220,14 +236,24 @@ func (c *KrakenFuturesClient) doRequest(method, endpoint string, params url.Valu
 		req = req.SetQueryString(postData)
 	}
 
+	start := time.Now()
 	resp, err := req.Execute(method, httpPath)
+	if c.capture != nil && isMutatingMethod(method) {
+		defer func() {
+			status, raw := 0, []byte(nil)
+			if resp != nil {
+				status, raw = resp.StatusCode(), resp.Body()
+			}
+			c.capture(newAPICall("kraken", method, httpPath, postData, "", status, raw, start, err))
+		}()
+	}
 	if err != nil {
 		return err
 	}
 
 	raw := resp.Body()
 	if resp.StatusCode() != 200 {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode(), string(raw))
+		return httpError("kraken", resp.StatusCode(), string(raw))
 	}
 
 	// Many Kraken Futures endpoints return HTTP 200 even on errors, with {result:"error", error:"..."}.
@@ -239,7 +265,7 @@ func (c *KrakenFuturesClient) doRequest(method, endpoint string, params url.Valu
 		if base.Error == "" {
 			return errors.New("kraken futures returned result=error")
 		}
-		return fmt.Errorf("kraken futures error: %s", base.Error)
+		return &ExchangeError{Exchange: "kraken", Code: base.Error, Kind: krakenErrorKinds[base.Error]}
 	}
 
 	if out != nil {
@@ -251,6 +277,17 @@ func (c *KrakenFuturesClient) doRequest(method, endpoint string, params url.Valu
 	return nil
 }
 
+// krakenErrorKinds maps Kraken Futures error strings onto the shared
+// connector error kinds.
+var krakenErrorKinds = map[string]error{
+	"apiLimitExceeded":           ErrRateLimited,
+	"authenticationError":        ErrAuth,
+	"insufficientAvailableFunds": ErrInsufficientMargin,
+	"invalidSymbol":              ErrInvalidSymbol,
+	"marketSuspended":            ErrUnavailable,
+	"orderNotFound":              ErrOrderNotFound,
+}
+
 // -----------------------------
 // TRADING
 // -----------------------------
@@ -406,6 +443,22 @@ func (c *KrakenFuturesClient) CancelAllOrders(symbol string) (*CancelAllOrdersRe
 	return &out, nil
 }
 
+// SetLeveragePreference sets the max leverage of symbol, which trades it in
+// isolated margin at that leverage.
+// PUT /leveragepreferences
+func (c *KrakenFuturesClient) SetLeveragePreference(symbol string, maxLeverage float64) error {
+	if strings.TrimSpace(symbol) == "" {
+		return errors.New("symbol is required")
+	}
+	if maxLeverage <= 0 {
+		return fmt.Errorf("leverage must be positive, got %v", maxLeverage)
+	}
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("maxLeverage", strconv.FormatFloat(maxLeverage, 'f', -1, 64))
+	return c.doPrivateRequest("PUT", "/leveragepreferences", params, nil)
+}
+
 // -----------------------------
 // PRIVATE QUERIES
 // -----------------------------
@@ -517,16 +570,29 @@ type TickerBySymbolResponse struct {
 }
 
 // GET /tickers/:symbol :contentReference[oaicite:6]{index=6}
+// The ticker is shared through the cache between the clients of the same
+// endpoint for cache.Config.TickerTTL.
 func (c *KrakenFuturesClient) GetTickerBySymbol(symbol string) (*TickerBySymbolResponse, error) {
 	if strings.TrimSpace(symbol) == "" {
 		return nil, errors.New("symbol is required")
 	}
-	var out TickerBySymbolResponse
-	ep := "/tickers/" + url.PathEscape(symbol)
-	if err := c.doPublicRequest("GET", ep, nil, &out); err != nil {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	out, err := cache.GetOrLoad(ctx, cache.Default(), cache.Key("ticker", c.baseURL, symbol), cache.TTLs().TickerTTL,
+		func() (*TickerBySymbolResponse, error) {
+			var out TickerBySymbolResponse
+			ep := "/tickers/" + url.PathEscape(symbol)
+			if err := c.doPublicRequest("GET", ep, nil, &out); err != nil {
+				return nil, err
+			}
+			return &out, nil
+		})
+	if err != nil {
 		return nil, err
 	}
-	return &out, nil
+	return out, nil
 }
 
 type OrderbookResponse struct {
@@ -2,15 +2,20 @@ package connectors
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strategyexecutor/src/cache"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	logger "github.com/sirupsen/logrus"
@@ -23,7 +28,11 @@ import (
 const (
 	kucoinSpotBaseURL    = "https://api.kucoin.com"
 	kucoinFuturesBaseURL = "https://api-futures.kucoin.com"
-	httpTimeout          = 10 * time.Second
+
+	kucoinSandboxSpotBaseURL    = "https://openapi-sandbox.kucoin.com"
+	kucoinSandboxFuturesBaseURL = "https://api-sandbox-futures.kucoin.com"
+
+	httpTimeout = 10 * time.Second
 )
 
 // ---------------------------------------------------------------------
@@ -37,6 +46,36 @@ type kucoinAPIResponse struct {
 	Data json.RawMessage `json:"data"`
 }
 
+// kucoinErrorKinds maps KuCoin error codes onto the shared connector error
+// kinds. 4000xx are the request authentication failures.
+var kucoinErrorKinds = map[string]error{
+	"400001": ErrAuth,
+	"400002": ErrAuth,
+	"400003": ErrAuth,
+	"400004": ErrAuth,
+	"400005": ErrAuth,
+	"400006": ErrAuth,
+	"400007": ErrAuth,
+	"429000": ErrRateLimited,
+	"200004": ErrInsufficientMargin,
+	"300003": ErrInsufficientMargin,
+	"900001": ErrInvalidSymbol,
+}
+
+// kucoinError decodes a non-2xx answer, which usually still carries a KuCoin
+// code and message, falling back to the HTTP status.
+func kucoinError(status int, body []byte) *ExchangeError {
+	var apiResp kucoinAPIResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil || apiResp.Code == "" {
+		return httpError("kucoin", status, string(body))
+	}
+	kind, ok := kucoinErrorKinds[apiResp.Code]
+	if !ok {
+		kind = kindForStatus(status)
+	}
+	return &ExchangeError{Exchange: "kucoin", Status: status, Code: apiResp.Code, Msg: apiResp.Msg, Kind: kind}
+}
+
 // KucoinFuturesContract representa os campos principais de /api/v1/contracts/{symbol}
 type KucoinFuturesContract struct {
 	Symbol          string  `json:"symbol"`
@@ -124,6 +163,15 @@ func kucoinSignPassphrase(secret, passphrase string) string {
 	return base64.StdEncoding.EncodeToString(hash)
 }
 
+// kucoinPassphraseHeader is the KC-API-PASSPHRASE of keyVersion: v1 keys
+// send the passphrase as is, v2 and v3 keys sign it.
+func kucoinPassphraseHeader(secret, passphrase, keyVersion string) string {
+	if keyVersion == "" || keyVersion == "1" {
+		return passphrase
+	}
+	return kucoinSignPassphrase(secret, passphrase)
+}
+
 // KC-API-SIGN = base64( HMAC_SHA256(apiSecret, timestamp + method + requestPath + body) )
 // requestPath = path + queryString (ex: "/api/v1/accounts?type=trade")
 func kucoinSignRequest(secret, timestamp, method, requestPath, body string) string {
@@ -138,17 +186,68 @@ func kucoinSignRequest(secret, timestamp, method, requestPath, body string) stri
 // CLIENTE BAIXO NÍVEL (SPOT OU FUTURES)
 // ---------------------------------------------------------------------
 
+// kucoinKeyVersion is the KC-API-KEY-VERSION shared by the spot and futures
+// clients of a connector. Users often store keys under the wrong version,
+// so the first passphrase rejection retries the call once with the
+// alternate version, which is kept when KuCoin accepts it.
+type kucoinKeyVersion struct {
+	mu       sync.Mutex
+	version  string
+	probed   bool
+	detected bool
+}
+
+func (v *kucoinKeyVersion) get() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.version
+}
+
+// probe reports whether the alternate version is still to be tried.
+func (v *kucoinKeyVersion) probe() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.probed {
+		return false
+	}
+	v.probed = true
+	return true
+}
+
+func (v *kucoinKeyVersion) detect(version string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.version = version
+	v.detected = true
+}
+
+// kucoinAlternateKeyVersion is the version tried when KuCoin rejects the
+// passphrase of keyVersion: v2 and v3 swap, v1 keys move to v2.
+func kucoinAlternateKeyVersion(keyVersion string) string {
+	if keyVersion == "2" {
+		return "3"
+	}
+	return "2"
+}
+
+// isKucoinPassphraseError reports whether err is KuCoin rejecting the
+// KC-API-PASSPHRASE, which a wrong key version also causes.
+func isKucoinPassphraseError(err error) bool {
+	var exErr *ExchangeError
+	return errors.As(err, &exErr) && exErr.Exchange == "kucoin" && exErr.Code == "400004"
+}
+
 type kucoinRESTClient struct {
 	apiKey        string
 	apiSecret     string
 	apiPassphrase string
-	keyVersion    string
+	keyVersion    *kucoinKeyVersion
 	baseURL       string
 	httpClient    *http.Client
 }
 
 func newKucoinRESTClient(
-	apiKey, apiSecret, apiPassphrase, keyVersion, baseURL string,
+	apiKey, apiSecret, apiPassphrase string, keyVersion *kucoinKeyVersion, baseURL string,
 ) *kucoinRESTClient {
 	return &kucoinRESTClient{
 		apiKey:        apiKey,
@@ -157,15 +256,45 @@ func newKucoinRESTClient(
 		keyVersion:    keyVersion,
 		baseURL:       baseURL,
 		httpClient: &http.Client{
-			Timeout: httpTimeout,
+			Timeout:   httpTimeout,
+			Transport: DefaultRetryPolicy.Transport(exchangeTransport("KUCOIN")),
 		},
 	}
 }
 
 // doRequest performs a signed HTTP call to KuCoin and returns a parsed kucoinAPIResponse.
+// The first passphrase rejection of the connector is retried with the
+// alternate key version, see kucoinKeyVersion.
 func (c *kucoinRESTClient) doRequest(
 	method, endpoint, query, body string,
 ) (*kucoinAPIResponse, error) {
+	keyVersion := c.keyVersion.get()
+	resp, err := c.send(method, endpoint, query, body, keyVersion)
+	if !isKucoinPassphraseError(err) || !c.keyVersion.probe() {
+		return resp, err
+	}
+
+	alternate := kucoinAlternateKeyVersion(keyVersion)
+	logger.WithFields(logger.Fields{
+		"key_version": keyVersion,
+		"alternate":   alternate,
+	}).Warn("KuCoin rejected the passphrase, retrying with the alternate key version")
+
+	altResp, altErr := c.send(method, endpoint, query, body, alternate)
+	var exErr *ExchangeError
+	if altErr != nil && (!errors.As(altErr, &exErr) || errors.Is(altErr, ErrAuth)) {
+		// Rejected again, or no answer: nothing tells the alternate is right.
+		return nil, err
+	}
+	c.keyVersion.detect(alternate)
+	logger.WithField("key_version", alternate).Info("KuCoin key version detected")
+	return altResp, altErr
+}
+
+// send performs one signed HTTP call with keyVersion.
+func (c *kucoinRESTClient) send(
+	method, endpoint, query, body, keyVersion string,
+) (*kucoinAPIResponse, error) {
 
 	// Build request path used for signing (path + query)
 	requestPath := endpoint
@@ -182,8 +311,8 @@ func (c *kucoinRESTClient) doRequest(
 	// Calculate request signature
 	signature := kucoinSignRequest(c.apiSecret, timestamp, method, requestPath, body)
 
-	// Encrypted passphrase
-	encryptedPassphrase := kucoinSignPassphrase(c.apiSecret, c.apiPassphrase)
+	// Passphrase, signed for v2/v3 keys
+	passphrase := kucoinPassphraseHeader(c.apiSecret, c.apiPassphrase, keyVersion)
 
 	var bodyReader io.Reader
 	if body != "" {
@@ -210,9 +339,9 @@ func (c *kucoinRESTClient) doRequest(
 	req.Header.Set("KC-API-KEY", c.apiKey)
 	req.Header.Set("KC-API-SIGN", signature)
 	req.Header.Set("KC-API-TIMESTAMP", timestamp)
-	req.Header.Set("KC-API-PASSPHRASE", encryptedPassphrase)
-	if c.keyVersion != "" {
-		req.Header.Set("KC-API-KEY-VERSION", c.keyVersion) // e.g. "3"
+	req.Header.Set("KC-API-PASSPHRASE", passphrase)
+	if keyVersion != "" {
+		req.Header.Set("KC-API-KEY-VERSION", keyVersion) // e.g. "3"
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -239,7 +368,7 @@ func (c *kucoinRESTClient) doRequest(
 			"status": resp.StatusCode,
 			"body":   string(respBody),
 		}).Error("KuCoin HTTP non-2xx status")
-		return nil, fmt.Errorf("http status %d: %s", resp.StatusCode, string(respBody))
+		return nil, kucoinError(resp.StatusCode, respBody)
 	}
 
 	var apiResp kucoinAPIResponse
@@ -253,7 +382,7 @@ func (c *kucoinRESTClient) doRequest(
 			"code": apiResp.Code,
 			"msg":  apiResp.Msg,
 		}).Error("KuCoin API returned error code")
-		return nil, fmt.Errorf("kucoin error code=%s msg=%s", apiResp.Code, apiResp.Msg)
+		return nil, &ExchangeError{Exchange: "kucoin", Code: apiResp.Code, Msg: apiResp.Msg, Kind: kucoinErrorKinds[apiResp.Code]}
 	}
 
 	return &apiResp, nil
@@ -266,18 +395,47 @@ func (c *kucoinRESTClient) doRequest(
 type KucoinConnector struct {
 	spotClient    *kucoinRESTClient
 	futuresClient *kucoinRESTClient
+	keyVersion    *kucoinKeyVersion
 }
 
 // NewKucoinConnector cria um connector usando REST cru (sem ccxt).
 func NewKucoinConnector(
 	apiKey, apiSecret, apiPassphrase, keyVersion string,
 ) *KucoinConnector {
+	return newKucoinConnector(apiKey, apiSecret, apiPassphrase, keyVersion, kucoinSpotBaseURL, kucoinFuturesBaseURL)
+}
+
+func newKucoinConnector(apiKey, apiSecret, apiPassphrase, keyVersion, spotBaseURL, futuresBaseURL string) *KucoinConnector {
+	version := &kucoinKeyVersion{version: keyVersion}
 	return &KucoinConnector{
-		spotClient:    newKucoinRESTClient(apiKey, apiSecret, apiPassphrase, keyVersion, kucoinSpotBaseURL),
-		futuresClient: newKucoinRESTClient(apiKey, apiSecret, apiPassphrase, keyVersion, kucoinFuturesBaseURL),
+		spotClient:    newKucoinRESTClient(apiKey, apiSecret, apiPassphrase, version, spotBaseURL),
+		futuresClient: newKucoinRESTClient(apiKey, apiSecret, apiPassphrase, version, futuresBaseURL),
+		keyVersion:    version,
 	}
 }
 
+// NewKucoinConnectorFor creates a connector for an account in environment;
+// testnet accounts go to the KuCoin sandbox.
+func NewKucoinConnectorFor(apiKey, apiSecret, apiPassphrase, keyVersion, environment string) (*KucoinConnector, error) {
+	env, err := NormalizeEnvironment(environment)
+	if err != nil {
+		return nil, err
+	}
+	if env == EnvironmentLive {
+		return NewKucoinConnector(apiKey, apiSecret, apiPassphrase, keyVersion), nil
+	}
+	return newKucoinConnector(apiKey, apiSecret, apiPassphrase, keyVersion, kucoinSandboxSpotBaseURL, kucoinSandboxFuturesBaseURL), nil
+}
+
+// KeyVersion returns the KC-API-KEY-VERSION the connector sends and whether
+// it was detected, i.e. differs from the configured one after KuCoin
+// accepted it on retry. Callers store a detected version with the account.
+func (k *KucoinConnector) KeyVersion() (version string, detected bool) {
+	k.keyVersion.mu.Lock()
+	defer k.keyVersion.mu.Unlock()
+	return k.keyVersion.version, k.keyVersion.detected
+}
+
 // TestConnection checks if we can reach both spot and futures APIs.
 func (k *KucoinConnector) TestConnection() error {
 	logger.Info("Testing KuCoin spot and futures connectivity")
@@ -351,6 +509,49 @@ func (k *KucoinConnector) GetAccountBalances() (map[string]float64, error) {
 	return balances, nil
 }
 
+// kucoinAccountTypes maps wallets to KuCoin inner-transfer account types.
+var kucoinAccountTypes = map[string]string{
+	WalletSpot:    "main",
+	WalletFutures: "contract",
+}
+
+// TransferBetweenWallets moves amount of currency between the main (spot)
+// and the futures account: POST /api/v2/accounts/inner-transfer.
+func (k *KucoinConnector) TransferBetweenWallets(currency string, amount float64, from, to string) error {
+	if currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("transfer amount must be positive, got %v", amount)
+	}
+	fromType, okFrom := kucoinAccountTypes[from]
+	toType, okTo := kucoinAccountTypes[to]
+	if !okFrom || !okTo || from == to {
+		return fmt.Errorf("unsupported transfer from %q to %q", from, to)
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"clientOid": fmt.Sprintf("go-%d", time.Now().UnixNano()),
+		"currency":  currency,
+		"from":      fromType,
+		"to":        toType,
+		"amount":    strconv.FormatFloat(amount, 'f', -1, 64),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal transfer body: %w", err)
+	}
+
+	logger.WithFields(logger.Fields{
+		"currency": currency,
+		"amount":   amount,
+		"from":     fromType,
+		"to":       toType,
+	}).Info("Transferring KuCoin funds between accounts")
+
+	_, err = k.spotClient.doRequest(http.MethodPost, "/api/v2/accounts/inner-transfer", "", string(b))
+	return err
+}
+
 // GetAvailableBaseFromUSDT converts the available USDT balance into base units using the latest ticker price.
 func (k *KucoinConnector) GetAvailableBaseFromUSDT(
 	symbol string,
@@ -536,6 +737,20 @@ func (k *KucoinConnector) ExecuteFuturesOrder(
 	return out, nil
 }
 
+// CancelAllFuturesOrders cancels the open futures limit orders, only those
+// of symbol when it is not empty. Stop orders are left in place.
+func (k *KucoinConnector) CancelAllFuturesOrders(symbol string) error {
+	query := ""
+	if symbol != "" {
+		query = "symbol=" + url.QueryEscape(symbol)
+	}
+	if _, err := k.futuresClient.doRequest(http.MethodDelete, "/api/v1/orders", query, ""); err != nil {
+		logger.WithError(err).WithField("symbol", symbol).Error("Failed to cancel KuCoin futures orders")
+		return fmt.Errorf("cancel futures orders: %w", err)
+	}
+	return nil
+}
+
 // CloseAllPositions is a placeholder to align KuCoin connector behavior with Phemex flows.
 func (k *KucoinConnector) CloseAllPositions(symbol string) error {
 	logger.WithField("symbol", symbol).Warn("CloseAllPositions for KuCoin is not implemented; skipping")
@@ -818,10 +1033,17 @@ func (k *KucoinConnector) GetFuturesAvailableFromRiskUnit(symbol string) (float6
 
 // GetFuturesContractInfo fetches futures contract details for a specific symbol.
 // Example: symbol = "XBTUSDTM"
+// The contract is shared through the cache for cache.Config.InstrumentTTL.
 func (k *KucoinConnector) GetFuturesContractInfo(symbol string) (*KucoinFuturesContract, error) {
 	if symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
+	key := cache.Key("instrument", k.futuresClient.baseURL, symbol)
+	return cache.GetOrLoad(context.Background(), cache.Default(), key, cache.TTLs().InstrumentTTL,
+		func() (*KucoinFuturesContract, error) { return k.fetchFuturesContractInfo(symbol) })
+}
+
+func (k *KucoinConnector) fetchFuturesContractInfo(symbol string) (*KucoinFuturesContract, error) {
 
 	endpoint := fmt.Sprintf("/api/v1/contracts/%s", symbol)
 	logger.WithFields(logger.Fields{
@@ -0,0 +1,77 @@
+package connectors_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strategyexecutor/src/connectors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// kucoinVersionServer answers like KuCoin for keys of version accepted: any
+// other KC-API-KEY-VERSION has its passphrase rejected.
+func kucoinVersionServer(t *testing.T, accepted string) *[]string {
+	sent := []string{}
+	t.Cleanup(connectors.SetExchangeTransport("KUCOIN", roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		version := r.Header.Get("KC-API-KEY-VERSION")
+		sent = append(sent, version)
+
+		status, body := http.StatusOK, `{"code":"200000","data":[]}`
+		switch {
+		case version != accepted:
+			status, body = http.StatusUnauthorized, `{"code":"400004","msg":"Invalid KC-API-PASSPHRASE"}`
+		case strings.HasSuffix(r.URL.Path, "/account-overview"):
+			body = `{"code":"200000","data":{"currency":"USDT","availableBalance":12.5}}`
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})))
+	return &sent
+}
+
+// TestKucoinDetectsKeyVersion checks a passphrase rejection is retried once
+// with the alternate key version, which the connector then keeps.
+func TestKucoinDetectsKeyVersion(t *testing.T) {
+	sent := kucoinVersionServer(t, "3")
+	k := connectors.NewKucoinConnector("key", "secret", "pass", "2")
+
+	balances, err := k.GetAccountBalances()
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"futures_USDT": 12.5}, balances)
+	require.Equal(t, []string{"2", "3", "3"}, *sent, "spot retried, futures sent with the detected version")
+
+	version, detected := k.KeyVersion()
+	require.Equal(t, "3", version)
+	require.True(t, detected)
+}
+
+// TestKucoinKeyVersionRejectedTwice checks the original rejection is
+// returned when the alternate version fails too, and no later call retries.
+func TestKucoinKeyVersionRejectedTwice(t *testing.T) {
+	sent := kucoinVersionServer(t, "1")
+	k := connectors.NewKucoinConnector("key", "secret", "pass", "2")
+
+	err := k.TestConnection()
+	var exErr *connectors.ExchangeError
+	require.True(t, errors.As(err, &exErr))
+	require.Equal(t, "400004", exErr.Code)
+	require.ErrorIs(t, err, connectors.ErrAuth)
+
+	require.Error(t, k.TestConnection())
+	require.Equal(t, []string{"2", "3", "2"}, *sent)
+
+	version, detected := k.KeyVersion()
+	require.Equal(t, "2", version)
+	require.False(t, detected)
+}
@@ -0,0 +1,52 @@
+package connectors
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strategyexecutor/src/model"
+	"time"
+)
+
+// NewsSource fetches news events for a time window. Every implementation
+// returns model.Event values with NewsSource set so they can be stored in the
+// trading_view_news_event table side by side.
+type NewsSource interface {
+	Name() string
+	FetchEvents(ctx context.Context, fromUTC time.Time, toUTC time.Time) ([]model.Event, error)
+}
+
+// TradingViewSource adapts ClientTV to the NewsSource interface.
+type TradingViewSource struct {
+	client    *ClientTV
+	countries []string
+}
+
+func NewTradingViewSource(client *ClientTV, countries []string) *TradingViewSource {
+	if client == nil {
+		client = NewClientTV(nil)
+	}
+	return &TradingViewSource{client: client, countries: countries}
+}
+
+func (s *TradingViewSource) Name() string {
+	return model.NewsSourceTradingView
+}
+
+func (s *TradingViewSource) FetchEvents(ctx context.Context, fromUTC time.Time, toUTC time.Time) ([]model.Event, error) {
+	evs, err := s.client.FetchImportantEvents(ctx, fromUTC, toUTC, s.countries)
+	if err != nil {
+		return nil, err
+	}
+	for i := range evs {
+		evs[i].NewsSource = model.NewsSourceTradingView
+	}
+	return evs, nil
+}
+
+// newsEventID builds a stable tv_event_id for non-TradingView sources so they
+// never collide with TradingView ids.
+func newsEventID(source, key string) string {
+	sum := sha1.Sum([]byte(key))
+	return source + ":" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+package connectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+// TestRSSSourceFetchEvents parses an RSS feed and keeps items inside the window.
+func TestRSSSourceFetchEvents(t *testing.T) {
+	feed := `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Crypto Wire</title>
+<item><guid>a1</guid><title>Bitcoin ETF approved</title><link>https://x/a1</link><description>big news</description><pubDate>Mon, 08 Dec 2025 15:00:00 +0000</pubDate></item>
+<item><guid>a2</guid><title>Old story</title><link>https://x/a2</link><pubDate>Mon, 01 Dec 2025 15:00:00 +0000</pubDate></item>
+<item><guid>a3</guid><title>Bad date</title><pubDate>yesterday</pubDate></item>
+</channel></rss>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	src := NewRSSSource(server.Client(), server.URL, "btc", 0)
+	from := time.Date(2025, 12, 8, 0, 0, 0, 0, time.UTC)
+	evs, err := src.FetchEvents(context.Background(), from, from.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("FetchEvents error: %v", err)
+	}
+	if len(evs) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(evs))
+	}
+
+	ev := evs[0]
+	if ev.Title != "Bitcoin ETF approved" || ev.Source != "Crypto Wire" || ev.Currency != "BTC" {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+	if ev.NewsSource != model.NewsSourceRSS || ev.ID == "" || ev.ID == "a1" {
+		t.Fatalf("unexpected source/id: %s %s", ev.NewsSource, ev.ID)
+	}
+
+	again, _ := src.FetchEvents(context.Background(), from, from.Add(24*time.Hour))
+	if again[0].ID != ev.ID {
+		t.Fatalf("expected stable id, got %s and %s", ev.ID, again[0].ID)
+	}
+}
+
+// TestCryptoPanicSourceFetchEvents maps CryptoPanic posts into events.
+func TestCryptoPanicSourceFetchEvents(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"results":[
+			{"id":42,"kind":"news","title":"Exchange hacked","url":"https://cp/42","published_at":"2025-12-08T10:00:00Z",
+			 "source":{"title":"CoinDesk"},"currencies":[{"code":"btc"}],"votes":{"important":3}},
+			{"id":43,"kind":"news","title":"Too old","published_at":"2025-11-01T10:00:00Z"}
+		]}`))
+	}))
+	defer server.Close()
+
+	src := NewCryptoPanicSource(server.Client(), server.URL, "token", []string{"BTC", "ETH"})
+	from := time.Date(2025, 12, 8, 0, 0, 0, 0, time.UTC)
+	evs, err := src.FetchEvents(context.Background(), from, from.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("FetchEvents error: %v", err)
+	}
+	if query != "auth_token=token&currencies=BTC%2CETH&public=true" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+	if len(evs) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(evs))
+	}
+	ev := evs[0]
+	if ev.ID != newsEventID(model.NewsSourceCryptoPanic, "42") || ev.Currency != "BTC" || ev.Importance != 1 || ev.NewsSource != model.NewsSourceCryptoPanic {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	if _, err := NewCryptoPanicSource(nil, server.URL, "", nil).FetchEvents(context.Background(), from, from); err == nil {
+		t.Fatalf("expected error without auth token")
+	}
+}
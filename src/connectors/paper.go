@@ -0,0 +1,365 @@
+package connectors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/model"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoLiveOrderBook is returned by PaperClient.OrderBook when the live
+// connector has no order book depth.
+var ErrNoLiveOrderBook = errors.New("live connector has no order book")
+
+// ErrNoLiveIndexPrice is returned by PaperClient.IndexPrice when the live
+// connector publishes no index price.
+var ErrNoLiveIndexPrice = errors.New("live connector has no index price")
+
+// ErrNoLiveQuotes is returned by PaperClient.BestBidAsk when the live
+// connector quotes no best bid and ask.
+var ErrNoLiveQuotes = errors.New("live connector has no quotes")
+
+// PaperAccount is the in-memory USDT-M account of a shadow account. It is
+// funded with the live available balance on first use and outlives the
+// PaperClient of a single run. Positions only change through orders: resting
+// stops and take profits are recorded, never triggered.
+type PaperAccount struct {
+	mu        sync.Mutex
+	funded    bool
+	balance   float64
+	positions map[string]*paperPosition // keyed by symbol and posSide
+	orders    map[string]ClientOrder    // keyed by clOrdID
+	seq       int
+}
+
+type paperPosition struct {
+	symbol  string
+	posSide string
+	size    float64
+	entry   float64
+	mark    float64
+}
+
+func NewPaperAccount() *PaperAccount {
+	return &PaperAccount{
+		positions: map[string]*paperPosition{},
+		orders:    map[string]ClientOrder{},
+	}
+}
+
+// ShadowCall is an order call of a shadow account: what would have been sent
+// to the exchange, and the paper outcome.
+type ShadowCall struct {
+	Method     string
+	ClOrdID    string
+	Symbol     string
+	Side       string
+	PosSide    string
+	Qty        string
+	OrdType    string
+	ReduceOnly bool
+	// Price is the limit price of the call, or its stop price for stops and
+	// take profits.
+	Price string
+	// FillPrice and PaperOrderID are set when the paper account filled it.
+	FillPrice    float64
+	PaperOrderID string
+	Err          error
+	At           time.Time
+}
+
+// PaperClient runs a shadow account: market data and balances are read from
+// Live, order calls are filled by Account at the live price and reported to
+// Record instead of reaching the exchange.
+type PaperClient struct {
+	Live    Connector
+	Account *PaperAccount
+	Record  func(ShadowCall)
+}
+
+var (
+	_ Connector              = (*PaperClient)(nil)
+	_ ClientOrderPlacer      = (*PaperClient)(nil)
+	_ ClientLimitOrderPlacer = (*PaperClient)(nil)
+	_ LeverageSetter         = (*PaperClient)(nil)
+	_ TakeProfitPlacer       = (*PaperClient)(nil)
+	_ OrderBookSource        = (*PaperClient)(nil)
+	_ IndexPriceSource       = (*PaperClient)(nil)
+)
+
+func NewPaperClient(live Connector, account *PaperAccount, record func(ShadowCall)) *PaperClient {
+	return &PaperClient{Live: live, Account: account, Record: record}
+}
+
+func (p *PaperClient) record(call ShadowCall) {
+	call.At = time.Now().UTC()
+	if p.Record != nil {
+		p.Record(call)
+	}
+}
+
+// GetAvailableBaseFromUSDT prices symbol live and answers with the paper
+// balance left after the margin of the open paper positions.
+func (p *PaperClient) GetAvailableBaseFromUSDT(symbol string) (string, float64, float64, float64, error) {
+	base, _, usdtAvail, price, err := p.Live.GetAvailableBaseFromUSDT(symbol)
+	if err != nil {
+		return "", 0, 0, 0, err
+	}
+	if price <= 0 {
+		return "", 0, 0, 0, fmt.Errorf("invalid price for %s", symbol)
+	}
+
+	a := p.Account
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.funded {
+		a.balance, a.funded = usdtAvail, true
+	}
+	margin := 0.0
+	for _, pos := range a.positions {
+		if pos.symbol == symbol {
+			pos.mark = price
+		}
+		margin += pos.size * pos.entry
+	}
+	avail := a.balance - margin
+	if avail < 0 {
+		avail = 0
+	}
+	return base, avail / price, avail, price, nil
+}
+
+func (p *PaperClient) GetPositionsUSDT() (*GAccountPositions, error) {
+	a := p.Account
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var out GAccountPositions
+	out.Account.Currency = "USDT"
+	out.Account.AccountBalanceRv = formatFloat(a.balance)
+	for _, pos := range a.positions {
+		side := "Buy"
+		if pos.posSide == "Short" {
+			side = "Sell"
+		}
+		out.Positions = append(out.Positions, GPosition{
+			Symbol:          pos.symbol,
+			Currency:        "USDT",
+			Side:            side,
+			PosSide:         pos.posSide,
+			SizeRq:          formatFloat(pos.size),
+			AvgEntryPriceRp: formatFloat(pos.entry),
+			MarkPriceRp:     formatFloat(pos.mark),
+		})
+	}
+	return &out, nil
+}
+
+func (p *PaperClient) PlaceOrder(symbol, side, posSide, qty, ordType string, reduce bool) (*APIResponse, error) {
+	p.Account.mu.Lock()
+	clOrdID := fmt.Sprintf("paper-cl-%d", p.Account.seq+1)
+	p.Account.mu.Unlock()
+	return p.PlaceOrderWithClientID(clOrdID, symbol, side, posSide, qty, ordType, reduce)
+}
+
+// PlaceOrderWithClientID fills the order at the live price.
+func (p *PaperClient) PlaceOrderWithClientID(clOrdID, symbol, side, posSide, qty, ordType string, reduce bool) (*APIResponse, error) {
+	call := ShadowCall{Method: "PlaceOrder", ClOrdID: clOrdID, Symbol: symbol, Side: side, PosSide: posSide, Qty: qty, OrdType: ordType, ReduceOnly: reduce}
+	return p.fill(call)
+}
+
+// PlaceLimitIOCWithClientID fills the order at the live price, like a
+// marketable limit.
+func (p *PaperClient) PlaceLimitIOCWithClientID(clOrdID, symbol, side, posSide, qty, priceRp string, reduce bool) (*APIResponse, error) {
+	call := ShadowCall{Method: "PlaceLimitIOC", ClOrdID: clOrdID, Symbol: symbol, Side: side, PosSide: posSide, Qty: qty, OrdType: "Limit", Price: priceRp, ReduceOnly: reduce}
+	return p.fill(call)
+}
+
+// PlaceBracketOrderWithClientID fills the entry at the live price and
+// records its attached exits, which are never triggered.
+func (p *PaperClient) PlaceBracketOrderWithClientID(clOrdID, symbol, side, posSide, qty, stopLossRp, takeProfitRp string) (*APIResponse, error) {
+	call := ShadowCall{Method: "PlaceOrder", ClOrdID: clOrdID, Symbol: symbol, Side: side, PosSide: posSide, Qty: qty, OrdType: "Market"}
+	resp, err := p.fill(call)
+	if err != nil || resp.Err() != nil {
+		return resp, err
+	}
+	if stopLossRp != "" {
+		p.record(ShadowCall{Method: "SetStopLoss", Symbol: symbol, PosSide: posSide, OrdType: "Stop", Price: stopLossRp, ReduceOnly: true})
+	}
+	if takeProfitRp != "" {
+		p.record(ShadowCall{Method: "PlaceTakeProfit", Symbol: symbol, PosSide: posSide, OrdType: "MarketIfTouched", Price: takeProfitRp, ReduceOnly: true})
+	}
+	return resp, nil
+}
+
+// PlacePostOnlyWithClientID fills the order at the live price: a paper
+// order never rests, so a passive entry completes on its first placement.
+func (p *PaperClient) PlacePostOnlyWithClientID(clOrdID, symbol, side, posSide, qty, priceRp string) (*APIResponse, error) {
+	call := ShadowCall{Method: "PlacePostOnly", ClOrdID: clOrdID, Symbol: symbol, Side: side, PosSide: posSide, Qty: qty, OrdType: "Limit", Price: priceRp}
+	return p.fill(call)
+}
+
+// CancelOrder fails: paper orders fill at once and never rest.
+func (p *PaperClient) CancelOrder(symbol, orderID string) (*APIResponse, error) {
+	return nil, fmt.Errorf("no active order %s for %s", orderID, symbol)
+}
+
+func (p *PaperClient) fill(call ShadowCall) (*APIResponse, error) {
+	_, _, _, price, err := p.Live.GetAvailableBaseFromUSDT(call.Symbol)
+	if err != nil {
+		call.Err = err
+		p.record(call)
+		return nil, err
+	}
+	size, err := strconv.ParseFloat(call.Qty, 64)
+	if err != nil || size <= 0 {
+		call.Err = fmt.Errorf("invalid order qty %q", call.Qty)
+		p.record(call)
+		return &APIResponse{Code: 11001, Msg: call.Err.Error()}, nil
+	}
+
+	a := p.Account
+	a.mu.Lock()
+	posSide := paperPosSide(call.Side, call.PosSide, call.ReduceOnly)
+	key := call.Symbol + "/" + posSide
+	pos, open := a.positions[key]
+	closedPnl := 0.0
+	switch {
+	case call.ReduceOnly && !open:
+		a.mu.Unlock()
+		call.Err = errors.New("reduce-only order without position")
+		p.record(call)
+		return &APIResponse{Code: 11001, Msg: call.Err.Error()}, nil
+	case call.ReduceOnly:
+		if size > pos.size {
+			size = pos.size
+		}
+		direction := 1.0
+		if posSide == "Short" {
+			direction = -1
+		}
+		closedPnl = direction * (price - pos.entry) * size
+		a.balance += closedPnl
+		pos.size -= size
+		if pos.size <= 0 {
+			delete(a.positions, key)
+		}
+	default:
+		if !open {
+			pos = &paperPosition{symbol: call.Symbol, posSide: posSide}
+			a.positions[key] = pos
+		}
+		pos.entry = (pos.size*pos.entry + size*price) / (pos.size + size)
+		pos.size += size
+		pos.mark = price
+	}
+	a.seq++
+	orderID := fmt.Sprintf("paper-%d", a.seq)
+	a.orders[call.ClOrdID] = ClientOrder{OrderID: orderID, ClOrdID: call.ClOrdID, OrdStatus: "Filled", OrderQtyRq: formatFloat(size), CumQtyRq: formatFloat(size)}
+	a.mu.Unlock()
+
+	call.FillPrice, call.PaperOrderID = price, orderID
+	p.record(call)
+
+	ts := time.Now().UnixNano()
+	data, err := json.Marshal(model.PhemexOrderResponse{
+		OrderID:        orderID,
+		ClOrdID:        call.ClOrdID,
+		Symbol:         call.Symbol,
+		Side:           call.Side,
+		PosSide:        call.PosSide,
+		ReduceOnly:     call.ReduceOnly,
+		ActionTimeNs:   ts,
+		TransactTimeNs: ts,
+		OrderType:      call.OrdType,
+		PriceRp:        formatFloat(price),
+		OrderQtyRq:     formatFloat(size),
+		TimeInForce:    "ImmediateOrCancel",
+		ClosedPnlRv:    formatFloat(closedPnl),
+		CumQtyRq:       formatFloat(size),
+		CumValueRv:     formatFloat(size * price),
+		LeavesQtyRq:    "0",
+		LeavesValueRv:  "0",
+		ExecStatus:     "TakerFill",
+		OrdStatus:      "Filled",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &APIResponse{Code: 0, Msg: "OK", Data: data}, nil
+}
+
+func (p *PaperClient) FindOrderByClientID(_, clOrdID string) (*ClientOrder, error) {
+	p.Account.mu.Lock()
+	defer p.Account.mu.Unlock()
+	order, ok := p.Account.orders[clOrdID]
+	if !ok {
+		return nil, nil
+	}
+	return &order, nil
+}
+
+func (p *PaperClient) SetStopLossForOpenPosition(symbol, posSide, stopPxRp, triggerType string, closeOnTrigger bool) (*APIResponse, error) {
+	p.record(ShadowCall{Method: "SetStopLoss", Symbol: symbol, PosSide: posSide, OrdType: "Stop", Price: stopPxRp, ReduceOnly: closeOnTrigger})
+	return &APIResponse{Code: 0, Msg: "OK", Data: json.RawMessage(`{}`)}, nil
+}
+
+func (p *PaperClient) PlaceTakeProfitOrder(symbol, posSide, side, qty, stopPxRp, triggerType string) (*APIResponse, error) {
+	p.record(ShadowCall{Method: "PlaceTakeProfit", Symbol: symbol, Side: side, PosSide: posSide, Qty: qty, OrdType: "MarketIfTouched", Price: stopPxRp, ReduceOnly: true})
+	return &APIResponse{Code: 0, Msg: "OK", Data: json.RawMessage(`{}`)}, nil
+}
+
+func (p *PaperClient) SetLeverage(symbol string, leverage float64) (*APIResponse, error) {
+	p.record(ShadowCall{Method: "SetLeverage", Symbol: symbol, Qty: formatFloat(leverage)})
+	return &APIResponse{Code: 0, Msg: "OK", Data: json.RawMessage(`{}`)}, nil
+}
+
+// OrderBook reads the live order book, so liquidity checks see the market.
+func (p *PaperClient) OrderBook(symbol string) (*OrderBook, error) {
+	source, ok := p.Live.(OrderBookSource)
+	if !ok {
+		return nil, ErrNoLiveOrderBook
+	}
+	return source.OrderBook(symbol)
+}
+
+// IndexPrice reads the live index price, so price checks see the market.
+func (p *PaperClient) IndexPrice(symbol string) (float64, error) {
+	source, ok := p.Live.(IndexPriceSource)
+	if !ok {
+		return 0, ErrNoLiveIndexPrice
+	}
+	return source.IndexPrice(symbol)
+}
+
+// BestBidAsk reads the live quotes, so passive entries price off the market.
+func (p *PaperClient) BestBidAsk(symbol string) (float64, float64, error) {
+	source, ok := p.Live.(QuoteSource)
+	if !ok {
+		return 0, 0, ErrNoLiveQuotes
+	}
+	return source.BestBidAsk(symbol)
+}
+
+// paperPosSide is the position an order acts on. One-way ("Merged") orders
+// open the side they trade and reduce the opposite one.
+func paperPosSide(side, posSide string, reduce bool) string {
+	switch {
+	case strings.EqualFold(posSide, "Long"):
+		return "Long"
+	case strings.EqualFold(posSide, "Short"):
+		return "Short"
+	}
+	if strings.EqualFold(side, "Buy") != reduce {
+		return "Long"
+	}
+	return "Short"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
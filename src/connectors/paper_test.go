@@ -0,0 +1,86 @@
+package connectors
+
+import (
+	"encoding/json"
+	"testing"
+
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/testsupport"
+)
+
+func TestPaperClientFillsAtLivePrice(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t).
+		WithBalance("BTCUSDT", 1000).
+		WithTicker("BTCUSDT", testsupport.Ticker{LastRp: "50000"})
+	live := newTestClient(exchange.URL, exchange.Server.Client())
+
+	var calls []ShadowCall
+	paper := NewPaperClient(live, NewPaperAccount(), func(c ShadowCall) { calls = append(calls, c) })
+
+	_, base, usdt, price, err := paper.GetAvailableBaseFromUSDT("BTCUSDT")
+	if err != nil || usdt != 1000 || base != 0.02 || price != 50000 {
+		t.Fatalf("expected the live balance and price, got %v %v %v %v", base, usdt, price, err)
+	}
+
+	resp, err := paper.PlaceOrder("BTCUSDT", "Buy", "Long", "0.01", "Market", false)
+	if err != nil || resp.Code != 0 {
+		t.Fatalf("unexpected answer %+v %v", resp, err)
+	}
+	var payload model.PhemexOrderResponse
+	if err := json.Unmarshal(resp.Data, &payload); err != nil || payload.PriceRp != "50000" || payload.OrdStatus != "Filled" {
+		t.Fatalf("unexpected payload %+v %v", payload, err)
+	}
+	if n := exchange.Count("POST", "/g-orders"); n != 0 {
+		t.Fatalf("paper orders must not reach the exchange, got %d calls", n)
+	}
+
+	// the open position locks its notional
+	if _, _, usdt, _, _ := paper.GetAvailableBaseFromUSDT("BTCUSDT"); usdt != 500 {
+		t.Fatalf("expected 500 USDT left, got %v", usdt)
+	}
+	pos, _ := paper.GetPositionsUSDT()
+	if len(pos.Positions) != 1 || pos.Positions[0].SizeRq != "0.01" || pos.Positions[0].PosSide != "Long" {
+		t.Fatalf("unexpected positions %+v", pos.Positions)
+	}
+
+	if _, err := paper.SetStopLossForOpenPosition("BTCUSDT", "Long", "49000", TriggerByMarkPrice, true); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if resp, _ := paper.PlaceOrder("BTCUSDT", "Sell", "Short", "0.01", "Market", true); resp.Code == 0 {
+		t.Fatalf("expected a reduce-only order without position to be rejected")
+	}
+	if resp, _ := paper.PlaceOrder("BTCUSDT", "Sell", "Long", "0.01", "Market", true); resp.Code != 0 {
+		t.Fatalf("expected the position to close, got %+v", resp)
+	}
+	if pos, _ := paper.GetPositionsUSDT(); len(pos.Positions) != 0 {
+		t.Fatalf("expected no position left, got %+v", pos.Positions)
+	}
+
+	methods := []string{}
+	for _, c := range calls {
+		methods = append(methods, c.Method)
+	}
+	if len(calls) != 4 || calls[0].FillPrice != 50000 || calls[1].Method != "SetStopLoss" || calls[1].Price != "49000" || calls[2].Err == nil {
+		t.Fatalf("unexpected recorded calls %v: %+v", methods, calls)
+	}
+}
+
+func TestPaperPosSide(t *testing.T) {
+	cases := []struct {
+		side, posSide string
+		reduce        bool
+		want          string
+	}{
+		{"Buy", "Long", false, "Long"},
+		{"Sell", "Short", false, "Short"},
+		{"Buy", "Merged", false, "Long"},
+		{"Sell", "Merged", false, "Short"},
+		{"Sell", "Merged", true, "Long"},
+		{"Buy", "", true, "Short"},
+	}
+	for _, tc := range cases {
+		if got := paperPosSide(tc.side, tc.posSide, tc.reduce); got != tc.want {
+			t.Fatalf("paperPosSide(%s, %s, %v) = %s, want %s", tc.side, tc.posSide, tc.reduce, got, tc.want)
+		}
+	}
+}
@@ -3,12 +3,15 @@
 package connectors
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"strategyexecutor/src/cache"
 	"strconv"
 	"strings"
 	"time"
@@ -17,16 +20,6 @@ import (
 	logger "github.com/sirupsen/logrus"
 )
 
-// -----------------------------
-// CONFIG
-// -----------------------------
-const (
-	// Default retry configuration
-	defaultRetryAttempts   = 5
-	defaultRetryBaseDelay  = 500 * time.Millisecond
-	defaultRetryMaxBackoff = 8 * time.Second
-)
-
 // -----------------------------
 // API RESPONSE WRAPPER
 // -----------------------------
@@ -47,17 +40,24 @@ type GAccountPositions struct {
 		AccountBalanceRv string `json:"accountBalanceRv"`
 	} `json:"account"`
 
-	Positions []struct {
-		AccountID        int64  `json:"accountID"`
-		Symbol           string `json:"symbol"`
-		Currency         string `json:"currency"`
-		Side             string `json:"side"`
-		PosSide          string `json:"posSide"`
-		SizeRq           string `json:"sizeRq"`
-		AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-		PositionMarginRv string `json:"positionMarginRv"`
-		MarkPriceRp      string `json:"markPriceRp"`
-	} `json:"positions"`
+	Positions []GPosition `json:"positions"`
+}
+
+// GPosition is a USDT-M position of GAccountPositions.
+type GPosition struct {
+	AccountID        int64  `json:"accountID"`
+	Symbol           string `json:"symbol"`
+	Currency         string `json:"currency"`
+	Side             string `json:"side"`
+	PosSide          string `json:"posSide"`
+	SizeRq           string `json:"sizeRq"`
+	AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
+	PositionMarginRv string `json:"positionMarginRv"`
+	MarkPriceRp      string `json:"markPriceRp"`
+	// Leverage is negative in cross margin mode.
+	LeverageRr         string `json:"leverageRr"`
+	LiquidationPriceRp string `json:"liquidationPriceRp"`
+	UnRealisedPnlRv    string `json:"unRealisedPnlRv"`
 }
 
 // -----------------------------
@@ -68,46 +68,43 @@ type Client struct {
 	apiSecret string
 	baseURL   string
 	http      *resty.Client
+	// ctx, when set, is attached to every request for cancellation and
+	// tracing. See WithContext.
+	ctx context.Context
+	// capture, when set, receives the order-mutating calls. See WithCapture.
+	capture CaptureFunc
 }
 
-func isRetryableResp(r *resty.Response, err error) bool {
-	if err != nil {
-		return true
-	}
-
-	if r == nil {
-		return false
-	}
+// WithContext returns a copy of c whose requests run under ctx.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	cp := *c
+	cp.ctx = ctx
+	return &cp
+}
 
-	code := r.StatusCode()
+// WithCapture returns a copy of c handing every POST, PUT and DELETE call,
+// redacted, to capture once it completed.
+func (c *Client) WithCapture(capture CaptureFunc) *Client {
+	cp := *c
+	cp.capture = capture
+	return &cp
+}
 
-	if code >= 500 && code <= 599 {
-		return true
+func (c *Client) newRequest() *resty.Request {
+	req := c.http.R()
+	if c.ctx != nil {
+		req.SetContext(c.ctx)
 	}
-	if code == 429 {
-		return true
-	}
-	if code == 408 {
-		return true
-	}
-	return false
+	return req
 }
 
 func NewClient(apiKey, apiSecret, baseURL string) *Client {
-	retryCount := defaultRetryAttempts - 1
-
 	if baseURL == "" {
 		baseURL = "https://testnet-api.phemex.com"
 		logger.Warnf("No base URL provided, using default: %s", baseURL)
 	}
 
-	httpClient := resty.New().
-		SetBaseURL(baseURL).
-		SetTimeout(15 * time.Second).
-		SetRetryCount(retryCount).
-		SetRetryWaitTime(defaultRetryBaseDelay).
-		SetRetryMaxWaitTime(defaultRetryMaxBackoff).
-		AddRetryCondition(isRetryableResp)
+	httpClient := DefaultRetryPolicy.newRestyClient(baseURL, 15*time.Second, exchangeTransport("PHEMEX"))
 
 	return &Client{
 		apiKey:    apiKey,
@@ -131,12 +128,12 @@ func signRequest(path, query, body string, expiry int64, secret string) string {
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
-func (c *Client) doRequest(method, path, query string, body []byte) (*APIResponse, error) {
+func (c *Client) doRequest(method, path, query string, body []byte) (apiResp *APIResponse, err error) {
 	expiry := time.Now().Add(1 * time.Minute).Unix()
 
 	sig := signRequest(path, query, string(body), expiry, c.apiSecret)
 
-	req := c.http.R().
+	req := c.newRequest().
 		SetHeader("x-phemex-access-token", c.apiKey).
 		SetHeader("x-phemex-request-expiry", fmt.Sprintf("%d", expiry)).
 		SetHeader("x-phemex-request-signature", sig)
@@ -148,7 +145,26 @@ func (c *Client) doRequest(method, path, query string, body []byte) (*APIRespons
 		req = req.SetBody(body).SetHeader("Content-Type", "application/json")
 	}
 
+	if batch := positionBatchFrom(c.ctx); batch != nil && isMutatingMethod(method) {
+		// The call may change the positions, even when it fails on the way
+		// back.
+		defer batch.invalidate(c.batchAccount())
+	}
+
+	start := time.Now()
 	resp, err := req.Execute(method, path)
+	if c.capture != nil && isMutatingMethod(method) {
+		defer func() {
+			status, raw, callErr := 0, []byte(nil), err
+			if resp != nil {
+				status, raw = resp.StatusCode(), resp.Body()
+			}
+			if callErr == nil {
+				callErr = apiResp.Err()
+			}
+			c.capture(newAPICall("phemex", method, path, query, string(body), status, raw, start, callErr))
+		}()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -156,37 +172,195 @@ func (c *Client) doRequest(method, path, query string, body []byte) (*APIRespons
 	raw := resp.Body()
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode(), string(raw))
+		return nil, httpError("phemex", resp.StatusCode(), string(raw))
 	}
 
-	var apiResp APIResponse
-	if err := json.Unmarshal(raw, &apiResp); err != nil {
+	var parsed APIResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
 		return nil, err
 	}
 
-	return &apiResp, nil
+	return &parsed, nil
 }
 
 // -----------------------------
 // B) ACCOUNT & POSITION METHODS
 // -----------------------------
+// GetPositionsUSDT returns the USDT-M account and positions, once per
+// account in a position batch, see WithPositionBatch.
 func (c *Client) GetPositionsUSDT() (*GAccountPositions, error) {
+	if batch := positionBatchFrom(c.ctx); batch != nil {
+		return batch.get(c.batchAccount(), c.fetchPositionsUSDT)
+	}
+	return c.fetchPositionsUSDT()
+}
+
+// batchAccount identifies the account of c in a position batch.
+func (c *Client) batchAccount() string {
+	return c.baseURL + "|" + c.apiKey
+}
+
+func (c *Client) fetchPositionsUSDT() (*GAccountPositions, error) {
 	resp, err := c.doRequest("GET", "/g-accounts/positions", "currency=USDT", nil)
 	if err != nil {
 		return nil, err
 	}
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("API error: %s", resp.Msg)
+	if err := resp.Err(); err != nil {
+		return nil, err
 	}
 
 	var parsed GAccountPositions
 	return &parsed, json.Unmarshal(resp.Data, &parsed)
 }
 
+// GetAccountBalances reports the USDT-M futures wallet balance, keyed like
+// the KuCoin connector.
+func (c *Client) GetAccountBalances() (map[string]float64, error) {
+	positions, err := c.GetPositionsUSDT()
+	if err != nil {
+		return nil, err
+	}
+	currency := positions.Account.Currency
+	if currency == "" {
+		currency = "USDT"
+	}
+	balance, err := strconv.ParseFloat(positions.Account.AccountBalanceRv, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse accountBalanceRv %q: %w", positions.Account.AccountBalanceRv, err)
+	}
+	return map[string]float64{"futures_" + currency: balance}, nil
+}
+
+// SetLeverage sets the leverage of both sides of a hedged-mode position.
+func (c *Client) SetLeverage(symbol string, leverage float64) (*APIResponse, error) {
+	if err := mustNonEmpty("symbol", symbol); err != nil {
+		return nil, err
+	}
+	if leverage <= 0 {
+		return nil, fmt.Errorf("leverage must be positive, got %v", leverage)
+	}
+	lev := strconv.FormatFloat(leverage, 'f', -1, 64)
+	query := fmt.Sprintf("longLeverageRr=%s&shortLeverageRr=%s&symbol=%s", lev, lev, symbol)
+	resp, err := c.doRequest("PUT", "/g-positions/leverage", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp, resp.Err()
+}
+
+// phemexValueScale is the Ev scale of the USDT and BTC wallet amounts.
+const phemexValueScale = 1e8
+
+// TransferBetweenWallets moves amount of currency between the spot and the
+// futures wallet (moveOp 2 is spot to futures, 1 the way back).
+func (c *Client) TransferBetweenWallets(currency string, amount float64, from, to string) error {
+	if err := mustNonEmpty("currency", currency); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return fmt.Errorf("transfer amount must be positive, got %v", amount)
+	}
+
+	var moveOp int
+	switch {
+	case from == WalletSpot && to == WalletFutures:
+		moveOp = 2
+	case from == WalletFutures && to == WalletSpot:
+		moveOp = 1
+	default:
+		return fmt.Errorf("unsupported transfer from %q to %q", from, to)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"amountEv": int64(math.Round(amount * phemexValueScale)),
+		"currency": currency,
+		"moveOp":   moveOp,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := c.doRequest("POST", "/assets/transfer", "", body)
+	if err != nil {
+		return err
+	}
+	return resp.Err()
+}
+
 // -----------------------------
 // C) TRADING METHODS
 // -----------------------------
 func (c *Client) PlaceOrder(symbol, side, posSide, qty, ordType string, reduce bool) (*APIResponse, error) {
+	return c.PlaceOrderWithClientID(fmt.Sprintf("go-%d", time.Now().UnixNano()), symbol, side, posSide, qty, ordType, reduce)
+}
+
+// PlaceOrderWithClientID places an order tagged with clOrdID.
+func (c *Client) PlaceOrderWithClientID(clOrdID, symbol, side, posSide, qty, ordType string, reduce bool) (*APIResponse, error) {
+	return c.placeIOC(clOrdID, symbol, side, posSide, qty, ordType, "", reduce)
+}
+
+// PlaceLimitIOCWithClientID places an ImmediateOrCancel limit order tagged
+// with clOrdID: it fills at priceRp or better and the rest is cancelled.
+func (c *Client) PlaceLimitIOCWithClientID(clOrdID, symbol, side, posSide, qty, priceRp string, reduce bool) (*APIResponse, error) {
+	if err := mustNonEmpty("priceRp", priceRp); err != nil {
+		return nil, err
+	}
+	return c.placeIOC(clOrdID, symbol, side, posSide, qty, "Limit", priceRp, reduce)
+}
+
+// PlacePostOnlyWithClientID places a PostOnly limit order tagged with
+// clOrdID: it rests on the book at priceRp and is rejected instead of
+// filling as a taker.
+func (c *Client) PlacePostOnlyWithClientID(clOrdID, symbol, side, posSide, qty, priceRp string) (*APIResponse, error) {
+	for name, v := range map[string]string{"clOrdID": clOrdID, "symbol": symbol, "side": side, "qty": qty, "priceRp": priceRp} {
+		if err := mustNonEmpty(name, v); err != nil {
+			return nil, err
+		}
+	}
+
+	body := map[string]interface{}{
+		"symbol":      symbol,
+		"side":        side,
+		"posSide":     posSide,
+		"ordType":     "Limit",
+		"orderQtyRq":  qty,
+		"priceRp":     priceRp,
+		"reduceOnly":  false,
+		"clOrdID":     clOrdID,
+		"timeInForce": "PostOnly",
+	}
+
+	b, _ := json.Marshal(body)
+	return c.doRequest("POST", "/g-orders", "", b)
+}
+
+// PlaceBracketOrderWithClientID places a market entry tagged with clOrdID
+// with its stop loss and take profit attached, triggered by the mark price:
+// Phemex holds them on the position from the fill on. Either price may be
+// empty.
+func (c *Client) PlaceBracketOrderWithClientID(clOrdID, symbol, side, posSide, qty, stopLossRp, takeProfitRp string) (*APIResponse, error) {
+	if stopLossRp == "" && takeProfitRp == "" {
+		return nil, fmt.Errorf("bracket order needs a stop loss or a take profit")
+	}
+	body := iocBody(clOrdID, symbol, side, posSide, qty, "Market", "", false)
+	if stopLossRp != "" {
+		body["stopLossRp"] = stopLossRp
+		body["slTrigger"] = TriggerByMarkPrice
+	}
+	if takeProfitRp != "" {
+		body["takeProfitRp"] = takeProfitRp
+		body["tpTrigger"] = TriggerByMarkPrice
+	}
+
+	b, _ := json.Marshal(body)
+	return c.doRequest("POST", "/g-orders", "", b)
+}
+
+func (c *Client) placeIOC(clOrdID, symbol, side, posSide, qty, ordType, priceRp string, reduce bool) (*APIResponse, error) {
+	b, _ := json.Marshal(iocBody(clOrdID, symbol, side, posSide, qty, ordType, priceRp, reduce))
+	return c.doRequest("POST", "/g-orders", "", b)
+}
+
+func iocBody(clOrdID, symbol, side, posSide, qty, ordType, priceRp string, reduce bool) map[string]interface{} {
 	body := map[string]interface{}{
 		"symbol":      symbol,
 		"side":        side,
@@ -194,16 +368,71 @@ func (c *Client) PlaceOrder(symbol, side, posSide, qty, ordType string, reduce b
 		"ordType":     ordType,
 		"orderQtyRq":  qty,
 		"reduceOnly":  reduce,
-		"clOrdID":     fmt.Sprintf("go-%d", time.Now().UnixNano()),
+		"clOrdID":     clOrdID,
 		"timeInForce": "ImmediateOrCancel",
 	}
+	if priceRp != "" {
+		body["priceRp"] = priceRp
+	}
+	return body
+}
+
+func (c *Client) CancelAll(symbol string) (*APIResponse, error) {
+	return c.doRequest("DELETE", "/g-orders/all", fmt.Sprintf("symbol=%s", symbol), nil)
+}
+
+// PlaceLimitOrder places a GoodTillCancel limit order at priceRp.
+func (c *Client) PlaceLimitOrder(symbol, side, posSide, qty, priceRp string, reduce bool) (*APIResponse, error) {
+	for name, v := range map[string]string{"symbol": symbol, "side": side, "posSide": posSide, "qty": qty, "priceRp": priceRp} {
+		if err := mustNonEmpty(name, v); err != nil {
+			return nil, err
+		}
+	}
+
+	body := map[string]interface{}{
+		"symbol":      symbol,
+		"side":        side,
+		"posSide":     posSide,
+		"ordType":     "Limit",
+		"orderQtyRq":  qty,
+		"priceRp":     priceRp,
+		"reduceOnly":  reduce,
+		"clOrdID":     fmt.Sprintf("go-lmt-%d", time.Now().UnixNano()),
+		"timeInForce": "GoodTillCancel",
+	}
 
 	b, _ := json.Marshal(body)
 	return c.doRequest("POST", "/g-orders", "", b)
 }
 
-func (c *Client) CancelAll(symbol string) (*APIResponse, error) {
-	return c.doRequest("DELETE", "/g-orders/all", fmt.Sprintf("symbol=%s", symbol), nil)
+// CancelOrder cancels one open order, given its orderID or its clOrdID.
+// Phemex needs the posSide of the order in hedged mode, so it is looked up
+// from the active orders first.
+func (c *Client) CancelOrder(symbol, orderID string) (*APIResponse, error) {
+	if err := mustNonEmpty("symbol", symbol); err != nil {
+		return nil, err
+	}
+	if err := mustNonEmpty("orderID", orderID); err != nil {
+		return nil, err
+	}
+
+	active, err := c.ListActiveOrders(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range active {
+		if o.OrderID != orderID && o.ClOrdID != orderID {
+			continue
+		}
+		query := fmt.Sprintf("orderID=%s&posSide=%s&symbol=%s", o.OrderID, o.PosSide, symbol)
+		resp, err := c.doRequest("DELETE", "/g-orders/cancel", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		return resp, resp.Err()
+	}
+	return nil, fmt.Errorf("no active order %s for %s", orderID, symbol)
 }
 
 // CloseAllPositions closes all open positions for the provided symbol by placing reduce-only
@@ -243,14 +472,134 @@ func (c *Client) GetActiveOrders(symbol string) (*APIResponse, error) {
 	return c.doRequest("GET", "/g-orders/activeList", fmt.Sprintf("symbol=%s", symbol), nil)
 }
 
+// ListActiveOrders returns the open and untriggered orders of symbol,
+// conditional stops included.
+func (c *Client) ListActiveOrders(symbol string) ([]ActiveOrder, error) {
+	resp, err := c.GetActiveOrders(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("GetActiveOrders failed: %w", err)
+	}
+	if err := resp.Err(); err != nil {
+		// Phemex answers OM_ORDER_NOT_FOUND when nothing is open.
+		if errors.Is(err, ErrOrderNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var page struct {
+		Rows []ActiveOrder `json:"rows"`
+	}
+	if err := json.Unmarshal(resp.Data, &page); err != nil {
+		return nil, fmt.Errorf("decode active orders: %w", err)
+	}
+	return page.Rows, nil
+}
+
 func (c *Client) GetOrderHistory(symbol string) (*APIResponse, error) {
 	return c.doRequest("GET", "/g-orders/trade/history", fmt.Sprintf("symbol=%s", symbol), nil)
 }
 
+// FindOrderByClientID looks an open or closed order up by its clOrdID.
+// Returns (nil, nil) when Phemex has no such order.
+func (c *Client) FindOrderByClientID(symbol, clOrdID string) (*ClientOrder, error) {
+	return c.findOrder(symbol, "clOrdID", clOrdID)
+}
+
+// GetOrder looks an open or closed order up by its orderID, e.g. to tell a
+// cancelled or rejected stop from a triggered one. Returns (nil, nil) when
+// Phemex has no such order.
+func (c *Client) GetOrder(symbol, orderID string) (*ClientOrder, error) {
+	if err := mustNonEmpty("orderID", orderID); err != nil {
+		return nil, err
+	}
+	return c.findOrder(symbol, "orderID", orderID)
+}
+
+// findOrder queries the orders-by-id endpoint with key (orderID or
+// clOrdID) set to id.
+func (c *Client) findOrder(symbol, key, id string) (*ClientOrder, error) {
+	resp, err := c.doRequest("GET", "/api-data/g-futures/orders/by-order-id", fmt.Sprintf("symbol=%s&%s=%s", symbol, key, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Err(); err != nil {
+		if errors.Is(err, ErrOrderNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var orders []ClientOrder
+	if err := json.Unmarshal(resp.Data, &orders); err != nil {
+		var page struct {
+			Rows []ClientOrder `json:"rows"`
+		}
+		if err := json.Unmarshal(resp.Data, &page); err != nil {
+			return nil, fmt.Errorf("decode orders: %w", err)
+		}
+		orders = page.Rows
+	}
+
+	for i := range orders {
+		if (key == "orderID" && orders[i].OrderID == id) || (key == "clOrdID" && orders[i].ClOrdID == id) {
+			return &orders[i], nil
+		}
+	}
+	return nil, nil
+}
+
 func (c *Client) GetFills(symbol string) (*APIResponse, error) {
 	return c.doRequest("GET", "/g-trades/fills", fmt.Sprintf("symbol=%s", symbol), nil)
 }
 
+// PhemexFill is a single execution returned by the fills endpoint.
+type PhemexFill struct {
+	ExecID      string `json:"execID"`
+	OrderID     string `json:"orderID"`
+	Symbol      string `json:"symbol"`
+	Side        string `json:"side"`
+	PosSide     string `json:"posSide"`
+	ExecQtyRq   string `json:"execQtyRq"`
+	ExecPriceRp string `json:"execPriceRp"`
+	ExecFeeRv   string `json:"execFeeRv"`
+	ClosedPnlRv string `json:"closedPnlRv"`
+	// ExecType is Trade for regular executions, LiqTrade and AdlTrade for
+	// positions closed by a liquidation or by auto-deleveraging.
+	ExecType       string `json:"execType"`
+	TransactTimeNs int64  `json:"transactTimeNs"`
+}
+
+// Forced reports whether the fill was a liquidation or an auto-deleveraging
+// rather than an order of the account.
+func (f PhemexFill) Forced() bool {
+	return f.ExecType == "LiqTrade" || f.ExecType == "AdlTrade"
+}
+
+// ListFills returns the decoded fills for symbol. The endpoint answers either
+// with a plain array or with a {"rows": [...]} page, both are accepted.
+func (c *Client) ListFills(symbol string) ([]PhemexFill, error) {
+	resp, err := c.GetFills(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+
+	var fills []PhemexFill
+	if err := json.Unmarshal(resp.Data, &fills); err == nil {
+		return fills, nil
+	}
+
+	var page struct {
+		Rows []PhemexFill `json:"rows"`
+	}
+	if err := json.Unmarshal(resp.Data, &page); err != nil {
+		return nil, fmt.Errorf("decode fills: %w", err)
+	}
+	return page.Rows, nil
+}
+
 // -----------------------------
 // E) MARKET DATA METHODS
 // -----------------------------
@@ -262,8 +611,23 @@ type mdResponse struct {
 	Result json.RawMessage `json:"result"`
 }
 
+// GetTicker reads the 24h ticker of symbol. It is shared through the cache
+// between the clients of the same endpoint for cache.Config.TickerTTL.
 func (c *Client) GetTicker(symbol string) (*APIResponse, error) {
-	resp, err := c.http.R().
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	data, err := cache.GetOrLoad(ctx, cache.Default(), cache.Key("ticker", c.baseURL, symbol), cache.TTLs().TickerTTL,
+		func() (json.RawMessage, error) { return c.fetchTicker(symbol) })
+	if err != nil {
+		return nil, err
+	}
+	return &APIResponse{Code: 0, Data: data}, nil
+}
+
+func (c *Client) fetchTicker(symbol string) (json.RawMessage, error) {
+	resp, err := c.newRequest().
 		SetQueryParam("symbol", symbol).
 		Get("/md/v3/ticker/24hr")
 	if err != nil {
@@ -271,7 +635,7 @@ func (c *Client) GetTicker(symbol string) (*APIResponse, error) {
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode(), string(resp.Body()))
+		return nil, httpError("phemex", resp.StatusCode(), string(resp.Body()))
 	}
 
 	var md mdResponse
@@ -282,11 +646,11 @@ func (c *Client) GetTicker(symbol string) (*APIResponse, error) {
 		return nil, errors.New(md.Error.Message)
 	}
 
-	return &APIResponse{Code: 0, Data: md.Result}, nil
+	return md.Result, nil
 }
 
 func (c *Client) GetOrderbook(symbol string) (*APIResponse, error) {
-	resp, err := c.http.R().
+	resp, err := c.newRequest().
 		SetQueryParam("symbol", symbol).
 		Get("/md/v2/orderbook")
 	if err != nil {
@@ -294,7 +658,7 @@ func (c *Client) GetOrderbook(symbol string) (*APIResponse, error) {
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode(), string(resp.Body()))
+		return nil, httpError("phemex", resp.StatusCode(), string(resp.Body()))
 	}
 
 	var md mdResponse
@@ -308,6 +672,61 @@ func (c *Client) GetOrderbook(symbol string) (*APIResponse, error) {
 	return &APIResponse{Code: 0, Data: md.Result}, nil
 }
 
+// OrderBook reads the order book snapshot of a USDT-M perpetual.
+func (c *Client) OrderBook(symbol string) (*OrderBook, error) {
+	resp, err := c.GetOrderbook(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	type side struct {
+		Asks [][]string `json:"asks"`
+		Bids [][]string `json:"bids"`
+	}
+	var md struct {
+		Book   *side `json:"orderbook_p"`
+		Legacy *side `json:"book"`
+	}
+	if err := json.Unmarshal(resp.Data, &md); err != nil {
+		return nil, err
+	}
+	raw := md.Book
+	if raw == nil {
+		raw = md.Legacy
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("no order book for %s", symbol)
+	}
+
+	book := &OrderBook{Symbol: symbol}
+	if book.Asks, err = parseBookLevels(raw.Asks); err != nil {
+		return nil, fmt.Errorf("asks of %s: %w", symbol, err)
+	}
+	if book.Bids, err = parseBookLevels(raw.Bids); err != nil {
+		return nil, fmt.Errorf("bids of %s: %w", symbol, err)
+	}
+	return book, nil
+}
+
+func parseBookLevels(rows [][]string) ([]BookLevel, error) {
+	levels := make([]BookLevel, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 2 {
+			return nil, fmt.Errorf("invalid level %v", row)
+		}
+		price, err := strconv.ParseFloat(row[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price %q", row[0])
+		}
+		qty, err := strconv.ParseFloat(row[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size %q", row[1])
+		}
+		levels = append(levels, BookLevel{Price: price, Qty: qty})
+	}
+	return levels, nil
+}
+
 func (c *Client) GetKlines(symbol string, res int) (*APIResponse, error) {
 	return c.doRequest("GET", "/md/perpetual/kline",
 		fmt.Sprintf("symbol=%s&resolution=%d", symbol, res),
@@ -315,6 +734,118 @@ func (c *Client) GetKlines(symbol string, res int) (*APIResponse, error) {
 	)
 }
 
+// FundingSnapshot is the funding/open-interest view of a USDT-M perpetual
+// extracted from the 24h ticker.
+type FundingSnapshot struct {
+	Symbol               string
+	FundingRate          float64
+	PredictedFundingRate float64
+	OpenInterest         float64
+	MarkPrice            float64
+	Timestamp            time.Time
+}
+
+// GetFundingSnapshot reads the current funding rate, predicted funding rate
+// and open interest for symbol from the 24h ticker.
+func (c *Client) GetFundingSnapshot(symbol string) (*FundingSnapshot, error) {
+	ticker, err := c.GetTicker(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var tk struct {
+		Symbol            string `json:"symbol"`
+		FundingRateRr     string `json:"fundingRateRr"`
+		PredFundingRateRr string `json:"predFundingRateRr"`
+		OpenInterestRv    string `json:"openInterestRv"`
+		MarkRp            string `json:"markRp"`
+		Timestamp         int64  `json:"timestamp"`
+	}
+	if err := json.Unmarshal(ticker.Data, &tk); err != nil {
+		return nil, err
+	}
+
+	parse := func(name, v string) (float64, error) {
+		if v == "" {
+			return 0, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s for %s: %q", name, symbol, v)
+		}
+		return f, nil
+	}
+
+	snap := &FundingSnapshot{Symbol: symbol, Timestamp: time.Now().UTC()}
+	if tk.Timestamp > 0 {
+		// Phemex reports ticker timestamps in nanoseconds.
+		snap.Timestamp = time.Unix(0, tk.Timestamp).UTC()
+	}
+	if snap.FundingRate, err = parse("fundingRateRr", tk.FundingRateRr); err != nil {
+		return nil, err
+	}
+	if snap.PredictedFundingRate, err = parse("predFundingRateRr", tk.PredFundingRateRr); err != nil {
+		return nil, err
+	}
+	if snap.OpenInterest, err = parse("openInterestRv", tk.OpenInterestRv); err != nil {
+		return nil, err
+	}
+	if snap.MarkPrice, err = parse("markRp", tk.MarkRp); err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+// IndexPrice reads the index price of a USDT-M perpetual, the price of its
+// underlying on the spot exchanges, from the 24h ticker.
+func (c *Client) IndexPrice(symbol string) (float64, error) {
+	ticker, err := c.GetTicker(symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	var tk struct {
+		IndexRp string `json:"indexRp"`
+	}
+	if err := json.Unmarshal(ticker.Data, &tk); err != nil {
+		return 0, err
+	}
+	price, err := strconv.ParseFloat(tk.IndexRp, 64)
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("invalid indexRp for %s: %q", symbol, tk.IndexRp)
+	}
+	return price, nil
+}
+
+// BestBidAsk reads the best bid and ask of a USDT-M perpetual from the 24h
+// ticker.
+func (c *Client) BestBidAsk(symbol string) (float64, float64, error) {
+	ticker, err := c.GetTicker(symbol)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var tk struct {
+		BidRp string `json:"bidRp"`
+		AskRp string `json:"askRp"`
+	}
+	if err := json.Unmarshal(ticker.Data, &tk); err != nil {
+		return 0, 0, err
+	}
+
+	bid, err := strconv.ParseFloat(tk.BidRp, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid bidRp for %s: %q", symbol, tk.BidRp)
+	}
+	ask, err := strconv.ParseFloat(tk.AskRp, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid askRp for %s: %q", symbol, tk.AskRp)
+	}
+
+	return bid, ask, nil
+}
+
 // -----------------------------
 // F) RISK & MARGIN
 // -----------------------------
@@ -578,6 +1109,45 @@ func (c *Client) PlaceStopLossOrder(
 	return c.doRequest("POST", "/g-orders", "", b)
 }
 
+// PlaceTakeProfitOrder places a conditional take profit (market if touched)
+// order. Like PlaceStopLossOrder it is reduceOnly and GoodTillCancel; side
+// must be opposite of the position direction.
+func (c *Client) PlaceTakeProfitOrder(
+	symbol string,
+	posSide string,
+	side string,
+	qty string,
+	stopPxRp string,
+	triggerType string,
+) (*APIResponse, error) {
+
+	for name, v := range map[string]string{"symbol": symbol, "posSide": posSide, "side": side, "qty": qty, "stopPxRp": stopPxRp} {
+		if err := mustNonEmpty(name, v); err != nil {
+			return nil, err
+		}
+	}
+	if triggerType == "" {
+		triggerType = TriggerByMarkPrice
+	}
+
+	body := map[string]interface{}{
+		"symbol":      symbol,
+		"posSide":     posSide,
+		"side":        side,
+		"ordType":     "MarketIfTouched",
+		"orderQtyRq":  qty,
+		"stopPxRp":    stopPxRp,
+		"triggerType": triggerType,
+		"reduceOnly":  true,
+		"timeInForce": "GoodTillCancel",
+		"text":        "takeprofit",
+		"clOrdID":     fmt.Sprintf("go-tp-%d", time.Now().UnixNano()),
+	}
+
+	b, _ := json.Marshal(body)
+	return c.doRequest("POST", "/g-orders", "", b)
+}
+
 // SetStopLossForOpenPosition finds the currently open position for (symbol, posSide)
 // and places a reduce-only STOP order for the full position size.
 // This is the safe way to do "set stop loss without a position ID".
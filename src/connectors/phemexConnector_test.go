@@ -21,6 +21,13 @@ package connectors
 // 18. TestSetStopLossForOpenPosition walks the happy path for open-position stop loss placement.
 // 19. TestSetStopLossForOpenPositionErrors surfaces missing positions and size zero errors.
 // 20. TestSetStopLossForSymbolHedgeMode covers dual-side stop creation and validation errors.
+// 21. TestGetFundingSnapshot parses funding rate and open interest from the ticker.
+// 22. TestListFills decodes fills returned as a plain array or as a rows page.
+// 23. TestBestBidAsk parses the best bid and ask from the ticker.
+// 24. TestFindOrderByClientID finds an order by clOrdID and reports unknown ids as nil.
+// 25. TestPlaceLimitOrder sends a resting GoodTillCancel limit order.
+// 26. TestPlaceTakeProfitOrder sends a reduce-only MarketIfTouched order and validates arguments.
+// 27. TestCancelOrder cancels one active order by id and reports unknown ids.
 
 import (
 	"crypto/hmac"
@@ -30,7 +37,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strategyexecutor/src/testsupport"
 	"testing"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 )
@@ -95,22 +104,9 @@ func TestSignRequest(t *testing.T) {
 func TestGetPositionsUSDT(t *testing.T) {
 	// Confirms USDT position retrieval decodes the server payload and returns the expected
 	// symbol details.
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_ = json.NewEncoder(w).Encode(APIResponse{Code: 0, Data: mustJSON(GAccountPositions{Positions: []struct {
-			AccountID        int64  `json:"accountID"`
-			Symbol           string `json:"symbol"`
-			Currency         string `json:"currency"`
-			Side             string `json:"side"`
-			PosSide          string `json:"posSide"`
-			SizeRq           string `json:"sizeRq"`
-			AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-			PositionMarginRv string `json:"positionMarginRv"`
-			MarkPriceRp      string `json:"markPriceRp"`
-		}{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "2"}}})})
-	}))
-	defer server.Close()
+	exchange := testsupport.NewMockExchange(t).WithPositions(testsupport.Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "2"})
 
-	client := newTestClient(server.URL, server.Client())
+	client := newTestClient(exchange.URL, exchange.Server.Client())
 	positions, err := client.GetPositionsUSDT()
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -350,44 +346,55 @@ func TestGetKlines(t *testing.T) {
 	}
 }
 
-// TestCloseAllPositions ensures closing orders are issued for existing positions.
-func TestCloseAllPositions(t *testing.T) {
-	// Ensures existing positions trigger a closing market order and tracks the number of
-	// generated orders to confirm all positions are addressed.
-	callCount := 0
+// TestGetFundingSnapshot parses funding rate and open interest from the ticker.
+func TestGetFundingSnapshot(t *testing.T) {
+	// Serves a ticker payload with funding fields and checks they are parsed, then verifies a
+	// malformed value is rejected.
+	payload := `{"symbol":"BTCUSDT","fundingRateRr":"0.0001","predFundingRateRr":"-0.00005","openInterestRv":"1234.5","markRp":"60000","timestamp":1700000000000000000}`
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/g-accounts/positions":
-			resp := APIResponse{Code: 0, Data: mustJSON(GAccountPositions{Positions: []struct {
-				AccountID        int64  `json:"accountID"`
-				Symbol           string `json:"symbol"`
-				Currency         string `json:"currency"`
-				Side             string `json:"side"`
-				PosSide          string `json:"posSide"`
-				SizeRq           string `json:"sizeRq"`
-				AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-				PositionMarginRv string `json:"positionMarginRv"`
-				MarkPriceRp      string `json:"markPriceRp"`
-			}{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"}}})}
-			_ = json.NewEncoder(w).Encode(resp)
-		case "/g-orders":
-			callCount++
-			resp := APIResponse{Code: 0, Data: mustJSON(map[string]string{"orderID": "1"})}
-			_ = json.NewEncoder(w).Encode(resp)
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
+		_ = json.NewEncoder(w).Encode(mdResponse{Result: []byte(payload)})
 	}))
 	defer server.Close()
 
 	client := newTestClient(server.URL, server.Client())
+	snap, err := client.GetFundingSnapshot("BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetFundingSnapshot error: %v", err)
+	}
+	if snap.FundingRate != 0.0001 || snap.PredictedFundingRate != -0.00005 {
+		t.Fatalf("unexpected funding rates: %+v", snap)
+	}
+	if snap.OpenInterest != 1234.5 || snap.MarkPrice != 60000 {
+		t.Fatalf("unexpected open interest/mark price: %+v", snap)
+	}
+	if !snap.Timestamp.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("unexpected timestamp: %v", snap.Timestamp)
+	}
+
+	payload = `{"symbol":"BTCUSDT","fundingRateRr":"abc"}`
+	if _, err := client.GetFundingSnapshot("BTCUSDT"); err == nil {
+		t.Fatalf("expected error for malformed funding rate")
+	}
+}
+
+// TestCloseAllPositions ensures closing orders are issued for existing positions.
+func TestCloseAllPositions(t *testing.T) {
+	// Ensures existing positions trigger a closing market order and tracks the number of
+	// generated orders to confirm all positions are addressed.
+	exchange := testsupport.NewMockExchange(t).WithPositions(testsupport.Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"})
+
+	client := newTestClient(exchange.URL, exchange.Server.Client())
 
 	if err := client.CloseAllPositions("BTCUSDT"); err != nil {
 		t.Fatalf("expected no error closing positions, got %v", err)
 	}
 
-	if callCount != 1 {
-		t.Fatalf("expected one closing order to be placed, got %d", callCount)
+	orders := exchange.Orders()
+	if len(orders) != 1 {
+		t.Fatalf("expected one closing order to be placed, got %d", len(orders))
+	}
+	if o := orders[0]; o.Side != "Sell" || o.PosSide != "Long" || o.OrderQtyRq != "1" || !o.ReduceOnly {
+		t.Fatalf("unexpected closing order: %+v", o)
 	}
 }
 
@@ -395,31 +402,11 @@ func TestCloseAllPositions(t *testing.T) {
 func TestCloseAllPositionsPlaceOrderError(t *testing.T) {
 	// Confirms that an error is returned when placing a closing order fails after fetching
 	// positions to close.
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/g-accounts/positions":
-			resp := APIResponse{Code: 0, Data: mustJSON(GAccountPositions{Positions: []struct {
-				AccountID        int64  `json:"accountID"`
-				Symbol           string `json:"symbol"`
-				Currency         string `json:"currency"`
-				Side             string `json:"side"`
-				PosSide          string `json:"posSide"`
-				SizeRq           string `json:"sizeRq"`
-				AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-				PositionMarginRv string `json:"positionMarginRv"`
-				MarkPriceRp      string `json:"markPriceRp"`
-			}{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"}}})}
-			_ = json.NewEncoder(w).Encode(resp)
-		case "/g-orders":
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte("boom"))
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
-	}))
-	defer server.Close()
+	exchange := testsupport.NewMockExchange(t).
+		WithPositions(testsupport.Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"}).
+		Fail(http.MethodPost, "/g-orders", testsupport.Failure{Status: http.StatusInternalServerError, Body: "boom"})
 
-	client := newTestClient(server.URL, server.Client())
+	client := newTestClient(exchange.URL, exchange.Server.Client())
 
 	if err := client.CloseAllPositions("BTCUSDT"); err == nil {
 		t.Fatalf("expected error when place order fails")
@@ -430,40 +417,16 @@ func TestCloseAllPositionsPlaceOrderError(t *testing.T) {
 func TestCloseAllPositionsNoPositions(t *testing.T) {
 	// Ensures a call with no matching positions short-circuits without hitting the order
 	// endpoint and still returns a nil error.
-	callCount := 0
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/g-accounts/positions":
-			emptyPositions := GAccountPositions{Positions: []struct {
-				AccountID        int64  `json:"accountID"`
-				Symbol           string `json:"symbol"`
-				Currency         string `json:"currency"`
-				Side             string `json:"side"`
-				PosSide          string `json:"posSide"`
-				SizeRq           string `json:"sizeRq"`
-				AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-				PositionMarginRv string `json:"positionMarginRv"`
-				MarkPriceRp      string `json:"markPriceRp"`
-			}{}}
-			resp := APIResponse{Code: 0, Data: mustJSON(emptyPositions)}
-			_ = json.NewEncoder(w).Encode(resp)
-		case "/g-orders":
-			callCount++
-			w.WriteHeader(http.StatusInternalServerError)
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
-	}))
-	defer server.Close()
+	exchange := testsupport.NewMockExchange(t)
 
-	client := newTestClient(server.URL, server.Client())
+	client := newTestClient(exchange.URL, exchange.Server.Client())
 
 	if err := client.CloseAllPositions("BTCUSDT"); err != nil {
 		t.Fatalf("expected no error closing positions, got %v", err)
 	}
 
-	if callCount != 0 {
-		t.Fatalf("expected no orders to be placed, got %d", callCount)
+	if n := exchange.Count(http.MethodPost, "/g-orders"); n != 0 {
+		t.Fatalf("expected no orders to be placed, got %d", n)
 	}
 }
 
@@ -471,26 +434,9 @@ func TestCloseAllPositionsNoPositions(t *testing.T) {
 func TestCloseAllPositionsUnknownSide(t *testing.T) {
 	// Ensures a position with an unknown side surfaces an error instead of silently skipping
 	// the invalid record.
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/g-accounts/positions":
-			resp := APIResponse{Code: 0, Data: mustJSON(GAccountPositions{Positions: []struct {
-				AccountID        int64  `json:"accountID"`
-				Symbol           string `json:"symbol"`
-				Currency         string `json:"currency"`
-				Side             string `json:"side"`
-				PosSide          string `json:"posSide"`
-				SizeRq           string `json:"sizeRq"`
-				AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-				PositionMarginRv string `json:"positionMarginRv"`
-				MarkPriceRp      string `json:"markPriceRp"`
-			}{{Symbol: "BTCUSDT", Side: "Unknown", PosSide: "Long", SizeRq: "1"}}})}
-			_ = json.NewEncoder(w).Encode(resp)
-		}
-	}))
-	defer server.Close()
+	exchange := testsupport.NewMockExchange(t).WithPositions(testsupport.Position{Symbol: "BTCUSDT", Side: "Unknown", PosSide: "Long", SizeRq: "1"})
 
-	client := newTestClient(server.URL, server.Client())
+	client := newTestClient(exchange.URL, exchange.Server.Client())
 	if err := client.CloseAllPositions("BTCUSDT"); err == nil {
 		t.Fatalf("expected error for unknown position side")
 	}
@@ -547,64 +493,27 @@ func TestPlaceStopLossOrderValidation(t *testing.T) {
 func TestSetStopLossForOpenPosition(t *testing.T) {
 	// Confirms the helper identifies the open position side, derives the opposite order side,
 	// and delegates to PlaceStopLossOrder with the position size.
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/g-accounts/positions":
-			resp := APIResponse{Code: 0, Data: mustJSON(GAccountPositions{Positions: []struct {
-				AccountID        int64  `json:"accountID"`
-				Symbol           string `json:"symbol"`
-				Currency         string `json:"currency"`
-				Side             string `json:"side"`
-				PosSide          string `json:"posSide"`
-				SizeRq           string `json:"sizeRq"`
-				AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-				PositionMarginRv string `json:"positionMarginRv"`
-				MarkPriceRp      string `json:"markPriceRp"`
-			}{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "3"}}})}
-			_ = json.NewEncoder(w).Encode(resp)
-		case "/g-orders":
-			_ = json.NewEncoder(w).Encode(APIResponse{Code: 0, Data: mustJSON(map[string]string{"orderID": "sl"})})
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
-	}))
-	defer server.Close()
+	exchange := testsupport.NewMockExchange(t).WithPositions(testsupport.Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "3"})
 
-	client := newTestClient(server.URL, server.Client())
+	client := newTestClient(exchange.URL, exchange.Server.Client())
 	if _, err := client.SetStopLossForOpenPosition("BTCUSDT", "Long", "30000", TriggerByMarkPrice, true); err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
+	if orders := exchange.Orders(); len(orders) != 1 || orders[0].Side != "Sell" || orders[0].OrderQtyRq != "3" {
+		t.Fatalf("unexpected stop loss orders: %+v", orders)
+	}
 }
 
 // TestSetStopLossForOpenPositionErrors checks missing positions and zero sizes.
 func TestSetStopLossForOpenPositionErrors(t *testing.T) {
 	// Ensures missing positions, zero sizes, and unknown sides all return errors before placing
 	// stop orders.
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/g-accounts/positions":
-			resp := APIResponse{Code: 0, Data: mustJSON(GAccountPositions{Positions: []struct {
-				AccountID        int64  `json:"accountID"`
-				Symbol           string `json:"symbol"`
-				Currency         string `json:"currency"`
-				Side             string `json:"side"`
-				PosSide          string `json:"posSide"`
-				SizeRq           string `json:"sizeRq"`
-				AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-				PositionMarginRv string `json:"positionMarginRv"`
-				MarkPriceRp      string `json:"markPriceRp"`
-			}{
-				{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0"},
-				{Symbol: "BTCUSDT", Side: "Unknown", PosSide: "Short", SizeRq: "1"},
-			}})}
-			_ = json.NewEncoder(w).Encode(resp)
-		default:
-			_ = json.NewEncoder(w).Encode(APIResponse{Code: 0})
-		}
-	}))
-	defer server.Close()
+	exchange := testsupport.NewMockExchange(t).WithPositions(
+		testsupport.Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0"},
+		testsupport.Position{Symbol: "BTCUSDT", Side: "Unknown", PosSide: "Short", SizeRq: "1"},
+	)
 
-	client := newTestClient(server.URL, server.Client())
+	client := newTestClient(exchange.URL, exchange.Server.Client())
 	if _, err := client.SetStopLossForOpenPosition("BTCUSDT", "Long", "30000", TriggerByMarkPrice, true); err == nil {
 		t.Fatalf("expected error for zero-sized position")
 	}
@@ -614,44 +523,26 @@ func TestSetStopLossForOpenPositionErrors(t *testing.T) {
 	if _, err := client.SetStopLossForOpenPosition("ETHUSDT", "Long", "30000", TriggerByMarkPrice, true); err == nil {
 		t.Fatalf("expected error when position not found")
 	}
+	if n := exchange.Count(http.MethodPost, "/g-orders"); n != 0 {
+		t.Fatalf("expected no stop orders, got %d", n)
+	}
 }
 
 // TestSetStopLossForSymbolHedgeMode covers dual-side stop placement and validation errors.
 func TestSetStopLossForSymbolHedgeMode(t *testing.T) {
 	// Ensures both long and short stop losses are placed when provided, and errors bubble when
 	// neither price is supplied or inner calls fail.
-	calls := 0
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/g-accounts/positions":
-			resp := APIResponse{Code: 0, Data: mustJSON(GAccountPositions{Positions: []struct {
-				AccountID        int64  `json:"accountID"`
-				Symbol           string `json:"symbol"`
-				Currency         string `json:"currency"`
-				Side             string `json:"side"`
-				PosSide          string `json:"posSide"`
-				SizeRq           string `json:"sizeRq"`
-				AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-				PositionMarginRv string `json:"positionMarginRv"`
-				MarkPriceRp      string `json:"markPriceRp"`
-			}{
-				{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"},
-				{Symbol: "BTCUSDT", Side: "Sell", PosSide: "Short", SizeRq: "2"},
-			}})}
-			_ = json.NewEncoder(w).Encode(resp)
-		case "/g-orders":
-			calls++
-			_ = json.NewEncoder(w).Encode(APIResponse{Code: 0, Data: mustJSON(map[string]string{"orderID": fmt.Sprintf("sl-%d", calls)})})
-		}
-	}))
-	defer server.Close()
+	exchange := testsupport.NewMockExchange(t).WithPositions(
+		testsupport.Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"},
+		testsupport.Position{Symbol: "BTCUSDT", Side: "Sell", PosSide: "Short", SizeRq: "2"},
+	)
 
-	client := newTestClient(server.URL, server.Client())
+	client := newTestClient(exchange.URL, exchange.Server.Client())
 	res, err := client.SetStopLossForSymbolHedgeMode("BTCUSDT", "30000", "31000", TriggerByMarkPrice, true)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
-	if len(res) != 2 || calls != 2 {
+	if calls := exchange.Count(http.MethodPost, "/g-orders"); len(res) != 2 || calls != 2 {
 		t.Fatalf("expected two stop loss calls, got responses=%d calls=%d", len(res), calls)
 	}
 
@@ -672,3 +563,301 @@ func mustJSON(v interface{}) json.RawMessage {
 	data, _ := json.Marshal(v)
 	return data
 }
+
+// TestListFills decodes fills returned as a plain array or as a rows page.
+func TestListFills(t *testing.T) {
+	// Serves both payload shapes of the fills endpoint and checks the decoded fields.
+	fill := `{"execID":"e1","orderID":"o1","symbol":"BTCUSDT","side":"Sell","posSide":"Long","execQtyRq":"0.01","execPriceRp":"60000","execFeeRv":"0.36","closedPnlRv":"12.5","transactTimeNs":1700000000000000000}`
+	for name, data := range map[string]string{
+		"array": "[" + fill + "]",
+		"rows":  `{"rows":[` + fill + `]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/g-trades/fills" || r.URL.Query().Get("symbol") != "BTCUSDT" {
+					t.Fatalf("unexpected request %s?%s", r.URL.Path, r.URL.RawQuery)
+				}
+				_, _ = fmt.Fprintf(w, `{"code":0,"msg":"","data":%s}`, data)
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL, server.Client())
+			fills, err := client.ListFills("BTCUSDT")
+			if err != nil {
+				t.Fatalf("ListFills returned error: %v", err)
+			}
+			if len(fills) != 1 {
+				t.Fatalf("expected 1 fill, got %d", len(fills))
+			}
+			if fills[0].ExecID != "e1" || fills[0].ClosedPnlRv != "12.5" || fills[0].ExecFeeRv != "0.36" || fills[0].TransactTimeNs != 1700000000000000000 {
+				t.Fatalf("unexpected fill: %+v", fills[0])
+			}
+		})
+	}
+}
+
+// TestBestBidAsk parses the best bid and ask from the ticker.
+func TestBestBidAsk(t *testing.T) {
+	// Serves a ticker with bid/ask prices, then a ticker without them to check the error path.
+	payload := `{"symbol":"BTCUSDT","bidRp":"59999.5","askRp":"60000.5"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(mdResponse{Result: []byte(payload)})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, server.Client())
+	bid, ask, err := client.BestBidAsk("BTCUSDT")
+	if err != nil {
+		t.Fatalf("BestBidAsk error: %v", err)
+	}
+	if bid != 59999.5 || ask != 60000.5 {
+		t.Fatalf("unexpected bid/ask: %v/%v", bid, ask)
+	}
+
+	payload = `{"symbol":"BTCUSDT"}`
+	if _, _, err := client.BestBidAsk("BTCUSDT"); err == nil {
+		t.Fatalf("expected error for missing bid/ask")
+	}
+}
+
+func TestOrderBook(t *testing.T) {
+	// Serves a v2 order book snapshot, then the legacy key, then a malformed level.
+	payload := `{"symbol":"BTCUSDT","orderbook_p":{"asks":[["60000.5","0.2"],["60001","1.5"]],"bids":[["59999.5","0.7"]]}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(mdResponse{Result: []byte(payload)})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, server.Client())
+	book, err := client.OrderBook("BTCUSDT")
+	if err != nil {
+		t.Fatalf("OrderBook error: %v", err)
+	}
+	if len(book.Asks) != 2 || book.Asks[1] != (BookLevel{Price: 60001, Qty: 1.5}) || len(book.Bids) != 1 || book.Bids[0].Price != 59999.5 {
+		t.Fatalf("unexpected book: %+v", book)
+	}
+
+	payload = `{"symbol":"BTCUSDT","book":{"asks":[["1","2"]],"bids":[["0.5","3"]]}}`
+	if book, err = client.OrderBook("BTCUSDT"); err != nil || book.Asks[0].Qty != 2 {
+		t.Fatalf("unexpected legacy book %+v (%v)", book, err)
+	}
+
+	payload = `{"symbol":"BTCUSDT","orderbook_p":{"asks":[["x","1"]],"bids":[]}}`
+	if _, err := client.OrderBook("BTCUSDT"); err == nil {
+		t.Fatalf("expected error for a malformed level")
+	}
+}
+
+func TestFindOrderByClientID(t *testing.T) {
+	// Serves the orders-by-id endpoint with a single order in a rows page.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api-data/g-futures/orders/by-order-id" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		rows := []ClientOrder{}
+		if r.URL.Query().Get("clOrdID") == "se-7" {
+			rows = append(rows, ClientOrder{OrderID: "abc", ClOrdID: "se-7", OrdStatus: "Filled"})
+		}
+		_ = json.NewEncoder(w).Encode(APIResponse{Code: 0, Data: mustJSON(map[string]interface{}{"rows": rows})})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, server.Client())
+	order, err := client.FindOrderByClientID("BTCUSDT", "se-7")
+	if err != nil {
+		t.Fatalf("FindOrderByClientID error: %v", err)
+	}
+	if order == nil || order.OrderID != "abc" || order.OrdStatus != "Filled" {
+		t.Fatalf("unexpected order: %+v", order)
+	}
+
+	order, err = client.FindOrderByClientID("BTCUSDT", "se-8")
+	if err != nil || order != nil {
+		t.Fatalf("expected no order, got %+v, %v", order, err)
+	}
+}
+
+// TestPlaceLimitOrder checks the limit order payload.
+func TestPlaceLimitOrder(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t)
+	client := newTestClient(exchange.URL, exchange.Server.Client())
+
+	if _, err := client.PlaceLimitOrder("BTCUSDT", "Buy", "Long", "0.01", "60000", false); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := client.PlaceLimitOrder("BTCUSDT", "Buy", "Long", "0.01", "", false); err == nil {
+		t.Fatalf("expected validation error for empty price")
+	}
+
+	orders := exchange.Orders()
+	if len(orders) != 1 || orders[0].OrdType != "Limit" || orders[0].PriceRp != "60000" || orders[0].ReduceOnly {
+		t.Fatalf("unexpected limit orders: %+v", orders)
+	}
+	var body map[string]interface{}
+	if err := exchange.Requests()[0].JSON(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["timeInForce"] != "GoodTillCancel" {
+		t.Fatalf("expected GoodTillCancel, got %+v", body)
+	}
+}
+
+// TestPlaceTakeProfitOrder checks the take profit payload and validation.
+func TestPlaceTakeProfitOrder(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t)
+	client := newTestClient(exchange.URL, exchange.Server.Client())
+
+	if _, err := client.PlaceTakeProfitOrder("BTCUSDT", "Short", "Buy", "2", "58000", ""); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := client.PlaceTakeProfitOrder("BTCUSDT", "Short", "", "2", "58000", ""); err == nil {
+		t.Fatalf("expected validation error for empty side")
+	}
+
+	orders := exchange.Orders()
+	if len(orders) != 1 || orders[0].OrdType != "MarketIfTouched" || orders[0].StopPxRp != "58000" || !orders[0].ReduceOnly {
+		t.Fatalf("unexpected take profit orders: %+v", orders)
+	}
+	var body map[string]interface{}
+	if err := exchange.Requests()[0].JSON(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["triggerType"] != TriggerByMarkPrice {
+		t.Fatalf("expected mark price trigger by default, got %+v", body)
+	}
+}
+
+// TestCancelOrder cancels by id using the posSide of the active order.
+func TestCancelOrder(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t)
+	client := newTestClient(exchange.URL, exchange.Server.Client())
+
+	if _, err := client.PlaceLimitOrder("BTCUSDT", "Sell", "Short", "1", "70000", false); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	orderID := exchange.Orders()[0].OrderID
+
+	if _, err := client.CancelOrder("BTCUSDT", orderID); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	reqs := exchange.Requests()
+	last := reqs[len(reqs)-1]
+	if last.Method != http.MethodDelete || last.Query != "orderID="+orderID+"&posSide=Short&symbol=BTCUSDT" {
+		t.Fatalf("unexpected cancel request: %+v", last)
+	}
+
+	if _, err := client.CancelOrder("BTCUSDT", orderID); err == nil {
+		t.Fatalf("expected error for an order that is no longer active")
+	}
+}
+
+// TestCancelOrderByClientID checks an order is cancelled by its clOrdID
+// with the orderID Phemex gave it.
+func TestCancelOrderByClientID(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t)
+	client := newTestClient(exchange.URL, exchange.Server.Client())
+
+	if _, err := client.PlaceStopLossOrder("BTCUSDT", "Long", "Sell", "1", "60000", "", true); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	stop := exchange.Orders()[0]
+
+	if _, err := client.CancelOrder("BTCUSDT", stop.ClOrdID); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	reqs := exchange.Requests()
+	last := reqs[len(reqs)-1]
+	if last.Method != http.MethodDelete || last.Query != "orderID="+stop.OrderID+"&posSide=Long&symbol=BTCUSDT" {
+		t.Fatalf("unexpected cancel request: %+v", last)
+	}
+}
+
+// TestGetOrder checks an order is found by its orderID whatever its status.
+func TestGetOrder(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t)
+	client := newTestClient(exchange.URL, exchange.Server.Client())
+
+	if _, err := client.PlaceStopLossOrder("BTCUSDT", "Long", "Sell", "1", "60000", "", true); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	orderID := exchange.Orders()[0].OrderID
+	if _, err := client.CancelOrder("BTCUSDT", orderID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	order, err := client.GetOrder("BTCUSDT", orderID)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if order == nil || order.OrdStatus != "Canceled" || order.OrdType != "Stop" || order.StopPxRp != "60000" {
+		t.Fatalf("unexpected order: %+v", order)
+	}
+
+	order, err = client.GetOrder("BTCUSDT", "unknown")
+	if err != nil || order != nil {
+		t.Fatalf("expected no order, got %+v, %v", order, err)
+	}
+	if _, err := client.GetOrder("BTCUSDT", ""); err == nil {
+		t.Fatalf("expected validation error for an empty orderID")
+	}
+}
+
+// TestListActiveOrders checks resting stops are listed and filled market
+// orders are not.
+func TestListActiveOrders(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t)
+	client := newTestClient(exchange.URL, exchange.Server.Client())
+
+	if _, err := client.PlaceOrder("BTCUSDT", "Buy", "Long", "1", "Market", false); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if _, err := client.PlaceStopLossOrder("BTCUSDT", "Long", "Sell", "1", "60000", "", true); err != nil {
+		t.Fatalf("place stop: %v", err)
+	}
+
+	active, err := client.ListActiveOrders("BTCUSDT")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(active) != 1 || active[0].OrdType != "Stop" || active[0].StopPxRp != "60000" || !active[0].ReduceOnly {
+		t.Fatalf("unexpected active orders: %+v", active)
+	}
+}
+
+// TestTransferBetweenWallets checks the transfer payload in both directions.
+func TestTransferBetweenWallets(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t)
+	client := newTestClient(exchange.URL, exchange.Server.Client())
+
+	if err := client.TransferBetweenWallets("USDT", 12.5, WalletSpot, WalletFutures); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := client.TransferBetweenWallets("USDT", 1, WalletFutures, WalletSpot); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := client.TransferBetweenWallets("USDT", 1, WalletSpot, WalletSpot); err == nil {
+		t.Fatalf("expected error for a transfer within the same wallet")
+	}
+	if err := client.TransferBetweenWallets("USDT", 0, WalletSpot, WalletFutures); err == nil {
+		t.Fatalf("expected error for a zero amount")
+	}
+
+	requests := exchange.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 transfers, got %d", len(requests))
+	}
+	var body struct {
+		AmountEv int64  `json:"amountEv"`
+		Currency string `json:"currency"`
+		MoveOp   int    `json:"moveOp"`
+	}
+	if err := requests[0].JSON(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if requests[0].Path != "/assets/transfer" || body.AmountEv != 1250000000 || body.Currency != "USDT" || body.MoveOp != 2 {
+		t.Fatalf("unexpected spot to futures transfer %s %+v", requests[0].Path, body)
+	}
+	if err := requests[1].JSON(&body); err != nil || body.MoveOp != 1 {
+		t.Fatalf("unexpected futures to spot transfer %+v (%v)", body, err)
+	}
+}
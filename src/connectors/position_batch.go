@@ -0,0 +1,72 @@
+package connectors
+
+import (
+	"context"
+	"sync"
+)
+
+// positionBatch shares the positions of each account between the calls of
+// one loop: the first GetPositionsUSDT of an account reaches the exchange,
+// the next ones are answered from it until a call that changes the account,
+// e.g. placing an order, reads them again.
+type positionBatch struct {
+	mu       sync.Mutex
+	accounts map[string]*batchedPositions
+}
+
+type batchedPositions struct {
+	mu        sync.Mutex
+	loaded    bool
+	positions *GAccountPositions
+}
+
+type positionBatchKey struct{}
+
+// WithPositionBatch returns ctx sharing the positions fetched by the
+// clients bound to it, see Client.WithContext. One loop of an executor or a
+// job over many accounts uses one batch, so the controllers and filters of
+// an account, and the accounts behind one API key, fetch positions once.
+func WithPositionBatch(ctx context.Context) context.Context {
+	return context.WithValue(ctx, positionBatchKey{}, &positionBatch{accounts: map[string]*batchedPositions{}})
+}
+
+func positionBatchFrom(ctx context.Context) *positionBatch {
+	if ctx == nil {
+		return nil
+	}
+	b, _ := ctx.Value(positionBatchKey{}).(*positionBatch)
+	return b
+}
+
+// get returns the positions of account, fetched once. Errors are not kept,
+// the next call fetches again.
+func (b *positionBatch) get(account string, fetch func() (*GAccountPositions, error)) (*GAccountPositions, error) {
+	b.mu.Lock()
+	e, ok := b.accounts[account]
+	if !ok {
+		e = &batchedPositions{}
+		b.accounts[account] = e
+	}
+	b.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.loaded {
+		positions, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		e.positions, e.loaded = positions, true
+	}
+	// Callers may edit what they get back.
+	cp := *e.positions
+	cp.Positions = append([]GPosition(nil), e.positions.Positions...)
+	return &cp, nil
+}
+
+// invalidate drops the positions of account.
+func (b *positionBatch) invalidate(account string) {
+	b.mu.Lock()
+	delete(b.accounts, account)
+	b.mu.Unlock()
+}
@@ -0,0 +1,66 @@
+package connectors
+
+import (
+	"context"
+	"net/http"
+	"strategyexecutor/src/testsupport"
+	"testing"
+)
+
+func TestPositionBatch(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t).
+		WithPositions(testsupport.Position{Symbol: "BTCUSDT", Currency: "USDT", Side: "Buy", PosSide: "Long", SizeRq: "0.5"}).
+		ThenPositions()
+	positions := func() int { return exchange.Count(http.MethodGet, "/g-accounts/positions") }
+
+	ctx := WithPositionBatch(context.Background())
+	client := newTestClient(exchange.URL, exchange.Server.Client()).WithContext(ctx)
+	first, err := client.GetPositionsUSDT()
+	if err != nil {
+		t.Fatalf("positions: %v", err)
+	}
+	first.Positions[0].Symbol = "edited"
+	second, err := client.GetPositionsUSDT()
+	if err != nil {
+		t.Fatalf("positions: %v", err)
+	}
+	if positions() != 1 {
+		t.Fatalf("expected the positions fetched once, got %d requests", positions())
+	}
+	if len(second.Positions) != 1 || second.Positions[0].Symbol != "BTCUSDT" {
+		t.Fatalf("expected the batched positions unchanged, got %+v", second.Positions)
+	}
+
+	// another account of the same exchange fetches its own
+	other := newTestClient(exchange.URL, exchange.Server.Client())
+	other.apiKey = "other-key"
+	if _, err := other.WithContext(ctx).GetPositionsUSDT(); err != nil {
+		t.Fatalf("positions: %v", err)
+	}
+	if positions() != 2 {
+		t.Fatalf("expected one request per account, got %d", positions())
+	}
+
+	// an order changes the positions, they are read again
+	if _, err := client.PlaceOrder("BTCUSDT", "Sell", "Long", "0.5", "Market", true); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	after, err := client.GetPositionsUSDT()
+	if err != nil {
+		t.Fatalf("positions: %v", err)
+	}
+	if positions() != 3 || len(after.Positions) != 0 {
+		t.Fatalf("expected the positions read again after the order, got %d requests and %+v", positions(), after.Positions)
+	}
+
+	// without a batch every call reaches the exchange
+	plain := newTestClient(exchange.URL, exchange.Server.Client())
+	for i := 0; i < 2; i++ {
+		if _, err := plain.GetPositionsUSDT(); err != nil {
+			t.Fatalf("positions: %v", err)
+		}
+	}
+	if positions() != 5 {
+		t.Fatalf("expected every call fetched without a batch, got %d", positions())
+	}
+}
@@ -0,0 +1,201 @@
+package connectors
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	// Default retry configuration
+	defaultRetryAttempts   = 5
+	defaultRetryBaseDelay  = 500 * time.Millisecond
+	defaultRetryMaxBackoff = 8 * time.Second
+	defaultRetryJitter     = 0.2
+)
+
+// RetryPolicy is the retry behaviour shared by the exchange clients: capped
+// exponential backoff with jitter, honouring Retry-After. Only transport
+// errors, 408, 429 and 5xx answers are retried, and only for idempotent
+// requests (see WithIdempotent).
+type RetryPolicy struct {
+	// MaxAttempts counts the first try; 1 disables retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter is the fraction of each delay that is randomized, from 0 to 1.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used by every connector unless overridden.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: defaultRetryAttempts,
+	BaseDelay:   defaultRetryBaseDelay,
+	MaxDelay:    defaultRetryMaxBackoff,
+	Jitter:      defaultRetryJitter,
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks the requests made with ctx as safe, or not, to send
+// more than once. Without it GET, HEAD, OPTIONS and DELETE are idempotent and
+// everything else, order POSTs included, is never retried automatically.
+func WithIdempotent(ctx context.Context, idempotent bool) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, idempotent)
+}
+
+func isIdempotent(ctx context.Context, method string) bool {
+	if ctx != nil {
+		if v, ok := ctx.Value(idempotentKey{}).(bool); ok {
+			return v
+		}
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests ||
+		status >= 500 && status <= 599
+}
+
+// isRetryableResp reports whether a resty attempt failed in a way worth
+// retrying, regardless of the request method.
+func isRetryableResp(r *resty.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	if r == nil {
+		return false
+	}
+	return isRetryableStatus(r.StatusCode())
+}
+
+// Delay returns how long to wait after the given failed attempt (1 for the
+// first try). A Retry-After header on resp wins over the backoff; both are
+// capped at MaxDelay.
+func (p RetryPolicy) Delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return min(d, p.MaxDelay)
+		}
+	}
+
+	d := float64(p.BaseDelay) * math.Exp2(float64(attempt-1))
+	if d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	// Jitter only shortens the delay so MaxDelay stays a hard cap.
+	d -= d * p.Jitter * rand.Float64()
+	return time.Duration(d)
+}
+
+// parseRetryAfter reads a Retry-After value in seconds or as an HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// newRestyClient returns a resty client for baseURL retrying under p. A nil
+// transport keeps resty's default.
+func (p RetryPolicy) newRestyClient(baseURL string, timeout time.Duration, transport http.RoundTripper) *resty.Client {
+	return resty.New().
+		SetTransport(transport).
+		SetBaseURL(baseURL).
+		SetTimeout(timeout).
+		SetRetryCount(max(p.MaxAttempts-1, 0)).
+		SetRetryWaitTime(p.BaseDelay).
+		SetRetryMaxWaitTime(p.MaxDelay).
+		AddRetryCondition(func(r *resty.Response, err error) bool {
+			if r == nil || r.Request == nil || !isIdempotent(r.Request.Context(), r.Request.Method) {
+				return false
+			}
+			return isRetryableResp(r, err)
+		}).
+		SetRetryAfter(func(_ *resty.Client, r *resty.Response) (time.Duration, error) {
+			return p.Delay(r.Request.Attempt, r.RawResponse), nil
+		})
+}
+
+// Transport wraps next, or http.DefaultTransport when nil, so plain
+// net/http clients retry under p. Request bodies are replayed through
+// GetBody, which http.NewRequest sets for in-memory bodies.
+func (p RetryPolicy) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &retryTransport{policy: p, next: next}
+}
+
+type retryTransport struct {
+	policy RetryPolicy
+	next   http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	retry := isIdempotent(ctx, req.Method) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if !retry || attempt >= t.policy.MaxAttempts {
+			return resp, err
+		}
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		wait := t.policy.Delay(attempt, resp)
+		fields := logger.Fields{
+			"method":  req.Method,
+			"url":     req.URL.Redacted(),
+			"attempt": attempt,
+			"wait":    wait,
+		}
+		if err != nil {
+			logger.WithFields(fields).WithError(err).Warn("HTTP request failed, retrying")
+		} else {
+			fields["status"] = resp.StatusCode
+			logger.WithFields(fields).Warn("HTTP request got retryable status, retrying")
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package connectors
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var fastRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: 0.5}
+	for attempt, base := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 6: time.Second} {
+		d := p.Delay(attempt, nil)
+		require.LessOrEqual(t, d, base, "attempt %d", attempt)
+		require.GreaterOrEqual(t, d, base/2, "attempt %d", attempt)
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"0"}}}
+	require.Zero(t, p.Delay(4, resp))
+	resp.Header.Set("Retry-After", "120")
+	require.Equal(t, time.Second, p.Delay(1, resp))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	d, ok := parseRetryAfter("3", now)
+	require.True(t, ok)
+	require.Equal(t, 3*time.Second, d)
+
+	d, ok = parseRetryAfter(now.Add(10*time.Second).Format(http.TimeFormat), now)
+	require.True(t, ok)
+	require.Equal(t, 10*time.Second, d)
+
+	_, ok = parseRetryAfter("soon", now)
+	require.False(t, ok)
+	_, ok = parseRetryAfter("", now)
+	require.False(t, ok)
+}
+
+func flakyServer(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32, *[]string) {
+	t.Helper()
+	var calls atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if calls.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, &bodies
+}
+
+func TestRetryTransport(t *testing.T) {
+	client := &http.Client{Transport: fastRetryPolicy.Transport(nil)}
+
+	t.Run("retries GET", func(t *testing.T) {
+		srv, calls, _ := flakyServer(t, 2)
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("gives up after MaxAttempts", func(t *testing.T) {
+		srv, calls, _ := flakyServer(t, 10)
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("never retries POST", func(t *testing.T) {
+		srv, calls, _ := flakyServer(t, 1)
+		resp, err := client.Post(srv.URL, "application/json", strings.NewReader(`{"side":"Buy"}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("retries POST marked idempotent and replays the body", func(t *testing.T) {
+		srv, calls, bodies := flakyServer(t, 1)
+		ctx := WithIdempotent(context.Background(), true)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, strings.NewReader(`{"user":"a"}`))
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.EqualValues(t, 2, calls.Load())
+		require.Equal(t, []string{`{"user":"a"}`, `{"user":"a"}`}, *bodies)
+	})
+}
+
+func TestRestyClientRetries(t *testing.T) {
+	t.Run("retries GET", func(t *testing.T) {
+		srv, calls, _ := flakyServer(t, 2)
+		c := fastRetryPolicy.newRestyClient(srv.URL, time.Second, nil)
+		resp, err := c.R().Get("/")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode())
+		require.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("never retries order POST", func(t *testing.T) {
+		srv, calls, _ := flakyServer(t, 1)
+		c := fastRetryPolicy.newRestyClient(srv.URL, time.Second, nil)
+		resp, err := c.R().SetBody(`{"clOrdID":"se-1"}`).Post("/g-orders")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode())
+		require.EqualValues(t, 1, calls.Load())
+	})
+}
@@ -0,0 +1,132 @@
+package connectors
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strategyexecutor/src/model"
+	"strings"
+	"time"
+)
+
+// RSSSource reads an RSS 2.0 feed. Items carry no importance, so every item
+// gets the configured importance (0 keeps them out of the news window gate
+// while still feeding the sentiment filter).
+type RSSSource struct {
+	httpClient *http.Client
+	feedURL    string
+	currency   string
+	importance int
+}
+
+func NewRSSSource(httpClient *http.Client, feedURL string, currency string, importance int) *RSSSource {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &RSSSource{
+		httpClient: httpClient,
+		feedURL:    feedURL,
+		currency:   strings.ToUpper(currency),
+		importance: importance,
+	}
+}
+
+type rssFeed struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	GUID        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+}
+
+func (s *RSSSource) Name() string {
+	return model.NewsSourceRSS
+}
+
+func (s *RSSSource) FetchEvents(ctx context.Context, fromUTC time.Time, toUTC time.Time) ([]model.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("accept", "application/rss+xml, application/xml, text/xml")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return nil, fmt.Errorf("unexpected status %d. body: %s", resp.StatusCode, string(b))
+	}
+
+	var feed rssFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("decode rss: %w", err)
+	}
+
+	out := make([]model.Event, 0, len(feed.Channel.Items))
+	for _, it := range feed.Channel.Items {
+		published, err := parseRSSDate(it.PubDate)
+		if err != nil {
+			continue
+		}
+		published = published.UTC()
+		if published.Before(fromUTC) || published.After(toUTC) {
+			continue
+		}
+
+		key := it.GUID
+		if key == "" {
+			key = it.Link
+		}
+		if key == "" {
+			key = it.Title + "|" + it.PubDate
+		}
+
+		out = append(out, model.Event{
+			ID:         newsEventID(model.NewsSourceRSS, s.feedURL+"|"+key),
+			Title:      strings.TrimSpace(it.Title),
+			Comment:    strings.TrimSpace(it.Description),
+			Source:     strings.TrimSpace(feed.Channel.Title),
+			SourceURL:  it.Link,
+			Currency:   s.currency,
+			Importance: s.importance,
+			Date:       model.TVTime{Time: published},
+			NewsSource: model.NewsSourceRSS,
+		})
+	}
+
+	return out, nil
+}
+
+func parseRSSDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	layouts := []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		time.RFC3339,
+	}
+
+	var lastErr error
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+		lastErr = err
+	}
+	return time.Time{}, fmt.Errorf("parse rss date %q: %w", s, lastErr)
+}
@@ -0,0 +1,87 @@
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strategyexecutor/src/model"
+	"time"
+)
+
+// StatusPage reads the scheduled maintenances announced on the status page
+// of an exchange, in the Statuspage format most exchanges publish, e.g.
+// https://status.kraken.com/api/v2/scheduled-maintenances.json.
+type StatusPage struct {
+	httpClient *http.Client
+	url        string
+}
+
+func NewStatusPage(httpClient *http.Client, url string) *StatusPage {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &StatusPage{httpClient: httpClient, url: url}
+}
+
+type statusPageResponse struct {
+	ScheduledMaintenances []struct {
+		ID             string     `json:"id"`
+		Name           string     `json:"name"`
+		Status         string     `json:"status"`
+		ScheduledFor   *time.Time `json:"scheduled_for"`
+		ScheduledUntil *time.Time `json:"scheduled_until"`
+		ResolvedAt     *time.Time `json:"resolved_at"`
+	} `json:"scheduled_maintenances"`
+}
+
+// FetchMaintenances returns the announced maintenances as windows without
+// an exchange. A maintenance completed before its scheduled end ends when
+// it was resolved; one without a start or an end is left out.
+func (s *StatusPage) FetchMaintenances(ctx context.Context) ([]model.MaintenanceWindow, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return nil, fmt.Errorf("unexpected status %d. body: %s", resp.StatusCode, string(b))
+	}
+
+	var decoded statusPageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+
+	out := make([]model.MaintenanceWindow, 0, len(decoded.ScheduledMaintenances))
+	for _, m := range decoded.ScheduledMaintenances {
+		if m.ID == "" || m.ScheduledFor == nil || m.ScheduledUntil == nil {
+			continue
+		}
+		ends := m.ScheduledUntil.UTC()
+		if m.Status == "completed" && m.ResolvedAt != nil && m.ResolvedAt.Before(ends) {
+			ends = m.ResolvedAt.UTC()
+		}
+		starts := m.ScheduledFor.UTC()
+		if !ends.After(starts) {
+			continue
+		}
+		out = append(out, model.MaintenanceWindow{
+			StartsAt:   starts,
+			EndsAt:     ends,
+			Source:     model.MaintenanceSourceStatusPage,
+			ExternalID: m.ID,
+			Title:      m.Name,
+		})
+	}
+	return out, nil
+}
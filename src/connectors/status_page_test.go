@@ -0,0 +1,39 @@
+package connectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+// TestStatusPageFetchMaintenances maps the scheduled maintenances of a
+// status page into windows.
+func TestStatusPageFetchMaintenances(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"page":{"id":"p"},"scheduled_maintenances":[
+			{"id":"m1","name":"Futures upgrade","status":"scheduled","scheduled_for":"2025-12-08T06:00:00.000Z","scheduled_until":"2025-12-08T08:00:00.000Z","resolved_at":null},
+			{"id":"m2","name":"Wallet maintenance","status":"completed","scheduled_for":"2025-12-01T06:00:00Z","scheduled_until":"2025-12-01T08:00:00Z","resolved_at":"2025-12-01T07:00:00Z"},
+			{"id":"m3","name":"No end","status":"scheduled","scheduled_for":"2025-12-09T06:00:00Z","scheduled_until":null}
+		]}`))
+	}))
+	defer server.Close()
+
+	windows, err := NewStatusPage(server.Client(), server.URL).FetchMaintenances(context.Background())
+	if err != nil {
+		t.Fatalf("FetchMaintenances error: %v", err)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("expected 2 windows, got %+v", windows)
+	}
+	w := windows[0]
+	if w.ExternalID != "m1" || w.Title != "Futures upgrade" || w.Source != model.MaintenanceSourceStatusPage ||
+		!w.StartsAt.Equal(time.Date(2025, 12, 8, 6, 0, 0, 0, time.UTC)) || !w.EndsAt.Equal(time.Date(2025, 12, 8, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected window: %+v", w)
+	}
+	if !windows[1].EndsAt.Equal(time.Date(2025, 12, 1, 7, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the early resolution as the end, got %v", windows[1].EndsAt)
+	}
+}
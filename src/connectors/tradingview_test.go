@@ -192,3 +192,106 @@ func TestFetchImportantEvents_RealAPI(t *testing.T) {
 		t.Logf("Event: %s at %s", ev.Title, ev.Date.Time)
 	}
 }
+
+// Test scoring persisted events and computing the net sentiment per symbol.
+func TestNetSentimentForSymbol(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := gorm.Open(sqlite.Open("file:net_sentiment?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open in memory db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.TradingViewNewsEvent{}); err != nil {
+		t.Fatalf("failed to automigrate: %v", err)
+	}
+
+	repo := repository.NewTradingViewRepositoryWithDB(db)
+	now := time.Date(2025, 12, 8, 16, 0, 0, 0, time.UTC)
+
+	events := []model.Event{
+		{ID: "1", Title: "Fed hawkish", Currency: "USD", Importance: 1, Date: tvTime(now.Add(-1 * time.Hour))},
+		{ID: "2", Title: "Rate hike", Currency: "USD", Importance: 1, Date: tvTime(now.Add(-2 * time.Hour))},
+		{ID: "3", Title: "ECB rate cut", Currency: "EUR", Importance: 1, Date: tvTime(now.Add(-1 * time.Hour))},
+		{ID: "4", Title: "Old news", Currency: "USD", Importance: 1, Date: tvTime(now.Add(-48 * time.Hour))},
+		{ID: "5", Title: "Unscored", Currency: "USD", Importance: 1, Date: tvTime(now.Add(-1 * time.Hour))},
+	}
+	if err := repo.SaveTradingViewNewsEvents(ctx, events); err != nil {
+		t.Fatalf("SaveTradingViewNewsEvents failed: %v", err)
+	}
+
+	scores := map[string]float64{"1": -0.5, "2": -0.25, "3": 0.6, "4": -1}
+	for id, score := range scores {
+		if err := repo.UpdateSentimentScore(ctx, id, score, "test"); err != nil {
+			t.Fatalf("UpdateSentimentScore failed: %v", err)
+		}
+	}
+
+	net, count, err := repo.NetSentimentForSymbol(ctx, "BTCUSDT", 6*time.Hour, now)
+	if err != nil {
+		t.Fatalf("NetSentimentForSymbol failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 contributing events, got %d", count)
+	}
+	if net != -0.75 {
+		t.Fatalf("expected net -0.75, got %v", net)
+	}
+}
+
+// Test that a republished headline with a new tv_event_id is not stored twice
+// and that the watchlist upsert is keyed on ticker.
+func TestUpsertTradingViewNewsEventDedupAndWatchlist(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := gorm.Open(sqlite.Open("file:tv_dedup?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open in memory db: %v", err)
+	}
+	if err := db.AutoMigrate(&model.TradingViewNewsEvent{}, &model.TVNewsWatchlist{}); err != nil {
+		t.Fatalf("failed to automigrate: %v", err)
+	}
+
+	repo := repository.NewTradingViewRepositoryWithDB(db)
+	at := time.Date(2025, 12, 8, 13, 30, 0, 0, time.UTC)
+
+	first := model.Event{ID: "100", Title: "CPI YoY", Country: "US", Indicator: "Inflation Rate", Importance: 1, Date: tvTime(at)}
+	stored, err := repo.UpsertTradingViewNewsEvent(ctx, first)
+	if err != nil || !stored {
+		t.Fatalf("expected first event stored, got stored=%v err=%v", stored, err)
+	}
+
+	// same event id again is an update, not a duplicate
+	first.Actual = floatPtr(3.1)
+	if stored, err = repo.UpsertTradingViewNewsEvent(ctx, first); err != nil || !stored {
+		t.Fatalf("expected update of same event, got stored=%v err=%v", stored, err)
+	}
+
+	republished := first
+	republished.ID = "101"
+	republished.Title = "  cpi yoy "
+	if stored, err = repo.UpsertTradingViewNewsEvent(ctx, republished); err != nil || stored {
+		t.Fatalf("expected republished headline skipped, got stored=%v err=%v", stored, err)
+	}
+
+	var count int64
+	db.Model(&model.TradingViewNewsEvent{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("expected 1 stored event, got %d", count)
+	}
+
+	if err := repo.UpsertWatchlistEntry(ctx, &model.TVNewsWatchlist{Ticker: "economics:uscpi", Country: "us", Enabled: true}); err != nil {
+		t.Fatalf("UpsertWatchlistEntry failed: %v", err)
+	}
+	if err := repo.UpsertWatchlistEntry(ctx, &model.TVNewsWatchlist{Ticker: "ECONOMICS:USCPI", Country: "US", Enabled: false}); err != nil {
+		t.Fatalf("UpsertWatchlistEntry failed: %v", err)
+	}
+
+	all, err := repo.ListWatchlist(ctx, false)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("expected 1 watchlist entry, got %d err=%v", len(all), err)
+	}
+	enabled, err := repo.ListWatchlist(ctx, true)
+	if err != nil || len(enabled) != 0 {
+		t.Fatalf("expected disabled entry filtered out, got %d err=%v", len(enabled), err)
+	}
+}
@@ -0,0 +1,129 @@
+package connectors
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strategyexecutor/src/tracing"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dialTimeout bounds the TCP connect, like the net/http default transport.
+const dialTimeout = 30 * time.Second
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// errPinMismatch is returned by the TLS handshake when no certificate of the
+// server chain matches the configured pins.
+var errPinMismatch = errors.New("tls: server public key does not match any pin")
+
+// Transport builds the base HTTP transport described by c.
+func (c TransportConfig) Transport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.ProxyURL != "" {
+		proxy, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy url: %w", err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxy.Scheme)
+		}
+		t.Proxy = http.ProxyURL(proxy)
+	}
+
+	minVersion, ok := tlsVersions[strings.TrimSpace(c.TLSMinVersion)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TLS min version %q", c.TLSMinVersion)
+	}
+	t.TLSClientConfig = &tls.Config{MinVersion: minVersion}
+
+	if c.MaxIdleConns > 0 {
+		t.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = c.MaxConnsPerHost
+	}
+	if c.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = c.IdleConnTimeout
+	}
+	if c.KeepAlive > 0 {
+		t.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: c.KeepAlive}).DialContext
+	}
+	t.ForceAttemptHTTP2 = c.HTTP2
+	if !c.HTTP2 {
+		// A non-nil empty map keeps net/http from upgrading to HTTP/2.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	pins := make(map[string]bool, len(c.TLSPins))
+	for _, pin := range c.TLSPins {
+		if pin = strings.TrimSpace(pin); pin != "" {
+			pins[pin] = true
+		}
+	}
+	if len(pins) > 0 {
+		// Runs after the standard chain verification, so pinning only
+		// narrows what is already trusted.
+		t.TLSClientConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			for _, cert := range cs.PeerCertificates {
+				sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				if pins[base64.StdEncoding.EncodeToString(sum[:])] {
+					return nil
+				}
+			}
+			return errPinMismatch
+		}
+	}
+
+	return t, nil
+}
+
+var exchangeTransports sync.Map // exchange -> http.RoundTripper
+
+// exchangeTransport returns the shared base transport of an exchange, built
+// once from its TransportConfig and traced. A bad configuration panics like
+// the other config loaders: the clients cannot run without it.
+func exchangeTransport(exchange string) http.RoundTripper {
+	if t, ok := exchangeTransports.Load(exchange); ok {
+		return t.(http.RoundTripper)
+	}
+	base, err := GetTransportConfig(exchange).Transport()
+	if err != nil {
+		panic(fmt.Errorf("%s transport config: %w", exchange, err))
+	}
+	actual, _ := exchangeTransports.LoadOrStore(exchange, tracing.Transport(base))
+	return actual.(http.RoundTripper)
+}
+
+// SetExchangeTransport replaces the base transport of an exchange for the
+// clients built afterwards, e.g. with a recorded cassette in tests. The
+// returned func restores the previous one.
+func SetExchangeTransport(exchange string, rt http.RoundTripper) (restore func()) {
+	prev, had := exchangeTransports.Load(exchange)
+	exchangeTransports.Store(exchange, rt)
+	return func() {
+		if had {
+			exchangeTransports.Store(exchange, prev)
+			return
+		}
+		exchangeTransports.Delete(exchange)
+	}
+}
@@ -0,0 +1,153 @@
+package connectors
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTransportConfig(t *testing.T) {
+	t.Setenv("KUCOIN_PROXY_URL", "socks5://127.0.0.1:1080")
+	t.Setenv("KUCOIN_TLS_PINS", "a,b")
+
+	cfg := GetTransportConfig("KUCOIN")
+	require.Equal(t, "socks5://127.0.0.1:1080", cfg.ProxyURL)
+	require.Equal(t, "1.2", cfg.TLSMinVersion)
+	require.Equal(t, []string{"a", "b"}, cfg.TLSPins)
+	require.Empty(t, GetTransportConfig("KRAKEN").ProxyURL)
+}
+
+func TestTransportConfigErrors(t *testing.T) {
+	_, err := TransportConfig{TLSMinVersion: "1.2", ProxyURL: "ftp://proxy:21"}.Transport()
+	require.ErrorContains(t, err, "unsupported proxy scheme")
+
+	_, err = TransportConfig{TLSMinVersion: "2.0"}.Transport()
+	require.ErrorContains(t, err, "unsupported TLS min version")
+
+	tr, err := TransportConfig{TLSMinVersion: "1.3"}.Transport()
+	require.NoError(t, err)
+	require.EqualValues(t, tls.VersionTLS13, tr.TLSClientConfig.MinVersion)
+}
+
+func TestTransportProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	tr, err := TransportConfig{TLSMinVersion: "1.2", ProxyURL: proxy.URL}.Transport()
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: tr}).Get("http://api.exchange.test/v1/ping")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, "http://api.exchange.test/v1/ping", proxied)
+}
+
+func TestTransportPinning(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	sum := sha256.Sum256(srv.Certificate().RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(sum[:])
+
+	get := func(pins ...string) error {
+		tr, err := TransportConfig{TLSMinVersion: "1.2", TLSPins: pins}.Transport()
+		require.NoError(t, err)
+		tr.TLSClientConfig.RootCAs = roots
+		resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	require.NoError(t, get())
+	require.NoError(t, get("other", pin))
+	err := get("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+	require.True(t, errors.Is(err, errPinMismatch), "got %v", err)
+}
+
+func TestSetExchangeTransport(t *testing.T) {
+	base := exchangeTransport("HYDRA")
+	fake := http.RoundTripper(&http.Transport{})
+
+	restore := SetExchangeTransport("HYDRA", fake)
+	require.Same(t, fake, exchangeTransport("HYDRA"))
+	restore()
+	require.Same(t, base, exchangeTransport("HYDRA"))
+
+	restore = SetExchangeTransport("TESTONLY", fake)
+	restore()
+	_, ok := exchangeTransports.Load("TESTONLY")
+	require.False(t, ok)
+}
+
+func TestTransportPool(t *testing.T) {
+	tr, err := TransportConfig{
+		TLSMinVersion:       "1.2",
+		MaxIdleConns:        50,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     time.Minute,
+		KeepAlive:           15 * time.Second,
+		HTTP2:               true,
+	}.Transport()
+	require.NoError(t, err)
+	require.Equal(t, 50, tr.MaxIdleConns)
+	require.Equal(t, 20, tr.MaxIdleConnsPerHost)
+	require.Equal(t, time.Minute, tr.IdleConnTimeout)
+	require.True(t, tr.ForceAttemptHTTP2)
+	require.Nil(t, tr.TLSNextProto)
+
+	tr, err = TransportConfig{TLSMinVersion: "1.2"}.Transport()
+	require.NoError(t, err)
+	require.False(t, tr.ForceAttemptHTTP2)
+	require.NotNil(t, tr.TLSNextProto)
+	require.Empty(t, tr.TLSNextProto)
+}
+
+func TestClientsShareConnections(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		conns int
+	)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"msg":"","data":{}}`))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	tr, err := GetTransportConfig("PHEMEX").Transport()
+	require.NoError(t, err)
+	defer SetExchangeTransport("PHEMEX", tr)()
+
+	// one client per user, as the executors build them
+	for i := 0; i < 5; i++ {
+		_, _ = NewClient("k", "s", srv.URL).GetTicker("BTCUSDT")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 1, conns)
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// topupCurrency is the margin currency moved into the futures wallet.
+const topupCurrency = "USDT"
+
+// autoTopup moves the top-up amount of userExchange from the spot wallet into
+// futures when usdtAvail is below its threshold, and reports whether funds
+// were moved so the caller reads the balance again. A failed transfer is
+// logged and the entry goes on with the balance it has.
+func autoTopup(ctx context.Context, client connectors.Connector, userExchange *model.UserExchange, usdtAvail float64) bool {
+	if userExchange == nil || !userExchange.TopupBelowUSDT.IsPositive() || !userExchange.TopupAmountUSDT.IsPositive() {
+		return false
+	}
+	below := userExchange.TopupBelowUSDT.InexactFloat64()
+	if usdtAvail >= below {
+		return false
+	}
+
+	amount := userExchange.TopupAmountUSDT.InexactFloat64()
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"user_id":     userExchange.UserID,
+		"exchange_id": userExchange.ExchangeID,
+		"usdtAvail":   usdtAvail,
+		"below":       below,
+		"amount":      amount,
+	})
+	transferer, ok := client.(connectors.WalletTransferer)
+	if !ok {
+		log.Warn("connector cannot transfer between wallets, futures not topped up")
+		return false
+	}
+	if err := transferer.TransferBetweenWallets(topupCurrency, amount, connectors.WalletSpot, connectors.WalletFutures); err != nil {
+		log.WithError(err).Error("failed to top up futures wallet")
+		return false
+	}
+	log.Info("futures wallet topped up from spot")
+	return true
+}
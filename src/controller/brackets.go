@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+type bracketRepository interface {
+	FindLatestEntry(ctx context.Context, userID uint, exchangeID uint, symbol string) (*model.Order, error)
+	UpdateBracket(ctx context.Context, orderID uint, stopOrderID string, takeProfitOrderID string) error
+}
+
+var newBracketRepo = func() bracketRepository {
+	return repository.NewOrderRepository()
+}
+
+// responseOrderID returns the exchange order ID of an order placement
+// response, empty when the response carries none.
+func responseOrderID(resp *connectors.APIResponse) string {
+	if resp == nil || len(resp.Data) == 0 {
+		return ""
+	}
+	var payload struct {
+		OrderID string `json:"orderID"`
+	}
+	if err := json.Unmarshal(resp.Data, &payload); err != nil {
+		return ""
+	}
+	return payload.OrderID
+}
+
+// cancelReplacedStop cancels the stop a moved stop loss replaced. A failure
+// is only logged: the old stop is further from the price, and
+// ReloadBrackets cancels it on the next start.
+func cancelReplacedStop(ctx context.Context, client connectors.Connector, symbol, oldID, newID string) {
+	if oldID == "" || oldID == newID {
+		return
+	}
+	canceler, ok := client.(connectors.OrderCanceler)
+	if !ok {
+		return
+	}
+	if _, err := canceler.CancelOrder(symbol, oldID); err != nil {
+		logger.WithContext(ctx).WithError(err).WithFields(map[string]interface{}{
+			"symbol":   symbol,
+			"order_id": oldID,
+		}).Warn("failed to cancel replaced stop")
+	}
+}
+
+// ReloadBrackets reconciles the protective orders of symbol with the
+// exchange after a restart. Stops and take profits of a closed position are
+// cancelled. For an open position one stop and one take profit are kept,
+// the ones stored on the latest entry when still resting, otherwise the
+// ones at the stored level, and the duplicates are cancelled. The entry is
+// updated with the IDs kept. Connectors that cannot list or cancel orders
+// are left alone.
+func ReloadBrackets(ctx context.Context, client connectors.Connector, user *model.User, exchangeID uint, symbol string) error {
+	lister, canList := client.(connectors.ActiveOrderLister)
+	canceler, canCancel := client.(connectors.OrderCanceler)
+	if !canList || !canCancel {
+		logger.WithContext(ctx).Debug("connector cannot list or cancel orders, brackets not reloaded")
+		return nil
+	}
+	symbol = NormalizeToUSDT(symbol)
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"user_id": user.ID,
+		"symbol":  symbol,
+	})
+
+	positions, err := client.GetPositionsUSDT()
+	if err != nil {
+		return fmt.Errorf("GetPositionsUSDT: %w", err)
+	}
+	active, err := lister.ListActiveOrders(symbol)
+	if err != nil {
+		return fmt.Errorf("ListActiveOrders: %w", err)
+	}
+	repo := newBracketRepo()
+	entry, err := repo.FindLatestEntry(ctx, user.ID, exchangeID, symbol)
+	if err != nil {
+		return fmt.Errorf("FindLatestEntry: %w", err)
+	}
+
+	var open []connectors.GPosition
+	if positions != nil {
+		for _, p := range positions.Positions {
+			size, err := decimal.NewFromString(strings.TrimSpace(p.SizeRq))
+			if p.Symbol == symbol && err == nil && !size.IsZero() {
+				open = append(open, p)
+			}
+		}
+	}
+
+	// group the protective orders by the position they close; the ones
+	// closing no open position are orphans
+	type group struct {
+		stops, takeProfits []connectors.ActiveOrder
+	}
+	groups := make([]group, len(open))
+	var orphans []connectors.ActiveOrder
+	for _, o := range active {
+		if !o.IsStop() && !o.IsTakeProfit() {
+			continue
+		}
+		matched := false
+		for i, p := range open {
+			if !o.Closes(p.PosSide, bracketSide(p)) {
+				continue
+			}
+			if o.IsStop() {
+				groups[i].stops = append(groups[i].stops, o)
+			} else {
+				groups[i].takeProfits = append(groups[i].takeProfits, o)
+			}
+			matched = true
+			break
+		}
+		if !matched {
+			orphans = append(orphans, o)
+		}
+	}
+
+	stopID, takeProfitID := "", ""
+	var cancel []connectors.ActiveOrder
+	cancel = append(cancel, orphans...)
+	for i, p := range open {
+		var storedStop, storedTP string
+		var stopLevel, tpLevel decimal.Decimal
+		owned := entry != nil && strings.EqualFold(entry.PosSide, bracketSide(p))
+		if owned {
+			storedStop, storedTP = entry.StopOrderID, entry.TakeProfitOrderID
+			stopLevel, tpLevel = entry.StopLossPct, entry.TakeProfitPct
+		}
+
+		keep, dup := keepBracketOrder(groups[i].stops, storedStop, stopLevel)
+		cancel = append(cancel, dup...)
+		if owned {
+			stopID = keep
+		}
+		keep, dup = keepBracketOrder(groups[i].takeProfits, storedTP, tpLevel)
+		cancel = append(cancel, dup...)
+		if owned {
+			takeProfitID = keep
+		}
+	}
+
+	var firstErr error
+	for _, o := range cancel {
+		if _, err := canceler.CancelOrder(symbol, o.OrderID); err != nil {
+			log.WithError(err).WithField("order_id", o.OrderID).Error("failed to cancel stale protective order")
+			if firstErr == nil {
+				firstErr = fmt.Errorf("cancel %s: %w", o.OrderID, err)
+			}
+			continue
+		}
+		log.WithFields(map[string]interface{}{
+			"order_id": o.OrderID,
+			"ord_type": o.OrdType,
+		}).Warn("stale protective order cancelled")
+	}
+
+	if entry != nil && (entry.StopOrderID != stopID || entry.TakeProfitOrderID != takeProfitID) {
+		if err := repo.UpdateBracket(ctx, entry.ID, stopID, takeProfitID); err != nil {
+			return fmt.Errorf("UpdateBracket: %w", err)
+		}
+		log.WithFields(map[string]interface{}{
+			"order_id":             entry.ID,
+			"stop_order_id":        stopID,
+			"take_profit_order_id": takeProfitID,
+		}).Info("bracket reloaded from the exchange")
+	}
+	return firstErr
+}
+
+// keepBracketOrder picks the order to keep out of orders protecting one
+// position: the stored one, else the one at level, else the last listed.
+// The others are returned as duplicates.
+func keepBracketOrder(orders []connectors.ActiveOrder, storedID string, level decimal.Decimal) (string, []connectors.ActiveOrder) {
+	if len(orders) == 0 {
+		return "", nil
+	}
+	keep := -1
+	for i, o := range orders {
+		if storedID != "" && o.OrderID == storedID {
+			keep = i
+			break
+		}
+	}
+	if keep < 0 && !level.IsZero() {
+		for i, o := range orders {
+			if px, err := decimal.NewFromString(o.StopPxRp); err == nil && px.Equal(level) {
+				keep = i
+			}
+		}
+	}
+	if keep < 0 {
+		keep = len(orders) - 1
+	}
+
+	var duplicates []connectors.ActiveOrder
+	for i, o := range orders {
+		if i != keep {
+			duplicates = append(duplicates, o)
+		}
+	}
+	return orders[keep].OrderID, duplicates
+}
+
+// bracketSide is Long or Short; one-way (Merged) positions take it from the
+// side they were opened with.
+func bracketSide(p connectors.GPosition) string {
+	switch {
+	case strings.EqualFold(p.PosSide, "Long"):
+		return "Long"
+	case strings.EqualFold(p.PosSide, "Short"):
+		return "Short"
+	case strings.EqualFold(p.Side, "Sell"):
+		return "Short"
+	default:
+		return "Long"
+	}
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strategyexecutor/src/model"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+type fakeBracketRepo struct {
+	entry   *model.Order
+	updates [][2]string
+}
+
+func (f *fakeBracketRepo) FindLatestEntry(context.Context, uint, uint, string) (*model.Order, error) {
+	return f.entry, nil
+}
+
+func (f *fakeBracketRepo) UpdateBracket(_ context.Context, _ uint, stopOrderID string, takeProfitOrderID string) error {
+	f.updates = append(f.updates, [2]string{stopOrderID, takeProfitOrderID})
+	return nil
+}
+
+func TestReloadBrackets(t *testing.T) {
+	original := newBracketRepo
+	defer func() { newBracketRepo = original }()
+
+	user := &model.User{ID: 1}
+
+	t.Run("open position keeps one stop and one take profit", func(t *testing.T) {
+		m := newPhemexMock(t).WithPositions(longBTC)
+		client := phemexClient(m)
+		// a stop placed before the crash, the stored one, and a duplicate
+		for _, px := range []string{"47000", "48000", "48000"} {
+			if _, err := client.PlaceStopLossOrder("BTCUSDT", "Long", "Sell", "0.001", px, "", true); err != nil {
+				t.Fatalf("place stop: %v", err)
+			}
+		}
+		if _, err := client.PlaceTakeProfitOrder("BTCUSDT", "Long", "Sell", "0.001", "55000", ""); err != nil {
+			t.Fatalf("place take profit: %v", err)
+		}
+		// a stop of a short closed meanwhile
+		if _, err := client.PlaceStopLossOrder("BTCUSDT", "Short", "Buy", "0.001", "52000", "", true); err != nil {
+			t.Fatalf("place stop: %v", err)
+		}
+		orders := m.Orders()
+
+		repo := &fakeBracketRepo{entry: &model.Order{ID: 7, Symbol: "BTCUSDT", PosSide: "Long",
+			StopLossPct: decimal.NewFromInt(48000), StopOrderID: orders[1].OrderID, TakeProfitPct: decimal.NewFromInt(55000)}}
+		newBracketRepo = func() bracketRepository { return repo }
+
+		if err := ReloadBrackets(context.Background(), client, user, 1, "BTCUSD"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := m.Count(http.MethodDelete, "/g-orders/cancel"); got != 3 {
+			t.Fatalf("expected the old stop, the duplicate and the orphan cancelled, got %d", got)
+		}
+		for i, o := range m.Orders() {
+			wantActive := i == 1 || i == 3
+			if (o.OrdStatus == "New") != wantActive {
+				t.Fatalf("order %d: unexpected status %q", i, o.OrdStatus)
+			}
+		}
+		if len(repo.updates) != 1 || repo.updates[0] != [2]string{orders[1].OrderID, orders[3].OrderID} {
+			t.Fatalf("expected the kept IDs stored, got %v", repo.updates)
+		}
+	})
+
+	t.Run("flat position cancels every protective order", func(t *testing.T) {
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		client := phemexClient(m)
+		if _, err := client.PlaceStopLossOrder("BTCUSDT", "Long", "Sell", "0.001", "48000", "", true); err != nil {
+			t.Fatalf("place stop: %v", err)
+		}
+		stopID := m.Orders()[0].OrderID
+
+		repo := &fakeBracketRepo{entry: &model.Order{ID: 7, Symbol: "BTCUSDT", PosSide: "Long", StopOrderID: stopID}}
+		newBracketRepo = func() bracketRepository { return repo }
+
+		if err := ReloadBrackets(context.Background(), client, user, 1, "BTCUSDT"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := m.Count(http.MethodDelete, "/g-orders/cancel"); got != 1 {
+			t.Fatalf("expected the stop cancelled, got %d", got)
+		}
+		if len(repo.updates) != 1 || repo.updates[0] != [2]string{"", ""} {
+			t.Fatalf("expected the stored IDs cleared, got %v", repo.updates)
+		}
+	})
+}
@@ -2,12 +2,32 @@ package controller
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 )
 
 type Config struct {
 	OrderSizePercent int `envconfig:"ORDER_SIZE_PERCENT" default:"25"`
+
+	// NewsSentimentThreshold blocks entries against strong net news
+	// sentiment (see sentiment.AllowsEntry). 0 disables the filter.
+	NewsSentimentThreshold float64       `envconfig:"NEWS_SENTIMENT_THRESHOLD" default:"0"`
+	NewsSentimentLookback  time.Duration `envconfig:"NEWS_SENTIMENT_LOOKBACK" default:"6h"`
+
+	// DuplicateSignalWindow suppresses a signal identical to one the user
+	// acted on within the window, e.g. an alert fired twice. 0 disables it.
+	DuplicateSignalWindow time.Duration `envconfig:"DUPLICATE_SIGNAL_WINDOW" default:"2m"`
+
+	// ApprovalSecret signs the approve/reject links of entries awaiting
+	// approval, served under PublicBaseURL. Without both the notification
+	// carries no links and entries are decided through the API.
+	ApprovalSecret string `envconfig:"APPROVAL_SECRET" default:""`
+	PublicBaseURL  string `envconfig:"PUBLIC_BASE_URL" default:""`
+
+	// TrailingStopService leaves the trailing of the stops to the
+	// trailing_stops command instead of each controller run.
+	TrailingStopService bool `envconfig:"TRAILING_STOP_SERVICE" default:"false"`
 }
 
 func GetConfig() Config {
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type dailyEntryCounter interface {
+	Increment(ctx context.Context, userID uint, symbol, day string) error
+}
+
+// newDailyEntryRepo returns nil when the database is not initialised, in
+// which case entries are not counted.
+var newDailyEntryRepo = func() dailyEntryCounter {
+	if database.MainDB == nil {
+		return nil
+	}
+	return repository.NewDailyEntryCountRepository()
+}
+
+// countDailyEntry counts the entry just sent on symbol towards the entries
+// of the current UTC day the daily_entries signal filter caps. A failure is
+// only logged: the entry is on the exchange already.
+func countDailyEntry(ctx context.Context, userID uint, symbol string) {
+	repo := newDailyEntryRepo()
+	if repo == nil {
+		return
+	}
+	day := model.DailyEntryDay(clock())
+	if err := repo.Increment(ctx, userID, symbol, day); err != nil {
+		logger.WithContext(ctx).WithError(err).WithFields(map[string]interface{}{
+			"symbol": symbol,
+			"day":    day,
+		}).Error("failed to count daily entry")
+	}
+}
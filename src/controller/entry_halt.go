@@ -0,0 +1,19 @@
+package controller
+
+import "context"
+
+type entryHaltKey struct{}
+
+// WithEntryHalt tells the controllers that new entries are paused for
+// reason, e.g. a news halt. Positions already open are still managed and
+// the signal is left to be evaluated again once the halt is over.
+func WithEntryHalt(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, entryHaltKey{}, reason)
+}
+
+// entryHalt returns the reason set with WithEntryHalt, false when entries
+// are not paused.
+func entryHalt(ctx context.Context) (string, bool) {
+	reason, ok := ctx.Value(entryHaltKey{}).(string)
+	return reason, ok && reason != ""
+}
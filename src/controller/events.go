@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// eventBus receives the events the controllers publish; tests replace it.
+var eventBus = events.Default
+
+// publishOrderPlaced publishes order of user as accepted by the exchange at
+// price, the order price when 0.
+func publishOrderPlaced(ctx context.Context, user *model.User, exchange string, order *model.Order, price float64) {
+	orderPrice := decimal.NewFromFloat(price)
+	if price == 0 && order.Price != nil {
+		orderPrice = *order.Price
+	}
+	eventBus.Publish(ctx, events.OrderPlaced{
+		UserID:     user.ID,
+		ExchangeID: order.ExchangeID,
+		Exchange:   exchange,
+		OrderID:    order.ID,
+		Symbol:     order.Symbol,
+		Side:       order.Side,
+		PosSide:    order.PosSide,
+		OrderDir:   order.OrderDir,
+		Quantity:   order.Quantity,
+		Price:      orderPrice,
+		PlacedAt:   clock().UTC(),
+	})
+}
+
+// publishOrderFilled publishes order of user as filled at price. side is
+// the side traded and posSide the position side, whatever convention the
+// order fields follow.
+func publishOrderFilled(ctx context.Context, user *model.User, exchange string, order *model.Order, side, posSide string, price float64) {
+	eventBus.Publish(ctx, events.OrderFilled{
+		UserID:     user.ID,
+		ExchangeID: order.ExchangeID,
+		Exchange:   exchange,
+		OrderID:    order.ID,
+		Symbol:     order.Symbol,
+		Side:       side,
+		PosSide:    posSide,
+		OrderDir:   order.OrderDir,
+		Quantity:   order.Quantity,
+		Price:      decimal.NewFromFloat(price),
+		FilledAt:   clock().UTC(),
+	})
+}
+
+// publishOrderFailed publishes order of user as failed with err.
+func publishOrderFailed(ctx context.Context, user *model.User, exchange string, order *model.Order, err error) {
+	eventBus.Publish(ctx, events.OrderFailed{
+		UserID:     user.ID,
+		ExchangeID: order.ExchangeID,
+		Exchange:   exchange,
+		OrderID:    order.ID,
+		Symbol:     order.Symbol,
+		Side:       order.Side,
+		PosSide:    order.PosSide,
+		OrderDir:   order.OrderDir,
+		Quantity:   order.Quantity,
+		Error:      err.Error(),
+		FailedAt:   clock().UTC(),
+	})
+}
+
+// publishSLMoved publishes the stop loss protecting order of user as moved
+// to stopLoss.
+func publishSLMoved(ctx context.Context, user *model.User, exchange string, order *model.Order, stopLoss decimal.Decimal) {
+	eventBus.Publish(ctx, events.SLMoved{
+		UserID:     user.ID,
+		ExchangeID: order.ExchangeID,
+		Exchange:   exchange,
+		OrderID:    order.ID,
+		Symbol:     order.Symbol,
+		PosSide:    order.PosSide,
+		StopLoss:   stopLoss,
+		MovedAt:    clock().UTC(),
+	})
+}
+
+// publishExceptionRaised publishes exc, for the user of ctx when there is
+// one.
+func publishExceptionRaised(ctx context.Context, exc *model.Exception, contextData map[string]interface{}) {
+	ev := events.ExceptionRaised{
+		Severity: exc.Severity,
+		Category: exc.Category,
+		Module:   exc.Module,
+		Method:   exc.Method,
+		Message:  exc.Message,
+		RaisedAt: exc.CreatedAt.UTC(),
+	}
+	if cu, ok := ctx.Value(captureUserKey{}).(captureUser); ok && cu.user != nil {
+		ev.UserID, ev.Exchange = cu.user.ID, cu.exchange
+	}
+	if symbol, ok := contextData["symbol"].(string); ok {
+		ev.Symbol = symbol
+	}
+	eventBus.Publish(ctx, ev)
+}
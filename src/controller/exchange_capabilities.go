@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type exchangeCapabilityRepository interface {
+	Supports(ctx context.Context, id uint, capability model.ExchangeCapability) (bool, error)
+}
+
+// newExchangeCapabilityRepo returns nil when the database is not
+// initialised, in which case every capability is assumed.
+var newExchangeCapabilityRepo = func() exchangeCapabilityRepository {
+	if database.MainDB == nil {
+		return nil
+	}
+	return repository.NewExchangeRepository()
+}
+
+// exchangeSupports reports whether the registry lets exchangeID use
+// capability. A registry that cannot be read does not gate anything, the
+// exchange call itself failing when the feature is missing.
+func exchangeSupports(ctx context.Context, exchangeID uint, capability model.ExchangeCapability) bool {
+	repo := newExchangeCapabilityRepo()
+	if repo == nil {
+		return true
+	}
+	ok, err := repo.Supports(ctx, exchangeID, capability)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).WithFields(map[string]interface{}{
+			"exchange_id": exchangeID,
+			"capability":  capability,
+		}).Warn("failed to read exchange capabilities, assuming supported")
+		return true
+	}
+	return ok
+}
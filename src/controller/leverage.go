@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"sync"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// leverageKey identifies the leverage setting of a symbol on an account.
+type leverageKey struct {
+	userID     uint
+	exchangeID uint
+	symbol     string
+}
+
+// leverageCache remembers the leverage last set per account and symbol, so
+// that entries only call the exchange when it changes. It lives as long as
+// the process: leverage changed by hand on the exchange is only set again
+// once the wanted value changes or after a restart.
+type leverageCache struct {
+	mu   sync.Mutex
+	last map[leverageKey]float64
+}
+
+var leverages = &leverageCache{last: map[leverageKey]float64{}}
+
+// entryLeverage is the leverage an entry wants: the one of the signal, else
+// the default of userExchange, capped by its MaxLeverage. nil leaves the
+// exchange setting alone.
+func entryLeverage(ctx context.Context, userExchange *model.UserExchange, signal externalmodel.TradingSignal) *float64 {
+	leverage := signal.Leverage
+	if leverage == nil && userExchange != nil && userExchange.Leverage.IsPositive() {
+		v := userExchange.Leverage.InexactFloat64()
+		leverage = &v
+	}
+	return capLeverage(ctx, userExchange, leverage)
+}
+
+// ensure calls set with leverage unless it is the value last set for key.
+// A failed call forgets the value, the exchange state being unknown.
+func (c *leverageCache) ensure(ctx context.Context, key leverageKey, leverage float64, set func(leverage float64) error) error {
+	c.mu.Lock()
+	last, ok := c.last[key]
+	c.mu.Unlock()
+	if ok && last == leverage {
+		return nil
+	}
+
+	if err := set(leverage); err != nil {
+		c.forget(key)
+		return fmt.Errorf("set leverage %v for %s: %w", leverage, key.symbol, err)
+	}
+
+	c.mu.Lock()
+	c.last[key] = leverage
+	c.mu.Unlock()
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"symbol":   key.symbol,
+		"leverage": leverage,
+		"previous": last,
+	}).Info("leverage set before entry")
+	return nil
+}
+
+func (c *leverageCache) forget(key leverageKey) {
+	c.mu.Lock()
+	delete(c.last, key)
+	c.mu.Unlock()
+}
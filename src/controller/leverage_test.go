@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestEntryLeverage(t *testing.T) {
+	ctx := context.Background()
+	f := func(v float64) *float64 { return &v }
+	account := &model.UserExchange{Leverage: decimal.NewFromInt(3), MaxLeverage: decimal.NewFromInt(10)}
+
+	for _, tc := range []struct {
+		name    string
+		account *model.UserExchange
+		signal  *float64
+		want    *float64
+	}{
+		{"nothing set", &model.UserExchange{}, nil, nil},
+		{"no account", nil, f(4), f(4)},
+		{"account default", account, nil, f(3)},
+		{"signal wins", account, f(7), f(7)},
+		{"capped", account, f(20), f(10)},
+	} {
+		got := entryLeverage(ctx, tc.account, externalmodel.TradingSignal{Leverage: tc.signal})
+		if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+			t.Fatalf("%s: leverage = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLeverageCacheSkipsUnchangedLeverage(t *testing.T) {
+	ctx := context.Background()
+	cache := &leverageCache{last: map[leverageKey]float64{}}
+	key := leverageKey{userID: 1, exchangeID: 2, symbol: "BTCUSDT"}
+	var calls []float64
+	set := func(v float64) error {
+		calls = append(calls, v)
+		return nil
+	}
+
+	for _, v := range []float64{5, 5, 3} {
+		if err := cache.ensure(ctx, key, v, set); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cache.ensure(ctx, leverageKey{userID: 1, exchangeID: 2, symbol: "ETHUSDT"}, 3, set); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 || calls[0] != 5 || calls[1] != 3 || calls[2] != 3 {
+		t.Fatalf("calls = %v", calls)
+	}
+
+	// A failed call leaves the exchange state unknown: set again next time.
+	if err := cache.ensure(ctx, key, 8, func(float64) error { return errors.New("rejected") }); err == nil {
+		t.Fatal("expected the exchange error")
+	}
+	calls = nil
+	if err := cache.ensure(ctx, key, 3, set); err != nil || len(calls) != 1 {
+		t.Fatalf("after a failure: err = %v, calls = %v", err, calls)
+	}
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/liquidity"
+	"strconv"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+var liquidityLimits = func() liquidity.Limits {
+	return liquidity.GetConfig().Limits()
+}
+
+// entryLiquidity checks the order book before a market entry of qty. It
+// returns the limit price to send the entry at instead of market, or the
+// reason to skip it. Both are empty when the entry may go at market, which is
+// also the case for connectors without order book depth.
+func entryLiquidity(ctx context.Context, client connectors.Connector, symbol, side string, qty float64) (limitPrice, skip string, err error) {
+	limits := liquidityLimits()
+	if !limits.Enabled() {
+		return "", "", nil
+	}
+	source, ok := client.(connectors.OrderBookSource)
+	if !ok {
+		logger.WithContext(ctx).WithField("symbol", symbol).Warn("connector has no order book, liquidity not checked")
+		return "", "", nil
+	}
+
+	book, err := source.OrderBook(symbol)
+	if err != nil {
+		return "", "", err
+	}
+	res, err := liquidity.Check(book, side, qty, limits)
+	if err != nil {
+		return "", "", err
+	}
+
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"symbol":       symbol,
+		"side":         side,
+		"qty":          qty,
+		"spread_bps":   res.SpreadBps,
+		"slippage_bps": res.SlippageBps,
+		"depth":        res.Depth,
+	})
+	if res.Allowed {
+		log.Debug("liquidity check passed")
+		return "", "", nil
+	}
+	if limits.Fallback == liquidity.FallbackLimit && res.LimitPrice > 0 {
+		limitPrice = strconv.FormatFloat(res.LimitPrice, 'f', -1, 64)
+		log.WithField("limit_price", limitPrice).Warn("thin order book, sending entry as limit: " + res.Reason)
+		return limitPrice, "", nil
+	}
+	log.Warn("thin order book, skipping entry: " + res.Reason)
+	return "", "liquidity: " + res.Reason, nil
+}
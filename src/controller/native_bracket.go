@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"strconv"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// attachNativeExits attaches the exits of signal to the entry intent when
+// the registry lets exchangeID hold them natively and the connector can send
+// them, and reports whether it did. The exchange then protects the position
+// from the fill on, whether or not the executor is up to place the exits.
+// Limit and passive entries keep the exits placed after the fill.
+func attachNativeExits(ctx context.Context, client connectors.Connector, ue *model.UserExchange, exchangeID uint, intent *model.OrderIntent, signal externalmodel.TradingSignal) bool {
+	if signal.StopLoss == nil && signal.TakeProfit == nil {
+		return false
+	}
+	if intent.Price != "" || intent.ReduceOnly || (ue != nil && ue.EntryMode == model.EntryModePassive) {
+		return false
+	}
+	if _, ok := client.(connectors.ClientBracketOrderPlacer); !ok {
+		return false
+	}
+	// Unlike the other capabilities, native exits are not assumed without
+	// the registry: the exits are placed after the fill as before.
+	if newExchangeCapabilityRepo() == nil || !exchangeSupports(ctx, exchangeID, model.CapabilityNativeBracket) {
+		return false
+	}
+
+	if signal.StopLoss != nil {
+		intent.StopLossPrice = strconv.FormatFloat(*signal.StopLoss, 'f', -1, 64)
+	}
+	if signal.TakeProfit != nil {
+		intent.TakeProfitPrice = strconv.FormatFloat(*signal.TakeProfit, 'f', -1, 64)
+	}
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"symbol":      intent.Symbol,
+		"stop_loss":   intent.StopLossPrice,
+		"take_profit": intent.TakeProfitPrice,
+	}).Info("exits attached to the entry")
+	return true
+}
+
+// storeNativeExitIDs stores on entry the IDs of the exits the exchange
+// created from the prices attached to intent once the entry filled, so the
+// stop watchdog can tell a stop-out as for the exits placed after the fill.
+// Connectors that cannot list orders are left alone; an exit not listed yet
+// is adopted by the watchdog later.
+func storeNativeExitIDs(ctx context.Context, client connectors.Connector, repo orderRepository, entry *model.Order, intent *model.OrderIntent) {
+	lister, ok := client.(connectors.ActiveOrderLister)
+	if !ok {
+		return
+	}
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"order_id": entry.ID,
+		"symbol":   entry.Symbol,
+	})
+	active, err := lister.ListActiveOrders(entry.Symbol)
+	if err != nil {
+		log.WithError(err).Warn("failed to list the exits attached to the entry")
+		return
+	}
+
+	side := "Long"
+	if strings.EqualFold(intent.Side, "Sell") {
+		side = "Short"
+	}
+	stopID, takeProfitID := "", ""
+	for _, o := range active {
+		if !o.Closes(intent.PosSide, side) {
+			continue
+		}
+		switch {
+		case o.IsStop() && stopID == "":
+			stopID = o.OrderID
+		case o.IsTakeProfit() && takeProfitID == "":
+			takeProfitID = o.OrderID
+		}
+	}
+	if stopID == "" && takeProfitID == "" {
+		log.Warn("exits attached to the entry not listed yet")
+		return
+	}
+	if err := repo.UpdateBracket(ctx, entry.ID, stopID, takeProfitID); err != nil {
+		log.WithError(err).Error("failed to store the protective order IDs")
+	}
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+)
+
+type notifier interface {
+	Notify(ctx context.Context, ev notify.Event)
+}
+
+var newNotifier = func() notifier {
+	return notify.NewNotifier()
+}
+
+// orderEvent builds a notification event of type t for order.
+func orderEvent(t notify.EventType, user *model.User, exchange string, order *model.Order) notify.Event {
+	ev := notify.Event{
+		Type:       t,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Exchange:   exchange,
+		OccurredAt: clock().UTC(),
+	}
+	if order != nil {
+		ev.OrderID = order.ID
+		ev.Symbol = order.Symbol
+		ev.Side = order.Side
+		ev.PosSide = order.PosSide
+		ev.Quantity = order.Quantity.InexactFloat64()
+		if order.Price != nil {
+			ev.Price = order.Price.InexactFloat64()
+		}
+		ev.StopLoss = order.StopLossPct.InexactFloat64()
+	}
+	return ev
+}
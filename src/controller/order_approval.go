@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+// approvalLinkConfig returns the secret and base URL of approval links.
+// Tests override it.
+var approvalLinkConfig = func() (secret, baseURL string) {
+	config := GetConfig()
+	return config.ApprovalSecret, config.PublicBaseURL
+}
+
+// needsApproval reports whether an entry of size at price is worth more than
+// the ApprovalNotional of userExchange.
+func needsApproval(userExchange *model.UserExchange, size decimal.Decimal, price float64) bool {
+	if userExchange == nil || !userExchange.ApprovalNotional.IsPositive() || price <= 0 {
+		return false
+	}
+	return size.Mul(decimal.NewFromFloat(price)).GreaterThan(userExchange.ApprovalNotional)
+}
+
+// approvalDeadline is when an entry created at createdAt stops waiting, zero
+// when it waits until decided.
+func approvalDeadline(userExchange *model.UserExchange, createdAt time.Time) time.Time {
+	if userExchange == nil || userExchange.ApprovalTimeoutMinutes <= 0 {
+		return time.Time{}
+	}
+	return createdAt.Add(time.Duration(userExchange.ApprovalTimeoutMinutes) * time.Minute)
+}
+
+// approvalTimeoutStatus is the status an entry moves to once its approval
+// deadline passed.
+func approvalTimeoutStatus(userExchange *model.UserExchange) string {
+	if userExchange != nil && userExchange.ApprovalTimeoutAction == model.ApprovalTimeoutApprove {
+		return model.OrderExecutionStatusApproved
+	}
+	return model.OrderExecutionStatusRejected
+}
+
+// expireApproval applies the timeout action of userExchange to order when its
+// deadline passed and returns the resulting status.
+func expireApproval(ctx context.Context, orders orderRepository, userExchange *model.UserExchange, order *model.Order, now time.Time) (string, error) {
+	deadline := approvalDeadline(userExchange, order.CreatedAt)
+	if deadline.IsZero() || now.Before(deadline) {
+		return order.Status, nil
+	}
+
+	status := approvalTimeoutStatus(userExchange)
+	resolved, err := orders.ResolveApproval(ctx, order.ID, status, "approval timed out")
+	if err != nil {
+		return "", err
+	}
+	if !resolved {
+		// decided meanwhile, the next run picks the decision up
+		return order.Status, nil
+	}
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"order_id": order.ID,
+		"status":   status,
+	}).Info("order approval timed out")
+	return status, nil
+}
+
+// requestApproval records order as awaiting approval instead of placing it
+// and sends the user the links deciding it.
+func requestApproval(
+	ctx context.Context,
+	orders orderRepository,
+	notifier notifier,
+	user *model.User,
+	userExchange *model.UserExchange,
+	exchange string,
+	order *model.Order,
+	reason string,
+) error {
+	order.Status = model.OrderExecutionStatusAwaitingApproval
+	applyStagger(ctx, order)
+	if err := orders.CreateWithAutoLogReason(ctx, order, reason); err != nil {
+		logger.WithContext(ctx).WithError(err).Error("failed to record order awaiting approval")
+		return err
+	}
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"order_id": order.ID,
+		"quantity": order.Quantity,
+		"limit":    userExchange.ApprovalNotional,
+	}).Info("entry above the approval notional, waiting for approval")
+
+	ev := orderEvent(notify.EventApprovalRequired, user, exchange, order)
+	if deadline := approvalDeadline(userExchange, clock()); !deadline.IsZero() {
+		action := "rejected"
+		if approvalTimeoutStatus(userExchange) == model.OrderExecutionStatusApproved {
+			action = "placed"
+		}
+		ev.Message = fmt.Sprintf("%s at %s without an answer", action, deadline.UTC().Format("2006-01-02 15:04 UTC"))
+	}
+	ev.ApproveURL, ev.RejectURL = approvalLinks(order.ID)
+	notifier.Notify(ctx, ev)
+	return nil
+}
+
+// approvalLinks returns the signed links deciding orderID, empty when links
+// are not configured.
+func approvalLinks(orderID uint) (approve, reject string) {
+	secret, baseURL := approvalLinkConfig()
+	if secret == "" || baseURL == "" {
+		return "", ""
+	}
+	link := func(decision string) string {
+		return fmt.Sprintf("%s/approvals/%d/%s?sig=%s",
+			strings.TrimRight(baseURL, "/"), orderID, decision, auth.ApprovalSignature(secret, orderID, decision))
+	}
+	return link(auth.ApprovalApprove), link(auth.ApprovalReject)
+}
@@ -8,6 +8,7 @@ import (
 	"strategyexecutor/src/model"
 	"strategyexecutor/src/repository"
 	"strategyexecutor/src/risk"
+	"strategyexecutor/src/tracing"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -24,11 +25,14 @@ func OrderControllerHydra(
 	targetExchange string, // hydra
 	userExchange *model.UserExchange,
 ) error {
+	ctx, span := tracing.Start(ctx, "controller.hydra")
+	defer span.End()
+	ctx = withCaptureUser(ctx, user, targetExchange)
 	config := connectors.GetConfig()
 	instrumentID := config.HydraInstrumentID
 	hydraSymbol := config.HydraSymbol
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	tradingSignalRepo := repository.NewTradingSignalRepository()
@@ -39,7 +43,7 @@ func OrderControllerHydra(
 	// ------------------------------------------------------------------
 	// 1) Fetch the latest TradingSignal (from read-only DB)
 	// ------------------------------------------------------------------
-	signals, err := tradingSignalRepo.FindLatest(ctx, targetSymbol, targetExchange, 1)
+	signals, err := latestSignals(ctx, tradingSignalRepo, targetSymbol, targetExchange)
 	if err != nil {
 		logger.WithError(err).Error("hydra - failed to fetch latest trading signal")
 		Capture(
@@ -102,6 +106,13 @@ func OrderControllerHydra(
 		cfg,
 	)
 
+	if session != risk.SessionNoTrade {
+		if reason, halted := entryHalt(ctx); halted {
+			logger.WithField("reason", reason).Warn("hydra - new entries halted, skipping")
+			return nil
+		}
+	}
+
 	logger.
 		WithField("session", session).
 		WithField("finalSize", finalSize).
@@ -115,12 +126,13 @@ func OrderControllerHydra(
 		Side:       FirstLetterUpper(signal.Action),  // buy/sell
 		PosSide:    FirstLetterUpper(signal.OrderID), //Short/Long
 		OrderType:  "market",
-		Quantity:   finalSize.InexactFloat64(), //
+		Quantity:   finalSize, //
 		Status:     model.OrderExecutionStatusPending,
 		OrderDir:   model.OrderDirectionEntry,
 	}
 
 	if session != risk.SessionNoTrade {
+		applyStagger(ctx, newOrder)
 		if err := orderRepo.CreateWithAutoLog(ctx, newOrder); err != nil {
 			logger.WithError(err).Error("hydra - failed to create order with auto log")
 			return err
@@ -146,7 +158,7 @@ func OrderControllerHydra(
 		)
 		return fmt.Errorf("hydra - expected session cookie to be set after login")
 	}
-	logger.Errorf("hydra - Got session cookie: %s", c.SessionCookie.Value)
+	logger.WithField("session_cookie", c.SessionCookie.Value).Debug("hydra - Got session cookie")
 
 	// 3. Fetch CSRF
 	if err := c.FetchCSRF(ctx); err != nil {
@@ -161,7 +173,7 @@ func OrderControllerHydra(
 		)
 		return fmt.Errorf("hydra - expected non empty CSRF token")
 	}
-	logger.Infof("CSRF: %s", c.CSRFTok)
+	logger.WithField("csrf_token", c.CSRFTok).Debug("hydra - Got CSRF token")
 
 	if c.DxtfidCookie == nil {
 		_ = orderRepo.UpdateStatusWithAutoLog(
@@ -172,7 +184,7 @@ func OrderControllerHydra(
 		)
 		return fmt.Errorf("hydra - expected Dxtfid cookie to be set after login")
 	}
-	logger.Infof("hydra - Got Dxtfid cookie: %s", c.DxtfidCookie.Value)
+	logger.WithField("dxtfid_cookie", c.DxtfidCookie.Value).Debug("hydra - Got Dxtfid cookie")
 
 	if err := c.InitAtmosphereTrackingID(ctx); err != nil {
 		_ = orderRepo.UpdateStatusWithAutoLog(
@@ -226,7 +238,7 @@ func OrderControllerHydra(
 		qty = -qty
 	}
 
-	stoploss := connectors.CalcStopLoss(*signal.Price, config.HydraSLPercent, signal.Action)
+	stoploss := connectors.CalcStopLoss(*signal.Price, stopLossPercent(userExchange, config.HydraSLPercent), signal.Action)
 	offset := math.Abs(*signal.Price - stoploss)
 
 	resp, status, err := c.PlaceMarketOrder(
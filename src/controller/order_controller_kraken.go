@@ -7,8 +7,10 @@ import (
 	"math"
 	"strategyexecutor/src/connectors"
 	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
 	"strategyexecutor/src/repository"
 	"strategyexecutor/src/risk"
+	"strategyexecutor/src/tracing"
 	"strings"
 	"time"
 
@@ -34,6 +36,9 @@ func OrderControllerKrakenFutures(
 	targetExchange string, // kraken
 	userExchange *model.UserExchange,
 ) error {
+	ctx, span := tracing.Start(ctx, "controller.kraken")
+	defer span.End()
+	ctx = withCaptureUser(ctx, user, targetExchange)
 	config := connectors.GetConfig()
 	krakenSymbol := config.KrakenSymbol
 
@@ -44,13 +49,14 @@ func OrderControllerKrakenFutures(
 	exceptionRepo := repository.NewExceptionRepository()
 	orderRepo := repository.NewOrderRepository()
 	userExchangeRep := repository.NewUserExchangeRepository()
+	notifier := newNotifier()
 
 	//orderSizePercent := userExchange.OrderSizePercent
 
 	// ------------------------------------------------------------------
 	// 1) Fetch latest TradingSignal
 	// ------------------------------------------------------------------
-	signals, err := tradingSignalRepo.FindLatest(ctx, targetSymbol, targetExchange, 1)
+	signals, err := latestSignals(ctx, tradingSignalRepo, targetSymbol, targetExchange)
 	if err != nil {
 		logger.WithError(err).Error("kraken - failed to fetch latest trading signal")
 		Capture(
@@ -113,6 +119,9 @@ func OrderControllerKrakenFutures(
 
 	if session == risk.SessionNoTrade {
 		logger.Warn(risk.SessionNoTrade + " - risk off mode")
+	} else if reason, halted := entryHalt(ctx); halted {
+		logger.WithField("reason", reason).Warn("kraken - new entries halted, skipping")
+		return nil
 	}
 
 	logger.
@@ -128,12 +137,13 @@ func OrderControllerKrakenFutures(
 		Side:       FirstLetterUpper(desiredSide),    // Buy/Sell
 		PosSide:    FirstLetterUpper(desiredPosSide), // Long/Short
 		OrderType:  "market",
-		Quantity:   finalSize.InexactFloat64(), // add to config
+		Quantity:   finalSize, // add to config
 		Status:     model.OrderExecutionStatusPending,
 		OrderDir:   model.OrderDirectionEntry,
 	}
 
 	if session != risk.SessionNoTrade {
+		applyStagger(ctx, newOrder)
 		if err := orderRepo.CreateWithAutoLog(ctx, newOrder); err != nil {
 			logger.WithError(err).Error("kraken - failed to create order with auto log")
 			return err
@@ -142,10 +152,17 @@ func OrderControllerKrakenFutures(
 
 	fail := func(msg string, e error) error {
 		_ = orderRepo.UpdateStatusWithAutoLog(ctx, newOrder.ID, model.OrderExecutionStatusError, msg)
+		err := fmt.Errorf("%s", msg)
 		if e != nil {
-			return fmt.Errorf("%s: %w", msg, e)
+			err = fmt.Errorf("%s: %w", msg, e)
 		}
-		return fmt.Errorf("%s", msg)
+
+		errEvent := orderEvent(notify.EventOrderError, user, targetExchange, newOrder)
+		errEvent.Err = err
+		notifier.Notify(ctx, errEvent)
+		publishOrderFailed(ctx, user, targetExchange, newOrder, err)
+
+		return err
 	}
 
 	// 4) Pre-clean: cancel orders, close positions, verify flat
@@ -154,6 +171,8 @@ func OrderControllerKrakenFutures(
 	}
 
 	if err := c.CloseAllPositions(krakenSymbol); err != nil {
+		err = fmt.Errorf("%w: %w", errClosePosition, err)
+		Capture(ctx, exceptionRepo, "OrderControllerKrakenFutures", "controller", "CloseAllPositions", "error", err, map[string]interface{}{"symbol": krakenSymbol})
 		return fail("CloseAllPositions failed", err)
 	}
 
@@ -180,11 +199,24 @@ func OrderControllerKrakenFutures(
 				Error("failed to mark risk off orders closed")
 			return err
 		}
+
+		killEvent := orderEvent(notify.EventKillSwitch, user, targetExchange, nil)
+		killEvent.Symbol = newOrder.Symbol
+		killEvent.Message = "no trade window, all positions closed"
+		notifier.Notify(ctx, killEvent)
+
 		return nil
 	}
 	// ------------------------------------------------------------------
 	// 6) Place market order
 	// ------------------------------------------------------------------
+	if leverage := entryLeverage(ctx, userExchange, signal); leverage != nil {
+		key := leverageKey{user.ID, exchangeID, krakenSymbol}
+		if err := leverages.ensure(ctx, key, *leverage, func(v float64) error { return c.SetLeveragePreference(krakenSymbol, v) }); err != nil {
+			return fail("kraken - failed to set leverage", err)
+		}
+	}
+
 	cliOrdID := fmt.Sprintf("go-%d", time.Now().UnixNano())
 	reduceOnly := false
 
@@ -213,6 +245,8 @@ func OrderControllerKrakenFutures(
 		"serverTime": sendResp.ServerTime,
 	}).Info("kraken - market order sent")
 
+	publishOrderPlaced(ctx, user, targetExchange, newOrder, 0)
+
 	// ------------------------------------------------------------------
 	// 7) Verify by openpositions that we have a position in the desired direction
 	// ------------------------------------------------------------------
@@ -254,7 +288,7 @@ func OrderControllerKrakenFutures(
 		return fail("kraken - cannot compute stop loss, entry price is invalid", nil)
 	}
 
-	stopPrice := math.Round(connectors.CalcStopLoss(entryPrice, config.KrakenSLPercent, desiredSide))
+	stopPrice := math.Round(connectors.CalcStopLoss(entryPrice, stopLossPercent(userExchange, config.KrakenSLPercent), desiredSide))
 
 	stopSide := oppositeOrderSide(desiredSide) // to close long: sell. to close short: buy
 	stopReduceOnly := true
@@ -321,6 +355,9 @@ func OrderControllerKrakenFutures(
 	}
 
 	logger.WithField("order_id", newOrder.ID).Info("kraken - order successfully completed")
+
+	publishOrderFilled(ctx, user, targetExchange, newOrder, newOrder.Side, newOrder.PosSide, entryPrice)
+
 	return nil
 }
 
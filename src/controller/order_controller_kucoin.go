@@ -3,12 +3,15 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/shopspring/decimal"
 	logger "github.com/sirupsen/logrus"
+	"math"
 	"strategyexecutor/src/mapper"
 	"strategyexecutor/src/model"
 	"strategyexecutor/src/repository"
 	"strategyexecutor/src/risk"
+	"strategyexecutor/src/tracing"
 	"strings"
 	"time"
 )
@@ -22,6 +25,7 @@ type kucoinFuturesClient interface {
 	ConvertUSDTToContracts(symbol string, usdt float64, leverage int) (size int64, usdtUsed float64, err error)
 	CloseAllPositions(symbol string) error
 	ExecuteFuturesOrderLeverage(symbol string, side string, orderType string, size int64, price *float64, leverage int, reduceOnly bool) (map[string]interface{}, error)
+	SetFuturesLeverage(symbol string, leverage int) error
 	GetFuturesAvailableFromRiskUnit(symbol string) (float64, error)
 }
 
@@ -40,7 +44,11 @@ func OrderControllerKucoin(
 	exchangeID uint,
 	targetSymbol string, // BTCUSD
 	targetExchange string,
+	userExchange *model.UserExchange,
 ) error {
+	ctx, span := tracing.Start(ctx, "controller.kucoin")
+	defer span.End()
+	ctx = withCaptureUser(ctx, user, targetExchange)
 
 	logger.Debugf("OrderControllerKucoin INITIALIZED ")
 	logger.Info("starting kucoin order controller flow")
@@ -50,7 +58,7 @@ func OrderControllerKucoin(
 	exceptionRepo := newExceptionRepo()
 	orderRepo := newOrderRepo()
 
-	signals, err := tradingSignalRepo.FindLatest(ctx, targetSymbol, targetExchange, 1)
+	signals, err := latestSignals(ctx, tradingSignalRepo, targetSymbol, targetExchange)
 	if err != nil {
 		Capture(ctx, exceptionRepo, "OrderControllerKucoin", "controller", "tradingSignalRepo.FindLatest", "error", err, map[string]interface{}{})
 		return err
@@ -113,6 +121,7 @@ func OrderControllerKucoin(
 		return err
 	}
 
+	orderPrice := decimal.NewFromFloat(price)
 	newOrder := &model.Order{
 		UserID:     user.ID,
 		ExchangeID: exchangeID,
@@ -121,20 +130,33 @@ func OrderControllerKucoin(
 		Side:       strings.ToLower(signal.Action),
 		PosSide:    FirstLetterUpper(signal.OrderID),
 		OrderType:  "market",
-		Quantity:   float64(contracts),
-		Price:      &price,
+		Quantity:   decimal.NewFromInt(int64(contracts)),
+		Price:      &orderPrice,
 		Status:     model.OrderExecutionStatusPending,
 	}
 
+	applyStagger(ctx, newOrder)
 	if err := orderRepo.CreateWithAutoLog(ctx, newOrder); err != nil {
 		return err
 	}
 
 	if err := kucoinClient.CloseAllPositions(newOrder.Symbol); err != nil {
+		Capture(ctx, exceptionRepo, "OrderControllerKucoin", "controller", "CloseAllPositions", "error", fmt.Errorf("%w: %w", errClosePosition, err), map[string]interface{}{"symbol": newOrder.Symbol})
 		_ = orderRepo.UpdateStatusWithAutoLog(ctx, newOrder.ID, model.OrderExecutionStatusError, "failed to close existing positions on kucoin")
 		return err
 	}
 
+	// KuCoin leverage is a whole number.
+	if leverage := entryLeverage(ctx, userExchange, signal); leverage != nil {
+		key := leverageKey{user.ID, exchangeID, newOrder.Symbol}
+		whole := math.Max(1, math.Round(*leverage))
+		if err := leverages.ensure(ctx, key, whole, func(v float64) error { return kucoinClient.SetFuturesLeverage(newOrder.Symbol, int(v)) }); err != nil {
+			logger.WithError(err).Errorf("failed to set kucoin leverage for symbol %s", symbol)
+			_ = orderRepo.UpdateStatusWithAutoLog(ctx, newOrder.ID, model.OrderExecutionStatusError, "failed to set kucoin leverage")
+			return err
+		}
+	}
+
 	resp, err := kucoinClient.ExecuteFuturesOrderLeverage(newOrder.Symbol, newOrder.Side, "market", contracts, nil, 0, false)
 	if err != nil {
 		logger.WithError(err).Errorf("failed to place kucoin futures order for symbol %s", symbol)
@@ -161,9 +183,8 @@ func OrderControllerKucoin(
 		return err
 	}
 
-	if mapped != nil && mapped.Price > 0 {
-		priceCopy := mapped.Price
-		_ = orderRepo.UpdatePriceAutoLog(ctx, newOrder.ID, &priceCopy, "update to price kucoin order")
+	if execPrice := mapper.ParseDecimalSafe("price", payload.Price); execPrice.IsPositive() {
+		_ = orderRepo.UpdatePriceAutoLog(ctx, newOrder.ID, &execPrice, "update to price kucoin order")
 	}
 
 	if err := kucoinRepo.Create(ctx, mapped); err != nil {
@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/logging"
 	"strategyexecutor/src/mapper"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/report"
 	"strategyexecutor/src/risk"
+	"strategyexecutor/src/signalfilter"
+	"strategyexecutor/src/strategy"
 	"strategyexecutor/src/tp_sl"
-	"strconv"
 	"strings"
 	"time"
 
@@ -16,8 +20,10 @@ import (
 	logger "github.com/sirupsen/logrus"
 
 	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/featureflag"
 	"strategyexecutor/src/model"
 	"strategyexecutor/src/repository"
+	"strategyexecutor/src/tracing"
 )
 
 type tradingSignalRepository interface {
@@ -26,6 +32,7 @@ type tradingSignalRepository interface {
 
 type phemexOrderRepository interface {
 	Create(ctx context.Context, order *model.PhemexOrder) error
+	UpdateFee(ctx context.Context, exchangeOrderID string, fee float64, currency string) error
 }
 
 type exceptionRepository interface {
@@ -35,14 +42,25 @@ type exceptionRepository interface {
 type orderRepository interface {
 	FindByExternalIDAndUserID(ctx context.Context, userID uint, externalID uint, orderDir string) (*model.Order, error)
 	CreateWithAutoLog(ctx context.Context, order *model.Order) error
+	CreateWithAutoLogReason(ctx context.Context, order *model.Order, reason string) error
+	CreateWithIntent(ctx context.Context, order *model.Order, intent *model.OrderIntent, reason string) error
 	UpdateStatusWithAutoLog(ctx context.Context, orderID uint, newStatus string, reason string) error
-	UpdatePriceAutoLog(ctx context.Context, orderID uint, price *float64, reason string) error
-	UpdateStopLoss(ctx context.Context, orderID uint, stopLoss float64) error
+	UpdatePriceAutoLog(ctx context.Context, orderID uint, price *decimal.Decimal, reason string) error
+	UpdateStopLoss(ctx context.Context, orderID uint, stopLoss decimal.Decimal) error
+	UpdateBracket(ctx context.Context, orderID uint, stopOrderID string, takeProfitOrderID string) error
+	UpdateFee(ctx context.Context, orderID uint, fee float64, currency string, estimated bool) error
 	FindByExchangeIDAndUserID(ctx context.Context, userID uint, exchangeID uint) (*model.Order, error)
+	ResolveApproval(ctx context.Context, orderID uint, newStatus string, reason string) (bool, error)
+}
+
+type newsSentimentRepository interface {
+	NetSentimentForSymbol(ctx context.Context, symbol string, window time.Duration, now time.Time) (float64, int, error)
 }
 
 type ohlcvRepository interface {
 	GetNextStopLoss(ctx context.Context, symbol string, now time.Time, side tp_sl.Side, currentSL decimal.Decimal, timeframe time.Duration, floor int) (decimal.Decimal, bool, error)
+	GetSwingStopLoss(ctx context.Context, symbol string, now time.Time, side tp_sl.Side, timeframe time.Duration, strength int) (decimal.Decimal, bool, error)
+	GetNextSwingStopLoss(ctx context.Context, symbol string, now time.Time, side tp_sl.Side, currentSL decimal.Decimal, timeframe time.Duration, strength int) (decimal.Decimal, bool, error)
 }
 
 var (
@@ -61,8 +79,23 @@ var (
 	newOHLCVRepo = func() ohlcvRepository {
 		return repository.NewOHLCVRepositoryRepository()
 	}
+	newNewsSentimentRepo = func() newsSentimentRepository {
+		return repository.NewTradingViewRepository()
+	}
 )
 
+// clock is the time source of the Phemex flow. Backtests replace it through
+// SetClock to replay historical candles.
+var clock = time.Now
+
+// SetClock overrides the controller clock and returns a function restoring
+// the previous one. It is not safe to call while controllers are running.
+func SetClock(now func() time.Time) (restore func()) {
+	previous := clock
+	clock = now
+	return func() { clock = previous }
+}
+
 func FirstLetterUpper(s string) string {
 	if len(s) == 0 {
 		return s
@@ -73,32 +106,52 @@ func FirstLetterUpper(s string) string {
 // OrderController executes the main trading flow based on the latest trading signal.
 func OrderController(
 	ctx context.Context,
-	phemexClient *connectors.Client,
+	phemexClient connectors.Connector,
 	user *model.User,
 	exchangeID uint,
 	targetSymbol string, // BTCUSD
 	targetExchange string, // phemex
 	userExchange *model.UserExchange,
 ) error {
+	ctx, span := tracing.Start(ctx, "controller.phemex")
+	defer span.End()
+	ctx = withCaptureUser(ctx, user, targetExchange)
 
-	logger.Debugf("OrderController INITIALIZED ")
-	logger.Info("starting order controller flow")
+	logger.WithContext(ctx).Debugf("OrderController INITIALIZED ")
+	logger.WithContext(ctx).Info("starting order controller flow")
 
-	tradingSignalRepo := repository.NewTradingSignalRepository()
-	phemexRepo := repository.NewPhemexOrderRepository()
-	exceptionRepo := repository.NewExceptionRepository()
-	orderRepo := repository.NewOrderRepository()
-	ohlcvRepo := repository.NewOHLCVRepositoryRepository()
+	tradingSignalRepo := newTradingSignalRepo()
+	phemexRepo := newPhemexOrderRepo()
+	exceptionRepo := newExceptionRepo()
+	orderRepo := newOrderRepo()
+	ohlcvRepo := newOHLCVRepo()
 	userExchangeRep := repository.NewUserExchangeRepository()
+	notifier := newNotifier()
 
 	orderSizePercent := userExchange.OrderSizePercent
+	hedged := hedgeMode(userExchange) && exchangeSupports(ctx, exchangeID, model.CapabilityHedge)
+
+	if err := resolveOutbox(ctx, phemexClient, orderRepo, user.ID, exchangeID); err != nil {
+		logger.WithContext(ctx).WithError(err).Error("failed to settle interrupted exchange calls")
+		Capture(
+			ctx,
+			exceptionRepo,
+			"OrderController",
+			"controller",
+			"resolveOutbox",
+			"error",
+			err,
+			map[string]interface{}{},
+		)
+		return err
+	}
 
 	// ------------------------------------------------------------------
 	// 1) Fetch the latest TradingSignal (from read-only DB)
 	// ------------------------------------------------------------------
-	signals, err := tradingSignalRepo.FindLatest(ctx, targetSymbol, targetExchange, 1)
+	signals, err := latestSignals(ctx, tradingSignalRepo, targetSymbol, targetExchange)
 	if err != nil {
-		logger.WithError(err).Error("failed to fetch latest trading signal")
+		logger.WithContext(ctx).WithError(err).Error("failed to fetch latest trading signal")
 		Capture(
 			ctx,
 			exceptionRepo,
@@ -112,13 +165,13 @@ func OrderController(
 		return err
 	}
 	if len(signals) == 0 {
-		logger.Warn("no trading signals found")
+		logger.WithContext(ctx).Warn("no trading signals found")
 		return nil
 	}
 
 	signal := signals[0]
 	symbol := NormalizeToUSDT(signal.Symbol)
-	logger.WithFields(map[string]interface{}{
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
 		"user":          user.Username,
 		"signal_id":     signal.ID,
 		"signal.Symbol": signal.Symbol,
@@ -132,7 +185,7 @@ func OrderController(
 
 	existingOrder, err := orderRepo.FindByExternalIDAndUserID(ctx, user.ID, signal.ID, model.OrderDirectionEntry)
 	if err != nil {
-		logger.WithError(err).Error("failed to fetch latest trading signal")
+		logger.WithContext(ctx).WithError(err).Error("failed to fetch latest trading signal")
 		Capture(
 			ctx,
 			exceptionRepo,
@@ -143,36 +196,58 @@ func OrderController(
 			err,
 			map[string]interface{}{},
 		)
-		logger.WithError(err).Error("failed to search for existing order")
+		logger.WithContext(ctx).WithError(err).Error("failed to search for existing order")
 		return err
 	}
 
 	if existingOrder != nil {
-		logger.WithField("order_id", existingOrder.ID).
+		logger.WithContext(ctx).WithField("order_id", existingOrder.ID).
 			Info("order already exists for this signal, checking status")
 
 		if existingOrder.Status == model.OrderExecutionStatusFilled {
+			if !featureflag.Enabled(ctx, featureflag.TrailingStopLoss, user.ID) {
+				logger.WithContext(ctx).WithField("order_id", existingOrder.ID).
+					Info("trailing SL disabled by feature flag, nothing to do")
+				return nil
+			}
+			if trailStopsInService() {
+				logger.WithContext(ctx).WithField("order_id", existingOrder.ID).
+					Info("trailing SL handled by the trailing stop service, nothing to do")
+				return nil
+			}
 
 			// check if we can raise the SL
-			logger.WithField("order_id", existingOrder.ID).
+			logger.WithContext(ctx).WithField("order_id", existingOrder.ID).
 				Info("order already filled, will check if we can raise the SL")
 
-			side := tp_sl.SideLong
-			if existingOrder.PosSide == "Short" {
-				side = tp_sl.SideShort
-			}
+			side := stopSide(existingOrder.PosSide)
+			currentSL := existingOrder.StopLossPct
 
-			newSL, isRaised, err := ohlcvRepo.GetNextStopLoss(
-				ctx,
-				existingOrder.Symbol,
-				time.Now(),
-				side,
-				decimal.NewFromFloat(existingOrder.StopLossPct),
-				15*time.Minute, // compute SL on 15m structure
-				45,             // floor average over last 45 bars
-			)
+			var newSL decimal.Decimal
+			var isRaised bool
+			if stopMode(userExchange) == tp_sl.StopModeSwing {
+				newSL, isRaised, err = ohlcvRepo.GetNextSwingStopLoss(
+					ctx,
+					existingOrder.Symbol,
+					clock(),
+					side,
+					currentSL,
+					stopTimeframe,
+					tp_sl.DefaultSwingStrength,
+				)
+			} else {
+				newSL, isRaised, err = ohlcvRepo.GetNextStopLoss(
+					ctx,
+					existingOrder.Symbol,
+					clock(),
+					side,
+					currentSL,
+					stopTimeframe,
+					floorLookback,
+				)
+			}
 			if err != nil {
-				logger.WithError(err).Error("failed to GetNextStopLoss")
+				logger.WithContext(ctx).WithError(err).Error("failed to GetNextStopLoss")
 				return err
 			}
 
@@ -184,50 +259,204 @@ func OrderController(
 				return nil
 			}
 
-			_, err = phemexClient.SetStopLossForOpenPosition(
-				"BTCUSDT",
-				"Long",
-				newSL.String(),
-				connectors.TriggerByMarkPrice,
-				true)
-			if err != nil {
-				logger.WithError(err).Error("failed to SetStopLossForOpenPosition")
-				return err
-			}
+			return moveStop(ctx, phemexClient, orderRepo, user, targetExchange, hedged, existingOrder, newSL)
+		}
+
+		if existingOrder.Status == model.OrderExecutionStatusFiltered {
+			logger.WithContext(ctx).WithField("order_id", existingOrder.ID).
+				Info("signal already blocked by signal filters, nothing to do")
+			return nil
+		}
 
-			err = orderRepo.UpdateStopLoss(ctx, existingOrder.ID, newSL.InexactFloat64())
+		if existingOrder.Status == model.OrderExecutionStatusAwaitingApproval {
+			existingOrder.Status, err = expireApproval(ctx, orderRepo, userExchange, existingOrder, clock())
 			if err != nil {
-				logger.WithError(err).Error("failed to UpdateStopLoss")
+				logger.WithContext(ctx).WithError(err).Error("failed to expire order approval")
 				return err
 			}
+		}
+		switch existingOrder.Status {
+		case model.OrderExecutionStatusAwaitingApproval:
+			logger.WithContext(ctx).WithField("order_id", existingOrder.ID).
+				Info("order awaiting approval, nothing to do")
+			return nil
+		case model.OrderExecutionStatusRejected:
+			logger.WithContext(ctx).WithField("order_id", existingOrder.ID).
+				Info("order rejected, nothing to do")
+			return nil
+		case model.OrderExecutionStatusLiquidated:
+			logger.WithContext(ctx).WithField("order_id", existingOrder.ID).
+				Info("position liquidated on the exchange, nothing to do")
+			return nil
+		}
 
-			// update SL
+	}
 
-			return nil
+	if existingOrder == nil && signal.Expired(clock()) {
+		logger.WithContext(ctx).WithField("valid_until", signal.ValidUntil).Info("signal expired, skipping")
+		return skipSignal(ctx, orderRepo, user.ID, exchangeID, signal.ID, symbol, "signal expired")
+	}
+
+	if existingOrder == nil {
+		duplicateOf, err := duplicateSignal(ctx, user.ID, exchangeID, symbol, signal)
+		if err != nil {
+			logger.WithContext(ctx).WithError(err).Error("failed to check for duplicate signal")
+			Capture(
+				ctx,
+				exceptionRepo,
+				"OrderController",
+				"controller",
+				"duplicateSignal",
+				"error",
+				err,
+				map[string]interface{}{"symbol": symbol},
+			)
+			return err
+		}
+		if duplicateOf != 0 {
+			return skipSignal(ctx, orderRepo, user.ID, exchangeID, signal.ID, symbol, fmt.Sprintf("duplicate of signal %d", duplicateOf))
+		}
+	}
+
+	// ------------------------------------------------------------------
+	// Ask the assigned strategy what to do with the signal
+	// ------------------------------------------------------------------
+	strategyRepo := newStrategyRepo()
+	strat, assignment, err := resolveStrategy(ctx, strategyRepo, user.ID, exchangeID, symbol)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).Error("failed to resolve strategy")
+		Capture(
+			ctx,
+			exceptionRepo,
+			"OrderController",
+			"controller",
+			"resolveStrategy",
+			"error",
+			err,
+			map[string]interface{}{"symbol": symbol},
+		)
+		return err
+	}
+	if !signalMatchesStrategy(signal, strat.Name()) {
+		logger.WithContext(ctx).WithFields(map[string]interface{}{
+			"strategy":     strat.Name(),
+			"strategy_tag": signal.StrategyTag,
+		}).Info("signal tagged for another strategy, skipping")
+		return nil
+	}
+	strategyCtx := strategy.Context{UserID: user.ID, ExchangeID: exchangeID, Symbol: symbol}
+
+	decision, err := strat.OnSignal(ctx, strategyCtx, signal)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).WithField("strategy", strat.Name()).Error("strategy OnSignal failed")
+		return err
+	}
+	recordStrategyAction(ctx, strategyRepo, assignment, strategy.HookSignal, decision, signal.ID, nil)
+
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"strategy": strat.Name(),
+		"action":   decision.Action,
+		"side":     decision.Side,
+		"posSide":  decision.PosSide,
+		"reason":   decision.Reason,
+	}).Info("strategy decision")
+
+	switch decision.Action {
+	case strategy.ActionNone:
+		return nil
+	case strategy.ActionClose:
+		if err := closeAllPositions(ctx, phemexClient, user, exchangeID, signal.ID, symbol); err != nil {
+			logger.WithContext(ctx).WithError(err).WithField("symbol", symbol).Error("failed to close all positions")
+			Capture(ctx, exceptionRepo, "OrderController", "controller", "closeAllPositions", "error", err, map[string]interface{}{"symbol": symbol})
+			return err
 		}
+		return nil
+	}
 
+	// ------------------------------------------------------------------
+	// Pre-trade filter chain; a denied entry is recorded as a filtered order
+	// ------------------------------------------------------------------
+	filterOutcome := evaluateSignalFilters(ctx, phemexClient, signalfilter.Entry{
+		UserID:     user.ID,
+		ExchangeID: exchangeID,
+		Symbol:     symbol,
+		Exchange:   targetExchange,
+		Side:       decision.Side,
+		PosSide:    decision.PosSide,
+		Now:        clock(),
+		SignalAt:   signalTime(signal),
+	})
+	recordFiltered := func(reason string) error {
+		filteredOrder := &model.Order{
+			UserID:     user.ID,
+			ExchangeID: exchangeID,
+			ExternalID: signal.ID,
+			Symbol:     symbol,
+			Side:       decision.Side,
+			PosSide:    decision.PosSide,
+			OrderType:  "market",
+			Status:     model.OrderExecutionStatusFiltered,
+			OrderDir:   model.OrderDirectionEntry,
+			StrategyID: strategyID(assignment),
+		}
+		applyStagger(ctx, filteredOrder)
+		if err := orderRepo.CreateWithAutoLogReason(ctx, filteredOrder, reason); err != nil {
+			logger.WithContext(ctx).WithError(err).Error("failed to record filtered order")
+			return err
+		}
+		return nil
+	}
+	if filterOutcome.Postponed() {
+		// nothing is recorded so the signal is evaluated again next run
+		return nil
+	}
+	if !filterOutcome.Allowed {
+		return recordFiltered(filterOutcome.Reason())
 	}
 
 	baseSymbol, baseAvail, usdtAvail, price, err := phemexClient.GetAvailableBaseFromUSDT(symbol)
-	logger.WithField("baseSymbol", baseSymbol).
+	if err == nil && autoTopup(ctx, phemexClient, userExchange, usdtAvail) {
+		baseSymbol, baseAvail, usdtAvail, price, err = phemexClient.GetAvailableBaseFromUSDT(symbol)
+	}
+	logger.WithContext(ctx).WithField("baseSymbol", baseSymbol).
 		WithField("baseAvail", baseAvail).
 		WithField("usdtAvail", usdtAvail).
 		WithField("price", price).
 		WithField("OrderSizePercent", orderSizePercent).
 		Debug("GetAvailableBaseFromUSDT")
 
-	value := PercentOfFloatSafe(baseAvail, orderSizePercent)
+	value := signalOrderSize(signal, baseAvail, orderSizePercent)
 
 	// check risk off mode
 	cfg := risk.NewSessionSizeConfigFromUserExchangeOrDefault(userExchange)
 	finalSize, session := risk.CalculateSizeByNYSession(
 		decimal.NewFromFloat(value),
-		time.Now(),
+		clock(),
 		cfg,
 	)
 
 	if session == risk.SessionNoTrade {
-		logger.Warn(risk.SessionNoTrade + " - risk off mode")
+		logger.WithContext(ctx).Warn(risk.SessionNoTrade + " - risk off mode")
+	} else if reason, halted := entryHalt(ctx); halted {
+		logger.WithContext(ctx).WithField("reason", reason).Warn("new entries halted, skipping")
+		return nil
+	}
+	finalSize = applyPerformanceSizing(ctx, userExchange, user.ID, exchangeID, symbol, finalSize)
+
+	if factor := filterOutcome.SizeFactor(); factor != 1 {
+		finalSize = finalSize.Mul(decimal.NewFromFloat(factor))
+		logger.WithContext(ctx).
+			WithField("factor", factor).
+			WithField("finalSize", finalSize).
+			Info("entry size reduced by signal filters")
+	}
+
+	finalSize = capPositionNotional(ctx, userExchange, finalSize, price)
+
+	// An approved entry never grows past the quantity the user approved
+	approved := existingOrder != nil && existingOrder.Status == model.OrderExecutionStatusApproved
+	if approved && finalSize.GreaterThan(existingOrder.Quantity) {
+		finalSize = existingOrder.Quantity
 	}
 
 	logger.
@@ -253,40 +482,129 @@ func OrderController(
 		WithField("finalSize", finalSize).
 		WithField("Symbol", symbol).
 		Debug("Value of order in ")
+
+	// Check the book can take the entry at market
+	limitPrice := ""
+	if session != risk.SessionNoTrade && finalSize.IsPositive() {
+		var skip string
+		limitPrice, skip, err = entryLiquidity(ctx, phemexClient, symbol, decision.Side, finalSize.InexactFloat64())
+		if err != nil {
+			logger.WithContext(ctx).WithError(err).Error("failed to check order book liquidity")
+			Capture(
+				ctx,
+				exceptionRepo,
+				"OrderController",
+				"controller",
+				"entryLiquidity",
+				"error",
+				err,
+				map[string]interface{}{"symbol": symbol},
+			)
+			skip = "liquidity check failed: " + err.Error()
+		}
+		if skip != "" {
+			return recordFiltered(skip)
+		}
+	}
+
+	// Check the venue price is in line with the market
+	if session != risk.SessionNoTrade && finalSize.IsPositive() {
+		reason, skip, err := entryPriceCheck(ctx, phemexClient, symbol, price)
+		if err != nil {
+			logger.WithContext(ctx).WithError(err).Error("failed to check the venue price")
+			Capture(
+				ctx,
+				exceptionRepo,
+				"OrderController",
+				"controller",
+				"entryPriceCheck",
+				"error",
+				err,
+				map[string]interface{}{"symbol": symbol},
+			)
+		}
+		if reason != "" {
+			ev := orderEvent(notify.EventPriceDivergence, user, targetExchange, nil)
+			ev.Symbol, ev.Side, ev.Price, ev.Message = symbol, decision.Side, price, reason
+			notifier.Notify(ctx, ev)
+		}
+		if skip {
+			return recordFiltered("price check: " + reason)
+		}
+	}
+
 	// ------------------------------------------------------------------
 	// 3) Create new Order (Phemex = exchange_id 1)
 	// ------------------------------------------------------------------
 
+	applyDefaultExits(userExchange, &signal, decision.PosSide, price)
+	if session != risk.SessionNoTrade && stopMode(userExchange) == tp_sl.StopModeSwing {
+		signal.StopLoss = swingEntryStop(ctx, ohlcvRepo, symbol, decision.PosSide, signal.StopLoss)
+	}
+
 	newOrder := &model.Order{
 		UserID:     user.ID,
 		ExchangeID: exchangeID, // Phemex
 		ExternalID: signal.ID,
-		Symbol:     symbol,                           //signal.Symbol, "BTCUSDT"
-		Side:       FirstLetterUpper(signal.Action),  // buy/sell
-		PosSide:    FirstLetterUpper(signal.OrderID), //Short/Long
+		Symbol:     symbol,           //signal.Symbol, "BTCUSDT"
+		Side:       decision.Side,    // Buy/Sell
+		PosSide:    decision.PosSide, // Long/Short
 		OrderType:  "market",
-		Quantity:   finalSize.InexactFloat64(), //
+		Quantity:   finalSize.Round(4),
 		Status:     model.OrderExecutionStatusFilled,
 		OrderDir:   model.OrderDirectionEntry,
+		StrategyID: strategyID(assignment),
+	}
+	if signal.StopLoss != nil {
+		newOrder.StopLossPct = decimal.NewFromFloat(*signal.StopLoss)
+		newOrder.InitialRisk = report.InitialRisk(price, *signal.StopLoss, newOrder.Quantity.InexactFloat64())
+	}
+	if signal.TakeProfit != nil {
+		newOrder.TakeProfitPct = decimal.NewFromFloat(*signal.TakeProfit)
+	}
+	quantityStr := newOrder.Quantity.StringFixed(4)
+	intent := newPlaceIntent(newOrder, quantityStr, "Market", false)
+	intent.PosSide = entryPosSide(hedged, newOrder.PosSide)
+	if limitPrice != "" {
+		newOrder.OrderType = "limit"
+		intent.OrderType = "Limit"
+		intent.Price = limitPrice
+	}
+	nativeExits := session != risk.SessionNoTrade && attachNativeExits(ctx, phemexClient, userExchange, exchangeID, intent, signal)
+
+	if session != risk.SessionNoTrade && !approved && needsApproval(userExchange, newOrder.Quantity, price) {
+		return requestApproval(ctx, orderRepo, notifier, user, userExchange, targetExchange, newOrder, filterOutcome.Reason())
 	}
 
 	if session != risk.SessionNoTrade {
-		if err := orderRepo.CreateWithAutoLog(ctx, newOrder); err != nil {
-			logger.WithError(err).Error("failed to create order with auto log")
+		applyStagger(ctx, newOrder)
+		if err := orderRepo.CreateWithIntent(ctx, newOrder, intent, filterOutcome.Reason()); err != nil {
+			logger.WithContext(ctx).WithError(err).Error("failed to create order with auto log")
 			return err
 		}
 	}
 
-	logger.WithField("order_id", newOrder.ID).Info("new order created")
+	ctx = logging.WithExchange(ctx, targetExchange, newOrder.ID)
+	logger.WithContext(ctx).Info("new order created")
 
 	// ------------------------------------------------------------------
 	// 4) Close all existing positions for this symbol on Phemex
 	// ------------------------------------------------------------------
 
 	if err := closeAllPositions(ctx, phemexClient, user, exchangeID, signal.ID, newOrder.Symbol); err != nil {
-		logger.WithError(err).
+		logger.WithContext(ctx).WithError(err).
 			WithField("symbol", newOrder.Symbol).
 			Error("failed to close all positions")
+		Capture(
+			ctx,
+			exceptionRepo,
+			"OrderController",
+			"controller",
+			"closeAllPositions",
+			"error",
+			err,
+			map[string]interface{}{"symbol": newOrder.Symbol},
+		)
 
 		_ = orderRepo.UpdateStatusWithAutoLog(
 			ctx,
@@ -298,38 +616,46 @@ func OrderController(
 		return err
 	}
 
-	logger.WithField("symbol", newOrder.Symbol).
+	logger.WithContext(ctx).WithField("symbol", newOrder.Symbol).
 		Info("all previous positions closed")
 
 	if session == risk.SessionNoTrade {
-		logger.Warn(risk.SessionNoTrade + " - risk off mode")
+		logger.WithContext(ctx).Warn(risk.SessionNoTrade + " - risk off mode")
 		err := userExchangeRep.MarkNoTradeWindowOrdersClosed(ctx, user.ID, exchangeID)
 		if err != nil {
-			logger.WithError(err).
+			logger.WithContext(ctx).WithError(err).
 				WithField("symbol", newOrder.Symbol).
 				Error("failed to mark risk off orders closed")
 			return err
 		}
+
+		killEvent := orderEvent(notify.EventKillSwitch, user, targetExchange, nil)
+		killEvent.Symbol = newOrder.Symbol
+		killEvent.Message = "no trade window, all positions closed"
+		notifier.Notify(ctx, killEvent)
+
 		return nil
 	}
 
 	// ------------------------------------------------------------------
 	// 5) Place new Market Order on Phemex
 	// ------------------------------------------------------------------
-	quantityStr := strconv.FormatFloat(newOrder.Quantity, 'f', 4, 64)
-
-	// TODO: ADD STOP LOSS
-	resp, err := phemexClient.PlaceOrder(
-		newOrder.Symbol,
-		newOrder.Side,
-		newOrder.PosSide,
-		quantityStr,
-		"Market",
-		false,
-	)
+	signal.Leverage = entryLeverage(ctx, userExchange, signal)
+	if err := applySignalLeverage(ctx, phemexClient, leverageKey{user.ID, exchangeID, newOrder.Symbol}, signal); err != nil {
+		logger.WithContext(ctx).WithError(err).Error("failed to apply signal leverage")
+		_ = orderRepo.UpdateStatusWithAutoLog(
+			ctx,
+			newOrder.ID,
+			model.OrderExecutionStatusError,
+			"failed to set signal leverage",
+		)
+		return err
+	}
+
+	resp, err := placeEntry(ctx, phemexClient, userExchange, intent)
 
 	if err != nil {
-		logger.WithFields(map[string]interface{}{
+		logger.WithContext(ctx).WithFields(map[string]interface{}{
 			"symbol":  newOrder.Symbol,
 			"side":    newOrder.Side,
 			"posSide": newOrder.PosSide,
@@ -357,11 +683,16 @@ func OrderController(
 			"failed to place order on Phemex",
 		)
 
+		errEvent := orderEvent(notify.EventOrderError, user, targetExchange, newOrder)
+		errEvent.Err = err
+		notifier.Notify(ctx, errEvent)
+		publishOrderFailed(ctx, user, targetExchange, newOrder, err)
+
 		return err // ou continue, dependendo do fluxo
 	}
 
 	if resp.Code != 0 {
-		logger.WithFields(map[string]interface{}{
+		logger.WithContext(ctx).WithFields(map[string]interface{}{
 			"symbol": newOrder.Symbol,
 			"code":   resp.Code,
 			"msg":    resp.Msg,
@@ -374,13 +705,20 @@ func OrderController(
 			"phemex returned non-zero code while placing order",
 		)
 
-		return fmt.Errorf("phemex error %d: %s", resp.Code, resp.Msg)
+		codeErr := resp.Err()
+		errEvent := orderEvent(notify.EventOrderError, user, targetExchange, newOrder)
+		errEvent.Err = codeErr
+		notifier.Notify(ctx, errEvent)
+		publishOrderFailed(ctx, user, targetExchange, newOrder, codeErr)
+
+		return codeErr
 	}
+	countDailyEntry(ctx, user.ID, newOrder.Symbol)
 
 	var payload model.PhemexOrderResponse
 
 	if err := json.Unmarshal(resp.Data, &payload); err != nil {
-		logger.WithFields(map[string]interface{}{
+		logger.WithContext(ctx).WithFields(map[string]interface{}{
 			"symbol": newOrder.Symbol,
 		}).WithError(err).Error("failed to unmarshal phemex response payload")
 
@@ -397,7 +735,7 @@ func OrderController(
 	// Map API payload -> DB model (versão safe)
 	ord, err := mapper.MapPhemexResponseToModel(&payload, newOrder.ID)
 	if err != nil {
-		logger.WithError(err).Error("failed to map phemex response to model")
+		logger.WithContext(ctx).WithError(err).Error("failed to map phemex response to model")
 
 		Capture(
 			ctx,
@@ -419,13 +757,15 @@ func OrderController(
 		return err
 	}
 
-	if err := orderRepo.UpdatePriceAutoLog(ctx, newOrder.ID, &ord.Price, "update to price phemex order"); err != nil {
-		logger.WithError(err).Error("failed to update price on order")
+	execPrice := mapper.ParseDecimalSafe("PriceRp", payload.PriceRp)
+	if err := orderRepo.UpdatePriceAutoLog(ctx, newOrder.ID, &execPrice, "update to price phemex order"); err != nil {
+		logger.WithContext(ctx).WithError(err).Error("failed to update price on order")
 	}
 
-	// Persist Phemex order in DB
+	// Persist Phemex order in DB. The entry is filled on the exchange by
+	// now, so a failure is captured and the position still gets its exits.
 	if err := phemexRepo.Create(ctx, ord); err != nil {
-		logger.WithError(err).Error("failed to persist phemex order")
+		logger.WithContext(ctx).WithError(err).Error("failed to persist phemex order")
 
 		Capture(
 			ctx,
@@ -441,14 +781,27 @@ func OrderController(
 				"qty":    quantityStr,
 			},
 		)
-	} else {
-		if err := orderRepo.UpdateStatusWithAutoLog(ctx, newOrder.ID, model.OrderExecutionStatusPending, "order placed on Phemex successfully"); err != nil {
-		}
 	}
 
+	notional := ord.CumValue
+	if notional == 0 {
+		notional = ord.OrderQty * price
+	}
+	fee, estimated := phemexOrderFee(ctx, phemexClient, targetExchange, newOrder.Symbol, ord.ExchangeOrderID, notional)
+	if !estimated {
+		ord.Fee, ord.FeeCurrency = fee, phemexFeeCurrency
+	}
+	recordOrderFee(ctx, orderRepo, phemexRepo, newOrder, ord.ExchangeOrderID, fee, estimated)
+
+	if err := orderRepo.UpdateStatusWithAutoLog(ctx, newOrder.ID, model.OrderExecutionStatusPending, "order placed on Phemex successfully"); err != nil {
+		logger.WithContext(ctx).WithError(err).Error("failed to update order status to pending")
+	}
+
+	publishOrderPlaced(ctx, user, targetExchange, newOrder, ord.Price)
+
 	pos, err := phemexClient.GetPositionsUSDT()
 	if err != nil {
-		logger.WithError(err).Error("failed to get positions on Phemex")
+		logger.WithContext(ctx).WithError(err).Error("failed to get positions on Phemex")
 		Capture(
 			ctx,
 			exceptionRepo,
@@ -460,9 +813,9 @@ func OrderController(
 			map[string]interface{}{},
 		)
 	}
-	logger.WithField("positions", pos).Info("positions on Phemex")
+	logger.WithContext(ctx).WithField("positions", pos).Info("positions on Phemex")
 
-	logger.WithFields(map[string]interface{}{
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
 		"order_id": newOrder.ID,
 		//"exchange_order": apiResp.OrderID,
 	}).Info("order placed on Phemex successfully")
@@ -481,7 +834,7 @@ func OrderController(
 				model.OrderExecutionStatusFilled,
 				"order executed successfully on phemex",
 			); err != nil {
-				logger.WithError(err).Error("failed to update order final status")
+				logger.WithContext(ctx).WithError(err).Error("failed to update order final status")
 				Capture(
 					ctx,
 					exceptionRepo,
@@ -499,8 +852,47 @@ func OrderController(
 				return err
 			}
 
-			logger.WithField("order_id", newOrder.ID).
+			logger.WithContext(ctx).WithField("order_id", newOrder.ID).
 				Info("order successfully completed")
+
+			filledPrice := ord.Price
+			if filledPrice == 0 {
+				filledPrice = price
+			}
+			publishOrderFilled(ctx, user, targetExchange, newOrder, newOrder.Side, newOrder.PosSide, filledPrice)
+
+			if nativeExits {
+				logger.WithContext(ctx).WithField("symbol", newOrder.Symbol).
+					Info("signal exits held by the exchange with the entry")
+				storeNativeExitIDs(ctx, phemexClient, orderRepo, newOrder, intent)
+			} else if !exchangeSupports(ctx, exchangeID, model.CapabilityStopOrder) {
+				logger.WithContext(ctx).WithField("symbol", newOrder.Symbol).
+					Warn("exchange does not support stop orders, signal exits not placed")
+			} else {
+				stopOrderID, takeProfitOrderID, err := placeSignalExits(ctx, phemexClient, signal, p)
+				if err != nil {
+					Capture(
+						ctx,
+						exceptionRepo,
+						"OrderController",
+						"controller",
+						"placeSignalExits",
+						"error",
+						err,
+						map[string]interface{}{"symbol": newOrder.Symbol},
+					)
+				}
+				if stopOrderID != "" || takeProfitOrderID != "" {
+					if err := orderRepo.UpdateBracket(ctx, newOrder.ID, stopOrderID, takeProfitOrderID); err != nil {
+						logger.WithContext(ctx).WithError(err).Error("failed to store the protective order IDs")
+					}
+				}
+			}
+
+			if err := strat.OnFill(ctx, strategyCtx, *newOrder); err != nil {
+				logger.WithContext(ctx).WithError(err).WithField("strategy", strat.Name()).Error("strategy OnFill failed")
+			}
+			recordStrategyAction(ctx, strategyRepo, assignment, strategy.HookFill, decision, signal.ID, &newOrder.ID)
 		}
 
 	}
@@ -510,7 +902,7 @@ func OrderController(
 
 func closeAllPositions(
 	ctx context.Context,
-	phemexClient *connectors.Client,
+	phemexClient connectors.Connector,
 	user *model.User,
 	exchangeID uint,
 	signalID uint,
@@ -520,7 +912,7 @@ func closeAllPositions(
 	exceptionRepo := newExceptionRepo()
 	orderRepo := newOrderRepo()
 
-	logger.WithFields(map[string]interface{}{
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
 		"symbol": symbol,
 		"user":   user.Username,
 	}).Info("Closing ALL positions for symbol")
@@ -550,16 +942,16 @@ func closeAllPositions(
 		case "Sell":
 			closeSide = "Buy"
 		default:
-			logger.WithFields(map[string]interface{}{
+			logger.WithContext(ctx).WithFields(map[string]interface{}{
 				"symbol": symbol,
 				"side":   p.Side,
 			}).Error("Unknown position side, skipping")
 			continue
 		}
 
-		quantity, err := strconv.ParseFloat(p.SizeRq, 64)
+		quantity, err := decimal.NewFromString(p.SizeRq)
 		if err != nil {
-			logger.WithError(err).Error("failed to parse SizeRq to float")
+			logger.WithContext(ctx).WithError(err).Error("failed to parse SizeRq to decimal")
 			return err
 		}
 
@@ -576,14 +968,20 @@ func closeAllPositions(
 			OrderDir:   model.OrderDirectionExit,
 		}
 
-		if err := orderRepo.CreateWithAutoLog(ctx, exitOrder); err != nil {
-			logger.WithError(err).Error("failed to create exit order with auto log")
+		// 3) Send a MARKET order with reduceOnly to fully close the position
+		intent := newPlaceIntent(exitOrder, p.SizeRq, "Market", true)
+		intent.Side = closeSide
+		intent.PosSide = p.PosSide
+
+		applyStagger(ctx, exitOrder)
+		if err := orderRepo.CreateWithIntent(ctx, exitOrder, intent, ""); err != nil {
+			logger.WithContext(ctx).WithError(err).Error("failed to create exit order with auto log")
 			return err
 		}
 
-		logger.WithField("order_id", exitOrder.ID).Info("new exit order created")
+		logger.WithContext(ctx).WithField("order_id", exitOrder.ID).Info("new exit order created")
 
-		logger.WithFields(map[string]interface{}{
+		logger.WithContext(ctx).WithFields(map[string]interface{}{
 			"symbol":    p.Symbol,
 			"posSide":   p.PosSide,
 			"side":      p.Side,
@@ -591,17 +989,9 @@ func closeAllPositions(
 			"closeSide": closeSide,
 		}).Info("Closing position")
 
-		// 3) Send a MARKET order with reduceOnly to fully close the position
-		resp, err := phemexClient.PlaceOrder(
-			p.Symbol,  // trading pair
-			closeSide, // opposite side to close the position
-			p.PosSide, // Long or Short
-			p.SizeRq,  // full position size
-			"Market",  // market order
-			true,      // reduceOnly = true (guarantees position close)
-		)
+		resp, err := placeOrder(ctx, phemexClient, intent)
 		if err != nil {
-			logger.WithFields(map[string]interface{}{
+			logger.WithContext(ctx).WithFields(map[string]interface{}{
 				"symbol":  p.Symbol,
 				"posSide": p.PosSide,
 				"side":    p.Side,
@@ -609,38 +999,38 @@ func closeAllPositions(
 			}).WithError(err).Error("Failed to close position")
 
 			return fmt.Errorf(
-				"failed to close position %s %s (%s): %w",
+				"failed to close position %s %s (%s): %w: %w",
 				p.Symbol,
 				p.PosSide,
 				p.Side,
+				errClosePosition,
 				err,
 			)
 		}
 
 		if resp.Code != 0 {
-			logger.WithFields(map[string]interface{}{
+			logger.WithContext(ctx).WithFields(map[string]interface{}{
 				"symbol": p.Symbol,
 				"code":   resp.Code,
 				"msg":    resp.Msg,
 			}).Error("Phemex returned non-zero code")
 
-			return fmt.Errorf("phemex error %d: %s", resp.Code, resp.Msg)
-		} else {
+			return fmt.Errorf("failed to close position %s %s: %w: %w", p.Symbol, p.PosSide, errClosePosition, resp.Err())
 		}
 
 		var payload model.PhemexOrderResponse
 
 		if err := json.Unmarshal(resp.Data, &payload); err != nil {
-			logger.WithFields(map[string]interface{}{
+			logger.WithContext(ctx).WithFields(map[string]interface{}{
 				"symbol": p.Symbol,
 			}).WithError(err).Error("closeAllPositions failed to unmarshal phemex response payload")
 			return err
 		}
 
 		// Map API payload -> DB model (versão safe)
-		_, err = mapper.MapPhemexResponseToModel(&payload, exitOrder.ID)
+		exitExec, err := mapper.MapPhemexResponseToModel(&payload, exitOrder.ID)
 		if err != nil {
-			logger.WithError(err).Error("closeAllPositions failed to map phemex response to model")
+			logger.WithContext(ctx).WithError(err).Error("closeAllPositions failed to map phemex response to model")
 
 			Capture(
 				ctx,
@@ -657,11 +1047,25 @@ func closeAllPositions(
 		}
 
 		// Persist Phemex order in DB
-		logger.WithFields(map[string]interface{}{
+		logger.WithContext(ctx).WithFields(map[string]interface{}{
 			"symbol": p.Symbol,
 			"side":   p.Side,
 		}).Debug("skipping persistence for exit order")
 
+		notional := exitExec.CumValue
+		if notional == 0 {
+			mark := mapper.ParseDecimalSafe("MarkPriceRp", p.MarkPriceRp)
+			notional = quantity.Mul(mark).InexactFloat64()
+		}
+		fee, estimated := phemexOrderFee(ctx, phemexClient, "phemex", symbol, exitExec.ExchangeOrderID, notional)
+		recordOrderFee(ctx, orderRepo, nil, exitOrder, exitExec.ExchangeOrderID, fee, estimated)
+
+		exitPrice := exitExec.Price
+		if exitPrice == 0 && quantity.IsPositive() {
+			exitPrice = notional / quantity.InexactFloat64()
+		}
+		publishOrderFilled(ctx, user, "phemex", exitOrder, closeSide, bracketSide(p), exitPrice)
+
 	}
 
 	return nil
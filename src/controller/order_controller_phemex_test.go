@@ -1,21 +1,30 @@
 package controller
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
-	"io"
+	"fmt"
+	"math"
 	"net/http"
-	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/shopspring/decimal"
 
+	"strategyexecutor/src/auth"
 	"strategyexecutor/src/connectors"
 	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/fees"
+	"strategyexecutor/src/liquidity"
+	"strategyexecutor/src/makerentry"
 	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/outbox"
+	"strategyexecutor/src/pricecheck"
+	"strategyexecutor/src/testsupport"
 	"strategyexecutor/src/tp_sl"
 )
 
@@ -33,6 +42,7 @@ func (m *mockTradingSignalRepo) FindLatest(ctx context.Context, symbol, exchange
 
 type mockPhemexOrderRepo struct {
 	created []*model.PhemexOrder
+	fees    map[string]float64
 	err     error
 }
 
@@ -44,9 +54,20 @@ func (m *mockPhemexOrderRepo) Create(ctx context.Context, order *model.PhemexOrd
 	return nil
 }
 
-type mockExceptionRepo struct{}
+func (m *mockPhemexOrderRepo) UpdateFee(ctx context.Context, exchangeOrderID string, fee float64, currency string) error {
+	if m.fees == nil {
+		m.fees = map[string]float64{}
+	}
+	m.fees[exchangeOrderID] = fee
+	return nil
+}
+
+type mockExceptionRepo struct {
+	created []*model.Exception
+}
 
 func (m *mockExceptionRepo) Create(ctx context.Context, exception *model.Exception) error {
+	m.created = append(m.created, exception)
 	return nil
 }
 
@@ -59,6 +80,15 @@ type mockOrderRepo struct {
 	updatePriceErr error
 	updateRespErr  error
 	statuses       []string
+	reasons        []string
+	intents        []*model.OrderIntent
+	fees           []recordedFee
+	brackets       [][2]string
+}
+
+type recordedFee struct {
+	fee       float64
+	estimated bool
 }
 
 var _ orderRepository = (*mockOrderRepo)(nil)
@@ -79,6 +109,20 @@ func (m *mockOrderRepo) CreateWithAutoLog(ctx context.Context, order *model.Orde
 	return nil
 }
 
+func (m *mockOrderRepo) CreateWithAutoLogReason(ctx context.Context, order *model.Order, reason string) error {
+	m.reasons = append(m.reasons, reason)
+	return m.CreateWithAutoLog(ctx, order)
+}
+
+func (m *mockOrderRepo) CreateWithIntent(ctx context.Context, order *model.Order, intent *model.OrderIntent, reason string) error {
+	if err := m.CreateWithAutoLogReason(ctx, order, reason); err != nil {
+		return err
+	}
+	intent.ID = order.ID
+	m.intents = append(m.intents, intent)
+	return nil
+}
+
 func (m *mockOrderRepo) UpdateStatusWithAutoLog(ctx context.Context, orderID uint, newStatus string, reason string) error {
 	if m.updateErr != nil {
 		return m.updateErr
@@ -87,7 +131,7 @@ func (m *mockOrderRepo) UpdateStatusWithAutoLog(ctx context.Context, orderID uin
 	return nil
 }
 
-func (m *mockOrderRepo) UpdatePriceAutoLog(ctx context.Context, orderID uint, price *float64, reason string) error {
+func (m *mockOrderRepo) UpdatePriceAutoLog(ctx context.Context, orderID uint, price *decimal.Decimal, reason string) error {
 	if m.updatePriceErr != nil {
 		return m.updatePriceErr
 	}
@@ -104,21 +148,44 @@ func (m *mockOrderRepo) UpdateResp(ctx context.Context, orderID uint, resp strin
 	return nil
 }
 
-func (m *mockOrderRepo) UpdateStopLoss(ctx context.Context, orderID uint, stopLoss float64) error {
+func (m *mockOrderRepo) UpdateStopLoss(ctx context.Context, orderID uint, stopLoss decimal.Decimal) error {
 	if m.updateErr != nil {
 		return m.updateErr
 	}
 	return nil
 }
 
+func (m *mockOrderRepo) UpdateBracket(ctx context.Context, orderID uint, stopOrderID string, takeProfitOrderID string) error {
+	m.brackets = append(m.brackets, [2]string{stopOrderID, takeProfitOrderID})
+	return nil
+}
+
+func (m *mockOrderRepo) UpdateFee(ctx context.Context, orderID uint, fee float64, currency string, estimated bool) error {
+	m.fees = append(m.fees, recordedFee{fee: fee, estimated: estimated})
+	return nil
+}
+
 func (m *mockOrderRepo) FindByExchangeIDAndUserID(ctx context.Context, userID uint, exchangeID uint) (*model.Order, error) {
 	return nil, nil
 }
 
+func (m *mockOrderRepo) ResolveApproval(ctx context.Context, orderID uint, newStatus string, reason string) (bool, error) {
+	if m.findOrder == nil || m.findOrder.Status != model.OrderExecutionStatusAwaitingApproval {
+		return false, nil
+	}
+	m.statuses = append(m.statuses, newStatus)
+	m.reasons = append(m.reasons, reason)
+	return true, nil
+}
+
 type mockOHLCVRepo struct {
 	newSL    decimal.Decimal
 	isRaised bool
 	err      error
+
+	swingStop  decimal.Decimal
+	swingFound bool
+	swingCalls int
 }
 
 func (m *mockOHLCVRepo) GetNextStopLoss(ctx context.Context, symbol string, now time.Time, side tp_sl.Side, currentSL decimal.Decimal, timeframe time.Duration, floor int) (decimal.Decimal, bool, error) {
@@ -128,155 +195,39 @@ func (m *mockOHLCVRepo) GetNextStopLoss(ctx context.Context, symbol string, now
 	return m.newSL, m.isRaised, nil
 }
 
-type pos struct {
-	AccountID        int64  `json:"accountID"`
-	Symbol           string `json:"symbol"`
-	Currency         string `json:"currency"`
-	Side             string `json:"side"`
-	PosSide          string `json:"posSide"`
-	SizeRq           string `json:"sizeRq"`
-	AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-	PositionMarginRv string `json:"positionMarginRv"`
-	MarkPriceRp      string `json:"markPriceRp"`
-}
-
-type serverConfig struct {
-	available         float64
-	ticker            string
-	positionsFirst    []pos
-	positionsSecond   []pos
-	positionsError    bool
-	riskUnitError     bool
-	tickerError       bool
-	closeOrderError   bool
-	placeOrderError   bool
-	placeOrderNonZero bool
-	placeOrderBadJSON bool
-}
-
-func convertPositions(ps []pos) []struct {
-	AccountID        int64  `json:"accountID"`
-	Symbol           string `json:"symbol"`
-	Currency         string `json:"currency"`
-	Side             string `json:"side"`
-	PosSide          string `json:"posSide"`
-	SizeRq           string `json:"sizeRq"`
-	AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-	PositionMarginRv string `json:"positionMarginRv"`
-	MarkPriceRp      string `json:"markPriceRp"`
-} {
-	converted := make([]struct {
-		AccountID        int64  `json:"accountID"`
-		Symbol           string `json:"symbol"`
-		Currency         string `json:"currency"`
-		Side             string `json:"side"`
-		PosSide          string `json:"posSide"`
-		SizeRq           string `json:"sizeRq"`
-		AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-		PositionMarginRv string `json:"positionMarginRv"`
-		MarkPriceRp      string `json:"markPriceRp"`
-	}, 0, len(ps))
-
-	for _, p := range ps {
-		converted = append(converted, struct {
-			AccountID        int64  `json:"accountID"`
-			Symbol           string `json:"symbol"`
-			Currency         string `json:"currency"`
-			Side             string `json:"side"`
-			PosSide          string `json:"posSide"`
-			SizeRq           string `json:"sizeRq"`
-			AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
-			PositionMarginRv string `json:"positionMarginRv"`
-			MarkPriceRp      string `json:"markPriceRp"`
-		}{
-			AccountID:        p.AccountID,
-			Symbol:           p.Symbol,
-			Currency:         p.Currency,
-			Side:             p.Side,
-			PosSide:          p.PosSide,
-			SizeRq:           p.SizeRq,
-			AvgEntryPriceRp:  p.AvgEntryPriceRp,
-			PositionMarginRv: p.PositionMarginRv,
-			MarkPriceRp:      p.MarkPriceRp,
-		})
-	}
+func (m *mockOHLCVRepo) GetSwingStopLoss(ctx context.Context, symbol string, now time.Time, side tp_sl.Side, timeframe time.Duration, strength int) (decimal.Decimal, bool, error) {
+	return m.swingStop, m.swingFound, m.err
+}
 
-	return converted
+func (m *mockOHLCVRepo) GetNextSwingStopLoss(ctx context.Context, symbol string, now time.Time, side tp_sl.Side, currentSL decimal.Decimal, timeframe time.Duration, strength int) (decimal.Decimal, bool, error) {
+	m.swingCalls++
+	if m.err != nil {
+		return decimal.Decimal{}, false, m.err
+	}
+	return m.newSL, m.isRaised, nil
 }
 
-func buildPhemexTestClient(t *testing.T, cfg serverConfig) *connectors.Client {
-	t.Helper()
+var (
+	longBTC = testsupport.Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"}
+	flatBTC = testsupport.Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0"}
+)
 
-	positionCalls := 0
-	orderCalls := 0
+// entryOrders matches the new order placed after the close.
+func entryOrders(r testsupport.Request) bool { return !r.ReduceOnly() }
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.URL.Path {
-		case "/g-accounts/risk-unit":
-			if cfg.riskUnitError {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			_ = json.NewEncoder(w).Encode(connectors.APIResponse{Code: 0, Data: mustJSON([]connectors.RiskUnit{{
-				Symbol:                "BTCUSDT",
-				EstAvailableBalanceRv: cfg.available,
-			}})})
-		case "/md/v3/ticker/24hr":
-			if cfg.tickerError {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]string{"lastRp": cfg.ticker}})
-		case "/g-accounts/positions":
-			if cfg.positionsError {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			positionCalls++
-			positions := cfg.positionsFirst
-			if positionCalls > 1 && len(cfg.positionsSecond) > 0 {
-				positions = cfg.positionsSecond
-			}
-			_ = json.NewEncoder(w).Encode(connectors.APIResponse{Code: 0, Data: mustJSON(connectors.GAccountPositions{Positions: convertPositions(positions)})})
-		case "/g-orders":
-			bodyBytes, _ := io.ReadAll(r.Body)
-			r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-
-			orderCalls++
-			if cfg.closeOrderError {
-				var payload map[string]interface{}
-				_ = json.Unmarshal(bodyBytes, &payload)
-				if reduceOnly, ok := payload["reduceOnly"].(bool); ok && reduceOnly {
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			}
-			if orderCalls == 1 && cfg.closeOrderError {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			if orderCalls > 1 {
-				if cfg.placeOrderError {
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				if cfg.placeOrderBadJSON {
-					_, _ = w.Write([]byte("not-json"))
-					return
-				}
-				if cfg.placeOrderNonZero {
-					_ = json.NewEncoder(w).Encode(connectors.APIResponse{Code: 500, Msg: "bad"})
-					return
-				}
-			}
-			_ = json.NewEncoder(w).Encode(connectors.APIResponse{Code: 0, Data: mustJSON(model.PhemexOrderResponse{OrderID: "abc", ClOrdID: "1", Symbol: "BTCUSDT", Side: "Buy", PriceRp: "50000", OrderQtyRq: "0.002"})})
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
-	}))
-	t.Cleanup(server.Close)
+// newPhemexMock serves a BTCUSDT account with 100 USDT available at 50000.
+// Each mock is a fresh account, so the leverage set on earlier ones is
+// forgotten.
+func newPhemexMock(t *testing.T) *testsupport.MockExchange {
+	t.Helper()
+	leverages = &leverageCache{last: map[leverageKey]float64{}}
+	return testsupport.NewMockExchange(t).
+		WithBalance("BTCUSDT", 100).
+		WithTicker("BTCUSDT", testsupport.Ticker{LastRp: "50000"})
+}
 
-	return connectors.NewClient("k", "s", server.URL)
+func phemexClient(m *testsupport.MockExchange) *connectors.Client {
+	return connectors.NewClient("k", "s", m.URL)
 }
 
 // TestOrderControllerFlows exercises Phemex order controller scenarios to ensure signals and orders
@@ -296,6 +247,7 @@ func TestOrderControllerFlows(t *testing.T) {
 		expectOrder           bool
 		expectedStatus        []string
 		expectedPhemexCreates int
+		expectCaptured        bool
 	}{
 		{
 			// success flow validates that a new order is created and
@@ -306,7 +258,7 @@ func TestOrderControllerFlows(t *testing.T) {
 			phemexRepo:            &mockPhemexOrderRepo{},
 			exceptionRepo:         &mockExceptionRepo{},
 			ohlcvRepo:             &mockOHLCVRepo{isRaised: false},
-			client:                buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000", positionsFirst: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"}}, positionsSecond: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"}}}),
+			client:                phemexClient(newPhemexMock(t).WithPositions(longBTC)),
 			expectOrder:           true,
 			expectedStatus:        []string{model.OrderExecutionStatusPending, model.OrderExecutionStatusFilled},
 			expectedPhemexCreates: 1,
@@ -319,7 +271,7 @@ func TestOrderControllerFlows(t *testing.T) {
 			orderRepo:     &mockOrderRepo{},
 			phemexRepo:    &mockPhemexOrderRepo{},
 			exceptionRepo: &mockExceptionRepo{},
-			client:        buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000"}),
+			client:        phemexClient(newPhemexMock(t)),
 			expectError:   true,
 		},
 		{
@@ -330,7 +282,7 @@ func TestOrderControllerFlows(t *testing.T) {
 			orderRepo:     &mockOrderRepo{},
 			phemexRepo:    &mockPhemexOrderRepo{},
 			exceptionRepo: &mockExceptionRepo{},
-			client:        buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000"}),
+			client:        phemexClient(newPhemexMock(t)),
 			expectError:   false,
 			expectOrder:   false,
 		},
@@ -342,7 +294,7 @@ func TestOrderControllerFlows(t *testing.T) {
 			orderRepo:     &mockOrderRepo{findOrder: &model.Order{ID: 99, Status: model.OrderExecutionStatusFilled}},
 			phemexRepo:    &mockPhemexOrderRepo{},
 			exceptionRepo: &mockExceptionRepo{},
-			client:        buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000"}),
+			client:        phemexClient(newPhemexMock(t)),
 			expectOrder:   false,
 		},
 		{
@@ -353,7 +305,7 @@ func TestOrderControllerFlows(t *testing.T) {
 			orderRepo:     &mockOrderRepo{findErr: errors.New("find err")},
 			phemexRepo:    &mockPhemexOrderRepo{},
 			exceptionRepo: &mockExceptionRepo{},
-			client:        buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000"}),
+			client:        phemexClient(newPhemexMock(t)),
 			expectError:   true,
 		},
 		{
@@ -364,7 +316,7 @@ func TestOrderControllerFlows(t *testing.T) {
 			orderRepo:     &mockOrderRepo{createErr: errors.New("create err")},
 			phemexRepo:    &mockPhemexOrderRepo{},
 			exceptionRepo: &mockExceptionRepo{},
-			client:        buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000"}),
+			client:        phemexClient(newPhemexMock(t)),
 			expectError:   true,
 		},
 		{
@@ -375,7 +327,7 @@ func TestOrderControllerFlows(t *testing.T) {
 			orderRepo:      &mockOrderRepo{},
 			phemexRepo:     &mockPhemexOrderRepo{},
 			exceptionRepo:  &mockExceptionRepo{},
-			client:         buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000", positionsFirst: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"}}, closeOrderError: true}),
+			client:         phemexClient(newPhemexMock(t).WithPositions(longBTC).Fail(http.MethodPost, "/g-orders", testsupport.Failure{Status: http.StatusInternalServerError})),
 			expectError:    true,
 			expectedStatus: []string{model.OrderExecutionStatusError},
 		},
@@ -387,7 +339,7 @@ func TestOrderControllerFlows(t *testing.T) {
 			orderRepo:      &mockOrderRepo{},
 			phemexRepo:     &mockPhemexOrderRepo{},
 			exceptionRepo:  &mockExceptionRepo{},
-			client:         buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000", positionsFirst: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"}}, positionsSecond: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0"}}, placeOrderError: true}),
+			client:         phemexClient(newPhemexMock(t).WithPositions(longBTC).ThenPositions(flatBTC).Fail(http.MethodPost, "/g-orders", testsupport.Failure{Status: http.StatusInternalServerError, Match: entryOrders})),
 			expectError:    true,
 			expectedStatus: []string{model.OrderExecutionStatusError},
 		},
@@ -399,7 +351,7 @@ func TestOrderControllerFlows(t *testing.T) {
 			orderRepo:      &mockOrderRepo{},
 			phemexRepo:     &mockPhemexOrderRepo{},
 			exceptionRepo:  &mockExceptionRepo{},
-			client:         buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000", positionsFirst: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"}}, positionsSecond: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0"}}, placeOrderNonZero: true}),
+			client:         phemexClient(newPhemexMock(t).WithPositions(longBTC).ThenPositions(flatBTC).Fail(http.MethodPost, "/g-orders", testsupport.Failure{Code: 500, Msg: "bad", Match: entryOrders})),
 			expectError:    true,
 			expectedStatus: []string{model.OrderExecutionStatusError},
 		},
@@ -411,21 +363,23 @@ func TestOrderControllerFlows(t *testing.T) {
 			orderRepo:      &mockOrderRepo{},
 			phemexRepo:     &mockPhemexOrderRepo{},
 			exceptionRepo:  &mockExceptionRepo{},
-			client:         buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000", positionsFirst: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "1"}}, positionsSecond: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0"}}, placeOrderBadJSON: true}),
+			client:         phemexClient(newPhemexMock(t).WithPositions(longBTC).ThenPositions(flatBTC).Fail(http.MethodPost, "/g-orders", testsupport.Failure{Body: "not-json", Match: entryOrders})),
 			expectError:    true,
 			expectedStatus: []string{model.OrderExecutionStatusError},
 		},
 		{
-			// phemex repo create error checks persistence failures when
-			// saving the Phemex order response locally.
+			// phemex repo create error checks a failure to save the Phemex
+			// order response locally is captured while the filled entry
+			// still completes.
 			name:           "phemex repo create error",
 			tradingRepo:    &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}},
 			orderRepo:      &mockOrderRepo{},
 			phemexRepo:     &mockPhemexOrderRepo{err: errors.New("persist fail")},
 			exceptionRepo:  &mockExceptionRepo{},
-			client:         buildPhemexTestClient(t, serverConfig{available: 100, ticker: "50000", positionsFirst: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0"}}, positionsSecond: []pos{{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0"}}}),
-			expectError:    true,
-			expectedStatus: []string{model.OrderExecutionStatusError},
+			client:         phemexClient(newPhemexMock(t).WithPositions(longBTC)),
+			expectOrder:    true,
+			expectedStatus: []string{model.OrderExecutionStatusPending, model.OrderExecutionStatusFilled},
+			expectCaptured: true,
 		},
 	}
 
@@ -490,6 +444,9 @@ func TestOrderControllerFlows(t *testing.T) {
 					}
 				}
 			}
+			if tc.expectCaptured && len(tc.exceptionRepo.created) == 0 {
+				t.Fatalf("expected the failure to be captured")
+			}
 			if tc.expectedPhemexCreates > 0 {
 				// Confirm we persisted the expected number of Phemex
 				// orders when successful flows complete.
@@ -501,7 +458,1323 @@ func TestOrderControllerFlows(t *testing.T) {
 	}
 }
 
-func mustJSON(v interface{}) json.RawMessage {
-	data, _ := json.Marshal(v)
-	return data
+// TestOrderControllerRecordsOrderFee checks the order fees come from the
+// fills when Phemex reports them and are estimated from the taker rate
+// otherwise. The previous position is closed first, so the exit fee is
+// recorded before the entry fee.
+func TestOrderControllerRecordsOrderFee(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalFees := newFeeSchedule
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		newFeeSchedule = originalFees
+	}()
+	newFeeSchedule = func() *fees.Schedule {
+		return fees.New(&fees.Config{TakerRates: map[string]float64{"phemex": 0.001}})
+	}
+
+	run := func(t *testing.T, m *testsupport.MockExchange) (*mockOrderRepo, *mockPhemexOrderRepo) {
+		t.Helper()
+		orderRepo := &mockOrderRepo{}
+		phemexRepo := &mockPhemexOrderRepo{}
+		newTradingSignalRepo = func() tradingSignalRepository {
+			return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+		}
+		newPhemexOrderRepo = func() phemexOrderRepository { return phemexRepo }
+		newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+		newOrderRepo = func() orderRepository { return orderRepo }
+		newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+
+		user := &model.User{ID: 1, Username: "tester"}
+		err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", &model.UserExchange{OrderSizePercent: 50})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(phemexRepo.created) != 1 {
+			t.Fatalf("expected an entry order to be placed")
+		}
+		return orderRepo, phemexRepo
+	}
+
+	t.Run("from fills", func(t *testing.T) {
+		m := newPhemexMock(t).
+			WithPositions(longBTC).
+			WithFills(
+				testsupport.Fill{OrderID: "mock-1", Symbol: "BTCUSDT", ExecFeeRv: "30"},
+				testsupport.Fill{OrderID: "mock-2", Symbol: "BTCUSDT", ExecFeeRv: "0.25"},
+				testsupport.Fill{OrderID: "mock-2", Symbol: "BTCUSDT", ExecFeeRv: "0.5"},
+			)
+		orderRepo, phemexRepo := run(t, m)
+
+		want := []recordedFee{{fee: 30}, {fee: 0.75}}
+		if len(orderRepo.fees) != len(want) || orderRepo.fees[0] != want[0] || orderRepo.fees[1] != want[1] {
+			t.Fatalf("expected fees %v, got %v", want, orderRepo.fees)
+		}
+		if got := phemexRepo.created[0]; got.Fee != 0.75 || got.FeeCurrency != "USDT" {
+			t.Fatalf("unexpected phemex order fee %v %q", got.Fee, got.FeeCurrency)
+		}
+		if got := phemexRepo.fees["mock-2"]; got != 0.75 {
+			t.Fatalf("expected stored phemex order fee 0.75, got %v", got)
+		}
+	})
+
+	t.Run("estimated", func(t *testing.T) {
+		orderRepo, phemexRepo := run(t, newPhemexMock(t).WithPositions(flatBTC))
+
+		entry := phemexRepo.created[0]
+		want := entry.OrderQty * 50000 * 0.001
+		if len(orderRepo.fees) != 1 || !orderRepo.fees[0].estimated || math.Abs(orderRepo.fees[0].fee-want) > 1e-9 {
+			t.Fatalf("expected estimated fee %v, got %v", want, orderRepo.fees)
+		}
+		if len(phemexRepo.fees) != 0 || entry.Fee != 0 {
+			t.Fatalf("estimated fees must not be stored on the phemex order: %v", phemexRepo.fees)
+		}
+	})
+}
+
+// flatSessionUserExchange sizes entries at percent of the balance with
+// session multipliers of 1, keeping sizes independent of the clock.
+func flatSessionUserExchange(percent int) *model.UserExchange {
+	one := decimal.NewFromInt(1)
+	return &model.UserExchange{
+		OrderSizePercent:         percent,
+		WeekendHolidayMultiplier: one,
+		DeadZoneMultiplier:       one,
+		AsiaMultiplier:           one,
+		LondonMultiplier:         one,
+		USMultiplier:             one,
+		DefaultMultiplier:        one,
+	}
+}
+
+func TestOrderControllerHonorsSignalV2(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+	}()
+
+	f := func(v float64) *float64 { return &v }
+	run := func(t *testing.T, m *testsupport.MockExchange, signal externalmodel.TradingSignal) *mockOrderRepo {
+		t.Helper()
+		orderRepo := &mockOrderRepo{}
+		newTradingSignalRepo = func() tradingSignalRepository {
+			return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{signal}}
+		}
+		newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+		newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+		newOrderRepo = func() orderRepository { return orderRepo }
+		newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+
+		user := &model.User{ID: 1, Username: "tester"}
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", flatSessionUserExchange(50)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return orderRepo
+	}
+	base := externalmodel.TradingSignal{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}
+
+	t.Run("sizing, leverage and exits", func(t *testing.T) {
+		signal := base
+		signal.Quantity, signal.Leverage = f(0.0012), f(5)
+		signal.StopLoss, signal.TakeProfit = f(48000), f(55000)
+		m := newPhemexMock(t).WithPositions(flatBTC).ThenPositions(longBTC)
+		orderRepo := run(t, m, signal)
+
+		if !orderRepo.order.StopLossPct.Equal(decimal.NewFromInt(48000)) || !orderRepo.order.TakeProfitPct.Equal(decimal.NewFromInt(55000)) {
+			t.Fatalf("unexpected order exits: %+v", orderRepo.order)
+		}
+		if m.Count(http.MethodPut, "/g-positions/leverage") != 1 {
+			t.Fatalf("expected leverage to be set once")
+		}
+		orders := m.Orders()
+		if len(orders) != 3 {
+			t.Fatalf("expected entry, stop loss and take profit orders, got %+v", orders)
+		}
+		if orders[0].OrderQtyRq != "0.0012" {
+			t.Fatalf("expected the signal quantity, got %q", orders[0].OrderQtyRq)
+		}
+		if orders[1].OrdType != "Stop" || orders[1].StopPxRp != "48000" || orders[1].Side != "Sell" {
+			t.Fatalf("unexpected stop loss %+v", orders[1])
+		}
+		if orders[2].OrdType != "MarketIfTouched" || orders[2].StopPxRp != "55000" || orders[2].Side != "Sell" {
+			t.Fatalf("unexpected take profit %+v", orders[2])
+		}
+		if len(orderRepo.brackets) != 1 || orderRepo.brackets[0] != [2]string{orders[1].OrderID, orders[2].OrderID} {
+			t.Fatalf("expected the protective order IDs stored, got %v", orderRepo.brackets)
+		}
+	})
+
+	t.Run("size percent", func(t *testing.T) {
+		signal := base
+		signal.SizePercent = f(10)
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		orderRepo := run(t, m, signal)
+
+		// 10% of the 100 USDT available at 50000.
+		if got := orderRepo.order.Quantity; !got.Equal(decimal.RequireFromString("0.0002")) {
+			t.Fatalf("expected 10%% of the available base, got %v", got)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		signal := base
+		signal.ValidUntil = &time.Time{}
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		orderRepo := run(t, m, signal)
+
+		if orderRepo.order == nil || orderRepo.order.Status != model.OrderExecutionStatusFiltered {
+			t.Fatalf("expected a filtered order, got %+v", orderRepo.order)
+		}
+		if len(orderRepo.reasons) != 1 || orderRepo.reasons[0] != "signal expired" {
+			t.Fatalf("unexpected reasons %v", orderRepo.reasons)
+		}
+		if len(m.Orders()) != 0 {
+			t.Fatalf("expired signal must not trade")
+		}
+	})
+
+	t.Run("other strategy", func(t *testing.T) {
+		signal := base
+		signal.StrategyTag = "someone-else"
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		orderRepo := run(t, m, signal)
+
+		if orderRepo.order != nil || len(m.Orders()) != 0 {
+			t.Fatalf("signal tagged for another strategy must be skipped")
+		}
+	})
+}
+
+type mockSignalClaimRepo struct {
+	previous *model.SignalClaim
+	claims   []*model.SignalClaim
+}
+
+func (m *mockSignalClaimRepo) Claim(ctx context.Context, claim *model.SignalClaim, window time.Duration) (*model.SignalClaim, error) {
+	m.claims = append(m.claims, claim)
+	return m.previous, nil
+}
+
+func TestOrderControllerSuppressesDuplicateSignal(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalClaims := newSignalClaimRepo
+	originalWindow := duplicateSignalWindow
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		newSignalClaimRepo = originalClaims
+		duplicateSignalWindow = originalWindow
+	}()
+	duplicateSignalWindow = func() time.Duration { return 2 * time.Minute }
+
+	run := func(t *testing.T, claims *mockSignalClaimRepo) (*mockOrderRepo, *testsupport.MockExchange) {
+		t.Helper()
+		orderRepo := &mockOrderRepo{}
+		newTradingSignalRepo = func() tradingSignalRepository {
+			return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 11, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+		}
+		newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+		newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+		newOrderRepo = func() orderRepository { return orderRepo }
+		newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+		newSignalClaimRepo = func() signalClaimRepository { return claims }
+
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		user := &model.User{ID: 1, Username: "tester"}
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", &model.UserExchange{OrderSizePercent: 50}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return orderRepo, m
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		orderRepo, m := run(t, &mockSignalClaimRepo{previous: &model.SignalClaim{SignalID: 10}})
+		if len(m.Orders()) != 0 {
+			t.Fatalf("duplicate signal must not trade")
+		}
+		if orderRepo.order == nil || orderRepo.order.Status != model.OrderExecutionStatusFiltered {
+			t.Fatalf("expected a filtered order, got %+v", orderRepo.order)
+		}
+		if len(orderRepo.reasons) != 1 || orderRepo.reasons[0] != "duplicate of signal 10" {
+			t.Fatalf("unexpected reasons %v", orderRepo.reasons)
+		}
+	})
+
+	t.Run("first", func(t *testing.T) {
+		claims := &mockSignalClaimRepo{}
+		_, m := run(t, claims)
+		if len(m.Orders()) != 1 {
+			t.Fatalf("expected the entry to be placed, got %+v", m.Orders())
+		}
+		if len(claims.claims) != 1 || claims.claims[0].SignalID != 11 || claims.claims[0].Fingerprint == "" {
+			t.Fatalf("unexpected claims %+v", claims.claims)
+		}
+	})
+}
+
+func TestOrderControllerChecksLiquidity(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalLimits := liquidityLimits
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		liquidityLimits = originalLimits
+	}()
+
+	run := func(t *testing.T, limits liquidity.Limits) (*mockOrderRepo, *testsupport.MockExchange) {
+		t.Helper()
+		liquidityLimits = func() liquidity.Limits { return limits }
+		orderRepo := &mockOrderRepo{}
+		newTradingSignalRepo = func() tradingSignalRepository {
+			return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+		}
+		newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+		newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+		newOrderRepo = func() orderRepository { return orderRepo }
+		newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+
+		// The 0.001 BTC entry sweeps two levels, averaging 50bps off the touch.
+		m := newPhemexMock(t).WithPositions(flatBTC).WithOrderBook("BTCUSDT",
+			[]testsupport.BookLevel{{"49990", "1"}},
+			[]testsupport.BookLevel{{"50000", "0.0005"}, {"50500", "0.01"}},
+		)
+		user := &model.User{ID: 1, Username: "tester"}
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", flatSessionUserExchange(50)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return orderRepo, m
+	}
+
+	t.Run("within limits", func(t *testing.T) {
+		_, m := run(t, liquidity.Limits{MaxSpreadBps: 10, MaxSlippageBps: 60})
+		if orders := m.Orders(); len(orders) != 1 || orders[0].OrdType != "Market" {
+			t.Fatalf("expected a market entry, got %+v", orders)
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		orderRepo, m := run(t, liquidity.Limits{MaxSlippageBps: 20, Fallback: liquidity.FallbackAbort})
+		if len(m.Orders()) != 0 {
+			t.Fatalf("thin book entry must not be sent, got %+v", m.Orders())
+		}
+		if orderRepo.order == nil || orderRepo.order.Status != model.OrderExecutionStatusFiltered {
+			t.Fatalf("expected a filtered order, got %+v", orderRepo.order)
+		}
+		if len(orderRepo.reasons) != 1 || !strings.HasPrefix(orderRepo.reasons[0], "liquidity: slippage 50.0bps") {
+			t.Fatalf("unexpected reasons %v", orderRepo.reasons)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		orderRepo, m := run(t, liquidity.Limits{MaxSlippageBps: 20, Fallback: liquidity.FallbackLimit})
+		orders := m.Orders()
+		if len(orders) != 1 || orders[0].OrdType != "Limit" || orders[0].PriceRp != "50000" {
+			t.Fatalf("expected a limit entry at the touch, got %+v", orders)
+		}
+		if orderRepo.intents[0].Price != "50000" || orderRepo.order.OrderType != "limit" {
+			t.Fatalf("unexpected intent %+v", orderRepo.intents[0])
+		}
+	})
+}
+
+func TestOrderControllerChecksReferencePrice(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalLimits := priceCheckLimits
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		priceCheckLimits = originalLimits
+	}()
+
+	run := func(t *testing.T, action, index string) (*mockOrderRepo, *testsupport.MockExchange) {
+		t.Helper()
+		priceCheckLimits = func() pricecheck.Limits {
+			return pricecheck.Limits{MaxDivergencePct: 1, Reference: pricecheck.ReferenceIndex, Action: action}
+		}
+		orderRepo := &mockOrderRepo{}
+		newTradingSignalRepo = func() tradingSignalRepository {
+			return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+		}
+		newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+		newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+		newOrderRepo = func() orderRepository { return orderRepo }
+		newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+
+		m := newPhemexMock(t).WithPositions(flatBTC).
+			WithTicker("BTCUSDT", testsupport.Ticker{LastRp: "50000", IndexRp: index})
+		user := &model.User{ID: 1, Username: "tester"}
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", flatSessionUserExchange(50)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return orderRepo, m
+	}
+
+	t.Run("in line", func(t *testing.T) {
+		_, m := run(t, pricecheck.ActionSkip, "49900")
+		if len(m.Orders()) != 1 {
+			t.Fatalf("expected the entry, got %+v", m.Orders())
+		}
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		orderRepo, m := run(t, pricecheck.ActionSkip, "48000")
+		if len(m.Orders()) != 0 {
+			t.Fatalf("diverging entry must not be sent, got %+v", m.Orders())
+		}
+		if orderRepo.order == nil || orderRepo.order.Status != model.OrderExecutionStatusFiltered {
+			t.Fatalf("expected a filtered order, got %+v", orderRepo.order)
+		}
+		if len(orderRepo.reasons) != 1 || !strings.HasPrefix(orderRepo.reasons[0], "price check: venue price") {
+			t.Fatalf("unexpected reasons %v", orderRepo.reasons)
+		}
+	})
+
+	t.Run("alert", func(t *testing.T) {
+		_, m := run(t, pricecheck.ActionAlert, "48000")
+		if len(m.Orders()) != 1 {
+			t.Fatalf("alert mode must still enter, got %+v", m.Orders())
+		}
+	})
+
+	t.Run("reference unavailable", func(t *testing.T) {
+		orderRepo, m := run(t, pricecheck.ActionSkip, "")
+		if len(m.Orders()) != 0 {
+			t.Fatalf("entry without reference must not be sent, got %+v", m.Orders())
+		}
+		if len(orderRepo.reasons) != 1 || !strings.HasPrefix(orderRepo.reasons[0], "price check: reference unavailable") {
+			t.Fatalf("unexpected reasons %v", orderRepo.reasons)
+		}
+	})
+
+	t.Run("reference unavailable alert", func(t *testing.T) {
+		_, m := run(t, pricecheck.ActionAlert, "")
+		if len(m.Orders()) != 1 {
+			t.Fatalf("alert mode must still enter without reference, got %+v", m.Orders())
+		}
+	})
+}
+
+func TestOrderControllerEntersPassively(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalLimits := passiveEntryLimits
+	originalSleep := passiveEntrySleep
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		passiveEntryLimits = originalLimits
+		passiveEntrySleep = originalSleep
+	}()
+	passiveEntryLimits = func() makerentry.Limits {
+		return makerentry.Limits{RepriceEvery: time.Second, MaxReprices: 1, Patience: time.Minute}
+	}
+
+	run := func(t *testing.T, filled bool) *testsupport.MockExchange {
+		t.Helper()
+		newTradingSignalRepo = func() tradingSignalRepository {
+			return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+		}
+		newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+		newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+		newOrderRepo = func() orderRepository { return &mockOrderRepo{} }
+		newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+
+		m := newPhemexMock(t).WithPositions(flatBTC).
+			WithTicker("BTCUSDT", testsupport.Ticker{LastRp: "50000", BidRp: "49990", AskRp: "50000"})
+		passiveEntrySleep = func(context.Context, time.Duration) error {
+			if filled {
+				m.FillResting()
+			}
+			return nil
+		}
+		ue := flatSessionUserExchange(50)
+		ue.EntryMode = model.EntryModePassive
+		user := &model.User{ID: 1, Username: "tester"}
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", ue); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return m
+	}
+
+	t.Run("filled at the bid", func(t *testing.T) {
+		orders := run(t, true).Orders()
+		if len(orders) != 1 || orders[0].OrdType != "Limit" || orders[0].PriceRp != "49990" || orders[0].OrdStatus != "Filled" {
+			t.Fatalf("expected one post-only entry at the bid, got %+v", orders)
+		}
+	})
+
+	t.Run("market after patience", func(t *testing.T) {
+		orders := run(t, false).Orders()
+		if len(orders) != 3 {
+			t.Fatalf("expected two post-only placements and a market entry, got %+v", orders)
+		}
+		for _, o := range orders[:2] {
+			if o.OrdType != "Limit" || o.OrdStatus != "Canceled" {
+				t.Fatalf("expected a cancelled post-only placement, got %+v", o)
+			}
+		}
+		if orders[2].OrdType != "Market" || orders[2].OrderQtyRq != orders[0].OrderQtyRq {
+			t.Fatalf("expected the whole entry at market, got %+v", orders[2])
+		}
+	})
+}
+
+type mockIntentStore struct{ transitions []string }
+
+func (m *mockIntentStore) ListUnresolved(context.Context, uint, uint) ([]model.OrderIntent, error) {
+	return nil, nil
+}
+
+func (m *mockIntentStore) MarkDispatched(context.Context, uint, time.Time) error {
+	m.transitions = append(m.transitions, model.OrderIntentStatusDispatched)
+	return nil
+}
+
+func (m *mockIntentStore) MarkDone(_ context.Context, _ uint, exchangeOrderID string, _ time.Time) error {
+	m.transitions = append(m.transitions, model.OrderIntentStatusDone+" "+exchangeOrderID)
+	return nil
+}
+
+func (m *mockIntentStore) MarkResolved(_ context.Context, _ uint, status, _ string, _ time.Time) error {
+	m.transitions = append(m.transitions, status)
+	return nil
+}
+
+func TestOrderControllerKeepsInterruptedPassiveFill(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalIntents := newOrderIntentRepo
+	originalLimits := passiveEntryLimits
+	originalSleep := passiveEntrySleep
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		newOrderIntentRepo = originalIntents
+		passiveEntryLimits = originalLimits
+		passiveEntrySleep = originalSleep
+	}()
+	passiveEntryLimits = func() makerentry.Limits {
+		return makerentry.Limits{RepriceEvery: time.Second, MaxReprices: 1, Patience: time.Minute}
+	}
+
+	stop := 48000.0
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex", StopLoss: &stop}}}
+	}
+	newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	orderRepo := &mockOrderRepo{}
+	newOrderRepo = func() orderRepository { return orderRepo }
+	newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+	store := &mockIntentStore{}
+	newOrderIntentRepo = func() outbox.Store { return store }
+
+	// The first placement fills in part, the reprice is refused by the
+	// exchange: the fill is a position left to protect.
+	postOnly := func(r testsupport.Request) bool { return strings.Contains(string(r.Body), "PostOnly") }
+	m := newPhemexMock(t).WithPositions(flatBTC).ThenPositions(longBTC).
+		WithTicker("BTCUSDT", testsupport.Ticker{LastRp: "50000", BidRp: "49990", AskRp: "50000"}).
+		Fail(http.MethodPost, "/g-orders", testsupport.Failure{Status: http.StatusInternalServerError, After: 1, Match: postOnly})
+	passiveEntrySleep = func(context.Context, time.Duration) error {
+		m.PartiallyFillResting("0.0005")
+		return nil
+	}
+	ue := flatSessionUserExchange(50)
+	ue.EntryMode = model.EntryModePassive
+	user := &model.User{ID: 1, Username: "tester"}
+	if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", ue); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	orders := m.Orders()
+	if len(orders) != 2 || orders[0].OrdType != "Limit" || orders[0].CumQtyRq != "0.0005" {
+		t.Fatalf("expected the partly filled placement and its stop, no market entry, got %+v", orders)
+	}
+	if orders[1].OrdType != "Stop" || orders[1].StopPxRp != "48000" {
+		t.Fatalf("expected the signal stop placed for the fill, got %+v", orders[1])
+	}
+	if !slices.Contains(orderRepo.statuses, model.OrderExecutionStatusFilled) || slices.Contains(orderRepo.statuses, model.OrderExecutionStatusError) {
+		t.Fatalf("expected the order filled, got %v", orderRepo.statuses)
+	}
+	if !slices.Equal(store.transitions, []string{model.OrderIntentStatusDispatched, model.OrderIntentStatusDone + " mock-1"}) {
+		t.Fatalf("expected the intent dispatched before the placements then done, got %v", store.transitions)
+	}
+}
+
+// TestOrderControllerCombinesPassiveAndMarketFills checks a passive entry
+// filled in part as maker is recorded as the whole entry: with the market
+// rest at their average price, or alone when the rest is refused.
+func TestOrderControllerCombinesPassiveAndMarketFills(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalIntents := newOrderIntentRepo
+	originalLimits := passiveEntryLimits
+	originalSleep := passiveEntrySleep
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		newOrderIntentRepo = originalIntents
+		passiveEntryLimits = originalLimits
+		passiveEntrySleep = originalSleep
+	}()
+	passiveEntryLimits = func() makerentry.Limits {
+		return makerentry.Limits{RepriceEvery: time.Second, MaxReprices: 1, Patience: time.Minute}
+	}
+
+	run := func(t *testing.T, failures ...testsupport.Failure) (*testsupport.MockExchange, *mockOrderRepo, *mockPhemexOrderRepo, *mockIntentStore) {
+		t.Helper()
+		newTradingSignalRepo = func() tradingSignalRepository {
+			return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+		}
+		phemexRepo := &mockPhemexOrderRepo{}
+		newPhemexOrderRepo = func() phemexOrderRepository { return phemexRepo }
+		newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+		orderRepo := &mockOrderRepo{}
+		newOrderRepo = func() orderRepository { return orderRepo }
+		newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+		store := &mockIntentStore{}
+		newOrderIntentRepo = func() outbox.Store { return store }
+
+		m := newPhemexMock(t).WithPositions(flatBTC).ThenPositions(longBTC).
+			WithTicker("BTCUSDT", testsupport.Ticker{LastRp: "50000", BidRp: "49990", AskRp: "50000"})
+		for _, f := range failures {
+			m.Fail(http.MethodPost, "/g-orders", f)
+		}
+		sleeps := 0
+		passiveEntrySleep = func(context.Context, time.Duration) error {
+			if sleeps++; sleeps == 1 {
+				m.PartiallyFillResting("0.0005")
+			}
+			return nil
+		}
+		ue := flatSessionUserExchange(50)
+		ue.EntryMode = model.EntryModePassive
+		user := &model.User{ID: 1, Username: "tester"}
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", ue); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Contains(orderRepo.statuses, model.OrderExecutionStatusFilled) || slices.Contains(orderRepo.statuses, model.OrderExecutionStatusError) {
+			t.Fatalf("expected the order filled, got %v", orderRepo.statuses)
+		}
+		if len(phemexRepo.created) != 1 {
+			t.Fatalf("expected the entry recorded once, got %d", len(phemexRepo.created))
+		}
+		return m, orderRepo, phemexRepo, store
+	}
+
+	t.Run("market rest filled", func(t *testing.T) {
+		m, _, phemexRepo, _ := run(t)
+		orders := m.Orders()
+		if len(orders) != 3 || orders[2].OrdType != "Market" {
+			t.Fatalf("expected two placements and the rest at market, got %+v", orders)
+		}
+		qty, _ := strconv.ParseFloat(orders[0].OrderQtyRq, 64)
+		want := (0.0005*49990 + (qty-0.0005)*50000) / qty
+		if got := phemexRepo.created[0]; math.Abs(got.OrderQty-qty) > 1e-9 || math.Abs(got.Price-want) > 1e-6 {
+			t.Fatalf("expected %v filled at %v, got %v at %v", qty, want, got.OrderQty, got.Price)
+		}
+	})
+
+	t.Run("market rest refused", func(t *testing.T) {
+		market := func(r testsupport.Request) bool {
+			return strings.Contains(string(r.Body), `"Market"`) && !r.ReduceOnly()
+		}
+		_, _, phemexRepo, store := run(t, testsupport.Failure{Code: 11001, Msg: "lot size", Match: market})
+		if got := phemexRepo.created[0]; got.OrderQty != 0.0005 || got.Price != 49990 {
+			t.Fatalf("expected the maker fill recorded, got %v at %v", got.OrderQty, got.Price)
+		}
+		if last := store.transitions[len(store.transitions)-1]; last != model.OrderIntentStatusDone+" mock-2" {
+			t.Fatalf("expected the intent done by the maker fill, got %v", store.transitions)
+		}
+	})
+}
+
+func TestOrderControllerTopsUpFuturesBeforeEntry(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+	}()
+
+	run := func(t *testing.T, below int64) *testsupport.MockExchange {
+		t.Helper()
+		newTradingSignalRepo = func() tradingSignalRepository {
+			return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+		}
+		newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+		newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+		newOrderRepo = func() orderRepository { return &mockOrderRepo{} }
+		newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		userExchange := flatSessionUserExchange(50)
+		userExchange.TopupBelowUSDT = decimal.NewFromInt(below)
+		userExchange.TopupAmountUSDT = decimal.NewFromInt(100)
+		user := &model.User{ID: 1, Username: "tester"}
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", userExchange); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return m
+	}
+
+	t.Run("below threshold", func(t *testing.T) {
+		m := run(t, 150)
+		if n := m.Count(http.MethodPost, "/assets/transfer"); n != 1 {
+			t.Fatalf("expected one transfer, got %d", n)
+		}
+		// 50% of the 200 USDT available after the top-up.
+		if orders := m.Orders(); len(orders) != 1 || orders[0].OrderQtyRq != "0.0020" {
+			t.Fatalf("expected the entry sized on the topped up balance, got %+v", orders)
+		}
+	})
+
+	t.Run("above threshold", func(t *testing.T) {
+		m := run(t, 50)
+		if n := m.Count(http.MethodPost, "/assets/transfer"); n != 0 {
+			t.Fatalf("expected no transfer, got %d", n)
+		}
+		if orders := m.Orders(); len(orders) != 1 || orders[0].OrderQtyRq != "0.0010" {
+			t.Fatalf("unexpected entry %+v", orders)
+		}
+	})
+}
+
+type fakeDailyEntryCounter struct{ counted []string }
+
+func (f *fakeDailyEntryCounter) Increment(_ context.Context, userID uint, symbol, day string) error {
+	f.counted = append(f.counted, fmt.Sprintf("%d %s %s", userID, symbol, day))
+	return nil
+}
+
+func TestOrderControllerCountsDailyEntries(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalEntries := newDailyEntryRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		newDailyEntryRepo = originalEntries
+	}()
+	defer SetClock(func() time.Time { return time.Date(2026, 3, 2, 23, 59, 0, 0, time.UTC) })()
+
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+	}
+	newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	newOrderRepo = func() orderRepository { return &mockOrderRepo{} }
+	newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+	counter := &fakeDailyEntryCounter{}
+	newDailyEntryRepo = func() dailyEntryCounter { return counter }
+
+	m := newPhemexMock(t).WithPositions(flatBTC)
+	user := &model.User{ID: 1, Username: "tester"}
+	if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", flatSessionUserExchange(50)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Orders()) != 1 {
+		t.Fatalf("expected one entry, got %+v", m.Orders())
+	}
+	if want := []string{"1 BTCUSDT 2026-03-02"}; !slices.Equal(counter.counted, want) {
+		t.Fatalf("counted %v, want %v", counter.counted, want)
+	}
+}
+
+type fakeRecentTrades struct{ pnls []float64 }
+
+func (f fakeRecentTrades) FindRecentClosed(_ context.Context, _, _ uint, _ string, limit int) ([]model.Trade, error) {
+	var trades []model.Trade
+	for i, pnl := range f.pnls {
+		if i == limit {
+			break
+		}
+		trades = append(trades, model.Trade{ID: uint(i + 1), PnL: &pnl})
+	}
+	return trades, nil
+}
+
+func TestOrderControllerSizesOnPerformance(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalTrades := newRecentTradeRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		newRecentTradeRepo = originalTrades
+	}()
+
+	run := func(t *testing.T, pnls ...float64) string {
+		t.Helper()
+		newTradingSignalRepo = func() tradingSignalRepository {
+			return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+		}
+		newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+		newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+		newOrderRepo = func() orderRepository { return &mockOrderRepo{} }
+		newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+		newRecentTradeRepo = func() recentTradeSource { return fakeRecentTrades{pnls: pnls} }
+
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		ue := flatSessionUserExchange(50)
+		ue.PerfSizeStep = decimal.NewFromFloat(0.5)
+		ue.PerfSizeWinWindow = 2
+		ue.PerfSizeLossWindow = 1
+		user := &model.User{ID: 1, Username: "tester"}
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", ue); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		orders := m.Orders()
+		if len(orders) != 1 {
+			t.Fatalf("expected one entry, got %+v", orders)
+		}
+		return orders[0].OrderQtyRq
+	}
+
+	// 50% of the 100 USDT available is 0.0010 BTC.
+	for name, tc := range map[string]struct {
+		pnls []float64
+		want string
+	}{
+		"no closed trades":     {want: "0.0010"},
+		"after two wins":       {pnls: []float64{12, 3, -5}, want: "0.0020"},
+		"after a losing trade": {pnls: []float64{-4, 12, 3}, want: "0.0005"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := run(t, tc.pnls...); got != tc.want {
+				t.Fatalf("entry of %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOrderControllerSwingStops(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+	}()
+
+	signalStop := 40000.0
+	signal := externalmodel.TradingSignal{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex", StopLoss: &signalStop}
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{signal}}
+	}
+	newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	user := &model.User{ID: 1, Username: "tester"}
+
+	run := func(t *testing.T, m *testsupport.MockExchange, orderRepo *mockOrderRepo, ohlcv *mockOHLCVRepo) {
+		t.Helper()
+		newOrderRepo = func() orderRepository { return orderRepo }
+		newOHLCVRepo = func() ohlcvRepository { return ohlcv }
+		userExchange := flatSessionUserExchange(50)
+		userExchange.StopMode = string(tp_sl.StopModeSwing)
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", userExchange); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	t.Run("entry stop at the swing low", func(t *testing.T) {
+		m := newPhemexMock(t).WithPositions(flatBTC).ThenPositions(longBTC)
+		orderRepo := &mockOrderRepo{}
+		run(t, m, orderRepo, &mockOHLCVRepo{swingStop: decimal.NewFromInt(48500), swingFound: true})
+
+		if !orderRepo.order.StopLossPct.Equal(decimal.NewFromInt(48500)) {
+			t.Fatalf("expected the swing stop on the order, got %v", orderRepo.order.StopLossPct)
+		}
+		// 50% of 100 USDT at 50000 is 0.001 BTC, 1500 away from the stop.
+		if risk := orderRepo.order.InitialRisk; risk == nil || math.Abs(*risk-1.5) > 1e-9 {
+			t.Fatalf("expected an initial risk of 1.5, got %v", risk)
+		}
+		orders := m.Orders()
+		if len(orders) != 2 || orders[1].StopPxRp != "48500" {
+			t.Fatalf("expected a stop loss at the swing low, got %+v", orders)
+		}
+	})
+
+	t.Run("no swing keeps the signal stop", func(t *testing.T) {
+		m := newPhemexMock(t).WithPositions(flatBTC).ThenPositions(longBTC)
+		orderRepo := &mockOrderRepo{}
+		run(t, m, orderRepo, &mockOHLCVRepo{})
+
+		if !orderRepo.order.StopLossPct.Equal(decimal.NewFromFloat(signalStop)) {
+			t.Fatalf("expected the signal stop, got %v", orderRepo.order.StopLossPct)
+		}
+	})
+
+	t.Run("trails to the next swing", func(t *testing.T) {
+		m := newPhemexMock(t).WithPositions(longBTC)
+		orderRepo := &mockOrderRepo{findOrder: &model.Order{ID: 7, Symbol: "BTCUSDT", PosSide: "Long", Status: model.OrderExecutionStatusFilled, StopLossPct: decimal.NewFromInt(48500)}}
+		ohlcv := &mockOHLCVRepo{newSL: decimal.NewFromInt(49200), isRaised: true}
+		run(t, m, orderRepo, ohlcv)
+
+		if ohlcv.swingCalls != 1 {
+			t.Fatalf("expected the swing trail to run once, got %d", ohlcv.swingCalls)
+		}
+		if orders := m.Orders(); len(orders) != 1 || orders[0].StopPxRp != "49200" {
+			t.Fatalf("expected the stop moved to 49200, got %+v", orders)
+		}
+	})
+
+	t.Run("leaves the stop to the trailing stop service", func(t *testing.T) {
+		original := trailStopsInService
+		defer func() { trailStopsInService = original }()
+		trailStopsInService = func() bool { return true }
+
+		m := newPhemexMock(t).WithPositions(longBTC)
+		orderRepo := &mockOrderRepo{findOrder: &model.Order{ID: 7, Symbol: "BTCUSDT", PosSide: "Long", Status: model.OrderExecutionStatusFilled, StopLossPct: decimal.NewFromInt(48500)}}
+		ohlcv := &mockOHLCVRepo{newSL: decimal.NewFromInt(49200), isRaised: true}
+		run(t, m, orderRepo, ohlcv)
+
+		if ohlcv.swingCalls != 0 || len(m.Orders()) != 0 {
+			t.Fatalf("expected the controller to leave the stop alone, got %+v", m.Orders())
+		}
+	})
+}
+
+func TestOrderControllerRiskOverrides(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+	}()
+
+	leverage := 20.0
+	signal := externalmodel.TradingSignal{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex", Leverage: &leverage}
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{signal}}
+	}
+	newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	orderRepo := &mockOrderRepo{}
+	newOrderRepo = func() orderRepository { return orderRepo }
+	newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+
+	oneWay := false
+	userExchange := flatSessionUserExchange(50)
+	userExchange.MaxLeverage = decimal.NewFromInt(5)
+	userExchange.MaxPositionNotional = decimal.NewFromInt(25)
+	userExchange.DefaultSLPct = decimal.NewFromInt(2)
+	userExchange.DefaultTPPct = decimal.NewFromInt(4)
+	userExchange.HedgeMode = &oneWay
+
+	m := newPhemexMock(t).WithPositions(flatBTC).ThenPositions(longBTC)
+	user := &model.User{ID: 1, Username: "tester"}
+	if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", userExchange); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var leverages []string
+	for _, r := range m.Requests() {
+		if r.Method == http.MethodPut && r.Path == "/g-positions/leverage" {
+			leverages = append(leverages, r.Query)
+		}
+	}
+	if len(leverages) != 1 || !strings.Contains(leverages[0], "longLeverageRr=5&") {
+		t.Fatalf("expected the leverage capped at 5, got %v", leverages)
+	}
+
+	orders := m.Orders()
+	if len(orders) != 3 {
+		t.Fatalf("expected entry, stop loss and take profit orders, got %+v", orders)
+	}
+	// 50% of 100 USDT is 0.001 BTC at 50000, capped to 25 USDT.
+	if orders[0].OrderQtyRq != "0.0005" || orders[0].PosSide != "Merged" {
+		t.Fatalf("expected a capped entry on the merged position, got %+v", orders[0])
+	}
+	if orders[1].StopPxRp != "49000" || orders[2].StopPxRp != "52000" {
+		t.Fatalf("expected the default exits at 2%% and 4%%, got %+v", orders[1:])
+	}
+	if orderRepo.order.PosSide != "Long" {
+		t.Fatalf("expected the order to keep its direction, got %q", orderRepo.order.PosSide)
+	}
+}
+
+type mockExchangeCapabilityRepo struct{ missing []model.ExchangeCapability }
+
+func (m *mockExchangeCapabilityRepo) Supports(_ context.Context, _ uint, c model.ExchangeCapability) (bool, error) {
+	return !slices.Contains(m.missing, c), nil
+}
+
+func TestOrderControllerExchangeCapabilities(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalCapabilities := newExchangeCapabilityRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		newExchangeCapabilityRepo = originalCapabilities
+	}()
+
+	stop := 48000.0
+	signal := externalmodel.TradingSignal{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex", StopLoss: &stop}
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{signal}}
+	}
+	newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	var orderRepo *mockOrderRepo
+	newOrderRepo = func() orderRepository { return orderRepo }
+	newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+
+	run := func(t *testing.T, missing ...model.ExchangeCapability) []testsupport.Order {
+		t.Helper()
+		orderRepo = &mockOrderRepo{}
+		newExchangeCapabilityRepo = func() exchangeCapabilityRepository {
+			return &mockExchangeCapabilityRepo{missing: missing}
+		}
+		m := newPhemexMock(t).WithPositions(flatBTC).ThenPositions(longBTC)
+		user := &model.User{ID: 1, Username: "tester"}
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", flatSessionUserExchange(50)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return m.Orders()
+	}
+
+	t.Run("no hedge", func(t *testing.T) {
+		orders := run(t, model.CapabilityHedge, model.CapabilityNativeBracket)
+		if len(orders) != 2 || orders[0].PosSide != "Merged" {
+			t.Fatalf("expected a merged entry and its stop, got %+v", orders)
+		}
+	})
+
+	t.Run("no stop orders", func(t *testing.T) {
+		orders := run(t, model.CapabilityStopOrder, model.CapabilityNativeBracket)
+		if len(orders) != 1 || orders[0].PosSide != "Long" || orders[0].StopLossRp != "" {
+			t.Fatalf("expected the entry alone, got %+v", orders)
+		}
+	})
+
+	t.Run("native bracket", func(t *testing.T) {
+		orders := run(t)
+		if len(orders) != 1 || orders[0].OrdType != "Market" || orders[0].StopLossRp != "48000" {
+			t.Fatalf("expected the entry with its stop attached, got %+v", orders)
+		}
+		// The stop the exchange created from the attached price is stored
+		// on the entry for the stop watchdog.
+		if len(orderRepo.brackets) != 1 || orderRepo.brackets[0] != [2]string{"mock-2", ""} {
+			t.Fatalf("expected the attached stop stored on the entry, got %v", orderRepo.brackets)
+		}
+	})
+}
+
+func TestOrderControllerApproval(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	originalNotifier := newNotifier
+	originalLinks := approvalLinkConfig
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+		newNotifier = originalNotifier
+		approvalLinkConfig = originalLinks
+	}()
+
+	signal := externalmodel.TradingSignal{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{signal}}
+	}
+	newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+	approvalLinkConfig = func() (string, string) { return "s3cret", "https://executor.example/" }
+
+	// 50% of 100 USDT is a 50 USDT entry, above the 40 USDT approval notional
+	userExchange := flatSessionUserExchange(50)
+	userExchange.ApprovalNotional = decimal.NewFromInt(40)
+	userExchange.ApprovalTimeoutMinutes = 30
+	userExchange.ApprovalTimeoutAction = model.ApprovalTimeoutApprove
+	user := &model.User{ID: 1, Username: "tester"}
+
+	awaiting := func(age time.Duration) *model.Order {
+		return &model.Order{
+			ID:        5,
+			UserID:    1,
+			Symbol:    "BTCUSDT",
+			Quantity:  decimal.RequireFromString("0.0008"),
+			Status:    model.OrderExecutionStatusAwaitingApproval,
+			CreatedAt: time.Now().Add(-age),
+		}
+	}
+
+	t.Run("large entry waits for approval", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		newNotifier = func() notifier { return rec }
+		orderRepo := &mockOrderRepo{}
+		newOrderRepo = func() orderRepository { return orderRepo }
+
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", userExchange); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(m.Orders()) != 0 {
+			t.Fatalf("expected nothing sent to the exchange, got %+v", m.Orders())
+		}
+		if orderRepo.order == nil || orderRepo.order.Status != model.OrderExecutionStatusAwaitingApproval || len(orderRepo.intents) != 0 {
+			t.Fatalf("expected an order awaiting approval without intent, got %+v", orderRepo.order)
+		}
+		if len(rec.events) != 1 || rec.events[0].Type != notify.EventApprovalRequired {
+			t.Fatalf("expected an approval notification, got %+v", rec.events)
+		}
+		want := "https://executor.example/approvals/1/approve?sig=" + auth.ApprovalSignature("s3cret", 1, auth.ApprovalApprove)
+		if rec.events[0].ApproveURL != want || rec.events[0].RejectURL == "" {
+			t.Fatalf("unexpected links %q %q", rec.events[0].ApproveURL, rec.events[0].RejectURL)
+		}
+	})
+
+	t.Run("pending approval places nothing", func(t *testing.T) {
+		newNotifier = func() notifier { return &recordingNotifier{} }
+		orderRepo := &mockOrderRepo{findOrder: awaiting(time.Minute)}
+		newOrderRepo = func() orderRepository { return orderRepo }
+
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", userExchange); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(m.Orders()) != 0 || orderRepo.order != nil || len(orderRepo.statuses) != 0 {
+			t.Fatalf("expected the order to keep waiting, got %+v %v", m.Orders(), orderRepo.statuses)
+		}
+	})
+
+	t.Run("rejected entry places nothing", func(t *testing.T) {
+		rejected := awaiting(time.Minute)
+		rejected.Status = model.OrderExecutionStatusRejected
+		orderRepo := &mockOrderRepo{findOrder: rejected}
+		newOrderRepo = func() orderRepository { return orderRepo }
+
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", userExchange); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(m.Orders()) != 0 || orderRepo.order != nil {
+			t.Fatalf("expected nothing placed, got %+v", m.Orders())
+		}
+	})
+
+	t.Run("timeout approves the approved quantity", func(t *testing.T) {
+		newNotifier = func() notifier { return &recordingNotifier{} }
+		orderRepo := &mockOrderRepo{findOrder: awaiting(time.Hour)}
+		newOrderRepo = func() orderRepository { return orderRepo }
+
+		m := newPhemexMock(t).WithPositions(flatBTC).ThenPositions(longBTC)
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", userExchange); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(orderRepo.statuses) == 0 || orderRepo.statuses[0] != model.OrderExecutionStatusApproved || orderRepo.reasons[0] != "approval timed out" {
+			t.Fatalf("expected the timeout to approve the order, got %v %v", orderRepo.statuses, orderRepo.reasons)
+		}
+		orders := m.Orders()
+		if len(orders) != 1 || orders[0].ReduceOnly || orders[0].OrderQtyRq != "0.0008" {
+			t.Fatalf("expected one entry of the approved quantity, got %+v", orders)
+		}
+	})
+
+	t.Run("timeout rejects by default", func(t *testing.T) {
+		rejectOnTimeout := *userExchange
+		rejectOnTimeout.ApprovalTimeoutAction = ""
+		orderRepo := &mockOrderRepo{findOrder: awaiting(time.Hour)}
+		newOrderRepo = func() orderRepository { return orderRepo }
+
+		m := newPhemexMock(t).WithPositions(flatBTC)
+		if err := OrderController(context.Background(), phemexClient(m), user, 1, "BTCUSDT", "phemex", &rejectOnTimeout); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(orderRepo.statuses) != 1 || orderRepo.statuses[0] != model.OrderExecutionStatusRejected || len(m.Orders()) != 0 {
+			t.Fatalf("expected the timeout to reject the order, got %v %+v", orderRepo.statuses, m.Orders())
+		}
+	})
+}
+
+func TestOrderControllerShadowMode(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+	}()
+
+	stop := 49000.0
+	signal := externalmodel.TradingSignal{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex", StopLoss: &stop}
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{signal}}
+	}
+	newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	orderRepo := &mockOrderRepo{}
+	newOrderRepo = func() orderRepository { return orderRepo }
+	newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+
+	m := newPhemexMock(t).WithPositions(flatBTC)
+	var calls []connectors.ShadowCall
+	paper := connectors.NewPaperClient(phemexClient(m), connectors.NewPaperAccount(), func(c connectors.ShadowCall) {
+		calls = append(calls, c)
+	})
+
+	user := &model.User{ID: 1, Username: "tester"}
+	if err := OrderController(context.Background(), paper, user, 1, "BTCUSDT", "phemex", flatSessionUserExchange(50)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(m.Orders()) != 0 {
+		t.Fatalf("expected nothing sent to the exchange, got %+v", m.Orders())
+	}
+	if len(calls) == 0 || calls[0].Method != "PlaceOrder" || calls[0].Qty != "0.0010" || calls[0].FillPrice != 50000 {
+		t.Fatalf("expected the entry recorded as a paper fill, got %+v", calls)
+	}
+	var stops int
+	for _, c := range calls {
+		if c.Method == "SetStopLoss" {
+			stops++
+		}
+	}
+	if stops != 1 {
+		t.Fatalf("expected the signal stop recorded, got %+v", calls)
+	}
+	if orderRepo.statuses[len(orderRepo.statuses)-1] != model.OrderExecutionStatusFilled {
+		t.Fatalf("expected the flow to complete on the paper fill, got %v", orderRepo.statuses)
+	}
+}
+
+func TestOrderControllerEntryHalt(t *testing.T) {
+	originalTrading := newTradingSignalRepo
+	originalPhemex := newPhemexOrderRepo
+	originalException := newExceptionRepo
+	originalOrder := newOrderRepo
+	originalOHLCV := newOHLCVRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newPhemexOrderRepo = originalPhemex
+		newExceptionRepo = originalException
+		newOrderRepo = originalOrder
+		newOHLCVRepo = originalOHLCV
+	}()
+
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+	}
+	newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	orderRepo := &mockOrderRepo{}
+	newOrderRepo = func() orderRepository { return orderRepo }
+	newOHLCVRepo = func() ohlcvRepository { return &mockOHLCVRepo{} }
+
+	m := newPhemexMock(t).WithPositions(flatBTC).ThenPositions(longBTC)
+	user := &model.User{ID: 1, Username: "tester"}
+	ctx := WithEntryHalt(context.Background(), "news halt: FOMC")
+	if err := OrderController(ctx, phemexClient(m), user, 1, "BTCUSDT", "phemex", flatSessionUserExchange(50)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if orders := m.Orders(); len(orders) != 0 {
+		t.Fatalf("expected no entry while halted, got %+v", orders)
+	}
+	// nothing is recorded, the signal is evaluated again after the halt
+	if orderRepo.order != nil {
+		t.Fatalf("expected no order recorded, got %+v", orderRepo.order)
+	}
 }
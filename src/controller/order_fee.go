@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+
+	logger "github.com/sirupsen/logrus"
+
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/fees"
+	"strategyexecutor/src/model"
+)
+
+// phemexFeeCurrency is the settle currency of the Phemex USDT perpetuals, in
+// which execFeeRv is charged.
+const phemexFeeCurrency = "USDT"
+
+// newFeeSchedule builds the fee rates used for estimates, tests replace it.
+var newFeeSchedule = func() *fees.Schedule {
+	return fees.New(fees.GetConfig())
+}
+
+// phemexOrderFee returns the fee of the Phemex order exchangeOrderID. Market
+// orders usually fill before the call returns, so the fills are read first;
+// when they are not available yet the fee is estimated from the taker rate of
+// exchange on notional and estimated is true.
+func phemexOrderFee(
+	ctx context.Context,
+	client connectors.Connector,
+	exchange string,
+	symbol string,
+	exchangeOrderID string,
+	notional float64,
+) (fee float64, estimated bool) {
+	if lister, ok := client.(connectors.FillLister); ok && exchangeOrderID != "" {
+		fills, err := lister.ListFills(symbol)
+		if err != nil {
+			logger.WithContext(ctx).WithError(err).WithField("symbol", symbol).
+				Warn("failed to list fills, estimating order fee")
+		} else if total, n := fees.SumPhemexFills(fills, exchangeOrderID); n > 0 {
+			return total, false
+		}
+	}
+	return newFeeSchedule().Estimate(exchange, notional, false), true
+}
+
+// recordOrderFee stores fee on the order and, when it comes from the fills,
+// on the persisted Phemex order. Failures are logged only: the fee is
+// informational and must not fail a placed order.
+func recordOrderFee(
+	ctx context.Context,
+	orderRepo orderRepository,
+	phemexRepo phemexOrderRepository,
+	order *model.Order,
+	exchangeOrderID string,
+	fee float64,
+	estimated bool,
+) {
+	order.Fee = fee
+	order.FeeCurrency = phemexFeeCurrency
+	order.FeeEstimated = estimated
+
+	if err := orderRepo.UpdateFee(ctx, order.ID, fee, phemexFeeCurrency, estimated); err != nil {
+		logger.WithContext(ctx).WithError(err).WithField("order_id", order.ID).Error("failed to store order fee")
+	}
+	if estimated || phemexRepo == nil {
+		return
+	}
+	if err := phemexRepo.UpdateFee(ctx, exchangeOrderID, fee, phemexFeeCurrency); err != nil {
+		logger.WithContext(ctx).WithError(err).WithField("exchange_order_id", exchangeOrderID).Error("failed to store phemex order fee")
+	}
+}
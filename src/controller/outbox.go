@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/outbox"
+	"strategyexecutor/src/repository"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// newOrderIntentRepo returns nil when the database is not initialised, in
+// which case orders are placed directly without outbox bookkeeping.
+var newOrderIntentRepo = func() outbox.Store {
+	if database.MainDB == nil {
+		return nil
+	}
+	return repository.NewOrderIntentRepository()
+}
+
+// newPlaceIntent describes the exchange call that will execute order.
+func newPlaceIntent(order *model.Order, qty, ordType string, reduce bool) *model.OrderIntent {
+	return &model.OrderIntent{
+		Symbol:     order.Symbol,
+		Side:       order.Side,
+		PosSide:    order.PosSide,
+		Quantity:   qty,
+		OrderType:  ordType,
+		ReduceOnly: reduce,
+		Status:     model.OrderIntentStatusPending,
+	}
+}
+
+func newDispatcher(client connectors.Connector) *outbox.Dispatcher {
+	store := newOrderIntentRepo()
+	if store == nil {
+		return nil
+	}
+	d := &outbox.Dispatcher{Store: store, Exchange: outbox.ExchangeFor(client), Now: clock}
+	if l := passiveEntryLimits(); l.Enabled() {
+		d.Placements = l.MaxReprices + 1
+	}
+	return d
+}
+
+// placeOrder executes intent through the outbox, or directly when there is
+// no database.
+func placeOrder(ctx context.Context, client connectors.Connector, intent *model.OrderIntent) (*connectors.APIResponse, error) {
+	d := newDispatcher(client)
+	if d != nil {
+		return d.Dispatch(ctx, intent)
+	}
+	if intent.Price == "" && intent.StopLossPrice == "" && intent.TakeProfitPrice == "" {
+		return client.PlaceOrder(intent.Symbol, intent.Side, intent.PosSide, intent.Quantity, intent.OrderType, intent.ReduceOnly)
+	}
+	if intent.ClientOrderID == "" {
+		intent.ClientOrderID = fmt.Sprintf("go-%d", time.Now().UnixNano())
+	}
+	return outbox.Send(outbox.ExchangeFor(client), intent)
+}
+
+// resolveOutbox settles the intents an earlier run left unfinished before
+// the controller looks at the signal again.
+func resolveOutbox(ctx context.Context, client connectors.Connector, orders outbox.OrderUpdater, userID, exchangeID uint) error {
+	d := newDispatcher(client)
+	if d == nil {
+		return nil
+	}
+	settled, err := d.Resolve(ctx, orders, userID, exchangeID)
+	if settled > 0 {
+		logger.WithFields(map[string]interface{}{
+			"user_id":     userID,
+			"exchange_id": exchangeID,
+			"settled":     settled,
+		}).Warn("settled interrupted exchange calls")
+	}
+	return err
+}
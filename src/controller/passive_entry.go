@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/makerentry"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/outbox"
+	"time"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+var (
+	passiveEntryLimits = func() makerentry.Limits {
+		return makerentry.GetConfig().Limits()
+	}
+	// passiveEntrySleep waits between reprices, time based when nil.
+	passiveEntrySleep func(ctx context.Context, d time.Duration) error
+)
+
+// placeEntry sends the entry intent describes. Accounts in the passive entry
+// mode work it as a post-only order at the touch first and send what is
+// left at market; intents the liquidity check priced are sent as they are.
+// A passive entry interrupted after a maker fill, or whose rest is refused,
+// goes on with what filled. The response returned reports the whole entry,
+// maker and market fills together.
+func placeEntry(ctx context.Context, client connectors.Connector, ue *model.UserExchange, intent *model.OrderIntent) (*connectors.APIResponse, error) {
+	if ue == nil || ue.EntryMode != model.EntryModePassive || intent.Price != "" {
+		return placeOrder(ctx, client, intent)
+	}
+	log := logger.WithContext(ctx).WithField("symbol", intent.Symbol)
+	ex, ok := client.(makerentry.Exchange)
+	if !ok {
+		log.Warn("connector cannot rest post-only orders, entering at market")
+		return placeOrder(ctx, client, intent)
+	}
+	qty, err := decimal.NewFromString(intent.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry quantity %q: %w", intent.Quantity, err)
+	}
+	prefix := intent.ClientOrderID
+	if prefix == "" {
+		prefix = fmt.Sprintf("go-%d", clock().UnixNano())
+	}
+
+	// The placements reach the exchange from here on: a crash must leave
+	// the intent to be looked up, not failed as never started.
+	store := newOrderIntentRepo()
+	if store != nil && intent.ID != 0 {
+		if err := store.MarkDispatched(ctx, intent.ID, clock()); err != nil {
+			return nil, fmt.Errorf("mark intent %d dispatched: %w", intent.ID, err)
+		}
+	}
+
+	w := &makerentry.Worker{Exchange: ex, Now: clock, Sleep: passiveEntrySleep}
+	res, err := w.Run(ctx, makerentry.Order{
+		Symbol:        intent.Symbol,
+		Side:          intent.Side,
+		PosSide:       intent.PosSide,
+		Qty:           qty,
+		ClientOrderID: prefix,
+	}, passiveEntryLimits())
+	log = log.WithFields(map[string]interface{}{
+		"filled":     res.Filled,
+		"remaining":  res.Remaining,
+		"placements": res.Placements,
+	})
+	if err != nil {
+		// What filled is a position to protect: it goes on as the entry,
+		// the rest is not sent while a placement may still rest.
+		if !res.Filled.IsPositive() || res.Resp == nil {
+			return nil, fmt.Errorf("passive entry: %w", err)
+		}
+		log.WithError(err).Error("passive entry interrupted, keeping what filled as maker")
+		markPassiveDone(ctx, store, intent, res.Resp, log)
+		return withEntryFill(res.Resp, res.Filled, res.FilledValue), nil
+	}
+
+	if !res.Remaining.IsPositive() && res.Resp != nil {
+		markPassiveDone(ctx, store, intent, res.Resp, log)
+		log.Info("entry filled passively")
+		return withEntryFill(res.Resp, res.Filled, res.FilledValue), nil
+	}
+
+	intent.Quantity = res.Remaining.String()
+	log.Info("passive entry patience ran out, sending the rest at market")
+	resp, err := placeOrder(ctx, client, intent)
+	if !res.Filled.IsPositive() || res.Resp == nil {
+		return resp, err
+	}
+	if err == nil && resp.Code == 0 {
+		qty, value := entryFill(resp)
+		return withEntryFill(resp, res.Filled.Add(qty), res.FilledValue.Add(value)), nil
+	}
+
+	// The rest was refused, e.g. below the lot size: the maker fill goes on
+	// as the entry. An intent whose outcome is unknown is left to Resolve.
+	if err == nil {
+		err = resp.Err()
+	}
+	log.WithError(err).Error("rest of the passive entry failed, keeping what filled as maker")
+	if intent.Status != model.OrderIntentStatusDispatched {
+		markPassiveDone(ctx, store, intent, res.Resp, log)
+	}
+	return withEntryFill(res.Resp, res.Filled, res.FilledValue), nil
+}
+
+// entryFill returns the filled quantity and quote value of the order resp
+// answers, its ordered quantity at its price when it reports no fills.
+func entryFill(resp *connectors.APIResponse) (qty, value decimal.Decimal) {
+	var payload model.PhemexOrderResponse
+	_ = json.Unmarshal(resp.Data, &payload)
+	price, _ := decimal.NewFromString(payload.PriceRp)
+	if qty, _ = decimal.NewFromString(payload.CumQtyRq); !qty.IsPositive() {
+		qty, _ = decimal.NewFromString(payload.OrderQtyRq)
+	}
+	if value, _ = decimal.NewFromString(payload.CumValueRv); !value.IsPositive() {
+		value = qty.Mul(price)
+	}
+	return qty, value
+}
+
+// withEntryFill returns resp reporting the whole entry instead of its last
+// order: qty filled worth value, at their volume weighted average price.
+func withEntryFill(resp *connectors.APIResponse, qty, value decimal.Decimal) *connectors.APIResponse {
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(resp.Data, &data); err != nil || data == nil || !qty.IsPositive() {
+		return resp
+	}
+	for key, v := range map[string]decimal.Decimal{
+		"orderQtyRq": qty,
+		"cumQtyRq":   qty,
+		"cumValueRv": value,
+		"priceRp":    value.Div(qty).Round(8),
+	} {
+		data[key], _ = json.Marshal(v.String())
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return resp
+	}
+	combined := *resp
+	combined.Data = raw
+	return &combined
+}
+
+// markPassiveDone records the intent as done by the last accepted post-only
+// placement resp.
+func markPassiveDone(ctx context.Context, store outbox.Store, intent *model.OrderIntent, resp *connectors.APIResponse, log *logger.Entry) {
+	if store == nil || intent.ID == 0 {
+		return
+	}
+	var payload struct {
+		OrderID string `json:"orderID"`
+	}
+	_ = json.Unmarshal(resp.Data, &payload)
+	if err := store.MarkDone(ctx, intent.ID, payload.OrderID, clock()); err != nil {
+		log.WithError(err).Error("failed to mark intent done")
+	}
+}
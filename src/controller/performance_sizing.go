@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/risk"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+type recentTradeSource interface {
+	FindRecentClosed(ctx context.Context, userID, exchangeID uint, symbol string, limit int) ([]model.Trade, error)
+}
+
+// newRecentTradeRepo returns nil when the database is not initialised, in
+// which case entries are not sized on performance.
+var newRecentTradeRepo = func() recentTradeSource {
+	if database.MainDB == nil {
+		return nil
+	}
+	return repository.NewTradeRepository()
+}
+
+// applyPerformanceSizing scales size with the recent wins and losses of the
+// account on symbol, see risk.PerformanceSizeConfig. Without the setting or
+// the trades the size is returned as it is.
+func applyPerformanceSizing(ctx context.Context, ue *model.UserExchange, userID, exchangeID uint, symbol string, size decimal.Decimal) decimal.Decimal {
+	cfg := risk.NewPerformanceSizeConfigFromUserExchange(ue)
+	if !cfg.Enabled() || !size.IsPositive() {
+		return size
+	}
+	trades := newRecentTradeRepo()
+	if trades == nil {
+		return size
+	}
+	log := logger.WithContext(ctx).WithField("symbol", symbol)
+	recent, err := trades.FindRecentClosed(ctx, userID, exchangeID, symbol, cfg.Lookback())
+	if err != nil {
+		log.WithError(err).Warn("failed to load recent trades, entry not sized on performance")
+		return size
+	}
+
+	factor, streak := risk.PerformanceSizeFactor(recent, cfg)
+	if factor.Equal(decimal.NewFromInt(1)) {
+		return size
+	}
+	log.WithFields(map[string]interface{}{
+		"streak": streak,
+		"factor": factor,
+	}).Info("entry size scaled on recent performance")
+	return size.Mul(factor)
+}
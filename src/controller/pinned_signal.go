@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/externalmodel"
+)
+
+type pinnedSignalKey struct{}
+
+// WithSignal makes the controllers act on signal instead of the latest
+// signal of the symbol. It is used to re-dispatch the signal of a failed
+// order during manual recovery, and by the executor loop to act on the
+// signal it recorded as dispatched.
+func WithSignal(ctx context.Context, signal externalmodel.TradingSignal) context.Context {
+	return context.WithValue(ctx, pinnedSignalKey{}, signal)
+}
+
+// latestSignals returns the signal pinned with WithSignal, or the latest
+// signal of symbol on exchange.
+func latestSignals(ctx context.Context, repo tradingSignalRepository, symbol, exchange string) ([]externalmodel.TradingSignal, error) {
+	if signal, ok := ctx.Value(pinnedSignalKey{}).(externalmodel.TradingSignal); ok {
+		return []externalmodel.TradingSignal{signal}, nil
+	}
+	return repo.FindLatest(ctx, symbol, exchange, 1)
+}
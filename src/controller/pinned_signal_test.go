@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/externalmodel"
+	"testing"
+)
+
+func TestLatestSignalsPrefersPinnedSignal(t *testing.T) {
+	repo := &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 20, Symbol: "BTCUSDT", Action: "sell"}}}
+
+	got, err := latestSignals(context.Background(), repo, "BTCUSDT", "phemex")
+	if err != nil || len(got) != 1 || got[0].ID != 20 {
+		t.Fatalf("expected latest signal 20, got %+v (%v)", got, err)
+	}
+
+	ctx := WithSignal(context.Background(), externalmodel.TradingSignal{ID: 10, Symbol: "BTCUSDT", Action: "buy"})
+	got, err = latestSignals(ctx, repo, "BTCUSDT", "phemex")
+	if err != nil || len(got) != 1 || got[0].ID != 10 {
+		t.Fatalf("expected pinned signal 10, got %+v (%v)", got, err)
+	}
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/pricecheck"
+	"strategyexecutor/src/repository"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type priceCandleSource interface {
+	FetchOHLCV1mRange(ctx context.Context, symbol string, from time.Time, to time.Time) ([]model.OHLCVCrypto1m, error)
+}
+
+var (
+	priceCheckLimits = func() pricecheck.Limits {
+		return pricecheck.GetConfig().Limits()
+	}
+	// newPriceCandleSource returns nil when the database is not
+	// initialised, in which case the candle reference is unavailable.
+	newPriceCandleSource = func() priceCandleSource {
+		if database.MainDB == nil {
+			return nil
+		}
+		return repository.NewOHLCVRepositoryRepository()
+	}
+)
+
+// entryPriceCheck compares price, the venue price an entry is sized at, with
+// the reference price. reason is set when they diverge or the check could
+// not be made, skip when the entry must not be sent for it; err tells why
+// the check could not be made.
+func entryPriceCheck(ctx context.Context, client connectors.Connector, symbol string, price float64) (reason string, skip bool, err error) {
+	limits := priceCheckLimits()
+	if !limits.Enabled() {
+		return "", false, nil
+	}
+	reference, err := referencePrice(ctx, client, symbol, limits)
+	if err != nil {
+		return "reference unavailable: " + err.Error(), limits.Action != pricecheck.ActionAlert, err
+	}
+	res, err := pricecheck.Check(price, reference, limits)
+	if err != nil {
+		return "price check failed: " + err.Error(), limits.Action != pricecheck.ActionAlert, err
+	}
+
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"symbol":         symbol,
+		"price":          price,
+		"reference":      reference,
+		"divergence_pct": res.DivergencePct,
+	})
+	if res.Allowed {
+		log.Debug("price check passed")
+		return "", false, nil
+	}
+	if limits.Action == pricecheck.ActionAlert {
+		log.Warn("venue price diverges, entering anyway: " + res.Reason)
+		return res.Reason, false, nil
+	}
+	log.Warn("venue price diverges, skipping entry: " + res.Reason)
+	return res.Reason, true, nil
+}
+
+// referencePrice reads the price of symbol entries are compared with.
+func referencePrice(ctx context.Context, client connectors.Connector, symbol string, limits pricecheck.Limits) (float64, error) {
+	switch limits.Reference {
+	case pricecheck.ReferenceIndex:
+		source, ok := client.(connectors.IndexPriceSource)
+		if !ok {
+			return 0, fmt.Errorf("connector has no index price for %s", symbol)
+		}
+		return source.IndexPrice(symbol)
+	case pricecheck.ReferenceCandles:
+		source := newPriceCandleSource()
+		if source == nil {
+			return 0, fmt.Errorf("no candle store for %s", symbol)
+		}
+		now := clock()
+		candles, err := source.FetchOHLCV1mRange(ctx, symbol, now.Add(-limits.MaxReferenceAge), now)
+		if err != nil {
+			return 0, err
+		}
+		if len(candles) == 0 {
+			return 0, fmt.Errorf("no %s candle in the last %s", symbol, limits.MaxReferenceAge)
+		}
+		return candles[len(candles)-1].Close.InexactFloat64(), nil
+	default:
+		return 0, fmt.Errorf("unknown price check reference %q", limits.Reference)
+	}
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+// mergedPosSide is the Phemex position side of one-way accounts.
+const mergedPosSide = "Merged"
+
+// hedgeMode reports whether userExchange holds long and short positions
+// apart, the default when unset.
+func hedgeMode(userExchange *model.UserExchange) bool {
+	return userExchange == nil || userExchange.HedgeMode == nil || *userExchange.HedgeMode
+}
+
+// entryPosSide is the position side an entry is placed on: posSide on hedged
+// accounts, the merged position on one-way accounts.
+func entryPosSide(hedged bool, posSide string) string {
+	if hedged {
+		return posSide
+	}
+	return mergedPosSide
+}
+
+// capLeverage returns leverage lowered to the MaxLeverage of userExchange.
+func capLeverage(ctx context.Context, userExchange *model.UserExchange, leverage *float64) *float64 {
+	if leverage == nil || userExchange == nil || !userExchange.MaxLeverage.IsPositive() {
+		return leverage
+	}
+	max := userExchange.MaxLeverage.InexactFloat64()
+	if *leverage <= max {
+		return leverage
+	}
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"leverage":     *leverage,
+		"max_leverage": max,
+	}).Warn("signal leverage capped by the account")
+	return &max
+}
+
+// capPositionNotional returns size lowered so that size at price stays within
+// the MaxPositionNotional of userExchange.
+func capPositionNotional(ctx context.Context, userExchange *model.UserExchange, size decimal.Decimal, price float64) decimal.Decimal {
+	if userExchange == nil || !userExchange.MaxPositionNotional.IsPositive() || price <= 0 {
+		return size
+	}
+	max := userExchange.MaxPositionNotional.Div(decimal.NewFromFloat(price))
+	if size.LessThanOrEqual(max) {
+		return size
+	}
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"size":                  size,
+		"capped":                max,
+		"max_position_notional": userExchange.MaxPositionNotional,
+	}).Warn("entry size capped by the account notional limit")
+	return max
+}
+
+// applyDefaultExits sets the stop loss and take profit of signal that it
+// lacks from the default percents of userExchange, measured from price.
+func applyDefaultExits(userExchange *model.UserExchange, signal *externalmodel.TradingSignal, posSide string, price float64) {
+	if userExchange == nil || price <= 0 {
+		return
+	}
+	long := posSide != "Short"
+	if signal.StopLoss == nil && userExchange.DefaultSLPct.IsPositive() {
+		stop := priceOffset(price, userExchange.DefaultSLPct.InexactFloat64(), !long)
+		signal.StopLoss = &stop
+	}
+	if signal.TakeProfit == nil && userExchange.DefaultTPPct.IsPositive() {
+		target := priceOffset(price, userExchange.DefaultTPPct.InexactFloat64(), long)
+		signal.TakeProfit = &target
+	}
+}
+
+// priceOffset is price moved pct percent up, or down when up is false.
+func priceOffset(price, pct float64, up bool) float64 {
+	if up {
+		return price * (1 + pct/100)
+	}
+	return price * (1 - pct/100)
+}
+
+// stopLossPercent is the stop distance of the fixed-size controllers: the
+// account's DefaultSLPct when set, otherwise fallback from the env config.
+func stopLossPercent(userExchange *model.UserExchange, fallback float64) float64 {
+	if userExchange != nil && userExchange.DefaultSLPct.IsPositive() {
+		return userExchange.DefaultSLPct.InexactFloat64()
+	}
+	return fallback
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type signalClaimRepository interface {
+	Claim(ctx context.Context, claim *model.SignalClaim, window time.Duration) (*model.SignalClaim, error)
+}
+
+var (
+	// newSignalClaimRepo returns nil when the database is not initialised,
+	// in which case duplicates are not suppressed.
+	newSignalClaimRepo = func() signalClaimRepository {
+		if database.MainDB == nil {
+			return nil
+		}
+		return repository.NewSignalClaimRepository()
+	}
+	duplicateSignalWindow = func() time.Duration {
+		return GetConfig().DuplicateSignalWindow
+	}
+)
+
+// duplicateSignal claims signal for the user and returns the id of the
+// earlier identical signal it duplicates, or 0 when the signal may proceed.
+func duplicateSignal(ctx context.Context, userID, exchangeID uint, symbol string, signal externalmodel.TradingSignal) (uint, error) {
+	window := duplicateSignalWindow()
+	repo := newSignalClaimRepo()
+	if window <= 0 || repo == nil {
+		return 0, nil
+	}
+
+	previous, err := repo.Claim(ctx, &model.SignalClaim{
+		UserID:      userID,
+		ExchangeID:  exchangeID,
+		Symbol:      symbol,
+		Fingerprint: signal.Fingerprint(),
+		SignalID:    signal.ID,
+		ClaimedAt:   clock(),
+	}, window)
+	if err != nil || previous == nil {
+		return 0, err
+	}
+
+	logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"signal_id":    signal.ID,
+		"duplicate_of": previous.SignalID,
+		"window":       window.String(),
+	}).Info("duplicate signal suppressed")
+	return previous.SignalID, nil
+}
+
+// skipSignal records an entry that was not taken for signalID as a filtered
+// order, so later runs do not evaluate the signal again.
+func skipSignal(ctx context.Context, orderRepo orderRepository, userID, exchangeID, signalID uint, symbol, reason string) error {
+	skipped := &model.Order{
+		UserID:     userID,
+		ExchangeID: exchangeID,
+		ExternalID: signalID,
+		Symbol:     symbol,
+		OrderType:  "market",
+		Status:     model.OrderExecutionStatusFiltered,
+		OrderDir:   model.OrderDirectionEntry,
+	}
+	applyStagger(ctx, skipped)
+	if err := orderRepo.CreateWithAutoLogReason(ctx, skipped, reason); err != nil {
+		logger.WithContext(ctx).WithError(err).WithField("reason", reason).Error("failed to record skipped signal")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/featureflag"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/signalfilter"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type signalFilterSettingsRepository interface {
+	GetByUserID(ctx context.Context, userID uint) (*model.SignalFilterSetting, error)
+}
+
+var (
+	// newSignalFilterSettingsRepo returns nil when the database is not
+	// initialised, in which case only the env configured filters run.
+	newSignalFilterSettingsRepo = func() signalFilterSettingsRepository {
+		if database.MainDB == nil {
+			return nil
+		}
+		return repository.NewSignalFilterSettingsRepository()
+	}
+	newSignalFilterSources = func(client connectors.Connector) signalfilter.Sources {
+		src := signalfilter.Sources{
+			News:      newNewsSentimentRepo(),
+			Positions: client,
+		}
+		if quotes, ok := client.(signalfilter.QuoteSource); ok {
+			src.Quotes = quotes
+		}
+		if live, ok := client.(signalfilter.LiveFundingSource); ok {
+			src.Live = live
+		}
+		if database.MainDB != nil {
+			src.Funding = repository.NewFundingRepository()
+			src.Candles = repository.NewOHLCVRepositoryRepository()
+			src.Trades = repository.NewTradeRepository()
+			src.Streaks = repository.NewLossStreakRepository()
+			src.Schedules = repository.NewScheduleRuleRepository()
+			src.StopOuts = repository.NewStopOutRepository()
+			src.Entries = repository.NewDailyEntryCountRepository()
+		}
+		return src
+	}
+)
+
+// signalFilterSetting loads the filter settings of userID. The news filter
+// falls back to NEWS_SENTIMENT_THRESHOLD / NEWS_SENTIMENT_LOOKBACK when the
+// user did not set a threshold, and is disabled while the news_filter flag is
+// off. Lookup failures are logged and only the env defaults apply.
+func signalFilterSetting(ctx context.Context, userID uint) model.SignalFilterSetting {
+	var setting model.SignalFilterSetting
+	if repo := newSignalFilterSettingsRepo(); repo != nil {
+		s, err := repo.GetByUserID(ctx, userID)
+		if err != nil {
+			logger.WithError(err).
+				WithField("user_id", userID).
+				Warn("failed to load signal filter settings, using defaults")
+		} else if s != nil {
+			setting = *s
+		}
+	}
+
+	if !featureflag.Enabled(ctx, featureflag.NewsFilter, userID) {
+		setting.NewsSentimentThreshold = 0
+		return setting
+	}
+	if setting.NewsSentimentThreshold <= 0 {
+		config := GetConfig()
+		setting.NewsSentimentThreshold = config.NewsSentimentThreshold
+		setting.NewsSentimentLookbackMinutes = int(config.NewsSentimentLookback / time.Minute)
+	}
+	return setting
+}
+
+// signalTime is when signal arrived, zero when unknown.
+func signalTime(signal externalmodel.TradingSignal) time.Time {
+	if signal.ReceivedAt != nil {
+		return *signal.ReceivedAt
+	}
+	if signal.TimestampDT != nil {
+		return *signal.TimestampDT
+	}
+	return time.Time{}
+}
+
+// evaluateSignalFilters runs the pre-trade filter chain of the user for a new
+// entry. An invalid configuration is logged and lets the entry through.
+func evaluateSignalFilters(ctx context.Context, client connectors.Connector, entry signalfilter.Entry) signalfilter.Outcome {
+	chain, err := signalfilter.Build(signalFilterSetting(ctx, entry.UserID), newSignalFilterSources(client))
+	if err != nil {
+		logger.WithError(err).
+			WithField("user_id", entry.UserID).
+			Error("invalid signal filter settings, allowing entry")
+		return signalfilter.Outcome{Allowed: true}
+	}
+
+	outcome := chain.Evaluate(ctx, entry)
+
+	log := logger.WithFields(map[string]interface{}{
+		"symbol":  entry.Symbol,
+		"posSide": entry.PosSide,
+		"reason":  outcome.Reason(),
+	})
+	if outcome.Postponed() {
+		log.Info("entry postponed by signal filters")
+	} else if !outcome.Allowed {
+		log.Warn("entry blocked by signal filters")
+	} else {
+		log.Debug("signal filters passed")
+	}
+	return outcome
+}
@@ -0,0 +1,266 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/signalfilter"
+	"strategyexecutor/src/testsupport"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type mockNewsSentimentRepo struct {
+	net float64
+	err error
+}
+
+func (m *mockNewsSentimentRepo) NetSentimentForSymbol(ctx context.Context, symbol string, window time.Duration, now time.Time) (float64, int, error) {
+	return m.net, 1, m.err
+}
+
+type mockSignalFilterSettingsRepo struct {
+	setting *model.SignalFilterSetting
+}
+
+func (m *mockSignalFilterSettingsRepo) GetByUserID(ctx context.Context, userID uint) (*model.SignalFilterSetting, error) {
+	return m.setting, nil
+}
+
+func TestEvaluateSignalFiltersNews(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold string
+		repo      *mockNewsSentimentRepo
+		posSide   string
+		want      bool
+	}{
+		{name: "disabled", threshold: "0", repo: &mockNewsSentimentRepo{net: -5}, posSide: "Long", want: true},
+		{name: "long blocked", threshold: "1", repo: &mockNewsSentimentRepo{net: -1.2}, posSide: "Long", want: false},
+		{name: "short allowed", threshold: "1", repo: &mockNewsSentimentRepo{net: -1.2}, posSide: "Short", want: true},
+		{name: "repo error fails open", threshold: "1", repo: &mockNewsSentimentRepo{err: errors.New("db down")}, posSide: "Long", want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("NEWS_SENTIMENT_THRESHOLD", tc.threshold)
+
+			original := newNewsSentimentRepo
+			defer func() { newNewsSentimentRepo = original }()
+			newNewsSentimentRepo = func() newsSentimentRepository { return tc.repo }
+
+			entry := signalfilter.Entry{UserID: 1, Symbol: "BTCUSDT", PosSide: tc.posSide, Now: time.Now()}
+			got := evaluateSignalFilters(context.Background(), nil, entry)
+			if got.Allowed != tc.want {
+				t.Fatalf("expected %v, got %v (%s)", tc.want, got.Allowed, got.Reason())
+			}
+		})
+	}
+}
+
+func TestEvaluateSignalFiltersUserSetting(t *testing.T) {
+	// The user setting overrides the env threshold and adds a trading window.
+	t.Setenv("NEWS_SENTIMENT_THRESHOLD", "0")
+
+	originalSettings := newSignalFilterSettingsRepo
+	originalNews := newNewsSentimentRepo
+	defer func() {
+		newSignalFilterSettingsRepo = originalSettings
+		newNewsSentimentRepo = originalNews
+	}()
+	newNewsSentimentRepo = func() newsSentimentRepository { return &mockNewsSentimentRepo{net: 0} }
+	newSignalFilterSettingsRepo = func() signalFilterSettingsRepository {
+		return &mockSignalFilterSettingsRepo{setting: &model.SignalFilterSetting{
+			UserID:                 1,
+			TradingStartHour:       13,
+			TradingEndHour:         20,
+			NewsSentimentThreshold: 1,
+		}}
+	}
+
+	entry := signalfilter.Entry{UserID: 1, Symbol: "BTCUSDT", PosSide: "Long", Now: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)}
+	got := evaluateSignalFilters(context.Background(), nil, entry)
+	if got.Allowed {
+		t.Fatalf("expected entry outside trading hours to be blocked")
+	}
+	if len(got.Results) != 2 || got.Denied()[0].Filter != "time_of_day" {
+		t.Fatalf("unexpected results: %+v", got.Results)
+	}
+
+	entry.Now = time.Date(2025, 1, 6, 14, 0, 0, 0, time.UTC)
+	if got := evaluateSignalFilters(context.Background(), nil, entry); !got.Allowed {
+		t.Fatalf("expected entry inside trading hours to pass: %s", got.Reason())
+	}
+}
+
+func TestOrderControllerRecordsFilteredEntry(t *testing.T) {
+	// A denied entry is stored as a filtered order with the filter reason and
+	// never reaches the exchange.
+	t.Setenv("NEWS_SENTIMENT_THRESHOLD", "1")
+
+	originalTrading := newTradingSignalRepo
+	originalOrder := newOrderRepo
+	originalException := newExceptionRepo
+	originalNews := newNewsSentimentRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newOrderRepo = originalOrder
+		newExceptionRepo = originalException
+		newNewsSentimentRepo = originalNews
+	}()
+
+	orderRepo := &mockOrderRepo{}
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+	}
+	newOrderRepo = func() orderRepository { return orderRepo }
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	newNewsSentimentRepo = func() newsSentimentRepository { return &mockNewsSentimentRepo{net: -3} }
+
+	// Every exchange endpoint fails, so any exchange call would surface as an error.
+	exchange := testsupport.NewMockExchange(t)
+	for _, path := range []string{"/g-accounts/risk-unit", "/md/v3/ticker/24hr", "/g-accounts/positions"} {
+		exchange.Fail(http.MethodGet, path, testsupport.Failure{Status: http.StatusInternalServerError})
+	}
+	exchange.Fail(http.MethodPost, "/g-orders", testsupport.Failure{Status: http.StatusInternalServerError})
+	client := phemexClient(exchange)
+
+	err := OrderController(context.Background(), client, &model.User{ID: 1, Username: "tester"}, 1, "BTCUSDT", "phemex", &model.UserExchange{OrderSizePercent: 50})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if orderRepo.order == nil || orderRepo.order.Status != model.OrderExecutionStatusFiltered {
+		t.Fatalf("expected a filtered order, got %+v", orderRepo.order)
+	}
+	if len(orderRepo.reasons) != 1 || !strings.Contains(orderRepo.reasons[0], "news: deny") {
+		t.Fatalf("unexpected reasons: %v", orderRepo.reasons)
+	}
+
+	// The same signal is not evaluated again once filtered.
+	orderRepo.findOrder = &model.Order{ID: 1, Status: model.OrderExecutionStatusFiltered}
+	orderRepo.order = nil
+	if err := OrderController(context.Background(), client, &model.User{ID: 1, Username: "tester"}, 1, "BTCUSDT", "phemex", &model.UserExchange{OrderSizePercent: 50}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if orderRepo.order != nil {
+		t.Fatalf("did not expect a new order for an already filtered signal")
+	}
+}
+
+func TestOrderControllerPostponesEntryBeforeFunding(t *testing.T) {
+	// A long paying funding within the window records nothing, so the signal
+	// is evaluated again on the next run.
+	originalTrading := newTradingSignalRepo
+	originalOrder := newOrderRepo
+	originalException := newExceptionRepo
+	originalPhemex := newPhemexOrderRepo
+	originalSettings := newSignalFilterSettingsRepo
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newOrderRepo = originalOrder
+		newExceptionRepo = originalException
+		newPhemexOrderRepo = originalPhemex
+		newSignalFilterSettingsRepo = originalSettings
+	}()
+	restore := SetClock(func() time.Time { return time.Date(2025, 1, 6, 7, 50, 0, 0, time.UTC) })
+	defer restore()
+
+	orderRepo := &mockOrderRepo{}
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+	}
+	newOrderRepo = func() orderRepository { return orderRepo }
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+	newSignalFilterSettingsRepo = func() signalFilterSettingsRepository {
+		return &mockSignalFilterSettingsRepo{setting: &model.SignalFilterSetting{UserID: 1, FundingDelayMinutes: 15, FundingDelayMinRateBps: 1}}
+	}
+
+	exchange := newPhemexMock(t).
+		WithPositions(flatBTC).
+		WithTicker("BTCUSDT", testsupport.Ticker{LastRp: "50000", FundingRateRr: "0.0003"})
+
+	err := OrderController(context.Background(), phemexClient(exchange), &model.User{ID: 1, Username: "tester"}, 1, "BTCUSDT", "phemex", &model.UserExchange{OrderSizePercent: 50})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if orderRepo.order != nil || len(exchange.Orders()) != 0 {
+		t.Fatalf("expected the entry to be postponed, got order %+v", orderRepo.order)
+	}
+
+	restore()
+	restore = SetClock(func() time.Time { return time.Date(2025, 1, 6, 8, 1, 0, 0, time.UTC) })
+	if err := OrderController(context.Background(), phemexClient(exchange), &model.User{ID: 1, Username: "tester"}, 1, "BTCUSDT", "phemex", &model.UserExchange{OrderSizePercent: 50}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(exchange.Orders()) != 1 {
+		t.Fatalf("expected the entry once funding passed, got %+v", exchange.Orders())
+	}
+}
+
+type mockScheduleRepo []model.ScheduleRule
+
+func (m mockScheduleRepo) ListForSymbol(ctx context.Context, userID uint, symbol string) ([]model.ScheduleRule, error) {
+	return m, nil
+}
+
+func TestOrderControllerScheduleReducesEntry(t *testing.T) {
+	// A reduce rule active at entry scales the order size by its multiplier.
+	originalTrading := newTradingSignalRepo
+	originalOrder := newOrderRepo
+	originalException := newExceptionRepo
+	originalPhemex := newPhemexOrderRepo
+	originalSources := newSignalFilterSources
+	defer func() {
+		newTradingSignalRepo = originalTrading
+		newOrderRepo = originalOrder
+		newExceptionRepo = originalException
+		newPhemexOrderRepo = originalPhemex
+		newSignalFilterSources = originalSources
+	}()
+	restore := SetClock(func() time.Time { return time.Date(2025, 1, 6, 15, 0, 0, 0, time.UTC) })
+	defer restore()
+
+	newTradingSignalRepo = func() tradingSignalRepository {
+		return &mockTradingSignalRepo{signals: []externalmodel.TradingSignal{{ID: 10, OrderID: "long", Symbol: "BTCUSDT", Action: "buy", ExchangeName: "phemex"}}}
+	}
+	newExceptionRepo = func() exceptionRepository { return &mockExceptionRepo{} }
+	newPhemexOrderRepo = func() phemexOrderRepository { return &mockPhemexOrderRepo{} }
+
+	entryQty := func(rules mockScheduleRepo) string {
+		t.Helper()
+		newOrderRepo = func() orderRepository { return &mockOrderRepo{} }
+		newSignalFilterSources = func(client connectors.Connector) signalfilter.Sources {
+			return signalfilter.Sources{Schedules: rules}
+		}
+		exchange := newPhemexMock(t).WithPositions(flatBTC)
+		err := OrderController(context.Background(), phemexClient(exchange), &model.User{ID: 1, Username: "tester"}, 1, "BTCUSDT", "phemex", &model.UserExchange{OrderSizePercent: 50})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		orders := exchange.Orders()
+		if len(orders) != 1 {
+			t.Fatalf("expected one entry, got %+v", orders)
+		}
+		return orders[0].OrderQtyRq
+	}
+
+	full := entryQty(nil)
+	reduced := entryQty(mockScheduleRepo{{
+		ID: 1, StartDay: 1, StartMinute: 14 * 60, EndDay: 1, EndMinute: 16 * 60,
+		Action: model.ScheduleActionReduce, SizeMultiplier: decimal.NewFromFloat(0.5),
+	}})
+	fullQty, _ := strconv.ParseFloat(full, 64)
+	reducedQty, _ := strconv.ParseFloat(reduced, 64)
+	// the quantity is rounded down to the 0.0001 lot
+	if fullQty <= 0 || reducedQty > fullQty/2 || fullQty/2-reducedQty >= 0.0001 {
+		t.Fatalf("expected half of %s, got %s", full, reduced)
+	}
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/externalmodel"
+)
+
+// signalMatchesStrategy reports whether a signal tagged for a strategy may be
+// executed by strategyName. Untagged signals match every strategy.
+func signalMatchesStrategy(signal externalmodel.TradingSignal, strategyName string) bool {
+	return signal.StrategyTag == "" || strings.EqualFold(signal.StrategyTag, strategyName)
+}
+
+// signalOrderSize is the entry size before risk scaling: the signal quantity
+// when set, otherwise its size percent or the user's order size percent of
+// the available base.
+func signalOrderSize(signal externalmodel.TradingSignal, baseAvail float64, orderSizePercent int) float64 {
+	if signal.Quantity != nil {
+		return *signal.Quantity
+	}
+	if signal.SizePercent != nil {
+		return baseAvail * *signal.SizePercent / 100
+	}
+	return PercentOfFloatSafe(baseAvail, orderSizePercent)
+}
+
+// applySignalLeverage sets the leverage of signal.Leverage before the entry,
+// unless it is already the leverage last set for key.
+func applySignalLeverage(ctx context.Context, client connectors.Connector, key leverageKey, signal externalmodel.TradingSignal) error {
+	if signal.Leverage == nil {
+		return nil
+	}
+	setter, ok := client.(connectors.LeverageSetter)
+	if !ok {
+		return fmt.Errorf("connector cannot set leverage %v for %s", *signal.Leverage, key.symbol)
+	}
+	return leverages.ensure(ctx, key, *signal.Leverage, func(leverage float64) error {
+		_, err := setter.SetLeverage(key.symbol, leverage)
+		return err
+	})
+}
+
+// placeSignalExits rests the stop loss and take profit of the signal against
+// the position just opened and returns their exchange order IDs. The entry
+// is already filled, so failures are logged and returned joined for the
+// caller to capture, not to unwind.
+func placeSignalExits(
+	ctx context.Context,
+	client connectors.Connector,
+	signal externalmodel.TradingSignal,
+	position connectors.GPosition,
+) (stopOrderID string, takeProfitOrderID string, err error) {
+	var errs []string
+	fields := map[string]interface{}{
+		"symbol":  position.Symbol,
+		"posSide": position.PosSide,
+		"size":    position.SizeRq,
+	}
+
+	if signal.StopLoss != nil {
+		stopPx := strconv.FormatFloat(*signal.StopLoss, 'f', -1, 64)
+		resp, err := client.SetStopLossForOpenPosition(position.Symbol, position.PosSide, stopPx, connectors.TriggerByMarkPrice, true)
+		if err == nil && resp != nil {
+			err = resp.Err()
+		}
+		if err != nil {
+			logger.WithContext(ctx).WithFields(fields).WithError(err).Error("failed to place signal stop loss")
+			errs = append(errs, "stop loss: "+err.Error())
+		} else {
+			stopOrderID = responseOrderID(resp)
+		}
+	}
+
+	if signal.TakeProfit != nil {
+		stopPx := strconv.FormatFloat(*signal.TakeProfit, 'f', -1, 64)
+		id, err := placeTakeProfit(client, position, stopPx)
+		if err != nil {
+			logger.WithContext(ctx).WithFields(fields).WithError(err).Error("failed to place signal take profit")
+			errs = append(errs, "take profit: "+err.Error())
+		}
+		takeProfitOrderID = id
+	}
+
+	if len(errs) > 0 {
+		return stopOrderID, takeProfitOrderID, fmt.Errorf("signal exits for %s: %s", position.Symbol, strings.Join(errs, "; "))
+	}
+	return stopOrderID, takeProfitOrderID, nil
+}
+
+func placeTakeProfit(client connectors.Connector, position connectors.GPosition, stopPx string) (string, error) {
+	placer, ok := client.(connectors.TakeProfitPlacer)
+	if !ok {
+		return "", fmt.Errorf("connector cannot place take profit orders")
+	}
+	closeSide := "Sell"
+	if position.Side == "Sell" {
+		closeSide = "Buy"
+	}
+	resp, err := placer.PlaceTakeProfitOrder(position.Symbol, position.PosSide, closeSide, position.SizeRq, stopPx, connectors.TriggerByMarkPrice)
+	if err != nil {
+		return "", err
+	}
+	if err := resp.Err(); err != nil {
+		return "", err
+	}
+	return responseOrderID(resp), nil
+}
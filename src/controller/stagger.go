@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"time"
+)
+
+type staggerKey struct{}
+
+// WithStagger tells the controllers the executor waited d before acting on
+// the signal, so that accounts trading the same signal on the same venue do
+// not all hit the exchange at once. The orders created for the signal
+// record it.
+func WithStagger(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, staggerKey{}, d)
+}
+
+// applyStagger records on order the stagger set with WithStagger, if any.
+func applyStagger(ctx context.Context, order *model.Order) {
+	if d, ok := ctx.Value(staggerKey{}).(time.Duration); ok {
+		order.StaggerMs = d.Milliseconds()
+	}
+}
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+func TestApplyStagger(t *testing.T) {
+	var order model.Order
+	applyStagger(context.Background(), &order)
+	if order.StaggerMs != 0 {
+		t.Fatalf("no stagger expected, got %d", order.StaggerMs)
+	}
+
+	applyStagger(WithStagger(context.Background(), 1500*time.Millisecond), &order)
+	if order.StaggerMs != 1500 {
+		t.Fatalf("expected a 1500ms stagger, got %d", order.StaggerMs)
+	}
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/strategy"
+	"strategyexecutor/src/strategy/signalfollower"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type strategyRepository interface {
+	FindAssigned(ctx context.Context, userID uint, exchangeID uint, symbol string) (*model.Strategy, error)
+	RecordAction(ctx context.Context, action *model.StrategyAction) error
+}
+
+// newStrategyRepo returns nil when the database is not initialised, in which
+// case every account runs the default strategy.
+var newStrategyRepo = func() strategyRepository {
+	if database.MainDB == nil {
+		return nil
+	}
+	return repository.NewStrategyRepository()
+}
+
+// resolveStrategy builds the strategy assigned to user/exchange/symbol. Without
+// an assignment the signal follower is used and the returned assignment is nil.
+func resolveStrategy(
+	ctx context.Context,
+	repo strategyRepository,
+	userID uint,
+	exchangeID uint,
+	symbol string,
+) (strategy.Strategy, *model.Strategy, error) {
+	var assignment *model.Strategy
+	if repo != nil {
+		var err error
+		assignment, err = repo.FindAssigned(ctx, userID, exchangeID, symbol)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load strategy assignment: %w", err)
+		}
+	}
+
+	if assignment == nil {
+		s, err := strategy.New(signalfollower.Name, nil)
+		return s, nil, err
+	}
+
+	var params json.RawMessage
+	if assignment.Params != "" {
+		params = json.RawMessage(assignment.Params)
+	}
+	s, err := strategy.New(assignment.Name, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("build strategy %d: %w", assignment.ID, err)
+	}
+	return s, assignment, nil
+}
+
+// recordStrategyAction stores the decision of an assigned strategy. It is
+// best effort: failures are only logged.
+func recordStrategyAction(
+	ctx context.Context,
+	repo strategyRepository,
+	assignment *model.Strategy,
+	hook strategy.Hook,
+	d strategy.Decision,
+	signalID uint,
+	orderID *uint,
+) {
+	if repo == nil || assignment == nil {
+		return
+	}
+
+	action := &model.StrategyAction{
+		StrategyID: assignment.ID,
+		Hook:       string(hook),
+		Action:     string(d.Action),
+		Side:       d.Side,
+		PosSide:    d.PosSide,
+		Reason:     d.Reason,
+		SignalID:   signalID,
+		OrderID:    orderID,
+	}
+	if err := repo.RecordAction(ctx, action); err != nil {
+		logger.WithError(err).
+			WithField("strategy_id", assignment.ID).
+			Error("failed to record strategy action")
+	}
+}
+
+func strategyID(assignment *model.Strategy) *uint {
+	if assignment == nil {
+		return nil
+	}
+	id := assignment.ID
+	return &id
+}
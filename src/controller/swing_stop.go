@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/tp_sl"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// stopTimeframe is the candle structure stops are placed and trailed on.
+const stopTimeframe = 15 * time.Minute
+
+// stopMode returns the stop mode of userExchange, StopModeSignal when unset.
+func stopMode(userExchange *model.UserExchange) tp_sl.StopMode {
+	if userExchange != nil && tp_sl.StopMode(userExchange.StopMode) == tp_sl.StopModeSwing {
+		return tp_sl.StopModeSwing
+	}
+	return tp_sl.StopModeSignal
+}
+
+func stopSide(posSide string) tp_sl.Side {
+	if posSide == "Short" {
+		return tp_sl.SideShort
+	}
+	return tp_sl.SideLong
+}
+
+// swingEntryStop returns the stop of a swing mode entry: the latest confirmed
+// swing beyond the entry. The signal stop is kept when there is no usable
+// swing or the candles cannot be read.
+func swingEntryStop(ctx context.Context, repo ohlcvRepository, symbol, posSide string, signalStop *float64) *float64 {
+	stop, ok, err := repo.GetSwingStopLoss(ctx, symbol, clock(), stopSide(posSide), stopTimeframe, tp_sl.DefaultSwingStrength)
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"symbol":  symbol,
+		"posSide": posSide,
+	})
+	if err != nil {
+		log.WithError(err).Warn("failed to compute swing stop, keeping signal stop")
+		return signalStop
+	}
+	if !ok {
+		log.Warn("no confirmed swing for the stop, keeping signal stop")
+		return signalStop
+	}
+
+	v := stop.InexactFloat64()
+	log.WithField("stop", v).Info("stop placed beyond the latest swing")
+	return &v
+}
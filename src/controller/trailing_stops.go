@@ -0,0 +1,286 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/cron"
+	"strategyexecutor/src/featureflag"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/tp_sl"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+// floorLookback is the stop timeframe bars the floor stop averages over.
+const floorLookback = 45
+
+// trailCandles is the stop timeframe candles read per symbol by the
+// trailing stop service, enough for the floor and the swing stop.
+const trailCandles = 60
+
+// trailStopsInService reports whether the trailing stop service trails the
+// stops, in which case the controller leaves filled entries alone.
+var trailStopsInService = func() bool {
+	return GetConfig().TrailingStopService
+}
+
+type stopStore interface {
+	UpdateStopLoss(ctx context.Context, orderID uint, stopLoss decimal.Decimal) error
+	UpdateBracket(ctx context.Context, orderID uint, stopOrderID string, takeProfitOrderID string) error
+}
+
+// moveStop moves the stop protecting order to newSL on the exchange and
+// stores it.
+func moveStop(ctx context.Context, client connectors.Connector, repo stopStore, user *model.User, exchange string, hedged bool, order *model.Order, newSL decimal.Decimal) error {
+	slResp, err := client.SetStopLossForOpenPosition(
+		order.Symbol,
+		entryPosSide(hedged, order.PosSide),
+		newSL.String(),
+		connectors.TriggerByMarkPrice,
+		true)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).Error("failed to SetStopLossForOpenPosition")
+		return err
+	}
+
+	// the new stop replaces the stored one, which would otherwise
+	// stay resting as an orphan
+	if stopOrderID := responseOrderID(slResp); stopOrderID != "" {
+		cancelReplacedStop(ctx, client, order.Symbol, order.StopOrderID, stopOrderID)
+		if err := repo.UpdateBracket(ctx, order.ID, stopOrderID, order.TakeProfitOrderID); err != nil {
+			logger.WithContext(ctx).WithError(err).Error("failed to store the stop order ID")
+		}
+	}
+
+	if err := repo.UpdateStopLoss(ctx, order.ID, newSL); err != nil {
+		logger.WithContext(ctx).WithError(err).Error("failed to UpdateStopLoss")
+		return err
+	}
+
+	publishSLMoved(ctx, user, exchange, order, newSL)
+	return nil
+}
+
+// nextStop trails currentSL on the ascending stop timeframe candles, like
+// the GetNextStopLoss and GetNextSwingStopLoss reads of the controller.
+func nextStop(mode tp_sl.StopMode, side tp_sl.Side, currentSL decimal.Decimal, candles []model.OHLCVCrypto1m) (decimal.Decimal, bool) {
+	if mode == tp_sl.StopModeSwing {
+		return tp_sl.ComputeNextSwingStop(side, currentSL, candles, tp_sl.DefaultSwingStrength)
+	}
+	if len(candles) < 2 {
+		return currentSL, false
+	}
+	if need := floorLookback + 2; len(candles) > need {
+		candles = candles[len(candles)-need:]
+	}
+	return tp_sl.ComputeNextStopLossDirectional(side, currentSL, candles, floorLookback)
+}
+
+type trailingAccounts interface {
+	ListRunOnServer(ctx context.Context) ([]model.UserExchange, error)
+}
+
+type trailingUsers interface {
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+type trailingOrders interface {
+	stopStore
+	FindCreatedSince(ctx context.Context, since time.Time) ([]model.Order, error)
+}
+
+type trailingCandles interface {
+	FetchRecentOHLCVAgg(ctx context.Context, symbol string, to time.Time, interval time.Duration, limitAgg int) ([]model.OHLCVCrypto1m, error)
+}
+
+type trailingMaintenance interface {
+	Active(ctx context.Context, exchangeID uint, now time.Time) (*model.MaintenanceWindow, error)
+}
+
+// TrailingStops trails the stops of the open Phemex positions of every
+// server-run account once per closed candle. The candles of a symbol are
+// read once for all the positions on it, instead of once per controller
+// run. Enable TRAILING_STOP_SERVICE on the executors so their controllers
+// leave the stops to it.
+type TrailingStops struct {
+	Accounts trailingAccounts
+	Users    trailingUsers
+	Orders   trailingOrders
+	Candles  trailingCandles
+	// NewClient returns the exchange client of user on ue.
+	NewClient func(ctx context.Context, user *model.User, ue *model.UserExchange) (connectors.Connector, error)
+	// Lookback bounds the age of the entries whose stops are trailed.
+	Lookback time.Duration
+	// Maintenance, when set, leaves the accounts of an exchange alone
+	// during its maintenance windows.
+	Maintenance trailingMaintenance
+}
+
+// trailedPosition is an open entry with the account it was placed on.
+type trailedPosition struct {
+	order   model.Order
+	account *model.UserExchange
+}
+
+// Run trails the stops delay after each close of an every candle, the
+// delay leaving the collector time to store it, until ctx is done.
+func (t *TrailingStops) Run(ctx context.Context, every, delay time.Duration) error {
+	if every <= 0 {
+		return fmt.Errorf("trailing stops: invalid candle interval %s", every)
+	}
+	for {
+		now := clock()
+		next := now.Truncate(every).Add(every).Add(delay)
+		if next.Sub(now) > every {
+			next = next.Add(-every)
+		}
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		if _, err := t.Tick(ctx, clock()); err != nil {
+			logger.WithContext(ctx).WithError(err).Error("trailing stops run failed")
+		}
+	}
+}
+
+// Tick trails every open position once and returns the stops moved. A
+// failure on one symbol or position is logged and the others are still
+// trailed; the first error is returned at the end.
+func (t *TrailingStops) Tick(ctx context.Context, now time.Time) (int, error) {
+	positions, err := t.openPositions(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	bySymbol := map[string][]trailedPosition{}
+	for _, p := range positions {
+		bySymbol[p.order.Symbol] = append(bySymbol[p.order.Symbol], p)
+	}
+	symbols := make([]string, 0, len(bySymbol))
+	for symbol := range bySymbol {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	users := map[uint]*model.User{}
+	moved := 0
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, symbol := range symbols {
+		log := logger.WithContext(ctx).WithField("symbol", symbol)
+		candles, err := t.Candles.FetchRecentOHLCVAgg(ctx, symbol, now, stopTimeframe, trailCandles)
+		if err != nil {
+			log.WithError(err).Error("failed to read candles for trailing stops")
+			fail(fmt.Errorf("candles of %s: %w", symbol, err))
+			continue
+		}
+		for _, p := range bySymbol[symbol] {
+			ok, err := t.trail(ctx, p, candles, users)
+			if err != nil {
+				log.WithError(err).WithFields(map[string]interface{}{
+					"order_id": p.order.ID,
+					"user_id":  p.order.UserID,
+				}).Error("failed to trail stop")
+				fail(err)
+				continue
+			}
+			if ok {
+				moved++
+				cron.AddItems(ctx, 1)
+			}
+		}
+	}
+	return moved, firstErr
+}
+
+// openPositions returns the open entries of the server-run Phemex accounts
+// with trailing enabled, outside of the maintenance windows.
+func (t *TrailingStops) openPositions(ctx context.Context, now time.Time) ([]trailedPosition, error) {
+	accounts, err := t.Accounts.ListRunOnServer(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListRunOnServer: %w", err)
+	}
+	inMaintenance := map[uint]bool{}
+	byAccount := map[[2]uint]*model.UserExchange{}
+	for i := range accounts {
+		ue := &accounts[i]
+		if ue.Exchange == nil || !strings.EqualFold(ue.Exchange.Name, "phemex") {
+			continue
+		}
+		paused, checked := inMaintenance[ue.ExchangeID]
+		if !checked && t.Maintenance != nil {
+			window, err := t.Maintenance.Active(ctx, ue.ExchangeID, now)
+			if err != nil {
+				return nil, fmt.Errorf("maintenance windows: %w", err)
+			}
+			if paused = window != nil; paused {
+				logger.WithContext(ctx).WithFields(map[string]interface{}{
+					"exchange": ue.Exchange.Name,
+					"until":    window.EndsAt,
+				}).Warn("exchange in maintenance, not trailing its stops")
+			}
+			inMaintenance[ue.ExchangeID] = paused
+		}
+		if paused {
+			continue
+		}
+		byAccount[[2]uint{ue.UserID, ue.ExchangeID}] = ue
+	}
+
+	orders, err := t.Orders.FindCreatedSince(ctx, now.Add(-t.Lookback))
+	if err != nil {
+		return nil, fmt.Errorf("FindCreatedSince: %w", err)
+	}
+	var out []trailedPosition
+	for _, entry := range report.OpenEntries(orders) {
+		ue, ok := byAccount[[2]uint{entry.UserID, entry.ExchangeID}]
+		if !ok || !featureflag.Enabled(ctx, featureflag.TrailingStopLoss, entry.UserID) {
+			continue
+		}
+		out = append(out, trailedPosition{order: entry, account: ue})
+	}
+	return out, nil
+}
+
+// trail moves the stop of p when candles raise it and reports whether it
+// moved.
+func (t *TrailingStops) trail(ctx context.Context, p trailedPosition, candles []model.OHLCVCrypto1m, users map[uint]*model.User) (bool, error) {
+	newSL, raised := nextStop(stopMode(p.account), stopSide(p.order.PosSide), p.order.StopLossPct, candles)
+	if !raised {
+		return false, nil
+	}
+
+	user, ok := users[p.order.UserID]
+	if !ok {
+		u, err := t.Users.GetUserByID(ctx, p.order.UserID)
+		if err != nil {
+			return false, fmt.Errorf("GetUserByID: %w", err)
+		}
+		if u == nil {
+			return false, fmt.Errorf("user %d not found", p.order.UserID)
+		}
+		user, users[p.order.UserID] = u, u
+	}
+	client, err := t.NewClient(ctx, user, p.account)
+	if err != nil {
+		return false, err
+	}
+	hedged := hedgeMode(p.account) && exchangeSupports(ctx, p.account.ExchangeID, model.CapabilityHedge)
+	if err := moveStop(ctx, client, t.Orders, user, strings.ToLower(p.account.Exchange.Name), hedged, &p.order, newSL); err != nil {
+		return false, err
+	}
+	return true, nil
+}
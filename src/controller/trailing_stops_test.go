@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type fakeTrailingAccounts []model.UserExchange
+
+func (f fakeTrailingAccounts) ListRunOnServer(context.Context) ([]model.UserExchange, error) {
+	return f, nil
+}
+
+type fakeTrailingUsers struct{}
+
+func (fakeTrailingUsers) GetUserByID(_ context.Context, id uint) (*model.User, error) {
+	return &model.User{ID: id}, nil
+}
+
+type fakeTrailingOrders struct {
+	mockOrderRepo
+	orders []model.Order
+	stops  map[uint]decimal.Decimal
+}
+
+func (f *fakeTrailingOrders) FindCreatedSince(context.Context, time.Time) ([]model.Order, error) {
+	return f.orders, nil
+}
+
+func (f *fakeTrailingOrders) UpdateStopLoss(_ context.Context, orderID uint, stopLoss decimal.Decimal) error {
+	f.stops[orderID] = stopLoss
+	return nil
+}
+
+type fakeTrailingCandles struct {
+	bySymbol map[string][]model.OHLCVCrypto1m
+	reads    map[string]int
+}
+
+func (f *fakeTrailingCandles) FetchRecentOHLCVAgg(_ context.Context, symbol string, _ time.Time, _ time.Duration, _ int) ([]model.OHLCVCrypto1m, error) {
+	f.reads[symbol]++
+	return f.bySymbol[symbol], nil
+}
+
+type fakeTrailingMaintenance map[uint]*model.MaintenanceWindow
+
+func (f fakeTrailingMaintenance) Active(_ context.Context, exchangeID uint, _ time.Time) (*model.MaintenanceWindow, error) {
+	return f[exchangeID], nil
+}
+
+func TestTrailingStopsReadsCandlesOncePerSymbol(t *testing.T) {
+	phemex := &model.Exchange{ID: 1, Name: "phemex"}
+	kraken := &model.Exchange{ID: 2, Name: "kraken"}
+	accounts := fakeTrailingAccounts{
+		{UserID: 1, ExchangeID: 1, Exchange: phemex},
+		{UserID: 2, ExchangeID: 1, Exchange: phemex},
+		{UserID: 3, ExchangeID: 2, Exchange: kraken},
+	}
+	entry := func(id, userID, exchangeID uint, symbol string, stop int64) model.Order {
+		return model.Order{
+			ID: id, UserID: userID, ExchangeID: exchangeID, Symbol: symbol,
+			Side: "Buy", PosSide: "Long", OrderDir: model.OrderDirectionEntry,
+			Status: model.OrderExecutionStatusFilled, Quantity: decimal.NewFromInt(1),
+			StopLossPct: decimal.NewFromInt(stop), CreatedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	orders := &fakeTrailingOrders{stops: map[uint]decimal.Decimal{}, orders: []model.Order{
+		entry(1, 1, 1, "BTCUSDT", 48500),
+		entry(2, 2, 1, "BTCUSDT", 49500),
+		entry(3, 1, 1, "ETHUSDT", 2000),
+		entry(4, 3, 2, "SOLUSDT", 100),
+	}}
+
+	// bullish candles with their lows at 49000
+	var btc []model.OHLCVCrypto1m
+	for i := 0; i < trailCandles; i++ {
+		btc = append(btc, model.OHLCVCrypto1m{
+			Symbol: "BTCUSDT", Open: decimal.NewFromInt(49500), Close: decimal.NewFromInt(50000),
+			High: decimal.NewFromInt(50100), Low: decimal.NewFromInt(49000),
+		})
+	}
+	candles := &fakeTrailingCandles{bySymbol: map[string][]model.OHLCVCrypto1m{"BTCUSDT": btc}, reads: map[string]int{}}
+
+	m := newPhemexMock(t).WithPositions(longBTC)
+	clients := 0
+	service := &TrailingStops{
+		Accounts: accounts,
+		Users:    fakeTrailingUsers{},
+		Orders:   orders,
+		Candles:  candles,
+		NewClient: func(context.Context, *model.User, *model.UserExchange) (connectors.Connector, error) {
+			clients++
+			return phemexClient(m), nil
+		},
+		Lookback: 24 * time.Hour,
+	}
+
+	moved, err := service.Tick(context.Background(), time.Date(2025, 3, 1, 12, 0, 10, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 1 || clients != 1 {
+		t.Fatalf("expected only the stop below the floor moved, moved %d with %d clients", moved, clients)
+	}
+	if !orders.stops[1].Equal(decimal.NewFromInt(49000)) {
+		t.Fatalf("expected order 1 trailed to 49000, got %v", orders.stops)
+	}
+	if orders := m.Orders(); len(orders) != 1 || orders[0].StopPxRp != "49000" {
+		t.Fatalf("expected the stop moved on the exchange, got %+v", orders)
+	}
+	if candles.reads["BTCUSDT"] != 1 || candles.reads["ETHUSDT"] != 1 || candles.reads["SOLUSDT"] != 0 {
+		t.Fatalf("expected one read per symbol of the Phemex positions, got %v", candles.reads)
+	}
+
+	// nothing is trailed on an exchange in maintenance
+	service.Maintenance = fakeTrailingMaintenance{1: {ExchangeID: 1, Title: "upgrade"}}
+	orders.stops = map[uint]decimal.Decimal{}
+	moved, err = service.Tick(context.Background(), time.Date(2025, 3, 1, 12, 1, 10, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 0 || clients != 1 || candles.reads["BTCUSDT"] != 1 {
+		t.Fatalf("expected the exchange in maintenance left alone, moved %d with %d clients and reads %v", moved, clients, candles.reads)
+	}
+}
@@ -3,9 +3,12 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	logger "github.com/sirupsen/logrus"
 	"runtime/debug"
+	"strategyexecutor/src/connectors"
 	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
 	"strings"
 	"time"
 )
@@ -73,8 +76,73 @@ func NormalizeToUSDT(symbol string) string {
 	return s
 }
 
+// errClosePosition marks the failure to close a position, which leaves the
+// account exposed and is always critical.
+var errClosePosition = errors.New("position not closed")
+
+// classifyException returns the severity and category of err captured at
+// level. Rejected credentials and positions left open are critical whatever
+// the level.
+func classifyException(level string, err error) (severity, category string) {
+	var exErr *connectors.ExchangeError
+	switch {
+	case errors.Is(err, connectors.ErrAuth):
+		return model.ExceptionSeverityCritical, model.ExceptionCategoryAuth
+	case errors.Is(err, errClosePosition):
+		return model.ExceptionSeverityCritical, model.ExceptionCategoryPosition
+	case errors.As(err, &exErr):
+		category = model.ExceptionCategoryExchange
+	default:
+		category = model.ExceptionCategoryInternal
+	}
+
+	switch level {
+	case "fatal", model.ExceptionSeverityCritical:
+		return model.ExceptionSeverityCritical, category
+	case "error", "warn", "warning":
+		return model.ExceptionSeverityWarn, category
+	default:
+		return model.ExceptionSeverityInfo, category
+	}
+}
+
+type captureUserKey struct{}
+
+type captureUser struct {
+	user     *model.User
+	exchange string
+}
+
+// withCaptureUser tags ctx with the user and exchange a controller runs
+// for, so Capture can notify them of critical exceptions.
+func withCaptureUser(ctx context.Context, user *model.User, exchange string) context.Context {
+	return context.WithValue(ctx, captureUserKey{}, captureUser{user: user, exchange: exchange})
+}
+
+// notifyCritical sends exc to the user of ctx, when there is one.
+func notifyCritical(ctx context.Context, exc *model.Exception, err error, contextData map[string]interface{}) {
+	cu, ok := ctx.Value(captureUserKey{}).(captureUser)
+	if !ok || cu.user == nil {
+		return
+	}
+	ev := notify.Event{
+		Type:       notify.EventCriticalError,
+		UserID:     cu.user.ID,
+		Username:   cu.user.Username,
+		Exchange:   cu.exchange,
+		Message:    exc.Method,
+		Err:        err,
+		OccurredAt: exc.CreatedAt.UTC(),
+	}
+	if symbol, ok := contextData["symbol"].(string); ok {
+		ev.Symbol = symbol
+	}
+	newNotifier().Notify(ctx, ev)
+}
+
 // Capture records a system exception, logs it locally, and optionally
-// persists it in the database.
+// persists it in the database. Critical exceptions, see classifyException,
+// are also notified to the user the controller runs for.
 func Capture(
 	ctx context.Context,
 	repo interface {
@@ -109,13 +177,22 @@ func Capture(
 		Context:   ctxJSON,
 		CreatedAt: time.Now(),
 	}
+	exc.Severity, exc.Category = classifyException(level, err)
+	if hint, ok := connectors.HintFor(err); ok {
+		exc.Exchange = hint.Exchange
+		exc.ErrorCode = hint.Code
+		exc.Hint = hint.Message
+		exc.Action = hint.Action
+	}
 
 	// Local log
 	logger.WithFields(map[string]interface{}{
-		"service": service,
-		"module":  module,
-		"method":  method,
-		"level":   level,
+		"service":  service,
+		"module":   module,
+		"method":   method,
+		"level":    level,
+		"severity": exc.Severity,
+		"category": exc.Category,
 	}).WithError(err).Error("System exception captured")
 
 	// Persist in database
@@ -124,4 +201,9 @@ func Capture(
 			logger.WithError(e).Error("Failed to persist exception")
 		}
 	}
+
+	publishExceptionRaised(ctx, exc, contextData)
+	if exc.Severity == model.ExceptionSeverityCritical {
+		notifyCritical(ctx, exc, err, contextData)
+	}
 }
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"testing"
+)
+
+type recordingNotifier struct {
+	events []notify.Event
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, ev notify.Event) {
+	n.events = append(n.events, ev)
+}
+
+func TestClassifyException(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		err      error
+		severity string
+		category string
+	}{
+		{"auth", "error", fmt.Errorf("fetch balance: %w", connectors.ErrAuth), model.ExceptionSeverityCritical, model.ExceptionCategoryAuth},
+		{"close position", "error", fmt.Errorf("BTCUSDT: %w", errClosePosition), model.ExceptionSeverityCritical, model.ExceptionCategoryPosition},
+		{"exchange", "error", &connectors.ExchangeError{Exchange: "phemex", Code: "11001"}, model.ExceptionSeverityWarn, model.ExceptionCategoryExchange},
+		{"internal", "info", errors.New("boom"), model.ExceptionSeverityInfo, model.ExceptionCategoryInternal},
+		{"fatal", "fatal", errors.New("boom"), model.ExceptionSeverityCritical, model.ExceptionCategoryInternal},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			severity, category := classifyException(tc.level, tc.err)
+			if severity != tc.severity || category != tc.category {
+				t.Fatalf("expected %s/%s, got %s/%s", tc.severity, tc.category, severity, category)
+			}
+		})
+	}
+}
+
+func TestCaptureNotifiesCritical(t *testing.T) {
+	originalNotifier := newNotifier
+	t.Cleanup(func() { newNotifier = originalNotifier })
+	rec := &recordingNotifier{}
+	newNotifier = func() notifier { return rec }
+
+	user := &model.User{ID: 7, Username: "alice"}
+	ctx := withCaptureUser(context.Background(), user, "phemex")
+
+	Capture(ctx, &mockExceptionRepo{}, "OrderController", "controller", "fetch", "error", errors.New("boom"), nil)
+	if len(rec.events) != 0 {
+		t.Fatalf("expected no notification for a warning, got %d", len(rec.events))
+	}
+
+	err := fmt.Errorf("BTCUSDT Long: %w", errClosePosition)
+	Capture(ctx, &mockExceptionRepo{}, "OrderController", "controller", "closeAllPositions", "error", err, map[string]interface{}{"symbol": "BTCUSDT"})
+	if len(rec.events) != 1 {
+		t.Fatalf("expected one notification, got %d", len(rec.events))
+	}
+	ev := rec.events[0]
+	if ev.Type != notify.EventCriticalError || ev.UserID != 7 || ev.Exchange != "phemex" || ev.Symbol != "BTCUSDT" || ev.Message != "closeAllPositions" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+
+	Capture(context.Background(), &mockExceptionRepo{}, "OrderController", "controller", "closeAllPositions", "error", err, nil)
+	if len(rec.events) != 1 {
+		t.Fatalf("expected no notification without a user in ctx")
+	}
+}
+
+func TestCapturePublishesExceptionRaised(t *testing.T) {
+	originalBus := eventBus
+	t.Cleanup(func() { eventBus = originalBus })
+	eventBus = events.NewBus()
+	var raised []events.ExceptionRaised
+	events.Subscribe(eventBus, func(_ context.Context, ev events.ExceptionRaised) {
+		raised = append(raised, ev)
+	})
+
+	ctx := withCaptureUser(context.Background(), &model.User{ID: 7}, "phemex")
+	Capture(ctx, &mockExceptionRepo{}, "OrderController", "controller", "fetch", "error", errors.New("boom"), map[string]interface{}{"symbol": "BTCUSDT"})
+	Capture(context.Background(), &mockExceptionRepo{}, "OrderController", "controller", "fetch", "info", errors.New("boom"), nil)
+
+	if len(raised) != 2 {
+		t.Fatalf("expected every exception published, got %d", len(raised))
+	}
+	if ev := raised[0]; ev.UserID != 7 || ev.Exchange != "phemex" || ev.Symbol != "BTCUSDT" || ev.Severity != model.ExceptionSeverityWarn || ev.Message != "boom" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if raised[1].UserID != 0 {
+		t.Fatalf("expected no user outside of a controller, got %d", raised[1].UserID)
+	}
+}
@@ -0,0 +1,313 @@
+// Package copytrade mirrors the filled orders of leader accounts to their
+// follower accounts (model.CopyFollower). It consumes the OrderFilled events
+// the controllers publish, so followers copy what the leader actually traded
+// rather than the signals it received.
+package copytrade
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/killswitch"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+// copyClient is the subset of the exchange connector used to copy orders.
+type copyClient interface {
+	GetPositionsUSDT() (*connectors.GAccountPositions, error)
+	PlaceOrder(symbol, side, posSide, qty, ordType string, reduce bool) (*connectors.APIResponse, error)
+}
+
+type followerStore interface {
+	ListByLeader(ctx context.Context, userID, exchangeID uint) ([]model.CopyFollower, error)
+}
+
+type userExchangeStore interface {
+	GetByUserAndExchange(ctx context.Context, userID, exchangeID uint) (*model.UserExchange, error)
+}
+
+type orderStore interface {
+	CreateWithAutoLogReason(ctx context.Context, order *model.Order, reason string) error
+}
+
+// Copier copies the OrderFilled events of leaders to their followers.
+// Only Phemex orders are copied, to follower accounts on the same exchange.
+// The protective orders of the leader are not copied: the follower caps
+// bound the risk of a copied entry.
+type Copier struct {
+	followers     followerStore
+	userExchanges userExchangeStore
+	orders        orderStore
+	newClient     func(creds security.Credentials) (copyClient, error)
+	halted        func(ctx context.Context, userID uint) bool
+}
+
+// New returns a Copier backed by the main database, sending orders to the
+// Phemex live endpoint baseURL.
+func New(baseURL string) *Copier {
+	return &Copier{
+		followers:     repository.NewCopyFollowerRepository(),
+		userExchanges: repository.NewUserExchangeRepository(),
+		orders:        repository.NewOrderRepository(),
+		newClient: func(creds security.Credentials) (copyClient, error) {
+			return connectors.NewClientFor(creds.APIKey, creds.APISecret, creds.Environment, baseURL)
+		},
+		halted: killswitch.Halted,
+	}
+}
+
+// Subscribe makes c copy the OrderFilled events published on bus.
+func (c *Copier) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, c.OnOrderFilled)
+}
+
+// OnOrderFilled copies ev to every enabled follower of its account. A
+// failure on one follower is logged and recorded as an errored order; the
+// remaining followers are still copied.
+func (c *Copier) OnOrderFilled(ctx context.Context, ev events.OrderFilled) {
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"component": "copytrade",
+		"leader_id": ev.UserID,
+		"order_id":  ev.OrderID,
+		"symbol":    ev.Symbol,
+	})
+	if !strings.EqualFold(ev.Exchange, "phemex") {
+		return
+	}
+
+	followers, err := c.followers.ListByLeader(ctx, ev.UserID, ev.ExchangeID)
+	if err != nil {
+		log.WithError(err).Error("failed to list copy followers")
+		return
+	}
+	if len(followers) == 0 {
+		return
+	}
+
+	leader, err := c.account(ctx, ev.UserID, ev.ExchangeID)
+	if err != nil {
+		log.WithError(err).Error("failed to load the leader account")
+		return
+	}
+	if leader.ue.Shadow {
+		log.Debug("shadow leader, orders not copied")
+		return
+	}
+	// Entries are sized on the equity of both accounts, exits close the
+	// follower position whatever its size.
+	leaderEquity := decimal.Zero
+	if ev.OrderDir == model.OrderDirectionEntry {
+		positions, err := leader.client.GetPositionsUSDT()
+		if err != nil {
+			log.WithError(err).Error("failed to load the leader equity")
+			return
+		}
+		leaderEquity = equity(positions)
+	}
+
+	for _, f := range followers {
+		flog := log.WithField("follower_id", f.FollowerUserID)
+		if f.FollowerExchangeID != ev.ExchangeID {
+			flog.Warn("follower on another exchange than its leader, skipping")
+			continue
+		}
+		if c.halted(ctx, f.FollowerUserID) {
+			flog.Warn("emergency stop active for follower, skipping")
+			continue
+		}
+		if err := c.copy(ctx, ev, leaderEquity, f); err != nil {
+			flog.WithError(err).Error("failed to copy order")
+			continue
+		}
+	}
+}
+
+// account is a UserExchange with its exchange client.
+type account struct {
+	ue     *model.UserExchange
+	client copyClient
+}
+
+func (c *Copier) account(ctx context.Context, userID, exchangeID uint) (*account, error) {
+	ue, err := c.userExchanges.GetByUserAndExchange(ctx, userID, exchangeID)
+	if err != nil {
+		return nil, err
+	}
+	if ue == nil {
+		return nil, fmt.Errorf("no exchange account for user %d", userID)
+	}
+	creds, err := security.ResolveCredentials(ctx, ue)
+	if err != nil {
+		return nil, err
+	}
+	client, err := c.newClient(creds)
+	if err != nil {
+		return nil, err
+	}
+	return &account{ue: ue, client: client}, nil
+}
+
+// copy sends the follower order of ev and records it.
+func (c *Copier) copy(ctx context.Context, ev events.OrderFilled, leaderEquity decimal.Decimal, f model.CopyFollower) error {
+	follower, err := c.account(ctx, f.FollowerUserID, f.FollowerExchangeID)
+	if err != nil {
+		return err
+	}
+	if !follower.ue.RunOnServer || follower.ue.Shadow {
+		return fmt.Errorf("follower account is not run on the server")
+	}
+
+	order, sent, err := plan(ev, leaderEquity, follower, f)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return nil
+	}
+
+	reason := fmt.Sprintf("copied from order %d of user %d", ev.OrderID, ev.UserID)
+	resp, sendErr := follower.client.PlaceOrder(ev.Symbol, sent.side, sent.posSide, order.Quantity.String(), "Market", sent.reduce)
+	if sendErr == nil && resp != nil && resp.Code != 0 {
+		sendErr = resp.Err()
+	}
+	order.Status = model.OrderExecutionStatusFilled
+	if sendErr != nil {
+		order.Status = model.OrderExecutionStatusError
+		reason += ": " + sendErr.Error()
+	}
+	if err := c.orders.CreateWithAutoLogReason(ctx, order, reason); err != nil {
+		return fmt.Errorf("record copied order: %w", err)
+	}
+	return sendErr
+}
+
+// orderPlan is how the copied order is sent.
+type orderPlan struct {
+	side    string
+	posSide string
+	reduce  bool
+}
+
+// plan builds the follower order of ev, nil when there is nothing to copy:
+// an exit of a position the follower does not hold, or an entry the caps
+// leave no room for.
+func plan(ev events.OrderFilled, leaderEquity decimal.Decimal, follower *account, f model.CopyFollower) (*model.Order, orderPlan, error) {
+	positions, err := follower.client.GetPositionsUSDT()
+	if err != nil {
+		return nil, orderPlan{}, fmt.Errorf("follower positions: %w", err)
+	}
+	held, rawPosSide := heldPosition(positions, ev.Symbol, ev.PosSide)
+
+	order := &model.Order{
+		UserID:     f.FollowerUserID,
+		ExchangeID: f.FollowerExchangeID,
+		Symbol:     ev.Symbol,
+		OrderType:  "market",
+		OrderDir:   ev.OrderDir,
+	}
+	if !ev.Price.IsZero() {
+		price := ev.Price
+		order.Price = &price
+	}
+
+	if ev.OrderDir == model.OrderDirectionExit {
+		if held.IsZero() {
+			return nil, orderPlan{}, nil
+		}
+		// Same convention as the exits of the controller.
+		order.Side, order.PosSide, order.Quantity = ev.PosSide, ev.Side, held
+		return order, orderPlan{side: ev.Side, posSide: rawPosSide, reduce: true}, nil
+	}
+
+	qty, err := copySize(ev.Quantity, ev.Price, leaderEquity, equity(positions), held, f)
+	if err != nil {
+		return nil, orderPlan{}, err
+	}
+	if qty.IsZero() {
+		return nil, orderPlan{}, nil
+	}
+
+	posSide := ev.PosSide
+	if follower.ue.HedgeMode != nil && !*follower.ue.HedgeMode {
+		posSide = "Merged"
+	}
+	order.Side, order.PosSide, order.Quantity = ev.Side, ev.PosSide, qty
+	return order, orderPlan{side: ev.Side, posSide: posSide}, nil
+}
+
+// copySize is the follower quantity of a leader entry of qty at price: qty in
+// the ratio of the follower to the leader equity, times f.Multiplier, then
+// bounded by f.MaxOrderNotional and by what f.MaxPositionNotional leaves
+// next to the held follower position. It is rounded down to the 4 decimals
+// of the controller and zero when the caps leave no room.
+func copySize(qty, price, leaderEquity, followerEquity, held decimal.Decimal, f model.CopyFollower) (decimal.Decimal, error) {
+	if !leaderEquity.IsPositive() {
+		return decimal.Zero, fmt.Errorf("leader equity %s is not positive", leaderEquity)
+	}
+	if !followerEquity.IsPositive() {
+		return decimal.Zero, fmt.Errorf("follower equity %s is not positive", followerEquity)
+	}
+	multiplier := f.Multiplier
+	if multiplier.IsZero() {
+		multiplier = decimal.NewFromInt(1)
+	}
+	size := qty.Mul(followerEquity).Div(leaderEquity).Mul(multiplier)
+
+	if price.IsPositive() {
+		if f.MaxOrderNotional.IsPositive() {
+			size = decimal.Min(size, f.MaxOrderNotional.Div(price))
+		}
+		if f.MaxPositionNotional.IsPositive() {
+			room := f.MaxPositionNotional.Div(price).Sub(held)
+			size = decimal.Min(size, decimal.Max(room, decimal.Zero))
+		}
+	}
+	return size.Truncate(4), nil
+}
+
+// heldPosition returns the size of the posSide (Long or Short) position of
+// symbol and the posSide the exchange reports it with, Merged on one-way
+// accounts.
+func heldPosition(positions *connectors.GAccountPositions, symbol, posSide string) (decimal.Decimal, string) {
+	if positions == nil {
+		return decimal.Zero, posSide
+	}
+	for _, p := range positions.Positions {
+		if p.Symbol != symbol {
+			continue
+		}
+		size, err := decimal.NewFromString(strings.TrimSpace(p.SizeRq))
+		if err != nil || size.IsZero() {
+			continue
+		}
+		side := p.PosSide
+		if !strings.EqualFold(side, "Long") && !strings.EqualFold(side, "Short") {
+			side = "Long"
+			if strings.EqualFold(p.Side, "Sell") {
+				side = "Short"
+			}
+		}
+		if strings.EqualFold(side, posSide) {
+			return size.Abs(), p.PosSide
+		}
+	}
+	return decimal.Zero, posSide
+}
+
+func equity(positions *connectors.GAccountPositions) decimal.Decimal {
+	if positions == nil {
+		return decimal.Zero
+	}
+	balance, err := decimal.NewFromString(strings.TrimSpace(positions.Account.AccountBalanceRv))
+	if err != nil {
+		return decimal.Zero
+	}
+	return balance
+}
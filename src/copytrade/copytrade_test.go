@@ -0,0 +1,185 @@
+package copytrade
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+type placedOrder struct {
+	symbol, side, posSide, qty string
+	reduce                     bool
+}
+
+type fakeClient struct {
+	positions connectors.GAccountPositions
+	placed    []placedOrder
+}
+
+func (c *fakeClient) GetPositionsUSDT() (*connectors.GAccountPositions, error) {
+	return &c.positions, nil
+}
+
+func (c *fakeClient) PlaceOrder(symbol, side, posSide, qty, _ string, reduce bool) (*connectors.APIResponse, error) {
+	c.placed = append(c.placed, placedOrder{symbol, side, posSide, qty, reduce})
+	return &connectors.APIResponse{}, nil
+}
+
+type fakeFollowers []model.CopyFollower
+
+func (f fakeFollowers) ListByLeader(_ context.Context, userID, exchangeID uint) ([]model.CopyFollower, error) {
+	var out []model.CopyFollower
+	for _, row := range f {
+		if row.LeaderUserID == userID && row.LeaderExchangeID == exchangeID {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+type fakeUserExchanges map[uint]*model.UserExchange
+
+func (f fakeUserExchanges) GetByUserAndExchange(_ context.Context, userID, _ uint) (*model.UserExchange, error) {
+	return f[userID], nil
+}
+
+type fakeOrders struct {
+	created []model.Order
+	reasons []string
+}
+
+func (f *fakeOrders) CreateWithAutoLogReason(_ context.Context, order *model.Order, reason string) error {
+	f.created = append(f.created, *order)
+	f.reasons = append(f.reasons, reason)
+	return nil
+}
+
+type fixture struct {
+	copier        *Copier
+	clients       map[string]*fakeClient
+	userExchanges fakeUserExchanges
+	orders        *fakeOrders
+}
+
+// newFixture has leader 1 with an equity of 10000 followed by user 2 (2000)
+// and user 3 (10000, one-way mode, twice the size capped at 500 per order).
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		clients:       map[string]*fakeClient{},
+		userExchanges: fakeUserExchanges{},
+		orders:        &fakeOrders{},
+	}
+	add := func(userID uint, key, balance string) *model.UserExchange {
+		encKey, err := security.EncryptString(key)
+		require.NoError(t, err)
+		encSecret, err := security.EncryptString("secret")
+		require.NoError(t, err)
+		ue := &model.UserExchange{UserID: userID, ExchangeID: 1, APIKeyHash: encKey, APISecretHash: encSecret, RunOnServer: true}
+		f.userExchanges[userID] = ue
+		client := &fakeClient{}
+		client.positions.Account.AccountBalanceRv = balance
+		f.clients[key] = client
+		return ue
+	}
+	add(1, "leader", "10000")
+	add(2, "small", "2000")
+	oneWay := false
+	add(3, "capped", "10000").HedgeMode = &oneWay
+
+	f.copier = &Copier{
+		followers: fakeFollowers{
+			{LeaderUserID: 1, LeaderExchangeID: 1, FollowerUserID: 2, FollowerExchangeID: 1, Enabled: true},
+			{LeaderUserID: 1, LeaderExchangeID: 1, FollowerUserID: 3, FollowerExchangeID: 1, Enabled: true,
+				Multiplier: decimal.NewFromInt(2), MaxOrderNotional: decimal.NewFromInt(500)},
+		},
+		userExchanges: f.userExchanges,
+		orders:        f.orders,
+		newClient: func(creds security.Credentials) (copyClient, error) {
+			return f.clients[creds.APIKey], nil
+		},
+		halted: func(context.Context, uint) bool { return false },
+	}
+	return f
+}
+
+func entryFilled() events.OrderFilled {
+	return events.OrderFilled{
+		UserID: 1, ExchangeID: 1, Exchange: "phemex", OrderID: 42,
+		Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", OrderDir: model.OrderDirectionEntry,
+		Quantity: decimal.RequireFromString("0.5"), Price: decimal.NewFromInt(1000),
+	}
+}
+
+func TestOnOrderFilledCopiesEntriesProportionally(t *testing.T) {
+	f := newFixture(t)
+	f.copier.OnOrderFilled(context.Background(), entryFilled())
+
+	require.Equal(t, []placedOrder{{"BTCUSDT", "Buy", "Long", "0.1", false}}, f.clients["small"].placed)
+	require.Equal(t, []placedOrder{{"BTCUSDT", "Buy", "Merged", "0.5", false}}, f.clients["capped"].placed,
+		"twice the leader size, capped by the order notional")
+	require.Empty(t, f.clients["leader"].placed)
+
+	require.Len(t, f.orders.created, 2)
+	for _, o := range f.orders.created {
+		require.Equal(t, model.OrderExecutionStatusFilled, o.Status)
+		require.Equal(t, model.OrderDirectionEntry, o.OrderDir)
+		require.Equal(t, "Long", o.PosSide)
+	}
+	require.Equal(t, "copied from order 42 of user 1", f.orders.reasons[0])
+}
+
+func TestOnOrderFilledClosesFollowerPositions(t *testing.T) {
+	f := newFixture(t)
+	f.clients["small"].positions.Positions = []connectors.GPosition{
+		{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0.3"},
+	}
+
+	exit := entryFilled()
+	exit.OrderDir, exit.Side = model.OrderDirectionExit, "Sell"
+	f.copier.OnOrderFilled(context.Background(), exit)
+
+	require.Equal(t, []placedOrder{{"BTCUSDT", "Sell", "Long", "0.3", true}}, f.clients["small"].placed,
+		"the whole follower position is closed")
+	require.Empty(t, f.clients["capped"].placed, "no position to close")
+	require.Len(t, f.orders.created, 1)
+	require.Equal(t, model.OrderDirectionExit, f.orders.created[0].OrderDir)
+}
+
+func TestOnOrderFilledSkips(t *testing.T) {
+	t.Run("shadow leader", func(t *testing.T) {
+		f := newFixture(t)
+		f.userExchanges[1].Shadow = true
+		f.copier.OnOrderFilled(context.Background(), entryFilled())
+		require.Empty(t, f.orders.created)
+	})
+
+	t.Run("halted and shadow followers", func(t *testing.T) {
+		f := newFixture(t)
+		f.userExchanges[3].Shadow = true
+		f.copier.halted = func(_ context.Context, userID uint) bool { return userID == 2 }
+		f.copier.OnOrderFilled(context.Background(), entryFilled())
+		require.Empty(t, f.clients["small"].placed)
+		require.Empty(t, f.clients["capped"].placed)
+	})
+
+	t.Run("position cap reached", func(t *testing.T) {
+		f := newFixture(t)
+		f.copier.followers = fakeFollowers{
+			{LeaderUserID: 1, LeaderExchangeID: 1, FollowerUserID: 2, FollowerExchangeID: 1, Enabled: true,
+				MaxPositionNotional: decimal.NewFromInt(300)},
+		}
+		f.clients["small"].positions.Positions = []connectors.GPosition{
+			{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0.3"},
+		}
+		f.copier.OnOrderFilled(context.Background(), entryFilled())
+		require.Empty(t, f.clients["small"].placed)
+		require.Empty(t, f.orders.created)
+	})
+}
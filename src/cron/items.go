@@ -0,0 +1,22 @@
+package cron
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type itemsKey struct{}
+
+// withItems returns ctx counting the items AddItems reports into n.
+func withItems(ctx context.Context, n *atomic.Int64) context.Context {
+	return context.WithValue(ctx, itemsKey{}, n)
+}
+
+// AddItems counts n items, e.g. accounts or candles, processed by the
+// scheduled run of ctx; they are recorded with the run. Outside of a
+// scheduled run it does nothing.
+func AddItems(ctx context.Context, n int) {
+	if counter, ok := ctx.Value(itemsKey{}).(*atomic.Int64); ok {
+		counter.Add(int64(n))
+	}
+}
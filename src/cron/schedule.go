@@ -0,0 +1,164 @@
+// Package cron runs the periodic jobs of the platform in one process on
+// cron-style schedules, see Scheduler.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule tells when a job runs next.
+type Schedule interface {
+	// Next returns the first run time strictly after t, zero when there is
+	// none.
+	Next(t time.Time) time.Time
+}
+
+// every runs at a fixed interval from the previous run.
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// fields is a five-field cron expression evaluated in UTC, one bit per
+// allowed value.
+type fields struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar follow cron: when both day fields are
+	// restricted a day matching either runs.
+	domStar, dowStar bool
+}
+
+// maxSearch bounds Next for expressions that never match, e.g. 31 February.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+func (f *fields) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+	for t.Before(limit) {
+		switch {
+		case f.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !f.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case f.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case f.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (f *fields) dayMatches(t time.Time) bool {
+	dom := f.dom&(1<<uint(t.Day())) != 0
+	dow := f.dow&(1<<uint(t.Weekday())) != 0
+	if f.domStar || f.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+var descriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// Parse parses spec: five fields (minute hour day-of-month month
+// day-of-week, in UTC) of numbers, ranges, lists and steps, e.g.
+// "*/15 * * * *" or "15 0 * * 1-5", one of @hourly, @daily, @weekly and
+// @monthly, or "@every <duration>".
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("cron: invalid interval in %q", spec)
+		}
+		return every(d), nil
+	}
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron: expected 5 fields in %q", spec)
+	}
+	f := &fields{domStar: parts[2] == "*", dowStar: parts[4] == "*"}
+	var err error
+	if f.minute, err = parseField(parts[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron: minute: %w", err)
+	}
+	if f.hour, err = parseField(parts[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron: hour: %w", err)
+	}
+	if f.dom, err = parseField(parts[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("cron: day of month: %w", err)
+	}
+	if f.month, err = parseField(parts[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron: month: %w", err)
+	}
+	if f.dow, err = parseField(parts[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("cron: day of week: %w", err)
+	}
+	// 7 is Sunday too.
+	if f.dow&(1<<7) != 0 {
+		f.dow |= 1
+	}
+	return f, nil
+}
+
+// parseField returns the bits of the comma separated items of field, each
+// "*", "n" or "a-b" with an optional "/step", within [min, max].
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		expr, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", item)
+			}
+			expr, step = item[:i], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case expr == "*":
+		case strings.Contains(expr, "-"):
+			a, b, _ := strings.Cut(expr, "-")
+			var errA, errB error
+			lo, errA = strconv.Atoi(a)
+			hi, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil {
+				return 0, fmt.Errorf("invalid range %q", item)
+			}
+		default:
+			n, err := strconv.Atoi(expr)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", item)
+			}
+			lo = n
+			// "n/step" runs from n to the end of the range.
+			if step == 1 {
+				hi = n
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", item, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
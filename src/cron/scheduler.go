@@ -0,0 +1,248 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/model"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Job is a periodic task of the scheduler.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+}
+
+// RunStore keeps the runs of each job and keeps two schedulers, e.g.
+// during a rollout, from running the same job at once.
+type RunStore interface {
+	// Acquire locks job name until until, unless another run holds it.
+	Acquire(ctx context.Context, name string, now, until time.Time) (bool, error)
+	// Finish releases the lock of run.Job and records run.
+	Finish(ctx context.Context, run *model.JobRun) error
+	// LastSuccess returns when job name last succeeded, zero when never.
+	LastSuccess(ctx context.Context, name string) (time.Time, error)
+}
+
+// Alerter is told about a job that has not succeeded for longer than its
+// schedule allows, e.g. a collector failing every run.
+type Alerter interface {
+	Overdue(ctx context.Context, name string, lastSuccess time.Time, interval time.Duration)
+}
+
+// overdueCheckPeriod is how often the jobs are checked for overdue runs.
+const overdueCheckPeriod = time.Minute
+
+type entry struct {
+	Job
+	next    time.Time
+	running atomic.Bool
+}
+
+// Scheduler runs jobs on their schedules. A job still running when it is
+// due again is skipped rather than started twice.
+type Scheduler struct {
+	store   RunStore
+	lockTTL time.Duration
+	now     func() time.Time
+
+	alerter     Alerter
+	staleFactor float64
+	started     time.Time
+	// overdue are the jobs alerted on, until they succeed again.
+	overdue map[string]bool
+
+	jobs []*entry
+	wg   sync.WaitGroup
+}
+
+// New returns a scheduler recording runs in store. lockTTL bounds how long
+// a run that died without finishing keeps its job locked.
+func New(store RunStore, lockTTL time.Duration) *Scheduler {
+	return &Scheduler{store: store, lockTTL: lockTTL, now: time.Now, overdue: map[string]bool{}}
+}
+
+// WatchOverdue tells alerter about the jobs without a successful run for
+// staleFactor times their interval, counted from the start of the
+// scheduler for the jobs that never succeeded.
+func (s *Scheduler) WatchOverdue(alerter Alerter, staleFactor float64) {
+	s.alerter, s.staleFactor = alerter, staleFactor
+}
+
+// Add registers job.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, &entry{Job: job})
+}
+
+// Run starts the due jobs until ctx is done, then waits for the running
+// ones to return.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if len(s.jobs) == 0 {
+		return fmt.Errorf("cron: no job registered")
+	}
+	now := s.now()
+	s.started = now
+	for _, e := range s.jobs {
+		e.next = e.Schedule.Next(now)
+		logger.WithFields(map[string]interface{}{
+			"job":  e.Name,
+			"next": e.next,
+		}).Info("job scheduled")
+	}
+
+	check := time.NewTicker(overdueCheckPeriod)
+	defer check.Stop()
+	defer s.wg.Wait()
+	for {
+		timer := time.NewTimer(s.earliest().Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+			s.runDue(ctx, s.now())
+		case <-check.C:
+			timer.Stop()
+			s.checkOverdue(ctx, s.now())
+		}
+	}
+}
+
+// checkOverdue alerts once on each job without a successful run for
+// staleFactor times its interval.
+func (s *Scheduler) checkOverdue(ctx context.Context, now time.Time) {
+	if s.alerter == nil {
+		return
+	}
+	for _, e := range s.jobs {
+		last, err := s.store.LastSuccess(ctx, e.Name)
+		if err != nil {
+			logger.WithField("job", e.Name).WithError(err).Error("Failed to load last successful run")
+			continue
+		}
+		since := last
+		if since.Before(s.started) {
+			since = s.started
+		}
+		interval := Interval(e.Schedule, now)
+		if interval <= 0 || now.Sub(since) <= time.Duration(float64(interval)*s.staleFactor) {
+			if s.overdue[e.Name] {
+				logger.WithField("job", e.Name).Info("job succeeded again")
+				delete(s.overdue, e.Name)
+			}
+			continue
+		}
+		if s.overdue[e.Name] {
+			continue
+		}
+		s.overdue[e.Name] = true
+		s.alerter.Overdue(ctx, e.Name, last, interval)
+	}
+}
+
+// Interval is the time between the next two runs of schedule after now,
+// zero when it never runs again.
+func Interval(schedule Schedule, now time.Time) time.Duration {
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return 0
+	}
+	after := schedule.Next(next)
+	if after.IsZero() {
+		return 0
+	}
+	return after.Sub(next)
+}
+
+// earliest is when the next job is due.
+func (s *Scheduler) earliest() time.Time {
+	var at time.Time
+	for _, e := range s.jobs {
+		if !e.next.IsZero() && (at.IsZero() || e.next.Before(at)) {
+			at = e.next
+		}
+	}
+	if at.IsZero() {
+		// Nothing will ever be due, check again later.
+		return s.now().Add(time.Hour)
+	}
+	return at
+}
+
+// runDue starts the jobs due at now and schedules their next run.
+func (s *Scheduler) runDue(ctx context.Context, now time.Time) {
+	for _, e := range s.jobs {
+		if e.next.IsZero() || e.next.After(now) {
+			continue
+		}
+		e.next = e.Schedule.Next(now)
+		if !e.running.CompareAndSwap(false, true) {
+			logger.WithField("job", e.Name).Warn("previous run still running, skipping")
+			continue
+		}
+		s.wg.Add(1)
+		go func(e *entry) {
+			defer s.wg.Done()
+			defer e.running.Store(false)
+			s.execute(ctx, e)
+		}(e)
+	}
+}
+
+// execute runs e once it holds its lock and records the outcome.
+func (s *Scheduler) execute(ctx context.Context, e *entry) {
+	log := logger.WithField("job", e.Name)
+	startedAt := s.now().UTC()
+	acquired, err := s.store.Acquire(ctx, e.Name, startedAt, startedAt.Add(s.lockTTL))
+	if err != nil {
+		log.WithError(err).Error("Failed to lock job")
+		return
+	}
+	if !acquired {
+		log.Warn("job running elsewhere, skipping")
+		return
+	}
+
+	log.Info("job started")
+	var items atomic.Int64
+	runErr := s.call(withItems(ctx, &items), e)
+	run := &model.JobRun{
+		Job:        e.Name,
+		StartedAt:  startedAt,
+		FinishedAt: s.now().UTC(),
+		Status:     model.ScheduledJobOK,
+		Items:      int(items.Load()),
+	}
+	run.DurationMS = run.FinishedAt.Sub(startedAt).Milliseconds()
+	done := log.WithFields(map[string]interface{}{
+		"duration": run.FinishedAt.Sub(startedAt),
+		"items":    run.Items,
+	})
+	if runErr != nil {
+		run.Status, run.Error = model.ScheduledJobFailed, runErr.Error()
+		done.WithError(runErr).Error("job failed")
+	} else {
+		done.Info("job finished")
+	}
+
+	// Record the run even when ctx was cancelled during it.
+	if err := s.store.Finish(context.WithoutCancel(ctx), run); err != nil {
+		log.WithError(err).Error("Failed to record job run")
+	}
+}
+
+// call runs e, turning a panic into an error so one job cannot take the
+// scheduler down.
+func (s *Scheduler) call(ctx context.Context, e *entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return e.Run(ctx)
+}
@@ -0,0 +1,169 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeStore struct {
+	mu          sync.Mutex
+	locked      map[string]bool
+	runs        []model.JobRun
+	lastSuccess map[string]time.Time
+}
+
+func (f *fakeStore) Acquire(_ context.Context, name string, _, _ time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.locked[name] {
+		return false, nil
+	}
+	f.locked[name] = true
+	return true, nil
+}
+
+func (f *fakeStore) Finish(_ context.Context, run *model.JobRun) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.locked, run.Job)
+	f.runs = append(f.runs, *run)
+	return nil
+}
+
+func (f *fakeStore) LastSuccess(_ context.Context, name string) (time.Time, error) {
+	return f.lastSuccess[name], nil
+}
+
+func TestSchedulerRunsDueJobsOnce(t *testing.T) {
+	store := &fakeStore{locked: map[string]bool{"elsewhere": true}}
+	s := New(store, time.Hour)
+	now := time.Date(2025, 3, 7, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	release := make(chan struct{})
+	slowRuns := 0
+	s.Add(Job{Name: "slow", Schedule: every(time.Minute), Run: func(ctx context.Context) error {
+		slowRuns++
+		AddItems(ctx, 2)
+		<-release
+		return nil
+	}})
+	s.Add(Job{Name: "failing", Schedule: every(time.Minute), Run: func(context.Context) error {
+		panic("boom")
+	}})
+	s.Add(Job{Name: "elsewhere", Schedule: every(time.Minute), Run: func(context.Context) error {
+		return errors.New("must not run")
+	}})
+	s.Add(Job{Name: "later", Schedule: every(time.Hour), Run: func(context.Context) error {
+		return errors.New("must not run")
+	}})
+	for _, e := range s.jobs {
+		e.next = e.Schedule.Next(now)
+	}
+
+	ctx := context.Background()
+	s.runDue(ctx, now.Add(time.Minute))
+	// due again while the first run of slow still runs
+	s.runDue(ctx, now.Add(2*time.Minute))
+	close(release)
+	s.wg.Wait()
+
+	if slowRuns != 1 {
+		t.Fatalf("expected overlapping runs skipped, got %d runs", slowRuns)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	failures := 0
+	for _, r := range store.runs {
+		switch r.Job {
+		case "failing":
+			if r.Status != model.ScheduledJobFailed || r.Error != "panic: boom" {
+				t.Fatalf("expected the panic recorded as a failure, got %+v", r)
+			}
+			failures++
+		case "slow":
+			if r.Status != model.ScheduledJobOK || r.Items != 2 {
+				t.Fatalf("unexpected run %+v", r)
+			}
+		default:
+			t.Fatalf("unexpected run of %s", r.Job)
+		}
+	}
+	// failing may still run when it is due again, like slow
+	if failures == 0 || len(store.runs) != failures+1 {
+		t.Fatalf("unexpected runs %v", store.runs)
+	}
+}
+
+func TestSchedulerRunStopsWithContext(t *testing.T) {
+	s := New(&fakeStore{locked: map[string]bool{}}, time.Hour)
+	if err := s.Run(context.Background()); err == nil {
+		t.Fatal("expected an error without jobs")
+	}
+
+	ran := make(chan struct{}, 10)
+	s.Add(Job{Name: "tick", Schedule: every(10 * time.Millisecond), Run: func(context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	<-ran
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+type fakeAlerter struct{ overdue []string }
+
+func (f *fakeAlerter) Overdue(_ context.Context, name string, _ time.Time, _ time.Duration) {
+	f.overdue = append(f.overdue, name)
+}
+
+func TestSchedulerAlertsOverdueJobs(t *testing.T) {
+	started := time.Date(2025, 3, 7, 10, 0, 0, 0, time.UTC)
+	store := &fakeStore{locked: map[string]bool{}, lastSuccess: map[string]time.Time{
+		"ohlcv": started.Add(-time.Hour),
+	}}
+	alerter := &fakeAlerter{}
+	s := New(store, time.Hour)
+	s.WatchOverdue(alerter, 2)
+	s.started = started
+	noop := func(context.Context) error { return nil }
+	s.Add(Job{Name: "ohlcv", Schedule: every(5 * time.Minute), Run: noop})
+	s.Add(Job{Name: "pnl_report", Schedule: every(24 * time.Hour), Run: noop})
+
+	ctx := context.Background()
+	// never succeeded jobs count from the start of the scheduler
+	s.checkOverdue(ctx, started.Add(9*time.Minute))
+	if len(alerter.overdue) != 0 {
+		t.Fatalf("expected no alert yet, got %v", alerter.overdue)
+	}
+	s.checkOverdue(ctx, started.Add(11*time.Minute))
+	s.checkOverdue(ctx, started.Add(12*time.Minute))
+	if len(alerter.overdue) != 1 || alerter.overdue[0] != "ohlcv" {
+		t.Fatalf("expected ohlcv alerted once, got %v", alerter.overdue)
+	}
+
+	// a success clears the alert, the next streak alerts again
+	store.lastSuccess["ohlcv"] = started.Add(12 * time.Minute)
+	s.checkOverdue(ctx, started.Add(13*time.Minute))
+	s.checkOverdue(ctx, started.Add(23*time.Minute))
+	if len(alerter.overdue) != 2 {
+		t.Fatalf("expected a second alert, got %v", alerter.overdue)
+	}
+
+	if got := Interval(every(time.Minute), started); got != time.Minute {
+		t.Fatalf("interval = %v", got)
+	}
+	daily, _ := Parse("15 0 * * *")
+	if got := Interval(daily, started); got != 24*time.Hour {
+		t.Fatalf("interval = %v", got)
+	}
+}
@@ -3,8 +3,9 @@ package database
 import (
 	"fmt"
 	"strategyexecutor/src/database/migrations"
+	"strategyexecutor/src/database/schema"
 	"strategyexecutor/src/model"
-	"time"
+	"strategyexecutor/src/tracing"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
@@ -22,13 +23,59 @@ type DatabaseConfig struct {
 	SSLMode  string
 }
 
+// Supported values of DATABASE_MIGRATION_MODE.
+const (
+	MigrationModeAuto      = "auto"
+	MigrationModeVersioned = "versioned"
+)
+
 // MainDB is the primary read/write database connection used by the application.
 var MainDB *gorm.DB
 
 // InitMainDB initializes the main (read/write) database connection and runs migrations.
 // This should be called once at application startup (e.g. in main()).
+//
+// With DATABASE_MIGRATION_MODE=versioned the schema is not touched: startup
+// fails if the versioned SQL migrations are behind, and `migrate up` must be
+// run first. The default "auto" mode keeps AutoMigrate for local development.
 func InitMainDB() error {
+	if err := OpenMainDB(); err != nil {
+		return err
+	}
+
+	config := GetConfig()
+	switch config.MigrationMode {
+	case MigrationModeVersioned:
+		runner, err := schema.NewRunner(MainDB)
+		if err != nil {
+			return fmt.Errorf("failed to load schema migrations: %w", err)
+		}
+		if err := runner.Check(); err != nil {
+			return fmt.Errorf("refusing to start: %w", err)
+		}
+		logrus.WithField("version", runner.Latest()).Info("[database] MainDB schema is up to date")
+		return nil
+	case MigrationModeAuto, "":
+	default:
+		return fmt.Errorf("unknown DATABASE_MIGRATION_MODE %q", config.MigrationMode)
+	}
+
+	if err := autoMigrate(MainDB); err != nil {
+		return err
+	}
+
+	if err := migrations.Run(MainDB); err != nil {
+		return fmt.Errorf("failed to run data migrations on MainDB: %w", err)
+	}
+
+	logrus.Info("[database] MainDB migrations completed")
+
+	return nil
+}
 
+// OpenMainDB connects MainDB without touching the schema. It is used by the
+// migrate command, which must be able to run against an outdated schema.
+func OpenMainDB() error {
 	config := GetConfig()
 	db, err := gorm.Open(postgres.Open(config.DatabaseURLMain),
 		&gorm.Config{
@@ -44,48 +91,108 @@ func InitMainDB() error {
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to get DB from GORM")
 	}
-	sqlDB.SetMaxOpenConns(20)
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetConnMaxLifetime(1 * time.Hour)
+	configurePool(sqlDB, config)
+
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return fmt.Errorf("failed to register tracing on MainDB: %w", err)
+	}
 
 	// Assign to the global variable only after a successful connection.
 	MainDB = db
 
 	logrus.Info("[database] MainDB connection established")
+	return nil
+}
 
+// MigrateMainDB applies pending versioned SQL migrations followed by the data
+// migrations, returning the SQL migrations that were applied.
+func MigrateMainDB() ([]schema.Migration, error) {
+	if MainDB == nil {
+		return nil, fmt.Errorf("MainDB is not connected")
+	}
+
+	// Legacy string user_id columns must be converted before the baseline
+	// creates indexes on them.
+	if err := migrations.PrepareLegacyUserColumns(MainDB); err != nil {
+		return nil, fmt.Errorf("failed to prepare legacy user columns: %w", err)
+	}
+
+	runner, err := schema.NewRunner(MainDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema migrations: %w", err)
+	}
+	applied, err := runner.Up()
+	if err != nil {
+		return applied, fmt.Errorf("failed to apply schema migrations: %w", err)
+	}
+
+	if err := migrations.Run(MainDB); err != nil {
+		return applied, fmt.Errorf("failed to run data migrations on MainDB: %w", err)
+	}
+	return applied, nil
+}
+
+func autoMigrate(db *gorm.DB) error {
 	// Prepare legacy user columns before AutoMigrate so we can safely
 	// convert string-based user_id columns into numeric IDs without
 	// failing casts.
-	if err := migrations.PrepareLegacyUserColumns(MainDB); err != nil {
+	if err := migrations.PrepareLegacyUserColumns(db); err != nil {
 		return fmt.Errorf("failed to prepare legacy user columns: %w", err)
 	}
 
 	// Run AutoMigrate only on the main database.
-	// Add here all models that belong to the write-side schema.
-	if err := MainDB.AutoMigrate(
+	// Add here all models that belong to the write-side schema, and add the
+	// matching DDL as a new file under schema/sql for versioned deployments.
+	if err := db.AutoMigrate(
 		&model.User{},
 		&model.Order{},
 		&model.OrderLog{},
 		&model.OrderExecutionLog{},
+		&model.OrderIntent{},
+		&model.ArchivedOrder{},
 		&model.Exchange{},
 		&model.PhemexOrder{},
 		&model.Exception{},
 		&model.UserExchange{},
+		&model.UserNotificationSetting{},
 		&model.TradingViewNewsEvent{},
+		&model.TVNewsWatchlist{},
 		&model.OHLCVCrypto1m{},
 		&model.OHLCVCrypto1h{},
+		&model.FundingRate{},
+		&model.OpenInterest{},
+		&model.DailyPnL{},
+		&model.PositionSnapshot{},
+		&model.EquitySnapshot{},
+		&model.Trade{},
+		&model.Strategy{},
+		&model.StrategyAction{},
+		&model.SignalFilterSetting{},
+		&model.LossStreak{},
+		&model.StopOut{},
+		&model.DailyEntryCount{},
+		&model.ScheduleRule{},
+		&model.SignalClaim{},
+		&model.AllocationTarget{},
+		&model.APIToken{},
+		&model.FeatureFlag{},
+		&model.ShadowCall{},
+		&model.APICallLog{},
+		&model.SignalExecution{},
+		&model.SignalExecutionSummary{},
+		&model.CopyFollower{},
+		&model.SignalJob{},
+		&model.SignalQueueConsumer{},
+		&model.ScheduledJob{},
+		&model.JobRun{},
+		&model.MaintenanceWindow{},
+		&model.OrderWebhook{},
+		&model.WebhookDelivery{},
+		&model.Onboarding{},
+		&model.OrderNote{},
 		&migrations.DataMigration{},
-		//&model.Strategy{},
-		//&model.StrategyAction{},
 	); err != nil {
 		return fmt.Errorf("failed to run migrations on MainDB: %w", err)
 	}
-
-	if err := migrations.Run(MainDB); err != nil {
-		return fmt.Errorf("failed to run data migrations on MainDB: %w", err)
-	}
-
-	logrus.Info("[database] MainDB migrations completed")
-
 	return nil
 }
@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"strategyexecutor/src/tracing"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -39,6 +40,12 @@ func InitReadOnlyDB() error {
 		return fmt.Errorf("failed to get sql.DB from ReadOnlyDB: %w", err)
 	}
 
+	configurePool(sqlDB, config)
+
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return fmt.Errorf("failed to register tracing on ReadOnlyDB: %w", err)
+	}
+
 	// ✅ REAL ping to the database
 	if err := sqlDB.Ping(); err != nil {
 		return fmt.Errorf("failed to ping ReadOnlyDB: %w", err)
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var schemaNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// OpenSandboxDB opens a connection to the main database whose search_path is
+// the given schema, creating the schema if needed. It is used by backtests so
+// their orders never land in the live tables. No migrations are run.
+func OpenSandboxDB(schema string) (*gorm.DB, error) {
+	if !schemaNamePattern.MatchString(schema) || schema == "public" {
+		return nil, fmt.Errorf("invalid sandbox schema %q", schema)
+	}
+
+	config := GetConfig()
+	gormConfig := &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.LogLevel(config.GormLogLevel)),
+	}
+
+	admin, err := gorm.Open(postgres.Open(config.DatabaseURLMain), gormConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := admin.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", schema)).Error; err != nil {
+		return nil, fmt.Errorf("failed to create sandbox schema: %w", err)
+	}
+	if sqlDB, err := admin.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+
+	dsn, err := withSearchPath(config.DatabaseURLMain, schema)
+	if err != nil {
+		return nil, err
+	}
+	return gorm.Open(postgres.Open(dsn), gormConfig)
+}
+
+// withSearchPath adds search_path to a URL or key=value style DSN.
+func withSearchPath(dsn, schema string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", fmt.Errorf("invalid database url: %w", err)
+		}
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+	return strings.TrimSpace(dsn) + " search_path=" + schema, nil
+}
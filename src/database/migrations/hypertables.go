@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"strategyexecutor/src/database/timescale"
+)
+
+// hypertables are the append-only history tables turned into TimescaleDB
+// hypertables, chunked on their time column and compressed once older than
+// compressAfter, segmented by the column they are read by.
+var hypertables = []struct {
+	table         string
+	timeColumn    string
+	segmentBy     string
+	chunk         string
+	compressAfter string
+}{
+	{"ohlcv_crypto_1m", "datetime", "symbol", "1 day", "7 days"},
+	{"ohlcv_crypto_1h", "datetime", "symbol", "30 days", "90 days"},
+	{"position_snapshots", "taken_at", "user_id", "7 days", "30 days"},
+	{"equity_snapshots", "taken_at", "user_id", "30 days", "90 days"},
+}
+
+// setupHypertables converts the history tables to compressed hypertables
+// when the timescaledb extension is installed. It is a no-op elsewhere and
+// on the tables already converted, so it runs on every start.
+func setupHypertables(db *gorm.DB) error {
+	ok, err := timescale.Available(db)
+	if err != nil {
+		return fmt.Errorf("detect timescaledb: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+
+	for _, h := range hypertables {
+		var n int64
+		if err := db.Raw("SELECT count(*) FROM timescaledb_information.hypertables WHERE hypertable_name = ?", h.table).
+			Scan(&n).Error; err != nil {
+			return fmt.Errorf("check hypertable %s: %w", h.table, err)
+		}
+		if n > 0 {
+			continue
+		}
+
+		logrus.WithField("table", h.table).Info("[migrations] converting table to a hypertable, existing rows are moved into chunks")
+		// Unique constraints of a hypertable must include its time column.
+		stmts := []string{
+			fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s_pkey", h.table, h.table),
+			fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (id, %s)", h.table, h.timeColumn),
+			fmt.Sprintf("SELECT create_hypertable('%s', '%s', chunk_time_interval => INTERVAL '%s', migrate_data => true)",
+				h.table, h.timeColumn, h.chunk),
+			fmt.Sprintf("ALTER TABLE %s SET (timescaledb.compress, timescaledb.compress_segmentby = '%s', timescaledb.compress_orderby = '%s DESC')",
+				h.table, h.segmentBy, h.timeColumn),
+			fmt.Sprintf("SELECT add_compression_policy('%s', INTERVAL '%s', if_not_exists => true)", h.table, h.compressAfter),
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, stmt := range stmts {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("create hypertable %s: %w", h.table, err)
+		}
+	}
+	return nil
+}
@@ -91,5 +91,29 @@ func Run(db *gorm.DB) error {
 		return err
 	}
 
+	if err := RunOnce(db, "00004_backfill_tv_news_content_hash", backfillTVNewsContentHash); err != nil {
+		return err
+	}
+
+	if err := RunOnce(db, "00005_user_scoped_indexes", createUserScopedIndexes); err != nil {
+		return err
+	}
+
+	if err := RunOnce(db, "00006_backfill_exchange_capabilities", backfillExchangeCapabilities); err != nil {
+		return err
+	}
+
+	if err := RunOnce(db, "00007_backfill_native_bracket", backfillNativeBracket); err != nil {
+		return err
+	}
+
+	if err := seedExchanges(db); err != nil {
+		return err
+	}
+
+	if err := setupHypertables(db); err != nil {
+		return err
+	}
+
 	return nil
 }
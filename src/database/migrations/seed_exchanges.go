@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"strategyexecutor/src/model"
+)
+
+// seedExchanges inserts the known exchanges missing from the registry.
+// Existing rows are left alone so capabilities edited through the admin API
+// survive restarts.
+func seedExchanges(db *gorm.DB) error {
+	for _, ex := range model.KnownExchanges() {
+		ex := ex
+		if err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoNothing: true,
+		}).Create(&ex).Error; err != nil {
+			return fmt.Errorf("seed exchange %s: %w", ex.Name, err)
+		}
+	}
+	return nil
+}
+
+// backfillExchangeCapabilities sets the capabilities of the exchanges created
+// before the registry had them.
+func backfillExchangeCapabilities(db *gorm.DB) error {
+	for _, ex := range model.KnownExchanges() {
+		if err := db.Model(&model.Exchange{}).
+			Where("name = ?", ex.Name).
+			Updates(map[string]interface{}{
+				"supports_hedge":      ex.SupportsHedge,
+				"supports_stop_order": ex.SupportsStopOrder,
+				"futures":             ex.Futures,
+				"spot":                ex.Spot,
+			}).Error; err != nil {
+			return fmt.Errorf("backfill capabilities of %s: %w", ex.Name, err)
+		}
+	}
+	return nil
+}
+
+// backfillNativeBracket sets the native bracket capability of the exchanges
+// created before the registry had it.
+func backfillNativeBracket(db *gorm.DB) error {
+	for _, ex := range model.KnownExchanges() {
+		if err := db.Model(&model.Exchange{}).
+			Where("name = ?", ex.Name).
+			Update("supports_native_bracket", ex.SupportsNativeBracket).Error; err != nil {
+			return fmt.Errorf("backfill native bracket of %s: %w", ex.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"fmt"
+
+	"strategyexecutor/src/model"
+
+	"gorm.io/gorm"
+)
+
+// backfillTVNewsContentHash computes content_hash for TradingView news events
+// stored before headline deduplication existed.
+func backfillTVNewsContentHash(db *gorm.DB) error {
+	var rows []model.TradingViewNewsEvent
+
+	res := db.Model(&model.TradingViewNewsEvent{}).
+		Where("content_hash IS NULL OR content_hash = ''").
+		FindInBatches(&rows, 500, func(tx *gorm.DB, _ int) error {
+			for _, row := range rows {
+				if err := tx.Model(&model.TradingViewNewsEvent{}).
+					Where("id = ?", row.ID).
+					UpdateColumn("content_hash", row.ComputeContentHash()).Error; err != nil {
+					return fmt.Errorf("update content_hash for id %d: %w", row.ID, err)
+				}
+			}
+			return nil
+		})
+
+	return res.Error
+}
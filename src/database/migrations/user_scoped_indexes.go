@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// userScopedIndexes lead with user_id so queries scoped to the authenticated
+// user stay index-only as the shared tables grow.
+var userScopedIndexes = []struct {
+	name    string
+	table   string
+	columns string
+}{
+	{"idx_orders_user_exchange_created", "orders", "user_id, exchange_id, created_at"},
+	{"idx_orders_user_status", "orders", "user_id, status"},
+	{"idx_orders_user_external", "orders", "user_id, external_id"},
+	{"idx_daily_pnl_user_day", "daily_pnl", "user_id, day"},
+	{"idx_trades_user_status", "trades", "user_id, status"},
+}
+
+// createUserScopedIndexes adds composite (user_id, ...) indexes on the tables
+// read through the user-scoped repositories.
+func createUserScopedIndexes(db *gorm.DB) error {
+	for _, idx := range userScopedIndexes {
+		stmt := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", idx.name, idx.table, idx.columns)
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("create index %s: %w", idx.name, err)
+		}
+	}
+	return nil
+}
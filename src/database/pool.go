@@ -0,0 +1,69 @@
+package database
+
+import (
+	"database/sql"
+	"strategyexecutor/src/metrics"
+
+	"gorm.io/gorm"
+)
+
+func configurePool(sqlDB *sql.DB, config Config) {
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+}
+
+// PoolStats returns the pool statistics of each initialized connection,
+// keyed "main" and "readonly".
+func PoolStats() map[string]sql.DBStats {
+	out := make(map[string]sql.DBStats)
+	for name, db := range map[string]*gorm.DB{"main": MainDB, "readonly": ReadOnlyDB} {
+		if db == nil {
+			continue
+		}
+		if sqlDB, err := db.DB(); err == nil {
+			out[name] = sqlDB.Stats()
+		}
+	}
+	return out
+}
+
+// CollectPoolStats publishes PoolStats on the metrics endpoint. Rising
+// wait_count and wait_duration with in_use pinned at max_open mean the pool
+// is saturated.
+func CollectPoolStats(w *metrics.Writer) {
+	writePoolStats(w, PoolStats())
+}
+
+func writePoolStats(w *metrics.Writer, stats map[string]sql.DBStats) {
+	type metric struct {
+		name, help string
+		counter    bool
+		value      func(sql.DBStats) float64
+	}
+	for _, m := range []metric{
+		{"db_pool_max_open_connections", "Maximum number of open connections.", false, func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+		{"db_pool_open_connections", "Established connections, in use and idle.", false, func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+		{"db_pool_in_use_connections", "Connections currently in use.", false, func(s sql.DBStats) float64 { return float64(s.InUse) }},
+		{"db_pool_idle_connections", "Idle connections.", false, func(s sql.DBStats) float64 { return float64(s.Idle) }},
+		{"db_pool_wait_count_total", "Connections waited for.", true, func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+		{"db_pool_wait_duration_seconds_total", "Time blocked waiting for a connection.", true, func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+		{"db_pool_max_idle_closed_total", "Connections closed due to SetMaxIdleConns.", true, func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+		{"db_pool_max_idle_time_closed_total", "Connections closed due to SetConnMaxIdleTime.", true, func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+		{"db_pool_max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.", true, func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+	} {
+		for _, name := range []string{"main", "readonly"} {
+			s, ok := stats[name]
+			if !ok {
+				continue
+			}
+			labels := metrics.Labels{"db": name}
+			if m.counter {
+				w.Counter(m.name, m.help, labels, m.value(s))
+			} else {
+				w.Gauge(m.name, m.help, labels, m.value(s))
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"strategyexecutor/src/metrics"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePoolStats(t *testing.T) {
+	stats := map[string]sql.DBStats{
+		"main":     {MaxOpenConnections: 20, OpenConnections: 20, InUse: 20, WaitCount: 7, WaitDuration: 1500 * time.Millisecond},
+		"readonly": {MaxOpenConnections: 5, OpenConnections: 1, Idle: 1},
+	}
+	h := metrics.Handler(func(w *metrics.Writer) { writePoolStats(w, stats) })
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE db_pool_in_use_connections gauge\n",
+		`db_pool_in_use_connections{db="main"} 20`,
+		`db_pool_in_use_connections{db="readonly"} 0`,
+		"# TYPE db_pool_wait_count_total counter\n",
+		`db_pool_wait_count_total{db="main"} 7`,
+		`db_pool_wait_duration_seconds_total{db="main"} 1.5`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+	if n := strings.Count(body, "# HELP db_pool_open_connections "); n != 1 {
+		t.Fatalf("HELP written %d times", n)
+	}
+}
+
+func TestPoolStatsSkipsUninitialized(t *testing.T) {
+	if got := PoolStats(); len(got) != 0 {
+		t.Fatalf("expected no pools, got %v", got)
+	}
+}
@@ -0,0 +1,251 @@
+// Package schema applies the versioned SQL migrations embedded under sql/.
+//
+// Files are named <version>_<name>.up.sql and applied in version order, each
+// inside its own transaction. Applied versions are recorded in the
+// schema_migrations table so production deployments can run migrations as an
+// explicit step instead of relying on AutoMigrate at startup.
+package schema
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+// ErrSchemaBehind is returned by Check when migrations are pending.
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.up\.sql$`)
+
+// Migration is a single versioned SQL file.
+type Migration struct {
+	Version uint
+	Name    string
+	SQL     string
+}
+
+// AppliedMigration records a migration executed against the database.
+type AppliedMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false;column:version"`
+	Name      string    `gorm:"size:200;not null;column:name"`
+	AppliedAt time.Time `gorm:"not null;column:applied_at"`
+}
+
+func (AppliedMigration) TableName() string { return "schema_migrations" }
+
+// Runner applies a fixed, ordered set of migrations to a database.
+type Runner struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewRunner returns a runner for the migrations embedded in the binary.
+func NewRunner(db *gorm.DB) (*Runner, error) {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return NewRunnerFromFS(db, sub)
+}
+
+// NewRunnerFromFS returns a runner for the *.up.sql files at the root of fsys.
+func NewRunnerFromFS(db *gorm.DB, fsys fs.FS) (*Runner, error) {
+	if db == nil {
+		return nil, fmt.Errorf("schema runner requires a database")
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{db: db, migrations: migrations}, nil
+}
+
+// Load reads and orders the migrations found at the root of fsys. Files that
+// do not look like migrations are ignored; duplicate versions are an error.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	var out []Migration
+	seen := make(map[uint]string)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := fileNamePattern.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", e.Name())
+		}
+		if prev, ok := seen[uint(version)]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %q and %q", version, prev, e.Name())
+		}
+		seen[uint(version)] = e.Name()
+
+		body, err := fs.ReadFile(fsys, path.Clean(e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %q: %w", e.Name(), err)
+		}
+		out = append(out, Migration{Version: uint(version), Name: m[2], SQL: string(body)})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Migrations returns the migrations known to the runner in version order.
+func (r *Runner) Migrations() []Migration {
+	return r.migrations
+}
+
+// Latest returns the highest known migration version, or 0 if there are none.
+func (r *Runner) Latest() uint {
+	if len(r.migrations) == 0 {
+		return 0
+	}
+	return r.migrations[len(r.migrations)-1].Version
+}
+
+// Version returns the highest version applied to the database, or 0 when
+// nothing has been applied yet (including when the table does not exist).
+func (r *Runner) Version() (uint, error) {
+	if !r.db.Migrator().HasTable(&AppliedMigration{}) {
+		return 0, nil
+	}
+	var version uint
+	if err := r.db.Model(&AppliedMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Pending returns the known migrations that have not been applied yet.
+func (r *Runner) Pending() ([]Migration, error) {
+	applied, err := r.applied()
+	if err != nil {
+		return nil, err
+	}
+	var out []Migration
+	for _, m := range r.migrations {
+		if _, ok := applied[m.Version]; !ok {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// Up applies every pending migration in order and returns the ones applied.
+// It stops at the first failure; earlier migrations stay applied.
+func (r *Runner) Up() ([]Migration, error) {
+	if err := r.db.AutoMigrate(&AppliedMigration{}); err != nil {
+		return nil, fmt.Errorf("ensure schema_migrations table: %w", err)
+	}
+
+	pending, err := r.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range pending {
+		if err := r.apply(m); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Check returns ErrSchemaBehind when the database is missing migrations that
+// this binary expects. A database ahead of the binary is accepted so an older
+// release can keep running during a rollout.
+func (r *Runner) Check() error {
+	pending, err := r.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	version, err := r.Version()
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: at version %d, expected %d (%d pending); run `migrate up`",
+		ErrSchemaBehind, version, r.Latest(), len(pending))
+}
+
+func (r *Runner) applied() (map[uint]struct{}, error) {
+	out := make(map[uint]struct{})
+	if !r.db.Migrator().HasTable(&AppliedMigration{}) {
+		return out, nil
+	}
+	var rows []AppliedMigration
+	if err := r.db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("read applied migrations: %w", err)
+	}
+	for _, row := range rows {
+		out[row.Version] = struct{}{}
+	}
+	return out, nil
+}
+
+func (r *Runner) apply(m Migration) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(m.SQL) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+			}
+		}
+		rec := AppliedMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}
+		if err := tx.Create(&rec).Error; err != nil {
+			return fmt.Errorf("record migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		return nil
+	})
+}
+
+// splitStatements breaks a migration into statements on lines ending with a
+// semicolon and drops "--" comment lines. Bodies that themselves contain
+// line-ending semicolons (e.g. plpgsql functions) are not supported.
+func splitStatements(sql string) []string {
+	var (
+		out []string
+		cur strings.Builder
+	)
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		cur.WriteString(line)
+		cur.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			if stmt := strings.TrimSpace(cur.String()); stmt != "" {
+				out = append(out, stmt)
+			}
+			cur.Reset()
+		}
+	}
+	if stmt := strings.TrimSpace(cur.String()); stmt != "" {
+		out = append(out, stmt)
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return db
+}
+
+func TestLoadOrdersAndSkipsUnrelatedFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_third.up.sql":  {Data: []byte("SELECT 3;")},
+		"0002_second.up.sql": {Data: []byte("SELECT 2;")},
+		"0001_first.up.sql":  {Data: []byte("SELECT 1;")},
+		"README.md":          {Data: []byte("notes")},
+		"0003_x.down.sql":    {Data: []byte("SELECT 0;")},
+	}
+
+	migrations, err := Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	require.Equal(t, uint(1), migrations[0].Version)
+	require.Equal(t, "second", migrations[1].Name)
+	require.Equal(t, uint(10), migrations[2].Version)
+}
+
+func TestLoadRejectsDuplicateVersions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_first.up.sql": {Data: []byte("SELECT 1;")},
+		"001_again.up.sql":  {Data: []byte("SELECT 1;")},
+	}
+	_, err := Load(fsys)
+	require.Error(t, err)
+}
+
+func TestRunnerUpAndCheck(t *testing.T) {
+	db := openTestDB(t)
+	fsys := fstest.MapFS{
+		"0001_widgets.up.sql": {Data: []byte("-- widgets\nCREATE TABLE widgets (id integer PRIMARY KEY);\nCREATE INDEX idx_widgets_id ON widgets (id);\n")},
+		"0002_gadgets.up.sql": {Data: []byte("CREATE TABLE gadgets (\n  id integer PRIMARY KEY\n);\n")},
+	}
+	runner, err := NewRunnerFromFS(db, fsys)
+	require.NoError(t, err)
+
+	err = runner.Check()
+	require.True(t, errors.Is(err, ErrSchemaBehind))
+
+	applied, err := runner.Up()
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+	require.True(t, db.Migrator().HasTable("widgets"))
+	require.True(t, db.Migrator().HasTable("gadgets"))
+
+	version, err := runner.Version()
+	require.NoError(t, err)
+	require.Equal(t, uint(2), version)
+	require.NoError(t, runner.Check())
+
+	// A second run is a no-op.
+	applied, err = runner.Up()
+	require.NoError(t, err)
+	require.Empty(t, applied)
+
+	// A newer binary ships another migration: the old database is behind.
+	fsys["0003_gizmos.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE gizmos (id integer PRIMARY KEY);")}
+	newer, err := NewRunnerFromFS(db, fsys)
+	require.NoError(t, err)
+	require.True(t, errors.Is(newer.Check(), ErrSchemaBehind))
+
+	pending, err := newer.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, "gizmos", pending[0].Name)
+}
+
+func TestRunnerFailedMigrationIsNotRecorded(t *testing.T) {
+	db := openTestDB(t)
+	fsys := fstest.MapFS{
+		"0001_ok.up.sql":     {Data: []byte("CREATE TABLE ok_table (id integer PRIMARY KEY);")},
+		"0002_broken.up.sql": {Data: []byte("CREATE TABLE broken_table (id integer PRIMARY KEY);\nTHIS IS NOT SQL;")},
+	}
+	runner, err := NewRunnerFromFS(db, fsys)
+	require.NoError(t, err)
+
+	applied, err := runner.Up()
+	require.Error(t, err)
+	require.Len(t, applied, 1)
+
+	version, err := runner.Version()
+	require.NoError(t, err)
+	require.Equal(t, uint(1), version)
+	require.False(t, db.Migrator().HasTable("broken_table"))
+}
+
+func TestEmbeddedBaselineApplies(t *testing.T) {
+	db := openTestDB(t)
+	runner, err := NewRunner(db)
+	require.NoError(t, err)
+	require.NotEmpty(t, runner.Migrations())
+	require.Equal(t, "baseline", runner.Migrations()[0].Name)
+
+	_, err = runner.Up()
+	require.NoError(t, err)
+	require.NoError(t, runner.Check())
+
+	for _, table := range []string{"users", "orders", "order_intents", "user_exchanges", "trades", "api_tokens", "data_migrations"} {
+		require.True(t, db.Migrator().HasTable(table), table)
+	}
+}
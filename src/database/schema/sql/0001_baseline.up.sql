@@ -0,0 +1,101 @@
+-- Baseline schema: every table previously created by AutoMigrate.
+-- Statements are idempotent so databases that were bootstrapped with
+-- AutoMigrate can adopt versioned migrations with `migrate up`.
+
+CREATE TABLE IF NOT EXISTS "users" ("id" bigserial,"user_name" text NOT NULL,"password" text,"email" varchar(255),"first_name" varchar(100),"last_name" varchar(100),"bio" varchar(1024),"avatar_url" varchar(512),"phone_number" varchar(100),"timezone" text,"last_login" timestamptz,"last_seen" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("user_name");
+
+CREATE TABLE IF NOT EXISTS "orders" ("id" bigserial,"strategy_id" bigint,"user_id" bigint,"legacy_user_id" varchar(60),"exchange_id" bigint,"external_id" bigint,"symbol" text,"side" text,"pos_side" text,"order_type" text,"quantity" decimal,"price" decimal,"stop_loss_pct" decimal,"take_profit_pct" decimal,"status" varchar(50) NOT NULL DEFAULT 'pending',"order_dir" varchar(10) NOT NULL,"executed_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_orders_external_id" ON "orders" ("external_id");
+CREATE INDEX IF NOT EXISTS "idx_orders_exchange_id" ON "orders" ("exchange_id");
+CREATE INDEX IF NOT EXISTS "idx_orders_user_id" ON "orders" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_orders_strategy_id" ON "orders" ("strategy_id");
+
+CREATE TABLE IF NOT EXISTS "order_logs" ("id" bigserial,"order_id" bigint,"symbol" varchar(100),"side" varchar(20),"pos_side" text,"order_type" varchar(50),"quantity" decimal,"stop_loss_pct" decimal,"take_profit_pct" decimal,"price" decimal,"exchange_id" bigint,"status" varchar(50) NOT NULL,"reason" text,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_orders_logs" FOREIGN KEY ("order_id") REFERENCES "orders"("id"));
+CREATE INDEX IF NOT EXISTS "idx_order_logs_exchange_id" ON "order_logs" ("exchange_id");
+CREATE INDEX IF NOT EXISTS "idx_order_logs_order_id" ON "order_logs" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "order_execution_logs" ("id" bigserial,"order_id" bigint,"symbol" varchar(100),"side" varchar(20),"order_type" varchar(50),"quantity" decimal,"price" decimal,"exchange_id" bigint,"exchange_order_id" varchar(255),"exchange_client_id" varchar(255),"external_reference" varchar(255),"status" varchar(50) NOT NULL,"reason" varchar(255),"error_message" text,"requested_at" timestamptz,"accepted_at" timestamptz,"executed_at" timestamptz,"completed_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_order_execution_logs_order" FOREIGN KEY ("order_id") REFERENCES "orders"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_order_execution_logs_exchange_id" ON "order_execution_logs" ("exchange_id");
+CREATE INDEX IF NOT EXISTS "idx_order_execution_logs_order_id" ON "order_execution_logs" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "order_intents" ("id" bigserial,"order_id" bigint NOT NULL,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"client_order_id" varchar(40) NOT NULL,"symbol" varchar(50) NOT NULL,"side" varchar(10),"pos_side" varchar(10),"quantity" varchar(50),"order_type" varchar(20),"reduce_only" boolean,"status" varchar(20) NOT NULL,"attempts" bigint,"exchange_order_id" varchar(100),"last_error" text,"dispatched_at" timestamptz,"completed_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_order_intents_client_order_id" ON "order_intents" ("client_order_id");
+CREATE INDEX IF NOT EXISTS "idx_order_intents_user_exchange_status" ON "order_intents" ("user_id","exchange_id","status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_order_intents_order_id" ON "order_intents" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "exchanges" ("id" bigserial,"name" text NOT NULL,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_exchanges_name" ON "exchanges" ("name");
+
+CREATE TABLE IF NOT EXISTS "phemex_orders" ("id" bigserial,"order_id" bigint NOT NULL,"exchange_order_id" varchar(100),"cl_ord_id" varchar(100),"symbol" varchar(50),"side" varchar(10),"action_time" timestamptz,"transact_time" timestamptz,"order_type" varchar(30),"price" decimal,"order_qty" decimal,"display_qty" decimal,"time_in_force" varchar(50),"closed_pnl" decimal,"closed_size" decimal,"cum_qty" decimal,"cum_value" decimal,"leaves_qty" decimal,"leaves_value" decimal,"stop_direction" varchar(30),"stop_price" decimal,"trigger" varchar(30),"peg_offset_value" decimal,"peg_offset_proportion" decimal,"peg_price_type" varchar(30),"exec_status" varchar(50),"ord_status" varchar(50),"exec_inst" varchar(50),"take_profit" decimal,"stop_loss" decimal,"sl_price" decimal,"tp_price" decimal,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_phemex_orders_order" FOREIGN KEY ("order_id") REFERENCES "orders"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_phemex_orders_symbol" ON "phemex_orders" ("symbol");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_phemex_orders_exchange_order_id" ON "phemex_orders" ("exchange_order_id");
+CREATE INDEX IF NOT EXISTS "idx_phemex_orders_order_id" ON "phemex_orders" ("order_id");
+
+CREATE TABLE IF NOT EXISTS "exceptions" ("id" bigserial,"service" varchar(100),"module" varchar(100),"method" varchar(100),"message" text,"stack" text,"level" varchar(20),"context" jsonb,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_exceptions_level" ON "exceptions" ("level");
+CREATE INDEX IF NOT EXISTS "idx_exceptions_module" ON "exceptions" ("module");
+CREATE INDEX IF NOT EXISTS "idx_exceptions_service" ON "exceptions" ("service");
+
+CREATE TABLE IF NOT EXISTS "user_exchanges" ("id" bigserial,"user_id" bigint NOT NULL,"legacy_user_id" varchar(60),"exchange_id" bigint NOT NULL,"api_key" text,"api_secret" text,"api_passphrase" text,"secret_backend" varchar(20),"secret_path" varchar(255),"order_size_percent" bigint,"run_on_server" boolean,"created_at" timestamptz,"updated_at" timestamptz,"last_validated_at" timestamptz,"last_validation_error" text,"validation_failures" bigint,"weekend_holiday_multiplier" text,"dead_zone_multiplier" text,"asia_multiplier" text,"london_multiplier" text,"us_multiplier" text,"default_multiplier" text,"enable_no_trade_window" boolean,"no_trade_window_orders_closed" boolean,PRIMARY KEY ("id"),CONSTRAINT "fk_user_exchanges_exchange" FOREIGN KEY ("exchange_id") REFERENCES "exchanges"("id") ON DELETE CASCADE);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_exchange" ON "user_exchanges" ("user_id","exchange_id");
+
+CREATE TABLE IF NOT EXISTS "user_notification_settings" ("id" bigserial,"user_id" bigint NOT NULL,"telegram_chat_id" varchar(100),"discord_webhook_url" varchar(512),"slack_webhook_url" varchar(512),"email" varchar(255),"notify_order_placed" boolean,"notify_order_filled" boolean,"notify_order_error" boolean,"notify_stop_loss_moved" boolean,"notify_kill_switch" boolean,"notify_executor_crash" boolean,"notify_daily_pnl" boolean,"notify_loss_cooldown" boolean,"notify_key_invalid" boolean,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_notification_settings_user_id" ON "user_notification_settings" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "trading_view_news_event" ("id" bigserial,"tv_event_id" text NOT NULL,"title" text,"country" text,"indicator" text,"ticker" text,"comment" text,"category" text,"period" text,"reference_date" timestamptz,"source" text,"source_url" text,"news_source" varchar(30) NOT NULL DEFAULT 'tradingview',"actual" decimal,"previous" decimal,"forecast" decimal,"actual_raw" decimal,"previous_raw" decimal,"forecast_raw" decimal,"currency" text,"unit" text,"importance" bigint,"event_date" timestamptz,"content_hash" varchar(64),"sentiment_score" decimal,"sentiment_scorer" text,"sentiment_scored_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_trading_view_news_event_content_hash" ON "trading_view_news_event" ("content_hash");
+CREATE INDEX IF NOT EXISTS "idx_trading_view_news_event_event_date" ON "trading_view_news_event" ("event_date");
+CREATE INDEX IF NOT EXISTS "idx_trading_view_news_event_importance" ON "trading_view_news_event" ("importance");
+CREATE INDEX IF NOT EXISTS "idx_trading_view_news_event_news_source" ON "trading_view_news_event" ("news_source");
+CREATE INDEX IF NOT EXISTS "idx_trading_view_news_event_country" ON "trading_view_news_event" ("country");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_trading_view_news_event_tv_event_id" ON "trading_view_news_event" ("tv_event_id");
+
+CREATE TABLE IF NOT EXISTS "tv_news_watchlist" ("id" bigserial,"ticker" varchar(100) NOT NULL,"country" varchar(10) NOT NULL DEFAULT 'US',"enabled" boolean NOT NULL,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tv_news_watchlist_ticker" ON "tv_news_watchlist" ("ticker");
+
+CREATE TABLE IF NOT EXISTS "ohlcv_crypto_1m" ("id" bigserial,"symbol" varchar(50) NOT NULL,"datetime" timestamptz NOT NULL,"open" double precision NOT NULL,"high" double precision NOT NULL,"low" double precision NOT NULL,"close" double precision NOT NULL,"volume" double precision NOT NULL,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_ohlcv_crypto_1m_datetime" ON "ohlcv_crypto_1m" ("datetime");
+CREATE INDEX IF NOT EXISTS "idx_ohlcv_crypto_1m_symbol_datetime" ON "ohlcv_crypto_1m" ("symbol","datetime");
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_ohlcv_crypto_1m_symbol_datetime" ON "ohlcv_crypto_1m" ("symbol","datetime");
+
+CREATE TABLE IF NOT EXISTS "ohlcv_crypto_1h" ("id" bigserial,"symbol" varchar(50) NOT NULL,"datetime" timestamptz NOT NULL,"open" double precision NOT NULL,"high" double precision NOT NULL,"low" double precision NOT NULL,"close" double precision NOT NULL,"volume" double precision NOT NULL,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_ohlcv_crypto_1h_datetime" ON "ohlcv_crypto_1h" ("datetime");
+CREATE INDEX IF NOT EXISTS "idx_ohlcv_crypto_1h_symbol_datetime" ON "ohlcv_crypto_1h" ("symbol","datetime");
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_ohlcv_crypto_1h_symbol_datetime" ON "ohlcv_crypto_1h" ("symbol","datetime");
+
+CREATE TABLE IF NOT EXISTS "funding_rate" ("id" bigserial,"exchange" varchar(50) NOT NULL,"symbol" varchar(50) NOT NULL,"datetime" timestamptz NOT NULL,"funding_rate" double precision NOT NULL,"predicted_funding_rate" double precision NOT NULL,"next_funding_time" timestamptz NOT NULL,"mark_price" double precision NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_funding_rate_datetime" ON "funding_rate" ("datetime");
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_funding_rate_exchange_symbol_datetime" ON "funding_rate" ("exchange","symbol","datetime");
+
+CREATE TABLE IF NOT EXISTS "open_interest" ("id" bigserial,"exchange" varchar(50) NOT NULL,"symbol" varchar(50) NOT NULL,"datetime" timestamptz NOT NULL,"open_interest" double precision NOT NULL,"mark_price" double precision NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_open_interest_datetime" ON "open_interest" ("datetime");
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_open_interest_exchange_symbol_datetime" ON "open_interest" ("exchange","symbol","datetime");
+
+CREATE TABLE IF NOT EXISTS "daily_pnl" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"day" date NOT NULL,"realized_pnl" double precision NOT NULL,"unrealized_pnl" double precision NOT NULL,"fees" double precision NOT NULL,"net_pnl" double precision NOT NULL,"max_drawdown" double precision NOT NULL,"fills" bigint,"wins" bigint,"losses" bigint,"win_rate" decimal,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_daily_pnl_user_exchange_day" ON "daily_pnl" ("user_id","exchange_id","day");
+
+CREATE TABLE IF NOT EXISTS "trades" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"symbol" varchar(50) NOT NULL,"pos_side" varchar(10),"status" varchar(10) NOT NULL,"entry_signal_id" bigint,"entry_order_id" bigint NOT NULL,"exit_signal_id" bigint,"exit_order_id" bigint,"quantity" decimal,"entry_price" decimal,"exit_price" decimal,"stop_loss" decimal,"entry_time" timestamptz NOT NULL,"exit_time" timestamptz,"pnl" decimal,"r_multiple" decimal,"duration_seconds" bigint,"mae" decimal,"mfe" decimal,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_trades_exit_order_id" ON "trades" ("exit_order_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_trades_entry_order_id" ON "trades" ("entry_order_id");
+CREATE INDEX IF NOT EXISTS "idx_trades_user_entry_time" ON "trades" ("user_id","entry_time");
+
+CREATE TABLE IF NOT EXISTS "strategies" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"symbol" varchar(50) NOT NULL,"name" varchar(100) NOT NULL,"params" text,"enabled" boolean,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_strategies_user_exchange_symbol" ON "strategies" ("user_id","exchange_id","symbol");
+
+CREATE TABLE IF NOT EXISTS "strategy_actions" ("id" bigserial,"strategy_id" bigint NOT NULL,"hook" varchar(20) NOT NULL,"action" varchar(20) NOT NULL,"side" varchar(10),"pos_side" varchar(10),"reason" varchar(512),"signal_id" bigint,"order_id" bigint,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_strategy_actions_strategy" FOREIGN KEY ("strategy_id") REFERENCES "strategies"("id") ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS "idx_strategy_actions_order_id" ON "strategy_actions" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_strategy_actions_signal_id" ON "strategy_actions" ("signal_id");
+CREATE INDEX IF NOT EXISTS "idx_strategy_actions_strategy_id" ON "strategy_actions" ("strategy_id");
+
+CREATE TABLE IF NOT EXISTS "signal_filter_settings" ("id" bigserial,"user_id" bigint NOT NULL,"trading_start_hour" bigint,"trading_end_hour" bigint,"timezone" varchar(64),"news_sentiment_threshold" decimal,"news_sentiment_lookback_minutes" bigint,"max_volatility_pct" decimal,"volatility_lookback_minutes" bigint,"max_spread_bps" decimal,"max_open_positions" bigint,"loss_cooldown_minutes" bigint,"max_consecutive_losses" bigint,"loss_streak_cooldown_minutes" bigint,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_signal_filter_settings_user_id" ON "signal_filter_settings" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "loss_streaks" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"symbol" varchar(50) NOT NULL,"consecutive_losses" bigint,"last_trade_id" bigint,"last_loss_at" timestamptz,"cooldown_until" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_loss_streak_user_exchange_symbol" ON "loss_streaks" ("user_id","exchange_id","symbol");
+
+CREATE TABLE IF NOT EXISTS "api_tokens" ("id" bigserial,"user_id" bigint NOT NULL,"name" varchar(100),"token_hash" varchar(64) NOT NULL,"last_used_at" timestamptz,"revoked_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_tokens_token_hash" ON "api_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_api_tokens_user_id" ON "api_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "data_migrations" ("id" varchar(200),"applied_at" timestamptz NOT NULL,PRIMARY KEY ("id"));
@@ -0,0 +1,7 @@
+-- Archive of orders moved out of the live tables (model.ArchivedOrder).
+
+CREATE TABLE IF NOT EXISTS "order_archives" ("id" bigserial,"order_id" bigint NOT NULL,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"external_id" bigint,"status" varchar(50),"order_created_at" timestamptz NOT NULL,"payload" jsonb NOT NULL,"archived_at" timestamptz NOT NULL,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_order_archives_order_created_at" ON "order_archives" ("order_created_at");
+CREATE INDEX IF NOT EXISTS "idx_order_archives_external_id" ON "order_archives" ("external_id");
+CREATE INDEX IF NOT EXISTS "idx_order_archives_user_created" ON "order_archives" ("user_id","order_created_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_order_archives_order_id" ON "order_archives" ("order_id");
@@ -0,0 +1,4 @@
+-- Feature flag overrides (model.FeatureFlag). user_id 0 is a global override.
+
+CREATE TABLE IF NOT EXISTS "feature_flags" ("id" bigserial,"key" varchar(64) NOT NULL,"user_id" bigint NOT NULL DEFAULT 0,"enabled" boolean NOT NULL,"note" varchar(255),"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_feature_flags_key_user" ON "feature_flags" ("key","user_id");
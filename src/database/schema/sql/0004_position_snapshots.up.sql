@@ -0,0 +1,5 @@
+-- Open positions recorded by the position_snapshot job (model.PositionSnapshot).
+
+CREATE TABLE IF NOT EXISTS "position_snapshots" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"symbol" varchar(50) NOT NULL,"pos_side" varchar(10) NOT NULL,"taken_at" timestamptz NOT NULL,"side" varchar(10),"size" double precision NOT NULL,"entry_price" double precision NOT NULL,"mark_price" double precision NOT NULL,"notional" double precision NOT NULL,"position_margin" double precision NOT NULL,"unrealized_pnl" double precision NOT NULL,"leverage" double precision NOT NULL,"liquidation_price" double precision NOT NULL,"account_balance" double precision NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_position_snapshot_user_exchange_symbol_side_taken" ON "position_snapshots" ("user_id","exchange_id","symbol","pos_side","taken_at");
+CREATE INDEX IF NOT EXISTS "idx_position_snapshot_user_taken" ON "position_snapshots" ("user_id","taken_at");
@@ -0,0 +1,4 @@
+-- Per-user equity recorded by the position_snapshot job (model.EquitySnapshot).
+
+CREATE TABLE IF NOT EXISTS "equity_snapshots" ("id" bigserial,"user_id" bigint NOT NULL,"taken_at" timestamptz NOT NULL,"balance" double precision NOT NULL,"unrealized_pnl" double precision NOT NULL,"equity" double precision NOT NULL,"exchanges" bigint NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_equity_snapshot_user_taken" ON "equity_snapshots" ("user_id","taken_at");
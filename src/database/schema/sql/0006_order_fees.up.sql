@@ -0,0 +1,8 @@
+-- Order fees (model.Order, model.PhemexOrder) and trade fees (model.Trade).
+
+ALTER TABLE "orders" ADD COLUMN "fee" decimal;
+ALTER TABLE "orders" ADD COLUMN "fee_currency" varchar(20);
+ALTER TABLE "orders" ADD COLUMN "fee_estimated" boolean NOT NULL DEFAULT false;
+ALTER TABLE "phemex_orders" ADD COLUMN "fee" decimal;
+ALTER TABLE "phemex_orders" ADD COLUMN "fee_currency" varchar(20);
+ALTER TABLE "trades" ADD COLUMN "fees" decimal;
@@ -0,0 +1,7 @@
+-- Exchange error code and operator hint on exceptions (model.Exception).
+
+ALTER TABLE "exceptions" ADD COLUMN "exchange" varchar(30);
+ALTER TABLE "exceptions" ADD COLUMN "error_code" varchar(50);
+ALTER TABLE "exceptions" ADD COLUMN "hint" text;
+ALTER TABLE "exceptions" ADD COLUMN "action" text;
+CREATE INDEX IF NOT EXISTS "idx_exceptions_exchange" ON "exceptions" ("exchange");
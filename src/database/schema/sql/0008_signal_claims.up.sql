@@ -0,0 +1,5 @@
+-- Signals acted on per user, for duplicate suppression (model.SignalClaim).
+
+CREATE TABLE IF NOT EXISTS "signal_claims" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"symbol" varchar(50) NOT NULL,"fingerprint" varchar(64) NOT NULL,"window_start" timestamptz NOT NULL,"signal_id" bigint NOT NULL,"claimed_at" timestamptz NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_signal_claim_window" ON "signal_claims" ("user_id","exchange_id","symbol","fingerprint","window_start");
+CREATE INDEX IF NOT EXISTS "idx_signal_claim_user_signal" ON "signal_claims" ("user_id","signal_id");
@@ -0,0 +1,3 @@
+-- Limit price of order intents sent as ImmediateOrCancel limits (model.OrderIntent).
+
+ALTER TABLE "order_intents" ADD COLUMN "price" varchar(50);
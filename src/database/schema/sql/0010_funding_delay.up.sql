@@ -0,0 +1,4 @@
+-- Funding-aware entry delay of the signal filters (model.SignalFilterSetting).
+
+ALTER TABLE "signal_filter_settings" ADD COLUMN "funding_delay_minutes" bigint;
+ALTER TABLE "signal_filter_settings" ADD COLUMN "funding_delay_min_rate_bps" decimal;
@@ -0,0 +1,4 @@
+-- Per-user target allocation of assets across exchanges (model.AllocationTarget).
+
+CREATE TABLE IF NOT EXISTS "allocation_targets" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"asset" varchar(20) NOT NULL,"target_pct" double precision NOT NULL,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_allocation_targets_exchange" FOREIGN KEY ("exchange_id") REFERENCES "exchanges"("id") ON DELETE CASCADE);
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_allocation_target" ON "allocation_targets" ("user_id","exchange_id","asset");
@@ -0,0 +1,4 @@
+-- Auto top-up of the futures wallet before entries (model.UserExchange).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "topup_below_usdt" decimal;
+ALTER TABLE "user_exchanges" ADD COLUMN "topup_amount_usdt" decimal;
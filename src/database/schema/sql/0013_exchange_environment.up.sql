@@ -0,0 +1,5 @@
+-- Testnet/live environment of exchange accounts (model.UserExchange) and
+-- testnet-only users (model.User).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "environment" varchar(10) NOT NULL DEFAULT 'live';
+ALTER TABLE "users" ADD COLUMN "testnet_only" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,5 @@
+-- Candlestick pattern entry filter of the signal filters (model.SignalFilterSetting).
+
+ALTER TABLE "signal_filter_settings" ADD COLUMN "candle_pattern_long" varchar(32);
+ALTER TABLE "signal_filter_settings" ADD COLUMN "candle_pattern_short" varchar(32);
+ALTER TABLE "signal_filter_settings" ADD COLUMN "candle_pattern_interval_minutes" bigint;
@@ -0,0 +1,3 @@
+-- Swing-structure stop placement per account (model.UserExchange).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "stop_mode" varchar(10);
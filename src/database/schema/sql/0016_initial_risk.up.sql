@@ -0,0 +1,5 @@
+-- Initial risk of entries and trades for R-multiple tracking (model.Order,
+-- model.Trade).
+
+ALTER TABLE "orders" ADD COLUMN "initial_risk" decimal;
+ALTER TABLE "trades" ADD COLUMN "initial_risk" decimal;
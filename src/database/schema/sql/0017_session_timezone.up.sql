@@ -0,0 +1,3 @@
+-- Timezone of the session based sizing per account (model.UserExchange).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "session_timezone" varchar(64);
@@ -0,0 +1,4 @@
+-- Weekly trading-hours windows blocking or reducing new entries per user/symbol (model.ScheduleRule).
+
+CREATE TABLE IF NOT EXISTS "schedule_rules" ("id" bigserial,"user_id" bigint NOT NULL,"symbol" varchar(50) NOT NULL DEFAULT '',"start_day" bigint NOT NULL,"start_minute" bigint NOT NULL,"end_day" bigint NOT NULL,"end_minute" bigint NOT NULL,"timezone" varchar(64),"action" varchar(10) NOT NULL,"size_multiplier" double precision NOT NULL DEFAULT 0,"note" varchar(255),"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_schedule_rules_user_id" ON "schedule_rules" ("user_id");
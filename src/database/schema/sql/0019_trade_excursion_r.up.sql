@@ -0,0 +1,4 @@
+-- MAE / MFE of the journal trades in units of their initial risk (model.Trade).
+
+ALTER TABLE "trades" ADD COLUMN "mae_r" decimal;
+ALTER TABLE "trades" ADD COLUMN "mfe_r" decimal;
@@ -0,0 +1,3 @@
+-- Strategy assignment of the journal trades, copied from the entry order (model.Trade).
+
+ALTER TABLE "trades" ADD COLUMN "strategy_id" bigint;
@@ -0,0 +1,9 @@
+-- Payload shape, hedge-mode flags and fill executions of Phemex orders (model.PhemexOrder).
+
+ALTER TABLE "phemex_orders" ADD COLUMN "kind" varchar(20);
+ALTER TABLE "phemex_orders" ADD COLUMN "pos_side" varchar(10);
+ALTER TABLE "phemex_orders" ADD COLUMN "reduce_only" boolean;
+ALTER TABLE "phemex_orders" ADD COLUMN "close_on_trigger" boolean;
+ALTER TABLE "phemex_orders" ADD COLUMN "exec_id" varchar(100);
+ALTER TABLE "phemex_orders" ADD COLUMN "exec_qty" decimal;
+ALTER TABLE "phemex_orders" ADD COLUMN "exec_price" decimal;
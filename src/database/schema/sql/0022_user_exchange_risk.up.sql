@@ -0,0 +1,7 @@
+-- Per-exchange risk overrides of an account (model.UserExchange).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "max_leverage" decimal;
+ALTER TABLE "user_exchanges" ADD COLUMN "max_position_notional" decimal;
+ALTER TABLE "user_exchanges" ADD COLUMN "default_sl_pct" decimal;
+ALTER TABLE "user_exchanges" ADD COLUMN "default_tp_pct" decimal;
+ALTER TABLE "user_exchanges" ADD COLUMN "hedge_mode" boolean;
@@ -0,0 +1,6 @@
+-- Capability flags of the exchange registry (model.Exchange).
+
+ALTER TABLE "exchanges" ADD COLUMN "supports_hedge" boolean NOT NULL DEFAULT false;
+ALTER TABLE "exchanges" ADD COLUMN "supports_stop_order" boolean NOT NULL DEFAULT false;
+ALTER TABLE "exchanges" ADD COLUMN "futures" boolean NOT NULL DEFAULT false;
+ALTER TABLE "exchanges" ADD COLUMN "spot" boolean NOT NULL DEFAULT false;
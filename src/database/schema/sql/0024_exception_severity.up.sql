@@ -0,0 +1,8 @@
+-- Severity and category of captured exceptions (model.Exception) and the
+-- notification of critical ones (model.UserNotificationSetting).
+
+ALTER TABLE "exceptions" ADD COLUMN "severity" varchar(10);
+ALTER TABLE "exceptions" ADD COLUMN "category" varchar(20);
+CREATE INDEX IF NOT EXISTS "idx_exceptions_severity" ON "exceptions" ("severity");
+CREATE INDEX IF NOT EXISTS "idx_exceptions_category" ON "exceptions" ("category");
+ALTER TABLE "user_notification_settings" ADD COLUMN "notify_critical_error" boolean;
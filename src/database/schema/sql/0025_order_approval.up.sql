@@ -0,0 +1,5 @@
+-- Two-stage confirmation of large entries (model.UserExchange).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "approval_notional" decimal;
+ALTER TABLE "user_exchanges" ADD COLUMN "approval_timeout_minutes" integer;
+ALTER TABLE "user_exchanges" ADD COLUMN "approval_timeout_action" varchar(10);
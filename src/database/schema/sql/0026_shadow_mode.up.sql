@@ -0,0 +1,6 @@
+-- Shadow accounts and the order calls they record (model.UserExchange, model.ShadowCall).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "shadow" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS "shadow_calls" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"method" varchar(30) NOT NULL,"client_order_id" varchar(64),"symbol" varchar(50),"side" varchar(10),"pos_side" varchar(10),"quantity" varchar(40),"order_type" varchar(30),"price" varchar(40),"reduce_only" boolean NOT NULL DEFAULT false,"fill_price" decimal,"paper_order_id" varchar(40),"error" text,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_shadow_call_user_exchange" ON "shadow_calls" ("user_id","exchange_id");
@@ -0,0 +1,4 @@
+-- Executor decisions per signal, for replay protection after downtime (model.SignalExecution).
+
+CREATE TABLE IF NOT EXISTS "signal_executions" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"signal_id" bigint NOT NULL,"symbol" varchar(50),"decision" varchar(20) NOT NULL,"reason" text,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_signal_execution" ON "signal_executions" ("user_id","exchange_id","signal_id");
@@ -0,0 +1,4 @@
+-- Exchange IDs of the protective orders of an entry (model.Order).
+
+ALTER TABLE "orders" ADD COLUMN "stop_order_id" varchar(64);
+ALTER TABLE "orders" ADD COLUMN "take_profit_order_id" varchar(64);
@@ -0,0 +1,3 @@
+-- Detected KuCoin key version of an account (model.UserExchange).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "key_version" varchar(2);
@@ -0,0 +1,6 @@
+-- Captured order-mutating exchange calls (model.UserExchange, model.APICallLog).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "capture_api_calls" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS "api_call_log" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"exchange" varchar(20) NOT NULL,"method" varchar(10) NOT NULL,"path" varchar(255) NOT NULL,"query" text,"request_body" text,"status" bigint,"response_body" text,"latency_ms" bigint,"error" text,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_api_call_log_user_exchange" ON "api_call_log" ("user_id","exchange_id");
@@ -0,0 +1,3 @@
+-- Fleet-wide outcome of each signal (model.SignalExecutionSummary).
+
+CREATE TABLE IF NOT EXISTS "signal_execution_summaries" ("signal_id" bigint,"symbol" varchar(50),"accounts" bigint NOT NULL DEFAULT 0,"filled" bigint NOT NULL DEFAULT 0,"skipped" bigint NOT NULL DEFAULT 0,"errored" bigint NOT NULL DEFAULT 0,"pending" bigint NOT NULL DEFAULT 0,"clean" boolean NOT NULL DEFAULT false,"updated_at" timestamptz,PRIMARY KEY ("signal_id"));
@@ -0,0 +1,5 @@
+-- Per-account execution priority and the stagger recorded on each order (model.UserExchange, model.Order).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "execution_priority" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "orders" ADD COLUMN "stagger_ms" bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Copy trading followers of a leader account (model.CopyFollower).
+
+CREATE TABLE IF NOT EXISTS "copy_followers" ("id" bigserial,"leader_user_id" bigint NOT NULL,"leader_exchange_id" bigint NOT NULL,"follower_user_id" bigint NOT NULL,"follower_exchange_id" bigint NOT NULL,"enabled" boolean NOT NULL DEFAULT true,"multiplier" decimal NOT NULL DEFAULT 1,"max_order_notional" decimal NOT NULL DEFAULT 0,"max_position_notional" decimal NOT NULL DEFAULT 0,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_copy_follower" ON "copy_followers" ("leader_user_id","leader_exchange_id","follower_user_id","follower_exchange_id");
+CREATE INDEX IF NOT EXISTS "idx_copy_follower_leader" ON "copy_followers" ("leader_user_id","leader_exchange_id");
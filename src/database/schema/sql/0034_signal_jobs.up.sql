@@ -0,0 +1,11 @@
+-- Queue of webhook signals per consuming account (model.SignalJob) and the
+-- executors consuming it (model.SignalQueueConsumer).
+
+CREATE TABLE IF NOT EXISTS "signal_jobs" ("id" bigserial,"signal_id" bigint NOT NULL,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"symbol" varchar(50) NOT NULL,"status" varchar(10) NOT NULL DEFAULT 'queued',"attempts" bigint NOT NULL DEFAULT 0,"visible_at" timestamptz NOT NULL,"last_error" text,"completed_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_signal_job" ON "signal_jobs" ("signal_id","user_id","exchange_id");
+CREATE INDEX IF NOT EXISTS "idx_signal_job_consumer" ON "signal_jobs" ("user_id","exchange_id","symbol","visible_at");
+CREATE INDEX IF NOT EXISTS "idx_signal_jobs_status" ON "signal_jobs" ("status");
+
+CREATE TABLE IF NOT EXISTS "signal_queue_consumers" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"symbol" varchar(50) NOT NULL,"exchange_name" varchar(50) NOT NULL,"last_seen_at" timestamptz NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_signal_queue_consumer" ON "signal_queue_consumers" ("user_id","exchange_id","symbol");
+CREATE INDEX IF NOT EXISTS "idx_signal_queue_consumer_symbol" ON "signal_queue_consumers" ("symbol","exchange_name");
@@ -0,0 +1,4 @@
+-- Last run and lock of each periodic job of the scheduler (model.ScheduledJob).
+
+CREATE TABLE IF NOT EXISTS "scheduled_jobs" ("id" bigserial,"name" varchar(50) NOT NULL,"locked_until" timestamptz,"last_started_at" timestamptz,"last_finished_at" timestamptz,"last_status" varchar(10),"last_error" text,"last_duration_ms" bigint NOT NULL DEFAULT 0,"runs" bigint NOT NULL DEFAULT 0,"failures" bigint NOT NULL DEFAULT 0,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_scheduled_jobs_name" ON "scheduled_jobs" ("name");
@@ -0,0 +1,9 @@
+-- History of the runs of the scheduled jobs (model.JobRun) and the last
+-- success and item count of each job.
+
+ALTER TABLE "scheduled_jobs" ADD COLUMN "last_succeeded_at" timestamptz;
+ALTER TABLE "scheduled_jobs" ADD COLUMN "last_items" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "job_runs" ("id" bigserial,"job" varchar(50) NOT NULL,"started_at" timestamptz NOT NULL,"finished_at" timestamptz NOT NULL,"status" varchar(10) NOT NULL,"error" text,"items" bigint NOT NULL DEFAULT 0,"duration_ms" bigint NOT NULL DEFAULT 0,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_job_run_job" ON "job_runs" ("job","started_at");
+CREATE INDEX IF NOT EXISTS "idx_job_runs_status" ON "job_runs" ("status");
@@ -0,0 +1,6 @@
+-- Maintenance windows of the exchanges (model.MaintenanceWindow), entered
+-- by hand or read from their status pages.
+
+CREATE TABLE IF NOT EXISTS "maintenance_windows" ("id" bigserial,"exchange_id" bigint NOT NULL,"starts_at" timestamptz NOT NULL,"ends_at" timestamptz NOT NULL,"source" varchar(20) NOT NULL,"external_id" varchar(100) NOT NULL DEFAULT '',"title" varchar(255),"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_maintenance_window_exchange" ON "maintenance_windows" ("exchange_id","starts_at");
+CREATE INDEX IF NOT EXISTS "idx_maintenance_windows_external_id" ON "maintenance_windows" ("external_id");
@@ -0,0 +1,10 @@
+-- Outbound webhooks of the order events (model.OrderWebhook) and their
+-- deliveries (model.WebhookDelivery).
+
+CREATE TABLE IF NOT EXISTS "order_webhooks" ("id" bigserial,"user_id" bigint NOT NULL,"url" varchar(512) NOT NULL,"secret_enc" varchar(512) NOT NULL,"events" varchar(255) NOT NULL DEFAULT '',"enabled" boolean NOT NULL DEFAULT true,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_order_webhooks_user_id" ON "order_webhooks" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" ("id" bigserial,"webhook_id" bigint NOT NULL,"user_id" bigint NOT NULL,"event" varchar(50) NOT NULL,"payload" text NOT NULL,"status" varchar(10) NOT NULL DEFAULT 'pending',"attempts" bigint NOT NULL DEFAULT 0,"next_attempt_at" timestamptz NOT NULL,"last_status" bigint NOT NULL DEFAULT 0,"last_error" text,"delivered_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_user_id" ON "webhook_deliveries" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_due" ON "webhook_deliveries" ("status","next_attempt_at");
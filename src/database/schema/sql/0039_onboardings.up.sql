@@ -0,0 +1,4 @@
+-- Progress of new users through the onboarding flow (model.Onboarding).
+
+CREATE TABLE IF NOT EXISTS "onboardings" ("id" bigserial,"user_id" bigint NOT NULL,"step" varchar(10) NOT NULL DEFAULT 'keys',"exchange_id" bigint NOT NULL DEFAULT 0,"symbol" varchar(50),"strategy" varchar(100),"risk_profile" varchar(20),"paper_started_at" timestamptz,"completed_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_onboardings_user_id" ON "onboardings" ("user_id");
@@ -0,0 +1,4 @@
+-- API role of users (model.User), existing users keep managing their own
+-- accounts.
+
+ALTER TABLE "users" ADD COLUMN "role" varchar(10) NOT NULL DEFAULT 'operator';
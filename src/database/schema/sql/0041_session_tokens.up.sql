@@ -0,0 +1,3 @@
+-- Expiry of the session tokens issued by logging in (model.APIToken).
+
+ALTER TABLE "api_tokens" ADD COLUMN "expires_at" timestamptz;
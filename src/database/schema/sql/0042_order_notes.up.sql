@@ -0,0 +1,5 @@
+-- Free-text notes on orders (model.OrderNote), shown in the trade journal.
+
+CREATE TABLE IF NOT EXISTS "order_notes" ("id" bigserial,"order_id" bigint NOT NULL,"user_id" bigint NOT NULL,"author_id" bigint NOT NULL,"author" varchar(100),"body" text NOT NULL,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_order_notes_order_id" ON "order_notes" ("order_id");
+CREATE INDEX IF NOT EXISTS "idx_order_notes_user_id" ON "order_notes" ("user_id");
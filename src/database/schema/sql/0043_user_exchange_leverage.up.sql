@@ -0,0 +1,4 @@
+-- Default leverage set before entries whose signal has none
+-- (model.UserExchange).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "leverage" decimal;
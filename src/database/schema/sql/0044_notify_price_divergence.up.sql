@@ -0,0 +1,3 @@
+-- Opt-out of the price divergence notifications (model.UserNotificationSetting).
+
+ALTER TABLE "user_notification_settings" ADD COLUMN "notify_price_divergence" boolean DEFAULT true;
@@ -0,0 +1,4 @@
+-- How entries are sent, at market or resting post-only first
+-- (model.UserExchange).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "entry_mode" varchar(10);
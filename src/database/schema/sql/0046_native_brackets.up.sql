@@ -0,0 +1,8 @@
+-- Exits held by the exchange from the entry on: the capability flag of the
+-- exchange registry (model.Exchange) and the prices attached to the entry
+-- intent (model.OrderIntent).
+
+ALTER TABLE "exchanges" ADD COLUMN "supports_native_bracket" boolean NOT NULL DEFAULT false;
+UPDATE "exchanges" SET "supports_native_bracket" = true WHERE "name" = 'phemex';
+ALTER TABLE "order_intents" ADD COLUMN "stop_loss_price" varchar(50);
+ALTER TABLE "order_intents" ADD COLUMN "take_profit_price" varchar(50);
@@ -0,0 +1,8 @@
+-- Positions closed by their stop loss (model.StopOut) and the cooldown of
+-- the signal filters blocking re-entries after them
+-- (model.SignalFilterSetting).
+
+CREATE TABLE IF NOT EXISTS "stop_outs" ("id" bigserial,"user_id" bigint NOT NULL,"exchange_id" bigint NOT NULL,"symbol" varchar(50) NOT NULL,"pos_side" varchar(10) NOT NULL,"entry_order_id" bigint,"stop_order_id" varchar(64),"stop_price" varchar(50),"stopped_at" timestamptz NOT NULL,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_stop_out_user_exchange_symbol_side" ON "stop_outs" ("user_id","exchange_id","symbol","pos_side");
+ALTER TABLE "signal_filter_settings" ADD COLUMN "stop_out_cooldown_minutes" bigint;
+ALTER TABLE "signal_filter_settings" ADD COLUMN "stop_out_confirm_interval_minutes" bigint;
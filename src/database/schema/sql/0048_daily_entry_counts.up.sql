@@ -0,0 +1,6 @@
+-- Entries sent per user, symbol and UTC day (model.DailyEntryCount) and their
+-- cap in the signal filters (model.SignalFilterSetting).
+
+CREATE TABLE IF NOT EXISTS "daily_entry_counts" ("id" bigserial,"user_id" bigint NOT NULL,"symbol" varchar(50) NOT NULL,"day" varchar(10) NOT NULL,"count" bigint NOT NULL DEFAULT 0,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "ux_daily_entry_user_symbol_day" ON "daily_entry_counts" ("user_id","symbol","day");
+ALTER TABLE "signal_filter_settings" ADD COLUMN "max_daily_entries" bigint;
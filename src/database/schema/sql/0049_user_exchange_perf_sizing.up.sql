@@ -0,0 +1,8 @@
+-- Entry size scaled with the recent wins and losses of the symbol, on top of
+-- the session sizing (model.UserExchange).
+
+ALTER TABLE "user_exchanges" ADD COLUMN "perf_size_step" decimal;
+ALTER TABLE "user_exchanges" ADD COLUMN "perf_size_win_window" bigint;
+ALTER TABLE "user_exchanges" ADD COLUMN "perf_size_loss_window" bigint;
+ALTER TABLE "user_exchanges" ADD COLUMN "perf_size_min" decimal;
+ALTER TABLE "user_exchanges" ADD COLUMN "perf_size_max" decimal;
@@ -0,0 +1,19 @@
+// Package timescale detects the TimescaleDB extension, used when present
+// for the hypertables of the candle and snapshot history.
+package timescale
+
+import "gorm.io/gorm"
+
+// Available reports whether db is a postgres database with the timescaledb
+// extension installed.
+func Available(db *gorm.DB) (bool, error) {
+	if db == nil || db.Dialector.Name() != "postgres" {
+		return false, nil
+	}
+	var n int64
+	err := db.Raw("SELECT count(*) FROM pg_extension WHERE extname = 'timescaledb'").Scan(&n).Error
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,104 @@
+// Package events is the publish/subscribe bus of the process: the
+// controllers publish what happened to the signals and orders of an account
+// and subsystems, such as notifications, copy trading or the event stream of
+// the API, subscribe to the events they act on. A Transport, see Attach,
+// carries the events of a bus to the other processes.
+package events
+
+import (
+	"context"
+	"sync"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Event is a typed event carried by a Bus. Name identifies its type and
+// Owner is the user it is about, 0 for none.
+type Event interface {
+	Name() string
+	Owner() uint
+}
+
+type handler struct {
+	id uint64
+	h  func(ctx context.Context, ev Event)
+}
+
+// Bus delivers published events to the handlers subscribed to their type.
+type Bus struct {
+	mu       sync.RWMutex
+	nextID   uint64
+	handlers map[string][]handler
+	// all are the handlers of every event, see SubscribeAll.
+	all []handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: map[string][]handler{}}
+}
+
+// Default is the bus of the process.
+var Default = NewBus()
+
+// Subscribe registers h for the events of type T published on b.
+func Subscribe[T Event](b *Bus, h func(ctx context.Context, ev T)) {
+	var zero T
+	name := zero.Name()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	b.handlers[name] = append(b.handlers[name], handler{id: b.nextID, h: func(ctx context.Context, ev Event) {
+		if typed, ok := ev.(T); ok {
+			h(ctx, typed)
+		}
+	}})
+}
+
+// SubscribeAll registers h for every event published on b, until the
+// returned function is called.
+func (b *Bus) SubscribeAll(h func(ctx context.Context, ev Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.all = append(b.all, handler{id: id, h: h})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, sub := range b.all {
+			if sub.id == id {
+				b.all = append(b.all[:i:i], b.all[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers ev to the handlers of its type, in subscription order,
+// then to the handlers of every event, before returning. A panicking
+// handler is logged and does not keep the others from running nor reach the
+// publisher.
+func (b *Bus) Publish(ctx context.Context, ev Event) {
+	b.mu.RLock()
+	handlers := append(append([]handler(nil), b.handlers[ev.Name()]...), b.all...)
+	b.mu.RUnlock()
+
+	for _, sub := range handlers {
+		deliver(ctx, ev, sub.h)
+	}
+}
+
+func deliver(ctx context.Context, ev Event, h func(ctx context.Context, ev Event)) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.WithContext(ctx).WithFields(map[string]interface{}{
+				"component": "events",
+				"event":     ev.Name(),
+				"panic":     r,
+			}).Error("event handler panicked")
+		}
+	}()
+	h(ctx, ev)
+}
@@ -0,0 +1,45 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+type otherEvent struct{}
+
+func (otherEvent) Name() string { return "other" }
+func (otherEvent) Owner() uint  { return 0 }
+
+func TestBusDeliversByType(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	Subscribe(bus, func(_ context.Context, ev OrderFilled) {
+		got = append(got, "first "+ev.Symbol)
+	})
+	Subscribe(bus, func(_ context.Context, ev OrderFilled) {
+		panic("broken consumer")
+	})
+	Subscribe(bus, func(_ context.Context, ev OrderFilled) {
+		got = append(got, "third "+ev.Symbol)
+	})
+	Subscribe(bus, func(context.Context, otherEvent) {
+		got = append(got, "other")
+	})
+
+	unsubscribe := bus.SubscribeAll(func(_ context.Context, ev Event) {
+		got = append(got, "all "+ev.Name())
+	})
+
+	bus.Publish(context.Background(), OrderFilled{Symbol: "BTCUSDT"})
+
+	if len(got) != 3 || got[0] != "first BTCUSDT" || got[1] != "third BTCUSDT" || got[2] != "all order_filled" {
+		t.Fatalf("unexpected deliveries %v", got)
+	}
+
+	unsubscribe()
+	got = nil
+	bus.Publish(context.Background(), otherEvent{})
+	if len(got) != 1 || got[0] != "other" {
+		t.Fatalf("unexpected deliveries after unsubscribe %v", got)
+	}
+}
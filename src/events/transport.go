@@ -0,0 +1,105 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Envelope is an event encoded to leave the process.
+type Envelope struct {
+	Name        string          `json:"name"`
+	Owner       uint            `json:"owner,omitempty"`
+	PublishedAt time.Time       `json:"published_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// Transport carries envelopes between the buses of several processes, e.g.
+// from the executors to the API server. Adapters for brokers such as NATS
+// or Kafka implement it.
+type Transport interface {
+	// Send publishes env to the other processes.
+	Send(ctx context.Context, env Envelope) error
+	// Receive calls h with the envelopes the other processes send until ctx
+	// is done or the transport fails.
+	Receive(ctx context.Context, h func(ctx context.Context, env Envelope)) error
+}
+
+// decoders decode the payload of each event type a Transport can carry.
+var decoders = map[string]func(payload json.RawMessage) (Event, error){
+	SignalReceived{}.Name():  decodeAs[SignalReceived],
+	OrderPlaced{}.Name():     decodeAs[OrderPlaced],
+	OrderFilled{}.Name():     decodeAs[OrderFilled],
+	OrderFailed{}.Name():     decodeAs[OrderFailed],
+	SLMoved{}.Name():         decodeAs[SLMoved],
+	ExceptionRaised{}.Name(): decodeAs[ExceptionRaised],
+}
+
+func decodeAs[T Event](payload json.RawMessage) (Event, error) {
+	var ev T
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// Encode wraps ev in an envelope published at now.
+func Encode(ev Event, now time.Time) (Envelope, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("encode %s: %w", ev.Name(), err)
+	}
+	return Envelope{Name: ev.Name(), Owner: ev.Owner(), PublishedAt: now.UTC(), Payload: payload}, nil
+}
+
+// Decode returns the event env carries.
+func Decode(env Envelope) (Event, error) {
+	decode, ok := decoders[env.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown event %q", env.Name)
+	}
+	ev, err := decode(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", env.Name, err)
+	}
+	return ev, nil
+}
+
+type receivedKey struct{}
+
+// Attach sends the events published on b through t and publishes on b the
+// events t receives, until ctx is done. Received events are not sent back.
+func (b *Bus) Attach(ctx context.Context, t Transport) {
+	log := logger.WithField("component", "events")
+
+	unsubscribe := b.SubscribeAll(func(ctx context.Context, ev Event) {
+		if ctx.Value(receivedKey{}) != nil {
+			return
+		}
+		env, err := Encode(ev, time.Now())
+		if err == nil {
+			err = t.Send(ctx, env)
+		}
+		if err != nil {
+			log.WithError(err).WithField("event", ev.Name()).Error("failed to send event")
+		}
+	})
+
+	go func() {
+		defer unsubscribe()
+		err := t.Receive(ctx, func(ctx context.Context, env Envelope) {
+			ev, err := Decode(env)
+			if err != nil {
+				log.WithError(err).Warn("dropping received event")
+				return
+			}
+			b.Publish(context.WithValue(ctx, receivedKey{}, true), ev)
+		})
+		if err != nil && ctx.Err() == nil {
+			log.WithError(err).Error("event transport stopped")
+		}
+	}()
+}
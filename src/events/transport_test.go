@@ -0,0 +1,76 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// pipe is a Transport whose Receive delivers what another pipe sends.
+type pipe struct {
+	out chan<- Envelope
+	in  <-chan Envelope
+}
+
+func newPipes() (*pipe, *pipe) {
+	ab, ba := make(chan Envelope, 8), make(chan Envelope, 8)
+	return &pipe{out: ab, in: ba}, &pipe{out: ba, in: ab}
+}
+
+func (p *pipe) Send(_ context.Context, env Envelope) error {
+	p.out <- env
+	return nil
+}
+
+func (p *pipe) Receive(ctx context.Context, h func(ctx context.Context, env Envelope)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case env := <-p.in:
+			h(ctx, env)
+		}
+	}
+}
+
+func TestAttachCarriesEventsBetweenBuses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	executor, server := NewBus(), NewBus()
+	executorSide, serverSide := newPipes()
+	executor.Attach(ctx, executorSide)
+	server.Attach(ctx, serverSide)
+
+	received := make(chan OrderFilled, 1)
+	Subscribe(server, func(_ context.Context, ev OrderFilled) { received <- ev })
+	echoed := make(chan Event, 1)
+	Subscribe(executor, func(_ context.Context, ev OrderFilled) { echoed <- ev })
+
+	sent := OrderFilled{UserID: 3, Symbol: "BTCUSDT", Quantity: decimal.RequireFromString("0.25"), FilledAt: time.Unix(1700000000, 0).UTC()}
+	executor.Publish(ctx, sent)
+	<-echoed // the local delivery
+
+	select {
+	case got := <-received:
+		if got.UserID != 3 || got.Symbol != "BTCUSDT" || !got.Quantity.Equal(sent.Quantity) || !got.FilledAt.Equal(sent.FilledAt) {
+			t.Fatalf("unexpected event %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not carried to the other bus")
+	}
+
+	select {
+	case ev := <-echoed:
+		t.Fatalf("received event sent back to its publisher: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDecodeUnknownEvent(t *testing.T) {
+	if _, err := Decode(Envelope{Name: "unknown", Payload: []byte("{}")}); err == nil {
+		t.Fatal("expected an error for an unknown event")
+	}
+}
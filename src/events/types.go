@@ -0,0 +1,113 @@
+package events
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SignalReceived is published when an executor dispatches a trading signal
+// to the controller of its account.
+type SignalReceived struct {
+	UserID     uint      `json:"user_id"`
+	ExchangeID uint      `json:"exchange_id"`
+	SignalID   uint      `json:"signal_id"`
+	Symbol     string    `json:"symbol"`
+	Action     string    `json:"action"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+func (SignalReceived) Name() string  { return "signal_received" }
+func (e SignalReceived) Owner() uint { return e.UserID }
+
+// OrderPlaced is published once the exchange accepted an entry order of an
+// account, before it is known to be filled.
+type OrderPlaced struct {
+	UserID     uint            `json:"user_id"`
+	ExchangeID uint            `json:"exchange_id"`
+	Exchange   string          `json:"exchange"`
+	OrderID    uint            `json:"order_id"`
+	Symbol     string          `json:"symbol"`
+	Side       string          `json:"side"`
+	PosSide    string          `json:"pos_side"`
+	OrderDir   string          `json:"order_dir"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Price      decimal.Decimal `json:"price"`
+	PlacedAt   time.Time       `json:"placed_at"`
+}
+
+func (OrderPlaced) Name() string  { return "order_placed" }
+func (e OrderPlaced) Owner() uint { return e.UserID }
+
+// OrderFilled is published once an order of an account is filled on the
+// exchange.
+type OrderFilled struct {
+	UserID     uint   `json:"user_id"`
+	ExchangeID uint   `json:"exchange_id"`
+	Exchange   string `json:"exchange"`
+	OrderID    uint   `json:"order_id"`
+	Symbol     string `json:"symbol"`
+	// Side is the side traded, Buy or Sell; PosSide the position side the
+	// order opened or closed, Long or Short.
+	Side     string          `json:"side"`
+	PosSide  string          `json:"pos_side"`
+	OrderDir string          `json:"order_dir"` // model.OrderDirectionEntry or model.OrderDirectionExit
+	Quantity decimal.Decimal `json:"quantity"`
+	Price    decimal.Decimal `json:"price"`
+	FilledAt time.Time       `json:"filled_at"`
+}
+
+func (OrderFilled) Name() string  { return "order_filled" }
+func (e OrderFilled) Owner() uint { return e.UserID }
+
+// OrderFailed is published when placing an order of an account failed,
+// the order being stored as errored.
+type OrderFailed struct {
+	UserID     uint            `json:"user_id"`
+	ExchangeID uint            `json:"exchange_id"`
+	Exchange   string          `json:"exchange"`
+	OrderID    uint            `json:"order_id"`
+	Symbol     string          `json:"symbol"`
+	Side       string          `json:"side"`
+	PosSide    string          `json:"pos_side"`
+	OrderDir   string          `json:"order_dir"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Error      string          `json:"error"`
+	FailedAt   time.Time       `json:"failed_at"`
+}
+
+func (OrderFailed) Name() string  { return "order_failed" }
+func (e OrderFailed) Owner() uint { return e.UserID }
+
+// SLMoved is published when the stop loss protecting the position of an
+// order moved, e.g. trailed by a new signal.
+type SLMoved struct {
+	UserID     uint            `json:"user_id"`
+	ExchangeID uint            `json:"exchange_id"`
+	Exchange   string          `json:"exchange"`
+	OrderID    uint            `json:"order_id"`
+	Symbol     string          `json:"symbol"`
+	PosSide    string          `json:"pos_side"`
+	StopLoss   decimal.Decimal `json:"stop_loss"`
+	MovedAt    time.Time       `json:"moved_at"`
+}
+
+func (SLMoved) Name() string  { return "sl_moved" }
+func (e SLMoved) Owner() uint { return e.UserID }
+
+// ExceptionRaised is published for every captured exception. UserID is 0
+// when it was raised outside of the controller of an account.
+type ExceptionRaised struct {
+	UserID   uint      `json:"user_id,omitempty"`
+	Exchange string    `json:"exchange,omitempty"`
+	Symbol   string    `json:"symbol,omitempty"`
+	Severity string    `json:"severity"`
+	Category string    `json:"category"`
+	Module   string    `json:"module"`
+	Method   string    `json:"method"`
+	Message  string    `json:"message"`
+	RaisedAt time.Time `json:"raised_at"`
+}
+
+func (ExceptionRaised) Name() string  { return "exception_raised" }
+func (e ExceptionRaised) Owner() uint { return e.UserID }
@@ -0,0 +1,41 @@
+package executors
+
+import (
+	"context"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// apiCallRecorder stores the calls captured for userExchange as
+// model.APICallLog rows (see UserExchange.CaptureAPICalls).
+func apiCallRecorder(ctx context.Context, userExchange *model.UserExchange) connectors.CaptureFunc {
+	logs := repository.NewAPICallLogRepository()
+	return func(call connectors.APICall) {
+		if err := logs.Create(ctx, apiCallLogRow(userExchange, call)); err != nil {
+			logger.WithContext(ctx).WithError(err).Error("failed to record api call")
+		}
+	}
+}
+
+func apiCallLogRow(userExchange *model.UserExchange, call connectors.APICall) *model.APICallLog {
+	row := &model.APICallLog{
+		UserID:       userExchange.UserID,
+		ExchangeID:   userExchange.ExchangeID,
+		Exchange:     call.Exchange,
+		Method:       call.Method,
+		Path:         call.Path,
+		Query:        call.Query,
+		RequestBody:  call.RequestBody,
+		Status:       call.Status,
+		ResponseBody: call.ResponseBody,
+		LatencyMs:    call.Latency.Milliseconds(),
+		CreatedAt:    call.At,
+	}
+	if call.Err != nil {
+		row.Error = call.Err.Error()
+	}
+	return row
+}
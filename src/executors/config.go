@@ -8,13 +8,50 @@ import (
 )
 
 type Config struct {
-	APIKey         string        `envconfig:"PHEMEX_API_KEY"`
-	APISecret      string        `envconfig:"PHEMEX_API_SECRET"`
-	UserID         string        `envconfig:"USER_ID"`
+	APIKey    string `envconfig:"PHEMEX_API_KEY"`
+	APISecret string `envconfig:"PHEMEX_API_SECRET"`
+	UserID    string `envconfig:"USER_ID"`
+	// BaseURL is the Phemex endpoint of live accounts; testnet accounts
+	// always use the Phemex testnet.
 	BaseURL        string        `envconfig:"BASE_URL" default:"https://testnet-api.phemex.com"`
 	TargetExchange string        `envconfig:"TARGET_EXCHANGE" default:"phemex"`
 	TargetSymbol   string        `envconfig:"TARGET_SYMBOL" default:"BTCUSD"`
 	LoopPeriod     time.Duration `envconfig:"LOOP_PERIOD" default:"30s"`
+
+	// News halt: pause new entries for NEWS_HALT_WINDOW after a matching
+	// high-impact event was ingested. Empty keywords disable the rule.
+	NewsHaltKeywords      []string      `envconfig:"NEWS_HALT_KEYWORDS" default:"FOMC,Interest Rate Decision,CPI,Nonfarm Payrolls"`
+	NewsHaltWindow        time.Duration `envconfig:"NEWS_HALT_WINDOW" default:"30m"`
+	NewsHaltMinImportance int           `envconfig:"NEWS_HALT_MIN_IMPORTANCE" default:"1"`
+
+	// Replay protection: what to do on startup with the signals received
+	// while the executor was down, see ReplayPolicy. REPLAY_MAX_AGE is the
+	// age limit of the max_age policy.
+	ReplayPolicy string        `envconfig:"REPLAY_POLICY" default:"latest"`
+	ReplayMaxAge time.Duration `envconfig:"REPLAY_MAX_AGE" default:"15m"`
+
+	// Maintenance: ticks are skipped during the maintenance windows of the
+	// exchange; when one ends, the signals received during it are settled
+	// by MAINTENANCE_POLICY, a ReplayPolicy with REPLAY_MAX_AGE as the age
+	// limit of max_age.
+	MaintenancePolicy string `envconfig:"MAINTENANCE_POLICY" default:"latest"`
+
+	// Stagger: wait before acting on a new signal so the accounts trading
+	// it do not compete with each other or trip the exchange rate limits.
+	// The delay is STAGGER_PRIORITY_STEP per UserExchange.ExecutionPriority
+	// plus a random share of STAGGER_WINDOW; both zero disable it.
+	StaggerWindow       time.Duration `envconfig:"STAGGER_WINDOW" default:"0s"`
+	StaggerPriorityStep time.Duration `envconfig:"STAGGER_PRIORITY_STEP" default:"0s"`
+
+	// Signal queue: act on the signals the webhook queued for this account
+	// instead of polling the latest one. A leased job is hidden for
+	// SIGNAL_QUEUE_VISIBILITY, a failed one retried after
+	// SIGNAL_QUEUE_RETRY_DELAY and dead-lettered after
+	// SIGNAL_QUEUE_MAX_ATTEMPTS.
+	SignalQueue            bool          `envconfig:"SIGNAL_QUEUE" default:"false"`
+	SignalQueueVisibility  time.Duration `envconfig:"SIGNAL_QUEUE_VISIBILITY" default:"2m"`
+	SignalQueueRetryDelay  time.Duration `envconfig:"SIGNAL_QUEUE_RETRY_DELAY" default:"30s"`
+	SignalQueueMaxAttempts int           `envconfig:"SIGNAL_QUEUE_MAX_ATTEMPTS" default:"5"`
 }
 
 func GetConfig() Config {
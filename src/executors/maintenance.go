@@ -0,0 +1,64 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type maintenanceStore interface {
+	Active(ctx context.Context, exchangeID uint, now time.Time) (*model.MaintenanceWindow, error)
+}
+
+// maintenanceGate pauses the loop during the maintenance windows of the
+// exchange, so no order is sent to it and no queued signal burns its
+// retries, then settles the signals received during the window by policy
+// once it ends.
+type maintenanceGate struct {
+	windows maintenanceStore
+	execs   signalExecutionStore
+	signals signalSource
+
+	userID, exchangeID   uint
+	symbol, exchangeName string
+	policy               ReplayPolicy
+	maxAge               time.Duration
+
+	// window is the maintenance the loop is waiting out, nil outside one.
+	window *model.MaintenanceWindow
+}
+
+// paused reports whether the tick at now falls in a maintenance window of
+// the exchange. The first tick after a window settles its backlog.
+func (g *maintenanceGate) paused(ctx context.Context, now time.Time) (bool, error) {
+	window, err := g.windows.Active(ctx, g.exchangeID, now)
+	if err != nil {
+		return false, fmt.Errorf("load maintenance windows: %w", err)
+	}
+	log := logger.WithContext(ctx).WithField("exchange", g.exchangeName)
+	if window != nil {
+		if g.window == nil || g.window.ID != window.ID {
+			log.WithFields(map[string]interface{}{
+				"window_id": window.ID,
+				"title":     window.Title,
+				"until":     window.EndsAt,
+			}).Warn("exchange maintenance, pausing order submission")
+		}
+		g.window = window
+		return true, nil
+	}
+	if g.window == nil {
+		return false, nil
+	}
+
+	during := "during the maintenance of " + g.exchangeName
+	if err := settleBacklog(ctx, g.execs, g.signals, g.userID, g.exchangeID, g.symbol, g.exchangeName, g.policy, g.maxAge, now, during); err != nil {
+		return false, err
+	}
+	log.WithField("window_id", g.window.ID).Info("exchange maintenance over, resuming")
+	g.window = nil
+	return false, nil
+}
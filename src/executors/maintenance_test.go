@@ -0,0 +1,69 @@
+package executors
+
+import (
+	"context"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+type fakeWindows []model.MaintenanceWindow
+
+func (f fakeWindows) Active(_ context.Context, exchangeID uint, now time.Time) (*model.MaintenanceWindow, error) {
+	for i := range f {
+		if f[i].ExchangeID == exchangeID && !now.Before(f[i].StartsAt) && now.Before(f[i].EndsAt) {
+			return &f[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func TestMaintenanceGate(t *testing.T) {
+	ctx := context.Background()
+	starts := time.Date(2025, 3, 4, 6, 0, 0, 0, time.UTC)
+	ends := starts.Add(2 * time.Hour)
+	signals := &fakeSignals{signals: []externalmodel.TradingSignal{
+		receivedSignal(10, starts.Add(-time.Hour)),
+		receivedSignal(11, starts.Add(10*time.Minute)),
+		receivedSignal(12, ends.Add(-30*time.Minute)),
+	}}
+	execs := newFakeExecs(10)
+	gate := &maintenanceGate{
+		windows:      fakeWindows{{ID: 1, ExchangeID: 1, StartsAt: starts, EndsAt: ends, Title: "upgrade"}, {ID: 2, ExchangeID: 2, StartsAt: ends, EndsAt: ends.Add(time.Hour)}},
+		execs:        execs,
+		signals:      signals,
+		userID:       1,
+		exchangeID:   1,
+		symbol:       "BTCUSDT",
+		exchangeName: "phemex",
+		policy:       ReplayMaxAge,
+		maxAge:       time.Hour,
+	}
+
+	for _, at := range []time.Time{starts, starts.Add(time.Hour)} {
+		paused, err := gate.paused(ctx, at)
+		if err != nil || !paused {
+			t.Fatalf("expected the tick at %v paused, got %v %v", at, paused, err)
+		}
+	}
+	if len(execs.decided) != 0 {
+		t.Fatalf("expected nothing decided during the window, got %v", execs.decided)
+	}
+
+	// the window of another exchange does not pause this one
+	paused, err := gate.paused(ctx, ends.Add(30*time.Minute))
+	if err != nil || paused {
+		t.Fatalf("expected the loop resumed after the window, got %v %v", paused, err)
+	}
+	skipped := execs.decided[11]
+	if len(execs.decided) != 1 || skipped.Decision != model.SignalExecutionSkipped || skipped.Reason != "superseded by signal 12 during the maintenance of phemex" {
+		t.Fatalf("expected only the superseded signal skipped, got %v", execs.decided)
+	}
+
+	// settled once
+	execs.decided = map[uint]model.SignalExecution{}
+	if paused, err := gate.paused(ctx, ends.Add(31*time.Minute)); err != nil || paused || len(execs.decided) != 0 {
+		t.Fatalf("expected no more settling, got %v %v %v", paused, err, execs.decided)
+	}
+}
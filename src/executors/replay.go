@@ -0,0 +1,223 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// ReplayPolicy decides, on startup, what happens to the signals that arrived
+// while the executor was down.
+type ReplayPolicy string
+
+const (
+	// ReplaySkipAll acts on none of them.
+	ReplaySkipAll ReplayPolicy = "skip_all"
+	// ReplayLatest acts on the latest signal of each symbol, as if the
+	// executor never stopped.
+	ReplayLatest ReplayPolicy = "latest"
+	// ReplayMaxAge acts on the latest signal of each symbol unless it is
+	// older than REPLAY_MAX_AGE.
+	ReplayMaxAge ReplayPolicy = "max_age"
+)
+
+// ParseReplayPolicy returns the policy named s.
+func ParseReplayPolicy(s string) (ReplayPolicy, error) {
+	switch p := ReplayPolicy(s); p {
+	case ReplaySkipAll, ReplayLatest, ReplayMaxAge:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown replay policy %q", s)
+	}
+}
+
+// replayBacklogLimit bounds the signals looked at on startup.
+const replayBacklogLimit = 500
+
+type signalExecutionStore interface {
+	Record(ctx context.Context, exec *model.SignalExecution) (bool, error)
+	Find(ctx context.Context, userID, exchangeID, signalID uint) (*model.SignalExecution, error)
+	LastSignalID(ctx context.Context, userID, exchangeID uint) (uint, error)
+}
+
+type signalSource interface {
+	FindLatest(ctx context.Context, symbol, exchangeName string, limit int) ([]externalmodel.TradingSignal, error)
+	FindAfterIDForSymbol(ctx context.Context, symbol, exchangeName string, lastID uint, limit int) ([]externalmodel.TradingSignal, error)
+}
+
+type replaySkip struct {
+	signal externalmodel.TradingSignal
+	reason string
+}
+
+// replaySkips returns the signals of backlog, oldest first, that policy
+// skips. Signals superseded by a later one of the same symbol are always
+// skipped, the controllers only act on the latest. during tells when the
+// backlog was received in the reasons, e.g. "while the executor was down".
+func replaySkips(policy ReplayPolicy, maxAge time.Duration, now time.Time, backlog []externalmodel.TradingSignal, during string) []replaySkip {
+	latest := map[string]uint{}
+	for _, s := range backlog {
+		if s.ID > latest[s.Symbol] {
+			latest[s.Symbol] = s.ID
+		}
+	}
+
+	var skips []replaySkip
+	for _, s := range backlog {
+		var reason string
+		switch {
+		case s.ID != latest[s.Symbol]:
+			reason = fmt.Sprintf("superseded by signal %d %s", latest[s.Symbol], during)
+		case policy == ReplaySkipAll:
+			reason = "arrived " + during
+		case policy == ReplayMaxAge:
+			at := signalTime(s)
+			if at.IsZero() || now.Sub(at) > maxAge {
+				reason = fmt.Sprintf("older than %s, arrived %s", maxAge, during)
+			}
+		}
+		if reason != "" {
+			skips = append(skips, replaySkip{signal: s, reason: reason})
+		}
+	}
+	return skips
+}
+
+// signalTime is when s arrived, zero when unknown.
+func signalTime(s externalmodel.TradingSignal) time.Time {
+	if s.ReceivedAt != nil {
+		return *s.ReceivedAt
+	}
+	if s.TimestampDT != nil {
+		return *s.TimestampDT
+	}
+	return time.Time{}
+}
+
+// guardReplay records the signals of symbol that arrived after the last one
+// the executor decided on and that policy skips. Without execution history
+// there is no downtime to tell and nothing is skipped.
+func guardReplay(
+	ctx context.Context,
+	execs signalExecutionStore,
+	signals signalSource,
+	userID, exchangeID uint,
+	symbol, exchangeName string,
+	policy ReplayPolicy,
+	maxAge time.Duration,
+	now time.Time,
+) error {
+	return settleBacklog(ctx, execs, signals, userID, exchangeID, symbol, exchangeName, policy, maxAge, now, "while the executor was down")
+}
+
+// settleBacklog is guardReplay for a backlog received during, e.g. "during
+// the maintenance of phemex".
+func settleBacklog(
+	ctx context.Context,
+	execs signalExecutionStore,
+	signals signalSource,
+	userID, exchangeID uint,
+	symbol, exchangeName string,
+	policy ReplayPolicy,
+	maxAge time.Duration,
+	now time.Time,
+	during string,
+) error {
+	last, err := execs.LastSignalID(ctx, userID, exchangeID)
+	if err != nil {
+		return fmt.Errorf("load last executed signal: %w", err)
+	}
+	if last == 0 {
+		logger.WithContext(ctx).Info("no signal execution history, replay protection starts with the next signal")
+		return nil
+	}
+
+	backlog, err := signals.FindAfterIDForSymbol(ctx, symbol, exchangeName, last, replayBacklogLimit)
+	if err != nil {
+		return fmt.Errorf("load signals received %s: %w", during, err)
+	}
+	skips := replaySkips(policy, maxAge, now, backlog, during)
+	for _, skip := range skips {
+		_, err := execs.Record(ctx, &model.SignalExecution{
+			UserID:     userID,
+			ExchangeID: exchangeID,
+			SignalID:   skip.signal.ID,
+			Symbol:     skip.signal.Symbol,
+			Decision:   model.SignalExecutionSkipped,
+			Reason:     skip.reason,
+		})
+		if err != nil {
+			return fmt.Errorf("record skipped signal %d: %w", skip.signal.ID, err)
+		}
+	}
+
+	if len(backlog) > 0 {
+		logger.WithContext(ctx).WithFields(map[string]interface{}{
+			"policy":         policy,
+			"last_signal_id": last,
+			"received":       len(backlog),
+			"skipped":        len(skips),
+		}).Warn("signals received " + during)
+	}
+	return nil
+}
+
+// nextSignal returns the latest signal of symbol for the controller to act
+// on and records it as dispatched, publishing it as received the first
+// time. skip is true when it was skipped by the
+// replay policy; signal is nil when there is no signal yet.
+func nextSignal(
+	ctx context.Context,
+	execs signalExecutionStore,
+	signals signalSource,
+	userID, exchangeID uint,
+	symbol, exchangeName string,
+) (signal *externalmodel.TradingSignal, skip bool, err error) {
+	latest, err := signals.FindLatest(ctx, symbol, exchangeName, 1)
+	if err != nil || len(latest) == 0 {
+		return nil, false, err
+	}
+
+	skip, err = recordDispatch(ctx, execs, userID, exchangeID, latest[0])
+	if err != nil || skip {
+		return nil, skip, err
+	}
+	return &latest[0], false, nil
+}
+
+// recordDispatch records signal as dispatched to the account and publishes
+// it as received the first time. skip is true when the account decided to
+// skip it before.
+func recordDispatch(ctx context.Context, execs signalExecutionStore, userID, exchangeID uint, signal externalmodel.TradingSignal) (skip bool, err error) {
+	exec, err := execs.Find(ctx, userID, exchangeID, signal.ID)
+	if err != nil {
+		return false, err
+	}
+	if exec != nil {
+		return exec.Decision == model.SignalExecutionSkipped, nil
+	}
+	_, err = execs.Record(ctx, &model.SignalExecution{
+		UserID:     userID,
+		ExchangeID: exchangeID,
+		SignalID:   signal.ID,
+		Symbol:     signal.Symbol,
+		Decision:   model.SignalExecutionDispatched,
+	})
+	if err != nil {
+		return false, err
+	}
+	events.Default.Publish(ctx, events.SignalReceived{
+		UserID:     userID,
+		ExchangeID: exchangeID,
+		SignalID:   signal.ID,
+		Symbol:     signal.Symbol,
+		Action:     signal.Action,
+		ReceivedAt: time.Now().UTC(),
+	})
+	return false, nil
+}
@@ -0,0 +1,141 @@
+package executors
+
+import (
+	"context"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+type fakeExecs struct {
+	last    uint
+	decided map[uint]model.SignalExecution
+}
+
+func newFakeExecs(last uint) *fakeExecs {
+	return &fakeExecs{last: last, decided: map[uint]model.SignalExecution{}}
+}
+
+func (f *fakeExecs) Record(_ context.Context, exec *model.SignalExecution) (bool, error) {
+	if _, ok := f.decided[exec.SignalID]; ok {
+		return false, nil
+	}
+	f.decided[exec.SignalID] = *exec
+	return true, nil
+}
+
+func (f *fakeExecs) Find(_ context.Context, _, _, signalID uint) (*model.SignalExecution, error) {
+	exec, ok := f.decided[signalID]
+	if !ok {
+		return nil, nil
+	}
+	return &exec, nil
+}
+
+func (f *fakeExecs) LastSignalID(context.Context, uint, uint) (uint, error) {
+	return f.last, nil
+}
+
+type fakeSignals struct{ signals []externalmodel.TradingSignal }
+
+func (f *fakeSignals) FindLatest(_ context.Context, _, _ string, _ int) ([]externalmodel.TradingSignal, error) {
+	if len(f.signals) == 0 {
+		return nil, nil
+	}
+	return f.signals[len(f.signals)-1:], nil
+}
+
+func (f *fakeSignals) FindAfterIDForSymbol(_ context.Context, _, _ string, lastID uint, _ int) ([]externalmodel.TradingSignal, error) {
+	var out []externalmodel.TradingSignal
+	for _, s := range f.signals {
+		if s.ID > lastID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func receivedSignal(id uint, at time.Time) externalmodel.TradingSignal {
+	return externalmodel.TradingSignal{ID: id, Symbol: "BTCUSDT", ReceivedAt: &at}
+}
+
+func TestReplaySkips(t *testing.T) {
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	backlog := []externalmodel.TradingSignal{
+		receivedSignal(11, now.Add(-2*time.Hour)),
+		receivedSignal(12, now.Add(-time.Hour)),
+		{ID: 13, Symbol: "ETHUSDT"},
+	}
+
+	cases := []struct {
+		policy  ReplayPolicy
+		maxAge  time.Duration
+		skipped []uint
+	}{
+		{ReplayLatest, 0, []uint{11}},
+		{ReplaySkipAll, 0, []uint{11, 12, 13}},
+		{ReplayMaxAge, 2 * time.Hour, []uint{11, 13}},
+		{ReplayMaxAge, 30 * time.Minute, []uint{11, 12, 13}},
+	}
+	for _, tc := range cases {
+		skips := replaySkips(tc.policy, tc.maxAge, now, backlog, "while the executor was down")
+		var got []uint
+		for _, s := range skips {
+			got = append(got, s.signal.ID)
+		}
+		if len(got) != len(tc.skipped) {
+			t.Fatalf("%s/%s: skipped %v, want %v", tc.policy, tc.maxAge, got, tc.skipped)
+		}
+		for i := range got {
+			if got[i] != tc.skipped[i] {
+				t.Fatalf("%s/%s: skipped %v, want %v", tc.policy, tc.maxAge, got, tc.skipped)
+			}
+		}
+	}
+
+	if _, err := ParseReplayPolicy("replay_everything"); err == nil {
+		t.Fatalf("expected an unknown policy to be refused")
+	}
+}
+
+func TestGuardReplayAndNextSignal(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	signals := &fakeSignals{signals: []externalmodel.TradingSignal{
+		receivedSignal(10, now.Add(-3*time.Hour)),
+		receivedSignal(11, now.Add(-2*time.Hour)),
+		receivedSignal(12, now.Add(-time.Hour)),
+	}}
+
+	// without history nothing is skipped
+	execs := newFakeExecs(0)
+	if err := guardReplay(ctx, execs, signals, 1, 1, "BTCUSDT", "phemex", ReplaySkipAll, 0, now); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(execs.decided) != 0 {
+		t.Fatalf("expected no decision without history, got %v", execs.decided)
+	}
+
+	execs = newFakeExecs(10)
+	if err := guardReplay(ctx, execs, signals, 1, 1, "BTCUSDT", "phemex", ReplaySkipAll, 0, now); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(execs.decided) != 2 || execs.decided[12].Decision != model.SignalExecutionSkipped {
+		t.Fatalf("expected signals 11 and 12 skipped, got %v", execs.decided)
+	}
+	signal, skip, err := nextSignal(ctx, execs, signals, 1, 1, "BTCUSDT", "phemex")
+	if err != nil || !skip || signal != nil {
+		t.Fatalf("expected the skipped latest signal not to be dispatched, got %v %v %v", signal, skip, err)
+	}
+
+	// a signal received after startup is dispatched once recorded
+	signals.signals = append(signals.signals, receivedSignal(13, now))
+	signal, skip, err = nextSignal(ctx, execs, signals, 1, 1, "BTCUSDT", "phemex")
+	if err != nil || skip || signal == nil || signal.ID != 13 {
+		t.Fatalf("expected signal 13 dispatched, got %v %v %v", signal, skip, err)
+	}
+	if execs.decided[13].Decision != model.SignalExecutionDispatched {
+		t.Fatalf("expected signal 13 recorded as dispatched, got %v", execs.decided[13])
+	}
+}
@@ -0,0 +1,73 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"sync"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// errShadowUnsupported is returned for shadow accounts on exchanges whose
+// controller does not run on a connectors.Connector; they are not run rather
+// than run live.
+var errShadowUnsupported = errors.New("shadow mode is not supported on this exchange")
+
+// paperAccounts holds the paper account of each shadow UserExchange for the
+// life of the process.
+var paperAccounts sync.Map // UserExchange.ID -> *connectors.PaperAccount
+
+func paperAccount(userExchangeID uint) *connectors.PaperAccount {
+	account, _ := paperAccounts.LoadOrStore(userExchangeID, connectors.NewPaperAccount())
+	return account.(*connectors.PaperAccount)
+}
+
+// shadowClient routes the order calls of live to the paper account of
+// userExchange and records each as a model.ShadowCall.
+func shadowClient(ctx context.Context, live connectors.Connector, userExchange *model.UserExchange) connectors.Connector {
+	calls := repository.NewShadowCallRepository()
+	return connectors.NewPaperClient(live, paperAccount(userExchange.ID), func(call connectors.ShadowCall) {
+		row := shadowCallRow(userExchange, call)
+		logger.WithContext(ctx).WithFields(map[string]interface{}{
+			"method":      row.Method,
+			"symbol":      row.Symbol,
+			"side":        row.Side,
+			"pos_side":    row.PosSide,
+			"qty":         row.Quantity,
+			"reduce_only": row.ReduceOnly,
+			"error":       row.Error,
+		}).Info("shadow call recorded instead of sent")
+		if err := calls.Create(ctx, row); err != nil {
+			logger.WithContext(ctx).WithError(err).Error("failed to record shadow call")
+		}
+	})
+}
+
+func shadowCallRow(userExchange *model.UserExchange, call connectors.ShadowCall) *model.ShadowCall {
+	row := &model.ShadowCall{
+		UserID:        userExchange.UserID,
+		ExchangeID:    userExchange.ExchangeID,
+		Method:        call.Method,
+		ClientOrderID: call.ClOrdID,
+		Symbol:        call.Symbol,
+		Side:          call.Side,
+		PosSide:       call.PosSide,
+		Quantity:      call.Qty,
+		OrderType:     call.OrdType,
+		Price:         call.Price,
+		ReduceOnly:    call.ReduceOnly,
+		PaperOrderID:  call.PaperOrderID,
+		CreatedAt:     call.At,
+	}
+	if call.PaperOrderID != "" {
+		fill := call.FillPrice
+		row.FillPrice = &fill
+	}
+	if call.Err != nil {
+		row.Error = call.Err.Error()
+	}
+	return row
+}
@@ -0,0 +1,119 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type signalJobStore interface {
+	RegisterConsumer(ctx context.Context, consumer *model.SignalQueueConsumer) error
+	Lease(ctx context.Context, userID, exchangeID uint, symbol string, now time.Time, visibility time.Duration, maxAttempts int) (*model.SignalJob, error)
+	Complete(ctx context.Context, id uint, now time.Time) error
+	Fail(ctx context.Context, job *model.SignalJob, cause error, retryAt time.Time, maxAttempts int) (bool, error)
+}
+
+type signalLookup interface {
+	FindByID(ctx context.Context, id uint) (*externalmodel.TradingSignal, error)
+}
+
+// signalQueue feeds the controller the signals queued for the account by
+// the webhook instead of polling the latest one. Between jobs the controller
+// keeps acting on the last signal taken from the queue.
+type signalQueue struct {
+	jobs     signalJobStore
+	signals  signalLookup
+	execs    signalExecutionStore
+	consumer model.SignalQueueConsumer
+
+	visibility  time.Duration
+	retryDelay  time.Duration
+	maxAttempts int
+
+	current *externalmodel.TradingSignal
+}
+
+// resume picks up the last signal dispatched to the account before a
+// restart as the current one.
+func (q *signalQueue) resume(ctx context.Context) error {
+	last, err := q.execs.LastSignalID(ctx, q.consumer.UserID, q.consumer.ExchangeID)
+	if err != nil || last == 0 {
+		return err
+	}
+	exec, err := q.execs.Find(ctx, q.consumer.UserID, q.consumer.ExchangeID, last)
+	if err != nil || exec == nil || exec.Decision != model.SignalExecutionDispatched {
+		return err
+	}
+	q.current, err = q.signals.FindByID(ctx, last)
+	return err
+}
+
+// next registers the account as a consumer as of now and leases its next
+// job. It returns the signal of the job, or the current signal with a nil
+// job when none is visible; signal is nil when the account has not taken
+// any signal yet. Jobs of missing or skipped signals are completed on the
+// way.
+func (q *signalQueue) next(ctx context.Context, now time.Time) (signal *externalmodel.TradingSignal, job *model.SignalJob, err error) {
+	consumer := q.consumer
+	consumer.LastSeenAt = now
+	if err := q.jobs.RegisterConsumer(ctx, &consumer); err != nil {
+		return nil, nil, fmt.Errorf("register signal queue consumer: %w", err)
+	}
+
+	for {
+		job, err := q.jobs.Lease(ctx, q.consumer.UserID, q.consumer.ExchangeID, q.consumer.Symbol, now, q.visibility, q.maxAttempts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("lease signal job: %w", err)
+		}
+		if job == nil {
+			return q.current, nil, nil
+		}
+
+		signal, err := q.signals.FindByID(ctx, job.SignalID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load queued signal %d: %w", job.SignalID, err)
+		}
+		skip := signal == nil
+		if signal != nil {
+			if skip, err = recordDispatch(ctx, q.execs, q.consumer.UserID, q.consumer.ExchangeID, *signal); err != nil {
+				return nil, nil, err
+			}
+		}
+		if skip {
+			if err := q.jobs.Complete(ctx, job.ID, now); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		q.current = signal
+		return signal, job, nil
+	}
+}
+
+// finish completes job after a successful run, or queues it again after
+// the retry delay. A nil job is a tick on the current signal.
+func (q *signalQueue) finish(ctx context.Context, job *model.SignalJob, runErr error, now time.Time) error {
+	if job == nil {
+		return nil
+	}
+	if runErr == nil {
+		return q.jobs.Complete(ctx, job.ID, now)
+	}
+	dead, err := q.jobs.Fail(ctx, job, runErr, now.Add(q.retryDelay), q.maxAttempts)
+	if err != nil {
+		return err
+	}
+	if dead {
+		logger.WithContext(ctx).WithFields(map[string]interface{}{
+			"job_id":    job.ID,
+			"signal_id": job.SignalID,
+			"attempts":  job.Attempts,
+		}).Warn("signal job dead-lettered")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+type fakeJobs struct {
+	queued    []model.SignalJob
+	completed []uint
+	failed    []uint
+	consumers []model.SignalQueueConsumer
+}
+
+func (f *fakeJobs) RegisterConsumer(_ context.Context, c *model.SignalQueueConsumer) error {
+	f.consumers = append(f.consumers, *c)
+	return nil
+}
+
+func (f *fakeJobs) Lease(context.Context, uint, uint, string, time.Time, time.Duration, int) (*model.SignalJob, error) {
+	if len(f.queued) == 0 {
+		return nil, nil
+	}
+	job := f.queued[0]
+	f.queued = f.queued[1:]
+	job.Attempts++
+	return &job, nil
+}
+
+func (f *fakeJobs) Complete(_ context.Context, id uint, _ time.Time) error {
+	f.completed = append(f.completed, id)
+	return nil
+}
+
+func (f *fakeJobs) Fail(_ context.Context, job *model.SignalJob, _ error, _ time.Time, maxAttempts int) (bool, error) {
+	f.failed = append(f.failed, job.ID)
+	return job.Attempts >= maxAttempts, nil
+}
+
+type fakeLookup map[uint]externalmodel.TradingSignal
+
+func (f fakeLookup) FindByID(_ context.Context, id uint) (*externalmodel.TradingSignal, error) {
+	s, ok := f[id]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func TestSignalQueue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	execs := newFakeExecs(0)
+	execs.decided[11] = model.SignalExecution{SignalID: 11, Decision: model.SignalExecutionSkipped}
+	jobs := &fakeJobs{queued: []model.SignalJob{
+		{ID: 1, SignalID: 10}, // deleted signal
+		{ID: 2, SignalID: 11}, // skipped by the replay policy
+		{ID: 3, SignalID: 12},
+	}}
+	q := &signalQueue{
+		jobs:        jobs,
+		signals:     fakeLookup{11: receivedSignal(11, now), 12: receivedSignal(12, now)},
+		execs:       execs,
+		consumer:    model.SignalQueueConsumer{UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", ExchangeName: "phemex"},
+		retryDelay:  time.Minute,
+		maxAttempts: 1,
+	}
+
+	signal, job, err := q.next(ctx, now)
+	if err != nil || signal == nil || signal.ID != 12 || job.ID != 3 {
+		t.Fatalf("expected the job of signal 12, got %v %v %v", signal, job, err)
+	}
+	if len(jobs.completed) != 2 {
+		t.Fatalf("expected the jobs of missing and skipped signals completed, got %v", jobs.completed)
+	}
+	if execs.decided[12].Decision != model.SignalExecutionDispatched {
+		t.Fatalf("expected signal 12 recorded as dispatched, got %v", execs.decided[12])
+	}
+	if len(jobs.consumers) != 1 || !jobs.consumers[0].LastSeenAt.Equal(now) {
+		t.Fatalf("expected the consumer registered as of now, got %v", jobs.consumers)
+	}
+
+	if err := q.finish(ctx, job, errors.New("exchange down"), now); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.failed) != 1 || jobs.failed[0] != 3 {
+		t.Fatalf("expected job 3 failed, got %v", jobs.failed)
+	}
+
+	// between jobs the current signal is acted on without a job
+	signal, job, err = q.next(ctx, now.Add(time.Minute))
+	if err != nil || signal == nil || signal.ID != 12 || job != nil {
+		t.Fatalf("expected the current signal without a job, got %v %v %v", signal, job, err)
+	}
+	if err := q.finish(ctx, job, nil, now); err != nil || len(jobs.completed) != 2 {
+		t.Fatalf("expected nothing settled without a job, got %v %v", jobs.completed, err)
+	}
+
+	// a restarted executor resumes the last dispatched signal
+	execs.last = 12
+	resumed := &signalQueue{jobs: jobs, signals: q.signals, execs: execs, consumer: q.consumer}
+	if err := resumed.resume(ctx); err != nil || resumed.current == nil || resumed.current.ID != 12 {
+		t.Fatalf("expected signal 12 resumed, got %v %v", resumed.current, err)
+	}
+}
@@ -0,0 +1,40 @@
+package executors
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// staggerDelay is how long an account of priority waits before acting on a
+// new signal: step per priority level, plus jitter (in [0, 1)) of window so
+// accounts of the same priority spread out too. Negative priorities count
+// as 0.
+func staggerDelay(priority int, step, window time.Duration, jitter float64) time.Duration {
+	if priority < 0 {
+		priority = 0
+	}
+	return time.Duration(priority)*step + time.Duration(jitter*float64(window))
+}
+
+// waitStagger sleeps d, or less when ctx is done first; it reports whether
+// the full delay elapsed.
+func waitStagger(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// stagger is the delay of a new signal for an account of priority under
+// config, see Config.StaggerWindow.
+func stagger(config Config, priority int) time.Duration {
+	return staggerDelay(priority, config.StaggerPriorityStep, config.StaggerWindow, rand.Float64())
+}
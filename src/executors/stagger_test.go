@@ -0,0 +1,40 @@
+package executors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStaggerDelay(t *testing.T) {
+	cases := []struct {
+		priority int
+		jitter   float64
+		want     time.Duration
+	}{
+		{0, 0, 0},
+		{0, 0.5, 5 * time.Second},
+		{2, 0, 4 * time.Second},
+		{2, 0.25, 6500 * time.Millisecond},
+		{-1, 0, 0},
+	}
+	for _, c := range cases {
+		if got := staggerDelay(c.priority, 2*time.Second, 10*time.Second, c.jitter); got != c.want {
+			t.Fatalf("staggerDelay(%d, jitter %v) = %s, want %s", c.priority, c.jitter, got, c.want)
+		}
+	}
+	if got := staggerDelay(3, 0, 0, 0.9); got != 0 {
+		t.Fatalf("disabled stagger = %s", got)
+	}
+}
+
+func TestWaitStaggerStopsWithContext(t *testing.T) {
+	if !waitStagger(context.Background(), time.Millisecond) {
+		t.Fatal("the delay should elapse")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if waitStagger(ctx, time.Hour) {
+		t.Fatal("a done context should cut the delay short")
+	}
+}
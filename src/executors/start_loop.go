@@ -6,10 +6,19 @@ import (
 	"fmt"
 	"strategyexecutor/src/connectors"
 	"strategyexecutor/src/controller"
+	"strategyexecutor/src/copytrade"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/featureflag"
+	"strategyexecutor/src/killswitch"
+	"strategyexecutor/src/logging"
 	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/orderhook"
 	"strategyexecutor/src/repository"
 	"strategyexecutor/src/risk"
 	"strategyexecutor/src/security"
+	"strategyexecutor/src/tracing"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -56,22 +65,82 @@ func StartLoop(ctx context.Context) error {
 		return err
 	}
 
-	if userExchange.APIKeyHash == "" || userExchange.APISecretHash == "" {
-		logger.Error("No valid key/secret set for exchange")
+	// Resolve once up front so missing credentials fail fast; every tick
+	// resolves again through the secrets cache to pick up rotated keys.
+	if _, err := security.ResolveCredentials(ctx, userExchange); err != nil {
+		logger.WithError(err).Error("No valid key/secret set for exchange")
 		return err
 	}
 
-	apiKey, err := security.DecryptString(userExchange.APIKeyHash)
+	// Settle the signals received while the executor was down before acting
+	// on any of them.
+	replayPolicy, err := ParseReplayPolicy(config.ReplayPolicy)
 	if err != nil {
-		logger.WithError(err).Error("Failed to decrypt API Key")
 		return err
 	}
-	apiSecret, err := security.DecryptString(userExchange.APISecretHash)
-	if err != nil {
-		logger.WithError(err).Error("Failed to decrypt API Secret")
+	signalExecRep := repository.NewSignalExecutionRepository()
+	signalSummaryRep := repository.NewSignalExecutionSummaryRepository()
+	signalRep := repository.NewTradingSignalRepository()
+	if err := guardReplay(ctx, signalExecRep, signalRep, user.ID, exchange.ID, config.TargetSymbol, targetExchange, replayPolicy, config.ReplayMaxAge, time.Now()); err != nil {
+		logger.WithError(err).Error("Failed to apply the replay policy")
 		return err
 	}
 
+	maintenancePolicy, err := ParseReplayPolicy(config.MaintenancePolicy)
+	if err != nil {
+		return fmt.Errorf("maintenance policy: %w", err)
+	}
+	maintenance := &maintenanceGate{
+		windows:      repository.NewMaintenanceWindowRepository(),
+		execs:        signalExecRep,
+		signals:      signalRep,
+		userID:       user.ID,
+		exchangeID:   exchange.ID,
+		symbol:       config.TargetSymbol,
+		exchangeName: targetExchange,
+		policy:       maintenancePolicy,
+		maxAge:       config.ReplayMaxAge,
+	}
+
+	// Protective orders placed or replaced right before a crash may not be
+	// stored; settle them before the first tick moves any stop.
+	if err := reloadBrackets(ctx, user, userExchange, exchange, targetExchange, config.TargetSymbol); err != nil {
+		logger.WithError(err).Error("Failed to reload the protective orders")
+	}
+
+	// Notify the order events of this account, mirror its fills to its
+	// copy trading followers and POST them to its order webhooks.
+	notify.NewNotifier().Subscribe(events.Default)
+	copytrade.New(config.BaseURL).Subscribe(events.Default)
+	orderHooks := orderhook.New()
+	orderHooks.Subscribe(events.Default)
+	go orderHooks.Run(ctx)
+
+	var queue *signalQueue
+	if config.SignalQueue {
+		queue = &signalQueue{
+			jobs:    repository.NewSignalJobRepository(),
+			signals: signalRep,
+			execs:   signalExecRep,
+			consumer: model.SignalQueueConsumer{
+				UserID:       user.ID,
+				ExchangeID:   exchange.ID,
+				Symbol:       config.TargetSymbol,
+				ExchangeName: targetExchange,
+			},
+			visibility:  config.SignalQueueVisibility,
+			retryDelay:  config.SignalQueueRetryDelay,
+			maxAttempts: config.SignalQueueMaxAttempts,
+		}
+		if err := queue.resume(ctx); err != nil {
+			logger.WithError(err).Error("Failed to resume the signal queue")
+			return err
+		}
+	}
+
+	// staggered is the last signal the stagger delay was applied to.
+	var staggered uint
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -91,6 +160,22 @@ func StartLoop(ctx context.Context) error {
 				logger.Warn("strategy disabled, skipping")
 				return nil
 			}
+			if killswitch.Halted(ctx, user.ID) {
+				logger.WithField("user_id", user.ID).Warn("emergency stop active, skipping tick")
+				continue
+			}
+			if !featureflag.Enabled(ctx, featureflag.LiveTrading, user.ID) {
+				logger.WithField("user_id", user.ID).Warn("live trading disabled by feature flag, skipping tick")
+				continue
+			}
+			paused, err := maintenance.paused(ctx, time.Now().UTC())
+			if err != nil {
+				logger.WithError(err).Error("Failed to check the exchange maintenance, skipping tick")
+				continue
+			}
+			if paused {
+				continue
+			}
 
 			// check risk off mode
 			cfg := risk.NewSessionSizeConfigFromUserExchangeOrDefault(userExchange)
@@ -111,6 +196,10 @@ func StartLoop(ctx context.Context) error {
 				}
 			}
 
+			// check news halt -> pause new entries after matching headlines;
+			// the controller still manages the open positions
+			entryHalt := newsHalt(ctx, tvRepo, config, user.ID)
+
 			// check if news window -> risk off mode
 
 			// fetch news for a reasonable window: yesterday → tomorrow
@@ -128,9 +217,83 @@ func StartLoop(ctx context.Context) error {
 				return errors.New("trade window is not allowed")
 			}
 
-			err = runController(ctx, apiKey, apiSecret, user, userExchange, exchange)
+			creds, err := security.ResolveCredentials(ctx, userExchange)
+			if err != nil {
+				logger.WithError(err).Error("Failed to resolve exchange credentials")
+				return err
+			}
+
+			var (
+				signal *externalmodel.TradingSignal
+				job    *model.SignalJob
+				skip   bool
+			)
+			if queue != nil {
+				signal, job, err = queue.next(ctx, time.Now().UTC())
+			} else {
+				signal, skip, err = nextSignal(ctx, signalExecRep, signalRep, user.ID, exchange.ID, config.TargetSymbol, targetExchange)
+			}
+			if err != nil {
+				logger.WithError(err).Error("Failed to pick the signal to act on")
+				return err
+			}
+			if skip {
+				logger.WithField("symbol", config.TargetSymbol).Info("latest signal skipped by the replay policy, skipping tick")
+				continue
+			}
+			if queue != nil && signal == nil {
+				logger.WithField("symbol", config.TargetSymbol).Info("no queued signal yet, skipping tick")
+				continue
+			}
+			// The controller, its filters and brackets read the positions
+			// of the account once per tick.
+			runCtx := connectors.WithPositionBatch(ctx)
+			if entryHalt != "" {
+				runCtx = controller.WithEntryHalt(runCtx, entryHalt)
+			}
+			if signal != nil {
+				runCtx = controller.WithSignal(runCtx, *signal)
+			}
+			if signal != nil && signal.ID != staggered {
+				staggered = signal.ID
+				delay := stagger(config, userExchange.ExecutionPriority)
+				if delay > 0 {
+					logger.WithFields(map[string]interface{}{
+						"signal_id": signal.ID,
+						"priority":  userExchange.ExecutionPriority,
+						"delay":     delay,
+					}).Info("staggering the new signal")
+					if !waitStagger(ctx, delay) {
+						logger.Println("loop stopped")
+						return nil
+					}
+					runCtx = controller.WithStagger(runCtx, delay)
+				}
+			}
+
+			err = runController(runCtx, creds.APIKey, creds.APISecret, user, userExchange, exchange)
+			if queue != nil {
+				if finishErr := queue.finish(ctx, job, err, time.Now().UTC()); finishErr != nil {
+					logger.WithError(finishErr).Error("Failed to settle the signal job")
+				}
+			}
+			if signal != nil {
+				// Keep the fleet-wide outcome of the signal current,
+				// whatever this account made of it.
+				if _, refreshErr := signalSummaryRep.Refresh(ctx, signal.ID); refreshErr != nil {
+					logger.WithError(refreshErr).Warn("Failed to refresh the signal execution summary")
+				}
+			}
 			if err != nil {
 				logger.WithError(err).Error("OrderController failed, will exit here")
+				notify.NewNotifier().Notify(ctx, notify.Event{
+					Type:     notify.EventExecutorCrash,
+					UserID:   user.ID,
+					Username: user.Username,
+					Exchange: targetExchange,
+					Symbol:   config.TargetSymbol,
+					Err:      err,
+				})
 				return err
 			}
 
@@ -138,22 +301,159 @@ func StartLoop(ctx context.Context) error {
 	}
 }
 
+// reloadBrackets runs controller.ReloadBrackets against the live account.
+// Only Phemex lists and cancels resting orders; shadow accounts have none.
+func reloadBrackets(ctx context.Context, user *model.User, userExchange *model.UserExchange, exchange *model.Exchange, targetExchange, targetSymbol string) error {
+	if targetExchange != "phemex" || userExchange.Shadow {
+		return nil
+	}
+	creds, err := security.ResolveCredentials(ctx, userExchange)
+	if err != nil {
+		return err
+	}
+	environment, err := security.AccountEnvironment(user, userExchange)
+	if err != nil {
+		return err
+	}
+	client, err := connectors.NewClientFor(creds.APIKey, creds.APISecret, environment, GetConfig().BaseURL)
+	if err != nil {
+		return err
+	}
+	if userExchange.CaptureAPICalls {
+		client = client.WithCapture(apiCallRecorder(ctx, userExchange))
+	}
+	return controller.ReloadBrackets(ctx, client.WithContext(ctx), user, exchange.ID, targetSymbol)
+}
+
+// phemexConnector returns the Phemex client the controller runs with for
+// userExchange: recording its API calls, failing on purpose under chaos
+// testing and simulated on shadow accounts, as configured.
+func phemexConnector(ctx context.Context, apiKey, apiSecret, environment, baseURL string, userExchange *model.UserExchange) (connectors.Connector, error) {
+	phemexClient, err := connectors.NewClientFor(apiKey, apiSecret, environment, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if userExchange.CaptureAPICalls {
+		phemexClient = phemexClient.WithCapture(apiCallRecorder(ctx, userExchange))
+	}
+	var client connectors.Connector = phemexClient.WithContext(ctx)
+	if chaos := connectors.GetChaosConfig(); chaos.Enabled {
+		chaosClient, err := connectors.NewChaosClient(phemexClient, chaos)
+		if err != nil {
+			return nil, err
+		}
+		client = chaosClient.WithContext(ctx)
+	}
+	if userExchange.Shadow {
+		client = shadowClient(ctx, client, userExchange)
+	}
+	return client, nil
+}
+
+// PhemexConnector returns the Phemex client of the controller of user on
+// userExchange, with its stored credentials.
+func PhemexConnector(ctx context.Context, user *model.User, userExchange *model.UserExchange) (connectors.Connector, error) {
+	creds, err := security.ResolveCredentials(ctx, userExchange)
+	if err != nil {
+		return nil, fmt.Errorf("resolve exchange credentials: %w", err)
+	}
+	environment, err := security.AccountEnvironment(user, userExchange)
+	if err != nil {
+		return nil, err
+	}
+	return phemexConnector(ctx, creds.APIKey, creds.APISecret, environment, GetConfig().BaseURL, userExchange)
+}
+
+// newsHalt returns why new entries on the target symbol are paused by a
+// recent headline, empty when they are not. A failed lookup is logged and
+// does not pause them.
+func newsHalt(ctx context.Context, tvRepo *repository.TradingViewRepository, config Config, userID uint) string {
+	haltCfg := risk.NewsHaltConfig{
+		Keywords:      config.NewsHaltKeywords,
+		Window:        config.NewsHaltWindow,
+		MinImportance: config.NewsHaltMinImportance,
+	}
+	if !haltCfg.Enabled() || !featureflag.Enabled(ctx, featureflag.NewsFilter, userID) {
+		return ""
+	}
+	now := time.Now().UTC()
+	recent, err := tvRepo.FindRecentlyIngestedEvents(ctx, config.TargetSymbol, now.Add(-haltCfg.Window), haltCfg.MinImportance)
+	if err != nil {
+		logger.WithError(fmt.Errorf("FindRecentlyIngestedEvents: %w", err)).
+			Error("failed to check the news halt, entries not halted")
+		return ""
+	}
+
+	halt := risk.EvaluateNewsHalt(now, recent, haltCfg)
+	if !halt.Halted {
+		return ""
+	}
+	logger.WithFields(map[string]interface{}{
+		"symbol": config.TargetSymbol,
+		"reason": halt.Reason,
+		"event":  halt.Event.Title,
+		"until":  halt.Until,
+	}).Warn("news halt active, pausing new entries")
+	return fmt.Sprintf("news halt: %s (%s) until %s", halt.Reason, halt.Event.Title, halt.Until.Format(time.RFC3339))
+}
+
 func runController(ctx context.Context, apiKey, apiSecret string, user *model.User, userExchange *model.UserExchange, exchange *model.Exchange) error {
 	config := GetConfig()
-	baseURL := config.BaseURL
-	targetExchange := config.TargetExchange
-	targetSymbol := config.TargetSymbol
+	return dispatch(ctx, apiKey, apiSecret, user, userExchange, exchange, config.TargetExchange, config.TargetSymbol)
+}
+
+// Redispatch runs the controller of exchange once on signal instead of the
+// latest signal, e.g. to retry an order that failed. The stored credentials
+// of userExchange are used.
+func Redispatch(ctx context.Context, user *model.User, userExchange *model.UserExchange, exchange *model.Exchange, signal externalmodel.TradingSignal) error {
+	creds, err := security.ResolveCredentials(ctx, userExchange)
+	if err != nil {
+		return fmt.Errorf("resolve exchange credentials: %w", err)
+	}
+	ctx = controller.WithSignal(ctx, signal)
+	return dispatch(ctx, creds.APIKey, creds.APISecret, user, userExchange, exchange, exchange.Name, signal.Symbol)
+}
+
+func dispatch(ctx context.Context, apiKey, apiSecret string, user *model.User, userExchange *model.UserExchange, exchange *model.Exchange, targetExchange, targetSymbol string) (err error) {
+	baseURL := GetConfig().BaseURL
+	ctx = logging.WithFields(ctx, logger.Fields{"user_id": user.ID, "exchange": targetExchange})
+
+	ctx, span := tracing.Start(ctx, "executor.run_controller",
+		tracing.Attr("user_id", user.ID),
+		tracing.Attr("exchange", targetExchange),
+		tracing.Attr("symbol", targetSymbol),
+	)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	environment, err := security.AccountEnvironment(user, userExchange)
+	if err != nil {
+		logger.WithError(err).Error("exchange account environment refused")
+		return err
+	}
 
 	// TODO: this should be an interface and the exchange specific implementation should be injected
 
+	if userExchange.Shadow && targetExchange != "phemex" {
+		return fmt.Errorf("%s: %w", targetExchange, errShadowUnsupported)
+	}
+
 	if targetExchange == "phemex" {
-		phemexClient := connectors.NewClient(apiKey, apiSecret, baseURL)
-		err := controller.OrderController(ctx, phemexClient, user, exchange.ID, targetSymbol, targetExchange, userExchange)
+		client, err := phemexConnector(ctx, apiKey, apiSecret, environment, baseURL, userExchange)
+		if err != nil {
+			return err
+		}
+		err = controller.OrderController(ctx, client, user, exchange.ID, targetSymbol, targetExchange, userExchange)
 		if err != nil {
 			logger.WithError(err).Error("OrderController returned an error")
 			return err
 		}
 	} else if targetExchange == "hydra" {
+		if environment != connectors.EnvironmentLive {
+			return fmt.Errorf("hydra: %w", connectors.ErrNoTestnet)
+		}
 		c, err := connectors.NewGooeyClient(apiKey, apiSecret)
 		if err != nil {
 			logger.WithError(err).Error("OrderController failed to start NewGooeyClient")
@@ -166,8 +466,14 @@ func runController(ctx context.Context, apiKey, apiSecret string, user *model.Us
 		}
 
 	} else if targetExchange == "kraken" {
-		c := connectors.NewKrakenFuturesClient(apiKey, apiSecret, "")
-		err := controller.OrderControllerKrakenFutures(ctx, c, user, exchange.ID, targetSymbol, targetExchange, userExchange)
+		c, err := connectors.NewKrakenFuturesClientFor(apiKey, apiSecret, environment, "")
+		if err != nil {
+			return err
+		}
+		if userExchange.CaptureAPICalls {
+			c = c.WithCapture(apiCallRecorder(ctx, userExchange))
+		}
+		err = controller.OrderControllerKrakenFutures(ctx, c.WithContext(ctx), user, exchange.ID, targetSymbol, targetExchange, userExchange)
 		if err != nil {
 			logger.WithError(err).Error("OrderControllerKrakenFutures returned an error")
 			return err
@@ -0,0 +1,250 @@
+// Package export writes the orders and the closed trades of a user over a
+// date range as CSV or XLSX, with the columns accounting and tax tools
+// expect: UTC times, fees, funding and net PnL. Rows are read and written
+// a page at a time, so a year of orders is never held in memory.
+package export
+
+import (
+	"context"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strings"
+	"time"
+)
+
+// pageSize is the number of rows read from the database at once.
+const pageSize = repository.MaxPageLimit
+
+// fundingLookback is how far before the entry of a trade funding snapshots
+// are read, to find the rate of the first settlement it was open for.
+const fundingLookback = 8 * time.Hour
+
+// OrderColumns is the header of an orders export.
+var OrderColumns = []string{
+	"order_id", "created_at_utc", "executed_at_utc", "exchange", "symbol",
+	"side", "pos_side", "direction", "type", "status",
+	"quantity", "price", "notional", "fee", "fee_currency", "fee_estimated",
+}
+
+// TradeColumns is the header of a trades export. Cost basis and proceeds are
+// the buy and sell values of the trade, whatever its side; gross_pnl is
+// before fees and funding, net_pnl after both.
+var TradeColumns = []string{
+	"trade_id", "exchange", "symbol", "side", "quantity",
+	"opened_at_utc", "entry_price", "closed_at_utc", "exit_price",
+	"cost_basis", "proceeds", "gross_pnl", "fees", "funding", "net_pnl",
+	"entry_order_id", "exit_order_id",
+}
+
+type orderPager interface {
+	FindLatest(ctx context.Context, page repository.Pagination) ([]model.Order, error)
+}
+
+type tradePager interface {
+	FindClosedPage(ctx context.Context, userID uint, page repository.Pagination) ([]model.Trade, error)
+}
+
+type fundingHistory interface {
+	FetchFundingRates(ctx context.Context, exchange, symbol string, from, to time.Time) ([]model.FundingRate, error)
+}
+
+type exchangeLister interface {
+	List(ctx context.Context) ([]model.Exchange, error)
+}
+
+// Exporter writes the exports of the main database.
+type Exporter struct {
+	orders    orderPager
+	trades    tradePager
+	funding   fundingHistory
+	exchanges exchangeLister
+}
+
+// New returns an Exporter backed by the main database.
+func New() *Exporter {
+	return &Exporter{
+		orders:    repository.NewOrderRepository(),
+		trades:    repository.NewTradeRepository(),
+		funding:   repository.NewFundingRepository(),
+		exchanges: repository.NewExchangeRepository(),
+	}
+}
+
+// Orders writes the orders of userID created in [from, to] to t, archived
+// orders included, oldest first. A zero bound is open. It returns the
+// number of orders written.
+func (e *Exporter) Orders(ctx context.Context, t Table, userID uint, from, to time.Time) (int, error) {
+	names, err := e.exchangeNames(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := t.Row(header(OrderColumns)...); err != nil {
+		return 0, err
+	}
+
+	// The orders are scoped to userID like in an API request of theirs.
+	ctx = auth.WithUserID(ctx, userID)
+	page := repository.Pagination{Limit: pageSize, Sort: repository.SortAsc, From: from, To: to}
+	written := 0
+	for {
+		rows, err := e.orders.FindLatest(ctx, page)
+		if err != nil {
+			return written, err
+		}
+		for _, o := range rows {
+			if err := t.Row(orderCells(o, names[o.ExchangeID])...); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if err := t.Flush(); err != nil {
+			return written, err
+		}
+		var last uint
+		if len(rows) > 0 {
+			last = rows[len(rows)-1].ID
+		}
+		if page.Cursor = page.NextCursor(len(rows), last); page.Cursor == 0 {
+			return written, nil
+		}
+	}
+}
+
+// Trades writes the trades of userID closed in [from, to] to t, oldest
+// first, with the funding paid while they were open estimated from the
+// collected funding rates. It returns the number of trades written.
+func (e *Exporter) Trades(ctx context.Context, t Table, userID uint, from, to time.Time) (int, error) {
+	names, err := e.exchangeNames(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := t.Row(header(TradeColumns)...); err != nil {
+		return 0, err
+	}
+
+	page := repository.Pagination{Limit: pageSize, Sort: repository.SortAsc, From: from, To: to}
+	written := 0
+	for {
+		rows, err := e.trades.FindClosedPage(ctx, userID, page)
+		if err != nil {
+			return written, err
+		}
+		for _, tr := range rows {
+			exchange := names[tr.ExchangeID]
+			funding, err := e.tradeFunding(ctx, exchange, tr)
+			if err != nil {
+				return written, err
+			}
+			if err := t.Row(tradeCells(tr, exchange, funding)...); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if err := t.Flush(); err != nil {
+			return written, err
+		}
+		var last uint
+		if len(rows) > 0 {
+			last = rows[len(rows)-1].ID
+		}
+		if page.Cursor = page.NextCursor(len(rows), last); page.Cursor == 0 {
+			return written, nil
+		}
+	}
+}
+
+func (e *Exporter) exchangeNames(ctx context.Context) (map[uint]string, error) {
+	exchanges, err := e.exchanges.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(exchanges))
+	for _, x := range exchanges {
+		names[x.ID] = x.Name
+	}
+	return names, nil
+}
+
+func (e *Exporter) tradeFunding(ctx context.Context, exchange string, tr model.Trade) (float64, error) {
+	if tr.ExitTime == nil || exchange == "" {
+		return 0, nil
+	}
+	rates, err := e.funding.FetchFundingRates(ctx, strings.ToLower(exchange), tr.Symbol, tr.EntryTime.Add(-fundingLookback), *tr.ExitTime)
+	if err != nil {
+		return 0, err
+	}
+	return Funding(tr, rates), nil
+}
+
+// Funding estimates the funding tr paid, negative when it received funding,
+// from rates ascending by time. Each settlement the trade was open for, i.e.
+// after its entry and until its exit, is charged at the last rate and mark
+// price collected before it.
+func Funding(tr model.Trade, rates []model.FundingRate) float64 {
+	if tr.ExitTime == nil {
+		return 0
+	}
+	settlements := map[time.Time]model.FundingRate{}
+	for _, r := range rates {
+		at := r.NextFundingTime
+		if !at.After(tr.EntryTime) || at.After(*tr.ExitTime) || r.Datetime.After(at) {
+			continue
+		}
+		settlements[at] = r
+	}
+	var paid float64
+	for _, r := range settlements {
+		paid += tr.Quantity * r.MarkPrice.InexactFloat64() * r.FundingRate.InexactFloat64()
+	}
+	// Longs pay a positive rate, shorts receive it.
+	if strings.EqualFold(tr.PosSide, "short") {
+		paid = -paid
+	}
+	return paid
+}
+
+func orderCells(o model.Order, exchange string) []interface{} {
+	var notional interface{}
+	if o.Price != nil {
+		notional = o.Quantity.Mul(*o.Price)
+	}
+	return []interface{}{
+		o.ID, o.CreatedAt, o.ExecutedAt, exchange, o.Symbol,
+		o.Side, o.PosSide, o.OrderDir, o.OrderType, o.Status,
+		o.Quantity, o.Price, notional, o.Fee, o.FeeCurrency, o.FeeEstimated,
+	}
+}
+
+func tradeCells(tr model.Trade, exchange string, funding float64) []interface{} {
+	cells := []interface{}{
+		tr.ID, exchange, tr.Symbol, strings.ToLower(tr.PosSide), tr.Quantity,
+		tr.EntryTime, tr.EntryPrice, tr.ExitTime, tr.ExitPrice,
+	}
+
+	var costBasis, proceeds, gross, net interface{}
+	if tr.ExitPrice != nil {
+		entry, exit := tr.Quantity*tr.EntryPrice, tr.Quantity*(*tr.ExitPrice)
+		costBasis, proceeds = entry, exit
+		if strings.EqualFold(tr.PosSide, "short") {
+			costBasis, proceeds = exit, entry
+		}
+	}
+	if tr.PnL != nil {
+		// PnL is net of the fees of the trade.
+		gross = *tr.PnL + tr.Fees
+		net = *tr.PnL - funding
+	}
+	return append(cells,
+		costBasis, proceeds, gross, tr.Fees, funding, net,
+		tr.EntryOrderID, tr.ExitOrderID,
+	)
+}
+
+func header(columns []string) []interface{} {
+	cells := make([]interface{}, len(columns))
+	for i, v := range columns {
+		cells[i] = v
+	}
+	return cells
+}
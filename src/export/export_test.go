@@ -0,0 +1,198 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOrders struct {
+	rows   []model.Order
+	userID uint
+}
+
+func (f *fakeOrders) FindLatest(ctx context.Context, page repository.Pagination) ([]model.Order, error) {
+	f.userID, _ = auth.UserIDFromContext(ctx)
+	var out []model.Order
+	for _, o := range f.rows {
+		if o.ID > page.Cursor && len(out) < page.Limit {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+type fakeTrades struct{ rows []model.Trade }
+
+func (f *fakeTrades) FindClosedPage(_ context.Context, _ uint, page repository.Pagination) ([]model.Trade, error) {
+	var out []model.Trade
+	for _, t := range f.rows {
+		if t.ID > page.Cursor && len(out) < page.Limit {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+type fakeFunding struct{ rates []model.FundingRate }
+
+func (f *fakeFunding) FetchFundingRates(_ context.Context, exchange, _ string, _, _ time.Time) ([]model.FundingRate, error) {
+	if exchange != "phemex" {
+		return nil, nil
+	}
+	return f.rates, nil
+}
+
+type fakeExchanges struct{}
+
+func (fakeExchanges) List(context.Context) ([]model.Exchange, error) {
+	return []model.Exchange{{ID: 1, Name: "Phemex"}}, nil
+}
+
+func fundingRate(at, next time.Time, rate float64) model.FundingRate {
+	return model.FundingRate{Datetime: at, NextFundingTime: next, FundingRate: decimal.NewFromFloat(rate), MarkPrice: decimal.NewFromInt(100)}
+}
+
+func TestFundingChargesEverySettlementOnce(t *testing.T) {
+	entry := time.Date(2025, 3, 1, 7, 0, 0, 0, time.UTC)
+	exit := time.Date(2025, 3, 1, 17, 0, 0, 0, time.UTC)
+	settle1, settle2 := entry.Add(time.Hour), entry.Add(9*time.Hour)
+	rates := []model.FundingRate{
+		fundingRate(entry.Add(-time.Hour), settle1, 0.0002),
+		fundingRate(entry.Add(30*time.Minute), settle1, 0.0001), // last before the settlement
+		fundingRate(settle1.Add(time.Hour), settle2, -0.0003),
+		fundingRate(exit.Add(-time.Hour), exit.Add(7*time.Hour), 0.0005), // after the exit
+	}
+	long := model.Trade{PosSide: "Long", Quantity: 2, EntryTime: entry, ExitTime: &exit}
+	require.InDelta(t, 2*100*(0.0001-0.0003), Funding(long, rates), 1e-12)
+	short := long
+	short.PosSide = "Short"
+	require.InDelta(t, -Funding(long, rates), Funding(short, rates), 1e-12)
+}
+
+func TestExportTradesAsCSV(t *testing.T) {
+	entry := time.Date(2025, 3, 1, 7, 0, 0, 0, time.UTC)
+	exit := entry.Add(10 * time.Hour)
+	exitPrice, pnl, exitOrder := 90.0, 19.0, uint(12)
+	e := &Exporter{
+		trades: &fakeTrades{rows: []model.Trade{{
+			ID: 5, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Short", Quantity: 2,
+			EntryTime: entry, EntryPrice: 100, ExitTime: &exit, ExitPrice: &exitPrice,
+			PnL: &pnl, Fees: 1, EntryOrderID: 11, ExitOrderID: &exitOrder,
+		}}},
+		funding:   &fakeFunding{rates: []model.FundingRate{fundingRate(entry, entry.Add(time.Hour), 0.001)}},
+		exchanges: fakeExchanges{},
+	}
+
+	var buf bytes.Buffer
+	table, err := NewTable(FormatCSV, &buf, "Trades")
+	require.NoError(t, err)
+	n, err := e.Trades(context.Background(), table, 3, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, table.Close())
+	require.Equal(t, 1, n)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, TradeColumns, records[0])
+	row := map[string]string{}
+	for i, c := range TradeColumns {
+		row[c] = records[1][i]
+	}
+	require.Equal(t, "Phemex", row["exchange"])
+	require.Equal(t, "short", row["side"])
+	require.Equal(t, "2025-03-01 07:00:00", row["opened_at_utc"])
+	require.Equal(t, "180", row["cost_basis"])
+	require.Equal(t, "200", row["proceeds"])
+	require.Equal(t, "20", row["gross_pnl"])
+	require.Equal(t, "-0.2", row["funding"], "a short receives a positive rate")
+	require.Equal(t, "19.2", row["net_pnl"])
+	require.Equal(t, "12", row["exit_order_id"])
+}
+
+func TestExportOrdersAsXLSX(t *testing.T) {
+	executed := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	price := decimal.NewFromInt(100)
+	orders := &fakeOrders{}
+	for id := uint(1); id <= pageSize+1; id++ {
+		orders.rows = append(orders.rows, model.Order{ID: id, ExchangeID: 1, Symbol: "BTC<USDT>", Quantity: decimal.NewFromInt(2), Price: &price, Status: "filled", ExecutedAt: &executed, Fee: 0.12})
+	}
+	e := &Exporter{orders: orders, exchanges: fakeExchanges{}}
+
+	var buf bytes.Buffer
+	table, err := NewTable(FormatXLSX, &buf, "Orders & fills")
+	require.NoError(t, err)
+	n, err := e.Orders(context.Background(), table, 3, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, table.Close())
+	require.Equal(t, pageSize+1, n, "read over two pages")
+	require.Equal(t, uint(3), orders.userID, "scoped to the user")
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		parts[f.Name] = string(b)
+	}
+	require.Contains(t, parts, "[Content_Types].xml")
+	require.Contains(t, parts["xl/workbook.xml"], `name="Orders &amp; fills"`)
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	require.Equal(t, pageSize+2, strings.Count(sheet, "<row "))
+	require.Contains(t, sheet, `<t xml:space="preserve">BTC&lt;USDT&gt;</t>`)
+	require.Contains(t, sheet, `<c s="1"><v>45717.5</v></c>`, "executed at as a date")
+	require.Contains(t, sheet, `<c><v>200</v></c>`, "notional")
+
+	_, err = NewTable("pdf", &buf, "")
+	require.Error(t, err)
+}
+
+func TestRealizedGainsOfYear(t *testing.T) {
+	at := func(year, month int) *time.Time {
+		ts := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		return &ts
+	}
+	price := func(p float64) *float64 { return &p }
+	e := &Exporter{
+		trades: &fakeTrades{rows: []model.Trade{
+			{ID: 1, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Long", Status: model.TradeStatusClosed, Quantity: 1, EntryTime: *at(2024, 11), EntryPrice: 100, ExitTime: at(2025, 2), ExitPrice: price(150)},
+			{ID: 2, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Long", Status: model.TradeStatusClosed, Quantity: 1, EntryTime: *at(2024, 12), EntryPrice: 140, ExitTime: at(2025, 1), ExitPrice: price(160)},
+			{ID: 3, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Long", Status: model.TradeStatusClosed, Quantity: 1, EntryTime: *at(2024, 1), EntryPrice: 10, ExitTime: at(2024, 2), ExitPrice: price(20)},
+		}},
+		exchanges: fakeExchanges{},
+	}
+
+	gains, err := e.RealizedGains(context.Background(), 3, 2025, report.LotsFIFO)
+	require.NoError(t, err)
+	require.Len(t, gains, 2)
+	// FIFO closes the lot of trade 1 with the exit of trade 2
+	require.Equal(t, uint(1), gains[0].OpenRef)
+	require.Equal(t, uint(2), gains[0].CloseRef)
+	require.Equal(t, "60", gains[0].Gain.String())
+	require.Equal(t, "10", gains[1].Gain.String())
+
+	var buf bytes.Buffer
+	table, err := NewTable(FormatCSV, &buf, "Gains")
+	require.NoError(t, err)
+	require.NoError(t, WriteGains(table, gains))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, []string{"Phemex", "BTCUSDT", "USDT", "long", "1", "2024-11-01 00:00:00", "2025-01-01 00:00:00", "100", "160", "0", "60", "1", "2"}, records[1])
+}
@@ -0,0 +1,129 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Formats of an export.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// timeLayout is how times are written to CSV, always in UTC: the layout
+// spreadsheets and accounting tools parse without a locale.
+const timeLayout = "2006-01-02 15:04:05"
+
+// Table receives the rows of an export one at a time, so an export never
+// holds more than a page of rows. A cell is a string, a number (int, uint,
+// float64, decimal.Decimal), a bool, a time.Time or nil for an empty cell;
+// pointers to those are followed.
+type Table interface {
+	Row(cells ...interface{}) error
+	// Flush writes the buffered rows out.
+	Flush() error
+	// Close completes the file.
+	Close() error
+}
+
+// NewTable returns a Table writing format to w, the rows of an XLSX file
+// going to a single sheet named sheet.
+func NewTable(format string, w io.Writer, sheet string) (Table, error) {
+	switch format {
+	case FormatCSV:
+		return &csvTable{w: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXTable(w, sheet)
+	}
+	return nil, fmt.Errorf("unknown export format %q, want csv or xlsx", format)
+}
+
+// ContentType is the media type of format.
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+type csvTable struct {
+	w *csv.Writer
+}
+
+func (t *csvTable) Row(cells ...interface{}) error {
+	record := make([]string, len(cells))
+	for i, c := range cells {
+		record[i] = csvCell(c)
+	}
+	return t.w.Write(record)
+}
+
+func (t *csvTable) Flush() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+func (t *csvTable) Close() error {
+	return t.Flush()
+}
+
+func csvCell(c interface{}) string {
+	switch v := deref(c).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(timeLayout)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case decimal.Decimal:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// deref follows the pointers to the supported cell types, nil pointers
+// being empty cells.
+func deref(c interface{}) interface{} {
+	switch v := c.(type) {
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *float64:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *decimal.Decimal:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *uint:
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	return c
+}
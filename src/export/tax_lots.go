@@ -0,0 +1,68 @@
+package export
+
+import (
+	"context"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/repository"
+	"time"
+)
+
+// GainColumns is the header of a realized gains export.
+var GainColumns = []string{
+	"exchange", "symbol", "currency", "side", "quantity",
+	"opened_at_utc", "closed_at_utc", "cost_basis", "proceeds", "fees", "gain",
+	"open_trade_id", "close_trade_id",
+}
+
+// RealizedGains returns the gains userID realized in the UTC calendar year,
+// matching the fills of their closed trades into lots with method
+// (report.LotsFIFO or report.LotsAverage). Every trade closed before the end
+// of the year is read: lots opened in earlier years may be closed in it.
+func (e *Exporter) RealizedGains(ctx context.Context, userID uint, year int, method string) ([]report.RealizedGain, error) {
+	names, err := e.exchangeNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)
+	page := repository.Pagination{Limit: pageSize, Sort: repository.SortAsc, To: end}
+	var fills []report.LotFill
+	for {
+		rows, err := e.trades.FindClosedPage(ctx, userID, page)
+		if err != nil {
+			return nil, err
+		}
+		fills = append(fills, report.TradeFills(rows, names)...)
+		var last uint
+		if len(rows) > 0 {
+			last = rows[len(rows)-1].ID
+		}
+		if page.Cursor = page.NextCursor(len(rows), last); page.Cursor == 0 {
+			break
+		}
+	}
+
+	gains, err := report.MatchLots(fills, method)
+	if err != nil {
+		return nil, err
+	}
+	return report.GainsInYear(gains, year), nil
+}
+
+// WriteGains writes gains to t under GainColumns.
+func WriteGains(t Table, gains []report.RealizedGain) error {
+	if err := t.Row(header(GainColumns)...); err != nil {
+		return err
+	}
+	for _, g := range gains {
+		err := t.Row(
+			g.Exchange, g.Symbol, g.Currency, g.PosSide, g.Quantity,
+			g.OpenedAt, g.ClosedAt, g.CostBasis, g.Proceeds, g.Fees, g.Gain,
+			g.OpenRef, g.CloseRef,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return t.Flush()
+}
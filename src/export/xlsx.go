@@ -0,0 +1,159 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// xlsxTable writes an XLSX workbook of one sheet. The sheet is the first
+// entry of the zip and is streamed as rows come; the small parts that
+// complete the workbook are written by Close.
+type xlsxTable struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	name  string
+	rows  int
+}
+
+// excelEpoch is day zero of the serial dates of spreadsheets.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// dateStyle is the index in xlsxStyles of the date and time format.
+const dateStyle = 1
+
+func newXLSXTable(w io.Writer, name string) (*xlsxTable, error) {
+	z := zip.NewWriter(w)
+	part, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	t := &xlsxTable{zip: z, sheet: bufio.NewWriter(part), name: name}
+	_, err = t.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return t, err
+}
+
+func (t *xlsxTable) Row(cells ...interface{}) error {
+	t.rows++
+	fmt.Fprintf(t.sheet, `<row r="%d">`, t.rows)
+	for _, c := range cells {
+		if err := t.cell(c); err != nil {
+			return err
+		}
+	}
+	_, err := t.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (t *xlsxTable) cell(c interface{}) error {
+	var err error
+	switch v := deref(c).(type) {
+	case nil:
+		_, err = t.sheet.WriteString(`<c/>`)
+	case time.Time:
+		if v.IsZero() {
+			_, err = t.sheet.WriteString(`<c/>`)
+			break
+		}
+		days := float64(v.UTC().Sub(excelEpoch)) / float64(24*time.Hour)
+		_, err = fmt.Fprintf(t.sheet, `<c s="%d"><v>%s</v></c>`, dateStyle, strconv.FormatFloat(days, 'f', -1, 64))
+	case float64:
+		_, err = fmt.Fprintf(t.sheet, `<c><v>%s</v></c>`, strconv.FormatFloat(v, 'f', -1, 64))
+	case decimal.Decimal:
+		_, err = fmt.Fprintf(t.sheet, `<c><v>%s</v></c>`, v.String())
+	case int, int64, uint, uint64:
+		_, err = fmt.Fprintf(t.sheet, `<c><v>%d</v></c>`, v)
+	case bool:
+		b := 0
+		if v {
+			b = 1
+		}
+		_, err = fmt.Fprintf(t.sheet, `<c t="b"><v>%d</v></c>`, b)
+	default:
+		if _, err = t.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+			return err
+		}
+		if err = xml.EscapeText(t.sheet, []byte(csvCell(v))); err != nil {
+			return err
+		}
+		_, err = t.sheet.WriteString(`</t></is></c>`)
+	}
+	return err
+}
+
+func (t *xlsxTable) Flush() error {
+	if err := t.sheet.Flush(); err != nil {
+		return err
+	}
+	return t.zip.Flush()
+}
+
+func (t *xlsxTable) Close() error {
+	if _, err := t.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	if err := t.sheet.Flush(); err != nil {
+		return err
+	}
+	var name bytes.Buffer
+	if err := xml.EscapeText(&name, []byte(t.name)); err != nil {
+		return err
+	}
+	parts := []struct{ path, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, name.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, p := range parts {
+		w, err := t.zip.Create(p.path)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, xml.Header+p.body); err != nil {
+			return err
+		}
+	}
+	return t.zip.Close()
+}
+
+const xlsxContentTypes = `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRootRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const xlsxWorkbookRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles holds the default cell style and, at dateStyle, a date and
+// time format.
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+	`</styleSheet>`
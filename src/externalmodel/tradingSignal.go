@@ -1,6 +1,12 @@
 package externalmodel
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type TradingSignal struct {
 	ID                     uint       `gorm:"primaryKey;column:id" json:"id"`
@@ -21,9 +27,75 @@ type TradingSignal struct {
 	Comment                string     `gorm:"column:comment" json:"comment"`
 	Message                string     `gorm:"column:message" json:"message"`
 	ReceivedAt             *time.Time `gorm:"column:received_at" json:"received_at,omitempty"`
+
+	// Schema v2, every field is optional. Quantity is in base units and wins
+	// over SizePercent, a percent of the available balance. StopLoss and
+	// TakeProfit are prices. A signal is ignored once ValidUntil has passed,
+	// and by users whose strategy does not match StrategyTag.
+	Quantity    *float64   `gorm:"column:quantity" json:"quantity,omitempty"`
+	SizePercent *float64   `gorm:"column:size_percent" json:"size_percent,omitempty"`
+	Leverage    *float64   `gorm:"column:leverage" json:"leverage,omitempty"`
+	StopLoss    *float64   `gorm:"column:stop_loss" json:"stop_loss,omitempty"`
+	TakeProfit  *float64   `gorm:"column:take_profit" json:"take_profit,omitempty"`
+	ValidUntil  *time.Time `gorm:"column:valid_until" json:"valid_until,omitempty"`
+	StrategyTag string     `gorm:"column:strategy_tag" json:"strategy_tag,omitempty"`
 }
 
 // TableName Ensures that GORM uses the exact table name from the database.
 func (TradingSignal) TableName() string {
 	return "trade_tradingsignal"
 }
+
+// Expired reports whether the signal carries a ValidUntil before now.
+func (s TradingSignal) Expired(now time.Time) bool {
+	return s.ValidUntil != nil && now.After(*s.ValidUntil)
+}
+
+// UnsetV2Columns returns the schema v2 columns left unset on s. Writers omit them
+// so signals without v2 fields can be stored in a table that predates them.
+func (s TradingSignal) UnsetV2Columns() (unset []string) {
+	for column, set := range map[string]bool{
+		"quantity":     s.Quantity != nil,
+		"size_percent": s.SizePercent != nil,
+		"leverage":     s.Leverage != nil,
+		"stop_loss":    s.StopLoss != nil,
+		"take_profit":  s.TakeProfit != nil,
+		"valid_until":  s.ValidUntil != nil,
+		"strategy_tag": s.StrategyTag != "",
+	} {
+		if !set {
+			unset = append(unset, column)
+		}
+	}
+	return unset
+}
+
+// Fingerprint identifies what the signal asks for, leaving out its id and
+// timestamps, so an alert fired twice yields the same fingerprint.
+func (s TradingSignal) Fingerprint() string {
+	opt := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	}
+	parts := []string{
+		strings.ToLower(s.ExchangeName),
+		strings.ToUpper(s.Symbol),
+		strings.ToLower(s.Action),
+		strings.ToLower(s.OrderType),
+		fmt.Sprint(s.Qty),
+		opt(s.Price),
+		strings.ToLower(s.MarketPosition),
+		strings.ToLower(s.PrevMarketPosition),
+		fmt.Sprint(s.MarketPositionSize),
+		opt(s.Quantity),
+		opt(s.SizePercent),
+		opt(s.Leverage),
+		opt(s.StopLoss),
+		opt(s.TakeProfit),
+		strings.ToLower(s.StrategyTag),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
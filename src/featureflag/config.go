@@ -0,0 +1,21 @@
+package featureflag
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// CacheTTL bounds how long a flag flip takes to reach running processes.
+	CacheTTL time.Duration `envconfig:"FEATURE_FLAG_CACHE_TTL" default:"30s"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
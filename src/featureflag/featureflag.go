@@ -0,0 +1,184 @@
+package featureflag
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Flag names a behavior operators can switch off without a deploy.
+type Flag string
+
+const (
+	// LiveTrading gates the executor loop: when off, ticks are skipped and
+	// no order reaches the exchange.
+	LiveTrading Flag = "live_trading"
+	// TrailingStopLoss gates raising the SL of filled orders.
+	TrailingStopLoss Flag = "trailing_sl"
+	// NewsFilter gates the news halt and the news sentiment signal filter.
+	NewsFilter Flag = "news_filter"
+	// EmergencyStop is turned on by the kill switch and halts the executor
+	// loop until it is released. Unlike the others it is off by default.
+	EmergencyStop Flag = "emergency_stop"
+)
+
+// defaults apply when no override exists. An empty feature_flags table keeps
+// the current behavior.
+var defaults = map[Flag]bool{
+	LiveTrading:      true,
+	TrailingStopLoss: true,
+	NewsFilter:       true,
+	EmergencyStop:    false,
+}
+
+// Known reports whether f is one of the flags above.
+func Known(f Flag) bool {
+	_, ok := defaults[f]
+	return ok
+}
+
+// Flags returns the known flags in a stable order.
+func Flags() []Flag {
+	return []Flag{LiveTrading, TrailingStopLoss, NewsFilter, EmergencyStop}
+}
+
+// Default returns the value of f when no override exists.
+func Default(f Flag) bool {
+	return defaults[f]
+}
+
+// Store loads every flag override.
+type Store interface {
+	List(ctx context.Context) ([]model.FeatureFlag, error)
+}
+
+var (
+	storeMu sync.Mutex
+	store   Store
+	loaded  bool
+	cache   = newFlagCache()
+)
+
+// SetStore replaces the store built from database.MainDB and returns a
+// function restoring the previous one.
+func SetStore(s Store) (restore func()) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	loadStoreLocked()
+	previous := store
+	store = s
+	cache.reset()
+
+	return func() {
+		storeMu.Lock()
+		defer storeMu.Unlock()
+		store = previous
+		cache.reset()
+	}
+}
+
+func currentStore() Store {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	loadStoreLocked()
+	return store
+}
+
+func loadStoreLocked() {
+	if loaded {
+		return
+	}
+	loaded = true
+	if database.MainDB != nil {
+		store = repository.NewFeatureFlagRepository()
+	}
+	cache.setTTL(GetConfig().CacheTTL)
+}
+
+// Enabled reports whether f is on for userID. A user override wins over the
+// global one (UserID 0), which wins over the default. Overrides are cached
+// for FEATURE_FLAG_CACHE_TTL; when they cannot be loaded the last loaded
+// values are kept, or the defaults apply.
+func Enabled(ctx context.Context, f Flag, userID uint) bool {
+	s := currentStore()
+	if s == nil {
+		return Default(f)
+	}
+	overrides := cache.fetch(ctx, s)
+	if v, ok := overrides[key{flag: f, userID: userID}]; ok && userID != 0 {
+		return v
+	}
+	if v, ok := overrides[key{flag: f}]; ok {
+		return v
+	}
+	return Default(f)
+}
+
+// Invalidate drops the cached overrides so the next lookup reloads them.
+func Invalidate() {
+	cache.reset()
+}
+
+// ----- cache -----
+
+type key struct {
+	flag   Flag
+	userID uint
+}
+
+type flagCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	overrides map[key]bool
+	fetchedAt time.Time
+	now       func() time.Time
+}
+
+func newFlagCache() *flagCache {
+	return &flagCache{now: time.Now}
+}
+
+func (c *flagCache) fetch(ctx context.Context, s Store) map[key]bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.overrides != nil && c.now().Sub(c.fetchedAt) < c.ttl {
+		return c.overrides
+	}
+
+	rows, err := s.List(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("failed to load feature flags, using cached values")
+		if c.overrides == nil {
+			return map[key]bool{}
+		}
+		return c.overrides
+	}
+
+	overrides := make(map[key]bool, len(rows))
+	for _, row := range rows {
+		overrides[key{flag: Flag(row.Key), userID: row.UserID}] = row.Enabled
+	}
+	c.overrides = overrides
+	c.fetchedAt = c.now()
+	return overrides
+}
+
+func (c *flagCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
+
+func (c *flagCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.overrides = nil
+}
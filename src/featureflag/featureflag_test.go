@@ -0,0 +1,117 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type fakeStore struct {
+	rows  []model.FeatureFlag
+	err   error
+	calls int
+}
+
+func (s *fakeStore) List(context.Context) ([]model.FeatureFlag, error) {
+	s.calls++
+	return s.rows, s.err
+}
+
+func useClock(t *testing.T, now *time.Time) {
+	t.Helper()
+	cache.now = func() time.Time { return *now }
+	cache.setTTL(time.Minute)
+	t.Cleanup(func() { cache.now = time.Now })
+}
+
+func TestEnabledPrecedence(t *testing.T) {
+	store := &fakeStore{rows: []model.FeatureFlag{
+		{Key: string(LiveTrading), UserID: 0, Enabled: false},
+		{Key: string(LiveTrading), UserID: 7, Enabled: true},
+		{Key: string(NewsFilter), UserID: 7, Enabled: false},
+	}}
+	defer SetStore(store)()
+	ctx := context.Background()
+
+	require.False(t, Enabled(ctx, LiveTrading, 1), "global override")
+	require.True(t, Enabled(ctx, LiveTrading, 7), "user override wins")
+	require.False(t, Enabled(ctx, NewsFilter, 7))
+	require.True(t, Enabled(ctx, NewsFilter, 1), "default")
+	require.True(t, Enabled(ctx, TrailingStopLoss, 7), "default")
+}
+
+func TestEnabledCachesAndKeepsLastValuesOnError(t *testing.T) {
+	store := &fakeStore{rows: []model.FeatureFlag{{Key: string(TrailingStopLoss), Enabled: false}}}
+	defer SetStore(store)()
+	now := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	useClock(t, &now)
+	ctx := context.Background()
+
+	require.False(t, Enabled(ctx, TrailingStopLoss, 1))
+	store.rows = nil
+	require.False(t, Enabled(ctx, TrailingStopLoss, 1), "still cached")
+	require.Equal(t, 1, store.calls)
+
+	now = now.Add(2 * time.Minute)
+	store.err = errors.New("db down")
+	require.False(t, Enabled(ctx, TrailingStopLoss, 1), "last loaded values kept")
+	require.Equal(t, 2, store.calls)
+
+	store.err = nil
+	require.True(t, Enabled(ctx, TrailingStopLoss, 1), "override removed")
+
+	Invalidate()
+	store.err = errors.New("db down")
+	require.True(t, Enabled(ctx, TrailingStopLoss, 1), "defaults when nothing was loaded")
+}
+
+func TestEnabledWithoutStoreUsesDefaults(t *testing.T) {
+	defer SetStore(nil)()
+	for _, f := range Flags() {
+		require.Equal(t, Default(f), Enabled(context.Background(), f, 1), f)
+	}
+	require.False(t, Default(EmergencyStop))
+	require.False(t, Enabled(context.Background(), Flag("unknown"), 1))
+}
+
+func TestRepositoryBackedFlags(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.FeatureFlag{}))
+	repo := repository.NewFeatureFlagRepositoryWithDB(db)
+	defer SetStore(repo)()
+	ctx := context.Background()
+
+	require.NoError(t, repo.Set(ctx, &model.FeatureFlag{Key: string(LiveTrading), Enabled: false, Note: "incident"}))
+	require.NoError(t, repo.Set(ctx, &model.FeatureFlag{Key: string(LiveTrading), UserID: 3, Enabled: true}))
+	require.False(t, Enabled(ctx, LiveTrading, 1))
+	require.True(t, Enabled(ctx, LiveTrading, 3))
+
+	// Upsert flips the existing row.
+	require.NoError(t, repo.Set(ctx, &model.FeatureFlag{Key: string(LiveTrading), Enabled: true}))
+	flags, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+	require.True(t, flags[0].Enabled)
+	require.Equal(t, uint(0), flags[0].UserID)
+
+	removed, err := repo.Unset(ctx, string(LiveTrading), 3)
+	require.NoError(t, err)
+	require.True(t, removed)
+	removed, err = repo.Unset(ctx, string(LiveTrading), 3)
+	require.NoError(t, err)
+	require.False(t, removed)
+
+	Invalidate()
+	require.True(t, Enabled(ctx, LiveTrading, 3))
+}
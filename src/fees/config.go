@@ -0,0 +1,23 @@
+package fees
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// TakerRates and MakerRates are the fee rates applied on notional per
+	// exchange name, e.g. "phemex:0.0006,kucoin:0.0006". They are used to
+	// estimate the fee of an order until its fills are known.
+	TakerRates map[string]float64 `envconfig:"FEE_TAKER_RATES" default:"phemex:0.0006,kucoin:0.0006,kraken:0.0005,hydra:0.0006"`
+	MakerRates map[string]float64 `envconfig:"FEE_MAKER_RATES" default:"phemex:0.0001,kucoin:0.0002,kraken:0.0002,hydra:0.0002"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,65 @@
+package fees
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"strategyexecutor/src/connectors"
+)
+
+// Schedule holds the per exchange fee rates.
+type Schedule struct {
+	taker map[string]float64
+	maker map[string]float64
+}
+
+// New builds the schedule of config. Exchange names are matched case
+// insensitively.
+func New(config *Config) *Schedule {
+	return &Schedule{
+		taker: lower(config.TakerRates),
+		maker: lower(config.MakerRates),
+	}
+}
+
+func lower(rates map[string]float64) map[string]float64 {
+	out := make(map[string]float64, len(rates))
+	for name, rate := range rates {
+		out[strings.ToLower(strings.TrimSpace(name))] = rate
+	}
+	return out
+}
+
+// Rate returns the maker or taker rate of exchange, 0 when unknown.
+func (s *Schedule) Rate(exchange string, maker bool) float64 {
+	rates := s.taker
+	if maker {
+		rates = s.maker
+	}
+	return rates[strings.ToLower(exchange)]
+}
+
+// Estimate returns the expected fee of an order of the given notional.
+func (s *Schedule) Estimate(exchange string, notional float64, maker bool) float64 {
+	return math.Abs(notional) * s.Rate(exchange, maker)
+}
+
+// SumPhemexFills adds up the fees of the fills of exchangeOrderID. It returns
+// the number of fills found so callers can tell a zero fee from no fill yet.
+// Rebates are negative.
+func SumPhemexFills(fills []connectors.PhemexFill, exchangeOrderID string) (float64, int) {
+	total, n := 0.0, 0
+	for _, f := range fills {
+		if exchangeOrderID == "" || f.OrderID != exchangeOrderID {
+			continue
+		}
+		fee, err := strconv.ParseFloat(f.ExecFeeRv, 64)
+		if err != nil {
+			continue
+		}
+		total += fee
+		n++
+	}
+	return total, n
+}
@@ -0,0 +1,42 @@
+package fees
+
+import (
+	"math"
+	"testing"
+
+	"strategyexecutor/src/connectors"
+)
+
+func TestEstimate(t *testing.T) {
+	s := New(&Config{
+		TakerRates: map[string]float64{"Phemex": 0.0006},
+		MakerRates: map[string]float64{"phemex": 0.0001},
+	})
+
+	if got := s.Estimate("phemex", 10000, false); math.Abs(got-6) > 1e-9 {
+		t.Fatalf("taker estimate = %v, want 6", got)
+	}
+	if got := s.Estimate("PHEMEX", -10000, true); math.Abs(got-1) > 1e-9 {
+		t.Fatalf("maker estimate = %v, want 1", got)
+	}
+	if got := s.Estimate("kraken", 10000, false); got != 0 {
+		t.Fatalf("unknown exchange estimate = %v, want 0", got)
+	}
+}
+
+func TestSumPhemexFills(t *testing.T) {
+	fills := []connectors.PhemexFill{
+		{OrderID: "a", ExecFeeRv: "0.5"},
+		{OrderID: "a", ExecFeeRv: "0.25"},
+		{OrderID: "b", ExecFeeRv: "9"},
+		{OrderID: "a", ExecFeeRv: ""},
+	}
+
+	fee, n := SumPhemexFills(fills, "a")
+	if fee != 0.75 || n != 2 {
+		t.Fatalf("SumPhemexFills = (%v, %d), want (0.75, 2)", fee, n)
+	}
+	if _, n := SumPhemexFills(fills, ""); n != 0 {
+		t.Fatalf("empty order id matched %d fills", n)
+	}
+}
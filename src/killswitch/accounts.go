@@ -0,0 +1,177 @@
+package killswitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/security"
+	"strings"
+	"time"
+)
+
+// errUnsupported is recorded for accounts whose connector cannot perform a
+// step, so operators know to act on the exchange by hand.
+var errUnsupported = errors.New("not supported by connector, act on the exchange directly")
+
+// hydraLookback is how far back the trade journal is read to find the open
+// Hydra positions, as in the Hydra controller.
+const hydraLookback = 7 * 24 * time.Hour
+
+// account performs the emergency steps on one exchange account.
+type account interface {
+	// CancelOrders cancels the working orders.
+	CancelOrders(ctx context.Context) error
+	// Flatten closes the open positions with reduce-only market orders.
+	Flatten(ctx context.Context) error
+}
+
+// openAccount builds the connector of exchange for creds.
+func (s *Switch) openAccount(ctx context.Context, exchange string, creds security.Credentials) (account, error) {
+	switch strings.ToLower(exchange) {
+	case "phemex":
+		c, err := connectors.NewClientFor(creds.APIKey, creds.APISecret, creds.Environment, s.Config.PhemexBaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return &phemexAccount{client: c.WithContext(ctx), symbols: s.Config.PhemexSymbols}, nil
+	case "kraken":
+		c, err := connectors.NewKrakenFuturesClientFor(creds.APIKey, creds.APISecret, creds.Environment, s.Config.KrakenBaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return &krakenAccount{client: c.WithContext(ctx)}, nil
+	case "kucoin":
+		c, err := connectors.NewKucoinConnectorFor(creds.APIKey, creds.APISecret, creds.APIPassphrase, creds.KucoinKeyVersion(s.Config.KucoinKeyVersion), creds.Environment)
+		if err != nil {
+			return nil, err
+		}
+		return &kucoinAccount{client: c}, nil
+	case "hydra":
+		if creds.Environment == connectors.EnvironmentTestnet {
+			return nil, fmt.Errorf("hydra: %w", connectors.ErrNoTestnet)
+		}
+		c, err := connectors.NewGooeyClient(creds.APIKey, creds.APISecret)
+		if err != nil {
+			return nil, err
+		}
+		return &hydraAccount{client: c}, nil
+	default:
+		return nil, fmt.Errorf("exchange %q: %w", exchange, errUnsupported)
+	}
+}
+
+type phemexAccount struct {
+	client  *connectors.Client
+	symbols []string
+}
+
+// positionSymbols returns the symbols with an open position, preceded by the
+// configured ones when withConfigured is set.
+func (a *phemexAccount) positionSymbols(withConfigured bool) ([]string, error) {
+	positions, err := a.client.GetPositionsUSDT()
+	if err != nil {
+		return nil, fmt.Errorf("GetPositionsUSDT: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var symbols []string
+	add := func(symbol string) {
+		if symbol != "" && !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	if withConfigured {
+		for _, symbol := range a.symbols {
+			add(strings.TrimSpace(symbol))
+		}
+	}
+	for _, p := range positions.Positions {
+		if size := strings.TrimSpace(p.SizeRq); size != "" && size != "0" {
+			add(p.Symbol)
+		}
+	}
+	return symbols, nil
+}
+
+func (a *phemexAccount) CancelOrders(_ context.Context) error {
+	symbols, err := a.positionSymbols(true)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, symbol := range symbols {
+		resp, err := a.client.CancelAll(symbol)
+		if err == nil {
+			err = resp.Err()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cancel %s: %w", symbol, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *phemexAccount) Flatten(_ context.Context) error {
+	symbols, err := a.positionSymbols(false)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, symbol := range symbols {
+		if err := a.client.CloseAllPositions(symbol); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type krakenAccount struct {
+	client *connectors.KrakenFuturesClient
+}
+
+func (a *krakenAccount) CancelOrders(_ context.Context) error {
+	_, err := a.client.CancelAllOrders("")
+	return err
+}
+
+func (a *krakenAccount) Flatten(_ context.Context) error {
+	return a.client.CloseAllPositions("")
+}
+
+type kucoinAccount struct {
+	client *connectors.KucoinConnector
+}
+
+func (a *kucoinAccount) CancelOrders(_ context.Context) error {
+	return a.client.CancelAllFuturesOrders("")
+}
+
+// Flatten is not available: the KuCoin connector cannot close positions yet.
+func (a *kucoinAccount) Flatten(_ context.Context) error {
+	return errUnsupported
+}
+
+type hydraAccount struct {
+	client *connectors.GooeyClient
+}
+
+// CancelOrders has nothing to do: the Hydra flow only places market orders.
+func (a *hydraAccount) CancelOrders(_ context.Context) error {
+	return nil
+}
+
+func (a *hydraAccount) Flatten(ctx context.Context) error {
+	if err := a.client.Login(ctx); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	if err := a.client.FetchCSRF(ctx); err != nil {
+		return fmt.Errorf("FetchCSRF: %w", err)
+	}
+	if err := a.client.InitAtmosphereTrackingID(ctx); err != nil {
+		return fmt.Errorf("init tracking id: %w", err)
+	}
+	now := time.Now().UTC()
+	return a.client.CloseAllOpenFromTradeJournal(ctx, now.Add(-hydraLookback), now)
+}
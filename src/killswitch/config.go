@@ -0,0 +1,25 @@
+package killswitch
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	PhemexBaseURL string `envconfig:"KILL_SWITCH_PHEMEX_BASE_URL" default:"https://api.phemex.com"`
+	KrakenBaseURL string `envconfig:"KILL_SWITCH_KRAKEN_BASE_URL" default:""`
+	// KucoinKeyVersion is sent as KC-API-KEY-VERSION.
+	KucoinKeyVersion string `envconfig:"KILL_SWITCH_KUCOIN_KEY_VERSION" default:"2"`
+	// PhemexSymbols are always swept for open orders: Phemex cancels per
+	// symbol and orders without a position would otherwise be missed.
+	PhemexSymbols []string `envconfig:"KILL_SWITCH_PHEMEX_SYMBOLS" default:"BTCUSDT"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,237 @@
+// Package killswitch is the emergency stop for incident response: it halts
+// trading for one user or everyone, cancels the working orders and, when
+// asked, flattens the positions of every exchange account. Trading stays
+// halted until the stop is released explicitly.
+package killswitch
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/featureflag"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Request describes an emergency stop.
+type Request struct {
+	// UserID is the user to stop, 0 stops every user.
+	UserID uint
+	// Flatten also closes the open positions.
+	Flatten bool
+	// Reason is stored with the halt and sent in the notifications.
+	Reason string
+}
+
+// AccountResult is the outcome of the stop on one exchange account. Errors
+// of one account never stop the others.
+type AccountResult struct {
+	UserID          uint   `json:"user_id"`
+	Exchange        string `json:"exchange"`
+	OrdersCancelled bool   `json:"orders_cancelled"`
+	PositionsClosed bool   `json:"positions_closed"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Result lists what the stop did per account.
+type Result struct {
+	Halted   bool            `json:"halted"`
+	Accounts []AccountResult `json:"accounts"`
+}
+
+// Failed reports whether any account step failed.
+func (r *Result) Failed() bool {
+	for _, a := range r.Accounts {
+		if a.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+type flagStore interface {
+	Set(ctx context.Context, f *model.FeatureFlag) error
+	Unset(ctx context.Context, key string, userID uint) (bool, error)
+}
+
+type userExchangeLister interface {
+	List(ctx context.Context) ([]model.UserExchange, error)
+}
+
+type userLookup interface {
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+type notifier interface {
+	Notify(ctx context.Context, ev notify.Event)
+}
+
+// openFunc builds the connector of an exchange account.
+type openFunc func(ctx context.Context, exchange string, creds security.Credentials) (account, error)
+
+type Switch struct {
+	Log    *logger.Entry
+	Config *Config
+
+	flags         flagStore
+	userExchanges userExchangeLister
+	users         userLookup
+	notifier      notifier
+	open          openFunc
+	credentials   func(ctx context.Context, ue *model.UserExchange) (security.Credentials, error)
+}
+
+// New returns a Switch backed by database.MainDB.
+func New() *Switch {
+	s := &Switch{
+		Log:           logger.WithField("component", "killswitch"),
+		Config:        GetConfig(),
+		flags:         repository.NewFeatureFlagRepository(),
+		userExchanges: repository.NewUserExchangeRepository(),
+		users:         repository.NewUserRepository(),
+		notifier:      notify.NewNotifier(),
+		credentials:   security.ResolveCredentials,
+	}
+	s.open = s.openAccount
+	return s
+}
+
+// Stop halts trading for req.UserID first, so no executor starts a new entry
+// once it sees the halt, then cancels the working orders of every account
+// and flattens the positions when req.Flatten is set. Running executors see
+// the halt within FEATURE_FLAG_CACHE_TTL. Only a failure to store the halt
+// is returned; account failures are reported in the Result.
+func (s *Switch) Stop(ctx context.Context, req Request) (*Result, error) {
+	log := s.Log.WithFields(logger.Fields{"user_id": req.UserID, "flatten": req.Flatten, "reason": req.Reason})
+
+	err := s.flags.Set(ctx, &model.FeatureFlag{
+		Key:     string(featureflag.EmergencyStop),
+		UserID:  req.UserID,
+		Enabled: true,
+		Note:    req.Reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("store halt: %w", err)
+	}
+	featureflag.Invalidate()
+	log.Warn("emergency stop: trading halted")
+
+	if req.UserID != 0 {
+		ctx = auth.WithUserID(ctx, req.UserID)
+	}
+	userExchanges, err := s.userExchanges.List(ctx)
+	if err != nil {
+		// The halt is stored: report the partial stop rather than hide it.
+		return &Result{Halted: true}, fmt.Errorf("list user exchanges: %w", err)
+	}
+
+	res := &Result{Halted: true}
+	for i := range userExchanges {
+		res.Accounts = append(res.Accounts, s.stopAccount(ctx, &userExchanges[i], req.Flatten))
+	}
+	s.notify(ctx, req, res)
+	return res, nil
+}
+
+func (s *Switch) stopAccount(ctx context.Context, ue *model.UserExchange, flatten bool) AccountResult {
+	out := AccountResult{UserID: ue.UserID}
+	if ue.Exchange != nil {
+		out.Exchange = ue.Exchange.Name
+	}
+	log := s.Log.WithFields(logger.Fields{"user_id": out.UserID, "exchange": out.Exchange})
+
+	fail := func(step string, err error) AccountResult {
+		out.Error = fmt.Sprintf("%s: %v", step, err)
+		log.WithError(err).Errorf("emergency stop: %s failed", step)
+		return out
+	}
+
+	creds, err := s.credentials(ctx, ue)
+	if err != nil {
+		return fail("resolve credentials", err)
+	}
+	acc, err := s.open(ctx, out.Exchange, creds)
+	if err != nil {
+		return fail("open connector", err)
+	}
+
+	// Cancel first so that a resting entry cannot reopen what is flattened.
+	if err := acc.CancelOrders(ctx); err != nil {
+		return fail("cancel orders", err)
+	}
+	out.OrdersCancelled = true
+
+	if flatten {
+		if err := acc.Flatten(ctx); err != nil {
+			return fail("flatten positions", err)
+		}
+		out.PositionsClosed = true
+	}
+
+	log.WithField("positions_closed", out.PositionsClosed).Warn("emergency stop: account stopped")
+	return out
+}
+
+// notify sends one kill switch notification per stopped user.
+func (s *Switch) notify(ctx context.Context, req Request, res *Result) {
+	byUser := map[uint][]AccountResult{}
+	var order []uint
+	for _, a := range res.Accounts {
+		if _, ok := byUser[a.UserID]; !ok {
+			order = append(order, a.UserID)
+		}
+		byUser[a.UserID] = append(byUser[a.UserID], a)
+	}
+
+	for _, userID := range order {
+		ev := notify.Event{
+			Type:     notify.EventKillSwitch,
+			UserID:   userID,
+			Exchange: "all exchanges",
+			Message:  summary(req, byUser[userID]),
+		}
+		if user, err := s.users.GetUserByID(ctx, userID); err == nil && user != nil {
+			ev.Username = user.Username
+		}
+		s.notifier.Notify(ctx, ev)
+	}
+}
+
+func summary(req Request, accounts []AccountResult) string {
+	msg := "emergency stop, trading halted until released, orders cancelled"
+	if req.Flatten {
+		msg += " and positions closed"
+	}
+	if req.Reason != "" {
+		msg += " (" + req.Reason + ")"
+	}
+	for _, a := range accounts {
+		if a.Error != "" {
+			msg += fmt.Sprintf("; %s FAILED: %s", a.Exchange, a.Error)
+		}
+	}
+	return msg
+}
+
+// Release lifts the halt stored for userID (0 for the global one). It does
+// not lift a global halt for a single user. It reports whether a halt
+// existed.
+func (s *Switch) Release(ctx context.Context, userID uint) (bool, error) {
+	released, err := s.flags.Unset(ctx, string(featureflag.EmergencyStop), userID)
+	if err != nil {
+		return false, fmt.Errorf("release halt: %w", err)
+	}
+	featureflag.Invalidate()
+	s.Log.WithFields(logger.Fields{"user_id": userID, "released": released}).Warn("emergency stop released")
+	return released, nil
+}
+
+// Halted reports whether trading is halted for userID, either by its own
+// stop or a global one.
+func Halted(ctx context.Context, userID uint) bool {
+	return featureflag.Enabled(ctx, featureflag.EmergencyStop, userID)
+}
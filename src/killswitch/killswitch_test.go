@@ -0,0 +1,231 @@
+package killswitch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/featureflag"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/security"
+	"strategyexecutor/src/testsupport"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFlags struct {
+	rows []model.FeatureFlag
+	err  error
+}
+
+func (f *fakeFlags) List(context.Context) ([]model.FeatureFlag, error) { return f.rows, nil }
+
+func (f *fakeFlags) Set(_ context.Context, flag *model.FeatureFlag) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.rows = append(f.rows, *flag)
+	return nil
+}
+
+func (f *fakeFlags) Unset(_ context.Context, key string, userID uint) (bool, error) {
+	for i, row := range f.rows {
+		if row.Key == key && row.UserID == userID {
+			f.rows = append(f.rows[:i], f.rows[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeUserExchanges struct {
+	rows      []model.UserExchange
+	scopedFor uint
+}
+
+func (f *fakeUserExchanges) List(ctx context.Context) ([]model.UserExchange, error) {
+	f.scopedFor, _ = auth.UserIDFromContext(ctx)
+	var out []model.UserExchange
+	for _, ue := range f.rows {
+		if f.scopedFor == 0 || ue.UserID == f.scopedFor {
+			out = append(out, ue)
+		}
+	}
+	return out, nil
+}
+
+type fakeUsers struct{}
+
+func (fakeUsers) GetUserByID(_ context.Context, id uint) (*model.User, error) {
+	return &model.User{ID: id, Username: "trader"}, nil
+}
+
+type fakeNotifier struct{ events []notify.Event }
+
+func (f *fakeNotifier) Notify(_ context.Context, ev notify.Event) { f.events = append(f.events, ev) }
+
+type fakeAccount struct {
+	steps     *[]string
+	name      string
+	cancelErr error
+	flatErr   error
+}
+
+func (a *fakeAccount) CancelOrders(context.Context) error {
+	*a.steps = append(*a.steps, a.name+":cancel")
+	return a.cancelErr
+}
+
+func (a *fakeAccount) Flatten(context.Context) error {
+	*a.steps = append(*a.steps, a.name+":flatten")
+	return a.flatErr
+}
+
+func newTestSwitch(t *testing.T, flags *fakeFlags, ues *fakeUserExchanges, accounts map[string]*fakeAccount) (*Switch, *fakeNotifier) {
+	t.Helper()
+	t.Cleanup(featureflag.SetStore(flags))
+	n := &fakeNotifier{}
+	s := &Switch{
+		Log:           logger.WithField("component", "killswitch"),
+		Config:        &Config{},
+		flags:         flags,
+		userExchanges: ues,
+		users:         fakeUsers{},
+		notifier:      n,
+		credentials: func(context.Context, *model.UserExchange) (security.Credentials, error) {
+			return security.Credentials{APIKey: "k", APISecret: "s"}, nil
+		},
+		open: func(_ context.Context, exchange string, _ security.Credentials) (account, error) {
+			acc, ok := accounts[exchange]
+			if !ok {
+				return nil, errUnsupported
+			}
+			return acc, nil
+		},
+	}
+	return s, n
+}
+
+func userExchange(userID uint, exchange string) model.UserExchange {
+	return model.UserExchange{UserID: userID, Exchange: &model.Exchange{Name: exchange}}
+}
+
+func TestStopUserHaltsCancelsAndFlattens(t *testing.T) {
+	var steps []string
+	flags := &fakeFlags{}
+	ues := &fakeUserExchanges{rows: []model.UserExchange{
+		userExchange(3, "phemex"), userExchange(3, "kraken"), userExchange(4, "phemex"),
+	}}
+	s, n := newTestSwitch(t, flags, ues, map[string]*fakeAccount{
+		"phemex": {steps: &steps, name: "phemex"},
+		"kraken": {steps: &steps, name: "kraken"},
+	})
+	ctx := context.Background()
+
+	require.False(t, Halted(ctx, 3))
+	res, err := s.Stop(ctx, Request{UserID: 3, Flatten: true, Reason: "incident"})
+	require.NoError(t, err)
+	require.True(t, res.Halted)
+	require.False(t, res.Failed())
+	require.Equal(t, uint(3), ues.scopedFor, "only the accounts of user 3")
+	require.Equal(t, []string{"phemex:cancel", "phemex:flatten", "kraken:cancel", "kraken:flatten"}, steps)
+
+	require.True(t, Halted(ctx, 3))
+	require.False(t, Halted(ctx, 4))
+	require.Equal(t, "incident", flags.rows[0].Note)
+
+	require.Len(t, n.events, 1)
+	require.Equal(t, notify.EventKillSwitch, n.events[0].Type)
+	require.Equal(t, "trader", n.events[0].Username)
+	require.Contains(t, n.events[0].Message, "positions closed")
+
+	released, err := s.Release(ctx, 3)
+	require.NoError(t, err)
+	require.True(t, released)
+	require.False(t, Halted(ctx, 3))
+}
+
+func TestStopAllKeepsGoingOnAccountErrors(t *testing.T) {
+	var steps []string
+	ues := &fakeUserExchanges{rows: []model.UserExchange{
+		userExchange(1, "phemex"), userExchange(1, "kucoin"), userExchange(2, "unknown"), userExchange(2, "kraken"),
+	}}
+	s, n := newTestSwitch(t, &fakeFlags{}, ues, map[string]*fakeAccount{
+		"phemex": {steps: &steps, name: "phemex", cancelErr: errors.New("timeout")},
+		"kucoin": {steps: &steps, name: "kucoin"},
+		"kraken": {steps: &steps, name: "kraken"},
+	})
+	ctx := context.Background()
+
+	res, err := s.Stop(ctx, Request{})
+	require.NoError(t, err)
+	require.True(t, res.Failed())
+	require.Len(t, res.Accounts, 4)
+	require.Contains(t, res.Accounts[0].Error, "cancel orders: timeout")
+	require.True(t, res.Accounts[1].OrdersCancelled)
+	require.False(t, res.Accounts[1].PositionsClosed, "not flattening")
+	require.Contains(t, res.Accounts[2].Error, "open connector")
+	require.True(t, res.Accounts[3].OrdersCancelled)
+	require.Equal(t, []string{"phemex:cancel", "kucoin:cancel", "kraken:cancel"}, steps, "a failed cancel skips the flatten")
+
+	require.True(t, Halted(ctx, 1))
+	require.True(t, Halted(ctx, 2))
+	require.Len(t, n.events, 2)
+	require.Contains(t, n.events[0].Message, "phemex FAILED")
+
+	// A user release does not lift the global halt.
+	released, err := s.Release(ctx, 1)
+	require.NoError(t, err)
+	require.False(t, released)
+	require.True(t, Halted(ctx, 1))
+
+	released, err = s.Release(ctx, 0)
+	require.NoError(t, err)
+	require.True(t, released)
+	require.False(t, Halted(ctx, 1))
+}
+
+func TestStopFailsWhenHaltCannotBeStored(t *testing.T) {
+	var steps []string
+	ues := &fakeUserExchanges{rows: []model.UserExchange{userExchange(1, "phemex")}}
+	s, _ := newTestSwitch(t, &fakeFlags{err: errors.New("db down")}, ues, map[string]*fakeAccount{
+		"phemex": {steps: &steps, name: "phemex"},
+	})
+
+	res, err := s.Stop(context.Background(), Request{UserID: 1})
+	require.Error(t, err)
+	require.Nil(t, res)
+	require.Empty(t, steps, "nothing is cancelled before the halt is stored")
+}
+
+func TestPhemexAccountSweepsSymbolsAndFlattens(t *testing.T) {
+	exchange := testsupport.NewMockExchange(t).WithPositions(
+		testsupport.Position{Symbol: "ETHUSDT", Side: "Sell", PosSide: "Short", SizeRq: "2"},
+		testsupport.Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0"},
+	)
+	acc := &phemexAccount{client: connectors.NewClient("k", "s", exchange.URL), symbols: []string{"BTCUSDT"}}
+	ctx := context.Background()
+
+	require.NoError(t, acc.CancelOrders(ctx))
+	var cancelled []string
+	for _, r := range exchange.Requests() {
+		if r.Method == http.MethodDelete {
+			cancelled = append(cancelled, r.Query)
+		}
+	}
+	require.Equal(t, []string{"symbol=BTCUSDT", "symbol=ETHUSDT"}, cancelled)
+
+	require.NoError(t, acc.Flatten(ctx))
+	orders := exchange.Orders()
+	require.Len(t, orders, 1)
+	require.Equal(t, "ETHUSDT", orders[0].Symbol)
+	require.Equal(t, "Buy", orders[0].Side)
+	require.True(t, orders[0].ReduceOnly)
+
+	exchange.Fail(http.MethodDelete, "/g-orders/all", testsupport.Failure{Code: 10500, Msg: "busy"})
+	require.ErrorContains(t, acc.CancelOrders(ctx), "cancel BTCUSDT")
+}
@@ -0,0 +1,35 @@
+package liquidity
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// MaxSpreadBps and MaxSlippageBps bound the spread and the average fill
+	// of a market entry swept through the book, in basis points. 0 disables
+	// the check.
+	MaxSpreadBps   float64 `envconfig:"LIQUIDITY_MAX_SPREAD_BPS" default:"0"`
+	MaxSlippageBps float64 `envconfig:"LIQUIDITY_MAX_SLIPPAGE_BPS" default:"0"`
+	// Fallback is what happens to an entry failing the check: "abort" skips
+	// it, "limit" sends it as an ImmediateOrCancel limit instead.
+	Fallback string `envconfig:"LIQUIDITY_FALLBACK" default:"abort"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
+
+// Limits returns the limits configured by config.
+func (c *Config) Limits() Limits {
+	return Limits{
+		MaxSpreadBps:   c.MaxSpreadBps,
+		MaxSlippageBps: c.MaxSlippageBps,
+		Fallback:       c.Fallback,
+	}
+}
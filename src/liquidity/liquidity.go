@@ -0,0 +1,107 @@
+// Package liquidity checks an order book before a market order is sent, so
+// entries on thin books do not sweep far through the levels.
+package liquidity
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"strategyexecutor/src/connectors"
+)
+
+const (
+	FallbackAbort = "abort"
+	FallbackLimit = "limit"
+)
+
+// Limits are the spread and slippage an entry may pay.
+type Limits struct {
+	MaxSpreadBps   float64
+	MaxSlippageBps float64
+	Fallback       string
+}
+
+// Enabled reports whether any limit is set.
+func (l Limits) Enabled() bool {
+	return l.MaxSpreadBps > 0 || l.MaxSlippageBps > 0
+}
+
+// Result is the outcome of Check.
+type Result struct {
+	Allowed     bool
+	Reason      string
+	SpreadBps   float64
+	SlippageBps float64
+	// Depth is the quantity on the side taken, up to the order size.
+	Depth float64
+	// LimitPrice is the worst level price within MaxSlippageBps of the
+	// touch, the price of the limit sent by the limit fallback.
+	LimitPrice float64
+}
+
+// Check sweeps qty through book on the side a side ("Buy" or "Sell") order
+// takes and compares spread and average fill against l.
+func Check(book *connectors.OrderBook, side string, qty float64, l Limits) (Result, error) {
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return Result{}, fmt.Errorf("empty order book for %s", book.Symbol)
+	}
+	bid, ask := book.Bids[0].Price, book.Asks[0].Price
+	if bid <= 0 || ask < bid {
+		return Result{}, fmt.Errorf("invalid order book for %s: bid %v ask %v", book.Symbol, bid, ask)
+	}
+
+	levels, buy := book.Asks, true
+	switch strings.ToLower(side) {
+	case "buy":
+	case "sell":
+		levels, buy = book.Bids, false
+	default:
+		return Result{}, fmt.Errorf("invalid side %q", side)
+	}
+	touch := levels[0].Price
+
+	res := Result{SpreadBps: (ask - bid) / ((ask + bid) / 2) * 10000}
+
+	var cost float64
+	for _, lvl := range levels {
+		take := math.Min(lvl.Qty, qty-res.Depth)
+		cost += take * lvl.Price
+		res.Depth += take
+		if res.Depth >= qty {
+			break
+		}
+	}
+	if res.Depth > 0 {
+		res.SlippageBps = math.Abs(cost/res.Depth-touch) / touch * 10000
+	}
+
+	bound := touch * (1 + l.MaxSlippageBps/10000)
+	if !buy {
+		bound = touch * (1 - l.MaxSlippageBps/10000)
+	}
+	for _, lvl := range levels {
+		if (buy && lvl.Price > bound) || (!buy && lvl.Price < bound) {
+			break
+		}
+		res.LimitPrice = lvl.Price
+	}
+
+	var failed []string
+	if l.MaxSpreadBps > 0 && res.SpreadBps > l.MaxSpreadBps {
+		failed = append(failed, fmt.Sprintf("spread %.1fbps > %.1fbps", res.SpreadBps, l.MaxSpreadBps))
+	}
+	if res.Depth < qty {
+		failed = append(failed, fmt.Sprintf("depth %g < %g", res.Depth, qty))
+	}
+	if l.MaxSlippageBps > 0 && res.SlippageBps > l.MaxSlippageBps {
+		failed = append(failed, fmt.Sprintf("slippage %.1fbps > %.1fbps", res.SlippageBps, l.MaxSlippageBps))
+	}
+
+	res.Allowed = len(failed) == 0
+	res.Reason = fmt.Sprintf("spread %.1fbps, slippage %.1fbps for %g", res.SpreadBps, res.SlippageBps, qty)
+	if !res.Allowed {
+		res.Reason = strings.Join(failed, ", ")
+	}
+	return res, nil
+}
@@ -0,0 +1,64 @@
+package liquidity
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"strategyexecutor/src/connectors"
+)
+
+func testBook() *connectors.OrderBook {
+	return &connectors.OrderBook{
+		Symbol: "BTCUSDT",
+		Bids:   []connectors.BookLevel{{Price: 99.9, Qty: 1}, {Price: 99.5, Qty: 2}, {Price: 98, Qty: 10}},
+		Asks:   []connectors.BookLevel{{Price: 100.1, Qty: 1}, {Price: 100.5, Qty: 2}, {Price: 102, Qty: 10}},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	limits := Limits{MaxSpreadBps: 25, MaxSlippageBps: 50}
+
+	tests := []struct {
+		name      string
+		side      string
+		qty       float64
+		limits    Limits
+		allowed   bool
+		slippage  float64
+		limit     float64
+		depth     float64
+		reasonHas string
+	}{
+		{name: "at touch", side: "Buy", qty: 1, limits: limits, allowed: true, limit: 100.5, depth: 1},
+		{name: "within slippage", side: "Buy", qty: 2, limits: limits, allowed: true, slippage: (100.3 - 100.1) / 100.1 * 10000, limit: 100.5, depth: 2},
+		{name: "sweeps too deep", side: "Buy", qty: 5, limits: limits, slippage: (100.1 + 2*100.5 + 2*102 - 5*100.1) / 5 / 100.1 * 10000, limit: 100.5, depth: 5, reasonHas: "slippage"},
+		{name: "sell side", side: "Sell", qty: 3, limits: limits, allowed: true, slippage: (99.9 - (99.9+2*99.5)/3) / 99.9 * 10000, limit: 99.5, depth: 3},
+		{name: "insufficient depth", side: "Sell", qty: 20, limits: Limits{MaxSpreadBps: 25}, slippage: (99.9 - (99.9+2*99.5+10*98)/13) / 99.9 * 10000, limit: 99.9, depth: 13, reasonHas: "depth"},
+		{name: "wide spread", side: "Buy", qty: 1, limits: Limits{MaxSpreadBps: 10}, limit: 100.1, depth: 1, reasonHas: "spread"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Check(testBook(), tt.side, tt.qty, tt.limits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Allowed != tt.allowed {
+				t.Fatalf("allowed = %v (%s)", res.Allowed, res.Reason)
+			}
+			if math.Abs(res.SlippageBps-tt.slippage) > 1e-9 {
+				t.Fatalf("slippage = %v, want %v", res.SlippageBps, tt.slippage)
+			}
+			if res.LimitPrice != tt.limit || res.Depth != tt.depth {
+				t.Fatalf("limit %v depth %v, want %v %v", res.LimitPrice, res.Depth, tt.limit, tt.depth)
+			}
+			if tt.reasonHas != "" && !strings.Contains(res.Reason, tt.reasonHas) {
+				t.Fatalf("reason %q does not mention %s", res.Reason, tt.reasonHas)
+			}
+		})
+	}
+
+	if _, err := Check(&connectors.OrderBook{Symbol: "X", Bids: testBook().Bids}, "Buy", 1, limits); err == nil {
+		t.Fatal("expected an error for a one sided book")
+	}
+}
@@ -0,0 +1,108 @@
+// Package logging configures the process wide logrus logger: text or JSON
+// output, fields taken from the request context and redaction of secrets.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/auth"
+	"strings"
+
+	"github.com/kelseyhightower/envconfig"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type Config struct {
+	Level string `envconfig:"LOG_LEVEL" default:"debug"`
+	// Format is "text" for humans or "json" for log aggregation.
+	Format string `envconfig:"LOG_FORMAT" default:"text"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
+
+// Setup applies the environment configuration to the standard logger.
+func Setup() {
+	Configure(logger.StandardLogger(), GetConfig())
+}
+
+// Configure sets level, formatter and hooks of l. Every formatter redacts
+// secrets, and entries logged with WithContext get the context fields.
+func Configure(l *logger.Logger, config *Config) {
+	level, err := logger.ParseLevel(strings.ToLower(config.Level))
+	if err != nil {
+		level = logger.DebugLevel // fallback seguro
+	}
+	l.SetLevel(level)
+
+	var inner logger.Formatter = &logger.TextFormatter{FullTimestamp: true}
+	if strings.EqualFold(config.Format, FormatJSON) {
+		inner = &logger.JSONFormatter{}
+	}
+	l.SetFormatter(&RedactingFormatter{Formatter: inner})
+	l.AddHook(contextHook{})
+}
+
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx carrying fields for every entry logged
+// with logger.WithContext(ctx). Fields already in ctx are kept unless
+// overridden.
+func WithFields(ctx context.Context, fields logger.Fields) context.Context {
+	merged := logger.Fields{}
+	for k, v := range fieldsFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// WithExchange tags ctx with the exchange and, when non zero, the order an
+// operation works on.
+func WithExchange(ctx context.Context, exchange string, orderID uint) context.Context {
+	fields := logger.Fields{"exchange": exchange}
+	if orderID != 0 {
+		fields["order_id"] = orderID
+	}
+	return WithFields(ctx, fields)
+}
+
+func fieldsFromContext(ctx context.Context) logger.Fields {
+	fields, _ := ctx.Value(fieldsKey{}).(logger.Fields)
+	return fields
+}
+
+// contextHook copies the context fields and the authenticated user into
+// entries that carry a context. Fields set on the entry itself win.
+type contextHook struct{}
+
+func (contextHook) Levels() []logger.Level { return logger.AllLevels }
+
+func (contextHook) Fire(entry *logger.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	for k, v := range fieldsFromContext(entry.Context) {
+		if _, ok := entry.Data[k]; !ok {
+			entry.Data[k] = v
+		}
+	}
+	if userID, ok := auth.UserIDFromContext(entry.Context); ok {
+		if _, set := entry.Data["user_id"]; !set {
+			entry.Data["user_id"] = userID
+		}
+	}
+	return nil
+}
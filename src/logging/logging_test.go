@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strategyexecutor/src/auth"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	cases := map[string]string{
+		`{"KC-API-KEY":"abc","KC-API-SIGN":"c2ln","symbol":"XBTUSDTM"}`: `{"KC-API-KEY":"[REDACTED]","KC-API-SIGN":"[REDACTED]","symbol":"XBTUSDTM"}`,
+		`{"username": "u1", "password": "hunter2"}`:                     `{"username": "u1", "password":"[REDACTED]"}`,
+		`Cookie: JSESSIONID=F00D; DXTFID=beef`:                          `Cookie: JSESSIONID=[REDACTED]; DXTFID=[REDACTED]`,
+		`GET /v1/orders?symbol=BTC&signature=deadbeef&apiKey=k`:         `GET /v1/orders?symbol=BTC&signature=[REDACTED]&apiKey=[REDACTED]`,
+		`Authorization: Bearer eyJhbGciOi.x.y`:                          `Authorization: Bearer [REDACTED]`,
+		`status=200 signal=long`:                                        `status=200 signal=long`,
+	}
+	for in, want := range cases {
+		require.Equal(t, want, Redact(in), in)
+	}
+}
+
+func TestIsSensitiveKey(t *testing.T) {
+	for _, k := range []string{"apiKey", "KC-API-PASSPHRASE", "x-phemex-request-signature", "Authent", "session_cookie", "csrf_token", "sign"} {
+		require.True(t, IsSensitiveKey(k), k)
+	}
+	for _, k := range []string{"symbol", "signal", "order_id", "exchange", "error"} {
+		require.False(t, IsSensitiveKey(k), k)
+	}
+}
+
+func newTestLogger(format string) (*logger.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	l := logger.New()
+	l.SetOutput(&buf)
+	Configure(l, &Config{Level: "debug", Format: format})
+	return l, &buf
+}
+
+func TestJSONOutputRedactsAndInjectsContext(t *testing.T) {
+	l, buf := newTestLogger(FormatJSON)
+
+	ctx := auth.WithUserID(context.Background(), 7)
+	ctx = WithExchange(ctx, "kucoin", 42)
+	l.WithContext(ctx).
+		WithField("body", `{"passphrase":"p"}`).
+		WithField("csrf_token", "tok").
+		WithError(errors.New("login failed: password=hunter2")).
+		Debug("KuCoin HTTP request")
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, "KuCoin HTTP request", out["msg"])
+	require.Equal(t, `{"passphrase":"[REDACTED]"}`, out["body"])
+	require.Equal(t, Redacted, out["csrf_token"])
+	require.Equal(t, "login failed: password=[REDACTED]", out["error"])
+	require.Equal(t, "kucoin", out["exchange"])
+	require.EqualValues(t, 42, out["order_id"])
+	require.EqualValues(t, 7, out["user_id"])
+}
+
+func TestContextFieldsDoNotOverrideEntry(t *testing.T) {
+	l, buf := newTestLogger(FormatJSON)
+
+	ctx := WithFields(context.Background(), logger.Fields{"exchange": "phemex", "symbol": "BTCUSDT"})
+	ctx = WithFields(ctx, logger.Fields{"symbol": "ETHUSDT"})
+	l.WithContext(ctx).WithField("exchange", "kraken").Info("tick")
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, "kraken", out["exchange"])
+	require.Equal(t, "ETHUSDT", out["symbol"])
+	require.NotContains(t, out, "user_id")
+}
+
+func TestTextOutputRedacts(t *testing.T) {
+	l, buf := newTestLogger(FormatText)
+	l.Infof("CSRF: X-CSRF-Token=%s", "secret-value")
+	require.NotContains(t, buf.String(), "secret-value")
+	require.Contains(t, buf.String(), Redacted)
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Redacted replaces every secret value in the log output.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeyParts mark a field or JSON key as secret when its normalized
+// name (lower case, no "-" or "_") contains one of them.
+var sensitiveKeyParts = []string{
+	"apikey",
+	"secret",
+	"password",
+	"passphrase",
+	"signature",
+	"authent",
+	"authorization",
+	"cookie",
+	"token",
+	"jsessionid",
+	"dxtfid",
+}
+
+// sensitiveKeys are secret names too short to match as a part.
+var sensitiveKeys = map[string]bool{
+	"sign":      true,
+	"kcapisign": true,
+}
+
+// IsSensitiveKey reports whether a field, header or JSON key holds a secret.
+func IsSensitiveKey(key string) bool {
+	k := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+	if sensitiveKeys[k] {
+		return true
+	}
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(k, part) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// "key": "value" pairs inside logged JSON bodies.
+	reJSONPair = regexp.MustCompile(`"([A-Za-z0-9_-]+)"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+	// key=value pairs in query strings and cookie headers.
+	reKeyValue = regexp.MustCompile(`([A-Za-z0-9_-]+)=([^&;,\s"]+)`)
+	reBearer   = regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9._~+/=-]+`)
+)
+
+// Redact masks the secret values found in s.
+func Redact(s string) string {
+	s = reJSONPair.ReplaceAllStringFunc(s, func(m string) string {
+		sub := reJSONPair.FindStringSubmatch(m)
+		if !IsSensitiveKey(sub[1]) {
+			return m
+		}
+		return fmt.Sprintf("%q:%q", sub[1], Redacted)
+	})
+	s = reKeyValue.ReplaceAllStringFunc(s, func(m string) string {
+		sub := reKeyValue.FindStringSubmatch(m)
+		if !IsSensitiveKey(sub[1]) {
+			return m
+		}
+		return sub[1] + "=" + Redacted
+	})
+	return reBearer.ReplaceAllString(s, "$1 "+Redacted)
+}
+
+// RedactingFormatter masks secrets in the message and fields of an entry
+// before handing it to Formatter. Fields with a sensitive name are replaced
+// whole; string and error values are scrubbed with Redact.
+type RedactingFormatter struct {
+	Formatter logger.Formatter
+}
+
+func (f *RedactingFormatter) Format(entry *logger.Entry) ([]byte, error) {
+	redacted := *entry
+	redacted.Message = Redact(entry.Message)
+	redacted.Data = make(logger.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		redacted.Data[k] = redactValue(k, v)
+	}
+	return f.Formatter.Format(&redacted)
+}
+
+func redactValue(key string, v interface{}) interface{} {
+	if IsSensitiveKey(key) {
+		return Redacted
+	}
+	switch val := v.(type) {
+	case string:
+		return Redact(val)
+	case []byte:
+		return Redact(string(val))
+	case error:
+		if msg := val.Error(); Redact(msg) != msg {
+			return Redact(msg)
+		}
+	}
+	return v
+}
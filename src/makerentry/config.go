@@ -0,0 +1,36 @@
+package makerentry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// RepriceEvery is how long a post-only entry rests before it is
+	// cancelled and placed again at the new best bid or ask.
+	RepriceEvery time.Duration `envconfig:"PASSIVE_ENTRY_REPRICE_EVERY" default:"5s"`
+	// MaxReprices caps the placements after the first one.
+	MaxReprices int `envconfig:"PASSIVE_ENTRY_MAX_REPRICES" default:"5"`
+	// Patience is how long an entry is worked passively before what is
+	// left is sent at market.
+	Patience time.Duration `envconfig:"PASSIVE_ENTRY_PATIENCE" default:"30s"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
+
+// Limits returns the limits configured by config.
+func (c *Config) Limits() Limits {
+	return Limits{
+		RepriceEvery: c.RepriceEvery,
+		MaxReprices:  c.MaxReprices,
+		Patience:     c.Patience,
+	}
+}
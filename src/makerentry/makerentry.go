@@ -0,0 +1,220 @@
+// Package makerentry works an entry passively: a post-only limit rests at
+// the best bid (buys) or ask (sells) to earn the maker fee, is repriced to
+// the new touch every RepriceEvery up to MaxReprices times, and whatever is
+// left once Patience runs out is handed back to be sent at market.
+package makerentry
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+// Limits bound how long an entry is worked passively. A zero Patience
+// disables passive entries.
+type Limits struct {
+	RepriceEvery time.Duration
+	MaxReprices  int
+	Patience     time.Duration
+}
+
+// Enabled reports whether entries may rest at all.
+func (l Limits) Enabled() bool {
+	return l.Patience > 0 && l.RepriceEvery > 0
+}
+
+// Exchange is the account an entry is worked on.
+type Exchange interface {
+	connectors.QuoteSource
+	connectors.PostOnlyPlacer
+	connectors.OrderCanceler
+	// FindOrderByClientID returns (nil, nil) when the exchange has no order
+	// with clOrdID.
+	FindOrderByClientID(symbol, clOrdID string) (*connectors.ClientOrder, error)
+}
+
+// Order is the entry to work.
+type Order struct {
+	Symbol  string
+	Side    string
+	PosSide string
+	Qty     decimal.Decimal
+	// ClientOrderID prefixes the client order ids of the placements, one
+	// per price.
+	ClientOrderID string
+}
+
+// Result is the outcome of a passive entry.
+type Result struct {
+	// Filled is the quantity filled as maker, Remaining what is left to
+	// send at market.
+	Filled    decimal.Decimal
+	Remaining decimal.Decimal
+	// FilledValue is the quote value of Filled at the prices of the
+	// placements it filled at.
+	FilledValue decimal.Decimal
+	// Placements is the number of post-only orders accepted.
+	Placements int
+	// Resp is the answer to the last accepted placement, nil when the
+	// exchange accepted none.
+	Resp *connectors.APIResponse
+}
+
+// Worker works entries on one exchange account.
+type Worker struct {
+	Exchange Exchange
+	Now      func() time.Time
+	// Sleep waits d or until ctx is done, time based when nil.
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Run works o passively within l. It returns an error, with the result so
+// far, when an order could not be placed, looked up or cancelled: the
+// caller must not send the remainder at market while a placement may still
+// rest on the book.
+func (w *Worker) Run(ctx context.Context, o Order, l Limits) (Result, error) {
+	res := Result{Filled: decimal.Zero, Remaining: o.Qty, FilledValue: decimal.Zero}
+	if !l.Enabled() || !o.Qty.IsPositive() {
+		return res, nil
+	}
+
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"symbol": o.Symbol,
+		"side":   o.Side,
+		"qty":    o.Qty,
+	})
+	deadline := w.Now().Add(l.Patience)
+	for attempt := 0; attempt <= l.MaxReprices && res.Remaining.IsPositive(); attempt++ {
+		left := deadline.Sub(w.Now())
+		if left <= 0 {
+			break
+		}
+
+		bid, ask, err := w.Exchange.BestBidAsk(o.Symbol)
+		if err != nil {
+			return res, fmt.Errorf("quote %s: %w", o.Symbol, err)
+		}
+		price := bid
+		if strings.EqualFold(o.Side, "Sell") {
+			price = ask
+		}
+		if price <= 0 {
+			return res, fmt.Errorf("no %s quote for %s", strings.ToLower(o.Side), o.Symbol)
+		}
+
+		clOrdID := PlacementID(o.ClientOrderID, attempt)
+		priceRp := strconv.FormatFloat(price, 'f', -1, 64)
+		resp, err := w.Exchange.PlacePostOnlyWithClientID(clOrdID, o.Symbol, o.Side, o.PosSide, res.Remaining.String(), priceRp)
+		if err != nil {
+			return res, fmt.Errorf("place post-only %s: %w", clOrdID, err)
+		}
+		placed := resp.Err() == nil
+		if placed {
+			res.Placements++
+			res.Resp = resp
+		} else {
+			// A post-only order that would cross is refused; the touch moved,
+			// try again at the next price.
+			log.WithError(resp.Err()).WithField("price", priceRp).Info("post-only entry refused")
+		}
+
+		wait := l.RepriceEvery
+		if wait > left {
+			wait = left
+		}
+		if err := w.sleep(ctx, wait); err != nil && !placed {
+			return res, err
+		}
+		if !placed {
+			continue
+		}
+
+		// Cancel what still rests, then read the fills: the order may fill
+		// between the two calls.
+		filled, err := w.settle(o.Symbol, clOrdID, res.Remaining)
+		if err != nil {
+			return res, err
+		}
+		res.Filled = res.Filled.Add(filled)
+		res.FilledValue = res.FilledValue.Add(filled.Mul(decimal.NewFromFloat(price)))
+		res.Remaining = o.Qty.Sub(res.Filled)
+		log.WithFields(map[string]interface{}{
+			"price":     priceRp,
+			"filled":    filled,
+			"remaining": res.Remaining,
+		}).Info("passive entry placement done")
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+	}
+	if res.Remaining.IsNegative() {
+		res.Remaining = decimal.Zero
+	}
+	return res, nil
+}
+
+// PlacementID is the client order id of placement attempt of the entry
+// prefixed by clientOrderID, counted from 0.
+func PlacementID(clientOrderID string, attempt int) string {
+	return fmt.Sprintf("%s-p%d", clientOrderID, attempt)
+}
+
+// settle cancels placement clOrdID of qty unless it filled, and returns its
+// filled quantity.
+func (w *Worker) settle(symbol, clOrdID string, qty decimal.Decimal) (decimal.Decimal, error) {
+	order, err := w.Exchange.FindOrderByClientID(symbol, clOrdID)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("look up %s: %w", clOrdID, err)
+	}
+	if order == nil {
+		return decimal.Zero, fmt.Errorf("placement %s not found", clOrdID)
+	}
+	if !strings.EqualFold(order.OrdStatus, "Filled") {
+		if _, err := w.Exchange.CancelOrder(symbol, clOrdID); err != nil {
+			// Filled or cancelled meanwhile, the lookup below tells.
+			logger.WithError(err).WithField("client_order_id", clOrdID).Warn("failed to cancel post-only entry")
+		}
+		if order, err = w.Exchange.FindOrderByClientID(symbol, clOrdID); err != nil {
+			return decimal.Zero, fmt.Errorf("look up %s: %w", clOrdID, err)
+		}
+		if order == nil || !closed(order.OrdStatus) {
+			return decimal.Zero, fmt.Errorf("placement %s still rests on the book", clOrdID)
+		}
+	}
+	if order.CumQtyRq == "" {
+		if strings.EqualFold(order.OrdStatus, "Filled") {
+			return qty, nil
+		}
+		return decimal.Zero, nil
+	}
+	return decimal.NewFromString(order.CumQtyRq)
+}
+
+// closed reports whether an order of status can no longer fill.
+func closed(status string) bool {
+	switch strings.ToLower(status) {
+	case "filled", "canceled", "cancelled", "rejected", "deactivated":
+		return true
+	}
+	return false
+}
+
+func (w *Worker) sleep(ctx context.Context, d time.Duration) error {
+	if w.Sleep != nil {
+		return w.Sleep(ctx, d)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
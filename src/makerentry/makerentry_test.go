@@ -0,0 +1,130 @@
+package makerentry
+
+import (
+	"context"
+	"encoding/json"
+	"strategyexecutor/src/connectors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExchange fills each placement by the quantity of fills, in order, and
+// cancels what is left.
+type fakeExchange struct {
+	bid, ask float64
+	fills    []string
+	refuse   int
+	placed   []placement
+	orders   map[string]*connectors.ClientOrder
+	cancels  []string
+}
+
+type placement struct {
+	clOrdID, qty, price string
+}
+
+func (f *fakeExchange) BestBidAsk(string) (float64, float64, error) {
+	return f.bid, f.ask, nil
+}
+
+func (f *fakeExchange) PlacePostOnlyWithClientID(clOrdID, symbol, side, posSide, qty, priceRp string) (*connectors.APIResponse, error) {
+	if f.refuse > 0 {
+		f.refuse--
+		return &connectors.APIResponse{Code: 11085, Msg: "TE_PO_NOT_MAKER"}, nil
+	}
+	f.placed = append(f.placed, placement{clOrdID, qty, priceRp})
+	o := &connectors.ClientOrder{ClOrdID: clOrdID, OrdStatus: "New", OrderQtyRq: qty, CumQtyRq: "0"}
+	if len(f.fills) > 0 {
+		o.CumQtyRq, f.fills = f.fills[0], f.fills[1:]
+		if o.CumQtyRq == qty {
+			o.OrdStatus = "Filled"
+		}
+	}
+	f.orders[clOrdID] = o
+	data, _ := json.Marshal(o)
+	return &connectors.APIResponse{Data: data}, nil
+}
+
+func (f *fakeExchange) CancelOrder(_, clOrdID string) (*connectors.APIResponse, error) {
+	f.cancels = append(f.cancels, clOrdID)
+	f.orders[clOrdID].OrdStatus = "Canceled"
+	return &connectors.APIResponse{}, nil
+}
+
+func (f *fakeExchange) FindOrderByClientID(_, clOrdID string) (*connectors.ClientOrder, error) {
+	o, ok := f.orders[clOrdID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *o
+	return &copied, nil
+}
+
+func run(t *testing.T, ex *fakeExchange, side string, l Limits) Result {
+	t.Helper()
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	w := &Worker{
+		Exchange: ex,
+		Now:      func() time.Time { return now },
+		Sleep: func(_ context.Context, d time.Duration) error {
+			now = now.Add(d)
+			return nil
+		},
+	}
+	if ex.orders == nil {
+		ex.orders = map[string]*connectors.ClientOrder{}
+	}
+	res, err := w.Run(context.Background(), Order{Symbol: "BTCUSDT", Side: side, PosSide: "Long", Qty: decimal.RequireFromString("0.01"), ClientOrderID: "se-1"}, l)
+	require.NoError(t, err)
+	return res
+}
+
+func TestRunFillsAtTheTouch(t *testing.T) {
+	ex := &fakeExchange{bid: 49990, ask: 50000, fills: []string{"0.01"}}
+	res := run(t, ex, "Buy", Limits{RepriceEvery: 5 * time.Second, MaxReprices: 3, Patience: 30 * time.Second})
+
+	require.Equal(t, []placement{{"se-1-p0", "0.01", "49990"}}, ex.placed)
+	require.Empty(t, ex.cancels)
+	require.True(t, res.Remaining.IsZero())
+	require.Equal(t, "0.01", res.Filled.String())
+	require.Equal(t, "499.9", res.FilledValue.String())
+	require.Equal(t, 1, res.Placements)
+}
+
+func TestRunRepricesTheRemainder(t *testing.T) {
+	ex := &fakeExchange{bid: 49990, ask: 50000, fills: []string{"0.004"}}
+	res := run(t, ex, "Sell", Limits{RepriceEvery: 5 * time.Second, MaxReprices: 1, Patience: time.Minute})
+
+	require.Equal(t, []placement{{"se-1-p0", "0.01", "50000"}, {"se-1-p1", "0.006", "50000"}}, ex.placed)
+	require.Equal(t, []string{"se-1-p0", "se-1-p1"}, ex.cancels)
+	require.Equal(t, "0.004", res.Filled.String())
+	require.Equal(t, "200", res.FilledValue.String())
+	require.Equal(t, "0.006", res.Remaining.String())
+}
+
+func TestRunStopsAtPatience(t *testing.T) {
+	ex := &fakeExchange{bid: 49990, ask: 50000}
+	res := run(t, ex, "Buy", Limits{RepriceEvery: 5 * time.Second, MaxReprices: 10, Patience: 12 * time.Second})
+
+	require.Len(t, ex.placed, 3)
+	require.Equal(t, "0.01", res.Remaining.String())
+}
+
+func TestRunRetriesRefusedPlacements(t *testing.T) {
+	ex := &fakeExchange{bid: 49990, ask: 50000, refuse: 1, fills: []string{"0.01"}}
+	res := run(t, ex, "Buy", Limits{RepriceEvery: time.Second, MaxReprices: 2, Patience: time.Minute})
+
+	require.Equal(t, []placement{{"se-1-p1", "0.01", "49990"}}, ex.placed)
+	require.True(t, res.Remaining.IsZero())
+}
+
+func TestRunDisabled(t *testing.T) {
+	ex := &fakeExchange{bid: 49990, ask: 50000}
+	res := run(t, ex, "Buy", Limits{})
+
+	require.Empty(t, ex.placed)
+	require.Equal(t, "0.01", res.Remaining.String())
+}
@@ -0,0 +1,27 @@
+package mapper
+
+import (
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+// ParseDecimalSafe parses an exchange numeric string without going through
+// float64, so prices and quantities stored on model.Order keep the exact
+// digits the exchange sent. Empty or invalid values are logged and default
+// to zero, like the float parsing of the mappers.
+func ParseDecimalSafe(field, v string) decimal.Decimal {
+	if v == "" {
+		logger.WithField("field", field).Debug("Empty numeric field received, defaulting to 0")
+		return decimal.Zero
+	}
+
+	d, err := decimal.NewFromString(v)
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"field": field,
+			"value": v,
+		}).WithError(err).Error("Failed to parse decimal from exchange response field; defaulting to 0")
+		return decimal.Zero
+	}
+	return d
+}
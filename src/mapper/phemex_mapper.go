@@ -9,9 +9,47 @@ import (
 	"strategyexecutor/src/model"
 )
 
+// conditionalOrderTypes are the Phemex order types that rest until a trigger
+// price is reached.
+var conditionalOrderTypes = map[string]bool{
+	"Stop":            true,
+	"StopLimit":       true,
+	"MarketIfTouched": true,
+	"LimitIfTouched":  true,
+}
+
+// ClassifyPhemexResponse tells which payload shape resp is: a fill carries an
+// execution id, an amend answer a replace exec status, and a conditional
+// order a stop order type or an untriggered status. Anything else is the
+// answer to a plain order placement.
+func ClassifyPhemexResponse(resp *model.PhemexOrderResponse) model.PhemexPayloadKind {
+	switch {
+	case resp.ExecID != "":
+		return model.PhemexPayloadFill
+	case resp.ExecStatus == "Replaced" || resp.ExecStatus == "PendingReplace":
+		return model.PhemexPayloadAmend
+	case conditionalOrderTypes[phemexOrderType(resp)] || resp.OrdStatus == "Untriggered":
+		return model.PhemexPayloadConditional
+	default:
+		return model.PhemexPayloadOrder
+	}
+}
+
+// phemexOrderType returns the order type of resp whichever key carried it.
+func phemexOrderType(resp *model.PhemexOrderResponse) string {
+	if resp.OrderType != "" {
+		return resp.OrderType
+	}
+	return resp.OrdType
+}
+
 // MapPhemexResponseToModel converts a raw Phemex API response into a database model
 // in a "safe" way: parsing errors on numeric fields are logged and defaulted to 0,
 // instead of aborting the whole mapping.
+//
+// Order placements, conditional orders, amend answers and fill notifications
+// are all accepted; the shape found is recorded on PhemexOrder.Kind, see
+// ClassifyPhemexResponse.
 func MapPhemexResponseToModel(
 	resp *model.PhemexOrderResponse,
 	internalOrderID uint,
@@ -23,12 +61,15 @@ func MapPhemexResponseToModel(
 		return nil, nil
 	}
 
+	kind := ClassifyPhemexResponse(resp)
+
 	logger.WithFields(map[string]interface{}{
 		"mapper":           "MapPhemexResponseToModel",
 		"internal_orderID": internalOrderID,
 		"exchange_orderID": resp.OrderID,
 		"symbol":           resp.Symbol,
 		"side":             resp.Side,
+		"kind":             kind,
 	}).Debug("Safely mapping Phemex response to DB model")
 
 	parseFloatSafe := func(field, v string) float64 {
@@ -74,6 +115,13 @@ func MapPhemexResponseToModel(
 	slPrice := parseFloatSafe("SlPxRp", resp.SlPxRp)
 	tpPrice := parseFloatSafe("TpPxRp", resp.TpPxRp)
 
+	var execQty, execPrice, execFee float64
+	if kind == model.PhemexPayloadFill {
+		execQty = parseFloatSafe("ExecQtyRq", resp.ExecQtyRq)
+		execPrice = parseFloatSafe("ExecPriceRp", resp.ExecPriceRp)
+		execFee = parseFloatSafe("ExecFeeRv", resp.ExecFeeRv)
+	}
+
 	// Convert nanoseconds to time.Time (se vier 0, vira epoch)
 	actionTime := time.Unix(0, resp.ActionTimeNs)
 	transactTime := time.Unix(0, resp.TransactTimeNs)
@@ -85,10 +133,15 @@ func MapPhemexResponseToModel(
 		Symbol:          resp.Symbol,
 		Side:            resp.Side,
 
+		Kind:           kind,
+		PosSide:        resp.PosSide,
+		ReduceOnly:     resp.ReduceOnly,
+		CloseOnTrigger: resp.CloseOnTrigger,
+
 		ActionTime:   actionTime,
 		TransactTime: transactTime,
 
-		OrderType:   resp.OrderType,
+		OrderType:   phemexOrderType(resp),
 		Price:       price,
 		OrderQty:    orderQty,
 		DisplayQty:  displayQty,
@@ -103,6 +156,12 @@ func MapPhemexResponseToModel(
 		LeavesQty:   leavesQty,
 		LeavesValue: leavesValue,
 
+		ExecID:      resp.ExecID,
+		ExecQty:     execQty,
+		ExecPrice:   execPrice,
+		Fee:         execFee,
+		FeeCurrency: resp.FeeCurrency,
+
 		StopDirection: resp.StopDirection,
 		StopPrice:     stopPrice,
 		Trigger:       resp.Trigger,
@@ -127,6 +186,7 @@ func MapPhemexResponseToModel(
 		"exchange_orderID": resp.OrderID,
 		"symbol":           resp.Symbol,
 		"side":             resp.Side,
+		"kind":             kind,
 		"price":            price,
 		"order_qty":        orderQty,
 	}).Info("Phemex response safely mapped to model")
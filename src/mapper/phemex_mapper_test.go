@@ -0,0 +1,112 @@
+package mapper
+
+import (
+	"encoding/json"
+	"strategyexecutor/src/model"
+	"testing"
+)
+
+func TestMapPhemexResponseToModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		check   func(t *testing.T, o *model.PhemexOrder)
+	}{
+		{
+			name:    "order create",
+			payload: `{"orderID":"ex-1","clOrdID":"cl-1","symbol":"BTCUSDT","side":"Buy","posSide":"Long","orderType":"Market","priceRp":"50000","orderQtyRq":"0.002","cumQtyRq":"0.002","cumValueRv":"100","execStatus":"TakerFill","ordStatus":"Filled","transactTimeNs":1700000000000000000}`,
+			check: func(t *testing.T, o *model.PhemexOrder) {
+				if o.Kind != model.PhemexPayloadOrder || o.OrderType != "Market" || o.PosSide != "Long" {
+					t.Fatalf("unexpected order %+v", o)
+				}
+				if o.Price != 50000 || o.OrderQty != 0.002 || o.CumValue != 100 {
+					t.Fatalf("unexpected amounts %+v", o)
+				}
+				if o.TransactTime.UnixNano() != 1700000000000000000 {
+					t.Fatalf("unexpected transact time %v", o.TransactTime)
+				}
+			},
+		},
+		{
+			name:    "conditional stop",
+			payload: `{"orderID":"ex-2","symbol":"BTCUSDT","side":"Sell","posSide":"Long","ordType":"Stop","orderQtyRq":"0","stopPxRp":"48000","trigger":"ByMarkPrice","stopDirection":"Falling","ordStatus":"Untriggered","reduceOnly":true,"closeOnTrigger":true}`,
+			check: func(t *testing.T, o *model.PhemexOrder) {
+				if o.Kind != model.PhemexPayloadConditional || o.OrderType != "Stop" {
+					t.Fatalf("unexpected kind %q or type %q", o.Kind, o.OrderType)
+				}
+				if o.StopPrice != 48000 || o.Trigger != "ByMarkPrice" || o.StopDirection != "Falling" {
+					t.Fatalf("unexpected trigger %+v", o)
+				}
+				if !o.ReduceOnly || !o.CloseOnTrigger {
+					t.Fatalf("expected the hedge-mode flags, got %+v", o)
+				}
+			},
+		},
+		{
+			name:    "take profit untriggered",
+			payload: `{"orderID":"ex-3","symbol":"BTCUSDT","side":"Sell","orderType":"MarketIfTouched","stopPxRp":"55000","ordStatus":"Untriggered"}`,
+			check: func(t *testing.T, o *model.PhemexOrder) {
+				if o.Kind != model.PhemexPayloadConditional || o.StopPrice != 55000 {
+					t.Fatalf("unexpected take profit %+v", o)
+				}
+			},
+		},
+		{
+			name:    "amend",
+			payload: `{"orderID":"ex-4","clOrdID":"cl-4","symbol":"BTCUSDT","side":"Buy","orderType":"Limit","priceRp":"49500","orderQtyRq":"0.003","leavesQtyRq":"0.003","execStatus":"Replaced","ordStatus":"New"}`,
+			check: func(t *testing.T, o *model.PhemexOrder) {
+				if o.Kind != model.PhemexPayloadAmend || o.Price != 49500 || o.LeavesQty != 0.003 {
+					t.Fatalf("unexpected amend %+v", o)
+				}
+			},
+		},
+		{
+			name:    "fill notification",
+			payload: `{"orderID":"ex-5","clOrdID":"cl-5","symbol":"BTCUSDT","side":"Buy","ordType":"Limit","priceRp":"50000","orderQtyRq":"0.004","execID":"fill-1","execQtyRq":"0.001","execPriceRp":"49990.5","execFeeRv":"0.03","feeCurrency":"USDT","cumQtyRq":"0.001","leavesQtyRq":"0.003","execStatus":"MakerFill","ordStatus":"PartiallyFilled"}`,
+			check: func(t *testing.T, o *model.PhemexOrder) {
+				if o.Kind != model.PhemexPayloadFill || o.ExecID != "fill-1" || o.OrderType != "Limit" {
+					t.Fatalf("unexpected fill %+v", o)
+				}
+				if o.ExecQty != 0.001 || o.ExecPrice != 49990.5 || o.Fee != 0.03 || o.FeeCurrency != "USDT" {
+					t.Fatalf("unexpected execution %+v", o)
+				}
+				if o.CumQty != 0.001 || o.LeavesQty != 0.003 {
+					t.Fatalf("unexpected progress %+v", o)
+				}
+			},
+		},
+		{
+			name:    "invalid numbers default to zero",
+			payload: `{"orderID":"ex-6","symbol":"BTCUSDT","orderType":"Market","priceRp":"n/a","orderQtyRq":""}`,
+			check: func(t *testing.T, o *model.PhemexOrder) {
+				if o.Kind != model.PhemexPayloadOrder || o.Price != 0 || o.OrderQty != 0 {
+					t.Fatalf("expected zero amounts, got %+v", o)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp model.PhemexOrderResponse
+			if err := json.Unmarshal([]byte(tt.payload), &resp); err != nil {
+				t.Fatalf("decode payload: %v", err)
+			}
+			o, err := MapPhemexResponseToModel(&resp, 42)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if o.OrderID != 42 || o.ExchangeOrderID != resp.OrderID {
+				t.Fatalf("unexpected identifiers %+v", o)
+			}
+			tt.check(t, o)
+		})
+	}
+}
+
+func TestMapPhemexResponseToModelNil(t *testing.T) {
+	o, err := MapPhemexResponseToModel(nil, 1)
+	if o != nil || err != nil {
+		t.Fatalf("expected nothing for a nil response, got %+v, %v", o, err)
+	}
+}
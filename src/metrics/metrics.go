@@ -0,0 +1,76 @@
+// Package metrics serves process metrics in the Prometheus text exposition
+// format. Collectors are plain functions called on every scrape, so values
+// are always read from their source instead of being mirrored in counters.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Collector writes its current samples to w.
+type Collector func(w *Writer)
+
+// Labels are the label pairs of a sample.
+type Labels map[string]string
+
+// Writer accumulates samples for one scrape. Samples of the same metric must
+// be written consecutively; HELP and TYPE are emitted once per metric.
+type Writer struct {
+	buf  bytes.Buffer
+	seen map[string]bool
+}
+
+// Gauge writes a sample of a value that can go up and down.
+func (w *Writer) Gauge(name, help string, labels Labels, value float64) {
+	w.sample(name, "gauge", help, labels, value)
+}
+
+// Counter writes a sample of a monotonically increasing value.
+func (w *Writer) Counter(name, help string, labels Labels, value float64) {
+	w.sample(name, "counter", help, labels, value)
+}
+
+func (w *Writer) sample(name, kind, help string, labels Labels, value float64) {
+	if w.seen == nil {
+		w.seen = make(map[string]bool)
+	}
+	if !w.seen[name] {
+		w.seen[name] = true
+		fmt.Fprintf(&w.buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = k + "=" + strconv.Quote(labels[k])
+		}
+		w.buf.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	w.buf.WriteString(" " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+}
+
+// Handler serves GET /metrics from the given collectors.
+func Handler(collectors ...Collector) http.HandlerFunc {
+	return func(rw http.ResponseWriter, _ *http.Request) {
+		var w Writer
+		for _, c := range collectors {
+			c(&w)
+		}
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if _, err := rw.Write(w.buf.Bytes()); err != nil {
+			logger.WithError(err).Error("failed to write metrics")
+		}
+	}
+}
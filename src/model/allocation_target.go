@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AllocationTarget is the share of a user's holdings of Asset that should sit
+// on an exchange. The rebalance command compares the targets of a user with
+// the balances reported by each exchange.
+type AllocationTarget struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	UserID     uint   `gorm:"not null;uniqueIndex:ux_allocation_target,priority:1" json:"user_id"`
+	ExchangeID uint   `gorm:"not null;uniqueIndex:ux_allocation_target,priority:2" json:"exchange_id"`
+	Asset      string `gorm:"size:20;not null;uniqueIndex:ux_allocation_target,priority:3" json:"asset"`
+	// TargetPct is the percent of the user's Asset, summed over the exchanges
+	// with a target for it, to hold on ExchangeID.
+	TargetPct decimal.Decimal `gorm:"column:target_pct;type:double precision;not null" json:"target_pct"`
+
+	Exchange *Exchange `gorm:"constraint:OnDelete:CASCADE" json:"exchange,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (AllocationTarget) TableName() string {
+	return "allocation_targets"
+}
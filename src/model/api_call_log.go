@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// APICallLog is an order-mutating exchange call captured for an account
+// with UserExchange.CaptureAPICalls set. Query and bodies are redacted.
+type APICallLog struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	UserID     uint   `gorm:"not null;index:idx_api_call_log_user_exchange" json:"user_id"`
+	ExchangeID uint   `gorm:"not null;index:idx_api_call_log_user_exchange" json:"exchange_id"`
+	Exchange   string `gorm:"size:20;not null" json:"exchange"`
+
+	Method       string `gorm:"size:10;not null" json:"method"`
+	Path         string `gorm:"size:255;not null" json:"path"`
+	Query        string `gorm:"type:text" json:"query,omitempty"`
+	RequestBody  string `gorm:"column:request_body;type:text" json:"request_body,omitempty"`
+	Status       int    `json:"status"` // 0 when no response was received
+	ResponseBody string `gorm:"column:response_body;type:text" json:"response_body,omitempty"`
+	LatencyMs    int64  `gorm:"column:latency_ms" json:"latency_ms"`
+	Error        string `gorm:"type:text" json:"error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (APICallLog) TableName() string {
+	return "api_call_log"
+}
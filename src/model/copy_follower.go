@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CopyFollower mirrors the filled orders of a leader account to a follower
+// account on the same exchange (see src/copytrade). Entries are sized in
+// proportion to the equity of both accounts, scaled by Multiplier and
+// bounded by the caps; exits close the follower position.
+type CopyFollower struct {
+	ID                 uint `gorm:"primaryKey" json:"id"`
+	LeaderUserID       uint `gorm:"not null;uniqueIndex:ux_copy_follower;index:idx_copy_follower_leader" json:"leader_user_id"`
+	LeaderExchangeID   uint `gorm:"not null;uniqueIndex:ux_copy_follower;index:idx_copy_follower_leader" json:"leader_exchange_id"`
+	FollowerUserID     uint `gorm:"not null;uniqueIndex:ux_copy_follower" json:"follower_user_id"`
+	FollowerExchangeID uint `gorm:"not null;uniqueIndex:ux_copy_follower" json:"follower_exchange_id"`
+	Enabled            bool `gorm:"not null;default:true" json:"enabled"`
+
+	// Multiplier scales the equity-proportional size, 1 copies the leader
+	// risk as is.
+	Multiplier decimal.Decimal `gorm:"type:decimal;not null;default:1" json:"multiplier"`
+	// MaxOrderNotional caps the notional of one copied entry, in quote
+	// currency; zero means no cap.
+	MaxOrderNotional decimal.Decimal `gorm:"type:decimal;not null;default:0" json:"max_order_notional"`
+	// MaxPositionNotional caps the notional of the follower position a
+	// copied entry may grow; zero means no cap.
+	MaxPositionNotional decimal.Decimal `gorm:"type:decimal;not null;default:0" json:"max_position_notional"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (CopyFollower) TableName() string {
+	return "copy_followers"
+}
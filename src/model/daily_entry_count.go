@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// DailyEntryDayLayout formats the UTC day of a DailyEntryCount.
+const DailyEntryDayLayout = "2006-01-02"
+
+// DailyEntryCount is the number of entries sent for a user on a symbol
+// during one UTC day, across exchanges. New entries are blocked once it
+// reaches SignalFilterSetting.MaxDailyEntries.
+type DailyEntryCount struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:ux_daily_entry_user_symbol_day,priority:1" json:"user_id"`
+	Symbol    string    `gorm:"size:50;not null;uniqueIndex:ux_daily_entry_user_symbol_day,priority:2" json:"symbol"`
+	Day       string    `gorm:"size:10;not null;uniqueIndex:ux_daily_entry_user_symbol_day,priority:3" json:"day"`
+	Count     int       `gorm:"not null;default:0" json:"count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (DailyEntryCount) TableName() string {
+	return "daily_entry_counts"
+}
+
+// DailyEntryDay returns the UTC day t falls on, as stored in
+// DailyEntryCount.Day.
+func DailyEntryDay(t time.Time) string {
+	return t.UTC().Format(DailyEntryDayLayout)
+}
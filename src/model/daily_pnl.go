@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DailyPnL is the per user / exchange / day trading summary produced by the
+// pnl_report job.
+type DailyPnL struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:ux_daily_pnl_user_exchange_day,priority:1" json:"user_id"`
+	ExchangeID uint      `gorm:"not null;uniqueIndex:ux_daily_pnl_user_exchange_day,priority:2" json:"exchange_id"`
+	Day        time.Time `gorm:"type:date;not null;uniqueIndex:ux_daily_pnl_user_exchange_day,priority:3" json:"day"`
+
+	RealizedPnL   decimal.Decimal `gorm:"column:realized_pnl;type:double precision;not null" json:"realized_pnl"`
+	UnrealizedPnL decimal.Decimal `gorm:"column:unrealized_pnl;type:double precision;not null" json:"unrealized_pnl"`
+	Fees          decimal.Decimal `gorm:"column:fees;type:double precision;not null" json:"fees"`
+	NetPnL        decimal.Decimal `gorm:"column:net_pnl;type:double precision;not null" json:"net_pnl"`
+	MaxDrawdown   decimal.Decimal `gorm:"column:max_drawdown;type:double precision;not null" json:"max_drawdown"`
+
+	Fills   int     `gorm:"column:fills" json:"fills"`
+	Wins    int     `gorm:"column:wins" json:"wins"`
+	Losses  int     `gorm:"column:losses" json:"losses"`
+	WinRate float64 `gorm:"column:win_rate" json:"win_rate"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (DailyPnL) TableName() string {
+	return "daily_pnl"
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// EquitySnapshot is the equity of a user across the snapshotted exchanges at
+// TakenAt: wallet balance plus unrealized PnL of the open positions.
+type EquitySnapshot struct {
+	ID      uint      `gorm:"primaryKey" json:"id"`
+	UserID  uint      `gorm:"not null;uniqueIndex:ux_equity_snapshot_user_taken,priority:1" json:"user_id"`
+	TakenAt time.Time `gorm:"not null;uniqueIndex:ux_equity_snapshot_user_taken,priority:2" json:"taken_at"`
+
+	Balance       decimal.Decimal `gorm:"type:double precision;not null" json:"balance"`
+	UnrealizedPnL decimal.Decimal `gorm:"column:unrealized_pnl;type:double precision;not null" json:"unrealized_pnl"`
+	Equity        decimal.Decimal `gorm:"type:double precision;not null" json:"equity"`
+	// Exchanges is the number of accounts summed into the snapshot.
+	Exchanges int `gorm:"not null" json:"exchanges"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (EquitySnapshot) TableName() string {
+	return "equity_snapshots"
+}
@@ -16,12 +16,44 @@ type Exception struct {
 	Message string `gorm:"type:text" json:"message"` // err.Error()
 	Stack   string `gorm:"type:text" json:"stack"`   // stack trace (optional)
 
+	// Exchange error details with the operator hint, set when the error came
+	// from an exchange (see connectors.HintFor)
+	Exchange  string `gorm:"size:30;index" json:"exchange,omitempty"`
+	ErrorCode string `gorm:"size:50" json:"error_code,omitempty"`
+	Hint      string `gorm:"type:text" json:"hint,omitempty"`
+	Action    string `gorm:"type:text" json:"action,omitempty"`
+
 	// Severity level
 	Level string `gorm:"size:20;index" json:"level"` // debug | info | warn | error | fatal
 
+	// Severity and Category drive the routing of the exception, critical
+	// ones are notified to the user right away (see controller.Capture).
+	Severity string `gorm:"size:10;index" json:"severity,omitempty"`
+	Category string `gorm:"size:20;index" json:"category,omitempty"`
+
 	// Extra context stored as JSON (optional)
 	Context string `gorm:"type:jsonb" json:"context,omitempty"`
 
 	// Audit info
 	CreatedAt time.Time `json:"created_at"`
 }
+
+const (
+	ExceptionSeverityInfo     = "info"
+	ExceptionSeverityWarn     = "warn"
+	ExceptionSeverityCritical = "critical"
+)
+
+const (
+	// ExceptionCategoryAuth is an exchange rejecting the account credentials.
+	ExceptionCategoryAuth = "auth"
+	// ExceptionCategoryPosition is a position left exposed: it could not be
+	// closed or has no active stop.
+	ExceptionCategoryPosition = "position"
+	// ExceptionCategoryExchange is any other error answered by an exchange.
+	ExceptionCategoryExchange = "exchange"
+	// ExceptionCategoryDrift is an exchange position that differs from the
+	// one the filled orders expect.
+	ExceptionCategoryDrift    = "drift"
+	ExceptionCategoryInternal = "internal"
+)
@@ -3,4 +3,61 @@ package model
 type Exchange struct {
 	ID   uint   `gorm:"primaryKey" json:"id"`
 	Name string `gorm:"uniqueIndex;not null" json:"name"`
+
+	// Capabilities of the exchange API, seeded for the known exchanges and
+	// editable through the admin API. Controllers gate behaviour on them
+	// with Supports.
+	SupportsHedge     bool `gorm:"column:supports_hedge;not null;default:false" json:"supports_hedge"`
+	SupportsStopOrder bool `gorm:"column:supports_stop_order;not null;default:false" json:"supports_stop_order"`
+	Futures           bool `gorm:"column:futures;not null;default:false" json:"futures"`
+	Spot              bool `gorm:"column:spot;not null;default:false" json:"spot"`
+	// SupportsNativeBracket is attaching the stop loss and take profit to
+	// the entry, so the exchange protects the position from the fill on
+	// whether or not the executor is up to place the exits.
+	SupportsNativeBracket bool `gorm:"column:supports_native_bracket;not null;default:false" json:"supports_native_bracket"`
+}
+
+// ExchangeCapability is a feature an exchange API may offer.
+type ExchangeCapability string
+
+const (
+	// CapabilityHedge is holding long and short positions of a symbol apart.
+	CapabilityHedge ExchangeCapability = "hedge"
+	// CapabilityStopOrder is resting stop loss and take profit orders.
+	CapabilityStopOrder ExchangeCapability = "stop_order"
+	CapabilityFutures   ExchangeCapability = "futures"
+	CapabilitySpot      ExchangeCapability = "spot"
+	// CapabilityNativeBracket is stop loss and take profit held by the
+	// exchange from the entry on, see Exchange.SupportsNativeBracket.
+	CapabilityNativeBracket ExchangeCapability = "native_bracket"
+)
+
+// Supports reports whether e offers c.
+func (e *Exchange) Supports(c ExchangeCapability) bool {
+	switch c {
+	case CapabilityHedge:
+		return e.SupportsHedge
+	case CapabilityStopOrder:
+		return e.SupportsStopOrder
+	case CapabilityNativeBracket:
+		return e.SupportsNativeBracket
+	case CapabilityFutures:
+		return e.Futures
+	case CapabilitySpot:
+		return e.Spot
+	default:
+		return false
+	}
+}
+
+// KnownExchanges returns the exchanges the executor has connectors for, with
+// their capabilities. New connectors add their exchange here to have it
+// seeded on the next start.
+func KnownExchanges() []Exchange {
+	return []Exchange{
+		{Name: "phemex", SupportsHedge: true, SupportsStopOrder: true, SupportsNativeBracket: true, Futures: true, Spot: true},
+		{Name: "kucoin", SupportsStopOrder: true, Futures: true, Spot: true},
+		{Name: "kraken", SupportsStopOrder: true, Futures: true},
+		{Name: "hydra", SupportsStopOrder: true},
+	}
 }
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// FeatureFlag overrides the default of a feature flag, globally when UserID
+// is 0 or for one user. A user row wins over the global row.
+type FeatureFlag struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	Key     string `gorm:"size:64;not null;uniqueIndex:ux_feature_flags_key_user,priority:1" json:"key"`
+	UserID  uint   `gorm:"not null;default:0;uniqueIndex:ux_feature_flags_key_user,priority:2" json:"user_id"`
+	Enabled bool   `gorm:"not null" json:"enabled"`
+	// Note records why the flag was flipped.
+	Note string `gorm:"size:255" json:"note"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (FeatureFlag) TableName() string {
+	return "feature_flags"
+}
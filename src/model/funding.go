@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FundingRate stores a funding-rate snapshot for a perpetual contract.
+type FundingRate struct {
+	ID                   uint            `gorm:"primaryKey" json:"id"`
+	Exchange             string          `json:"exchange" gorm:"type:varchar(50);not null;uniqueIndex:ux_funding_rate_exchange_symbol_datetime,priority:1"`
+	Symbol               string          `json:"symbol" gorm:"type:varchar(50);not null;uniqueIndex:ux_funding_rate_exchange_symbol_datetime,priority:2"`
+	Datetime             time.Time       `json:"datetime" gorm:"not null;uniqueIndex:ux_funding_rate_exchange_symbol_datetime,priority:3;index:idx_funding_rate_datetime"`
+	FundingRate          decimal.Decimal `json:"funding_rate" gorm:"type:double precision;not null"`
+	PredictedFundingRate decimal.Decimal `json:"predicted_funding_rate" gorm:"type:double precision;not null"`
+	NextFundingTime      time.Time       `json:"next_funding_time" gorm:"not null"`
+	MarkPrice            decimal.Decimal `json:"mark_price" gorm:"type:double precision;not null"`
+	CreatedAt            time.Time       `json:"created_at"`
+}
+
+func (FundingRate) TableName() string {
+	return "funding_rate"
+}
+
+// OpenInterest stores an open-interest snapshot for a perpetual contract.
+type OpenInterest struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	Exchange     string          `json:"exchange" gorm:"type:varchar(50);not null;uniqueIndex:ux_open_interest_exchange_symbol_datetime,priority:1"`
+	Symbol       string          `json:"symbol" gorm:"type:varchar(50);not null;uniqueIndex:ux_open_interest_exchange_symbol_datetime,priority:2"`
+	Datetime     time.Time       `json:"datetime" gorm:"not null;uniqueIndex:ux_open_interest_exchange_symbol_datetime,priority:3;index:idx_open_interest_datetime"`
+	OpenInterest decimal.Decimal `json:"open_interest" gorm:"type:double precision;not null"`
+	MarkPrice    decimal.Decimal `json:"mark_price" gorm:"type:double precision;not null"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+func (OpenInterest) TableName() string {
+	return "open_interest"
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// LossStreak is the persisted consecutive-loss state of a user on an
+// exchange/symbol, refreshed from the trade journal. New entries are blocked
+// while CooldownUntil is in the future.
+type LossStreak struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	UserID            uint       `gorm:"not null;uniqueIndex:ux_loss_streak_user_exchange_symbol,priority:1" json:"user_id"`
+	ExchangeID        uint       `gorm:"not null;uniqueIndex:ux_loss_streak_user_exchange_symbol,priority:2" json:"exchange_id"`
+	Symbol            string     `gorm:"size:50;not null;uniqueIndex:ux_loss_streak_user_exchange_symbol,priority:3" json:"symbol"`
+	ConsecutiveLosses int        `gorm:"column:consecutive_losses" json:"consecutive_losses"`
+	LastTradeID       uint       `gorm:"column:last_trade_id" json:"last_trade_id"`
+	LastLossAt        *time.Time `gorm:"column:last_loss_at" json:"last_loss_at,omitempty"`
+	CooldownUntil     *time.Time `gorm:"column:cooldown_until" json:"cooldown_until,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+func (LossStreak) TableName() string {
+	return "loss_streaks"
+}
+
+// InCooldown reports whether entries are blocked at now.
+func (s LossStreak) InCooldown(now time.Time) bool {
+	return s.CooldownUntil != nil && now.Before(*s.CooldownUntil)
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Sources of a MaintenanceWindow.
+const (
+	MaintenanceSourceManual     = "manual"
+	MaintenanceSourceStatusPage = "status_page"
+)
+
+// MaintenanceWindow is a period during which an exchange does not take
+// orders, entered by an operator or read from the status page of the
+// exchange. The executors of the exchange skip their ticks inside it.
+type MaintenanceWindow struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ExchangeID uint      `gorm:"not null;index:idx_maintenance_window_exchange,priority:1" json:"exchange_id"`
+	StartsAt   time.Time `gorm:"not null;index:idx_maintenance_window_exchange,priority:2" json:"starts_at"`
+	EndsAt     time.Time `gorm:"not null" json:"ends_at"`
+	Source     string    `gorm:"size:20;not null" json:"source"`
+	// ExternalID is the id of the maintenance on the status page, empty
+	// for manual windows.
+	ExternalID string    `gorm:"size:100;not null;default:'';index" json:"external_id,omitempty"`
+	Title      string    `gorm:"size:255" json:"title,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (MaintenanceWindow) TableName() string {
+	return "maintenance_windows"
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// UserNotificationSetting holds the notification channels of a user and which
+// event types should be delivered. A channel is active when its target
+// (chat id, webhook, email) is set.
+type UserNotificationSetting struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"not null;uniqueIndex" json:"user_id"`
+
+	TelegramChatID    string `gorm:"column:telegram_chat_id;size:100" json:"telegram_chat_id"`
+	DiscordWebhookURL string `gorm:"column:discord_webhook_url;size:512" json:"-"`
+	SlackWebhookURL   string `gorm:"column:slack_webhook_url;size:512" json:"-"`
+	Email             string `gorm:"column:email;size:255" json:"email"`
+
+	NotifyOrderPlaced   bool `gorm:"column:notify_order_placed" json:"notify_order_placed"`
+	NotifyOrderFilled   bool `gorm:"column:notify_order_filled" json:"notify_order_filled"`
+	NotifyOrderError    bool `gorm:"column:notify_order_error" json:"notify_order_error"`
+	NotifyStopLossMoved bool `gorm:"column:notify_stop_loss_moved" json:"notify_stop_loss_moved"`
+	NotifyKillSwitch    bool `gorm:"column:notify_kill_switch" json:"notify_kill_switch"`
+	NotifyExecutorCrash bool `gorm:"column:notify_executor_crash" json:"notify_executor_crash"`
+	NotifyDailyPnL      bool `gorm:"column:notify_daily_pnl" json:"notify_daily_pnl"`
+	NotifyLossCooldown  bool `gorm:"column:notify_loss_cooldown" json:"notify_loss_cooldown"`
+	NotifyKeyInvalid    bool `gorm:"column:notify_key_invalid" json:"notify_key_invalid"`
+	NotifyCriticalError bool `gorm:"column:notify_critical_error" json:"notify_critical_error"`
+	// NotifyPriceDivergence reports entries skipped or sent while the venue
+	// price diverged from the reference.
+	NotifyPriceDivergence bool `gorm:"column:notify_price_divergence;default:true" json:"notify_price_divergence"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (UserNotificationSetting) TableName() string {
+	return "user_notification_settings"
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Steps of an Onboarding, in the order a new user goes through them.
+const (
+	OnboardingStepKeys   = "keys"
+	OnboardingStepPreset = "preset"
+	OnboardingStepPaper  = "paper"
+	OnboardingStepDone   = "done"
+)
+
+// Onboarding is the progress of a new user through the onboarding flow:
+// exchange keys validated live, a symbol, strategy and risk profile chosen
+// from the presets, then a paper trade on a shadow account before the
+// account may run live on the server.
+type Onboarding struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	UserID     uint   `gorm:"not null;uniqueIndex" json:"user_id"`
+	Step       string `gorm:"size:10;not null;default:keys" json:"step"`
+	ExchangeID uint   `gorm:"not null;default:0" json:"exchange_id,omitempty"`
+	// The presets chosen by the user.
+	Symbol      string `gorm:"size:50" json:"symbol,omitempty"`
+	Strategy    string `gorm:"size:100" json:"strategy,omitempty"`
+	RiskProfile string `gorm:"column:risk_profile;size:20" json:"risk_profile,omitempty"`
+	// PaperStartedAt is when the account started trading on paper; only
+	// paper fills after it count towards going live.
+	PaperStartedAt *time.Time `json:"paper_started_at,omitempty"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (Onboarding) TableName() string {
+	return "onboardings"
+}
@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 const (
 	OrderDirectionEntry = "entry"
@@ -11,22 +15,44 @@ const (
 type Order struct {
 	ID uint `gorm:"primaryKey" json:"id"`
 	//StrategyActionID *uint `gorm:"index" json:"strategy_action_id"`
-	//StrategyID         *uint      `gorm:"index" json:"strategy_id"`
+	StrategyID   *uint  `gorm:"index" json:"strategy_id,omitempty"`
 	UserID       uint   `gorm:"index" json:"user_id"`
 	LegacyUserID string `gorm:"size:60;column:legacy_user_id" json:"legacy_user_id,omitempty"`
 	ExchangeID   uint   `gorm:"index" json:"exchange_id"`
 	//ExchangeResp  string   `json:"exchange_resp,omitempty"` dropped from db
-	ExternalID    uint     `gorm:"index" json:"external_id"`
-	Symbol        string   `json:"symbol"`
-	Side          string   `json:"side"`
-	PosSide       string   `json:"pos_side"`
-	OrderType     string   `json:"order_type"`
-	Quantity      float64  `json:"quantity"`
-	Price         *float64 `json:"price,omitempty"`
-	StopLossPct   float64  `json:"stop_loss_pct"`
-	TakeProfitPct float64  `json:"take_profit_pct"`
-	Status        string   `gorm:"size:50;not null;default:pending" json:"status"`
-	OrderDir      string   `gorm:"size:10;not null;" json:"order_dir"` //entry , exit
+	ExternalID    uint             `gorm:"index" json:"external_id"`
+	Symbol        string           `json:"symbol"`
+	Side          string           `json:"side"`
+	PosSide       string           `json:"pos_side"`
+	OrderType     string           `json:"order_type"`
+	Quantity      decimal.Decimal  `gorm:"type:decimal" json:"quantity"`
+	Price         *decimal.Decimal `gorm:"type:decimal" json:"price,omitempty"`
+	StopLossPct   decimal.Decimal  `gorm:"type:decimal" json:"stop_loss_pct"`
+	TakeProfitPct decimal.Decimal  `gorm:"type:decimal" json:"take_profit_pct"`
+	Status        string           `gorm:"size:50;not null;default:pending" json:"status"`
+	OrderDir      string           `gorm:"size:10;not null;" json:"order_dir"` //entry , exit
+
+	// InitialRisk is what an entry loses if stopped out at the stop it was
+	// opened with: |entry price - stop| * quantity, in quote currency. Nil
+	// when the entry had no stop.
+	InitialRisk *float64 `gorm:"column:initial_risk" json:"initial_risk,omitempty"`
+
+	// Fee is estimated from the exchange fee rate when the order is placed
+	// (FeeEstimated) and replaced by the sum of the fill fees once known.
+	Fee          float64 `json:"fee"`
+	FeeCurrency  string  `gorm:"size:20" json:"fee_currency,omitempty"`
+	FeeEstimated bool    `gorm:"not null;default:false" json:"fee_estimated"`
+
+	// StopOrderID and TakeProfitOrderID are the exchange IDs of the
+	// protective orders resting for an entry, so a restarted executor can
+	// tell its own orders from orphans (see controller.ReloadBrackets).
+	StopOrderID       string `gorm:"size:64" json:"stop_order_id,omitempty"`
+	TakeProfitOrderID string `gorm:"size:64" json:"take_profit_order_id,omitempty"`
+
+	// StaggerMs is how long the executor waited after the signal before
+	// acting on it, see UserExchange.ExecutionPriority.
+	StaggerMs int64 `gorm:"not null;default:0" json:"stagger_ms,omitempty"`
+
 	//TriggeredByAlertID *uint      `json:"triggered_by_alert_id,omitempty"`
 	ExecutedAt *time.Time `json:"executed_at,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
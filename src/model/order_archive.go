@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// ArchivedOrder is an order moved out of the live tables by the archive job.
+// The order and every row hanging off it are kept as one JSON document, so
+// the archive does not need to follow schema changes of the live tables. The
+// columns used to look archived orders up are copied out of the document.
+type ArchivedOrder struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrderID        uint      `gorm:"not null;uniqueIndex" json:"order_id"`
+	UserID         uint      `gorm:"not null;index:idx_order_archives_user_created,priority:1" json:"user_id"`
+	ExchangeID     uint      `gorm:"not null" json:"exchange_id"`
+	ExternalID     uint      `gorm:"index" json:"external_id"`
+	Status         string    `gorm:"size:50" json:"status"`
+	OrderCreatedAt time.Time `gorm:"not null;index;index:idx_order_archives_user_created,priority:2" json:"order_created_at"`
+	Payload        string    `gorm:"type:jsonb;not null" json:"payload"` // OrderArchivePayload
+	ArchivedAt     time.Time `gorm:"not null" json:"archived_at"`
+}
+
+func (ArchivedOrder) TableName() string {
+	return "order_archives"
+}
+
+// OrderArchivePayload is the document stored in ArchivedOrder.Payload. Order
+// carries its status log in Order.Logs.
+type OrderArchivePayload struct {
+	Order         Order               `json:"order"`
+	ExecutionLogs []OrderExecutionLog `json:"execution_logs,omitempty"`
+	PhemexOrders  []PhemexOrder       `json:"phemex_orders,omitempty"`
+	Intents       []OrderIntent       `json:"intents,omitempty"`
+}
@@ -1,7 +1,11 @@
 // model/order_execution_log.go
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // OrderExecutionStatus constants represent the lifecycle of an order execution.
 // You can adjust these values to fit exactly your domain.
@@ -15,6 +19,18 @@ const (
 	//OrderExecutionStatusCanceled   = "canceled"
 	OrderExecutionStatusError         = "error"
 	OrderExecutionStatusCanceledError = "canceled_error"
+	// OrderExecutionStatusFiltered marks entries blocked by the signal filter
+	// chain; they never reach the exchange.
+	OrderExecutionStatusFiltered = "filtered"
+	// OrderExecutionStatusAwaitingApproval marks entries above the approval
+	// notional of the account; they wait for OrderExecutionStatusApproved or
+	// OrderExecutionStatusRejected before reaching the exchange.
+	OrderExecutionStatusAwaitingApproval = "awaiting_approval"
+	OrderExecutionStatusApproved         = "approved"
+	OrderExecutionStatusRejected         = "rejected"
+	// OrderExecutionStatusLiquidated marks entries the exchange closed by a
+	// liquidation or an auto-deleveraging, and the exits recorded for them.
+	OrderExecutionStatusLiquidated = "liquidated"
 )
 
 // OrderExecutionLog stores the detailed history of each interaction with the exchange
@@ -63,19 +79,20 @@ type OrderLog struct {
 	OrderID uint   `gorm:"index" json:"order_id"`
 	Order   *Order `gorm:"constraint:OnDelete:CASCADE" json:"order,omitempty"`
 	// Snapshot of the order at the moment of this log entry
-	Symbol        string   `gorm:"size:100" json:"symbol"`
-	Side          string   `gorm:"size:20" json:"side"`
-	PosSide       string   `json:"pos_side"`
-	OrderType     string   `gorm:"size:50" json:"order_type"`
-	Quantity      float64  `json:"quantity"`
-	StopLossPct   float64  `json:"stop_loss_pct"`
-	TakeProfitPct float64  `json:"take_profit_pct"`
-	Price         *float64 `json:"price,omitempty"`
+	Symbol        string           `gorm:"size:100" json:"symbol"`
+	Side          string           `gorm:"size:20" json:"side"`
+	PosSide       string           `json:"pos_side"`
+	OrderType     string           `gorm:"size:50" json:"order_type"`
+	Quantity      decimal.Decimal  `gorm:"type:decimal" json:"quantity"`
+	StopLossPct   decimal.Decimal  `gorm:"type:decimal" json:"stop_loss_pct"`
+	TakeProfitPct decimal.Decimal  `gorm:"type:decimal" json:"take_profit_pct"`
+	Price         *decimal.Decimal `gorm:"type:decimal" json:"price,omitempty"`
 
 	// Exchange-specific identifiers
 	ExchangeID uint `gorm:"index" json:"exchange_id"`
 	// Execution / conclusion details
 	Status    string    `gorm:"size:50;not null" json:"status"` // see OrderExecutionStatus* constants
+	Reason    string    `gorm:"type:text" json:"reason"`        // why the order reached this status
 	CreatedAt time.Time `json:"created_at"`                     // log creation
 }
 
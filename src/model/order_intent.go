@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+const (
+	// OrderIntentStatusPending: written with the order, not sent yet.
+	OrderIntentStatusPending = "pending"
+	// OrderIntentStatusDispatched: the exchange call started; its outcome is
+	// unknown until it returns or the outbox looks the order up.
+	OrderIntentStatusDispatched = "dispatched"
+	OrderIntentStatusDone       = "done"
+	OrderIntentStatusFailed     = "failed"
+	// OrderIntentStatusUnknown: the outcome could not be verified on the
+	// exchange and needs manual reconciliation.
+	OrderIntentStatusUnknown = "unknown"
+)
+
+// OrderIntent is the outbox row of an exchange call. It is inserted in the
+// same transaction as its Order, so an order whose call was interrupted by a
+// crash can be found and settled on the next run.
+type OrderIntent struct {
+	ID         uint `gorm:"primaryKey" json:"id"`
+	OrderID    uint `gorm:"not null;uniqueIndex" json:"order_id"`
+	UserID     uint `gorm:"not null;index:idx_order_intents_user_exchange_status,priority:1" json:"user_id"`
+	ExchangeID uint `gorm:"not null;index:idx_order_intents_user_exchange_status,priority:2" json:"exchange_id"`
+	// ClientOrderID is sent as the exchange client order id so the order can
+	// be looked up when the call outcome is unknown.
+	ClientOrderID string `gorm:"size:40;not null;uniqueIndex" json:"client_order_id"`
+
+	Symbol     string `gorm:"size:50;not null" json:"symbol"`
+	Side       string `gorm:"size:10" json:"side"`
+	PosSide    string `gorm:"size:10" json:"pos_side"`
+	Quantity   string `gorm:"size:50" json:"quantity"`
+	OrderType  string `gorm:"size:20" json:"order_type"`
+	ReduceOnly bool   `json:"reduce_only"`
+	// Price turns the order into an ImmediateOrCancel limit at that price.
+	Price string `gorm:"size:50" json:"price,omitempty"`
+	// StopLossPrice and TakeProfitPrice are attached to the order for the
+	// exchange to hold, see model.CapabilityNativeBracket.
+	StopLossPrice   string `gorm:"size:50" json:"stop_loss_price,omitempty"`
+	TakeProfitPrice string `gorm:"size:50" json:"take_profit_price,omitempty"`
+
+	Status          string     `gorm:"size:20;not null;index:idx_order_intents_user_exchange_status,priority:3" json:"status"`
+	Attempts        int        `json:"attempts"`
+	ExchangeOrderID string     `gorm:"size:100" json:"exchange_order_id,omitempty"`
+	LastError       string     `gorm:"type:text" json:"last_error,omitempty"`
+	DispatchedAt    *time.Time `json:"dispatched_at,omitempty"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (OrderIntent) TableName() string {
+	return "order_intents"
+}
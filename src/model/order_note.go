@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// MaxOrderNoteLength bounds the body of an OrderNote.
+const MaxOrderNoteLength = 2000
+
+// OrderNote is a free-text note attached to an order, e.g. to document a
+// manual intervention during an incident next to the trade it touched.
+type OrderNote struct {
+	ID      uint `gorm:"primaryKey" json:"id"`
+	OrderID uint `gorm:"not null;index" json:"order_id"`
+	// UserID owns the order; AuthorID wrote the note, e.g. an admin.
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	AuthorID  uint      `gorm:"not null" json:"author_id"`
+	Author    string    `gorm:"size:100" json:"author"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (OrderNote) TableName() string {
+	return "order_notes"
+}
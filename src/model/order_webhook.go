@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// OrderWebhook is a URL of a user the order events of their accounts are
+// POSTed to, signed with its secret, e.g. to feed their own accounting or
+// alerting.
+type OrderWebhook struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"not null;index" json:"user_id"`
+	URL    string `gorm:"size:512;not null" json:"url"`
+	// SecretEnc is the signing secret, encrypted like the exchange keys.
+	SecretEnc string `gorm:"column:secret_enc;size:512;not null" json:"-"`
+	// Events are the comma separated events sent, e.g.
+	// "order_filled,order_failed"; empty sends every order event.
+	Events    string    `gorm:"size:255;not null;default:''" json:"events"`
+	Enabled   bool      `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (OrderWebhook) TableName() string {
+	return "order_webhooks"
+}
+
+// Statuses of a WebhookDelivery.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	// WebhookDeliveryDead is a delivery that failed too many times.
+	WebhookDeliveryDead = "dead"
+)
+
+// WebhookDelivery is one event to POST to an OrderWebhook. A pending
+// delivery is sent once NextAttemptAt passed and retried with an
+// exponential backoff until it is delivered or dead.
+type WebhookDelivery struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	WebhookID uint   `gorm:"not null;index" json:"webhook_id"`
+	UserID    uint   `gorm:"not null;index" json:"user_id"`
+	Event     string `gorm:"size:50;not null" json:"event"`
+	Payload   string `gorm:"type:text;not null" json:"payload"`
+	Status    string `gorm:"size:10;not null;default:pending;index:idx_webhook_delivery_due,priority:1" json:"status"`
+	// Attempts counts the POSTs sent.
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_webhook_delivery_due,priority:2" json:"next_attempt_at"`
+	LastStatus    int        `gorm:"not null;default:0" json:"last_status,omitempty"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
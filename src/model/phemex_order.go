@@ -2,6 +2,23 @@ package model
 
 import "time"
 
+// PhemexPayloadKind is the shape of a Phemex order payload a PhemexOrder was
+// mapped from.
+type PhemexPayloadKind string
+
+const (
+	// PhemexPayloadOrder is the answer to a plain order placement.
+	PhemexPayloadOrder PhemexPayloadKind = "order"
+	// PhemexPayloadConditional is a stop or if-touched order resting until its
+	// trigger price is reached.
+	PhemexPayloadConditional PhemexPayloadKind = "conditional"
+	// PhemexPayloadAmend is the answer to an order replace.
+	PhemexPayloadAmend PhemexPayloadKind = "amend"
+	// PhemexPayloadFill is a single execution of an order, as pushed by the
+	// account stream.
+	PhemexPayloadFill PhemexPayloadKind = "fill"
+)
+
 // PhemexOrder represents a normalized Phemex order response stored in the database.
 type PhemexOrder struct {
 	ID uint `gorm:"primaryKey" json:"id"`
@@ -17,6 +34,12 @@ type PhemexOrder struct {
 	Symbol          string `gorm:"size:50;index" json:"symbol"`
 	Side            string `gorm:"size:10" json:"side"`
 
+	// Payload shape and hedge-mode flags
+	Kind           PhemexPayloadKind `gorm:"size:20" json:"kind"`
+	PosSide        string            `gorm:"size:10" json:"pos_side"`
+	ReduceOnly     bool              `json:"reduce_only"`
+	CloseOnTrigger bool              `json:"close_on_trigger"`
+
 	// Timestamps (nanoseconds -> time)
 	ActionTime   time.Time `json:"action_time"`
 	TransactTime time.Time `json:"transact_time"`
@@ -32,6 +55,10 @@ type PhemexOrder struct {
 	ClosedPnl  float64 `json:"closed_pnl"`
 	ClosedSize float64 `json:"closed_size"`
 
+	// Fees, summed from the fills (execFeeRv)
+	Fee         float64 `json:"fee"`
+	FeeCurrency string  `gorm:"size:20" json:"fee_currency"`
+
 	// Cumulative
 	CumQty   float64 `json:"cum_qty"`
 	CumValue float64 `json:"cum_value"`
@@ -40,6 +67,11 @@ type PhemexOrder struct {
 	LeavesQty   float64 `json:"leaves_qty"`
 	LeavesValue float64 `json:"leaves_value"`
 
+	// Execution, set on fills only
+	ExecID    string  `gorm:"size:100" json:"exec_id"`
+	ExecQty   float64 `json:"exec_qty"`
+	ExecPrice float64 `json:"exec_price"`
+
 	// Stops
 	StopDirection string  `gorm:"size:30" json:"stop_direction"`
 	StopPrice     float64 `json:"stop_price"`
@@ -70,6 +102,9 @@ type PhemexOrderResponse struct {
 	ClOrdID               string `json:"clOrdID"`
 	Symbol                string `json:"symbol"`
 	Side                  string `json:"side"`
+	PosSide               string `json:"posSide"`
+	ReduceOnly            bool   `json:"reduceOnly"`
+	CloseOnTrigger        bool   `json:"closeOnTrigger"`
 	ActionTimeNs          int64  `json:"actionTimeNs"`
 	TransactTimeNs        int64  `json:"transactTimeNs"`
 	OrderType             string `json:"orderType"`
@@ -96,4 +131,15 @@ type PhemexOrderResponse struct {
 	StopLossRp            string `json:"stopLossRp"`
 	SlPxRp                string `json:"slPxRp"`
 	TpPxRp                string `json:"tpPxRp"`
+
+	// OrdType carries the order type on conditional orders and account
+	// stream pushes, which use ordType instead of orderType.
+	OrdType string `json:"ordType"`
+
+	// Execution fields of fill notifications.
+	ExecID      string `json:"execID"`
+	ExecQtyRq   string `json:"execQtyRq"`
+	ExecPriceRp string `json:"execPriceRp"`
+	ExecFeeRv   string `json:"execFeeRv"`
+	FeeCurrency string `json:"feeCurrency"`
 }
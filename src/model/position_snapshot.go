@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PositionSnapshot is an open position of a user exchange as seen by the
+// position_snapshot job at TakenAt, kept for exposure history, drawdown and
+// incident forensics.
+type PositionSnapshot struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:ux_position_snapshot_user_exchange_symbol_side_taken,priority:1;index:idx_position_snapshot_user_taken,priority:1" json:"user_id"`
+	ExchangeID uint      `gorm:"not null;uniqueIndex:ux_position_snapshot_user_exchange_symbol_side_taken,priority:2" json:"exchange_id"`
+	Symbol     string    `gorm:"type:varchar(50);not null;uniqueIndex:ux_position_snapshot_user_exchange_symbol_side_taken,priority:3" json:"symbol"`
+	PosSide    string    `gorm:"type:varchar(10);not null;uniqueIndex:ux_position_snapshot_user_exchange_symbol_side_taken,priority:4" json:"pos_side"`
+	TakenAt    time.Time `gorm:"not null;uniqueIndex:ux_position_snapshot_user_exchange_symbol_side_taken,priority:5;index:idx_position_snapshot_user_taken,priority:2" json:"taken_at"`
+
+	Side             string          `gorm:"type:varchar(10)" json:"side"`
+	Size             decimal.Decimal `gorm:"type:double precision;not null" json:"size"`
+	EntryPrice       decimal.Decimal `gorm:"type:double precision;not null" json:"entry_price"`
+	MarkPrice        decimal.Decimal `gorm:"type:double precision;not null" json:"mark_price"`
+	Notional         decimal.Decimal `gorm:"type:double precision;not null" json:"notional"`
+	PositionMargin   decimal.Decimal `gorm:"type:double precision;not null" json:"position_margin"`
+	UnrealizedPnL    decimal.Decimal `gorm:"column:unrealized_pnl;type:double precision;not null" json:"unrealized_pnl"`
+	Leverage         decimal.Decimal `gorm:"type:double precision;not null" json:"leverage"`
+	LiquidationPrice decimal.Decimal `gorm:"type:double precision;not null" json:"liquidation_price"`
+	// AccountBalance is the wallet balance of the account at TakenAt.
+	AccountBalance decimal.Decimal `gorm:"type:double precision;not null" json:"account_balance"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (PositionSnapshot) TableName() string {
+	return "position_snapshots"
+}
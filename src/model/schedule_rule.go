@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// ScheduleActionBlock refuses new entries inside the window.
+	ScheduleActionBlock = "block"
+	// ScheduleActionReduce scales the size of new entries inside the window
+	// by SizeMultiplier.
+	ScheduleActionReduce = "reduce"
+)
+
+// ScheduleRule is a weekly trading-hours window of a user, e.g. no new
+// entries from Friday 20:00 to Sunday 22:00 UTC. Days follow time.Weekday
+// (0 is Sunday) and times are minutes after midnight in Timezone. A window
+// whose end comes before its start wraps over the end of the week.
+type ScheduleRule struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"not null;index" json:"user_id"`
+	// Symbol limits the rule to one symbol; empty applies it to all.
+	Symbol      string `gorm:"size:50;not null;default:''" json:"symbol"`
+	StartDay    int    `gorm:"not null" json:"start_day"`
+	StartMinute int    `gorm:"not null" json:"start_minute"`
+	EndDay      int    `gorm:"not null" json:"end_day"`
+	EndMinute   int    `gorm:"not null" json:"end_minute"`
+	// Timezone is the IANA zone of the window, UTC when empty.
+	Timezone       string          `gorm:"size:64" json:"timezone"`
+	Action         string          `gorm:"size:10;not null" json:"action"`
+	SizeMultiplier decimal.Decimal `gorm:"column:size_multiplier;type:double precision;not null;default:0" json:"size_multiplier"`
+	Note           string          `gorm:"size:255" json:"note,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+func (ScheduleRule) TableName() string {
+	return "schedule_rules"
+}
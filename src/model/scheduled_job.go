@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Outcomes of the last run of a ScheduledJob.
+const (
+	ScheduledJobOK     = "ok"
+	ScheduledJobFailed = "failed"
+)
+
+// ScheduledJob is the bookkeeping of a periodic job run by the scheduler:
+// its last run and, while it runs, the lock keeping other schedulers from
+// starting it too.
+type ScheduledJob struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"size:50;not null;uniqueIndex" json:"name"`
+	// LockedUntil is set while a run holds the job; a run that died keeps
+	// it locked until then.
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	// LastSucceededAt is when the last successful run finished; a job
+	// without one for too long is alerted on.
+	LastSucceededAt *time.Time `json:"last_succeeded_at,omitempty"`
+	LastStatus      string     `gorm:"size:10" json:"last_status,omitempty"`
+	LastError       string     `gorm:"type:text" json:"last_error,omitempty"`
+	LastDurationMS  int64      `gorm:"not null;default:0" json:"last_duration_ms"`
+	LastItems       int        `gorm:"not null;default:0" json:"last_items"`
+	Runs            int        `gorm:"not null;default:0" json:"runs"`
+	Failures        int        `gorm:"not null;default:0" json:"failures"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (ScheduledJob) TableName() string {
+	return "scheduled_jobs"
+}
+
+// JobRun is one run of a ScheduledJob.
+type JobRun struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Job        string    `gorm:"size:50;not null;index:idx_job_run_job,priority:1" json:"job"`
+	StartedAt  time.Time `gorm:"not null;index:idx_job_run_job,priority:2" json:"started_at"`
+	FinishedAt time.Time `gorm:"not null" json:"finished_at"`
+	Status     string    `gorm:"size:10;not null;index" json:"status"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	// Items counts what the run processed, e.g. accounts or candles.
+	Items      int       `gorm:"not null;default:0" json:"items"`
+	DurationMS int64     `gorm:"not null;default:0" json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (JobRun) TableName() string {
+	return "job_runs"
+}
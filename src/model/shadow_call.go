@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// ShadowCall is an order call a shadow account made against its paper
+// account instead of the exchange: what the real call would have been and
+// how the paper account answered (see UserExchange.Shadow).
+type ShadowCall struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	UserID     uint   `gorm:"not null;index:idx_shadow_call_user_exchange" json:"user_id"`
+	ExchangeID uint   `gorm:"not null;index:idx_shadow_call_user_exchange" json:"exchange_id"`
+	Method     string `gorm:"size:30;not null" json:"method"`
+
+	// The request that would have reached the exchange. Entries placed
+	// through the outbox carry the client order id of their order intent.
+	ClientOrderID string `gorm:"column:client_order_id;size:64" json:"client_order_id,omitempty"`
+	Symbol        string `gorm:"size:50" json:"symbol"`
+	Side          string `gorm:"size:10" json:"side,omitempty"`
+	PosSide       string `gorm:"size:10" json:"pos_side,omitempty"`
+	Quantity      string `gorm:"size:40" json:"quantity,omitempty"`
+	OrderType     string `gorm:"size:30" json:"order_type,omitempty"`
+	Price         string `gorm:"size:40" json:"price,omitempty"`
+	ReduceOnly    bool   `gorm:"not null;default:false" json:"reduce_only"`
+
+	// The paper outcome
+	FillPrice    *float64 `json:"fill_price,omitempty"`
+	PaperOrderID string   `gorm:"size:40" json:"paper_order_id,omitempty"`
+	Error        string   `gorm:"type:text" json:"error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (ShadowCall) TableName() string {
+	return "shadow_calls"
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// SignalClaim records that a user acted on a trading signal. Signals with the
+// same fingerprint claimed within the duplicate window are suppressed; the
+// unique index over the window bucket stops two executors racing on the same
+// alert from both getting through.
+type SignalClaim struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:ux_signal_claim_window,priority:1;index:idx_signal_claim_user_signal,priority:1" json:"user_id"`
+	ExchangeID  uint      `gorm:"not null;uniqueIndex:ux_signal_claim_window,priority:2" json:"exchange_id"`
+	Symbol      string    `gorm:"size:50;not null;uniqueIndex:ux_signal_claim_window,priority:3" json:"symbol"`
+	Fingerprint string    `gorm:"size:64;not null;uniqueIndex:ux_signal_claim_window,priority:4" json:"fingerprint"`
+	WindowStart time.Time `gorm:"not null;uniqueIndex:ux_signal_claim_window,priority:5" json:"window_start"`
+	SignalID    uint      `gorm:"not null;index:idx_signal_claim_user_signal,priority:2" json:"signal_id"`
+	ClaimedAt   time.Time `gorm:"not null" json:"claimed_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (SignalClaim) TableName() string {
+	return "signal_claims"
+}
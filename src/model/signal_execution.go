@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Decisions of a SignalExecution.
+const (
+	// SignalExecutionDispatched marks a signal handed to the controller.
+	SignalExecutionDispatched = "dispatched"
+	// SignalExecutionSkipped marks a signal the executor will not act on,
+	// e.g. one that arrived while it was down (see executors.ReplayPolicy).
+	SignalExecutionSkipped = "skipped"
+)
+
+// SignalExecution records what the executor of an account decided for a
+// signal. The highest SignalID tells where the executor stopped, so the
+// signals that arrived while it was down can be told apart on startup.
+type SignalExecution struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:ux_signal_execution" json:"user_id"`
+	ExchangeID uint      `gorm:"not null;uniqueIndex:ux_signal_execution" json:"exchange_id"`
+	SignalID   uint      `gorm:"not null;uniqueIndex:ux_signal_execution" json:"signal_id"`
+	Symbol     string    `gorm:"size:50" json:"symbol"`
+	Decision   string    `gorm:"size:20;not null" json:"decision"`
+	Reason     string    `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (SignalExecution) TableName() string {
+	return "signal_executions"
+}
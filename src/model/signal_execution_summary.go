@@ -0,0 +1,104 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// Outcomes of a signal for one account, counted by SignalExecutionSummary.
+const (
+	SignalOutcomeFilled  = "filled"
+	SignalOutcomeSkipped = "skipped"
+	SignalOutcomeErrored = "errored"
+	SignalOutcomePending = "pending"
+)
+
+// SignalExecutionSummary aggregates what every account a signal reached did
+// with it, so an operator can tell at a glance whether it executed cleanly
+// fleet-wide. It is rebuilt from the signal executions and entry orders of
+// the signal, see NewSignalExecutionSummary.
+type SignalExecutionSummary struct {
+	SignalID uint   `gorm:"primaryKey;autoIncrement:false" json:"signal_id"`
+	Symbol   string `gorm:"size:50" json:"symbol"`
+	Accounts int    `gorm:"not null;default:0" json:"accounts"`
+	Filled   int    `gorm:"not null;default:0" json:"filled"`
+	// Skipped counts the accounts that did not act on the signal: replay
+	// policy, signal filters, risk checks or a rejected approval.
+	Skipped int `gorm:"not null;default:0" json:"skipped"`
+	Errored int `gorm:"not null;default:0" json:"errored"`
+	// Pending counts the entries still waiting, e.g. for an approval.
+	Pending int `gorm:"not null;default:0" json:"pending"`
+	// Clean is true once every account filled or skipped the signal.
+	Clean     bool      `gorm:"not null;default:false" json:"clean"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (SignalExecutionSummary) TableName() string {
+	return "signal_execution_summaries"
+}
+
+// NewSignalExecutionSummary aggregates the decisions execs of the executors
+// and the entry orders placed for signalID, one outcome per account. An
+// account with several entries (e.g. retried after an error) counts its
+// latest one; a dispatched signal without entry was passed on by the
+// controller and counts as skipped.
+func NewSignalExecutionSummary(signalID uint, execs []SignalExecution, entries []Order) SignalExecutionSummary {
+	type account struct {
+		userID     uint
+		exchangeID uint
+	}
+
+	summary := SignalExecutionSummary{SignalID: signalID}
+	outcomes := map[account]string{}
+	for _, exec := range execs {
+		if exec.SignalID != signalID {
+			continue
+		}
+		summary.Symbol = exec.Symbol
+		outcomes[account{exec.UserID, exec.ExchangeID}] = SignalOutcomeSkipped
+	}
+
+	sorted := make([]Order, 0, len(entries))
+	for _, o := range entries {
+		if o.ExternalID == signalID && o.OrderDir == OrderDirectionEntry {
+			sorted = append(sorted, o)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for _, o := range sorted {
+		if summary.Symbol == "" {
+			summary.Symbol = o.Symbol
+		}
+		outcomes[account{o.UserID, o.ExchangeID}] = signalOutcome(o.Status)
+	}
+
+	for _, outcome := range outcomes {
+		summary.Accounts++
+		switch outcome {
+		case SignalOutcomeFilled:
+			summary.Filled++
+		case SignalOutcomeSkipped:
+			summary.Skipped++
+		case SignalOutcomeErrored:
+			summary.Errored++
+		default:
+			summary.Pending++
+		}
+	}
+	summary.Clean = summary.Accounts > 0 && summary.Errored == 0 && summary.Pending == 0
+	return summary
+}
+
+// signalOutcome maps the status of an entry order to its outcome.
+func signalOutcome(status string) string {
+	switch status {
+	case OrderExecutionStatusFilled, OrderExecutionStatusLiquidated:
+		return SignalOutcomeFilled
+	case OrderExecutionStatusFiltered, OrderExecutionStatusRejected:
+		return SignalOutcomeSkipped
+	case OrderExecutionStatusError, OrderExecutionStatusCanceledError:
+		return SignalOutcomeErrored
+	default:
+		return SignalOutcomePending
+	}
+}
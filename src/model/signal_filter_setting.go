@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// SignalFilterSetting configures the pre-trade filter chain of a user. Every
+// filter is disabled while its limit is zero.
+type SignalFilterSetting struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"not null;uniqueIndex" json:"user_id"`
+
+	// Entries are allowed from TradingStartHour to TradingEndHour (exclusive)
+	// in Timezone (IANA name, UTC when empty). Equal hours disable the filter.
+	TradingStartHour int    `gorm:"column:trading_start_hour" json:"trading_start_hour"`
+	TradingEndHour   int    `gorm:"column:trading_end_hour" json:"trading_end_hour"`
+	Timezone         string `gorm:"column:timezone;size:64" json:"timezone"`
+
+	NewsSentimentThreshold       float64 `gorm:"column:news_sentiment_threshold" json:"news_sentiment_threshold"`
+	NewsSentimentLookbackMinutes int     `gorm:"column:news_sentiment_lookback_minutes" json:"news_sentiment_lookback_minutes"`
+
+	MaxVolatilityPct          float64 `gorm:"column:max_volatility_pct" json:"max_volatility_pct"`
+	VolatilityLookbackMinutes int     `gorm:"column:volatility_lookback_minutes" json:"volatility_lookback_minutes"`
+
+	MaxSpreadBps     float64 `gorm:"column:max_spread_bps" json:"max_spread_bps"`
+	MaxOpenPositions int     `gorm:"column:max_open_positions" json:"max_open_positions"`
+
+	LossCooldownMinutes int `gorm:"column:loss_cooldown_minutes" json:"loss_cooldown_minutes"`
+
+	// After MaxConsecutiveLosses losing trades in a row on a symbol, entries
+	// are blocked for LossStreakCooldownMinutes (see model.LossStreak).
+	MaxConsecutiveLosses      int `gorm:"column:max_consecutive_losses" json:"max_consecutive_losses"`
+	LossStreakCooldownMinutes int `gorm:"column:loss_streak_cooldown_minutes" json:"loss_streak_cooldown_minutes"`
+
+	// Entries within FundingDelayMinutes of a funding timestamp are postponed
+	// until after it when the position would pay at least
+	// FundingDelayMinRateBps.
+	FundingDelayMinutes    int     `gorm:"column:funding_delay_minutes" json:"funding_delay_minutes"`
+	FundingDelayMinRateBps float64 `gorm:"column:funding_delay_min_rate_bps" json:"funding_delay_min_rate_bps"`
+
+	// Entries on a side with a CandlePattern (see tp_sl.Pattern) need the
+	// last closed candle of CandlePatternIntervalMinutes (15 when zero) to
+	// form it.
+	CandlePatternLong            string `gorm:"column:candle_pattern_long;size:32" json:"candle_pattern_long"`
+	CandlePatternShort           string `gorm:"column:candle_pattern_short;size:32" json:"candle_pattern_short"`
+	CandlePatternIntervalMinutes int    `gorm:"column:candle_pattern_interval_minutes" json:"candle_pattern_interval_minutes"`
+
+	// After a stop loss closed a position, entries on its symbol and side are
+	// blocked for StopOutCooldownMinutes (see model.StopOut). A signal
+	// received after the stop-out ends the cooldown early when the last
+	// closed candle of StopOutConfirmIntervalMinutes, closed after it, moved
+	// in the direction of the entry; zero disables the early release.
+	StopOutCooldownMinutes        int `gorm:"column:stop_out_cooldown_minutes" json:"stop_out_cooldown_minutes"`
+	StopOutConfirmIntervalMinutes int `gorm:"column:stop_out_confirm_interval_minutes" json:"stop_out_confirm_interval_minutes"`
+
+	// Entries per symbol and UTC day are capped at MaxDailyEntries (see
+	// model.DailyEntryCount); zero leaves them unlimited.
+	MaxDailyEntries int `gorm:"column:max_daily_entries" json:"max_daily_entries"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (SignalFilterSetting) TableName() string {
+	return "signal_filter_settings"
+}
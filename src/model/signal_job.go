@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Statuses of a SignalJob.
+const (
+	SignalJobQueued = "queued"
+	// SignalJobLeased is a job an executor is working on. Once its
+	// VisibleAt passed without completion it can be leased again.
+	SignalJobLeased = "leased"
+	SignalJobDone   = "done"
+	// SignalJobDead is a job that failed too many times, the dead letter
+	// queue. An operator retries it explicitly.
+	SignalJobDead = "dead"
+)
+
+// SignalJob delivers a webhook signal to the executor of one account when
+// signals are processed through the queue. A job is leased until VisibleAt,
+// then completed, retried or, after too many attempts, dead-lettered: each
+// account acts on each signal at least once.
+type SignalJob struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	SignalID   uint   `gorm:"not null;uniqueIndex:ux_signal_job,priority:1" json:"signal_id"`
+	UserID     uint   `gorm:"not null;uniqueIndex:ux_signal_job,priority:2;index:idx_signal_job_consumer,priority:1" json:"user_id"`
+	ExchangeID uint   `gorm:"not null;uniqueIndex:ux_signal_job,priority:3;index:idx_signal_job_consumer,priority:2" json:"exchange_id"`
+	Symbol     string `gorm:"size:50;not null;index:idx_signal_job_consumer,priority:3" json:"symbol"`
+	Status     string `gorm:"size:10;not null;default:queued;index" json:"status"`
+	// Attempts counts the leases of the job.
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	VisibleAt   time.Time  `gorm:"not null;index:idx_signal_job_consumer,priority:4" json:"visible_at"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (SignalJob) TableName() string {
+	return "signal_jobs"
+}
+
+// SignalQueueConsumer is an executor consuming the queued signals of Symbol
+// on ExchangeName. New signals are queued for the consumers seen recently.
+type SignalQueueConsumer struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null;uniqueIndex:ux_signal_queue_consumer,priority:1" json:"user_id"`
+	ExchangeID   uint      `gorm:"not null;uniqueIndex:ux_signal_queue_consumer,priority:2" json:"exchange_id"`
+	Symbol       string    `gorm:"size:50;not null;uniqueIndex:ux_signal_queue_consumer,priority:3;index:idx_signal_queue_consumer_symbol,priority:1" json:"symbol"`
+	ExchangeName string    `gorm:"size:50;not null;index:idx_signal_queue_consumer_symbol,priority:2" json:"exchange_name"`
+	LastSeenAt   time.Time `gorm:"not null" json:"last_seen_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (SignalQueueConsumer) TableName() string {
+	return "signal_queue_consumers"
+}
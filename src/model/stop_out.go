@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// StopOut is the last position of a user on an exchange/symbol/side closed
+// by its stop loss, recorded by the stop watchdog. New entries on that side
+// are blocked for SignalFilterSetting.StopOutCooldownMinutes after
+// StoppedAt.
+type StopOut struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null;uniqueIndex:ux_stop_out_user_exchange_symbol_side,priority:1" json:"user_id"`
+	ExchangeID   uint      `gorm:"not null;uniqueIndex:ux_stop_out_user_exchange_symbol_side,priority:2" json:"exchange_id"`
+	Symbol       string    `gorm:"size:50;not null;uniqueIndex:ux_stop_out_user_exchange_symbol_side,priority:3" json:"symbol"`
+	PosSide      string    `gorm:"size:10;not null;uniqueIndex:ux_stop_out_user_exchange_symbol_side,priority:4" json:"pos_side"`
+	EntryOrderID uint      `gorm:"column:entry_order_id" json:"entry_order_id"`
+	StopOrderID  string    `gorm:"column:stop_order_id;size:64" json:"stop_order_id"`
+	StopPrice    string    `gorm:"column:stop_price;size:50" json:"stop_price,omitempty"`
+	StoppedAt    time.Time `gorm:"column:stopped_at;not null" json:"stopped_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (StopOut) TableName() string {
+	return "stop_outs"
+}
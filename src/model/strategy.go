@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// Strategy assigns a registered strategy (by name) to a user on one exchange
+// and symbol. Params holds the strategy specific JSON settings.
+type Strategy struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:ux_strategies_user_exchange_symbol,priority:1" json:"user_id"`
+	ExchangeID uint      `gorm:"not null;uniqueIndex:ux_strategies_user_exchange_symbol,priority:2" json:"exchange_id"`
+	Symbol     string    `gorm:"size:50;not null;uniqueIndex:ux_strategies_user_exchange_symbol,priority:3" json:"symbol"`
+	Name       string    `gorm:"size:100;not null" json:"name"`
+	Params     string    `gorm:"type:text" json:"params"`
+	Enabled    bool      `gorm:"column:enabled" json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (Strategy) TableName() string {
+	return "strategies"
+}
+
+// StrategyAction records a decision taken by a strategy hook.
+type StrategyAction struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	StrategyID uint      `gorm:"not null;index" json:"strategy_id"`
+	Hook       string    `gorm:"size:20;not null" json:"hook"`
+	Action     string    `gorm:"size:20;not null" json:"action"`
+	Side       string    `gorm:"size:10" json:"side"`
+	PosSide    string    `gorm:"size:10" json:"pos_side"`
+	Reason     string    `gorm:"size:512" json:"reason"`
+	SignalID   uint      `gorm:"index" json:"signal_id"`
+	OrderID    *uint     `gorm:"index" json:"order_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	Strategy *Strategy `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (StrategyAction) TableName() string {
+	return "strategy_actions"
+}
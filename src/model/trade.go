@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+const (
+	TradeStatusOpen   = "open"
+	TradeStatusClosed = "closed"
+)
+
+// Trade is a journal record pairing an entry order with the exit order that
+// closed it, enriched with the execution prices and excursions from OHLCV.
+type Trade struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	UserID     uint   `gorm:"not null;index:idx_trades_user_entry_time,priority:1" json:"user_id"`
+	ExchangeID uint   `gorm:"not null" json:"exchange_id"`
+	Symbol     string `gorm:"size:50;not null" json:"symbol"`
+	PosSide    string `gorm:"size:10" json:"pos_side"`
+	Status     string `gorm:"size:10;not null" json:"status"`
+
+	// Links back to the signals and orders that produced the trade.
+	EntrySignalID uint  `json:"entry_signal_id"`
+	EntryOrderID  uint  `gorm:"not null;uniqueIndex" json:"entry_order_id"`
+	ExitSignalID  *uint `json:"exit_signal_id,omitempty"`
+	ExitOrderID   *uint `gorm:"index" json:"exit_order_id,omitempty"`
+
+	Quantity   float64    `json:"quantity"`
+	EntryPrice float64    `json:"entry_price"`
+	ExitPrice  *float64   `json:"exit_price,omitempty"`
+	StopLoss   float64    `json:"stop_loss"`
+	EntryTime  time.Time  `gorm:"not null;index:idx_trades_user_entry_time,priority:2" json:"entry_time"`
+	ExitTime   *time.Time `json:"exit_time,omitempty"`
+
+	// PnL is net of Fees, the entry and exit order fees.
+	PnL             *float64 `gorm:"column:pnl" json:"pnl,omitempty"`
+	Fees            float64  `json:"fees"`
+	RMultiple       *float64 `json:"r_multiple,omitempty"`
+	DurationSeconds int64    `json:"duration_seconds"`
+	// MAE / MFE are the worst / best price move against / in favour of the
+	// position while it was open, in quote currency per unit.
+	MAE float64 `gorm:"column:mae" json:"mae"`
+	MFE float64 `gorm:"column:mfe" json:"mfe"`
+	// MAER / MFER are MAE / MFE of the whole position in units of
+	// InitialRisk, nil when the initial risk is unknown.
+	MAER *float64 `gorm:"column:mae_r" json:"mae_r,omitempty"`
+	MFER *float64 `gorm:"column:mfe_r" json:"mfe_r,omitempty"`
+
+	// InitialRisk comes from the entry order; RMultiple is the gross result
+	// of the trade in units of it.
+	InitialRisk *float64 `gorm:"column:initial_risk" json:"initial_risk,omitempty"`
+
+	// StrategyID is the strategy assignment of the entry order, nil for the
+	// default signal follower.
+	StrategyID *uint `json:"strategy_id,omitempty"`
+
+	// Notes are the notes of the entry and exit orders, loaded on demand.
+	Notes []OrderNote `gorm:"-" json:"notes,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Trade) TableName() string {
+	return "trades"
+}
@@ -1,8 +1,11 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -33,8 +36,18 @@ type Event struct {
 	Unit          string   `json:"unit"`
 	Importance    int      `json:"importance"`
 	Date          TVTime   `json:"date"`
+
+	// NewsSource is the provider the event came from (tradingview, rss,
+	// cryptopanic). Not part of the TradingView payload.
+	NewsSource string `json:"-"`
 }
 
+const (
+	NewsSourceTradingView = "tradingview"
+	NewsSourceRSS         = "rss"
+	NewsSourceCryptoPanic = "cryptopanic"
+)
+
 // TVTime handles TradingView timestamps like:
 // - "2025-12-08T16:00:00.000Z"
 // - "2025-11-30T00:00:00Z"
@@ -96,6 +109,9 @@ type TradingViewNewsEvent struct {
 	Source    string `gorm:"column:source"`
 	SourceURL string `gorm:"column:source_url"`
 
+	// NewsSource is the provider the row was fetched from, see NewsSource* constants.
+	NewsSource string `gorm:"column:news_source;type:varchar(30);not null;default:'tradingview';index"`
+
 	Actual      *float64 `gorm:"column:actual"`
 	Previous    *float64 `gorm:"column:previous"`
 	Forecast    *float64 `gorm:"column:forecast"`
@@ -109,6 +125,16 @@ type TradingViewNewsEvent struct {
 
 	EventDate time.Time `gorm:"column:event_date;index"` // your main time for blocking
 
+	// ContentHash identifies the headline independently of tv_event_id so
+	// republished events with a new id are not stored twice.
+	ContentHash string `gorm:"column:content_hash;type:varchar(64);index"`
+
+	// Sentiment in [-1, 1], nil until scored. SentimentScorer records which
+	// scorer produced it so scores can be recomputed when rules change.
+	SentimentScore    *float64   `gorm:"column:sentiment_score"`
+	SentimentScorer   string     `gorm:"column:sentiment_scorer"`
+	SentimentScoredAt *time.Time `gorm:"column:sentiment_scored_at"`
+
 	CreatedAt time.Time `gorm:"column:created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at"`
 }
@@ -117,6 +143,20 @@ func (TradingViewNewsEvent) TableName() string {
 	return "trading_view_news_event"
 }
 
+// ComputeContentHash returns a sha256 over the normalized headline fields
+// (title, country, indicator, period, event date).
+func (m TradingViewNewsEvent) ComputeContentHash() string {
+	parts := []string{
+		strings.ToLower(strings.TrimSpace(m.Title)),
+		strings.ToUpper(strings.TrimSpace(m.Country)),
+		strings.ToLower(strings.TrimSpace(m.Indicator)),
+		strings.ToLower(strings.TrimSpace(m.Period)),
+		m.EventDate.UTC().Format(time.RFC3339),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
 // NewTradingViewNewsEventFromEvent Converter from API Event into DB VO
 func NewTradingViewNewsEventFromEvent(ev Event) TradingViewNewsEvent {
 	var refDate *time.Time
@@ -125,7 +165,7 @@ func NewTradingViewNewsEventFromEvent(ev Event) TradingViewNewsEvent {
 		refDate = &t
 	}
 
-	return TradingViewNewsEvent{
+	m := TradingViewNewsEvent{
 		TVEventID:     ev.ID,
 		Title:         ev.Title,
 		Country:       ev.Country,
@@ -147,7 +187,14 @@ func NewTradingViewNewsEventFromEvent(ev Event) TradingViewNewsEvent {
 		Unit:          ev.Unit,
 		Importance:    ev.Importance,
 		EventDate:     ev.Date.Time.UTC(),
+		NewsSource:    ev.NewsSource,
 	}
+	if m.NewsSource == "" {
+		m.NewsSource = NewsSourceTradingView
+	}
+	m.ContentHash = m.ComputeContentHash()
+
+	return m
 }
 
 // TradingViewNewsEvent Optional converter back from VO to API Event. Useful for CanEnterTradeAt
@@ -179,5 +226,6 @@ func (m TradingViewNewsEvent) ToEvent() Event {
 		Unit:          m.Unit,
 		Importance:    m.Importance,
 		Date:          TVTime{Time: m.EventDate.UTC()},
+		NewsSource:    m.NewsSource,
 	}
 }
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// TVNewsWatchlist controls which TradingView calendar tickers the tvnews
+// fetcher keeps. Country is passed to the calendar API; an empty watchlist
+// means every important event is kept.
+type TVNewsWatchlist struct {
+	ID        uint      `gorm:"primaryKey"`
+	Ticker    string    `gorm:"column:ticker;type:varchar(100);uniqueIndex;not null"`
+	Country   string    `gorm:"column:country;type:varchar(10);not null;default:'US'"`
+	Enabled   bool      `gorm:"column:enabled;not null"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (TVNewsWatchlist) TableName() string {
+	return "tv_news_watchlist"
+}
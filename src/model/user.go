@@ -17,4 +17,21 @@ type User struct {
 	LastSeen    time.Time `json:"last_seen"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+
+	// TestnetOnly users may only hold testnet exchange accounts; their
+	// connectors never reach production endpoints.
+	TestnetOnly bool `gorm:"column:testnet_only;not null;default:false" json:"testnet_only"`
+
+	// Role is what the user may do through the API, one of the Role*
+	// constants.
+	Role string `gorm:"column:role;size:10;not null;default:operator" json:"role"`
 }
+
+// Roles of a User, from the most to the least privileged. Admins also
+// toggle the kill switch and edit the accounts of other users, operators
+// manage their own accounts and viewers only read them.
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleViewer   = "viewer"
+)
@@ -11,15 +11,35 @@ type UserExchange struct {
 	UserID uint `gorm:"not null;index:idx_user_exchange,unique" json:"user_id"`
 	// LegacyUserID keeps the previous identifier used before the User model existed.
 	// It remains available for backward compatibility but is no longer used as a key.
-	LegacyUserID      string    `gorm:"size:60;column:legacy_user_id" json:"legacy_user_id,omitempty"`
-	ExchangeID        uint      `gorm:"not null;index:idx_user_exchange,unique" json:"exchange_id"`
-	APIKeyHash        string    `gorm:"column:api_key;type:text" json:"-"`
-	APISecretHash     string    `gorm:"column:api_secret;type:text" json:"-"`
-	APIPassphraseHash string    `gorm:"column:api_passphrase;type:text" json:"-"`
-	OrderSizePercent  int       `gorm:"column:order_size_percent" json:"order_size_percent"`
-	RunOnServer       bool      `gorm:"column:run_on_server" json:"run_on_server"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	LegacyUserID      string `gorm:"size:60;column:legacy_user_id" json:"legacy_user_id,omitempty"`
+	ExchangeID        uint   `gorm:"not null;index:idx_user_exchange,unique" json:"exchange_id"`
+	APIKeyHash        string `gorm:"column:api_key;type:text" json:"-"`
+	APISecretHash     string `gorm:"column:api_secret;type:text" json:"-"`
+	APIPassphraseHash string `gorm:"column:api_passphrase;type:text" json:"-"`
+	// SecretBackend / SecretPath reference credentials kept in an external
+	// secrets manager ("vault" or "aws") instead of the ciphertext columns.
+	SecretBackend    string    `gorm:"column:secret_backend;size:20" json:"secret_backend,omitempty"`
+	SecretPath       string    `gorm:"column:secret_path;size:255" json:"secret_path,omitempty"`
+	OrderSizePercent int       `gorm:"column:order_size_percent" json:"order_size_percent"`
+	RunOnServer      bool      `gorm:"column:run_on_server" json:"run_on_server"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Environment is "live" or "testnet" and picks the exchange endpoints
+	// the account's connectors use.
+	Environment string `gorm:"column:environment;size:10;not null;default:live" json:"environment"`
+
+	// KeyVersion is the KuCoin KC-API-KEY-VERSION the exchange accepted
+	// for the keys, empty until detected; the configured default applies
+	// then.
+	KeyVersion string `gorm:"column:key_version;size:2" json:"key_version,omitempty"`
+
+	// LastValidatedAt / LastValidationError / ValidationFailures are written
+	// by the key_health job. ValidationFailures counts consecutive
+	// authentication failures and resets on the next successful check.
+	LastValidatedAt     *time.Time `gorm:"column:last_validated_at" json:"last_validated_at,omitempty"`
+	LastValidationError string     `gorm:"column:last_validation_error;type:text" json:"last_validation_error,omitempty"`
+	ValidationFailures  int        `gorm:"column:validation_failures" json:"validation_failures"`
 
 	WeekendHolidayMultiplier  decimal.Decimal `gorm:"column:weekend_holiday_multiplier" json:"weekend_holiday_multiplier"`
 	DeadZoneMultiplier        decimal.Decimal `gorm:"column:dead_zone_multiplier" json:"dead_zone_multiplier"`
@@ -29,6 +49,86 @@ type UserExchange struct {
 	DefaultMultiplier         decimal.Decimal `gorm:"column:default_multiplier" json:"default_multiplier"`
 	EnableNoTradeWindow       bool            `gorm:"column:enable_no_trade_window" json:"enable_no_trade_window"`
 	NoTradeWindowOrdersClosed bool            `gorm:"column:no_trade_window_orders_closed" json:"no_trade_window_orders_closed"`
+	// SessionTimezone is the IANA zone the sessions above are evaluated in,
+	// America/New_York when empty.
+	SessionTimezone string `gorm:"column:session_timezone;size:64" json:"session_timezone"`
+
+	// Performance sizing on top of the session sizing (anti-martingale):
+	// after consecutive wins on the symbol entries grow by PerfSizeStep per
+	// win, counting at most PerfSizeWinWindow of them, and after consecutive
+	// losses they shrink by PerfSizeStep per loss, counting at most
+	// PerfSizeLossWindow. The factor stays within PerfSizeMin and PerfSizeMax,
+	// 0.5 and 2 when zero. A zero step disables it.
+	PerfSizeStep       decimal.Decimal `gorm:"column:perf_size_step" json:"perf_size_step"`
+	PerfSizeWinWindow  int             `gorm:"column:perf_size_win_window" json:"perf_size_win_window"`
+	PerfSizeLossWindow int             `gorm:"column:perf_size_loss_window" json:"perf_size_loss_window"`
+	PerfSizeMin        decimal.Decimal `gorm:"column:perf_size_min" json:"perf_size_min"`
+	PerfSizeMax        decimal.Decimal `gorm:"column:perf_size_max" json:"perf_size_max"`
+
+	// Before an entry, TopupAmountUSDT is moved from the spot wallet into
+	// futures when the futures available balance is below TopupBelowUSDT.
+	// Zero disables the top-up.
+	TopupBelowUSDT  decimal.Decimal `gorm:"column:topup_below_usdt" json:"topup_below_usdt"`
+	TopupAmountUSDT decimal.Decimal `gorm:"column:topup_amount_usdt" json:"topup_amount_usdt"`
+
+	// StopMode is how entry stops are placed and trailed: "signal" (empty)
+	// or "swing", see tp_sl.StopMode.
+	StopMode string `gorm:"column:stop_mode;size:10" json:"stop_mode"`
+
+	// EntryMode is how entries are sent: "market" (empty) takes the book,
+	// "passive" rests a post-only limit at the best bid or ask first and
+	// sends what is left at market once its patience runs out.
+	EntryMode string `gorm:"column:entry_mode;size:10" json:"entry_mode"`
+
+	// Risk overrides of the account, zero values leave the signal and the
+	// process-wide config in charge. Leverage is set before entries whose
+	// signal asks for none, MaxLeverage caps either. MaxPositionNotional caps
+	// the USDT value of an entry. DefaultSLPct and DefaultTPPct place exits
+	// that far in percent from the entry price when the signal has none.
+	Leverage            decimal.Decimal `gorm:"column:leverage" json:"leverage"`
+	MaxLeverage         decimal.Decimal `gorm:"column:max_leverage" json:"max_leverage"`
+	MaxPositionNotional decimal.Decimal `gorm:"column:max_position_notional" json:"max_position_notional"`
+	DefaultSLPct        decimal.Decimal `gorm:"column:default_sl_pct" json:"default_sl_pct"`
+	DefaultTPPct        decimal.Decimal `gorm:"column:default_tp_pct" json:"default_tp_pct"`
+	// HedgeMode tells whether the exchange account holds long and short
+	// positions apart; nil means hedged. One-way accounts place entries on
+	// the merged position.
+	HedgeMode *bool `gorm:"column:hedge_mode" json:"hedge_mode,omitempty"`
+
+	// Entries worth more than ApprovalNotional USDT wait for the user to
+	// approve them, zero disables the check. After ApprovalTimeoutMinutes
+	// (0 waits forever) ApprovalTimeoutAction decides: "reject" (empty) or
+	// "approve".
+	ApprovalNotional       decimal.Decimal `gorm:"column:approval_notional" json:"approval_notional"`
+	ApprovalTimeoutMinutes int             `gorm:"column:approval_timeout_minutes" json:"approval_timeout_minutes"`
+	ApprovalTimeoutAction  string          `gorm:"column:approval_timeout_action;size:10" json:"approval_timeout_action"`
+
+	// Shadow runs the account on a paper account: the full flow runs
+	// against live prices but orders are filled in memory and recorded as
+	// ShadowCall rows instead of reaching the exchange.
+	Shadow bool `gorm:"column:shadow;not null;default:false" json:"shadow"`
+
+	// CaptureAPICalls records the order-mutating exchange calls of the
+	// account as APICallLog rows, to debug failed submissions.
+	CaptureAPICalls bool `gorm:"column:capture_api_calls;not null;default:false" json:"capture_api_calls"`
+
+	// ExecutionPriority orders the accounts trading the same signal on the
+	// same venue: the executor waits ExecutionPriority stagger steps, plus
+	// a random share of the stagger window, before acting on a new signal.
+	// 0, the default, acts first.
+	ExecutionPriority int `gorm:"column:execution_priority;not null;default:0" json:"execution_priority"`
 
 	Exchange *Exchange `gorm:"constraint:OnDelete:CASCADE" json:"exchange"`
 }
+
+// Modes of UserExchange.EntryMode.
+const (
+	EntryModeMarket  = "market"
+	EntryModePassive = "passive"
+)
+
+// Actions of UserExchange.ApprovalTimeoutAction.
+const (
+	ApprovalTimeoutReject  = "reject"
+	ApprovalTimeoutApprove = "approve"
+)
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strings"
+)
+
+const telegramBaseURL = "https://api.telegram.org"
+
+// postJSON sends payload to url and treats any non-2xx status as an error.
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4*1024))
+		return fmt.Errorf("unexpected status %d. body: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// TelegramChannel sends through the Telegram Bot API.
+type TelegramChannel struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	chatID  string
+}
+
+func NewTelegramChannel(client *http.Client, baseURL, token, chatID string) *TelegramChannel {
+	if baseURL == "" {
+		baseURL = telegramBaseURL
+	}
+	return &TelegramChannel{client: client, baseURL: baseURL, token: token, chatID: chatID}
+}
+
+func (c *TelegramChannel) Name() string { return "telegram" }
+
+func (c *TelegramChannel) Send(ctx context.Context, msg Message) error {
+	return postJSON(ctx, c.client, c.baseURL+"/bot"+c.token+"/sendMessage", map[string]string{
+		"chat_id": c.chatID,
+		"text":    msg.Title + "\n" + msg.Body,
+	})
+}
+
+// DiscordChannel posts to a Discord webhook.
+type DiscordChannel struct {
+	client     *http.Client
+	webhookURL string
+}
+
+func NewDiscordChannel(client *http.Client, webhookURL string) *DiscordChannel {
+	return &DiscordChannel{client: client, webhookURL: webhookURL}
+}
+
+func (c *DiscordChannel) Name() string { return "discord" }
+
+func (c *DiscordChannel) Send(ctx context.Context, msg Message) error {
+	return postJSON(ctx, c.client, c.webhookURL, map[string]string{
+		"content": "**" + msg.Title + "**\n" + msg.Body,
+	})
+}
+
+// SlackChannel posts to a Slack incoming webhook.
+type SlackChannel struct {
+	client     *http.Client
+	webhookURL string
+}
+
+func NewSlackChannel(client *http.Client, webhookURL string) *SlackChannel {
+	return &SlackChannel{client: client, webhookURL: webhookURL}
+}
+
+func (c *SlackChannel) Name() string { return "slack" }
+
+func (c *SlackChannel) Send(ctx context.Context, msg Message) error {
+	return postJSON(ctx, c.client, c.webhookURL, map[string]string{
+		"text": "*" + msg.Title + "*\n" + msg.Body,
+	})
+}
+
+// EmailChannel sends plain-text mail through SMTP.
+type EmailChannel struct {
+	config *Config
+	to     string
+	send   func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewEmailChannel(config *Config, to string) *EmailChannel {
+	return &EmailChannel{config: config, to: to, send: smtp.SendMail}
+}
+
+func (c *EmailChannel) Name() string { return "email" }
+
+func (c *EmailChannel) Send(_ context.Context, msg Message) error {
+	var auth smtp.Auth
+	if c.config.SMTPUser != "" {
+		auth = smtp.PlainAuth("", c.config.SMTPUser, c.config.SMTPPassword, c.config.SMTPHost)
+	}
+
+	body := strings.Join([]string{
+		"From: " + c.config.SMTPFrom,
+		"To: " + c.to,
+		"Subject: " + msg.Title,
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Body,
+	}, "\r\n")
+
+	addr := fmt.Sprintf("%s:%d", c.config.SMTPHost, c.config.SMTPPort)
+	return c.send(addr, auth, c.config.SMTPFrom, []string{c.to}, []byte(body))
+}
@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	TelegramBotToken string        `envconfig:"TELEGRAM_BOT_TOKEN"`
+	SMTPHost         string        `envconfig:"SMTP_HOST"`
+	SMTPPort         int           `envconfig:"SMTP_PORT" default:"587"`
+	SMTPUser         string        `envconfig:"SMTP_USER"`
+	SMTPPassword     string        `envconfig:"SMTP_PASSWORD"`
+	SMTPFrom         string        `envconfig:"SMTP_FROM"`
+	SendTimeout      time.Duration `envconfig:"NOTIFY_SEND_TIMEOUT" default:"5s"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"strategyexecutor/src/events"
+)
+
+// Subscribe makes n notify the order events published on bus: placed and
+// filled orders and stop loss moves.
+func (n *Notifier) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, ev events.OrderPlaced) {
+		n.Notify(ctx, Event{
+			Type:       EventOrderPlaced,
+			UserID:     ev.UserID,
+			Exchange:   ev.Exchange,
+			OrderID:    ev.OrderID,
+			Symbol:     ev.Symbol,
+			Side:       ev.Side,
+			PosSide:    ev.PosSide,
+			Quantity:   ev.Quantity.InexactFloat64(),
+			Price:      ev.Price.InexactFloat64(),
+			OccurredAt: ev.PlacedAt,
+		})
+	})
+	events.Subscribe(bus, func(ctx context.Context, ev events.OrderFilled) {
+		n.Notify(ctx, Event{
+			Type:       EventOrderFilled,
+			UserID:     ev.UserID,
+			Exchange:   ev.Exchange,
+			OrderID:    ev.OrderID,
+			Symbol:     ev.Symbol,
+			Side:       ev.Side,
+			PosSide:    ev.PosSide,
+			Quantity:   ev.Quantity.InexactFloat64(),
+			Price:      ev.Price.InexactFloat64(),
+			OccurredAt: ev.FilledAt,
+		})
+	})
+	events.Subscribe(bus, func(ctx context.Context, ev events.SLMoved) {
+		n.Notify(ctx, Event{
+			Type:       EventStopLossMoved,
+			UserID:     ev.UserID,
+			Exchange:   ev.Exchange,
+			OrderID:    ev.OrderID,
+			Symbol:     ev.Symbol,
+			PosSide:    ev.PosSide,
+			StopLoss:   ev.StopLoss.InexactFloat64(),
+			OccurredAt: ev.MovedAt,
+		})
+	})
+}
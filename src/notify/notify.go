@@ -0,0 +1,198 @@
+// Package notify delivers trading events (orders, stop-loss moves, kill
+// switch, crashes, daily reports) to the channels each user configured.
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type EventType string
+
+const (
+	EventOrderPlaced   EventType = "order_placed"
+	EventOrderFilled   EventType = "order_filled"
+	EventOrderError    EventType = "order_error"
+	EventStopLossMoved EventType = "stop_loss_moved"
+	EventKillSwitch    EventType = "kill_switch"
+	EventExecutorCrash EventType = "executor_crash"
+	EventDailyPnL      EventType = "daily_pnl"
+	EventLossCooldown  EventType = "loss_cooldown"
+	EventKeyInvalid    EventType = "key_invalid"
+	// EventCriticalError reports a critical exception, e.g. a position
+	// that could not be closed.
+	EventCriticalError EventType = "critical_error"
+	// EventApprovalRequired asks the user to approve a large entry. It is
+	// always sent, the entry waits for it.
+	EventApprovalRequired EventType = "approval_required"
+	// EventPriceDivergence reports an entry whose venue price diverged from
+	// the reference price.
+	EventPriceDivergence EventType = "price_divergence"
+)
+
+// Event is what callers report. Only the fields relevant to Type need to be set.
+type Event struct {
+	Type       EventType
+	UserID     uint
+	Username   string
+	Exchange   string
+	Symbol     string
+	Side       string
+	PosSide    string
+	Quantity   float64
+	Price      float64
+	StopLoss   float64
+	OrderID    uint
+	Message    string
+	Err        error
+	OccurredAt time.Time
+
+	// ApproveURL and RejectURL decide an EventApprovalRequired entry.
+	ApproveURL string
+	RejectURL  string
+}
+
+// Message is a rendered notification.
+type Message struct {
+	Title string
+	Body  string
+}
+
+// Channel delivers a rendered message to one user target.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+type settingsRepository interface {
+	GetByUserID(ctx context.Context, userID uint) (*model.UserNotificationSetting, error)
+}
+
+// Notifier routes events to the channels enabled in the user's settings.
+// Delivery is best effort: failures are logged and never returned, so a
+// notification outage can't break the trading flow.
+type Notifier struct {
+	config     *Config
+	settings   settingsRepository
+	httpClient *http.Client
+}
+
+// NewNotifier creates a Notifier backed by the main database. If the database
+// is not initialised Notify is a no-op.
+func NewNotifier() *Notifier {
+	config := GetConfig()
+
+	var settings settingsRepository
+	if database.MainDB != nil {
+		settings = repository.NewNotificationSettingsRepository()
+	}
+
+	return &Notifier{
+		config:     config,
+		settings:   settings,
+		httpClient: &http.Client{Timeout: config.SendTimeout},
+	}
+}
+
+func NewNotifierWithSettings(config *Config, settings settingsRepository, httpClient *http.Client) *Notifier {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: config.SendTimeout}
+	}
+	return &Notifier{config: config, settings: settings, httpClient: httpClient}
+}
+
+// Notify renders ev and sends it to every channel the user enabled for ev.Type.
+func (n *Notifier) Notify(ctx context.Context, ev Event) {
+	if n == nil || n.settings == nil || ev.UserID == 0 {
+		return
+	}
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now().UTC()
+	}
+
+	log := logger.WithFields(map[string]interface{}{
+		"component": "notify",
+		"event":     ev.Type,
+		"user_id":   ev.UserID,
+	})
+
+	s, err := n.settings.GetByUserID(ctx, ev.UserID)
+	if err != nil {
+		log.WithError(err).Error("failed to load notification settings")
+		return
+	}
+	if s == nil || !Enabled(s, ev.Type) {
+		return
+	}
+
+	msg, err := Render(ev)
+	if err != nil {
+		log.WithError(err).Error("failed to render notification")
+		return
+	}
+
+	for _, ch := range n.channelsFor(s) {
+		sendCtx, cancel := context.WithTimeout(ctx, n.config.SendTimeout)
+		if err := ch.Send(sendCtx, msg); err != nil {
+			log.WithError(err).WithField("channel", ch.Name()).Error("failed to send notification")
+		}
+		cancel()
+	}
+}
+
+// Enabled reports whether the user opted in to eventType.
+func Enabled(s *model.UserNotificationSetting, eventType EventType) bool {
+	switch eventType {
+	case EventOrderPlaced:
+		return s.NotifyOrderPlaced
+	case EventOrderFilled:
+		return s.NotifyOrderFilled
+	case EventOrderError:
+		return s.NotifyOrderError
+	case EventStopLossMoved:
+		return s.NotifyStopLossMoved
+	case EventKillSwitch:
+		return s.NotifyKillSwitch
+	case EventExecutorCrash:
+		return s.NotifyExecutorCrash
+	case EventDailyPnL:
+		return s.NotifyDailyPnL
+	case EventLossCooldown:
+		return s.NotifyLossCooldown
+	case EventKeyInvalid:
+		return s.NotifyKeyInvalid
+	case EventCriticalError:
+		return s.NotifyCriticalError
+	case EventApprovalRequired:
+		return true
+	case EventPriceDivergence:
+		return s.NotifyPriceDivergence
+	default:
+		return false
+	}
+}
+
+func (n *Notifier) channelsFor(s *model.UserNotificationSetting) []Channel {
+	var channels []Channel
+
+	if s.TelegramChatID != "" && n.config.TelegramBotToken != "" {
+		channels = append(channels, NewTelegramChannel(n.httpClient, "", n.config.TelegramBotToken, s.TelegramChatID))
+	}
+	if s.DiscordWebhookURL != "" {
+		channels = append(channels, NewDiscordChannel(n.httpClient, s.DiscordWebhookURL))
+	}
+	if s.SlackWebhookURL != "" {
+		channels = append(channels, NewSlackChannel(n.httpClient, s.SlackWebhookURL))
+	}
+	if s.Email != "" && n.config.SMTPHost != "" {
+		channels = append(channels, NewEmailChannel(n.config, s.Email))
+	}
+
+	return channels
+}
@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type mockSettingsRepo struct {
+	settings *model.UserNotificationSetting
+	err      error
+}
+
+func (m *mockSettingsRepo) GetByUserID(ctx context.Context, userID uint) (*model.UserNotificationSetting, error) {
+	return m.settings, m.err
+}
+
+func TestRender(t *testing.T) {
+	at := time.Date(2025, 3, 4, 14, 0, 0, 0, time.UTC)
+
+	msg, err := Render(Event{Type: EventOrderPlaced, Exchange: "phemex", OrderID: 7, Side: "Buy", PosSide: "Long", Quantity: 0.5, Symbol: "BTCUSDT", OccurredAt: at})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if msg.Title != "Order placed: BTCUSDT Long" {
+		t.Fatalf("unexpected title: %q", msg.Title)
+	}
+	if msg.Body != "phemex order #7 Buy 0.5 BTCUSDT (Long) placed at 2025-03-04 14:00:00 UTC." {
+		t.Fatalf("unexpected body: %q", msg.Body)
+	}
+
+	msg, err = Render(Event{Type: EventOrderError, Exchange: "kraken", Symbol: "PF_XBTUSD", Err: errors.New("boom")})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if msg.Body != "kraken order on PF_XBTUSD failed: boom" {
+		t.Fatalf("unexpected body: %q", msg.Body)
+	}
+
+	msg, err = Render(Event{Type: EventLossCooldown, Symbol: "BTCUSDT", Message: "3 consecutive losing trades"})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if msg.Title != "Loss cooldown: BTCUSDT" || msg.Body != "New BTCUSDT entries paused: 3 consecutive losing trades" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+
+	msg, err = Render(Event{Type: EventApprovalRequired, Exchange: "phemex", OrderID: 9, Side: "Buy", PosSide: "Long", Quantity: 2, Symbol: "BTCUSDT", ApproveURL: "https://x/a", RejectURL: "https://x/r"})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if msg.Body != "phemex order #9 Buy 2 BTCUSDT (Long) waits for approval.\nApprove: https://x/a\nReject: https://x/r" {
+		t.Fatalf("unexpected body: %q", msg.Body)
+	}
+
+	if _, err := Render(Event{Type: "unknown"}); err == nil {
+		t.Fatalf("expected error for unknown event type")
+	}
+}
+
+func TestNotifierRoutesToEnabledChannels(t *testing.T) {
+	var paths []string
+	var payloads []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		paths = append(paths, r.URL.Path)
+		payloads = append(payloads, p)
+	}))
+	defer server.Close()
+
+	settings := &model.UserNotificationSetting{
+		UserID:            1,
+		DiscordWebhookURL: server.URL + "/discord",
+		SlackWebhookURL:   server.URL + "/slack",
+		NotifyOrderFilled: true,
+	}
+	n := NewNotifierWithSettings(&Config{SendTimeout: time.Second}, &mockSettingsRepo{settings: settings}, server.Client())
+
+	// disabled event type: nothing sent
+	n.Notify(context.Background(), Event{Type: EventOrderPlaced, UserID: 1, Symbol: "BTCUSDT"})
+	if len(paths) != 0 {
+		t.Fatalf("expected no delivery for disabled event, got %v", paths)
+	}
+
+	n.Notify(context.Background(), Event{Type: EventOrderFilled, UserID: 1, Symbol: "BTCUSDT", Exchange: "phemex"})
+	if len(paths) != 2 || paths[0] != "/discord" || paths[1] != "/slack" {
+		t.Fatalf("unexpected deliveries: %v", paths)
+	}
+	if !strings.Contains(payloads[0]["content"], "Order filled: BTCUSDT") {
+		t.Fatalf("unexpected discord payload: %v", payloads[0])
+	}
+	if !strings.Contains(payloads[1]["text"], "Order filled: BTCUSDT") {
+		t.Fatalf("unexpected slack payload: %v", payloads[1])
+	}
+}
+
+func TestNotifierIgnoresMissingSettingsAndErrors(t *testing.T) {
+	n := NewNotifierWithSettings(&Config{SendTimeout: time.Second}, &mockSettingsRepo{err: errors.New("db down")}, nil)
+	n.Notify(context.Background(), Event{Type: EventOrderFilled, UserID: 1})
+
+	n = NewNotifierWithSettings(&Config{SendTimeout: time.Second}, &mockSettingsRepo{}, nil)
+	n.Notify(context.Background(), Event{Type: EventOrderFilled, UserID: 1})
+
+	var nilNotifier *Notifier
+	nilNotifier.Notify(context.Background(), Event{Type: EventOrderFilled, UserID: 1})
+}
+
+func TestTelegramChannelSend(t *testing.T) {
+	var path string
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	ch := NewTelegramChannel(server.Client(), server.URL, "TOKEN", "42")
+	if err := ch.Send(context.Background(), Message{Title: "t", Body: "b"}); err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+	if path != "/botTOKEN/sendMessage" || payload["chat_id"] != "42" || payload["text"] != "t\nb" {
+		t.Fatalf("unexpected request: %s %v", path, payload)
+	}
+}
+
+func TestEmailChannelSend(t *testing.T) {
+	var gotAddr string
+	var gotMsg string
+	ch := NewEmailChannel(&Config{SMTPHost: "smtp.local", SMTPPort: 25, SMTPFrom: "bot@local"}, "me@local")
+	ch.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr = addr
+		gotMsg = string(msg)
+		return nil
+	}
+
+	if err := ch.Send(context.Background(), Message{Title: "Subject", Body: "Body"}); err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+	if gotAddr != "smtp.local:25" || !strings.Contains(gotMsg, "Subject: Subject") || !strings.HasSuffix(gotMsg, "Body") {
+		t.Fatalf("unexpected mail: %s %q", gotAddr, gotMsg)
+	}
+}
+
+func TestNotifierSubscribesToOrderEvents(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		bodies = append(bodies, p["content"])
+	}))
+	defer server.Close()
+
+	settings := &model.UserNotificationSetting{
+		UserID:              1,
+		DiscordWebhookURL:   server.URL,
+		NotifyOrderFilled:   true,
+		NotifyStopLossMoved: true,
+	}
+	n := NewNotifierWithSettings(&Config{SendTimeout: time.Second}, &mockSettingsRepo{settings: settings}, server.Client())
+	bus := events.NewBus()
+	n.Subscribe(bus)
+
+	ctx := context.Background()
+	bus.Publish(ctx, events.OrderPlaced{UserID: 1, Symbol: "BTCUSDT"})
+	bus.Publish(ctx, events.OrderFilled{UserID: 1, Exchange: "phemex", Symbol: "BTCUSDT", PosSide: "Long", Price: decimal.NewFromInt(100)})
+	bus.Publish(ctx, events.SLMoved{UserID: 1, Exchange: "phemex", Symbol: "BTCUSDT", StopLoss: decimal.NewFromInt(95)})
+
+	if len(bodies) != 2 || !strings.Contains(bodies[0], "filled at 100") || !strings.Contains(bodies[1], "stop loss moved to 95") {
+		t.Fatalf("unexpected deliveries: %q", bodies)
+	}
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+type eventTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+func mustTemplate(title, body string) eventTemplate {
+	return eventTemplate{
+		title: template.Must(template.New("title").Parse(title)),
+		body:  template.Must(template.New("body").Parse(body)),
+	}
+}
+
+var templates = map[EventType]eventTemplate{
+	EventOrderPlaced: mustTemplate(
+		"Order placed: {{.Symbol}} {{.PosSide}}",
+		"{{.Exchange}} order #{{.OrderID}} {{.Side}} {{.Quantity}} {{.Symbol}} ({{.PosSide}}) placed at {{.OccurredAt.Format \"2006-01-02 15:04:05 UTC\"}}.",
+	),
+	EventOrderFilled: mustTemplate(
+		"Order filled: {{.Symbol}} {{.PosSide}}",
+		"{{.Exchange}} order #{{.OrderID}} {{.Side}} {{.Quantity}} {{.Symbol}} filled{{if .Price}} at {{.Price}}{{end}}.",
+	),
+	EventOrderError: mustTemplate(
+		"Order error: {{.Symbol}}",
+		"{{.Exchange}} order{{if .OrderID}} #{{.OrderID}}{{end}} on {{.Symbol}} failed: {{.ErrText}}{{if .Message}} ({{.Message}}){{end}}",
+	),
+	EventStopLossMoved: mustTemplate(
+		"Stop loss moved: {{.Symbol}}",
+		"{{.Exchange}} order #{{.OrderID}} {{.Symbol}} ({{.PosSide}}) stop loss moved to {{.StopLoss}}.",
+	),
+	EventKillSwitch: mustTemplate(
+		"Kill switch triggered",
+		"Kill switch triggered for {{.Username}} on {{.Exchange}}{{if .Symbol}} {{.Symbol}}{{end}}: {{.Message}}",
+	),
+	EventExecutorCrash: mustTemplate(
+		"Executor stopped",
+		"Executor for {{.Username}} on {{.Exchange}} stopped: {{.ErrText}}",
+	),
+	EventDailyPnL: mustTemplate(
+		"Daily PnL: {{.Exchange}} {{.OccurredAt.Format \"2006-01-02\"}}",
+		"{{.Message}}",
+	),
+	EventLossCooldown: mustTemplate(
+		"Loss cooldown: {{.Symbol}}",
+		"New {{.Symbol}} entries paused: {{.Message}}",
+	),
+	EventKeyInvalid: mustTemplate(
+		"API key rejected: {{.Exchange}}",
+		"{{.Exchange}} rejected the API key of {{.Username}}: {{.Message}}",
+	),
+	EventCriticalError: mustTemplate(
+		"Critical error: {{.Exchange}}{{if .Symbol}} {{.Symbol}}{{end}}",
+		"{{.Message}} failed for {{.Username}} on {{.Exchange}}: {{.ErrText}}",
+	),
+	EventPriceDivergence: mustTemplate(
+		"Price divergence: {{.Exchange}} {{.Symbol}}",
+		"{{.Exchange}} {{.Symbol}} entry for {{.Username}}{{if .Side}} ({{.Side}}){{end}}: {{.Message}}",
+	),
+	EventApprovalRequired: mustTemplate(
+		"Approval required: {{.Symbol}} {{.PosSide}}",
+		"{{.Exchange}} order #{{.OrderID}} {{.Side}} {{.Quantity}} {{.Symbol}} ({{.PosSide}}) waits for approval{{if .Message}}, {{.Message}}{{end}}.{{if .ApproveURL}}\nApprove: {{.ApproveURL}}\nReject: {{.RejectURL}}{{end}}",
+	),
+}
+
+// templateData exposes Event plus the error as text for templates.
+type templateData struct {
+	Event
+	ErrText string
+}
+
+// Render builds the title and body for ev.
+func Render(ev Event) (Message, error) {
+	tpl, ok := templates[ev.Type]
+	if !ok {
+		return Message{}, fmt.Errorf("no template for event type %q", ev.Type)
+	}
+
+	data := templateData{Event: ev}
+	if ev.Err != nil {
+		data.ErrText = ev.Err.Error()
+	}
+
+	var title, body bytes.Buffer
+	if err := tpl.title.Execute(&title, data); err != nil {
+		return Message{}, err
+	}
+	if err := tpl.body.Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{Title: title.String(), Body: body.String()}, nil
+}
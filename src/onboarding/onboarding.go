@@ -0,0 +1,352 @@
+// Package onboarding walks a new user through a safe default path before
+// their account trades live: exchange keys validated against the exchange,
+// a symbol, strategy and risk profile from the presets, then a paper trade
+// on a shadow account. Only a filled paper trade unlocks run_on_server on
+// the live account.
+package onboarding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotOnboarding is returned for a user without an onboarding.
+	ErrNotOnboarding = errors.New("user is not onboarding")
+	// ErrWrongStep is returned for a step taken out of order.
+	ErrWrongStep = errors.New("onboarding step not allowed now")
+	// ErrInvalid wraps the request errors of a step.
+	ErrInvalid = errors.New("invalid onboarding request")
+	// ErrKeysRejected wraps the errors of storing the exchange keys,
+	// typically keys the exchange refused.
+	ErrKeysRejected = errors.New("exchange keys rejected")
+	// ErrUserExists is returned when the user name is taken.
+	ErrUserExists = errors.New("user name already taken")
+	// ErrPaperTradePending is returned when going live before the paper
+	// account filled a trade.
+	ErrPaperTradePending = errors.New("no paper trade filled yet")
+)
+
+// minPasswordLength is the shortest password accepted for new users.
+const minPasswordLength = 10
+
+// paperExchanges are the exchanges shadow accounts run on, see
+// executors.errShadowUnsupported; the paper trade cannot happen elsewhere.
+var paperExchanges = []string{"phemex"}
+
+// SetKeysFunc validates creds against exchange and stores them for userID
+// with run_on_server off.
+type SetKeysFunc func(ctx context.Context, userID uint, exchange string, creds security.Credentials, orderSizePercent int) (*model.UserExchange, error)
+
+type onboardingStore interface {
+	Create(ctx context.Context, o *model.Onboarding) error
+	FindByUser(ctx context.Context, userID uint) (*model.Onboarding, error)
+	Update(ctx context.Context, o *model.Onboarding) error
+}
+
+type userStore interface {
+	GetUserByUserName(ctx context.Context, userName string) (*model.User, error)
+	Create(ctx context.Context, u *model.User) error
+}
+
+type tokenStore interface {
+	Create(ctx context.Context, token *model.APIToken) error
+}
+
+type userExchangeStore interface {
+	GetByUserAndExchange(ctx context.Context, userID uint, exchangeID uint) (*model.UserExchange, error)
+	Update(ctx context.Context, ue *model.UserExchange) error
+}
+
+type strategyStore interface {
+	Upsert(ctx context.Context, s *model.Strategy) error
+}
+
+type paperFills interface {
+	CountFills(ctx context.Context, userID, exchangeID uint, symbol string, since time.Time) (int64, error)
+}
+
+// Flow runs the steps of the onboarding.
+type Flow struct {
+	onboardings   onboardingStore
+	users         userStore
+	tokens        tokenStore
+	userExchanges userExchangeStore
+	strategies    strategyStore
+	fills         paperFills
+	setKeys       SetKeysFunc
+	now           func() time.Time
+}
+
+// New returns a flow on the main database storing keys with setKeys.
+func New(setKeys SetKeysFunc) *Flow {
+	return &Flow{
+		onboardings:   repository.NewOnboardingRepository(),
+		users:         repository.NewUserRepository(),
+		tokens:        repository.NewAPITokenRepository(),
+		userExchanges: repository.NewUserExchangeRepository(),
+		strategies:    repository.NewStrategyRepository(),
+		fills:         repository.NewShadowCallRepository(),
+		setKeys:       setKeys,
+		now:           time.Now,
+	}
+}
+
+// CreateUserRequest is the first step, taken by an operator.
+type CreateUserRequest struct {
+	Username    string `json:"user_name"`
+	Password    string `json:"password"`
+	Email       string `json:"email"`
+	TestnetOnly bool   `json:"testnet_only"`
+}
+
+// KeysRequest are the exchange keys of the user.
+type KeysRequest struct {
+	Exchange string `json:"exchange"`
+	security.Credentials
+	// Environment is "live" or "testnet"; empty is live unless the user is
+	// testnet-only.
+	Environment string `json:"environment"`
+}
+
+// PresetRequest are the presets chosen by the user.
+type PresetRequest struct {
+	Symbol      string `json:"symbol"`
+	Strategy    string `json:"strategy"`
+	RiskProfile string `json:"risk_profile"`
+}
+
+// CreateUser creates the user and its onboarding, and issues the API token
+// the user takes the next steps with. The token is only returned here.
+func (f *Flow) CreateUser(ctx context.Context, req CreateUserRequest) (*model.User, string, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		return nil, "", fmt.Errorf("%w: user name is required", ErrInvalid)
+	}
+	if len(req.Password) < minPasswordLength {
+		return nil, "", fmt.Errorf("%w: password must be at least %d characters", ErrInvalid, minPasswordLength)
+	}
+	switch _, err := f.users.GetUserByUserName(ctx, req.Username); {
+	case err == nil:
+		return nil, "", ErrUserExists
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, "", fmt.Errorf("find user: %w", err)
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", fmt.Errorf("hash password: %w", err)
+	}
+
+	user := &model.User{
+		Username:    req.Username,
+		Password:    string(hashed),
+		Email:       strings.TrimSpace(req.Email),
+		TestnetOnly: req.TestnetOnly,
+	}
+	if err := f.users.Create(ctx, user); err != nil {
+		return nil, "", fmt.Errorf("create user: %w", err)
+	}
+	if err := f.onboardings.Create(ctx, &model.Onboarding{UserID: user.ID, Step: model.OnboardingStepKeys}); err != nil {
+		return nil, "", fmt.Errorf("create onboarding: %w", err)
+	}
+
+	token, hash, err := auth.GenerateToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("generate token: %w", err)
+	}
+	if err := f.tokens.Create(ctx, &model.APIToken{UserID: user.ID, Name: "onboarding", TokenHash: hash}); err != nil {
+		return nil, "", fmt.Errorf("create token: %w", err)
+	}
+
+	logger.WithFields(logger.Fields{"user_id": user.ID, "user_name": user.Username}).Info("user created for onboarding")
+	return user, token, nil
+}
+
+// State returns the onboarding of userID.
+func (f *Flow) State(ctx context.Context, userID uint) (*model.Onboarding, error) {
+	o, err := f.onboardings.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, ErrNotOnboarding
+	}
+	return o, nil
+}
+
+// state returns the onboarding of userID when it is at one of steps.
+func (f *Flow) state(ctx context.Context, userID uint, steps ...string) (*model.Onboarding, error) {
+	o, err := f.State(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(steps, o.Step) {
+		return nil, fmt.Errorf("%w: onboarding is at step %s", ErrWrongStep, o.Step)
+	}
+	return o, nil
+}
+
+// SetKeys validates the keys against the exchange and stores them with
+// run_on_server off. Keys may be replaced until the paper trade starts.
+func (f *Flow) SetKeys(ctx context.Context, userID uint, req KeysRequest) (*model.Onboarding, error) {
+	o, err := f.state(ctx, userID, model.OnboardingStepKeys, model.OnboardingStepPreset, model.OnboardingStepPaper)
+	if err != nil {
+		return nil, err
+	}
+	if o.PaperStartedAt != nil {
+		return nil, fmt.Errorf("%w: the paper trade already started", ErrWrongStep)
+	}
+	exchange := strings.ToLower(strings.TrimSpace(req.Exchange))
+	if !slices.Contains(paperExchanges, exchange) {
+		return nil, fmt.Errorf("%w: onboarding supports %s", ErrInvalid, strings.Join(paperExchanges, ", "))
+	}
+	if req.APIKey == "" || req.APISecret == "" {
+		return nil, fmt.Errorf("%w: api key and secret are required", ErrInvalid)
+	}
+
+	req.Credentials.Environment = req.Environment
+	ue, err := f.setKeys(ctx, userID, exchange, req.Credentials, RiskProfiles[0].OrderSizePercent)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeysRejected, err)
+	}
+
+	if o.ExchangeID != ue.ExchangeID {
+		// The presets were chosen for another account.
+		o.Symbol, o.Strategy, o.RiskProfile = "", "", ""
+		o.Step = model.OnboardingStepPreset
+	}
+	if o.Step == model.OnboardingStepKeys {
+		o.Step = model.OnboardingStepPreset
+	}
+	o.ExchangeID = ue.ExchangeID
+	if err := f.onboardings.Update(ctx, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ChoosePreset applies the chosen risk profile to the account and assigns
+// the chosen strategy on the symbol.
+func (f *Flow) ChoosePreset(ctx context.Context, userID uint, req PresetRequest) (*model.Onboarding, error) {
+	o, err := f.state(ctx, userID, model.OnboardingStepPreset, model.OnboardingStepPaper)
+	if err != nil {
+		return nil, err
+	}
+	if o.PaperStartedAt != nil {
+		return nil, fmt.Errorf("%w: the paper trade already started", ErrWrongStep)
+	}
+	presets := CurrentPresets()
+	if !slices.Contains(presets.Symbols, req.Symbol) {
+		return nil, fmt.Errorf("%w: symbol must be one of %s", ErrInvalid, strings.Join(presets.Symbols, ", "))
+	}
+	if !slices.Contains(presets.Strategies, req.Strategy) {
+		return nil, fmt.Errorf("%w: strategy must be one of %s", ErrInvalid, strings.Join(presets.Strategies, ", "))
+	}
+	profile, ok := findRiskProfile(req.RiskProfile)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown risk profile %q", ErrInvalid, req.RiskProfile)
+	}
+
+	ue, err := f.userExchanges.GetByUserAndExchange(ctx, userID, o.ExchangeID)
+	if err != nil {
+		return nil, fmt.Errorf("GetByUserAndExchange: %w", err)
+	}
+	profile.Apply(ue)
+	if err := f.userExchanges.Update(ctx, ue); err != nil {
+		return nil, fmt.Errorf("update user exchange: %w", err)
+	}
+	err = f.strategies.Upsert(ctx, &model.Strategy{
+		UserID:     userID,
+		ExchangeID: o.ExchangeID,
+		Symbol:     req.Symbol,
+		Name:       req.Strategy,
+		Enabled:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("assign strategy: %w", err)
+	}
+
+	o.Symbol, o.Strategy, o.RiskProfile = req.Symbol, req.Strategy, profile.Name
+	o.Step = model.OnboardingStepPaper
+	if err := f.onboardings.Update(ctx, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// StartPaper runs the account on the server as a shadow account: the next
+// signals trade on paper at live prices.
+func (f *Flow) StartPaper(ctx context.Context, userID uint) (*model.Onboarding, error) {
+	o, err := f.state(ctx, userID, model.OnboardingStepPaper)
+	if err != nil {
+		return nil, err
+	}
+	if o.PaperStartedAt != nil {
+		return o, nil
+	}
+
+	ue, err := f.userExchanges.GetByUserAndExchange(ctx, userID, o.ExchangeID)
+	if err != nil {
+		return nil, fmt.Errorf("GetByUserAndExchange: %w", err)
+	}
+	ue.Shadow, ue.RunOnServer = true, true
+	if err := f.userExchanges.Update(ctx, ue); err != nil {
+		return nil, fmt.Errorf("update user exchange: %w", err)
+	}
+
+	now := f.now().UTC()
+	o.PaperStartedAt = &now
+	if err := f.onboardings.Update(ctx, o); err != nil {
+		return nil, err
+	}
+	logger.WithFields(logger.Fields{"user_id": userID, "exchange_id": o.ExchangeID, "symbol": o.Symbol}).Info("onboarding paper trading started")
+	return o, nil
+}
+
+// GoLive turns the shadow account into the live account once the paper
+// account filled a trade on the chosen symbol.
+func (f *Flow) GoLive(ctx context.Context, userID uint) (*model.Onboarding, error) {
+	o, err := f.state(ctx, userID, model.OnboardingStepPaper)
+	if err != nil {
+		return nil, err
+	}
+	if o.PaperStartedAt == nil {
+		return nil, fmt.Errorf("%w: start the paper trade first", ErrWrongStep)
+	}
+	fills, err := f.fills.CountFills(ctx, userID, o.ExchangeID, o.Symbol, *o.PaperStartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("count paper fills: %w", err)
+	}
+	if fills == 0 {
+		return nil, ErrPaperTradePending
+	}
+
+	ue, err := f.userExchanges.GetByUserAndExchange(ctx, userID, o.ExchangeID)
+	if err != nil {
+		return nil, fmt.Errorf("GetByUserAndExchange: %w", err)
+	}
+	ue.Shadow, ue.RunOnServer = false, true
+	if err := f.userExchanges.Update(ctx, ue); err != nil {
+		return nil, fmt.Errorf("update user exchange: %w", err)
+	}
+
+	now := f.now().UTC()
+	o.Step, o.CompletedAt = model.OnboardingStepDone, &now
+	if err := f.onboardings.Update(ctx, o); err != nil {
+		return nil, err
+	}
+	logger.WithFields(logger.Fields{"user_id": userID, "exchange_id": o.ExchangeID}).Info("onboarding completed, account runs live")
+	return o, nil
+}
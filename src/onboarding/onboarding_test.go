@@ -0,0 +1,200 @@
+package onboarding
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+	_ "strategyexecutor/src/strategy/signalfollower"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type fakeOnboardings map[uint]*model.Onboarding
+
+func (f fakeOnboardings) Create(_ context.Context, o *model.Onboarding) error {
+	cp := *o
+	f[o.UserID] = &cp
+	return nil
+}
+
+func (f fakeOnboardings) FindByUser(_ context.Context, userID uint) (*model.Onboarding, error) {
+	o, ok := f[userID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *o
+	return &cp, nil
+}
+
+func (f fakeOnboardings) Update(_ context.Context, o *model.Onboarding) error {
+	cp := *o
+	f[o.UserID] = &cp
+	return nil
+}
+
+type fakeUsers struct{ rows []model.User }
+
+func (f *fakeUsers) GetUserByUserName(_ context.Context, name string) (*model.User, error) {
+	for i := range f.rows {
+		if f.rows[i].Username == name {
+			return &f.rows[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeUsers) Create(_ context.Context, u *model.User) error {
+	u.ID = uint(len(f.rows) + 1)
+	f.rows = append(f.rows, *u)
+	return nil
+}
+
+type fakeTokens []model.APIToken
+
+func (f *fakeTokens) Create(_ context.Context, token *model.APIToken) error {
+	*f = append(*f, *token)
+	return nil
+}
+
+type fakeUserExchanges map[[2]uint]*model.UserExchange
+
+func (f fakeUserExchanges) GetByUserAndExchange(_ context.Context, userID, exchangeID uint) (*model.UserExchange, error) {
+	ue, ok := f[[2]uint{userID, exchangeID}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *ue
+	return &cp, nil
+}
+
+func (f fakeUserExchanges) Update(_ context.Context, ue *model.UserExchange) error {
+	cp := *ue
+	f[[2]uint{ue.UserID, ue.ExchangeID}] = &cp
+	return nil
+}
+
+type fakeStrategies []model.Strategy
+
+func (f *fakeStrategies) Upsert(_ context.Context, s *model.Strategy) error {
+	*f = append(*f, *s)
+	return nil
+}
+
+type fakeFills struct {
+	n     int64
+	since time.Time
+}
+
+func (f *fakeFills) CountFills(_ context.Context, _, _ uint, _ string, since time.Time) (int64, error) {
+	f.since = since
+	return f.n, nil
+}
+
+func TestFlowWalksTheSafePath(t *testing.T) {
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	onboardings := fakeOnboardings{}
+	users := &fakeUsers{}
+	tokens := &fakeTokens{}
+	userExchanges := fakeUserExchanges{}
+	strategies := &fakeStrategies{}
+	fills := &fakeFills{}
+	var keysErr error
+	f := &Flow{
+		onboardings:   onboardings,
+		users:         users,
+		tokens:        tokens,
+		userExchanges: userExchanges,
+		strategies:    strategies,
+		fills:         fills,
+		setKeys: func(_ context.Context, userID uint, exchange string, creds security.Credentials, percent int) (*model.UserExchange, error) {
+			if keysErr != nil {
+				return nil, keysErr
+			}
+			require.Equal(t, "phemex", exchange)
+			require.Equal(t, "testnet", creds.Environment)
+			ue := &model.UserExchange{UserID: userID, ExchangeID: 1, OrderSizePercent: percent}
+			userExchanges[[2]uint{userID, 1}] = ue
+			return ue, nil
+		},
+		now: func() time.Time { return now },
+	}
+	ctx := context.Background()
+
+	_, _, err := f.CreateUser(ctx, CreateUserRequest{Username: "alice", Password: "short"})
+	require.ErrorIs(t, err, ErrInvalid)
+	user, token, err := f.CreateUser(ctx, CreateUserRequest{Username: " alice ", Password: "correct horse"})
+	require.NoError(t, err)
+	require.Equal(t, "alice", user.Username)
+	require.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("correct horse")))
+	require.NotEmpty(t, token)
+	require.Len(t, *tokens, 1)
+	require.Equal(t, user.ID, (*tokens)[0].UserID)
+	_, _, err = f.CreateUser(ctx, CreateUserRequest{Username: "alice", Password: "correct horse"})
+	require.ErrorIs(t, err, ErrUserExists)
+
+	_, err = f.State(ctx, 99)
+	require.ErrorIs(t, err, ErrNotOnboarding)
+
+	// steps are taken in order
+	_, err = f.ChoosePreset(ctx, user.ID, PresetRequest{Symbol: "BTCUSDT", Strategy: "signal_follower", RiskProfile: "balanced"})
+	require.ErrorIs(t, err, ErrWrongStep)
+	_, err = f.StartPaper(ctx, user.ID)
+	require.ErrorIs(t, err, ErrWrongStep)
+
+	keys := KeysRequest{Exchange: "Phemex", Credentials: security.Credentials{APIKey: "k", APISecret: "s"}, Environment: "testnet"}
+	_, err = f.SetKeys(ctx, user.ID, KeysRequest{Exchange: "kraken", Credentials: keys.Credentials})
+	require.ErrorIs(t, err, ErrInvalid, "no paper trading on kraken")
+	keysErr = errors.New("invalid api key")
+	_, err = f.SetKeys(ctx, user.ID, keys)
+	require.ErrorIs(t, err, ErrKeysRejected)
+	keysErr = nil
+	o, err := f.SetKeys(ctx, user.ID, keys)
+	require.NoError(t, err)
+	require.Equal(t, model.OnboardingStepPreset, o.Step)
+	require.Equal(t, uint(1), o.ExchangeID)
+
+	_, err = f.ChoosePreset(ctx, user.ID, PresetRequest{Symbol: "DOGEUSDT", Strategy: "signal_follower", RiskProfile: "balanced"})
+	require.ErrorIs(t, err, ErrInvalid)
+	_, err = f.ChoosePreset(ctx, user.ID, PresetRequest{Symbol: "BTCUSDT", Strategy: "signal_follower", RiskProfile: "yolo"})
+	require.ErrorIs(t, err, ErrInvalid)
+	o, err = f.ChoosePreset(ctx, user.ID, PresetRequest{Symbol: "BTCUSDT", Strategy: "signal_follower", RiskProfile: "balanced"})
+	require.NoError(t, err)
+	require.Equal(t, model.OnboardingStepPaper, o.Step)
+	ue := userExchanges[[2]uint{user.ID, 1}]
+	require.Equal(t, 5, ue.OrderSizePercent)
+	require.Equal(t, "5", ue.MaxLeverage.String())
+	require.False(t, ue.RunOnServer)
+	require.Equal(t, []model.Strategy{{UserID: user.ID, ExchangeID: 1, Symbol: "BTCUSDT", Name: "signal_follower", Enabled: true}}, []model.Strategy(*strategies))
+
+	// live needs a filled paper trade
+	_, err = f.GoLive(ctx, user.ID)
+	require.ErrorIs(t, err, ErrWrongStep)
+	o, err = f.StartPaper(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, now, *o.PaperStartedAt)
+	ue = userExchanges[[2]uint{user.ID, 1}]
+	require.True(t, ue.Shadow)
+	require.True(t, ue.RunOnServer)
+	_, err = f.SetKeys(ctx, user.ID, keys)
+	require.ErrorIs(t, err, ErrWrongStep, "keys are fixed once trading on paper")
+
+	_, err = f.GoLive(ctx, user.ID)
+	require.ErrorIs(t, err, ErrPaperTradePending)
+	fills.n = 1
+	now = now.Add(time.Hour)
+	o, err = f.GoLive(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.OnboardingStepDone, o.Step)
+	require.Equal(t, *o.PaperStartedAt, fills.since)
+	ue = userExchanges[[2]uint{user.ID, 1}]
+	require.False(t, ue.Shadow)
+	require.True(t, ue.RunOnServer)
+
+	_, err = f.StartPaper(ctx, user.ID)
+	require.ErrorIs(t, err, ErrWrongStep)
+}
@@ -0,0 +1,56 @@
+package onboarding
+
+import (
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// RiskProfile is a preset of the risk settings of an account.
+type RiskProfile struct {
+	Name             string          `json:"name"`
+	OrderSizePercent int             `json:"order_size_percent"`
+	MaxLeverage      decimal.Decimal `json:"max_leverage"`
+	DefaultSLPct     decimal.Decimal `json:"default_sl_pct"`
+	DefaultTPPct     decimal.Decimal `json:"default_tp_pct"`
+}
+
+// Apply copies the settings of p to ue.
+func (p RiskProfile) Apply(ue *model.UserExchange) {
+	ue.OrderSizePercent = p.OrderSizePercent
+	ue.MaxLeverage = p.MaxLeverage
+	ue.DefaultSLPct = p.DefaultSLPct
+	ue.DefaultTPPct = p.DefaultTPPct
+}
+
+// RiskProfiles are the risk presets offered, safest first.
+var RiskProfiles = []RiskProfile{
+	{Name: "conservative", OrderSizePercent: 2, MaxLeverage: decimal.NewFromInt(2), DefaultSLPct: decimal.NewFromFloat(1.5), DefaultTPPct: decimal.NewFromInt(3)},
+	{Name: "balanced", OrderSizePercent: 5, MaxLeverage: decimal.NewFromInt(5), DefaultSLPct: decimal.NewFromInt(2), DefaultTPPct: decimal.NewFromInt(4)},
+	{Name: "aggressive", OrderSizePercent: 10, MaxLeverage: decimal.NewFromInt(10), DefaultSLPct: decimal.NewFromInt(3), DefaultTPPct: decimal.NewFromInt(6)},
+}
+
+// Symbols are the symbols a new account may start on.
+var Symbols = []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}
+
+// Presets are the choices of the preset step.
+type Presets struct {
+	Symbols      []string      `json:"symbols"`
+	Strategies   []string      `json:"strategies"`
+	RiskProfiles []RiskProfile `json:"risk_profiles"`
+}
+
+// CurrentPresets returns the presets, with every registered strategy.
+func CurrentPresets() Presets {
+	return Presets{Symbols: Symbols, Strategies: strategy.Names(), RiskProfiles: RiskProfiles}
+}
+
+func findRiskProfile(name string) (RiskProfile, bool) {
+	for _, p := range RiskProfiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return RiskProfile{}, false
+}
@@ -0,0 +1,29 @@
+package orderhook
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	SendTimeout time.Duration `envconfig:"ORDER_WEBHOOK_TIMEOUT" default:"10s"`
+	// MaxAttempts is the number of POSTs after which a delivery is dead.
+	MaxAttempts int `envconfig:"ORDER_WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	// Backoff is the delay before the first retry, doubled after every
+	// failed attempt up to MaxBackoff.
+	Backoff    time.Duration `envconfig:"ORDER_WEBHOOK_BACKOFF" default:"10s"`
+	MaxBackoff time.Duration `envconfig:"ORDER_WEBHOOK_MAX_BACKOFF" default:"1h"`
+	// Poll is how often the due retries are looked for.
+	Poll  time.Duration `envconfig:"ORDER_WEBHOOK_POLL" default:"5s"`
+	Batch int           `envconfig:"ORDER_WEBHOOK_BATCH" default:"50"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
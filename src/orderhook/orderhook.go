@@ -0,0 +1,272 @@
+// Package orderhook POSTs the order events of users to the webhooks they
+// configured (model.OrderWebhook), e.g. to feed their own accounting.
+//
+// Events are stored as deliveries first and sent by Run, so a webhook that
+// is down gets them later: a failed POST is retried with an exponential
+// backoff until it succeeds or MaxAttempts is reached.
+package orderhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strategyexecutor/src/webhook"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Headers of a delivery. SignatureHeader is "sha256=" followed by the hex
+// HMAC-SHA256 of the body keyed by the secret of the webhook, as verified
+// by webhook.HMAC.
+const (
+	SignatureHeader = "X-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Store persists the webhooks and their deliveries.
+type Store interface {
+	ListEnabled(ctx context.Context, userID uint) ([]model.OrderWebhook, error)
+	FindByID(ctx context.Context, id uint) (*model.OrderWebhook, error)
+	Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id uint, status int, at time.Time) error
+	MarkFailed(ctx context.Context, id uint, status int, cause error, retryAt time.Time, dead bool) error
+}
+
+// Payload is the JSON body POSTed for an event.
+type Payload struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// Dispatcher queues the order events of the webhooks and sends them.
+type Dispatcher struct {
+	config  *Config
+	store   Store
+	client  *http.Client
+	decrypt func(string) (string, error)
+	now     func() time.Time
+	// wake makes Run send a new delivery without waiting for the next poll.
+	wake chan struct{}
+}
+
+// New returns a Dispatcher backed by the main database.
+func New() *Dispatcher {
+	config := GetConfig()
+	return &Dispatcher{
+		config:  config,
+		store:   repository.NewOrderWebhookRepository(),
+		client:  newClient(config.SendTimeout),
+		decrypt: security.DecryptString,
+		now:     time.Now,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Subscribe makes d queue the OrderFilled and OrderFailed events published
+// on bus.
+func (d *Dispatcher) Subscribe(bus *events.Bus) {
+	events.Subscribe(bus, func(ctx context.Context, ev events.OrderFilled) {
+		d.Enqueue(ctx, ev.UserID, ev.Name(), ev.FilledAt, ev)
+	})
+	events.Subscribe(bus, func(ctx context.Context, ev events.OrderFailed) {
+		d.Enqueue(ctx, ev.UserID, ev.Name(), ev.FailedAt, ev)
+	})
+}
+
+// Enqueue stores a delivery of event for every enabled webhook of userID
+// subscribed to it. Failures are logged: the order flow must not break on a
+// webhook.
+func (d *Dispatcher) Enqueue(ctx context.Context, userID uint, event string, at time.Time, data interface{}) {
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"component": "orderhook",
+		"user_id":   userID,
+		"event":     event,
+	})
+	hooks, err := d.store.ListEnabled(ctx, userID)
+	if err != nil {
+		log.WithError(err).Error("failed to list order webhooks")
+		return
+	}
+
+	var body []byte
+	var deliveries []model.WebhookDelivery
+	now := d.now().UTC()
+	for _, h := range hooks {
+		if !Subscribed(h.Events, event) {
+			continue
+		}
+		if body == nil {
+			if body, err = json.Marshal(Payload{Event: event, OccurredAt: at.UTC(), Data: data}); err != nil {
+				log.WithError(err).Error("failed to encode order webhook payload")
+				return
+			}
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookID:     h.ID,
+			UserID:        userID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := d.store.Enqueue(ctx, deliveries); err != nil {
+		log.WithError(err).Error("failed to queue order webhook deliveries")
+		return
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Subscribed reports whether a webhook with the comma separated events
+// filter receives event. An empty filter receives every event.
+func Subscribed(filter, event string) bool {
+	if strings.TrimSpace(filter) == "" {
+		return true
+	}
+	for _, e := range strings.Split(filter, ",") {
+		if strings.EqualFold(strings.TrimSpace(e), event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run sends the due deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Poll)
+	defer ticker.Stop()
+	for {
+		d.SendDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// SendDue sends the deliveries due now, a batch at a time.
+func (d *Dispatcher) SendDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		// A delivery stays claimed for longer than its POST may take, so
+		// another worker does not send it twice.
+		due, err := d.store.Claim(ctx, d.now().UTC(), 2*d.config.SendTimeout, d.config.Batch)
+		if err != nil {
+			logger.WithError(err).Error("failed to claim order webhook deliveries")
+			return
+		}
+		for i := range due {
+			d.send(ctx, &due[i])
+		}
+		if len(due) < d.config.Batch {
+			return
+		}
+	}
+}
+
+// send POSTs delivery and records the outcome.
+func (d *Dispatcher) send(ctx context.Context, delivery *model.WebhookDelivery) {
+	log := logger.WithContext(ctx).WithFields(map[string]interface{}{
+		"component":   "orderhook",
+		"delivery_id": delivery.ID,
+		"webhook_id":  delivery.WebhookID,
+		"attempt":     delivery.Attempts + 1,
+	})
+
+	status, err := d.post(ctx, delivery)
+	now := d.now().UTC()
+	if err == nil {
+		if err := d.store.MarkDelivered(ctx, delivery.ID, status, now); err != nil {
+			log.WithError(err).Error("failed to record order webhook delivery")
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	dead := attempts >= d.config.MaxAttempts
+	retryAt := now.Add(Backoff(d.config.Backoff, d.config.MaxBackoff, attempts))
+	if dead {
+		log.WithError(err).Error("order webhook delivery failed for the last time")
+	} else {
+		log.WithError(err).WithField("retry_at", retryAt).Warn("order webhook delivery failed")
+	}
+	// Record the attempt even when ctx was cancelled during the POST.
+	if err := d.store.MarkFailed(context.WithoutCancel(ctx), delivery.ID, status, err, retryAt, dead); err != nil {
+		log.WithError(err).Error("failed to record order webhook failure")
+	}
+}
+
+// post sends delivery to its webhook and returns the status answered, zero
+// when none was. Any status but 2xx is an error, a redirect included. The
+// answered body is not kept: it is shown back to the user.
+func (d *Dispatcher) post(ctx context.Context, delivery *model.WebhookDelivery) (int, error) {
+	hook, err := d.store.FindByID(ctx, delivery.WebhookID)
+	if err != nil {
+		return 0, fmt.Errorf("load webhook: %w", err)
+	}
+	if hook == nil {
+		return 0, fmt.Errorf("webhook %d not found", delivery.WebhookID)
+	}
+	secret, err := d.decrypt(hook.SecretEnc)
+	if err != nil {
+		return 0, fmt.Errorf("decrypt webhook secret: %w", err)
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, "sha256="+webhook.Sign(secret, body))
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff is the delay before the retry following attempt failed attempts:
+// base doubled after each of them, capped at max.
+func Backoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,210 @@
+package orderhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/webhook"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	hooks      []model.OrderWebhook
+	deliveries []model.WebhookDelivery
+}
+
+func (s *fakeStore) ListEnabled(_ context.Context, userID uint) ([]model.OrderWebhook, error) {
+	var out []model.OrderWebhook
+	for _, h := range s.hooks {
+		if h.UserID == userID && h.Enabled {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) FindByID(_ context.Context, id uint) (*model.OrderWebhook, error) {
+	for i := range s.hooks {
+		if s.hooks[i].ID == id {
+			return &s.hooks[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeStore) Enqueue(_ context.Context, deliveries []model.WebhookDelivery) error {
+	for _, d := range deliveries {
+		d.ID = uint(len(s.deliveries) + 1)
+		s.deliveries = append(s.deliveries, d)
+	}
+	return nil
+}
+
+func (s *fakeStore) Claim(_ context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	var out []model.WebhookDelivery
+	for i := range s.deliveries {
+		d := &s.deliveries[i]
+		if d.Status == model.WebhookDeliveryPending && !d.NextAttemptAt.After(now) && len(out) < limit {
+			out = append(out, *d)
+			d.NextAttemptAt = now.Add(lease)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) MarkDelivered(_ context.Context, id uint, status int, at time.Time) error {
+	d := &s.deliveries[id-1]
+	d.Status, d.LastStatus, d.DeliveredAt = model.WebhookDeliveryDelivered, status, &at
+	d.Attempts++
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(_ context.Context, id uint, status int, cause error, retryAt time.Time, dead bool) error {
+	d := &s.deliveries[id-1]
+	d.LastStatus, d.LastError, d.NextAttemptAt = status, cause.Error(), retryAt
+	d.Attempts++
+	if dead {
+		d.Status = model.WebhookDeliveryDead
+	}
+	return nil
+}
+
+func TestDispatcherSignsAndRetriesDeliveries(t *testing.T) {
+	failures := 2
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := webhook.HMAC(SignatureHeader, "s3cret").Verify(r, body); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		bodies = append(bodies, body)
+	}))
+	defer srv.Close()
+
+	store := &fakeStore{hooks: []model.OrderWebhook{
+		{ID: 1, UserID: 7, URL: srv.URL, SecretEnc: "s3cret", Events: "order_filled", Enabled: true},
+		{ID: 2, UserID: 7, URL: srv.URL, SecretEnc: "s3cret", Events: "order_failed", Enabled: true},
+		{ID: 3, UserID: 8, URL: srv.URL, SecretEnc: "s3cret", Enabled: true},
+	}}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	d := &Dispatcher{
+		config:  &Config{MaxAttempts: 3, Backoff: 10 * time.Second, MaxBackoff: time.Minute, SendTimeout: time.Second, Batch: 10},
+		store:   store,
+		client:  srv.Client(),
+		decrypt: func(s string) (string, error) { return s, nil },
+		now:     func() time.Time { return now },
+		wake:    make(chan struct{}, 1),
+	}
+	bus := events.NewBus()
+	d.Subscribe(bus)
+
+	bus.Publish(context.Background(), events.OrderFilled{UserID: 7, OrderID: 42, Symbol: "BTCUSDT", Quantity: decimal.NewFromInt(1), FilledAt: now})
+	require.Len(t, store.deliveries, 1, "only the webhook subscribed to fills")
+
+	d.SendDue(context.Background())
+	require.Equal(t, 503, store.deliveries[0].LastStatus)
+	require.Equal(t, now.Add(10*time.Second), store.deliveries[0].NextAttemptAt)
+	now = now.Add(10 * time.Second)
+	d.SendDue(context.Background())
+	require.Equal(t, now.Add(20*time.Second), store.deliveries[0].NextAttemptAt, "backoff doubled")
+	now = now.Add(20 * time.Second)
+	d.SendDue(context.Background())
+	require.Equal(t, model.WebhookDeliveryDelivered, store.deliveries[0].Status)
+	require.Equal(t, 3, store.deliveries[0].Attempts)
+
+	require.Len(t, bodies, 1)
+	var payload struct {
+		Event string             `json:"event"`
+		Data  events.OrderFilled `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(bodies[0], &payload))
+	require.Equal(t, "order_filled", payload.Event)
+	require.Equal(t, uint(42), payload.Data.OrderID)
+
+	// a webhook that keeps failing ends up dead
+	store.hooks[1].SecretEnc = "rotated"
+	bus.Publish(context.Background(), events.OrderFailed{UserID: 7, OrderID: 43, Error: "insufficient margin", FailedAt: now})
+	for i := 0; i < 3; i++ {
+		d.SendDue(context.Background())
+		now = now.Add(time.Minute)
+	}
+	require.Equal(t, model.WebhookDeliveryDead, store.deliveries[1].Status)
+	require.Equal(t, 401, store.deliveries[1].LastStatus)
+}
+
+func TestBackoffAndSubscribed(t *testing.T) {
+	require.Equal(t, 10*time.Second, Backoff(10*time.Second, time.Minute, 1))
+	require.Equal(t, 40*time.Second, Backoff(10*time.Second, time.Minute, 3))
+	require.Equal(t, time.Minute, Backoff(10*time.Second, time.Minute, 30))
+
+	require.True(t, Subscribed("", "order_failed"))
+	require.True(t, Subscribed("order_filled, order_failed", "order_failed"))
+	require.False(t, Subscribed("order_filled", "order_failed"))
+}
+
+func TestDeliveriesOnlyReachPublicAddresses(t *testing.T) {
+	for _, raw := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8200/v1/secret",
+		"http://10.0.0.5/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://0.0.0.0/hook",
+		"ftp://93.184.216.34/hook",
+	} {
+		_, err := ValidateURL(context.Background(), raw)
+		require.Error(t, err, raw)
+	}
+	u, err := ValidateURL(context.Background(), " https://93.184.216.34/hook ")
+	require.NoError(t, err)
+	require.Equal(t, "https://93.184.216.34/hook", u.String())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/hook", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("internal secret"))
+	}))
+	defer srv.Close()
+
+	_, err = newClient(time.Second).Post(srv.URL, "application/json", nil)
+	require.ErrorIs(t, err, ErrForbiddenAddress, "refused at dial time")
+
+	// against a loopback test server, the redirect and body checks only
+	client := newClient(time.Second)
+	client.Transport = srv.Client().Transport
+	store := &fakeStore{
+		hooks: []model.OrderWebhook{{ID: 1, URL: srv.URL + "/moved"}, {ID: 2, URL: srv.URL + "/hook"}},
+		deliveries: []model.WebhookDelivery{
+			{ID: 1, WebhookID: 1, Status: model.WebhookDeliveryPending},
+			{ID: 2, WebhookID: 2, Status: model.WebhookDeliveryPending},
+		},
+	}
+	d := &Dispatcher{
+		config:  &Config{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute, SendTimeout: time.Second, Batch: 10},
+		store:   store,
+		client:  client,
+		decrypt: func(s string) (string, error) { return s, nil },
+		now:     time.Now,
+	}
+	d.SendDue(context.Background())
+	require.Equal(t, http.StatusFound, store.deliveries[0].LastStatus, "redirect not followed")
+	require.Equal(t, http.StatusInternalServerError, store.deliveries[1].LastStatus)
+	require.NotContains(t, store.deliveries[1].LastError, "internal secret")
+}
@@ -0,0 +1,311 @@
+// Package outbox executes exchange calls recorded as model.OrderIntent rows
+// and settles the intents a crash left pending or dispatched.
+//
+// An intent is written in the same transaction as its order. It moves to
+// dispatched right before the call and to done or failed once the exchange
+// answers, so on restart:
+//   - pending means the call never started: the intent fails and the order
+//     is marked as error so the controller can act on the signal again;
+//   - dispatched means the outcome is unknown: the order is looked up on the
+//     exchange by its client order id, then by the ids of the post-only
+//     placements of a passive entry.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/makerentry"
+	"strategyexecutor/src/model"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+// ErrLookupUnsupported is returned by exchanges that cannot find an order by
+// client order id. Their interrupted intents end up unknown.
+var ErrLookupUnsupported = errors.New("exchange cannot look orders up by client order id")
+
+// Store persists the intent state transitions.
+type Store interface {
+	ListUnresolved(ctx context.Context, userID, exchangeID uint) ([]model.OrderIntent, error)
+	MarkDispatched(ctx context.Context, id uint, at time.Time) error
+	MarkDone(ctx context.Context, id uint, exchangeOrderID string, at time.Time) error
+	MarkResolved(ctx context.Context, id uint, status, errText string, at time.Time) error
+}
+
+// OrderUpdater updates the order an intent belongs to.
+type OrderUpdater interface {
+	UpdateStatusWithAutoLog(ctx context.Context, orderID uint, newStatus string, reason string) error
+}
+
+// Dispatcher runs intents against one exchange account.
+type Dispatcher struct {
+	Store    Store
+	Exchange connectors.ClientOrderPlacer
+	Now      func() time.Time
+	// Placements is the number of post-only placements a passive entry may
+	// make before the rest is sent at market, see makerentry. They are
+	// looked up when an interrupted intent is not found itself.
+	Placements int
+}
+
+// ExchangeFor returns c as a ClientOrderPlacer. Connectors without client
+// order ids are adapted: orders are placed with PlaceOrder and lookups fail
+// with ErrLookupUnsupported.
+func ExchangeFor(c connectors.Connector) connectors.ClientOrderPlacer {
+	if p, ok := c.(connectors.ClientOrderPlacer); ok {
+		return p
+	}
+	return placeOnly{c}
+}
+
+type placeOnly struct{ connectors.Connector }
+
+func (p placeOnly) PlaceOrderWithClientID(_, symbol, side, posSide, qty, ordType string, reduce bool) (*connectors.APIResponse, error) {
+	return p.PlaceOrder(symbol, side, posSide, qty, ordType, reduce)
+}
+
+func (placeOnly) FindOrderByClientID(string, string) (*connectors.ClientOrder, error) {
+	return nil, ErrLookupUnsupported
+}
+
+// ErrLimitUnsupported is returned for a priced intent on an exchange that
+// cannot place client order id tagged limit orders.
+var ErrLimitUnsupported = errors.New("exchange cannot place limit orders by client order id")
+
+// ErrBracketUnsupported is returned for an intent with attached exits on an
+// exchange that cannot hold them.
+var ErrBracketUnsupported = errors.New("exchange cannot attach exits to orders by client order id")
+
+// Send places the order intent describes, as an ImmediateOrCancel limit when
+// it has a price, with its exits attached when it has them. It does no
+// bookkeeping, see Dispatch.
+func Send(exchange connectors.ClientOrderPlacer, intent *model.OrderIntent) (*connectors.APIResponse, error) {
+	if intent.StopLossPrice != "" || intent.TakeProfitPrice != "" {
+		bracket, ok := exchange.(connectors.ClientBracketOrderPlacer)
+		if !ok || intent.Price != "" || intent.ReduceOnly {
+			return nil, ErrBracketUnsupported
+		}
+		return bracket.PlaceBracketOrderWithClientID(
+			intent.ClientOrderID,
+			intent.Symbol,
+			intent.Side,
+			intent.PosSide,
+			intent.Quantity,
+			intent.StopLossPrice,
+			intent.TakeProfitPrice,
+		)
+	}
+	if intent.Price == "" {
+		return exchange.PlaceOrderWithClientID(
+			intent.ClientOrderID,
+			intent.Symbol,
+			intent.Side,
+			intent.PosSide,
+			intent.Quantity,
+			intent.OrderType,
+			intent.ReduceOnly,
+		)
+	}
+
+	limit, ok := exchange.(connectors.ClientLimitOrderPlacer)
+	if !ok {
+		return nil, ErrLimitUnsupported
+	}
+	return limit.PlaceLimitIOCWithClientID(
+		intent.ClientOrderID,
+		intent.Symbol,
+		intent.Side,
+		intent.PosSide,
+		intent.Quantity,
+		intent.Price,
+		intent.ReduceOnly,
+	)
+}
+
+// Dispatch executes intent. The exchange is not called unless the intent was
+// marked dispatched first. A transport error, throttling or outage leaves it
+// dispatched, to be looked up by Resolve; an exchange rejection (non-zero
+// code or a refusing HTTP answer, see connectors.IsRejected) fails it.
+func (d *Dispatcher) Dispatch(ctx context.Context, intent *model.OrderIntent) (*connectors.APIResponse, error) {
+	log := logger.WithFields(map[string]interface{}{
+		"intent_id":       intent.ID,
+		"order_id":        intent.OrderID,
+		"client_order_id": intent.ClientOrderID,
+	})
+
+	if err := d.Store.MarkDispatched(ctx, intent.ID, d.Now()); err != nil {
+		return nil, fmt.Errorf("mark intent %d dispatched: %w", intent.ID, err)
+	}
+	intent.Status = model.OrderIntentStatusDispatched
+	intent.Attempts++
+
+	resp, err := Send(d.Exchange, intent)
+	if err != nil {
+		status := model.OrderIntentStatusDispatched
+		if connectors.IsRejected(err) || errors.Is(err, ErrLimitUnsupported) || errors.Is(err, ErrBracketUnsupported) {
+			status = model.OrderIntentStatusFailed
+		}
+		intent.Status = status
+		if markErr := d.Store.MarkResolved(ctx, intent.ID, status, err.Error(), d.Now()); markErr != nil {
+			log.WithError(markErr).Error("failed to record intent error")
+		}
+		return nil, err
+	}
+
+	if codeErr := resp.Err(); codeErr != nil {
+		intent.Status = model.OrderIntentStatusFailed
+		if markErr := d.Store.MarkResolved(ctx, intent.ID, intent.Status, codeErr.Error(), d.Now()); markErr != nil {
+			log.WithError(markErr).Error("failed to mark intent failed")
+		}
+		return resp, nil
+	}
+
+	// The order is on the exchange: failing to record it must not fail the
+	// call, Resolve will find it by client order id.
+	intent.Status = model.OrderIntentStatusDone
+	intent.ExchangeOrderID = exchangeOrderID(resp.Data)
+	if err := d.Store.MarkDone(ctx, intent.ID, intent.ExchangeOrderID, d.Now()); err != nil {
+		log.WithError(err).Error("failed to mark intent done")
+	}
+	return resp, nil
+}
+
+// Resolve settles the unresolved intents of a user on an exchange and updates
+// their orders. It returns the number of intents settled; lookups that fail
+// are left for the next run.
+func (d *Dispatcher) Resolve(ctx context.Context, orders OrderUpdater, userID, exchangeID uint) (int, error) {
+	intents, err := d.Store.ListUnresolved(ctx, userID, exchangeID)
+	if err != nil {
+		return 0, fmt.Errorf("list unresolved intents: %w", err)
+	}
+
+	settled := 0
+	for i := range intents {
+		intent := &intents[i]
+		log := logger.WithFields(map[string]interface{}{
+			"intent_id":       intent.ID,
+			"order_id":        intent.OrderID,
+			"client_order_id": intent.ClientOrderID,
+			"status":          intent.Status,
+		})
+
+		status, orderStatus, reason, err := d.settle(intent)
+		if err != nil {
+			log.WithError(err).Warn("could not settle order intent, will retry")
+			continue
+		}
+
+		if status == model.OrderIntentStatusDone {
+			err = d.Store.MarkDone(ctx, intent.ID, intent.ExchangeOrderID, d.Now())
+		} else {
+			err = d.Store.MarkResolved(ctx, intent.ID, status, reason, d.Now())
+		}
+		if err != nil {
+			return settled, fmt.Errorf("settle intent %d: %w", intent.ID, err)
+		}
+		if orderStatus != "" {
+			if err := orders.UpdateStatusWithAutoLog(ctx, intent.OrderID, orderStatus, "outbox: "+reason); err != nil {
+				return settled, fmt.Errorf("update order %d: %w", intent.OrderID, err)
+			}
+		}
+
+		log.WithField("resolution", status).Warn("settled interrupted order intent: " + reason)
+		settled++
+	}
+	return settled, nil
+}
+
+// settle decides the final intent status, the order status to apply (empty
+// to leave it) and a reason.
+func (d *Dispatcher) settle(intent *model.OrderIntent) (status, orderStatus, reason string, err error) {
+	if intent.Status == model.OrderIntentStatusPending {
+		return model.OrderIntentStatusFailed, model.OrderExecutionStatusError,
+			"exchange call never started", nil
+	}
+
+	found, err := d.Exchange.FindOrderByClientID(intent.Symbol, intent.ClientOrderID)
+	if errors.Is(err, ErrLookupUnsupported) {
+		return model.OrderIntentStatusUnknown, "",
+			"exchange call interrupted and the exchange cannot be queried, reconcile manually", nil
+	}
+	if err != nil {
+		return "", "", "", err
+	}
+	if found == nil {
+		return d.settlePlacements(intent)
+	}
+
+	intent.ExchangeOrderID = found.OrderID
+	switch strings.ToLower(found.OrdStatus) {
+	case "filled", "partiallyfilled":
+		return model.OrderIntentStatusDone, model.OrderExecutionStatusFilled,
+			"exchange call interrupted, order found " + found.OrdStatus, nil
+	case "canceled", "cancelled", "rejected", "deactivated":
+		return model.OrderIntentStatusFailed, model.OrderExecutionStatusError,
+			"exchange call interrupted, order found " + found.OrdStatus, nil
+	default:
+		return model.OrderIntentStatusDone, model.OrderExecutionStatusPending,
+			"exchange call interrupted, order found " + found.OrdStatus, nil
+	}
+}
+
+// settlePlacements settles an intent not found on the exchange from the
+// post-only placements a passive entry made for it: the entry may have
+// filled as maker before the rest was to be sent at market.
+func (d *Dispatcher) settlePlacements(intent *model.OrderIntent) (status, orderStatus, reason string, err error) {
+	placed, filled, resting := false, false, false
+	for attempt := 0; attempt < d.Placements; attempt++ {
+		found, err := d.Exchange.FindOrderByClientID(intent.Symbol, makerentry.PlacementID(intent.ClientOrderID, attempt))
+		if err != nil {
+			return "", "", "", err
+		}
+		if found == nil {
+			continue
+		}
+		placed = true
+		cum, _ := decimal.NewFromString(found.CumQtyRq)
+		switch strings.ToLower(found.OrdStatus) {
+		case "filled", "partiallyfilled":
+			filled = true
+		case "canceled", "cancelled", "rejected", "deactivated":
+			filled = filled || cum.IsPositive()
+		default:
+			resting = true
+		}
+		if filled || resting {
+			intent.ExchangeOrderID = found.OrderID
+		}
+	}
+
+	switch {
+	case filled:
+		return model.OrderIntentStatusDone, model.OrderExecutionStatusFilled,
+			"exchange call interrupted, passive entry filled as maker", nil
+	case resting:
+		return model.OrderIntentStatusDone, model.OrderExecutionStatusPending,
+			"exchange call interrupted, passive entry resting on the book", nil
+	case placed:
+		return model.OrderIntentStatusFailed, model.OrderExecutionStatusError,
+			"exchange call interrupted, passive entry placements cancelled unfilled", nil
+	default:
+		return model.OrderIntentStatusFailed, model.OrderExecutionStatusError,
+			"exchange call interrupted, order never reached the exchange", nil
+	}
+}
+
+// exchangeOrderID extracts orderID from a place-order response payload.
+func exchangeOrderID(data json.RawMessage) string {
+	var payload struct {
+		OrderID string `json:"orderID"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return ""
+	}
+	return payload.OrderID
+}
@@ -0,0 +1,273 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	intents map[uint]*model.OrderIntent
+	errors  map[uint]string
+}
+
+func newFakeStore(intents ...model.OrderIntent) *fakeStore {
+	s := &fakeStore{intents: map[uint]*model.OrderIntent{}, errors: map[uint]string{}}
+	for i := range intents {
+		s.intents[intents[i].ID] = &intents[i]
+	}
+	return s
+}
+
+func (s *fakeStore) ListUnresolved(_ context.Context, _, _ uint) ([]model.OrderIntent, error) {
+	var out []model.OrderIntent
+	for id := uint(1); id <= uint(len(s.intents)); id++ {
+		in := s.intents[id]
+		if in.Status == model.OrderIntentStatusPending || in.Status == model.OrderIntentStatusDispatched {
+			out = append(out, *in)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) MarkDispatched(_ context.Context, id uint, _ time.Time) error {
+	s.intents[id].Status = model.OrderIntentStatusDispatched
+	s.intents[id].Attempts++
+	return nil
+}
+
+func (s *fakeStore) MarkDone(_ context.Context, id uint, exchangeOrderID string, _ time.Time) error {
+	s.intents[id].Status = model.OrderIntentStatusDone
+	s.intents[id].ExchangeOrderID = exchangeOrderID
+	return nil
+}
+
+func (s *fakeStore) MarkResolved(_ context.Context, id uint, status, errText string, _ time.Time) error {
+	s.intents[id].Status = status
+	s.errors[id] = errText
+	return nil
+}
+
+type fakeExchange struct {
+	placeErr error
+	code     int
+	placed   []string
+	orders   map[string]*connectors.ClientOrder
+}
+
+func (e *fakeExchange) PlaceOrderWithClientID(clOrdID, _, _, _, _, _ string, _ bool) (*connectors.APIResponse, error) {
+	e.placed = append(e.placed, clOrdID)
+	if e.placeErr != nil {
+		return nil, e.placeErr
+	}
+	return &connectors.APIResponse{Code: e.code, Data: json.RawMessage(`{"orderID":"ex-` + clOrdID + `"}`)}, nil
+}
+
+type fakeLimitExchange struct {
+	fakeExchange
+	prices []string
+}
+
+func (e *fakeLimitExchange) PlaceLimitIOCWithClientID(clOrdID, _, _, _, _, priceRp string, _ bool) (*connectors.APIResponse, error) {
+	e.prices = append(e.prices, priceRp)
+	return e.PlaceOrderWithClientID(clOrdID, "", "", "", "", "Limit", false)
+}
+
+type fakeBracketExchange struct {
+	fakeExchange
+	exits [][2]string
+}
+
+func (e *fakeBracketExchange) PlaceBracketOrderWithClientID(clOrdID, _, _, _, _, stopLossRp, takeProfitRp string) (*connectors.APIResponse, error) {
+	e.exits = append(e.exits, [2]string{stopLossRp, takeProfitRp})
+	return e.PlaceOrderWithClientID(clOrdID, "", "", "", "", "Market", false)
+}
+
+func (e *fakeExchange) FindOrderByClientID(_, clOrdID string) (*connectors.ClientOrder, error) {
+	return e.orders[clOrdID], nil
+}
+
+type fakeOrders struct{ statuses map[uint]string }
+
+func (o *fakeOrders) UpdateStatusWithAutoLog(_ context.Context, orderID uint, status string, _ string) error {
+	o.statuses[orderID] = status
+	return nil
+}
+
+func TestDispatch(t *testing.T) {
+	store := newFakeStore(
+		model.OrderIntent{ID: 1, OrderID: 10, ClientOrderID: "se-10", Status: model.OrderIntentStatusPending},
+		model.OrderIntent{ID: 2, OrderID: 11, ClientOrderID: "se-11", Status: model.OrderIntentStatusPending},
+		model.OrderIntent{ID: 3, OrderID: 12, ClientOrderID: "se-12", Status: model.OrderIntentStatusPending},
+		model.OrderIntent{ID: 4, OrderID: 13, ClientOrderID: "se-13", Status: model.OrderIntentStatusPending},
+		model.OrderIntent{ID: 5, OrderID: 14, ClientOrderID: "se-14", Status: model.OrderIntentStatusPending},
+	)
+	ex := &fakeExchange{}
+	d := &Dispatcher{Store: store, Exchange: ex, Now: time.Now}
+
+	resp, err := d.Dispatch(context.Background(), store.intents[1])
+	require.NoError(t, err)
+	require.Zero(t, resp.Code)
+	require.Equal(t, model.OrderIntentStatusDone, store.intents[1].Status)
+	require.Equal(t, "ex-se-10", store.intents[1].ExchangeOrderID)
+
+	// rejected by the exchange: final failure
+	ex.code = 11001
+	resp, err = d.Dispatch(context.Background(), store.intents[2])
+	require.NoError(t, err)
+	require.Equal(t, 11001, resp.Code)
+	require.Equal(t, model.OrderIntentStatusFailed, store.intents[2].Status)
+
+	// transport error: outcome unknown, stays dispatched for Resolve
+	ex.placeErr = errors.New("i/o timeout")
+	_, err = d.Dispatch(context.Background(), store.intents[3])
+	require.Error(t, err)
+	require.Equal(t, model.OrderIntentStatusDispatched, store.intents[3].Status)
+	require.Equal(t, "i/o timeout", store.errors[3])
+
+	// typed rejection over HTTP: the exchange did not act on it
+	ex.placeErr = &connectors.ExchangeError{Exchange: "phemex", Status: 400, Code: "11052", Kind: connectors.ErrInsufficientMargin}
+	_, err = d.Dispatch(context.Background(), store.intents[4])
+	require.ErrorIs(t, err, connectors.ErrInsufficientMargin)
+	require.Equal(t, model.OrderIntentStatusFailed, store.intents[4].Status)
+
+	// rate limited: may or may not have landed, leave it to Resolve
+	ex.placeErr = &connectors.ExchangeError{Exchange: "phemex", Status: 429, Kind: connectors.ErrRateLimited}
+	_, err = d.Dispatch(context.Background(), store.intents[5])
+	require.Error(t, err)
+	require.Equal(t, model.OrderIntentStatusDispatched, store.intents[5].Status)
+
+	require.Equal(t, []string{"se-10", "se-11", "se-12", "se-13", "se-14"}, ex.placed)
+}
+
+func TestResolve(t *testing.T) {
+	store := newFakeStore(
+		// crashed after the DB write, before the call
+		model.OrderIntent{ID: 1, OrderID: 10, ClientOrderID: "se-10", Status: model.OrderIntentStatusPending},
+		// crashed during the call, the exchange filled it
+		model.OrderIntent{ID: 2, OrderID: 11, ClientOrderID: "se-11", Status: model.OrderIntentStatusDispatched},
+		// crashed during the call, the request never arrived
+		model.OrderIntent{ID: 3, OrderID: 12, ClientOrderID: "se-12", Status: model.OrderIntentStatusDispatched},
+		// already settled
+		model.OrderIntent{ID: 4, OrderID: 13, ClientOrderID: "se-13", Status: model.OrderIntentStatusDone},
+	)
+	ex := &fakeExchange{orders: map[string]*connectors.ClientOrder{
+		"se-11": {OrderID: "ex-11", ClOrdID: "se-11", OrdStatus: "Filled"},
+	}}
+	orders := &fakeOrders{statuses: map[uint]string{}}
+	d := &Dispatcher{Store: store, Exchange: ex, Now: time.Now}
+
+	settled, err := d.Resolve(context.Background(), orders, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, settled)
+	require.Empty(t, ex.placed, "resolving must never place orders")
+
+	require.Equal(t, model.OrderIntentStatusFailed, store.intents[1].Status)
+	require.Equal(t, model.OrderExecutionStatusError, orders.statuses[10])
+
+	require.Equal(t, model.OrderIntentStatusDone, store.intents[2].Status)
+	require.Equal(t, "ex-11", store.intents[2].ExchangeOrderID)
+	require.Equal(t, model.OrderExecutionStatusFilled, orders.statuses[11])
+
+	require.Equal(t, model.OrderIntentStatusFailed, store.intents[3].Status)
+	require.Equal(t, model.OrderExecutionStatusError, orders.statuses[12])
+
+	_, touched := orders.statuses[13]
+	require.False(t, touched)
+}
+
+func TestResolvePassivePlacements(t *testing.T) {
+	store := newFakeStore(
+		// filled in part as maker, then interrupted before the market rest
+		model.OrderIntent{ID: 1, OrderID: 10, ClientOrderID: "se-10", Status: model.OrderIntentStatusDispatched},
+		// the second placement still rests
+		model.OrderIntent{ID: 2, OrderID: 11, ClientOrderID: "se-11", Status: model.OrderIntentStatusDispatched},
+		// every placement cancelled unfilled
+		model.OrderIntent{ID: 3, OrderID: 12, ClientOrderID: "se-12", Status: model.OrderIntentStatusDispatched},
+	)
+	ex := &fakeExchange{orders: map[string]*connectors.ClientOrder{
+		"se-10-p0": {OrderID: "ex-10", OrdStatus: "Canceled", CumQtyRq: "0.5"},
+		"se-11-p0": {OrderID: "ex-11a", OrdStatus: "Canceled", CumQtyRq: "0"},
+		"se-11-p1": {OrderID: "ex-11b", OrdStatus: "New"},
+		"se-12-p0": {OrderID: "ex-12", OrdStatus: "Canceled"},
+	}}
+	orders := &fakeOrders{statuses: map[uint]string{}}
+	d := &Dispatcher{Store: store, Exchange: ex, Now: time.Now, Placements: 2}
+
+	settled, err := d.Resolve(context.Background(), orders, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, settled)
+
+	require.Equal(t, model.OrderIntentStatusDone, store.intents[1].Status)
+	require.Equal(t, "ex-10", store.intents[1].ExchangeOrderID)
+	require.Equal(t, model.OrderExecutionStatusFilled, orders.statuses[10])
+
+	require.Equal(t, model.OrderIntentStatusDone, store.intents[2].Status)
+	require.Equal(t, "ex-11b", store.intents[2].ExchangeOrderID)
+	require.Equal(t, model.OrderExecutionStatusPending, orders.statuses[11])
+
+	require.Equal(t, model.OrderIntentStatusFailed, store.intents[3].Status)
+	require.Equal(t, model.OrderExecutionStatusError, orders.statuses[12])
+}
+
+type plainConnector struct{ connectors.Connector }
+
+func TestResolveWithoutLookupMarksUnknown(t *testing.T) {
+	store := newFakeStore(model.OrderIntent{ID: 1, OrderID: 10, ClientOrderID: "se-10", Status: model.OrderIntentStatusDispatched})
+	orders := &fakeOrders{statuses: map[uint]string{}}
+	d := &Dispatcher{Store: store, Exchange: ExchangeFor(plainConnector{}), Now: time.Now}
+
+	settled, err := d.Resolve(context.Background(), orders, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, settled)
+	require.Equal(t, model.OrderIntentStatusUnknown, store.intents[1].Status)
+	require.Empty(t, orders.statuses)
+}
+
+func TestDispatchLimit(t *testing.T) {
+	store := newFakeStore(
+		model.OrderIntent{ID: 1, OrderID: 10, ClientOrderID: "se-10", Price: "100.5", Status: model.OrderIntentStatusPending},
+		model.OrderIntent{ID: 2, OrderID: 11, ClientOrderID: "se-11", Price: "100.5", Status: model.OrderIntentStatusPending},
+	)
+	ex := &fakeLimitExchange{}
+	d := &Dispatcher{Store: store, Exchange: ex, Now: time.Now}
+
+	_, err := d.Dispatch(context.Background(), store.intents[1])
+	require.NoError(t, err)
+	require.Equal(t, model.OrderIntentStatusDone, store.intents[1].Status)
+	require.Equal(t, []string{"100.5"}, ex.prices)
+
+	// market only exchanges refuse priced intents before anything is sent
+	d.Exchange = &fakeExchange{}
+	_, err = d.Dispatch(context.Background(), store.intents[2])
+	require.ErrorIs(t, err, ErrLimitUnsupported)
+	require.Equal(t, model.OrderIntentStatusFailed, store.intents[2].Status)
+	require.Empty(t, d.Exchange.(*fakeExchange).placed)
+}
+
+func TestDispatchBracket(t *testing.T) {
+	store := newFakeStore(
+		model.OrderIntent{ID: 1, OrderID: 10, ClientOrderID: "se-10", StopLossPrice: "95", TakeProfitPrice: "110", Status: model.OrderIntentStatusPending},
+		model.OrderIntent{ID: 2, OrderID: 11, ClientOrderID: "se-11", StopLossPrice: "95", Status: model.OrderIntentStatusPending},
+	)
+	ex := &fakeBracketExchange{}
+	d := &Dispatcher{Store: store, Exchange: ex, Now: time.Now}
+
+	_, err := d.Dispatch(context.Background(), store.intents[1])
+	require.NoError(t, err)
+	require.Equal(t, model.OrderIntentStatusDone, store.intents[1].Status)
+	require.Equal(t, [][2]string{{"95", "110"}}, ex.exits)
+
+	// exchanges that cannot hold the exits refuse the intent before sending
+	d.Exchange = &fakeExchange{}
+	_, err = d.Dispatch(context.Background(), store.intents[2])
+	require.ErrorIs(t, err, ErrBracketUnsupported)
+	require.Equal(t, model.OrderIntentStatusFailed, store.intents[2].Status)
+	require.Empty(t, d.Exchange.(*fakeExchange).placed)
+}
@@ -0,0 +1,41 @@
+package pricecheck
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// MaxDivergencePct skips entries when the venue price is further than
+	// this percent from the reference. 0 disables the check.
+	MaxDivergencePct float64 `envconfig:"PRICE_CHECK_MAX_DIVERGENCE_PCT" default:"0"`
+	// Reference is "index", the index price of the venue, or "candles", the
+	// latest stored 1m close when not older than MaxReferenceAge.
+	Reference       string        `envconfig:"PRICE_CHECK_REFERENCE" default:"index"`
+	MaxReferenceAge time.Duration `envconfig:"PRICE_CHECK_MAX_REFERENCE_AGE" default:"5m"`
+	// Action is "skip" to drop a diverging entry or "alert" to only notify.
+	Action string `envconfig:"PRICE_CHECK_ACTION" default:"skip"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	if err := config.Limits().Validate(); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
+
+// Limits returns the limits configured by config.
+func (c *Config) Limits() Limits {
+	return Limits{
+		MaxDivergencePct: c.MaxDivergencePct,
+		Reference:        c.Reference,
+		MaxReferenceAge:  c.MaxReferenceAge,
+		Action:           c.Action,
+	}
+}
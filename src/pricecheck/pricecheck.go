@@ -0,0 +1,78 @@
+// Package pricecheck compares the price of the execution venue with a
+// reference before an entry, so entries are not sent to a venue whose price
+// dislocated from the market, e.g. during a flash crash or on a testnet with
+// stale prices.
+package pricecheck
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// ReferenceIndex is the index price the venue publishes, e.g. the
+	// Phemex index of spot exchanges.
+	ReferenceIndex = "index"
+	// ReferenceCandles is the close of the latest stored 1m candle.
+	ReferenceCandles = "candles"
+
+	ActionSkip  = "skip"
+	ActionAlert = "alert"
+)
+
+// Limits bound the divergence of the venue price from the reference.
+type Limits struct {
+	// MaxDivergencePct is the largest difference allowed, in percent of the
+	// reference. 0 disables the check.
+	MaxDivergencePct float64
+	Reference        string
+	// MaxReferenceAge is how old a candle reference may be.
+	MaxReferenceAge time.Duration
+	// Action is what happens to an entry failing the check: "skip" drops
+	// it, "alert" sends it anyway. Both notify the user.
+	Action string
+}
+
+// Enabled reports whether the check is on.
+func (l Limits) Enabled() bool {
+	return l.MaxDivergencePct > 0
+}
+
+// Validate rejects a reference or an action the check does not know, which
+// would otherwise silently change what happens to the entries.
+func (l Limits) Validate() error {
+	switch l.Reference {
+	case ReferenceIndex, ReferenceCandles:
+	default:
+		return fmt.Errorf("unknown price check reference %q, want %q or %q", l.Reference, ReferenceIndex, ReferenceCandles)
+	}
+	switch l.Action {
+	case ActionSkip, ActionAlert:
+	default:
+		return fmt.Errorf("unknown price check action %q, want %q or %q", l.Action, ActionSkip, ActionAlert)
+	}
+	return nil
+}
+
+// Result is the outcome of Check.
+type Result struct {
+	Allowed       bool
+	Reason        string
+	DivergencePct float64
+}
+
+// Check compares venue with reference against l.
+func Check(venue, reference float64, l Limits) (Result, error) {
+	if venue <= 0 || reference <= 0 {
+		return Result{}, fmt.Errorf("invalid prices: venue %v reference %v", venue, reference)
+	}
+	res := Result{DivergencePct: math.Abs(venue-reference) / reference * 100}
+	if res.DivergencePct > l.MaxDivergencePct {
+		res.Reason = fmt.Sprintf("venue price %v diverges %.2f%% from %s price %v (max %v%%)",
+			venue, res.DivergencePct, l.Reference, reference, l.MaxDivergencePct)
+		return res, nil
+	}
+	res.Allowed = true
+	return res, nil
+}
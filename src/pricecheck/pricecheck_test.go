@@ -0,0 +1,53 @@
+package pricecheck
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	limits := Limits{MaxDivergencePct: 1, Reference: ReferenceIndex}
+
+	tests := []struct {
+		name       string
+		venue, ref float64
+		allowed    bool
+		divergence float64
+	}{
+		{name: "in line", venue: 50100, ref: 50000, allowed: true, divergence: 0.2},
+		{name: "at the limit", venue: 49500, ref: 50000, allowed: true, divergence: 1},
+		{name: "flash dislocation", venue: 47000, ref: 50000, divergence: 6},
+		{name: "stale testnet", venue: 62000, ref: 50000, divergence: 24},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Check(tt.venue, tt.ref, limits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Allowed != tt.allowed || math.Abs(res.DivergencePct-tt.divergence) > 1e-9 {
+				t.Fatalf("result = %+v", res)
+			}
+			if !tt.allowed && !strings.Contains(res.Reason, "index price 50000") {
+				t.Fatalf("reason = %q", res.Reason)
+			}
+		})
+	}
+
+	if _, err := Check(0, 50000, limits); err == nil {
+		t.Fatal("expected an error for a missing venue price")
+	}
+}
+
+func TestLimitsValidate(t *testing.T) {
+	if err := (Limits{Reference: ReferenceCandles, Action: ActionAlert}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := (Limits{Reference: "indx", Action: ActionSkip}).Validate(); err == nil {
+		t.Fatal("expected an unknown reference to be rejected")
+	}
+	if err := (Limits{Reference: ReferenceIndex, Action: "warn"}).Validate(); err == nil {
+		t.Fatal("expected an unknown action to be rejected")
+	}
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"fmt"
+	"strategyexecutor/src/model"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// EquityTimeframes are the bucket sizes an equity curve can be requested at.
+var EquityTimeframes = map[string]time.Duration{
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+}
+
+// EquityPoint is the last equity snapshot of a timeframe bucket.
+type EquityPoint struct {
+	Time          time.Time       `json:"time"`
+	Balance       decimal.Decimal `json:"balance"`
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl"`
+	Equity        decimal.Decimal `json:"equity"`
+	// Drawdown is the drop from the highest equity seen so far in the curve.
+	Drawdown    decimal.Decimal `json:"drawdown"`
+	DrawdownPct decimal.Decimal `json:"drawdown_pct"`
+}
+
+// EquityCurve is the outcome of BuildEquityCurve.
+type EquityCurve struct {
+	Timeframe      string          `json:"timeframe"`
+	Points         []EquityPoint   `json:"points"`
+	ReturnPct      decimal.Decimal `json:"return_pct"`
+	MaxDrawdown    decimal.Decimal `json:"max_drawdown"`
+	MaxDrawdownPct decimal.Decimal `json:"max_drawdown_pct"`
+}
+
+// BuildEquityCurve buckets snapshots, ascending by time, into timeframe and
+// keeps the last snapshot of each bucket, labelled with the bucket start.
+// Drawdowns are measured against the running peak of the bucketed curve.
+func BuildEquityCurve(snapshots []model.EquitySnapshot, timeframe string) (EquityCurve, error) {
+	step, ok := EquityTimeframes[timeframe]
+	if !ok {
+		return EquityCurve{}, fmt.Errorf("unknown timeframe %q", timeframe)
+	}
+
+	curve := EquityCurve{
+		Timeframe:      timeframe,
+		Points:         []EquityPoint{},
+		ReturnPct:      decimal.Zero,
+		MaxDrawdown:    decimal.Zero,
+		MaxDrawdownPct: decimal.Zero,
+	}
+	for _, s := range snapshots {
+		bucket := s.TakenAt.UTC().Truncate(step)
+		point := EquityPoint{Time: bucket, Balance: s.Balance, UnrealizedPnL: s.UnrealizedPnL, Equity: s.Equity}
+		if n := len(curve.Points); n > 0 && curve.Points[n-1].Time.Equal(bucket) {
+			curve.Points[n-1] = point
+			continue
+		}
+		curve.Points = append(curve.Points, point)
+	}
+	if len(curve.Points) == 0 {
+		return curve, nil
+	}
+
+	hundred := decimal.NewFromInt(100)
+	peak := curve.Points[0].Equity
+	for i := range curve.Points {
+		p := &curve.Points[i]
+		if p.Equity.GreaterThan(peak) {
+			peak = p.Equity
+		}
+		p.Drawdown = peak.Sub(p.Equity)
+		p.DrawdownPct = decimal.Zero
+		if peak.IsPositive() {
+			p.DrawdownPct = p.Drawdown.Div(peak).Mul(hundred).Round(4)
+		}
+		if p.Drawdown.GreaterThan(curve.MaxDrawdown) {
+			curve.MaxDrawdown = p.Drawdown
+		}
+		if p.DrawdownPct.GreaterThan(curve.MaxDrawdownPct) {
+			curve.MaxDrawdownPct = p.DrawdownPct
+		}
+	}
+
+	if first := curve.Points[0].Equity; first.IsPositive() {
+		last := curve.Points[len(curve.Points)-1].Equity
+		curve.ReturnPct = last.Sub(first).Div(first).Mul(hundred).Round(4)
+	}
+	return curve, nil
+}
@@ -0,0 +1,50 @@
+package report
+
+import (
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+func TestBuildEquityCurve(t *testing.T) {
+	base := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	snap := func(offset time.Duration, equity string) model.EquitySnapshot {
+		return model.EquitySnapshot{TakenAt: base.Add(offset), Balance: d(equity), Equity: d(equity)}
+	}
+	snapshots := []model.EquitySnapshot{
+		snap(5*time.Minute, "1000"),
+		snap(55*time.Minute, "1100"), // last of the first hour
+		snap(65*time.Minute, "880"),
+		snap(2*time.Hour+10*time.Minute, "990"),
+	}
+
+	curve, err := BuildEquityCurve(snapshots, "1h")
+	if err != nil {
+		t.Fatalf("BuildEquityCurve: %v", err)
+	}
+	if len(curve.Points) != 3 {
+		t.Fatalf("expected 3 points, got %+v", curve.Points)
+	}
+	if !curve.Points[0].Time.Equal(base) || !curve.Points[0].Equity.Equal(d("1100")) {
+		t.Fatalf("unexpected first point: %+v", curve.Points[0])
+	}
+	if !curve.Points[1].Drawdown.Equal(d("220")) || !curve.Points[1].DrawdownPct.Equal(d("20")) {
+		t.Fatalf("unexpected drawdown: %+v", curve.Points[1])
+	}
+	if !curve.MaxDrawdown.Equal(d("220")) || !curve.MaxDrawdownPct.Equal(d("20")) {
+		t.Fatalf("unexpected max drawdown: %s %s", curve.MaxDrawdown, curve.MaxDrawdownPct)
+	}
+	if !curve.ReturnPct.Equal(d("-10")) {
+		t.Fatalf("ReturnPct = %s", curve.ReturnPct)
+	}
+}
+
+func TestBuildEquityCurveEmptyAndUnknownTimeframe(t *testing.T) {
+	curve, err := BuildEquityCurve(nil, "1d")
+	if err != nil || len(curve.Points) != 0 || curve.Points == nil {
+		t.Fatalf("expected an empty curve, got %+v (%v)", curve, err)
+	}
+	if _, err := BuildEquityCurve(nil, "3d"); err == nil {
+		t.Fatalf("expected an error for an unknown timeframe")
+	}
+}
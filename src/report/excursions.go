@@ -0,0 +1,124 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strategyexecutor/src/model"
+)
+
+// ExcursionR expresses an excursion per unit of a position of qty in units of
+// its initial risk, or nil when the initial risk is unknown.
+func ExcursionR(excursion, qty float64, initialRisk *float64) *float64 {
+	if initialRisk == nil || *initialRisk <= 0 || qty <= 0 {
+		return nil
+	}
+	r := excursion * qty / *initialRisk
+	return &r
+}
+
+// setExcursions stores on trade the excursions measured on candles.
+func setExcursions(trade *model.Trade, candles []model.OHLCVCrypto1m) {
+	trade.MAE, trade.MFE = Excursions(trade.PosSide, trade.EntryPrice, candles)
+	trade.MAER = ExcursionR(trade.MAE, trade.Quantity, trade.InitialRisk)
+	trade.MFER = ExcursionR(trade.MFE, trade.Quantity, trade.InitialRisk)
+}
+
+type excursionTradeStore interface {
+	FindClosedWithoutExcursions(ctx context.Context, limit int) ([]model.Trade, error)
+	UpdateExcursions(ctx context.Context, trade *model.Trade) error
+}
+
+// ExcursionBackfill computes the excursions of closed trades stored without
+// them, typically because the candles of the holding period were not
+// collected yet when the journal closed the trade, or because the trade left
+// the journal lookback before they were.
+type ExcursionBackfill struct {
+	Trades  excursionTradeStore
+	Candles journalCandleSource
+}
+
+// Run backfills up to limit trades and returns the number updated. Trades
+// still without candles are left for a later run.
+func (b *ExcursionBackfill) Run(ctx context.Context, limit int) (int, error) {
+	trades, err := b.Trades.FindClosedWithoutExcursions(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("load trades: %w", err)
+	}
+
+	updated := 0
+	for i := range trades {
+		trade := &trades[i]
+		if trade.ExitTime == nil {
+			continue
+		}
+		candles, err := b.Candles.FetchOHLCV1mRange(ctx, trade.Symbol, trade.EntryTime, *trade.ExitTime)
+		if err != nil {
+			return updated, fmt.Errorf("load candles for trade %d: %w", trade.ID, err)
+		}
+		if len(candles) == 0 {
+			continue
+		}
+		setExcursions(trade, candles)
+		if err := b.Trades.UpdateExcursions(ctx, trade); err != nil {
+			return updated, fmt.Errorf("save excursions of trade %d: %w", trade.ID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// ExcursionStats summarises the excursions of closed trades in R, to tune
+// the stop and take profit distances.
+type ExcursionStats struct {
+	// Trades counts the closed trades with an initial risk.
+	Trades  int     `json:"trades"`
+	AvgMAER float64 `json:"avg_mae_r"`
+	AvgMFER float64 `json:"avg_mfe_r"`
+	// WinnerMAERP90 is the adverse excursion 90% of the winning trades stayed
+	// within: a stop beyond it would have kept them.
+	WinnerMAERP90 float64 `json:"winner_mae_r_p90"`
+	// MFERP50 is the favourable excursion half of the trades reached: a take
+	// profit at it would have been hit at least half of the time.
+	MFERP50 float64 `json:"mfe_r_p50"`
+}
+
+// SummarizeExcursions aggregates the excursions of the closed trades among
+// trades.
+func SummarizeExcursions(trades []model.Trade) ExcursionStats {
+	var stats ExcursionStats
+	var winnerMAE, mfe []float64
+	var sumMAE, sumMFE float64
+	for _, t := range trades {
+		if t.Status != model.TradeStatusClosed || t.MAER == nil || t.MFER == nil {
+			continue
+		}
+		stats.Trades++
+		sumMAE += *t.MAER
+		sumMFE += *t.MFER
+		mfe = append(mfe, *t.MFER)
+		if t.PnL != nil && *t.PnL > 0 {
+			winnerMAE = append(winnerMAE, *t.MAER)
+		}
+	}
+
+	if stats.Trades > 0 {
+		stats.AvgMAER = sumMAE / float64(stats.Trades)
+		stats.AvgMFER = sumMFE / float64(stats.Trades)
+	}
+	stats.WinnerMAERP90 = percentile(winnerMAE, 90)
+	stats.MFERP50 = percentile(mfe, 50)
+	return stats
+}
+
+// percentile returns the nearest-rank p-th percentile of values, 0 when empty.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
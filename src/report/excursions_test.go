@@ -0,0 +1,87 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"strategyexecutor/src/model"
+)
+
+type fakeExcursionTrades struct {
+	trades  []model.Trade
+	updated []model.Trade
+}
+
+func (f *fakeExcursionTrades) FindClosedWithoutExcursions(context.Context, int) ([]model.Trade, error) {
+	return f.trades, nil
+}
+
+func (f *fakeExcursionTrades) UpdateExcursions(_ context.Context, trade *model.Trade) error {
+	f.updated = append(f.updated, *trade)
+	return nil
+}
+
+func TestExcursionBackfill(t *testing.T) {
+	base := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	exit := base.Add(30 * time.Minute)
+	risk := 10.0
+
+	store := &fakeExcursionTrades{trades: []model.Trade{
+		{ID: 1, Symbol: "BTCUSDT", PosSide: "Long", Status: model.TradeStatusClosed, Quantity: 2, EntryPrice: 100, EntryTime: base, ExitTime: &exit, InitialRisk: &risk},
+		{ID: 2, Symbol: "BTCUSDT", PosSide: "Short", Status: model.TradeStatusClosed, Quantity: 1, EntryPrice: 100, EntryTime: base.Add(2 * time.Hour), ExitTime: &exit},
+	}}
+	b := &ExcursionBackfill{
+		Trades: store,
+		Candles: &fakeJournalCandles{candles: []model.OHLCVCrypto1m{
+			candle(base.Add(time.Minute), "104", "97", "103"),
+			candle(base.Add(29*time.Minute), "112", "101", "110"),
+		}},
+	}
+
+	updated, err := b.Run(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	// the second trade has no candle in its window and waits for a later run
+	if updated != 1 || len(store.updated) != 1 {
+		t.Fatalf("expected 1 trade updated, got %d", updated)
+	}
+	tr := store.updated[0]
+	if tr.MAE != 3 || tr.MFE != 12 {
+		t.Fatalf("unexpected excursions: %v/%v", tr.MAE, tr.MFE)
+	}
+	if tr.MAER == nil || *tr.MAER != 0.6 || tr.MFER == nil || *tr.MFER != 2.4 {
+		t.Fatalf("unexpected excursions in R: %v/%v", tr.MAER, tr.MFER)
+	}
+
+	if ExcursionR(3, 2, nil) != nil {
+		t.Fatalf("expected no R without an initial risk")
+	}
+}
+
+func TestSummarizeExcursions(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	trades := []model.Trade{
+		{Status: model.TradeStatusClosed, PnL: f(5), MAER: f(0.2), MFER: f(2)},
+		{Status: model.TradeStatusClosed, PnL: f(3), MAER: f(0.6), MFER: f(1)},
+		{Status: model.TradeStatusClosed, PnL: f(-4), MAER: f(1), MFER: f(0.4)},
+		{Status: model.TradeStatusClosed, PnL: f(2), MAE: 1, MFE: 2},
+		{Status: model.TradeStatusOpen, MAER: f(3), MFER: f(3)},
+	}
+
+	got := SummarizeExcursions(trades)
+	if got.Trades != 3 {
+		t.Fatalf("expected 3 measured trades, got %d", got.Trades)
+	}
+	if got.AvgMAER != 0.6 || got.AvgMFER != 3.4/3 {
+		t.Fatalf("unexpected averages: %+v", got)
+	}
+	if got.WinnerMAERP90 != 0.6 || got.MFERP50 != 1 {
+		t.Fatalf("unexpected percentiles: %+v", got)
+	}
+
+	if (SummarizeExcursions(nil) != ExcursionStats{}) {
+		t.Fatalf("expected empty stats without trades")
+	}
+}
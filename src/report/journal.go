@@ -0,0 +1,340 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strategyexecutor/src/model"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+)
+
+// TradePair is an entry order and the exit order that closed it. Exit is nil
+// while the position is still open.
+type TradePair struct {
+	Entry model.Order
+	Exit  *model.Order
+}
+
+// PairTrades matches entry orders with exit orders per user, exchange and
+// symbol. The controller creates the new entry before closing the previous
+// position, so an entry is paired with the first not yet used exit created
+// after it rather than with the exit preceding the next entry. Orders in
+// error state and entries blocked by the signal filters are ignored.
+func PairTrades(orders []model.Order) []TradePair {
+	type key struct {
+		userID     uint
+		exchangeID uint
+		symbol     string
+	}
+
+	sorted := make([]model.Order, len(orders))
+	copy(sorted, orders)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].ID < sorted[j].ID
+		}
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	entries := map[key][]model.Order{}
+	exits := map[key][]model.Order{}
+	seen := map[key]bool{}
+	var keys []key
+	for _, o := range sorted {
+		if o.Status == model.OrderExecutionStatusError ||
+			o.Status == model.OrderExecutionStatusCanceledError ||
+			o.Status == model.OrderExecutionStatusFiltered {
+			continue
+		}
+		k := key{o.UserID, o.ExchangeID, o.Symbol}
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+		switch o.OrderDir {
+		case model.OrderDirectionEntry:
+			entries[k] = append(entries[k], o)
+		case model.OrderDirectionExit:
+			exits[k] = append(exits[k], o)
+		}
+	}
+
+	var pairs []TradePair
+	for _, k := range keys {
+		available := exits[k]
+		for _, entry := range entries[k] {
+			pair := TradePair{Entry: entry}
+			for i := range available {
+				if available[i].CreatedAt.Before(entry.CreatedAt) {
+					continue
+				}
+				exit := available[i]
+				pair.Exit = &exit
+				available = append(available[:i:i], available[i+1:]...)
+				break
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// OpenEntries returns the filled entries PairTrades found no exit for: the
+// positions the orders expect to be open.
+func OpenEntries(orders []model.Order) []model.Order {
+	var open []model.Order
+	for _, pair := range PairTrades(orders) {
+		if pair.Exit == nil && pair.Entry.Status == model.OrderExecutionStatusFilled {
+			open = append(open, pair.Entry)
+		}
+	}
+	return open
+}
+
+// Excursions returns the maximum adverse and favourable excursion of a
+// position opened at entry, measured on the candle lows and highs.
+func Excursions(posSide string, entry float64, candles []model.OHLCVCrypto1m) (mae float64, mfe float64) {
+	short := strings.EqualFold(posSide, "Short")
+	for _, c := range candles {
+		high := c.High.InexactFloat64()
+		low := c.Low.InexactFloat64()
+
+		favourable, adverse := high-entry, entry-low
+		if short {
+			favourable, adverse = entry-low, high-entry
+		}
+		mfe = math.Max(mfe, favourable)
+		mae = math.Max(mae, adverse)
+	}
+	return mae, mfe
+}
+
+// RMultiple returns the trade result in units of initial risk, or nil when no
+// stop loss is known. stopLoss is a price: the controller stores the trailed
+// stop price in Order.StopLossPct.
+func RMultiple(posSide string, entry, exit, stopLoss float64) *float64 {
+	risk := math.Abs(entry - stopLoss)
+	if stopLoss <= 0 || risk == 0 {
+		return nil
+	}
+	move := exit - entry
+	if strings.EqualFold(posSide, "Short") {
+		move = -move
+	}
+	r := move / risk
+	return &r
+}
+
+// InitialRisk is the loss of a position of qty opened at entry if stopped out
+// at stopLoss, or nil when no stop loss is known.
+func InitialRisk(entry, stopLoss, qty float64) *float64 {
+	if stopLoss <= 0 || entry <= 0 || qty <= 0 || entry == stopLoss {
+		return nil
+	}
+	risk := math.Abs(entry-stopLoss) * qty
+	return &risk
+}
+
+type journalOrderSource interface {
+	FindCreatedSince(ctx context.Context, since time.Time) ([]model.Order, error)
+}
+
+type journalExecutionSource interface {
+	FindByOrderID(ctx context.Context, orderID string) (*model.PhemexOrder, error)
+}
+
+type journalCandleSource interface {
+	FetchOHLCV1mRange(ctx context.Context, symbol string, from time.Time, to time.Time) ([]model.OHLCVCrypto1m, error)
+}
+
+type journalTradeStore interface {
+	Upsert(ctx context.Context, trade *model.Trade) error
+}
+
+// JournalBuilder turns orders into Trade records.
+type JournalBuilder struct {
+	Orders     journalOrderSource
+	Executions journalExecutionSource
+	Candles    journalCandleSource
+	Trades     journalTradeStore
+}
+
+// Build pairs every order created since `since` and upserts the resulting
+// trades. Open trades are stored as well and get closed on a later run. It
+// returns the number of trades written.
+func (b *JournalBuilder) Build(ctx context.Context, since time.Time, now time.Time) (int, error) {
+	orders, err := b.Orders.FindCreatedSince(ctx, since)
+	if err != nil {
+		return 0, fmt.Errorf("load orders: %w", err)
+	}
+
+	written := 0
+	for _, pair := range PairTrades(orders) {
+		trade, err := b.buildTrade(ctx, pair, now)
+		if err != nil {
+			return written, err
+		}
+		if trade == nil {
+			continue
+		}
+		if err := b.Trades.Upsert(ctx, trade); err != nil {
+			return written, fmt.Errorf("save trade for order %d: %w", pair.Entry.ID, err)
+		}
+		written++
+	}
+	return written, nil
+}
+
+func (b *JournalBuilder) buildTrade(ctx context.Context, pair TradePair, now time.Time) (*model.Trade, error) {
+	entry := pair.Entry
+
+	entryPrice, entryTime, err := b.executionPrice(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	if entryPrice <= 0 {
+		logger.WithField("order_id", entry.ID).Warn("trade journal: entry price unknown, skipping")
+		return nil, nil
+	}
+
+	trade := &model.Trade{
+		UserID:        entry.UserID,
+		ExchangeID:    entry.ExchangeID,
+		Symbol:        entry.Symbol,
+		PosSide:       entry.PosSide,
+		Status:        model.TradeStatusOpen,
+		EntrySignalID: entry.ExternalID,
+		EntryOrderID:  entry.ID,
+		Quantity:      entry.Quantity.InexactFloat64(),
+		EntryPrice:    entryPrice,
+		StopLoss:      entry.StopLossPct.InexactFloat64(),
+		EntryTime:     entryTime,
+		Fees:          entry.Fee,
+		InitialRisk:   entry.InitialRisk,
+		StrategyID:    entry.StrategyID,
+	}
+
+	end := now
+	if pair.Exit != nil {
+		exitPrice, exitTime, err := b.executionPrice(ctx, *pair.Exit)
+		if err != nil {
+			return nil, err
+		}
+		if exitPrice <= 0 {
+			exitPrice, err = b.closeAt(ctx, entry.Symbol, exitTime)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		exitOrderID := pair.Exit.ID
+		exitSignalID := pair.Exit.ExternalID
+		trade.ExitOrderID = &exitOrderID
+		trade.ExitSignalID = &exitSignalID
+		trade.ExitTime = &exitTime
+		trade.Fees += pair.Exit.Fee
+		end = exitTime
+
+		if exitPrice > 0 {
+			trade.Status = model.TradeStatusClosed
+			trade.ExitPrice = &exitPrice
+
+			// gross result in decimal so the stored quantity is not rounded
+			move := decimal.NewFromFloat(exitPrice).Sub(decimal.NewFromFloat(entryPrice))
+			if strings.EqualFold(entry.PosSide, "Short") {
+				move = move.Neg()
+			}
+			gross := move.Mul(entry.Quantity)
+			pnl := gross.Sub(decimal.NewFromFloat(trade.Fees)).InexactFloat64()
+			trade.PnL = &pnl
+			if trade.InitialRisk != nil {
+				r := gross.InexactFloat64() / *trade.InitialRisk
+				trade.RMultiple = &r
+			} else {
+				// entries placed before the initial risk was recorded
+				trade.RMultiple = RMultiple(entry.PosSide, entryPrice, exitPrice, entry.StopLossPct.InexactFloat64())
+			}
+		}
+	}
+	trade.DurationSeconds = int64(end.Sub(entryTime).Seconds())
+
+	candles, err := b.Candles.FetchOHLCV1mRange(ctx, entry.Symbol, entryTime, end)
+	if err != nil {
+		return nil, fmt.Errorf("load candles for order %d: %w", entry.ID, err)
+	}
+	setExcursions(trade, candles)
+
+	return trade, nil
+}
+
+// executionPrice prefers the price stored on the order and falls back to the
+// persisted exchange execution. The time is the exchange transact time when
+// known, otherwise the order creation time.
+func (b *JournalBuilder) executionPrice(ctx context.Context, o model.Order) (float64, time.Time, error) {
+	price := 0.0
+	if o.Price != nil {
+		price = o.Price.InexactFloat64()
+	}
+	at := o.CreatedAt
+	if o.ExecutedAt != nil {
+		at = *o.ExecutedAt
+	}
+
+	exec, err := b.Executions.FindByOrderID(ctx, strconv.FormatUint(uint64(o.ID), 10))
+	if err != nil {
+		return 0, at, fmt.Errorf("load execution for order %d: %w", o.ID, err)
+	}
+	if exec != nil {
+		if price <= 0 {
+			price = exec.Price
+		}
+		if !exec.TransactTime.IsZero() {
+			at = exec.TransactTime
+		}
+	}
+	return price, at.UTC(), nil
+}
+
+// closeAt returns the close of the last 1m candle at or before t, 0 if none.
+func (b *JournalBuilder) closeAt(ctx context.Context, symbol string, t time.Time) (float64, error) {
+	candles, err := b.Candles.FetchOHLCV1mRange(ctx, symbol, t.Add(-time.Hour), t)
+	if err != nil {
+		return 0, fmt.Errorf("load exit candle: %w", err)
+	}
+	if len(candles) == 0 {
+		return 0, nil
+	}
+	return candles[len(candles)-1].Close.InexactFloat64(), nil
+}
+
+// TradeOrderIDs returns the entry and exit order ids of trades.
+func TradeOrderIDs(trades []model.Trade) []uint {
+	var ids []uint
+	for _, t := range trades {
+		ids = append(ids, t.EntryOrderID)
+		if t.ExitOrderID != nil {
+			ids = append(ids, *t.ExitOrderID)
+		}
+	}
+	return ids
+}
+
+// AttachNotes sets the Notes of every trade to the notes of its entry and
+// exit orders, keeping the order of notes.
+func AttachNotes(trades []model.Trade, notes []model.OrderNote) {
+	for i := range trades {
+		t := &trades[i]
+		t.Notes = nil
+		for _, n := range notes {
+			if n.OrderID == t.EntryOrderID || (t.ExitOrderID != nil && n.OrderID == *t.ExitOrderID) {
+				t.Notes = append(t.Notes, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package report
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type fakeJournalOrders struct{ orders []model.Order }
+
+func (f *fakeJournalOrders) FindCreatedSince(context.Context, time.Time) ([]model.Order, error) {
+	return f.orders, nil
+}
+
+type fakeJournalExecutions struct{ byOrder map[uint]*model.PhemexOrder }
+
+func (f *fakeJournalExecutions) FindByOrderID(_ context.Context, orderID string) (*model.PhemexOrder, error) {
+	id, _ := strconv.ParseUint(orderID, 10, 64)
+	return f.byOrder[uint(id)], nil
+}
+
+type fakeJournalCandles struct{ candles []model.OHLCVCrypto1m }
+
+func (f *fakeJournalCandles) FetchOHLCV1mRange(_ context.Context, _ string, from, to time.Time) ([]model.OHLCVCrypto1m, error) {
+	var out []model.OHLCVCrypto1m
+	for _, c := range f.candles {
+		if !c.Datetime.Before(from) && !c.Datetime.After(to) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+type fakeJournalTrades struct{ trades []*model.Trade }
+
+func (f *fakeJournalTrades) Upsert(_ context.Context, trade *model.Trade) error {
+	f.trades = append(f.trades, trade)
+	return nil
+}
+
+func candle(at time.Time, high, low, closePrice string) model.OHLCVCrypto1m {
+	return model.OHLCVCrypto1m{Symbol: "BTCUSDT", Datetime: at, High: d(high), Low: d(low), Close: d(closePrice)}
+}
+
+func TestPairTrades(t *testing.T) {
+	base := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	order := func(id uint, dir string, offset time.Duration, status string) model.Order {
+		return model.Order{ID: id, UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", OrderDir: dir, Status: status, CreatedAt: base.Add(offset)}
+	}
+
+	orders := []model.Order{
+		// entry 2 is created before the exit closing entry 1
+		order(3, model.OrderDirectionExit, 61*time.Minute, model.OrderExecutionStatusPending),
+		order(1, model.OrderDirectionEntry, 0, model.OrderExecutionStatusFilled),
+		order(2, model.OrderDirectionEntry, time.Hour, model.OrderExecutionStatusFilled),
+		order(4, model.OrderDirectionEntry, 2*time.Hour, model.OrderExecutionStatusError),
+	}
+	pairs := PairTrades(orders)
+
+	if len(pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %d", len(pairs))
+	}
+	if pairs[0].Entry.ID != 1 || pairs[0].Exit == nil || pairs[0].Exit.ID != 3 {
+		t.Fatalf("unexpected first pair: %+v", pairs[0])
+	}
+	if pairs[1].Entry.ID != 2 || pairs[1].Exit != nil {
+		t.Fatalf("expected second trade to be open: %+v", pairs[1])
+	}
+
+	if open := OpenEntries(orders); len(open) != 1 || open[0].ID != 2 {
+		t.Fatalf("expected entry 2 open, got %+v", open)
+	}
+}
+
+func TestExcursionsAndRMultiple(t *testing.T) {
+	candles := []model.OHLCVCrypto1m{
+		candle(time.Time{}, "105", "98", "100"),
+		candle(time.Time{}, "110", "99", "108"),
+	}
+
+	mae, mfe := Excursions("Long", 100, candles)
+	if mae != 2 || mfe != 10 {
+		t.Fatalf("long excursions = %v/%v", mae, mfe)
+	}
+	mae, mfe = Excursions("Short", 100, candles)
+	if mae != 10 || mfe != 2 {
+		t.Fatalf("short excursions = %v/%v", mae, mfe)
+	}
+
+	if r := RMultiple("Long", 100, 110, 95); r == nil || *r != 2 {
+		t.Fatalf("long R = %v", r)
+	}
+	if r := RMultiple("Short", 100, 110, 105); r == nil || *r != -2 {
+		t.Fatalf("short R = %v", r)
+	}
+	if r := RMultiple("Long", 100, 110, 0); r != nil {
+		t.Fatalf("expected nil R without stop, got %v", *r)
+	}
+}
+
+func TestJournalBuilderBuild(t *testing.T) {
+	base := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	entryPrice := decimal.NewFromInt(100)
+
+	trades := &fakeJournalTrades{}
+	b := &JournalBuilder{
+		Orders: &fakeJournalOrders{orders: []model.Order{
+			{ID: 1, UserID: 1, ExchangeID: 1, ExternalID: 11, Symbol: "BTCUSDT", PosSide: "Long", Quantity: decimal.NewFromInt(2), StopLossPct: decimal.NewFromInt(95), Price: &entryPrice, Fee: 0.5, OrderDir: model.OrderDirectionEntry, Status: model.OrderExecutionStatusFilled, CreatedAt: base},
+			{ID: 2, UserID: 1, ExchangeID: 1, ExternalID: 12, Symbol: "BTCUSDT", Fee: 1.5, OrderDir: model.OrderDirectionExit, Status: model.OrderExecutionStatusPending, CreatedAt: base.Add(30 * time.Minute)},
+		}},
+		Executions: &fakeJournalExecutions{byOrder: map[uint]*model.PhemexOrder{
+			1: {OrderID: 1, Price: 99, TransactTime: base.Add(time.Second)},
+		}},
+		Candles: &fakeJournalCandles{candles: []model.OHLCVCrypto1m{
+			candle(base.Add(time.Minute), "104", "97", "103"),
+			candle(base.Add(29*time.Minute), "112", "101", "110"),
+		}},
+		Trades: trades,
+	}
+
+	written, err := b.Build(context.Background(), base.Add(-time.Hour), base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	if written != 1 || len(trades.trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", written)
+	}
+
+	tr := trades.trades[0]
+	if tr.Status != model.TradeStatusClosed || tr.EntrySignalID != 11 || *tr.ExitSignalID != 12 {
+		t.Fatalf("unexpected trade links: %+v", tr)
+	}
+	// order price wins over the execution price, exit price comes from the last candle
+	if tr.EntryPrice != 100 || *tr.ExitPrice != 110 {
+		t.Fatalf("unexpected prices: entry %v exit %v", tr.EntryPrice, *tr.ExitPrice)
+	}
+	// pnl is net of the entry and exit fees, R is measured on price
+	if *tr.PnL != 18 || tr.Fees != 2 || *tr.RMultiple != 2 {
+		t.Fatalf("unexpected pnl/fees/R: %v/%v/%v", *tr.PnL, tr.Fees, *tr.RMultiple)
+	}
+	if tr.DurationSeconds != int64((30*time.Minute - time.Second).Seconds()) {
+		t.Fatalf("unexpected duration: %d", tr.DurationSeconds)
+	}
+	if tr.MAE != 3 || tr.MFE != 12 {
+		t.Fatalf("unexpected excursions: %v/%v", tr.MAE, tr.MFE)
+	}
+}
+
+func TestJournalBuilderUsesInitialRisk(t *testing.T) {
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	entryPrice, exitPrice := decimal.NewFromInt(100), decimal.NewFromInt(110)
+	// opened with a stop at 96, since trailed to 99
+	initialRisk := InitialRisk(100, 96, 2)
+	strategyID := uint(4)
+
+	trades := &fakeJournalTrades{}
+	b := &JournalBuilder{
+		Orders: &fakeJournalOrders{orders: []model.Order{
+			{ID: 1, UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Long", Quantity: decimal.NewFromInt(2), StopLossPct: decimal.NewFromInt(99), InitialRisk: initialRisk, StrategyID: &strategyID, Price: &entryPrice, OrderDir: model.OrderDirectionEntry, Status: model.OrderExecutionStatusFilled, CreatedAt: base},
+			{ID: 2, UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", Price: &exitPrice, OrderDir: model.OrderDirectionExit, Status: model.OrderExecutionStatusFilled, CreatedAt: base.Add(time.Hour)},
+		}},
+		Executions: &fakeJournalExecutions{},
+		Candles:    &fakeJournalCandles{},
+		Trades:     trades,
+	}
+
+	if _, err := b.Build(context.Background(), base.Add(-time.Hour), base.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	tr := trades.trades[0]
+	if tr.InitialRisk == nil || *tr.InitialRisk != 8 {
+		t.Fatalf("expected the entry initial risk on the trade, got %v", tr.InitialRisk)
+	}
+	if *tr.RMultiple != 2.5 {
+		t.Fatalf("expected R measured on the initial stop, got %v", *tr.RMultiple)
+	}
+	if tr.StrategyID == nil || *tr.StrategyID != strategyID {
+		t.Fatalf("expected the entry strategy on the trade, got %v", tr.StrategyID)
+	}
+
+	if InitialRisk(100, 0, 2) != nil || InitialRisk(100, 100, 2) != nil {
+		t.Fatalf("expected no initial risk without a stop")
+	}
+}
+
+func TestSummarizeR(t *testing.T) {
+	r := func(v float64) *float64 { return &v }
+	stats := SummarizeR([]model.Trade{
+		{Status: model.TradeStatusClosed, RMultiple: r(2)},
+		{Status: model.TradeStatusClosed, RMultiple: r(-1)},
+		{Status: model.TradeStatusClosed, RMultiple: r(3.5)},
+		{Status: model.TradeStatusClosed, RMultiple: r(-0.5)},
+		{Status: model.TradeStatusClosed},
+		{Status: model.TradeStatusOpen, RMultiple: r(10)},
+	})
+
+	want := RStats{Trades: 4, Unmeasured: 1, Wins: 2, Losses: 2, TotalR: 4, Expectancy: 1, AvgWinR: 2.75, AvgLossR: -0.75, BestR: 3.5, WorstR: -1}
+	if stats != want {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if (SummarizeR(nil) != RStats{}) {
+		t.Fatalf("expected empty stats")
+	}
+}
+
+func TestAttachNotes(t *testing.T) {
+	exit := uint(2)
+	trades := []model.Trade{{EntryOrderID: 1, ExitOrderID: &exit}, {EntryOrderID: 3}}
+	if ids := TradeOrderIDs(trades); len(ids) != 3 || ids[1] != 2 {
+		t.Fatalf("order ids = %v", ids)
+	}
+
+	AttachNotes(trades, []model.OrderNote{
+		{ID: 1, OrderID: 1, Body: "entered by hand"},
+		{ID: 2, OrderID: 9, Body: "unrelated"},
+		{ID: 3, OrderID: 2, Body: "flattened during the outage"},
+	})
+	if len(trades[0].Notes) != 2 || trades[0].Notes[0].ID != 1 || trades[0].Notes[1].ID != 3 {
+		t.Fatalf("notes of trade 1 = %+v", trades[0].Notes)
+	}
+	if len(trades[1].Notes) != 0 {
+		t.Fatalf("notes of trade 2 = %+v", trades[1].Notes)
+	}
+}
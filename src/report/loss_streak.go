@@ -0,0 +1,142 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/risk"
+	"time"
+)
+
+// lossStreakWindow bounds how many closed trades are inspected per symbol.
+const lossStreakWindow = 50
+
+type lossStreakTradeSource interface {
+	List(ctx context.Context, filter repository.TradeFilter) ([]model.Trade, error)
+	FindRecentClosed(ctx context.Context, userID, exchangeID uint, symbol string, limit int) ([]model.Trade, error)
+}
+
+type lossStreakStore interface {
+	Get(ctx context.Context, userID, exchangeID uint, symbol string) (*model.LossStreak, error)
+	Upsert(ctx context.Context, s *model.LossStreak) error
+}
+
+type lossStreakSettings interface {
+	GetByUserID(ctx context.Context, userID uint) (*model.SignalFilterSetting, error)
+}
+
+type lossStreakNotifier interface {
+	Notify(ctx context.Context, ev notify.Event)
+}
+
+// LossStreakTracker refreshes the persisted consecutive-loss state from the
+// trade journal and notifies users when a cooldown starts.
+type LossStreakTracker struct {
+	Trades   lossStreakTradeSource
+	Streaks  lossStreakStore
+	Settings lossStreakSettings
+	Notifier lossStreakNotifier
+}
+
+// Refresh recomputes the streak of every user/exchange/symbol with a trade
+// closed since `since` and returns the number of streaks written.
+func (t *LossStreakTracker) Refresh(ctx context.Context, since time.Time, now time.Time) (int, error) {
+	trades, err := t.Trades.List(ctx, repository.TradeFilter{Status: model.TradeStatusClosed, From: since})
+	if err != nil {
+		return 0, fmt.Errorf("load closed trades: %w", err)
+	}
+
+	type key struct {
+		userID     uint
+		exchangeID uint
+		symbol     string
+	}
+	seen := map[key]bool{}
+	configs := map[uint]risk.LossStreakConfig{}
+
+	written := 0
+	for _, tr := range trades {
+		k := key{tr.UserID, tr.ExchangeID, tr.Symbol}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		cfg, ok := configs[tr.UserID]
+		if !ok {
+			if cfg, err = t.config(ctx, tr.UserID); err != nil {
+				return written, err
+			}
+			configs[tr.UserID] = cfg
+		}
+
+		if err := t.refresh(ctx, k.userID, k.exchangeID, k.symbol, cfg, now); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+func (t *LossStreakTracker) config(ctx context.Context, userID uint) (risk.LossStreakConfig, error) {
+	setting, err := t.Settings.GetByUserID(ctx, userID)
+	if err != nil {
+		return risk.LossStreakConfig{}, fmt.Errorf("load signal filter settings of user %d: %w", userID, err)
+	}
+	if setting == nil {
+		return risk.LossStreakConfig{}, nil
+	}
+	return risk.LossStreakConfig{
+		MaxLosses: setting.MaxConsecutiveLosses,
+		Cooldown:  time.Duration(setting.LossStreakCooldownMinutes) * time.Minute,
+	}, nil
+}
+
+func (t *LossStreakTracker) refresh(ctx context.Context, userID, exchangeID uint, symbol string, cfg risk.LossStreakConfig, now time.Time) error {
+	recent, err := t.Trades.FindRecentClosed(ctx, userID, exchangeID, symbol, lossStreakWindow)
+	if err != nil {
+		return fmt.Errorf("load recent trades of user %d %s: %w", userID, symbol, err)
+	}
+	state := risk.EvaluateLossStreak(recent, cfg)
+
+	previous, err := t.Streaks.Get(ctx, userID, exchangeID, symbol)
+	if err != nil {
+		return fmt.Errorf("load loss streak of user %d %s: %w", userID, symbol, err)
+	}
+
+	streak := &model.LossStreak{
+		UserID:            userID,
+		ExchangeID:        exchangeID,
+		Symbol:            symbol,
+		ConsecutiveLosses: state.Losses,
+		LastTradeID:       state.LastTradeID,
+		LastLossAt:        state.LastLossAt,
+		CooldownUntil:     state.CooldownUntil,
+	}
+	if err := t.Streaks.Upsert(ctx, streak); err != nil {
+		return fmt.Errorf("save loss streak of user %d %s: %w", userID, symbol, err)
+	}
+
+	if streak.InCooldown(now) && !sameTime(previous, streak.CooldownUntil) && t.Notifier != nil {
+		t.Notifier.Notify(ctx, notify.Event{
+			Type:   notify.EventLossCooldown,
+			UserID: userID,
+			Symbol: symbol,
+			Message: fmt.Sprintf("%d consecutive losing trades, entries blocked until %s",
+				state.Losses, streak.CooldownUntil.UTC().Format("2006-01-02 15:04 UTC")),
+			OccurredAt: now,
+		})
+	}
+	return nil
+}
+
+// sameTime reports whether the stored cooldown already ends at until, in
+// which case the user was notified on a previous run.
+func sameTime(previous *model.LossStreak, until *time.Time) bool {
+	if previous == nil || previous.CooldownUntil == nil || until == nil {
+		return false
+	}
+	return previous.CooldownUntil.Equal(*until)
+}
@@ -0,0 +1,101 @@
+package report
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/notify"
+	"strategyexecutor/src/repository"
+	"testing"
+	"time"
+)
+
+type fakeStreakTrades struct{ trades []model.Trade }
+
+func (f *fakeStreakTrades) List(context.Context, repository.TradeFilter) ([]model.Trade, error) {
+	return f.trades, nil
+}
+
+func (f *fakeStreakTrades) FindRecentClosed(_ context.Context, userID, exchangeID uint, symbol string, limit int) ([]model.Trade, error) {
+	var out []model.Trade
+	for _, tr := range f.trades {
+		if tr.UserID == userID && tr.ExchangeID == exchangeID && tr.Symbol == symbol && len(out) < limit {
+			out = append(out, tr)
+		}
+	}
+	return out, nil
+}
+
+type fakeStreakStore struct{ rows map[string]*model.LossStreak }
+
+func (f *fakeStreakStore) Get(_ context.Context, _, _ uint, symbol string) (*model.LossStreak, error) {
+	return f.rows[symbol], nil
+}
+
+func (f *fakeStreakStore) Upsert(_ context.Context, s *model.LossStreak) error {
+	f.rows[s.Symbol] = s
+	return nil
+}
+
+type fakeStreakSettings struct{ setting *model.SignalFilterSetting }
+
+func (f *fakeStreakSettings) GetByUserID(context.Context, uint) (*model.SignalFilterSetting, error) {
+	return f.setting, nil
+}
+
+type fakeStreakNotifier struct{ events []notify.Event }
+
+func (f *fakeStreakNotifier) Notify(_ context.Context, ev notify.Event) {
+	f.events = append(f.events, ev)
+}
+
+func TestLossStreakTrackerRefresh(t *testing.T) {
+	base := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	closed := func(id uint, symbol string, pnl float64, exit time.Time) model.Trade {
+		return model.Trade{ID: id, UserID: 1, ExchangeID: 1, Symbol: symbol, Status: model.TradeStatusClosed, PnL: &pnl, ExitTime: &exit}
+	}
+
+	// newest exit first, as returned by the repository
+	trades := &fakeStreakTrades{trades: []model.Trade{
+		closed(5, "BTCUSDT", -3, base.Add(3*time.Hour)),
+		closed(4, "ETHUSDT", -1, base.Add(150*time.Minute)),
+		closed(3, "BTCUSDT", -2, base.Add(2*time.Hour)),
+		closed(2, "ETHUSDT", 4, base.Add(90*time.Minute)),
+		closed(1, "BTCUSDT", 1, base.Add(time.Hour)),
+	}}
+	store := &fakeStreakStore{rows: map[string]*model.LossStreak{}}
+	notifier := &fakeStreakNotifier{}
+	tracker := &LossStreakTracker{
+		Trades:   trades,
+		Streaks:  store,
+		Settings: &fakeStreakSettings{setting: &model.SignalFilterSetting{MaxConsecutiveLosses: 2, LossStreakCooldownMinutes: 60}},
+		Notifier: notifier,
+	}
+
+	now := base.Add(3*time.Hour + 10*time.Minute)
+	written, err := tracker.Refresh(context.Background(), base, now)
+	if err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if written != 2 {
+		t.Fatalf("expected 2 streaks, got %d", written)
+	}
+
+	btc := store.rows["BTCUSDT"]
+	if btc.ConsecutiveLosses != 2 || btc.LastTradeID != 5 || !btc.CooldownUntil.Equal(base.Add(4*time.Hour)) {
+		t.Fatalf("unexpected BTC streak: %+v", btc)
+	}
+	if eth := store.rows["ETHUSDT"]; eth.ConsecutiveLosses != 1 || eth.CooldownUntil != nil {
+		t.Fatalf("unexpected ETH streak: %+v", eth)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Type != notify.EventLossCooldown || notifier.events[0].Symbol != "BTCUSDT" {
+		t.Fatalf("unexpected notifications: %+v", notifier.events)
+	}
+
+	// A second run with the same trades keeps the cooldown without notifying again.
+	if _, err := tracker.Refresh(context.Background(), base, now.Add(time.Minute)); err != nil {
+		t.Fatalf("Refresh returned error: %v", err)
+	}
+	if len(notifier.events) != 1 {
+		t.Fatalf("expected no new notification, got %d", len(notifier.events))
+	}
+}
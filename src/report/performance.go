@@ -0,0 +1,92 @@
+package report
+
+import (
+	"sort"
+	"strategyexecutor/src/model"
+)
+
+// MonthlyPnL is the net PnL of the trades closed in a calendar month (UTC).
+type MonthlyPnL struct {
+	Month  string  `json:"month"` // 2006-01
+	Trades int     `json:"trades"`
+	PnL    float64 `json:"pnl"`
+}
+
+// Performance is the track record of a group of closed trades.
+type Performance struct {
+	Key     string  `json:"key"`
+	Trades  int     `json:"trades"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	WinRate float64 `json:"win_rate"`
+	NetPnL  float64 `json:"net_pnl"`
+	// ProfitFactor is the gross profit over the gross loss, nil when nothing
+	// was lost.
+	ProfitFactor *float64 `json:"profit_factor,omitempty"`
+	// Expectancy is the average net PnL per trade.
+	Expectancy float64 `json:"expectancy"`
+	// AvgR is the average R multiple of the trades with a known risk.
+	AvgR    float64      `json:"avg_r"`
+	Monthly []MonthlyPnL `json:"monthly"`
+}
+
+// SummarizePerformance groups the closed trades with a PnL among trades by
+// key and returns the performance of each group, ordered by key.
+func SummarizePerformance(trades []model.Trade, key func(model.Trade) string) []Performance {
+	groups := map[string][]model.Trade{}
+	for _, t := range trades {
+		if t.Status != model.TradeStatusClosed || t.PnL == nil || t.ExitTime == nil {
+			continue
+		}
+		k := key(t)
+		groups[k] = append(groups[k], t)
+	}
+
+	out := make([]Performance, 0, len(groups))
+	for k, group := range groups {
+		out = append(out, performance(k, group))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+func performance(key string, trades []model.Trade) Performance {
+	p := Performance{Key: key, Trades: len(trades), Monthly: []MonthlyPnL{}}
+	var grossProfit, grossLoss float64
+	months := map[string]*MonthlyPnL{}
+	for _, t := range trades {
+		pnl := *t.PnL
+		p.NetPnL += pnl
+		switch {
+		case pnl > 0:
+			p.Wins++
+			grossProfit += pnl
+		case pnl < 0:
+			p.Losses++
+			grossLoss -= pnl
+		}
+
+		month := t.ExitTime.UTC().Format("2006-01")
+		m, ok := months[month]
+		if !ok {
+			m = &MonthlyPnL{Month: month}
+			months[month] = m
+		}
+		m.Trades++
+		m.PnL += pnl
+	}
+
+	p.WinRate = float64(p.Wins) / float64(p.Trades)
+	p.Expectancy = p.NetPnL / float64(p.Trades)
+	if grossLoss > 0 {
+		pf := grossProfit / grossLoss
+		p.ProfitFactor = &pf
+	}
+	p.AvgR = SummarizeR(trades).Expectancy
+
+	for _, m := range months {
+		p.Monthly = append(p.Monthly, *m)
+	}
+	sort.Slice(p.Monthly, func(i, j int) bool { return p.Monthly[i].Month < p.Monthly[j].Month })
+	return p
+}
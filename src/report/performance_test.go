@@ -0,0 +1,47 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"strategyexecutor/src/model"
+)
+
+func TestSummarizePerformance(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	at := func(month time.Month, day int) *time.Time {
+		v := time.Date(2025, month, day, 12, 0, 0, 0, time.UTC)
+		return &v
+	}
+	trades := []model.Trade{
+		{Symbol: "BTCUSDT", Status: model.TradeStatusClosed, PnL: f(30), RMultiple: f(3), ExitTime: at(1, 5)},
+		{Symbol: "BTCUSDT", Status: model.TradeStatusClosed, PnL: f(-10), RMultiple: f(-1), ExitTime: at(1, 20)},
+		{Symbol: "BTCUSDT", Status: model.TradeStatusClosed, PnL: f(-5), ExitTime: at(2, 1)},
+		{Symbol: "ETHUSDT", Status: model.TradeStatusClosed, PnL: f(8), RMultiple: f(1), ExitTime: at(2, 3)},
+		{Symbol: "ETHUSDT", Status: model.TradeStatusOpen},
+	}
+
+	got := SummarizePerformance(trades, func(t model.Trade) string { return t.Symbol })
+	if len(got) != 2 || got[0].Key != "BTCUSDT" || got[1].Key != "ETHUSDT" {
+		t.Fatalf("unexpected groups: %+v", got)
+	}
+
+	btc := got[0]
+	if btc.Trades != 3 || btc.Wins != 1 || btc.Losses != 2 || btc.NetPnL != 15 || btc.Expectancy != 5 {
+		t.Fatalf("unexpected BTC performance: %+v", btc)
+	}
+	if btc.WinRate != 1.0/3 || btc.ProfitFactor == nil || *btc.ProfitFactor != 2 || btc.AvgR != 1 {
+		t.Fatalf("unexpected BTC ratios: %+v", btc)
+	}
+	if len(btc.Monthly) != 2 || btc.Monthly[0] != (MonthlyPnL{Month: "2025-01", Trades: 2, PnL: 20}) ||
+		btc.Monthly[1] != (MonthlyPnL{Month: "2025-02", Trades: 1, PnL: -5}) {
+		t.Fatalf("unexpected BTC monthly PnL: %+v", btc.Monthly)
+	}
+
+	if got[1].ProfitFactor != nil || got[1].WinRate != 1 {
+		t.Fatalf("expected no profit factor without losses: %+v", got[1])
+	}
+	if len(SummarizePerformance(nil, func(model.Trade) string { return "" })) != 0 {
+		t.Fatalf("expected no group without trades")
+	}
+}
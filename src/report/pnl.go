@@ -0,0 +1,83 @@
+// Package report computes trading performance summaries.
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fill is an exchange-neutral execution used for PnL reporting.
+type Fill struct {
+	Time      time.Time
+	Symbol    string
+	ClosedPnL decimal.Decimal // realized PnL booked by this fill, zero for opening fills
+	Fee       decimal.Decimal // fee paid (positive) or rebate (negative)
+}
+
+// DailyStats is the outcome of ComputeDailyStats.
+type DailyStats struct {
+	RealizedPnL   decimal.Decimal
+	UnrealizedPnL decimal.Decimal
+	Fees          decimal.Decimal
+	NetPnL        decimal.Decimal
+	MaxDrawdown   decimal.Decimal
+	Fills         int
+	Wins          int
+	Losses        int
+	WinRate       float64
+}
+
+// ComputeDailyStats aggregates the fills of one day. A closing fill with
+// positive PnL counts as a win, negative as a loss; opening fills only add
+// fees. MaxDrawdown is the largest peak-to-trough drop of the cumulative
+// realized PnL net of fees, in chronological order, starting from zero.
+func ComputeDailyStats(fills []Fill, unrealized decimal.Decimal) DailyStats {
+	sorted := make([]Fill, len(fills))
+	copy(sorted, fills)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	stats := DailyStats{
+		RealizedPnL:   decimal.Zero,
+		UnrealizedPnL: unrealized,
+		Fees:          decimal.Zero,
+		MaxDrawdown:   decimal.Zero,
+		Fills:         len(sorted),
+	}
+
+	equity := decimal.Zero
+	peak := decimal.Zero
+	for _, f := range sorted {
+		stats.RealizedPnL = stats.RealizedPnL.Add(f.ClosedPnL)
+		stats.Fees = stats.Fees.Add(f.Fee)
+
+		switch {
+		case f.ClosedPnL.IsPositive():
+			stats.Wins++
+		case f.ClosedPnL.IsNegative():
+			stats.Losses++
+		}
+
+		equity = equity.Add(f.ClosedPnL).Sub(f.Fee)
+		if equity.GreaterThan(peak) {
+			peak = equity
+		}
+		if dd := peak.Sub(equity); dd.GreaterThan(stats.MaxDrawdown) {
+			stats.MaxDrawdown = dd
+		}
+	}
+
+	if closed := stats.Wins + stats.Losses; closed > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(closed)
+	}
+	stats.NetPnL = stats.RealizedPnL.Sub(stats.Fees).Add(stats.UnrealizedPnL)
+
+	return stats
+}
+
+// DayBounds returns [00:00, 24:00) UTC of the day containing t.
+func DayBounds(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.Add(24 * time.Hour)
+}
@@ -0,0 +1,49 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func TestComputeDailyStats(t *testing.T) {
+	base := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	fills := []Fill{
+		{Time: base.Add(3 * time.Hour), ClosedPnL: d("-30"), Fee: d("1")},
+		{Time: base.Add(1 * time.Hour), ClosedPnL: d("0"), Fee: d("1")},
+		{Time: base.Add(2 * time.Hour), ClosedPnL: d("50"), Fee: d("1")},
+		{Time: base.Add(4 * time.Hour), ClosedPnL: d("10"), Fee: d("1")},
+	}
+
+	stats := ComputeDailyStats(fills, d("5"))
+
+	if !stats.RealizedPnL.Equal(d("30")) {
+		t.Fatalf("RealizedPnL = %s", stats.RealizedPnL)
+	}
+	if !stats.Fees.Equal(d("4")) {
+		t.Fatalf("Fees = %s", stats.Fees)
+	}
+	if !stats.NetPnL.Equal(d("31")) {
+		t.Fatalf("NetPnL = %s", stats.NetPnL)
+	}
+	// equity: -1, 48, 17, 26 -> peak 48, trough 17
+	if !stats.MaxDrawdown.Equal(d("31")) {
+		t.Fatalf("MaxDrawdown = %s", stats.MaxDrawdown)
+	}
+	if stats.Fills != 4 || stats.Wins != 2 || stats.Losses != 1 {
+		t.Fatalf("unexpected counts: %+v", stats)
+	}
+	if stats.WinRate < 0.666 || stats.WinRate > 0.667 {
+		t.Fatalf("WinRate = %v", stats.WinRate)
+	}
+}
+
+func TestComputeDailyStatsEmpty(t *testing.T) {
+	stats := ComputeDailyStats(nil, decimal.Zero)
+	if stats.Fills != 0 || stats.WinRate != 0 || !stats.NetPnL.IsZero() || !stats.MaxDrawdown.IsZero() {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
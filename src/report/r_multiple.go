@@ -0,0 +1,64 @@
+package report
+
+import "strategyexecutor/src/model"
+
+// RStats aggregates the R multiples of closed trades. Trades without an R
+// multiple (no stop known) are counted in Unmeasured only.
+type RStats struct {
+	Trades     int     `json:"trades"`
+	Unmeasured int     `json:"unmeasured"`
+	Wins       int     `json:"wins"`
+	Losses     int     `json:"losses"`
+	TotalR     float64 `json:"total_r"`
+	// Expectancy is the average R per measured trade.
+	Expectancy float64 `json:"expectancy"`
+	AvgWinR    float64 `json:"avg_win_r"`
+	AvgLossR   float64 `json:"avg_loss_r"`
+	BestR      float64 `json:"best_r"`
+	WorstR     float64 `json:"worst_r"`
+}
+
+// SummarizeR aggregates the R multiples of the closed trades among trades.
+func SummarizeR(trades []model.Trade) RStats {
+	var stats RStats
+	var winR, lossR float64
+	for _, t := range trades {
+		if t.Status != model.TradeStatusClosed {
+			continue
+		}
+		if t.RMultiple == nil {
+			stats.Unmeasured++
+			continue
+		}
+
+		r := *t.RMultiple
+		if stats.Trades == 0 || r > stats.BestR {
+			stats.BestR = r
+		}
+		if stats.Trades == 0 || r < stats.WorstR {
+			stats.WorstR = r
+		}
+		stats.Trades++
+		stats.TotalR += r
+
+		switch {
+		case r > 0:
+			stats.Wins++
+			winR += r
+		case r < 0:
+			stats.Losses++
+			lossR += r
+		}
+	}
+
+	if stats.Trades > 0 {
+		stats.Expectancy = stats.TotalR / float64(stats.Trades)
+	}
+	if stats.Wins > 0 {
+		stats.AvgWinR = winR / float64(stats.Wins)
+	}
+	if stats.Losses > 0 {
+		stats.AvgLossR = lossR / float64(stats.Losses)
+	}
+	return stats
+}
@@ -0,0 +1,253 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strategyexecutor/src/model"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Cost basis methods of MatchLots.
+const (
+	// LotsFIFO closes the oldest open lot first.
+	LotsFIFO = "fifo"
+	// LotsAverage pools the open lots of an instrument at their weighted
+	// average price.
+	LotsAverage = "average"
+)
+
+// LotFill is one execution fed to MatchLots: a buy or a sell of Quantity at
+// Price, Fee being paid in the quote currency of the symbol.
+type LotFill struct {
+	Time     time.Time
+	Exchange string
+	Symbol   string
+	Side     string // Buy or Sell
+	Quantity decimal.Decimal
+	Price    decimal.Decimal
+	Fee      decimal.Decimal
+	// Ref identifies where the fill comes from, e.g. a trade id.
+	Ref uint
+}
+
+// RealizedGain is the disposal of (part of) a lot. CostBasis is what was
+// paid to buy the quantity and Proceeds what its sale returned, both net of
+// their share of the fees, whatever the side of the lot: the buy of a short
+// lot is its closing fill.
+type RealizedGain struct {
+	Exchange  string          `json:"exchange"`
+	Symbol    string          `json:"symbol"`
+	Currency  string          `json:"currency"`
+	PosSide   string          `json:"pos_side"` // long or short
+	Quantity  decimal.Decimal `json:"quantity"`
+	OpenedAt  time.Time       `json:"opened_at"`
+	ClosedAt  time.Time       `json:"closed_at"`
+	CostBasis decimal.Decimal `json:"cost_basis"`
+	Proceeds  decimal.Decimal `json:"proceeds"`
+	Fees      decimal.Decimal `json:"fees"`
+	Gain      decimal.Decimal `json:"gain"`
+	OpenRef   uint            `json:"open_ref"`
+	CloseRef  uint            `json:"close_ref"`
+}
+
+// CurrencyGains sums the realized gains in one currency.
+type CurrencyGains struct {
+	Currency  string          `json:"currency"`
+	Disposals int             `json:"disposals"`
+	CostBasis decimal.Decimal `json:"cost_basis"`
+	Proceeds  decimal.Decimal `json:"proceeds"`
+	Fees      decimal.Decimal `json:"fees"`
+	Gain      decimal.Decimal `json:"gain"`
+}
+
+// openLot is the open quantity of a fill, with what is left of its fee.
+type openLot struct {
+	short    bool
+	qty      decimal.Decimal
+	price    decimal.Decimal
+	fee      decimal.Decimal
+	openedAt time.Time
+	ref      uint
+}
+
+// MatchLots matches the fills, in time order, against the open lots of
+// their exchange and symbol with method and returns the gains realized, in
+// the order they were. A fill first closes the lots of the opposite side;
+// what is left of it opens a lot, long for a buy and short for a sell.
+func MatchLots(fills []LotFill, method string) ([]RealizedGain, error) {
+	if method != LotsFIFO && method != LotsAverage {
+		return nil, fmt.Errorf("unknown cost basis method %q, want fifo or average", method)
+	}
+	sorted := make([]LotFill, len(fills))
+	copy(sorted, fills)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	books := map[string][]openLot{}
+	var gains []RealizedGain
+	for _, f := range sorted {
+		if !f.Quantity.IsPositive() {
+			continue
+		}
+		key := f.Exchange + "|" + f.Symbol
+		buy := strings.EqualFold(f.Side, "Buy")
+		feePerUnit := f.Fee.Div(f.Quantity)
+		remaining := f.Quantity
+
+		lots := books[key]
+		for remaining.IsPositive() && len(lots) > 0 && lots[0].short == buy {
+			lot := &lots[0]
+			qty := decimal.Min(remaining, lot.qty)
+			openFee := lot.fee.Mul(qty).Div(lot.qty)
+			closeFee := feePerUnit.Mul(qty)
+			gains = append(gains, realize(f, *lot, qty, openFee, closeFee))
+
+			lot.qty = lot.qty.Sub(qty)
+			lot.fee = lot.fee.Sub(openFee)
+			remaining = remaining.Sub(qty)
+			if !lot.qty.IsPositive() {
+				lots = lots[1:]
+			}
+		}
+
+		if remaining.IsPositive() {
+			lot := openLot{
+				short:    !buy,
+				qty:      remaining,
+				price:    f.Price,
+				fee:      feePerUnit.Mul(remaining),
+				openedAt: f.Time,
+				ref:      f.Ref,
+			}
+			if method == LotsAverage && len(lots) > 0 {
+				lots[0] = pool(lots[0], lot)
+			} else {
+				lots = append(lots, lot)
+			}
+		}
+		books[key] = lots
+	}
+	return gains, nil
+}
+
+// pool merges lot into the pooled lot of the average cost method.
+func pool(pooled, lot openLot) openLot {
+	qty := pooled.qty.Add(lot.qty)
+	pooled.price = pooled.qty.Mul(pooled.price).Add(lot.qty.Mul(lot.price)).Div(qty)
+	pooled.qty = qty
+	pooled.fee = pooled.fee.Add(lot.fee)
+	return pooled
+}
+
+func realize(f LotFill, lot openLot, qty, openFee, closeFee decimal.Decimal) RealizedGain {
+	g := RealizedGain{
+		Exchange: f.Exchange,
+		Symbol:   f.Symbol,
+		Currency: QuoteCurrency(f.Symbol),
+		PosSide:  "long",
+		Quantity: qty,
+		OpenedAt: lot.openedAt,
+		ClosedAt: f.Time,
+		Fees:     openFee.Add(closeFee),
+		OpenRef:  lot.ref,
+		CloseRef: f.Ref,
+	}
+	opened, closed := qty.Mul(lot.price), qty.Mul(f.Price)
+	if lot.short {
+		g.PosSide = "short"
+		g.Proceeds = opened.Sub(openFee)
+		g.CostBasis = closed.Add(closeFee)
+	} else {
+		g.CostBasis = opened.Add(openFee)
+		g.Proceeds = closed.Sub(closeFee)
+	}
+	g.Gain = g.Proceeds.Sub(g.CostBasis)
+	return g
+}
+
+// SummarizeGains sums gains per currency, ordered by currency.
+func SummarizeGains(gains []RealizedGain) []CurrencyGains {
+	byCurrency := map[string]*CurrencyGains{}
+	for _, g := range gains {
+		s := byCurrency[g.Currency]
+		if s == nil {
+			s = &CurrencyGains{Currency: g.Currency}
+			byCurrency[g.Currency] = s
+		}
+		s.Disposals++
+		s.CostBasis = s.CostBasis.Add(g.CostBasis)
+		s.Proceeds = s.Proceeds.Add(g.Proceeds)
+		s.Fees = s.Fees.Add(g.Fees)
+		s.Gain = s.Gain.Add(g.Gain)
+	}
+	out := make([]CurrencyGains, 0, len(byCurrency))
+	for _, s := range byCurrency {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Currency < out[j].Currency })
+	return out
+}
+
+// GainsInYear returns the gains realized in the UTC calendar year.
+func GainsInYear(gains []RealizedGain, year int) []RealizedGain {
+	var out []RealizedGain
+	for _, g := range gains {
+		if g.ClosedAt.UTC().Year() == year {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// quoteCurrencies are the settlement currencies recognized at the end of a
+// symbol, longest first: KuCoin suffixes its USDT perpetuals with an M.
+var quoteCurrencies = []struct{ suffix, currency string }{
+	{"USDTM", "USDT"},
+	{"USDT", "USDT"},
+	{"USDC", "USDC"},
+	{"USD", "USD"},
+}
+
+// QuoteCurrency returns the currency symbol is quoted and settled in, e.g.
+// USDT for BTCUSDT, or "" when it is not recognized.
+func QuoteCurrency(symbol string) string {
+	upper := strings.ToUpper(symbol)
+	for _, q := range quoteCurrencies {
+		if strings.HasSuffix(upper, q.suffix) {
+			return q.currency
+		}
+	}
+	return ""
+}
+
+// TradeFills turns the closed trades into the fills that opened and closed
+// them. A trade stores the sum of the fees of both orders: it is split
+// between them by notional, exact when both paid the same fee rate.
+func TradeFills(trades []model.Trade, exchangeNames map[uint]string) []LotFill {
+	var fills []LotFill
+	for _, t := range trades {
+		if t.Status != model.TradeStatusClosed || t.ExitTime == nil || t.ExitPrice == nil {
+			continue
+		}
+		qty := decimal.NewFromFloat(t.Quantity)
+		entry, exit := decimal.NewFromFloat(t.EntryPrice), decimal.NewFromFloat(*t.ExitPrice)
+		fees := decimal.NewFromFloat(t.Fees)
+		entryFee := decimal.Zero
+		if notional := entry.Add(exit); notional.IsPositive() {
+			entryFee = fees.Mul(entry).Div(notional)
+		}
+
+		openSide, closeSide := "Buy", "Sell"
+		if strings.EqualFold(t.PosSide, "Short") {
+			openSide, closeSide = "Sell", "Buy"
+		}
+		exchange := exchangeNames[t.ExchangeID]
+		fills = append(fills,
+			LotFill{Time: t.EntryTime, Exchange: exchange, Symbol: t.Symbol, Side: openSide, Quantity: qty, Price: entry, Fee: entryFee, Ref: t.ID},
+			LotFill{Time: *t.ExitTime, Exchange: exchange, Symbol: t.Symbol, Side: closeSide, Quantity: qty, Price: exit, Fee: fees.Sub(entryFee), Ref: t.ID},
+		)
+	}
+	return fills
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+func TestMatchLots(t *testing.T) {
+	at := func(month int) time.Time { return time.Date(2024, time.Month(month), 1, 0, 0, 0, 0, time.UTC) }
+	fills := []LotFill{
+		{Time: at(3), Symbol: "BTCUSDT", Side: "Sell", Quantity: d("1.5"), Price: d("130"), Fee: d("1.5"), Ref: 3},
+		{Time: at(1), Symbol: "BTCUSDT", Side: "Buy", Quantity: d("1"), Price: d("100"), Fee: d("1"), Ref: 1},
+		{Time: at(2), Symbol: "BTCUSDT", Side: "Buy", Quantity: d("1"), Price: d("120"), Fee: d("1"), Ref: 2},
+		// closes the last long half and opens a short half
+		{Time: at(4), Symbol: "BTCUSDT", Side: "Sell", Quantity: d("1"), Price: d("90"), Fee: d("1"), Ref: 4},
+		{Time: at(14), Symbol: "BTCUSDT", Side: "Buy", Quantity: d("0.5"), Price: d("80"), Fee: d("0.5"), Ref: 5},
+		{Time: at(5), Symbol: "ETHUSD", Side: "Buy", Quantity: d("2"), Price: d("10"), Ref: 6},
+	}
+
+	cases := []struct {
+		method string
+		gains  []string
+	}{
+		{LotsFIFO, []string{"28", "4", "-16", "4"}},
+		{LotsAverage, []string{"27", "-11", "4"}},
+	}
+	for _, tc := range cases {
+		gains, err := MatchLots(fills, tc.method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(gains) != len(tc.gains) {
+			t.Fatalf("%s: got %d gains, want %d: %+v", tc.method, len(gains), len(tc.gains), gains)
+		}
+		for i, want := range tc.gains {
+			if !gains[i].Gain.Equal(d(want)) {
+				t.Fatalf("%s: gain %d = %s, want %s", tc.method, i, gains[i].Gain, want)
+			}
+		}
+
+		short := gains[len(gains)-1]
+		if short.PosSide != "short" || !short.Proceeds.Equal(d("44.5")) || !short.CostBasis.Equal(d("40.5")) || short.OpenRef != 4 || short.CloseRef != 5 {
+			t.Fatalf("%s: unexpected short disposal %+v", tc.method, short)
+		}
+
+		summary := SummarizeGains(GainsInYear(gains, 2024))
+		if len(summary) != 1 || summary[0].Currency != "USDT" || !summary[0].Gain.Equal(d("16")) {
+			t.Fatalf("%s: unexpected 2024 summary %+v", tc.method, summary)
+		}
+	}
+
+	if _, err := MatchLots(fills, "lifo"); err == nil {
+		t.Fatal("expected an unknown method rejected")
+	}
+}
+
+func TestTradeFills(t *testing.T) {
+	entry := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	exit := entry.Add(time.Hour)
+	exitPrice := 300.0
+	fills := TradeFills([]model.Trade{
+		{ID: 7, ExchangeID: 1, Symbol: "ETHUSDTM", PosSide: "Short", Status: model.TradeStatusClosed, Quantity: 2, EntryPrice: 100, EntryTime: entry, ExitPrice: &exitPrice, ExitTime: &exit, Fees: 4},
+		{ID: 8, Symbol: "ETHUSDTM", Status: model.TradeStatusOpen, Quantity: 1, EntryPrice: 100, EntryTime: entry},
+	}, map[uint]string{1: "kucoin"})
+
+	if len(fills) != 2 {
+		t.Fatalf("got %d fills, want the 2 of the closed trade", len(fills))
+	}
+	if fills[0].Side != "Sell" || !fills[0].Fee.Equal(d("1")) || fills[1].Side != "Buy" || !fills[1].Fee.Equal(d("3")) || fills[1].Exchange != "kucoin" {
+		t.Fatalf("unexpected fills %+v", fills)
+	}
+	if QuoteCurrency(fills[0].Symbol) != "USDT" || QuoteCurrency("PF_XBTUSD") != "USD" || QuoteCurrency("BTCEUR") != "" {
+		t.Fatal("unexpected quote currencies")
+	}
+}
@@ -170,98 +170,104 @@ func (r *PhemexOrderRepository) FindByOrderID(
 	return &order, nil
 }
 
-// ---------------------------------------------------
-// Query helpers
-// ---------------------------------------------------
-
-// FindLatest returns the latest Phemex orders ordered from newest to oldest.
-func (r *PhemexOrderRepository) FindLatest(
+// UpdateFee stores the fee summed from the fills of the Phemex order with
+// the given exchange order ID.
+func (r *PhemexOrderRepository) UpdateFee(
 	ctx context.Context,
-	limit int,
-) ([]model.PhemexOrder, error) {
+	exchangeOrderID string,
+	fee float64,
+	currency string,
+) error {
 
-	if limit <= 0 {
-		limit = 20
+	fields := map[string]interface{}{
+		"repo":              "PhemexOrderRepository",
+		"op":                "UpdateFee",
+		"exchange_order_id": exchangeOrderID,
+		"fee":               fee,
 	}
 
-	logger.WithFields(map[string]interface{}{
-		"repo":  "PhemexOrderRepository",
-		"op":    "FindLatest",
-		"limit": limit,
-	}).Debug("Fetching latest Phemex orders")
-
-	var orders []model.PhemexOrder
-
 	err := r.db.WithContext(ctx).
-		Order("id DESC").
-		Limit(limit).
-		Find(&orders).Error
+		Model(&model.PhemexOrder{}).
+		Where("exchange_order_id = ?", exchangeOrderID).
+		Updates(map[string]interface{}{
+			"fee":          fee,
+			"fee_currency": currency,
+		}).Error
 
 	if err != nil {
-		logger.WithFields(map[string]interface{}{
-			"repo":  "PhemexOrderRepository",
-			"op":    "FindLatest",
-			"limit": limit,
-		}).WithError(err).Error("Failed to fetch latest Phemex orders")
-
-		return nil, err
+		logger.WithFields(fields).WithError(err).Error("Failed to update Phemex order fee")
+		return err
 	}
 
-	logger.WithFields(map[string]interface{}{
-		"repo":        "PhemexOrderRepository",
-		"op":          "FindLatest",
-		"limit":       limit,
-		"rows_return": len(orders),
-	}).Info("Latest Phemex orders fetched")
+	logger.WithFields(fields).Debug("Phemex order fee updated successfully")
+	return nil
+}
 
-	return orders, nil
+// ---------------------------------------------------
+// Query helpers
+// ---------------------------------------------------
+
+// FindLatest returns one page of Phemex orders, newest first unless
+// page.Sort is asc.
+func (r *PhemexOrderRepository) FindLatest(
+	ctx context.Context,
+	page Pagination,
+) ([]model.PhemexOrder, error) {
+	return r.findPage(ctx, "FindLatest", "", page)
 }
 
-// FindLatestBySymbol returns the latest Phemex orders for a given symbol,
-// ordered from newest to oldest.
+// FindLatestBySymbol returns one page of Phemex orders for a given symbol.
 func (r *PhemexOrderRepository) FindLatestBySymbol(
 	ctx context.Context,
 	symbol string,
-	limit int,
+	page Pagination,
 ) ([]model.PhemexOrder, error) {
+	return r.findPage(ctx, "FindLatestBySymbol", symbol, page)
+}
 
-	if limit <= 0 {
-		limit = 20
-	}
+func (r *PhemexOrderRepository) findPage(
+	ctx context.Context,
+	op string,
+	symbol string,
+	page Pagination,
+) ([]model.PhemexOrder, error) {
+
+	page = page.Normalize()
 
 	logger.WithFields(map[string]interface{}{
 		"repo":   "PhemexOrderRepository",
-		"op":     "FindLatestBySymbol",
+		"op":     op,
 		"symbol": symbol,
-		"limit":  limit,
-	}).Debug("Fetching latest Phemex orders by symbol")
+		"limit":  page.Limit,
+		"cursor": page.Cursor,
+	}).Debug("Fetching latest Phemex orders")
 
 	var orders []model.PhemexOrder
 
-	err := r.db.WithContext(ctx).
-		Where("symbol = ?", symbol).
-		Order("id DESC").
-		Limit(limit).
-		Find(&orders).Error
+	q := r.db.WithContext(ctx).
+		Scopes(ownedOrderScope(ctx), paginate(page, "created_at"))
+	if symbol != "" {
+		q = q.Where("symbol = ?", symbol)
+	}
 
-	if err != nil {
+	if err := q.Find(&orders).Error; err != nil {
 		logger.WithFields(map[string]interface{}{
 			"repo":   "PhemexOrderRepository",
-			"op":     "FindLatestBySymbol",
+			"op":     op,
 			"symbol": symbol,
-			"limit":  limit,
-		}).WithError(err).Error("Failed to fetch latest Phemex orders by symbol")
+			"limit":  page.Limit,
+		}).WithError(err).Error("Failed to fetch latest Phemex orders")
 
 		return nil, err
 	}
 
 	logger.WithFields(map[string]interface{}{
 		"repo":        "PhemexOrderRepository",
-		"op":          "FindLatestBySymbol",
+		"op":          op,
 		"symbol":      symbol,
-		"limit":       limit,
+		"limit":       page.Limit,
 		"rows_return": len(orders),
-	}).Info("Latest Phemex orders by symbol fetched")
+	}).Info("Latest Phemex orders fetched")
 
 	return orders, nil
 }
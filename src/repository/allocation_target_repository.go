@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AllocationTargetRepository persists the per-user target allocation of
+// assets across exchanges.
+type AllocationTargetRepository struct {
+	db *gorm.DB
+}
+
+func NewAllocationTargetRepository() *AllocationTargetRepository {
+	return &AllocationTargetRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewAllocationTargetRepositoryWithDB(db *gorm.DB) *AllocationTargetRepository {
+	return &AllocationTargetRepository{
+		db: db,
+	}
+}
+
+// ListByUser returns the targets of userID with their Exchange preloaded,
+// ordered by asset and exchange.
+func (r *AllocationTargetRepository) ListByUser(ctx context.Context, userID uint) ([]model.AllocationTarget, error) {
+	var rows []model.AllocationTarget
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Preload("Exchange").
+		Where("user_id = ?", userID).
+		Order("asset ASC, exchange_id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Upsert inserts or replaces the target of (UserID, ExchangeID, Asset).
+func (r *AllocationTargetRepository) Upsert(ctx context.Context, t *model.AllocationTarget) error {
+	if err := checkOwner(ctx, t.UserID); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "exchange_id"}, {Name: "asset"}},
+			DoUpdates: clause.AssignmentColumns([]string{"target_pct", "updated_at"}),
+		}).
+		Create(t).Error
+}
+
+// Delete removes the target of (userID, exchangeID, asset). It reports
+// whether a row was removed.
+func (r *AllocationTargetRepository) Delete(ctx context.Context, userID, exchangeID uint, asset string) (bool, error) {
+	if err := checkOwner(ctx, userID); err != nil {
+		return false, err
+	}
+	res := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ? AND asset = ?", userID, exchangeID, asset).
+		Delete(&model.AllocationTarget{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+)
+
+func TestAllocationTargetUpsertListDelete(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.AllocationTarget{}))
+	require.NoError(t, db.Create(&model.Exchange{ID: 1, Name: "phemex"}).Error)
+	require.NoError(t, db.Create(&model.Exchange{ID: 2, Name: "kucoin"}).Error)
+	repo := NewAllocationTargetRepositoryWithDB(db)
+	ctx := context.Background()
+
+	upsert := func(userID, exchangeID uint, pct int64) {
+		t.Helper()
+		require.NoError(t, repo.Upsert(ctx, &model.AllocationTarget{
+			UserID: userID, ExchangeID: exchangeID, Asset: "USDT", TargetPct: decimal.NewFromInt(pct),
+		}))
+	}
+	upsert(1, 2, 40)
+	upsert(1, 1, 70)
+	upsert(1, 1, 60)
+	upsert(2, 1, 100)
+
+	rows, err := repo.ListByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, uint(1), rows[0].ExchangeID)
+	require.True(t, rows[0].TargetPct.Equal(decimal.NewFromInt(60)), "upsert replaces the target")
+	require.Equal(t, "phemex", rows[0].Exchange.Name)
+
+	rows, err = repo.ListByUser(auth.WithUserID(ctx, 2), 1)
+	require.NoError(t, err)
+	require.Empty(t, rows, "targets of another user are not visible")
+
+	removed, err := repo.Delete(ctx, 1, 2, "USDT")
+	require.NoError(t, err)
+	require.True(t, removed)
+	rows, err = repo.ListByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+}
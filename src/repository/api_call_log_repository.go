@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// APICallLogRepository stores the captured exchange calls of accounts with
+// capture enabled.
+type APICallLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAPICallLogRepository() *APICallLogRepository {
+	return &APICallLogRepository{db: database.MainDB}
+}
+
+func NewAPICallLogRepositoryWithDB(db *gorm.DB) *APICallLogRepository {
+	return &APICallLogRepository{db: db}
+}
+
+// Create persists call.
+func (r *APICallLogRepository) Create(ctx context.Context, call *model.APICallLog) error {
+	if err := checkOwner(ctx, call.UserID); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(call).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":    "APICallLogRepository",
+			"op":      "Create",
+			"user_id": call.UserID,
+			"path":    call.Path,
+		}).WithError(err).Error("Failed to persist api call log")
+		return err
+	}
+	return nil
+}
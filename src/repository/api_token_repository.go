@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APITokenRepository stores the hashed API tokens used by the HTTP API.
+type APITokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAPITokenRepository() *APITokenRepository {
+	return &APITokenRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewAPITokenRepositoryWithDB(db *gorm.DB) *APITokenRepository {
+	return &APITokenRepository{
+		db: db,
+	}
+}
+
+func (r *APITokenRepository) Create(ctx context.Context, token *model.APIToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindActiveByHash returns the non-revoked token with hash, or (nil, nil).
+func (r *APITokenRepository) FindActiveByHash(ctx context.Context, hash string) (*model.APIToken, error) {
+	var token model.APIToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND revoked_at IS NULL", hash).
+		First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// TouchLastUsed records that the token authenticated a request at t.
+func (r *APITokenRepository) TouchLastUsed(ctx context.Context, id uint, t time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.APIToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", t).Error
+}
+
+// Revoke stops token id from authenticating from t on.
+func (r *APITokenRepository) Revoke(ctx context.Context, id uint, t time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.APIToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", t).Error
+}
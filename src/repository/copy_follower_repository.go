@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CopyFollowerRepository reads the followers of the copy trading leaders.
+type CopyFollowerRepository struct {
+	db *gorm.DB
+}
+
+func NewCopyFollowerRepository() *CopyFollowerRepository {
+	return &CopyFollowerRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewCopyFollowerRepositoryWithDB(db *gorm.DB) *CopyFollowerRepository {
+	return &CopyFollowerRepository{
+		db: db,
+	}
+}
+
+// ListByLeader returns the enabled followers of the leader account.
+func (r *CopyFollowerRepository) ListByLeader(ctx context.Context, userID, exchangeID uint) ([]model.CopyFollower, error) {
+	var rows []model.CopyFollower
+	err := r.db.WithContext(ctx).
+		Where("leader_user_id = ? AND leader_exchange_id = ? AND enabled = ?", userID, exchangeID, true).
+		Order("id").
+		Find(&rows).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":        "CopyFollowerRepository",
+			"op":          "ListByLeader",
+			"user_id":     userID,
+			"exchange_id": exchangeID,
+		}).WithError(err).Error("Failed to list copy followers")
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DailyEntryCountRepository persists the entries sent per user/symbol and
+// UTC day.
+type DailyEntryCountRepository struct {
+	db *gorm.DB
+}
+
+func NewDailyEntryCountRepository() *DailyEntryCountRepository {
+	return &DailyEntryCountRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewDailyEntryCountRepositoryWithDB(db *gorm.DB) *DailyEntryCountRepository {
+	return &DailyEntryCountRepository{
+		db: db,
+	}
+}
+
+// Get returns the counter of a user on symbol for day, or (nil, nil) when
+// no entry was counted yet.
+func (r *DailyEntryCountRepository) Get(ctx context.Context, userID uint, symbol, day string) (*model.DailyEntryCount, error) {
+	var c model.DailyEntryCount
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND symbol = ? AND day = ?", userID, symbol, day).
+		First(&c).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListByUser returns the counters of userID for day ordered by symbol.
+func (r *DailyEntryCountRepository) ListByUser(ctx context.Context, userID uint, day string) ([]model.DailyEntryCount, error) {
+	var rows []model.DailyEntryCount
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND day = ?", userID, day).
+		Order("symbol ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Increment counts one more entry of userID on symbol for day, in a single
+// statement so concurrent entries are all counted.
+func (r *DailyEntryCountRepository) Increment(ctx context.Context, userID uint, symbol, day string) error {
+	if err := checkOwner(ctx, userID); err != nil {
+		return err
+	}
+
+	c := &model.DailyEntryCount{UserID: userID, Symbol: symbol, Day: day, Count: 1}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "symbol"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"count":      gorm.Expr("daily_entry_counts.count + 1"),
+				"updated_at": gorm.Expr("excluded.updated_at"),
+			}),
+		}).
+		Create(c).Error
+}
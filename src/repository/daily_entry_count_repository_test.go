@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyEntryCountRepository(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.DailyEntryCount{}))
+	repo := NewDailyEntryCountRepositoryWithDB(db)
+	ctx := context.Background()
+
+	got, err := repo.Get(ctx, 3, "BTCUSDT", "2026-03-02")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repo.Increment(ctx, 3, "BTCUSDT", "2026-03-02"))
+	}
+	require.NoError(t, repo.Increment(ctx, 3, "BTCUSDT", "2026-03-03"))
+	require.NoError(t, repo.Increment(auth.WithUserID(ctx, 3), 3, "ETHUSDT", "2026-03-02"))
+	require.NoError(t, repo.Increment(ctx, 4, "BTCUSDT", "2026-03-02"))
+	require.ErrorIs(t, repo.Increment(auth.WithUserID(ctx, 4), 3, "BTCUSDT", "2026-03-02"), ErrCrossTenant)
+
+	got, err = repo.Get(ctx, 3, "BTCUSDT", "2026-03-02")
+	require.NoError(t, err)
+	require.Equal(t, 3, got.Count)
+
+	rows, err := repo.ListByUser(ctx, 3, "2026-03-02")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, "BTCUSDT", rows[0].Symbol)
+	require.Equal(t, 1, rows[1].Count)
+
+	rows, err = repo.ListByUser(auth.WithUserID(ctx, 4), 3, "2026-03-02")
+	require.NoError(t, err)
+	require.Empty(t, rows, "counters of other users are hidden")
+}
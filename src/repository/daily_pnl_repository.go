@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DailyPnLRepository persists the per-day PnL summaries.
+type DailyPnLRepository struct {
+	db *gorm.DB
+}
+
+// NewDailyPnLRepository creates a new repository instance.
+func NewDailyPnLRepository() *DailyPnLRepository {
+	return &DailyPnLRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewDailyPnLRepositoryWithDB(db *gorm.DB) *DailyPnLRepository {
+	return &DailyPnLRepository{
+		db: db,
+	}
+}
+
+// Upsert stores the summary, replacing an existing row for the same
+// (user_id, exchange_id, day) so the job can be rerun safely.
+func (r *DailyPnLRepository) Upsert(ctx context.Context, row *model.DailyPnL) error {
+	logger.WithFields(map[string]interface{}{
+		"repo":        "DailyPnLRepository",
+		"op":          "Upsert",
+		"user_id":     row.UserID,
+		"exchange_id": row.ExchangeID,
+		"day":         row.Day.Format("2006-01-02"),
+		"net_pnl":     row.NetPnL.String(),
+	}).Debug("Saving daily pnl")
+
+	if err := checkOwner(ctx, row.UserID); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "exchange_id"}, {Name: "day"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"realized_pnl",
+				"unrealized_pnl",
+				"fees",
+				"net_pnl",
+				"max_drawdown",
+				"fills",
+				"wins",
+				"losses",
+				"win_rate",
+				"updated_at",
+			}),
+		}).
+		Create(row).Error
+}
+
+// FindByUserAndRange returns the user's summaries with day in [from, to],
+// ascending by day.
+func (r *DailyPnLRepository) FindByUserAndRange(
+	ctx context.Context,
+	userID uint,
+	from time.Time,
+	to time.Time,
+) ([]model.DailyPnL, error) {
+	var rows []model.DailyPnL
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ?", userID).
+		Where("day >= ? AND day <= ?", from.UTC(), to.UTC()).
+		Order("day ASC, exchange_id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EquitySnapshotRepository persists the per-user equity history.
+type EquitySnapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewEquitySnapshotRepository creates a new repository instance.
+func NewEquitySnapshotRepository() *EquitySnapshotRepository {
+	return &EquitySnapshotRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewEquitySnapshotRepositoryWithDB(db *gorm.DB) *EquitySnapshotRepository {
+	return &EquitySnapshotRepository{
+		db: db,
+	}
+}
+
+// Upsert stores the snapshot, replacing an existing row for the same
+// (user_id, taken_at) so the job can be rerun safely.
+func (r *EquitySnapshotRepository) Upsert(ctx context.Context, row *model.EquitySnapshot) error {
+	logger.WithFields(map[string]interface{}{
+		"repo":     "EquitySnapshotRepository",
+		"op":       "Upsert",
+		"user_id":  row.UserID,
+		"taken_at": row.TakenAt,
+		"equity":   row.Equity.String(),
+	}).Debug("Saving equity snapshot")
+
+	if err := checkOwner(ctx, row.UserID); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "taken_at"}},
+			DoUpdates: clause.AssignmentColumns([]string{"balance", "unrealized_pnl", "equity", "exchanges"}),
+		}).
+		Create(row).Error
+}
+
+// FindByUserAndRange returns the user's snapshots taken in [from, to],
+// ascending by time.
+func (r *EquitySnapshotRepository) FindByUserAndRange(
+	ctx context.Context,
+	userID uint,
+	from time.Time,
+	to time.Time,
+) ([]model.EquitySnapshot, error) {
+	var rows []model.EquitySnapshot
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ?", userID).
+		Where("taken_at >= ? AND taken_at <= ?", from.UTC(), to.UTC()).
+		Order("taken_at ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -10,6 +10,15 @@ import (
 	"strategyexecutor/src/model"
 )
 
+// ExceptionFilter narrows the exceptions returned by ExceptionRepository.List.
+// Zero values are ignored.
+type ExceptionFilter struct {
+	Service  string
+	Module   string
+	Level    string
+	Exchange string
+}
+
 // ExceptionRepository handles persistence of system exceptions.
 type ExceptionRepository struct {
 	db *gorm.DB
@@ -23,6 +32,12 @@ func NewExceptionRepository() *ExceptionRepository {
 	}
 }
 
+func NewExceptionRepositoryWithDB(db *gorm.DB) *ExceptionRepository {
+	return &ExceptionRepository{
+		db: db,
+	}
+}
+
 // Create persists a new exception in the database.
 func (r *ExceptionRepository) Create(
 	ctx context.Context,
@@ -38,3 +53,38 @@ func (r *ExceptionRepository) Create(
 
 	return r.db.WithContext(ctx).Create(exc).Error
 }
+
+// List returns one page of exceptions matching filter, newest first unless
+// page.Sort is asc.
+func (r *ExceptionRepository) List(
+	ctx context.Context,
+	filter ExceptionFilter,
+	page Pagination,
+) ([]model.Exception, error) {
+
+	q := r.db.WithContext(ctx).Scopes(paginate(page, "created_at"))
+	if filter.Service != "" {
+		q = q.Where("service = ?", filter.Service)
+	}
+	if filter.Module != "" {
+		q = q.Where("module = ?", filter.Module)
+	}
+	if filter.Level != "" {
+		q = q.Where("level = ?", filter.Level)
+	}
+	if filter.Exchange != "" {
+		q = q.Where("exchange = ?", filter.Exchange)
+	}
+
+	var rows []model.Exception
+	if err := q.Find(&rows).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":  "ExceptionRepository",
+			"op":    "List",
+			"level": filter.Level,
+		}).WithError(err).Error("Failed to list exceptions")
+
+		return nil, err
+	}
+	return rows, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	logger "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"strategyexecutor/src/model"
 )
@@ -26,6 +27,13 @@ func NewExchangeRepository() *GormExchangeRepository {
 	}
 }
 
+// NewExchangeRepositoryWithDB creates an Exchange repository on db.
+func NewExchangeRepositoryWithDB(db *gorm.DB) *GormExchangeRepository {
+	return &GormExchangeRepository{
+		db: db,
+	}
+}
+
 // CreateExchange inserts a new exchange into the database.
 func (s *GormExchangeRepository) CreateExchange(
 	ctx context.Context,
@@ -155,3 +163,52 @@ func (s *GormExchangeRepository) FindByName(
 
 	return &exchange, nil
 }
+
+// List returns the exchange registry ordered by name.
+func (s *GormExchangeRepository) List(ctx context.Context) ([]model.Exchange, error) {
+	var exchanges []model.Exchange
+	err := s.db.WithContext(ctx).
+		Order("name ASC").
+		Find(&exchanges).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "ExchangeRepository",
+			"op":   "List",
+		}).WithError(err).Error("Failed to list exchanges")
+		return nil, err
+	}
+	return exchanges, nil
+}
+
+// SaveCapabilities creates exchange by name, or sets the capabilities of the
+// existing one. exchange is updated with the stored row.
+func (s *GormExchangeRepository) SaveCapabilities(ctx context.Context, exchange *model.Exchange) error {
+	err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"supports_hedge", "supports_stop_order", "supports_native_bracket", "futures", "spot"}),
+		}).
+		Create(exchange).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "ExchangeRepository",
+			"op":   "SaveCapabilities",
+			"name": exchange.Name,
+		}).WithError(err).Error("Failed to save exchange capabilities")
+		return err
+	}
+
+	return s.db.WithContext(ctx).
+		Where("name = ?", exchange.Name).
+		First(exchange).Error
+}
+
+// Supports reports whether the exchange id offers capability. Unknown
+// exchanges support nothing.
+func (s *GormExchangeRepository) Supports(ctx context.Context, id uint, capability model.ExchangeCapability) (bool, error) {
+	exchange, err := s.FindByID(ctx, id)
+	if err != nil || exchange == nil {
+		return false, err
+	}
+	return exchange.Supports(capability), nil
+}
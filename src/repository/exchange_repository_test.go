@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"strategyexecutor/src/model"
+)
+
+func TestExchangeRegistry(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.Exchange{}))
+	repo := NewExchangeRepositoryWithDB(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateExchange(ctx, &model.Exchange{Name: "phemex"}))
+
+	phemex := &model.Exchange{Name: "phemex", SupportsHedge: true, SupportsStopOrder: true, Futures: true}
+	require.NoError(t, repo.SaveCapabilities(ctx, phemex))
+	require.NotZero(t, phemex.ID)
+	kraken := &model.Exchange{Name: "kraken", Futures: true}
+	require.NoError(t, repo.SaveCapabilities(ctx, kraken))
+
+	rows, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, "kraken", rows[0].Name)
+	require.Equal(t, phemex.ID, rows[1].ID)
+	require.True(t, rows[1].SupportsHedge)
+
+	ok, err := repo.Supports(ctx, phemex.ID, model.CapabilityHedge)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = repo.Supports(ctx, kraken.ID, model.CapabilityStopOrder)
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = repo.Supports(ctx, 99, model.CapabilityFutures)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FeatureFlagRepository persists feature flag overrides. Flags are operator
+// data: queries are not restricted to the user in ctx.
+type FeatureFlagRepository struct {
+	db *gorm.DB
+}
+
+func NewFeatureFlagRepository() *FeatureFlagRepository {
+	return &FeatureFlagRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewFeatureFlagRepositoryWithDB(db *gorm.DB) *FeatureFlagRepository {
+	return &FeatureFlagRepository{
+		db: db,
+	}
+}
+
+// List returns every override, global ones first.
+func (r *FeatureFlagRepository) List(ctx context.Context) ([]model.FeatureFlag, error) {
+	var flags []model.FeatureFlag
+	err := r.db.WithContext(ctx).
+		Order("key ASC").
+		Order("user_id ASC").
+		Find(&flags).Error
+	return flags, err
+}
+
+// Set inserts or replaces the override of f.Key for f.UserID.
+func (r *FeatureFlagRepository) Set(ctx context.Context, f *model.FeatureFlag) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"enabled", "note", "updated_at"}),
+		}).
+		Create(f).Error
+}
+
+// Unset removes the override of key for userID, restoring the global value
+// or the default. It reports whether an override existed.
+func (r *FeatureFlagRepository) Unset(ctx context.Context, key string, userID uint) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Where("key = ? AND user_id = ?", key, userID).
+		Delete(&model.FeatureFlag{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FundingRepository persists funding-rate and open-interest snapshots.
+type FundingRepository struct {
+	db *gorm.DB
+}
+
+// NewFundingRepository creates a new repository instance.
+func NewFundingRepository() *FundingRepository {
+	return &FundingRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewFundingRepositoryWithDB(db *gorm.DB) *FundingRepository {
+	return &FundingRepository{
+		db: db,
+	}
+}
+
+// SaveFundingRate upserts a funding-rate snapshot on (exchange, symbol, datetime).
+func (r *FundingRepository) SaveFundingRate(ctx context.Context, fr *model.FundingRate) error {
+	logger.WithFields(map[string]interface{}{
+		"repo":     "FundingRepository",
+		"op":       "SaveFundingRate",
+		"exchange": fr.Exchange,
+		"symbol":   fr.Symbol,
+		"datetime": fr.Datetime,
+		"rate":     fr.FundingRate.String(),
+	}).Debug("Saving funding rate")
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "exchange"}, {Name: "symbol"}, {Name: "datetime"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"funding_rate",
+				"predicted_funding_rate",
+				"next_funding_time",
+				"mark_price",
+			}),
+		}).
+		Create(fr).Error
+}
+
+// SaveOpenInterest upserts an open-interest snapshot on (exchange, symbol, datetime).
+func (r *FundingRepository) SaveOpenInterest(ctx context.Context, oi *model.OpenInterest) error {
+	logger.WithFields(map[string]interface{}{
+		"repo":         "FundingRepository",
+		"op":           "SaveOpenInterest",
+		"exchange":     oi.Exchange,
+		"symbol":       oi.Symbol,
+		"datetime":     oi.Datetime,
+		"openInterest": oi.OpenInterest.String(),
+	}).Debug("Saving open interest")
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "exchange"}, {Name: "symbol"}, {Name: "datetime"}},
+			DoUpdates: clause.AssignmentColumns([]string{"open_interest", "mark_price"}),
+		}).
+		Create(oi).Error
+}
+
+// GetLatestFundingRate returns the most recent funding-rate snapshot for the
+// given exchange/symbol, or (nil, nil) when none has been collected yet.
+func (r *FundingRepository) GetLatestFundingRate(
+	ctx context.Context,
+	exchange string,
+	symbol string,
+) (*model.FundingRate, error) {
+	var fr model.FundingRate
+	err := r.db.WithContext(ctx).
+		Where("exchange = ? AND symbol = ?", exchange, symbol).
+		Order("datetime DESC").
+		First(&fr).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &fr, nil
+}
+
+// FetchFundingRates returns funding-rate snapshots in [from, to], ascending by datetime.
+func (r *FundingRepository) FetchFundingRates(
+	ctx context.Context,
+	exchange string,
+	symbol string,
+	from time.Time,
+	to time.Time,
+) ([]model.FundingRate, error) {
+	var rows []model.FundingRate
+	err := r.db.WithContext(ctx).
+		Where("exchange = ? AND symbol = ?", exchange, symbol).
+		Where("datetime >= ? AND datetime <= ?", from.UTC(), to.UTC()).
+		Order("datetime ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetLatestOpenInterest returns the most recent open-interest snapshot for the
+// given exchange/symbol, or (nil, nil) when none has been collected yet.
+func (r *FundingRepository) GetLatestOpenInterest(
+	ctx context.Context,
+	exchange string,
+	symbol string,
+) (*model.OpenInterest, error) {
+	var oi model.OpenInterest
+	err := r.db.WithContext(ctx).
+		Where("exchange = ? AND symbol = ?", exchange, symbol).
+		Order("datetime DESC").
+		First(&oi).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &oi, nil
+}
+
+// FetchOpenInterest returns open-interest snapshots in [from, to], ascending by datetime.
+func (r *FundingRepository) FetchOpenInterest(
+	ctx context.Context,
+	exchange string,
+	symbol string,
+	from time.Time,
+	to time.Time,
+) ([]model.OpenInterest, error) {
+	var rows []model.OpenInterest
+	err := r.db.WithContext(ctx).
+		Where("exchange = ? AND symbol = ?", exchange, symbol).
+		Where("datetime >= ? AND datetime <= ?", from.UTC(), to.UTC()).
+		Order("datetime ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
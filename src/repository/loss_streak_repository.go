@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LossStreakRepository persists the consecutive-loss state per user/symbol.
+type LossStreakRepository struct {
+	db *gorm.DB
+}
+
+func NewLossStreakRepository() *LossStreakRepository {
+	return &LossStreakRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewLossStreakRepositoryWithDB(db *gorm.DB) *LossStreakRepository {
+	return &LossStreakRepository{
+		db: db,
+	}
+}
+
+// Get returns the streak of a user on exchange/symbol, or (nil, nil) when
+// none was recorded yet.
+func (r *LossStreakRepository) Get(ctx context.Context, userID, exchangeID uint, symbol string) (*model.LossStreak, error) {
+	var s model.LossStreak
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ? AND symbol = ?", userID, exchangeID, symbol).
+		First(&s).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListByUser returns every streak of userID ordered by symbol.
+func (r *LossStreakRepository) ListByUser(ctx context.Context, userID uint) ([]model.LossStreak, error) {
+	var rows []model.LossStreak
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ?", userID).
+		Order("exchange_id ASC, symbol ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Upsert stores s keyed by (user_id, exchange_id, symbol).
+func (r *LossStreakRepository) Upsert(ctx context.Context, s *model.LossStreak) error {
+	if err := checkOwner(ctx, s.UserID); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "exchange_id"}, {Name: "symbol"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"consecutive_losses",
+				"last_trade_id",
+				"last_loss_at",
+				"cooldown_until",
+				"updated_at",
+			}),
+		}).
+		Create(s).Error
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// MaintenanceWindowRepository persists the maintenance windows of the
+// exchanges.
+type MaintenanceWindowRepository struct {
+	db *gorm.DB
+}
+
+func NewMaintenanceWindowRepository() *MaintenanceWindowRepository {
+	return &MaintenanceWindowRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewMaintenanceWindowRepositoryWithDB(db *gorm.DB) *MaintenanceWindowRepository {
+	return &MaintenanceWindowRepository{
+		db: db,
+	}
+}
+
+// Create stores a new window.
+func (r *MaintenanceWindowRepository) Create(ctx context.Context, w *model.MaintenanceWindow) error {
+	return r.db.WithContext(ctx).Create(w).Error
+}
+
+// Upsert stores w, replacing the window of the same exchange, source and
+// external id, e.g. a maintenance rescheduled on the status page.
+func (r *MaintenanceWindowRepository) Upsert(ctx context.Context, w *model.MaintenanceWindow) error {
+	err := r.db.WithContext(ctx).
+		Where("exchange_id = ? AND source = ? AND external_id = ?", w.ExchangeID, w.Source, w.ExternalID).
+		Assign(map[string]interface{}{
+			"starts_at": w.StartsAt,
+			"ends_at":   w.EndsAt,
+			"title":     w.Title,
+		}).
+		FirstOrCreate(w).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":        "MaintenanceWindowRepository",
+			"op":          "Upsert",
+			"exchange_id": w.ExchangeID,
+			"external_id": w.ExternalID,
+		}).WithError(err).Error("Failed to store maintenance window")
+	}
+	return err
+}
+
+// Active returns the window of exchangeID covering now, the one ending last
+// when they overlap, or nil.
+func (r *MaintenanceWindowRepository) Active(ctx context.Context, exchangeID uint, now time.Time) (*model.MaintenanceWindow, error) {
+	var rows []model.MaintenanceWindow
+	err := r.db.WithContext(ctx).
+		Where("exchange_id = ? AND starts_at <= ? AND ends_at > ?", exchangeID, now, now).
+		Order("ends_at DESC").
+		Limit(1).
+		Find(&rows).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":        "MaintenanceWindowRepository",
+			"op":          "Active",
+			"exchange_id": exchangeID,
+		}).WithError(err).Error("Failed to load active maintenance window")
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// ListEndingAfter returns the windows ending after since by start, of
+// exchangeID or of every exchange when it is 0.
+func (r *MaintenanceWindowRepository) ListEndingAfter(ctx context.Context, exchangeID uint, since time.Time) ([]model.MaintenanceWindow, error) {
+	q := r.db.WithContext(ctx).Where("ends_at > ?", since)
+	if exchangeID != 0 {
+		q = q.Where("exchange_id = ?", exchangeID)
+	}
+	var rows []model.MaintenanceWindow
+	if err := q.Order("starts_at ASC, id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Delete removes window id. It reports whether a row was removed.
+func (r *MaintenanceWindowRepository) Delete(ctx context.Context, id uint) (bool, error) {
+	res := r.db.WithContext(ctx).Delete(&model.MaintenanceWindow{}, id)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
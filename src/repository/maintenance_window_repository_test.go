@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindowRepository(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.MaintenanceWindow{}))
+	repo := NewMaintenanceWindowRepositoryWithDB(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 7, 10, 0, 0, 0, time.UTC)
+
+	manual := &model.MaintenanceWindow{ExchangeID: 1, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Source: model.MaintenanceSourceManual}
+	require.NoError(t, repo.Create(ctx, manual))
+	page := &model.MaintenanceWindow{ExchangeID: 1, StartsAt: now.Add(-time.Minute), EndsAt: now.Add(30 * time.Minute), Source: model.MaintenanceSourceStatusPage, ExternalID: "m1", Title: "Upgrade"}
+	require.NoError(t, repo.Upsert(ctx, page))
+	require.NoError(t, repo.Create(ctx, &model.MaintenanceWindow{ExchangeID: 2, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour), Source: model.MaintenanceSourceManual}))
+
+	active, err := repo.Active(ctx, 1, now)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	require.Equal(t, manual.ID, active.ID, "the window ending last")
+	active, err = repo.Active(ctx, 2, now)
+	require.NoError(t, err)
+	require.Nil(t, active, "not started yet")
+
+	// rescheduled on the status page
+	require.NoError(t, repo.Upsert(ctx, &model.MaintenanceWindow{ExchangeID: 1, StartsAt: now, EndsAt: now.Add(3 * time.Hour), Source: model.MaintenanceSourceStatusPage, ExternalID: "m1", Title: "Upgrade, extended"}))
+	rows, err := repo.ListEndingAfter(ctx, 1, now)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, page.ID, rows[1].ID)
+	require.Equal(t, "Upgrade, extended", rows[1].Title)
+	active, err = repo.Active(ctx, 1, now)
+	require.NoError(t, err)
+	require.Equal(t, page.ID, active.ID)
+
+	rows, err = repo.ListEndingAfter(ctx, 0, now.Add(90*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	removed, err := repo.Delete(ctx, manual.ID)
+	require.NoError(t, err)
+	require.True(t, removed)
+	removed, err = repo.Delete(ctx, manual.ID)
+	require.NoError(t, err)
+	require.False(t, removed)
+}
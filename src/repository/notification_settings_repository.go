@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidWebhookURL is returned by Upsert for a Discord or Slack webhook
+// that is not an https URL of its provider.
+var ErrInvalidWebhookURL = errors.New("invalid webhook url")
+
+// Hosts the Discord and Slack webhooks of the settings may post to.
+var (
+	discordWebhookHosts = []string{"discord.com", "discordapp.com"}
+	slackWebhookHosts   = []string{"hooks.slack.com"}
+)
+
+// NotificationSettingsRepository persists per-user notification settings.
+type NotificationSettingsRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationSettingsRepository() *NotificationSettingsRepository {
+	return &NotificationSettingsRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewNotificationSettingsRepositoryWithDB(db *gorm.DB) *NotificationSettingsRepository {
+	return &NotificationSettingsRepository{
+		db: db,
+	}
+}
+
+// GetByUserID returns the settings for userID, or (nil, nil) when the user
+// has not configured notifications.
+func (r *NotificationSettingsRepository) GetByUserID(ctx context.Context, userID uint) (*model.UserNotificationSetting, error) {
+	var s model.UserNotificationSetting
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ?", userID).
+		First(&s).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Upsert inserts or replaces the settings of s.UserID. The Discord and Slack
+// webhooks must be https URLs of their provider.
+func (r *NotificationSettingsRepository) Upsert(ctx context.Context, s *model.UserNotificationSetting) error {
+	if err := checkOwner(ctx, s.UserID); err != nil {
+		return err
+	}
+	if err := checkWebhookURL("discord", s.DiscordWebhookURL, discordWebhookHosts); err != nil {
+		return err
+	}
+	if err := checkWebhookURL("slack", s.SlackWebhookURL, slackWebhookHosts); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"telegram_chat_id",
+				"discord_webhook_url",
+				"slack_webhook_url",
+				"email",
+				"notify_order_placed",
+				"notify_order_filled",
+				"notify_order_error",
+				"notify_stop_loss_moved",
+				"notify_kill_switch",
+				"notify_executor_crash",
+				"notify_daily_pnl",
+				"notify_loss_cooldown",
+				"notify_key_invalid",
+				"notify_price_divergence",
+				"updated_at",
+			}),
+		}).
+		Create(s).Error
+}
+
+// checkWebhookURL checks raw, when set, is an https URL of one of hosts.
+func checkWebhookURL(channel, raw string, hosts []string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Port() != "" ||
+		!slices.Contains(hosts, strings.ToLower(u.Hostname())) {
+		return fmt.Errorf("%s: %w, expected https://%s/...", channel, ErrInvalidWebhookURL, hosts[0])
+	}
+	return nil
+}
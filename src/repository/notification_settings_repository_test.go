@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationSettingsRepositoryChecksWebhooks(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.UserNotificationSetting{}))
+	repo := NewNotificationSettingsRepositoryWithDB(db)
+	ctx := context.Background()
+
+	for _, s := range []model.UserNotificationSetting{
+		{UserID: 1, DiscordWebhookURL: "http://discord.com/api/webhooks/1/x"},
+		{UserID: 1, DiscordWebhookURL: "https://169.254.169.254/latest/meta-data"},
+		{UserID: 1, DiscordWebhookURL: "https://discord.com.evil.example/api/webhooks/1/x"},
+		{UserID: 1, SlackWebhookURL: "https://hooks.slack.com:8443/services/T/B/x"},
+		{UserID: 1, SlackWebhookURL: "https://discord.com/api/webhooks/1/x"},
+	} {
+		require.ErrorIs(t, repo.Upsert(ctx, &s), ErrInvalidWebhookURL, "%+v", s)
+	}
+
+	require.NoError(t, repo.Upsert(ctx, &model.UserNotificationSetting{
+		UserID:            1,
+		DiscordWebhookURL: "https://discordapp.com/api/webhooks/1/x",
+		SlackWebhookURL:   "https://hooks.slack.com/services/T/B/x",
+	}))
+	s, err := repo.GetByUserID(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "https://hooks.slack.com/services/T/B/x", s.SlackWebhookURL)
+}
@@ -3,20 +3,32 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strategyexecutor/src/cache"
 	"strategyexecutor/src/database"
+	"strategyexecutor/src/database/timescale"
 	"strategyexecutor/src/model"
 	"strategyexecutor/src/tp_sl"
+	"sync"
 	"time"
 
 	"github.com/shopspring/decimal"
 	logger "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var ErrInvalidInterval = errors.New("invalid interval. allowed: 5m,15m,30m,45m")
 
 type OHLCVRepository struct {
 	db *gorm.DB
+	// candles serves the recent 1m candles when set.
+	candles *cache.Candles
+
+	// timescale is whether the database has the timescaledb extension,
+	// detected on the first aggregated read.
+	timescaleOnce sync.Once
+	timescale     bool
 }
 
 // NewOHLCVRepositoryRepository creates a new repository using the given gorm DB.
@@ -25,7 +37,8 @@ func NewOHLCVRepositoryRepository() *OHLCVRepository {
 		Info("Creating new ExchangeRepository with custom DB instance")
 
 	return &OHLCVRepository{
-		db: database.MainDB,
+		db:      database.MainDB,
+		candles: cache.DefaultCandles(),
 	}
 }
 
@@ -38,6 +51,52 @@ func NewOHLCVRepositoryRepositoryWithDB(db *gorm.DB) *OHLCVRepository {
 	}
 }
 
+// ohlcvBatchSize is the rows per INSERT of the bulk upserts, far below the
+// bind parameter limit of postgres.
+const ohlcvBatchSize = 1000
+
+// ohlcvUpsert updates the candles already stored for their symbol and
+// datetime, e.g. the still open candle of the previous run.
+var ohlcvUpsert = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "datetime"}, {Name: "symbol"}},
+	DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "volume"}),
+}
+
+// UpsertOHLCV1m stores rows with multi-row INSERT ... ON CONFLICT statements
+// of ohlcvBatchSize rows, instead of one statement per candle.
+func (s *OHLCVRepository) UpsertOHLCV1m(ctx context.Context, rows []model.OHLCVCrypto1m) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	err := s.db.WithContext(ctx).Clauses(ohlcvUpsert).CreateInBatches(&rows, ohlcvBatchSize).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "OHLCVRepository",
+			"op":   "UpsertOHLCV1m",
+			"rows": len(rows),
+		}).WithError(err).Error("Failed to upsert candles")
+	}
+	return err
+}
+
+// UpsertOHLCV1h is UpsertOHLCV1m for the hourly candles.
+func (s *OHLCVRepository) UpsertOHLCV1h(ctx context.Context, rows []model.OHLCVCrypto1h) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	err := s.db.WithContext(ctx).Clauses(ohlcvUpsert).CreateInBatches(&rows, ohlcvBatchSize).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "OHLCVRepository",
+			"op":   "UpsertOHLCV1h",
+			"rows": len(rows),
+		}).WithError(err).Error("Failed to upsert candles")
+	}
+	return err
+}
+
+// FetchRecentOHLCV1m returns the latest limit 1m candles of symbol up to to,
+// ascending, through the candle cache of the process when it has one.
 func (s *OHLCVRepository) FetchRecentOHLCV1m(
 	ctx context.Context,
 	symbol string,
@@ -47,11 +106,26 @@ func (s *OHLCVRepository) FetchRecentOHLCV1m(
 	if limit <= 0 {
 		limit = 200
 	}
+	if s.candles != nil {
+		return s.candles.Recent(ctx, symbol, to, limit, s.loadRecentOHLCV1m)
+	}
+	return s.loadRecentOHLCV1m(ctx, symbol, time.Time{}, to, limit)
+}
 
+// loadRecentOHLCV1m is the cache.LoadCandles of FetchRecentOHLCV1m.
+func (s *OHLCVRepository) loadRecentOHLCV1m(
+	ctx context.Context,
+	symbol string,
+	from time.Time,
+	to time.Time,
+	limit int,
+) ([]model.OHLCVCrypto1m, error) {
+	q := s.db.WithContext(ctx).Where("symbol = ? AND datetime <= ?", symbol, to)
+	if !from.IsZero() {
+		q = q.Where("datetime >= ?", from)
+	}
 	var rows []model.OHLCVCrypto1m
-	err := s.db.WithContext(ctx).
-		Where("symbol = ? AND datetime <= ?", symbol, to).
-		Order("datetime DESC").
+	err := q.Order("datetime DESC").
 		Limit(limit).
 		Find(&rows).Error
 	if err != nil {
@@ -64,6 +138,25 @@ func (s *OHLCVRepository) FetchRecentOHLCV1m(
 	}
 	return rows, nil
 }
+
+// FetchOHLCV1mRange returns the 1m candles of symbol in [from, to], ascending.
+func (s *OHLCVRepository) FetchOHLCV1mRange(
+	ctx context.Context,
+	symbol string,
+	from time.Time,
+	to time.Time,
+) ([]model.OHLCVCrypto1m, error) {
+	var rows []model.OHLCVCrypto1m
+	err := s.db.WithContext(ctx).
+		Where("symbol = ? AND datetime >= ? AND datetime <= ?", symbol, from, to).
+		Order("datetime ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
 func (s *OHLCVRepository) GetNextStopLoss(
 	ctx context.Context,
 	symbol string,
@@ -116,6 +209,47 @@ func (s *OHLCVRepository) GetNextStopLoss(
 	return newSL, moved, nil
 }
 
+// swingLookback is the number of aggregated candles searched for swings.
+const swingLookback = 60
+
+// GetSwingStopLoss returns the initial stop of a position opened now on
+// symbol, beyond the latest confirmed swing of the interval candles (see
+// tp_sl.SwingStop). ok is false when no usable swing was found.
+func (s *OHLCVRepository) GetSwingStopLoss(
+	ctx context.Context,
+	symbol string,
+	now time.Time,
+	side tp_sl.Side,
+	interval time.Duration,
+	strength int,
+) (decimal.Decimal, bool, error) {
+	candles, err := s.FetchRecentOHLCVAgg(ctx, symbol, now, interval, swingLookback)
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+	stop, ok := tp_sl.SwingStop(side, candles, strength)
+	return stop, ok, nil
+}
+
+// GetNextSwingStopLoss trails currentSL to the latest confirmed swing of the
+// interval candles, see tp_sl.ComputeNextSwingStop.
+func (s *OHLCVRepository) GetNextSwingStopLoss(
+	ctx context.Context,
+	symbol string,
+	now time.Time,
+	side tp_sl.Side,
+	currentSL decimal.Decimal,
+	interval time.Duration,
+	strength int,
+) (decimal.Decimal, bool, error) {
+	candles, err := s.FetchRecentOHLCVAgg(ctx, symbol, now, interval, swingLookback)
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+	newSL, moved := tp_sl.ComputeNextSwingStop(side, currentSL, candles, strength)
+	return newSL, moved, nil
+}
+
 func bucketStart(t time.Time, interval time.Duration) time.Time {
 	// Works for intervals that are multiples of 1 minute
 	// Align to wall-clock boundaries: 12:07 with 5m => 12:05
@@ -124,15 +258,22 @@ func bucketStart(t time.Time, interval time.Duration) time.Time {
 	return time.Unix((secs/step)*step, 0).UTC()
 }
 
-func AggregateOHLCVFrom1m(
-	candles []model.OHLCVCrypto1m,
-	interval time.Duration,
-) ([]model.OHLCVCrypto1m, error) {
+func checkAggInterval(interval time.Duration) error {
 	if interval != 5*time.Minute &&
 		interval != 15*time.Minute &&
 		interval != 30*time.Minute &&
 		interval != 45*time.Minute {
-		return nil, ErrInvalidInterval
+		return ErrInvalidInterval
+	}
+	return nil
+}
+
+func AggregateOHLCVFrom1m(
+	candles []model.OHLCVCrypto1m,
+	interval time.Duration,
+) ([]model.OHLCVCrypto1m, error) {
+	if err := checkAggInterval(interval); err != nil {
+		return nil, err
 	}
 
 	if len(candles) == 0 {
@@ -202,6 +343,12 @@ func (s *OHLCVRepository) FetchRecentOHLCVAgg(
 	if mult <= 0 {
 		return nil, ErrInvalidInterval
 	}
+	if s.hasTimescale(ctx) {
+		if err := checkAggInterval(interval); err != nil {
+			return nil, err
+		}
+		return s.fetchRecentBuckets(ctx, symbol, to, interval, limitAgg)
+	}
 	limit1m := limitAgg*mult + mult // small buffer
 
 	rows1m, err := s.FetchRecentOHLCV1m(ctx, symbol, to, limit1m)
@@ -220,3 +367,54 @@ func (s *OHLCVRepository) FetchRecentOHLCVAgg(
 	}
 	return agg, nil
 }
+
+// hasTimescale reports whether the candles can be aggregated by TimescaleDB.
+func (s *OHLCVRepository) hasTimescale(ctx context.Context) bool {
+	s.timescaleOnce.Do(func() {
+		ok, err := timescale.Available(s.db.WithContext(ctx))
+		if err != nil {
+			logger.WithFields(map[string]interface{}{
+				"repo": "OHLCVRepository",
+				"op":   "hasTimescale",
+			}).WithError(err).Warn("Failed to detect timescaledb, aggregating candles in memory")
+		}
+		s.timescale = ok
+	})
+	return s.timescale
+}
+
+// fetchRecentBuckets aggregates the 1m candles up to to into the last
+// limitAgg interval buckets with time_bucket, so only the buckets leave the
+// database. Buckets are aligned on the Unix epoch like bucketStart.
+func (s *OHLCVRepository) fetchRecentBuckets(
+	ctx context.Context,
+	symbol string,
+	to time.Time,
+	interval time.Duration,
+	limitAgg int,
+) ([]model.OHLCVCrypto1m, error) {
+	bucket := fmt.Sprintf("%d seconds", int64(interval.Seconds()))
+	from := bucketStart(to, interval).Add(-time.Duration(limitAgg-1) * interval)
+
+	var rows []model.OHLCVCrypto1m
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT symbol,
+			time_bucket(CAST(? AS interval), datetime, TIMESTAMPTZ '1970-01-01 00:00:00+00') AS datetime,
+			first(open, datetime) AS open,
+			max(high) AS high,
+			min(low) AS low,
+			last(close, datetime) AS close,
+			sum(volume) AS volume
+		FROM ohlcv_crypto_1m
+		WHERE symbol = ? AND datetime >= ? AND datetime <= ?
+		GROUP BY 1, 2
+		ORDER BY 2 ASC`, bucket, symbol, from, to).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].Datetime = rows[i].Datetime.UTC()
+	}
+	return rows, nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOHLCVRepositoryUpsertsInBulk(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.OHLCVCrypto1m{}))
+	repo := NewOHLCVRepositoryRepositoryWithDB(db)
+	ctx := context.Background()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	candles := func(from, n int, close int64) []model.OHLCVCrypto1m {
+		rows := make([]model.OHLCVCrypto1m, 0, n)
+		for i := from; i < from+n; i++ {
+			rows = append(rows, model.OHLCVCrypto1m{
+				Symbol:   "BTC_USDT",
+				Datetime: start.Add(time.Duration(i) * time.Minute),
+				Open:     decimal.NewFromInt(100),
+				High:     decimal.NewFromInt(110),
+				Low:      decimal.NewFromInt(90),
+				Close:    decimal.NewFromInt(close),
+				Volume:   decimal.NewFromInt(1),
+			})
+		}
+		return rows
+	}
+
+	// more rows than one batch
+	require.NoError(t, repo.UpsertOHLCV1m(ctx, candles(0, 2500, 105)))
+	// overlapping rows update the stored candles
+	require.NoError(t, repo.UpsertOHLCV1m(ctx, candles(2400, 200, 107)))
+	require.NoError(t, repo.UpsertOHLCV1m(ctx, nil))
+
+	var n int64
+	require.NoError(t, db.Model(&model.OHLCVCrypto1m{}).Count(&n).Error)
+	require.Equal(t, int64(2600), n)
+
+	rows, err := repo.FetchOHLCV1mRange(ctx, "BTC_USDT", start.Add(2399*time.Minute), start.Add(2400*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.True(t, rows[0].Close.Equal(decimal.NewFromInt(105)))
+	require.True(t, rows[1].Close.Equal(decimal.NewFromInt(107)))
+}
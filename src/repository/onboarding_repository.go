@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// OnboardingRepository stores the progress of users through onboarding.
+type OnboardingRepository struct {
+	db *gorm.DB
+}
+
+func NewOnboardingRepository() *OnboardingRepository {
+	return &OnboardingRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewOnboardingRepositoryWithDB(db *gorm.DB) *OnboardingRepository {
+	return &OnboardingRepository{
+		db: db,
+	}
+}
+
+// Create persists o, failing when its user already has one.
+func (r *OnboardingRepository) Create(ctx context.Context, o *model.Onboarding) error {
+	if err := checkOwner(ctx, o.UserID); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(o).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":    "OnboardingRepository",
+			"op":      "Create",
+			"user_id": o.UserID,
+		}).WithError(err).Error("Failed to create onboarding")
+		return err
+	}
+	return nil
+}
+
+// FindByUser returns the onboarding of userID, or (nil, nil) when the user
+// was not onboarded through the flow.
+func (r *OnboardingRepository) FindByUser(ctx context.Context, userID uint) (*model.Onboarding, error) {
+	var rows []model.Onboarding
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ?", userID).
+		Limit(1).
+		Find(&rows).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":    "OnboardingRepository",
+			"op":      "FindByUser",
+			"user_id": userID,
+		}).WithError(err).Error("Failed to load onboarding")
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// Update saves every field of o.
+func (r *OnboardingRepository) Update(ctx context.Context, o *model.Onboarding) error {
+	if err := checkOwner(ctx, o.UserID); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Save(o).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":    "OnboardingRepository",
+			"op":      "Update",
+			"user_id": o.UserID,
+			"step":    o.Step,
+		}).WithError(err).Error("Failed to update onboarding")
+		return err
+	}
+	return nil
+}
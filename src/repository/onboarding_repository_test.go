@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnboardingRepositoryAndPaperFills(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.Onboarding{}, &model.ShadowCall{}))
+	repo := NewOnboardingRepositoryWithDB(db)
+	calls := NewShadowCallRepositoryWithDB(db)
+	ctx := context.Background()
+	since := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+
+	o, err := repo.FindByUser(ctx, 3)
+	require.NoError(t, err)
+	require.Nil(t, o)
+	require.NoError(t, repo.Create(ctx, &model.Onboarding{UserID: 3, Step: model.OnboardingStepKeys}))
+	require.Error(t, repo.Create(ctx, &model.Onboarding{UserID: 3, Step: model.OnboardingStepKeys}), "one per user")
+
+	o, err = repo.FindByUser(ctx, 3)
+	require.NoError(t, err)
+	o.Step, o.ExchangeID, o.PaperStartedAt = model.OnboardingStepPaper, 1, &since
+	require.NoError(t, repo.Update(ctx, o))
+	require.ErrorIs(t, repo.Update(auth.WithUserID(ctx, 4), o), ErrCrossTenant)
+	o, err = repo.FindByUser(auth.WithUserID(ctx, 4), 3)
+	require.NoError(t, err)
+	require.Nil(t, o, "hidden from other users")
+	o, err = repo.FindByUser(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, model.OnboardingStepPaper, o.Step)
+
+	fill := 100.0
+	for _, call := range []model.ShadowCall{
+		{UserID: 3, ExchangeID: 1, Method: "PlaceOrder", Symbol: "BTCUSDT", FillPrice: &fill, CreatedAt: since.Add(-time.Minute)},
+		{UserID: 3, ExchangeID: 1, Method: "PlaceOrder", Symbol: "BTCUSDT", Error: "reduce-only order without position", CreatedAt: since.Add(time.Minute)},
+		{UserID: 3, ExchangeID: 1, Method: "PlaceOrder", Symbol: "ETHUSDT", FillPrice: &fill, CreatedAt: since.Add(time.Minute)},
+	} {
+		require.NoError(t, calls.Create(ctx, &call))
+	}
+	n, err := calls.CountFills(ctx, 3, 1, "BTCUSDT", since)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	require.NoError(t, calls.Create(ctx, &model.ShadowCall{UserID: 3, ExchangeID: 1, Method: "PlaceOrder", Symbol: "BTCUSDT", FillPrice: &fill, CreatedAt: since.Add(2 * time.Minute)}))
+	n, err = calls.CountFills(ctx, 3, 1, "BTCUSDT", since)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+}
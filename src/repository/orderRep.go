@@ -3,8 +3,10 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/shopspring/decimal"
 	logger "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
@@ -55,6 +57,10 @@ func (r *OrderRepository) Create(
 		"qty":    order.Quantity,
 	}).Debug("Creating new order")
 
+	if err := checkOwner(ctx, order.UserID); err != nil {
+		return err
+	}
+
 	err := r.db.WithContext(ctx).Create(order).Error
 	if err != nil {
 		logger.WithFields(map[string]interface{}{
@@ -74,8 +80,8 @@ func (r *OrderRepository) Create(
 	return nil
 }
 
-// FindByID fetches a single order by its primary ID.
-// Returns (nil, nil) if the order is not found.
+// FindByID fetches a single order by its primary ID, falling back to the
+// archive. Returns (nil, nil) if the order is not found.
 func (r *OrderRepository) FindByID(
 	ctx context.Context,
 	id uint,
@@ -90,11 +96,20 @@ func (r *OrderRepository) FindByID(
 	var order model.Order
 
 	err := r.db.WithContext(ctx).
-		Preload("ExecutionLogs").
+		Scopes(userScope(ctx)).
+		Preload("Logs").
 		First(&order, id).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			archived, archErr := findArchivedOrder(ctx, r.db, id)
+			if archErr != nil {
+				return nil, archErr
+			}
+			if archived != nil {
+				return &archived.Order, nil
+			}
+
 			logger.WithFields(map[string]interface{}{
 				"repo": "OrderRepository",
 				"op":   "FindByID",
@@ -122,34 +137,38 @@ func (r *OrderRepository) FindByID(
 	return &order, nil
 }
 
-// FindLatest returns the latest orders ordered from newest to oldest.
+// FindLatest returns one page of orders, newest first unless page.Sort is asc.
+// Archived orders are merged in when the date range reaches the archive.
 func (r *OrderRepository) FindLatest(
 	ctx context.Context,
-	limit int,
+	page Pagination,
 ) ([]model.Order, error) {
 
-	if limit <= 0 {
-		limit = 20
-	}
+	page = page.Normalize()
 
 	logger.WithFields(map[string]interface{}{
-		"repo":  "OrderRepository",
-		"op":    "FindLatest",
-		"limit": limit,
+		"repo":   "OrderRepository",
+		"op":     "FindLatest",
+		"limit":  page.Limit,
+		"cursor": page.Cursor,
 	}).Debug("Fetching latest orders")
 
 	var orders []model.Order
 
 	err := r.db.WithContext(ctx).
-		Order("id DESC").
-		Limit(limit).
+		Scopes(userScope(ctx), paginate(page, "created_at")).
 		Find(&orders).Error
 
+	if err == nil {
+		orders, err = r.withArchivedOrders(ctx, orders, page)
+	}
+
 	if err != nil {
 		logger.WithFields(map[string]interface{}{
-			"repo":  "OrderRepository",
-			"op":    "FindLatest",
-			"limit": limit,
+			"repo":   "OrderRepository",
+			"op":     "FindLatest",
+			"limit":  page.Limit,
+			"cursor": page.Cursor,
 		}).WithError(err).Error("Failed to fetch latest orders")
 
 		return nil, err
@@ -158,13 +177,62 @@ func (r *OrderRepository) FindLatest(
 	logger.WithFields(map[string]interface{}{
 		"repo":        "OrderRepository",
 		"op":          "FindLatest",
-		"limit":       limit,
+		"limit":       page.Limit,
+		"cursor":      page.Cursor,
 		"rows_return": len(orders),
 	}).Info("Latest orders fetched")
 
 	return orders, nil
 }
 
+// FindLogsByOrderID returns one page of the status log of an order, read
+// from the archive once the order has been archived.
+func (r *OrderRepository) FindLogsByOrderID(
+	ctx context.Context,
+	orderID uint,
+	page Pagination,
+) ([]model.OrderLog, error) {
+
+	page = page.Normalize()
+
+	logger.WithFields(map[string]interface{}{
+		"repo":     "OrderRepository",
+		"op":       "FindLogsByOrderID",
+		"order_id": orderID,
+		"limit":    page.Limit,
+		"cursor":   page.Cursor,
+	}).Debug("Fetching order logs")
+
+	var logs []model.OrderLog
+
+	err := r.db.WithContext(ctx).
+		Scopes(ownedOrderScope(ctx), paginate(page, "created_at")).
+		Where("order_id = ?", orderID).
+		Find(&logs).Error
+
+	if err == nil && len(logs) == 0 {
+		var archived *model.OrderArchivePayload
+		archived, err = findArchivedOrder(ctx, r.db, orderID)
+		if archived != nil {
+			logs = pageRows(archived.Order.Logs, page,
+				func(l model.OrderLog) uint { return l.ID },
+				func(l model.OrderLog) time.Time { return l.CreatedAt })
+		}
+	}
+
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":     "OrderRepository",
+			"op":       "FindLogsByOrderID",
+			"order_id": orderID,
+		}).WithError(err).Error("Failed to fetch order logs")
+
+		return nil, err
+	}
+
+	return logs, nil
+}
+
 // FindByExternalID fetches an order by its ExternalID.
 // Returns (nil, nil) if the order is not found.
 func (r *OrderRepository) FindByExternalID(
@@ -181,6 +249,7 @@ func (r *OrderRepository) FindByExternalID(
 	var order model.Order
 
 	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
 		Where("external_id = ?", externalID).
 		First(&order).Error
 
@@ -225,6 +294,7 @@ func (r *OrderRepository) FindByExternalIDAndUser(
 	var order model.Order
 
 	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
 		Where("external_id = ? AND user_id = ?", externalID, userID).
 		Order("created_at DESC").
 		First(&order).Error
@@ -279,6 +349,7 @@ func (r *OrderRepository) FindByExternalIDAndUserID(
 	var order model.Order
 
 	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
 		Where("external_id = ? AND user_id = ? and order_dir = ?", externalID, userID, orderDir).
 		Order("created_at DESC").
 		First(&order).Error
@@ -331,6 +402,7 @@ func (r *OrderRepository) UpdateStatus(
 	}).Debug("Updating order status")
 
 	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
 		Model(&model.Order{}).
 		Where("id = ?", id).
 		Update("status", status).Error
@@ -360,7 +432,7 @@ func (r *OrderRepository) UpdateStatus(
 func (r *OrderRepository) UpdateStopLoss(
 	ctx context.Context,
 	id uint,
-	stopLoss float64,
+	stopLoss decimal.Decimal,
 ) error {
 
 	logger.WithFields(map[string]interface{}{
@@ -371,6 +443,7 @@ func (r *OrderRepository) UpdateStopLoss(
 	}).Debug("Updating order SL")
 
 	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
 		Model(&model.Order{}).
 		Where("id = ?", id).
 		Update("stop_loss_pct", stopLoss).Error
@@ -396,6 +469,81 @@ func (r *OrderRepository) UpdateStopLoss(
 	return nil
 }
 
+// UpdateBracket stores the exchange IDs of the protective orders of the
+// given order ID; an empty ID clears the column.
+func (r *OrderRepository) UpdateBracket(
+	ctx context.Context,
+	id uint,
+	stopOrderID string,
+	takeProfitOrderID string,
+) error {
+
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Model(&model.Order{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"stop_order_id":        stopOrderID,
+			"take_profit_order_id": takeProfitOrderID,
+		}).Error
+
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "OrderRepository",
+			"op":   "UpdateBracket",
+			"id":   id,
+		}).WithError(err).Error("Failed to update order bracket")
+
+		return err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"repo":                 "OrderRepository",
+		"op":                   "UpdateBracket",
+		"id":                   id,
+		"stop_order_id":        stopOrderID,
+		"take_profit_order_id": takeProfitOrderID,
+	}).Info("Order bracket updated successfully")
+
+	return nil
+}
+
+// FindLatestEntry returns the latest filled entry of symbol for the user and
+// exchange. Returns (nil, nil) when there is none.
+func (r *OrderRepository) FindLatestEntry(
+	ctx context.Context,
+	userID uint,
+	exchangeID uint,
+	symbol string,
+) (*model.Order, error) {
+
+	var order model.Order
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ? AND symbol = ? AND order_dir = ? AND status = ?",
+			userID, exchangeID, symbol, model.OrderDirectionEntry, model.OrderExecutionStatusFilled).
+		Order("created_at DESC, id DESC").
+		First(&order).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"repo":        "OrderRepository",
+			"op":          "FindLatestEntry",
+			"user_id":     userID,
+			"exchange_id": exchangeID,
+			"symbol":      symbol,
+		}).WithError(err).Error("Failed to fetch latest entry")
+
+		return nil, err
+	}
+
+	return &order, nil
+}
+
 // ---------------------------------------------------
 // OrderExecutionLog methods
 // ---------------------------------------------------
@@ -412,6 +560,10 @@ func (r *OrderRepository) CreateExecutionLog(
 		"status":   logEntry.Status,
 	}).Debug("Creating execution log")
 
+	if err := checkRowOwner(ctx, r.db, &model.Order{}, logEntry.OrderID); err != nil {
+		return err
+	}
+
 	err := r.db.WithContext(ctx).Create(logEntry).Error
 	if err != nil {
 		logger.WithFields(map[string]interface{}{
@@ -432,9 +584,35 @@ func (r *OrderRepository) CreateExecutionLog(
 	return nil
 }
 
+// withArchivedOrders merges archived orders into a page of live orders when
+// the page's date range reaches the archive.
+func (r *OrderRepository) withArchivedOrders(
+	ctx context.Context,
+	live []model.Order,
+	page Pagination,
+) ([]model.Order, error) {
+	reaches, err := archiveReaches(ctx, r.db, page.From)
+	if err != nil || !reaches {
+		return live, err
+	}
+	archived, err := findArchivedOrders(ctx, r.db, page)
+	if err != nil {
+		return nil, err
+	}
+	if len(archived) == 0 {
+		return live, nil
+	}
+	return pageRows(append(live, archived...), page,
+		func(o model.Order) uint { return o.ID },
+		func(o model.Order) time.Time { return o.CreatedAt }), nil
+}
+
+// FindExecutionLogsByOrderID returns one page of the execution logs of an
+// order; pass Sort: SortAsc to read them in the order they happened.
 func (r *OrderRepository) FindExecutionLogsByOrderID(
 	ctx context.Context,
 	orderID uint,
+	page Pagination,
 ) ([]model.OrderExecutionLog, error) {
 
 	logger.WithFields(map[string]interface{}{
@@ -446,8 +624,8 @@ func (r *OrderRepository) FindExecutionLogsByOrderID(
 	var logs []model.OrderExecutionLog
 
 	err := r.db.WithContext(ctx).
+		Scopes(ownedOrderScope(ctx), paginate(page, "created_at")).
 		Where("order_id = ?", orderID).
-		Order("id ASC").
 		Find(&logs).Error
 
 	if err != nil {
@@ -484,6 +662,7 @@ func (r *OrderRepository) FindLastExecutionLogByOrderID(
 	var logEntry model.OrderExecutionLog
 
 	err := r.db.WithContext(ctx).
+		Scopes(ownedOrderScope(ctx)).
 		Where("order_id = ?", orderID).
 		Order("id DESC").
 		First(&logEntry).Error
@@ -519,14 +698,41 @@ func (r *OrderRepository) CreateWithAutoLog(
 	ctx context.Context,
 	order *model.Order,
 ) error {
+	return r.CreateWithAutoLogReason(ctx, order, "")
+}
+
+// CreateWithAutoLogReason creates order and its first log entry, recording
+// reason (e.g. the signal filter verdicts) on the log.
+func (r *OrderRepository) CreateWithAutoLogReason(
+	ctx context.Context,
+	order *model.Order,
+	reason string,
+) error {
+	return r.CreateWithIntent(ctx, order, nil, reason)
+}
+
+// CreateWithIntent creates order, its first log entry and, when intent is not
+// nil, the outbox intent of the exchange call in one transaction. An intent
+// without ClientOrderID gets one derived from the order ID.
+func (r *OrderRepository) CreateWithIntent(
+	ctx context.Context,
+	order *model.Order,
+	intent *model.OrderIntent,
+	reason string,
+) error {
 
 	logger.WithFields(map[string]interface{}{
 		"repo":   "OrderRepository",
 		"op":     "CreateWithAutoLog",
 		"symbol": order.Symbol,
 		"side":   order.Side,
+		"reason": reason,
 	}).Info("Creating order with automatic execution log")
 
+	if err := checkOwner(ctx, order.UserID); err != nil {
+		return err
+	}
+
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(order).Error; err != nil {
 			logger.WithError(err).Error("Failed to create order inside transaction")
@@ -545,6 +751,7 @@ func (r *OrderRepository) CreateWithAutoLog(
 			StopLossPct:   order.StopLossPct,
 			TakeProfitPct: order.TakeProfitPct,
 			Status:        order.Status,
+			Reason:        reason,
 			CreatedAt:     time.Now(),
 			//OrderDir:      order.OrderDir,
 		}
@@ -554,10 +761,66 @@ func (r *OrderRepository) CreateWithAutoLog(
 			return err
 		}
 
+		if intent == nil {
+			return nil
+		}
+
+		intent.OrderID = order.ID
+		intent.UserID = order.UserID
+		intent.ExchangeID = order.ExchangeID
+		if intent.ClientOrderID == "" {
+			intent.ClientOrderID = fmt.Sprintf("se-%d", order.ID)
+		}
+		if intent.Status == "" {
+			intent.Status = model.OrderIntentStatusPending
+		}
+		if err := tx.Create(intent).Error; err != nil {
+			logger.WithError(err).Error("Failed to create order intent inside transaction")
+			return err
+		}
+
 		return nil
 	})
 }
 
+// UpdateFee stores the fee of the given order ID. estimated marks a fee
+// derived from the configured rate rather than from the fills.
+func (r *OrderRepository) UpdateFee(
+	ctx context.Context,
+	id uint,
+	fee float64,
+	currency string,
+	estimated bool,
+) error {
+
+	fields := map[string]interface{}{
+		"repo":      "OrderRepository",
+		"op":        "UpdateFee",
+		"id":        id,
+		"fee":       fee,
+		"currency":  currency,
+		"estimated": estimated,
+	}
+
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Model(&model.Order{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"fee":           fee,
+			"fee_currency":  currency,
+			"fee_estimated": estimated,
+		}).Error
+
+	if err != nil {
+		logger.WithFields(fields).WithError(err).Error("Failed to update order fee")
+		return err
+	}
+
+	logger.WithFields(fields).Debug("Order fee updated successfully")
+	return nil
+}
+
 func (r *OrderRepository) UpdateStatusWithAutoLog(
 	ctx context.Context,
 	orderID uint,
@@ -576,7 +839,7 @@ func (r *OrderRepository) UpdateStatusWithAutoLog(
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var order model.Order
 
-		if err := tx.First(&order, orderID).Error; err != nil {
+		if err := tx.Scopes(userScope(ctx)).First(&order, orderID).Error; err != nil {
 			logger.WithError(err).Error("Failed to load order inside transaction")
 			return err
 		}
@@ -601,6 +864,7 @@ func (r *OrderRepository) UpdateStatusWithAutoLog(
 			StopLossPct:   order.StopLossPct,
 			TakeProfitPct: order.TakeProfitPct,
 			Status:        newStatus,
+			Reason:        reason,
 			CreatedAt:     time.Now(),
 		}
 
@@ -613,10 +877,80 @@ func (r *OrderRepository) UpdateStatusWithAutoLog(
 	})
 }
 
+// ResolveApproval moves an order awaiting approval to newStatus, approved or
+// rejected, and logs reason. It returns false when the order is not awaiting
+// approval, e.g. because it was already decided.
+func (r *OrderRepository) ResolveApproval(
+	ctx context.Context,
+	orderID uint,
+	newStatus string,
+	reason string,
+) (bool, error) {
+
+	logger.WithFields(map[string]interface{}{
+		"repo":      "OrderRepository",
+		"op":        "ResolveApproval",
+		"order_id":  orderID,
+		"newStatus": newStatus,
+		"reason":    reason,
+	}).Info("Resolving order approval")
+
+	resolved := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order model.Order
+
+		err := tx.Scopes(userScope(ctx)).First(&order, orderID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to load order inside transaction")
+			return err
+		}
+
+		res := tx.
+			Model(&model.Order{}).
+			Where("id = ? AND status = ?", orderID, model.OrderExecutionStatusAwaitingApproval).
+			Update("status", newStatus)
+		if res.Error != nil {
+			logger.WithError(res.Error).Error("Failed to update order status inside transaction")
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		logEntry := &model.OrderLog{
+			OrderID:       order.ID,
+			ExchangeID:    order.ExchangeID,
+			Symbol:        order.Symbol,
+			Side:          order.Side,
+			PosSide:       order.PosSide,
+			OrderType:     order.OrderType,
+			Quantity:      order.Quantity,
+			Price:         order.Price,
+			StopLossPct:   order.StopLossPct,
+			TakeProfitPct: order.TakeProfitPct,
+			Status:        newStatus,
+			Reason:        reason,
+			CreatedAt:     time.Now(),
+		}
+
+		if err := tx.Create(logEntry).Error; err != nil {
+			logger.WithError(err).Error("Failed to create auto execution log on approval")
+			return err
+		}
+
+		resolved = true
+		return nil
+	})
+	return resolved, err
+}
+
 func (r *OrderRepository) UpdatePriceAutoLog(
 	ctx context.Context,
 	orderID uint,
-	price *float64,
+	price *decimal.Decimal,
 	reason string,
 ) error {
 
@@ -631,7 +965,7 @@ func (r *OrderRepository) UpdatePriceAutoLog(
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var order model.Order
 
-		if err := tx.First(&order, orderID).Error; err != nil {
+		if err := tx.Scopes(userScope(ctx)).First(&order, orderID).Error; err != nil {
 			logger.WithError(err).Error("Failed to load order inside transaction")
 			return err
 		}
@@ -656,6 +990,7 @@ func (r *OrderRepository) UpdatePriceAutoLog(
 			StopLossPct:   order.StopLossPct,
 			TakeProfitPct: order.TakeProfitPct,
 			Status:        order.Status,
+			Reason:        reason,
 			CreatedAt:     time.Now(),
 		}
 
@@ -686,6 +1021,7 @@ func (r *OrderRepository) FindByExchangeIDAndUserID(
 	var order model.Order
 
 	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
 		Where("exchange_id = ? AND user_id = ?", exchangeID, userID).
 		Order("created_at DESC").
 		First(&order).Error
@@ -722,3 +1058,71 @@ func (r *OrderRepository) FindByExchangeIDAndUserID(
 
 	return &order, nil
 }
+
+// FindCreatedSince returns every order created at or after since, oldest first.
+func (r *OrderRepository) FindCreatedSince(
+	ctx context.Context,
+	since time.Time,
+) ([]model.Order, error) {
+
+	logger.WithFields(map[string]interface{}{
+		"repo":  "OrderRepository",
+		"op":    "FindCreatedSince",
+		"since": since,
+	}).Debug("Fetching orders created since")
+
+	var orders []model.Order
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("created_at >= ?", since).
+		Order("created_at ASC, id ASC").
+		Find(&orders).Error
+
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "OrderRepository",
+			"op":   "FindCreatedSince",
+		}).WithError(err).Error("Failed to fetch orders created since")
+
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// FindByStatus returns one page of the live orders with the given status,
+// newest first unless page.Sort is asc.
+func (r *OrderRepository) FindByStatus(
+	ctx context.Context,
+	status string,
+	page Pagination,
+) ([]model.Order, error) {
+
+	page = page.Normalize()
+
+	logger.WithFields(map[string]interface{}{
+		"repo":   "OrderRepository",
+		"op":     "FindByStatus",
+		"status": status,
+		"limit":  page.Limit,
+		"cursor": page.Cursor,
+	}).Debug("Fetching orders by status")
+
+	var orders []model.Order
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx), paginate(page, "created_at")).
+		Where("status = ?", status).
+		Find(&orders).Error
+
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":   "OrderRepository",
+			"op":     "FindByStatus",
+			"status": status,
+		}).WithError(err).Error("Failed to fetch orders by status")
+
+		return nil, err
+	}
+
+	return orders, nil
+}
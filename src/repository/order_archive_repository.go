@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// OrderArchiveRepository moves old orders, with their logs, execution logs,
+// Phemex orders and intents, from the live tables into order_archives.
+type OrderArchiveRepository struct {
+	db *gorm.DB
+}
+
+// NewOrderArchiveRepository creates a new repository instance.
+func NewOrderArchiveRepository() *OrderArchiveRepository {
+	return &OrderArchiveRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewOrderArchiveRepositoryWithDB(db *gorm.DB) *OrderArchiveRepository {
+	return &OrderArchiveRepository{
+		db: db,
+	}
+}
+
+// ArchiveBefore archives up to limit orders created before cutoff and returns
+// how many were moved. Pending orders and orders with an unresolved intent
+// stay live. Each call is one transaction; call it until it returns fewer
+// than limit to drain the backlog.
+func (r *OrderArchiveRepository) ArchiveBefore(
+	ctx context.Context,
+	cutoff time.Time,
+	limit int,
+	now time.Time,
+) (int, error) {
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+
+	var archived int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		unresolved := tx.Session(&gorm.Session{NewDB: true}).
+			Model(&model.OrderIntent{}).
+			Select("order_id").
+			Where("status IN ?", []string{model.OrderIntentStatusPending, model.OrderIntentStatusDispatched})
+
+		var orders []model.Order
+		if err := tx.
+			Preload("Logs", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+			Where("created_at < ?", cutoff.UTC()).
+			Where("status <> ?", model.OrderExecutionStatusPending).
+			Where("id NOT IN (?)", unresolved).
+			Order("id ASC").
+			Limit(limit).
+			Find(&orders).Error; err != nil {
+			return fmt.Errorf("select orders to archive: %w", err)
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(orders))
+		for i, o := range orders {
+			ids[i] = o.ID
+		}
+
+		var execLogs []model.OrderExecutionLog
+		if err := tx.Where("order_id IN ?", ids).Order("id ASC").Find(&execLogs).Error; err != nil {
+			return fmt.Errorf("select execution logs to archive: %w", err)
+		}
+		var phemexOrders []model.PhemexOrder
+		if err := tx.Where("order_id IN ?", ids).Order("id ASC").Find(&phemexOrders).Error; err != nil {
+			return fmt.Errorf("select phemex orders to archive: %w", err)
+		}
+		var intents []model.OrderIntent
+		if err := tx.Where("order_id IN ?", ids).Order("id ASC").Find(&intents).Error; err != nil {
+			return fmt.Errorf("select intents to archive: %w", err)
+		}
+
+		payloads := make(map[uint]*model.OrderArchivePayload, len(orders))
+		for _, o := range orders {
+			payloads[o.ID] = &model.OrderArchivePayload{Order: o}
+		}
+		for _, l := range execLogs {
+			payloads[l.OrderID].ExecutionLogs = append(payloads[l.OrderID].ExecutionLogs, l)
+		}
+		for _, p := range phemexOrders {
+			payloads[p.OrderID].PhemexOrders = append(payloads[p.OrderID].PhemexOrders, p)
+		}
+		for _, in := range intents {
+			payloads[in.OrderID].Intents = append(payloads[in.OrderID].Intents, in)
+		}
+
+		rows := make([]model.ArchivedOrder, 0, len(orders))
+		for _, o := range orders {
+			body, err := json.Marshal(payloads[o.ID])
+			if err != nil {
+				return fmt.Errorf("encode archived order %d: %w", o.ID, err)
+			}
+			rows = append(rows, model.ArchivedOrder{
+				OrderID:        o.ID,
+				UserID:         o.UserID,
+				ExchangeID:     o.ExchangeID,
+				ExternalID:     o.ExternalID,
+				Status:         o.Status,
+				OrderCreatedAt: o.CreatedAt,
+				Payload:        string(body),
+				ArchivedAt:     now.UTC(),
+			})
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("insert archived orders: %w", err)
+		}
+
+		// Children first: order_logs has no cascading foreign key.
+		for _, child := range []interface{}{
+			&model.OrderExecutionLog{},
+			&model.PhemexOrder{},
+			&model.OrderIntent{},
+			&model.OrderLog{},
+		} {
+			if err := tx.Where("order_id IN ?", ids).Delete(child).Error; err != nil {
+				return fmt.Errorf("delete archived children: %w", err)
+			}
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&model.Order{}).Error; err != nil {
+			return fmt.Errorf("delete archived orders: %w", err)
+		}
+
+		archived = len(orders)
+		return nil
+	})
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":   "OrderArchiveRepository",
+			"op":     "ArchiveBefore",
+			"cutoff": cutoff,
+		}).WithError(err).Error("Failed to archive orders")
+
+		return 0, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"repo":     "OrderArchiveRepository",
+		"op":       "ArchiveBefore",
+		"cutoff":   cutoff,
+		"archived": archived,
+	}).Info("Orders archived")
+
+	return archived, nil
+}
+
+// findArchivedOrder returns the archived document of an order visible to the
+// user in ctx, or (nil, nil) when the order is not archived.
+func findArchivedOrder(ctx context.Context, db *gorm.DB, orderID uint) (*model.OrderArchivePayload, error) {
+	var row model.ArchivedOrder
+	err := db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("order_id = ?", orderID).
+		First(&row).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeArchivedOrder(row)
+}
+
+// findArchivedOrders returns one page of archived orders visible to the user
+// in ctx, keyed on the original order id so it can be merged with a page of
+// live orders.
+func findArchivedOrders(ctx context.Context, db *gorm.DB, page Pagination) ([]model.Order, error) {
+	var rows []model.ArchivedOrder
+	if err := db.WithContext(ctx).
+		Scopes(userScope(ctx), paginateBy(page, "order_id", "order_created_at")).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	orders := make([]model.Order, 0, len(rows))
+	for _, row := range rows {
+		payload, err := decodeArchivedOrder(row)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, payload.Order)
+	}
+	return orders, nil
+}
+
+// archiveReaches reports whether a date range starting at from can include
+// archived orders, i.e. whether it starts before the newest archived order.
+func archiveReaches(ctx context.Context, db *gorm.DB, from time.Time) (bool, error) {
+	var newest model.ArchivedOrder
+	err := db.WithContext(ctx).
+		Select("order_created_at").
+		Order("order_created_at DESC").
+		First(&newest).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return from.IsZero() || !from.After(newest.OrderCreatedAt), nil
+}
+
+func decodeArchivedOrder(row model.ArchivedOrder) (*model.OrderArchivePayload, error) {
+	var payload model.OrderArchivePayload
+	if err := json.Unmarshal([]byte(row.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("decode archived order %d: %w", row.OrderID, err)
+	}
+	return &payload, nil
+}
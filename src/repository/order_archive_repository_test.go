@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderArchiveMovesOldOrdersAndReadsThrough(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.PhemexOrder{}, &model.OrderIntent{}))
+	orders := (&OrderRepository{}).WithDB(db)
+	archive := NewOrderArchiveRepositoryWithDB(db)
+	ctx := context.Background()
+
+	now := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, -8, 0)
+	mk := func(status string, created time.Time) *model.Order {
+		o := &model.Order{UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", Status: status, OrderDir: model.OrderDirectionEntry, CreatedAt: created}
+		require.NoError(t, orders.CreateWithAutoLog(ctx, o))
+		return o
+	}
+	filled := mk(model.OrderExecutionStatusFilled, old)
+	stuck := mk(model.OrderExecutionStatusPending, old)
+	recent := mk(model.OrderExecutionStatusFilled, now.AddDate(0, 0, -1))
+	require.NoError(t, db.Create(&model.PhemexOrder{OrderID: filled.ID, ExchangeOrderID: "ph-1", Symbol: "BTCUSDT"}).Error)
+
+	n, err := archive.ArchiveBefore(ctx, now.AddDate(0, -6, 0), 10, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	var live []uint
+	require.NoError(t, db.Model(&model.Order{}).Order("id").Pluck("id", &live).Error)
+	require.Equal(t, []uint{stuck.ID, recent.ID}, live)
+	var children int64
+	require.NoError(t, db.Model(&model.PhemexOrder{}).Where("order_id = ?", filled.ID).Count(&children).Error)
+	require.Zero(t, children)
+	require.NoError(t, db.Model(&model.OrderLog{}).Where("order_id = ?", filled.ID).Count(&children).Error)
+	require.Zero(t, children)
+
+	// A second pass finds nothing new.
+	n, err = archive.ArchiveBefore(ctx, now.AddDate(0, -6, 0), 10, now)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	got, err := orders.FindByID(ctx, filled.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.Equal(t, model.OrderExecutionStatusFilled, got.Status)
+	require.Len(t, got.Logs, 1)
+
+	logs, err := orders.FindLogsByOrderID(ctx, filled.ID, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	all, err := orders.FindLatest(ctx, Pagination{})
+	require.NoError(t, err)
+	require.Equal(t, []uint{recent.ID, stuck.ID, filled.ID}, []uint{all[0].ID, all[1].ID, all[2].ID})
+
+	// A range after the archive horizon only reads live orders.
+	window, err := orders.FindLatest(ctx, Pagination{From: now.AddDate(0, -1, 0)})
+	require.NoError(t, err)
+	require.Len(t, window, 1)
+	require.Equal(t, recent.ID, window[0].ID)
+
+	// Paging through keeps a single order across live and archive.
+	first, err := orders.FindLatest(ctx, Pagination{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	rest, err := orders.FindLatest(ctx, Pagination{Limit: 2, Cursor: first[1].ID})
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	require.Equal(t, filled.ID, rest[0].ID)
+
+	// Archived orders stay private to their owner.
+	got, err = orders.FindByID(auth.WithUserID(ctx, 2), filled.ID)
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderIntentRepository tracks the outbox intents of exchange calls. Intents
+// are created together with their order by OrderRepository.CreateWithIntent.
+type OrderIntentRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderIntentRepository() *OrderIntentRepository {
+	return &OrderIntentRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewOrderIntentRepositoryWithDB(db *gorm.DB) *OrderIntentRepository {
+	return &OrderIntentRepository{
+		db: db,
+	}
+}
+
+// ListUnresolved returns the pending and dispatched intents of a user on an
+// exchange, oldest first.
+func (r *OrderIntentRepository) ListUnresolved(ctx context.Context, userID, exchangeID uint) ([]model.OrderIntent, error) {
+	var rows []model.OrderIntent
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ?", userID, exchangeID).
+		Where("status IN ?", []string{model.OrderIntentStatusPending, model.OrderIntentStatusDispatched}).
+		Order("id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// MarkDispatched records that the exchange call of intent id is starting.
+func (r *OrderIntentRepository) MarkDispatched(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Model(&model.OrderIntent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":        model.OrderIntentStatusDispatched,
+			"attempts":      gorm.Expr("attempts + 1"),
+			"dispatched_at": at,
+		}).Error
+}
+
+// MarkDone records that the exchange accepted the order as exchangeOrderID.
+func (r *OrderIntentRepository) MarkDone(ctx context.Context, id uint, exchangeOrderID string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Model(&model.OrderIntent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":            model.OrderIntentStatusDone,
+			"exchange_order_id": exchangeOrderID,
+			"last_error":        "",
+			"completed_at":      at,
+		}).Error
+}
+
+// MarkResolved moves intent id to a final failed or unknown status, or back
+// to dispatched after a call whose outcome is unknown, keeping errText.
+func (r *OrderIntentRepository) MarkResolved(ctx context.Context, id uint, status, errText string, at time.Time) error {
+	updates := map[string]interface{}{
+		"status":     status,
+		"last_error": errText,
+	}
+	if status != model.OrderIntentStatusDispatched {
+		updates["completed_at"] = at
+	}
+	return r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Model(&model.OrderIntent{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// OrderNoteRepository stores the notes attached to orders.
+type OrderNoteRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderNoteRepository() *OrderNoteRepository {
+	return &OrderNoteRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewOrderNoteRepositoryWithDB(db *gorm.DB) *OrderNoteRepository {
+	return &OrderNoteRepository{
+		db: db,
+	}
+}
+
+// Create persists note on the order of its UserID.
+func (r *OrderNoteRepository) Create(ctx context.Context, note *model.OrderNote) error {
+	if err := checkOwner(ctx, note.UserID); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(note).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":     "OrderNoteRepository",
+			"op":       "Create",
+			"order_id": note.OrderID,
+		}).WithError(err).Error("Failed to create order note")
+		return err
+	}
+	return nil
+}
+
+// FindByOrderIDs returns the notes of orderIDs, oldest first.
+func (r *OrderNoteRepository) FindByOrderIDs(ctx context.Context, orderIDs []uint) ([]model.OrderNote, error) {
+	if len(orderIDs) == 0 {
+		return nil, nil
+	}
+	var rows []model.OrderNote
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("order_id IN ?", orderIDs).
+		Order("created_at, id").
+		Find(&rows).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":   "OrderNoteRepository",
+			"op":     "FindByOrderIDs",
+			"orders": len(orderIDs),
+		}).WithError(err).Error("Failed to load order notes")
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderNoteRepository(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.OrderNote{}))
+	repo := NewOrderNoteRepositoryWithDB(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, &model.OrderNote{OrderID: 10, UserID: 3, AuthorID: 1, Body: "closed by hand"}))
+	require.NoError(t, repo.Create(auth.WithUserID(ctx, 3), &model.OrderNote{OrderID: 10, UserID: 3, AuthorID: 3, Body: "confirmed"}))
+	require.NoError(t, repo.Create(ctx, &model.OrderNote{OrderID: 11, UserID: 4, AuthorID: 1, Body: "other user"}))
+	require.ErrorIs(t, repo.Create(auth.WithUserID(ctx, 4), &model.OrderNote{OrderID: 10, UserID: 3, Body: "x"}), ErrCrossTenant)
+
+	rows, err := repo.FindByOrderIDs(ctx, []uint{10, 11})
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	require.Equal(t, "closed by hand", rows[0].Body)
+
+	rows, err = repo.FindByOrderIDs(auth.WithUserID(ctx, 3), []uint{10, 11})
+	require.NoError(t, err)
+	require.Len(t, rows, 2, "notes of other users are hidden")
+
+	rows, err = repo.FindByOrderIDs(ctx, nil)
+	require.NoError(t, err)
+	require.Empty(t, rows)
+}
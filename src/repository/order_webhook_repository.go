@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// OrderWebhookRepository persists the outbound order webhooks of users and
+// their deliveries.
+type OrderWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderWebhookRepository() *OrderWebhookRepository {
+	return &OrderWebhookRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewOrderWebhookRepositoryWithDB(db *gorm.DB) *OrderWebhookRepository {
+	return &OrderWebhookRepository{
+		db: db,
+	}
+}
+
+// Create stores a new webhook.
+func (r *OrderWebhookRepository) Create(ctx context.Context, hook *model.OrderWebhook) error {
+	if err := checkOwner(ctx, hook.UserID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(hook).Error
+}
+
+// ListByUser returns every webhook of userID ordered by id.
+func (r *OrderWebhookRepository) ListByUser(ctx context.Context, userID uint) ([]model.OrderWebhook, error) {
+	var rows []model.OrderWebhook
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListEnabled returns the enabled webhooks of userID.
+func (r *OrderWebhookRepository) ListEnabled(ctx context.Context, userID uint) ([]model.OrderWebhook, error) {
+	var rows []model.OrderWebhook
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND enabled = ?", userID, true).
+		Order("id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// FindByID returns webhook id, or (nil, nil) when it does not exist.
+func (r *OrderWebhookRepository) FindByID(ctx context.Context, id uint) (*model.OrderWebhook, error) {
+	var rows []model.OrderWebhook
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("id = ?", id).
+		Limit(1).
+		Find(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+// Delete removes webhook id of userID and its deliveries. It
+// reports whether the webhook was removed.
+func (r *OrderWebhookRepository) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	if err := checkOwner(ctx, userID); err != nil {
+		return false, err
+	}
+	var removed bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Scopes(userScope(ctx)).
+			Where("user_id = ? AND id = ?", userID, id).
+			Delete(&model.OrderWebhook{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		removed = true
+		return tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error
+	})
+	return removed, err
+}
+
+// Enqueue stores deliveries, pending.
+func (r *OrderWebhookRepository) Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Create(&deliveries).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "OrderWebhookRepository",
+			"op":   "Enqueue",
+		}).WithError(err).Error("Failed to queue webhook deliveries")
+	}
+	return err
+}
+
+// Claim returns up to limit pending deliveries due at now, each hidden from
+// the other workers until now+lease, oldest first.
+func (r *OrderWebhookRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	log := logger.WithFields(map[string]interface{}{
+		"repo": "OrderWebhookRepository",
+		"op":   "Claim",
+	})
+
+	var due []model.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(limit).
+		Find(&due).Error
+	if err != nil {
+		log.WithError(err).Error("Failed to find due webhook deliveries")
+		return nil, err
+	}
+
+	claimed := due[:0]
+	for _, d := range due {
+		// Pushing the delivery past now hides it from the other workers,
+		// only the first one to update it takes it.
+		res := r.db.WithContext(ctx).
+			Model(&model.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", d.ID, model.WebhookDeliveryPending, now).
+			Update("next_attempt_at", now.Add(lease))
+		if res.Error != nil {
+			log.WithError(res.Error).Error("Failed to claim webhook delivery")
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			claimed = append(claimed, d)
+		}
+	}
+	return claimed, nil
+}
+
+// MarkDelivered records delivery id as delivered at at with the status code answered.
+func (r *OrderWebhookRepository) MarkDelivered(ctx context.Context, id uint, status int, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       model.WebhookDeliveryDelivered,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_status":  status,
+			"last_error":   "",
+			"delivered_at": at,
+		}).Error
+}
+
+// MarkFailed records a failed attempt of delivery id, due again at retryAt
+// or dead when dead is set.
+func (r *OrderWebhookRepository) MarkFailed(ctx context.Context, id uint, status int, cause error, retryAt time.Time, dead bool) error {
+	updates := map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_status":     status,
+		"last_error":      cause.Error(),
+		"next_attempt_at": retryAt,
+	}
+	if dead {
+		updates["status"] = model.WebhookDeliveryDead
+	}
+	err := r.db.WithContext(ctx).
+		Model(&model.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":        "OrderWebhookRepository",
+			"op":          "MarkFailed",
+			"delivery_id": id,
+		}).WithError(err).Error("Failed to record webhook delivery failure")
+	}
+	return err
+}
+
+// ListDeliveries returns a page of the deliveries of webhook id, newest
+// first by default.
+func (r *OrderWebhookRepository) ListDeliveries(ctx context.Context, webhookID uint, page Pagination) ([]model.WebhookDelivery, error) {
+	var rows []model.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("webhook_id = ?", webhookID).
+		Scopes(paginate(page, "created_at")).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
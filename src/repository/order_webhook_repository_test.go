@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderWebhookRepositoryClaimsAndRetriesDeliveries(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.OrderWebhook{}, &model.WebhookDelivery{}))
+	repo := NewOrderWebhookRepositoryWithDB(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	hook := &model.OrderWebhook{UserID: 1, URL: "https://example.com/hook", SecretEnc: "enc", Enabled: true}
+	require.NoError(t, repo.Create(ctx, hook))
+	require.NoError(t, repo.Create(ctx, &model.OrderWebhook{UserID: 2, URL: "https://example.com/other", SecretEnc: "enc", Enabled: true}))
+	require.ErrorIs(t, repo.Create(auth.WithUserID(ctx, 2), &model.OrderWebhook{UserID: 1, URL: "https://x", SecretEnc: "enc"}), ErrCrossTenant)
+
+	hooks, err := repo.ListEnabled(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	found, err := repo.FindByID(auth.WithUserID(ctx, 2), hook.ID)
+	require.NoError(t, err)
+	require.Nil(t, found, "hidden from other users")
+
+	require.NoError(t, repo.Enqueue(ctx, []model.WebhookDelivery{
+		{WebhookID: hook.ID, UserID: 1, Event: "order_filled", Payload: "{}", Status: model.WebhookDeliveryPending, NextAttemptAt: now},
+		{WebhookID: hook.ID, UserID: 1, Event: "order_failed", Payload: "{}", Status: model.WebhookDeliveryPending, NextAttemptAt: now.Add(time.Hour)},
+	}))
+
+	claimed, err := repo.Claim(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, "order_filled", claimed[0].Event)
+	again, err := repo.Claim(ctx, now.Add(30*time.Second), time.Minute, 10)
+	require.NoError(t, err)
+	require.Empty(t, again, "leased by the first claim")
+
+	id := claimed[0].ID
+	require.NoError(t, repo.MarkFailed(ctx, id, 503, errors.New("unavailable"), now.Add(10*time.Second), false))
+	claimed, err = repo.Claim(ctx, now.Add(10*time.Second), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1, "due again after its backoff")
+	require.Equal(t, 1, claimed[0].Attempts)
+	require.Equal(t, 503, claimed[0].LastStatus)
+	require.NoError(t, repo.MarkDelivered(ctx, id, 200, now.Add(11*time.Second)))
+
+	deliveries, err := repo.ListDeliveries(ctx, hook.ID, Pagination{Sort: SortAsc})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	require.Equal(t, model.WebhookDeliveryDelivered, deliveries[0].Status)
+	require.Equal(t, 2, deliveries[0].Attempts)
+	require.NotNil(t, deliveries[0].DeliveredAt)
+
+	require.NoError(t, repo.MarkFailed(ctx, deliveries[1].ID, 0, errors.New("timeout"), now.Add(2*time.Hour), true))
+	claimed, err = repo.Claim(ctx, now.Add(3*time.Hour), time.Minute, 10)
+	require.NoError(t, err)
+	require.Empty(t, claimed, "dead deliveries are not sent again")
+
+	removed, err := repo.Delete(auth.WithUserID(ctx, 2), 1, hook.ID)
+	require.ErrorIs(t, err, ErrCrossTenant)
+	require.False(t, removed)
+	removed, err = repo.Delete(ctx, 1, hook.ID)
+	require.NoError(t, err)
+	require.True(t, removed)
+	hooks, err = repo.ListByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, hooks)
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 500
+
+	SortDesc = "desc"
+	SortAsc  = "asc"
+)
+
+// Pagination bounds a list query. Pages are keyset based: Cursor is the id of
+// the last row of the previous page (0 for the first page) and rows are
+// returned by id in Sort order ("desc", newest first, by default). From and
+// To bound created_at and are ignored when zero. Limit is clamped to
+// MaxPageLimit and defaults to DefaultPageLimit.
+type Pagination struct {
+	Limit  int
+	Cursor uint
+	Sort   string
+	From   time.Time
+	To     time.Time
+}
+
+// Normalize returns p with the default limit and sort applied.
+func (p Pagination) Normalize() Pagination {
+	if p.Limit <= 0 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	if strings.EqualFold(p.Sort, SortAsc) {
+		p.Sort = SortAsc
+	} else {
+		p.Sort = SortDesc
+	}
+	return p
+}
+
+// NextCursor returns the cursor of the page following one that returned rows
+// rows ending at lastID, or 0 when that page was the last one.
+func (p Pagination) NextCursor(rows int, lastID uint) uint {
+	if rows < p.Normalize().Limit {
+		return 0
+	}
+	return lastID
+}
+
+// paginate applies p to a query on the current table, using timeColumn for
+// the date range.
+func paginate(p Pagination, timeColumn string) func(*gorm.DB) *gorm.DB {
+	return paginateBy(p, "id", timeColumn)
+}
+
+// paginateBy is paginate for tables whose cursor column is not id.
+func paginateBy(p Pagination, idColumn, timeColumn string) func(*gorm.DB) *gorm.DB {
+	p = p.Normalize()
+	id := clause.Column{Table: clause.CurrentTable, Name: idColumn}
+	ts := clause.Column{Table: clause.CurrentTable, Name: timeColumn}
+
+	return func(db *gorm.DB) *gorm.DB {
+		if p.Cursor != 0 {
+			if p.Sort == SortAsc {
+				db = db.Where(clause.Gt{Column: id, Value: p.Cursor})
+			} else {
+				db = db.Where(clause.Lt{Column: id, Value: p.Cursor})
+			}
+		}
+		if !p.From.IsZero() {
+			db = db.Where(clause.Gte{Column: ts, Value: p.From.UTC()})
+		}
+		if !p.To.IsZero() {
+			db = db.Where(clause.Lte{Column: ts, Value: p.To.UTC()})
+		}
+		return db.
+			Order(clause.OrderByColumn{Column: id, Desc: p.Sort == SortDesc}).
+			Limit(p.Limit)
+	}
+}
+
+// pageRows applies p to rows already in memory, e.g. to merge live and
+// archived results. The input order does not matter.
+func pageRows[T any](rows []T, p Pagination, id func(T) uint, ts func(T) time.Time) []T {
+	p = p.Normalize()
+	out := make([]T, 0, len(rows))
+	for _, row := range rows {
+		if p.Cursor != 0 {
+			if p.Sort == SortAsc && id(row) <= p.Cursor {
+				continue
+			}
+			if p.Sort == SortDesc && id(row) >= p.Cursor {
+				continue
+			}
+		}
+		if !p.From.IsZero() && ts(row).Before(p.From) {
+			continue
+		}
+		if !p.To.IsZero() && ts(row).After(p.To) {
+			continue
+		}
+		out = append(out, row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if p.Sort == SortAsc {
+			return id(out[i]) < id(out[j])
+		}
+		return id(out[i]) > id(out[j])
+	})
+	if len(out) > p.Limit {
+		out = out[:p.Limit]
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginationNormalize(t *testing.T) {
+	p := Pagination{}.Normalize()
+	require.Equal(t, DefaultPageLimit, p.Limit)
+	require.Equal(t, SortDesc, p.Sort)
+
+	p = Pagination{Limit: 10_000, Sort: "ASC"}.Normalize()
+	require.Equal(t, MaxPageLimit, p.Limit)
+	require.Equal(t, SortAsc, p.Sort)
+
+	require.Equal(t, uint(7), Pagination{Limit: 2}.NextCursor(2, 7))
+	require.Zero(t, Pagination{Limit: 2}.NextCursor(1, 7))
+}
+
+func TestOrderRepositoryFindLatestPages(t *testing.T) {
+	db := newScopeTestDB(t)
+	repo := (&OrderRepository{}).WithDB(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		o := &model.Order{UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", Status: "pending", OrderDir: model.OrderDirectionEntry, CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+		require.NoError(t, repo.Create(ctx, o))
+	}
+
+	page := Pagination{Limit: 2}
+	var ids []uint
+	for {
+		rows, err := repo.FindLatest(ctx, page)
+		require.NoError(t, err)
+		for _, r := range rows {
+			ids = append(ids, r.ID)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		page.Cursor = page.NextCursor(len(rows), rows[len(rows)-1].ID)
+		if page.Cursor == 0 {
+			break
+		}
+	}
+	require.Equal(t, []uint{5, 4, 3, 2, 1}, ids)
+
+	asc, err := repo.FindLatest(ctx, Pagination{Limit: 2, Sort: SortAsc, Cursor: 2})
+	require.NoError(t, err)
+	require.Len(t, asc, 2)
+	require.Equal(t, uint(3), asc[0].ID)
+
+	window, err := repo.FindLatest(ctx, Pagination{From: base.Add(time.Hour), To: base.Add(3 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, window, 3)
+	require.Equal(t, uint(4), window[0].ID)
+}
+
+func TestExceptionRepositoryListFilters(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.Exception{}))
+	repo := NewExceptionRepositoryWithDB(db)
+	ctx := context.Background()
+
+	for _, level := range []string{"error", "warn", "error"} {
+		require.NoError(t, repo.Create(ctx, &model.Exception{Service: "executor", Module: "phemex", Level: level}))
+	}
+
+	rows, err := repo.List(ctx, ExceptionFilter{Level: "error"}, Pagination{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, uint(3), rows[0].ID)
+
+	rows, err = repo.List(ctx, ExceptionFilter{Level: "error"}, Pagination{Cursor: rows[0].ID})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, uint(1), rows[0].ID)
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PositionSnapshotRepository persists the periodic open position snapshots.
+type PositionSnapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewPositionSnapshotRepository creates a new repository instance.
+func NewPositionSnapshotRepository() *PositionSnapshotRepository {
+	return &PositionSnapshotRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewPositionSnapshotRepositoryWithDB(db *gorm.DB) *PositionSnapshotRepository {
+	return &PositionSnapshotRepository{
+		db: db,
+	}
+}
+
+// SaveAll upserts the snapshots on (user_id, exchange_id, symbol, pos_side,
+// taken_at) so a rerun within the same bucket replaces the rows.
+func (r *PositionSnapshotRepository) SaveAll(ctx context.Context, rows []model.PositionSnapshot) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"repo":     "PositionSnapshotRepository",
+		"op":       "SaveAll",
+		"user_id":  rows[0].UserID,
+		"taken_at": rows[0].TakenAt,
+		"rows":     len(rows),
+	}).Debug("Saving position snapshots")
+
+	for i := range rows {
+		if err := checkOwner(ctx, rows[i].UserID); err != nil {
+			return err
+		}
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "exchange_id"}, {Name: "symbol"}, {Name: "pos_side"}, {Name: "taken_at"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"side",
+				"size",
+				"entry_price",
+				"mark_price",
+				"notional",
+				"position_margin",
+				"unrealized_pnl",
+				"leverage",
+				"liquidation_price",
+				"account_balance",
+			}),
+		}).
+		Create(&rows).Error
+}
+
+// FindByUserAndRange returns the user's snapshots taken in [from, to],
+// ascending by time.
+func (r *PositionSnapshotRepository) FindByUserAndRange(
+	ctx context.Context,
+	userID uint,
+	from time.Time,
+	to time.Time,
+) ([]model.PositionSnapshot, error) {
+	var rows []model.PositionSnapshot
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ?", userID).
+		Where("taken_at >= ? AND taken_at <= ?", from.UTC(), to.UTC()).
+		Order("taken_at ASC, exchange_id ASC, symbol ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	"gorm.io/gorm"
+)
+
+// ScheduleRuleRepository persists the weekly trading-hours rules of users.
+type ScheduleRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduleRuleRepository() *ScheduleRuleRepository {
+	return &ScheduleRuleRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewScheduleRuleRepositoryWithDB(db *gorm.DB) *ScheduleRuleRepository {
+	return &ScheduleRuleRepository{
+		db: db,
+	}
+}
+
+// Create stores a new rule.
+func (r *ScheduleRuleRepository) Create(ctx context.Context, rule *model.ScheduleRule) error {
+	if err := checkOwner(ctx, rule.UserID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+// ListByUser returns every rule of userID ordered by id.
+func (r *ScheduleRuleRepository) ListByUser(ctx context.Context, userID uint) ([]model.ScheduleRule, error) {
+	var rows []model.ScheduleRule
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListForSymbol returns the rules of userID applying to symbol: the rules of
+// that symbol and the ones without a symbol.
+func (r *ScheduleRuleRepository) ListForSymbol(ctx context.Context, userID uint, symbol string) ([]model.ScheduleRule, error) {
+	var rows []model.ScheduleRule
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND (symbol = '' OR symbol = ?)", userID, symbol).
+		Order("id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Delete removes rule id of userID. It reports whether a row was removed.
+func (r *ScheduleRuleRepository) Delete(ctx context.Context, userID, id uint) (bool, error) {
+	if err := checkOwner(ctx, userID); err != nil {
+		return false, err
+	}
+	res := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND id = ?", userID, id).
+		Delete(&model.ScheduleRule{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
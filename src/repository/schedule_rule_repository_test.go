@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+)
+
+func TestScheduleRuleCreateListDelete(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.ScheduleRule{}))
+	repo := NewScheduleRuleRepositoryWithDB(db)
+	ctx := context.Background()
+
+	create := func(userID uint, symbol string) *model.ScheduleRule {
+		t.Helper()
+		rule := &model.ScheduleRule{
+			UserID: userID, Symbol: symbol, StartDay: 5, StartMinute: 20 * 60, EndDay: 0, EndMinute: 22 * 60,
+			Action: model.ScheduleActionBlock,
+		}
+		require.NoError(t, repo.Create(ctx, rule))
+		return rule
+	}
+	all := create(1, "")
+	create(1, "ETHUSDT")
+	btc := create(1, "BTCUSDT")
+	create(2, "")
+
+	rows, err := repo.ListForSymbol(ctx, 1, "BTCUSDT")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, all.ID, rows[0].ID)
+	require.Equal(t, btc.ID, rows[1].ID)
+
+	rows, err = repo.ListByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	rows, err = repo.ListByUser(auth.WithUserID(ctx, 2), 1)
+	require.NoError(t, err)
+	require.Empty(t, rows, "rules of another user are not visible")
+
+	removed, err := repo.Delete(ctx, 2, btc.ID)
+	require.NoError(t, err)
+	require.False(t, removed, "a rule is only removed by its owner")
+	removed, err = repo.Delete(ctx, 1, btc.ID)
+	require.NoError(t, err)
+	require.True(t, removed)
+	rows, err = repo.ListForSymbol(ctx, 1, "BTCUSDT")
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+}
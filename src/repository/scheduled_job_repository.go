@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScheduledJobRepository keeps the last run and the lock of the periodic
+// jobs, the cron.RunStore of the scheduler.
+type ScheduledJobRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduledJobRepository() *ScheduledJobRepository {
+	return &ScheduledJobRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewScheduledJobRepositoryWithDB(db *gorm.DB) *ScheduledJobRepository {
+	return &ScheduledJobRepository{
+		db: db,
+	}
+}
+
+// Acquire locks job name until until and records now as its start, unless
+// another run holds the lock. It reports whether the lock was taken.
+func (r *ScheduledJobRepository) Acquire(ctx context.Context, name string, now, until time.Time) (bool, error) {
+	log := logger.WithFields(map[string]interface{}{
+		"repo": "ScheduledJobRepository",
+		"op":   "Acquire",
+		"job":  name,
+	})
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+		Create(&model.ScheduledJob{Name: name}).Error
+	if err != nil {
+		log.WithError(err).Error("Failed to create scheduled job")
+		return false, err
+	}
+
+	res := r.db.WithContext(ctx).
+		Model(&model.ScheduledJob{}).
+		Where("name = ? AND (locked_until IS NULL OR locked_until <= ?)", name, now).
+		Updates(map[string]interface{}{"locked_until": until, "last_started_at": now})
+	if res.Error != nil {
+		log.WithError(res.Error).Error("Failed to lock scheduled job")
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Finish stores run and releases the lock of its job, keeping the outcome
+// of the run as the last one of the job. The lock is released only while
+// run still holds it: a run outliving its lock leaves the lock of the run
+// that took the job over in place.
+func (r *ScheduledJobRepository) Finish(ctx context.Context, run *model.JobRun) error {
+	updates := map[string]interface{}{
+		"last_finished_at": run.FinishedAt,
+		"last_status":      run.Status,
+		"last_error":       run.Error,
+		"last_duration_ms": run.DurationMS,
+		"last_items":       run.Items,
+		"runs":             gorm.Expr("runs + 1"),
+	}
+	if run.Status == model.ScheduledJobOK {
+		updates["last_succeeded_at"] = run.FinishedAt
+	} else {
+		updates["failures"] = gorm.Expr("failures + 1")
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.ScheduledJob{}).
+			Where("name = ?", run.Job).
+			Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.ScheduledJob{}).
+			Where("name = ? AND last_started_at = ?", run.Job, run.StartedAt).
+			Update("locked_until", nil).Error
+	})
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "ScheduledJobRepository",
+			"op":   "Finish",
+			"job":  run.Job,
+		}).WithError(err).Error("Failed to record scheduled job run")
+	}
+	return err
+}
+
+// LastSuccess returns when job name last succeeded, zero when it never did.
+func (r *ScheduledJobRepository) LastSuccess(ctx context.Context, name string) (time.Time, error) {
+	var job model.ScheduledJob
+	err := r.db.WithContext(ctx).Where("name = ?", name).Limit(1).Find(&job).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "ScheduledJobRepository",
+			"op":   "LastSuccess",
+			"job":  name,
+		}).WithError(err).Error("Failed to load scheduled job")
+		return time.Time{}, err
+	}
+	if job.LastSucceededAt == nil {
+		return time.Time{}, nil
+	}
+	return *job.LastSucceededAt, nil
+}
+
+// ListRuns returns a page of runs, newest first by default, of job and with
+// status when they are not empty.
+func (r *ScheduledJobRepository) ListRuns(ctx context.Context, job, status string, page Pagination) ([]model.JobRun, error) {
+	q := r.db.WithContext(ctx)
+	if job != "" {
+		q = q.Where("job = ?", job)
+	}
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var rows []model.JobRun
+	if err := q.Scopes(paginate(page, "started_at")).Find(&rows).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "ScheduledJobRepository",
+			"op":   "ListRuns",
+		}).WithError(err).Error("Failed to list job runs")
+		return nil, err
+	}
+	return rows, nil
+}
+
+// List returns every job by name.
+func (r *ScheduledJobRepository) List(ctx context.Context) ([]model.ScheduledJob, error) {
+	var rows []model.ScheduledJob
+	if err := r.db.WithContext(ctx).Order("name").Find(&rows).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "ScheduledJobRepository",
+			"op":   "List",
+		}).WithError(err).Error("Failed to list scheduled jobs")
+		return nil, err
+	}
+	return rows, nil
+}
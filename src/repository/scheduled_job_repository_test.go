@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledJobRepositoryLocksAndRecordsRuns(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.ScheduledJob{}, &model.JobRun{}))
+	repo := NewScheduledJobRepositoryWithDB(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	ok, err := repo.Acquire(ctx, "funding", now, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = repo.Acquire(ctx, "funding", now.Add(time.Minute), now.Add(time.Hour))
+	require.NoError(t, err)
+	require.False(t, ok, "locked by the first run")
+
+	last, err := repo.LastSuccess(ctx, "funding")
+	require.NoError(t, err)
+	require.True(t, last.IsZero())
+	require.NoError(t, repo.Finish(ctx, &model.JobRun{Job: "funding", StartedAt: now, FinishedAt: now.Add(1500 * time.Millisecond), Status: model.ScheduledJobFailed, Error: "exchange down", DurationMS: 1500}))
+	ok, err = repo.Acquire(ctx, "funding", now.Add(time.Minute), now.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, ok, "released by Finish")
+	require.NoError(t, repo.Finish(ctx, &model.JobRun{Job: "funding", StartedAt: now.Add(time.Minute), FinishedAt: now.Add(2 * time.Minute), Status: model.ScheduledJobOK, Items: 2, DurationMS: 60000}))
+	last, err = repo.LastSuccess(ctx, "funding")
+	require.NoError(t, err)
+	require.True(t, last.Equal(now.Add(2*time.Minute)))
+
+	// a run that died keeps the job locked until its lock expires
+	ok, err = repo.Acquire(ctx, "key_health", now, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = repo.Acquire(ctx, "key_health", now.Add(time.Hour), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// the run that died finishes late, the lock of the run that took over holds
+	require.NoError(t, repo.Finish(ctx, &model.JobRun{Job: "key_health", StartedAt: now, FinishedAt: now.Add(61 * time.Minute), Status: model.ScheduledJobOK}))
+	ok, err = repo.Acquire(ctx, "key_health", now.Add(62*time.Minute), now.Add(3*time.Hour))
+	require.NoError(t, err)
+	require.False(t, ok, "still locked by the second run")
+
+	rows, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, "funding", rows[0].Name)
+	require.Equal(t, 2, rows[0].Runs)
+	require.Equal(t, 1, rows[0].Failures)
+	require.Equal(t, model.ScheduledJobOK, rows[0].LastStatus)
+	require.Empty(t, rows[0].LastError)
+	require.Equal(t, int64(60000), rows[0].LastDurationMS)
+	require.Equal(t, 2, rows[0].LastItems)
+	require.Nil(t, rows[0].LockedUntil)
+	require.NotNil(t, rows[1].LockedUntil)
+
+	runs, err := repo.ListRuns(ctx, "funding", "", Pagination{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, 2, runs[0].Items, "newest first")
+	runs, err = repo.ListRuns(ctx, "", model.ScheduledJobFailed, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, "exchange down", runs[0].Error)
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ShadowCallRepository stores the order calls of shadow accounts.
+type ShadowCallRepository struct {
+	db *gorm.DB
+}
+
+func NewShadowCallRepository() *ShadowCallRepository {
+	return &ShadowCallRepository{db: database.MainDB}
+}
+
+func NewShadowCallRepositoryWithDB(db *gorm.DB) *ShadowCallRepository {
+	return &ShadowCallRepository{db: db}
+}
+
+// Create persists call.
+func (r *ShadowCallRepository) Create(ctx context.Context, call *model.ShadowCall) error {
+	if err := checkOwner(ctx, call.UserID); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(call).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":    "ShadowCallRepository",
+			"op":      "Create",
+			"user_id": call.UserID,
+			"method":  call.Method,
+		}).WithError(err).Error("Failed to persist shadow call")
+		return err
+	}
+	return nil
+}
+
+// CountFills counts the shadow calls of user on exchange and symbol the paper
+// account filled since since.
+func (r *ShadowCallRepository) CountFills(ctx context.Context, userID, exchangeID uint, symbol string, since time.Time) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).
+		Model(&model.ShadowCall{}).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ? AND symbol = ?", userID, exchangeID, symbol).
+		Where("fill_price IS NOT NULL AND created_at >= ?", since).
+		Count(&n).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":    "ShadowCallRepository",
+			"op":      "CountFills",
+			"user_id": userID,
+		}).WithError(err).Error("Failed to count shadow fills")
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SignalClaimRepository records the signals users acted on to suppress
+// duplicates.
+type SignalClaimRepository struct {
+	db *gorm.DB
+}
+
+func NewSignalClaimRepository() *SignalClaimRepository {
+	return &SignalClaimRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewSignalClaimRepositoryWithDB(db *gorm.DB) *SignalClaimRepository {
+	return &SignalClaimRepository{
+		db: db,
+	}
+}
+
+// Claim records that claim.UserID acts on claim.SignalID. It returns the
+// earlier claim of another signal with the same fingerprint within window
+// before claim.ClaimedAt, in which case nothing is stored and the signal is a
+// duplicate, or (nil, nil) once the signal is claimed. Claiming a signal again
+// is a no-op.
+func (r *SignalClaimRepository) Claim(ctx context.Context, claim *model.SignalClaim, window time.Duration) (*model.SignalClaim, error) {
+	if err := checkOwner(ctx, claim.UserID); err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	err := r.db.WithContext(ctx).
+		Model(&model.SignalClaim{}).
+		Where("user_id = ? AND signal_id = ?", claim.UserID, claim.SignalID).
+		Count(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, nil
+	}
+
+	var previous model.SignalClaim
+	err = r.db.WithContext(ctx).
+		Where("user_id = ? AND exchange_id = ? AND symbol = ? AND fingerprint = ?",
+			claim.UserID, claim.ExchangeID, claim.Symbol, claim.Fingerprint).
+		Where("signal_id <> ? AND claimed_at > ? AND claimed_at <= ?",
+			claim.SignalID, claim.ClaimedAt.Add(-window), claim.ClaimedAt).
+		Order("claimed_at DESC").
+		First(&previous).Error
+	if err == nil {
+		return &previous, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Two executors may get here for duplicates at once; the unique index over
+	// the window bucket lets only one of them in.
+	claim.WindowStart = claim.ClaimedAt.Truncate(window)
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(claim)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return nil, nil
+	}
+
+	err = r.db.WithContext(ctx).
+		Where("user_id = ? AND exchange_id = ? AND symbol = ? AND fingerprint = ? AND window_start = ?",
+			claim.UserID, claim.ExchangeID, claim.Symbol, claim.Fingerprint, claim.WindowStart).
+		First(&previous).Error
+	if err != nil {
+		return nil, err
+	}
+	if previous.SignalID == claim.SignalID {
+		return nil, nil
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"user_id":   claim.UserID,
+		"symbol":    claim.Symbol,
+		"signal_id": claim.SignalID,
+		"claimed":   previous.SignalID,
+	}).Info("concurrent duplicate signal lost the claim")
+	return &previous, nil
+}
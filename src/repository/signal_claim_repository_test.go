@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"strategyexecutor/src/model"
+)
+
+func TestSignalClaimSuppressesDuplicatesWithinWindow(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.SignalClaim{}))
+	repo := NewSignalClaimRepositoryWithDB(db)
+	ctx := context.Background()
+
+	now := time.Date(2025, 3, 4, 10, 0, 30, 0, time.UTC)
+	claim := func(signalID uint, fingerprint string, at time.Time) *model.SignalClaim {
+		t.Helper()
+		previous, err := repo.Claim(ctx, &model.SignalClaim{
+			UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", Fingerprint: fingerprint, SignalID: signalID, ClaimedAt: at,
+		}, 2*time.Minute)
+		require.NoError(t, err)
+		return previous
+	}
+
+	require.Nil(t, claim(1, "a", now))
+	require.Nil(t, claim(1, "a", now.Add(time.Second)), "claiming a signal again is a no-op")
+
+	previous := claim(2, "a", now.Add(time.Minute))
+	require.NotNil(t, previous)
+	require.Equal(t, uint(1), previous.SignalID)
+
+	require.Nil(t, claim(3, "b", now.Add(time.Minute)), "another fingerprint is not a duplicate")
+	require.Nil(t, claim(4, "a", now.Add(3*time.Minute)), "outside the window")
+
+	var count int64
+	require.NoError(t, db.Model(&model.SignalClaim{}).Count(&count).Error)
+	require.Equal(t, int64(3), count)
+}
+
+func TestSignalClaimUniqueIndexSettlesRaces(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.SignalClaim{}))
+	repo := NewSignalClaimRepositoryWithDB(db)
+	ctx := context.Background()
+
+	// a concurrent executor stored its claim for signal 1 while this one
+	// looked for a previous claim
+	now := time.Date(2025, 3, 4, 10, 0, 30, 0, time.UTC)
+	require.NoError(t, db.Create(&model.SignalClaim{
+		UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", Fingerprint: "a", SignalID: 1,
+		WindowStart: now.Truncate(2 * time.Minute), ClaimedAt: now.Add(time.Second),
+	}).Error)
+
+	previous, err := repo.Claim(ctx, &model.SignalClaim{
+		UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", Fingerprint: "a", SignalID: 2, ClaimedAt: now,
+	}, 2*time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, previous)
+	require.Equal(t, uint(1), previous.SignalID)
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SignalExecutionRepository records the executor decision on each signal.
+type SignalExecutionRepository struct {
+	db *gorm.DB
+}
+
+func NewSignalExecutionRepository() *SignalExecutionRepository {
+	return &SignalExecutionRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewSignalExecutionRepositoryWithDB(db *gorm.DB) *SignalExecutionRepository {
+	return &SignalExecutionRepository{
+		db: db,
+	}
+}
+
+// Record stores exec unless a decision on the same signal exists already, in
+// which case it returns false and the first decision stands.
+func (r *SignalExecutionRepository) Record(ctx context.Context, exec *model.SignalExecution) (bool, error) {
+	if err := checkOwner(ctx, exec.UserID); err != nil {
+		return false, err
+	}
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(exec)
+	if res.Error != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":      "SignalExecutionRepository",
+			"op":        "Record",
+			"user_id":   exec.UserID,
+			"signal_id": exec.SignalID,
+		}).WithError(res.Error).Error("Failed to record signal execution")
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// Find returns the decision on signalID, nil when there is none.
+func (r *SignalExecutionRepository) Find(ctx context.Context, userID, exchangeID, signalID uint) (*model.SignalExecution, error) {
+	var exec model.SignalExecution
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ? AND signal_id = ?", userID, exchangeID, signalID).
+		First(&exec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &exec, nil
+}
+
+// LastSignalID returns the highest signal the executor decided on for the
+// account, 0 when it never decided on any.
+func (r *SignalExecutionRepository) LastSignalID(ctx context.Context, userID, exchangeID uint) (uint, error) {
+	var last *uint
+	err := r.db.WithContext(ctx).
+		Model(&model.SignalExecution{}).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ?", userID, exchangeID).
+		Select("MAX(signal_id)").
+		Scan(&last).Error
+	if err != nil || last == nil {
+		return 0, err
+	}
+	return *last, nil
+}
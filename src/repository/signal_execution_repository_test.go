@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalExecutionRepository(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.SignalExecution{}))
+	repo := NewSignalExecutionRepositoryWithDB(db)
+	ctx := context.Background()
+
+	last, err := repo.LastSignalID(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Zero(t, last)
+
+	stored, err := repo.Record(ctx, &model.SignalExecution{UserID: 1, ExchangeID: 1, SignalID: 7, Symbol: "BTCUSDT", Decision: model.SignalExecutionSkipped, Reason: "down"})
+	require.NoError(t, err)
+	require.True(t, stored)
+	stored, err = repo.Record(ctx, &model.SignalExecution{UserID: 1, ExchangeID: 1, SignalID: 7, Symbol: "BTCUSDT", Decision: model.SignalExecutionDispatched})
+	require.NoError(t, err)
+	require.False(t, stored, "the first decision stands")
+	_, err = repo.Record(ctx, &model.SignalExecution{UserID: 1, ExchangeID: 1, SignalID: 9, Symbol: "BTCUSDT", Decision: model.SignalExecutionDispatched})
+	require.NoError(t, err)
+	_, err = repo.Record(ctx, &model.SignalExecution{UserID: 2, ExchangeID: 1, SignalID: 12, Symbol: "BTCUSDT", Decision: model.SignalExecutionDispatched})
+	require.NoError(t, err)
+
+	exec, err := repo.Find(ctx, 1, 1, 7)
+	require.NoError(t, err)
+	require.Equal(t, model.SignalExecutionSkipped, exec.Decision)
+	exec, err = repo.Find(ctx, 1, 1, 8)
+	require.NoError(t, err)
+	require.Nil(t, exec)
+
+	last, err = repo.LastSignalID(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint(9), last)
+
+	_, err = repo.Record(auth.WithUserID(ctx, 1), &model.SignalExecution{UserID: 2, ExchangeID: 1, SignalID: 13})
+	require.ErrorIs(t, err, ErrCrossTenant)
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SignalExecutionSummaryRepository stores the fleet-wide outcome of each
+// signal. Summaries span every user and are not scoped to the one
+// authenticated in ctx.
+type SignalExecutionSummaryRepository struct {
+	db *gorm.DB
+}
+
+func NewSignalExecutionSummaryRepository() *SignalExecutionSummaryRepository {
+	return &SignalExecutionSummaryRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewSignalExecutionSummaryRepositoryWithDB(db *gorm.DB) *SignalExecutionSummaryRepository {
+	return &SignalExecutionSummaryRepository{
+		db: db,
+	}
+}
+
+// Refresh rebuilds the summary of signalID from its signal executions and
+// entry orders and stores it. A signal no account decided on yet returns an
+// empty summary, which is not stored.
+func (r *SignalExecutionSummaryRepository) Refresh(ctx context.Context, signalID uint) (*model.SignalExecutionSummary, error) {
+	log := logger.WithFields(map[string]interface{}{
+		"repo":      "SignalExecutionSummaryRepository",
+		"op":        "Refresh",
+		"signal_id": signalID,
+	})
+
+	var execs []model.SignalExecution
+	if err := r.db.WithContext(ctx).Where("signal_id = ?", signalID).Find(&execs).Error; err != nil {
+		log.WithError(err).Error("Failed to load signal executions")
+		return nil, err
+	}
+	var entries []model.Order
+	err := r.db.WithContext(ctx).
+		Where("external_id = ? AND order_dir = ?", signalID, model.OrderDirectionEntry).
+		Find(&entries).Error
+	if err != nil {
+		log.WithError(err).Error("Failed to load signal entries")
+		return nil, err
+	}
+
+	summary := model.NewSignalExecutionSummary(signalID, execs, entries)
+	if summary.Accounts == 0 {
+		return &summary, nil
+	}
+	err = r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "signal_id"}},
+			UpdateAll: true,
+		}).
+		Create(&summary).Error
+	if err != nil {
+		log.WithError(err).Error("Failed to store signal execution summary")
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// List returns a page of summaries keyed by signal id, latest signal first
+// by default. From and To bound the last refresh.
+func (r *SignalExecutionSummaryRepository) List(ctx context.Context, page Pagination) ([]model.SignalExecutionSummary, error) {
+	var rows []model.SignalExecutionSummary
+	err := r.db.WithContext(ctx).
+		Scopes(paginateBy(page, "signal_id", "updated_at")).
+		Find(&rows).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "SignalExecutionSummaryRepository",
+			"op":   "List",
+		}).WithError(err).Error("Failed to list signal execution summaries")
+		return nil, err
+	}
+	return rows, nil
+}
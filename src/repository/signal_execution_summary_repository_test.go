@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalExecutionSummaryRepositoryRefresh(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.SignalExecution{}, &model.SignalExecutionSummary{}))
+	repo := NewSignalExecutionSummaryRepositoryWithDB(db)
+	ctx := context.Background()
+
+	execs := []model.SignalExecution{
+		{UserID: 1, ExchangeID: 1, SignalID: 7, Symbol: "BTCUSDT", Decision: model.SignalExecutionDispatched},
+		{UserID: 2, ExchangeID: 1, SignalID: 7, Symbol: "BTCUSDT", Decision: model.SignalExecutionDispatched},
+		{UserID: 3, ExchangeID: 1, SignalID: 7, Symbol: "BTCUSDT", Decision: model.SignalExecutionSkipped},
+		{UserID: 4, ExchangeID: 1, SignalID: 7, Symbol: "BTCUSDT", Decision: model.SignalExecutionDispatched},
+		{UserID: 5, ExchangeID: 1, SignalID: 7, Symbol: "BTCUSDT", Decision: model.SignalExecutionDispatched},
+		{UserID: 1, ExchangeID: 1, SignalID: 8, Symbol: "BTCUSDT", Decision: model.SignalExecutionDispatched},
+	}
+	require.NoError(t, db.Create(&execs).Error)
+	orders := []model.Order{
+		{UserID: 1, ExchangeID: 1, ExternalID: 7, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusFilled, OrderDir: model.OrderDirectionEntry},
+		{UserID: 1, ExchangeID: 1, ExternalID: 7, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusFilled, OrderDir: model.OrderDirectionExit},
+		{UserID: 2, ExchangeID: 1, ExternalID: 7, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusFiltered, OrderDir: model.OrderDirectionEntry},
+		// user 4 errored, then a retry is waiting for approval
+		{UserID: 4, ExchangeID: 1, ExternalID: 7, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusError, OrderDir: model.OrderDirectionEntry},
+		{UserID: 4, ExchangeID: 1, ExternalID: 7, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusAwaitingApproval, OrderDir: model.OrderDirectionEntry},
+		{UserID: 5, ExchangeID: 1, ExternalID: 7, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusError, OrderDir: model.OrderDirectionEntry},
+		// an order placed by hand for user 6, without executor decision
+		{UserID: 6, ExchangeID: 1, ExternalID: 7, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusFilled, OrderDir: model.OrderDirectionEntry},
+	}
+	require.NoError(t, db.Create(&orders).Error)
+
+	summary, err := repo.Refresh(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, "BTCUSDT", summary.Symbol)
+	require.Equal(t, 6, summary.Accounts)
+	require.Equal(t, 2, summary.Filled)
+	require.Equal(t, 2, summary.Skipped, "filtered and skipped by the replay policy")
+	require.Equal(t, 1, summary.Errored)
+	require.Equal(t, 1, summary.Pending, "the latest entry of an account counts")
+	require.False(t, summary.Clean)
+
+	require.NoError(t, db.Model(&model.Order{}).Where("user_id IN ?", []uint{4, 5}).
+		Update("status", model.OrderExecutionStatusFilled).Error)
+	summary, err = repo.Refresh(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, 4, summary.Filled)
+	require.True(t, summary.Clean)
+
+	_, err = repo.Refresh(ctx, 8)
+	require.NoError(t, err)
+	empty, err := repo.Refresh(ctx, 9)
+	require.NoError(t, err)
+	require.Zero(t, empty.Accounts)
+
+	rows, err := repo.List(ctx, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, rows, 2, "refreshed again in place, signals without decision are not stored")
+	require.Equal(t, uint(8), rows[0].SignalID)
+	require.Equal(t, 1, rows[0].Skipped, "dispatched, passed on by the controller")
+	require.Equal(t, 4, rows[1].Filled)
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SignalFilterSettingsRepository persists per-user pre-trade filter settings.
+type SignalFilterSettingsRepository struct {
+	db *gorm.DB
+}
+
+func NewSignalFilterSettingsRepository() *SignalFilterSettingsRepository {
+	return &SignalFilterSettingsRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewSignalFilterSettingsRepositoryWithDB(db *gorm.DB) *SignalFilterSettingsRepository {
+	return &SignalFilterSettingsRepository{
+		db: db,
+	}
+}
+
+// GetByUserID returns the settings for userID, or (nil, nil) when the user
+// has not configured any filter.
+func (r *SignalFilterSettingsRepository) GetByUserID(ctx context.Context, userID uint) (*model.SignalFilterSetting, error) {
+	var s model.SignalFilterSetting
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ?", userID).
+		First(&s).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Upsert inserts or replaces the settings of s.UserID.
+func (r *SignalFilterSettingsRepository) Upsert(ctx context.Context, s *model.SignalFilterSetting) error {
+	if err := checkOwner(ctx, s.UserID); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"trading_start_hour",
+				"trading_end_hour",
+				"timezone",
+				"news_sentiment_threshold",
+				"news_sentiment_lookback_minutes",
+				"max_volatility_pct",
+				"volatility_lookback_minutes",
+				"max_spread_bps",
+				"max_open_positions",
+				"loss_cooldown_minutes",
+				"max_consecutive_losses",
+				"loss_streak_cooldown_minutes",
+				"funding_delay_minutes",
+				"funding_delay_min_rate_bps",
+				"stop_out_cooldown_minutes",
+				"stop_out_confirm_interval_minutes",
+				"max_daily_entries",
+				"updated_at",
+			}),
+		}).
+		Create(s).Error
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSignalJobNotDead is returned by Retry for a job that is not in the dead
+// letter queue.
+var ErrSignalJobNotDead = errors.New("signal job is not dead")
+
+// leaseCandidates is how many visible jobs Lease tries before giving up on
+// a tick, when other workers lease them first.
+const leaseCandidates = 5
+
+// SignalJobRepository is the Postgres-backed queue of webhook signals, one
+// job per signal and consuming account.
+type SignalJobRepository struct {
+	db *gorm.DB
+}
+
+func NewSignalJobRepository() *SignalJobRepository {
+	return &SignalJobRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewSignalJobRepositoryWithDB(db *gorm.DB) *SignalJobRepository {
+	return &SignalJobRepository{
+		db: db,
+	}
+}
+
+// RegisterConsumer records that the executor of consumer consumes the
+// signals of its symbol, as of consumer.LastSeenAt.
+func (r *SignalJobRepository) RegisterConsumer(ctx context.Context, consumer *model.SignalQueueConsumer) error {
+	if err := checkOwner(ctx, consumer.UserID); err != nil {
+		return err
+	}
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "exchange_id"}, {Name: "symbol"}},
+			DoUpdates: clause.AssignmentColumns([]string{"exchange_name", "last_seen_at"}),
+		}).
+		Create(consumer).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":    "SignalJobRepository",
+			"op":      "RegisterConsumer",
+			"user_id": consumer.UserID,
+		}).WithError(err).Error("Failed to register signal queue consumer")
+	}
+	return err
+}
+
+// Enqueue queues signalID for every consumer of symbol on exchangeName seen
+// since, visible at now, and returns how many jobs were queued. Queueing a
+// signal again is a no-op.
+func (r *SignalJobRepository) Enqueue(ctx context.Context, signalID uint, symbol, exchangeName string, since, now time.Time) (int, error) {
+	log := logger.WithFields(map[string]interface{}{
+		"repo":      "SignalJobRepository",
+		"op":        "Enqueue",
+		"signal_id": signalID,
+	})
+
+	var consumers []model.SignalQueueConsumer
+	err := r.db.WithContext(ctx).
+		Where("symbol = ? AND exchange_name = ? AND last_seen_at >= ?", symbol, exchangeName, since).
+		Find(&consumers).Error
+	if err != nil {
+		log.WithError(err).Error("Failed to list signal queue consumers")
+		return 0, err
+	}
+	if len(consumers) == 0 {
+		return 0, nil
+	}
+
+	jobs := make([]model.SignalJob, 0, len(consumers))
+	for _, c := range consumers {
+		jobs = append(jobs, model.SignalJob{
+			SignalID:   signalID,
+			UserID:     c.UserID,
+			ExchangeID: c.ExchangeID,
+			Symbol:     symbol,
+			Status:     model.SignalJobQueued,
+			VisibleAt:  now,
+		})
+	}
+	res := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&jobs)
+	if res.Error != nil {
+		log.WithError(res.Error).Error("Failed to queue signal jobs")
+		return 0, res.Error
+	}
+	return int(res.RowsAffected), nil
+}
+
+// CreateAndEnqueue stores signal and queues it like Enqueue in one
+// transaction, so a signal is never stored without its jobs and a failed
+// request can be retried by the provider without storing it twice.
+func (r *SignalJobRepository) CreateAndEnqueue(ctx context.Context, signal *externalmodel.TradingSignal, since, now time.Time) (int, error) {
+	queued := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := (&TradingSignalRepository{db: tx}).Create(ctx, signal); err != nil {
+			return err
+		}
+		n, err := NewSignalJobRepositoryWithDB(tx).Enqueue(ctx, signal.ID, signal.Symbol, signal.ExchangeName, since, now)
+		if err != nil {
+			return err
+		}
+		queued = n
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("store and queue signal: %w", err)
+	}
+	return queued, nil
+}
+
+// Lease takes the oldest job of the account on symbol visible at now, hiding
+// it from other workers for visibility. A job whose lease expired after
+// maxAttempts is dead-lettered instead. It returns nil when no job is
+// visible.
+func (r *SignalJobRepository) Lease(ctx context.Context, userID, exchangeID uint, symbol string, now time.Time, visibility time.Duration, maxAttempts int) (*model.SignalJob, error) {
+	log := logger.WithFields(map[string]interface{}{
+		"repo":    "SignalJobRepository",
+		"op":      "Lease",
+		"user_id": userID,
+	})
+
+	for i := 0; i < leaseCandidates; i++ {
+		var job model.SignalJob
+		err := r.db.WithContext(ctx).
+			Scopes(userScope(ctx)).
+			Where("user_id = ? AND exchange_id = ? AND symbol = ?", userID, exchangeID, symbol).
+			Where("status IN ? AND visible_at <= ?", []string{model.SignalJobQueued, model.SignalJobLeased}, now).
+			Order("id").
+			First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to find a visible signal job")
+			return nil, err
+		}
+
+		updates := map[string]interface{}{
+			"status":     model.SignalJobLeased,
+			"attempts":   job.Attempts + 1,
+			"visible_at": now.Add(visibility),
+		}
+		if job.Status == model.SignalJobLeased && job.Attempts >= maxAttempts {
+			updates = map[string]interface{}{
+				"status":     model.SignalJobDead,
+				"last_error": fmt.Sprintf("lease expired after %d attempts", job.Attempts),
+			}
+		}
+		// Only the worker that saw the job in this state takes it.
+		res := r.db.WithContext(ctx).
+			Model(&model.SignalJob{}).
+			Where("id = ? AND status = ? AND attempts = ?", job.ID, job.Status, job.Attempts).
+			Updates(updates)
+		if res.Error != nil {
+			log.WithError(res.Error).Error("Failed to lease signal job")
+			return nil, res.Error
+		}
+		if res.RowsAffected == 0 || updates["status"] == model.SignalJobDead {
+			continue
+		}
+		job.Status, job.Attempts, job.VisibleAt = model.SignalJobLeased, job.Attempts+1, now.Add(visibility)
+		return &job, nil
+	}
+	return nil, nil
+}
+
+// Complete marks job id as done at now.
+func (r *SignalJobRepository) Complete(ctx context.Context, id uint, now time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.SignalJob{}).
+		Scopes(userScope(ctx)).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"status": model.SignalJobDone, "completed_at": now}).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":   "SignalJobRepository",
+			"op":     "Complete",
+			"job_id": id,
+		}).WithError(err).Error("Failed to complete signal job")
+	}
+	return err
+}
+
+// Fail records cause on job and queues it again at retryAt, or dead-letters
+// it once it was attempted maxAttempts times. dead reports the latter.
+func (r *SignalJobRepository) Fail(ctx context.Context, job *model.SignalJob, cause error, retryAt time.Time, maxAttempts int) (dead bool, err error) {
+	updates := map[string]interface{}{
+		"status":     model.SignalJobQueued,
+		"visible_at": retryAt,
+		"last_error": cause.Error(),
+	}
+	if job.Attempts >= maxAttempts {
+		dead = true
+		updates["status"] = model.SignalJobDead
+	}
+	err = r.db.WithContext(ctx).
+		Model(&model.SignalJob{}).
+		Scopes(userScope(ctx)).
+		Where("id = ?", job.ID).
+		Updates(updates).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":   "SignalJobRepository",
+			"op":     "Fail",
+			"job_id": job.ID,
+		}).WithError(err).Error("Failed to record signal job failure")
+		return false, err
+	}
+	return dead, nil
+}
+
+// List returns a page of jobs, every status when status is empty.
+func (r *SignalJobRepository) List(ctx context.Context, status string, page Pagination) ([]model.SignalJob, error) {
+	q := r.db.WithContext(ctx).Scopes(userScope(ctx))
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var rows []model.SignalJob
+	if err := q.Scopes(paginate(page, "created_at")).Find(&rows).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "SignalJobRepository",
+			"op":   "List",
+		}).WithError(err).Error("Failed to list signal jobs")
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Retry queues the dead job id again with its attempts reset, visible at
+// now. It returns (nil, nil) when the job does not exist and
+// ErrSignalJobNotDead when it is not dead.
+func (r *SignalJobRepository) Retry(ctx context.Context, id uint, now time.Time) (*model.SignalJob, error) {
+	var job model.SignalJob
+	err := r.db.WithContext(ctx).Scopes(userScope(ctx)).First(&job, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != model.SignalJobDead {
+		return nil, ErrSignalJobNotDead
+	}
+
+	job.Status, job.Attempts, job.VisibleAt = model.SignalJobQueued, 0, now
+	err = r.db.WithContext(ctx).
+		Model(&model.SignalJob{}).
+		Where("id = ? AND status = ?", id, model.SignalJobDead).
+		Updates(map[string]interface{}{"status": job.Status, "attempts": 0, "visible_at": now}).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":   "SignalJobRepository",
+			"op":     "Retry",
+			"job_id": id,
+		}).WithError(err).Error("Failed to retry signal job")
+		return nil, err
+	}
+	return &job, nil
+}
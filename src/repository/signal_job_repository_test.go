@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalJobRepositoryQueue(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.SignalJob{}, &model.SignalQueueConsumer{}))
+	repo := NewSignalJobRepositoryWithDB(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.RegisterConsumer(ctx, &model.SignalQueueConsumer{UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", ExchangeName: "phemex", LastSeenAt: now.Add(-48 * time.Hour)}))
+	require.NoError(t, repo.RegisterConsumer(ctx, &model.SignalQueueConsumer{UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", ExchangeName: "phemex", LastSeenAt: now}))
+	require.NoError(t, repo.RegisterConsumer(ctx, &model.SignalQueueConsumer{UserID: 2, ExchangeID: 1, Symbol: "BTCUSDT", ExchangeName: "phemex", LastSeenAt: now.Add(-48 * time.Hour)}))
+	require.NoError(t, repo.RegisterConsumer(ctx, &model.SignalQueueConsumer{UserID: 3, ExchangeID: 1, Symbol: "ETHUSDT", ExchangeName: "phemex", LastSeenAt: now}))
+
+	since := now.Add(-24 * time.Hour)
+	queued, err := repo.Enqueue(ctx, 7, "BTCUSDT", "phemex", since, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, queued, "only the recent consumers of the symbol")
+	queued, err = repo.Enqueue(ctx, 7, "BTCUSDT", "phemex", since, now)
+	require.NoError(t, err)
+	require.Zero(t, queued, "queued once")
+	_, err = repo.Enqueue(ctx, 8, "BTCUSDT", "phemex", since, now)
+	require.NoError(t, err)
+
+	// FIFO, hidden while leased
+	job, err := repo.Lease(ctx, 1, 1, "BTCUSDT", now, time.Minute, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint(7), job.SignalID)
+	require.Equal(t, 1, job.Attempts)
+	next, err := repo.Lease(ctx, 1, 1, "BTCUSDT", now, time.Minute, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint(8), next.SignalID)
+	none, err := repo.Lease(ctx, 1, 1, "BTCUSDT", now, time.Minute, 2)
+	require.NoError(t, err)
+	require.Nil(t, none)
+
+	require.NoError(t, repo.Complete(ctx, next.ID, now))
+
+	// the lease of 7 expires, it is delivered again
+	later := now.Add(2 * time.Minute)
+	again, err := repo.Lease(ctx, 1, 1, "BTCUSDT", later, time.Minute, 2)
+	require.NoError(t, err)
+	require.Equal(t, job.ID, again.ID)
+	require.Equal(t, 2, again.Attempts)
+
+	dead, err := repo.Fail(ctx, again, errors.New("exchange down"), later.Add(time.Minute), 2)
+	require.NoError(t, err)
+	require.True(t, dead, "out of attempts")
+	none, err = repo.Lease(ctx, 1, 1, "BTCUSDT", later.Add(time.Hour), time.Minute, 2)
+	require.NoError(t, err)
+	require.Nil(t, none)
+
+	rows, err := repo.List(ctx, model.SignalJobDead, Pagination{})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "exchange down", rows[0].LastError)
+
+	_, err = repo.Retry(ctx, next.ID, later)
+	require.ErrorIs(t, err, ErrSignalJobNotDead)
+	retried, err := repo.Retry(ctx, job.ID, later)
+	require.NoError(t, err)
+	require.Equal(t, model.SignalJobQueued, retried.Status)
+	job, err = repo.Lease(ctx, 1, 1, "BTCUSDT", later, time.Minute, 2)
+	require.NoError(t, err)
+	require.Equal(t, retried.ID, job.ID)
+	require.Equal(t, 1, job.Attempts)
+
+	// a failure with attempts left queues it again
+	dead, err = repo.Fail(ctx, job, errors.New("timeout"), later.Add(time.Minute), 2)
+	require.NoError(t, err)
+	require.False(t, dead)
+	none, err = repo.Lease(ctx, 1, 1, "BTCUSDT", later, time.Minute, 2)
+	require.NoError(t, err)
+	require.Nil(t, none, "not before the retry delay")
+
+	// a lease expiring after the last attempt dead-letters the job
+	job, err = repo.Lease(ctx, 1, 1, "BTCUSDT", later.Add(time.Minute), time.Minute, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, job.Attempts)
+	none, err = repo.Lease(ctx, 1, 1, "BTCUSDT", later.Add(time.Hour), time.Minute, 2)
+	require.NoError(t, err)
+	require.Nil(t, none)
+	var stored model.SignalJob
+	require.NoError(t, db.First(&stored, job.ID).Error)
+	require.Equal(t, model.SignalJobDead, stored.Status)
+	require.Equal(t, "lease expired after 2 attempts", stored.LastError)
+}
+
+func TestSignalJobRepositoryCreateAndEnqueue(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&externalmodel.TradingSignal{}, &model.SignalQueueConsumer{}))
+	repo := NewSignalJobRepositoryWithDB(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.RegisterConsumer(ctx, &model.SignalQueueConsumer{UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", ExchangeName: "phemex", LastSeenAt: now}))
+
+	// signal_jobs is missing, the signal is rolled back with its jobs
+	_, err := repo.CreateAndEnqueue(ctx, &externalmodel.TradingSignal{Symbol: "BTCUSDT", ExchangeName: "phemex"}, now.Add(-time.Hour), now)
+	require.Error(t, err)
+	var count int64
+	require.NoError(t, db.Model(&externalmodel.TradingSignal{}).Count(&count).Error)
+	require.Zero(t, count)
+
+	require.NoError(t, db.AutoMigrate(&model.SignalJob{}))
+	signal := &externalmodel.TradingSignal{Symbol: "BTCUSDT", ExchangeName: "phemex"}
+	queued, err := repo.CreateAndEnqueue(ctx, signal, now.Add(-time.Hour), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, queued)
+	require.NotZero(t, signal.ID)
+	job, err := repo.Lease(ctx, 1, 1, "BTCUSDT", now, time.Minute, 2)
+	require.NoError(t, err)
+	require.Equal(t, signal.ID, job.SignalID)
+}
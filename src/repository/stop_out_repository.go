@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StopOutRepository persists the last stop-out per user/symbol/side.
+type StopOutRepository struct {
+	db *gorm.DB
+}
+
+func NewStopOutRepository() *StopOutRepository {
+	return &StopOutRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewStopOutRepositoryWithDB(db *gorm.DB) *StopOutRepository {
+	return &StopOutRepository{
+		db: db,
+	}
+}
+
+// Get returns the last stop-out of a user on exchange/symbol/posSide, or
+// (nil, nil) when none was recorded yet.
+func (r *StopOutRepository) Get(ctx context.Context, userID, exchangeID uint, symbol, posSide string) (*model.StopOut, error) {
+	var s model.StopOut
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ? AND symbol = ? AND pos_side = ?", userID, exchangeID, symbol, posSide).
+		First(&s).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Upsert stores s keyed by (user_id, exchange_id, symbol, pos_side),
+// replacing the previous stop-out of the position.
+func (r *StopOutRepository) Upsert(ctx context.Context, s *model.StopOut) error {
+	if err := checkOwner(ctx, s.UserID); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "exchange_id"}, {Name: "symbol"}, {Name: "pos_side"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"entry_order_id",
+				"stop_order_id",
+				"stop_price",
+				"stopped_at",
+				"updated_at",
+			}),
+		}).
+		Create(s).Error
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopOutRepository(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.StopOut{}))
+	repo := NewStopOutRepositoryWithDB(db)
+	ctx := context.Background()
+	at := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	got, err := repo.Get(ctx, 3, 1, "BTCUSDT", "Long")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	require.NoError(t, repo.Upsert(ctx, &model.StopOut{UserID: 3, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Long", EntryOrderID: 7, StopOrderID: "s-1", StoppedAt: at}))
+	require.NoError(t, repo.Upsert(ctx, &model.StopOut{UserID: 3, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Short", EntryOrderID: 8, StopOrderID: "s-2", StoppedAt: at}))
+	require.NoError(t, repo.Upsert(ctx, &model.StopOut{UserID: 3, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Long", EntryOrderID: 9, StopOrderID: "s-3", StoppedAt: at.Add(time.Hour)}))
+	require.ErrorIs(t, repo.Upsert(auth.WithUserID(ctx, 4), &model.StopOut{UserID: 3, ExchangeID: 1, Symbol: "ETHUSDT", PosSide: "Long", StoppedAt: at}), ErrCrossTenant)
+
+	got, err = repo.Get(ctx, 3, 1, "BTCUSDT", "Long")
+	require.NoError(t, err)
+	require.Equal(t, uint(9), got.EntryOrderID, "the later stop-out replaces the previous one")
+	require.Equal(t, "s-3", got.StopOrderID)
+	require.True(t, got.StoppedAt.Equal(at.Add(time.Hour)))
+
+	got, err = repo.Get(ctx, 3, 1, "BTCUSDT", "Short")
+	require.NoError(t, err)
+	require.Equal(t, "s-2", got.StopOrderID)
+
+	got, err = repo.Get(auth.WithUserID(ctx, 4), 3, 1, "BTCUSDT", "Long")
+	require.NoError(t, err)
+	require.Nil(t, got, "stop-outs of other users are hidden")
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StrategyRepository persists strategy assignments and their decisions.
+type StrategyRepository struct {
+	db *gorm.DB
+}
+
+// NewStrategyRepository creates a new repository instance.
+func NewStrategyRepository() *StrategyRepository {
+	return &StrategyRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewStrategyRepositoryWithDB(db *gorm.DB) *StrategyRepository {
+	return &StrategyRepository{
+		db: db,
+	}
+}
+
+// FindAssigned returns the enabled strategy of user/exchange/symbol, or
+// (nil, nil) when none is assigned.
+func (r *StrategyRepository) FindAssigned(
+	ctx context.Context,
+	userID uint,
+	exchangeID uint,
+	symbol string,
+) (*model.Strategy, error) {
+	var s model.Strategy
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND exchange_id = ? AND symbol = ? AND enabled = ?", userID, exchangeID, symbol, true).
+		First(&s).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListByUser returns every strategy assignment of userID, enabled or not.
+func (r *StrategyRepository) ListByUser(ctx context.Context, userID uint) ([]model.Strategy, error) {
+	var rows []model.Strategy
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Upsert assigns a strategy, replacing the previous one of user/exchange/symbol.
+func (r *StrategyRepository) Upsert(ctx context.Context, s *model.Strategy) error {
+	logger.WithFields(map[string]interface{}{
+		"repo":        "StrategyRepository",
+		"op":          "Upsert",
+		"user_id":     s.UserID,
+		"exchange_id": s.ExchangeID,
+		"symbol":      s.Symbol,
+		"name":        s.Name,
+	}).Debug("Saving strategy assignment")
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "exchange_id"}, {Name: "symbol"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "params", "enabled", "updated_at"}),
+		}).
+		Create(s).Error
+}
+
+// RecordAction stores a strategy decision.
+func (r *StrategyRepository) RecordAction(ctx context.Context, action *model.StrategyAction) error {
+	return r.db.WithContext(ctx).Create(action).Error
+}
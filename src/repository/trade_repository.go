@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TradeFilter narrows the trades returned by TradeRepository.List. Zero
+// values are ignored.
+type TradeFilter struct {
+	UserID uint
+	Symbol string
+	Status string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+// TradeRepository persists the trade journal.
+type TradeRepository struct {
+	db *gorm.DB
+}
+
+// NewTradeRepository creates a new repository instance.
+func NewTradeRepository() *TradeRepository {
+	return &TradeRepository{
+		db: database.MainDB,
+	}
+}
+
+func NewTradeRepositoryWithDB(db *gorm.DB) *TradeRepository {
+	return &TradeRepository{
+		db: db,
+	}
+}
+
+// Upsert stores the trade keyed by its entry order, so rebuilding the journal
+// updates open trades once they are closed.
+func (r *TradeRepository) Upsert(ctx context.Context, trade *model.Trade) error {
+	logger.WithFields(map[string]interface{}{
+		"repo":           "TradeRepository",
+		"op":             "Upsert",
+		"entry_order_id": trade.EntryOrderID,
+		"status":         trade.Status,
+	}).Debug("Saving trade")
+
+	if err := checkOwner(ctx, trade.UserID); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "entry_order_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"status",
+				"exit_signal_id",
+				"exit_order_id",
+				"quantity",
+				"entry_price",
+				"exit_price",
+				"stop_loss",
+				"entry_time",
+				"exit_time",
+				"pnl",
+				"fees",
+				"initial_risk",
+				"r_multiple",
+				"duration_seconds",
+				"mae",
+				"mfe",
+				"mae_r",
+				"mfe_r",
+				"strategy_id",
+				"updated_at",
+			}),
+		}).
+		Create(trade).Error
+}
+
+// List returns trades matching filter, newest entry first.
+func (r *TradeRepository) List(ctx context.Context, filter TradeFilter) ([]model.Trade, error) {
+	q := r.db.WithContext(ctx).Model(&model.Trade{}).Scopes(userScope(ctx))
+	if filter.UserID != 0 {
+		q = q.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Symbol != "" {
+		q = q.Where("symbol = ?", filter.Symbol)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("entry_time >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("entry_time <= ?", filter.To.UTC())
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+
+	var rows []model.Trade
+	if err := q.Order("entry_time DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// FindClosedPage returns one page of the closed trades of userID, bounding
+// the date range on their exit time, e.g. to export the realized PnL of a
+// tax year a page at a time.
+func (r *TradeRepository) FindClosedPage(ctx context.Context, userID uint, page Pagination) ([]model.Trade, error) {
+	var rows []model.Trade
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx), paginate(page, "exit_time")).
+		Where("user_id = ? AND status = ?", userID, model.TradeStatusClosed).
+		Find(&rows).Error
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo": "TradeRepository",
+			"op":   "FindClosedPage",
+		}).WithError(err).Error("Failed to fetch closed trades")
+		return nil, err
+	}
+	return rows, nil
+}
+
+// FindLastClosed returns the most recently closed trade of a user on
+// exchange/symbol, or (nil, nil) when there is none.
+func (r *TradeRepository) FindLastClosed(ctx context.Context, userID, exchangeID uint, symbol string) (*model.Trade, error) {
+	var trade model.Trade
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ? AND symbol = ? AND status = ?", userID, exchangeID, symbol, model.TradeStatusClosed).
+		Order("exit_time DESC, id DESC").
+		First(&trade).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &trade, nil
+}
+
+// FindRecentClosed returns up to limit closed trades of a user on
+// exchange/symbol, latest exit first.
+func (r *TradeRepository) FindRecentClosed(ctx context.Context, userID, exchangeID uint, symbol string, limit int) ([]model.Trade, error) {
+	var rows []model.Trade
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ? AND symbol = ? AND status = ?", userID, exchangeID, symbol, model.TradeStatusClosed).
+		Order("exit_time DESC, id DESC").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// FindClosedWithoutExcursions returns up to limit closed trades whose MAE and
+// MFE were never measured, oldest exit first.
+func (r *TradeRepository) FindClosedWithoutExcursions(ctx context.Context, limit int) ([]model.Trade, error) {
+	var rows []model.Trade
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("status = ? AND exit_time IS NOT NULL AND mae = 0 AND mfe = 0", model.TradeStatusClosed).
+		Order("exit_time ASC, id ASC").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// UpdateExcursions stores the MAE and MFE columns of trade.
+func (r *TradeRepository) UpdateExcursions(ctx context.Context, trade *model.Trade) error {
+	if err := checkOwner(ctx, trade.UserID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).
+		Model(&model.Trade{}).
+		Where("id = ?", trade.ID).
+		Updates(map[string]interface{}{
+			"mae":   trade.MAE,
+			"mfe":   trade.MFE,
+			"mae_r": trade.MAER,
+			"mfe_r": trade.MFER,
+		}).Error
+}
@@ -4,10 +4,12 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	logger "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
+	"strategyexecutor/src/cache"
 	"strategyexecutor/src/database"      // TODO: adjust to your real module path
 	"strategyexecutor/src/externalmodel" // TODO: adjust to your real module path
 )
@@ -38,6 +40,38 @@ func (r *TradingSignalRepository) WithDB(db *gorm.DB) *TradingSignalRepository {
 	return &TradingSignalRepository{db: db}
 }
 
+// Create stores a signal received by the webhook endpoint. The table belongs
+// to the signal ingestion app; the server writes to it only when webhook
+// providers are configured, through the connection the executors read from.
+// Unset schema v2 columns are left out of the insert.
+func (r *TradingSignalRepository) Create(
+	ctx context.Context,
+	signal *externalmodel.TradingSignal,
+) error {
+
+	fields := map[string]interface{}{
+		"repo":     "TradingSignalRepository",
+		"op":       "Create",
+		"symbol":   signal.Symbol,
+		"exchange": signal.ExchangeName,
+		"action":   signal.Action,
+	}
+
+	q := r.db.WithContext(ctx)
+	if unset := signal.UnsetV2Columns(); len(unset) > 0 {
+		q = q.Omit(unset...)
+	}
+	if err := q.Create(signal).Error; err != nil {
+		logger.WithFields(fields).WithError(err).Error("Failed to store trading signal")
+		return err
+	}
+
+	cache.Invalidate(ctx, cache.Default(), latestSignalKey(signal.Symbol, signal.ExchangeName))
+
+	logger.WithFields(fields).WithField("id", signal.ID).Info("Trading signal stored")
+	return nil
+}
+
 // FindByID fetches a single trading signal by its primary ID.
 // Returns (nil, nil) if not found.
 func (r *TradingSignalRepository) FindByID(
@@ -87,6 +121,8 @@ func (r *TradingSignalRepository) FindByID(
 
 // FindLatest fetches the latest trading signals ordered from newest to oldest.
 // The limit parameter defines how many records will be returned.
+// The single latest signal, which every executor of symbol polls, is shared
+// through the cache for cache.Config.SignalTTL; Create invalidates it.
 func (r *TradingSignalRepository) FindLatest(
 	ctx context.Context,
 	symbol,
@@ -97,6 +133,23 @@ func (r *TradingSignalRepository) FindLatest(
 	if limit <= 0 {
 		limit = 10 // default safety limit
 	}
+	if limit == 1 {
+		return cache.GetOrLoad(ctx, cache.Default(), latestSignalKey(symbol, exchangeName), cache.TTLs().SignalTTL,
+			func() ([]externalmodel.TradingSignal, error) { return r.findLatest(ctx, symbol, exchangeName, limit) })
+	}
+	return r.findLatest(ctx, symbol, exchangeName, limit)
+}
+
+func latestSignalKey(symbol, exchangeName string) string {
+	return cache.Key("signals", "latest", exchangeName, symbol)
+}
+
+func (r *TradingSignalRepository) findLatest(
+	ctx context.Context,
+	symbol,
+	exchangeName string,
+	limit int,
+) ([]externalmodel.TradingSignal, error) {
 
 	logger.WithFields(map[string]interface{}{
 		"repo":  "TradingSignalRepository",
@@ -133,6 +186,46 @@ func (r *TradingSignalRepository) FindLatest(
 	return signals, nil
 }
 
+// FindAfterIDForSymbol fetches the signals of symbol on exchangeName with ID
+// greater than lastID, oldest first, with the columns needed to date them.
+func (r *TradingSignalRepository) FindAfterIDForSymbol(
+	ctx context.Context,
+	symbol,
+	exchangeName string,
+	lastID uint,
+	limit int,
+) ([]externalmodel.TradingSignal, error) {
+
+	if limit <= 0 {
+		limit = 100 // default safety limit
+	}
+
+	fields := map[string]interface{}{
+		"repo":   "TradingSignalRepository",
+		"op":     "FindAfterIDForSymbol",
+		"symbol": symbol,
+		"lastID": lastID,
+		"limit":  limit,
+	}
+	logger.WithFields(fields).Debug("Fetching trading signals of symbol after ID")
+
+	var signals []externalmodel.TradingSignal
+
+	err := r.db.WithContext(ctx).
+		Select("id", "order_id", "symbol", "action", "price", "timestamp_dt", "received_at").
+		Where("symbol = ? AND exchange_name = ? AND id > ?", symbol, exchangeName, lastID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&signals).Error
+
+	if err != nil {
+		logger.WithFields(fields).WithError(err).Error("Failed to fetch trading signals of symbol after ID")
+		return nil, err
+	}
+
+	return signals, nil
+}
+
 // FindAfterID fetches trading signals with ID greater than lastID,
 // ordered from oldest to newest (ascending by ID).
 // This is ideal for incremental polling every N seconds.
@@ -323,3 +416,42 @@ func (r *TradingSignalRepository) CountNewAfterID(
 
 	return count, nil
 }
+
+// FindInTimeRange fetches the signals of symbol/exchange whose timestamp_dt is
+// in [from, to], ordered from oldest to newest. Used to replay history.
+func (r *TradingSignalRepository) FindInTimeRange(
+	ctx context.Context,
+	symbol string,
+	exchangeName string,
+	from time.Time,
+	to time.Time,
+) ([]externalmodel.TradingSignal, error) {
+
+	logger.WithFields(map[string]interface{}{
+		"repo":   "TradingSignalRepository",
+		"op":     "FindInTimeRange",
+		"symbol": symbol,
+		"from":   from,
+		"to":     to,
+	}).Debug("Fetching trading signals in time range")
+
+	var signals []externalmodel.TradingSignal
+
+	err := r.db.WithContext(ctx).
+		Where("symbol = ? AND exchange_name = ?", symbol, exchangeName).
+		Where("timestamp_dt >= ? AND timestamp_dt <= ?", from, to).
+		Order("timestamp_dt ASC, id ASC").
+		Find(&signals).Error
+
+	if err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":   "TradingSignalRepository",
+			"op":     "FindInTimeRange",
+			"symbol": symbol,
+		}).WithError(err).Error("Failed to fetch trading signals in time range")
+
+		return nil, err
+	}
+
+	return signals, nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"strategyexecutor/src/cache"
+	"strategyexecutor/src/externalmodel"
+)
+
+func TestTradingSignalCreateOmitsUnsetV2Columns(t *testing.T) {
+	db := newScopeTestDB(t)
+	// the ingestion app's table before schema v2
+	require.NoError(t, db.Exec(`CREATE TABLE trade_tradingsignal (
+		id integer PRIMARY KEY AUTOINCREMENT, order_id text, exchange_name text, symbol text,
+		action text, order_type text, qty real, price real, market_position text,
+		prev_market_position text, market_position_size real, prev_market_position_size real,
+		signal_token text, timestamp_raw text, timestamp_dt datetime, comment text,
+		message text, received_at datetime)`).Error)
+
+	repo := (&TradingSignalRepository{}).WithDB(db)
+	ctx := context.Background()
+
+	signal := &externalmodel.TradingSignal{ExchangeName: "phemex", Symbol: "BTCUSDT", Action: "buy"}
+	require.NoError(t, repo.Create(ctx, signal))
+	require.NotZero(t, signal.ID)
+
+	// v2 fields need the new columns
+	sl := 60000.0
+	require.Error(t, repo.Create(ctx, &externalmodel.TradingSignal{ExchangeName: "phemex", Symbol: "BTCUSDT", Action: "buy", StopLoss: &sl}))
+
+	require.NoError(t, db.Exec(`ALTER TABLE trade_tradingsignal ADD COLUMN stop_loss real`).Error)
+	require.NoError(t, db.Exec(`ALTER TABLE trade_tradingsignal ADD COLUMN valid_until datetime`).Error)
+	until := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	v2 := &externalmodel.TradingSignal{ExchangeName: "phemex", Symbol: "BTCUSDT", Action: "buy", StopLoss: &sl, ValidUntil: &until}
+	require.NoError(t, repo.Create(ctx, v2))
+
+	var stored externalmodel.TradingSignal
+	require.NoError(t, db.Select("id", "stop_loss", "valid_until").First(&stored, v2.ID).Error)
+	require.NotNil(t, stored.StopLoss)
+	require.Equal(t, sl, *stored.StopLoss)
+	require.True(t, stored.Expired(until.Add(time.Second)))
+	require.False(t, stored.Expired(until))
+}
+
+type mapCache map[string][]byte
+
+func (m mapCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	v, ok := m[key]
+	return v, ok, nil
+}
+
+func (m mapCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	m[key] = value
+	return nil
+}
+
+func (m mapCache) Delete(_ context.Context, keys ...string) error {
+	for _, k := range keys {
+		delete(m, k)
+	}
+	return nil
+}
+
+func TestTradingSignalFindLatestCachedUntilCreate(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.Exec(`CREATE TABLE trade_tradingsignal (
+		id integer PRIMARY KEY AUTOINCREMENT, order_id text, exchange_name text, symbol text,
+		action text, order_type text, qty real, price real, market_position text,
+		prev_market_position text, market_position_size real, prev_market_position_size real,
+		signal_token text, timestamp_raw text, timestamp_dt datetime, comment text,
+		message text, received_at datetime)`).Error)
+	t.Cleanup(cache.SetDefault(mapCache{}))
+
+	repo := (&TradingSignalRepository{}).WithDB(db)
+	ctx := context.Background()
+	require.NoError(t, repo.Create(ctx, &externalmodel.TradingSignal{ExchangeName: "phemex", Symbol: "BTCUSDT", Action: "buy"}))
+
+	latest, err := repo.FindLatest(ctx, "BTCUSDT", "phemex", 1)
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	first := latest[0].ID
+
+	// written by the ingestion app, served from the cache until it expires
+	require.NoError(t, db.Exec(`INSERT INTO trade_tradingsignal (exchange_name, symbol, action) VALUES ('phemex', 'BTCUSDT', 'sell')`).Error)
+	latest, err = repo.FindLatest(ctx, "BTCUSDT", "phemex", 1)
+	require.NoError(t, err)
+	require.Equal(t, first, latest[0].ID)
+	uncached, err := repo.FindLatest(ctx, "BTCUSDT", "phemex", 2)
+	require.NoError(t, err)
+	require.Len(t, uncached, 2)
+
+	// a signal stored by the webhook endpoint invalidates it
+	require.NoError(t, repo.Create(ctx, &externalmodel.TradingSignal{ExchangeName: "phemex", Symbol: "BTCUSDT", Action: "buy"}))
+	latest, err = repo.FindLatest(ctx, "BTCUSDT", "phemex", 1)
+	require.NoError(t, err)
+	require.Equal(t, "buy", latest[0].Action)
+	require.Greater(t, latest[0].ID, uncached[0].ID)
+}
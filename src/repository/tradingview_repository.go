@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"strategyexecutor/src/database"
 	"strategyexecutor/src/model"
+	"strategyexecutor/src/sentiment"
+	"strings"
 	"time"
 
+	logger "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -29,46 +33,107 @@ func NewTradingViewRepositoryWithDB(db *gorm.DB) *TradingViewRepository {
 	}
 }
 
-// SaveTradingViewNewsEvents upserts all events based on tv_event_id
+// SaveTradingViewNewsEvents upserts all events based on tv_event_id, skipping
+// events whose content hash is already stored under another tv_event_id.
 func (r *TradingViewRepository) SaveTradingViewNewsEvents(ctx context.Context, events []model.Event) error {
 	for _, ev := range events {
-		m := model.NewTradingViewNewsEventFromEvent(ev)
-
-		// upsert on tv_event_id
-		if err := r.db.WithContext(ctx).
-			Clauses(clause.OnConflict{
-				Columns: []clause.Column{{Name: "tv_event_id"}},
-				DoUpdates: clause.AssignmentColumns([]string{
-					"title",
-					"country",
-					"indicator",
-					"ticker",
-					"comment",
-					"category",
-					"period",
-					"reference_date",
-					"source",
-					"source_url",
-					"actual",
-					"previous",
-					"forecast",
-					"actual_raw",
-					"previous_raw",
-					"forecast_raw",
-					"currency",
-					"unit",
-					"importance",
-					"event_date",
-					"updated_at",
-				}),
-			}).
-			Create(&m).Error; err != nil {
+		if _, err := r.UpsertTradingViewNewsEvent(ctx, ev); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// UpsertTradingViewNewsEvent inserts or updates a single event keyed on
+// tv_event_id. If the same headline (content_hash) is already stored under a
+// different tv_event_id the event is treated as a duplicate and skipped.
+// It returns false when the event was skipped as a duplicate.
+func (r *TradingViewRepository) UpsertTradingViewNewsEvent(ctx context.Context, ev model.Event) (bool, error) {
+	m := model.NewTradingViewNewsEventFromEvent(ev)
+
+	var dup model.TradingViewNewsEvent
+	err := r.db.WithContext(ctx).
+		Select("id", "tv_event_id").
+		Where("content_hash = ? AND tv_event_id <> ?", m.ContentHash, m.TVEventID).
+		Take(&dup).Error
+	if err == nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":        "TradingViewRepository",
+			"op":          "UpsertTradingViewNewsEvent",
+			"tv_event_id": m.TVEventID,
+			"existing_id": dup.TVEventID,
+			"title":       m.Title,
+		}).Debug("duplicate headline, skipping")
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	// upsert on tv_event_id
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "tv_event_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"title",
+				"country",
+				"indicator",
+				"ticker",
+				"comment",
+				"category",
+				"period",
+				"reference_date",
+				"source",
+				"source_url",
+				"actual",
+				"previous",
+				"forecast",
+				"actual_raw",
+				"previous_raw",
+				"forecast_raw",
+				"currency",
+				"unit",
+				"importance",
+				"event_date",
+				"news_source",
+				"content_hash",
+				"updated_at",
+			}),
+		}).
+		Create(&m).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ListWatchlist returns the TV news watchlist ordered by ticker.
+func (r *TradingViewRepository) ListWatchlist(ctx context.Context, enabledOnly bool) ([]model.TVNewsWatchlist, error) {
+	var rows []model.TVNewsWatchlist
+
+	q := r.db.WithContext(ctx)
+	if enabledOnly {
+		q = q.Where("enabled = ?", true)
+	}
+	if err := q.Order("ticker ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// UpsertWatchlistEntry inserts or updates a watchlist entry keyed on ticker.
+func (r *TradingViewRepository) UpsertWatchlistEntry(ctx context.Context, entry *model.TVNewsWatchlist) error {
+	entry.Ticker = strings.ToUpper(strings.TrimSpace(entry.Ticker))
+	entry.Country = strings.ToUpper(strings.TrimSpace(entry.Country))
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "ticker"}},
+			DoUpdates: clause.AssignmentColumns([]string{"country", "enabled", "updated_at"}),
+		}).
+		Create(entry).Error
+}
+
 // LoadImportantEventsFromDB replicates the FetchImportantEvents logic but from Postgres
 func (r *TradingViewRepository) LoadImportantEventsFromDB(
 	ctx context.Context,
@@ -96,3 +161,81 @@ func (r *TradingViewRepository) LoadImportantEventsFromDB(
 	}
 	return evs, nil
 }
+
+// UpdateSentimentScore stores the sentiment score computed by scorer for the
+// event identified by tvEventID.
+func (r *TradingViewRepository) UpdateSentimentScore(
+	ctx context.Context,
+	tvEventID string,
+	score float64,
+	scorer string,
+) error {
+	now := time.Now().UTC()
+	return r.db.WithContext(ctx).
+		Model(&model.TradingViewNewsEvent{}).
+		Where("tv_event_id = ?", tvEventID).
+		Updates(map[string]interface{}{
+			"sentiment_score":     score,
+			"sentiment_scorer":    scorer,
+			"sentiment_scored_at": now,
+		}).Error
+}
+
+// NetSentimentForSymbol sums the sentiment of scored events relevant to
+// symbol (by currency or ticker) with event_date in [now-window, now].
+// It returns the net score and how many events contributed.
+func (r *TradingViewRepository) NetSentimentForSymbol(
+	ctx context.Context,
+	symbol string,
+	window time.Duration,
+	now time.Time,
+) (float64, int, error) {
+	currencies := sentiment.CurrenciesForSymbol(symbol)
+	if len(currencies) == 0 {
+		return 0, 0, nil
+	}
+
+	var res struct {
+		Net   float64
+		Count int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&model.TradingViewNewsEvent{}).
+		Select("COALESCE(SUM(sentiment_score), 0) AS net, COUNT(*) AS count").
+		Where("sentiment_score IS NOT NULL").
+		Where("event_date >= ? AND event_date <= ?", now.Add(-window).UTC(), now.UTC()).
+		Where("currency IN ? OR ticker IN ?", currencies, []string{strings.ToUpper(symbol), currencies[0]}).
+		Scan(&res).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return res.Net, res.Count, nil
+}
+
+// FindRecentlyIngestedEvents returns events relevant to symbol (by currency
+// or ticker) that were stored at or after since with importance >=
+// minImportance, newest first.
+func (r *TradingViewRepository) FindRecentlyIngestedEvents(
+	ctx context.Context,
+	symbol string,
+	since time.Time,
+	minImportance int,
+) ([]model.TradingViewNewsEvent, error) {
+	currencies := sentiment.CurrenciesForSymbol(symbol)
+	if len(currencies) == 0 {
+		return nil, nil
+	}
+
+	var rows []model.TradingViewNewsEvent
+	err := r.db.WithContext(ctx).
+		Where("created_at >= ?", since.UTC()).
+		Where("importance >= ?", minImportance).
+		Where("currency IN ? OR ticker IN ?", currencies, []string{strings.ToUpper(symbol), currencies[0]}).
+		Order("created_at DESC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
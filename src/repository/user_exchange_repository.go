@@ -33,6 +33,9 @@ func NewUserExchangeRepository() *GormUserExchangeRepository {
 
 // Create inserts a new UserExchange record.
 func (r *GormUserExchangeRepository) Create(ctx context.Context, ue *model.UserExchange) error {
+	if err := checkOwner(ctx, ue.UserID); err != nil {
+		return err
+	}
 	return r.db.WithContext(ctx).Create(ue).Error
 }
 
@@ -45,6 +48,7 @@ func (r *GormUserExchangeRepository) GetByUserAndExchange(
 
 	var ue model.UserExchange
 	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
 		Where("user_id = ? AND exchange_id = ?", userID, exchangeID).
 		First(&ue).Error
 
@@ -63,6 +67,7 @@ func (r *GormUserExchangeRepository) MarkNoTradeWindowOrdersClosed(
 	exchangeID uint,
 ) error {
 	res := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
 		Model(&model.UserExchange{}).
 		Where("user_id = ? AND exchange_id = ?", userID, exchangeID).
 		Update("no_trade_window_orders_closed", true)
@@ -81,6 +86,12 @@ func (r *GormUserExchangeRepository) MarkNoTradeWindowOrdersClosed(
 
 // Update updates an existing UserExchange using its primary key (ID).
 func (r *GormUserExchangeRepository) Update(ctx context.Context, ue *model.UserExchange) error {
+	if err := checkOwner(ctx, ue.UserID); err != nil {
+		return err
+	}
+	if err := checkRowOwner(ctx, r.db, &model.UserExchange{}, ue.ID); err != nil {
+		return err
+	}
 	// Save will update all fields, including zero values.
 	return r.db.WithContext(ctx).Save(ue).Error
 }
@@ -95,6 +106,7 @@ func (r *GormUserExchangeRepository) UpdateByUserAndExchange(
 ) error {
 
 	return r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
 		Model(&model.UserExchange{}).
 		Where("user_id = ? AND exchange_id = ?", userID, exchangeID).
 		Updates(updates).Error
@@ -107,6 +119,10 @@ func (r *GormUserExchangeRepository) Upsert(
 	ue *model.UserExchange,
 ) error {
 
+	if err := checkOwner(ctx, ue.UserID); err != nil {
+		return err
+	}
+
 	// OnConflict: match on composite unique index (user_id, exchange_id)
 	// If a record already exists, update the credential fields and ShowInForms.
 	return r.db.WithContext(ctx).
@@ -124,3 +140,66 @@ func (r *GormUserExchangeRepository) Upsert(
 		}).
 		Create(ue).Error
 }
+
+// ListRunOnServer returns every UserExchange with run_on_server enabled, with
+// its Exchange preloaded.
+func (r *GormUserExchangeRepository) ListRunOnServer(ctx context.Context) ([]model.UserExchange, error) {
+	var rows []model.UserExchange
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Preload("Exchange").
+		Where("run_on_server = ?", true).
+		Order("user_id ASC, exchange_id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// List returns every UserExchange visible to ctx, with its Exchange
+// preloaded, whether or not it runs on the server.
+func (r *GormUserExchangeRepository) List(ctx context.Context) ([]model.UserExchange, error) {
+	var rows []model.UserExchange
+	err := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Preload("Exchange").
+		Order("user_id ASC, exchange_id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Delete removes the UserExchange of (userID, exchangeID). It reports
+// whether a row was removed.
+func (r *GormUserExchangeRepository) Delete(ctx context.Context, userID uint, exchangeID uint) (bool, error) {
+	if err := checkOwner(ctx, userID); err != nil {
+		return false, err
+	}
+	res := r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Where("user_id = ? AND exchange_id = ?", userID, exchangeID).
+		Delete(&model.UserExchange{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// RecordValidation stores the outcome of a credential health check, the
+// resulting run_on_server flag and the detected key version.
+func (r *GormUserExchangeRepository) RecordValidation(ctx context.Context, ue *model.UserExchange) error {
+	return r.db.WithContext(ctx).
+		Scopes(userScope(ctx)).
+		Model(&model.UserExchange{}).
+		Where("id = ?", ue.ID).
+		Updates(map[string]interface{}{
+			"last_validated_at":     ue.LastValidatedAt,
+			"last_validation_error": ue.LastValidationError,
+			"validation_failures":   ue.ValidationFailures,
+			"run_on_server":         ue.RunOnServer,
+			"key_version":           ue.KeyVersion,
+		}).Error
+}
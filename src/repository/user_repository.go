@@ -22,6 +22,12 @@ func NewUserRepository() *GormUserRepository {
 
 }
 
+func NewUserRepositoryWithDB(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{
+		db: db,
+	}
+}
+
 func (r *GormUserRepository) GetUserByUserName(
 	ctx context.Context,
 	userName string,
@@ -38,3 +44,50 @@ func (r *GormUserRepository) GetUserByUserName(
 
 	return &u, nil
 }
+
+func (r *GormUserRepository) GetUserByID(
+	ctx context.Context,
+	id uint,
+) (*model.User, error) {
+
+	var u model.User
+	err := r.db.WithContext(ctx).
+		First(&u, id).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// Create persists u, failing when its user name is taken.
+func (r *GormUserRepository) Create(ctx context.Context, u *model.User) error {
+	if err := r.db.WithContext(ctx).Create(u).Error; err != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":      "GormUserRepository",
+			"op":        "Create",
+			"user_name": u.Username,
+		}).WithError(err).Error("Failed to create user")
+		return err
+	}
+	return nil
+}
+
+// SetRole changes the role of user id, reporting whether the user exists.
+func (r *GormUserRepository) SetRole(ctx context.Context, id uint, role string) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", id).
+		Update("role", role)
+	if res.Error != nil {
+		logger.WithFields(map[string]interface{}{
+			"repo":    "GormUserRepository",
+			"op":      "SetRole",
+			"user_id": id,
+			"role":    role,
+		}).WithError(res.Error).Error("Failed to set user role")
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepositoryCreateAndSetRole(t *testing.T) {
+	db := newScopeTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.User{}))
+	repo := NewUserRepositoryWithDB(db)
+	ctx := context.Background()
+
+	u := &model.User{Username: "alice", Password: "hash"}
+	require.NoError(t, repo.Create(ctx, u))
+	require.Error(t, repo.Create(ctx, &model.User{Username: "alice"}), "user names are unique")
+
+	got, err := repo.GetUserByID(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.RoleOperator, got.Role, "new users manage their own accounts")
+
+	found, err := repo.SetRole(ctx, u.ID, model.RoleViewer)
+	require.NoError(t, err)
+	require.True(t, found)
+	got, err = repo.GetUserByUserName(ctx, "alice")
+	require.NoError(t, err)
+	require.Equal(t, model.RoleViewer, got.Role)
+
+	found, err = repo.SetRole(ctx, u.ID+1, model.RoleAdmin)
+	require.NoError(t, err)
+	require.False(t, found)
+}
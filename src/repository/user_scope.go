@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/auth"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCrossTenant is returned when an authenticated request tries to write a
+// row owned by another user.
+var ErrCrossTenant = errors.New("record belongs to another user")
+
+// userScope restricts a query on a table with a user_id column to the user
+// authenticated in ctx. Contexts without a user (jobs, executors) are left
+// unscoped.
+func userScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		userID, ok := auth.UserIDFromContext(ctx)
+		if !ok {
+			return db
+		}
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "user_id"},
+			Value:  userID,
+		})
+	}
+}
+
+// checkOwner rejects writes of rows owned by a user other than the one
+// authenticated in ctx.
+func checkOwner(ctx context.Context, userID uint) error {
+	if authUserID, ok := auth.UserIDFromContext(ctx); ok && authUserID != userID {
+		return ErrCrossTenant
+	}
+	return nil
+}
+
+// ownedOrderScope restricts a query on a table keyed by order_id (order and
+// execution logs) to orders of the user authenticated in ctx.
+func ownedOrderScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		userID, ok := auth.UserIDFromContext(ctx)
+		if !ok {
+			return db
+		}
+		orders := db.Session(&gorm.Session{NewDB: true}).
+			Table("orders").
+			Select("id").
+			Where("user_id = ?", userID)
+		return db.Where("order_id IN (?)", orders)
+	}
+}
+
+// checkRowOwner rejects writes by primary key against a row of value's table
+// that the user authenticated in ctx does not own.
+func checkRowOwner(ctx context.Context, db *gorm.DB, value interface{}, id uint) error {
+	if _, ok := auth.UserIDFromContext(ctx); !ok {
+		return nil
+	}
+	var count int64
+	err := db.WithContext(ctx).
+		Model(value).
+		Scopes(userScope(ctx)).
+		Where("id = ?", id).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrCrossTenant
+	}
+	return nil
+}
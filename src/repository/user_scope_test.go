@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newScopeTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&model.Order{},
+		&model.OrderLog{},
+		&model.OrderExecutionLog{},
+		&model.ArchivedOrder{},
+		&model.Exchange{},
+		&model.UserExchange{},
+	))
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+	return db
+}
+
+func TestOrderRepositoryIsolatesUsers(t *testing.T) {
+	db := newScopeTestDB(t)
+	repo := (&OrderRepository{}).WithDB(db)
+	bg := context.Background()
+
+	alice := &model.Order{UserID: 1, ExchangeID: 1, ExternalID: 10, Symbol: "BTCUSDT", Status: "pending", OrderDir: model.OrderDirectionEntry}
+	bob := &model.Order{UserID: 2, ExchangeID: 1, ExternalID: 20, Symbol: "BTCUSDT", Status: "pending", OrderDir: model.OrderDirectionEntry}
+	require.NoError(t, repo.Create(bg, alice))
+	require.NoError(t, repo.Create(bg, bob))
+
+	asAlice := auth.WithUserID(bg, 1)
+
+	// reads
+	got, err := repo.FindByID(asAlice, bob.ID)
+	require.NoError(t, err)
+	require.Nil(t, got)
+	got, err = repo.FindByID(asAlice, alice.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	got, err = repo.FindByExternalIDAndUserID(asAlice, 2, 20, model.OrderDirectionEntry)
+	require.NoError(t, err)
+	require.Nil(t, got)
+	latest, err := repo.FindLatest(asAlice, Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	require.Equal(t, alice.ID, latest[0].ID)
+	pending, err := repo.FindByStatus(asAlice, "pending", Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, alice.ID, pending[0].ID)
+
+	// writes
+	require.ErrorIs(t, repo.Create(asAlice, &model.Order{UserID: 2, OrderDir: model.OrderDirectionEntry}), ErrCrossTenant)
+	require.ErrorIs(t, repo.CreateWithAutoLog(asAlice, &model.Order{UserID: 2, OrderDir: model.OrderDirectionEntry}), ErrCrossTenant)
+	require.NoError(t, repo.UpdateStatus(asAlice, bob.ID, "cancelled"))
+	require.NoError(t, repo.UpdateStopLoss(asAlice, bob.ID, decimal.NewFromInt(9)))
+	require.NoError(t, repo.UpdateFee(asAlice, bob.ID, 3, "USDT", false))
+	err = repo.UpdateStatusWithAutoLog(asAlice, bob.ID, "cancelled", "hijack")
+	require.True(t, errors.Is(err, gorm.ErrRecordNotFound), "got %v", err)
+	require.ErrorIs(t, repo.CreateExecutionLog(asAlice, &model.OrderExecutionLog{OrderID: bob.ID}), ErrCrossTenant)
+
+	stored, err := repo.FindByID(bg, bob.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pending", stored.Status)
+	require.True(t, stored.StopLossPct.IsZero())
+	require.Zero(t, stored.Fee)
+
+	var logs int64
+	require.NoError(t, db.Model(&model.OrderLog{}).Where("order_id = ?", bob.ID).Count(&logs).Error)
+	require.Zero(t, logs)
+
+	// background work is not bound to a user
+	all, err := repo.FindLatest(bg, Pagination{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+}
+
+func TestUserExchangeRepositoryIsolatesKeys(t *testing.T) {
+	db := newScopeTestDB(t)
+	repo := &GormUserExchangeRepository{db: db}
+	bg := context.Background()
+
+	alice := &model.UserExchange{UserID: 1, ExchangeID: 1, APIKeyHash: "alice-key", RunOnServer: true}
+	bob := &model.UserExchange{UserID: 2, ExchangeID: 1, APIKeyHash: "bob-key", RunOnServer: true}
+	require.NoError(t, repo.Create(bg, alice))
+	require.NoError(t, repo.Create(bg, bob))
+
+	asAlice := auth.WithUserID(bg, 1)
+
+	_, err := repo.GetByUserAndExchange(asAlice, 2, 1)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	rows, err := repo.ListRunOnServer(asAlice)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, uint(1), rows[0].UserID)
+
+	// overwriting bob's keys, by owner field or by primary key
+	require.ErrorIs(t, repo.Upsert(asAlice, &model.UserExchange{UserID: 2, ExchangeID: 1, APIKeyHash: "stolen"}), ErrCrossTenant)
+	require.ErrorIs(t, repo.Create(asAlice, &model.UserExchange{UserID: 2, ExchangeID: 2}), ErrCrossTenant)
+	require.ErrorIs(t, repo.Update(asAlice, &model.UserExchange{ID: bob.ID, UserID: 1, ExchangeID: 1, APIKeyHash: "stolen"}), ErrCrossTenant)
+	require.NoError(t, repo.UpdateByUserAndExchange(asAlice, "2", 1, map[string]interface{}{"api_key": "stolen"}))
+	require.ErrorIs(t, repo.MarkNoTradeWindowOrdersClosed(asAlice, 2, 1), gorm.ErrRecordNotFound)
+	require.NoError(t, repo.RecordValidation(asAlice, &model.UserExchange{ID: bob.ID, RunOnServer: false}))
+
+	stored, err := repo.GetByUserAndExchange(bg, 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, "bob-key", stored.APIKeyHash)
+	require.True(t, stored.RunOnServer)
+	require.False(t, stored.NoTradeWindowOrdersClosed)
+
+	// alice can still manage her own row
+	alice.APIKeyHash = "alice-rotated"
+	require.NoError(t, repo.Update(asAlice, alice))
+	stored, err = repo.GetByUserAndExchange(asAlice, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, "alice-rotated", stored.APIKeyHash)
+}
+
+func TestOrderRepositoryResolveApproval(t *testing.T) {
+	db := newScopeTestDB(t)
+	repo := (&OrderRepository{}).WithDB(db)
+	bg := context.Background()
+
+	order := &model.Order{UserID: 1, ExchangeID: 1, ExternalID: 10, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusAwaitingApproval, OrderDir: model.OrderDirectionEntry}
+	require.NoError(t, repo.Create(bg, order))
+
+	ok, err := repo.ResolveApproval(auth.WithUserID(bg, 2), order.ID, model.OrderExecutionStatusApproved, "hijack")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = repo.ResolveApproval(auth.WithUserID(bg, 1), order.ID, model.OrderExecutionStatusApproved, "approved by user")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = repo.ResolveApproval(bg, order.ID, model.OrderExecutionStatusRejected, "approval timed out")
+	require.NoError(t, err)
+	require.False(t, ok, "a decided order is not decided again")
+
+	stored, err := repo.FindByID(bg, order.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.OrderExecutionStatusApproved, stored.Status)
+	require.Len(t, stored.Logs, 1)
+	require.Equal(t, "approved by user", stored.Logs[0].Reason)
+}
+
+func TestOrderRepositoryBracket(t *testing.T) {
+	db := newScopeTestDB(t)
+	repo := (&OrderRepository{}).WithDB(db)
+	bg := context.Background()
+
+	got, err := repo.FindLatestEntry(bg, 1, 1, "BTCUSDT")
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	older := &model.Order{UserID: 1, ExchangeID: 1, ExternalID: 10, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusFilled, OrderDir: model.OrderDirectionEntry}
+	entry := &model.Order{UserID: 1, ExchangeID: 1, ExternalID: 11, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusFilled, OrderDir: model.OrderDirectionEntry}
+	failed := &model.Order{UserID: 1, ExchangeID: 1, ExternalID: 12, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusError, OrderDir: model.OrderDirectionEntry}
+	exit := &model.Order{UserID: 1, ExchangeID: 1, ExternalID: 11, Symbol: "BTCUSDT", Status: model.OrderExecutionStatusFilled, OrderDir: model.OrderDirectionExit}
+	for _, o := range []*model.Order{older, entry, failed, exit} {
+		require.NoError(t, repo.Create(bg, o))
+	}
+
+	require.NoError(t, repo.UpdateBracket(auth.WithUserID(bg, 2), entry.ID, "sl-2", "tp-2"))
+	require.NoError(t, repo.UpdateBracket(bg, entry.ID, "sl-1", "tp-1"))
+
+	got, err = repo.FindLatestEntry(bg, 1, 1, "BTCUSDT")
+	require.NoError(t, err)
+	require.Equal(t, entry.ID, got.ID)
+	require.Equal(t, "sl-1", got.StopOrderID)
+	require.Equal(t, "tp-1", got.TakeProfitOrderID)
+
+	require.NoError(t, repo.UpdateBracket(bg, entry.ID, "", "tp-1"))
+	got, err = repo.FindLatestEntry(bg, 1, 1, "BTCUSDT")
+	require.NoError(t, err)
+	require.Empty(t, got.StopOrderID)
+}
@@ -0,0 +1,58 @@
+package risk
+
+import (
+	"strategyexecutor/src/model"
+	"time"
+)
+
+// ----- cooldown after consecutive losses -----
+
+// LossStreakConfig blocks new entries for Cooldown once MaxLosses trades in a
+// row closed with a loss.
+type LossStreakConfig struct {
+	MaxLosses int
+	Cooldown  time.Duration
+}
+
+// Enabled reports whether the rule is configured.
+func (c LossStreakConfig) Enabled() bool {
+	return c.MaxLosses > 0 && c.Cooldown > 0
+}
+
+type LossStreakState struct {
+	// Losses is the number of consecutive losing trades ending with the
+	// latest closed trade.
+	Losses      int
+	LastTradeID uint
+	LastLossAt  *time.Time
+	// CooldownUntil is set once Losses reaches MaxLosses; every further loss
+	// restarts the cooldown from its exit time.
+	CooldownUntil *time.Time
+}
+
+// EvaluateLossStreak counts the consecutive losses at the head of trades,
+// which must be closed trades ordered newest exit first. A trade without
+// PnL ends the streak like a winning one.
+func EvaluateLossStreak(trades []model.Trade, cfg LossStreakConfig) LossStreakState {
+	var state LossStreakState
+	if len(trades) > 0 {
+		state.LastTradeID = trades[0].ID
+	}
+
+	for _, t := range trades {
+		if t.PnL == nil || *t.PnL >= 0 || t.ExitTime == nil {
+			break
+		}
+		if state.Losses == 0 {
+			exit := *t.ExitTime
+			state.LastLossAt = &exit
+		}
+		state.Losses++
+	}
+
+	if cfg.Enabled() && state.Losses >= cfg.MaxLosses {
+		until := state.LastLossAt.Add(cfg.Cooldown)
+		state.CooldownUntil = &until
+	}
+	return state
+}
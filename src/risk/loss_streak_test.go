@@ -0,0 +1,60 @@
+package risk
+
+import (
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+func TestEvaluateLossStreak(t *testing.T) {
+	base := time.Date(2025, 3, 4, 14, 0, 0, 0, time.UTC)
+	trade := func(id uint, pnl float64, exitAfter time.Duration) model.Trade {
+		exit := base.Add(exitAfter)
+		return model.Trade{ID: id, PnL: &pnl, ExitTime: &exit, Status: model.TradeStatusClosed}
+	}
+	cfg := LossStreakConfig{MaxLosses: 3, Cooldown: 2 * time.Hour}
+
+	tests := []struct {
+		name         string
+		trades       []model.Trade
+		cfg          LossStreakConfig
+		wantLosses   int
+		wantCooldown *time.Time
+	}{
+		{name: "no trades", cfg: cfg},
+		{name: "latest trade won", trades: []model.Trade{trade(4, 5, 3*time.Hour), trade(3, -1, 2*time.Hour), trade(2, -1, time.Hour)}, cfg: cfg},
+		{name: "streak below limit", trades: []model.Trade{trade(4, -1, 3*time.Hour), trade(3, -1, 2*time.Hour), trade(2, 1, time.Hour)}, cfg: cfg, wantLosses: 2},
+		{
+			name:         "streak reaches limit",
+			trades:       []model.Trade{trade(4, -1, 3*time.Hour), trade(3, -2, 2*time.Hour), trade(2, -1, time.Hour), trade(1, 3, 0)},
+			cfg:          cfg,
+			wantLosses:   3,
+			wantCooldown: ptrTime(base.Add(5 * time.Hour)),
+		},
+		{
+			name:       "disabled rule still counts",
+			trades:     []model.Trade{trade(4, -1, 3*time.Hour), trade(3, -2, 2*time.Hour), trade(2, -1, time.Hour)},
+			wantLosses: 3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := EvaluateLossStreak(tc.trades, tc.cfg)
+			if got.Losses != tc.wantLosses {
+				t.Fatalf("expected %d losses, got %d", tc.wantLosses, got.Losses)
+			}
+			if (got.CooldownUntil == nil) != (tc.wantCooldown == nil) ||
+				(got.CooldownUntil != nil && !got.CooldownUntil.Equal(*tc.wantCooldown)) {
+				t.Fatalf("expected cooldown %v, got %v", tc.wantCooldown, got.CooldownUntil)
+			}
+			if len(tc.trades) > 0 && got.LastTradeID != tc.trades[0].ID {
+				t.Fatalf("expected last trade %d, got %d", tc.trades[0].ID, got.LastTradeID)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
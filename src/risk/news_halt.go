@@ -0,0 +1,89 @@
+package risk
+
+import (
+	"regexp"
+	"strategyexecutor/src/model"
+	"strings"
+	"time"
+)
+
+// ----- news driven halt -----
+
+// NewsHaltConfig pauses new entries for Window after a news event with at
+// least MinImportance matching one of Keywords was ingested.
+type NewsHaltConfig struct {
+	Keywords      []string
+	Window        time.Duration
+	MinImportance int
+}
+
+type NewsHaltDecision struct {
+	Halted bool
+	Reason string
+	Event  *model.TradingViewNewsEvent
+	Until  time.Time
+}
+
+// Enabled reports whether the rule has anything to match on.
+func (c NewsHaltConfig) Enabled() bool {
+	return c.Window > 0 && len(c.Keywords) > 0
+}
+
+// EvaluateNewsHalt checks events (already scoped to the symbol) ingested in
+// [now-Window, now] against the configured keywords. When several events
+// match, the one ingested last decides how long the halt lasts.
+func EvaluateNewsHalt(now time.Time, events []model.TradingViewNewsEvent, cfg NewsHaltConfig) NewsHaltDecision {
+	if !cfg.Enabled() {
+		return NewsHaltDecision{Reason: "news_halt_disabled"}
+	}
+
+	matcher := keywordMatcher(cfg.Keywords)
+	if matcher == nil {
+		return NewsHaltDecision{Reason: "news_halt_disabled"}
+	}
+
+	var latest *model.TradingViewNewsEvent
+	for i := range events {
+		ev := &events[i]
+		if ev.Importance < cfg.MinImportance {
+			continue
+		}
+		if ev.CreatedAt.Before(now.Add(-cfg.Window)) || ev.CreatedAt.After(now) {
+			continue
+		}
+		text := strings.Join([]string{ev.Title, ev.Indicator, ev.Comment, ev.Ticker}, " ")
+		if !matcher.MatchString(text) {
+			continue
+		}
+		if latest == nil || ev.CreatedAt.After(latest.CreatedAt) {
+			latest = ev
+		}
+	}
+
+	if latest == nil {
+		return NewsHaltDecision{Reason: "allowed"}
+	}
+
+	return NewsHaltDecision{
+		Halted: true,
+		Reason: "halted_by_news_keyword",
+		Event:  latest,
+		Until:  latest.CreatedAt.Add(cfg.Window),
+	}
+}
+
+// keywordMatcher builds a case-insensitive whole-word matcher for keywords.
+func keywordMatcher(keywords []string) *regexp.Regexp {
+	var parts []string
+	for _, k := range keywords {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		parts = append(parts, regexp.QuoteMeta(k))
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(parts, "|") + `)\b`)
+}
@@ -0,0 +1,64 @@
+package risk
+
+import (
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+func TestEvaluateNewsHalt(t *testing.T) {
+	now := time.Date(2025, 3, 4, 14, 0, 0, 0, time.UTC)
+	cfg := NewsHaltConfig{Keywords: []string{"FOMC", "rate decision"}, Window: 30 * time.Minute, MinImportance: 1}
+
+	events := []model.TradingViewNewsEvent{
+		{TVEventID: "old", Title: "FOMC Statement", Importance: 1, CreatedAt: now.Add(-45 * time.Minute)},
+		{TVEventID: "low", Title: "FOMC member speaks", Importance: 0, CreatedAt: now.Add(-5 * time.Minute)},
+		{TVEventID: "other", Title: "Crude Oil Inventories", Importance: 1, CreatedAt: now.Add(-5 * time.Minute)},
+	}
+
+	tests := []struct {
+		name       string
+		events     []model.TradingViewNewsEvent
+		cfg        NewsHaltConfig
+		wantHalted bool
+		wantID     string
+	}{
+		{name: "no matching recent events", events: events, cfg: cfg, wantHalted: false},
+		{
+			name:       "recent keyword match",
+			events:     append(events, model.TradingViewNewsEvent{TVEventID: "hit", Title: "Fed Interest Rate Decision", Importance: 1, CreatedAt: now.Add(-10 * time.Minute)}),
+			cfg:        cfg,
+			wantHalted: true,
+			wantID:     "hit",
+		},
+		{
+			name:       "partial word does not match",
+			events:     []model.TradingViewNewsEvent{{Title: "FOMCX", Importance: 1, CreatedAt: now}},
+			cfg:        cfg,
+			wantHalted: false,
+		},
+		{
+			name:       "disabled without keywords",
+			events:     []model.TradingViewNewsEvent{{Title: "FOMC", Importance: 1, CreatedAt: now}},
+			cfg:        NewsHaltConfig{Window: 30 * time.Minute},
+			wantHalted: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := EvaluateNewsHalt(now, tc.events, tc.cfg)
+			if d.Halted != tc.wantHalted {
+				t.Fatalf("Halted = %v, want %v (reason %s)", d.Halted, tc.wantHalted, d.Reason)
+			}
+			if tc.wantHalted {
+				if d.Event == nil || d.Event.TVEventID != tc.wantID {
+					t.Fatalf("unexpected halting event: %+v", d.Event)
+				}
+				if !d.Until.Equal(d.Event.CreatedAt.Add(tc.cfg.Window)) {
+					t.Fatalf("unexpected Until: %v", d.Until)
+				}
+			}
+		})
+	}
+}
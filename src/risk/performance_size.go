@@ -0,0 +1,97 @@
+package risk
+
+import (
+	"strategyexecutor/src/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// ----- anti-martingale sizing after wins and losses -----
+
+var (
+	defaultPerformanceSizeMin = decimal.NewFromFloat(0.5)
+	defaultPerformanceSizeMax = decimal.NewFromInt(2)
+)
+
+// PerformanceSizeConfig scales entries with the recent results on a symbol:
+// Step more per consecutive win, counting at most WinWindow wins, and Step
+// less per consecutive loss, counting at most LossWindow losses. The factor
+// stays within Min and Max.
+type PerformanceSizeConfig struct {
+	Step       decimal.Decimal
+	WinWindow  int
+	LossWindow int
+	Min        decimal.Decimal
+	Max        decimal.Decimal
+}
+
+// NewPerformanceSizeConfigFromUserExchange reads the performance sizing of
+// ux, with the default bounds where it sets none.
+func NewPerformanceSizeConfigFromUserExchange(ux *model.UserExchange) PerformanceSizeConfig {
+	cfg := PerformanceSizeConfig{Min: defaultPerformanceSizeMin, Max: defaultPerformanceSizeMax}
+	if ux == nil {
+		return cfg
+	}
+	cfg.Step = ux.PerfSizeStep
+	cfg.WinWindow = ux.PerfSizeWinWindow
+	cfg.LossWindow = ux.PerfSizeLossWindow
+	if ux.PerfSizeMin.IsPositive() {
+		cfg.Min = ux.PerfSizeMin
+	}
+	if ux.PerfSizeMax.IsPositive() {
+		cfg.Max = ux.PerfSizeMax
+	}
+	return cfg
+}
+
+// Enabled reports whether the sizing is configured.
+func (c PerformanceSizeConfig) Enabled() bool {
+	return c.Step.IsPositive() && (c.WinWindow > 0 || c.LossWindow > 0)
+}
+
+// Lookback is the number of closed trades the factor may depend on.
+func (c PerformanceSizeConfig) Lookback() int {
+	return max(c.WinWindow, c.LossWindow)
+}
+
+// PerformanceSizeFactor returns the size factor after trades, which must be
+// closed trades ordered newest exit first, and the streak it is based on:
+// the number of consecutive wins, or of losses as a negative number, at the
+// head of trades. A trade without PnL or breaking even ends the streak.
+func PerformanceSizeFactor(trades []model.Trade, cfg PerformanceSizeConfig) (decimal.Decimal, int) {
+	one := decimal.NewFromInt(1)
+	if !cfg.Enabled() {
+		return one, 0
+	}
+
+	streak := 0
+	for _, t := range trades {
+		if t.PnL == nil || *t.PnL == 0 {
+			break
+		}
+		win := *t.PnL > 0
+		if (win && streak < 0) || (!win && streak > 0) {
+			break
+		}
+		if win {
+			streak++
+		} else {
+			streak--
+		}
+	}
+
+	counted := 0
+	if streak > 0 {
+		counted = min(streak, cfg.WinWindow)
+	} else if streak < 0 {
+		counted = -min(-streak, cfg.LossWindow)
+	}
+	factor := one.Add(cfg.Step.Mul(decimal.NewFromInt(int64(counted))))
+	if factor.LessThan(cfg.Min) {
+		factor = cfg.Min
+	}
+	if cfg.Max.GreaterThanOrEqual(cfg.Min) && factor.GreaterThan(cfg.Max) {
+		factor = cfg.Max
+	}
+	return factor, streak
+}
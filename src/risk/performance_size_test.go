@@ -0,0 +1,62 @@
+package risk
+
+import (
+	"strategyexecutor/src/model"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerformanceSizeFactor(t *testing.T) {
+	closed := func(pnls ...float64) []model.Trade {
+		trades := make([]model.Trade, 0, len(pnls))
+		for i, pnl := range pnls {
+			trades = append(trades, model.Trade{ID: uint(i + 1), PnL: &pnl})
+		}
+		return trades
+	}
+	cfg := PerformanceSizeConfig{
+		Step:       decimal.NewFromFloat(0.25),
+		WinWindow:  3,
+		LossWindow: 2,
+		Min:        decimal.NewFromFloat(0.5),
+		Max:        decimal.NewFromFloat(1.5),
+	}
+
+	tests := []struct {
+		name   string
+		trades []model.Trade
+		cfg    PerformanceSizeConfig
+		want   string
+		streak int
+	}{
+		{name: "no trades", cfg: cfg, want: "1", streak: 0},
+		{name: "two wins", trades: closed(10, 5, -3), cfg: cfg, want: "1.5", streak: 2},
+		{name: "wins beyond the window", trades: closed(10, 5, 4, 2, 1), cfg: PerformanceSizeConfig{Step: cfg.Step, WinWindow: 3, Min: cfg.Min, Max: decimal.NewFromInt(3)}, want: "1.75", streak: 5},
+		{name: "capped at max", trades: closed(10, 5, 4), cfg: cfg, want: "1.5", streak: 3},
+		{name: "one loss", trades: closed(-10, 5), cfg: cfg, want: "0.75", streak: -1},
+		{name: "losses beyond the window", trades: closed(-10, -5, -4, -1), cfg: cfg, want: "0.5", streak: -4},
+		{name: "break even ends the streak", trades: closed(0, 5, 5), cfg: cfg, want: "1", streak: 0},
+		{name: "losses not counted", trades: closed(-10, -5), cfg: PerformanceSizeConfig{Step: cfg.Step, WinWindow: 3, Min: cfg.Min, Max: cfg.Max}, want: "1", streak: -2},
+		{name: "disabled", trades: closed(10, 5), cfg: PerformanceSizeConfig{WinWindow: 3}, want: "1", streak: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			factor, streak := PerformanceSizeFactor(tc.trades, tc.cfg)
+			require.Equal(t, tc.want, factor.String())
+			require.Equal(t, tc.streak, streak)
+		})
+	}
+}
+
+func TestNewPerformanceSizeConfigFromUserExchange(t *testing.T) {
+	cfg := NewPerformanceSizeConfigFromUserExchange(&model.UserExchange{PerfSizeStep: decimal.NewFromFloat(0.1), PerfSizeWinWindow: 2, PerfSizeMax: decimal.NewFromFloat(1.2)})
+	require.True(t, cfg.Enabled())
+	require.Equal(t, "0.5", cfg.Min.String())
+	require.Equal(t, "1.2", cfg.Max.String())
+	require.Equal(t, 2, cfg.Lookback())
+
+	require.False(t, NewPerformanceSizeConfigFromUserExchange(nil).Enabled())
+}
@@ -3,10 +3,27 @@ package risk
 import (
 	"strategyexecutor/src/model"
 	"time"
+	// session boundaries must not depend on the zoneinfo of the host
+	_ "time/tzdata"
 
 	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
 )
 
+// DefaultSessionTimezone is the zone session boundaries are evaluated in
+// unless the account configures another one.
+const DefaultSessionTimezone = "America/New_York"
+
+var newYork = mustLoadLocation(DefaultSessionTimezone)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
 // ----- session labels -----
 
 type Session string
@@ -37,6 +54,10 @@ type SessionSizeConfig struct {
 	DefaultMultiplier        decimal.Decimal
 
 	EnableNoTradeWindow bool
+
+	// Location is the zone the session hours, the no trade window and the
+	// holidays are evaluated in, DST included. Nil means America/New_York.
+	Location *time.Location
 }
 
 // NewSessionSizeConfigFromUserExchangeOrDefault builds a SessionSizeConfig starting from the
@@ -70,6 +91,18 @@ func NewSessionSizeConfigFromUserExchangeOrDefault(ux *model.UserExchange) *Sess
 	// If you want "unset" semantics, add e.g. EnableNoTradeWindowSet bool or use *bool.
 	cfg.EnableNoTradeWindow = ux.EnableNoTradeWindow
 
+	if ux.SessionTimezone != "" {
+		loc, err := time.LoadLocation(ux.SessionTimezone)
+		if err != nil {
+			logger.WithError(err).
+				WithField("user_exchange_id", ux.ID).
+				WithField("timezone", ux.SessionTimezone).
+				Warn("invalid session timezone, using " + DefaultSessionTimezone)
+		} else {
+			cfg.Location = loc
+		}
+	}
+
 	return cfg
 }
 
@@ -83,6 +116,7 @@ func DefaultSessionSizeConfig() *SessionSizeConfig {
 		USMultiplier:             decimal.NewFromFloat(1.25),
 		DefaultMultiplier:        decimal.NewFromFloat(0.15),
 		EnableNoTradeWindow:      true,
+		Location:                 newYork,
 	}
 }
 
@@ -90,6 +124,7 @@ func DefaultSessionSizeConfig() *SessionSizeConfig {
 
 // CalculateSizeByNYSession baseSize. nominal size you want to trade (e.g. 0.001 BTC). now. current time, usually time.Now(). cfg. multipliers and flags.
 // returns finalSize (possibly zero in no trade window) and the detected session.
+// Sessions are evaluated in cfg.Location, New York unless configured.
 func CalculateSizeByNYSession(
 	baseSize decimal.Decimal,
 	now time.Time,
@@ -102,9 +137,9 @@ func CalculateSizeByNYSession(
 		cfg = DefaultSessionSizeConfig()
 	}
 
-	et := getEasternTime(now)
+	et := now.In(cfg.location())
 
-	// no trade window, NY based, derived from "Friday after UK session until Sunday begin UK session"
+	// no trade window, derived from "Friday after UK session until Sunday begin UK session"
 	if cfg.EnableNoTradeWindow && isNoTradeWindowNY(et) {
 		return decimal.Zero, SessionNoTrade
 	}
@@ -117,12 +152,11 @@ func CalculateSizeByNYSession(
 
 // ----- helpers, using your original logic -----
 
-func getEasternTime(t time.Time) time.Time {
-	nyLocation, err := time.LoadLocation("America/New_York")
-	if err != nil {
-		return t.UTC()
+func (cfg *SessionSizeConfig) location() *time.Location {
+	if cfg.Location == nil {
+		return newYork
 	}
-	return t.In(nyLocation)
+	return cfg.Location
 }
 
 // isNoTradeWindowNY "Friday after UK session and end Sunday begin UK session"
@@ -1,6 +1,7 @@
 package risk
 
 import (
+	"strategyexecutor/src/model"
 	"testing"
 	"time"
 
@@ -230,3 +231,60 @@ func TestCalculateSizeSimple(t *testing.T) {
 		t.Fatalf("size mismatch. got=%s want=%s", gotSize.String(), wantSize.String())
 	}
 }
+
+func TestCalculateSizeByNYSession_DSTTransitions(t *testing.T) {
+	baseSize := decimal.NewFromInt(1)
+	utc := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2025, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		at          time.Time
+		wantSession Session
+	}{
+		// US clocks spring forward on Sunday 2025-03-09 at 02:00 EST (07:00 UTC).
+		{name: "07:30 UTC is 02:30 EST before spring forward", at: utc(time.March, 7, 7, 30), wantSession: SessionAsia},
+		{name: "07:30 UTC is 03:30 EDT after spring forward", at: utc(time.March, 10, 7, 30), wantSession: SessionLondon},
+		{name: "no trade until the last EST minute", at: utc(time.March, 9, 6, 59), wantSession: SessionNoTrade},
+		{name: "window ends when clocks jump to 03:00 EDT", at: utc(time.March, 9, 7, 0), wantSession: SessionLondon},
+		// US clocks fall back on Sunday 2025-11-02 at 02:00 EDT (06:00 UTC).
+		{name: "01:30 EDT before fall back", at: utc(time.November, 2, 5, 30), wantSession: SessionNoTrade},
+		{name: "02:30 EST after fall back", at: utc(time.November, 2, 7, 30), wantSession: SessionNoTrade},
+		{name: "window ends at 03:00 EST", at: utc(time.November, 2, 8, 0), wantSession: SessionLondon},
+		{name: "07:30 UTC is 03:30 EDT the week before", at: utc(time.October, 27, 7, 30), wantSession: SessionLondon},
+		{name: "07:30 UTC is 02:30 EST after fall back", at: utc(time.November, 3, 7, 30), wantSession: SessionAsia},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, gotSession := CalculateSizeByNYSession(baseSize, tt.at, DefaultSessionSizeConfig())
+			if gotSession != tt.wantSession {
+				t.Fatalf("session mismatch. got=%s want=%s", gotSession, tt.wantSession)
+			}
+		})
+	}
+}
+
+func TestCalculateSizeByNYSession_UserTimezone(t *testing.T) {
+	baseSize := decimal.NewFromInt(1)
+	cfg := NewSessionSizeConfigFromUserExchangeOrDefault(&model.UserExchange{SessionTimezone: "Europe/London"})
+	if cfg.Location.String() != "Europe/London" {
+		t.Fatalf("expected the user timezone, got %s", cfg.Location)
+	}
+
+	// UK clocks spring forward on 2025-03-30, three weeks after the US.
+	_, gotSession := CalculateSizeByNYSession(baseSize, time.Date(2025, time.March, 25, 2, 30, 0, 0, time.UTC), cfg)
+	if gotSession != SessionAsia {
+		t.Fatalf("02:30 GMT: got=%s want=%s", gotSession, SessionAsia)
+	}
+	_, gotSession = CalculateSizeByNYSession(baseSize, time.Date(2025, time.April, 1, 2, 30, 0, 0, time.UTC), cfg)
+	if gotSession != SessionLondon {
+		t.Fatalf("03:30 BST: got=%s want=%s", gotSession, SessionLondon)
+	}
+
+	cfg = NewSessionSizeConfigFromUserExchangeOrDefault(&model.UserExchange{SessionTimezone: "Mars/Olympus"})
+	if cfg.Location.String() != DefaultSessionTimezone {
+		t.Fatalf("expected an invalid timezone to fall back to New York, got %s", cfg.Location)
+	}
+}
@@ -2,12 +2,27 @@ package security
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 )
 
 type Config struct {
 	ExchangeCRKey string `envconfig:"EXCHANGE_CREDENTIALS_KEY" default:"Pjk+k4hske5KkKtbaKSVDOgpllRl+0EI6oCAdx88XqI="`
+
+	// Secrets backends referenced by UserExchange.SecretBackend. A backend is
+	// only available when its address / region is set.
+	VaultAddr  string `envconfig:"VAULT_ADDR"`
+	VaultToken string `envconfig:"VAULT_TOKEN"`
+	VaultMount string `envconfig:"VAULT_KV_MOUNT" default:"secret"`
+
+	AWSRegion          string `envconfig:"AWS_REGION"`
+	AWSAccessKeyID     string `envconfig:"AWS_ACCESS_KEY_ID"`
+	AWSSecretAccessKey string `envconfig:"AWS_SECRET_ACCESS_KEY"`
+	AWSSessionToken    string `envconfig:"AWS_SESSION_TOKEN"`
+
+	SecretsCacheTTL time.Duration `envconfig:"SECRETS_CACHE_TTL" default:"15m"`
+	SecretsTimeout  time.Duration `envconfig:"SECRETS_TIMEOUT" default:"10s"`
 }
 
 func GetConfig() Config {
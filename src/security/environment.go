@@ -0,0 +1,25 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+)
+
+// ErrTestnetOnly is returned when a testnet-only user would use a live
+// exchange account.
+var ErrTestnetOnly = errors.New("user is testnet-only")
+
+// AccountEnvironment returns the environment ue trades in. A live account of
+// a testnet-only user is refused so its connectors are never built.
+func AccountEnvironment(user *model.User, ue *model.UserExchange) (string, error) {
+	env, err := connectors.NormalizeEnvironment(ue.Environment)
+	if err != nil {
+		return "", err
+	}
+	if user != nil && user.TestnetOnly && env != connectors.EnvironmentTestnet {
+		return "", fmt.Errorf("user %d exchange %d is %s: %w", ue.UserID, ue.ExchangeID, env, ErrTestnetOnly)
+	}
+	return env, nil
+}
@@ -0,0 +1,226 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strategyexecutor/src/model"
+	"sync"
+	"time"
+)
+
+const (
+	SecretBackendVault = "vault"
+	SecretBackendAWS   = "aws"
+)
+
+// Credentials are the decrypted exchange credentials of a UserExchange.
+type Credentials struct {
+	APIKey        string `json:"api_key"`
+	APISecret     string `json:"api_secret"`
+	APIPassphrase string `json:"api_passphrase"`
+	// Environment is the environment of the account the credentials belong
+	// to, set by ResolveCredentials. It is never read from a secret.
+	Environment string `json:"-"`
+	// KeyVersion is the detected KuCoin key version of the account, set by
+	// ResolveCredentials, see model.UserExchange.KeyVersion.
+	KeyVersion string `json:"-"`
+}
+
+// KucoinKeyVersion returns the detected KuCoin key version of the
+// credentials, fallback when none was detected yet.
+func (c Credentials) KucoinKeyVersion(fallback string) string {
+	if c.KeyVersion != "" {
+		return c.KeyVersion
+	}
+	return fallback
+}
+
+// SecretsBackend fetches credentials stored outside Postgres. The secret at
+// path must hold the api_key / api_secret (/ api_passphrase) fields.
+type SecretsBackend interface {
+	Name() string
+	Fetch(ctx context.Context, path string) (Credentials, error)
+}
+
+var ErrSecretsBackendNotConfigured = errors.New("secrets backend not configured")
+
+var (
+	backendsMu sync.Mutex
+	backends   map[string]SecretsBackend
+	cache      = newSecretsCache()
+)
+
+// SetSecretsBackend registers backend under name, replacing the one built
+// from the environment. It returns a function restoring the previous one.
+func SetSecretsBackend(name string, backend SecretsBackend) (restore func()) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	loadBackendsLocked()
+	previous, had := backends[name]
+	backends[name] = backend
+	cache.reset()
+
+	return func() {
+		backendsMu.Lock()
+		defer backendsMu.Unlock()
+		if had {
+			backends[name] = previous
+		} else {
+			delete(backends, name)
+		}
+		cache.reset()
+	}
+}
+
+func backendFor(name string) (SecretsBackend, error) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	loadBackendsLocked()
+	backend, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrSecretsBackendNotConfigured, name)
+	}
+	return backend, nil
+}
+
+func loadBackendsLocked() {
+	if backends != nil {
+		return
+	}
+	config := GetConfig()
+	client := &http.Client{Timeout: config.SecretsTimeout}
+
+	backends = map[string]SecretsBackend{}
+	if config.VaultAddr != "" {
+		backends[SecretBackendVault] = NewVaultBackend(config.VaultAddr, config.VaultToken, config.VaultMount, client)
+	}
+	if config.AWSRegion != "" {
+		backends[SecretBackendAWS] = NewAWSSecretsBackend(config.AWSRegion, AWSCredentials{
+			AccessKeyID:     config.AWSAccessKeyID,
+			SecretAccessKey: config.AWSSecretAccessKey,
+			SessionToken:    config.AWSSessionToken,
+		}, client)
+	}
+	cache.setTTL(config.SecretsCacheTTL)
+}
+
+// ResolveCredentials returns the exchange credentials of ue. When
+// ue.SecretPath is set they are fetched from ue.SecretBackend and cached for
+// SECRETS_CACHE_TTL; otherwise the ciphertext columns are decrypted. The
+// environment and key version of ue are copied onto the credentials.
+func ResolveCredentials(ctx context.Context, ue *model.UserExchange) (Credentials, error) {
+	creds, err := resolveCredentials(ctx, ue)
+	if err != nil {
+		return Credentials{}, err
+	}
+	creds.Environment = ue.Environment
+	creds.KeyVersion = ue.KeyVersion
+	return creds, nil
+}
+
+func resolveCredentials(ctx context.Context, ue *model.UserExchange) (Credentials, error) {
+	if ue.SecretPath == "" {
+		return decryptColumns(ue)
+	}
+
+	backend, err := backendFor(ue.SecretBackend)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	creds, err := cache.fetch(ctx, backend, ue.SecretPath)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("fetch %s secret %q: %w", backend.Name(), ue.SecretPath, err)
+	}
+	if creds.APIKey == "" || creds.APISecret == "" {
+		return Credentials{}, fmt.Errorf("%s secret %q has no api_key/api_secret", backend.Name(), ue.SecretPath)
+	}
+	return creds, nil
+}
+
+func decryptColumns(ue *model.UserExchange) (Credentials, error) {
+	if ue.APIKeyHash == "" || ue.APISecretHash == "" {
+		return Credentials{}, fmt.Errorf("no valid key/secret set for user %d", ue.UserID)
+	}
+
+	var creds Credentials
+	var err error
+	if creds.APIKey, err = DecryptString(ue.APIKeyHash); err != nil {
+		return Credentials{}, fmt.Errorf("decrypt api key: %w", err)
+	}
+	if creds.APISecret, err = DecryptString(ue.APISecretHash); err != nil {
+		return Credentials{}, fmt.Errorf("decrypt api secret: %w", err)
+	}
+	if ue.APIPassphraseHash != "" {
+		if creds.APIPassphrase, err = DecryptString(ue.APIPassphraseHash); err != nil {
+			return Credentials{}, fmt.Errorf("decrypt api passphrase: %w", err)
+		}
+	}
+	return creds, nil
+}
+
+// decodeCredentials reads the credential fields from a JSON secret payload.
+func decodeCredentials(raw []byte) (Credentials, error) {
+	var creds Credentials
+	if err := json.Unmarshal(raw, &creds); err != nil {
+		return Credentials{}, fmt.Errorf("decode secret: %w", err)
+	}
+	return creds, nil
+}
+
+// ----- cache -----
+
+type cachedSecret struct {
+	creds     Credentials
+	fetchedAt time.Time
+}
+
+type secretsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedSecret
+	now     func() time.Time
+}
+
+func newSecretsCache() *secretsCache {
+	return &secretsCache{entries: map[string]cachedSecret{}, now: time.Now}
+}
+
+func (c *secretsCache) fetch(ctx context.Context, backend SecretsBackend, path string) (Credentials, error) {
+	key := backend.Name() + ":" + path
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	fresh := ok && c.now().Sub(entry.fetchedAt) < c.ttl
+	c.mu.Unlock()
+	if fresh {
+		return entry.creds, nil
+	}
+
+	creds, err := backend.Fetch(ctx, path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cachedSecret{creds: creds, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return creds, nil
+}
+
+func (c *secretsCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
+
+func (c *secretsCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]cachedSecret{}
+}
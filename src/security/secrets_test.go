@@ -0,0 +1,180 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVaultBackendFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/data/exchanges/phemex/7" || r.Header.Get("X-Vault-Token") != "tok" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"api_key":"k","api_secret":"s"},"metadata":{"version":3}}}`))
+	}))
+	defer server.Close()
+
+	creds, err := NewVaultBackend(server.URL+"/", "tok", "/kv/", server.Client()).Fetch(context.Background(), "exchanges/phemex/7")
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if creds.APIKey != "k" || creds.APISecret != "s" {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+
+	if _, err := NewVaultBackend(server.URL, "bad", "kv", server.Client()).Fetch(context.Background(), "exchanges/phemex/7"); err == nil {
+		t.Fatalf("expected error for rejected token")
+	}
+}
+
+func TestAWSSecretsBackendFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/20250304/eu-west-1/secretsmanager/aws4_request") ||
+			r.Header.Get("X-Amz-Security-Token") != "session" ||
+			body["SecretId"] != "prod/phemex/7" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"api_key":"k","api_secret":"s","api_passphrase":"p"}`})
+	}))
+	defer server.Close()
+
+	backend := NewAWSSecretsBackend("eu-west-1", AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"}, server.Client())
+	backend.endpoint = server.URL
+	backend.now = func() time.Time { return time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC) }
+
+	creds, err := backend.Fetch(context.Background(), "prod/phemex/7")
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if creds != (Credentials{APIKey: "k", APISecret: "s", APIPassphrase: "p"}) {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+}
+
+// TestSignV4 checks the signer against the get-vanilla case of the AWS
+// Signature Version 4 test suite.
+func TestSignV4(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+	signV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("unexpected Authorization header:\n got %s\nwant %s", got, want)
+	}
+}
+
+type countingBackend struct {
+	calls int
+	creds Credentials
+	err   error
+}
+
+func (c *countingBackend) Name() string { return "counting" }
+
+func (c *countingBackend) Fetch(context.Context, string) (Credentials, error) {
+	c.calls++
+	return c.creds, c.err
+}
+
+func TestResolveCredentials(t *testing.T) {
+	backend := &countingBackend{creds: Credentials{APIKey: "k", APISecret: "s"}}
+	restore := SetSecretsBackend(SecretBackendVault, backend)
+	defer restore()
+
+	now := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	cache.setTTL(time.Minute)
+	defer func() { cache.now = time.Now }()
+
+	ue := &model.UserExchange{UserID: 7, SecretBackend: SecretBackendVault, SecretPath: "exchanges/7"}
+	for i := 0; i < 2; i++ {
+		creds, err := ResolveCredentials(context.Background(), ue)
+		if err != nil {
+			t.Fatalf("ResolveCredentials error: %v", err)
+		}
+		if creds.APIKey != "k" {
+			t.Fatalf("unexpected credentials: %+v", creds)
+		}
+	}
+	if backend.calls != 1 {
+		t.Fatalf("expected the second call to hit the cache, got %d fetches", backend.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := ResolveCredentials(context.Background(), ue); err != nil {
+		t.Fatalf("ResolveCredentials error: %v", err)
+	}
+	if backend.calls != 2 {
+		t.Fatalf("expected an expired entry to be fetched again, got %d fetches", backend.calls)
+	}
+
+	// Unknown backends and incomplete secrets are rejected.
+	if _, err := ResolveCredentials(context.Background(), &model.UserExchange{SecretBackend: "nope", SecretPath: "x"}); !errors.Is(err, ErrSecretsBackendNotConfigured) {
+		t.Fatalf("expected ErrSecretsBackendNotConfigured, got %v", err)
+	}
+	backend.creds = Credentials{APIKey: "k"}
+	if _, err := ResolveCredentials(context.Background(), &model.UserExchange{SecretBackend: SecretBackendVault, SecretPath: "other"}); err == nil {
+		t.Fatalf("expected error for a secret without api_secret")
+	}
+}
+
+func TestResolveCredentialsFromColumns(t *testing.T) {
+	key, err := EncryptString("k")
+	if err != nil {
+		t.Fatalf("EncryptString error: %v", err)
+	}
+	secret, err := EncryptString("s")
+	if err != nil {
+		t.Fatalf("EncryptString error: %v", err)
+	}
+
+	creds, err := ResolveCredentials(context.Background(), &model.UserExchange{APIKeyHash: key, APISecretHash: secret})
+	if err != nil {
+		t.Fatalf("ResolveCredentials error: %v", err)
+	}
+	if creds.APIKey != "k" || creds.APISecret != "s" || creds.APIPassphrase != "" {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+
+	if _, err := ResolveCredentials(context.Background(), &model.UserExchange{UserID: 1}); err == nil {
+		t.Fatalf("expected error without credentials")
+	}
+}
+
+func TestAccountEnvironment(t *testing.T) {
+	env, err := AccountEnvironment(&model.User{ID: 1}, &model.UserExchange{UserID: 1})
+	if err != nil || env != "live" {
+		t.Fatalf("expected live for an unset environment, got %q, %v", env, err)
+	}
+
+	testnetOnly := &model.User{ID: 1, TestnetOnly: true}
+	if _, err := AccountEnvironment(testnetOnly, &model.UserExchange{UserID: 1, Environment: "live"}); !errors.Is(err, ErrTestnetOnly) {
+		t.Fatalf("expected ErrTestnetOnly, got %v", err)
+	}
+	env, err = AccountEnvironment(testnetOnly, &model.UserExchange{UserID: 1, Environment: "testnet"})
+	if err != nil || env != "testnet" {
+		t.Fatalf("expected testnet, got %q, %v", env, err)
+	}
+
+	key, _ := EncryptString("k")
+	secret, _ := EncryptString("s")
+	creds, err := ResolveCredentials(context.Background(), &model.UserExchange{APIKeyHash: key, APISecretHash: secret, Environment: "testnet"})
+	if err != nil || creds.Environment != "testnet" {
+		t.Fatalf("expected the environment on the credentials, got %+v, %v", creds, err)
+	}
+}
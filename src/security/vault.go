@@ -0,0 +1,66 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// VaultBackend reads credentials from a HashiCorp Vault KV v2 mount.
+type VaultBackend struct {
+	addr   string
+	token  string
+	mount  string
+	client *http.Client
+}
+
+func NewVaultBackend(addr, token, mount string, client *http.Client) *VaultBackend {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &VaultBackend{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		mount:  strings.Trim(mount, "/"),
+		client: client,
+	}
+}
+
+func (v *VaultBackend) Name() string { return SecretBackendVault }
+
+// Fetch reads the latest version of the secret at path under the KV mount.
+func (v *VaultBackend) Fetch(ctx context.Context, path string) (Credentials, error) {
+	url := fmt.Sprintf("%s/v1/%s/data/%s", v.addr, v.mount, strings.TrimLeft(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Credentials{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Credentials{}, fmt.Errorf("vault HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Data struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return Credentials{}, fmt.Errorf("decode vault response: %w", err)
+	}
+	return decodeCredentials(payload.Data.Data)
+}
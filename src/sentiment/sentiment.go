@@ -0,0 +1,114 @@
+package sentiment
+
+import (
+	"context"
+	"regexp"
+	"strategyexecutor/src/model"
+	"strings"
+)
+
+// Scorer assigns a sentiment score in [-1, 1] to a news event. Negative is
+// risk-off / bearish, positive is risk-on / bullish.
+//
+// The context and error allow remote scorers (LLM, classifier service) to be
+// plugged in later without changing the callers.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, ev model.TradingViewNewsEvent) (float64, error)
+}
+
+// Rule adds Weight to the score every time Pattern matches the event text.
+type Rule struct {
+	Pattern *regexp.Regexp
+	Weight  float64
+}
+
+// RuleScorer is a keyword/regex based Scorer.
+type RuleScorer struct {
+	rules []Rule
+}
+
+const RuleScorerName = "rules_v1"
+
+func NewRuleScorer(rules []Rule) *RuleScorer {
+	return &RuleScorer{rules: rules}
+}
+
+// DefaultRules are conservative macro / crypto keywords. Phrases are matched
+// case-insensitively on title, indicator and comment.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Pattern: regexp.MustCompile(`(?i)\brate cuts?\b`), Weight: 0.6},
+		{Pattern: regexp.MustCompile(`(?i)\bdovish\b`), Weight: 0.5},
+		{Pattern: regexp.MustCompile(`(?i)\b(easing|stimulus)\b`), Weight: 0.4},
+		{Pattern: regexp.MustCompile(`(?i)\b(etf approv(al|ed)|inflows?)\b`), Weight: 0.5},
+		{Pattern: regexp.MustCompile(`(?i)\b(better than expected|beats? expectations?)\b`), Weight: 0.3},
+		{Pattern: regexp.MustCompile(`(?i)\brate hikes?\b`), Weight: -0.6},
+		{Pattern: regexp.MustCompile(`(?i)\bhawkish\b`), Weight: -0.5},
+		{Pattern: regexp.MustCompile(`(?i)\b(tightening|recession|default)\b`), Weight: -0.4},
+		{Pattern: regexp.MustCompile(`(?i)\b(hack(ed)?|exploit|bans?|lawsuit|outflows?)\b`), Weight: -0.5},
+		{Pattern: regexp.MustCompile(`(?i)\b(worse than expected|miss(es|ed)? expectations?)\b`), Weight: -0.3},
+	}
+}
+
+func (s *RuleScorer) Name() string {
+	return RuleScorerName
+}
+
+func (s *RuleScorer) Score(_ context.Context, ev model.TradingViewNewsEvent) (float64, error) {
+	text := strings.Join([]string{ev.Title, ev.Indicator, ev.Comment}, " ")
+
+	score := 0.0
+	for _, r := range s.rules {
+		score += float64(len(r.Pattern.FindAllStringIndex(text, -1))) * r.Weight
+	}
+
+	return clamp(score), nil
+}
+
+func clamp(v float64) float64 {
+	if v > 1 {
+		return 1
+	}
+	if v < -1 {
+		return -1
+	}
+	return v
+}
+
+// CurrenciesForSymbol returns the currencies whose news is relevant for a
+// trading symbol, e.g. BTCUSDT -> [BTC USD]. Stablecoin quotes map to USD.
+func CurrenciesForSymbol(symbol string) []string {
+	s := strings.ToUpper(strings.TrimSpace(symbol))
+	for _, quote := range []string{"USDT", "USDC", "USD"} {
+		if strings.HasSuffix(s, quote) && len(s) > len(quote) {
+			return []string{strings.TrimSuffix(s, quote), "USD"}
+		}
+	}
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// AllowsEntry decides whether the net sentiment permits a new entry on
+// posSide. Longs are blocked when net <= -threshold, shorts when
+// net >= threshold. A non-positive threshold disables the filter.
+func AllowsEntry(posSide string, net, threshold float64) (bool, string) {
+	if threshold <= 0 {
+		return true, "sentiment_filter_disabled"
+	}
+
+	switch strings.ToLower(posSide) {
+	case "long":
+		if net <= -threshold {
+			return false, "blocked_by_negative_sentiment"
+		}
+	case "short":
+		if net >= threshold {
+			return false, "blocked_by_positive_sentiment"
+		}
+	}
+
+	return true, "allowed"
+}
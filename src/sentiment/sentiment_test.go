@@ -0,0 +1,75 @@
+package sentiment
+
+import (
+	"context"
+	"strategyexecutor/src/model"
+	"testing"
+)
+
+func TestRuleScorer_Score(t *testing.T) {
+	scorer := NewRuleScorer(DefaultRules())
+
+	tests := []struct {
+		name string
+		ev   model.TradingViewNewsEvent
+		want float64
+	}{
+		{name: "neutral", ev: model.TradingViewNewsEvent{Title: "Nonfarm Payrolls"}, want: 0},
+		{name: "positive", ev: model.TradingViewNewsEvent{Title: "Fed signals rate cut"}, want: 0.6},
+		{name: "negative", ev: model.TradingViewNewsEvent{Title: "Hawkish Fed", Comment: "another rate hike"}, want: -1},
+		{name: "mixed", ev: model.TradingViewNewsEvent{Title: "Dovish tone", Comment: "recession fears"}, want: 0.1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := scorer.Score(context.Background(), tc.ev)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := got - tc.want; diff > 1e-9 || diff < -1e-9 {
+				t.Fatalf("Score() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCurrenciesForSymbol(t *testing.T) {
+	tests := map[string][]string{
+		"BTCUSDT": {"BTC", "USD"},
+		"ethusdc": {"ETH", "USD"},
+		"XBTUSD":  {"XBT", "USD"},
+		"EUR":     {"EUR"},
+		"":        nil,
+	}
+	for symbol, want := range tests {
+		got := CurrenciesForSymbol(symbol)
+		if len(got) != len(want) {
+			t.Fatalf("CurrenciesForSymbol(%q) = %v, want %v", symbol, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("CurrenciesForSymbol(%q) = %v, want %v", symbol, got, want)
+			}
+		}
+	}
+}
+
+func TestAllowsEntry(t *testing.T) {
+	tests := []struct {
+		posSide   string
+		net       float64
+		threshold float64
+		want      bool
+	}{
+		{"Long", -2, 0, true},
+		{"Long", -1.5, 1, false},
+		{"Long", 1.5, 1, true},
+		{"Short", 1.5, 1, false},
+		{"Short", -1.5, 1, true},
+	}
+	for _, tc := range tests {
+		if got, _ := AllowsEntry(tc.posSide, tc.net, tc.threshold); got != tc.want {
+			t.Fatalf("AllowsEntry(%s, %v, %v) = %v, want %v", tc.posSide, tc.net, tc.threshold, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/security"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+// ErrNotFound is returned by the admin actions for a missing target, e.g.
+// keys or an order that do not exist.
+var ErrNotFound = errors.New("not found")
+
+// KeyRequest stores the exchange keys of a user.
+type KeyRequest struct {
+	UserID   uint   `json:"-"`
+	Exchange string `json:"-"`
+	security.Credentials
+	// OrderSizePercent is required for new keys; 0 keeps the current value.
+	OrderSizePercent int  `json:"order_size_percent"`
+	SkipValidation   bool `json:"skip_validation"`
+	// Environment is "live" or "testnet"; empty keeps the current value.
+	Environment string `json:"environment"`
+}
+
+// KeyManager validates, stores and removes the exchange keys of users, the
+// keys CLI run on the server.
+type KeyManager interface {
+	SetKey(ctx context.Context, req KeyRequest) (*model.UserExchange, error)
+	SetRunOnServer(ctx context.Context, userID uint, exchange string, on bool) error
+	Delete(ctx context.Context, userID uint, exchange string) error
+}
+
+// OrderRetrier re-dispatches the signal of a failed order, the orders retry
+// CLI run on the server. The plan is answered as is.
+type OrderRetrier interface {
+	Retry(ctx context.Context, id uint, dryRun bool) (interface{}, error)
+}
+
+type runOnServerRequest struct {
+	RunOnServer bool `json:"run_on_server"`
+}
+
+// adminContext unbinds the admin from the context of r: admin actions act on
+// the rows of any user, like the CLIs they replace.
+func adminContext(r *http.Request) context.Context {
+	return auth.WithUserID(r.Context(), 0)
+}
+
+// writeAdminActionError answers the error of an admin action: 404 for
+// ErrNotFound, 422 with the cause otherwise, e.g. keys the exchange refused.
+func writeAdminActionError(w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	logger.WithError(err).Warn(msg)
+	writeError(w, http.StatusUnprocessableEntity, msg+": "+err.Error())
+}
+
+// setKeyHandler serves PUT /api/users/{userID}/keys/{exchange} for admins,
+// which validates the keys of the body against the exchange and stores them
+// encrypted.
+func setKeyHandler(keys KeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		userID, ok := pathID(w, r, "userID")
+		if !ok {
+			return
+		}
+		var body KeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		body.UserID, body.Exchange = userID, strings.TrimSpace(chi.URLParam(r, "exchange"))
+
+		ue, err := keys.SetKey(adminContext(r), body)
+		if err != nil {
+			writeAdminActionError(w, err, "failed to set keys")
+			return
+		}
+		logger.WithFields(logger.Fields{"admin_id": adminID, "user_id": userID, "exchange": body.Exchange}).Warn("exchange keys set through the API")
+		writeJSON(w, http.StatusOK, ue)
+	}
+}
+
+// setRunOnServerHandler serves PUT /api/users/{userID}/keys/{exchange}/run-on-server
+// for admins.
+func setRunOnServerHandler(keys KeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		userID, ok := pathID(w, r, "userID")
+		if !ok {
+			return
+		}
+		var body runOnServerRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		exchange := strings.TrimSpace(chi.URLParam(r, "exchange"))
+
+		if err := keys.SetRunOnServer(adminContext(r), userID, exchange, body.RunOnServer); err != nil {
+			writeAdminActionError(w, err, "failed to set run on server")
+			return
+		}
+		logger.WithFields(logger.Fields{"admin_id": adminID, "user_id": userID, "exchange": exchange, "run_on_server": body.RunOnServer}).Warn("run on server set through the API")
+		writeJSON(w, http.StatusOK, body)
+	}
+}
+
+// deleteKeyHandler serves DELETE /api/users/{userID}/keys/{exchange} for
+// admins.
+func deleteKeyHandler(keys KeyManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		userID, ok := pathID(w, r, "userID")
+		if !ok {
+			return
+		}
+		exchange := strings.TrimSpace(chi.URLParam(r, "exchange"))
+
+		if err := keys.Delete(adminContext(r), userID, exchange); err != nil {
+			writeAdminActionError(w, err, "failed to delete keys")
+			return
+		}
+		logger.WithFields(logger.Fields{"admin_id": adminID, "user_id": userID, "exchange": exchange}).Warn("exchange keys deleted through the API")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// retryOrderHandler serves POST /api/orders/{id}/retry for admins. It is a
+// dry run unless execute=true.
+func retryOrderHandler(orders OrderRetrier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		id, ok := pathID(w, r, "id")
+		if !ok {
+			return
+		}
+		execute := r.URL.Query().Get("execute") == "true"
+
+		plan, err := orders.Retry(adminContext(r), id, !execute)
+		if err != nil {
+			writeAdminActionError(w, err, "failed to retry order")
+			return
+		}
+		logger.WithFields(logger.Fields{"admin_id": adminID, "order_id": id, "execute": execute}).Warn("order retry through the API")
+		writeJSON(w, http.StatusOK, plan)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeKeyManager struct {
+	set      []KeyRequest
+	deleted  []string
+	scoped   bool
+	runOn    map[string]bool
+	rejected error
+}
+
+func (f *fakeKeyManager) note(ctx context.Context) {
+	if _, ok := auth.UserIDFromContext(ctx); ok {
+		f.scoped = true
+	}
+}
+
+func (f *fakeKeyManager) SetKey(ctx context.Context, req KeyRequest) (*model.UserExchange, error) {
+	f.note(ctx)
+	if f.rejected != nil {
+		return nil, f.rejected
+	}
+	f.set = append(f.set, req)
+	return &model.UserExchange{UserID: req.UserID, OrderSizePercent: req.OrderSizePercent}, nil
+}
+
+func (f *fakeKeyManager) SetRunOnServer(ctx context.Context, userID uint, exchange string, on bool) error {
+	f.note(ctx)
+	if _, ok := f.runOn[exchange]; !ok {
+		return ErrNotFound
+	}
+	f.runOn[exchange] = on
+	return nil
+}
+
+func (f *fakeKeyManager) Delete(ctx context.Context, userID uint, exchange string) error {
+	f.note(ctx)
+	f.deleted = append(f.deleted, exchange)
+	return nil
+}
+
+type fakeOrderRetrier struct {
+	dryRuns []bool
+}
+
+func (f *fakeOrderRetrier) Retry(_ context.Context, id uint, dryRun bool) (interface{}, error) {
+	if id == 9 {
+		return nil, ErrNotFound
+	}
+	f.dryRuns = append(f.dryRuns, dryRun)
+	return map[string]uint{"OrderID": id}, nil
+}
+
+func TestAdminActionHandlers(t *testing.T) {
+	keys := &fakeKeyManager{runOn: map[string]bool{"phemex": false}}
+	orders := &fakeOrderRetrier{}
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req.WithContext(auth.WithUserID(req.Context(), 1)))
+		})
+	})
+	r.Put("/api/users/{userID}/keys/{exchange}", setKeyHandler(keys))
+	r.Delete("/api/users/{userID}/keys/{exchange}", deleteKeyHandler(keys))
+	r.Put("/api/users/{userID}/keys/{exchange}/run-on-server", setRunOnServerHandler(keys))
+	r.Post("/api/orders/{id}/retry", retryOrderHandler(orders))
+
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPut, "/api/users/4/keys/phemex", `{"api_key":"k","api_secret":"s","order_size_percent":5}`, http.StatusOK},
+		{http.MethodPut, "/api/users/x/keys/phemex", `{}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/users/4/keys/phemex/run-on-server", `{"run_on_server":true}`, http.StatusOK},
+		{http.MethodPut, "/api/users/4/keys/kraken/run-on-server", `{"run_on_server":true}`, http.StatusNotFound},
+		{http.MethodDelete, "/api/users/4/keys/phemex", ``, http.StatusNoContent},
+		{http.MethodPost, "/api/orders/7/retry", ``, http.StatusOK},
+		{http.MethodPost, "/api/orders/7/retry?execute=true", ``, http.StatusOK},
+		{http.MethodPost, "/api/orders/9/retry", ``, http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != tc.status {
+			t.Fatalf("%s %s: status = %d, want %d (%s)", tc.method, tc.path, rec.Code, tc.status, rec.Body.String())
+		}
+	}
+
+	if len(keys.set) != 1 || keys.set[0].UserID != 4 || keys.set[0].Exchange != "phemex" || keys.set[0].APIKey != "k" || keys.set[0].OrderSizePercent != 5 {
+		t.Fatalf("set keys = %+v", keys.set)
+	}
+	if !keys.runOn["phemex"] || len(keys.deleted) != 1 {
+		t.Fatalf("run on = %v, deleted = %v", keys.runOn, keys.deleted)
+	}
+	if keys.scoped {
+		t.Fatal("admin actions must not be scoped to the admin")
+	}
+	if len(orders.dryRuns) != 2 || !orders.dryRuns[0] || orders.dryRuns[1] {
+		t.Fatalf("dry runs = %v", orders.dryRuns)
+	}
+
+	keys.rejected = errors.New("invalid api key")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/users/4/keys/phemex", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "invalid api key") {
+		t.Fatalf("rejected keys: status = %d (%s)", rec.Code, rec.Body.String())
+	}
+}
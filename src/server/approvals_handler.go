@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+type approvalResolver interface {
+	ResolveApproval(ctx context.Context, orderID uint, newStatus string, reason string) (bool, error)
+}
+
+// approvalStatuses maps a decision to the order status it sets.
+var approvalStatuses = map[string]string{
+	auth.ApprovalApprove: model.OrderExecutionStatusApproved,
+	auth.ApprovalReject:  model.OrderExecutionStatusRejected,
+}
+
+// orderApprovalHandler serves POST /api/orders/{id}/approve and
+// /api/orders/{id}/reject for the authenticated user.
+func orderApprovalHandler(orders approvalResolver, decision string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := requestUserID(w, r); !ok {
+			return
+		}
+		orderID, ok := approvalOrderID(w, r)
+		if !ok {
+			return
+		}
+		resolveApproval(w, r, orders, orderID, decision, "decided through the api")
+	}
+}
+
+// approvalLinkHandler serves /approvals/{id}/{decision}?sig=, the signed
+// links sent with approval notifications. They need no API token. A GET only
+// renders a page confirming the decision, which link previews and mail
+// scanners fetch harmlessly; the decision is applied by the POST of that page.
+func approvalLinkHandler(orders approvalResolver, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orderID, ok := approvalOrderID(w, r)
+		if !ok {
+			return
+		}
+		decision := chi.URLParam(r, "decision")
+		if _, ok := approvalStatuses[decision]; !ok {
+			writeError(w, http.StatusNotFound, "unknown decision")
+			return
+		}
+		if !auth.VerifyApproval(secret, orderID, decision, r.URL.Query().Get("sig")) {
+			writeError(w, http.StatusForbidden, "invalid signature")
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			err := approvalConfirmPage.Execute(w, map[string]interface{}{
+				"OrderID":  orderID,
+				"Decision": decision,
+				"Action":   r.URL.RequestURI(),
+			})
+			if err != nil {
+				logger.WithError(err).WithField("order_id", orderID).Error("failed to render approval page")
+			}
+			return
+		}
+		resolveApproval(w, r, orders, orderID, decision, "decided through the notification link")
+	}
+}
+
+var approvalConfirmPage = template.Must(template.New("approval").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Order {{.OrderID}}</title></head>
+<body>
+<form method="post" action="{{.Action}}">
+<p>Confirm to {{.Decision}} order {{.OrderID}}.</p>
+<button type="submit">{{.Decision}}</button>
+</form>
+</body>
+</html>
+`))
+
+func approvalOrderID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	orderID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || orderID == 0 {
+		writeError(w, http.StatusBadRequest, "id must be a positive integer")
+		return 0, false
+	}
+	return uint(orderID), true
+}
+
+func resolveApproval(w http.ResponseWriter, r *http.Request, orders approvalResolver, orderID uint, decision, reason string) {
+	status := approvalStatuses[decision]
+	resolved, err := orders.ResolveApproval(r.Context(), orderID, status, reason)
+	if err != nil {
+		logger.WithError(err).WithField("order_id", orderID).Error("failed to resolve order approval")
+		writeError(w, http.StatusInternalServerError, "failed to resolve approval")
+		return
+	}
+	if !resolved {
+		writeError(w, http.StatusConflict, "order is not awaiting approval")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": orderID, "status": status})
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeApprovals struct {
+	awaiting map[uint]bool
+	statuses map[uint]string
+}
+
+func (f *fakeApprovals) ResolveApproval(_ context.Context, orderID uint, newStatus string, _ string) (bool, error) {
+	if !f.awaiting[orderID] {
+		return false, nil
+	}
+	f.awaiting[orderID] = false
+	f.statuses[orderID] = newStatus
+	return true, nil
+}
+
+func newFakeApprovals(ids ...uint) *fakeApprovals {
+	f := &fakeApprovals{awaiting: map[uint]bool{}, statuses: map[uint]string{}}
+	for _, id := range ids {
+		f.awaiting[id] = true
+	}
+	return f
+}
+
+func TestOrderApprovalHandler(t *testing.T) {
+	orders := newFakeApprovals(42)
+	r := chi.NewRouter()
+	r.Post("/api/orders/{id}/approve", orderApprovalHandler(orders, auth.ApprovalApprove))
+
+	cases := []struct {
+		name   string
+		url    string
+		userID uint
+		status int
+	}{
+		{name: "unauthenticated", url: "/api/orders/42/approve", status: http.StatusUnauthorized},
+		{name: "bad id", url: "/api/orders/abc/approve", userID: 1, status: http.StatusBadRequest},
+		{name: "approved", url: "/api/orders/42/approve", userID: 1, status: http.StatusOK},
+		{name: "already decided", url: "/api/orders/42/approve", userID: 1, status: http.StatusConflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, authedRequest(http.MethodPost, tc.url, tc.userID))
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d body=%s", rec.Code, tc.status, rec.Body.String())
+			}
+		})
+	}
+	if orders.statuses[42] != model.OrderExecutionStatusApproved {
+		t.Fatalf("unexpected status %q", orders.statuses[42])
+	}
+}
+
+func TestApprovalLinkHandler(t *testing.T) {
+	orders := newFakeApprovals(7)
+	r := chi.NewRouter()
+	r.Get("/approvals/{id}/{decision}", approvalLinkHandler(orders, "s3cret"))
+	r.Post("/approvals/{id}/{decision}", approvalLinkHandler(orders, "s3cret"))
+
+	approveSig := auth.ApprovalSignature("s3cret", 7, auth.ApprovalApprove)
+	rejectSig := auth.ApprovalSignature("s3cret", 7, auth.ApprovalReject)
+	cases := []struct {
+		name   string
+		method string
+		url    string
+		status int
+	}{
+		{name: "unknown decision", method: http.MethodPost, url: "/approvals/7/maybe?sig=" + rejectSig, status: http.StatusNotFound},
+		{name: "missing signature", method: http.MethodPost, url: "/approvals/7/reject", status: http.StatusForbidden},
+		{name: "signature of the other decision", method: http.MethodPost, url: "/approvals/7/reject?sig=" + approveSig, status: http.StatusForbidden},
+		{name: "signature of another order", method: http.MethodPost, url: "/approvals/8/reject?sig=" + rejectSig, status: http.StatusForbidden},
+		{name: "invalid link opened", method: http.MethodGet, url: "/approvals/7/reject", status: http.StatusForbidden},
+		{name: "link opened", method: http.MethodGet, url: "/approvals/7/approve?sig=" + approveSig, status: http.StatusOK},
+		{name: "rejected", method: http.MethodPost, url: "/approvals/7/reject?sig=" + rejectSig, status: http.StatusOK},
+		{name: "already decided", method: http.MethodPost, url: "/approvals/7/approve?sig=" + approveSig, status: http.StatusConflict},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.url, nil))
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d body=%s", rec.Code, tc.status, rec.Body.String())
+			}
+			if tc.name == "link opened" && !strings.Contains(rec.Body.String(), `<form method="post" action="/approvals/7/approve?sig=`+approveSig+`">`) {
+				t.Fatalf("expected a confirmation form, got %s", rec.Body.String())
+			}
+		})
+	}
+	if orders.statuses[7] != model.OrderExecutionStatusRejected {
+		t.Fatalf("unexpected status %q", orders.statuses[7])
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type tokenLookup interface {
+	FindActiveByHash(ctx context.Context, hash string) (*model.APIToken, error)
+	TouchLastUsed(ctx context.Context, id uint, t time.Time) error
+}
+
+// requireUser authenticates requests with "Authorization: Bearer <token>" and
+// binds the token's user to the request context. Handlers and repositories
+// take the user from there, never from request parameters.
+func requireUser(tokens tokenLookup, now func() time.Time) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			raw = strings.TrimSpace(raw)
+			if !ok || raw == "" {
+				writeError(w, http.StatusUnauthorized, "authentication required")
+				return
+			}
+
+			token, err := tokens.FindActiveByHash(r.Context(), auth.HashToken(raw))
+			if err != nil {
+				logger.WithError(err).Error("failed to look up api token")
+				writeError(w, http.StatusInternalServerError, "authentication failed")
+				return
+			}
+			if token == nil || token.UserID == 0 {
+				writeError(w, http.StatusUnauthorized, "invalid token")
+				return
+			}
+			if token.ExpiresAt != nil && !now().Before(*token.ExpiresAt) {
+				writeError(w, http.StatusUnauthorized, "session expired")
+				return
+			}
+
+			if err := tokens.TouchLastUsed(r.Context(), token.ID, now()); err != nil {
+				logger.WithError(err).Warn("failed to record api token use")
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUserID(r.Context(), token.UserID)))
+		})
+	}
+}
+
+// requestUserID returns the authenticated user of r, writing a 401 when the
+// route was mounted without requireUser.
+func requestUserID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "authentication required")
+	}
+	return userID, ok
+}
+
+// requireStaticToken guards operational endpoints with a shared bearer token.
+// An empty token leaves the endpoint open, e.g. when only reachable in-cluster.
+func requireStaticToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if token == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(raw)), []byte(token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "authentication required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type userLookup interface {
+	GetUserByID(ctx context.Context, id uint) (*model.User, error)
+}
+
+// roleRanks orders the roles of model.User, unknown roles rank below viewers.
+var roleRanks = map[string]int{
+	model.RoleViewer:   1,
+	model.RoleOperator: 2,
+	model.RoleAdmin:    3,
+}
+
+// roleAllows reports whether role grants what min grants.
+func roleAllows(role, min string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[min]
+}
+
+// loadRole binds the role of the user authenticated by requireUser to the
+// request context. Viewers are read-only: they are refused everything but
+// GET and HEAD.
+func loadRole(users userLookup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := requestUserID(w, r)
+			if !ok {
+				return
+			}
+			user, err := users.GetUserByID(r.Context(), userID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				writeError(w, http.StatusUnauthorized, "unknown user")
+				return
+			}
+			if err != nil {
+				logger.WithError(err).Error("failed to look up user role")
+				writeError(w, http.StatusInternalServerError, "authentication failed")
+				return
+			}
+
+			if r.Method != http.MethodGet && r.Method != http.MethodHead && !roleAllows(user.Role, model.RoleOperator) {
+				writeError(w, http.StatusForbidden, "read-only access")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithRole(r.Context(), user.Role)))
+		})
+	}
+}
+
+// requireRole refuses the requests of users whose role, bound by loadRole,
+// does not grant min.
+func requireRole(min string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !roleAllows(auth.RoleFromContext(r.Context()), min) {
+				writeError(w, http.StatusForbidden, min+" role required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
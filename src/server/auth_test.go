@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// authedRequest builds a request already authenticated as userID; 0 leaves
+// it anonymous.
+func authedRequest(method, url string, userID uint) *http.Request {
+	req := httptest.NewRequest(method, url, nil)
+	if userID == 0 {
+		return req
+	}
+	return req.WithContext(auth.WithUserID(req.Context(), userID))
+}
+
+type fakeTokens struct {
+	byHash  map[string]*model.APIToken
+	touched []uint
+}
+
+func (f *fakeTokens) FindActiveByHash(_ context.Context, hash string) (*model.APIToken, error) {
+	return f.byHash[hash], nil
+}
+
+func (f *fakeTokens) TouchLastUsed(_ context.Context, id uint, _ time.Time) error {
+	f.touched = append(f.touched, id)
+	return nil
+}
+
+func TestRequireUser(t *testing.T) {
+	tokens := &fakeTokens{byHash: map[string]*model.APIToken{
+		auth.HashToken("alice-token"): {ID: 5, UserID: 3},
+	}}
+
+	var gotUser uint
+	h := requireUser(tokens, time.Now)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _ = auth.UserIDFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for header, status := range map[string]int{
+		"":                   http.StatusUnauthorized,
+		"alice-token":        http.StatusUnauthorized,
+		"Bearer wrong-token": http.StatusUnauthorized,
+		"Bearer alice-token": http.StatusNoContent,
+	} {
+		gotUser = 0
+		req := httptest.NewRequest(http.MethodGet, "/api/trades?user_id=9", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Fatalf("%q: status = %d, want %d", header, rec.Code, status)
+		}
+		if status == http.StatusNoContent && gotUser != 3 {
+			t.Fatalf("authenticated as %d, want 3", gotUser)
+		}
+	}
+
+	if len(tokens.touched) != 1 || tokens.touched[0] != 5 {
+		t.Fatalf("touched = %v, want [5]", tokens.touched)
+	}
+}
+
+func TestRequireStaticToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	cases := []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{name: "open when unset", status: http.StatusOK},
+		{name: "missing", token: "s3cret", status: http.StatusUnauthorized},
+		{name: "wrong", token: "s3cret", header: "Bearer nope", status: http.StatusUnauthorized},
+		{name: "valid", token: "s3cret", header: "Bearer s3cret", status: http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			requireStaticToken(tc.token)(ok).ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+}
+
+type fakeUserRoles map[uint]string
+
+func (f fakeUserRoles) GetUserByID(_ context.Context, id uint) (*model.User, error) {
+	role, ok := f[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &model.User{ID: id, Role: role}, nil
+}
+
+func TestLoadRoleAndRequireRole(t *testing.T) {
+	users := fakeUserRoles{1: model.RoleAdmin, 2: model.RoleOperator, 3: model.RoleViewer}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	open := loadRole(users)(ok)
+	adminOnly := loadRole(users)(requireRole(model.RoleAdmin)(ok))
+
+	cases := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		userID  uint
+		status  int
+	}{
+		{"viewer reads", open, http.MethodGet, 3, http.StatusNoContent},
+		{"viewer cannot write", open, http.MethodPost, 3, http.StatusForbidden},
+		{"operator writes", open, http.MethodDelete, 2, http.StatusNoContent},
+		{"unknown user", open, http.MethodGet, 9, http.StatusUnauthorized},
+		{"anonymous", open, http.MethodGet, 0, http.StatusUnauthorized},
+		{"operator cannot stop trading", adminOnly, http.MethodPost, 2, http.StatusForbidden},
+		{"admin stops trading", adminOnly, http.MethodPost, 1, http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.handler.ServeHTTP(rec, authedRequest(tc.method, "/api/emergency-stop", tc.userID))
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+}
@@ -2,12 +2,39 @@ package server
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 )
 
 type Config struct {
 	Port string `envconfig:"PORT" default:"9898"`
+	// MetricsToken, when set, must be sent as a bearer token to read /metrics.
+	MetricsToken string `envconfig:"METRICS_TOKEN" default:""`
+	// KillSwitchToken enables the /admin emergency stop routes, which can
+	// halt every user, and must be sent as a bearer token to call them.
+	KillSwitchToken string `envconfig:"KILL_SWITCH_TOKEN" default:""`
+	// StatsCacheTTL is how long a /api/stats answer is served from memory.
+	StatsCacheTTL time.Duration `envconfig:"STATS_CACHE_TTL" default:"60s"`
+	// ApprovalSecret verifies the approve/reject links of entries awaiting
+	// approval; the /approvals routes are only mounted when it is set.
+	ApprovalSecret string `envconfig:"APPROVAL_SECRET" default:""`
+	// EventsKeepAlive is how often /api/events writes a comment to keep an
+	// idle stream open.
+	EventsKeepAlive time.Duration `envconfig:"EVENTS_KEEP_ALIVE" default:"30s"`
+	// SignalQueue queues each webhook signal for the executors consuming
+	// its symbol, see executors.Config.SignalQueue. An executor not seen
+	// for SignalQueueConsumerTTL gets no more jobs.
+	SignalQueue            bool          `envconfig:"SIGNAL_QUEUE" default:"false"`
+	SignalQueueConsumerTTL time.Duration `envconfig:"SIGNAL_QUEUE_CONSUMER_TTL" default:"24h"`
+	// SessionTTL is how long the token of a POST /auth/login, e.g. by the
+	// admin CLIs, authenticates.
+	SessionTTL time.Duration `envconfig:"SESSION_TTL" default:"12h"`
+	// LoginMaxFailures failed logins from one address or for one user name
+	// within LoginFailureWindow refuse its next logins until the window has
+	// passed; 0 disables the limit.
+	LoginMaxFailures   int           `envconfig:"LOGIN_MAX_FAILURES" default:"5"`
+	LoginFailureWindow time.Duration `envconfig:"LOGIN_FAILURE_WINDOW" default:"15m"`
 }
 
 func GetConfig() *Config {
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type dailyEntryLister interface {
+	ListByUser(ctx context.Context, userID uint, day string) ([]model.DailyEntryCount, error)
+}
+
+type filterSettingsGetter interface {
+	GetByUserID(ctx context.Context, userID uint) (*model.SignalFilterSetting, error)
+}
+
+// dailyEntryResponse is a stored counter plus whether it blocks entries.
+type dailyEntryResponse struct {
+	model.DailyEntryCount
+	LimitReached bool `json:"limit_reached"`
+}
+
+// dailyEntriesResponse are the entries of a UTC day per symbol and their
+// cap, zero when unlimited.
+type dailyEntriesResponse struct {
+	Day             string               `json:"day"`
+	MaxDailyEntries int                  `json:"max_daily_entries"`
+	Symbols         []dailyEntryResponse `json:"symbols"`
+}
+
+// dailyEntriesHandler serves GET /api/daily-entries for the authenticated
+// user. ?day=YYYY-MM-DD selects a UTC day, today by default.
+func dailyEntriesHandler(counts dailyEntryLister, settings filterSettingsGetter, now func() time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		day := model.DailyEntryDay(now())
+		if raw := r.URL.Query().Get("day"); raw != "" {
+			if _, err := time.Parse(model.DailyEntryDayLayout, raw); err != nil {
+				writeError(w, http.StatusBadRequest, "day must be YYYY-MM-DD")
+				return
+			}
+			day = raw
+		}
+
+		rows, err := counts.ListByUser(r.Context(), userID, day)
+		if err != nil {
+			logger.WithError(err).Error("failed to list daily entries")
+			writeError(w, http.StatusInternalServerError, "failed to list daily entries")
+			return
+		}
+		setting, err := settings.GetByUserID(r.Context(), userID)
+		if err != nil {
+			logger.WithError(err).Error("failed to load signal filter settings")
+			writeError(w, http.StatusInternalServerError, "failed to list daily entries")
+			return
+		}
+
+		out := dailyEntriesResponse{Day: day, Symbols: make([]dailyEntryResponse, 0, len(rows))}
+		if setting != nil {
+			out.MaxDailyEntries = setting.MaxDailyEntries
+		}
+		for _, c := range rows {
+			out.Symbols = append(out.Symbols, dailyEntryResponse{
+				DailyEntryCount: c,
+				LimitReached:    out.MaxDailyEntries > 0 && c.Count >= out.MaxDailyEntries,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+type fakeDailyEntryLister struct {
+	userID uint
+	day    string
+	rows   []model.DailyEntryCount
+	err    error
+}
+
+func (f *fakeDailyEntryLister) ListByUser(_ context.Context, userID uint, day string) ([]model.DailyEntryCount, error) {
+	f.userID, f.day = userID, day
+	return f.rows, f.err
+}
+
+type fakeFilterSettings struct{ setting *model.SignalFilterSetting }
+
+func (f fakeFilterSettings) GetByUserID(context.Context, uint) (*model.SignalFilterSetting, error) {
+	return f.setting, nil
+}
+
+func TestDailyEntriesHandler(t *testing.T) {
+	now := time.Date(2025, 3, 1, 23, 0, 0, 0, time.UTC)
+	lister := &fakeDailyEntryLister{rows: []model.DailyEntryCount{
+		{ID: 1, UserID: 3, Symbol: "BTCUSDT", Day: "2025-03-01", Count: 5},
+		{ID: 2, UserID: 3, Symbol: "ETHUSDT", Day: "2025-03-01", Count: 1},
+	}}
+	h := dailyEntriesHandler(lister, fakeFilterSettings{setting: &model.SignalFilterSetting{MaxDailyEntries: 5}}, func() time.Time { return now })
+
+	rec := httptest.NewRecorder()
+	h(rec, authedRequest(http.MethodGet, "/api/daily-entries?user_id=9", 3))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if lister.userID != 3 || lister.day != "2025-03-01" {
+		t.Fatalf("listed user %d day %q, want the authenticated user 3 today", lister.userID, lister.day)
+	}
+
+	var got struct {
+		Day             string `json:"day"`
+		MaxDailyEntries int    `json:"max_daily_entries"`
+		Symbols         []struct {
+			Symbol       string `json:"symbol"`
+			Count        int    `json:"count"`
+			LimitReached bool   `json:"limit_reached"`
+		} `json:"symbols"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Day != "2025-03-01" || got.MaxDailyEntries != 5 || len(got.Symbols) != 2 ||
+		!got.Symbols[0].LimitReached || got.Symbols[1].LimitReached || got.Symbols[0].Count != 5 {
+		t.Fatalf("unexpected body: %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	dailyEntriesHandler(lister, fakeFilterSettings{}, time.Now)(rec, authedRequest(http.MethodGet, "/api/daily-entries?day=2025-02-27", 3))
+	if rec.Code != http.StatusOK || lister.day != "2025-02-27" {
+		t.Fatalf("status = %d day %q, want the requested day", rec.Code, lister.day)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.MaxDailyEntries != 0 || got.Symbols[0].LimitReached {
+		t.Fatalf("without settings nothing is capped: %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h(rec, authedRequest(http.MethodGet, "/api/daily-entries?day=yesterday", 3))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid day: status = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/api/daily-entries", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated: status = %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	dailyEntriesHandler(&fakeDailyEntryLister{err: errors.New("boom")}, fakeFilterSettings{}, time.Now)(rec, authedRequest(http.MethodGet, "/api/daily-entries", 1))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strategyexecutor/src/killswitch"
+	"strconv"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type emergencyStopper interface {
+	Stop(ctx context.Context, req killswitch.Request) (*killswitch.Result, error)
+	Release(ctx context.Context, userID uint) (bool, error)
+}
+
+// stopTarget picks the user a stop applies to, 0 meaning every user. It
+// writes the error response itself when it returns false.
+type stopTarget func(w http.ResponseWriter, r *http.Request) (uint, bool)
+
+// adminStopTarget reads the optional user_id query parameter of the admin
+// routes and of admin users; without it the stop applies to every user.
+func adminStopTarget(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	raw := r.URL.Query().Get("user_id")
+	if raw == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 {
+		writeError(w, http.StatusBadRequest, "invalid user_id")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+type emergencyStopRequest struct {
+	Flatten bool   `json:"flatten"`
+	Reason  string `json:"reason"`
+}
+
+type emergencyReleaseResponse struct {
+	Released bool `json:"released"`
+}
+
+// emergencyStopHandler serves POST /api/emergency-stop for admin users and
+// POST /admin/emergency-stop. The body is optional. It answers 207
+// when some account could not be stopped, the halt being stored regardless.
+func emergencyStopHandler(stopper emergencyStopper, target stopTarget) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := target(w, r)
+		if !ok {
+			return
+		}
+
+		var body emergencyStopRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+
+		res, err := stopper.Stop(r.Context(), killswitch.Request{UserID: userID, Flatten: body.Flatten, Reason: body.Reason})
+		if err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("emergency stop failed")
+			if res == nil {
+				writeError(w, http.StatusInternalServerError, "failed to halt trading")
+				return
+			}
+			writeJSON(w, http.StatusInternalServerError, res)
+			return
+		}
+
+		status := http.StatusOK
+		if res.Failed() {
+			status = http.StatusMultiStatus
+		}
+		writeJSON(w, status, res)
+	}
+}
+
+// emergencyReleaseHandler serves the release routes, which lift the halt
+// stored for the target. Without a user_id, admins lift the global halt.
+func emergencyReleaseHandler(stopper emergencyStopper, target stopTarget) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := target(w, r)
+		if !ok {
+			return
+		}
+
+		released, err := stopper.Release(r.Context(), userID)
+		if err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("emergency stop release failed")
+			writeError(w, http.StatusInternalServerError, "failed to release emergency stop")
+			return
+		}
+		writeJSON(w, http.StatusOK, emergencyReleaseResponse{Released: released})
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/killswitch"
+	"strings"
+	"testing"
+)
+
+type fakeStopper struct {
+	req       killswitch.Request
+	res       *killswitch.Result
+	err       error
+	releaseID uint
+}
+
+func (f *fakeStopper) Stop(_ context.Context, req killswitch.Request) (*killswitch.Result, error) {
+	f.req = req
+	return f.res, f.err
+}
+
+func (f *fakeStopper) Release(_ context.Context, userID uint) (bool, error) {
+	f.releaseID = userID
+	return true, f.err
+}
+
+func TestEmergencyStopHandlerUser(t *testing.T) {
+	stopper := &fakeStopper{res: &killswitch.Result{Halted: true, Accounts: []killswitch.AccountResult{
+		{UserID: 3, Exchange: "phemex", OrdersCancelled: true},
+	}}}
+	h := emergencyStopHandler(stopper, requestUserID)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/emergency-stop?user_id=9", strings.NewReader(`{"flatten":true,"reason":"fat finger"}`))
+	req = req.WithContext(auth.WithUserID(req.Context(), 3))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if stopper.req != (killswitch.Request{UserID: 3, Flatten: true, Reason: "fat finger"}) {
+		t.Fatalf("unexpected request %+v", stopper.req)
+	}
+	var got killswitch.Result
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || !got.Halted || len(got.Accounts) != 1 {
+		t.Fatalf("unexpected body %s (%v)", rec.Body.String(), err)
+	}
+
+	// Empty body: cancel only.
+	stopper.res.Accounts[0].Error = "cancel orders: timeout"
+	rec = httptest.NewRecorder()
+	h(rec, authedRequest(http.MethodPost, "/api/emergency-stop", 3))
+	if rec.Code != http.StatusMultiStatus || stopper.req.Flatten {
+		t.Fatalf("partial stop: status = %d req=%+v", rec.Code, stopper.req)
+	}
+
+	stopper.err, stopper.res = errors.New("db down"), nil
+	rec = httptest.NewRecorder()
+	h(rec, authedRequest(http.MethodPost, "/api/emergency-stop", 3))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("halt failure: status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodPost, "/api/emergency-stop", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestEmergencyStopHandlerAdmin(t *testing.T) {
+	stopper := &fakeStopper{res: &killswitch.Result{Halted: true}}
+	stop := emergencyStopHandler(stopper, adminStopTarget)
+	release := emergencyReleaseHandler(stopper, adminStopTarget)
+
+	rec := httptest.NewRecorder()
+	stop(rec, httptest.NewRequest(http.MethodPost, "/admin/emergency-stop", nil))
+	if rec.Code != http.StatusOK || stopper.req.UserID != 0 {
+		t.Fatalf("global stop: status = %d req=%+v", rec.Code, stopper.req)
+	}
+
+	rec = httptest.NewRecorder()
+	stop(rec, httptest.NewRequest(http.MethodPost, "/admin/emergency-stop?user_id=abc", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad user_id: status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	release(rec, httptest.NewRequest(http.MethodPost, "/admin/emergency-stop/release?user_id=5", nil))
+	if rec.Code != http.StatusOK || stopper.releaseID != 5 || !strings.Contains(rec.Body.String(), `"released":true`) {
+		t.Fatalf("release: status = %d id=%d body=%s", rec.Code, stopper.releaseID, rec.Body.String())
+	}
+}
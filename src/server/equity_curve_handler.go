@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/report"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultEquityTimeframe = "1d"
+	defaultEquityWindow    = 30 * 24 * time.Hour
+	// maxEquityPoints bounds from/to for the requested timeframe.
+	maxEquityPoints = 2000
+)
+
+type equitySnapshotLister interface {
+	FindByUserAndRange(ctx context.Context, userID uint, from, to time.Time) ([]model.EquitySnapshot, error)
+}
+
+// equityCurveHandler serves GET /api/equity-curve?timeframe=&from=&to= for
+// the authenticated user. timeframe is one of 5m, 15m, 1h, 4h, 1d (default)
+// or 1w; from and to are RFC3339 and default to the last 30 days.
+func equityCurveHandler(snapshots equitySnapshotLister, now func() time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		q := r.URL.Query()
+
+		timeframe := q.Get("timeframe")
+		if timeframe == "" {
+			timeframe = defaultEquityTimeframe
+		}
+		step, known := report.EquityTimeframes[timeframe]
+		if !known {
+			writeError(w, http.StatusBadRequest, "timeframe must be one of 5m, 15m, 1h, 4h, 1d, 1w")
+			return
+		}
+
+		var err error
+		to := now().UTC()
+		if v := q.Get("to"); v != "" {
+			if to, err = time.Parse(time.RFC3339, v); err != nil {
+				writeError(w, http.StatusBadRequest, "to must be RFC3339")
+				return
+			}
+		}
+		from := to.Add(-defaultEquityWindow)
+		if v := q.Get("from"); v != "" {
+			if from, err = time.Parse(time.RFC3339, v); err != nil {
+				writeError(w, http.StatusBadRequest, "from must be RFC3339")
+				return
+			}
+		}
+		if !from.Before(to) {
+			writeError(w, http.StatusBadRequest, "from must be before to")
+			return
+		}
+		if to.Sub(from)/step > maxEquityPoints {
+			writeError(w, http.StatusBadRequest, "range too large for the timeframe, use a larger timeframe")
+			return
+		}
+
+		rows, err := snapshots.FindByUserAndRange(r.Context(), userID, from, to)
+		if err != nil {
+			logger.WithError(err).Error("failed to list equity snapshots")
+			writeError(w, http.StatusInternalServerError, "failed to load equity curve")
+			return
+		}
+
+		curve, err := report.BuildEquityCurve(rows, timeframe)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, curve)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type fakeEquityLister struct {
+	userID   uint
+	from, to time.Time
+	rows     []model.EquitySnapshot
+	err      error
+}
+
+func (f *fakeEquityLister) FindByUserAndRange(_ context.Context, userID uint, from, to time.Time) ([]model.EquitySnapshot, error) {
+	f.userID, f.from, f.to = userID, from, to
+	return f.rows, f.err
+}
+
+func TestEquityCurveHandler(t *testing.T) {
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+	lister := &fakeEquityLister{rows: []model.EquitySnapshot{
+		{UserID: 3, TakenAt: now.Add(-3 * time.Hour), Equity: decimal.NewFromInt(1000)},
+		{UserID: 3, TakenAt: now.Add(-2 * time.Hour), Equity: decimal.NewFromInt(900)},
+	}}
+	h := equityCurveHandler(lister, func() time.Time { return now })
+
+	rec := httptest.NewRecorder()
+	h(rec, authedRequest(http.MethodGet, "/api/equity-curve?user_id=9&timeframe=1h", 3))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if lister.userID != 3 || !lister.to.Equal(now) || !lister.from.Equal(now.Add(-30*24*time.Hour)) {
+		t.Fatalf("unexpected query: user %d from %s to %s", lister.userID, lister.from, lister.to)
+	}
+
+	var got struct {
+		Timeframe string `json:"timeframe"`
+		Points    []struct {
+			Equity   string `json:"equity"`
+			Drawdown string `json:"drawdown"`
+		} `json:"points"`
+		MaxDrawdownPct string `json:"max_drawdown_pct"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Timeframe != "1h" || len(got.Points) != 2 || got.Points[1].Drawdown != "100" || got.MaxDrawdownPct != "10" {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+}
+
+func TestEquityCurveHandlerErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		url    string
+		userID uint
+		err    error
+		status int
+	}{
+		{name: "unauthenticated", url: "/api/equity-curve", status: http.StatusUnauthorized},
+		{name: "bad timeframe", url: "/api/equity-curve?timeframe=3d", userID: 1, status: http.StatusBadRequest},
+		{name: "bad from", url: "/api/equity-curve?from=yesterday", userID: 1, status: http.StatusBadRequest},
+		{name: "from after to", url: "/api/equity-curve?from=2025-03-02T00:00:00Z&to=2025-03-01T00:00:00Z", userID: 1, status: http.StatusBadRequest},
+		{name: "too many points", url: "/api/equity-curve?timeframe=5m&from=2024-01-01T00:00:00Z&to=2025-01-01T00:00:00Z", userID: 1, status: http.StatusBadRequest},
+		{name: "repository error", url: "/api/equity-curve", userID: 1, err: errors.New("boom"), status: http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			equityCurveHandler(&fakeEquityLister{err: tc.err}, time.Now)(rec, authedRequest(http.MethodGet, tc.url, tc.userID))
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+}
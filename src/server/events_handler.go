@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strategyexecutor/src/events"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// eventStreamBuffer is how many events a slow client may lag behind before
+// the next ones are dropped.
+const eventStreamBuffer = 64
+
+// eventsStreamHandler serves GET /api/events, a server-sent events stream of
+// the events published on bus about the authenticated user. Each event is
+// sent as its events.Envelope; a comment is sent every keepAlive so proxies
+// keep the connection open.
+func eventsStreamHandler(bus *events.Bus, keepAlive time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, "streaming not supported")
+			return
+		}
+		log := logger.WithContext(r.Context()).WithField("user_id", userID)
+
+		stream := make(chan events.Envelope, eventStreamBuffer)
+		unsubscribe := bus.SubscribeAll(func(_ context.Context, ev events.Event) {
+			if ev.Owner() != userID {
+				return
+			}
+			env, err := events.Encode(ev, time.Now())
+			if err != nil {
+				log.WithError(err).Error("failed to encode event")
+				return
+			}
+			select {
+			case stream <- env:
+			default:
+				log.WithField("event", ev.Name()).Warn("event stream client too slow, event dropped")
+			}
+		})
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		if _, err := fmt.Fprint(w, ": connected\n\n"); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case env := <-stream:
+				data, err := json.Marshal(env)
+				if err != nil {
+					log.WithError(err).Error("failed to encode event")
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", env.Name, data); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
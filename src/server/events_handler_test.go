@@ -0,0 +1,80 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/events"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventsStreamHandler(t *testing.T) {
+	bus := events.NewBus()
+	h := eventsStreamHandler(bus, time.Hour)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h(w, r.WithContext(auth.WithUserID(r.Context(), 3)))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q", ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != ": connected" {
+		t.Fatalf("expected the connected comment, got %q", lines.Text())
+	}
+
+	bus.Publish(context.Background(), events.OrderFilled{UserID: 9, Symbol: "ETHUSDT"})
+	bus.Publish(context.Background(), events.SLMoved{UserID: 3, Symbol: "BTCUSDT"})
+
+	var event, data string
+	for lines.Scan() {
+		line := lines.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			event = name
+		}
+		if payload, ok := strings.CutPrefix(line, "data: "); ok {
+			data = payload
+			break
+		}
+	}
+	if event != "sl_moved" {
+		t.Fatalf("streamed event %q, want only the events of user 3", event)
+	}
+	var env events.Envelope
+	if err := json.Unmarshal([]byte(data), &env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	ev, err := events.Decode(env)
+	if err != nil {
+		t.Fatalf("decode envelope: %v", err)
+	}
+	if moved, ok := ev.(events.SLMoved); !ok || moved.Symbol != "BTCUSDT" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+}
+
+func TestEventsStreamHandlerRequiresUser(t *testing.T) {
+	rec := httptest.NewRecorder()
+	eventsStreamHandler(events.NewBus(), time.Hour)(rec, httptest.NewRequest(http.MethodGet, "/api/events", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+type exceptionLister interface {
+	List(ctx context.Context, filter repository.ExceptionFilter, page repository.Pagination) ([]model.Exception, error)
+}
+
+// exceptionsHandler serves GET /admin/exceptions?service=&module=&level=&exchange=
+// with the paging parameters of ordersHandler. Exchange errors carry the
+// code, hint and suggested action recorded when they were captured.
+func exceptionsHandler(exceptions exceptionLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		page, msg := parsePagination(q)
+		if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+		filter := repository.ExceptionFilter{
+			Service:  q.Get("service"),
+			Module:   q.Get("module"),
+			Level:    q.Get("level"),
+			Exchange: strings.ToLower(q.Get("exchange")),
+		}
+
+		rows, err := exceptions.List(r.Context(), filter, page)
+		if err != nil {
+			logger.WithError(err).Error("failed to list exceptions")
+			writeError(w, http.StatusInternalServerError, "failed to list exceptions")
+			return
+		}
+		if rows == nil {
+			rows = []model.Exception{}
+		}
+		if len(rows) > 0 {
+			setNextCursor(w, page, len(rows), rows[len(rows)-1].ID)
+		}
+
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// errorHintHandler serves GET /admin/error-codes/{exchange}/{code}, the
+// meaning and suggested action of a raw exchange error code.
+func errorHintHandler(w http.ResponseWriter, r *http.Request) {
+	hint, ok := connectors.LookupErrorHint(chi.URLParam(r, "exchange"), chi.URLParam(r, "code"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown error code")
+		return
+	}
+	writeJSON(w, http.StatusOK, hint)
+}
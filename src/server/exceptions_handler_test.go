@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeExceptionLister struct {
+	filter repository.ExceptionFilter
+	page   repository.Pagination
+	rows   []model.Exception
+}
+
+func (f *fakeExceptionLister) List(_ context.Context, filter repository.ExceptionFilter, page repository.Pagination) ([]model.Exception, error) {
+	f.filter, f.page = filter, page
+	return f.rows, nil
+}
+
+func TestExceptionsHandler(t *testing.T) {
+	lister := &fakeExceptionLister{rows: []model.Exception{
+		{ID: 5, Exchange: "phemex", ErrorCode: "11052", Hint: "Insufficient margin", Action: "Deposit USDT"},
+	}}
+
+	rec := httptest.NewRecorder()
+	exceptionsHandler(lister)(rec, httptest.NewRequest(http.MethodGet, "/admin/exceptions?exchange=Phemex&level=error&limit=1", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if lister.filter.Exchange != "phemex" || lister.filter.Level != "error" || lister.page.Limit != 1 {
+		t.Fatalf("unexpected call: filter=%+v page=%+v", lister.filter, lister.page)
+	}
+	if got := rec.Header().Get(nextCursorHeader); got != "5" {
+		t.Fatalf("next cursor = %q", got)
+	}
+	var got []model.Exception
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 1 || got[0].Action != "Deposit USDT" {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	exceptionsHandler(lister)(rec, httptest.NewRequest(http.MethodGet, "/admin/exceptions?sort=sideways", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestErrorHintHandler(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/admin/error-codes/{exchange}/{code}", errorHintHandler)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/error-codes/phemex/11082", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	var hint connectors.ErrorHint
+	if err := json.Unmarshal(rec.Body.Bytes(), &hint); err != nil || hint.Code != "11082" || hint.Action == "" {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/error-codes/phemex/1", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strategyexecutor/src/model"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+type exchangeRegistry interface {
+	List(ctx context.Context) ([]model.Exchange, error)
+	SaveCapabilities(ctx context.Context, exchange *model.Exchange) error
+}
+
+type exchangeCapabilitiesRequest struct {
+	SupportsHedge         bool `json:"supports_hedge"`
+	SupportsStopOrder     bool `json:"supports_stop_order"`
+	SupportsNativeBracket bool `json:"supports_native_bracket"`
+	Futures               bool `json:"futures"`
+	Spot                  bool `json:"spot"`
+}
+
+// exchangesHandler serves GET /admin/exchanges, the exchange registry with
+// the capabilities of each exchange.
+func exchangesHandler(exchanges exchangeRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rows, err := exchanges.List(r.Context())
+		if err != nil {
+			logger.WithError(err).Error("failed to list exchanges")
+			writeError(w, http.StatusInternalServerError, "failed to list exchanges")
+			return
+		}
+		if rows == nil {
+			rows = []model.Exchange{}
+		}
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// saveExchangeHandler serves PUT /admin/exchanges/{name}, which registers the
+// exchange or replaces its capabilities with the ones of the body.
+func saveExchangeHandler(exchanges exchangeRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.ToLower(strings.TrimSpace(chi.URLParam(r, "name")))
+		if name == "" {
+			writeError(w, http.StatusBadRequest, "exchange name is required")
+			return
+		}
+
+		var body exchangeCapabilitiesRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+
+		exchange := &model.Exchange{
+			Name:                  name,
+			SupportsHedge:         body.SupportsHedge,
+			SupportsStopOrder:     body.SupportsStopOrder,
+			SupportsNativeBracket: body.SupportsNativeBracket,
+			Futures:               body.Futures,
+			Spot:                  body.Spot,
+		}
+		if err := exchanges.SaveCapabilities(r.Context(), exchange); err != nil {
+			logger.WithError(err).WithField("exchange", name).Error("failed to save exchange")
+			writeError(w, http.StatusInternalServerError, "failed to save exchange")
+			return
+		}
+		writeJSON(w, http.StatusOK, exchange)
+	}
+}
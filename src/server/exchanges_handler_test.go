@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeExchangeRegistry struct {
+	rows  []model.Exchange
+	saved *model.Exchange
+}
+
+func (f *fakeExchangeRegistry) List(context.Context) ([]model.Exchange, error) {
+	return f.rows, nil
+}
+
+func (f *fakeExchangeRegistry) SaveCapabilities(_ context.Context, exchange *model.Exchange) error {
+	exchange.ID = 3
+	f.saved = exchange
+	return nil
+}
+
+func TestExchangesHandler(t *testing.T) {
+	registry := &fakeExchangeRegistry{rows: []model.Exchange{{ID: 1, Name: "phemex", SupportsHedge: true}}}
+
+	rec := httptest.NewRecorder()
+	exchangesHandler(registry)(rec, httptest.NewRequest(http.MethodGet, "/admin/exchanges", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	var got []model.Exchange
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 1 || !got[0].SupportsHedge {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+}
+
+func TestSaveExchangeHandler(t *testing.T) {
+	registry := &fakeExchangeRegistry{}
+	r := chi.NewRouter()
+	r.Put("/admin/exchanges/{name}", saveExchangeHandler(registry))
+
+	rec := httptest.NewRecorder()
+	body := `{"supports_stop_order":true,"supports_native_bracket":true,"futures":true}`
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/exchanges/Bybit", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if s := registry.saved; s == nil || s.Name != "bybit" || !s.SupportsStopOrder || !s.SupportsNativeBracket || !s.Futures || s.SupportsHedge {
+		t.Fatalf("unexpected save: %+v", registry.saved)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/exchanges/bybit", strings.NewReader("{")))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
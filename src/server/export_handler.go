@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strategyexecutor/src/export"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+type exporter interface {
+	Orders(ctx context.Context, t export.Table, userID uint, from, to time.Time) (int, error)
+	Trades(ctx context.Context, t export.Table, userID uint, from, to time.Time) (int, error)
+}
+
+// exportHandler serves GET /api/export/{kind}, kind being orders or trades,
+// the orders or closed trades of the authenticated user as a CSV or XLSX
+// attachment. Query parameters: format (csv, the default, or xlsx) and the
+// from / to bounds of ordersHandler. The file is streamed as it is read.
+func exportHandler(exports exporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		kind := chi.URLParam(r, "kind")
+		write := exports.Orders
+		switch kind {
+		case "orders":
+		case "trades":
+			write = exports.Trades
+		default:
+			writeError(w, http.StatusNotFound, "export must be orders or trades")
+			return
+		}
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = export.FormatCSV
+		}
+		if format != export.FormatCSV && format != export.FormatXLSX {
+			writeError(w, http.StatusBadRequest, "format must be csv or xlsx")
+			return
+		}
+		page, msg := parsePagination(r.URL.Query())
+		if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		w.Header().Set("Content-Type", export.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, kind, format))
+		table, err := export.NewTable(format, w, kind)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// Once the first rows went out the status can't change anymore: a
+		// failure truncates the file and is only logged.
+		n, err := write(r.Context(), table, userID, page.From, page.To)
+		if err == nil {
+			err = table.Close()
+		}
+		log := logger.WithFields(map[string]interface{}{
+			"user_id": userID,
+			"export":  kind,
+			"format":  format,
+			"rows":    n,
+		})
+		if err != nil {
+			log.WithError(err).Error("failed to export")
+			return
+		}
+		log.Info("export written")
+	}
+}
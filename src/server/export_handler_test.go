@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/export"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeExporter struct {
+	userID   uint
+	from, to time.Time
+	kind     string
+}
+
+func (f *fakeExporter) Orders(_ context.Context, t export.Table, userID uint, from, to time.Time) (int, error) {
+	f.userID, f.from, f.to, f.kind = userID, from, to, "orders"
+	return 1, t.Row("order_id", 7)
+}
+
+func (f *fakeExporter) Trades(_ context.Context, t export.Table, userID uint, from, to time.Time) (int, error) {
+	f.userID, f.from, f.to, f.kind = userID, from, to, "trades"
+	return 1, t.Row("trade_id", 9)
+}
+
+func TestExportHandler(t *testing.T) {
+	exports := &fakeExporter{}
+	r := chi.NewRouter()
+	r.Get("/api/export/{kind}", exportHandler(exports))
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req.WithContext(auth.WithUserID(req.Context(), 3)))
+		return rec
+	}
+
+	rec := serve("/api/export/trades?from=2025-01-01T00:00:00Z&to=2025-12-31T23:59:59Z")
+	if rec.Code != http.StatusOK || rec.Body.String() != "trade_id,9\n" {
+		t.Fatalf("status = %d body=%q", rec.Code, rec.Body.String())
+	}
+	if exports.userID != 3 || exports.kind != "trades" || exports.from.Year() != 2025 || exports.to.Month() != time.December {
+		t.Fatalf("unexpected export %+v", exports)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="trades.csv"` {
+		t.Fatalf("Content-Disposition = %q", got)
+	}
+
+	rec = serve("/api/export/orders?format=xlsx")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "PK") || exports.kind != "orders" {
+		t.Fatalf("status = %d, kind %s", rec.Code, exports.kind)
+	}
+	if got := rec.Header().Get("Content-Type"); got != export.ContentType(export.FormatXLSX) {
+		t.Fatalf("Content-Type = %q", got)
+	}
+
+	for path, want := range map[string]int{
+		"/api/export/positions":          http.StatusNotFound,
+		"/api/export/orders?format=pdf":  http.StatusBadRequest,
+		"/api/export/orders?from=monday": http.StatusBadRequest,
+	} {
+		if rec := serve(path); rec.Code != want {
+			t.Fatalf("%s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
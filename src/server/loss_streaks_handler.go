@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strategyexecutor/src/model"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type lossStreakLister interface {
+	ListByUser(ctx context.Context, userID uint) ([]model.LossStreak, error)
+}
+
+// lossStreakResponse is a stored streak plus whether its cooldown is running.
+type lossStreakResponse struct {
+	model.LossStreak
+	InCooldown bool `json:"in_cooldown"`
+}
+
+// lossStreaksHandler serves GET /api/loss-streaks for the authenticated user.
+func lossStreaksHandler(streaks lossStreakLister, now func() time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		rows, err := streaks.ListByUser(r.Context(), userID)
+		if err != nil {
+			logger.WithError(err).Error("failed to list loss streaks")
+			writeError(w, http.StatusInternalServerError, "failed to list loss streaks")
+			return
+		}
+
+		at := now()
+		out := make([]lossStreakResponse, 0, len(rows))
+		for _, s := range rows {
+			out = append(out, lossStreakResponse{LossStreak: s, InCooldown: s.InCooldown(at)})
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+type fakeLossStreakLister struct {
+	userID uint
+	rows   []model.LossStreak
+	err    error
+}
+
+func (f *fakeLossStreakLister) ListByUser(_ context.Context, userID uint) ([]model.LossStreak, error) {
+	f.userID = userID
+	return f.rows, f.err
+}
+
+func TestLossStreaksHandler(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	until := now.Add(time.Hour)
+	expired := now.Add(-time.Hour)
+	lister := &fakeLossStreakLister{rows: []model.LossStreak{
+		{ID: 1, UserID: 3, Symbol: "BTCUSDT", ConsecutiveLosses: 3, CooldownUntil: &until},
+		{ID: 2, UserID: 3, Symbol: "ETHUSDT", ConsecutiveLosses: 3, CooldownUntil: &expired},
+	}}
+	h := lossStreaksHandler(lister, func() time.Time { return now })
+
+	rec := httptest.NewRecorder()
+	h(rec, authedRequest(http.MethodGet, "/api/loss-streaks?user_id=9", 3))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if lister.userID != 3 {
+		t.Fatalf("listed user %d, want the authenticated user 3", lister.userID)
+	}
+
+	var got []struct {
+		Symbol            string `json:"symbol"`
+		ConsecutiveLosses int    `json:"consecutive_losses"`
+		InCooldown        bool   `json:"in_cooldown"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got) != 2 || !got[0].InCooldown || got[1].InCooldown || got[0].ConsecutiveLosses != 3 {
+		t.Fatalf("unexpected body: %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/api/loss-streaks?user_id=3", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated: status = %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	lossStreaksHandler(&fakeLossStreakLister{err: errors.New("boom")}, time.Now)(rec, authedRequest(http.MethodGet, "/api/loss-streaks", 1))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/onboarding"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type onboardingFlow interface {
+	CreateUser(ctx context.Context, req onboarding.CreateUserRequest) (*model.User, string, error)
+	State(ctx context.Context, userID uint) (*model.Onboarding, error)
+	SetKeys(ctx context.Context, userID uint, req onboarding.KeysRequest) (*model.Onboarding, error)
+	ChoosePreset(ctx context.Context, userID uint, req onboarding.PresetRequest) (*model.Onboarding, error)
+	StartPaper(ctx context.Context, userID uint) (*model.Onboarding, error)
+	GoLive(ctx context.Context, userID uint) (*model.Onboarding, error)
+}
+
+// onboardingUserCreated is a user created for onboarding with the API token
+// of its next steps, only shown once.
+type onboardingUserCreated struct {
+	User  *model.User `json:"user"`
+	Token string      `json:"token"`
+}
+
+// onboardingState is an onboarding with the presets of its preset step.
+type onboardingState struct {
+	*model.Onboarding
+	Presets onboarding.Presets `json:"presets"`
+}
+
+// createOnboardingUserHandler serves POST /admin/onboarding/users, which
+// creates a user at the first onboarding step and returns their API token.
+func createOnboardingUserHandler(flow onboardingFlow) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body onboarding.CreateUserRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		user, token, err := flow.CreateUser(r.Context(), body)
+		if err != nil {
+			writeOnboardingError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, onboardingUserCreated{User: user, Token: token})
+	}
+}
+
+// onboardingHandler serves GET /api/onboarding, the step the authenticated
+// user is at and the presets to choose from.
+func onboardingHandler(flow onboardingFlow) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		o, err := flow.State(r.Context(), userID)
+		if err != nil {
+			writeOnboardingError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, onboardingState{Onboarding: o, Presets: onboarding.CurrentPresets()})
+	}
+}
+
+// onboardingKeysHandler serves POST /api/onboarding/keys, which validates the
+// exchange keys live and stores them without running on the server.
+func onboardingKeysHandler(flow onboardingFlow) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		var body onboarding.KeysRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		o, err := flow.SetKeys(r.Context(), userID, body)
+		if err != nil {
+			writeOnboardingError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, o)
+	}
+}
+
+// onboardingPresetHandler serves POST /api/onboarding/preset with the
+// symbol, strategy and risk profile chosen among the presets.
+func onboardingPresetHandler(flow onboardingFlow) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		var body onboarding.PresetRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		o, err := flow.ChoosePreset(r.Context(), userID, body)
+		if err != nil {
+			writeOnboardingError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, o)
+	}
+}
+
+// onboardingStepHandler serves the onboarding steps without a body,
+// POST /api/onboarding/paper and POST /api/onboarding/live.
+func onboardingStepHandler(step func(ctx context.Context, userID uint) (*model.Onboarding, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		o, err := step(r.Context(), userID)
+		if err != nil {
+			writeOnboardingError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, o)
+	}
+}
+
+func writeOnboardingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, onboarding.ErrNotOnboarding):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, onboarding.ErrInvalid):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, onboarding.ErrKeysRejected):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, onboarding.ErrWrongStep),
+		errors.Is(err, onboarding.ErrPaperTradePending),
+		errors.Is(err, onboarding.ErrUserExists):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		logger.WithError(err).Error("onboarding step failed")
+		writeError(w, http.StatusInternalServerError, "onboarding step failed")
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/onboarding"
+	"strings"
+	"testing"
+)
+
+type fakeOnboardingFlow struct {
+	keys onboarding.KeysRequest
+	err  error
+}
+
+func (f *fakeOnboardingFlow) CreateUser(_ context.Context, req onboarding.CreateUserRequest) (*model.User, string, error) {
+	return &model.User{ID: 5, Username: req.Username}, "tok", f.err
+}
+
+func (f *fakeOnboardingFlow) State(_ context.Context, userID uint) (*model.Onboarding, error) {
+	return &model.Onboarding{UserID: userID, Step: model.OnboardingStepKeys}, f.err
+}
+
+func (f *fakeOnboardingFlow) SetKeys(_ context.Context, userID uint, req onboarding.KeysRequest) (*model.Onboarding, error) {
+	f.keys = req
+	return &model.Onboarding{UserID: userID, Step: model.OnboardingStepPreset}, f.err
+}
+
+func (f *fakeOnboardingFlow) ChoosePreset(_ context.Context, userID uint, _ onboarding.PresetRequest) (*model.Onboarding, error) {
+	return &model.Onboarding{UserID: userID, Step: model.OnboardingStepPaper}, f.err
+}
+
+func (f *fakeOnboardingFlow) StartPaper(_ context.Context, userID uint) (*model.Onboarding, error) {
+	return &model.Onboarding{UserID: userID, Step: model.OnboardingStepPaper}, f.err
+}
+
+func (f *fakeOnboardingFlow) GoLive(_ context.Context, userID uint) (*model.Onboarding, error) {
+	return nil, f.err
+}
+
+func TestOnboardingHandlers(t *testing.T) {
+	flow := &fakeOnboardingFlow{}
+
+	rec := httptest.NewRecorder()
+	createOnboardingUserHandler(flow)(rec, httptest.NewRequest(http.MethodPost, "/admin/onboarding/users", strings.NewReader(`{"user_name":"alice","password":"correct horse"}`)))
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"token":"tok"`) {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	onboardingHandler(flow)(rec, authedRequest(http.MethodGet, "/api/onboarding", 5))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"risk_profiles"`) {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	req := authedRequest(http.MethodPost, "/api/onboarding/keys", 5)
+	req.Body = http.NoBody
+	rec = httptest.NewRecorder()
+	onboardingKeysHandler(flow)(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected an empty body rejected, got %d", rec.Code)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/api/onboarding/keys", strings.NewReader(`{"exchange":"phemex","api_key":"k","api_secret":"s","environment":"testnet"}`))
+	req = req.WithContext(authedRequest(http.MethodPost, "/", 5).Context())
+	rec = httptest.NewRecorder()
+	onboardingKeysHandler(flow)(rec, req)
+	if rec.Code != http.StatusOK || flow.keys.APIKey != "k" || flow.keys.Environment != "testnet" {
+		t.Fatalf("status = %d keys=%+v", rec.Code, flow.keys)
+	}
+
+	rec = httptest.NewRecorder()
+	onboardingStepHandler(flow.StartPaper)(rec, authedRequest(http.MethodPost, "/api/onboarding/paper", 0))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a user, got %d", rec.Code)
+	}
+
+	for err, want := range map[error]int{
+		onboarding.ErrNotOnboarding:                        http.StatusNotFound,
+		fmt.Errorf("%w: bad", onboarding.ErrInvalid):       http.StatusBadRequest,
+		fmt.Errorf("%w: nope", onboarding.ErrKeysRejected): http.StatusUnprocessableEntity,
+		onboarding.ErrPaperTradePending:                    http.StatusConflict,
+		fmt.Errorf("%w: at keys", onboarding.ErrWrongStep): http.StatusConflict,
+		onboarding.ErrUserExists:                           http.StatusConflict,
+		fmt.Errorf("db down"):                              http.StatusInternalServerError,
+	} {
+		flow.err = err
+		rec = httptest.NewRecorder()
+		onboardingStepHandler(flow.GoLive)(rec, authedRequest(http.MethodPost, "/api/onboarding/live", 5))
+		if rec.Code != want {
+			t.Fatalf("%v: status = %d, want %d", err, rec.Code, want)
+		}
+	}
+}
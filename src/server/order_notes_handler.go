@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type orderNoteStore interface {
+	Create(ctx context.Context, note *model.OrderNote) error
+	FindByOrderIDs(ctx context.Context, orderIDs []uint) ([]model.OrderNote, error)
+}
+
+type orderByID interface {
+	FindByID(ctx context.Context, id uint) (*model.Order, error)
+}
+
+type orderNoteRequest struct {
+	Body string `json:"body"`
+}
+
+// notesContext is the context the notes of r are read and written with:
+// admins document incidents on the orders of any user, others on their own.
+func notesContext(r *http.Request) context.Context {
+	if auth.RoleFromContext(r.Context()) == model.RoleAdmin {
+		return adminContext(r)
+	}
+	return r.Context()
+}
+
+// noteOrder loads the order of the {id} URL parameter, writing the error
+// answer when it is not visible to the request.
+func noteOrder(w http.ResponseWriter, r *http.Request, orders orderByID) (*model.Order, bool) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+	order, err := orders.FindByID(notesContext(r), id)
+	if err != nil {
+		logger.WithError(err).Error("failed to load order")
+		writeError(w, http.StatusInternalServerError, "failed to load order")
+		return nil, false
+	}
+	if order == nil {
+		writeError(w, http.StatusNotFound, "order not found")
+		return nil, false
+	}
+	return order, true
+}
+
+// orderNotesHandler serves GET /api/orders/{id}/notes, oldest first.
+func orderNotesHandler(orders orderByID, notes orderNoteStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := requestUserID(w, r); !ok {
+			return
+		}
+		order, ok := noteOrder(w, r, orders)
+		if !ok {
+			return
+		}
+
+		rows, err := notes.FindByOrderIDs(notesContext(r), []uint{order.ID})
+		if err != nil {
+			logger.WithError(err).Error("failed to list order notes")
+			writeError(w, http.StatusInternalServerError, "failed to list order notes")
+			return
+		}
+		if rows == nil {
+			rows = []model.OrderNote{}
+		}
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// addOrderNoteHandler serves POST /api/orders/{id}/notes, which attaches the
+// free-text body to the order with the authenticated user as author.
+func addOrderNoteHandler(orders orderByID, notes orderNoteStore, users userLookup, now func() time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authorID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		var body orderNoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		text := strings.TrimSpace(body.Body)
+		if text == "" || utf8.RuneCountInString(text) > model.MaxOrderNoteLength {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("body must be 1 to %d characters", model.MaxOrderNoteLength))
+			return
+		}
+		order, ok := noteOrder(w, r, orders)
+		if !ok {
+			return
+		}
+		author, err := users.GetUserByID(r.Context(), authorID)
+		if err != nil {
+			logger.WithError(err).Error("failed to load note author")
+			writeError(w, http.StatusInternalServerError, "failed to add order note")
+			return
+		}
+
+		note := &model.OrderNote{
+			OrderID:   order.ID,
+			UserID:    order.UserID,
+			AuthorID:  authorID,
+			Author:    author.Username,
+			Body:      text,
+			CreatedAt: now().UTC(),
+		}
+		if err := notes.Create(notesContext(r), note); err != nil {
+			logger.WithError(err).Error("failed to add order note")
+			writeError(w, http.StatusInternalServerError, "failed to add order note")
+			return
+		}
+		logger.WithFields(logger.Fields{"author_id": authorID, "order_id": order.ID, "user_id": order.UserID}).Info("order note added")
+		writeJSON(w, http.StatusCreated, note)
+	}
+}
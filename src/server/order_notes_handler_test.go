@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// fakeNoteOrders scopes the orders by the user of the context like the
+// repository does.
+type fakeNoteOrders map[uint]*model.Order
+
+func (f fakeNoteOrders) FindByID(ctx context.Context, id uint) (*model.Order, error) {
+	o, ok := f[id]
+	if userID, scoped := auth.UserIDFromContext(ctx); !ok || (scoped && o.UserID != userID) {
+		return nil, nil
+	}
+	return o, nil
+}
+
+type fakeOrderNotes struct{ rows []model.OrderNote }
+
+func (f *fakeOrderNotes) Create(_ context.Context, note *model.OrderNote) error {
+	note.ID = uint(len(f.rows) + 1)
+	f.rows = append(f.rows, *note)
+	return nil
+}
+
+func (f *fakeOrderNotes) FindByOrderIDs(_ context.Context, ids []uint) ([]model.OrderNote, error) {
+	var rows []model.OrderNote
+	for _, n := range f.rows {
+		if n.OrderID == ids[0] {
+			rows = append(rows, n)
+		}
+	}
+	return rows, nil
+}
+
+func TestOrderNotesHandlers(t *testing.T) {
+	orders := fakeNoteOrders{10: {ID: 10, UserID: 3}, 11: {ID: 11, UserID: 4}}
+	notes := &fakeOrderNotes{}
+	users := fakeUserRoles{1: model.RoleAdmin, 3: model.RoleOperator}
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			userID := uint(3)
+			if req.Header.Get("X-Admin") != "" {
+				userID = 1
+			}
+			ctx := auth.WithRole(auth.WithUserID(req.Context(), userID), users[userID])
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	})
+	r.Get("/api/orders/{id}/notes", orderNotesHandler(orders, notes))
+	r.Post("/api/orders/{id}/notes", addOrderNoteHandler(orders, notes, users, func() time.Time { return now }))
+
+	for _, tc := range []struct {
+		path, body string
+		admin      bool
+		status     int
+	}{
+		{"/api/orders/10/notes", `{"body":" closed by hand after the API outage "}`, false, http.StatusCreated},
+		{"/api/orders/11/notes", `{"body":"not mine"}`, false, http.StatusNotFound},
+		{"/api/orders/11/notes", `{"body":"flattened for the user"}`, true, http.StatusCreated},
+		{"/api/orders/10/notes", `{"body":"   "}`, false, http.StatusBadRequest},
+		{"/api/orders/10/notes", `{"body":"` + strings.Repeat("x", model.MaxOrderNoteLength+1) + `"}`, false, http.StatusBadRequest},
+		{"/api/orders/x/notes", `{"body":"x"}`, false, http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		if tc.admin {
+			req.Header.Set("X-Admin", "1")
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Fatalf("POST %s admin=%v: status = %d, want %d (%s)", tc.path, tc.admin, rec.Code, tc.status, rec.Body.String())
+		}
+	}
+
+	if len(notes.rows) != 2 {
+		t.Fatalf("notes = %+v", notes.rows)
+	}
+	first, second := notes.rows[0], notes.rows[1]
+	if first.Body != "closed by hand after the API outage" || first.UserID != 3 || first.AuthorID != 3 || !first.CreatedAt.Equal(now) {
+		t.Fatalf("first note = %+v", first)
+	}
+	if second.UserID != 4 || second.AuthorID != 1 {
+		t.Fatalf("admin note = %+v", second)
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/orders/10/notes", nil))
+	var got []model.OrderNote
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || rec.Code != http.StatusOK || len(got) != 1 {
+		t.Fatalf("GET notes: status = %d, notes = %+v, err = %v", rec.Code, got, err)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/orders/11/notes", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("GET notes of another user: status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/orderhook"
+	"strategyexecutor/src/repository"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+type orderWebhookStore interface {
+	Create(ctx context.Context, hook *model.OrderWebhook) error
+	ListByUser(ctx context.Context, userID uint) ([]model.OrderWebhook, error)
+	FindByID(ctx context.Context, id uint) (*model.OrderWebhook, error)
+	Delete(ctx context.Context, userID, id uint) (bool, error)
+	ListDeliveries(ctx context.Context, webhookID uint, page repository.Pagination) ([]model.WebhookDelivery, error)
+}
+
+// orderWebhookEvents are the events an order webhook can subscribe to.
+var orderWebhookEvents = []string{events.OrderFilled{}.Name(), events.OrderFailed{}.Name()}
+
+type orderWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret signs the deliveries; one is generated when empty.
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// orderWebhookCreated is a created webhook with its secret, only shown once.
+type orderWebhookCreated struct {
+	model.OrderWebhook
+	Secret string `json:"secret"`
+}
+
+// orderWebhooksHandler serves GET /api/order-webhooks for the authenticated
+// user.
+func orderWebhooksHandler(hooks orderWebhookStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		rows, err := hooks.ListByUser(r.Context(), userID)
+		if err != nil {
+			logger.WithError(err).Error("failed to list order webhooks")
+			writeError(w, http.StatusInternalServerError, "failed to list order webhooks")
+			return
+		}
+		if rows == nil {
+			rows = []model.OrderWebhook{}
+		}
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// createOrderWebhookHandler serves POST /api/order-webhooks, which registers
+// a URL the order events of the authenticated user are POSTed to, which
+// must resolve to public addresses. The secret signing them is stored
+// encrypted and only returned here.
+func createOrderWebhookHandler(hooks orderWebhookStore, encrypt func(string) (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		var body orderWebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		u, err := orderhook.ValidateURL(r.Context(), body.URL)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, e := range body.Events {
+			if !containsFold(orderWebhookEvents, e) {
+				writeError(w, http.StatusBadRequest, "events must be among "+strings.Join(orderWebhookEvents, ", "))
+				return
+			}
+		}
+
+		secret := body.Secret
+		if secret == "" {
+			b := make([]byte, 32)
+			if _, err := rand.Read(b); err != nil {
+				logger.WithError(err).Error("failed to generate order webhook secret")
+				writeError(w, http.StatusInternalServerError, "failed to create order webhook")
+				return
+			}
+			secret = hex.EncodeToString(b)
+		}
+		secretEnc, err := encrypt(secret)
+		if err != nil {
+			logger.WithError(err).Error("failed to encrypt order webhook secret")
+			writeError(w, http.StatusInternalServerError, "failed to create order webhook")
+			return
+		}
+
+		hook := &model.OrderWebhook{
+			UserID:    userID,
+			URL:       u.String(),
+			SecretEnc: secretEnc,
+			Events:    strings.ToLower(strings.Join(body.Events, ",")),
+			Enabled:   true,
+		}
+		if err := hooks.Create(r.Context(), hook); err != nil {
+			logger.WithError(err).Error("failed to create order webhook")
+			writeError(w, http.StatusInternalServerError, "failed to create order webhook")
+			return
+		}
+		writeJSON(w, http.StatusCreated, orderWebhookCreated{OrderWebhook: *hook, Secret: secret})
+	}
+}
+
+// deleteOrderWebhookHandler serves DELETE /api/order-webhooks/{id}.
+func deleteOrderWebhookHandler(hooks orderWebhookStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id == 0 {
+			writeError(w, http.StatusBadRequest, "id must be a positive integer")
+			return
+		}
+
+		removed, err := hooks.Delete(r.Context(), userID, uint(id))
+		if err != nil {
+			logger.WithError(err).Error("failed to delete order webhook")
+			writeError(w, http.StatusInternalServerError, "failed to delete order webhook")
+			return
+		}
+		if !removed {
+			writeError(w, http.StatusNotFound, "order webhook not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// orderWebhookDeliveriesHandler serves GET /api/order-webhooks/{id}/deliveries
+// with the paging parameters of ordersHandler, e.g. to find the dead ones.
+func orderWebhookDeliveriesHandler(hooks orderWebhookStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id == 0 {
+			writeError(w, http.StatusBadRequest, "id must be a positive integer")
+			return
+		}
+		page, msg := parsePagination(r.URL.Query())
+		if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		hook, err := hooks.FindByID(r.Context(), uint(id))
+		if err != nil {
+			logger.WithError(err).Error("failed to load order webhook")
+			writeError(w, http.StatusInternalServerError, "failed to list webhook deliveries")
+			return
+		}
+		if hook == nil || hook.UserID != userID {
+			writeError(w, http.StatusNotFound, "order webhook not found")
+			return
+		}
+
+		rows, err := hooks.ListDeliveries(r.Context(), hook.ID, page)
+		if err != nil {
+			logger.WithError(err).Error("failed to list webhook deliveries")
+			writeError(w, http.StatusInternalServerError, "failed to list webhook deliveries")
+			return
+		}
+		if rows == nil {
+			rows = []model.WebhookDelivery{}
+		}
+		if len(rows) > 0 {
+			setNextCursor(w, page, len(rows), rows[len(rows)-1].ID)
+		}
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+func containsFold(values []string, v string) bool {
+	for _, s := range values {
+		if strings.EqualFold(s, strings.TrimSpace(v)) {
+			return true
+		}
+	}
+	return false
+}
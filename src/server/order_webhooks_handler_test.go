@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeOrderWebhookStore struct {
+	hooks []model.OrderWebhook
+}
+
+func (f *fakeOrderWebhookStore) Create(_ context.Context, hook *model.OrderWebhook) error {
+	hook.ID = uint(len(f.hooks) + 1)
+	f.hooks = append(f.hooks, *hook)
+	return nil
+}
+
+func (f *fakeOrderWebhookStore) ListByUser(_ context.Context, userID uint) ([]model.OrderWebhook, error) {
+	var out []model.OrderWebhook
+	for _, h := range f.hooks {
+		if h.UserID == userID {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOrderWebhookStore) FindByID(_ context.Context, id uint) (*model.OrderWebhook, error) {
+	for i := range f.hooks {
+		if f.hooks[i].ID == id {
+			return &f.hooks[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeOrderWebhookStore) Delete(_ context.Context, userID, id uint) (bool, error) {
+	for i, h := range f.hooks {
+		if h.ID == id && h.UserID == userID {
+			f.hooks = append(f.hooks[:i], f.hooks[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeOrderWebhookStore) ListDeliveries(_ context.Context, webhookID uint, _ repository.Pagination) ([]model.WebhookDelivery, error) {
+	return []model.WebhookDelivery{{ID: 1, WebhookID: webhookID, Status: model.WebhookDeliveryDead}}, nil
+}
+
+func TestOrderWebhooksHandlers(t *testing.T) {
+	store := &fakeOrderWebhookStore{}
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req.WithContext(auth.WithUserID(req.Context(), 3)))
+		})
+	})
+	r.Get("/api/order-webhooks", orderWebhooksHandler(store))
+	r.Post("/api/order-webhooks", createOrderWebhookHandler(store, func(s string) (string, error) { return "enc:" + s, nil }))
+	r.Delete("/api/order-webhooks/{id}", deleteOrderWebhookHandler(store))
+	r.Get("/api/order-webhooks/{id}/deliveries", orderWebhookDeliveriesHandler(store))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/order-webhooks", strings.NewReader(`{"url":"https://93.184.216.34/hook","events":["order_failed"]}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID        uint   `json:"id"`
+		Secret    string `json:"secret"`
+		SecretEnc string `json:"secret_enc"`
+		Events    string `json:"events"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(created.Secret) != 64 || created.SecretEnc != "" || created.Events != "order_failed" {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+	if h := store.hooks[0]; h.UserID != 3 || h.SecretEnc != "enc:"+created.Secret {
+		t.Fatalf("unexpected webhook stored: %+v", h)
+	}
+
+	for _, body := range []string{
+		`{"url":"ftp://93.184.216.34"}`,
+		`{"url":"https://93.184.216.34","events":["order_placed"]}`,
+		`{"url":"http://169.254.169.254/latest/meta-data"}`,
+		`{"url":"http://127.0.0.1:8200/v1/secret"}`,
+		`{`,
+	} {
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/order-webhooks", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", body, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/order-webhooks", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "enc:") {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/order-webhooks/1/deliveries", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"dead"`) {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// webhooks of other users are not found
+	store.hooks = append(store.hooks, model.OrderWebhook{ID: 2, UserID: 4})
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/order-webhooks/2/deliveries", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/order-webhooks/2", nil),
+	} {
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("%s %s: status = %d, want 404", req.Method, req.URL, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/order-webhooks/1", nil))
+	if rec.Code != http.StatusNoContent || len(store.hooks) != 1 {
+		t.Fatalf("status = %d, hooks %+v", rec.Code, store.hooks)
+	}
+
+	rec = httptest.NewRecorder()
+	orderWebhooksHandler(store)(rec, httptest.NewRequest(http.MethodGet, "/api/order-webhooks", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated: status = %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+// nextCursorHeader carries the cursor of the following page; it is omitted on
+// the last page.
+const nextCursorHeader = "X-Next-Cursor"
+
+type orderLister interface {
+	FindLatest(ctx context.Context, page repository.Pagination) ([]model.Order, error)
+	FindLogsByOrderID(ctx context.Context, orderID uint, page repository.Pagination) ([]model.OrderLog, error)
+}
+
+// ordersHandler serves GET /api/orders?limit=&cursor=&sort=&from=&to= for the
+// authenticated user, newest first by default.
+func ordersHandler(orders orderLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := requestUserID(w, r); !ok {
+			return
+		}
+		page, msg := parsePagination(r.URL.Query())
+		if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		rows, err := orders.FindLatest(r.Context(), page)
+		if err != nil {
+			logger.WithError(err).Error("failed to list orders")
+			writeError(w, http.StatusInternalServerError, "failed to list orders")
+			return
+		}
+		if rows == nil {
+			rows = []model.Order{}
+		}
+		if len(rows) > 0 {
+			setNextCursor(w, page, len(rows), rows[len(rows)-1].ID)
+		}
+
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// orderLogsHandler serves GET /api/orders/{id}/logs with the same paging
+// parameters as ordersHandler. Orders of other users yield an empty list.
+func orderLogsHandler(orders orderLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := requestUserID(w, r); !ok {
+			return
+		}
+		orderID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || orderID == 0 {
+			writeError(w, http.StatusBadRequest, "id must be a positive integer")
+			return
+		}
+		page, msg := parsePagination(r.URL.Query())
+		if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		rows, err := orders.FindLogsByOrderID(r.Context(), uint(orderID), page)
+		if err != nil {
+			logger.WithError(err).Error("failed to list order logs")
+			writeError(w, http.StatusInternalServerError, "failed to list order logs")
+			return
+		}
+		if rows == nil {
+			rows = []model.OrderLog{}
+		}
+		if len(rows) > 0 {
+			setNextCursor(w, page, len(rows), rows[len(rows)-1].ID)
+		}
+
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// parsePagination reads limit, cursor, sort, from and to. It returns a
+// client-facing message when a parameter is invalid.
+func parsePagination(q url.Values) (repository.Pagination, string) {
+	var page repository.Pagination
+	var err error
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return page, "limit must be a positive integer"
+		}
+		page.Limit = limit
+	}
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return page, "cursor must be a positive integer"
+		}
+		page.Cursor = uint(cursor)
+	}
+	switch v := q.Get("sort"); v {
+	case "", repository.SortAsc, repository.SortDesc:
+		page.Sort = v
+	default:
+		return page, "sort must be asc or desc"
+	}
+	if v := q.Get("from"); v != "" {
+		if page.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return page, "from must be RFC3339"
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if page.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return page, "to must be RFC3339"
+		}
+	}
+	return page.Normalize(), ""
+}
+
+func setNextCursor(w http.ResponseWriter, page repository.Pagination, rows int, lastID uint) {
+	if next := page.NextCursor(rows, lastID); next != 0 {
+		w.Header().Set(nextCursorHeader, strconv.FormatUint(uint64(next), 10))
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeOrderLister struct {
+	page    repository.Pagination
+	orderID uint
+	orders  []model.Order
+	logs    []model.OrderLog
+}
+
+func (f *fakeOrderLister) FindLatest(_ context.Context, page repository.Pagination) ([]model.Order, error) {
+	f.page = page
+	return f.orders, nil
+}
+
+func (f *fakeOrderLister) FindLogsByOrderID(_ context.Context, orderID uint, page repository.Pagination) ([]model.OrderLog, error) {
+	f.orderID, f.page = orderID, page
+	return f.logs, nil
+}
+
+func TestOrdersHandlerPages(t *testing.T) {
+	lister := &fakeOrderLister{orders: []model.Order{{ID: 9}, {ID: 8}}}
+
+	rec := httptest.NewRecorder()
+	ordersHandler(lister)(rec, authedRequest(http.MethodGet, "/api/orders?limit=2&cursor=10&from=2025-03-01T00:00:00Z", 3))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get(nextCursorHeader); got != "8" {
+		t.Fatalf("next cursor = %q", got)
+	}
+	if lister.page.Limit != 2 || lister.page.Cursor != 10 || lister.page.Sort != repository.SortDesc ||
+		!lister.page.From.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected page: %+v", lister.page)
+	}
+	var got []model.Order
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 2 {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	// a short page is the last one
+	rec = httptest.NewRecorder()
+	ordersHandler(lister)(rec, authedRequest(http.MethodGet, "/api/orders?limit=5000", 3))
+	if rec.Header().Get(nextCursorHeader) != "" {
+		t.Fatalf("unexpected next cursor on last page")
+	}
+	if lister.page.Limit != repository.MaxPageLimit {
+		t.Fatalf("limit = %d, want clamp to %d", lister.page.Limit, repository.MaxPageLimit)
+	}
+}
+
+func TestOrderLogsHandler(t *testing.T) {
+	lister := &fakeOrderLister{logs: []model.OrderLog{{ID: 1, OrderID: 42}}}
+	r := chi.NewRouter()
+	r.Get("/api/orders/{id}/logs", orderLogsHandler(lister))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, authedRequest(http.MethodGet, "/api/orders/42/logs?sort=asc", 3))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if lister.orderID != 42 || lister.page.Sort != repository.SortAsc {
+		t.Fatalf("unexpected call: order=%d page=%+v", lister.orderID, lister.page)
+	}
+}
+
+func TestOrdersHandlerErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		url    string
+		userID uint
+		status int
+	}{
+		{name: "unauthenticated", url: "/api/orders", status: http.StatusUnauthorized},
+		{name: "bad limit", url: "/api/orders?limit=0", userID: 1, status: http.StatusBadRequest},
+		{name: "bad cursor", url: "/api/orders?cursor=abc", userID: 1, status: http.StatusBadRequest},
+		{name: "bad sort", url: "/api/orders?sort=sideways", userID: 1, status: http.StatusBadRequest},
+		{name: "bad to", url: "/api/orders?to=tomorrow", userID: 1, status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ordersHandler(&fakeOrderLister{})(rec, authedRequest(http.MethodGet, tc.url, tc.userID))
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type scheduledJobStore interface {
+	List(ctx context.Context) ([]model.ScheduledJob, error)
+	ListRuns(ctx context.Context, job, status string, page repository.Pagination) ([]model.JobRun, error)
+}
+
+// scheduledJobsHandler serves GET /admin/scheduled-jobs, the last run of
+// each periodic job of the scheduler.
+func scheduledJobsHandler(jobs scheduledJobStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rows, err := jobs.List(r.Context())
+		if err != nil {
+			logger.WithError(err).Error("failed to list scheduled jobs")
+			writeError(w, http.StatusInternalServerError, "failed to list scheduled jobs")
+			return
+		}
+		if rows == nil {
+			rows = []model.ScheduledJob{}
+		}
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// jobRunsHandler serves GET /admin/job-runs with the paging parameters of
+// ordersHandler and optional job and status filters, e.g.
+// ?job=ohlcv_crypto&status=failed.
+func jobRunsHandler(jobs scheduledJobStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, msg := parsePagination(r.URL.Query())
+		if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", model.ScheduledJobOK, model.ScheduledJobFailed:
+		default:
+			writeError(w, http.StatusBadRequest, "status must be ok or failed")
+			return
+		}
+
+		rows, err := jobs.ListRuns(r.Context(), r.URL.Query().Get("job"), status, page)
+		if err != nil {
+			logger.WithError(err).Error("failed to list job runs")
+			writeError(w, http.StatusInternalServerError, "failed to list job runs")
+			return
+		}
+		if rows == nil {
+			rows = []model.JobRun{}
+		}
+		if len(rows) > 0 {
+			setNextCursor(w, page, len(rows), rows[len(rows)-1].ID)
+		}
+
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"testing"
+)
+
+type fakeScheduledJobs []model.ScheduledJob
+
+func (f fakeScheduledJobs) List(context.Context) ([]model.ScheduledJob, error) {
+	return f, nil
+}
+
+func (f fakeScheduledJobs) ListRuns(_ context.Context, job, status string, page repository.Pagination) ([]model.JobRun, error) {
+	return []model.JobRun{{ID: 7, Job: job, Status: status, Items: page.Limit}}, nil
+}
+
+func TestScheduledJobsHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	scheduledJobsHandler(fakeScheduledJobs(nil))(rec, httptest.NewRequest(http.MethodGet, "/admin/scheduled-jobs", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "[]\n" {
+		t.Fatalf("status = %d body=%q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	jobs := fakeScheduledJobs{{Name: "funding", LastStatus: model.ScheduledJobFailed, LastError: "exchange down", Runs: 3, Failures: 1}}
+	scheduledJobsHandler(jobs)(rec, httptest.NewRequest(http.MethodGet, "/admin/scheduled-jobs", nil))
+	var got []model.ScheduledJob
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 1 || got[0].LastError != "exchange down" {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+}
+
+func TestJobRunsHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	jobRunsHandler(fakeScheduledJobs(nil))(rec, httptest.NewRequest(http.MethodGet, "/admin/job-runs?job=funding&status=failed&limit=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get(nextCursorHeader); got != "7" {
+		t.Fatalf("next cursor = %q", got)
+	}
+	var got []model.JobRun
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 1 || got[0].Job != "funding" || got[0].Status != model.ScheduledJobFailed || got[0].Items != 1 {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	jobRunsHandler(fakeScheduledJobs(nil))(rec, httptest.NewRequest(http.MethodGet, "/admin/job-runs?status=lost", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected an unknown status rejected, got %d", rec.Code)
+	}
+}
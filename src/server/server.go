@@ -6,6 +6,17 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/database"
+	"strategyexecutor/src/events"
+	"strategyexecutor/src/export"
+	"strategyexecutor/src/killswitch"
+	"strategyexecutor/src/metrics"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/onboarding"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/security"
+	"strategyexecutor/src/webhook"
 	"syscall"
 	"time"
 
@@ -13,10 +24,20 @@ import (
 	logger "github.com/sirupsen/logrus"
 )
 
-func StartServer(port string) {
+// Options are the services of the cmd packages the server exposes; the
+// routes of a nil one are not mounted.
+type Options struct {
+	Onboarding *onboarding.Flow
+	Keys       KeyManager
+	Orders     OrderRetrier
+}
+
+// StartServer serves the API on port until SIGINT or SIGTERM.
+func StartServer(port string, opts Options) {
 	// Router with middleware
 	r := chi.NewRouter()
 	// === Global Middleware ===
+	r.Use(traceRequests)
 
 	// Public routes
 	r.Get("/healthcheck", func(w http.ResponseWriter, r *http.Request) {
@@ -25,6 +46,114 @@ func StartServer(port string) {
 		}
 	})
 
+	r.With(requireStaticToken(GetConfig().MetricsToken)).
+		Get("/metrics", metrics.Handler(database.CollectPoolStats))
+
+	killSwitch := killswitch.New()
+
+	// Operator routes, only mounted when a token guards them
+	if token := GetConfig().KillSwitchToken; token != "" {
+		r.Route("/admin", func(admin chi.Router) {
+			admin.Use(requireStaticToken(token))
+			admin.Post("/emergency-stop", emergencyStopHandler(killSwitch, adminStopTarget))
+			admin.Post("/emergency-stop/release", emergencyReleaseHandler(killSwitch, adminStopTarget))
+			admin.Get("/exceptions", exceptionsHandler(repository.NewExceptionRepository()))
+			admin.Get("/error-codes/{exchange}/{code}", errorHintHandler)
+			admin.Get("/exchanges", exchangesHandler(repository.NewExchangeRepository()))
+			admin.Put("/exchanges/{name}", saveExchangeHandler(repository.NewExchangeRepository()))
+			admin.Get("/signals", signalSummariesHandler(repository.NewSignalExecutionSummaryRepository()))
+			admin.Get("/signals/{id}/summary", signalSummaryHandler(repository.NewSignalExecutionSummaryRepository()))
+			admin.Get("/signal-jobs", signalJobsHandler(repository.NewSignalJobRepository()))
+			admin.Post("/signal-jobs/{id}/retry", retrySignalJobHandler(repository.NewSignalJobRepository(), time.Now))
+			admin.Get("/scheduled-jobs", scheduledJobsHandler(repository.NewScheduledJobRepository()))
+			admin.Get("/job-runs", jobRunsHandler(repository.NewScheduledJobRepository()))
+			if opts.Onboarding != nil {
+				admin.Post("/onboarding/users", createOnboardingUserHandler(opts.Onboarding))
+			}
+		})
+	}
+
+	// Signal webhooks, each provider verifies its own requests
+	providers, err := webhook.FromConfig(webhook.GetConfig())
+	if err != nil {
+		logger.WithError(err).Fatal("invalid webhook configuration")
+	}
+	if names := providers.Names(); len(names) > 0 {
+		logger.WithField("providers", names).Info("webhook providers enabled")
+		var queue signalQueue
+		if GetConfig().SignalQueue {
+			queue = repository.NewSignalJobRepository()
+		}
+		r.Post("/webhooks/{provider}", webhookHandler(providers, repository.NewTradingSignalRepository(), queue, GetConfig().SignalQueueConsumerTTL, time.Now))
+	}
+
+	// Approval links of large entries, authenticated by their signature
+	if secret := GetConfig().ApprovalSecret; secret != "" {
+		approvalLink := approvalLinkHandler(repository.NewOrderRepository(), secret)
+		r.Get("/approvals/{id}/{decision}", approvalLink)
+		r.Post("/approvals/{id}/{decision}", approvalLink)
+	}
+
+	// Sessions of the admin CLIs, authenticated by password
+	r.Post("/auth/login", loginHandler(repository.NewUserRepository(), repository.NewAPITokenRepository(),
+		newLoginLimiter(GetConfig().LoginMaxFailures, GetConfig().LoginFailureWindow, time.Now), GetConfig().SessionTTL, time.Now))
+	r.With(requireUser(repository.NewAPITokenRepository(), time.Now)).
+		Post("/auth/logout", logoutHandler(repository.NewAPITokenRepository(), time.Now))
+
+	// API routes, authenticated per user
+	r.Route("/api", func(api chi.Router) {
+		api.Use(requireUser(repository.NewAPITokenRepository(), time.Now))
+		api.Use(loadRole(repository.NewUserRepository()))
+		api.Get("/trades", tradesHandler(repository.NewTradeRepository(), repository.NewOrderNoteRepository()))
+		api.Get("/trades/summary", tradesSummaryHandler(repository.NewTradeRepository()))
+		api.Get("/trades/excursions", tradesExcursionsHandler(repository.NewTradeRepository()))
+		api.Get("/stats", statsHandler(
+			repository.NewTradeRepository(),
+			repository.NewStrategyRepository(),
+			repository.NewExchangeRepository(),
+			GetConfig().StatsCacheTTL,
+			time.Now,
+		))
+		api.Get("/loss-streaks", lossStreaksHandler(repository.NewLossStreakRepository(), time.Now))
+		api.Get("/daily-entries", dailyEntriesHandler(repository.NewDailyEntryCountRepository(), repository.NewSignalFilterSettingsRepository(), time.Now))
+		api.Get("/equity-curve", equityCurveHandler(repository.NewEquitySnapshotRepository(), time.Now))
+		api.Get("/orders", ordersHandler(repository.NewOrderRepository()))
+		api.Get("/orders/{id}/logs", orderLogsHandler(repository.NewOrderRepository()))
+		api.Get("/orders/{id}/notes", orderNotesHandler(repository.NewOrderRepository(), repository.NewOrderNoteRepository()))
+		api.Post("/orders/{id}/notes", addOrderNoteHandler(repository.NewOrderRepository(), repository.NewOrderNoteRepository(), repository.NewUserRepository(), time.Now))
+		api.Post("/orders/{id}/approve", orderApprovalHandler(repository.NewOrderRepository(), auth.ApprovalApprove))
+		api.Post("/orders/{id}/reject", orderApprovalHandler(repository.NewOrderRepository(), auth.ApprovalReject))
+		if opts.Orders != nil {
+			api.With(requireRole(model.RoleAdmin)).Post("/orders/{id}/retry", retryOrderHandler(opts.Orders))
+		}
+		api.With(requireRole(model.RoleAdmin)).Post("/emergency-stop", emergencyStopHandler(killSwitch, adminStopTarget))
+		api.With(requireRole(model.RoleAdmin)).Post("/emergency-stop/release", emergencyReleaseHandler(killSwitch, adminStopTarget))
+		api.Get("/events", eventsStreamHandler(events.Default, GetConfig().EventsKeepAlive))
+		api.Get("/export/{kind}", exportHandler(export.New()))
+		api.Get("/tax-report", taxReportHandler(export.New()))
+		api.Get("/order-webhooks", orderWebhooksHandler(repository.NewOrderWebhookRepository()))
+		api.Post("/order-webhooks", createOrderWebhookHandler(repository.NewOrderWebhookRepository(), security.EncryptString))
+		api.Delete("/order-webhooks/{id}", deleteOrderWebhookHandler(repository.NewOrderWebhookRepository()))
+		api.Get("/order-webhooks/{id}/deliveries", orderWebhookDeliveriesHandler(repository.NewOrderWebhookRepository()))
+		api.Route("/users/{userID}", func(users chi.Router) {
+			users.Use(requireRole(model.RoleAdmin))
+			users.Put("/role", setUserRoleHandler(repository.NewUserRepository()))
+			users.Patch("/exchanges/{exchangeID}", editUserExchangeHandler(repository.NewUserExchangeRepository()))
+			if opts.Keys != nil {
+				users.Put("/keys/{exchange}", setKeyHandler(opts.Keys))
+				users.Put("/keys/{exchange}/run-on-server", setRunOnServerHandler(opts.Keys))
+				users.Delete("/keys/{exchange}", deleteKeyHandler(opts.Keys))
+			}
+		})
+		if flow := opts.Onboarding; flow != nil {
+			api.Get("/onboarding", onboardingHandler(flow))
+			api.Post("/onboarding/keys", onboardingKeysHandler(flow))
+			api.Post("/onboarding/preset", onboardingPresetHandler(flow))
+			api.Post("/onboarding/paper", onboardingStepHandler(flow.StartPaper))
+			api.Post("/onboarding/live", onboardingStepHandler(flow.GoLive))
+		}
+	})
+
 	// Graceful server
 	// Server setup
 	addr := ":" + port
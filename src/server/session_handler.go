@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type userByName interface {
+	GetUserByUserName(ctx context.Context, userName string) (*model.User, error)
+}
+
+type sessionTokens interface {
+	Create(ctx context.Context, token *model.APIToken) error
+	FindActiveByHash(ctx context.Context, hash string) (*model.APIToken, error)
+	Revoke(ctx context.Context, id uint, t time.Time) error
+}
+
+type loginRequest struct {
+	Username string `json:"user_name"`
+	Password string `json:"password"`
+}
+
+// Session is the answer of POST /auth/login.
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserID    uint      `json:"user_id"`
+	Role      string    `json:"role"`
+}
+
+// dummyPasswordHash is compared against for unknown users, so a refused
+// login takes as long whether or not the user exists.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(fmt.Errorf("hash dummy password: %w", err))
+	}
+	return hash
+})
+
+// loginHandler serves POST /auth/login, which trades a user name and
+// password for a session token valid for ttl, e.g. for the admin CLIs.
+// Logins from an address or for a user name with too many recent failures
+// are refused by limiter before the password is checked.
+func loginHandler(users userByName, tokens sessionTokens, limiter *loginLimiter, ttl time.Duration, now func() time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body loginRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+
+		userName := strings.TrimSpace(body.Username)
+		keys := []string{"ip:" + remoteHost(r), "user:" + strings.ToLower(userName)}
+		if !limiter.allow(keys...) {
+			logger.WithFields(logger.Fields{"user_name": userName, "remote": remoteHost(r)}).Warn("login rate limited")
+			writeError(w, http.StatusTooManyRequests, "too many failed logins, retry later")
+			return
+		}
+
+		user, err := users.GetUserByUserName(r.Context(), userName)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.WithError(err).Error("failed to look up user")
+			writeError(w, http.StatusInternalServerError, "login failed")
+			return
+		}
+		stored := dummyPasswordHash()
+		if user != nil && user.Password != "" {
+			stored = []byte(user.Password)
+		}
+		if bcrypt.CompareHashAndPassword(stored, []byte(body.Password)) != nil || user == nil || user.Password == "" {
+			limiter.fail(keys...)
+			logger.WithField("user_name", body.Username).Warn("login refused")
+			writeError(w, http.StatusUnauthorized, "invalid credentials")
+			return
+		}
+		limiter.reset(keys...)
+
+		raw, hash, err := auth.GenerateToken()
+		if err != nil {
+			logger.WithError(err).Error("failed to generate session token")
+			writeError(w, http.StatusInternalServerError, "login failed")
+			return
+		}
+		expiresAt := now().UTC().Add(ttl)
+		token := &model.APIToken{UserID: user.ID, Name: "session", TokenHash: hash, ExpiresAt: &expiresAt}
+		if err := tokens.Create(r.Context(), token); err != nil {
+			logger.WithError(err).Error("failed to store session token")
+			writeError(w, http.StatusInternalServerError, "login failed")
+			return
+		}
+
+		logger.WithFields(logger.Fields{"user_id": user.ID, "expires_at": expiresAt}).Info("session opened")
+		writeJSON(w, http.StatusOK, Session{Token: raw, ExpiresAt: expiresAt, UserID: user.ID, Role: user.Role})
+	}
+}
+
+// logoutHandler serves POST /auth/logout, which revokes the token the
+// request is authenticated with.
+func logoutHandler(tokens sessionTokens, now func() time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		token, err := tokens.FindActiveByHash(r.Context(), auth.HashToken(strings.TrimSpace(raw)))
+		if err != nil {
+			logger.WithError(err).Error("failed to look up api token")
+			writeError(w, http.StatusInternalServerError, "logout failed")
+			return
+		}
+		if token == nil {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		if err := tokens.Revoke(r.Context(), token.ID, now().UTC()); err != nil {
+			logger.WithError(err).Error("failed to revoke api token")
+			writeError(w, http.StatusInternalServerError, "logout failed")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// loginLimiter counts the failed logins per key, e.g. an address or a user
+// name, and refuses a key reaching max failures until window has passed
+// since its first one. A nil limiter allows everything.
+type loginLimiter struct {
+	mu       sync.Mutex
+	max      int
+	window   time.Duration
+	now      func() time.Time
+	failures map[string]loginFailures
+}
+
+type loginFailures struct {
+	count int
+	since time.Time
+}
+
+func newLoginLimiter(max int, window time.Duration, now func() time.Time) *loginLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &loginLimiter{max: max, window: window, now: now, failures: map[string]loginFailures{}}
+}
+
+// allow reports whether none of keys reached its failure limit.
+func (l *loginLimiter) allow(keys ...string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for _, key := range keys {
+		if f, ok := l.failures[key]; ok && f.count >= l.max && now.Before(f.since.Add(l.window)) {
+			return false
+		}
+	}
+	return true
+}
+
+// fail counts a failed login for keys and drops the expired counts, so the
+// limiter only holds the failures of the last window.
+func (l *loginLimiter) fail(keys ...string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for k, f := range l.failures {
+		if !now.Before(f.since.Add(l.window)) {
+			delete(l.failures, k)
+		}
+	}
+	for _, key := range keys {
+		f, ok := l.failures[key]
+		if !ok {
+			f.since = now
+		}
+		f.count++
+		l.failures[key] = f
+	}
+}
+
+// reset forgets the failures of keys after a successful login.
+func (l *loginLimiter) reset(keys ...string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		delete(l.failures, key)
+	}
+}
+
+// remoteHost returns the address r comes from, without its port.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
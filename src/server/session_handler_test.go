@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type fakeUsersByName map[string]*model.User
+
+func (f fakeUsersByName) GetUserByUserName(_ context.Context, userName string) (*model.User, error) {
+	u, ok := f[userName]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return u, nil
+}
+
+type fakeSessionTokens struct {
+	byHash  map[string]*model.APIToken
+	revoked []uint
+}
+
+func (f *fakeSessionTokens) Create(_ context.Context, token *model.APIToken) error {
+	token.ID = uint(len(f.byHash) + 1)
+	f.byHash[token.TokenHash] = token
+	return nil
+}
+
+func (f *fakeSessionTokens) FindActiveByHash(_ context.Context, hash string) (*model.APIToken, error) {
+	return f.byHash[hash], nil
+}
+
+func (f *fakeSessionTokens) Revoke(_ context.Context, id uint, _ time.Time) error {
+	f.revoked = append(f.revoked, id)
+	return nil
+}
+
+func (f *fakeSessionTokens) TouchLastUsed(context.Context, uint, time.Time) error { return nil }
+
+func TestLoginAndLogout(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := fakeUsersByName{"alice": {ID: 3, Password: string(hash), Role: model.RoleAdmin}}
+	tokens := &fakeSessionTokens{byHash: map[string]*model.APIToken{}}
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	login := loginHandler(users, tokens, nil, time.Hour, clock)
+	for body, status := range map[string]int{
+		`{"user_name":"alice","password":"wrong"}`: http.StatusUnauthorized,
+		`{"user_name":"bob","password":"s3cret"}`:  http.StatusUnauthorized,
+		`not json`: http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		login(rec, httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body)))
+		if rec.Code != status {
+			t.Fatalf("login %s: status = %d, want %d", body, rec.Code, status)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	login(rec, httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"user_name":" alice ","password":"s3cret"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	var s Session
+	if err := json.NewDecoder(rec.Body).Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.UserID != 3 || s.Role != model.RoleAdmin || !s.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("session = %+v", s)
+	}
+	stored := tokens.byHash[auth.HashToken(s.Token)]
+	if stored == nil || stored.UserID != 3 || stored.ExpiresAt == nil {
+		t.Fatalf("stored token = %+v", stored)
+	}
+
+	// The token authenticates until it expires.
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	for at, status := range map[time.Time]int{
+		now.Add(30 * time.Minute): http.StatusNoContent,
+		now.Add(time.Hour):        http.StatusUnauthorized,
+	} {
+		at := at
+		req := httptest.NewRequest(http.MethodGet, "/api/trades", nil)
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+		rec := httptest.NewRecorder()
+		requireUser(tokens, func() time.Time { return at })(ok).ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Fatalf("at %s: status = %d, want %d", at, rec.Code, status)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	rec = httptest.NewRecorder()
+	logoutHandler(tokens, clock)(rec, req)
+	if rec.Code != http.StatusNoContent || len(tokens.revoked) != 1 || tokens.revoked[0] != stored.ID {
+		t.Fatalf("logout: status = %d, revoked = %v", rec.Code, tokens.revoked)
+	}
+}
+
+func TestLoginRateLimit(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := fakeUsersByName{"alice": {ID: 3, Password: string(hash)}, "carol": {ID: 4, Password: string(hash)}}
+	tokens := &fakeSessionTokens{byHash: map[string]*model.APIToken{}}
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	login := loginHandler(users, tokens, newLoginLimiter(2, 15*time.Minute, clock), time.Hour, clock)
+
+	post := func(remote, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		login(rec, req)
+		return rec.Code
+	}
+	const wrong = `{"user_name":"alice","password":"wrong"}`
+	const right = `{"user_name":"alice","password":"s3cret"}`
+
+	for _, remote := range []string{"10.0.0.1:1000", "10.0.0.2:1000"} {
+		if code := post(remote, wrong); code != http.StatusUnauthorized {
+			t.Fatalf("status = %d", code)
+		}
+	}
+	if code := post("10.0.0.3:1000", right); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the user name to be limited from any address, got %d", code)
+	}
+
+	if code := post("10.0.0.1:1000", `{"user_name":"bob","password":"wrong"}`); code != http.StatusUnauthorized {
+		t.Fatalf("status = %d", code)
+	}
+	if code := post("10.0.0.1:2000", `{"user_name":"carol","password":"s3cret"}`); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the address to be limited for any user name, got %d", code)
+	}
+
+	now = now.Add(15 * time.Minute)
+	if code := post("10.0.0.1:1000", right); code != http.StatusOK {
+		t.Fatalf("expected the limit to expire with its window, got %d", code)
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+type signalJobStore interface {
+	List(ctx context.Context, status string, page repository.Pagination) ([]model.SignalJob, error)
+	Retry(ctx context.Context, id uint, now time.Time) (*model.SignalJob, error)
+}
+
+// signalJobsHandler serves GET /admin/signal-jobs with the paging
+// parameters of ordersHandler and an optional status, e.g. status=dead for
+// the dead letter queue.
+func signalJobsHandler(jobs signalJobStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, msg := parsePagination(r.URL.Query())
+		if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", model.SignalJobQueued, model.SignalJobLeased, model.SignalJobDone, model.SignalJobDead:
+		default:
+			writeError(w, http.StatusBadRequest, "status must be one of queued, leased, done, dead")
+			return
+		}
+
+		rows, err := jobs.List(r.Context(), status, page)
+		if err != nil {
+			logger.WithError(err).Error("failed to list signal jobs")
+			writeError(w, http.StatusInternalServerError, "failed to list signal jobs")
+			return
+		}
+		if rows == nil {
+			rows = []model.SignalJob{}
+		}
+		if len(rows) > 0 {
+			setNextCursor(w, page, len(rows), rows[len(rows)-1].ID)
+		}
+
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// retrySignalJobHandler serves POST /admin/signal-jobs/{id}/retry: the dead
+// job is queued again for its executor with its attempts reset.
+func retrySignalJobHandler(jobs signalJobStore, now func() time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id == 0 {
+			writeError(w, http.StatusBadRequest, "id must be a positive integer")
+			return
+		}
+
+		job, err := jobs.Retry(r.Context(), uint(id), now().UTC())
+		if errors.Is(err, repository.ErrSignalJobNotDead) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Error("failed to retry signal job")
+			writeError(w, http.StatusInternalServerError, "failed to retry signal job")
+			return
+		}
+		if job == nil {
+			writeError(w, http.StatusNotFound, "signal job not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeSignalJobs struct {
+	status string
+	rows   []model.SignalJob
+}
+
+func (f *fakeSignalJobs) List(_ context.Context, status string, _ repository.Pagination) ([]model.SignalJob, error) {
+	f.status = status
+	return f.rows, nil
+}
+
+func (f *fakeSignalJobs) Retry(_ context.Context, id uint, now time.Time) (*model.SignalJob, error) {
+	for i := range f.rows {
+		if f.rows[i].ID != id {
+			continue
+		}
+		if f.rows[i].Status != model.SignalJobDead {
+			return nil, repository.ErrSignalJobNotDead
+		}
+		f.rows[i].Status, f.rows[i].Attempts, f.rows[i].VisibleAt = model.SignalJobQueued, 0, now
+		return &f.rows[i], nil
+	}
+	return nil, nil
+}
+
+func TestSignalJobsHandler(t *testing.T) {
+	store := &fakeSignalJobs{rows: []model.SignalJob{
+		{ID: 4, SignalID: 9, UserID: 1, Status: model.SignalJobDead, Attempts: 5, LastError: "exchange down"},
+		{ID: 5, SignalID: 9, UserID: 2, Status: model.SignalJobDone, Attempts: 1},
+	}}
+	now := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	r := chi.NewRouter()
+	r.Get("/admin/signal-jobs", signalJobsHandler(store))
+	r.Post("/admin/signal-jobs/{id}/retry", retrySignalJobHandler(store, func() time.Time { return now }))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/signal-jobs?status=dead", nil))
+	if rec.Code != http.StatusOK || store.status != model.SignalJobDead {
+		t.Fatalf("status = %d filter=%q body=%s", rec.Code, store.status, rec.Body.String())
+	}
+	var got []model.SignalJob
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 2 || got[0].LastError != "exchange down" {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/signal-jobs?status=lost", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected an unknown status rejected, got %d", rec.Code)
+	}
+
+	for path, want := range map[string]int{
+		"/admin/signal-jobs/4/retry":   http.StatusOK,
+		"/admin/signal-jobs/5/retry":   http.StatusConflict,
+		"/admin/signal-jobs/6/retry":   http.StatusNotFound,
+		"/admin/signal-jobs/abc/retry": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		if rec.Code != want {
+			t.Fatalf("%s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+	if store.rows[0].Status != model.SignalJobQueued || !store.rows[0].VisibleAt.Equal(now) {
+		t.Fatalf("expected job 4 queued again, got %+v", store.rows[0])
+	}
+}
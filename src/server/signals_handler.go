@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+type signalSummaryStore interface {
+	Refresh(ctx context.Context, signalID uint) (*model.SignalExecutionSummary, error)
+	List(ctx context.Context, page repository.Pagination) ([]model.SignalExecutionSummary, error)
+}
+
+// signalSummariesHandler serves GET /admin/signals with the paging
+// parameters of ordersHandler, the cursor being a signal id: how each
+// signal executed across all the accounts it reached.
+func signalSummariesHandler(summaries signalSummaryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, msg := parsePagination(r.URL.Query())
+		if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		rows, err := summaries.List(r.Context(), page)
+		if err != nil {
+			logger.WithError(err).Error("failed to list signal summaries")
+			writeError(w, http.StatusInternalServerError, "failed to list signal summaries")
+			return
+		}
+		if rows == nil {
+			rows = []model.SignalExecutionSummary{}
+		}
+		if len(rows) > 0 {
+			setNextCursor(w, page, len(rows), rows[len(rows)-1].SignalID)
+		}
+
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// signalSummaryHandler serves GET /admin/signals/{id}/summary. The summary
+// is rebuilt first, so entries settled since the executors last stored it
+// (e.g. approved ones) are counted.
+func signalSummaryHandler(summaries signalSummaryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		signalID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || signalID == 0 {
+			writeError(w, http.StatusBadRequest, "id must be a positive integer")
+			return
+		}
+
+		summary, err := summaries.Refresh(r.Context(), uint(signalID))
+		if err != nil {
+			logger.WithError(err).Error("failed to refresh signal summary")
+			writeError(w, http.StatusInternalServerError, "failed to refresh signal summary")
+			return
+		}
+		if summary.Accounts == 0 {
+			writeError(w, http.StatusNotFound, "signal not executed for any account")
+			return
+		}
+		writeJSON(w, http.StatusOK, summary)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeSignalSummaries struct {
+	refreshed []uint
+	page      repository.Pagination
+	rows      []model.SignalExecutionSummary
+}
+
+func (f *fakeSignalSummaries) Refresh(_ context.Context, signalID uint) (*model.SignalExecutionSummary, error) {
+	f.refreshed = append(f.refreshed, signalID)
+	for i := range f.rows {
+		if f.rows[i].SignalID == signalID {
+			return &f.rows[i], nil
+		}
+	}
+	return &model.SignalExecutionSummary{SignalID: signalID}, nil
+}
+
+func (f *fakeSignalSummaries) List(_ context.Context, page repository.Pagination) ([]model.SignalExecutionSummary, error) {
+	f.page = page
+	return f.rows, nil
+}
+
+func TestSignalSummariesHandler(t *testing.T) {
+	store := &fakeSignalSummaries{rows: []model.SignalExecutionSummary{
+		{SignalID: 9, Symbol: "BTCUSDT", Accounts: 3, Filled: 2, Errored: 1},
+	}}
+
+	rec := httptest.NewRecorder()
+	signalSummariesHandler(store)(rec, httptest.NewRequest(http.MethodGet, "/admin/signals?limit=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if store.page.Limit != 1 {
+		t.Fatalf("unexpected page: %+v", store.page)
+	}
+	if got := rec.Header().Get(nextCursorHeader); got != "9" {
+		t.Fatalf("next cursor = %q", got)
+	}
+	var got []model.SignalExecutionSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 1 || got[0].Errored != 1 {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+}
+
+func TestSignalSummaryHandler(t *testing.T) {
+	store := &fakeSignalSummaries{rows: []model.SignalExecutionSummary{
+		{SignalID: 9, Symbol: "BTCUSDT", Accounts: 2, Filled: 2, Clean: true},
+	}}
+	r := chi.NewRouter()
+	r.Get("/admin/signals/{id}/summary", signalSummaryHandler(store))
+
+	for path, want := range map[string]int{
+		"/admin/signals/9/summary":   http.StatusOK,
+		"/admin/signals/10/summary":  http.StatusNotFound,
+		"/admin/signals/abc/summary": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Fatalf("%s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/signals/9/summary", nil))
+	var got model.SignalExecutionSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || !got.Clean || got.Filled != 2 {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+	if len(store.refreshed) != 3 || store.refreshed[0] == 0 {
+		t.Fatalf("refreshed = %v", store.refreshed)
+	}
+}
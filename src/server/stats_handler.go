@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/strategy/signalfollower"
+	"strconv"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	statsByStrategy = "strategy"
+	statsBySymbol   = "symbol"
+	statsByExchange = "exchange"
+)
+
+type strategyLister interface {
+	ListByUser(ctx context.Context, userID uint) ([]model.Strategy, error)
+}
+
+type exchangeFinder interface {
+	FindByID(ctx context.Context, id uint) (*model.Exchange, error)
+}
+
+// statsResponse is the performance of the closed trades of a user grouped by
+// GroupBy.
+type statsResponse struct {
+	GroupBy string               `json:"group_by"`
+	Groups  []report.Performance `json:"groups"`
+}
+
+// statsHandler serves GET /api/stats?group_by=strategy|symbol|exchange&symbol=&from=&to=,
+// the win rate, profit factor, average R, expectancy and monthly PnL of the
+// closed trades of the authenticated user. Answers are cached for ttl.
+func statsHandler(trades tradeLister, strategies strategyLister, exchanges exchangeFinder, ttl time.Duration, now func() time.Time) http.HandlerFunc {
+	cache := newStatsCache(ttl, now)
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, ok := tradeFilter(w, r)
+		if !ok {
+			return
+		}
+		filter.Status = model.TradeStatusClosed
+
+		groupBy := r.URL.Query().Get("group_by")
+		if groupBy == "" {
+			groupBy = statsByStrategy
+		}
+		if groupBy != statsByStrategy && groupBy != statsBySymbol && groupBy != statsByExchange {
+			writeError(w, http.StatusBadRequest, "group_by must be strategy, symbol or exchange")
+			return
+		}
+
+		cacheKey := fmt.Sprintf("%d|%s", filter.UserID, r.URL.RawQuery)
+		if resp, ok := cache.get(cacheKey); ok {
+			writeJSON(w, http.StatusOK, resp)
+			return
+		}
+
+		rows, err := trades.List(r.Context(), filter)
+		if err != nil {
+			logger.WithError(err).Error("failed to list trades")
+			writeError(w, http.StatusInternalServerError, "failed to list trades")
+			return
+		}
+
+		key, err := statsKey(r.Context(), groupBy, filter.UserID, rows, strategies, exchanges)
+		if err != nil {
+			logger.WithError(err).WithField("group_by", groupBy).Error("failed to label trades")
+			writeError(w, http.StatusInternalServerError, "failed to compute stats")
+			return
+		}
+
+		resp := statsResponse{GroupBy: groupBy, Groups: report.SummarizePerformance(rows, key)}
+		cache.set(cacheKey, resp)
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// statsKey returns the grouping of trades: the strategy name, the symbol or
+// the exchange name. Trades without a strategy ran the signal follower.
+func statsKey(
+	ctx context.Context,
+	groupBy string,
+	userID uint,
+	trades []model.Trade,
+	strategies strategyLister,
+	exchanges exchangeFinder,
+) (func(model.Trade) string, error) {
+	switch groupBy {
+	case statsBySymbol:
+		return func(t model.Trade) string { return t.Symbol }, nil
+
+	case statsByExchange:
+		names := map[uint]string{}
+		for _, t := range trades {
+			if _, ok := names[t.ExchangeID]; ok {
+				continue
+			}
+			ex, err := exchanges.FindByID(ctx, t.ExchangeID)
+			if err != nil {
+				return nil, err
+			}
+			names[t.ExchangeID] = strconv.FormatUint(uint64(t.ExchangeID), 10)
+			if ex != nil {
+				names[t.ExchangeID] = ex.Name
+			}
+		}
+		return func(t model.Trade) string { return names[t.ExchangeID] }, nil
+
+	default:
+		rows, err := strategies.ListByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[uint]string, len(rows))
+		for _, s := range rows {
+			names[s.ID] = s.Name
+		}
+		return func(t model.Trade) string {
+			if t.StrategyID == nil {
+				return signalfollower.Name
+			}
+			if name, ok := names[*t.StrategyID]; ok {
+				return name
+			}
+			return fmt.Sprintf("strategy %d", *t.StrategyID)
+		}, nil
+	}
+}
+
+// statsCache keeps the stats answers for ttl.
+type statsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]statsCacheEntry
+}
+
+type statsCacheEntry struct {
+	resp      statsResponse
+	expiresAt time.Time
+}
+
+func newStatsCache(ttl time.Duration, now func() time.Time) *statsCache {
+	return &statsCache{ttl: ttl, now: now, entries: map[string]statsCacheEntry{}}
+}
+
+func (c *statsCache) get(key string) (statsResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expiresAt) {
+		return statsResponse{}, false
+	}
+	return e.resp, true
+}
+
+// set stores resp and drops the expired entries, so the cache only holds the
+// answers of the last ttl.
+func (c *statsCache) set(key string, resp statsResponse) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = statsCacheEntry{resp: resp, expiresAt: now.Add(c.ttl)}
+}
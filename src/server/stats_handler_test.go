@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/strategy/signalfollower"
+	"testing"
+	"time"
+)
+
+type fakeStrategyLister struct{ rows []model.Strategy }
+
+func (f fakeStrategyLister) ListByUser(context.Context, uint) ([]model.Strategy, error) {
+	return f.rows, nil
+}
+
+type fakeExchangeFinder map[uint]string
+
+func (f fakeExchangeFinder) FindByID(_ context.Context, id uint) (*model.Exchange, error) {
+	name, ok := f[id]
+	if !ok {
+		return nil, nil
+	}
+	return &model.Exchange{ID: id, Name: name}, nil
+}
+
+func TestStatsHandler(t *testing.T) {
+	pnl := func(v float64) *float64 { return &v }
+	exit := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	strategyID := uint(4)
+	lister := &fakeTradeLister{rows: []model.Trade{
+		{ID: 1, ExchangeID: 1, Symbol: "BTCUSDT", Status: model.TradeStatusClosed, PnL: pnl(10), ExitTime: &exit, StrategyID: &strategyID},
+		{ID: 2, ExchangeID: 2, Symbol: "BTCUSDT", Status: model.TradeStatusClosed, PnL: pnl(-5), ExitTime: &exit},
+		{ID: 3, ExchangeID: 1, Symbol: "ETHUSDT", Status: model.TradeStatusClosed, PnL: pnl(4), ExitTime: &exit},
+	}}
+	now := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	h := statsHandler(lister, fakeStrategyLister{rows: []model.Strategy{{ID: 4, Name: "breakout"}}},
+		fakeExchangeFinder{1: "phemex", 2: "kucoin"}, time.Minute, func() time.Time { return now })
+
+	stats := func(query string) statsResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h(rec, authedRequest(http.MethodGet, "/api/stats"+query, 3))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+		}
+		var got statsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return got
+	}
+
+	got := stats("")
+	if got.GroupBy != "strategy" || len(got.Groups) != 2 ||
+		got.Groups[0].Key != "breakout" || got.Groups[1].Key != signalfollower.Name || got.Groups[1].Trades != 2 {
+		t.Fatalf("unexpected strategy stats: %+v", got)
+	}
+	if lister.filter.UserID != 3 || lister.filter.Status != model.TradeStatusClosed {
+		t.Fatalf("unexpected filter: %+v", lister.filter)
+	}
+
+	got = stats("?group_by=exchange")
+	if len(got.Groups) != 2 || got.Groups[0].Key != "kucoin" || got.Groups[1].Key != "phemex" || got.Groups[1].NetPnL != 14 {
+		t.Fatalf("unexpected exchange stats: %+v", got)
+	}
+
+	got = stats("?group_by=symbol")
+	if len(got.Groups) != 2 || got.Groups[0].Key != "BTCUSDT" || got.Groups[0].Monthly[0].Month != "2025-03" {
+		t.Fatalf("unexpected symbol stats: %+v", got)
+	}
+
+	// answers are cached until the ttl passes
+	lister.rows = nil
+	if got := stats("?group_by=symbol"); len(got.Groups) != 2 {
+		t.Fatalf("expected the cached answer, got %+v", got)
+	}
+	now = now.Add(2 * time.Minute)
+	if got := stats("?group_by=symbol"); len(got.Groups) != 0 {
+		t.Fatalf("expected a fresh answer after the ttl, got %+v", got)
+	}
+
+	rec := httptest.NewRecorder()
+	h(rec, authedRequest(http.MethodGet, "/api/stats?group_by=month", 3))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strategyexecutor/src/export"
+	"strategyexecutor/src/report"
+	"strconv"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type gainsReporter interface {
+	RealizedGains(ctx context.Context, userID uint, year int, method string) ([]report.RealizedGain, error)
+}
+
+// taxReport is the JSON answer of taxReportHandler.
+type taxReport struct {
+	Year    int                    `json:"year"`
+	Method  string                 `json:"method"`
+	Summary []report.CurrencyGains `json:"summary"`
+	Gains   []report.RealizedGain  `json:"gains"`
+}
+
+// taxReportHandler serves GET /api/tax-report?year=2025, the gains the
+// authenticated user realized in a UTC calendar year, lot by lot and summed
+// per currency. method is fifo (the default) or average; format is json
+// (the default), csv or xlsx, the files holding the lots only.
+func taxReportHandler(gains gainsReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		q := r.URL.Query()
+		year, err := strconv.Atoi(q.Get("year"))
+		if err != nil || year < 2000 || year > 9999 {
+			writeError(w, http.StatusBadRequest, "year must be a calendar year, e.g. 2025")
+			return
+		}
+		method := q.Get("method")
+		if method == "" {
+			method = report.LotsFIFO
+		}
+		if method != report.LotsFIFO && method != report.LotsAverage {
+			writeError(w, http.StatusBadRequest, "method must be fifo or average")
+			return
+		}
+		format := q.Get("format")
+		switch format {
+		case "", "json", export.FormatCSV, export.FormatXLSX:
+		default:
+			writeError(w, http.StatusBadRequest, "format must be json, csv or xlsx")
+			return
+		}
+
+		rows, err := gains.RealizedGains(r.Context(), userID, year, method)
+		if err != nil {
+			logger.WithError(err).Error("failed to compute realized gains")
+			writeError(w, http.StatusInternalServerError, "failed to compute realized gains")
+			return
+		}
+		if rows == nil {
+			rows = []report.RealizedGain{}
+		}
+
+		if format == "" || format == "json" {
+			writeJSON(w, http.StatusOK, taxReport{
+				Year:    year,
+				Method:  method,
+				Summary: report.SummarizeGains(rows),
+				Gains:   rows,
+			})
+			return
+		}
+
+		name := fmt.Sprintf("gains-%d-%s", year, method)
+		w.Header().Set("Content-Type", export.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+		table, err := export.NewTable(format, w, name)
+		if err == nil {
+			err = export.WriteGains(table, rows)
+		}
+		if err == nil {
+			err = table.Close()
+		}
+		if err != nil {
+			logger.WithError(err).WithField("user_id", userID).Error("failed to write realized gains")
+		}
+	}
+}
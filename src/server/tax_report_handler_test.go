@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/report"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+type fakeGainsReporter struct {
+	userID uint
+	year   int
+	method string
+}
+
+func (f *fakeGainsReporter) RealizedGains(_ context.Context, userID uint, year int, method string) ([]report.RealizedGain, error) {
+	f.userID, f.year, f.method = userID, year, method
+	return []report.RealizedGain{
+		{Symbol: "BTCUSDT", Currency: "USDT", Gain: decimal.NewFromInt(60)},
+		{Symbol: "ETHUSDT", Currency: "USDT", Gain: decimal.NewFromInt(-10)},
+	}, nil
+}
+
+func TestTaxReportHandler(t *testing.T) {
+	gains := &fakeGainsReporter{}
+	h := taxReportHandler(gains)
+
+	rec := httptest.NewRecorder()
+	h(rec, authedRequest(http.MethodGet, "/api/tax-report?year=2025", 3))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	if gains.userID != 3 || gains.year != 2025 || gains.method != report.LotsFIFO {
+		t.Fatalf("unexpected query %+v", gains)
+	}
+	var got struct {
+		Summary []struct {
+			Currency  string `json:"currency"`
+			Disposals int    `json:"disposals"`
+			Gain      string `json:"gain"`
+		} `json:"summary"`
+		Gains []json.RawMessage `json:"gains"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got.Gains) != 2 || len(got.Summary) != 1 || got.Summary[0].Gain != "50" || got.Summary[0].Disposals != 2 {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h(rec, authedRequest(http.MethodGet, "/api/tax-report?year=2025&method=average&format=csv", 3))
+	if rec.Code != http.StatusOK || gains.method != report.LotsAverage || strings.Count(rec.Body.String(), "\n") != 3 {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	for _, path := range []string{"/api/tax-report", "/api/tax-report?year=2025&method=lifo", "/api/tax-report?year=2025&format=pdf"} {
+		rec = httptest.NewRecorder()
+		h(rec, authedRequest(http.MethodGet, path, 3))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"strategyexecutor/src/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// traceRequests starts a server span per request, continuing the caller's
+// trace when it sent a traceparent header. The span is named after the chi
+// route pattern once routing is done, so ids in the path do not explode the
+// number of span names.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.StartKind(ctx, tracing.SpanKindServer, "HTTP "+r.Method,
+			tracing.Attr("http.method", r.Method),
+			tracing.Attr("http.target", r.URL.Path),
+		)
+		if span == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(ww, r)
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(tracing.Attr("http.route", rctx.RoutePattern()))
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(tracing.Attr("http.status_code", status))
+		if status >= 500 {
+			span.RecordError(errStatus(status))
+		}
+	})
+}
+
+type errStatus int
+
+func (e errStatus) Error() string { return http.StatusText(int(e)) }
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/repository"
+	"strconv"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultTradesLimit = 100
+	maxTradesLimit     = 1000
+)
+
+type tradeLister interface {
+	List(ctx context.Context, filter repository.TradeFilter) ([]model.Trade, error)
+}
+
+type tradeNoteLister interface {
+	FindByOrderIDs(ctx context.Context, orderIDs []uint) ([]model.OrderNote, error)
+}
+
+// tradesHandler serves GET /api/trades?symbol=&status=&from=&to=&limit= for
+// the authenticated user, with the notes of their orders. from and to are
+// RFC3339 timestamps applied to the entry time.
+func tradesHandler(trades tradeLister, notes tradeNoteLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, ok := tradeFilter(w, r)
+		if !ok {
+			return
+		}
+		q := r.URL.Query()
+
+		filter.Limit = defaultTradesLimit
+		if v := q.Get("limit"); v != "" {
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit <= 0 {
+				writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			filter.Limit = min(limit, maxTradesLimit)
+		}
+
+		rows, err := trades.List(r.Context(), filter)
+		if err != nil {
+			logger.WithError(err).Error("failed to list trades")
+			writeError(w, http.StatusInternalServerError, "failed to list trades")
+			return
+		}
+		if rows == nil {
+			rows = []model.Trade{}
+		}
+		tradeNotes, err := notes.FindByOrderIDs(r.Context(), report.TradeOrderIDs(rows))
+		if err != nil {
+			logger.WithError(err).Error("failed to list trade notes")
+			writeError(w, http.StatusInternalServerError, "failed to list trades")
+			return
+		}
+		report.AttachNotes(rows, tradeNotes)
+
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// tradesSummaryHandler serves GET /api/trades/summary?symbol=&from=&to=, the
+// R multiple statistics of the closed trades of the authenticated user.
+func tradesSummaryHandler(trades tradeLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, ok := tradeFilter(w, r)
+		if !ok {
+			return
+		}
+		filter.Status = model.TradeStatusClosed
+
+		rows, err := trades.List(r.Context(), filter)
+		if err != nil {
+			logger.WithError(err).Error("failed to list trades")
+			writeError(w, http.StatusInternalServerError, "failed to list trades")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, report.SummarizeR(rows))
+	}
+}
+
+// tradesExcursionsHandler serves GET /api/trades/excursions?symbol=&from=&to=,
+// the MAE/MFE statistics in R of the closed trades of the authenticated user.
+func tradesExcursionsHandler(trades tradeLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, ok := tradeFilter(w, r)
+		if !ok {
+			return
+		}
+		filter.Status = model.TradeStatusClosed
+
+		rows, err := trades.List(r.Context(), filter)
+		if err != nil {
+			logger.WithError(err).Error("failed to list trades")
+			writeError(w, http.StatusInternalServerError, "failed to list trades")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, report.SummarizeExcursions(rows))
+	}
+}
+
+// tradeFilter reads the user, symbol, status, from and to of a trades
+// request. It writes the error response and returns false when invalid.
+func tradeFilter(w http.ResponseWriter, r *http.Request) (repository.TradeFilter, bool) {
+	userID, ok := requestUserID(w, r)
+	if !ok {
+		return repository.TradeFilter{}, false
+	}
+	q := r.URL.Query()
+
+	var err error
+	filter := repository.TradeFilter{
+		UserID: userID,
+		Symbol: q.Get("symbol"),
+		Status: q.Get("status"),
+	}
+
+	if v := q.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, "from must be RFC3339")
+			return filter, false
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, "to must be RFC3339")
+			return filter, false
+		}
+	}
+	return filter, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.WithError(err).Error("failed to encode response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/report"
+	"strategyexecutor/src/repository"
+	"testing"
+	"time"
+)
+
+type fakeTradeLister struct {
+	filter repository.TradeFilter
+	rows   []model.Trade
+	err    error
+}
+
+func (f *fakeTradeLister) List(_ context.Context, filter repository.TradeFilter) ([]model.Trade, error) {
+	f.filter = filter
+	return f.rows, f.err
+}
+
+type fakeTradeNotes []model.OrderNote
+
+func (f fakeTradeNotes) FindByOrderIDs(_ context.Context, ids []uint) ([]model.OrderNote, error) {
+	var rows []model.OrderNote
+	for _, n := range f {
+		for _, id := range ids {
+			if n.OrderID == id {
+				rows = append(rows, n)
+			}
+		}
+	}
+	return rows, nil
+}
+
+func TestTradesHandler(t *testing.T) {
+	lister := &fakeTradeLister{rows: []model.Trade{{ID: 7, UserID: 3, Symbol: "BTCUSDT", EntryOrderID: 40}}}
+	h := tradesHandler(lister, fakeTradeNotes{{OrderID: 40, Body: "entered by hand"}, {OrderID: 41, Body: "other"}})
+
+	rec := httptest.NewRecorder()
+	// user_id in the query must not override the authenticated user.
+	h(rec, authedRequest(http.MethodGet, "/api/trades?user_id=9&symbol=BTCUSDT&from=2025-03-01T00:00:00Z&limit=5000", 3))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	var got []model.Trade
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != 7 || len(got[0].Notes) != 1 || got[0].Notes[0].Body != "entered by hand" {
+		t.Fatalf("unexpected body: %+v", got)
+	}
+	if lister.filter.UserID != 3 || lister.filter.Symbol != "BTCUSDT" || lister.filter.Limit != maxTradesLimit ||
+		!lister.filter.From.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected filter: %+v", lister.filter)
+	}
+}
+
+func TestTradesHandlerErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		url    string
+		userID uint
+		err    error
+		status int
+	}{
+		{name: "unauthenticated", url: "/api/trades?user_id=1", status: http.StatusUnauthorized},
+		{name: "bad from", url: "/api/trades?from=yesterday", userID: 1, status: http.StatusBadRequest},
+		{name: "bad limit", url: "/api/trades?limit=-1", userID: 1, status: http.StatusBadRequest},
+		{name: "repository error", url: "/api/trades", userID: 1, err: errors.New("boom"), status: http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tradesHandler(&fakeTradeLister{err: tc.err}, fakeTradeNotes{})(rec, authedRequest(http.MethodGet, tc.url, tc.userID))
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+}
+
+func TestTradesSummaryHandler(t *testing.T) {
+	r := func(v float64) *float64 { return &v }
+	lister := &fakeTradeLister{rows: []model.Trade{
+		{ID: 1, Status: model.TradeStatusClosed, RMultiple: r(2)},
+		{ID: 2, Status: model.TradeStatusClosed, RMultiple: r(-1)},
+	}}
+
+	rec := httptest.NewRecorder()
+	tradesSummaryHandler(lister)(rec, authedRequest(http.MethodGet, "/api/trades/summary?symbol=BTCUSDT&status=open", 3))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	var got report.RStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Trades != 2 || got.TotalR != 1 || got.Expectancy != 0.5 {
+		t.Fatalf("unexpected summary: %+v", got)
+	}
+	if lister.filter.UserID != 3 || lister.filter.Status != model.TradeStatusClosed || lister.filter.Limit != 0 {
+		t.Fatalf("unexpected filter: %+v", lister.filter)
+	}
+}
+
+func TestTradesExcursionsHandler(t *testing.T) {
+	r := func(v float64) *float64 { return &v }
+	lister := &fakeTradeLister{rows: []model.Trade{
+		{ID: 1, Status: model.TradeStatusClosed, PnL: r(10), MAER: r(0.5), MFER: r(2)},
+		{ID: 2, Status: model.TradeStatusClosed, PnL: r(-5), MAER: r(1), MFER: r(0.5)},
+	}}
+
+	rec := httptest.NewRecorder()
+	tradesExcursionsHandler(lister)(rec, authedRequest(http.MethodGet, "/api/trades/excursions?symbol=BTCUSDT", 3))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	var got report.ExcursionStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Trades != 2 || got.AvgMAER != 0.75 || got.WinnerMAERP90 != 0.5 {
+		t.Fatalf("unexpected excursions: %+v", got)
+	}
+	if lister.filter.UserID != 3 || lister.filter.Symbol != "BTCUSDT" || lister.filter.Status != model.TradeStatusClosed {
+		t.Fatalf("unexpected filter: %+v", lister.filter)
+	}
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+	logger "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type userRoleStore interface {
+	SetRole(ctx context.Context, id uint, role string) (bool, error)
+}
+
+type userExchangeEditor interface {
+	GetByUserAndExchange(ctx context.Context, userID uint, exchangeID uint) (*model.UserExchange, error)
+	Update(ctx context.Context, ue *model.UserExchange) error
+}
+
+type userRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// userExchangeEdit are the settings of an exchange account an admin may
+// change; absent fields are kept.
+type userExchangeEdit struct {
+	RunOnServer      *bool `json:"run_on_server"`
+	Shadow           *bool `json:"shadow"`
+	OrderSizePercent *int  `json:"order_size_percent"`
+	// Leverage is set before entries whose signal has none, 0 clears it.
+	Leverage *decimal.Decimal `json:"leverage"`
+	// EntryMode is "market" or "passive", see model.UserExchange.
+	EntryMode *string `json:"entry_mode"`
+}
+
+// pathID parses the positive integer URL parameter name, writing a 400
+// when it is not one.
+func pathID(w http.ResponseWriter, r *http.Request, name string) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, name), 10, 64)
+	if err != nil || id == 0 {
+		writeError(w, http.StatusBadRequest, name+" must be a positive integer")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// setUserRoleHandler serves PUT /api/users/{userID}/role for admins.
+func setUserRoleHandler(users userRoleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		userID, ok := pathID(w, r, "userID")
+		if !ok {
+			return
+		}
+		var body userRoleRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		if _, known := roleRanks[body.Role]; !known {
+			writeError(w, http.StatusBadRequest, "role must be admin, operator or viewer")
+			return
+		}
+		if userID == adminID && body.Role != model.RoleAdmin {
+			writeError(w, http.StatusBadRequest, "admins cannot demote themselves")
+			return
+		}
+
+		found, err := users.SetRole(r.Context(), userID, body.Role)
+		if err != nil {
+			logger.WithError(err).Error("failed to set user role")
+			writeError(w, http.StatusInternalServerError, "failed to set user role")
+			return
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		logger.WithFields(logger.Fields{"admin_id": adminID, "user_id": userID, "role": body.Role}).Info("user role changed")
+		writeJSON(w, http.StatusOK, userRoleRequest{Role: body.Role})
+	}
+}
+
+// editUserExchangeHandler serves PATCH /api/users/{userID}/exchanges/{exchangeID}
+// for admins, which edits the exchange account of any user.
+func editUserExchangeHandler(userExchanges userExchangeEditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminID, ok := requestUserID(w, r)
+		if !ok {
+			return
+		}
+		userID, ok := pathID(w, r, "userID")
+		if !ok {
+			return
+		}
+		exchangeID, ok := pathID(w, r, "exchangeID")
+		if !ok {
+			return
+		}
+		var body userExchangeEdit
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body")
+			return
+		}
+		if body.OrderSizePercent != nil && (*body.OrderSizePercent < 1 || *body.OrderSizePercent > 100) {
+			writeError(w, http.StatusBadRequest, "order_size_percent must be between 1 and 100")
+			return
+		}
+		if body.Leverage != nil && body.Leverage.IsNegative() {
+			writeError(w, http.StatusBadRequest, "leverage must not be negative")
+			return
+		}
+		if body.EntryMode != nil && *body.EntryMode != model.EntryModeMarket && *body.EntryMode != model.EntryModePassive {
+			writeError(w, http.StatusBadRequest, "entry_mode must be market or passive")
+			return
+		}
+
+		// The repositories scope to the authenticated user, act as the owner.
+		ctx := auth.WithUserID(r.Context(), userID)
+		ue, err := userExchanges.GetByUserAndExchange(ctx, userID, exchangeID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, http.StatusNotFound, "user exchange not found")
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Error("failed to load user exchange")
+			writeError(w, http.StatusInternalServerError, "failed to edit user exchange")
+			return
+		}
+
+		if body.RunOnServer != nil {
+			ue.RunOnServer = *body.RunOnServer
+		}
+		if body.Shadow != nil {
+			ue.Shadow = *body.Shadow
+		}
+		if body.OrderSizePercent != nil {
+			ue.OrderSizePercent = *body.OrderSizePercent
+		}
+		if body.Leverage != nil {
+			ue.Leverage = *body.Leverage
+		}
+		if body.EntryMode != nil {
+			ue.EntryMode = *body.EntryMode
+		}
+		if err := userExchanges.Update(ctx, ue); err != nil {
+			logger.WithError(err).Error("failed to update user exchange")
+			writeError(w, http.StatusInternalServerError, "failed to edit user exchange")
+			return
+		}
+		logger.WithFields(logger.Fields{
+			"admin_id":      adminID,
+			"user_id":       userID,
+			"exchange_id":   exchangeID,
+			"run_on_server": ue.RunOnServer,
+			"shadow":        ue.Shadow,
+		}).Info("user exchange edited by admin")
+		writeJSON(w, http.StatusOK, ue)
+	}
+}
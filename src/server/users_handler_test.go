@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/auth"
+	"strategyexecutor/src/model"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type fakeRoleStore map[uint]string
+
+func (f fakeRoleStore) SetRole(_ context.Context, id uint, role string) (bool, error) {
+	if _, ok := f[id]; !ok {
+		return false, nil
+	}
+	f[id] = role
+	return true, nil
+}
+
+type fakeExchangeEditor struct {
+	rows    map[[2]uint]*model.UserExchange
+	ctxUser uint
+}
+
+func (f *fakeExchangeEditor) GetByUserAndExchange(ctx context.Context, userID, exchangeID uint) (*model.UserExchange, error) {
+	f.ctxUser, _ = auth.UserIDFromContext(ctx)
+	ue, ok := f.rows[[2]uint{userID, exchangeID}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *ue
+	return &cp, nil
+}
+
+func (f *fakeExchangeEditor) Update(_ context.Context, ue *model.UserExchange) error {
+	f.rows[[2]uint{ue.UserID, ue.ExchangeID}] = ue
+	return nil
+}
+
+func TestUserAdminHandlers(t *testing.T) {
+	roles := fakeRoleStore{1: model.RoleAdmin, 4: model.RoleOperator}
+	editor := &fakeExchangeEditor{rows: map[[2]uint]*model.UserExchange{
+		{4, 2}: {UserID: 4, ExchangeID: 2, RunOnServer: true, OrderSizePercent: 10},
+	}}
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req.WithContext(auth.WithUserID(req.Context(), 1)))
+		})
+	})
+	r.Put("/api/users/{userID}/role", setUserRoleHandler(roles))
+	r.Patch("/api/users/{userID}/exchanges/{exchangeID}", editUserExchangeHandler(editor))
+
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPut, "/api/users/4/role", `{"role":"viewer"}`, http.StatusOK},
+		{http.MethodPut, "/api/users/4/role", `{"role":"root"}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/users/1/role", `{"role":"viewer"}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/users/9/role", `{"role":"viewer"}`, http.StatusNotFound},
+		{http.MethodPatch, "/api/users/4/exchanges/2", `{"run_on_server":false,"order_size_percent":5,"leverage":3,"entry_mode":"passive"}`, http.StatusOK},
+		{http.MethodPatch, "/api/users/4/exchanges/2", `{"order_size_percent":0}`, http.StatusBadRequest},
+		{http.MethodPatch, "/api/users/4/exchanges/2", `{"leverage":-1}`, http.StatusBadRequest},
+		{http.MethodPatch, "/api/users/4/exchanges/2", `{"entry_mode":"twap"}`, http.StatusBadRequest},
+		{http.MethodPatch, "/api/users/4/exchanges/3", `{}`, http.StatusNotFound},
+		{http.MethodPatch, "/api/users/x/exchanges/2", `{}`, http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != tc.status {
+			t.Fatalf("%s %s %s: status = %d, want %d (%s)", tc.method, tc.path, tc.body, rec.Code, tc.status, rec.Body.String())
+		}
+	}
+
+	if roles[4] != model.RoleViewer {
+		t.Fatalf("role = %q", roles[4])
+	}
+	ue := editor.rows[[2]uint{4, 2}]
+	if ue.RunOnServer || ue.OrderSizePercent != 5 || !ue.Leverage.Equal(decimal.NewFromInt(3)) || ue.EntryMode != model.EntryModePassive {
+		t.Fatalf("unexpected account %+v", ue)
+	}
+	if editor.ctxUser != 4 {
+		t.Fatalf("loaded as user %d, want the owner", editor.ctxUser)
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/webhook"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	logger "github.com/sirupsen/logrus"
+)
+
+// maxWebhookBody bounds the body read before verification.
+const maxWebhookBody = 64 << 10
+
+type signalStore interface {
+	Create(ctx context.Context, signal *externalmodel.TradingSignal) error
+}
+
+// signalQueue stores a signal and queues it for the executors consuming it
+// in one transaction, see repository.SignalJobRepository.
+type signalQueue interface {
+	CreateAndEnqueue(ctx context.Context, signal *externalmodel.TradingSignal, since, now time.Time) (int, error)
+}
+
+type webhookResponse struct {
+	ID uint `json:"id"`
+}
+
+// webhookHandler serves POST /webhooks/{provider}. The request is verified
+// by the provider registered under that name, parsed into a signal and stored
+// for the executors; it answers 202 with the signal id. With a queue the
+// signal is stored through it instead, together with a job for every
+// executor that consumed its symbol within consumerTTL.
+func webhookHandler(providers *webhook.Registry, signals signalStore, queue signalQueue, consumerTTL time.Duration, now func() time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "provider")
+		provider, ok := providers.Lookup(name)
+		if !ok {
+			writeError(w, http.StatusNotFound, "unknown provider")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+		if err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, "body too large")
+			return
+		}
+
+		log := logger.WithFields(map[string]interface{}{
+			"provider": provider.Name,
+			"remote":   r.RemoteAddr,
+		})
+
+		if err := provider.Verifier.Verify(r, body); err != nil {
+			log.WithError(err).Warn("webhook rejected")
+			if errors.Is(err, webhook.ErrForbiddenIP) {
+				writeError(w, http.StatusForbidden, "forbidden")
+				return
+			}
+			writeError(w, http.StatusUnauthorized, "authentication failed")
+			return
+		}
+
+		signal, err := provider.Parse(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		received := now().UTC()
+		signal.ReceivedAt = &received
+
+		if queue != nil {
+			queued, err := queue.CreateAndEnqueue(r.Context(), signal, received.Add(-consumerTTL), received)
+			if err != nil {
+				log.WithError(err).Error("failed to store and queue webhook signal")
+				writeError(w, http.StatusInternalServerError, "failed to store signal")
+				return
+			}
+			log = log.WithField("queued", queued)
+		} else if err := signals.Create(r.Context(), signal); err != nil {
+			log.WithError(err).Error("failed to store webhook signal")
+			writeError(w, http.StatusInternalServerError, "failed to store signal")
+			return
+		}
+
+		log.WithFields(map[string]interface{}{
+			"signal_id": signal.ID,
+			"symbol":    signal.Symbol,
+			"action":    signal.Action,
+		}).Info("webhook signal stored")
+		writeJSON(w, http.StatusAccepted, webhookResponse{ID: signal.ID})
+	}
+}
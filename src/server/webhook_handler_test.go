@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/webhook"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeSignalStore struct {
+	stored []*externalmodel.TradingSignal
+}
+
+func (f *fakeSignalStore) Create(_ context.Context, signal *externalmodel.TradingSignal) error {
+	signal.ID = uint(len(f.stored) + 1)
+	f.stored = append(f.stored, signal)
+	return nil
+}
+
+func TestWebhookHandler(t *testing.T) {
+	providers, err := webhook.FromConfig(&webhook.Config{
+		TradingViewPassphrase: "pass",
+		TradingViewAllowedIPs: []string{"52.89.214.238"},
+		HMACSecrets:           map[string]string{"custom": "key"},
+		HMACHeader:            "X-Signature",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &fakeSignalStore{}
+	now := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	r := chi.NewRouter()
+	r.Post("/webhooks/{provider}", webhookHandler(providers, store, nil, 0, func() time.Time { return now }))
+
+	post := func(provider, remote, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/"+provider, strings.NewReader(body))
+		req.RemoteAddr = remote
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	signal := `{"passphrase":"pass","exchange":"phemex","symbol":"BTCUSDT","action":"buy"}`
+	rec := post("tradingview", "52.89.214.238:443", signal, nil)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp webhookResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.ID != 1 {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+	if got := store.stored[0]; got.Symbol != "BTCUSDT" || got.ReceivedAt == nil || !got.ReceivedAt.Equal(now) {
+		t.Fatalf("unexpected stored signal: %+v", got)
+	}
+
+	body := `{"exchange":"kucoin","symbol":"XBTUSDTM","action":"sell"}`
+	rec = post("custom", "8.8.8.8:1", body, http.Header{"X-Signature": {webhook.Sign("key", []byte(body))}})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("hmac status = %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	cases := []struct {
+		name     string
+		provider string
+		remote   string
+		body     string
+		status   int
+	}{
+		{"unknown provider", "nope", "52.89.214.238:443", signal, http.StatusNotFound},
+		{"address not allowed", "tradingview", "8.8.8.8:1", signal, http.StatusForbidden},
+		{"bad passphrase", "tradingview", "52.89.214.238:443", strings.Replace(signal, `"pass"`, `"x"`, 1), http.StatusUnauthorized},
+		{"unsigned", "custom", "8.8.8.8:1", body, http.StatusUnauthorized},
+		{"invalid signal", "tradingview", "52.89.214.238:443", `{"passphrase":"pass","action":"buy"}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := post(tc.provider, tc.remote, tc.body, nil); rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+	if len(store.stored) != 2 {
+		t.Fatalf("rejected requests were stored: %d", len(store.stored))
+	}
+}
+
+type fakeSignalQueue struct {
+	signalIDs []uint
+	since     time.Time
+	err       error
+}
+
+func (f *fakeSignalQueue) CreateAndEnqueue(_ context.Context, signal *externalmodel.TradingSignal, since, _ time.Time) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	signal.ID = uint(len(f.signalIDs) + 1)
+	f.signalIDs = append(f.signalIDs, signal.ID)
+	f.since = since
+	return 1, nil
+}
+
+func TestWebhookHandlerQueuesSignals(t *testing.T) {
+	providers, err := webhook.FromConfig(&webhook.Config{HMACSecrets: map[string]string{"custom": "key"}, HMACHeader: "X-Signature"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &fakeSignalStore{}
+	queue := &fakeSignalQueue{}
+	now := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+	r := chi.NewRouter()
+	r.Post("/webhooks/{provider}", webhookHandler(providers, store, queue, time.Hour, func() time.Time { return now }))
+
+	post := func() int {
+		body := `{"exchange":"kucoin","symbol":"XBTUSDTM","action":"sell"}`
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/custom", strings.NewReader(body))
+		req.Header.Set("X-Signature", webhook.Sign("key", []byte(body)))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post(); code != http.StatusAccepted {
+		t.Fatalf("status = %d", code)
+	}
+	if len(queue.signalIDs) != 1 || queue.signalIDs[0] != 1 || !queue.since.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("expected signal 1 queued for the consumers of the last hour, got %v since %v", queue.signalIDs, queue.since)
+	}
+
+	queue.err = errors.New("db down")
+	if code := post(); code != http.StatusInternalServerError {
+		t.Fatalf("expected a queue failure to fail the request, got %d", code)
+	}
+	if len(store.stored) != 0 || len(queue.signalIDs) != 1 {
+		t.Fatalf("expected signals stored through the queue only, got %d stored and %v queued", len(store.stored), queue.signalIDs)
+	}
+}
@@ -0,0 +1,162 @@
+package signalfilter
+
+import (
+	"fmt"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/tp_sl"
+	"time"
+)
+
+const (
+	defaultNewsLookback       = 6 * time.Hour
+	defaultVolatilityLookback = time.Hour
+	defaultPatternInterval    = 15 * time.Minute
+	// fundingInterval is the funding period of the USDT-M perpetuals traded.
+	fundingInterval = 8 * time.Hour
+)
+
+// Sources are the data dependencies of the filters. A filter whose source is
+// nil is left out of the chain.
+type Sources struct {
+	News      NewsSentimentSource
+	Candles   CandleSource
+	Quotes    QuoteSource
+	Positions PositionSource
+	Trades    TradeSource
+	Streaks   StreakSource
+	Funding   FundingSource
+	Live      LiveFundingSource
+	Schedules ScheduleSource
+	StopOuts  StopOutSource
+	Entries   DailyEntrySource
+}
+
+// Build returns the chain configured by setting, in a fixed order from the
+// cheapest to the most expensive check.
+func Build(setting model.SignalFilterSetting, src Sources) (Chain, error) {
+	var chain Chain
+
+	if setting.TradingStartHour != setting.TradingEndHour {
+		if !validHour(setting.TradingStartHour) || !validHour(setting.TradingEndHour) {
+			return nil, fmt.Errorf("invalid trading hours %d-%d", setting.TradingStartHour, setting.TradingEndHour)
+		}
+		loc := time.UTC
+		if setting.Timezone != "" {
+			var err error
+			if loc, err = time.LoadLocation(setting.Timezone); err != nil {
+				return nil, fmt.Errorf("invalid timezone %q: %w", setting.Timezone, err)
+			}
+		}
+		chain = append(chain, TimeOfDay{StartHour: setting.TradingStartHour, EndHour: setting.TradingEndHour, Location: loc})
+	}
+
+	if src.Schedules != nil {
+		chain = append(chain, Schedule{Rules: src.Schedules})
+	}
+
+	if setting.LossCooldownMinutes > 0 && src.Trades != nil {
+		chain = append(chain, LossCooldown{
+			Trades:   src.Trades,
+			Cooldown: time.Duration(setting.LossCooldownMinutes) * time.Minute,
+		})
+	}
+
+	if setting.MaxConsecutiveLosses > 0 && src.Streaks != nil {
+		chain = append(chain, LossStreak{Streaks: src.Streaks})
+	}
+
+	if setting.MaxDailyEntries > 0 && src.Entries != nil {
+		chain = append(chain, DailyEntries{Counts: src.Entries, Max: setting.MaxDailyEntries})
+	}
+
+	if setting.StopOutCooldownMinutes > 0 && src.StopOuts != nil {
+		f := StopOutCooldown{
+			StopOuts: src.StopOuts,
+			Cooldown: time.Duration(setting.StopOutCooldownMinutes) * time.Minute,
+		}
+		if setting.StopOutConfirmIntervalMinutes > 0 && src.Candles != nil {
+			f.Candles = src.Candles
+			f.ConfirmInterval = time.Duration(setting.StopOutConfirmIntervalMinutes) * time.Minute
+		}
+		chain = append(chain, f)
+	}
+
+	if setting.NewsSentimentThreshold > 0 && src.News != nil {
+		chain = append(chain, News{
+			Source:    src.News,
+			Threshold: setting.NewsSentimentThreshold,
+			Lookback:  minutesOr(setting.NewsSentimentLookbackMinutes, defaultNewsLookback),
+		})
+	}
+
+	if setting.MaxVolatilityPct > 0 && src.Candles != nil {
+		chain = append(chain, Volatility{
+			Candles:     src.Candles,
+			Lookback:    minutesOr(setting.VolatilityLookbackMinutes, defaultVolatilityLookback),
+			MaxRangePct: setting.MaxVolatilityPct,
+		})
+	}
+
+	if (setting.CandlePatternLong != "" || setting.CandlePatternShort != "") && src.Candles != nil {
+		f, err := candlePattern(setting, src.Candles)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, f)
+	}
+
+	if setting.MaxSpreadBps > 0 && src.Quotes != nil {
+		chain = append(chain, Spread{Quotes: src.Quotes, MaxBps: setting.MaxSpreadBps})
+	}
+
+	if setting.FundingDelayMinutes > 0 && (src.Funding != nil || src.Live != nil) {
+		chain = append(chain, FundingWindow{
+			Collected: src.Funding,
+			Live:      src.Live,
+			Window:    time.Duration(setting.FundingDelayMinutes) * time.Minute,
+			MinRate:   setting.FundingDelayMinRateBps / 10000,
+			Interval:  fundingInterval,
+		})
+	}
+
+	if setting.MaxOpenPositions > 0 && src.Positions != nil {
+		chain = append(chain, MaxPositions{Positions: src.Positions, Max: setting.MaxOpenPositions})
+	}
+
+	return chain, nil
+}
+
+func candlePattern(setting model.SignalFilterSetting, candles CandleSource) (CandlePattern, error) {
+	f := CandlePattern{
+		Candles:  candles,
+		Interval: minutesOr(setting.CandlePatternIntervalMinutes, defaultPatternInterval),
+	}
+	if f.Interval != time.Minute && f.Interval != 5*time.Minute && f.Interval != 15*time.Minute &&
+		f.Interval != 30*time.Minute && f.Interval != 45*time.Minute {
+		return f, fmt.Errorf("invalid candle pattern interval %s", f.Interval)
+	}
+
+	var err error
+	if setting.CandlePatternLong != "" {
+		if f.Long, err = tp_sl.ParsePattern(setting.CandlePatternLong); err != nil {
+			return f, err
+		}
+	}
+	if setting.CandlePatternShort != "" {
+		if f.Short, err = tp_sl.ParsePattern(setting.CandlePatternShort); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+func validHour(h int) bool {
+	return h >= 0 && h <= 24
+}
+
+func minutesOr(minutes int, fallback time.Duration) time.Duration {
+	if minutes <= 0 {
+		return fallback
+	}
+	return time.Duration(minutes) * time.Minute
+}
@@ -0,0 +1,465 @@
+package signalfilter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/repository"
+	"strategyexecutor/src/sentiment"
+	"strategyexecutor/src/tp_sl"
+	"strings"
+	"time"
+)
+
+// NewsSentimentSource returns the net news sentiment of a symbol.
+type NewsSentimentSource interface {
+	NetSentimentForSymbol(ctx context.Context, symbol string, window time.Duration, now time.Time) (float64, int, error)
+}
+
+// CandleSource returns 1m candles of a symbol in [from, to].
+type CandleSource interface {
+	FetchOHLCV1mRange(ctx context.Context, symbol string, from, to time.Time) ([]model.OHLCVCrypto1m, error)
+}
+
+// QuoteSource returns the best bid and ask of a symbol.
+type QuoteSource interface {
+	BestBidAsk(symbol string) (bid float64, ask float64, err error)
+}
+
+// PositionSource returns the open positions of the account.
+type PositionSource interface {
+	GetPositionsUSDT() (*connectors.GAccountPositions, error)
+}
+
+// TradeSource returns the latest closed journal trade of a user/symbol, or
+// nil when there is none.
+type TradeSource interface {
+	FindLastClosed(ctx context.Context, userID, exchangeID uint, symbol string) (*model.Trade, error)
+}
+
+// FundingSource returns the latest collected funding snapshot of a symbol, or
+// nil when none was collected.
+type FundingSource interface {
+	GetLatestFundingRate(ctx context.Context, exchange, symbol string) (*model.FundingRate, error)
+}
+
+// LiveFundingSource reads the current funding of a symbol from the exchange.
+type LiveFundingSource interface {
+	GetFundingSnapshot(symbol string) (*connectors.FundingSnapshot, error)
+}
+
+// TimeOfDay allows entries only between StartHour (inclusive) and EndHour
+// (exclusive) in Location. A window where EndHour < StartHour wraps midnight.
+type TimeOfDay struct {
+	StartHour int
+	EndHour   int
+	Location  *time.Location
+}
+
+func (f TimeOfDay) Name() string { return "time_of_day" }
+
+func (f TimeOfDay) Evaluate(_ context.Context, e Entry) (Result, error) {
+	loc := f.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	hour := e.Now.In(loc).Hour()
+
+	inside := hour >= f.StartHour && hour < f.EndHour
+	if f.EndHour < f.StartHour {
+		inside = hour >= f.StartHour || hour < f.EndHour
+	}
+
+	reason := fmt.Sprintf("hour %02d %s, window %02d-%02d", hour, loc, f.StartHour, f.EndHour)
+	return Result{Allowed: inside, Reason: reason}, nil
+}
+
+// News blocks entries against a strong net news sentiment, see
+// sentiment.AllowsEntry.
+type News struct {
+	Source    NewsSentimentSource
+	Threshold float64
+	Lookback  time.Duration
+}
+
+func (f News) Name() string { return "news" }
+
+func (f News) Evaluate(ctx context.Context, e Entry) (Result, error) {
+	net, count, err := f.Source.NetSentimentForSymbol(ctx, e.Symbol, f.Lookback, e.Now)
+	if err != nil {
+		return Result{}, err
+	}
+
+	allowed, reason := sentiment.AllowsEntry(e.PosSide, net, f.Threshold)
+	return Result{
+		Allowed: allowed,
+		Reason:  fmt.Sprintf("%s, net %.2f over %d events, threshold %.2f", reason, net, count, f.Threshold),
+	}, nil
+}
+
+// Volatility blocks entries when the high-low range of the last Lookback of
+// 1m candles exceeds MaxRangePct percent of the last close.
+type Volatility struct {
+	Candles     CandleSource
+	Lookback    time.Duration
+	MaxRangePct float64
+}
+
+func (f Volatility) Name() string { return "volatility" }
+
+func (f Volatility) Evaluate(ctx context.Context, e Entry) (Result, error) {
+	candles, err := f.Candles.FetchOHLCV1mRange(ctx, e.Symbol, e.Now.Add(-f.Lookback), e.Now)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(candles) == 0 {
+		return Result{Allowed: true, Reason: "no candles in lookback"}, nil
+	}
+
+	high, low := candles[0].High, candles[0].Low
+	for _, c := range candles[1:] {
+		if c.High.GreaterThan(high) {
+			high = c.High
+		}
+		if c.Low.LessThan(low) {
+			low = c.Low
+		}
+	}
+	last := candles[len(candles)-1].Close
+	if !last.IsPositive() {
+		return Result{Allowed: true, Reason: "invalid last close"}, nil
+	}
+
+	rangePct := high.Sub(low).Div(last).InexactFloat64() * 100
+	return Result{
+		Allowed: rangePct <= f.MaxRangePct,
+		Reason:  fmt.Sprintf("range %.2f%% over %s, max %.2f%%", rangePct, f.Lookback, f.MaxRangePct),
+	}, nil
+}
+
+// CandlePattern allows entries only when the last closed candle of Interval
+// forms the pattern required for the position side, e.g. a bullish engulfing
+// 15m candle before a long. An empty pattern leaves that side unfiltered.
+type CandlePattern struct {
+	Candles  CandleSource
+	Interval time.Duration
+	Long     tp_sl.Pattern
+	Short    tp_sl.Pattern
+}
+
+func (f CandlePattern) Name() string { return "candle_pattern" }
+
+func (f CandlePattern) Evaluate(ctx context.Context, e Entry) (Result, error) {
+	want := f.Long
+	if e.PosSide == "Short" {
+		want = f.Short
+	}
+	if want == "" {
+		return Result{Allowed: true, Reason: "no pattern required for " + strings.ToLower(e.PosSide)}, nil
+	}
+
+	// The last two closed candles end at the start of the current bucket.
+	end := e.Now.UTC().Truncate(f.Interval)
+	candles, err := f.Candles.FetchOHLCV1mRange(ctx, e.Symbol, end.Add(-2*f.Interval), end.Add(-time.Minute))
+	if err != nil {
+		return Result{}, err
+	}
+	if f.Interval > time.Minute {
+		if candles, err = repository.AggregateOHLCVFrom1m(candles, f.Interval); err != nil {
+			return Result{}, err
+		}
+	}
+	if len(candles) == 0 {
+		return Result{}, fmt.Errorf("no %s candles before %s", f.Interval, end.Format(time.RFC3339))
+	}
+
+	found := tp_sl.DetectPatterns(candles)
+	return Result{
+		Allowed: tp_sl.HasPattern(candles, want),
+		Reason:  fmt.Sprintf("last %s candle %v, %s requires %s", f.Interval, found, strings.ToLower(e.PosSide), want),
+	}, nil
+}
+
+// Spread blocks entries when the bid/ask spread exceeds MaxBps basis points
+// of the mid price.
+type Spread struct {
+	Quotes QuoteSource
+	MaxBps float64
+}
+
+func (f Spread) Name() string { return "spread" }
+
+func (f Spread) Evaluate(_ context.Context, e Entry) (Result, error) {
+	bid, ask, err := f.Quotes.BestBidAsk(e.Symbol)
+	if err != nil {
+		return Result{}, err
+	}
+	if bid <= 0 || ask <= 0 || ask < bid {
+		return Result{}, fmt.Errorf("invalid quote for %s: bid %v ask %v", e.Symbol, bid, ask)
+	}
+
+	bps := (ask - bid) / ((ask + bid) / 2) * 10000
+	return Result{
+		Allowed: bps <= f.MaxBps,
+		Reason:  fmt.Sprintf("spread %.1fbps, max %.1fbps", bps, f.MaxBps),
+	}, nil
+}
+
+// MaxPositions blocks entries when the account already holds Max open
+// positions on other symbols. Positions on the entry symbol are ignored
+// because the controller closes them before opening the new one.
+type MaxPositions struct {
+	Positions PositionSource
+	Max       int
+}
+
+func (f MaxPositions) Name() string { return "max_positions" }
+
+func (f MaxPositions) Evaluate(_ context.Context, e Entry) (Result, error) {
+	positions, err := f.Positions.GetPositionsUSDT()
+	if err != nil {
+		return Result{}, err
+	}
+
+	open := 0
+	for _, p := range positions.Positions {
+		if p.Symbol == e.Symbol || p.SizeRq == "" || p.SizeRq == "0" {
+			continue
+		}
+		open++
+	}
+
+	return Result{
+		Allowed: open < f.Max,
+		Reason:  fmt.Sprintf("%d open positions on other symbols, max %d", open, f.Max),
+	}, nil
+}
+
+// StreakSource returns the persisted consecutive-loss state of a
+// user/symbol, or nil when none was recorded.
+type StreakSource interface {
+	Get(ctx context.Context, userID, exchangeID uint, symbol string) (*model.LossStreak, error)
+}
+
+// LossStreak blocks entries while the cooldown started after too many
+// consecutive losses is running. The state is maintained by
+// report.LossStreakTracker.
+type LossStreak struct {
+	Streaks StreakSource
+}
+
+func (f LossStreak) Name() string { return "loss_streak" }
+
+func (f LossStreak) Evaluate(ctx context.Context, e Entry) (Result, error) {
+	streak, err := f.Streaks.Get(ctx, e.UserID, e.ExchangeID, e.Symbol)
+	if err != nil {
+		return Result{}, err
+	}
+	if streak == nil {
+		return Result{Allowed: true, Reason: "no loss streak recorded"}, nil
+	}
+	if streak.InCooldown(e.Now) {
+		return Result{
+			Allowed: false,
+			Reason: fmt.Sprintf("%d consecutive losses, cooling down until %s",
+				streak.ConsecutiveLosses, streak.CooldownUntil.UTC().Format(time.RFC3339)),
+		}, nil
+	}
+	return Result{Allowed: true, Reason: fmt.Sprintf("%d consecutive losses", streak.ConsecutiveLosses)}, nil
+}
+
+// LossCooldown blocks entries for Cooldown after the last closed trade on the
+// symbol was a loss.
+type LossCooldown struct {
+	Trades   TradeSource
+	Cooldown time.Duration
+}
+
+func (f LossCooldown) Name() string { return "loss_cooldown" }
+
+func (f LossCooldown) Evaluate(ctx context.Context, e Entry) (Result, error) {
+	trade, err := f.Trades.FindLastClosed(ctx, e.UserID, e.ExchangeID, e.Symbol)
+	if err != nil {
+		return Result{}, err
+	}
+	if trade == nil || trade.PnL == nil || trade.ExitTime == nil {
+		return Result{Allowed: true, Reason: "no closed trade"}, nil
+	}
+	if *trade.PnL >= 0 {
+		return Result{Allowed: true, Reason: fmt.Sprintf("last trade %d was not a loss", trade.ID)}, nil
+	}
+
+	until := trade.ExitTime.Add(f.Cooldown)
+	if e.Now.Before(until) {
+		return Result{
+			Allowed: false,
+			Reason:  fmt.Sprintf("last trade %d lost %.2f, cooling down until %s", trade.ID, *trade.PnL, until.UTC().Format(time.RFC3339)),
+		}, nil
+	}
+	return Result{Allowed: true, Reason: fmt.Sprintf("cooldown after trade %d expired", trade.ID)}, nil
+}
+
+// StopOutSource returns the last stop-out of a user position, or nil when
+// none was recorded.
+type StopOutSource interface {
+	Get(ctx context.Context, userID, exchangeID uint, symbol, posSide string) (*model.StopOut, error)
+}
+
+// StopOutCooldown blocks entries on the symbol and side of a position its
+// stop loss closed, for Cooldown after the stop-out. With a ConfirmInterval a
+// signal received after the stop-out is let through earlier when the last
+// closed candle of that interval, closed after the stop-out, moved in the
+// direction of the entry. The stop-outs are recorded by the stop watchdog.
+type StopOutCooldown struct {
+	StopOuts        StopOutSource
+	Candles         CandleSource
+	Cooldown        time.Duration
+	ConfirmInterval time.Duration
+}
+
+func (f StopOutCooldown) Name() string { return "stop_out_cooldown" }
+
+func (f StopOutCooldown) Evaluate(ctx context.Context, e Entry) (Result, error) {
+	stop, err := f.StopOuts.Get(ctx, e.UserID, e.ExchangeID, e.Symbol, e.PosSide)
+	if err != nil {
+		return Result{}, err
+	}
+	if stop == nil {
+		return Result{Allowed: true, Reason: "no stop-out recorded"}, nil
+	}
+
+	until := stop.StoppedAt.Add(f.Cooldown)
+	if !e.Now.Before(until) {
+		return Result{Allowed: true, Reason: fmt.Sprintf("cooldown after the stop-out of order %d expired", stop.EntryOrderID)}, nil
+	}
+	reason := fmt.Sprintf("%s stopped out at %s, cooling down until %s",
+		strings.ToLower(e.PosSide), stop.StoppedAt.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339))
+
+	if f.ConfirmInterval <= 0 || f.Candles == nil || !e.SignalAt.After(stop.StoppedAt) {
+		return Result{Allowed: false, Reason: reason}, nil
+	}
+	confirmed, detail, err := f.confirmed(ctx, e, stop.StoppedAt)
+	if err != nil {
+		return Result{}, err
+	}
+	if confirmed {
+		return Result{Allowed: true, Reason: fmt.Sprintf("%s stopped out at %s, released by %s", strings.ToLower(e.PosSide), stop.StoppedAt.UTC().Format(time.RFC3339), detail)}, nil
+	}
+	return Result{Allowed: false, Reason: reason + ", " + detail}, nil
+}
+
+// confirmed reports whether the last closed ConfirmInterval candle closed
+// after stoppedAt in the direction of the entry.
+func (f StopOutCooldown) confirmed(ctx context.Context, e Entry, stoppedAt time.Time) (bool, string, error) {
+	end := e.Now.UTC().Truncate(f.ConfirmInterval)
+	if !end.After(stoppedAt) {
+		return false, fmt.Sprintf("no %s candle closed since", f.ConfirmInterval), nil
+	}
+	candles, err := f.Candles.FetchOHLCV1mRange(ctx, e.Symbol, end.Add(-f.ConfirmInterval), end.Add(-time.Minute))
+	if err != nil {
+		return false, "", err
+	}
+	if len(candles) == 0 {
+		return false, "", fmt.Errorf("no candles of the %s candle before %s", f.ConfirmInterval, end.Format(time.RFC3339))
+	}
+
+	open, closed := candles[0].Open, candles[len(candles)-1].Close
+	with := closed.GreaterThan(open)
+	if e.PosSide == "Short" {
+		with = closed.LessThan(open)
+	}
+	return with, fmt.Sprintf("%s candle %s -> %s", f.ConfirmInterval, open, closed), nil
+}
+
+// DailyEntrySource returns the entries counted for a user on symbol during
+// a UTC day, or nil when none was.
+type DailyEntrySource interface {
+	Get(ctx context.Context, userID uint, symbol, day string) (*model.DailyEntryCount, error)
+}
+
+// DailyEntries blocks entries once Max entries were sent on the symbol
+// during the current UTC day, containing a signal source firing over and
+// over. The controller counts the entries it sends.
+type DailyEntries struct {
+	Counts DailyEntrySource
+	Max    int
+}
+
+func (f DailyEntries) Name() string { return "daily_entries" }
+
+func (f DailyEntries) Evaluate(ctx context.Context, e Entry) (Result, error) {
+	day := model.DailyEntryDay(e.Now)
+	count, err := f.Counts.Get(ctx, e.UserID, e.Symbol, day)
+	if err != nil {
+		return Result{}, err
+	}
+	sent := 0
+	if count != nil {
+		sent = count.Count
+	}
+	return Result{
+		Allowed: sent < f.Max,
+		Reason:  fmt.Sprintf("%d entries on %s, max %d", sent, day, f.Max),
+	}, nil
+}
+
+// FundingWindow postpones entries within Window of the next funding
+// timestamp when the position would pay a funding rate of at least MinRate.
+// Collected snapshots are used while they describe the upcoming funding;
+// otherwise the rate is read live and the funding is assumed on the Interval
+// grid from midnight UTC.
+type FundingWindow struct {
+	Collected FundingSource
+	Live      LiveFundingSource
+	Window    time.Duration
+	MinRate   float64
+	Interval  time.Duration
+}
+
+func (f FundingWindow) Name() string { return "funding_window" }
+
+func (f FundingWindow) Evaluate(ctx context.Context, e Entry) (Result, error) {
+	rate, next, err := f.funding(ctx, e)
+	if err != nil {
+		return Result{}, err
+	}
+
+	until := next.Sub(e.Now)
+	if until > f.Window {
+		return Result{Allowed: true, Reason: fmt.Sprintf("next funding in %s", until.Round(time.Minute))}, nil
+	}
+
+	pays := (e.PosSide == "Long" && rate > 0) || (e.PosSide == "Short" && rate < 0)
+	reason := fmt.Sprintf("funding %.4f%% in %s", rate*100, until.Round(time.Minute))
+	if !pays || math.Abs(rate) < f.MinRate {
+		return Result{Allowed: true, Reason: reason + " not against " + strings.ToLower(e.PosSide)}, nil
+	}
+	return Result{
+		Allowed:  false,
+		Postpone: true,
+		Reason:   fmt.Sprintf("%s against %s, postponed until %s", reason, strings.ToLower(e.PosSide), next.UTC().Format(time.RFC3339)),
+	}, nil
+}
+
+func (f FundingWindow) funding(ctx context.Context, e Entry) (float64, time.Time, error) {
+	if f.Collected != nil {
+		fr, err := f.Collected.GetLatestFundingRate(ctx, e.Exchange, e.Symbol)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		if fr != nil && fr.NextFundingTime.After(e.Now) && e.Now.Sub(fr.Datetime) < f.Interval {
+			return fr.FundingRate.InexactFloat64(), fr.NextFundingTime, nil
+		}
+	}
+	if f.Live == nil {
+		return 0, time.Time{}, fmt.Errorf("no current funding snapshot for %s", e.Symbol)
+	}
+
+	snap, err := f.Live.GetFundingSnapshot(e.Symbol)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return snap.FundingRate, e.Now.UTC().Truncate(f.Interval).Add(f.Interval), nil
+}
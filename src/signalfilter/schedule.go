@@ -0,0 +1,127 @@
+package signalfilter
+
+import (
+	"context"
+	"fmt"
+	"strategyexecutor/src/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// ScheduleSource returns the schedule rules of a user applying to a symbol.
+type ScheduleSource interface {
+	ListForSymbol(ctx context.Context, userID uint, symbol string) ([]model.ScheduleRule, error)
+}
+
+// Schedule enforces the weekly trading-hours rules of the user: a block rule
+// denies entries inside its window, a reduce rule scales their size. When
+// several reduce rules overlap, their multipliers are combined.
+type Schedule struct {
+	Rules ScheduleSource
+}
+
+func (f Schedule) Name() string { return "schedule" }
+
+func (f Schedule) Evaluate(ctx context.Context, e Entry) (Result, error) {
+	rules, err := f.Rules.ListForSymbol(ctx, e.UserID, e.Symbol)
+	if err != nil {
+		return Result{}, err
+	}
+
+	factor := 1.0
+	var reduced []string
+	for _, rule := range rules {
+		active, err := ScheduleRuleActive(rule, e.Now)
+		if err != nil {
+			return Result{}, fmt.Errorf("schedule rule %d: %w", rule.ID, err)
+		}
+		if !active {
+			continue
+		}
+		switch rule.Action {
+		case model.ScheduleActionBlock:
+			return Result{Allowed: false, Reason: fmt.Sprintf("no new entries %s", FormatScheduleRule(rule))}, nil
+		case model.ScheduleActionReduce:
+			m := rule.SizeMultiplier.InexactFloat64()
+			factor *= m
+			reduced = append(reduced, fmt.Sprintf("size x%.2f %s", m, FormatScheduleRule(rule)))
+		default:
+			return Result{}, fmt.Errorf("schedule rule %d: unknown action %q", rule.ID, rule.Action)
+		}
+	}
+
+	if len(reduced) == 0 {
+		return Result{Allowed: true, Reason: fmt.Sprintf("%d rules, none active", len(rules))}, nil
+	}
+	return Result{Allowed: true, SizeFactor: factor, Reason: strings.Join(reduced, ", ")}, nil
+}
+
+// ScheduleRuleActive reports whether now falls inside the weekly window of
+// rule, start inclusive and end exclusive.
+func ScheduleRuleActive(rule model.ScheduleRule, now time.Time) (bool, error) {
+	loc := time.UTC
+	if rule.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(rule.Timezone); err != nil {
+			return false, fmt.Errorf("invalid timezone %q: %w", rule.Timezone, err)
+		}
+	}
+	local := now.In(loc)
+	at := int(local.Weekday())*minutesPerDay + local.Hour()*60 + local.Minute()
+	start := rule.StartDay*minutesPerDay + rule.StartMinute
+	end := rule.EndDay*minutesPerDay + rule.EndMinute
+
+	if end < start {
+		return at >= start || at < end, nil
+	}
+	return at >= start && at < end, nil
+}
+
+// ParseWeekTime parses a weekly time such as "Fri 20:00" into a weekday and
+// minutes after midnight.
+func ParseWeekTime(s string) (time.Weekday, int, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("invalid week time %q, expected e.g. \"Fri 20:00\"", s)
+	}
+
+	day := -1
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(fields[0], d.String()[:3]) || strings.EqualFold(fields[0], d.String()) {
+			day = int(d)
+			break
+		}
+	}
+	if day < 0 {
+		return 0, 0, fmt.Errorf("invalid weekday %q", fields[0])
+	}
+
+	hh, mm, ok := strings.Cut(fields[1], ":")
+	hour, errH := strconv.Atoi(hh)
+	minute, errM := strconv.Atoi(mm)
+	if !ok || errH != nil || errM != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid time of day %q", fields[1])
+	}
+	return time.Weekday(day), hour*60 + minute, nil
+}
+
+// FormatWeekTime is the inverse of ParseWeekTime.
+func FormatWeekTime(day time.Weekday, minute int) string {
+	return fmt.Sprintf("%s %02d:%02d", day.String()[:3], minute/60, minute%60)
+}
+
+// FormatScheduleRule describes the window of rule, e.g.
+// "Fri 20:00-Sun 22:00 UTC".
+func FormatScheduleRule(rule model.ScheduleRule) string {
+	tz := rule.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	return fmt.Sprintf("%s-%s %s",
+		FormatWeekTime(time.Weekday(rule.StartDay), rule.StartMinute),
+		FormatWeekTime(time.Weekday(rule.EndDay), rule.EndMinute),
+		tz)
+}
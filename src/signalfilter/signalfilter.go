@@ -0,0 +1,137 @@
+// Package signalfilter implements the pre-trade validation pipeline run
+// before a controller places a new entry. Every filter returns allow/deny
+// with a human readable reason that is persisted with the order log.
+package signalfilter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Entry describes the position a controller is about to open.
+type Entry struct {
+	UserID     uint
+	ExchangeID uint
+	Symbol     string
+	Exchange   string
+	Side       string // Buy / Sell
+	PosSide    string // Long / Short
+	Now        time.Time
+	// SignalAt is when the signal asking for the entry was received, zero
+	// when unknown.
+	SignalAt time.Time
+}
+
+// Result is the verdict of a single filter. A denial with Postpone set asks
+// for the entry to be evaluated again later instead of dropped. An allowed
+// entry may be scaled by SizeFactor; zero leaves the size unchanged.
+type Result struct {
+	Filter   string
+	Allowed  bool
+	Postpone bool
+	Reason   string
+
+	SizeFactor float64
+}
+
+// Filter is one step of the pipeline.
+type Filter interface {
+	Name() string
+	Evaluate(ctx context.Context, e Entry) (Result, error)
+}
+
+// Chain evaluates filters in order.
+type Chain []Filter
+
+// Outcome collects every filter result of a chain evaluation.
+type Outcome struct {
+	Allowed bool
+	Results []Result
+}
+
+// Evaluate runs every filter so the persisted log explains all verdicts, not
+// only the first denial. A filter returning an error fails open: a data
+// outage must never block trading on its own.
+func (c Chain) Evaluate(ctx context.Context, e Entry) Outcome {
+	out := Outcome{Allowed: true}
+	for _, f := range c {
+		res, err := f.Evaluate(ctx, e)
+		if err != nil {
+			logger.WithError(err).
+				WithField("filter", f.Name()).
+				WithField("symbol", e.Symbol).
+				Warn("signal filter failed, allowing entry")
+			res = Result{Allowed: true, Reason: "error: " + err.Error()}
+		}
+		res.Filter = f.Name()
+		if !res.Allowed {
+			out.Allowed = false
+		}
+		out.Results = append(out.Results, res)
+	}
+	return out
+}
+
+// Denied returns the results that blocked the entry.
+func (o Outcome) Denied() []Result {
+	var denied []Result
+	for _, r := range o.Results {
+		if !r.Allowed {
+			denied = append(denied, r)
+		}
+	}
+	return denied
+}
+
+// SizeFactor is the product of the size factors of the results, 1 when no
+// filter scaled the entry.
+func (o Outcome) SizeFactor() float64 {
+	factor := 1.0
+	for _, r := range o.Results {
+		if r.SizeFactor > 0 {
+			factor *= r.SizeFactor
+		}
+	}
+	return factor
+}
+
+// Postponed reports whether the entry was denied only by filters asking for
+// it to be retried later.
+func (o Outcome) Postponed() bool {
+	denied := o.Denied()
+	for _, r := range denied {
+		if !r.Postpone {
+			return false
+		}
+	}
+	return len(denied) > 0
+}
+
+// Reason summarises the outcome for the order log, e.g.
+// "denied by spread: 12.0bps > 5.0bps; time_of_day: allow (...)".
+func (o Outcome) Reason() string {
+	if len(o.Results) == 0 {
+		return "no signal filters configured"
+	}
+
+	parts := make([]string, 0, len(o.Results))
+	for _, r := range o.Results {
+		verdict := "allow"
+		if r.Postpone && !r.Allowed {
+			verdict = "postpone"
+		} else if !r.Allowed {
+			verdict = "deny"
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s (%s)", r.Filter, verdict, r.Reason))
+	}
+
+	prefix := "signal filters passed: "
+	if !o.Allowed {
+		prefix = "signal filters denied: "
+	}
+	return prefix + strings.Join(parts, "; ")
+}
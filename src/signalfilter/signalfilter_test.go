@@ -0,0 +1,443 @@
+package signalfilter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strategyexecutor/src/connectors"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/tp_sl"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCandles []model.OHLCVCrypto1m
+
+func (f fakeCandles) FetchOHLCV1mRange(ctx context.Context, symbol string, from, to time.Time) ([]model.OHLCVCrypto1m, error) {
+	return f, nil
+}
+
+type fakeQuotes struct {
+	bid, ask float64
+	err      error
+}
+
+func (f fakeQuotes) BestBidAsk(symbol string) (float64, float64, error) {
+	return f.bid, f.ask, f.err
+}
+
+type fakePositions struct {
+	symbols []string
+}
+
+func (f fakePositions) GetPositionsUSDT() (*connectors.GAccountPositions, error) {
+	type position struct {
+		Symbol string `json:"symbol"`
+		SizeRq string `json:"sizeRq"`
+	}
+	var payload struct {
+		Positions []position `json:"positions"`
+	}
+	for _, s := range f.symbols {
+		payload.Positions = append(payload.Positions, position{Symbol: s, SizeRq: "1"})
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	out := &connectors.GAccountPositions{}
+	return out, json.Unmarshal(raw, out)
+}
+
+type fakeTrades struct {
+	trade *model.Trade
+}
+
+func (f fakeTrades) FindLastClosed(ctx context.Context, userID, exchangeID uint, symbol string) (*model.Trade, error) {
+	return f.trade, nil
+}
+
+type fakeStreaks struct {
+	streak *model.LossStreak
+}
+
+func (f fakeStreaks) Get(ctx context.Context, userID, exchangeID uint, symbol string) (*model.LossStreak, error) {
+	return f.streak, nil
+}
+
+type fakeStopOuts struct {
+	stop *model.StopOut
+}
+
+func (f fakeStopOuts) Get(ctx context.Context, userID, exchangeID uint, symbol, posSide string) (*model.StopOut, error) {
+	if f.stop == nil || f.stop.PosSide != posSide {
+		return nil, nil
+	}
+	return f.stop, nil
+}
+
+type fakeEntryCounts map[string]int
+
+func (f fakeEntryCounts) Get(ctx context.Context, userID uint, symbol, day string) (*model.DailyEntryCount, error) {
+	n, ok := f[symbol+" "+day]
+	if !ok {
+		return nil, nil
+	}
+	return &model.DailyEntryCount{UserID: userID, Symbol: symbol, Day: day, Count: n}, nil
+}
+
+type fakeSchedules []model.ScheduleRule
+
+func (f fakeSchedules) ListForSymbol(ctx context.Context, userID uint, symbol string) ([]model.ScheduleRule, error) {
+	return f, nil
+}
+
+type failingFilter struct{}
+
+func (failingFilter) Name() string { return "failing" }
+
+func (failingFilter) Evaluate(context.Context, Entry) (Result, error) {
+	return Result{}, errors.New("boom")
+}
+
+func candle(high, low, close float64) model.OHLCVCrypto1m {
+	return model.OHLCVCrypto1m{
+		High:  decimal.NewFromFloat(high),
+		Low:   decimal.NewFromFloat(low),
+		Close: decimal.NewFromFloat(close),
+	}
+}
+
+func TestFilters(t *testing.T) {
+	now := time.Date(2025, 1, 6, 22, 30, 0, 0, time.UTC)
+	lossPnL, winPnL := -10.0, 5.0
+	exit := now.Add(-30 * time.Minute)
+	later := now.Add(30 * time.Minute)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		entry  Entry
+		want   bool
+	}{
+		{name: "time of day inside", filter: TimeOfDay{StartHour: 20, EndHour: 23}, want: true},
+		{name: "time of day outside", filter: TimeOfDay{StartHour: 8, EndHour: 20}, want: false},
+		{name: "time of day wraps midnight", filter: TimeOfDay{StartHour: 22, EndHour: 2}, want: true},
+		{name: "volatility calm", filter: Volatility{Candles: fakeCandles{candle(101, 99, 100), candle(100.5, 99.5, 100)}, Lookback: time.Hour, MaxRangePct: 3}, want: true},
+		{name: "volatility wild", filter: Volatility{Candles: fakeCandles{candle(110, 99, 100), candle(100.5, 95, 100)}, Lookback: time.Hour, MaxRangePct: 3}, want: false},
+		{name: "volatility without candles", filter: Volatility{Candles: fakeCandles{}, Lookback: time.Hour, MaxRangePct: 3}, want: true},
+		{name: "spread tight", filter: Spread{Quotes: fakeQuotes{bid: 99.99, ask: 100.01}, MaxBps: 5}, want: true},
+		{name: "spread wide", filter: Spread{Quotes: fakeQuotes{bid: 99, ask: 101}, MaxBps: 5}, want: false},
+		{name: "max positions ignores entry symbol", filter: MaxPositions{Positions: fakePositions{symbols: []string{"BTCUSDT", "ETHUSDT"}}, Max: 2}, want: true},
+		{name: "max positions reached", filter: MaxPositions{Positions: fakePositions{symbols: []string{"SOLUSDT", "ETHUSDT"}}, Max: 2}, want: false},
+		{name: "loss cooldown active", filter: LossCooldown{Trades: fakeTrades{trade: &model.Trade{ID: 1, PnL: &lossPnL, ExitTime: &exit}}, Cooldown: time.Hour}, want: false},
+		{name: "loss cooldown expired", filter: LossCooldown{Trades: fakeTrades{trade: &model.Trade{ID: 1, PnL: &lossPnL, ExitTime: &exit}}, Cooldown: 10 * time.Minute}, want: true},
+		{name: "loss cooldown after win", filter: LossCooldown{Trades: fakeTrades{trade: &model.Trade{ID: 1, PnL: &winPnL, ExitTime: &exit}}, Cooldown: time.Hour}, want: true},
+		{name: "loss streak cooling down", filter: LossStreak{Streaks: fakeStreaks{streak: &model.LossStreak{ConsecutiveLosses: 3, CooldownUntil: &later}}}, want: false},
+		{name: "loss streak cooldown over", filter: LossStreak{Streaks: fakeStreaks{streak: &model.LossStreak{ConsecutiveLosses: 3, CooldownUntil: &exit}}}, want: true},
+		{name: "loss streak not recorded", filter: LossStreak{Streaks: fakeStreaks{}}, want: true},
+		{name: "loss cooldown without trades", filter: LossCooldown{Trades: fakeTrades{}, Cooldown: time.Hour}, want: true},
+		{name: "daily entries below max", filter: DailyEntries{Counts: fakeEntryCounts{"BTCUSDT 2025-01-06": 2}, Max: 3}, want: true},
+		{name: "daily entries reached", filter: DailyEntries{Counts: fakeEntryCounts{"BTCUSDT 2025-01-06": 3}, Max: 3}, want: false},
+		{name: "daily entries of another day", filter: DailyEntries{Counts: fakeEntryCounts{"BTCUSDT 2025-01-05": 9}, Max: 3}, want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			entry := Entry{UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: "Long", Now: now}
+			res, err := tc.filter.Evaluate(context.Background(), entry)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, res.Allowed, res.Reason)
+			require.NotEmpty(t, res.Reason)
+		})
+	}
+}
+
+func TestStopOutCooldown(t *testing.T) {
+	now := time.Date(2025, 1, 6, 12, 10, 0, 0, time.UTC)
+	stopped := &model.StopOut{EntryOrderID: 4, PosSide: "Long", StoppedAt: time.Date(2025, 1, 6, 10, 40, 0, 0, time.UTC)}
+	bar := func(open, close float64) fakeCandles {
+		return fakeCandles{
+			{Open: decimal.NewFromFloat(open), Close: decimal.NewFromFloat(open)},
+			{Open: decimal.NewFromFloat(close), Close: decimal.NewFromFloat(close)},
+		}
+	}
+	confirming := StopOutCooldown{StopOuts: fakeStopOuts{stop: stopped}, Cooldown: 4 * time.Hour, Candles: bar(100, 102), ConfirmInterval: time.Hour}
+	newSignal := now.Add(-5 * time.Minute)
+
+	tests := []struct {
+		name     string
+		filter   StopOutCooldown
+		posSide  string
+		signalAt time.Time
+		want     bool
+	}{
+		{name: "cooling down", filter: StopOutCooldown{StopOuts: fakeStopOuts{stop: stopped}, Cooldown: 4 * time.Hour}, posSide: "Long", signalAt: newSignal, want: false},
+		{name: "cooldown expired", filter: StopOutCooldown{StopOuts: fakeStopOuts{stop: stopped}, Cooldown: time.Hour}, posSide: "Long", want: true},
+		{name: "other side not stopped", filter: confirming, posSide: "Short", want: true},
+		{name: "not recorded", filter: StopOutCooldown{StopOuts: fakeStopOuts{}, Cooldown: time.Hour}, posSide: "Long", want: true},
+		{name: "higher timeframe confirms a new signal", filter: confirming, posSide: "Long", signalAt: newSignal, want: true},
+		{name: "signal older than the stop-out", filter: confirming, posSide: "Long", signalAt: stopped.StoppedAt.Add(-time.Minute), want: false},
+		{name: "higher timeframe against the entry", filter: StopOutCooldown{StopOuts: fakeStopOuts{stop: stopped}, Cooldown: 4 * time.Hour, Candles: bar(102, 100), ConfirmInterval: time.Hour}, posSide: "Long", signalAt: newSignal, want: false},
+		{name: "no higher timeframe candle closed since", filter: StopOutCooldown{StopOuts: fakeStopOuts{stop: stopped}, Cooldown: 4 * time.Hour, Candles: bar(100, 102), ConfirmInterval: 8 * time.Hour}, posSide: "Long", signalAt: newSignal, want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			entry := Entry{UserID: 1, ExchangeID: 1, Symbol: "BTCUSDT", PosSide: tc.posSide, Now: now, SignalAt: tc.signalAt}
+			res, err := tc.filter.Evaluate(context.Background(), entry)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, res.Allowed, res.Reason)
+			require.NotEmpty(t, res.Reason)
+		})
+	}
+}
+
+func TestChainEvaluate(t *testing.T) {
+	now := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	chain := Chain{
+		failingFilter{},
+		TimeOfDay{StartHour: 13, EndHour: 20},
+		Spread{Quotes: fakeQuotes{bid: 99.99, ask: 100.01}, MaxBps: 5},
+	}
+
+	out := chain.Evaluate(context.Background(), Entry{Symbol: "BTCUSDT", Now: now})
+	require.False(t, out.Allowed)
+	require.Len(t, out.Results, 3)
+	require.True(t, out.Results[0].Allowed, "filter errors fail open")
+	require.Equal(t, []Result{out.Results[1]}, out.Denied())
+	require.Contains(t, out.Reason(), "signal filters denied: failing: allow (error: boom)")
+	require.Contains(t, out.Reason(), "time_of_day: deny")
+
+	require.Equal(t, "no signal filters configured", Chain{}.Evaluate(context.Background(), Entry{}).Reason())
+}
+
+func TestBuild(t *testing.T) {
+	src := Sources{
+		Candles:   fakeCandles{},
+		Quotes:    fakeQuotes{},
+		Positions: fakePositions{},
+		Trades:    fakeTrades{},
+		Streaks:   fakeStreaks{},
+		Live:      fakeLiveFunding{},
+		StopOuts:  fakeStopOuts{},
+		Entries:   fakeEntryCounts{},
+	}
+
+	chain, err := Build(model.SignalFilterSetting{}, src)
+	require.NoError(t, err)
+	require.Empty(t, chain)
+
+	src.Schedules = fakeSchedules{}
+
+	chain, err = Build(model.SignalFilterSetting{
+		TradingStartHour:       9,
+		TradingEndHour:         17,
+		Timezone:               "America/New_York",
+		NewsSentimentThreshold: 1, // no news source: skipped
+		MaxVolatilityPct:       2,
+		MaxSpreadBps:           5,
+		MaxOpenPositions:       3,
+		LossCooldownMinutes:    60,
+		MaxConsecutiveLosses:   3,
+		FundingDelayMinutes:    15,
+		CandlePatternLong:      "bullish_engulfing",
+		StopOutCooldownMinutes: 120,
+		MaxDailyEntries:        4,
+	}, src)
+	require.NoError(t, err)
+
+	var names []string
+	for _, f := range chain {
+		names = append(names, f.Name())
+	}
+	require.Equal(t, []string{"time_of_day", "schedule", "loss_cooldown", "loss_streak", "daily_entries", "stop_out_cooldown", "volatility", "candle_pattern", "spread", "funding_window", "max_positions"}, names)
+	require.Equal(t, 4, chain[4].(DailyEntries).Max)
+	require.Equal(t, 2*time.Hour, chain[5].(StopOutCooldown).Cooldown)
+	require.Zero(t, chain[5].(StopOutCooldown).ConfirmInterval)
+	require.Equal(t, time.Hour, chain[6].(Volatility).Lookback)
+	require.Equal(t, 15*time.Minute, chain[7].(CandlePattern).Interval)
+
+	_, err = Build(model.SignalFilterSetting{TradingStartHour: 9, TradingEndHour: 17, Timezone: "Nowhere/City"}, src)
+	require.Error(t, err)
+	_, err = Build(model.SignalFilterSetting{TradingStartHour: 9, TradingEndHour: 30}, src)
+	require.Error(t, err)
+	_, err = Build(model.SignalFilterSetting{CandlePatternShort: "doji"}, src)
+	require.Error(t, err)
+	_, err = Build(model.SignalFilterSetting{CandlePatternLong: "inside_bar", CandlePatternIntervalMinutes: 7}, src)
+	require.Error(t, err)
+}
+
+func TestCandlePattern(t *testing.T) {
+	now := time.Date(2025, 1, 6, 10, 31, 0, 0, time.UTC)
+	candle := func(at time.Time, o, h, l, c string) model.OHLCVCrypto1m {
+		return model.OHLCVCrypto1m{
+			Symbol: "BTCUSDT", Datetime: at,
+			Open: decimal.RequireFromString(o), High: decimal.RequireFromString(h),
+			Low: decimal.RequireFromString(l), Close: decimal.RequireFromString(c),
+		}
+	}
+	// 10:00 bucket closes bearish, the 10:15 bucket engulfs it bullish.
+	candles := fakeCandles{
+		candle(time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC), "102", "103", "100", "101"),
+		candle(time.Date(2025, 1, 6, 10, 14, 0, 0, time.UTC), "101", "101", "99", "100"),
+		candle(time.Date(2025, 1, 6, 10, 15, 0, 0, time.UTC), "99.5", "101", "99", "100.5"),
+		candle(time.Date(2025, 1, 6, 10, 29, 0, 0, time.UTC), "100.5", "104", "100", "103"),
+	}
+	filter := CandlePattern{Candles: candles, Interval: 15 * time.Minute, Long: tp_sl.PatternBullishEngulfing, Short: tp_sl.PatternBearishEngulfing}
+
+	res, err := filter.Evaluate(context.Background(), Entry{Symbol: "BTCUSDT", PosSide: "Long", Now: now})
+	require.NoError(t, err)
+	require.True(t, res.Allowed, res.Reason)
+
+	res, err = filter.Evaluate(context.Background(), Entry{Symbol: "BTCUSDT", PosSide: "Short", Now: now})
+	require.NoError(t, err)
+	require.False(t, res.Allowed, res.Reason)
+
+	filter.Short = ""
+	res, err = filter.Evaluate(context.Background(), Entry{Symbol: "BTCUSDT", PosSide: "Short", Now: now})
+	require.NoError(t, err)
+	require.True(t, res.Allowed, res.Reason)
+
+	_, err = CandlePattern{Candles: fakeCandles{}, Interval: 15 * time.Minute, Long: tp_sl.PatternInsideBar}.
+		Evaluate(context.Background(), Entry{Symbol: "BTCUSDT", PosSide: "Long", Now: now})
+	require.Error(t, err, "no candles")
+}
+
+type fakeFunding struct {
+	rate *model.FundingRate
+}
+
+func (f fakeFunding) GetLatestFundingRate(ctx context.Context, exchange, symbol string) (*model.FundingRate, error) {
+	return f.rate, nil
+}
+
+type fakeLiveFunding struct {
+	rate float64
+}
+
+func (f fakeLiveFunding) GetFundingSnapshot(symbol string) (*connectors.FundingSnapshot, error) {
+	return &connectors.FundingSnapshot{Symbol: symbol, FundingRate: f.rate}, nil
+}
+
+func TestFundingWindow(t *testing.T) {
+	// 20 minutes before the 08:00 UTC funding
+	now := time.Date(2025, 1, 6, 7, 40, 0, 0, time.UTC)
+	collected := func(rate float64, next time.Time) fakeFunding {
+		return fakeFunding{rate: &model.FundingRate{
+			Datetime:        now.Add(-5 * time.Minute),
+			FundingRate:     decimal.NewFromFloat(rate),
+			NextFundingTime: next,
+		}}
+	}
+	window := func(src FundingSource, live LiveFundingSource) FundingWindow {
+		return FundingWindow{Collected: src, Live: live, Window: 30 * time.Minute, MinRate: 0.0001, Interval: 8 * time.Hour}
+	}
+	eight := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		filter   FundingWindow
+		posSide  string
+		postpone bool
+	}{
+		{name: "long pays", filter: window(collected(0.0003, eight), nil), posSide: "Long", postpone: true},
+		{name: "short receives", filter: window(collected(0.0003, eight), nil), posSide: "Short"},
+		{name: "short pays negative rate", filter: window(collected(-0.0003, eight), nil), posSide: "Short", postpone: true},
+		{name: "rate below minimum", filter: window(collected(0.00005, eight), nil), posSide: "Long"},
+		{name: "funding far away", filter: window(collected(0.0003, eight.Add(8*time.Hour)), nil), posSide: "Long"},
+		{name: "stale snapshot falls back to live", filter: window(collected(0.0003, now.Add(-time.Minute)), fakeLiveFunding{rate: -0.0002}), posSide: "Long"},
+		{name: "live on the funding grid", filter: window(fakeFunding{}, fakeLiveFunding{rate: 0.0002}), posSide: "Long", postpone: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.filter.Evaluate(context.Background(), Entry{Symbol: "BTCUSDT", Exchange: "phemex", PosSide: tc.posSide, Now: now})
+			require.NoError(t, err)
+			require.Equal(t, !tc.postpone, res.Allowed, res.Reason)
+			require.Equal(t, tc.postpone, res.Postpone, res.Reason)
+		})
+	}
+
+	_, err := window(fakeFunding{}, nil).Evaluate(context.Background(), Entry{Symbol: "BTCUSDT", PosSide: "Long", Now: now})
+	require.Error(t, err, "no funding data")
+}
+
+func TestOutcomePostponed(t *testing.T) {
+	postpone := Result{Filter: "funding_window", Postpone: true, Reason: "funding"}
+	deny := Result{Filter: "spread", Reason: "wide"}
+
+	out := Outcome{Results: []Result{{Filter: "time_of_day", Allowed: true, Reason: "ok"}, postpone}}
+	require.True(t, out.Postponed())
+	require.Contains(t, out.Reason(), "funding_window: postpone (funding)")
+
+	require.False(t, Outcome{Results: []Result{postpone, deny}}.Postponed(), "a hard denial drops the entry")
+	require.False(t, Outcome{Allowed: true}.Postponed())
+}
+
+func TestSchedule(t *testing.T) {
+	weekend := model.ScheduleRule{ID: 1, StartDay: 5, StartMinute: 20 * 60, EndDay: 0, EndMinute: 22 * 60, Action: model.ScheduleActionBlock}
+	sundayNight := model.ScheduleRule{
+		ID: 2, StartDay: 0, StartMinute: 22 * 60, EndDay: 1, EndMinute: 2 * 60,
+		Action: model.ScheduleActionReduce, SizeMultiplier: decimal.NewFromFloat(0.5),
+	}
+	newYork := model.ScheduleRule{ID: 3, StartDay: 1, StartMinute: 9 * 60, EndDay: 1, EndMinute: 10 * 60, Timezone: "America/New_York", Action: model.ScheduleActionBlock}
+	f := Schedule{Rules: fakeSchedules{weekend, sundayNight, newYork}}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		allowed bool
+		factor  float64
+	}{
+		{name: "friday before the window", now: time.Date(2025, 1, 3, 19, 59, 0, 0, time.UTC), allowed: true},
+		{name: "friday night", now: time.Date(2025, 1, 3, 20, 0, 0, 0, time.UTC), allowed: false},
+		{name: "saturday", now: time.Date(2025, 1, 4, 12, 0, 0, 0, time.UTC), allowed: false},
+		{name: "sunday night reduced", now: time.Date(2025, 1, 5, 23, 0, 0, 0, time.UTC), allowed: true, factor: 0.5},
+		{name: "monday after midnight reduced", now: time.Date(2025, 1, 6, 1, 0, 0, 0, time.UTC), allowed: true, factor: 0.5},
+		{name: "monday morning", now: time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC), allowed: true},
+		{name: "monday new york open", now: time.Date(2025, 1, 6, 14, 30, 0, 0, time.UTC), allowed: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := f.Evaluate(context.Background(), Entry{UserID: 1, Symbol: "BTCUSDT", Now: tc.now})
+			require.NoError(t, err)
+			require.Equal(t, tc.allowed, res.Allowed, res.Reason)
+			require.Equal(t, tc.factor, res.SizeFactor, res.Reason)
+		})
+	}
+
+	res, err := f.Evaluate(context.Background(), Entry{Now: time.Date(2025, 1, 4, 12, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	require.Equal(t, "no new entries Fri 20:00-Sun 22:00 UTC", res.Reason)
+
+	_, err = Schedule{Rules: fakeSchedules{{ID: 4, Timezone: "Nowhere/City", Action: model.ScheduleActionBlock}}}.
+		Evaluate(context.Background(), Entry{Now: time.Now()})
+	require.Error(t, err)
+}
+
+func TestParseWeekTime(t *testing.T) {
+	day, minute, err := ParseWeekTime("fri 20:30")
+	require.NoError(t, err)
+	require.Equal(t, time.Friday, day)
+	require.Equal(t, 20*60+30, minute)
+	require.Equal(t, "Fri 20:30", FormatWeekTime(day, minute))
+
+	day, _, err = ParseWeekTime("Sunday 00:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Sunday, day)
+
+	for _, s := range []string{"", "Fri", "Fri 24:00", "Fri 20", "Xyz 10:00"} {
+		_, _, err := ParseWeekTime(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestOutcomeSizeFactor(t *testing.T) {
+	out := Outcome{Results: []Result{{Allowed: true}, {Allowed: true, SizeFactor: 0.5}, {Allowed: true, SizeFactor: 0.5}}}
+	require.Equal(t, 0.25, out.SizeFactor())
+	require.Equal(t, 1.0, Outcome{}.SizeFactor())
+}
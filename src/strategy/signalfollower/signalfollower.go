@@ -0,0 +1,72 @@
+// Package signalfollower is the default strategy: it trades every
+// TradingView signal as received, which is the historical controller
+// behaviour.
+package signalfollower
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"strategyexecutor/src/strategy"
+	"strings"
+)
+
+const Name = "signal_follower"
+
+// Params are the optional settings stored in model.Strategy.Params.
+type Params struct {
+	// LongOnly turns short signals into a flat position instead of a short.
+	LongOnly bool `json:"long_only"`
+}
+
+type SignalFollower struct {
+	params Params
+}
+
+func init() {
+	strategy.Register(Name, New)
+}
+
+func New(raw json.RawMessage) (strategy.Strategy, error) {
+	var params Params
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, fmt.Errorf("%s: invalid params: %w", Name, err)
+		}
+	}
+	return &SignalFollower{params: params}, nil
+}
+
+func (s *SignalFollower) Name() string { return Name }
+
+// OnSignal maps the signal action (buy/sell) to the order side and the signal
+// order id (long/short) to the position side.
+func (s *SignalFollower) OnSignal(_ context.Context, _ strategy.Context, signal externalmodel.TradingSignal) (strategy.Decision, error) {
+	d := strategy.Decision{
+		Action:  strategy.ActionOpen,
+		Side:    firstLetterUpper(signal.Action),
+		PosSide: firstLetterUpper(signal.OrderID),
+		Reason:  "follow signal",
+	}
+	if s.params.LongOnly && strings.EqualFold(d.PosSide, "Short") {
+		return strategy.Decision{Action: strategy.ActionClose, Reason: "long only: short signal closes positions"}, nil
+	}
+	return d, nil
+}
+
+func (s *SignalFollower) OnCandle(context.Context, strategy.Context, model.OHLCVCrypto1m) (strategy.Decision, error) {
+	return strategy.Decision{Action: strategy.ActionNone}, nil
+}
+
+func (s *SignalFollower) OnFill(context.Context, strategy.Context, model.Order) error {
+	return nil
+}
+
+func firstLetterUpper(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
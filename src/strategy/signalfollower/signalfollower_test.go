@@ -0,0 +1,51 @@
+package signalfollower
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/strategy"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistered(t *testing.T) {
+	require.Contains(t, strategy.Names(), Name)
+
+	s, err := strategy.New(Name, nil)
+	require.NoError(t, err)
+	require.Equal(t, Name, s.Name())
+
+	_, err = strategy.New("does_not_exist", nil)
+	require.True(t, errors.Is(err, strategy.ErrUnknownStrategy))
+
+	_, err = strategy.New(Name, json.RawMessage(`{"long_only":`))
+	require.Error(t, err)
+}
+
+func TestOnSignalFollowsSignal(t *testing.T) {
+	s, err := New(nil)
+	require.NoError(t, err)
+
+	d, err := s.OnSignal(context.Background(), strategy.Context{}, externalmodel.TradingSignal{Action: "sell", OrderID: "short"})
+	require.NoError(t, err)
+	require.Equal(t, strategy.ActionOpen, d.Action)
+	require.Equal(t, "Sell", d.Side)
+	require.Equal(t, "Short", d.PosSide)
+}
+
+func TestOnSignalLongOnly(t *testing.T) {
+	s, err := New(json.RawMessage(`{"long_only":true}`))
+	require.NoError(t, err)
+
+	d, err := s.OnSignal(context.Background(), strategy.Context{}, externalmodel.TradingSignal{Action: "sell", OrderID: "short"})
+	require.NoError(t, err)
+	require.Equal(t, strategy.ActionClose, d.Action)
+
+	d, err = s.OnSignal(context.Background(), strategy.Context{}, externalmodel.TradingSignal{Action: "buy", OrderID: "long"})
+	require.NoError(t, err)
+	require.Equal(t, strategy.ActionOpen, d.Action)
+	require.Equal(t, "Long", d.PosSide)
+}
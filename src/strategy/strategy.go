@@ -0,0 +1,110 @@
+// Package strategy defines the hooks a trading strategy implements and a
+// registry so strategies can live in their own packages and be assigned per
+// user / exchange / symbol through model.Strategy.
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strategyexecutor/src/externalmodel"
+	"strategyexecutor/src/model"
+	"sync"
+)
+
+type Action string
+
+const (
+	// ActionNone leaves positions untouched.
+	ActionNone Action = "none"
+	// ActionOpen closes opposite positions and opens Side / PosSide.
+	ActionOpen Action = "open"
+	// ActionClose closes every position on the symbol without a new entry.
+	ActionClose Action = "close"
+)
+
+type Hook string
+
+const (
+	HookSignal Hook = "signal"
+	HookCandle Hook = "candle"
+	HookFill   Hook = "fill"
+)
+
+// Decision is what a strategy wants the controller to do.
+type Decision struct {
+	Action  Action
+	Side    string // Buy / Sell
+	PosSide string // Long / Short
+	Reason  string
+}
+
+// Context identifies the account and market a strategy runs for.
+type Context struct {
+	UserID     uint
+	ExchangeID uint
+	Symbol     string
+}
+
+// Strategy decides what to trade. Controllers own how orders reach the exchange.
+type Strategy interface {
+	Name() string
+	// OnSignal is called for every new TradingView signal.
+	OnSignal(ctx context.Context, sc Context, signal externalmodel.TradingSignal) (Decision, error)
+	// OnCandle is called by candle driven runners (e.g. backtests) on every closed candle.
+	OnCandle(ctx context.Context, sc Context, candle model.OHLCVCrypto1m) (Decision, error)
+	// OnFill is called once an order placed for the strategy is filled.
+	OnFill(ctx context.Context, sc Context, order model.Order) error
+}
+
+// Factory builds a strategy from its stored JSON params (may be empty).
+type Factory func(params json.RawMessage) (Strategy, error)
+
+var ErrUnknownStrategy = errors.New("unknown strategy")
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a strategy available under name. It is meant to be called
+// from the init function of the strategy package and panics on duplicates.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if name == "" || factory == nil {
+		panic("strategy: Register with empty name or nil factory")
+	}
+	if _, dup := registry[name]; dup {
+		panic("strategy: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New builds the strategy registered under name.
+func New(name string, params json.RawMessage) (Strategy, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStrategy, name)
+	}
+	return factory(params)
+}
+
+// Names returns the registered strategy names, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
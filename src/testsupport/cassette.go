@@ -0,0 +1,283 @@
+package testsupport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// CassetteRecordEnv switches cassettes to record mode when set to 1.
+// Recording talks to the real exchange and needs live API keys.
+const CassetteRecordEnv = "CASSETTE_RECORD"
+
+// volatileFields change on every run (client order ids, nonces) and are
+// ignored when matching a request against the cassette.
+var volatileFields = map[string]bool{
+	"clOrdID":   true,
+	"cliOrdId":  true,
+	"clientOid": true,
+	"nonce":     true,
+	"timestamp": true,
+}
+
+// redactedFields identify the account in responses and are replaced when
+// recording, so fixtures can be committed.
+var redactedFields = map[string]bool{
+	"accountID": true,
+	"accountId": true,
+	"userID":    true,
+	"userId":    true,
+	"uid":       true,
+}
+
+// Interaction is one recorded request and its response. Request headers are
+// never stored: they carry the API key and signatures.
+type Interaction struct {
+	Request struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+		Query  string `json:"query,omitempty"`
+		Body   string `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		Status      int    `json:"status"`
+		ContentType string `json:"content_type,omitempty"`
+		Body        string `json:"body"`
+	} `json:"response"`
+}
+
+// Cassette is an http.RoundTripper replaying the interactions stored in a
+// fixture file, or recording them from Real when CASSETTE_RECORD=1.
+// Requests are matched on method, path, query and body with the volatile
+// fields left out; identical requests are answered in recorded order.
+type Cassette struct {
+	Path   string
+	Record bool
+	// Real performs the requests in record mode.
+	Real http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewCassette loads testdata/cassettes/<name>.json. In record mode the file
+// is rewritten from real traffic at the end of the test; in replay mode a
+// missing file fails the test.
+func NewCassette(t testing.TB, name string, real http.RoundTripper) *Cassette {
+	t.Helper()
+	path := filepath.Join("testdata", "cassettes", name+".json")
+	return newCassette(t, path, os.Getenv(CassetteRecordEnv) == "1", real)
+}
+
+func newCassette(t testing.TB, path string, record bool, real http.RoundTripper) *Cassette {
+	t.Helper()
+	c := &Cassette{Path: path, Record: record, Real: real}
+	if c.Real == nil {
+		c.Real = http.DefaultTransport
+	}
+
+	if c.Record {
+		t.Cleanup(func() {
+			if err := c.save(); err != nil {
+				t.Errorf("save cassette %s: %v", c.Path, err)
+			}
+		})
+		return c
+	}
+
+	raw, err := os.ReadFile(c.Path)
+	if err != nil {
+		t.Fatalf("load cassette: %v (record it with %s=1)", err, CassetteRecordEnv)
+	}
+	if err := json.Unmarshal(raw, &c.interactions); err != nil {
+		t.Fatalf("decode cassette %s: %v", c.Path, err)
+	}
+	c.used = make([]bool, len(c.interactions))
+	return c
+}
+
+// Unused returns the recorded interactions no request matched, which
+// usually means the code under test stopped making a call.
+func (c *Cassette) Unused() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []Interaction
+	for i, used := range c.used {
+		if !used {
+			out = append(out, c.interactions[i])
+		}
+	}
+	return out
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if c.Record {
+		return c.record(req, body)
+	}
+	return c.replay(req, body)
+}
+
+func (c *Cassette) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := c.Real.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	var in Interaction
+	in.Request.Method = req.Method
+	in.Request.Path = req.URL.Path
+	in.Request.Query = req.URL.RawQuery
+	in.Request.Body = string(body)
+	in.Response.Status = resp.StatusCode
+	in.Response.ContentType = resp.Header.Get("Content-Type")
+	in.Response.Body = redactJSON(respBody)
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, in)
+	c.mu.Unlock()
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request, body []byte) (*http.Response, error) {
+	key := matchKey(req.Method, req.URL.Path, req.URL.RawQuery, string(body))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, in := range c.interactions {
+		if c.used[i] || matchKey(in.Request.Method, in.Request.Path, in.Request.Query, in.Request.Body) != key {
+			continue
+		}
+		c.used[i] = true
+		header := http.Header{}
+		if in.Response.ContentType != "" {
+			header.Set("Content-Type", in.Response.ContentType)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+			StatusCode:    in.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette %s: no recorded interaction for %s", c.Path, key)
+}
+
+func (c *Cassette) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	raw, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.Path, append(raw, '\n'), 0o644)
+}
+
+// matchKey canonicalises a request without its volatile fields.
+func matchKey(method, path, query, body string) string {
+	return method + " " + path + "?" + canonicalQuery(query) + " " + canonicalBody(body)
+}
+
+func canonicalQuery(raw string) string {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+	for k := range values {
+		if volatileFields[k] {
+			values.Del(k)
+		}
+	}
+	return values.Encode()
+}
+
+func canonicalBody(body string) string {
+	if strings.TrimSpace(body) == "" {
+		return ""
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &obj); err != nil {
+		return canonicalQuery(body)
+	}
+	for k := range obj {
+		if volatileFields[k] {
+			delete(obj, k)
+		}
+	}
+	// encoding/json sorts map keys.
+	out, _ := json.Marshal(obj)
+	return string(out)
+}
+
+// redactJSON replaces the account identifying fields of a JSON body. Bodies
+// that are not JSON are kept as they are.
+func redactJSON(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(out)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if redactedFields[k] {
+				t[k] = 0
+				continue
+			}
+			t[k] = redactValue(t[k])
+		}
+		return t
+	case []interface{}:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,101 @@
+package testsupport
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRecordThenReplay(t *testing.T) {
+	m := NewMockExchange(t).
+		WithPositions(Position{Symbol: "BTCUSDT", Side: "Buy", PosSide: "Long", SizeRq: "0.01"})
+	m.Handle("GET", "/g-accounts/positions", func(w http.ResponseWriter, r *http.Request) {
+		JSON(w, http.StatusOK, map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"account":   map[string]interface{}{"userID": 42, "accountId": 4201, "currency": "USDT"},
+				"positions": []interface{}{},
+			},
+		})
+	})
+	path := filepath.Join(t.TempDir(), "flow.json")
+
+	rec := newCassette(t, path, true, nil)
+	client := &http.Client{Transport: rec}
+	post(t, client, m.URL+"/g-orders", `{"symbol":"BTCUSDT","clOrdID":"go-1"}`)
+	get := mustGet(t, client, m.URL+"/g-accounts/positions?currency=USDT")
+	if !strings.Contains(get, `"userID":42`) {
+		t.Fatalf("recording must return the real body, got %s", get)
+	}
+	if err := rec.save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	play := newCassette(t, path, false, nil)
+	client = &http.Client{Transport: play}
+	// Other host, other client order id: still the recorded interaction.
+	post(t, client, "https://api.example.com/g-orders", `{"clOrdID":"go-2","symbol":"BTCUSDT"}`)
+	body := mustGet(t, client, "https://api.example.com/g-accounts/positions?currency=USDT")
+	if strings.Contains(body, "42") {
+		t.Fatalf("account ids must be redacted, got %s", body)
+	}
+	if len(play.Unused()) != 0 {
+		t.Fatalf("unused interactions: %+v", play.Unused())
+	}
+
+	// Each interaction answers once.
+	if _, err := client.Get("https://api.example.com/g-accounts/positions?currency=USDT"); err == nil {
+		t.Fatal("expected a miss once the interaction is used")
+	}
+}
+
+func TestCassetteMiss(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.json")
+	rec := newCassette(t, path, true, nil)
+	if err := rec.save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	play := newCassette(t, path, false, nil)
+	_, err := (&http.Client{Transport: play}).Get("https://api.example.com/g-orders/activeList?symbol=BTCUSDT")
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Fatalf("expected a miss, got %v", err)
+	}
+}
+
+func TestMatchKeyIgnoresVolatileFields(t *testing.T) {
+	a := matchKey("POST", "/api/v3/sendorder", "cliOrdId=go-1&size=1&symbol=PF_XBTUSD", "")
+	b := matchKey("POST", "/api/v3/sendorder", "symbol=PF_XBTUSD&size=1&cliOrdId=go-2", "")
+	if a != b {
+		t.Fatalf("keys differ: %q vs %q", a, b)
+	}
+	if matchKey("POST", "/api/v1/orders", "", `{"clientOid":"x","size":1}`) !=
+		matchKey("POST", "/api/v1/orders", "", `{"size":1,"clientOid":"y"}`) {
+		t.Fatal("JSON bodies must match without clientOid")
+	}
+	if a == matchKey("POST", "/api/v3/sendorder", "size=2&symbol=PF_XBTUSD", "") {
+		t.Fatal("different sizes must not match")
+	}
+}
+
+func post(t *testing.T, c *http.Client, url, body string) {
+	t.Helper()
+	resp, err := c.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	resp.Body.Close()
+}
+
+func mustGet(t *testing.T, c *http.Client, url string) string {
+	t.Helper()
+	resp, err := c.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
@@ -0,0 +1,562 @@
+// Package testsupport holds test doubles shared by the package tests. It only
+// depends on the standard library so that any package, connectors included,
+// can use it from its tests without an import cycle.
+package testsupport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Position is a Phemex USDT-M position as served by /g-accounts/positions.
+type Position struct {
+	AccountID        int64  `json:"accountID"`
+	Symbol           string `json:"symbol"`
+	Currency         string `json:"currency"`
+	Side             string `json:"side"`
+	PosSide          string `json:"posSide"`
+	SizeRq           string `json:"sizeRq"`
+	AvgEntryPriceRp  string `json:"avgEntryPriceRp"`
+	PositionMarginRv string `json:"positionMarginRv"`
+	MarkPriceRp      string `json:"markPriceRp"`
+
+	LeverageRr         string `json:"leverageRr,omitempty"`
+	LiquidationPriceRp string `json:"liquidationPriceRp,omitempty"`
+	UnRealisedPnlRv    string `json:"unRealisedPnlRv,omitempty"`
+}
+
+// Ticker is the 24h ticker of a symbol. Empty fields are left out.
+type Ticker struct {
+	LastRp            string `json:"lastRp,omitempty"`
+	BidRp             string `json:"bidRp,omitempty"`
+	AskRp             string `json:"askRp,omitempty"`
+	MarkRp            string `json:"markRp,omitempty"`
+	IndexRp           string `json:"indexRp,omitempty"`
+	FundingRateRr     string `json:"fundingRateRr,omitempty"`
+	PredFundingRateRr string `json:"predFundingRateRr,omitempty"`
+	OpenInterestRv    string `json:"openInterestRv,omitempty"`
+}
+
+// Fill is an execution as served by /g-trades/fills.
+type Fill struct {
+	ExecID         string `json:"execID"`
+	OrderID        string `json:"orderID"`
+	Symbol         string `json:"symbol"`
+	Side           string `json:"side"`
+	PosSide        string `json:"posSide"`
+	ExecQtyRq      string `json:"execQtyRq"`
+	ExecPriceRp    string `json:"execPriceRp"`
+	ExecFeeRv      string `json:"execFeeRv"`
+	ClosedPnlRv    string `json:"closedPnlRv"`
+	ExecType       string `json:"execType"`
+	TransactTimeNs int64  `json:"transactTimeNs"`
+}
+
+// BookLevel is an order book level, price and size.
+type BookLevel [2]string
+
+// Order is an order accepted by POST /g-orders.
+type Order struct {
+	OrderID    string `json:"orderID"`
+	ClOrdID    string `json:"clOrdID"`
+	Symbol     string `json:"symbol"`
+	Side       string `json:"side"`
+	PosSide    string `json:"posSide,omitempty"`
+	OrdType    string `json:"ordType,omitempty"`
+	PriceRp    string `json:"priceRp"`
+	StopPxRp   string `json:"stopPxRp,omitempty"`
+	OrderQtyRq string `json:"orderQtyRq"`
+	ReduceOnly bool   `json:"reduceOnly"`
+	OrdStatus  string `json:"ordStatus"`
+	CumQtyRq   string `json:"cumQtyRq,omitempty"`
+	// StopLossRp and TakeProfitRp are the exits attached to the order.
+	StopLossRp   string `json:"stopLossRp,omitempty"`
+	TakeProfitRp string `json:"takeProfitRp,omitempty"`
+}
+
+// Request is a request received by the mock.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// JSON decodes the request body into v.
+func (r Request) JSON(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// ReduceOnly reports whether the request is an order with reduceOnly set,
+// i.e. a close rather than an entry.
+func (r Request) ReduceOnly() bool {
+	var body struct {
+		ReduceOnly bool `json:"reduceOnly"`
+	}
+	return r.JSON(&body) == nil && body.ReduceOnly
+}
+
+// Failure makes matching requests fail instead of reaching the scenario.
+type Failure struct {
+	// Status is the HTTP status sent, 200 when only Code is set.
+	Status int
+	// Code and Msg are sent as a Phemex business error when Code is not 0.
+	Code int
+	Msg  string
+	// Body is sent verbatim when set, e.g. to serve malformed JSON.
+	Body string
+	// After lets that many matching requests through before failing.
+	After int
+	// Times bounds how many requests fail; 0 fails every one after After.
+	Times int
+	// Match restricts the failure to some requests of the route.
+	Match func(Request) bool
+
+	seen, failed int
+}
+
+// MockExchange is an httptest server speaking the Phemex REST API the
+// connectors use. Scenarios are built with the With* methods; every request
+// is recorded. Routes it does not know answer 404 unless set with Handle.
+type MockExchange struct {
+	URL    string
+	Server *httptest.Server
+
+	mu          sync.Mutex
+	positions   [][]Position
+	served      int
+	balances    map[string]float64
+	tickers     map[string]Ticker
+	books       map[string][2][]BookLevel
+	fills       []Fill
+	orders      []Order
+	exits       []Order
+	failures    map[string][]*Failure
+	latency     map[string]time.Duration
+	handlers    map[string]http.HandlerFunc
+	requests    []Request
+	nextOrderID int
+}
+
+// NewMockExchange starts a mock closed at the end of the test.
+func NewMockExchange(t testing.TB) *MockExchange {
+	t.Helper()
+	m := &MockExchange{
+		balances: map[string]float64{},
+		tickers:  map[string]Ticker{},
+		books:    map[string][2][]BookLevel{},
+		failures: map[string][]*Failure{},
+		latency:  map[string]time.Duration{},
+		handlers: map[string]http.HandlerFunc{},
+	}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serveHTTP))
+	m.URL = m.Server.URL
+	t.Cleanup(m.Server.Close)
+	return m
+}
+
+func route(method, path string) string {
+	return method + " " + path
+}
+
+// WithPositions sets the positions served from now on.
+func (m *MockExchange) WithPositions(ps ...Position) *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.positions = [][]Position{ps}
+	m.served = 0
+	return m
+}
+
+// ThenPositions queues the positions served after the previous ones were
+// served once, e.g. an empty list once a close order went through. The last
+// queued list keeps being served.
+func (m *MockExchange) ThenPositions(ps ...Position) *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.positions) == 0 {
+		m.positions = [][]Position{nil}
+	}
+	m.positions = append(m.positions, ps)
+	return m
+}
+
+// WithBalance sets the available balance of the risk unit of symbol.
+func (m *MockExchange) WithBalance(symbol string, available float64) *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.balances[symbol] = available
+	return m
+}
+
+// WithTicker sets the ticker of symbol.
+func (m *MockExchange) WithTicker(symbol string, t Ticker) *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tickers[symbol] = t
+	return m
+}
+
+// WithOrderBook sets the order book of symbol, best levels first.
+func (m *MockExchange) WithOrderBook(symbol string, bids, asks []BookLevel) *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.books[symbol] = [2][]BookLevel{bids, asks}
+	return m
+}
+
+// WithFills adds executions to the fills history.
+func (m *MockExchange) WithFills(fills ...Fill) *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fills = append(m.fills, fills...)
+	return m
+}
+
+// WithLatency delays every answer of the route by d, or until the client
+// gives up.
+func (m *MockExchange) WithLatency(method, path string, d time.Duration) *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latency[route(method, path)] = d
+	return m
+}
+
+// Fail injects f on the route. Failures are checked in the order they were
+// added, the first one that applies answers.
+func (m *MockExchange) Fail(method, path string, f Failure) *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := route(method, path)
+	m.failures[key] = append(m.failures[key], &f)
+	return m
+}
+
+// Handle serves the route with h, replacing the built-in scenario. Use it
+// for endpoints the mock does not know.
+func (m *MockExchange) Handle(method, path string, h http.HandlerFunc) *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[route(method, path)] = h
+	return m
+}
+
+// FillResting fills the orders resting on the book, e.g. post-only entries
+// the market traded through.
+func (m *MockExchange) FillResting() *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.orders {
+		if o := &m.orders[i]; resting(*o) {
+			o.OrdStatus, o.CumQtyRq = "Filled", o.OrderQtyRq
+		}
+	}
+	return m
+}
+
+// PartiallyFillResting fills qty of every resting non-market order.
+func (m *MockExchange) PartiallyFillResting(qty string) *MockExchange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.orders {
+		if o := &m.orders[i]; resting(*o) {
+			o.OrdStatus, o.CumQtyRq = "PartiallyFilled", qty
+		}
+	}
+	return m
+}
+
+// Requests returns the received requests, oldest first.
+func (m *MockExchange) Requests() []Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Request(nil), m.requests...)
+}
+
+// Count returns how many requests the route received.
+func (m *MockExchange) Count(method, path string) int {
+	n := 0
+	for _, r := range m.Requests() {
+		if r.Method == method && r.Path == path {
+			n++
+		}
+	}
+	return n
+}
+
+// Exits returns the exit orders the exchange created from the stop loss
+// and take profit attached to the market orders accepted so far.
+func (m *MockExchange) Exits() []Order {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Order(nil), m.exits...)
+}
+
+// attachExits creates the conditional exits attached to the filled market
+// order o, as Phemex does once the entry fills. Called with m.mu held.
+func (m *MockExchange) attachExits(o Order) {
+	closeSide := "Sell"
+	if o.Side == "Sell" {
+		closeSide = "Buy"
+	}
+	for _, exit := range []struct{ ordType, stopPx string }{
+		{"Stop", o.StopLossRp},
+		{"MarketIfTouched", o.TakeProfitRp},
+	} {
+		if exit.stopPx == "" {
+			continue
+		}
+		m.nextOrderID++
+		m.exits = append(m.exits, Order{
+			OrderID:    fmt.Sprintf("mock-%d", m.nextOrderID),
+			Symbol:     o.Symbol,
+			Side:       closeSide,
+			PosSide:    o.PosSide,
+			OrdType:    exit.ordType,
+			StopPxRp:   exit.stopPx,
+			OrderQtyRq: o.OrderQtyRq,
+			ReduceOnly: true,
+			OrdStatus:  "New",
+		})
+	}
+}
+
+// Orders returns the orders accepted so far.
+func (m *MockExchange) Orders() []Order {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Order(nil), m.orders...)
+}
+
+func (m *MockExchange) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	req := Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Header: r.Header.Clone(), Body: body}
+	key := route(r.Method, r.URL.Path)
+
+	m.mu.Lock()
+	m.requests = append(m.requests, req)
+	delay := m.latency[key]
+	failure := m.failureLocked(key, req)
+	handler := m.handlers[key]
+	m.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case failure != nil:
+		writeFailure(w, failure)
+	case handler != nil:
+		handler(w, r)
+	default:
+		m.serveScenario(w, r, req)
+	}
+}
+
+func (m *MockExchange) failureLocked(key string, req Request) *Failure {
+	for _, f := range m.failures[key] {
+		if f.Match != nil && !f.Match(req) {
+			continue
+		}
+		f.seen++
+		if f.seen <= f.After || (f.Times > 0 && f.failed >= f.Times) {
+			continue
+		}
+		f.failed++
+		return f
+	}
+	return nil
+}
+
+func writeFailure(w http.ResponseWriter, f *Failure) {
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	switch {
+	case f.Body != "":
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, f.Body)
+	case f.Code != 0:
+		JSON(w, status, map[string]interface{}{"code": f.Code, "msg": f.Msg, "data": nil})
+	default:
+		w.WriteHeader(status)
+	}
+}
+
+func (m *MockExchange) serveScenario(w http.ResponseWriter, r *http.Request, req Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	symbol := r.URL.Query().Get("symbol")
+	switch route(r.Method, r.URL.Path) {
+	case route(http.MethodGet, "/g-accounts/positions"):
+		var positions []Position
+		if n := len(m.positions); n > 0 {
+			positions = m.positions[min(m.served, n-1)]
+			m.served++
+		}
+		if positions == nil {
+			positions = []Position{}
+		}
+		ok(w, map[string]interface{}{"positions": positions})
+
+	case route(http.MethodGet, "/g-accounts/risk-unit"):
+		units := make([]map[string]interface{}, 0, len(m.balances))
+		for _, s := range sortedKeys(m.balances) {
+			units = append(units, map[string]interface{}{"symbol": s, "estAvailableBalanceRv": m.balances[s]})
+		}
+		ok(w, units)
+
+	case route(http.MethodGet, "/md/v3/ticker/24hr"):
+		t, found := m.tickers[symbol]
+		if !found {
+			JSON(w, http.StatusOK, map[string]interface{}{"error": map[string]interface{}{"code": 6001, "message": "invalid symbol"}})
+			return
+		}
+		JSON(w, http.StatusOK, map[string]interface{}{"result": struct {
+			Symbol string `json:"symbol"`
+			Ticker
+		}{symbol, t}})
+
+	case route(http.MethodGet, "/md/v2/orderbook"):
+		b, found := m.books[symbol]
+		if !found {
+			JSON(w, http.StatusOK, map[string]interface{}{"error": map[string]interface{}{"code": 6001, "message": "invalid symbol"}})
+			return
+		}
+		JSON(w, http.StatusOK, map[string]interface{}{"result": map[string]interface{}{
+			"symbol":      symbol,
+			"orderbook_p": map[string]interface{}{"bids": b[0], "asks": b[1]},
+		}})
+
+	case route(http.MethodPost, "/g-orders"):
+		var o Order
+		if err := req.JSON(&o); err != nil {
+			JSON(w, http.StatusBadRequest, map[string]interface{}{"code": 10001, "msg": "invalid body"})
+			return
+		}
+		m.nextOrderID++
+		o.OrderID = fmt.Sprintf("mock-%d", m.nextOrderID)
+		if o.PriceRp == "" {
+			// Market orders fill at the last price.
+			o.PriceRp = m.tickers[o.Symbol].LastRp
+		}
+		o.OrdStatus = "New"
+		m.orders = append(m.orders, o)
+		if o.OrdType == "Market" {
+			m.attachExits(o)
+		}
+		ok(w, o)
+
+	case route(http.MethodPut, "/g-positions/leverage"):
+		ok(w, map[string]interface{}{})
+
+	case route(http.MethodPost, "/assets/transfer"):
+		// Spot to futures (moveOp 2) credits the available balance of every
+		// symbol, futures to spot debits it.
+		var t struct {
+			AmountEv int64 `json:"amountEv"`
+			MoveOp   int   `json:"moveOp"`
+		}
+		if err := req.JSON(&t); err != nil {
+			JSON(w, http.StatusBadRequest, map[string]interface{}{"code": 10001, "msg": "invalid body"})
+			return
+		}
+		amount := float64(t.AmountEv) / 1e8
+		if t.MoveOp == 1 {
+			amount = -amount
+		}
+		for s := range m.balances {
+			m.balances[s] += amount
+		}
+		ok(w, map[string]interface{}{})
+
+	case route(http.MethodDelete, "/g-orders/all"):
+		ok(w, map[string]interface{}{})
+
+	case route(http.MethodGet, "/g-orders/activeList"):
+		// Market orders fill at once; only resting orders stay active.
+		rows := []Order{}
+		for _, o := range append(append([]Order(nil), m.orders...), m.exits...) {
+			if resting(o) && (symbol == "" || o.Symbol == symbol) {
+				rows = append(rows, o)
+			}
+		}
+		ok(w, map[string]interface{}{"rows": rows})
+
+	case route(http.MethodDelete, "/g-orders/cancel"):
+		for i := range m.orders {
+			if m.orders[i].OrderID == r.URL.Query().Get("orderID") && resting(m.orders[i]) {
+				m.orders[i].OrdStatus = "Canceled"
+				ok(w, m.orders[i])
+				return
+			}
+		}
+		JSON(w, http.StatusOK, map[string]interface{}{"code": 10002, "msg": "OM_ORDER_NOT_FOUND"})
+
+	case route(http.MethodGet, "/g-trades/fills"):
+		fills := []Fill{}
+		for _, f := range m.fills {
+			if symbol == "" || f.Symbol == symbol {
+				fills = append(fills, f)
+			}
+		}
+		ok(w, map[string]interface{}{"rows": fills})
+
+	case route(http.MethodGet, "/api-data/g-futures/orders/by-order-id"):
+		rows := []Order{}
+		clOrdID := r.URL.Query().Get("clOrdID")
+		orderID := r.URL.Query().Get("orderID")
+		for _, o := range m.orders {
+			if (o.ClOrdID != "" && o.ClOrdID == clOrdID) || (orderID != "" && o.OrderID == orderID) {
+				rows = append(rows, o)
+			}
+		}
+		ok(w, map[string]interface{}{"rows": rows})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// JSON writes v as a JSON response.
+func JSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// ok writes a successful Phemex envelope around data.
+func ok(w http.ResponseWriter, data interface{}) {
+	JSON(w, http.StatusOK, map[string]interface{}{"code": 0, "msg": "", "data": data})
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resting reports whether o still rests on the book.
+func resting(o Order) bool {
+	return (o.OrdStatus == "New" || o.OrdStatus == "PartiallyFilled") && o.OrdType != "Market"
+}
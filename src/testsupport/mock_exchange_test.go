@@ -0,0 +1,117 @@
+package testsupport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, m *MockExchange, path string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(m.URL + path)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestPositionsSequence(t *testing.T) {
+	m := NewMockExchange(t).
+		WithPositions(Position{Symbol: "BTCUSDT", SizeRq: "1"}).
+		ThenPositions()
+
+	for i, want := range []int{1, 0, 0} {
+		_, body := get(t, m, "/g-accounts/positions?currency=USDT")
+		var out struct {
+			Data struct {
+				Positions []Position `json:"positions"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(body), &out); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(out.Data.Positions) != want {
+			t.Fatalf("call %d: %d positions, want %d", i, len(out.Data.Positions), want)
+		}
+	}
+	if n := m.Count(http.MethodGet, "/g-accounts/positions"); n != 3 {
+		t.Fatalf("recorded %d requests, want 3", n)
+	}
+}
+
+func TestFailureWindow(t *testing.T) {
+	m := NewMockExchange(t).
+		WithBalance("BTCUSDT", 10).
+		Fail(http.MethodGet, "/g-accounts/risk-unit", Failure{Status: http.StatusBadGateway, After: 1, Times: 2})
+
+	var got []int
+	for i := 0; i < 4; i++ {
+		status, _ := get(t, m, "/g-accounts/risk-unit")
+		got = append(got, status)
+	}
+	want := []int{200, 502, 502, 200}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("statuses %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBusinessErrorAndMatch(t *testing.T) {
+	m := NewMockExchange(t).
+		WithTicker("BTCUSDT", Ticker{LastRp: "50000"}).
+		Fail(http.MethodPost, "/g-orders", Failure{Code: 11001, Msg: "insufficient", Match: func(r Request) bool { return !r.ReduceOnly() }})
+
+	post := func(body string) string {
+		resp, err := http.Post(m.URL+"/g-orders", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST: %v", err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+
+	if body := post(`{"symbol":"BTCUSDT","side":"Buy","orderQtyRq":"1"}`); !strings.Contains(body, `"code":11001`) {
+		t.Fatalf("entry should fail: %s", body)
+	}
+	if body := post(`{"symbol":"BTCUSDT","side":"Sell","orderQtyRq":"1","reduceOnly":true}`); !strings.Contains(body, `"code":0`) {
+		t.Fatalf("close should pass: %s", body)
+	}
+	orders := m.Orders()
+	if len(orders) != 1 || orders[0].OrderID != "mock-1" || orders[0].PriceRp != "50000" || !orders[0].ReduceOnly {
+		t.Fatalf("unexpected orders: %+v", orders)
+	}
+}
+
+func TestLatencyHonoursClientTimeout(t *testing.T) {
+	m := NewMockExchange(t).WithLatency(http.MethodGet, "/g-accounts/positions", time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, m.URL+"/g-accounts/positions", nil)
+	_, err := http.DefaultClient.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+}
+
+func TestHandleOverridesAndUnknownRoutes(t *testing.T) {
+	m := NewMockExchange(t).Handle(http.MethodGet, "/derivatives/api/v3/openpositions", func(w http.ResponseWriter, r *http.Request) {
+		JSON(w, http.StatusOK, map[string]string{"result": "success"})
+	})
+
+	if status, body := get(t, m, "/derivatives/api/v3/openpositions"); status != 200 || !strings.Contains(body, "success") {
+		t.Fatalf("custom handler: %d %s", status, body)
+	}
+	if status, _ := get(t, m, "/nope"); status != http.StatusNotFound {
+		t.Fatalf("unknown route: %d", status)
+	}
+}
@@ -0,0 +1,133 @@
+package tp_sl
+
+import (
+	"fmt"
+	"strategyexecutor/src/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// Pattern is a candlestick pattern formed by the last candle of a series.
+type Pattern string
+
+const (
+	PatternBullishEngulfing Pattern = "bullish_engulfing"
+	PatternBearishEngulfing Pattern = "bearish_engulfing"
+	PatternBullishPinBar    Pattern = "bullish_pin_bar"
+	PatternBearishPinBar    Pattern = "bearish_pin_bar"
+	PatternInsideBar        Pattern = "inside_bar"
+)
+
+var patterns = []Pattern{
+	PatternBullishEngulfing,
+	PatternBearishEngulfing,
+	PatternBullishPinBar,
+	PatternBearishPinBar,
+	PatternInsideBar,
+}
+
+// ParsePattern returns the pattern named s.
+func ParsePattern(s string) (Pattern, error) {
+	for _, p := range patterns {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown candle pattern %q", s)
+}
+
+var (
+	// pinBarMinWick is the share of the candle range the rejection wick of a
+	// pin bar covers at least.
+	pinBarMinWick = decimal.NewFromInt(2).Div(decimal.NewFromInt(3))
+	// pinBarMaxBody is the share of the candle range the body of a pin bar
+	// covers at most.
+	pinBarMaxBody = decimal.NewFromInt(1).Div(decimal.NewFromInt(3))
+)
+
+func bodyTop(c model.OHLCVCrypto1m) decimal.Decimal    { return decimal.Max(c.Open, c.Close) }
+func bodyBottom(c model.OHLCVCrypto1m) decimal.Decimal { return decimal.Min(c.Open, c.Close) }
+
+// IsBullishEngulfing reports whether the bullish body of cur covers the
+// bearish body of prev.
+func IsBullishEngulfing(prev, cur model.OHLCVCrypto1m) bool {
+	return IsBearish(prev) && IsBullish(cur) &&
+		cur.Open.LessThanOrEqual(prev.Close) && cur.Close.GreaterThanOrEqual(prev.Open)
+}
+
+// IsBearishEngulfing reports whether the bearish body of cur covers the
+// bullish body of prev.
+func IsBearishEngulfing(prev, cur model.OHLCVCrypto1m) bool {
+	return IsBullish(prev) && IsBearish(cur) &&
+		cur.Open.GreaterThanOrEqual(prev.Close) && cur.Close.LessThanOrEqual(prev.Open)
+}
+
+// IsBullishPinBar reports whether c rejected lower prices: a small body at
+// the top of the range over a long lower wick.
+func IsBullishPinBar(c model.OHLCVCrypto1m) bool {
+	rng := c.High.Sub(c.Low)
+	if !rng.IsPositive() {
+		return false
+	}
+	body := bodyTop(c).Sub(bodyBottom(c))
+	lowerWick := bodyBottom(c).Sub(c.Low)
+	return body.LessThanOrEqual(rng.Mul(pinBarMaxBody)) && lowerWick.GreaterThanOrEqual(rng.Mul(pinBarMinWick))
+}
+
+// IsBearishPinBar reports whether c rejected higher prices: a small body at
+// the bottom of the range under a long upper wick.
+func IsBearishPinBar(c model.OHLCVCrypto1m) bool {
+	rng := c.High.Sub(c.Low)
+	if !rng.IsPositive() {
+		return false
+	}
+	body := bodyTop(c).Sub(bodyBottom(c))
+	upperWick := c.High.Sub(bodyTop(c))
+	return body.LessThanOrEqual(rng.Mul(pinBarMaxBody)) && upperWick.GreaterThanOrEqual(rng.Mul(pinBarMinWick))
+}
+
+// IsInsideBar reports whether the range of cur lies within the range of prev.
+func IsInsideBar(prev, cur model.OHLCVCrypto1m) bool {
+	return cur.High.LessThanOrEqual(prev.High) && cur.Low.GreaterThanOrEqual(prev.Low) &&
+		cur.High.Sub(cur.Low).LessThan(prev.High.Sub(prev.Low))
+}
+
+// DetectPatterns returns the patterns formed by the last candle of candles,
+// which should be closed and in chronological order.
+func DetectPatterns(candles []model.OHLCVCrypto1m) []Pattern {
+	if len(candles) == 0 {
+		return nil
+	}
+	cur := candles[len(candles)-1]
+
+	var found []Pattern
+	if len(candles) >= 2 {
+		prev := candles[len(candles)-2]
+		if IsBullishEngulfing(prev, cur) {
+			found = append(found, PatternBullishEngulfing)
+		}
+		if IsBearishEngulfing(prev, cur) {
+			found = append(found, PatternBearishEngulfing)
+		}
+		if IsInsideBar(prev, cur) {
+			found = append(found, PatternInsideBar)
+		}
+	}
+	if IsBullishPinBar(cur) {
+		found = append(found, PatternBullishPinBar)
+	}
+	if IsBearishPinBar(cur) {
+		found = append(found, PatternBearishPinBar)
+	}
+	return found
+}
+
+// HasPattern reports whether the last candle of candles forms p.
+func HasPattern(candles []model.OHLCVCrypto1m, p Pattern) bool {
+	for _, found := range DetectPatterns(candles) {
+		if found == p {
+			return true
+		}
+	}
+	return false
+}
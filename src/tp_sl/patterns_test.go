@@ -0,0 +1,88 @@
+package tp_sl
+
+import (
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+func TestEngulfing(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	bearish := c(now, "102", "103", "99", "100")
+	bullish := c(now.Add(time.Minute), "99.5", "104", "99", "103")
+
+	if !IsBullishEngulfing(bearish, bullish) {
+		t.Fatalf("expected bullish engulfing")
+	}
+	if IsBearishEngulfing(bearish, bullish) {
+		t.Fatalf("expected not bearish engulfing")
+	}
+
+	small := c(now.Add(time.Minute), "100.5", "102", "100", "101.5")
+	if IsBullishEngulfing(bearish, small) {
+		t.Fatalf("expected no engulfing when the body is covered")
+	}
+
+	up := c(now, "100", "103", "99", "102")
+	down := c(now.Add(time.Minute), "102.5", "103", "98", "99")
+	if !IsBearishEngulfing(up, down) {
+		t.Fatalf("expected bearish engulfing")
+	}
+}
+
+func TestPinBar(t *testing.T) {
+	now := time.Now()
+	hammer := c(now, "109", "110", "100", "109.5")
+	if !IsBullishPinBar(hammer) || IsBearishPinBar(hammer) {
+		t.Fatalf("expected a bullish pin bar only")
+	}
+	star := c(now, "100.5", "110", "100", "100")
+	if !IsBearishPinBar(star) || IsBullishPinBar(star) {
+		t.Fatalf("expected a bearish pin bar only")
+	}
+	if IsBullishPinBar(c(now, "100", "110", "100", "110")) {
+		t.Fatalf("expected no pin bar for a full body")
+	}
+	if IsBullishPinBar(c(now, "100", "100", "100", "100")) {
+		t.Fatalf("expected no pin bar for a flat candle")
+	}
+}
+
+func TestInsideBar(t *testing.T) {
+	now := time.Now()
+	mother := c(now, "100", "110", "95", "105")
+	if !IsInsideBar(mother, c(now, "104", "108", "97", "102")) {
+		t.Fatalf("expected inside bar")
+	}
+	if IsInsideBar(mother, c(now, "104", "111", "97", "102")) {
+		t.Fatalf("expected no inside bar above the mother high")
+	}
+	if IsInsideBar(mother, mother) {
+		t.Fatalf("expected no inside bar for an equal range")
+	}
+}
+
+func TestDetectPatterns(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	candles := []model.OHLCVCrypto1m{
+		c(now, "102", "103", "99", "100"),
+		c(now.Add(time.Minute), "99.5", "104", "99", "103"),
+	}
+	found := DetectPatterns(candles)
+	if len(found) != 1 || found[0] != PatternBullishEngulfing {
+		t.Fatalf("expected [bullish_engulfing], got %v", found)
+	}
+	if !HasPattern(candles, PatternBullishEngulfing) || HasPattern(candles, PatternInsideBar) {
+		t.Fatalf("unexpected HasPattern answers")
+	}
+	if DetectPatterns(nil) != nil {
+		t.Fatalf("expected no pattern without candles")
+	}
+
+	if _, err := ParsePattern("inside_bar"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := ParsePattern("doji"); err == nil {
+		t.Fatalf("expected an error for an unknown pattern")
+	}
+}
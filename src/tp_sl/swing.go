@@ -0,0 +1,124 @@
+package tp_sl
+
+import (
+	"strategyexecutor/src/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// StopMode selects how the stop loss of an entry is placed and trailed.
+type StopMode string
+
+const (
+	// StopModeSignal uses the stop of the signal and trails it with
+	// ComputeNextStopLossDirectional.
+	StopModeSignal StopMode = "signal"
+	// StopModeSwing places the stop beyond the latest confirmed swing and
+	// trails it to each new swing, see ComputeNextSwingStop.
+	StopModeSwing StopMode = "swing"
+)
+
+// DefaultSwingStrength is the number of candles on each side a swing point
+// must exceed to be confirmed.
+const DefaultSwingStrength = 2
+
+// IsSwingLow reports whether candles[i] has a strictly lower low than the
+// strength candles before and after it.
+func IsSwingLow(candles []model.OHLCVCrypto1m, i, strength int) bool {
+	if strength <= 0 || i-strength < 0 || i+strength >= len(candles) {
+		return false
+	}
+	for j := i - strength; j <= i+strength; j++ {
+		if j != i && !candles[i].Low.LessThan(candles[j].Low) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSwingHigh reports whether candles[i] has a strictly higher high than the
+// strength candles before and after it.
+func IsSwingHigh(candles []model.OHLCVCrypto1m, i, strength int) bool {
+	if strength <= 0 || i-strength < 0 || i+strength >= len(candles) {
+		return false
+	}
+	for j := i - strength; j <= i+strength; j++ {
+		if j != i && !candles[i].High.GreaterThan(candles[j].High) {
+			return false
+		}
+	}
+	return true
+}
+
+// LastSwingLow returns the low of the most recent confirmed swing low.
+func LastSwingLow(candles []model.OHLCVCrypto1m, strength int) (decimal.Decimal, bool) {
+	for i := len(candles) - 1 - strength; i >= strength; i-- {
+		if IsSwingLow(candles, i, strength) {
+			return candles[i].Low, true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// LastSwingHigh returns the high of the most recent confirmed swing high.
+func LastSwingHigh(candles []model.OHLCVCrypto1m, strength int) (decimal.Decimal, bool) {
+	for i := len(candles) - 1 - strength; i >= strength; i-- {
+		if IsSwingHigh(candles, i, strength) {
+			return candles[i].High, true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// SwingStop returns the initial stop of a new position: the latest confirmed
+// swing low for longs, swing high for shorts. ok is false when there is no
+// swing on the right side of the last close.
+func SwingStop(side Side, candles []model.OHLCVCrypto1m, strength int) (stop decimal.Decimal, ok bool) {
+	if len(candles) == 0 {
+		return decimal.Zero, false
+	}
+	if strength <= 0 {
+		strength = DefaultSwingStrength
+	}
+	last := candles[len(candles)-1].Close
+
+	switch side {
+	case SideLong:
+		stop, ok = LastSwingLow(candles, strength)
+		return stop, ok && stop.LessThan(last)
+	case SideShort:
+		stop, ok = LastSwingHigh(candles, strength)
+		return stop, ok && stop.GreaterThan(last)
+	default:
+		return decimal.Zero, false
+	}
+}
+
+// ComputeNextSwingStop trails a swing stop: it moves to the latest confirmed
+// swing when that tightens the stop, never loosening it.
+//
+// Long:  SL = max(SL, last swing low)
+// Short: SL = min(SL, last swing high)
+func ComputeNextSwingStop(
+	side Side,
+	currentSL decimal.Decimal,
+	candles []model.OHLCVCrypto1m,
+	strength int,
+) (newSL decimal.Decimal, moved bool) {
+	candidate, ok := SwingStop(side, candles, strength)
+	if !ok {
+		return currentSL, false
+	}
+
+	switch side {
+	case SideLong:
+		if candidate.GreaterThan(currentSL) {
+			return candidate, true
+		}
+	case SideShort:
+		if currentSL.IsZero() || candidate.LessThan(currentSL) {
+			return candidate, true
+		}
+	}
+	return currentSL, false
+}
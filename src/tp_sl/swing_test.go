@@ -0,0 +1,89 @@
+package tp_sl
+
+import (
+	"strategyexecutor/src/model"
+	"testing"
+	"time"
+)
+
+// swingCandles has a confirmed swing low of 95 at index 2 and a confirmed
+// swing high of 112 at index 5; the last two candles confirm the high.
+func swingCandles() []model.OHLCVCrypto1m {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return now.Add(time.Duration(i) * 15 * time.Minute) }
+	return []model.OHLCVCrypto1m{
+		c(at(0), "100", "104", "98", "102"),
+		c(at(1), "102", "103", "97", "98"),
+		c(at(2), "98", "101", "95", "100"),
+		c(at(3), "100", "106", "97", "105"),
+		c(at(4), "105", "109", "99", "108"),
+		c(at(5), "108", "112", "104", "110"),
+		c(at(6), "110", "111", "103", "104"),
+		c(at(7), "104", "108", "101", "106"),
+	}
+}
+
+func TestLastSwing(t *testing.T) {
+	candles := swingCandles()
+
+	low, ok := LastSwingLow(candles, 2)
+	if !ok || !low.Equal(d("95")) {
+		t.Fatalf("expected swing low 95, got %s %v", low, ok)
+	}
+	high, ok := LastSwingHigh(candles, 2)
+	if !ok || !high.Equal(d("112")) {
+		t.Fatalf("expected swing high 112, got %s %v", high, ok)
+	}
+
+	// Without two candles after it the high is not confirmed yet.
+	if _, ok := LastSwingHigh(candles[:7], 2); ok {
+		t.Fatalf("expected no confirmed swing high")
+	}
+	if IsSwingLow(candles, 0, 2) || IsSwingLow(candles, 2, 0) {
+		t.Fatalf("expected no swing at the edges or with zero strength")
+	}
+}
+
+func TestSwingStop(t *testing.T) {
+	candles := swingCandles()
+
+	stop, ok := SwingStop(SideLong, candles, 2)
+	if !ok || !stop.Equal(d("95")) {
+		t.Fatalf("expected long stop 95, got %s %v", stop, ok)
+	}
+	stop, ok = SwingStop(SideShort, candles, 2)
+	if !ok || !stop.Equal(d("112")) {
+		t.Fatalf("expected short stop 112, got %s %v", stop, ok)
+	}
+
+	// A swing on the wrong side of the last close is unusable.
+	crashed := append(swingCandles(), c(time.Now(), "106", "106", "90", "92"))
+	if _, ok := SwingStop(SideLong, crashed, 2); ok {
+		t.Fatalf("expected no long stop above the last close")
+	}
+	if _, ok := SwingStop(SideLong, nil, 2); ok {
+		t.Fatalf("expected no stop without candles")
+	}
+}
+
+func TestComputeNextSwingStop(t *testing.T) {
+	candles := swingCandles()
+
+	sl, moved := ComputeNextSwingStop(SideLong, d("90"), candles, 2)
+	if !moved || !sl.Equal(d("95")) {
+		t.Fatalf("expected long stop raised to 95, got %s %v", sl, moved)
+	}
+	sl, moved = ComputeNextSwingStop(SideLong, d("96"), candles, 2)
+	if moved || !sl.Equal(d("96")) {
+		t.Fatalf("expected long stop never lowered, got %s %v", sl, moved)
+	}
+
+	sl, moved = ComputeNextSwingStop(SideShort, d("120"), candles, 2)
+	if !moved || !sl.Equal(d("112")) {
+		t.Fatalf("expected short stop lowered to 112, got %s %v", sl, moved)
+	}
+	sl, moved = ComputeNextSwingStop(SideShort, d("111"), candles, 2)
+	if moved || !sl.Equal(d("111")) {
+		t.Fatalf("expected short stop never raised, got %s %v", sl, moved)
+	}
+}
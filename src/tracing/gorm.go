@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin records a client span for every query run with a context,
+// e.g. db.WithContext(ctx) in the repositories. Register it with db.Use.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string { return "tracing" }
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", beforeQuery("db.create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", afterQuery),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", beforeQuery("db.query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", afterQuery),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", beforeQuery("db.update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", afterQuery),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", beforeQuery("db.delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", afterQuery),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", beforeQuery("db.row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", afterQuery),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", beforeQuery("db.raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", afterQuery),
+	)
+}
+
+func beforeQuery(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		ctx, span := StartKind(db.Statement.Context, SpanKindClient, name, Attr("db.system", db.Dialector.Name()))
+		if span == nil {
+			return
+		}
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func afterQuery(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, _ := v.(*Span)
+	if span == nil {
+		return
+	}
+
+	if db.Statement.Table != "" {
+		span.SetAttributes(Attr("db.sql.table", db.Statement.Table))
+	}
+	span.SetAttributes(
+		Attr("db.statement", db.Statement.SQL.String()),
+		Attr("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+	}
+	span.End()
+}
@@ -0,0 +1,268 @@
+// Package tracing records spans across the executor, controllers, connector
+// HTTP calls and database queries and exports them to an OpenTelemetry
+// collector (Jaeger, Tempo) over OTLP/HTTP with JSON encoding. Context is
+// propagated with the W3C traceparent header.
+//
+// Tracing is off unless OTEL_EXPORTER_OTLP_ENDPOINT is set; Start then
+// returns a nil span and every Span method is a no-op on nil.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	logger "github.com/sirupsen/logrus"
+)
+
+type Config struct {
+	// Endpoint is the collector base URL, e.g. http://tempo:4318. Empty
+	// disables tracing.
+	Endpoint    string `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT" default:""`
+	ServiceName string `envconfig:"OTEL_SERVICE_NAME" default:"strategyexecutor"`
+	// SampleRatio is the share of root spans recorded, from 0 to 1. Child
+	// spans follow their parent.
+	SampleRatio float64 `envconfig:"OTEL_TRACES_SAMPLER_ARG" default:"1"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
+
+// SpanKind follows the OTLP enum.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+
+// SpanContext identifies a span, local or received from a remote caller.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Attribute is a key/value pair attached to a span. Values are strings,
+// bools, integers or floats; anything else is exported with fmt.Sprint.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func Attr(key string, value interface{}) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is one timed operation. Methods are safe on a nil span and after End.
+type Span struct {
+	tracer   *Tracer
+	sc       SpanContext
+	parentID SpanID
+	name     string
+	kind     SpanKind
+	start    time.Time
+
+	mu       sync.Mutex
+	end      time.Time
+	attrs    []Attribute
+	errorMsg string
+	ended    bool
+}
+
+// SpanContext returns the identity of s, zero for a nil span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetName renames s, e.g. once a server span knows its route.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.name = name
+	s.mu.Unlock()
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs = append(s.attrs, attrs...)
+	s.mu.Unlock()
+}
+
+// RecordError marks s as failed with err. A nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errorMsg = err.Error()
+	s.mu.Unlock()
+}
+
+// End finishes s and queues it for export. Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if s.sc.Sampled {
+		s.tracer.exporter.enqueue(s)
+	}
+}
+
+// Tracer creates spans and hands the sampled ones to its exporter.
+type Tracer struct {
+	service     string
+	sampleRatio float64
+	exporter    *exporter
+}
+
+var global atomic.Pointer[Tracer]
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// Setup installs the tracer described by the environment and returns the
+// function flushing it on shutdown. It is a no-op when tracing is disabled.
+func Setup() (shutdown func(context.Context) error) {
+	config := GetConfig()
+	if config.Endpoint == "" {
+		return func(context.Context) error { return nil }
+	}
+
+	t := NewTracer(config)
+	global.Store(t)
+	logger.WithFields(map[string]interface{}{
+		"endpoint": config.Endpoint,
+		"service":  config.ServiceName,
+		"ratio":    config.SampleRatio,
+	}).Info("Tracing enabled")
+
+	return func(ctx context.Context) error {
+		global.CompareAndSwap(t, nil)
+		return t.Shutdown(ctx)
+	}
+}
+
+// NewTracer returns a tracer exporting to config.Endpoint. Use Setup to
+// install it process wide.
+func NewTracer(config *Config) *Tracer {
+	return &Tracer{
+		service:     config.ServiceName,
+		sampleRatio: config.SampleRatio,
+		exporter:    newExporter(config.Endpoint, config.ServiceName),
+	}
+}
+
+// SetTracer installs t process wide, nil disables tracing. It returns the
+// previous tracer so tests can restore it.
+func SetTracer(t *Tracer) *Tracer {
+	return global.Swap(t)
+}
+
+// Shutdown exports the spans still queued.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	return t.exporter.shutdown(ctx)
+}
+
+// Start begins an internal span as a child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return StartKind(ctx, SpanKindInternal, name, attrs...)
+}
+
+// StartKind is Start for client and server spans.
+func StartKind(ctx context.Context, kind SpanKind, name string, attrs ...Attribute) (context.Context, *Span) {
+	t := global.Load()
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := SpanFromContext(ctx).SpanContext()
+	if !parent.IsValid() {
+		parent, _ = ctx.Value(remoteKey{}).(SpanContext)
+	}
+
+	span := &Span{
+		tracer: t,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	if parent.IsValid() {
+		span.sc.TraceID = parent.TraceID
+		span.sc.Sampled = parent.Sampled
+		span.parentID = parent.SpanID
+	} else {
+		span.sc.TraceID = newTraceID()
+		span.sc.Sampled = t.sampleRatio >= 1 || mathrand.Float64() < t.sampleRatio
+	}
+	span.sc.SpanID = newSpanID()
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanFromContext returns the current span of ctx, nil when there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemote returns ctx with sc, received from a caller, as the
+// parent of the next span started from it.
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+func newTraceID() (id TraceID) {
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() (id SpanID) {
+	_, _ = rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,159 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type collector struct {
+	mu    sync.Mutex
+	spans []otlpSpan
+}
+
+func (c *collector) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/traces", r.URL.Path)
+		var req otlpRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "service.name", req.ResourceSpans[0].Resource.Attributes[0].Key)
+		c.mu.Lock()
+		c.spans = append(c.spans, req.ResourceSpans[0].ScopeSpans[0].Spans...)
+		c.mu.Unlock()
+	}
+}
+
+func (c *collector) byName(name string) otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	return otlpSpan{}
+}
+
+func installTracer(t *testing.T, endpoint string, ratio float64) *Tracer {
+	t.Helper()
+	tr := NewTracer(&Config{Endpoint: endpoint, ServiceName: "test", SampleRatio: ratio})
+	prev := SetTracer(tr)
+	t.Cleanup(func() {
+		SetTracer(prev)
+		_ = tr.Shutdown(context.Background())
+	})
+	return tr
+}
+
+func TestDisabled(t *testing.T) {
+	prev := SetTracer(nil)
+	defer SetTracer(prev)
+
+	ctx, span := Start(context.Background(), "noop")
+	require.Nil(t, span)
+	require.Nil(t, SpanFromContext(ctx))
+	span.SetAttributes(Attr("k", "v"))
+	span.RecordError(errors.New("ignored"))
+	span.End()
+
+	h := http.Header{}
+	Inject(ctx, h)
+	require.Empty(t, h.Get(traceparentHeader))
+}
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	installTracer(t, "http://127.0.0.1:0", 1)
+
+	ctx, span := Start(context.Background(), "parent")
+	h := http.Header{}
+	Inject(ctx, h)
+
+	sc, ok := parseTraceparent(h.Get(traceparentHeader))
+	require.True(t, ok)
+	require.Equal(t, span.SpanContext(), sc)
+
+	child := Extract(context.Background(), h)
+	_, childSpan := Start(child, "child")
+	require.Equal(t, sc.TraceID, childSpan.SpanContext().TraceID)
+	require.Equal(t, sc.SpanID, childSpan.parentID)
+
+	for _, bad := range []string{"", "garbage", "00-" + sc.TraceID.String() + "-0000000000000000-01", "ff-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-01"} {
+		_, ok := parseTraceparent(bad)
+		require.False(t, ok, bad)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	installTracer(t, "http://127.0.0.1:0", 0)
+
+	ctx, root := Start(context.Background(), "root")
+	require.False(t, root.SpanContext().Sampled)
+	_, child := Start(ctx, "child")
+	require.False(t, child.SpanContext().Sampled)
+	require.Equal(t, root.SpanContext().TraceID, child.SpanContext().TraceID)
+}
+
+func TestEndToEndExport(t *testing.T) {
+	var col collector
+	collectorSrv := httptest.NewServer(col.handler(t))
+	defer collectorSrv.Close()
+	tr := installTracer(t, collectorSrv.URL, 1)
+
+	var gotTraceparent string
+	exchange := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get(traceparentHeader)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer exchange.Close()
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(GormPlugin{}))
+
+	ctx, root := Start(context.Background(), "executor.run_controller", Attr("user_id", uint(7)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, exchange.URL+"/g-accounts/positions?currency=USDT", nil)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	var n int
+	require.NoError(t, db.WithContext(ctx).Raw("SELECT 1").Scan(&n).Error)
+
+	root.End()
+	require.NoError(t, tr.Shutdown(context.Background()))
+
+	rootOut := col.byName("executor.run_controller")
+	require.NotEmpty(t, rootOut.SpanID)
+	require.Empty(t, rootOut.ParentSpanID)
+
+	httpOut := col.byName("HTTP GET")
+	require.Equal(t, rootOut.TraceID, httpOut.TraceID)
+	require.Equal(t, rootOut.SpanID, httpOut.ParentSpanID)
+	require.Equal(t, SpanKindClient, httpOut.Kind)
+	require.Equal(t, statusError, httpOut.Status.Code)
+	require.Contains(t, gotTraceparent, httpOut.SpanID)
+
+	dbOut := col.byName("db.row")
+	require.Equal(t, rootOut.SpanID, dbOut.ParentSpanID)
+
+	var url string
+	for _, a := range httpOut.Attributes {
+		if a.Key == "http.url" {
+			url = *a.Value.StringValue
+		}
+	}
+	require.Equal(t, exchange.URL+"/g-accounts/positions", url)
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type Config struct {
+	// TradingViewPassphrase enables the "tradingview" provider. TradingView
+	// cannot sign requests, so the alert message must carry it in its
+	// "passphrase" field.
+	TradingViewPassphrase string `envconfig:"WEBHOOK_TRADINGVIEW_PASSPHRASE" default:""`
+	// TradingViewAllowedIPs are the addresses TradingView sends webhooks from.
+	TradingViewAllowedIPs []string `envconfig:"WEBHOOK_TRADINGVIEW_ALLOWED_IPS" default:"52.89.214.238,34.212.75.30,54.218.53.128,52.32.178.7"`
+
+	// HMACSecrets registers one provider per entry, "name:secret". Requests
+	// carry the hex HMAC-SHA256 of the body in HMACHeader.
+	HMACSecrets    map[string]string `envconfig:"WEBHOOK_HMAC_SECRETS" default:""`
+	HMACHeader     string            `envconfig:"WEBHOOK_HMAC_HEADER" default:"X-Signature"`
+	HMACAllowedIPs []string          `envconfig:"WEBHOOK_HMAC_ALLOWED_IPS" default:""`
+
+	// TrustForwardedFor takes the client address from the last
+	// X-Forwarded-For entry, only enable it behind a proxy appending to it.
+	TrustForwardedFor bool `envconfig:"WEBHOOK_TRUST_FORWARDED_FOR" default:"false"`
+}
+
+func GetConfig() *Config {
+	var config Config
+	if err := envconfig.Process("", &config); err != nil {
+		panic(fmt.Errorf("error processing env config: %w", err))
+	}
+	return &config
+}
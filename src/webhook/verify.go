@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Verification errors. ErrForbiddenIP is told apart so the server can answer
+// 403 instead of 401.
+var (
+	ErrUnauthenticated = errors.New("webhook authentication failed")
+	ErrForbiddenIP     = errors.New("webhook source address not allowed")
+)
+
+// Verifier authenticates a webhook request. body is the raw request body,
+// already read.
+type Verifier interface {
+	Verify(r *http.Request, body []byte) error
+}
+
+// VerifierFunc adapts a function to Verifier.
+type VerifierFunc func(r *http.Request, body []byte) error
+
+func (f VerifierFunc) Verify(r *http.Request, body []byte) error { return f(r, body) }
+
+// Passphrase checks the "passphrase" field of a JSON body, the only secret a
+// TradingView alert can carry.
+func Passphrase(passphrase string) Verifier {
+	return VerifierFunc(func(_ *http.Request, body []byte) error {
+		var payload struct {
+			Passphrase string `json:"passphrase"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil || payload.Passphrase == "" {
+			return ErrUnauthenticated
+		}
+		if subtle.ConstantTimeCompare([]byte(payload.Passphrase), []byte(passphrase)) != 1 {
+			return ErrUnauthenticated
+		}
+		return nil
+	})
+}
+
+// HMAC checks header holds the hex HMAC-SHA256 of the body keyed by secret.
+// A "sha256=" prefix, as sent by several providers, is accepted.
+func HMAC(header, secret string) Verifier {
+	return VerifierFunc(func(r *http.Request, body []byte) error {
+		raw := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(header)), "sha256=")
+		got, err := hex.DecodeString(raw)
+		if err != nil || len(got) == 0 {
+			return ErrUnauthenticated
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if !hmac.Equal(got, mac.Sum(nil)) {
+			return ErrUnauthenticated
+		}
+		return nil
+	})
+}
+
+// Sign returns the hex HMAC-SHA256 of body, as expected by HMAC.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IPAllowList accepts requests from the given addresses or CIDR ranges. With
+// trustForwarded the last X-Forwarded-For address, set by the proxy in front
+// of the server, is used instead of the connection address.
+func IPAllowList(entries []string, trustForwarded bool) (Verifier, error) {
+	var nets []*net.IPNet
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if !strings.Contains(e, "/") {
+			if ip := net.ParseIP(e); ip != nil && ip.To4() != nil {
+				e += "/32"
+			} else {
+				e += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed address %q: %w", e, err)
+		}
+		nets = append(nets, n)
+	}
+
+	return VerifierFunc(func(r *http.Request, _ []byte) error {
+		ip := net.ParseIP(clientIP(r, trustForwarded))
+		if ip == nil {
+			return ErrForbiddenIP
+		}
+		for _, n := range nets {
+			if n.Contains(ip) {
+				return nil
+			}
+		}
+		return ErrForbiddenIP
+	}), nil
+}
+
+func clientIP(r *http.Request, trustForwarded bool) string {
+	if trustForwarded {
+		// The proxy appends the address it saw; earlier entries come from
+		// the client and cannot be trusted.
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			parts := strings.Split(fwd, ",")
+			return strings.TrimSpace(parts[len(parts)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// All requires every verifier to pass, in order.
+func All(verifiers ...Verifier) Verifier {
+	return VerifierFunc(func(r *http.Request, body []byte) error {
+		for _, v := range verifiers {
+			if err := v.Verify(r, body); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,255 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"strategyexecutor/src/externalmodel"
+)
+
+// Provider is a source of trading signals: how its requests are
+// authenticated and how its payload maps onto a TradingSignal.
+type Provider struct {
+	Name     string
+	Verifier Verifier
+	Parse    func(body []byte) (*externalmodel.TradingSignal, error)
+}
+
+// Registry holds the providers accepted by the webhook endpoint.
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry() *Registry {
+	return &Registry{providers: map[string]Provider{}}
+}
+
+// Register adds p. A provider without verifier is refused so no source can
+// be accepted unauthenticated by mistake.
+func (r *Registry) Register(p Provider) error {
+	name := strings.ToLower(strings.TrimSpace(p.Name))
+	switch {
+	case name == "":
+		return errors.New("webhook provider without name")
+	case p.Verifier == nil:
+		return fmt.Errorf("webhook provider %q has no verifier", name)
+	case p.Parse == nil:
+		return fmt.Errorf("webhook provider %q has no parser", name)
+	}
+	if _, ok := r.providers[name]; ok {
+		return fmt.Errorf("webhook provider %q registered twice", name)
+	}
+	p.Name = name
+	r.providers[name] = p
+	return nil
+}
+
+// Lookup returns the provider registered under name.
+func (r *Registry) Lookup(name string) (Provider, bool) {
+	p, ok := r.providers[strings.ToLower(name)]
+	return p, ok
+}
+
+// Names lists the registered providers, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FromConfig registers the TradingView provider when a passphrase is set and
+// one HMAC provider per configured secret.
+func FromConfig(config *Config) (*Registry, error) {
+	reg := NewRegistry()
+
+	if config.TradingViewPassphrase != "" {
+		verifier := Passphrase(config.TradingViewPassphrase)
+		if len(config.TradingViewAllowedIPs) > 0 {
+			allow, err := IPAllowList(config.TradingViewAllowedIPs, config.TrustForwardedFor)
+			if err != nil {
+				return nil, err
+			}
+			verifier = All(allow, verifier)
+		}
+		if err := reg.Register(Provider{Name: "tradingview", Verifier: verifier, Parse: ParseSignal}); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, secret := range config.HMACSecrets {
+		if secret == "" {
+			return nil, fmt.Errorf("webhook provider %q has an empty secret", name)
+		}
+		verifier := HMAC(config.HMACHeader, secret)
+		if len(config.HMACAllowedIPs) > 0 {
+			allow, err := IPAllowList(config.HMACAllowedIPs, config.TrustForwardedFor)
+			if err != nil {
+				return nil, err
+			}
+			verifier = All(allow, verifier)
+		}
+		if err := reg.Register(Provider{Name: name, Verifier: verifier, Parse: ParseSignal}); err != nil {
+			return nil, err
+		}
+	}
+
+	return reg, nil
+}
+
+// signalPayload is the JSON accepted by ParseSignal. Numbers may be sent as
+// strings since TradingView placeholders are often quoted.
+type signalPayload struct {
+	OrderID                string     `json:"order_id"`
+	Exchange               string     `json:"exchange"`
+	Symbol                 string     `json:"symbol"`
+	Action                 string     `json:"action"`
+	OrderType              string     `json:"order_type"`
+	Qty                    flexFloat  `json:"qty"`
+	Price                  *flexFloat `json:"price"`
+	MarketPosition         string     `json:"market_position"`
+	PrevMarketPosition     string     `json:"prev_market_position"`
+	MarketPositionSize     flexFloat  `json:"market_position_size"`
+	PrevMarketPositionSize flexFloat  `json:"prev_market_position_size"`
+	Timestamp              string     `json:"timestamp"`
+	Comment                string     `json:"comment"`
+
+	// schema v2
+	Quantity        *flexFloat `json:"quantity"`
+	SizePercent     *flexFloat `json:"size_percent"`
+	Leverage        *flexFloat `json:"leverage"`
+	StopLoss        *flexFloat `json:"stop_loss"`
+	TakeProfit      *flexFloat `json:"take_profit"`
+	ValidUntil      string     `json:"valid_until"`
+	ValidUntilCamel string     `json:"validUntil"`
+	Strategy        string     `json:"strategy"`
+}
+
+// ParseSignal decodes the generic signal payload. Exchange, symbol and a buy
+// or sell action are required; the schema v2 fields are optional, zero
+// meaning unset. valid_until (or validUntil) is RFC3339 or unix seconds.
+func ParseSignal(body []byte) (*externalmodel.TradingSignal, error) {
+	var p signalPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("invalid signal payload: %w", err)
+	}
+
+	action := strings.ToLower(strings.TrimSpace(p.Action))
+	if action != "buy" && action != "sell" {
+		return nil, fmt.Errorf("invalid action %q, want buy or sell", p.Action)
+	}
+	if strings.TrimSpace(p.Exchange) == "" || strings.TrimSpace(p.Symbol) == "" {
+		return nil, errors.New("exchange and symbol are required")
+	}
+
+	signal := &externalmodel.TradingSignal{
+		OrderID:                p.OrderID,
+		ExchangeName:           strings.ToLower(strings.TrimSpace(p.Exchange)),
+		Symbol:                 strings.ToUpper(strings.TrimSpace(p.Symbol)),
+		Action:                 action,
+		OrderType:              p.OrderType,
+		Qty:                    float64(p.Qty),
+		MarketPosition:         p.MarketPosition,
+		PrevMarketPosition:     p.PrevMarketPosition,
+		MarketPositionSize:     float64(p.MarketPositionSize),
+		PrevMarketPositionSize: float64(p.PrevMarketPositionSize),
+		TimestampRaw:           p.Timestamp,
+		Comment:                p.Comment,
+	}
+	if p.Price != nil {
+		price := float64(*p.Price)
+		signal.Price = &price
+	}
+	if ts, err := time.Parse(time.RFC3339, p.Timestamp); err == nil {
+		ts = ts.UTC()
+		signal.TimestampDT = &ts
+	}
+
+	if err := parseV2(&p, signal); err != nil {
+		return nil, err
+	}
+	return signal, nil
+}
+
+func parseV2(p *signalPayload, signal *externalmodel.TradingSignal) error {
+	signal.Quantity = p.Quantity.value()
+	signal.SizePercent = p.SizePercent.value()
+	signal.Leverage = p.Leverage.value()
+	signal.StopLoss = p.StopLoss.value()
+	signal.TakeProfit = p.TakeProfit.value()
+	signal.StrategyTag = strings.TrimSpace(p.Strategy)
+
+	for name, v := range map[string]*float64{
+		"quantity":     signal.Quantity,
+		"size_percent": signal.SizePercent,
+		"leverage":     signal.Leverage,
+		"stop_loss":    signal.StopLoss,
+		"take_profit":  signal.TakeProfit,
+	} {
+		if v != nil && *v < 0 {
+			return fmt.Errorf("%s must be positive", name)
+		}
+	}
+	if signal.SizePercent != nil && *signal.SizePercent > 100 {
+		return errors.New("size_percent must be at most 100")
+	}
+	if sl, tp := signal.StopLoss, signal.TakeProfit; sl != nil && tp != nil {
+		if (signal.Action == "buy" && *sl >= *tp) || (signal.Action == "sell" && *sl <= *tp) {
+			return fmt.Errorf("stop_loss %v and take_profit %v are on the wrong side for a %s", *sl, *tp, signal.Action)
+		}
+	}
+
+	raw := strings.TrimSpace(p.ValidUntil)
+	if raw == "" {
+		raw = strings.TrimSpace(p.ValidUntilCamel)
+	}
+	if raw != "" {
+		until, err := parseTime(raw)
+		if err != nil {
+			return fmt.Errorf("invalid valid_until %q", raw)
+		}
+		signal.ValidUntil = &until
+	}
+	return nil
+}
+
+func parseTime(raw string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	return t.UTC(), err
+}
+
+// flexFloat decodes a JSON number or a numeric string. Empty strings are 0.
+type flexFloat float64
+
+func (f *flexFloat) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	if raw == "" || raw == "null" {
+		*f = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*f = flexFloat(v)
+	return nil
+}
+
+// value returns nil for a missing or zero number.
+func (f *flexFloat) value() *float64 {
+	if f == nil || *f == 0 {
+		return nil
+	}
+	v := float64(*f)
+	return &v
+}
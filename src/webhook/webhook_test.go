@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func request(remote string, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/x", strings.NewReader(body))
+	r.RemoteAddr = remote
+	return r
+}
+
+func TestPassphrase(t *testing.T) {
+	v := Passphrase("s3cret")
+
+	if err := v.Verify(request("1.2.3.4:1", ""), []byte(`{"passphrase":"s3cret"}`)); err != nil {
+		t.Fatalf("valid passphrase rejected: %v", err)
+	}
+	for _, body := range []string{`{"passphrase":"nope"}`, `{}`, `not json`} {
+		if err := v.Verify(request("1.2.3.4:1", ""), []byte(body)); !errors.Is(err, ErrUnauthenticated) {
+			t.Fatalf("body %s: err = %v, want ErrUnauthenticated", body, err)
+		}
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	body := []byte(`{"symbol":"BTCUSDT"}`)
+	v := HMAC("X-Signature", "key")
+
+	r := request("1.2.3.4:1", "")
+	r.Header.Set("X-Signature", Sign("key", body))
+	if err := v.Verify(r, body); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+
+	r.Header.Set("X-Signature", "sha256="+Sign("key", body))
+	if err := v.Verify(r, body); err != nil {
+		t.Fatalf("prefixed signature rejected: %v", err)
+	}
+
+	r.Header.Set("X-Signature", Sign("other", body))
+	if err := v.Verify(r, body); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("wrong key: err = %v", err)
+	}
+	r.Header.Del("X-Signature")
+	if err := v.Verify(r, body); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("missing header: err = %v", err)
+	}
+}
+
+func TestIPAllowList(t *testing.T) {
+	v, err := IPAllowList([]string{"52.89.214.238", "10.0.0.0/8"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for remote, want := range map[string]error{
+		"52.89.214.238:443": nil,
+		"10.1.2.3:80":       nil,
+		"8.8.8.8:80":        ErrForbiddenIP,
+	} {
+		if err := v.Verify(request(remote, ""), nil); !errors.Is(err, want) {
+			t.Fatalf("%s: err = %v, want %v", remote, err, want)
+		}
+	}
+
+	// the forwarded address is ignored unless trusted
+	r := request("8.8.8.8:80", "")
+	r.Header.Set("X-Forwarded-For", "10.1.2.3")
+	if err := v.Verify(r, nil); !errors.Is(err, ErrForbiddenIP) {
+		t.Fatalf("untrusted forwarded address accepted")
+	}
+	trusted, _ := IPAllowList([]string{"10.0.0.0/8"}, true)
+	if err := trusted.Verify(r, nil); err != nil {
+		t.Fatalf("trusted forwarded address rejected: %v", err)
+	}
+	// only the address appended by the proxy counts
+	r.Header.Set("X-Forwarded-For", "10.1.2.3, 8.8.8.8")
+	if err := trusted.Verify(r, nil); !errors.Is(err, ErrForbiddenIP) {
+		t.Fatalf("client supplied forwarded address accepted")
+	}
+
+	if _, err := IPAllowList([]string{"not-an-ip"}, false); err == nil {
+		t.Fatalf("expected error for invalid entry")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	reg, err := FromConfig(&Config{
+		TradingViewPassphrase: "pass",
+		TradingViewAllowedIPs: []string{"52.89.214.238"},
+		HMACSecrets:           map[string]string{"Custom": "key"},
+		HMACHeader:            "X-Signature",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(reg.Names(), ","); got != "custom,tradingview" {
+		t.Fatalf("providers = %s", got)
+	}
+
+	tv, _ := reg.Lookup("TradingView")
+	body := []byte(`{"passphrase":"pass"}`)
+	if err := tv.Verifier.Verify(request("8.8.8.8:1", ""), body); !errors.Is(err, ErrForbiddenIP) {
+		t.Fatalf("tradingview from unknown address: err = %v", err)
+	}
+	if err := tv.Verifier.Verify(request("52.89.214.238:1", ""), body); err != nil {
+		t.Fatalf("tradingview rejected: %v", err)
+	}
+
+	empty, err := FromConfig(&Config{})
+	if err != nil || len(empty.Names()) != 0 {
+		t.Fatalf("expected no providers, got %v (%v)", empty.Names(), err)
+	}
+	if _, err := FromConfig(&Config{HMACSecrets: map[string]string{"x": ""}}); err == nil {
+		t.Fatalf("expected error for empty secret")
+	}
+}
+
+func TestRegistryRefusesUnverifiedProviders(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.Register(Provider{Name: "open", Parse: ParseSignal}); err == nil {
+		t.Fatalf("provider without verifier accepted")
+	}
+	p := Provider{Name: "a", Verifier: Passphrase("x"), Parse: ParseSignal}
+	if err := reg.Register(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register(p); err == nil {
+		t.Fatalf("duplicate provider accepted")
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	s, err := ParseSignal([]byte(`{"passphrase":"x","exchange":"Phemex","symbol":"btcusdt","action":"BUY",
+		"order_id":"long","qty":"0.5","price":64000.5,"market_position":"long","timestamp":"2025-03-04T10:00:00Z"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ExchangeName != "phemex" || s.Symbol != "BTCUSDT" || s.Action != "buy" || s.OrderID != "long" {
+		t.Fatalf("unexpected signal: %+v", s)
+	}
+	if s.Qty != 0.5 || s.Price == nil || *s.Price != 64000.5 || s.TimestampDT == nil {
+		t.Fatalf("unexpected numbers: qty=%v price=%v ts=%v", s.Qty, s.Price, s.TimestampDT)
+	}
+
+	for _, body := range []string{
+		`{"exchange":"phemex","symbol":"BTCUSDT","action":"hold"}`,
+		`{"symbol":"BTCUSDT","action":"buy"}`,
+		`{"exchange":"phemex","symbol":"BTCUSDT","action":"buy","qty":"lots"}`,
+	} {
+		if _, err := ParseSignal([]byte(body)); err == nil {
+			t.Fatalf("expected error for %s", body)
+		}
+	}
+}
+
+func TestParseSignalV2(t *testing.T) {
+	s, err := ParseSignal([]byte(`{"exchange":"phemex","symbol":"BTCUSDT","action":"buy",
+		"quantity":"0.01","size_percent":"","leverage":5,"stop_loss":"60000","take_profit":70000,
+		"validUntil":"2025-03-04T10:05:00Z","strategy":"breakout"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Quantity == nil || *s.Quantity != 0.01 || s.SizePercent != nil || *s.Leverage != 5 {
+		t.Fatalf("unexpected sizing: %+v", s)
+	}
+	if *s.StopLoss != 60000 || *s.TakeProfit != 70000 || s.StrategyTag != "breakout" {
+		t.Fatalf("unexpected exits: %+v", s)
+	}
+	if s.ValidUntil == nil || !s.ValidUntil.Equal(time.Date(2025, 3, 4, 10, 5, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected valid until: %v", s.ValidUntil)
+	}
+
+	s, err = ParseSignal([]byte(`{"exchange":"phemex","symbol":"BTCUSDT","action":"sell","valid_until":"1741082700"}`))
+	if err != nil || s.ValidUntil == nil || s.ValidUntil.Unix() != 1741082700 || s.StopLoss != nil {
+		t.Fatalf("unexpected signal %+v (%v)", s, err)
+	}
+
+	for _, body := range []string{
+		`{"exchange":"phemex","symbol":"BTCUSDT","action":"buy","size_percent":150}`,
+		`{"exchange":"phemex","symbol":"BTCUSDT","action":"buy","leverage":-2}`,
+		`{"exchange":"phemex","symbol":"BTCUSDT","action":"buy","stop_loss":70000,"take_profit":60000}`,
+		`{"exchange":"phemex","symbol":"BTCUSDT","action":"sell","stop_loss":60000,"take_profit":70000}`,
+		`{"exchange":"phemex","symbol":"BTCUSDT","action":"buy","valid_until":"soon"}`,
+	} {
+		if _, err := ParseSignal([]byte(body)); err == nil {
+			t.Fatalf("expected error for %s", body)
+		}
+	}
+}